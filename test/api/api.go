@@ -0,0 +1,26 @@
+// Package api содержит контракты gRPC сервисов и сгенерированный по ним код, общий для
+// сервиса аутентификации, сервиса заявок и клиента authclient:
+//
+//   - auth — AuthService сервиса аутентификации (пакет authpb);
+//   - call — CallService сервиса заявок (пакет callpb).
+//
+// Сгенерированный код хранится в репозитории. После изменения .proto его нужно перегенерировать
+// из директории test/api (требуются protoc, protoc-gen-go и protoc-gen-go-grpc версий
+// из заголовков файлов *.pb.go):
+//
+//	go generate .
+//
+// TestGeneratedCodeUpToDate падает, если сгенерированный код не соответствует .proto.
+//
+// Совместимость с уже развернутыми клиентами и серверами проверяют два теста. TestWireCompatibility
+// разбирает эталонные сообщения из testdata/<пакет>/<сообщение>.bin текущим кодом, а TestNoBreakingChanges
+// сравнивает контракты с testdata/descriptor.binpb по правилам buf breaking (WIRE_JSON): поля нельзя
+// перенумеровывать, переименовывать и менять их тип, удаленные номера нужно резервировать. После
+// добавления сообщения или совместимого изменения эталоны дописываются, а описание обновляется командой
+//
+//	go test -run 'TestWireCompatibility|TestNoBreakingChanges' -update .
+//
+// Существующие эталоны -update не перезаписывает.
+package api
+
+//go:generate protoc -I . --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative auth/auth.proto call/call.proto
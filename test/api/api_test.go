@@ -0,0 +1,39 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	authpb "api/auth"
+	callpb "api/call"
+)
+
+// Тест соответствия сгенерированного кода .proto: описание каждого файла, встроенное
+// в сгенерированный код, должно совпадать с результатом компиляции .proto
+func TestGeneratedCodeUpToDate(t *testing.T) {
+	generated := []protoreflect.FileDescriptor{
+		authpb.File_auth_auth_proto,
+		callpb.File_call_call_proto,
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: []string{"."}}),
+	}
+	for _, file := range generated {
+		compiled, err := compiler.Compile(context.Background(), file.Path())
+		require.NoError(t, err, file.Path())
+
+		want := protodesc.ToFileDescriptorProto(compiled[0])
+		want.SourceCodeInfo = nil
+		got := protodesc.ToFileDescriptorProto(file)
+		assert.Empty(t, cmp.Diff(want, got, protocmp.Transform()), "%s: сгенерированный код устарел, выполните go generate в test/api", file.Path())
+	}
+}
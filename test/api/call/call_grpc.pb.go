@@ -0,0 +1,323 @@
+// api/call/call.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: call/call.proto
+
+package callpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CallService_CreateCall_FullMethodName       = "/call.CallService/CreateCall"
+	CallService_GetCall_FullMethodName          = "/call.CallService/GetCall"
+	CallService_ListCalls_FullMethodName        = "/call.CallService/ListCalls"
+	CallService_StreamCalls_FullMethodName      = "/call.CallService/StreamCalls"
+	CallService_UpdateCallStatus_FullMethodName = "/call.CallService/UpdateCallStatus"
+	CallService_DeleteCall_FullMethodName       = "/call.CallService/DeleteCall"
+)
+
+// CallServiceClient is the client API for CallService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// CallService предоставляет доступ к заявкам для внутренних сервисов.
+// Каждый вызов должен содержать метаданные authorization: Bearer <token>.
+type CallServiceClient interface {
+	CreateCall(ctx context.Context, in *CreateCallRequest, opts ...grpc.CallOption) (*Call, error)
+	GetCall(ctx context.Context, in *GetCallRequest, opts ...grpc.CallOption) (*Call, error)
+	ListCalls(ctx context.Context, in *ListCallsRequest, opts ...grpc.CallOption) (*ListCallsResponse, error)
+	StreamCalls(ctx context.Context, in *StreamCallsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamCallsResponse], error)
+	UpdateCallStatus(ctx context.Context, in *UpdateCallStatusRequest, opts ...grpc.CallOption) (*UpdateCallStatusResponse, error)
+	DeleteCall(ctx context.Context, in *DeleteCallRequest, opts ...grpc.CallOption) (*DeleteCallResponse, error)
+}
+
+type callServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCallServiceClient(cc grpc.ClientConnInterface) CallServiceClient {
+	return &callServiceClient{cc}
+}
+
+func (c *callServiceClient) CreateCall(ctx context.Context, in *CreateCallRequest, opts ...grpc.CallOption) (*Call, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Call)
+	err := c.cc.Invoke(ctx, CallService_CreateCall_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *callServiceClient) GetCall(ctx context.Context, in *GetCallRequest, opts ...grpc.CallOption) (*Call, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Call)
+	err := c.cc.Invoke(ctx, CallService_GetCall_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *callServiceClient) ListCalls(ctx context.Context, in *ListCallsRequest, opts ...grpc.CallOption) (*ListCallsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCallsResponse)
+	err := c.cc.Invoke(ctx, CallService_ListCalls_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *callServiceClient) StreamCalls(ctx context.Context, in *StreamCallsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamCallsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CallService_ServiceDesc.Streams[0], CallService_StreamCalls_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamCallsRequest, StreamCallsResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CallService_StreamCallsClient = grpc.ServerStreamingClient[StreamCallsResponse]
+
+func (c *callServiceClient) UpdateCallStatus(ctx context.Context, in *UpdateCallStatusRequest, opts ...grpc.CallOption) (*UpdateCallStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateCallStatusResponse)
+	err := c.cc.Invoke(ctx, CallService_UpdateCallStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *callServiceClient) DeleteCall(ctx context.Context, in *DeleteCallRequest, opts ...grpc.CallOption) (*DeleteCallResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteCallResponse)
+	err := c.cc.Invoke(ctx, CallService_DeleteCall_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CallServiceServer is the server API for CallService service.
+// All implementations must embed UnimplementedCallServiceServer
+// for forward compatibility.
+//
+// CallService предоставляет доступ к заявкам для внутренних сервисов.
+// Каждый вызов должен содержать метаданные authorization: Bearer <token>.
+type CallServiceServer interface {
+	CreateCall(context.Context, *CreateCallRequest) (*Call, error)
+	GetCall(context.Context, *GetCallRequest) (*Call, error)
+	ListCalls(context.Context, *ListCallsRequest) (*ListCallsResponse, error)
+	StreamCalls(*StreamCallsRequest, grpc.ServerStreamingServer[StreamCallsResponse]) error
+	UpdateCallStatus(context.Context, *UpdateCallStatusRequest) (*UpdateCallStatusResponse, error)
+	DeleteCall(context.Context, *DeleteCallRequest) (*DeleteCallResponse, error)
+	mustEmbedUnimplementedCallServiceServer()
+}
+
+// UnimplementedCallServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCallServiceServer struct{}
+
+func (UnimplementedCallServiceServer) CreateCall(context.Context, *CreateCallRequest) (*Call, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCall not implemented")
+}
+func (UnimplementedCallServiceServer) GetCall(context.Context, *GetCallRequest) (*Call, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCall not implemented")
+}
+func (UnimplementedCallServiceServer) ListCalls(context.Context, *ListCallsRequest) (*ListCallsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCalls not implemented")
+}
+func (UnimplementedCallServiceServer) StreamCalls(*StreamCallsRequest, grpc.ServerStreamingServer[StreamCallsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamCalls not implemented")
+}
+func (UnimplementedCallServiceServer) UpdateCallStatus(context.Context, *UpdateCallStatusRequest) (*UpdateCallStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCallStatus not implemented")
+}
+func (UnimplementedCallServiceServer) DeleteCall(context.Context, *DeleteCallRequest) (*DeleteCallResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCall not implemented")
+}
+func (UnimplementedCallServiceServer) mustEmbedUnimplementedCallServiceServer() {}
+func (UnimplementedCallServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeCallServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CallServiceServer will
+// result in compilation errors.
+type UnsafeCallServiceServer interface {
+	mustEmbedUnimplementedCallServiceServer()
+}
+
+func RegisterCallServiceServer(s grpc.ServiceRegistrar, srv CallServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCallServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CallService_ServiceDesc, srv)
+}
+
+func _CallService_CreateCall_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCallRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CallServiceServer).CreateCall(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CallService_CreateCall_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CallServiceServer).CreateCall(ctx, req.(*CreateCallRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CallService_GetCall_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCallRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CallServiceServer).GetCall(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CallService_GetCall_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CallServiceServer).GetCall(ctx, req.(*GetCallRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CallService_ListCalls_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCallsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CallServiceServer).ListCalls(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CallService_ListCalls_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CallServiceServer).ListCalls(ctx, req.(*ListCallsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CallService_StreamCalls_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamCallsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CallServiceServer).StreamCalls(m, &grpc.GenericServerStream[StreamCallsRequest, StreamCallsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CallService_StreamCallsServer = grpc.ServerStreamingServer[StreamCallsResponse]
+
+func _CallService_UpdateCallStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCallStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CallServiceServer).UpdateCallStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CallService_UpdateCallStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CallServiceServer).UpdateCallStatus(ctx, req.(*UpdateCallStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CallService_DeleteCall_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCallRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CallServiceServer).DeleteCall(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CallService_DeleteCall_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CallServiceServer).DeleteCall(ctx, req.(*DeleteCallRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CallService_ServiceDesc is the grpc.ServiceDesc for CallService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CallService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "call.CallService",
+	HandlerType: (*CallServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateCall",
+			Handler:    _CallService_CreateCall_Handler,
+		},
+		{
+			MethodName: "GetCall",
+			Handler:    _CallService_GetCall_Handler,
+		},
+		{
+			MethodName: "ListCalls",
+			Handler:    _CallService_ListCalls_Handler,
+		},
+		{
+			MethodName: "UpdateCallStatus",
+			Handler:    _CallService_UpdateCallStatus_Handler,
+		},
+		{
+			MethodName: "DeleteCall",
+			Handler:    _CallService_DeleteCall_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamCalls",
+			Handler:       _CallService_StreamCalls_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "call/call.proto",
+}
@@ -0,0 +1,347 @@
+package api
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	authpb "api/auth"
+	callpb "api/call"
+)
+
+// update записывает эталоны для новых сообщений и обновляет базовое описание API.
+// Существующие эталоны не перезаписываются: они фиксируют формат, который уже понимают клиенты.
+var update = flag.Bool("update", false, "записать эталоны новых сообщений и обновить testdata/descriptor.binpb")
+
+// baselinePath — описание контрактов на момент последнего обновления: с ним сравнивается текущее
+// описание в TestNoBreakingChanges.
+const baselinePath = "testdata/descriptor.binpb"
+
+// apiFiles — файлы контрактов, совместимость которых проверяется.
+var apiFiles = []protoreflect.FileDescriptor{
+	authpb.File_auth_auth_proto,
+	callpb.File_call_call_proto,
+}
+
+// Тест разбора эталонных сообщений текущим сгенерированным кодом: каждое поле эталона
+// должно разбираться в поле с тем же именем и значением. Перенумерованное или удаленное
+// поле становится неизвестным и не совпадает с ожидаемым сообщением; новые поля в эталоне
+// отсутствуют и не проверяются
+func TestWireCompatibility(t *testing.T) {
+	for _, file := range apiFiles {
+		forEachMessage(file.Messages(), func(md protoreflect.MessageDescriptor) {
+			path := filepath.Join("testdata", string(file.Package()), string(md.Name())+".bin")
+			data, err := os.ReadFile(path)
+			if errors.Is(err, fs.ErrNotExist) && *update {
+				data, err = proto.MarshalOptions{Deterministic: true}.Marshal(sample(md).Interface())
+				require.NoError(t, err)
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+				require.NoError(t, os.WriteFile(path, data, 0o644))
+				return
+			}
+			if !assert.NoError(t, err, "нет эталона %s: запустите go test -run TestWireCompatibility -update", md.FullName()) {
+				return
+			}
+
+			mt, err := protoregistry.GlobalTypes.FindMessageByName(md.FullName())
+			require.NoError(t, err)
+			got := mt.New()
+			require.NoError(t, proto.Unmarshal(data, got.Interface()), md.FullName())
+			want := restrict(sample(md), got)
+			assert.Empty(t, cmp.Diff(want.Interface(), got.Interface(), protocmp.Transform()), md.FullName())
+		})
+	}
+}
+
+// Тест отсутствия несовместимых изменений относительно testdata/descriptor.binpb. Правила
+// повторяют категорию WIRE_JSON команды buf breaking: сообщения, сервисы и методы не удаляются,
+// номер поля не меняет имя, тип и повторяемость, а удаленные номера и значения перечислений
+// резервируются
+func TestNoBreakingChanges(t *testing.T) {
+	current := &descriptorpb.FileDescriptorSet{}
+	for _, file := range apiFiles {
+		current.File = append(current.File, protodesc.ToFileDescriptorProto(file))
+	}
+
+	data, err := os.ReadFile(baselinePath)
+	if errors.Is(err, fs.ErrNotExist) && *update {
+		writeBaseline(t, current)
+		return
+	}
+	require.NoError(t, err)
+	baseline := &descriptorpb.FileDescriptorSet{}
+	require.NoError(t, proto.Unmarshal(data, baseline))
+
+	breaks := breakingChanges(baseline, current)
+	for _, b := range breaks {
+		t.Error(b)
+	}
+	if len(breaks) == 0 && *update {
+		writeBaseline(t, current)
+	}
+}
+
+func writeBaseline(t *testing.T, set *descriptorpb.FileDescriptorSet) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(set)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(baselinePath, data, 0o644))
+}
+
+// forEachMessage вызывает fn для каждого сообщения, включая вложенные.
+func forEachMessage(messages protoreflect.MessageDescriptors, fn func(protoreflect.MessageDescriptor)) {
+	for i := 0; i < messages.Len(); i++ {
+		md := messages.Get(i)
+		if md.IsMapEntry() {
+			continue
+		}
+		fn(md)
+		forEachMessage(md.Messages(), fn)
+	}
+}
+
+// sample создает сообщение, в котором заполнено каждое поле (в oneof — первое). Значение
+// поля зависит от его имени, поэтому поля одного типа, поменявшиеся номерами, не совпадут
+// с эталоном.
+func sample(md protoreflect.MessageDescriptor) protoreflect.Message {
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(md.FullName())
+	if err != nil {
+		panic(err)
+	}
+	m := mt.New()
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if oneof := fd.ContainingOneof(); oneof != nil && oneof.Fields().Get(0) != fd {
+			continue
+		}
+		switch {
+		case fd.IsMap():
+			m.Mutable(fd).Map().Set(scalarValue(fd.MapKey()).MapKey(), fieldValue(fd.MapValue()))
+		case fd.IsList():
+			list := m.Mutable(fd).List()
+			if fd.Message() != nil {
+				list.Append(protoreflect.ValueOfMessage(sample(fd.Message())))
+			} else {
+				list.Append(scalarValue(fd))
+			}
+		default:
+			m.Set(fd, fieldValue(fd))
+		}
+	}
+	return m
+}
+
+func fieldValue(fd protoreflect.FieldDescriptor) protoreflect.Value {
+	if fd.Message() != nil {
+		return protoreflect.ValueOfMessage(sample(fd.Message()))
+	}
+	return scalarValue(fd)
+}
+
+// scalarValue возвращает ненулевое значение поля, зависящее от его имени.
+func scalarValue(fd protoreflect.FieldDescriptor) protoreflect.Value {
+	h := fnv.New32a()
+	h.Write([]byte(fd.Name()))
+	n := h.Sum32()%1000 + 1
+
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(true)
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		return protoreflect.ValueOfEnum(values.Get(values.Len() - 1).Number())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(int32(n))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(int64(n))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(n)
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(uint64(n))
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(float32(n) + 0.5)
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(float64(n) + 0.5)
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(string(fd.Name()))
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(fd.Name()))
+	}
+	panic(fmt.Sprintf("unsupported field kind %v", fd.Kind()))
+}
+
+// restrict оставляет в want только поля, заполненные в got, в том числе во вложенных
+// сообщениях: поля, добавленные после записи эталона, в нем отсутствуют.
+func restrict(want, got protoreflect.Message) protoreflect.Message {
+	out := want.Type().New()
+	got.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		w := want.Get(fd)
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			list := out.Mutable(fd).List()
+			for i := 0; i < min(w.List().Len(), v.List().Len()); i++ {
+				list.Append(protoreflect.ValueOfMessage(restrict(w.List().Get(i).Message(), v.List().Get(i).Message())))
+			}
+		case fd.Message() != nil && !fd.IsList() && !fd.IsMap():
+			out.Set(fd, protoreflect.ValueOfMessage(restrict(w.Message(), v.Message())))
+		default:
+			out.Set(fd, w)
+		}
+		return true
+	})
+	return out
+}
+
+// breakingChanges возвращает описания несовместимых изменений current относительно baseline.
+func breakingChanges(baseline, current *descriptorpb.FileDescriptorSet) []string {
+	messages := map[string]*descriptorpb.DescriptorProto{}
+	enums := map[string]*descriptorpb.EnumDescriptorProto{}
+	services := map[string]*descriptorpb.ServiceDescriptorProto{}
+	for _, file := range current.File {
+		collectTypes(file.GetPackage(), file.MessageType, file.EnumType, messages, enums)
+		for _, service := range file.Service {
+			services[file.GetPackage()+"."+service.GetName()] = service
+		}
+	}
+
+	var breaks []string
+	for _, file := range baseline.File {
+		baseMessages := map[string]*descriptorpb.DescriptorProto{}
+		baseEnums := map[string]*descriptorpb.EnumDescriptorProto{}
+		collectTypes(file.GetPackage(), file.MessageType, file.EnumType, baseMessages, baseEnums)
+
+		for name, old := range baseMessages {
+			msg, ok := messages[name]
+			if !ok {
+				breaks = append(breaks, fmt.Sprintf("сообщение %s удалено", name))
+				continue
+			}
+			breaks = append(breaks, fieldBreaks(name, old, msg)...)
+		}
+		for name, old := range baseEnums {
+			enum, ok := enums[name]
+			if !ok {
+				breaks = append(breaks, fmt.Sprintf("перечисление %s удалено", name))
+				continue
+			}
+			breaks = append(breaks, enumBreaks(name, old, enum)...)
+		}
+		for _, old := range file.Service {
+			name := file.GetPackage() + "." + old.GetName()
+			service, ok := services[name]
+			if !ok {
+				breaks = append(breaks, fmt.Sprintf("сервис %s удален", name))
+				continue
+			}
+			breaks = append(breaks, methodBreaks(name, old, service)...)
+		}
+	}
+	return breaks
+}
+
+// collectTypes собирает сообщения и перечисления по полным именам, включая вложенные.
+func collectTypes(prefix string, msgs []*descriptorpb.DescriptorProto, enumTypes []*descriptorpb.EnumDescriptorProto,
+	messages map[string]*descriptorpb.DescriptorProto, enums map[string]*descriptorpb.EnumDescriptorProto) {
+	for _, enum := range enumTypes {
+		enums[prefix+"."+enum.GetName()] = enum
+	}
+	for _, msg := range msgs {
+		name := prefix + "." + msg.GetName()
+		messages[name] = msg
+		collectTypes(name, msg.NestedType, msg.EnumType, messages, enums)
+	}
+}
+
+func fieldBreaks(name string, old, msg *descriptorpb.DescriptorProto) []string {
+	fields := map[int32]*descriptorpb.FieldDescriptorProto{}
+	for _, field := range msg.Field {
+		fields[field.GetNumber()] = field
+	}
+
+	var breaks []string
+	for _, was := range old.Field {
+		field, ok := fields[was.GetNumber()]
+		switch {
+		case !ok && !reservedNumber(msg.ReservedRange, was.GetNumber()):
+			breaks = append(breaks, fmt.Sprintf("%s: поле %d (%s) удалено без reserved", name, was.GetNumber(), was.GetName()))
+		case !ok:
+		case field.GetName() != was.GetName():
+			breaks = append(breaks, fmt.Sprintf("%s: поле %d переименовано из %s в %s", name, was.GetNumber(), was.GetName(), field.GetName()))
+		case field.GetType() != was.GetType() || field.GetTypeName() != was.GetTypeName():
+			breaks = append(breaks, fmt.Sprintf("%s: у поля %s изменился тип", name, was.GetName()))
+		case field.GetLabel() != was.GetLabel():
+			breaks = append(breaks, fmt.Sprintf("%s: у поля %s изменилась повторяемость", name, was.GetName()))
+		}
+	}
+	return breaks
+}
+
+// reservedNumber сообщает, входит ли номер в зарезервированные диапазоны; конец диапазона
+// в описании не включается.
+func reservedNumber(ranges []*descriptorpb.DescriptorProto_ReservedRange, number int32) bool {
+	for _, r := range ranges {
+		if number >= r.GetStart() && number < r.GetEnd() {
+			return true
+		}
+	}
+	return false
+}
+
+func enumBreaks(name string, old, enum *descriptorpb.EnumDescriptorProto) []string {
+	values := map[int32]bool{}
+	for _, value := range enum.Value {
+		values[value.GetNumber()] = true
+	}
+	var breaks []string
+	for _, was := range old.Value {
+		if values[was.GetNumber()] {
+			continue
+		}
+		reserved := false
+		for _, r := range enum.ReservedRange {
+			// Конец диапазона значений перечисления включается
+			if was.GetNumber() >= r.GetStart() && was.GetNumber() <= r.GetEnd() {
+				reserved = true
+			}
+		}
+		if !reserved {
+			breaks = append(breaks, fmt.Sprintf("%s: значение %s удалено без reserved", name, was.GetName()))
+		}
+	}
+	return breaks
+}
+
+func methodBreaks(name string, old, service *descriptorpb.ServiceDescriptorProto) []string {
+	methods := map[string]*descriptorpb.MethodDescriptorProto{}
+	for _, method := range service.Method {
+		methods[method.GetName()] = method
+	}
+	var breaks []string
+	for _, was := range old.Method {
+		method, ok := methods[was.GetName()]
+		switch {
+		case !ok:
+			breaks = append(breaks, fmt.Sprintf("%s: метод %s удален", name, was.GetName()))
+		case method.GetInputType() != was.GetInputType() || method.GetOutputType() != was.GetOutputType():
+			breaks = append(breaks, fmt.Sprintf("%s: у метода %s изменились типы запроса или ответа", name, was.GetName()))
+		case method.GetClientStreaming() != was.GetClientStreaming() || method.GetServerStreaming() != was.GetServerStreaming():
+			breaks = append(breaks, fmt.Sprintf("%s: у метода %s изменился потоковый режим", name, was.GetName()))
+		}
+	}
+	return breaks
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/uptrace/bun/driver/pgdriver"
+
+	"auth-service/internal/password"
+	"common/selfcheck"
+)
+
+// Параметры, которые проверяются флагом --check.
+type checkConfig struct {
+	DSN            string
+	DevInMemory    bool
+	MigrationsDir  string
+	JWTKey         string
+	PasswordHasher string
+	BcryptCost     int
+}
+
+// Возвращает проверки готовности к запуску: ключ подписи токенов, параметры хеширования
+// паролей, соединение с базой данных и актуальность миграций. В режиме DEV_INMEMORY
+// база данных не проверяется.
+func checkProbes(cfg checkConfig) []selfcheck.Probe {
+	probes := []selfcheck.Probe{
+		{Name: "jwt_key", Run: func(context.Context) error { return checkJWTKey(cfg.JWTKey) }},
+		{Name: "password_hasher", Run: func(context.Context) error {
+			_, err := password.New(cfg.PasswordHasher, cfg.BcryptCost)
+			return err
+		}},
+	}
+	if cfg.DevInMemory {
+		return probes
+	}
+	return append(probes,
+		selfcheck.Probe{Name: "database", Run: func(ctx context.Context) error {
+			return withDB(cfg.DSN, func(db *sql.DB) error { return db.PingContext(ctx) })
+		}},
+		selfcheck.Probe{Name: "migrations", Run: func(ctx context.Context) error {
+			return withDB(cfg.DSN, func(db *sql.DB) error {
+				return selfcheck.MigrationsUpToDate(ctx, db, cfg.MigrationsDir)
+			})
+		}},
+	)
+}
+
+// Проверяет, что ключом можно подписать токен и проверить подпись.
+func checkJWTKey(key string) error {
+	if key == "" {
+		return errors.New("JWT_KEY is empty")
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: "selfcheck"}).SignedString([]byte(key))
+	if err != nil {
+		return err
+	}
+	_, err = jwt.Parse(signed, func(*jwt.Token) (any, error) { return []byte(key), nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	return err
+}
+
+// Открывает соединение с базой данных dsn на время вызова fn.
+func withDB(dsn string, fn func(db *sql.DB) error) error {
+	db := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn)))
+	defer db.Close()
+	return fn(db)
+}
@@ -0,0 +1,114 @@
+// Package audit записывает события безопасности учетных записей (входы, неудачные попытки
+// входа, входы с новых устройств) в журнал аудита. Сервис аутентификации зависит только
+// от интерфейса Recorder; реализация по умолчанию пишет события в стандартный журнал
+// в формате JSON. Counted дополнительно учитывает события в показателях /debug/vars.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Типы событий аудита
+const (
+	// EventLogin — успешный вход пользователя.
+	EventLogin = "login"
+	// EventLoginFailed — неудачная попытка входа; причина указывается в Reason.
+	EventLoginFailed = "login_failed"
+	// EventNewDeviceLogin — вход с устройства, с которого пользователь раньше не входил.
+	EventNewDeviceLogin = "new_device_login"
+	// EventUserErased — учетная запись пользователя удалена по его запросу.
+	EventUserErased = "user_erased"
+	// EventUserImpersonated — администратор ActorID получил токен для работы от имени пользователя.
+	EventUserImpersonated = "user_impersonated"
+)
+
+// Причины неудачных попыток входа (Event.Reason)
+const (
+	// ReasonUnknownUser — пользователя с указанным именем нет; UserID события не задан.
+	ReasonUnknownUser = "unknown_user"
+	// ReasonWrongPassword — пароль не совпадает с паролем пользователя.
+	ReasonWrongPassword = "wrong_password"
+	// ReasonInvalidIDToken — ID-токен внешнего провайдера OIDC не прошел проверку; UserID не задан.
+	ReasonInvalidIDToken = "invalid_id_token"
+	// reasonOther учитывает в показателях причины, не перечисленные выше.
+	reasonOther = "other"
+)
+
+// reasons — причины, которые учитываются в показателе auth_failures под своим именем.
+// Перечень ограничен, чтобы число меток показателя не росло.
+var reasons = map[string]bool{
+	ReasonUnknownUser:    true,
+	ReasonWrongPassword:  true,
+	ReasonInvalidIDToken: true,
+}
+
+// Показатели в /debug/vars: auth_events — число событий по типу, auth_failures — число
+// неудачных попыток входа по причине.
+var (
+	eventStats   = expvar.NewMap("auth_events")
+	failureStats = expvar.NewMap("auth_failures")
+)
+
+// Event — событие аудита.
+type Event struct {
+	Type      string    `json:"type"`
+	Time      time.Time `json:"time"`
+	UserID    uuid.UUID `json:"user_id"`
+	SessionID uuid.UUID `json:"session_id,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	// ActorID — пользователь, выполнивший действие над учетной записью UserID, если это не он сам.
+	ActorID uuid.UUID `json:"actor_id,omitempty"`
+	// Reason — причина неудачной попытки входа (ReasonUnknownUser, ReasonWrongPassword,
+	// ReasonInvalidIDToken).
+	Reason string `json:"reason,omitempty"`
+}
+
+// Recorder сохраняет события аудита. Ошибки записи не должны прерывать операцию,
+// породившую событие, поэтому Record не возвращает ошибку.
+type Recorder interface {
+	Record(ctx context.Context, event Event)
+}
+
+// LogRecorder записывает события в стандартный журнал строками вида "audit {...}".
+type LogRecorder struct{}
+
+// Record реализует Recorder.
+func (LogRecorder) Record(_ context.Context, event Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("failed to encode audit event %s: %v", event.Type, err)
+		return
+	}
+	log.Printf("audit %s", data)
+}
+
+// Counted возвращает Recorder, который учитывает каждое событие в показателях auth_events
+// и auth_failures и передает его recorder. Причины вне перечня учитываются как "other".
+func Counted(recorder Recorder) Recorder {
+	return countingRecorder{recorder: recorder}
+}
+
+// countingRecorder учитывает события в показателях перед записью.
+type countingRecorder struct {
+	recorder Recorder
+}
+
+// Record реализует Recorder.
+func (r countingRecorder) Record(ctx context.Context, event Event) {
+	eventStats.Add(event.Type, 1)
+	if event.Type == EventLoginFailed {
+		reason := event.Reason
+		if !reasons[reason] {
+			reason = reasonOther
+		}
+		failureStats.Add(reason, 1)
+	}
+	r.recorder.Record(ctx, event)
+}
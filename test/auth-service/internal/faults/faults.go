@@ -0,0 +1,216 @@
+// Package faults вносит сбои в gRPC-вызовы сервиса аутентификации для проверки устойчивости
+// клиентов в средах разработки и тестирования.
+//
+// Правила задаются переменной окружения FAULT_INJECTION_RULES и действуют, только если
+// FAULT_INJECTION_ENABLED=true и сервис запущен не в production-режиме; иначе перехватчик
+// не подключается к серверу. Каждый внесенный сбой записывается в журнал с идентификатором
+// запроса из метаданных x-request-id.
+package faults
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDKey — ключ метаданных gRPC с идентификатором запроса.
+const RequestIDKey = "x-request-id"
+
+// healthPrefix — префикс методов проверки состояния, в которые сбои не вносятся: иначе
+// оркестратор исключил бы экземпляр из балансировки.
+const healthPrefix = "/grpc.health.v1.Health/"
+
+// injected — число внесенных сбоев по видам (latency, code, reset), публикуемое в /debug/vars.
+var injected = expvar.NewMap("faults_injected")
+
+// Rule — правило внесения сбоя в вызовы метода.
+type Rule struct {
+	// Method — короткое имя метода (например, "ValidateToken"); "*" означает любой метод.
+	Method string
+	// Probability — доля вызовов от 0 до 1, в которые вносится сбой.
+	Probability float64
+	// Latency — задержка перед вызовом обработчика; прерывается по сроку вызова.
+	Latency time.Duration
+	// Code — код ошибки, с которым вызов завершается после задержки; codes.OK — вызов
+	// обрабатывается как обычно.
+	Code codes.Code
+	// Reset имитирует разрыв соединения: вызов завершается с codes.Unavailable, как при
+	// обрыве соединения до ответа.
+	Reset bool
+}
+
+// codeNames — коды ошибок gRPC по именам (например, "Unavailable").
+var codeNames = func() map[string]codes.Code {
+	names := make(map[string]codes.Code)
+	for c := codes.Canceled; c <= codes.Unauthenticated; c++ {
+		names[c.String()] = c
+	}
+	return names
+}()
+
+// ParseRules разбирает правила, разделенные точкой с запятой, в формате ParseRule.
+// Пустая строка означает отсутствие правил.
+func ParseRules(s string) ([]Rule, error) {
+	var rules []Rule
+	for _, item := range strings.Split(s, ";") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		rule, err := ParseRule(item)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ParseRule разбирает правило вида "МЕТОД ДОЛЯ СБОЙ...", где сбой — latency=200ms,
+// code=Unavailable или reset, например "ValidateToken 0.1 latency=200ms code=Unavailable".
+func ParseRule(s string) (Rule, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 3 {
+		return Rule{}, fmt.Errorf("fault rule %q: expected method, probability and fault", s)
+	}
+	rule := Rule{Method: fields[0]}
+	probability, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || probability <= 0 || probability > 1 {
+		return Rule{}, fmt.Errorf("fault rule %q: probability must be in (0, 1]", s)
+	}
+	rule.Probability = probability
+
+	for _, fault := range fields[2:] {
+		name, value, _ := strings.Cut(fault, "=")
+		switch name {
+		case "latency":
+			latency, err := time.ParseDuration(value)
+			if err != nil || latency <= 0 {
+				return Rule{}, fmt.Errorf("fault rule %q: invalid latency %q", s, value)
+			}
+			rule.Latency = latency
+		case "code":
+			code, ok := codeNames[value]
+			if !ok {
+				return Rule{}, fmt.Errorf("fault rule %q: unknown code %q", s, value)
+			}
+			rule.Code = code
+		case "reset":
+			rule.Reset = true
+		default:
+			return Rule{}, fmt.Errorf("fault rule %q: unknown fault %q", s, fault)
+		}
+	}
+	if rule.Code != codes.OK && rule.Reset {
+		return Rule{}, fmt.Errorf("fault rule %q: code and reset are mutually exclusive", s)
+	}
+	return rule, nil
+}
+
+// fault описывает вносимый сбой для журнала.
+func (r Rule) fault() string {
+	var faults []string
+	if r.Latency > 0 {
+		faults = append(faults, "latency="+r.Latency.String())
+	}
+	if r.Code != codes.OK {
+		faults = append(faults, "code="+r.Code.String())
+	}
+	if r.Reset {
+		faults = append(faults, "reset")
+	}
+	return strings.Join(faults, " ")
+}
+
+// Injector вносит сбои в вызовы по правилам Rule. Без правил перехватчик только передает
+// вызов обработчику.
+type Injector struct {
+	rules  atomic.Pointer[[]Rule]
+	random func() float64
+}
+
+// NewInjector создает Injector с правилами rules.
+func NewInjector(rules []Rule) *Injector {
+	f := &Injector{random: rand.Float64}
+	f.SetRules(rules)
+	return f
+}
+
+// SetRules заменяет действующие правила; пустой список отключает внесение сбоев.
+func (f *Injector) SetRules(rules []Rule) {
+	rules = append([]Rule{}, rules...)
+	f.rules.Store(&rules)
+}
+
+// UnaryServerInterceptor возвращает перехватчик, вносящий сбои в вызовы. К вызову
+// применяется первое подходящее по методу правило, с вероятностью Probability. Перехватчик
+// подключается после ограничения времени вызова, чтобы задержки учитывались в его сроке.
+func (f *Injector) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		rules := *f.rules.Load()
+		if len(rules) == 0 || strings.HasPrefix(info.FullMethod, healthPrefix) {
+			return handler(ctx, req)
+		}
+		method := path.Base(info.FullMethod)
+		for _, rule := range rules {
+			if rule.Method != "*" && rule.Method != method {
+				continue
+			}
+			if f.random() < rule.Probability {
+				if err := inject(ctx, info.FullMethod, rule); err != nil {
+					return nil, err
+				}
+			}
+			break
+		}
+		return handler(ctx, req)
+	}
+}
+
+// inject вносит в вызов сбой по правилу rule и возвращает ошибку, с которой вызов должен
+// завершиться; nil — вызов передается обработчику.
+func inject(ctx context.Context, fullMethod string, rule Rule) error {
+	log.Printf("fault injected: request_id=%q method=%s fault=%q", requestID(ctx), fullMethod, rule.fault())
+
+	if rule.Latency > 0 {
+		injected.Add("latency", 1)
+		timer := time.NewTimer(rule.Latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+
+	switch {
+	case rule.Code != codes.OK:
+		injected.Add("code", 1)
+		return status.Error(rule.Code, "fault injected")
+	case rule.Reset:
+		injected.Add("reset", 1)
+		return status.Error(codes.Unavailable, "fault injected: connection reset")
+	}
+	return nil
+}
+
+// requestID возвращает идентификатор запроса из метаданных вызова или, если клиент его
+// не передал, новый идентификатор, чтобы записи о сбоях можно было различить.
+func requestID(ctx context.Context) string {
+	if values := metadata.ValueFromIncomingContext(ctx, RequestIDKey); len(values) > 0 && values[0] != "" {
+		return values[0]
+	}
+	return uuid.NewString()
+}
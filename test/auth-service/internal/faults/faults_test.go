@@ -0,0 +1,98 @@
+package faults
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// okHandler — обработчик вызова, всегда завершающийся успешно.
+func okHandler(context.Context, any) (any, error) {
+	return "ok", nil
+}
+
+// call выполняет вызов метода method через перехватчик interceptor.
+func call(ctx context.Context, interceptor grpc.UnaryServerInterceptor, method string) error {
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, okHandler)
+	return err
+}
+
+// Тест разбора правил: коды gRPC задаются по имени, неверные правила отклоняются
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("ValidateToken 0.1 latency=200ms code=Unavailable; * 1 reset")
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{
+		{Method: "ValidateToken", Probability: 0.1, Latency: 200 * time.Millisecond, Code: codes.Unavailable},
+		{Method: "*", Probability: 1, Reset: true},
+	}, rules)
+
+	for _, s := range []string{"* 0.5", "* 0 reset", "* 0.5 code=Broken", "* 0.5 code=Internal reset", "* 0.5 drop"} {
+		_, err := ParseRules(s)
+		assert.Error(t, err, s)
+	}
+}
+
+// Тест вероятности: сбой вносится в долю вызовов, близкую к Probability, только
+// в вызовы метода из правила и никогда в проверку состояния
+func TestInjector_Probability(t *testing.T) {
+	injector := NewInjector([]Rule{
+		{Method: "ValidateToken", Probability: 0.3, Code: codes.Unavailable},
+		{Method: "*", Probability: 1, Reset: true},
+	})
+	injector.random = rand.New(rand.NewPCG(1, 2)).Float64
+	interceptor := injector.UnaryServerInterceptor()
+
+	const calls = 1000
+	var failed int
+	for range calls {
+		if err := call(context.Background(), interceptor, "/auth.AuthService/ValidateToken"); err != nil {
+			assert.Equal(t, codes.Unavailable, status.Code(err))
+			failed++
+		}
+	}
+	assert.InDelta(t, 0.3*calls, failed, 0.05*calls)
+
+	assert.Equal(t, codes.Unavailable, status.Code(call(context.Background(), interceptor, "/auth.AuthService/Login")))
+	assert.NoError(t, call(context.Background(), interceptor, "/grpc.health.v1.Health/Check"))
+
+	injector.SetRules(nil)
+	assert.NoError(t, call(context.Background(), interceptor, "/auth.AuthService/Login"))
+}
+
+// Тест задержки: задержка прерывается по сроку вызова
+func TestInjector_LatencyDeadline(t *testing.T) {
+	interceptor := NewInjector([]Rule{{Method: "*", Probability: 1, Latency: time.Minute}}).UnaryServerInterceptor()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := call(ctx, interceptor, "/auth.AuthService/Login")
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+// Тест отсутствия издержек: без правил перехватчик не выделяет память
+func TestInjector_NoRulesAllocs(t *testing.T) {
+	interceptor := NewInjector(nil).UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/auth.AuthService/ValidateToken"}
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = interceptor(context.Background(), nil, info, okHandler)
+	})
+	assert.Zero(t, allocs)
+}
+
+// Бенчмарк перехватчика без правил: издержки должны оставаться на уровне вызова функции
+func BenchmarkInjector_NoRules(b *testing.B) {
+	interceptor := NewInjector(nil).UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/auth.AuthService/ValidateToken"}
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = interceptor(ctx, nil, info, okHandler)
+	}
+}
@@ -0,0 +1,212 @@
+// Package gateway предоставляет HTTP/JSON фасад для gRPC-сервиса аутентификации.
+//
+// Шлюз не вызывает обработчики напрямую, а проксирует запросы через gRPC-клиент,
+// подключенный к тому же серверу, поэтому к HTTP-запросам применяются те же
+// перехватчики, что и к gRPC-вызовам. Собственного секрета внутренних сервисов
+// у шлюза нет: вызывающий передает его в заголовке X-Internal-Token, и шлюз
+// переносит заголовок в метаданные x-internal-token.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/netip"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "api/auth"
+	"auth-service/internal/handler"
+	"auth-service/internal/internalauth"
+	"common/clientip"
+)
+
+// maxBodySize ограничивает размер тела запроса к шлюзу.
+const maxBodySize = 1 << 20
+
+// InternalTokenHeader — HTTP-заголовок, в котором вызывающий передает секрет внутренних сервисов.
+const InternalTokenHeader = "X-Internal-Token"
+
+var (
+	unmarshalOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+	marshalOptions   = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+)
+
+// Gateway обрабатывает HTTP-запросы и передает их в gRPC-сервис аутентификации.
+type Gateway struct {
+	client  pb.AuthServiceClient
+	proxies []netip.Prefix
+	mux     *http.ServeMux
+}
+
+// New создает шлюз поверх gRPC-клиента сервиса аутентификации. IP клиента определяется
+// по X-Forwarded-For только для запросов от доверенных прокси trustedProxies.
+func New(client pb.AuthServiceClient, trustedProxies []netip.Prefix) *Gateway {
+	g := &Gateway{client: client, proxies: trustedProxies, mux: http.NewServeMux()}
+	g.mux.HandleFunc("POST /v1/register", g.register)
+	g.mux.HandleFunc("POST /v1/login", g.login)
+	g.mux.HandleFunc("POST /v1/refresh", g.refresh)
+	g.mux.HandleFunc("POST /v1/validate", g.validate)
+	return g
+}
+
+// ServeHTTP реализует http.Handler.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+// register обрабатывает POST /v1/register и возвращает 201 при успешной регистрации.
+func (g *Gateway) register(w http.ResponseWriter, r *http.Request) {
+	req := &pb.RegisterRequest{}
+	if !decode(w, r, req) {
+		return
+	}
+	resp, err := g.client.Register(g.clientContext(r), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	write(w, http.StatusCreated, resp)
+}
+
+// login обрабатывает POST /v1/login.
+func (g *Gateway) login(w http.ResponseWriter, r *http.Request) {
+	req := &pb.LoginRequest{}
+	if !decode(w, r, req) {
+		return
+	}
+	resp, err := g.client.Login(g.clientContext(r), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	write(w, http.StatusOK, resp)
+}
+
+// refresh обрабатывает POST /v1/refresh.
+func (g *Gateway) refresh(w http.ResponseWriter, r *http.Request) {
+	req := &pb.RefreshRequest{}
+	if !decode(w, r, req) {
+		return
+	}
+	resp, err := g.client.Refresh(g.clientContext(r), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	write(w, http.StatusOK, resp)
+}
+
+// validate обрабатывает POST /v1/validate.
+func (g *Gateway) validate(w http.ResponseWriter, r *http.Request) {
+	req := &pb.ValidateTokenRequest{}
+	if !decode(w, r, req) {
+		return
+	}
+	resp, err := g.client.ValidateToken(callContext(r), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	write(w, http.StatusOK, resp)
+}
+
+// clientContext передает в метаданных вызова IP клиента, его User-Agent и описание
+// устройства (заголовок X-Device-Label или, если он не задан, User-Agent) для сохранения
+// в сеансе и определения нового устройства. IP клиента определяется так же, как в сервисе
+// заявок: X-Forwarded-For учитывается только от доверенных прокси (см. пакет clientip).
+func (g *Gateway) clientContext(r *http.Request) context.Context {
+	ip := clientip.FromRequest(r, g.proxies)
+	device := r.Header.Get("X-Device-Label")
+	if device == "" {
+		device = r.UserAgent()
+	}
+	return metadata.AppendToOutgoingContext(callContext(r),
+		handler.ClientIPMetadataKey, ip,
+		handler.DeviceLabelMetadataKey, device,
+		handler.UserAgentMetadataKey, r.UserAgent(),
+	)
+}
+
+// callContext возвращает контекст вызова с секретом из заголовка X-Internal-Token,
+// если вызывающий его передал.
+func callContext(r *http.Request) context.Context {
+	token := r.Header.Get(InternalTokenHeader)
+	if token == "" {
+		return r.Context()
+	}
+	return metadata.AppendToOutgoingContext(r.Context(), internalauth.MetadataKey, token)
+}
+
+// decode читает JSON-тело запроса в сообщение; при ошибке отвечает 400 и возвращает false.
+func decode(w http.ResponseWriter, r *http.Request, msg proto.Message) bool {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed to read request body"})
+		return false
+	}
+	if err := unmarshalOptions.Unmarshal(body, msg); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return false
+	}
+	return true
+}
+
+// write отправляет gRPC-ответ в формате JSON с именами полей из proto-файла.
+func write(w http.ResponseWriter, code int, msg proto.Message) {
+	data, err := marshalOptions.Marshal(msg)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to encode response"})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(data)
+}
+
+// writeError отправляет ошибку gRPC с соответствующим HTTP-статусом.
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	writeJSON(w, HTTPStatus(st.Code()), map[string]string{"error": st.Message()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// HTTPStatus сопоставляет код gRPC с HTTP-статусом.
+func HTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled:
+		return 499
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,229 @@
+package gateway
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "api/auth"
+	"auth-service/internal/internalauth"
+)
+
+// fakeAuthServer возвращает заранее заданные ответы и запоминает последний запрос и его метаданные.
+type fakeAuthServer struct {
+	pb.UnimplementedAuthServiceServer
+	err          error
+	lastRequest  any
+	lastMetadata metadata.MD
+}
+
+func (s *fakeAuthServer) Register(_ context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	s.lastRequest = req
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &pb.RegisterResponse{Token: "token", UserId: "user-1"}, nil
+}
+
+func (s *fakeAuthServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	s.lastRequest = req
+	s.lastMetadata, _ = metadata.FromIncomingContext(ctx)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &pb.LoginResponse{Token: "token", UserId: "user-1"}, nil
+}
+
+func (s *fakeAuthServer) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.RefreshResponse, error) {
+	s.lastRequest = req
+	s.lastMetadata, _ = metadata.FromIncomingContext(ctx)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &pb.RefreshResponse{Token: "token", UserId: "user-1", RefreshToken: "refresh-2", SessionId: "session-1", ExpiresAt: 1700000000}, nil
+}
+
+func (s *fakeAuthServer) ValidateToken(_ context.Context, req *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
+	s.lastRequest = req
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &pb.ValidateTokenResponse{Valid: false}, nil
+}
+
+// setupGateway поднимает gRPC-сервер с параметрами opts на bufconn и шлюз поверх клиента к нему;
+// шлюз доверяет прокси из подсети 10.0.0.0/8.
+func setupGateway(t *testing.T, srv *fakeAuthServer, opts ...grpc.ServerOption) http.Handler {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(opts...)
+	pb.RegisterAuthServiceServer(server, srv)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return New(pb.NewAuthServiceClient(conn), []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+}
+
+func doRequest(handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+// Тест успешной регистрации: 201 и поля ответа в snake_case
+func TestRegister_Created(t *testing.T) {
+	srv := &fakeAuthServer{}
+	gw := setupGateway(t, srv)
+
+	w := doRequest(gw, http.MethodPost, "/v1/register", `{"username":"alice","password":"secret"}`)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"token":"token","user_id":"user-1","refresh_token":"","session_id":"","expires_at":"0"}`, w.Body.String())
+	req, ok := srv.lastRequest.(*pb.RegisterRequest)
+	require.True(t, ok)
+	assert.Equal(t, "alice", req.Username)
+	assert.Equal(t, "secret", req.Password)
+}
+
+// Тест секрета внутренних сервисов: шлюз не добавляет его сам, поэтому регистрация
+// без заголовка X-Internal-Token отклоняется, а с верным секретом проходит
+func TestRegister_RequiresInternalToken(t *testing.T) {
+	srv := &fakeAuthServer{}
+	gw := setupGateway(t, srv, grpc.UnaryInterceptor(internalauth.UnaryServerInterceptor([]string{"internal-secret"}, internalauth.DefaultAllowedMethods)))
+	body := `{"username":"alice","password":"secret"}`
+
+	w := doRequest(gw, http.MethodPost, "/v1/register", body)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Nil(t, srv.lastRequest)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/register", strings.NewReader(body))
+	req.Header.Set(InternalTokenHeader, "wrong-secret")
+	w = httptest.NewRecorder()
+	gw.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Nil(t, srv.lastRequest)
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/register", strings.NewReader(body))
+	req.Header.Set(InternalTokenHeader, "internal-secret")
+	w = httptest.NewRecorder()
+	gw.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NotNil(t, srv.lastRequest)
+}
+
+// Тест сопоставления кодов gRPC с HTTP-статусами
+func TestErrorMapping(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		err  error
+		want int
+	}{
+		{"already exists", "/v1/register", status.Error(codes.AlreadyExists, "user already exists"), http.StatusConflict},
+		{"invalid argument", "/v1/register", status.Error(codes.InvalidArgument, "username and password are required"), http.StatusBadRequest},
+		{"unauthenticated", "/v1/login", status.Error(codes.Unauthenticated, "invalid credentials"), http.StatusUnauthorized},
+		{"internal", "/v1/login", status.Error(codes.Internal, "failed to login user"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gw := setupGateway(t, &fakeAuthServer{err: tt.err})
+
+			w := doRequest(gw, http.MethodPost, tt.path, `{"username":"alice","password":"secret"}`)
+
+			assert.Equal(t, tt.want, w.Code)
+			assert.JSONEq(t, `{"error":"`+status.Convert(tt.err).Message()+`"}`, w.Body.String())
+		})
+	}
+}
+
+// Тест обновления токенов: IP клиента и описание устройства передаются в метаданных
+func TestRefresh_ForwardsClientInfo(t *testing.T) {
+	srv := &fakeAuthServer{}
+	gw := setupGateway(t, srv)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/refresh", strings.NewReader(`{"refresh_token":"refresh-1"}`))
+	req.RemoteAddr = "203.0.113.5:40000"
+	req.Header.Set("User-Agent", "curl/8.0")
+	w := httptest.NewRecorder()
+	gw.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"token":"token","user_id":"user-1","refresh_token":"refresh-2","session_id":"session-1","expires_at":"1700000000"}`, w.Body.String())
+	assert.Equal(t, "refresh-1", srv.lastRequest.(*pb.RefreshRequest).RefreshToken)
+	assert.Equal(t, []string{"203.0.113.5"}, srv.lastMetadata.Get("x-client-ip"))
+	assert.Equal(t, []string{"curl/8.0"}, srv.lastMetadata.Get("x-device-label"))
+	assert.Equal(t, []string{"curl/8.0"}, srv.lastMetadata.Get("x-user-agent"))
+}
+
+// Тест IP клиента: X-Forwarded-For учитывается только от доверенного прокси
+func TestLogin_TrustedProxies(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		want       string
+	}{
+		{"через доверенный прокси", "10.0.0.1:40000", "203.0.113.9"},
+		{"подмена от недоверенного источника", "198.51.100.2:40000", "198.51.100.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &fakeAuthServer{}
+			gw := setupGateway(t, srv)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/login", strings.NewReader(`{"username":"alice","password":"secret"}`))
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", "203.0.113.9")
+			w := httptest.NewRecorder()
+			gw.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, []string{tt.want}, srv.lastMetadata.Get("x-client-ip"))
+		})
+	}
+}
+
+// Тест проверки токена: недействительный токен возвращается с valid=false, а не опускается
+func TestValidate_InvalidToken(t *testing.T) {
+	gw := setupGateway(t, &fakeAuthServer{})
+
+	w := doRequest(gw, http.MethodPost, "/v1/validate", `{"token":"bad"}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"valid":false,"user_id":"","role":"","session_id":"","expires_at":"0","org_id":"","org_role":"","impersonated_by":"","expires_in":"0"}`, w.Body.String())
+}
+
+// Тест некорректного JSON и неподдерживаемого метода
+func TestBadRequests(t *testing.T) {
+	srv := &fakeAuthServer{}
+	gw := setupGateway(t, srv)
+
+	w := doRequest(gw, http.MethodPost, "/v1/login", `{"username":`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Nil(t, srv.lastRequest)
+
+	w = doRequest(gw, http.MethodGet, "/v1/login", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -1,124 +1,311 @@
-package handler
-
-import (
-	"context"
-
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
-
-	pb "auth-service/internal/proto"
-	"auth-service/internal/service"
-)
-
-// AuthHandler реализует интерфейс AuthServiceServer для обработки аутентификационных запросов.
-// Структура содержит сервис аутентификации и реализует все необходимые методы для регистрации,
-// входа в систему и проверки токенов.
-
-type AuthHandler struct {
-	pb.UnimplementedAuthServiceServer
-	authService service.AuthService
-}
-
-// NewAuthHandler создает новый экземпляр AuthHandler с переданным сервисом аутентификации.
-
-func NewAuthHandler(authService service.AuthService) *AuthHandler {
-	return &AuthHandler{authService: authService}
-}
-
-// Register обрабатывает запрос на регистрацию нового пользователя.
-// Проверяет корректность входных данных и вызывает соответствующий метод сервиса.
-//
-// Args:
-//   ctx: контекст выполнения операции
-//   req: структура с данными для регистрации (username и password)
-//
-// Returns:
-//   *pb.RegisterResponse: токен и ID пользователя при успешной регистрации
-//   error: ошибка с соответствующим кодом gRPC если:
-//     - отсутствуют обязательные поля (codes.InvalidArgument)
-//     - пользователь уже существует (codes.AlreadyExists)
-//     - произошла внутренняя ошибка (codes.Internal)
-
-func (h *AuthHandler) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
-	if req.Username == "" || req.Password == "" {
-		return nil, status.Error(codes.InvalidArgument, "username and password are required")
-	}
-
-	token, userID, err := h.authService.Register(ctx, req.Username, req.Password)
-	if err != nil {
-		if err == service.ErrUserAlreadyExists {
-			return nil, status.Error(codes.AlreadyExists, "user already exists")
-		}
-		return nil, status.Error(codes.Internal, "failed to register user")
-	}
-
-	return &pb.RegisterResponse{
-		Token:  token,
-		UserId: userID.String(),
-	}, nil
-}
-
-// Login обрабатывает запрос на вход в систему существующего пользователя.
-// Проверяет корректность учетных данных и выдает токен доступа при успешной аутентификации.
-//
-// Args:
-//   ctx: контекст выполнения операции
-//   req: структура с данными для входа (username и password)
-//
-// Returns:
-//   *pb.LoginResponse: токен и ID пользователя при успешном входе
-//   error: ошибка с соответствующим кодом gRPC если:
-//     - отсутствуют обязательные поля (codes.InvalidArgument)
-//     - неверные учетные данные (codes.Unauthenticated)
-//     - произошла внутренняя ошибка (codes.Internal)
-
-func (h *AuthHandler) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
-	if req.Username == "" || req.Password == "" {
-		return nil, status.Error(codes.InvalidArgument, "username and password are required")
-	}
-
-	token, userID, err := h.authService.Login(ctx, req.Username, req.Password)
-	if err != nil {
-		if err == service.ErrInvalidCredentials {
-			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
-		}
-		return nil, status.Error(codes.Internal, "failed to login user")
-	}
-
-	return &pb.LoginResponse{
-		Token:  token,
-		UserId: userID.String(),
-	}, nil
-}
-
-// ValidateToken проверяет действительность токена аутентификации.
-//
-// Args:
-//
-//	ctx: контекст выполнения операции
-//	req: структура с токеном для проверки
-//
-// Returns:
-//
-//	*pb.ValidateTokenResponse: структура содержит поле Valid и UserId при успешной проверке
-//	error: ошибка с соответствующим кодом gRPC если:
-//	  - отсутствует токен (codes.InvalidArgument)
-
-func (h *AuthHandler) ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
-	if req.Token == "" {
-		return nil, status.Error(codes.InvalidArgument, "token is required")
-	}
-
-	userID, err := h.authService.ValidateToken(ctx, req.Token)
-	if err != nil {
-		return &pb.ValidateTokenResponse{
-			Valid:  false,
-			UserId: "",
-		}, nil
-	}
-
-	return &pb.ValidateTokenResponse{
-		Valid:  true,
-		UserId: userID.String(),
-	}, nil
-}
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "api/auth"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+)
+
+// errTimeout возвращается клиенту, если запрос к базе данных не уложился в отведенное время.
+
+var errTimeout = status.Error(codes.DeadlineExceeded, "request timed out")
+
+// errHashCapacity возвращается клиенту, если хеширование пароля не началось до истечения
+// срока запроса из-за ограничения числа одновременных операций с паролями.
+
+var errHashCapacity = status.Error(codes.ResourceExhausted, "too many password operations in progress")
+
+// AuthHandler реализует интерфейс AuthServiceServer для обработки аутентификационных запросов.
+// Структура содержит сервис аутентификации и реализует все необходимые методы для регистрации,
+// входа в систему и проверки токенов. Обязательные поля и длину строк запроса до вызова методов
+// проверяет перехватчик validation.UnaryServerInterceptor.
+
+type AuthHandler struct {
+	pb.UnimplementedAuthServiceServer
+	authService service.AuthService
+}
+
+// NewAuthHandler создает новый экземпляр AuthHandler с переданным сервисом аутентификации.
+
+func NewAuthHandler(authService service.AuthService) *AuthHandler {
+	return &AuthHandler{authService: authService}
+}
+
+// Register обрабатывает запрос на регистрацию нового пользователя.
+// Проверяет корректность входных данных и вызывает соответствующий метод сервиса.
+//
+// Args:
+//   ctx: контекст выполнения операции
+//   req: структура с данными для регистрации (username, password и необязательный email)
+//
+// Returns:
+//   *pb.RegisterResponse: access- и refresh-токены, срок действия access-токена, ID пользователя и ID созданного сеанса
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный формат email (codes.InvalidArgument)
+//     - пользователь или email уже существуют (codes.AlreadyExists)
+//     - хеширование пароля не началось до истечения срока запроса (codes.ResourceExhausted)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	tokens, err := h.authService.Register(ctx, req.Username, req.Password, req.Email, clientInfo(ctx))
+	if err != nil {
+		if errors.Is(err, service.ErrUserAlreadyExists) {
+			return nil, status.Error(codes.AlreadyExists, "user already exists")
+		}
+		if errors.Is(err, service.ErrEmailAlreadyExists) {
+			return nil, status.Error(codes.AlreadyExists, "email already in use")
+		}
+		if errors.Is(err, service.ErrInvalidEmail) {
+			return nil, status.Error(codes.InvalidArgument, "invalid email")
+		}
+		if errors.Is(err, service.ErrHashCapacity) {
+			return nil, errHashCapacity
+		}
+		if errors.Is(err, repository.ErrTimeout) {
+			return nil, errTimeout
+		}
+		return nil, status.Error(codes.Internal, "failed to register user")
+	}
+
+	return &pb.RegisterResponse{
+		Token:        tokens.AccessToken,
+		UserId:       tokens.UserID.String(),
+		RefreshToken: tokens.RefreshToken,
+		SessionId:    tokens.SessionID.String(),
+		ExpiresAt:    tokens.ExpiresAt.Unix(),
+	}, nil
+}
+
+// Login обрабатывает запрос на вход в систему существующего пользователя.
+// Проверяет корректность учетных данных и выдает токен доступа при успешной аутентификации.
+//
+// Args:
+//   ctx: контекст выполнения операции
+//   req: структура с данными для входа (username и password)
+//
+// Returns:
+//   *pb.LoginResponse: access- и refresh-токены, срок действия access-токена, ID пользователя и ID созданного сеанса
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверные учетные данные (codes.Unauthenticated)
+//     - проверка пароля не началась до истечения срока запроса (codes.ResourceExhausted)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	tokens, err := h.authService.Login(ctx, req.Username, req.Password, clientInfo(ctx))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+		if errors.Is(err, service.ErrHashCapacity) {
+			return nil, errHashCapacity
+		}
+		if errors.Is(err, repository.ErrTimeout) {
+			return nil, errTimeout
+		}
+		return nil, status.Error(codes.Internal, "failed to login user")
+	}
+
+	return &pb.LoginResponse{
+		Token:        tokens.AccessToken,
+		UserId:       tokens.UserID.String(),
+		RefreshToken: tokens.RefreshToken,
+		SessionId:    tokens.SessionID.String(),
+		ExpiresAt:    tokens.ExpiresAt.Unix(),
+	}, nil
+}
+
+// ValidateToken проверяет действительность токена аутентификации.
+//
+// Args:
+//
+//	ctx: контекст выполнения операции
+//	req: структура с токеном для проверки
+//
+// Returns:
+//
+//	*pb.ValidateTokenResponse: структура содержит поля Valid, UserId, Role, SessionId и, если пользователь
+//	  состоит в организации, OrgId и OrgRole при успешной проверке
+//	error: ошибка с соответствующим кодом gRPC если:
+//	  - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//	  - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
+	claims, err := h.authService.ValidateToken(ctx, req.Token)
+	if err != nil {
+		if errors.Is(err, repository.ErrTimeout) {
+			return nil, errTimeout
+		}
+		if !errors.Is(err, service.ErrInvalidToken) {
+			return nil, status.Error(codes.Internal, "failed to validate token")
+		}
+		return &pb.ValidateTokenResponse{
+			Valid:  false,
+			UserId: "",
+		}, nil
+	}
+
+	resp := &pb.ValidateTokenResponse{
+		Valid:  true,
+		UserId: claims.UserID.String(),
+		Role:   claims.Role,
+	}
+	if claims.SessionID != uuid.Nil {
+		resp.SessionId = claims.SessionID.String()
+	}
+	if !claims.ExpiresAt.IsZero() {
+		resp.ExpiresAt = claims.ExpiresAt.Unix()
+		resp.ExpiresIn = int64(claims.ExpiresIn / time.Second)
+	}
+	if claims.OrgID != uuid.Nil {
+		resp.OrgId = claims.OrgID.String()
+		resp.OrgRole = claims.OrgRole
+	}
+	if claims.ImpersonatedBy != uuid.Nil {
+		resp.ImpersonatedBy = claims.ImpersonatedBy.String()
+	}
+	return resp, nil
+}
+
+// GetUser возвращает данные пользователя, включая email и признак его подтверждения.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID пользователя (codes.InvalidArgument)
+//     - пользователь не найден (codes.NotFound)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	user, err := h.authService.GetUser(ctx, userID)
+	if err != nil {
+		return nil, emailError(err, "failed to get user")
+	}
+
+	return &pb.GetUserResponse{
+		UserId:        user.ID.String(),
+		Username:      user.Username,
+		Role:          user.Role,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+	}, nil
+}
+
+// GetUsers возвращает ID, имена и время регистрации пользователей с указанными ID;
+// неизвестные ID пропускаются.
+// Используется сервисом заявок, чтобы показать имена пользователей в списках без запроса на каждого.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID пользователя или передано больше service.MaxGetUsers ID (codes.InvalidArgument)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) GetUsers(ctx context.Context, req *pb.GetUsersRequest) (*pb.GetUsersResponse, error) {
+	userIDs := make([]uuid.UUID, 0, len(req.UserIds))
+	for _, id := range req.UserIds {
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	users, err := h.authService.GetUsers(ctx, userIDs)
+	if err != nil {
+		if errors.Is(err, service.ErrTooManyUsers) {
+			return nil, status.Errorf(codes.InvalidArgument, "at most %d user IDs are allowed", service.MaxGetUsers)
+		}
+		if errors.Is(err, repository.ErrTimeout) {
+			return nil, errTimeout
+		}
+		return nil, status.Error(codes.Internal, "failed to get users")
+	}
+
+	resp := &pb.GetUsersResponse{Users: make([]*pb.UserSummary, 0, len(users))}
+	for _, user := range users {
+		resp.Users = append(resp.Users, &pb.UserSummary{
+			UserId:    user.ID.String(),
+			Username:  user.Username,
+			CreatedAt: timestamppb.New(user.CreatedAt),
+		})
+	}
+	return resp, nil
+}
+
+// UpdateEmail устанавливает пользователю новый email и отправляет на него токен подтверждения.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID пользователя или формат email (codes.InvalidArgument)
+//     - пользователь не найден (codes.NotFound)
+//     - email принадлежит другому пользователю (codes.AlreadyExists)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) UpdateEmail(ctx context.Context, req *pb.UpdateEmailRequest) (*pb.UpdateEmailResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	if err := h.authService.UpdateEmail(ctx, userID, req.Email); err != nil {
+		return nil, emailError(err, "failed to update email")
+	}
+	return &pb.UpdateEmailResponse{}, nil
+}
+
+// VerifyEmail подтверждает email пользователя одноразовым токеном.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID пользователя, токен не совпадает, использован или истек (codes.InvalidArgument)
+//     - пользователь не найден (codes.NotFound)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.VerifyEmailResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	if err := h.authService.VerifyEmail(ctx, userID, req.Token); err != nil {
+		return nil, emailError(err, "failed to verify email")
+	}
+	return &pb.VerifyEmailResponse{}, nil
+}
+
+// emailError переводит ошибки операций с пользователем и email в gRPC-статус;
+// непредвиденные ошибки возвращаются как codes.Internal с сообщением message.
+
+func emailError(err error, message string) error {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, service.ErrInvalidEmail):
+		return status.Error(codes.InvalidArgument, "invalid email")
+	case errors.Is(err, service.ErrEmailAlreadyExists):
+		return status.Error(codes.AlreadyExists, "email already in use")
+	case errors.Is(err, service.ErrInvalidVerificationToken):
+		return status.Error(codes.InvalidArgument, "invalid or expired verification token")
+	case errors.Is(err, repository.ErrTimeout):
+		return errTimeout
+	}
+	return status.Error(codes.Internal, message)
+}
@@ -0,0 +1,590 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "api/auth"
+	"auth-service/internal/model"
+	"auth-service/internal/oidc"
+	"auth-service/internal/oidc/oidctest"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+)
+
+// fakeUserRepository хранит пользователей в памяти; при заданном err все методы возвращают эту ошибку.
+
+type fakeUserRepository struct {
+	users map[string]*model.User
+	err   error
+}
+
+func (r *fakeUserRepository) Create(_ context.Context, user *model.User) error {
+	if r.err != nil {
+		return r.err
+	}
+	user.ID = uuid.New()
+	r.users[user.Username] = user
+	return nil
+}
+
+func (r *fakeUserRepository) GetByUsername(_ context.Context, username string) (*model.User, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	user, ok := r.users[username]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepository) GetByID(_ context.Context, id uuid.UUID) (*model.User, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for _, user := range r.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeUserRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*model.User, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	var users []*model.User
+	for _, id := range ids {
+		if user, err := r.GetByID(ctx, id); err == nil {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeUserRepository) GetByEmail(_ context.Context, email string) (*model.User, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeUserRepository) UpdateEmail(ctx context.Context, id uuid.UUID, email, verificationHash string, expiresAt time.Time) error {
+	user, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	user.Email = email
+	user.EmailVerificationHash = verificationHash
+	user.EmailVerificationExpiresAt = expiresAt
+	return nil
+}
+
+func (r *fakeUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	user, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = passwordHash
+	return nil
+}
+
+func (r *fakeUserRepository) ConfirmEmail(ctx context.Context, id uuid.UUID, verificationHash string) error {
+	user, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerificationHash != verificationHash {
+		return repository.ErrNotFound
+	}
+	user.EmailVerified = true
+	user.EmailVerificationHash = ""
+	return nil
+}
+
+func (r *fakeUserRepository) PurgeExpiredVerificationsBefore(context.Context, time.Time, int) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeUserRepository) Delete(_ context.Context, id uuid.UUID) error {
+	if r.err != nil {
+		return r.err
+	}
+	for username, user := range r.users {
+		if user.ID == id {
+			delete(r.users, username)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *fakeUserRepository) List(ctx context.Context, filter model.UserFilter) ([]*model.User, int, error) {
+	if r.err != nil {
+		return nil, 0, r.err
+	}
+	return r.snapshot(ctx).List(ctx, filter)
+}
+
+func (r *fakeUserRepository) ForEach(ctx context.Context, filter model.UserFilter, fn func(*model.User) error) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.snapshot(ctx).ForEach(ctx, filter, fn)
+}
+
+// snapshot копирует пользователей в репозиторий в памяти, отбирающий и сортирующий их по фильтру.
+func (r *fakeUserRepository) snapshot(ctx context.Context) repository.UserRepository {
+	repo := repository.NewInMemoryUserRepository()
+	for _, user := range r.users {
+		copied := *user
+		_ = repo.Create(ctx, &copied)
+	}
+	return repo
+}
+
+func setupHandler() (*AuthHandler, *fakeUserRepository) {
+	repo := &fakeUserRepository{users: make(map[string]*model.User)}
+	return NewAuthHandler(service.NewAuthService(repo, "test-key")), repo
+}
+
+// Тест кодов ответа при сбое базы данных: клиент получает Internal, а не Unauthenticated или valid=false,
+// без текста ошибки; истечение времени запроса распознается и после дополнения ошибки контекстом
+func TestDatabaseFailureStatusCodes(t *testing.T) {
+	h, repo := setupHandler()
+	ctx := context.Background()
+
+	registered, err := h.Register(ctx, &pb.RegisterRequest{Username: "user", Password: "password"})
+	require.NoError(t, err)
+
+	repo.err = errors.New("connection refused")
+
+	_, err = h.Register(ctx, &pb.RegisterRequest{Username: "other", Password: "password"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.NotContains(t, status.Convert(err).Message(), "connection refused")
+
+	_, err = h.Login(ctx, &pb.LoginRequest{Username: "user", Password: "password"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	_, err = h.ValidateToken(ctx, &pb.ValidateTokenRequest{Token: registered.Token})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	repo.err = repository.ErrTimeout
+
+	_, err = h.Register(ctx, &pb.RegisterRequest{Username: "other", Password: "password"})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	_, err = h.Login(ctx, &pb.LoginRequest{Username: "user", Password: "password"})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+// Тест кодов ответа при отсутствии пользователя: отсутствие записи по-прежнему означает ошибку клиента
+func TestNotFoundStatusCodes(t *testing.T) {
+	h, repo := setupHandler()
+	ctx := context.Background()
+
+	registered, err := h.Register(ctx, &pb.RegisterRequest{Username: "user", Password: "password"})
+	require.NoError(t, err)
+
+	_, err = h.Login(ctx, &pb.LoginRequest{Username: "nobody", Password: "password"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	delete(repo.users, "user")
+	resp, err := h.ValidateToken(ctx, &pb.ValidateTokenRequest{Token: registered.Token})
+	require.NoError(t, err)
+	assert.False(t, resp.Valid)
+}
+
+// Тест пакетного получения имен: неизвестные ID пропускаются, неверный ID и слишком длинный список отклоняются
+func TestGetUsers(t *testing.T) {
+	h, _ := setupHandler()
+	ctx := context.Background()
+
+	alice, err := h.Register(ctx, &pb.RegisterRequest{Username: "alice", Password: "password"})
+	require.NoError(t, err)
+
+	resp, err := h.GetUsers(ctx, &pb.GetUsersRequest{UserIds: []string{alice.UserId, uuid.NewString()}})
+	require.NoError(t, err)
+	require.Len(t, resp.Users, 1)
+	assert.Equal(t, alice.UserId, resp.Users[0].UserId)
+	assert.Equal(t, "alice", resp.Users[0].Username)
+	assert.NotNil(t, resp.Users[0].CreatedAt)
+
+	_, err = h.GetUsers(ctx, &pb.GetUsersRequest{UserIds: []string{"bad"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	tooMany := make([]string, service.MaxGetUsers+1)
+	for i := range tooMany {
+		tooMany[i] = uuid.NewString()
+	}
+	_, err = h.GetUsers(ctx, &pb.GetUsersRequest{UserIds: tooMany})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// Тест кодов ответа операций с email: неверный формат, занятый адрес, неизвестный пользователь и неверный токен
+func TestEmailStatusCodes(t *testing.T) {
+	h, _ := setupHandler()
+	ctx := context.Background()
+
+	first, err := h.Register(ctx, &pb.RegisterRequest{Username: "first", Password: "password", Email: "first@example.com"})
+	require.NoError(t, err)
+
+	_, err = h.Register(ctx, &pb.RegisterRequest{Username: "second", Password: "password", Email: "not-an-email"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = h.Register(ctx, &pb.RegisterRequest{Username: "second", Password: "password", Email: "First@Example.com"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	user, err := h.GetUser(ctx, &pb.GetUserRequest{UserId: first.UserId})
+	require.NoError(t, err)
+	assert.Equal(t, "first@example.com", user.Email)
+	assert.False(t, user.EmailVerified)
+
+	_, err = h.GetUser(ctx, &pb.GetUserRequest{UserId: uuid.NewString()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = h.UpdateEmail(ctx, &pb.UpdateEmailRequest{UserId: "not-a-uuid", Email: "new@example.com"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = h.VerifyEmail(ctx, &pb.VerifyEmailRequest{UserId: first.UserId, Token: "wrong-token"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = h.VerifyEmail(ctx, &pb.VerifyEmailRequest{UserId: first.UserId})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// Тест сеансов: данные клиента берутся из метаданных, отозванный refresh-токен дает Unauthenticated,
+// повторный отзыв — NotFound
+func TestSessionStatusCodes(t *testing.T) {
+	h, _ := setupHandler()
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		ClientIPMetadataKey, "203.0.113.5",
+		DeviceLabelMetadataKey, "Firefox on Linux",
+		UserAgentMetadataKey, "Mozilla/5.0 Firefox/128.0",
+	))
+
+	login, err := h.Register(ctx, &pb.RegisterRequest{Username: "user", Password: "password"})
+	require.NoError(t, err)
+
+	list, err := h.ListSessions(ctx, &pb.ListSessionsRequest{UserId: login.UserId})
+	require.NoError(t, err)
+	require.Len(t, list.Sessions, 1)
+	assert.Equal(t, login.SessionId, list.Sessions[0].Id)
+	assert.Equal(t, "203.0.113.5", list.Sessions[0].Ip)
+	assert.Equal(t, "Firefox on Linux", list.Sessions[0].DeviceLabel)
+
+	devices, err := h.ListDevices(ctx, &pb.ListDevicesRequest{UserId: login.UserId})
+	require.NoError(t, err)
+	require.Len(t, devices.Devices, 1)
+	assert.Equal(t, "Mozilla/5.0 Firefox/128.0", devices.Devices[0].UserAgent)
+	assert.Equal(t, "203.0.113.5", devices.Devices[0].Ip)
+	_, err = h.ListDevices(ctx, &pb.ListDevicesRequest{UserId: "bad"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	validated, err := h.ValidateToken(ctx, &pb.ValidateTokenRequest{Token: login.Token})
+	require.NoError(t, err)
+	assert.Equal(t, login.SessionId, validated.SessionId)
+	assert.Equal(t, login.ExpiresAt, validated.ExpiresAt)
+	assert.Positive(t, validated.ExpiresIn)
+	assert.LessOrEqual(t, validated.ExpiresIn, int64(service.AccessTokenTTL/time.Second))
+
+	_, err = h.RevokeSession(ctx, &pb.RevokeSessionRequest{UserId: login.UserId, SessionId: login.SessionId})
+	require.NoError(t, err)
+	_, err = h.RevokeSession(ctx, &pb.RevokeSessionRequest{UserId: login.UserId, SessionId: login.SessionId})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = h.Refresh(ctx, &pb.RefreshRequest{RefreshToken: login.RefreshToken})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = h.RevokeAllSessions(ctx, &pb.RevokeAllSessionsRequest{UserId: login.UserId, ExceptSessionId: "bad"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// Тест выгрузки данных пользователя: хеши не передаются, отозванный сеанс выгружается со временем отзыва,
+// повторная выгрузка дает ResourceExhausted
+
+func TestExportUserData(t *testing.T) {
+	h, _ := setupHandler()
+	ctx := context.Background()
+
+	login, err := h.Register(ctx, &pb.RegisterRequest{Username: "user", Password: "password", Email: "user@example.com"})
+	require.NoError(t, err)
+	_, err = h.RevokeSession(ctx, &pb.RevokeSessionRequest{UserId: login.UserId, SessionId: login.SessionId})
+	require.NoError(t, err)
+
+	resp, err := h.ExportUserData(ctx, &pb.ExportUserDataRequest{UserId: login.UserId})
+	require.NoError(t, err)
+	assert.Equal(t, "user", resp.User.Username)
+	assert.NotNil(t, resp.User.EmailVerificationExpiresAt)
+	require.Len(t, resp.Sessions, 1)
+	assert.NotNil(t, resp.Sessions[0].RevokedAt)
+	assert.NotNil(t, resp.Sessions[0].ExpiresAt)
+
+	_, err = h.ExportUserData(ctx, &pb.ExportUserDataRequest{UserId: login.UserId})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	_, err = h.ExportUserData(ctx, &pb.ExportUserDataRequest{UserId: uuid.NewString()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = h.ExportUserData(ctx, &pb.ExportUserDataRequest{UserId: "bad"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// Тест удаления учетной записи: неверный пароль дает Unauthenticated, повторное удаление
+// завершается успешно, после удаления пользователь не найден
+
+func TestEraseUser(t *testing.T) {
+	h, _ := setupHandler()
+	ctx := context.Background()
+
+	login, err := h.Register(ctx, &pb.RegisterRequest{Username: "user", Password: "password"})
+	require.NoError(t, err)
+
+	_, err = h.VerifyPassword(ctx, &pb.VerifyPasswordRequest{UserId: login.UserId, Password: "wrong"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = h.VerifyPassword(ctx, &pb.VerifyPasswordRequest{UserId: login.UserId, Password: "password"})
+	require.NoError(t, err)
+
+	for range 2 {
+		_, err = h.EraseUser(ctx, &pb.EraseUserRequest{UserId: login.UserId})
+		require.NoError(t, err)
+	}
+	_, err = h.VerifyPassword(ctx, &pb.VerifyPasswordRequest{UserId: login.UserId, Password: "password"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = h.EraseUser(ctx, &pb.EraseUserRequest{UserId: "bad"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// Тест работы от имени пользователя: токен администратора дает токен пользователя, проверка
+// которого возвращает impersonated_by; ошибки отображаются в коды gRPC
+func TestImpersonateUser(t *testing.T) {
+	h, repo := setupHandler()
+	ctx := context.Background()
+	user, err := h.Register(ctx, &pb.RegisterRequest{Username: "user", Password: "password"})
+	require.NoError(t, err)
+	_, err = h.Register(ctx, &pb.RegisterRequest{Username: "admin", Password: "password"})
+	require.NoError(t, err)
+	repo.users["admin"].Role = model.RoleAdmin
+	admin, err := h.Login(ctx, &pb.LoginRequest{Username: "admin", Password: "password"})
+	require.NoError(t, err)
+
+	resp, err := h.ImpersonateUser(ctx, &pb.ImpersonateUserRequest{AdminToken: admin.Token, UserId: user.UserId})
+	require.NoError(t, err)
+	assert.Equal(t, user.UserId, resp.UserId)
+	assert.Positive(t, resp.ExpiresAt)
+	validated, err := h.ValidateToken(ctx, &pb.ValidateTokenRequest{Token: resp.Token})
+	require.NoError(t, err)
+	assert.Equal(t, user.UserId, validated.UserId)
+	assert.Equal(t, admin.UserId, validated.ImpersonatedBy)
+	validated, err = h.ValidateToken(ctx, &pb.ValidateTokenRequest{Token: admin.Token})
+	require.NoError(t, err)
+	assert.Empty(t, validated.ImpersonatedBy)
+
+	tests := []struct {
+		name string
+		req  *pb.ImpersonateUserRequest
+		want codes.Code
+	}{
+		{"invalid user id", &pb.ImpersonateUserRequest{AdminToken: admin.Token, UserId: "bad"}, codes.InvalidArgument},
+		{"invalid admin token", &pb.ImpersonateUserRequest{AdminToken: "invalid", UserId: user.UserId}, codes.Unauthenticated},
+		{"not an admin", &pb.ImpersonateUserRequest{AdminToken: user.Token, UserId: admin.UserId}, codes.PermissionDenied},
+		{"impersonation token", &pb.ImpersonateUserRequest{AdminToken: resp.Token, UserId: user.UserId}, codes.PermissionDenied},
+		{"another admin", &pb.ImpersonateUserRequest{AdminToken: admin.Token, UserId: admin.UserId}, codes.PermissionDenied},
+		{"unknown user", &pb.ImpersonateUserRequest{AdminToken: admin.Token, UserId: uuid.NewString()}, codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := h.ImpersonateUser(ctx, tt.req)
+			assert.Equal(t, tt.want, status.Code(err))
+		})
+	}
+}
+
+// Тест входа через OIDC: обмен ID-токена на токены сервиса и коды ответа для неизвестного
+// провайдера, недействительного токена и отключенного входа через OIDC
+func TestExchangeOIDCToken(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	verifier, err := oidc.NewVerifier([]oidc.ProviderConfig{{Name: "corp", Issuer: issuer.URL, ClientID: oidctest.ClientID}})
+	require.NoError(t, err)
+	h := NewAuthHandler(service.NewAuthService(repository.NewInMemoryUserRepository(), "test-key",
+		service.WithOIDC(verifier, repository.NewInMemoryExternalIdentityRepository())))
+	ctx := context.Background()
+	idToken := issuer.Token(t, issuer.Claims("subject-1"))
+
+	resp, err := h.ExchangeOIDCToken(ctx, &pb.ExchangeOIDCTokenRequest{Provider: "corp", IdToken: idToken})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.RefreshToken)
+	assert.Positive(t, resp.ExpiresAt)
+	validated, err := h.ValidateToken(ctx, &pb.ValidateTokenRequest{Token: resp.Token})
+	require.NoError(t, err)
+	assert.Equal(t, resp.UserId, validated.UserId)
+
+	_, err = h.ExchangeOIDCToken(ctx, &pb.ExchangeOIDCTokenRequest{Provider: "other", IdToken: idToken})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = h.ExchangeOIDCToken(ctx, &pb.ExchangeOIDCTokenRequest{Provider: "corp", IdToken: "invalid"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	disabled, _ := setupHandler()
+	_, err = disabled.ExchangeOIDCToken(ctx, &pb.ExchangeOIDCTokenRequest{Provider: "corp", IdToken: idToken})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+// exportStream собирает сообщения потока ExportUsers.
+
+type exportStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses []*pb.ExportUsersResponse
+}
+
+func (s *exportStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *exportStream) Send(resp *pb.ExportUsersResponse) error {
+	s.responses = append(s.responses, resp)
+	return nil
+}
+
+// usersStream собирает сообщения потока StreamUsers.
+
+type usersStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses []*pb.StreamUsersResponse
+}
+
+func (s *usersStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *usersStream) Send(resp *pb.StreamUsersResponse) error {
+	s.responses = append(s.responses, resp)
+	return nil
+}
+
+// Тест потоковой выгрузки пользователей: размер пакета по умолчанию и заданный, полный
+// последний пакет без пустого сообщения после него и коды ответа для неверного размера
+// пакета и отмененного вызова
+func TestStreamUsers(t *testing.T) {
+	h, repo := setupHandler()
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 150; i++ {
+		username := fmt.Sprintf("user%03d", i)
+		repo.users[username] = &model.User{ID: uuid.New(), Username: username, Role: model.RoleUser, CreatedAt: start.Add(time.Duration(i) * time.Minute)}
+	}
+
+	stream := &usersStream{ctx: ctx}
+	require.NoError(t, h.StreamUsers(&pb.StreamUsersRequest{}, stream))
+	require.Len(t, stream.responses, 2)
+	assert.Len(t, stream.responses[0].Users, service.DefaultStreamUsersBatch)
+	assert.Len(t, stream.responses[1].Users, 50)
+	assert.Equal(t, "user149", stream.responses[0].Users[0].Username)
+
+	stream = &usersStream{ctx: ctx}
+	require.NoError(t, h.StreamUsers(&pb.StreamUsersRequest{Filter: &pb.UserFilter{Sort: model.UserSortByUsername, Ascending: true}, BatchSize: 50}, stream))
+	require.Len(t, stream.responses, 3)
+	var usernames []string
+	for _, resp := range stream.responses {
+		assert.Len(t, resp.Users, 50)
+		for _, user := range resp.Users {
+			usernames = append(usernames, user.Username)
+		}
+	}
+	assert.Len(t, usernames, 150)
+	assert.True(t, slices.IsSorted(usernames))
+	assert.Equal(t, "user000", usernames[0])
+
+	err := h.StreamUsers(&pb.StreamUsersRequest{BatchSize: service.MaxListUsersLimit + 1}, &usersStream{ctx: ctx})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	stream = &usersStream{ctx: canceled}
+	err = h.StreamUsers(&pb.StreamUsersRequest{}, stream)
+	assert.Equal(t, codes.Canceled, status.Code(err))
+	assert.Empty(t, stream.responses)
+}
+
+// Тест списка и выгрузки пользователей: отбор, сортировка по умолчанию (сначала новые),
+// пакеты потока, пустой результат и коды ответа для неверного фильтра и сбоя базы данных
+func TestListAndExportUsers(t *testing.T) {
+	h, repo := setupHandler()
+	ctx := context.Background()
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 150; i++ {
+		username := fmt.Sprintf("user%03d", i)
+		repo.users[username] = &model.User{ID: uuid.New(), Username: username, Role: model.RoleUser, CreatedAt: start.Add(time.Duration(i) * time.Minute)}
+	}
+	repo.users["admin"] = &model.User{ID: uuid.New(), Username: "admin", Role: model.RoleAdmin, Email: "admin@example.com", EmailVerified: true, CreatedAt: start.Add(-time.Hour)}
+
+	resp, err := h.ListUsers(ctx, &pb.ListUsersRequest{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int32(151), resp.Total)
+	require.Len(t, resp.Users, 2)
+	assert.Equal(t, "user149", resp.Users[0].Username)
+
+	resp, err = h.ListUsers(ctx, &pb.ListUsersRequest{Filter: &pb.UserFilter{
+		UsernamePrefix: "user1",
+		CreatedTo:      timestamppb.New(start.Add(120 * time.Minute)),
+		Sort:           model.UserSortByUsername,
+		Ascending:      true,
+	}, Limit: 3, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int32(20), resp.Total)
+	require.Len(t, resp.Users, 3)
+	assert.Equal(t, "user101", resp.Users[0].Username)
+
+	resp, err = h.ListUsers(ctx, &pb.ListUsersRequest{Filter: &pb.UserFilter{UsernamePrefix: "adm"}})
+	require.NoError(t, err)
+	require.Len(t, resp.Users, 1)
+	assert.Equal(t, model.RoleAdmin, resp.Users[0].Role)
+	assert.Equal(t, "admin@example.com", resp.Users[0].Email)
+	assert.True(t, resp.Users[0].EmailVerified)
+
+	stream := &exportStream{ctx: ctx}
+	require.NoError(t, h.ExportUsers(&pb.ExportUsersRequest{Filter: &pb.UserFilter{UsernamePrefix: "user"}}, stream))
+	require.Len(t, stream.responses, 2)
+	assert.Len(t, stream.responses[0].Users, exportUsersBatch)
+	assert.Len(t, stream.responses[1].Users, 50)
+
+	stream = &exportStream{ctx: ctx}
+	require.NoError(t, h.ExportUsers(&pb.ExportUsersRequest{Filter: &pb.UserFilter{UsernamePrefix: "nobody"}}, stream))
+	assert.Empty(t, stream.responses)
+
+	_, err = h.ListUsers(ctx, &pb.ListUsersRequest{Filter: &pb.UserFilter{Sort: "password_hash"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = h.ListUsers(ctx, &pb.ListUsersRequest{Limit: service.MaxListUsersLimit + 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	err = h.ExportUsers(&pb.ExportUsersRequest{Filter: &pb.UserFilter{
+		CreatedFrom: timestamppb.New(start),
+		CreatedTo:   timestamppb.New(start),
+	}}, &exportStream{ctx: ctx})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	repo.err = errors.New("connection refused")
+	_, err = h.ListUsers(ctx, &pb.ListUsersRequest{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	err = h.ExportUsers(&pb.ExportUsersRequest{}, &exportStream{ctx: ctx})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "api/auth"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+)
+
+// VerifyPassword проверяет пароль пользователя перед удалением учетной записи.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID пользователя (codes.InvalidArgument)
+//     - пароль не совпадает (codes.Unauthenticated)
+//     - пользователь не найден (codes.NotFound)
+//     - проверка пароля не началась до истечения срока запроса (codes.ResourceExhausted)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) VerifyPassword(ctx context.Context, req *pb.VerifyPasswordRequest) (*pb.VerifyPasswordResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	if err := h.authService.VerifyPassword(ctx, userID, req.Password); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials):
+			return nil, status.Error(codes.Unauthenticated, "invalid password")
+		case errors.Is(err, service.ErrUserNotFound):
+			return nil, status.Error(codes.NotFound, "user not found")
+		case errors.Is(err, service.ErrHashCapacity):
+			return nil, errHashCapacity
+		case errors.Is(err, repository.ErrTimeout):
+			return nil, errTimeout
+		}
+		return nil, status.Error(codes.Internal, "failed to verify password")
+	}
+	return &pb.VerifyPasswordResponse{}, nil
+}
+
+// EraseUser безвозвратно удаляет пользователя с его сеансами и устройствами.
+// Для уже удаленного пользователя завершается успешно.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID пользователя (codes.InvalidArgument)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) EraseUser(ctx context.Context, req *pb.EraseUserRequest) (*pb.EraseUserResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	if err := h.authService.EraseUser(ctx, userID); err != nil {
+		if errors.Is(err, repository.ErrTimeout) {
+			return nil, errTimeout
+		}
+		return nil, status.Error(codes.Internal, "failed to erase user")
+	}
+	return &pb.EraseUserResponse{}, nil
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "api/auth"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+)
+
+// ExportUserData выгружает данные пользователя: учетную запись, все сеансы и известные устройства.
+// Хеши пароля и токенов не выгружаются.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID пользователя (codes.InvalidArgument)
+//     - пользователь не найден (codes.NotFound)
+//     - данные пользователя уже выгружались в течение service.ExportInterval (codes.ResourceExhausted)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) ExportUserData(ctx context.Context, req *pb.ExportUserDataRequest) (*pb.ExportUserDataResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	export, err := h.authService.ExportUserData(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			return nil, status.Error(codes.NotFound, "user not found")
+		case errors.Is(err, service.ErrExportRateLimited):
+			return nil, status.Error(codes.ResourceExhausted, "user data was exported recently")
+		case errors.Is(err, repository.ErrTimeout):
+			return nil, errTimeout
+		}
+		return nil, status.Error(codes.Internal, "failed to export user data")
+	}
+
+	user := export.User
+	resp := &pb.ExportUserDataResponse{
+		User: &pb.UserData{
+			UserId:                     user.ID.String(),
+			Username:                   user.Username,
+			Role:                       user.Role,
+			Email:                      user.Email,
+			EmailVerified:              user.EmailVerified,
+			CreatedAt:                  timestamppb.New(user.CreatedAt),
+			EmailVerificationExpiresAt: optionalTimestamp(user.EmailVerificationExpiresAt),
+		},
+		Sessions: make([]*pb.Session, 0, len(export.Sessions)),
+		Devices:  make([]*pb.KnownDevice, 0, len(export.Devices)),
+	}
+	for _, session := range export.Sessions {
+		resp.Sessions = append(resp.Sessions, sessionToProto(session))
+	}
+	for _, device := range export.Devices {
+		resp.Devices = append(resp.Devices, deviceToProto(device))
+	}
+	return resp, nil
+}
+
+// sessionToProto преобразует сеанс в сообщение gRPC без хеша refresh-токена.
+
+func sessionToProto(session *model.Session) *pb.Session {
+	return &pb.Session{
+		Id:          session.ID.String(),
+		DeviceLabel: session.DeviceLabel,
+		Ip:          session.IP,
+		CreatedAt:   timestamppb.New(session.CreatedAt),
+		LastUsedAt:  timestamppb.New(session.LastUsedAt),
+		ExpiresAt:   timestamppb.New(session.ExpiresAt),
+		RevokedAt:   optionalTimestamp(session.RevokedAt),
+	}
+}
+
+// deviceToProto преобразует известное устройство в сообщение gRPC.
+
+func deviceToProto(device *model.KnownDevice) *pb.KnownDevice {
+	return &pb.KnownDevice{
+		Fingerprint: device.Fingerprint,
+		UserAgent:   device.UserAgent,
+		Ip:          device.IP,
+		FirstSeenAt: timestamppb.New(device.FirstSeenAt),
+		LastSeenAt:  timestamppb.New(device.LastSeenAt),
+	}
+}
+
+// optionalTimestamp возвращает nil для нулевого времени, чтобы поле не передавалось.
+
+func optionalTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
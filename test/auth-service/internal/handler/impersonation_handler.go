@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "api/auth"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+)
+
+// ImpersonateUser выпускает администратору токен для работы от имени пользователя
+// на service.ImpersonationTokenTTL; выпуск записывается в журнал аудита.
+//
+// Returns:
+//   *pb.ImpersonateUserResponse: токен, ID пользователя и срок действия токена
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID пользователя (codes.InvalidArgument)
+//     - токен администратора недействителен (codes.Unauthenticated)
+//     - владелец токена не администратор или пользователь — администратор (codes.PermissionDenied)
+//     - пользователь не найден (codes.NotFound)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) ImpersonateUser(ctx context.Context, req *pb.ImpersonateUserRequest) (*pb.ImpersonateUserResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	tokens, err := h.authService.ImpersonateUser(ctx, req.AdminToken, userID, clientInfo(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidToken):
+			return nil, status.Error(codes.Unauthenticated, "invalid admin token")
+		case errors.Is(err, service.ErrNotAdmin):
+			return nil, status.Error(codes.PermissionDenied, "only administrators can impersonate users")
+		case errors.Is(err, service.ErrImpersonateAdmin):
+			return nil, status.Error(codes.PermissionDenied, "administrators cannot be impersonated")
+		case errors.Is(err, service.ErrUserNotFound):
+			return nil, status.Error(codes.NotFound, "user not found")
+		case errors.Is(err, repository.ErrTimeout):
+			return nil, errTimeout
+		}
+		return nil, status.Error(codes.Internal, "failed to impersonate user")
+	}
+	return &pb.ImpersonateUserResponse{
+		Token:     tokens.AccessToken,
+		UserId:    tokens.UserID.String(),
+		ExpiresAt: tokens.ExpiresAt.Unix(),
+	}, nil
+}
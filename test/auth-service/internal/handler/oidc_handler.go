@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "api/auth"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+)
+
+// ExchangeOIDCToken обрабатывает вход по ID-токену внешнего провайдера OIDC.
+// При первом входе связывает внешнюю учетную запись с пользователем или создает нового.
+//
+// Returns:
+//   *pb.ExchangeOIDCTokenResponse: access- и refresh-токены, срок действия access-токена, ID пользователя и ID созданного сеанса
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - провайдер не настроен (codes.InvalidArgument)
+//     - ID-токен недействителен (codes.Unauthenticated)
+//     - ключи провайдера недоступны (codes.Unavailable)
+//     - вход через OIDC не включен (codes.Unimplemented)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) ExchangeOIDCToken(ctx context.Context, req *pb.ExchangeOIDCTokenRequest) (*pb.ExchangeOIDCTokenResponse, error) {
+	tokens, err := h.authService.ExchangeOIDCToken(ctx, req.Provider, req.IdToken, clientInfo(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnknownProvider):
+			return nil, status.Error(codes.InvalidArgument, "unknown identity provider")
+		case errors.Is(err, service.ErrInvalidIDToken):
+			return nil, status.Error(codes.Unauthenticated, "invalid ID token")
+		case errors.Is(err, service.ErrProviderUnavailable):
+			return nil, status.Error(codes.Unavailable, "identity provider unavailable")
+		case errors.Is(err, service.ErrOIDCDisabled):
+			return nil, status.Error(codes.Unimplemented, "OIDC login is disabled")
+		case errors.Is(err, repository.ErrTimeout):
+			return nil, errTimeout
+		}
+		return nil, status.Error(codes.Internal, "failed to exchange ID token")
+	}
+
+	return &pb.ExchangeOIDCTokenResponse{
+		Token:        tokens.AccessToken,
+		UserId:       tokens.UserID.String(),
+		RefreshToken: tokens.RefreshToken,
+		SessionId:    tokens.SessionID.String(),
+		ExpiresAt:    tokens.ExpiresAt.Unix(),
+	}, nil
+}
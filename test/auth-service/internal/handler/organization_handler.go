@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "api/auth"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+)
+
+// CreateOrganization создает организацию, владельцем которой становится пользователь.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID пользователя или название организации (codes.InvalidArgument)
+//     - пользователь не найден (codes.NotFound)
+//     - пользователь уже состоит в организации (codes.AlreadyExists)
+//     - организации отключены (codes.Unimplemented)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) CreateOrganization(ctx context.Context, req *pb.CreateOrganizationRequest) (*pb.CreateOrganizationResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	org, err := h.authService.CreateOrganization(ctx, userID, req.Name)
+	if err != nil {
+		return nil, organizationError(err, "failed to create organization")
+	}
+	return &pb.CreateOrganizationResponse{Organization: &pb.Organization{
+		Id:        org.ID.String(),
+		Name:      org.Name,
+		CreatedAt: timestamppb.New(org.CreatedAt),
+	}}, nil
+}
+
+// InviteToOrganization добавляет пользователя в организацию с ролью участника.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID организации или пригласившего (codes.InvalidArgument)
+//     - пригласивший не является владельцем организации (codes.PermissionDenied)
+//     - пользователь не найден (codes.NotFound)
+//     - пользователь уже состоит в организации (codes.AlreadyExists)
+//     - организации отключены (codes.Unimplemented)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) InviteToOrganization(ctx context.Context, req *pb.InviteToOrganizationRequest) (*pb.InviteToOrganizationResponse, error) {
+	orgID, err := uuid.Parse(req.OrgId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid organization ID")
+	}
+	inviterID, err := uuid.Parse(req.InviterId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	member, err := h.authService.InviteToOrganization(ctx, orgID, inviterID, req.Username)
+	if err != nil {
+		return nil, organizationError(err, "failed to invite user")
+	}
+	return &pb.InviteToOrganizationResponse{Member: memberToProto(member)}, nil
+}
+
+// CreateInvite создает одноразовое приглашение в организацию и возвращает его код.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID организации или владельца, неверный срок действия (codes.InvalidArgument)
+//     - пользователь не является владельцем организации (codes.PermissionDenied)
+//     - организации отключены (codes.Unimplemented)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) CreateInvite(ctx context.Context, req *pb.CreateInviteRequest) (*pb.CreateInviteResponse, error) {
+	orgID, ownerID, err := parseOrgOwner(req.OrgId, req.OwnerId)
+	if err != nil {
+		return nil, err
+	}
+
+	invite, code, err := h.authService.CreateInvite(ctx, orgID, ownerID, time.Duration(req.TtlSeconds)*time.Second)
+	if err != nil {
+		return nil, organizationError(err, "failed to create invite")
+	}
+	return &pb.CreateInviteResponse{Invite: inviteToProto(invite), Code: code}, nil
+}
+
+// ListInvites возвращает приглашения организации, от новых к старым.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID организации или владельца (codes.InvalidArgument)
+//     - пользователь не является владельцем организации (codes.PermissionDenied)
+//     - организации отключены (codes.Unimplemented)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) ListInvites(ctx context.Context, req *pb.ListInvitesRequest) (*pb.ListInvitesResponse, error) {
+	orgID, ownerID, err := parseOrgOwner(req.OrgId, req.OwnerId)
+	if err != nil {
+		return nil, err
+	}
+
+	invites, err := h.authService.ListInvites(ctx, orgID, ownerID)
+	if err != nil {
+		return nil, organizationError(err, "failed to list invites")
+	}
+	resp := &pb.ListInvitesResponse{Invites: make([]*pb.OrganizationInvite, 0, len(invites))}
+	for _, invite := range invites {
+		resp.Invites = append(resp.Invites, inviteToProto(invite))
+	}
+	return resp, nil
+}
+
+// RevokeInvite отзывает непринятое приглашение в организацию.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID организации, владельца или приглашения (codes.InvalidArgument)
+//     - пользователь не является владельцем организации (codes.PermissionDenied)
+//     - приглашение не найдено или уже принято (codes.NotFound)
+//     - организации отключены (codes.Unimplemented)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) RevokeInvite(ctx context.Context, req *pb.RevokeInviteRequest) (*pb.RevokeInviteResponse, error) {
+	orgID, ownerID, err := parseOrgOwner(req.OrgId, req.OwnerId)
+	if err != nil {
+		return nil, err
+	}
+	inviteID, err := uuid.Parse(req.InviteId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid invite ID")
+	}
+
+	if err := h.authService.RevokeInvite(ctx, orgID, ownerID, inviteID); err != nil {
+		return nil, organizationError(err, "failed to revoke invite")
+	}
+	return &pb.RevokeInviteResponse{}, nil
+}
+
+// AcceptInvite принимает приглашение: пользователь становится участником организации.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID пользователя; код неизвестен, истек, отозван
+//       или использован другим пользователем (codes.InvalidArgument)
+//     - пользователь не найден (codes.NotFound)
+//     - пользователь уже состоит в организации (codes.AlreadyExists)
+//     - организации отключены (codes.Unimplemented)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) AcceptInvite(ctx context.Context, req *pb.AcceptInviteRequest) (*pb.AcceptInviteResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	member, err := h.authService.AcceptInvite(ctx, userID, req.Code)
+	if err != nil {
+		return nil, organizationError(err, "failed to accept invite")
+	}
+	return &pb.AcceptInviteResponse{Member: memberToProto(member)}, nil
+}
+
+// parseOrgOwner разбирает ID организации и ее владельца из запроса к приглашениям.
+
+func parseOrgOwner(orgID, ownerID string) (uuid.UUID, uuid.UUID, error) {
+	org, err := uuid.Parse(orgID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, status.Error(codes.InvalidArgument, "invalid organization ID")
+	}
+	owner, err := uuid.Parse(ownerID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+	return org, owner, nil
+}
+
+// memberToProto преобразует участие в организации в сообщение gRPC.
+
+func memberToProto(member *model.OrganizationMember) *pb.OrganizationMember {
+	return &pb.OrganizationMember{
+		OrgId:    member.OrgID.String(),
+		UserId:   member.UserID.String(),
+		Role:     member.Role,
+		JoinedAt: timestamppb.New(member.CreatedAt),
+	}
+}
+
+// inviteToProto преобразует приглашение в сообщение gRPC; состояние определяется
+// на текущий момент.
+
+func inviteToProto(invite *model.OrganizationInvite) *pb.OrganizationInvite {
+	msg := &pb.OrganizationInvite{
+		Id:        invite.ID.String(),
+		OrgId:     invite.OrgID.String(),
+		CreatedBy: invite.CreatedBy.String(),
+		CreatedAt: timestamppb.New(invite.CreatedAt),
+		ExpiresAt: timestamppb.New(invite.ExpiresAt),
+		Status:    invite.Status(time.Now()),
+	}
+	if invite.AcceptedBy != nil {
+		msg.AcceptedBy = invite.AcceptedBy.String()
+	}
+	if invite.AcceptedAt != nil {
+		msg.AcceptedAt = timestamppb.New(*invite.AcceptedAt)
+	}
+	if invite.RevokedAt != nil {
+		msg.RevokedAt = timestamppb.New(*invite.RevokedAt)
+	}
+	return msg
+}
+
+// organizationError переводит ошибки операций с организациями в gRPC-статус;
+// непредвиденные ошибки возвращаются как codes.Internal с сообщением message.
+
+func organizationError(err error, message string) error {
+	switch {
+	case errors.Is(err, service.ErrOrganizationsDisabled):
+		return status.Error(codes.Unimplemented, "organizations are disabled")
+	case errors.Is(err, service.ErrInvalidOrganizationName):
+		return status.Error(codes.InvalidArgument, "invalid organization name")
+	case errors.Is(err, service.ErrAlreadyInOrganization):
+		return status.Error(codes.AlreadyExists, "user already belongs to an organization")
+	case errors.Is(err, service.ErrNotOrganizationOwner):
+		return status.Error(codes.PermissionDenied, "only the organization owner can invite members")
+	case errors.Is(err, service.ErrInvalidInviteTTL):
+		return status.Error(codes.InvalidArgument, "invalid invite lifetime")
+	case errors.Is(err, service.ErrInvalidInvite):
+		return status.Error(codes.InvalidArgument, "invalid or expired invite code")
+	case errors.Is(err, service.ErrInviteNotFound):
+		return status.Error(codes.NotFound, "invite not found")
+	case errors.Is(err, service.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, repository.ErrTimeout):
+		return errTimeout
+	}
+	return status.Error(codes.Internal, message)
+}
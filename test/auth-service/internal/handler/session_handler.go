@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	pb "api/auth"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+)
+
+// Ключи метаданных gRPC, в которых внутренние сервисы передают данные клиента,
+// от имени которого выполняется вход. Сервис аутентификации доверяет им, потому что
+// вызовы принимаются только от внутренних сервисов.
+const (
+	ClientIPMetadataKey    = "x-client-ip"
+	DeviceLabelMetadataKey = "x-device-label"
+	UserAgentMetadataKey   = "x-user-agent"
+)
+
+// Refresh выпускает новую пару токенов по refresh-токену.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - refresh-токен неизвестен, уже использован, сеанс отозван или истек (codes.Unauthenticated)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.RefreshResponse, error) {
+	tokens, err := h.authService.Refresh(ctx, req.RefreshToken, clientInfo(ctx))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+		}
+		if errors.Is(err, repository.ErrTimeout) {
+			return nil, errTimeout
+		}
+		return nil, status.Error(codes.Internal, "failed to refresh token")
+	}
+
+	return &pb.RefreshResponse{
+		Token:        tokens.AccessToken,
+		UserId:       tokens.UserID.String(),
+		RefreshToken: tokens.RefreshToken,
+		SessionId:    tokens.SessionID.String(),
+		ExpiresAt:    tokens.ExpiresAt.Unix(),
+	}, nil
+}
+
+// ListSessions возвращает действующие сеансы пользователя, от последних использованных к давним.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID пользователя (codes.InvalidArgument)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	sessions, err := h.authService.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, sessionError(err, "failed to list sessions")
+	}
+
+	resp := &pb.ListSessionsResponse{Sessions: make([]*pb.Session, 0, len(sessions))}
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, sessionToProto(session))
+	}
+	return resp, nil
+}
+
+// ListDevices возвращает устройства, с которых пользователь уже входил, от последних
+// использованных к давним. Вход с устройства не из этого списка отмечается в журнале аудита.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID пользователя (codes.InvalidArgument)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) ListDevices(ctx context.Context, req *pb.ListDevicesRequest) (*pb.ListDevicesResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	devices, err := h.authService.ListDevices(ctx, userID)
+	if err != nil {
+		return nil, sessionError(err, "failed to list devices")
+	}
+
+	resp := &pb.ListDevicesResponse{Devices: make([]*pb.KnownDevice, 0, len(devices))}
+	for _, device := range devices {
+		resp.Devices = append(resp.Devices, deviceToProto(device))
+	}
+	return resp, nil
+}
+
+// RevokeSession отзывает сеанс пользователя вместе с его refresh- и access-токенами.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID пользователя или сеанса (codes.InvalidArgument)
+//     - сеанс не найден, принадлежит другому пользователю или уже отозван (codes.NotFound)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+	sessionID, err := uuid.Parse(req.SessionId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid session ID")
+	}
+
+	if err := h.authService.RevokeSession(ctx, userID, sessionID); err != nil {
+		return nil, sessionError(err, "failed to revoke session")
+	}
+	return &pb.RevokeSessionResponse{}, nil
+}
+
+// RevokeAllSessions отзывает все сеансы пользователя, кроме except_session_id, если он задан.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверный ID пользователя или сеанса (codes.InvalidArgument)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) RevokeAllSessions(ctx context.Context, req *pb.RevokeAllSessionsRequest) (*pb.RevokeAllSessionsResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+	var exceptID uuid.UUID
+	if req.ExceptSessionId != "" {
+		if exceptID, err = uuid.Parse(req.ExceptSessionId); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid session ID")
+		}
+	}
+
+	revoked, err := h.authService.RevokeAllSessions(ctx, userID, exceptID)
+	if err != nil {
+		return nil, sessionError(err, "failed to revoke sessions")
+	}
+	return &pb.RevokeAllSessionsResponse{Revoked: int32(revoked)}, nil
+}
+
+// sessionError переводит ошибки операций с сеансами в gRPC-статус;
+// непредвиденные ошибки возвращаются как codes.Internal с сообщением message.
+
+func sessionError(err error, message string) error {
+	switch {
+	case errors.Is(err, service.ErrSessionNotFound):
+		return status.Error(codes.NotFound, "session not found")
+	case errors.Is(err, repository.ErrTimeout):
+		return errTimeout
+	}
+	return status.Error(codes.Internal, message)
+}
+
+// clientInfo извлекает данные клиента из метаданных вызова. Если внутренний сервис
+// не передал IP клиента, используется адрес, с которого пришел вызов.
+
+func clientInfo(ctx context.Context) service.ClientInfo {
+	var info service.ClientInfo
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(ClientIPMetadataKey); len(values) > 0 {
+			info.IP = values[0]
+		}
+		if values := md.Get(DeviceLabelMetadataKey); len(values) > 0 {
+			info.DeviceLabel = values[0]
+		}
+		if values := md.Get(UserAgentMetadataKey); len(values) > 0 {
+			info.UserAgent = values[0]
+		}
+	}
+	if info.IP == "" {
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			info.IP = p.Addr.String()
+			if host, _, err := net.SplitHostPort(info.IP); err == nil {
+				info.IP = host
+			}
+		}
+	}
+	return info
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "api/auth"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"common/querybuilder"
+)
+
+// exportUsersBatch — число пользователей в одном сообщении потока ExportUsers.
+const exportUsersBatch = 100
+
+// ListUsers возвращает страницу пользователей, удовлетворяющих условиям отбора, и их общее число.
+// Используется сервисом заявок для списка пользователей в административном интерфейсе.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверные условия отбора или размер страницы больше service.MaxListUsersLimit (codes.InvalidArgument)
+//     - запрос к базе данных не уложился в отведенное время (codes.DeadlineExceeded)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	filter := userFilter(req.Filter)
+	filter.Limit, filter.Offset = int(req.Limit), int(req.Offset)
+
+	users, total, err := h.authService.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, usersError(err, "failed to list users")
+	}
+
+	resp := &pb.ListUsersResponse{Users: make([]*pb.UserData, 0, len(users)), Total: int32(total)}
+	for _, user := range users {
+		resp.Users = append(resp.Users, userData(user))
+	}
+	return resp, nil
+}
+
+// ExportUsers отправляет всех пользователей, удовлетворяющих условиям отбора, пакетами
+// по exportUsersBatch. Пользователи читаются из базы данных построчно, поэтому выгрузка
+// любого числа пользователей не требует загрузки списка в память. Пустой результат —
+// поток без сообщений.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверные условия отбора (codes.InvalidArgument)
+//     - клиент отменил вызов (codes.Canceled)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) ExportUsers(req *pb.ExportUsersRequest, stream pb.AuthService_ExportUsersServer) error {
+	batch := make([]*pb.UserData, 0, exportUsersBatch)
+	send := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := stream.Send(&pb.ExportUsersResponse{Users: batch})
+		batch = make([]*pb.UserData, 0, exportUsersBatch)
+		return err
+	}
+
+	ctx := stream.Context()
+	err := h.authService.ExportUsers(ctx, userFilter(req.Filter), func(user *model.User) error {
+		batch = append(batch, userData(user))
+		if len(batch) < exportUsersBatch {
+			return nil
+		}
+		return send()
+	})
+	if err == nil {
+		err = send()
+	}
+	if err != nil {
+		return streamUsersError(ctx, err, "failed to export users")
+	}
+	return nil
+}
+
+// StreamUsers отправляет всех пользователей, удовлетворяющих условиям отбора, пакетами
+// по batch_size. Каждый пакет читается из базы данных после того, как предыдущий принят
+// потоком: Send блокируется, пока клиент не освободит окно управления потоком, поэтому
+// медленный клиент задерживает чтение, а не накапливает пакеты в памяти сервиса. Отмена
+// вызова клиентом прекращает чтение. Пустой результат — поток без сообщений.
+//
+// Returns:
+//   error: ошибка с соответствующим кодом gRPC если:
+//     - неверные условия отбора или размер пакета больше service.MaxListUsersLimit (codes.InvalidArgument)
+//     - клиент отменил вызов (codes.Canceled)
+//     - произошла внутренняя ошибка (codes.Internal)
+
+func (h *AuthHandler) StreamUsers(req *pb.StreamUsersRequest, stream pb.AuthService_StreamUsersServer) error {
+	ctx := stream.Context()
+	err := h.authService.StreamUsers(ctx, userFilter(req.Filter), int(req.BatchSize), func(users []*model.User) error {
+		resp := &pb.StreamUsersResponse{Users: make([]*pb.UserData, 0, len(users))}
+		for _, user := range users {
+			resp.Users = append(resp.Users, userData(user))
+		}
+		return stream.Send(resp)
+	})
+	if err != nil {
+		return streamUsersError(ctx, err, "failed to stream users")
+	}
+	return nil
+}
+
+// streamUsersError переводит ошибку потоковой выгрузки пользователей в статус gRPC: отмена
+// вызова — в статус контекста, ошибку отправки сообщения — без изменений, остальные —
+// как usersError.
+
+func streamUsersError(ctx context.Context, err error, message string) error {
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	// Ошибка отправки сообщения уже содержит статус gRPC
+	if s, ok := status.FromError(err); ok {
+		return s.Err()
+	}
+	return usersError(err, message)
+}
+
+// userFilter переводит условия отбора из запроса в model.UserFilter без пагинации.
+
+func userFilter(filter *pb.UserFilter) model.UserFilter {
+	if filter == nil {
+		return model.UserFilter{SortDesc: true}
+	}
+	result := model.UserFilter{
+		UsernamePrefix: filter.UsernamePrefix,
+		SortBy:         filter.Sort,
+		SortDesc:       !filter.Ascending,
+	}
+	if filter.CreatedFrom != nil {
+		from := filter.CreatedFrom.AsTime()
+		result.CreatedFrom = &from
+	}
+	if filter.CreatedTo != nil {
+		to := filter.CreatedTo.AsTime()
+		result.CreatedTo = &to
+	}
+	return result
+}
+
+// userData переводит пользователя в сообщение UserData без срока токена подтверждения email.
+
+func userData(user *model.User) *pb.UserData {
+	return &pb.UserData{
+		UserId:        user.ID.String(),
+		Username:      user.Username,
+		Role:          user.Role,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		CreatedAt:     timestamppb.New(user.CreatedAt),
+	}
+}
+
+// usersError переводит ошибку ListUsers, ExportUsers или StreamUsers в статус gRPC. Фильтр, отклоненный
+// реестром полей репозитория, — такая же ошибка клиента, как отклоненный сервисом.
+
+func usersError(err error, message string) error {
+	var filterErr *querybuilder.FilterError
+	switch {
+	case errors.Is(err, service.ErrInvalidUserFilter):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.As(err, &filterErr):
+		return status.Error(codes.InvalidArgument, filterErr.Error())
+	case errors.Is(err, repository.ErrTimeout):
+		return errTimeout
+	}
+	return status.Error(codes.Internal, message)
+}
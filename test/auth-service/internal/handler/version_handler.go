@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"context"
+
+	pb "api/auth"
+	"common/buildinfo"
+)
+
+// GetVersion возвращает сведения о сборке сервиса: версию, коммит, дату сборки и версию Go.
+// Вызов не требует секрета внутреннего сервиса (см. internalauth.DefaultAllowedMethods).
+
+func (h *AuthHandler) GetVersion(context.Context, *pb.GetVersionRequest) (*pb.GetVersionResponse, error) {
+	info := buildinfo.Get()
+	return &pb.GetVersionResponse{
+		Version:   info.Version,
+		Commit:    info.Commit,
+		BuildDate: info.BuildDate,
+		GoVersion: info.GoVersion,
+	}, nil
+}
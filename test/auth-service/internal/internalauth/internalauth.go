@@ -0,0 +1,101 @@
+// Package internalauth реализует аутентификацию внутренних сервисов по общему секрету.
+//
+// Клиент передает секрет в метаданных x-internal-token каждого вызова, а перехватчик
+// сервера отклоняет вызовы без действительного секрета. Сервер принимает несколько
+// секретов одновременно, чтобы секрет можно было сменить без остановки клиентов:
+// сначала сервер начинает принимать новый секрет наряду со старым, затем клиенты
+// переходят на новый, после чего старый секрет удаляется из конфигурации сервера.
+package internalauth
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MetadataKey — ключ метаданных gRPC, в котором передается секрет внутреннего сервиса.
+const MetadataKey = "x-internal-token"
+
+// DefaultAllowedMethods — префиксы методов, доступных без секрета: проверка состояния сервиса
+// вызывается оркестратором, а сведения о сборке запрашивают операторы, у которых секрета нет.
+var DefaultAllowedMethods = []string{"/grpc.health.v1.Health/", "/auth.AuthService/GetVersion"}
+
+// UnaryServerInterceptor возвращает перехватчик, пропускающий только вызовы с одним из секретов
+// tokens в метаданных x-internal-token; остальные отклоняются с кодом Unauthenticated.
+// Методы, полное имя которых начинается с одного из префиксов allowed, не проверяются.
+// Пустые секреты игнорируются; если не задано ни одного, отклоняются все вызовы.
+func UnaryServerInterceptor(tokens []string, allowed []string) grpc.UnaryServerInterceptor {
+	secrets := nonEmpty(tokens)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := check(ctx, info.FullMethod, secrets, allowed); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor возвращает перехватчик потоковых вызовов с теми же правилами,
+// что и UnaryServerInterceptor.
+func StreamServerInterceptor(tokens []string, allowed []string) grpc.StreamServerInterceptor {
+	secrets := nonEmpty(tokens)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := check(ss.Context(), info.FullMethod, secrets, allowed); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// nonEmpty возвращает непустые секреты tokens.
+func nonEmpty(tokens []string) [][]byte {
+	var secrets [][]byte
+	for _, token := range tokens {
+		if token != "" {
+			secrets = append(secrets, []byte(token))
+		}
+	}
+	return secrets
+}
+
+// check возвращает ошибку Unauthenticated, если вызов метода fullMethod не входит в allowed
+// и не содержит одного из секретов secrets.
+func check(ctx context.Context, fullMethod string, secrets [][]byte, allowed []string) error {
+	for _, prefix := range allowed {
+		if strings.HasPrefix(fullMethod, prefix) {
+			return nil
+		}
+	}
+	if !authorized(ctx, secrets) {
+		return status.Error(codes.Unauthenticated, "missing or invalid internal token")
+	}
+	return nil
+}
+
+// authorized сообщает, совпадает ли секрет из метаданных вызова с одним из secrets.
+// Сравнение выполняется за постоянное время и проверяет все секреты.
+func authorized(ctx context.Context, secrets [][]byte) bool {
+	values := metadata.ValueFromIncomingContext(ctx, MetadataKey)
+	if len(values) != 1 {
+		return false
+	}
+	got := []byte(values[0])
+	ok := 0
+	for _, secret := range secrets {
+		ok |= subtle.ConstantTimeCompare(got, secret)
+	}
+	return ok == 1
+}
+
+// UnaryClientInterceptor возвращает перехватчик клиента, добавляющий секрет token
+// в метаданные каждого вызова.
+func UnaryClientInterceptor(token string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = metadata.AppendToOutgoingContext(ctx, MetadataKey, token)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
@@ -0,0 +1,136 @@
+package internalauth
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "api/auth"
+)
+
+// stubAuthServer отвечает на ValidateToken действительным токеном без обращения к хранилищу.
+
+type stubAuthServer struct {
+	pb.UnimplementedAuthServiceServer
+}
+
+func (stubAuthServer) ValidateToken(context.Context, *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
+	return &pb.ValidateTokenResponse{Valid: true}, nil
+}
+
+func (stubAuthServer) ExportUsers(_ *pb.ExportUsersRequest, stream pb.AuthService_ExportUsersServer) error {
+	return stream.Send(&pb.ExportUsersResponse{})
+}
+
+// startServer запускает gRPC-сервер поверх bufconn с перехватчиком, принимающим секреты tokens,
+// и возвращает соединение с ним, созданное с параметрами opts.
+
+func startServer(t *testing.T, tokens []string, opts ...grpc.DialOption) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor(tokens, DefaultAllowedMethods)),
+		grpc.StreamInterceptor(StreamServerInterceptor(tokens, DefaultAllowedMethods)),
+	)
+	pb.RegisterAuthServiceServer(server, stubAuthServer{})
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	opts = append(opts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// validate вызывает ValidateToken, передавая секрет token, если он не пустой.
+
+func validate(conn *grpc.ClientConn, token string) error {
+	ctx := context.Background()
+	if token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, MetadataKey, token)
+	}
+	_, err := pb.NewAuthServiceClient(conn).ValidateToken(ctx, &pb.ValidateTokenRequest{Token: "jwt"})
+	return err
+}
+
+// TestInterceptor_Accepted проверяет, что вызов с действительным секретом проходит,
+// в том числе когда секрет добавляет UnaryClientInterceptor.
+
+func TestInterceptor_Accepted(t *testing.T) {
+	conn := startServer(t, []string{"secret"})
+	assert.NoError(t, validate(conn, "secret"))
+
+	conn = startServer(t, []string{"secret"}, grpc.WithUnaryInterceptor(UnaryClientInterceptor("secret")))
+	assert.NoError(t, validate(conn, ""))
+}
+
+// TestInterceptor_Rejected проверяет отклонение вызовов без секрета, с неверным секретом
+// и с несколькими значениями секрета, а также доступность проверки состояния без секрета.
+
+func TestInterceptor_Rejected(t *testing.T) {
+	conn := startServer(t, []string{"secret"})
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(validate(conn, "")))
+	assert.Equal(t, codes.Unauthenticated, status.Code(validate(conn, "wrong")))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), MetadataKey, "wrong", MetadataKey, "secret")
+	_, err := pb.NewAuthServiceClient(conn).ValidateToken(ctx, &pb.ValidateTokenRequest{Token: "jwt"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+}
+
+// TestStreamInterceptor проверяет, что потоковый вызов проходит только с действительным секретом.
+
+func TestStreamInterceptor(t *testing.T) {
+	conn := startServer(t, []string{"secret"})
+	export := func(token string) error {
+		ctx := context.Background()
+		if token != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, MetadataKey, token)
+		}
+		stream, err := pb.NewAuthServiceClient(conn).ExportUsers(ctx, &pb.ExportUsersRequest{})
+		if err != nil {
+			return err
+		}
+		_, err = stream.Recv()
+		return err
+	}
+
+	assert.NoError(t, export("secret"))
+	assert.Equal(t, codes.Unauthenticated, status.Code(export("")))
+	assert.Equal(t, codes.Unauthenticated, status.Code(export("wrong")))
+}
+
+// TestInterceptor_RotatedSecret проверяет смену секрета: пока сервер принимает оба секрета,
+// проходят вызовы и со старым, и с новым; после удаления старого он отклоняется.
+
+func TestInterceptor_RotatedSecret(t *testing.T) {
+	conn := startServer(t, []string{"new-secret", "old-secret"})
+	assert.NoError(t, validate(conn, "new-secret"))
+	assert.NoError(t, validate(conn, "old-secret"))
+
+	conn = startServer(t, []string{"new-secret", ""})
+	assert.NoError(t, validate(conn, "new-secret"))
+	assert.Equal(t, codes.Unauthenticated, status.Code(validate(conn, "old-secret")))
+	assert.Equal(t, codes.Unauthenticated, status.Code(validate(conn, "")))
+}
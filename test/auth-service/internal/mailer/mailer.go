@@ -0,0 +1,30 @@
+// Package mailer определяет отправку писем пользователям. Сервис аутентификации
+// зависит только от интерфейса Mailer; реализация для разработки пишет письма в журнал.
+package mailer
+
+import (
+	"context"
+	"log"
+)
+
+// Message — письмо пользователю.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer отправляет письма.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer записывает письма в журнал вместо отправки. Предназначен для разработки:
+// в журнал попадают токены подтверждения, поэтому в production его использовать нельзя.
+type LogMailer struct{}
+
+// Send реализует Mailer.
+func (LogMailer) Send(_ context.Context, msg Message) error {
+	log.Printf("mail to=%s subject=%q body=%q", msg.To, msg.Subject, msg.Body)
+	return nil
+}
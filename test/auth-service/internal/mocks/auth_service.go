@@ -0,0 +1,455 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../service/auth_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=../service/auth_service.go -destination=auth_service.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "auth-service/internal/model"
+	service "auth-service/internal/service"
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuthService is a mock of AuthService interface.
+type MockAuthService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockAuthServiceMockRecorder is the mock recorder for MockAuthService.
+type MockAuthServiceMockRecorder struct {
+	mock *MockAuthService
+}
+
+// NewMockAuthService creates a new mock instance.
+func NewMockAuthService(ctrl *gomock.Controller) *MockAuthService {
+	mock := &MockAuthService{ctrl: ctrl}
+	mock.recorder = &MockAuthServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthService) EXPECT() *MockAuthServiceMockRecorder {
+	return m.recorder
+}
+
+// AcceptInvite mocks base method.
+func (m *MockAuthService) AcceptInvite(ctx context.Context, userID uuid.UUID, code string) (*model.OrganizationMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptInvite", ctx, userID, code)
+	ret0, _ := ret[0].(*model.OrganizationMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptInvite indicates an expected call of AcceptInvite.
+func (mr *MockAuthServiceMockRecorder) AcceptInvite(ctx, userID, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptInvite", reflect.TypeOf((*MockAuthService)(nil).AcceptInvite), ctx, userID, code)
+}
+
+// CacheStats mocks base method.
+func (m *MockAuthService) CacheStats() service.CacheStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CacheStats")
+	ret0, _ := ret[0].(service.CacheStats)
+	return ret0
+}
+
+// CacheStats indicates an expected call of CacheStats.
+func (mr *MockAuthServiceMockRecorder) CacheStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CacheStats", reflect.TypeOf((*MockAuthService)(nil).CacheStats))
+}
+
+// CreateInvite mocks base method.
+func (m *MockAuthService) CreateInvite(ctx context.Context, orgID, ownerID uuid.UUID, ttl time.Duration) (*model.OrganizationInvite, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInvite", ctx, orgID, ownerID, ttl)
+	ret0, _ := ret[0].(*model.OrganizationInvite)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateInvite indicates an expected call of CreateInvite.
+func (mr *MockAuthServiceMockRecorder) CreateInvite(ctx, orgID, ownerID, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvite", reflect.TypeOf((*MockAuthService)(nil).CreateInvite), ctx, orgID, ownerID, ttl)
+}
+
+// CreateOrganization mocks base method.
+func (m *MockAuthService) CreateOrganization(ctx context.Context, userID uuid.UUID, name string) (*model.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrganization", ctx, userID, name)
+	ret0, _ := ret[0].(*model.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrganization indicates an expected call of CreateOrganization.
+func (mr *MockAuthServiceMockRecorder) CreateOrganization(ctx, userID, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganization", reflect.TypeOf((*MockAuthService)(nil).CreateOrganization), ctx, userID, name)
+}
+
+// EraseUser mocks base method.
+func (m *MockAuthService) EraseUser(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EraseUser indicates an expected call of EraseUser.
+func (mr *MockAuthServiceMockRecorder) EraseUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUser", reflect.TypeOf((*MockAuthService)(nil).EraseUser), ctx, userID)
+}
+
+// ExchangeOIDCToken mocks base method.
+func (m *MockAuthService) ExchangeOIDCToken(ctx context.Context, provider, idToken string, client service.ClientInfo) (*service.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExchangeOIDCToken", ctx, provider, idToken, client)
+	ret0, _ := ret[0].(*service.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExchangeOIDCToken indicates an expected call of ExchangeOIDCToken.
+func (mr *MockAuthServiceMockRecorder) ExchangeOIDCToken(ctx, provider, idToken, client any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExchangeOIDCToken", reflect.TypeOf((*MockAuthService)(nil).ExchangeOIDCToken), ctx, provider, idToken, client)
+}
+
+// ExportUserData mocks base method.
+func (m *MockAuthService) ExportUserData(ctx context.Context, userID uuid.UUID) (*service.UserExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUserData", ctx, userID)
+	ret0, _ := ret[0].(*service.UserExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportUserData indicates an expected call of ExportUserData.
+func (mr *MockAuthServiceMockRecorder) ExportUserData(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserData", reflect.TypeOf((*MockAuthService)(nil).ExportUserData), ctx, userID)
+}
+
+// ExportUsers mocks base method.
+func (m *MockAuthService) ExportUsers(ctx context.Context, filter model.UserFilter, fn func(*model.User) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUsers", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportUsers indicates an expected call of ExportUsers.
+func (mr *MockAuthServiceMockRecorder) ExportUsers(ctx, filter, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUsers", reflect.TypeOf((*MockAuthService)(nil).ExportUsers), ctx, filter, fn)
+}
+
+// GetUser mocks base method.
+func (m *MockAuthService) GetUser(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, userID)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockAuthServiceMockRecorder) GetUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockAuthService)(nil).GetUser), ctx, userID)
+}
+
+// GetUsers mocks base method.
+func (m *MockAuthService) GetUsers(ctx context.Context, userIDs []uuid.UUID) ([]*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsers", ctx, userIDs)
+	ret0, _ := ret[0].([]*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsers indicates an expected call of GetUsers.
+func (mr *MockAuthServiceMockRecorder) GetUsers(ctx, userIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockAuthService)(nil).GetUsers), ctx, userIDs)
+}
+
+// ImpersonateUser mocks base method.
+func (m *MockAuthService) ImpersonateUser(ctx context.Context, adminToken string, userID uuid.UUID, client service.ClientInfo) (*service.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImpersonateUser", ctx, adminToken, userID, client)
+	ret0, _ := ret[0].(*service.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImpersonateUser indicates an expected call of ImpersonateUser.
+func (mr *MockAuthServiceMockRecorder) ImpersonateUser(ctx, adminToken, userID, client any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImpersonateUser", reflect.TypeOf((*MockAuthService)(nil).ImpersonateUser), ctx, adminToken, userID, client)
+}
+
+// InvalidateUser mocks base method.
+func (m *MockAuthService) InvalidateUser(userID uuid.UUID) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "InvalidateUser", userID)
+}
+
+// InvalidateUser indicates an expected call of InvalidateUser.
+func (mr *MockAuthServiceMockRecorder) InvalidateUser(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateUser", reflect.TypeOf((*MockAuthService)(nil).InvalidateUser), userID)
+}
+
+// InviteToOrganization mocks base method.
+func (m *MockAuthService) InviteToOrganization(ctx context.Context, orgID, inviterID uuid.UUID, username string) (*model.OrganizationMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InviteToOrganization", ctx, orgID, inviterID, username)
+	ret0, _ := ret[0].(*model.OrganizationMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InviteToOrganization indicates an expected call of InviteToOrganization.
+func (mr *MockAuthServiceMockRecorder) InviteToOrganization(ctx, orgID, inviterID, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InviteToOrganization", reflect.TypeOf((*MockAuthService)(nil).InviteToOrganization), ctx, orgID, inviterID, username)
+}
+
+// ListDevices mocks base method.
+func (m *MockAuthService) ListDevices(ctx context.Context, userID uuid.UUID) ([]*model.KnownDevice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDevices", ctx, userID)
+	ret0, _ := ret[0].([]*model.KnownDevice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDevices indicates an expected call of ListDevices.
+func (mr *MockAuthServiceMockRecorder) ListDevices(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDevices", reflect.TypeOf((*MockAuthService)(nil).ListDevices), ctx, userID)
+}
+
+// ListInvites mocks base method.
+func (m *MockAuthService) ListInvites(ctx context.Context, orgID, ownerID uuid.UUID) ([]*model.OrganizationInvite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInvites", ctx, orgID, ownerID)
+	ret0, _ := ret[0].([]*model.OrganizationInvite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInvites indicates an expected call of ListInvites.
+func (mr *MockAuthServiceMockRecorder) ListInvites(ctx, orgID, ownerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInvites", reflect.TypeOf((*MockAuthService)(nil).ListInvites), ctx, orgID, ownerID)
+}
+
+// ListSessions mocks base method.
+func (m *MockAuthService) ListSessions(ctx context.Context, userID uuid.UUID) ([]*model.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessions", ctx, userID)
+	ret0, _ := ret[0].([]*model.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSessions indicates an expected call of ListSessions.
+func (mr *MockAuthServiceMockRecorder) ListSessions(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockAuthService)(nil).ListSessions), ctx, userID)
+}
+
+// ListUsers mocks base method.
+func (m *MockAuthService) ListUsers(ctx context.Context, filter model.UserFilter) ([]*model.User, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, filter)
+	ret0, _ := ret[0].([]*model.User)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockAuthServiceMockRecorder) ListUsers(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockAuthService)(nil).ListUsers), ctx, filter)
+}
+
+// Login mocks base method.
+func (m *MockAuthService) Login(ctx context.Context, username, password string, client service.ClientInfo) (*service.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Login", ctx, username, password, client)
+	ret0, _ := ret[0].(*service.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Login indicates an expected call of Login.
+func (mr *MockAuthServiceMockRecorder) Login(ctx, username, password, client any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthService)(nil).Login), ctx, username, password, client)
+}
+
+// Refresh mocks base method.
+func (m *MockAuthService) Refresh(ctx context.Context, refreshToken string, client service.ClientInfo) (*service.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refresh", ctx, refreshToken, client)
+	ret0, _ := ret[0].(*service.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Refresh indicates an expected call of Refresh.
+func (mr *MockAuthServiceMockRecorder) Refresh(ctx, refreshToken, client any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockAuthService)(nil).Refresh), ctx, refreshToken, client)
+}
+
+// Register mocks base method.
+func (m *MockAuthService) Register(ctx context.Context, username, password, email string, client service.ClientInfo) (*service.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Register", ctx, username, password, email, client)
+	ret0, _ := ret[0].(*service.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockAuthServiceMockRecorder) Register(ctx, username, password, email, client any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockAuthService)(nil).Register), ctx, username, password, email, client)
+}
+
+// RevokeAllSessions mocks base method.
+func (m *MockAuthService) RevokeAllSessions(ctx context.Context, userID, exceptSessionID uuid.UUID) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAllSessions", ctx, userID, exceptSessionID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAllSessions indicates an expected call of RevokeAllSessions.
+func (mr *MockAuthServiceMockRecorder) RevokeAllSessions(ctx, userID, exceptSessionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllSessions", reflect.TypeOf((*MockAuthService)(nil).RevokeAllSessions), ctx, userID, exceptSessionID)
+}
+
+// RevokeInvite mocks base method.
+func (m *MockAuthService) RevokeInvite(ctx context.Context, orgID, ownerID, inviteID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeInvite", ctx, orgID, ownerID, inviteID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeInvite indicates an expected call of RevokeInvite.
+func (mr *MockAuthServiceMockRecorder) RevokeInvite(ctx, orgID, ownerID, inviteID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeInvite", reflect.TypeOf((*MockAuthService)(nil).RevokeInvite), ctx, orgID, ownerID, inviteID)
+}
+
+// RevokeSession mocks base method.
+func (m *MockAuthService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, userID, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockAuthServiceMockRecorder) RevokeSession(ctx, userID, sessionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockAuthService)(nil).RevokeSession), ctx, userID, sessionID)
+}
+
+// StreamUsers mocks base method.
+func (m *MockAuthService) StreamUsers(ctx context.Context, filter model.UserFilter, batchSize int, fn func([]*model.User) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamUsers", ctx, filter, batchSize, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamUsers indicates an expected call of StreamUsers.
+func (mr *MockAuthServiceMockRecorder) StreamUsers(ctx, filter, batchSize, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamUsers", reflect.TypeOf((*MockAuthService)(nil).StreamUsers), ctx, filter, batchSize, fn)
+}
+
+// UpdateEmail mocks base method.
+func (m *MockAuthService) UpdateEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEmail", ctx, userID, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEmail indicates an expected call of UpdateEmail.
+func (mr *MockAuthServiceMockRecorder) UpdateEmail(ctx, userID, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEmail", reflect.TypeOf((*MockAuthService)(nil).UpdateEmail), ctx, userID, email)
+}
+
+// ValidateToken mocks base method.
+func (m *MockAuthService) ValidateToken(ctx context.Context, token string) (*service.TokenClaims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateToken", ctx, token)
+	ret0, _ := ret[0].(*service.TokenClaims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateToken indicates an expected call of ValidateToken.
+func (mr *MockAuthServiceMockRecorder) ValidateToken(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateToken", reflect.TypeOf((*MockAuthService)(nil).ValidateToken), ctx, token)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockAuthService) VerifyEmail(ctx context.Context, userID uuid.UUID, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, userID, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockAuthServiceMockRecorder) VerifyEmail(ctx, userID, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockAuthService)(nil).VerifyEmail), ctx, userID, token)
+}
+
+// VerifyPassword mocks base method.
+func (m *MockAuthService) VerifyPassword(ctx context.Context, userID uuid.UUID, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyPassword", ctx, userID, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyPassword indicates an expected call of VerifyPassword.
+func (mr *MockAuthServiceMockRecorder) VerifyPassword(ctx, userID, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyPassword", reflect.TypeOf((*MockAuthService)(nil).VerifyPassword), ctx, userID, password)
+}
@@ -0,0 +1,88 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../repository/device_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=../repository/device_repository.go -destination=device_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "auth-service/internal/model"
+	context "context"
+	reflect "reflect"
+
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDeviceRepository is a mock of DeviceRepository interface.
+type MockDeviceRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockDeviceRepositoryMockRecorder is the mock recorder for MockDeviceRepository.
+type MockDeviceRepositoryMockRecorder struct {
+	mock *MockDeviceRepository
+}
+
+// NewMockDeviceRepository creates a new mock instance.
+func NewMockDeviceRepository(ctrl *gomock.Controller) *MockDeviceRepository {
+	mock := &MockDeviceRepository{ctrl: ctrl}
+	mock.recorder = &MockDeviceRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceRepository) EXPECT() *MockDeviceRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteByUser mocks base method.
+func (m *MockDeviceRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByUser", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteByUser indicates an expected call of DeleteByUser.
+func (mr *MockDeviceRepositoryMockRecorder) DeleteByUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUser", reflect.TypeOf((*MockDeviceRepository)(nil).DeleteByUser), ctx, userID)
+}
+
+// ListByUser mocks base method.
+func (m *MockDeviceRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*model.KnownDevice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", ctx, userID)
+	ret0, _ := ret[0].([]*model.KnownDevice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockDeviceRepositoryMockRecorder) ListByUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockDeviceRepository)(nil).ListByUser), ctx, userID)
+}
+
+// Remember mocks base method.
+func (m *MockDeviceRepository) Remember(ctx context.Context, device *model.KnownDevice) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remember", ctx, device)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Remember indicates an expected call of Remember.
+func (mr *MockDeviceRepositoryMockRecorder) Remember(ctx, device any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remember", reflect.TypeOf((*MockDeviceRepository)(nil).Remember), ctx, device)
+}
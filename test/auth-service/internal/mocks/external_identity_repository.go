@@ -0,0 +1,87 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../repository/external_identity_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=../repository/external_identity_repository.go -destination=external_identity_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "auth-service/internal/model"
+	context "context"
+	reflect "reflect"
+
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockExternalIdentityRepository is a mock of ExternalIdentityRepository interface.
+type MockExternalIdentityRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockExternalIdentityRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockExternalIdentityRepositoryMockRecorder is the mock recorder for MockExternalIdentityRepository.
+type MockExternalIdentityRepositoryMockRecorder struct {
+	mock *MockExternalIdentityRepository
+}
+
+// NewMockExternalIdentityRepository creates a new mock instance.
+func NewMockExternalIdentityRepository(ctrl *gomock.Controller) *MockExternalIdentityRepository {
+	mock := &MockExternalIdentityRepository{ctrl: ctrl}
+	mock.recorder = &MockExternalIdentityRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExternalIdentityRepository) EXPECT() *MockExternalIdentityRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockExternalIdentityRepository) Create(ctx context.Context, identity *model.ExternalIdentity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, identity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockExternalIdentityRepositoryMockRecorder) Create(ctx, identity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockExternalIdentityRepository)(nil).Create), ctx, identity)
+}
+
+// DeleteByUser mocks base method.
+func (m *MockExternalIdentityRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByUser", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteByUser indicates an expected call of DeleteByUser.
+func (mr *MockExternalIdentityRepositoryMockRecorder) DeleteByUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUser", reflect.TypeOf((*MockExternalIdentityRepository)(nil).DeleteByUser), ctx, userID)
+}
+
+// Get mocks base method.
+func (m *MockExternalIdentityRepository) Get(ctx context.Context, provider, subject string) (*model.ExternalIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, provider, subject)
+	ret0, _ := ret[0].(*model.ExternalIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockExternalIdentityRepositoryMockRecorder) Get(ctx, provider, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockExternalIdentityRepository)(nil).Get), ctx, provider, subject)
+}
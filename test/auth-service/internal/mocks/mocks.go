@@ -0,0 +1,12 @@
+// Package mocks содержит сгенерированные mockgen моки интерфейсов сервиса аутентификации.
+//
+// После изменения интерфейсов моки нужно перегенерировать командой
+// go generate ./internal/mocks, иначе тест TestMocksUpToDate не пройдет.
+package mocks
+
+//go:generate go tool mockgen -source=../service/auth_service.go -destination=auth_service.go -package=mocks
+//go:generate go tool mockgen -source=../repository/user_repository.go -destination=user_repository.go -package=mocks
+//go:generate go tool mockgen -source=../repository/session_repository.go -destination=session_repository.go -package=mocks
+//go:generate go tool mockgen -source=../repository/device_repository.go -destination=device_repository.go -package=mocks
+//go:generate go tool mockgen -source=../repository/organization_repository.go -destination=organization_repository.go -package=mocks
+//go:generate go tool mockgen -source=../repository/external_identity_repository.go -destination=external_identity_repository.go -package=mocks
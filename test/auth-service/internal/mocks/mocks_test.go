@@ -0,0 +1,62 @@
+package mocks
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const generatePrefix = "//go:generate go tool mockgen "
+
+// TestMocksUpToDate перегенерирует моки по директивам go:generate из mocks.go во временный
+// каталог и сравнивает их с закоммиченными: тест падает, если интерфейс изменился,
+// а моки не были перегенерированы.
+func TestMocksUpToDate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("mock generation check runs mockgen")
+	}
+
+	source, err := os.ReadFile("mocks.go")
+	require.NoError(t, err)
+
+	var checked int
+	for _, line := range strings.Split(string(source), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, generatePrefix) {
+			continue
+		}
+
+		args := strings.Fields(strings.TrimPrefix(line, generatePrefix))
+		var destination, tmpDestination string
+		for i, arg := range args {
+			if value, ok := strings.CutPrefix(arg, "-destination="); ok {
+				destination = value
+				tmpDestination = filepath.Join(t.TempDir(), value)
+				args[i] = "-destination=" + tmpDestination
+			}
+		}
+		require.NotEmpty(t, destination, "directive without -destination: %s", line)
+
+		cmd := exec.Command("go", append([]string{"tool", "mockgen"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+
+		generated, err := os.ReadFile(tmpDestination)
+		require.NoError(t, err)
+		committed, err := os.ReadFile(destination)
+		require.NoError(t, err)
+
+		// В заголовке сгенерированного файла записана команда генерации с путем назначения
+		want := strings.ReplaceAll(string(generated), tmpDestination, destination)
+		// Рабочая копия может быть извлечена с окончаниями строк CRLF
+		got := strings.ReplaceAll(string(committed), "\r\n", "\n")
+		assert.Equal(t, want, got, "%s is stale, run go generate ./internal/mocks", destination)
+		checked++
+	}
+	assert.Positive(t, checked)
+}
@@ -0,0 +1,174 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../repository/organization_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=../repository/organization_repository.go -destination=organization_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "auth-service/internal/model"
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOrganizationRepository is a mock of OrganizationRepository interface.
+type MockOrganizationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrganizationRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockOrganizationRepositoryMockRecorder is the mock recorder for MockOrganizationRepository.
+type MockOrganizationRepositoryMockRecorder struct {
+	mock *MockOrganizationRepository
+}
+
+// NewMockOrganizationRepository creates a new mock instance.
+func NewMockOrganizationRepository(ctrl *gomock.Controller) *MockOrganizationRepository {
+	mock := &MockOrganizationRepository{ctrl: ctrl}
+	mock.recorder = &MockOrganizationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrganizationRepository) EXPECT() *MockOrganizationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AcceptInvite mocks base method.
+func (m *MockOrganizationRepository) AcceptInvite(ctx context.Context, invite *model.OrganizationInvite, member *model.OrganizationMember) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptInvite", ctx, invite, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AcceptInvite indicates an expected call of AcceptInvite.
+func (mr *MockOrganizationRepositoryMockRecorder) AcceptInvite(ctx, invite, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptInvite", reflect.TypeOf((*MockOrganizationRepository)(nil).AcceptInvite), ctx, invite, member)
+}
+
+// AddMember mocks base method.
+func (m *MockOrganizationRepository) AddMember(ctx context.Context, member *model.OrganizationMember) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddMember", ctx, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddMember indicates an expected call of AddMember.
+func (mr *MockOrganizationRepositoryMockRecorder) AddMember(ctx, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddMember", reflect.TypeOf((*MockOrganizationRepository)(nil).AddMember), ctx, member)
+}
+
+// Create mocks base method.
+func (m *MockOrganizationRepository) Create(ctx context.Context, org *model.Organization, owner *model.OrganizationMember) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, org, owner)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockOrganizationRepositoryMockRecorder) Create(ctx, org, owner any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOrganizationRepository)(nil).Create), ctx, org, owner)
+}
+
+// CreateInvite mocks base method.
+func (m *MockOrganizationRepository) CreateInvite(ctx context.Context, invite *model.OrganizationInvite) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInvite", ctx, invite)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateInvite indicates an expected call of CreateInvite.
+func (mr *MockOrganizationRepositoryMockRecorder) CreateInvite(ctx, invite any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvite", reflect.TypeOf((*MockOrganizationRepository)(nil).CreateInvite), ctx, invite)
+}
+
+// DeleteByUser mocks base method.
+func (m *MockOrganizationRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByUser", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteByUser indicates an expected call of DeleteByUser.
+func (mr *MockOrganizationRepositoryMockRecorder) DeleteByUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUser", reflect.TypeOf((*MockOrganizationRepository)(nil).DeleteByUser), ctx, userID)
+}
+
+// GetInviteByCodeHash mocks base method.
+func (m *MockOrganizationRepository) GetInviteByCodeHash(ctx context.Context, codeHash string) (*model.OrganizationInvite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInviteByCodeHash", ctx, codeHash)
+	ret0, _ := ret[0].(*model.OrganizationInvite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInviteByCodeHash indicates an expected call of GetInviteByCodeHash.
+func (mr *MockOrganizationRepositoryMockRecorder) GetInviteByCodeHash(ctx, codeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInviteByCodeHash", reflect.TypeOf((*MockOrganizationRepository)(nil).GetInviteByCodeHash), ctx, codeHash)
+}
+
+// GetMembership mocks base method.
+func (m *MockOrganizationRepository) GetMembership(ctx context.Context, userID uuid.UUID) (*model.OrganizationMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMembership", ctx, userID)
+	ret0, _ := ret[0].(*model.OrganizationMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMembership indicates an expected call of GetMembership.
+func (mr *MockOrganizationRepositoryMockRecorder) GetMembership(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMembership", reflect.TypeOf((*MockOrganizationRepository)(nil).GetMembership), ctx, userID)
+}
+
+// ListInvites mocks base method.
+func (m *MockOrganizationRepository) ListInvites(ctx context.Context, orgID uuid.UUID) ([]*model.OrganizationInvite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInvites", ctx, orgID)
+	ret0, _ := ret[0].([]*model.OrganizationInvite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInvites indicates an expected call of ListInvites.
+func (mr *MockOrganizationRepositoryMockRecorder) ListInvites(ctx, orgID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInvites", reflect.TypeOf((*MockOrganizationRepository)(nil).ListInvites), ctx, orgID)
+}
+
+// RevokeInvite mocks base method.
+func (m *MockOrganizationRepository) RevokeInvite(ctx context.Context, orgID, inviteID uuid.UUID, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeInvite", ctx, orgID, inviteID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeInvite indicates an expected call of RevokeInvite.
+func (mr *MockOrganizationRepositoryMockRecorder) RevokeInvite(ctx, orgID, inviteID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeInvite", reflect.TypeOf((*MockOrganizationRepository)(nil).RevokeInvite), ctx, orgID, inviteID, at)
+}
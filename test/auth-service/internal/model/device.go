@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KnownDevice — устройство, с которого пользователь уже входил. Устройство определяется
+// отпечатком (Fingerprint) — хешем User-Agent и подсети IP-адреса клиента, поэтому смена
+// адреса внутри одной подсети не считается новым устройством.
+
+type KnownDevice struct {
+	UserID      uuid.UUID `bun:"user_id,pk,type:uuid"`
+	Fingerprint string    `bun:"fingerprint,pk"`
+	UserAgent   string    `bun:"user_agent,notnull"`
+	// IP — адрес клиента при последнем входе с устройства.
+	IP          string    `bun:"ip,notnull"`
+	FirstSeenAt time.Time `bun:"first_seen_at,notnull"`
+	LastSeenAt  time.Time `bun:"last_seen_at,notnull"`
+}
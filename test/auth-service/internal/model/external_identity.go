@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExternalIdentity связывает учетную запись внешнего провайдера OIDC с пользователем.
+// Учетная запись определяется постоянным ID у провайдера (claim sub), а не email: email
+// у провайдера может смениться или перейти к другому сотруднику.
+
+type ExternalIdentity struct {
+	Provider string    `bun:"provider,pk"`
+	Subject  string    `bun:"subject,pk"`
+	UserID   uuid.UUID `bun:"user_id,notnull,type:uuid"`
+	// Email — email из ID-токена при связывании; только для справки.
+	Email     string    `bun:"email,nullzero"`
+	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,12 @@
+package model
+
+import "github.com/google/uuid"
+
+// NewID возвращает новый идентификатор записи — UUID версии 7. Такие ID упорядочены
+// по времени создания, поэтому новые строки попадают в конец первичного индекса.
+// ID назначаются в сервисном слое до сохранения; значение по умолчанию gen_random_uuid()
+// в базе данных остается для строк, вставленных без ID.
+
+func NewID() uuid.UUID {
+	return uuid.Must(uuid.NewV7())
+}
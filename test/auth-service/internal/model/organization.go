@@ -0,0 +1,71 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Роли участников организации
+
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleMember = "member"
+)
+
+// Organization — организация, участники которой работают с общей очередью заявок
+// в сервисе заявок. Пользователь состоит не более чем в одной организации.
+
+type Organization struct {
+	ID        uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	Name      string    `bun:"name,notnull"`
+	CreatedAt time.Time `bun:"created_at,notnull"`
+}
+
+// OrganizationMember — участие пользователя в организации. Владелец (OrgRoleOwner) создает
+// организацию и приглашает в нее участников (OrgRoleMember).
+
+type OrganizationMember struct {
+	OrgID     uuid.UUID `bun:"org_id,pk,type:uuid"`
+	UserID    uuid.UUID `bun:"user_id,pk,type:uuid"`
+	Role      string    `bun:"role,notnull"`
+	CreatedAt time.Time `bun:"created_at,notnull"`
+}
+
+// Состояния приглашения в организацию
+
+const (
+	InviteStatusPending  = "pending"
+	InviteStatusAccepted = "accepted"
+	InviteStatusRevoked  = "revoked"
+	InviteStatusExpired  = "expired"
+)
+
+// OrganizationInvite — одноразовое приглашение в организацию. Код приглашения передается
+// владельцем приглашаемому и хранится только в виде хеша (CodeHash).
+
+type OrganizationInvite struct {
+	ID         uuid.UUID  `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	OrgID      uuid.UUID  `bun:"org_id,notnull,type:uuid"`
+	CodeHash   string     `bun:"code_hash,notnull,unique"`
+	CreatedBy  uuid.UUID  `bun:"created_by,notnull,type:uuid"`
+	CreatedAt  time.Time  `bun:"created_at,notnull"`
+	ExpiresAt  time.Time  `bun:"expires_at,notnull"`
+	RevokedAt  *time.Time `bun:"revoked_at"`
+	AcceptedBy *uuid.UUID `bun:"accepted_by,type:uuid"`
+	AcceptedAt *time.Time `bun:"accepted_at"`
+}
+
+// Status возвращает состояние приглашения в момент now.
+
+func (i *OrganizationInvite) Status(now time.Time) string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InviteStatusAccepted
+	case i.RevokedAt != nil:
+		return InviteStatusRevoked
+	case !now.Before(i.ExpiresAt):
+		return InviteStatusExpired
+	}
+	return InviteStatusPending
+}
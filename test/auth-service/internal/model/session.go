@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session — сеанс пользователя, создаваемый при регистрации или входе. Сеансу принадлежат
+// refresh-токен (в базе хранится только его хеш) и все access-токены, выпущенные по нему.
+// Отзыв сеанса (RevokedAt) делает недействительными и refresh-токен, и access-токены.
+
+type Session struct {
+	ID               uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	UserID           uuid.UUID `bun:"user_id,notnull,type:uuid"`
+	RefreshTokenHash string    `bun:"refresh_token_hash,notnull,unique"`
+	// DeviceLabel — описание устройства, переданное клиентом (например, User-Agent).
+	DeviceLabel string `bun:"device_label,notnull"`
+	// IP — адрес клиента при входе или последнем обновлении токенов.
+	IP         string    `bun:"ip,notnull"`
+	CreatedAt  time.Time `bun:"created_at,notnull"`
+	LastUsedAt time.Time `bun:"last_used_at,notnull"`
+	ExpiresAt  time.Time `bun:"expires_at,notnull"`
+	RevokedAt  time.Time `bun:"revoked_at,nullzero"`
+}
+
+// Active сообщает, действует ли сеанс в момент now: он не отозван и не истек.
+
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt.IsZero() && now.Before(s.ExpiresAt)
+}
@@ -1,14 +1,51 @@
-package model
-
-import (
-	"time"
-
-	"github.com/google/uuid"
-)
-
-type User struct {
-	ID           uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
-	Username     string    `bun:"username,notnull,unique"`
-	PasswordHash string    `bun:"password_hash,notnull"`
-	CreatedAt    time.Time `bun:"created_at,notnull,default:current_timestamp"`
-}
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Роли пользователей
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// User — учетная запись пользователя. Email необязателен; пустая строка хранится как NULL.
+// EmailVerificationHash и EmailVerificationExpiresAt описывают одноразовый токен
+// подтверждения email и заполнены, пока email не подтвержден.
+
+type User struct {
+	ID                         uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	Username                   string    `bun:"username,notnull,unique"`
+	PasswordHash               string    `bun:"password_hash,notnull"`
+	Role                       string    `bun:"role,notnull,default:'user'"`
+	Email                      string    `bun:"email,nullzero,unique"`
+	EmailVerified              bool      `bun:"email_verified,notnull,default:false"`
+	EmailVerificationHash      string    `bun:"email_verification_hash,nullzero"`
+	EmailVerificationExpiresAt time.Time `bun:"email_verification_expires_at,nullzero"`
+	CreatedAt                  time.Time `bun:"created_at,notnull,default:current_timestamp"`
+}
+
+// UserFilter задает условия отбора, сортировку и пагинацию списка пользователей.
+// Нулевые значения полей означают отсутствие соответствующего условия.
+
+type UserFilter struct {
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// UsernamePrefix отбирает пользователей, имя которых начинается с этой строки (с учетом регистра)
+	UsernamePrefix string
+	SortBy         string
+	SortDesc       bool
+	Limit          int
+	Offset         int
+}
+
+// Поля, по которым допускается сортировка списка пользователей
+
+const (
+	UserSortByCreatedAt = "created_at"
+	UserSortByUsername  = "username"
+)
@@ -0,0 +1,223 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxResponseSize ограничивает размер документа обнаружения и JWKS.
+const maxResponseSize = 1 << 20
+
+// keySet — кэш публичных ключей провайдера из JWKS. Ключи загружаются при первом обращении
+// и после истечения ttl. Неизвестный ключ вызывает повторную загрузку не чаще раза
+// в refreshInterval: провайдер мог сменить ключи раньше истечения кэша.
+type keySet struct {
+	client          *http.Client
+	issuer          string
+	now             func() time.Time
+	ttl             time.Duration
+	refreshInterval time.Duration
+
+	mu sync.Mutex
+	// jwksURL — адрес JWKS из конфигурации или из документа обнаружения.
+	jwksURL   string
+	keys      map[string]any
+	fetchedAt time.Time
+}
+
+// key возвращает ключ kid. Пустой kid допускается, если JWKS содержит единственный ключ.
+// Если ключи не удалось загрузить, а в кэше их нет, возвращает ErrProviderUnavailable;
+// при неудачной загрузке ключи из кэша используются и после истечения ttl.
+func (s *keySet) key(ctx context.Context, kid string) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	fresh := s.keys != nil && now.Sub(s.fetchedAt) < s.ttl
+	if fresh {
+		if key, ok := s.lookup(kid); ok {
+			return key, nil
+		}
+		// Ключи только что загружены, и неизвестный ключ в них не появится
+		if now.Sub(s.fetchedAt) < s.refreshInterval {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+	}
+
+	if err := s.refresh(ctx); err != nil {
+		if key, ok := s.lookup(kid); ok {
+			log.Printf("failed to refresh JWKS of %s, using cached keys: %v", s.issuer, err)
+			return key, nil
+		}
+		return nil, fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookup ищет ключ kid в кэше.
+func (s *keySet) lookup(kid string) (any, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// refresh загружает JWKS, при необходимости определив его адрес по документу обнаружения.
+func (s *keySet) refresh(ctx context.Context) error {
+	if s.jwksURL == "" {
+		url, err := s.discover(ctx)
+		if err != nil {
+			return err
+		}
+		s.jwksURL = url
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := s.get(ctx, s.jwksURL, &jwks); err != nil {
+		return fmt.Errorf("fetch JWKS: %w", err)
+	}
+	keys := make(map[string]any, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// Ключ неподдерживаемого типа не мешает использовать остальные
+			log.Printf("skipping JWKS key %q of %s: %v", jwk.Kid, s.issuer, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return errors.New("JWKS contains no usable keys")
+	}
+	s.keys = keys
+	s.fetchedAt = s.now()
+	return nil
+}
+
+// discover возвращает адрес JWKS из документа обнаружения провайдера. Издатель в документе
+// должен совпадать с настроенным.
+func (s *keySet) discover(ctx context.Context) (string, error) {
+	var doc struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := s.get(ctx, strings.TrimSuffix(s.issuer, "/")+"/.well-known/openid-configuration", &doc); err != nil {
+		return "", fmt.Errorf("fetch discovery document: %w", err)
+	}
+	if doc.Issuer != s.issuer {
+		return "", fmt.Errorf("discovery document issuer %q does not match %q", doc.Issuer, s.issuer)
+	}
+	if doc.JWKSURI == "" {
+		return "", errors.New("discovery document has no jwks_uri")
+	}
+	return doc.JWKSURI, nil
+}
+
+// get загружает JSON-документ url в v.
+func (s *keySet) get(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v)
+}
+
+// jsonWebKey — ключ JWKS (RFC 7517). Поддерживаются ключи RSA и EC.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey возвращает *rsa.PublicKey или *ecdsa.PublicKey ключа.
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("exponent: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// decodeBigInt декодирует число в base64url без дополнения.
+func decodeBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, errors.New("value is missing")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
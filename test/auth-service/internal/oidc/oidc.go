@@ -0,0 +1,284 @@
+// Package oidc проверяет ID-токены внешних провайдеров OpenID Connect.
+//
+// Для каждого провайдера из конфигурации Verifier проверяет подпись токена ключами из JWKS
+// провайдера, издателя (iss), получателя (aud — ID клиента сервиса у провайдера) и сроки
+// токена. Адрес JWKS берется из конфигурации или из документа обнаружения провайдера
+// (/.well-known/openid-configuration). Ключи кэшируются; токен с неизвестным ключом (kid)
+// вызывает повторную загрузку JWKS, поэтому смена ключей провайдером не требует перезапуска.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"common/clock"
+)
+
+var (
+	// ErrUnknownProvider возвращается для провайдера, которого нет в конфигурации.
+	ErrUnknownProvider = errors.New("unknown identity provider")
+	// ErrInvalidToken возвращается для ID-токена, не прошедшего проверку.
+	ErrInvalidToken = errors.New("invalid ID token")
+	// ErrProviderUnavailable возвращается, если не удалось загрузить ключи провайдера.
+	ErrProviderUnavailable = errors.New("identity provider unavailable")
+)
+
+// Параметры проверки по умолчанию (см. Option)
+const (
+	// DefaultKeysTTL — время, в течение которого загруженный JWKS используется без повторной загрузки.
+	DefaultKeysTTL = time.Hour
+	// DefaultRefreshInterval — наименьший промежуток между загрузками JWKS из-за токенов
+	// с неизвестным ключом: поток таких токенов не должен превращаться в поток запросов к провайдеру.
+	DefaultRefreshInterval = time.Minute
+	// DefaultHTTPTimeout ограничивает запросы к провайдеру.
+	DefaultHTTPTimeout = 5 * time.Second
+	// DefaultLeeway — допустимое расхождение часов сервиса и провайдера.
+	DefaultLeeway = 30 * time.Second
+)
+
+// algorithms — допустимые алгоритмы подписи ID-токенов. Симметричные алгоритмы и "none"
+// не принимаются: ключ из JWKS публичный.
+var algorithms = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+// ProviderConfig описывает внешний провайдер OIDC.
+type ProviderConfig struct {
+	// Name — имя провайдера в запросах на вход, например "corp".
+	Name string
+	// Issuer — издатель ID-токенов (claim iss) и адрес документа обнаружения.
+	Issuer string
+	// ClientID — ID клиента сервиса у провайдера; должен входить в claim aud.
+	ClientID string
+	// JWKSURL — адрес JWKS; пустое значение — jwks_uri из документа обнаружения.
+	JWKSURL string
+	// LinkVerifiedEmail разрешает при первом входе связать внешнюю учетную запись
+	// с пользователем, подтвердившим тот же email. Включается только для провайдеров,
+	// которые сами подтверждают email (claim email_verified) и которым доверяет сервис.
+	LinkVerifiedEmail bool
+}
+
+// Identity — проверенные данные внешней учетной записи из ID-токена.
+type Identity struct {
+	Provider string
+	// Subject — постоянный ID учетной записи у провайдера (claim sub).
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	Name              string
+}
+
+// Verifier проверяет ID-токены настроенных провайдеров. Безопасен для одновременного использования.
+type Verifier struct {
+	providers map[string]*provider
+}
+
+// provider — провайдер с кэшем ключей и разборщиком его токенов.
+type provider struct {
+	cfg    ProviderConfig
+	keys   *keySet
+	parser *jwt.Parser
+}
+
+// options — параметры Verifier, задаваемые Option.
+type options struct {
+	client          *http.Client
+	clock           clock.Clock
+	keysTTL         time.Duration
+	refreshInterval time.Duration
+	leeway          time.Duration
+}
+
+// Option задает необязательный параметр Verifier.
+type Option func(*options)
+
+// WithHTTPClient задает HTTP-клиент для запросов к провайдерам. По умолчанию — клиент
+// с ограничением времени запроса DefaultHTTPTimeout.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+// WithClock задает часы, по которым проверяются сроки токенов и кэша ключей.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithKeysTTL задает время кэширования JWKS (по умолчанию DefaultKeysTTL).
+func WithKeysTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.keysTTL = ttl
+	}
+}
+
+// WithRefreshInterval задает наименьший промежуток между загрузками JWKS из-за неизвестного
+// ключа (по умолчанию DefaultRefreshInterval); 0 — загружать при каждом неизвестном ключе.
+func WithRefreshInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.refreshInterval = interval
+	}
+}
+
+// WithLeeway задает допустимое расхождение часов при проверке сроков (по умолчанию DefaultLeeway).
+func WithLeeway(leeway time.Duration) Option {
+	return func(o *options) {
+		o.leeway = leeway
+	}
+}
+
+// NewVerifier создает Verifier для провайдеров configs. Имена провайдеров должны быть
+// уникальными, издатель и ID клиента обязательны. Ключи провайдеров загружаются при первой
+// проверке токена, поэтому недоступный провайдер не мешает запуску сервиса.
+func NewVerifier(configs []ProviderConfig, opts ...Option) (*Verifier, error) {
+	o := options{
+		clock:           clock.Real,
+		keysTTL:         DefaultKeysTTL,
+		refreshInterval: DefaultRefreshInterval,
+		leeway:          DefaultLeeway,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.client == nil {
+		o.client = &http.Client{Timeout: DefaultHTTPTimeout}
+	}
+
+	v := &Verifier{providers: make(map[string]*provider, len(configs))}
+	for _, cfg := range configs {
+		if cfg.Name == "" || cfg.Issuer == "" || cfg.ClientID == "" {
+			return nil, fmt.Errorf("identity provider %q: name, issuer and client ID are required", cfg.Name)
+		}
+		if _, ok := v.providers[cfg.Name]; ok {
+			return nil, fmt.Errorf("identity provider %q is configured twice", cfg.Name)
+		}
+		v.providers[cfg.Name] = &provider{
+			cfg: cfg,
+			keys: &keySet{
+				client:          o.client,
+				issuer:          cfg.Issuer,
+				jwksURL:         cfg.JWKSURL,
+				now:             o.clock.Now,
+				ttl:             o.keysTTL,
+				refreshInterval: o.refreshInterval,
+			},
+			parser: jwt.NewParser(
+				jwt.WithValidMethods(algorithms),
+				jwt.WithIssuer(cfg.Issuer),
+				jwt.WithAudience(cfg.ClientID),
+				jwt.WithExpirationRequired(),
+				jwt.WithIssuedAt(),
+				jwt.WithLeeway(o.leeway),
+				jwt.WithTimeFunc(o.clock.Now),
+			),
+		}
+	}
+	return v, nil
+}
+
+// Provider возвращает конфигурацию провайдера name.
+func (v *Verifier) Provider(name string) (ProviderConfig, bool) {
+	p, ok := v.providers[name]
+	if !ok {
+		return ProviderConfig{}, false
+	}
+	return p.cfg, true
+}
+
+// Verify проверяет ID-токен провайдера providerName и возвращает данные учетной записи.
+// Возвращает ErrUnknownProvider для неизвестного провайдера, ErrProviderUnavailable, если
+// ключи провайдера не удалось загрузить, и ошибку, соответствующую ErrInvalidToken,
+// если токен не прошел проверку.
+func (v *Verifier) Verify(ctx context.Context, providerName, rawToken string) (*Identity, error) {
+	p, ok := v.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	claims := &idTokenClaims{}
+	_, err := p.parser.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.keys.key(ctx, kid)
+	})
+	if err != nil {
+		if errors.Is(err, ErrProviderUnavailable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: subject is missing", ErrInvalidToken)
+	}
+
+	return &Identity{
+		Provider:          p.cfg.Name,
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     bool(claims.EmailVerified),
+		PreferredUsername: claims.PreferredUsername,
+		Name:              claims.Name,
+	}, nil
+}
+
+// idTokenClaims — claims ID-токена, которые использует сервис.
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Email             string    `json:"email"`
+	EmailVerified     claimBool `json:"email_verified"`
+	PreferredUsername string    `json:"preferred_username"`
+	Name              string    `json:"name"`
+}
+
+// claimBool — логический claim. Некоторые провайдеры передают его строкой "true" или "false".
+type claimBool bool
+
+func (b *claimBool) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case bool:
+		*b = claimBool(v)
+	case string:
+		*b = claimBool(strings.EqualFold(v, "true"))
+	default:
+		*b = false
+	}
+	return nil
+}
+
+// ParseProviders читает конфигурацию провайдеров с именами из списка names через запятую.
+// Параметры провайдера name берутся из переменных OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID,
+// OIDC_<NAME>_JWKS_URL и OIDC_<NAME>_LINK_EMAIL (true или false), где <NAME> — имя
+// в верхнем регистре с заменой "-" на "_"; lookup возвращает значение переменной.
+func ParseProviders(names string, lookup func(string) string) ([]ProviderConfig, error) {
+	var configs []ProviderConfig
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		cfg := ProviderConfig{
+			Name:              name,
+			Issuer:            lookup(prefix + "ISSUER"),
+			ClientID:          lookup(prefix + "CLIENT_ID"),
+			JWKSURL:           lookup(prefix + "JWKS_URL"),
+			LinkVerifiedEmail: lookup(prefix+"LINK_EMAIL") == "true",
+		}
+		if cfg.Issuer == "" || cfg.ClientID == "" {
+			return nil, fmt.Errorf("identity provider %q: %sISSUER and %sCLIENT_ID are required", name, prefix, prefix)
+		}
+		configs = append(configs, cfg)
+	}
+	return configs, nil
+}
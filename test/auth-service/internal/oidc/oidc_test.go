@@ -0,0 +1,174 @@
+package oidc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/oidc"
+	"auth-service/internal/oidc/oidctest"
+	"common/clock"
+)
+
+// newVerifier создает Verifier с провайдером "corp" поддельного издателя issuer.
+func newVerifier(t *testing.T, issuer *oidctest.Issuer, opts ...oidc.Option) *oidc.Verifier {
+	t.Helper()
+	verifier, err := oidc.NewVerifier([]oidc.ProviderConfig{
+		{Name: "corp", Issuer: issuer.URL, ClientID: oidctest.ClientID},
+	}, opts...)
+	require.NoError(t, err)
+	return verifier
+}
+
+// Тест проверки ID-токена: действительный токен возвращает данные учетной записи,
+// а токены другого получателя, издателя, с истекшим сроком, без sub, подписанные чужим
+// ключом или без подписи отклоняются
+func TestVerifier_Verify(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	verifier := newVerifier(t, issuer)
+	ctx := context.Background()
+
+	claims := issuer.Claims("subject-1")
+	claims["email"] = "user@example.com"
+	claims["email_verified"] = "true"
+	claims["preferred_username"] = "jdoe"
+	identity, err := verifier.Verify(ctx, "corp", issuer.Token(t, claims))
+	require.NoError(t, err)
+	assert.Equal(t, &oidc.Identity{
+		Provider:          "corp",
+		Subject:           "subject-1",
+		Email:             "user@example.com",
+		EmailVerified:     true,
+		PreferredUsername: "jdoe",
+	}, identity)
+
+	_, err = verifier.Verify(ctx, "other", issuer.Token(t, claims))
+	assert.ErrorIs(t, err, oidc.ErrUnknownProvider)
+
+	other := oidctest.NewIssuer(t)
+	invalid := map[string]string{
+		"wrong audience": issuer.Token(t, with(issuer.Claims("s"), "aud", "another-client")),
+		"wrong issuer":   issuer.Token(t, with(issuer.Claims("s"), "iss", other.URL)),
+		"expired":        issuer.Token(t, with(issuer.Claims("s"), "exp", time.Now().Add(-time.Hour).Unix())),
+		"no expiry":      issuer.Token(t, with(issuer.Claims("s"), "exp", nil)),
+		"no subject":     issuer.Token(t, with(issuer.Claims(""), "sub", nil)),
+		"foreign key":    other.Token(t, with(issuer.Claims("s"), "iss", issuer.URL)),
+		"unsigned":       unsigned(t, issuer.Claims("s")),
+		"malformed":      "not-a-token",
+	}
+	for name, token := range invalid {
+		_, err := verifier.Verify(ctx, "corp", token)
+		assert.ErrorIs(t, err, oidc.ErrInvalidToken, name)
+	}
+}
+
+// Тест смены ключей: токен с новым ключом загружает JWKS заново, ключи кэшируются,
+// а неизвестный ключ вызывает повторную загрузку не чаще раза в RefreshInterval
+func TestVerifier_KeyRotation(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	fake := clock.NewFake(time.Now())
+	verifier := newVerifier(t, issuer, oidc.WithClock(fake), oidc.WithKeysTTL(10*time.Minute), oidc.WithRefreshInterval(time.Minute))
+	ctx := context.Background()
+
+	_, err := verifier.Verify(ctx, "corp", issuer.Token(t, issuer.Claims("s")))
+	require.NoError(t, err)
+	_, err = verifier.Verify(ctx, "corp", issuer.Token(t, issuer.Claims("s")))
+	require.NoError(t, err)
+	assert.Equal(t, 1, issuer.JWKSRequests())
+
+	fake.Advance(time.Minute)
+	issuer.RotateKey(t, false)
+	_, err = verifier.Verify(ctx, "corp", issuer.Token(t, issuer.Claims("s")))
+	require.NoError(t, err)
+	assert.Equal(t, 2, issuer.JWKSRequests())
+
+	// Сразу после загрузки неизвестный ключ не загружает JWKS повторно
+	issuer.RotateKey(t, false)
+	_, err = verifier.Verify(ctx, "corp", issuer.Token(t, issuer.Claims("s")))
+	assert.ErrorIs(t, err, oidc.ErrInvalidToken)
+	assert.Equal(t, 2, issuer.JWKSRequests())
+
+	fake.Advance(time.Minute)
+	_, err = verifier.Verify(ctx, "corp", issuer.Token(t, issuer.Claims("s")))
+	require.NoError(t, err)
+	assert.Equal(t, 3, issuer.JWKSRequests())
+
+	// После истечения кэша ключи загружаются заново
+	fake.Advance(10 * time.Minute)
+	_, err = verifier.Verify(ctx, "corp", issuer.Token(t, issuer.Claims("s")))
+	require.NoError(t, err)
+	assert.Equal(t, 4, issuer.JWKSRequests())
+}
+
+// Тест недоступного провайдера: без загруженных ключей возвращается ErrProviderUnavailable,
+// а загруженные ранее ключи используются и после истечения кэша
+func TestVerifier_ProviderUnavailable(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	fake := clock.NewFake(time.Now())
+	verifier := newVerifier(t, issuer, oidc.WithClock(fake), oidc.WithKeysTTL(10*time.Minute))
+	ctx := context.Background()
+	token := issuer.Token(t, issuer.Claims("s"))
+	_, err := verifier.Verify(ctx, "corp", token)
+	require.NoError(t, err)
+
+	down := oidctest.NewIssuer(t)
+	downVerifier := newVerifier(t, down)
+	downToken := down.Token(t, down.Claims("s"))
+	issuer.Close()
+	down.Close()
+
+	_, err = downVerifier.Verify(ctx, "corp", downToken)
+	assert.ErrorIs(t, err, oidc.ErrProviderUnavailable)
+
+	fake.Advance(10 * time.Minute)
+	_, err = verifier.Verify(ctx, "corp", token)
+	assert.NoError(t, err)
+}
+
+// Тест чтения конфигурации провайдеров из переменных окружения
+func TestParseProviders(t *testing.T) {
+	env := map[string]string{
+		"OIDC_CORP_ISSUER":           "https://idp.example.com",
+		"OIDC_CORP_CLIENT_ID":        "calls",
+		"OIDC_CORP_LINK_EMAIL":       "true",
+		"OIDC_PARTNER_SSO_ISSUER":    "https://sso.partner.example",
+		"OIDC_PARTNER_SSO_CLIENT_ID": "calls-partner",
+		"OIDC_PARTNER_SSO_JWKS_URL":  "https://sso.partner.example/keys",
+	}
+	lookup := func(key string) string { return env[key] }
+
+	configs, err := oidc.ParseProviders("corp, partner-sso", lookup)
+	require.NoError(t, err)
+	assert.Equal(t, []oidc.ProviderConfig{
+		{Name: "corp", Issuer: "https://idp.example.com", ClientID: "calls", LinkVerifiedEmail: true},
+		{Name: "partner-sso", Issuer: "https://sso.partner.example", ClientID: "calls-partner", JWKSURL: "https://sso.partner.example/keys"},
+	}, configs)
+
+	configs, err = oidc.ParseProviders("", lookup)
+	require.NoError(t, err)
+	assert.Empty(t, configs)
+
+	_, err = oidc.ParseProviders("missing", lookup)
+	assert.Error(t, err)
+}
+
+// with возвращает claims с замененным значением key; nil удаляет claim.
+func with(claims jwt.MapClaims, key string, value any) jwt.MapClaims {
+	if value == nil {
+		delete(claims, key)
+	} else {
+		claims[key] = value
+	}
+	return claims
+}
+
+// unsigned возвращает токен с алгоритмом "none".
+func unsigned(t *testing.T, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	return token
+}
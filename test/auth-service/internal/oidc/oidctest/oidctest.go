@@ -0,0 +1,143 @@
+// Package oidctest запускает поддельного провайдера OIDC для тестов: документ обнаружения,
+// JWKS и выпуск ID-токенов, подписанных ключами провайдера, со сменой ключей.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClientID — ID клиента, для которого Issuer выпускает токены по умолчанию (claim aud).
+const ClientID = "call-service"
+
+// Issuer — поддельный провайдер OIDC на httptest.Server.
+type Issuer struct {
+	// URL — издатель токенов (claim iss) и адрес документа обнаружения.
+	URL string
+
+	server       *httptest.Server
+	jwksRequests atomic.Int64
+
+	mu      sync.Mutex
+	keys    []signingKey
+	nextKey int
+}
+
+// signingKey — ключ подписи с ID, публикуемый в JWKS.
+type signingKey struct {
+	kid string
+	key *rsa.PrivateKey
+}
+
+// NewIssuer запускает провайдера с одним ключом подписи; сервер останавливается по окончании теста.
+func NewIssuer(t testing.TB) *Issuer {
+	t.Helper()
+	i := &Issuer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"issuer": i.URL, "jwks_uri": i.URL + "/jwks"})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		i.jwksRequests.Add(1)
+		writeJSON(w, i.jwks())
+	})
+	i.server = httptest.NewServer(mux)
+	t.Cleanup(i.server.Close)
+	i.URL = i.server.URL
+	i.RotateKey(t, false)
+	return i
+}
+
+// Close останавливает провайдера раньше окончания теста.
+func (i *Issuer) Close() {
+	i.server.Close()
+}
+
+// RotateKey создает новый ключ подписи, которым подписываются следующие токены. Если keepOld
+// равен true, прежние ключи остаются в JWKS, иначе публикуется только новый ключ.
+func (i *Issuer) RotateKey(t testing.TB, keepOld bool) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate signing key: %v", err)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.nextKey++
+	signing := signingKey{kid: fmt.Sprintf("key-%d", i.nextKey), key: key}
+	if keepOld {
+		i.keys = append(i.keys, signing)
+	} else {
+		i.keys = []signingKey{signing}
+	}
+}
+
+// JWKSRequests возвращает число запросов JWKS к провайдеру.
+func (i *Issuer) JWKSRequests() int {
+	return int(i.jwksRequests.Load())
+}
+
+// Claims возвращает claims действительного ID-токена учетной записи subject для ClientID:
+// выпущен сейчас и действует час.
+func (i *Issuer) Claims(subject string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss": i.URL,
+		"aud": ClientID,
+		"sub": subject,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+}
+
+// Token подписывает claims текущим ключом провайдера.
+func (i *Issuer) Token(t testing.TB, claims jwt.MapClaims) string {
+	t.Helper()
+	i.mu.Lock()
+	signing := i.keys[len(i.keys)-1]
+	i.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = signing.kid
+	signed, err := token.SignedString(signing.key)
+	if err != nil {
+		t.Fatalf("sign ID token: %v", err)
+	}
+	return signed
+}
+
+// jwks возвращает JWKS с публичными ключами провайдера.
+func (i *Issuer) jwks() map[string]any {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	keys := make([]map[string]string, 0, len(i.keys))
+	for _, signing := range i.keys {
+		keys = append(keys, map[string]string{
+			"kty": "RSA",
+			"kid": signing.kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(signing.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(signing.key.E)).Bytes()),
+		})
+	}
+	return map[string]any{"keys": keys}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
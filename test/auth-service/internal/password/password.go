@@ -0,0 +1,211 @@
+// Package password хеширует и проверяет пароли пользователей. Сервис аутентификации зависит
+// только от интерфейса Hasher; реализации — bcrypt с настраиваемой стоимостью и Argon2id.
+// Любая реализация проверяет хеши обоих форматов, поэтому смена алгоритма по умолчанию
+// не делает недействительными сохраненные пароли: они перехешируются при следующем входе.
+// WithPepper дополняет любую реализацию секретом сервера (перцем).
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Алгоритмы хеширования паролей
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var (
+	// ErrMismatch — пароль не совпадает с хешем.
+	ErrMismatch = errors.New("password does not match")
+	// ErrUnknownFormat — хеш записан в неизвестном формате.
+	ErrUnknownFormat = errors.New("unknown password hash format")
+)
+
+// Hasher хеширует пароли и проверяет их по сохраненным хешам.
+type Hasher interface {
+	// Hash возвращает хеш пароля со случайной солью.
+	Hash(password string) (string, error)
+	// Compare возвращает nil, если пароль соответствует хешу, ErrMismatch, если не соответствует,
+	// и ErrUnknownFormat для хеша неизвестного формата. Хеши bcrypt и Argon2id проверяются
+	// любой реализацией независимо от ее параметров.
+	Compare(hash, password string) error
+	// NeedsRehash сообщает, что хеш получен другим алгоритмом или с другими параметрами
+	// и после успешной проверки пароль следует перехешировать.
+	NeedsRehash(hash string) bool
+}
+
+// New создает Hasher для алгоритма algorithm: AlgorithmBcrypt со стоимостью bcryptCost
+// или AlgorithmArgon2id с параметрами DefaultArgon2idParams.
+func New(algorithm string, bcryptCost int) (Hasher, error) {
+	switch algorithm {
+	case AlgorithmBcrypt:
+		return NewBcrypt(bcryptCost)
+	case AlgorithmArgon2id:
+		return NewArgon2id(DefaultArgon2idParams), nil
+	}
+	return nil, fmt.Errorf("unknown password hashing algorithm %q", algorithm)
+}
+
+// compare проверяет пароль по хешу, определяя алгоритм по префиксу хеша.
+func compare(hash, password string) error {
+	switch {
+	case isBcrypt(hash):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatch
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrUnknownFormat, err)
+		}
+		return nil
+	case strings.HasPrefix(hash, argon2idPrefix):
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return err
+		}
+		actual := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(actual, key) != 1 {
+			return ErrMismatch
+		}
+		return nil
+	}
+	return ErrUnknownFormat
+}
+
+// Bcrypt хеширует пароли bcrypt с заданной стоимостью.
+type Bcrypt struct {
+	cost int
+}
+
+// NewBcrypt создает Hasher bcrypt со стоимостью cost от bcrypt.MinCost до bcrypt.MaxCost.
+func NewBcrypt(cost int) (*Bcrypt, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return &Bcrypt{cost: cost}, nil
+}
+
+// Hash возвращает хеш bcrypt пароля.
+func (b *Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Compare проверяет пароль по хешу bcrypt или Argon2id.
+func (b *Bcrypt) Compare(hash, password string) error {
+	return compare(hash, password)
+}
+
+// NeedsRehash сообщает, что хеш не является хешем bcrypt со стоимостью b.
+func (b *Bcrypt) NeedsRehash(hash string) bool {
+	if !isBcrypt(hash) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != b.cost
+}
+
+// isBcrypt сообщает, является ли hash хешем bcrypt.
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// argon2idPrefix — начало хеша Argon2id в формате PHC.
+const argon2idPrefix = "$argon2id$"
+
+// Argon2idParams — параметры Argon2id: память в КиБ, число проходов, степень параллелизма,
+// длина соли и ключа в байтах.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams — параметры Argon2id по умолчанию: 64 МиБ памяти, 3 прохода, 2 потока.
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Argon2id хеширует пароли Argon2id и сохраняет хеш в формате PHC:
+// $argon2id$v=19$m=<память>,t=<проходы>,p=<потоки>$<соль>$<ключ>.
+type Argon2id struct {
+	params Argon2idParams
+}
+
+// NewArgon2id создает Hasher Argon2id с параметрами params.
+func NewArgon2id(params Argon2idParams) *Argon2id {
+	return &Argon2id{params: params}
+}
+
+// Hash возвращает хеш Argon2id пароля в формате PHC.
+func (a *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.params.Iterations, a.params.Memory, a.params.Parallelism, a.params.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		a.params.Memory, a.params.Iterations, a.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Compare проверяет пароль по хешу Argon2id или bcrypt.
+func (a *Argon2id) Compare(hash, password string) error {
+	return compare(hash, password)
+}
+
+// NeedsRehash сообщает, что хеш не является хешем Argon2id с параметрами a.
+func (a *Argon2id) NeedsRehash(hash string) bool {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	params.SaltLength, params.KeyLength = uint32(len(salt)), uint32(len(key))
+	return params != a.params
+}
+
+// decodeArgon2id разбирает хеш Argon2id в формате PHC.
+func decodeArgon2id(hash string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownFormat
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownFormat
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrUnknownFormat
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownFormat
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownFormat
+	}
+	if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, ErrUnknownFormat
+	}
+	return params, salt, key, nil
+}
@@ -0,0 +1,147 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2idParams — облегченные параметры Argon2id, чтобы тесты выполнялись быстро.
+var testArgon2idParams = Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+// Тест bcrypt: хеш проверяется, неверный пароль отклоняется, хеш с другой стоимостью требует перехеширования
+func TestBcrypt(t *testing.T) {
+	hasher, err := NewBcrypt(bcrypt.MinCost)
+	require.NoError(t, err)
+
+	hash, err := hasher.Hash("secret")
+	require.NoError(t, err)
+	assert.NoError(t, hasher.Compare(hash, "secret"))
+	assert.ErrorIs(t, hasher.Compare(hash, "wrong"), ErrMismatch)
+	assert.False(t, hasher.NeedsRehash(hash))
+
+	stronger, err := NewBcrypt(bcrypt.MinCost + 1)
+	require.NoError(t, err)
+	assert.True(t, stronger.NeedsRehash(hash))
+	assert.NoError(t, stronger.Compare(hash, "secret"))
+
+	_, err = NewBcrypt(bcrypt.MinCost - 1)
+	assert.Error(t, err)
+	_, err = NewBcrypt(bcrypt.MaxCost + 1)
+	assert.Error(t, err)
+}
+
+// Тест Argon2id: хеш в формате PHC проверяется, неверный пароль отклоняется,
+// хеш с другими параметрами требует перехеширования
+func TestArgon2id(t *testing.T) {
+	hasher := NewArgon2id(testArgon2idParams)
+
+	hash, err := hasher.Hash("secret")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"), hash)
+	assert.NoError(t, hasher.Compare(hash, "secret"))
+	assert.ErrorIs(t, hasher.Compare(hash, "wrong"), ErrMismatch)
+	assert.False(t, hasher.NeedsRehash(hash))
+
+	other, err := hasher.Hash("secret")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "соль должна быть случайной")
+
+	stronger := testArgon2idParams
+	stronger.Iterations = 2
+	assert.True(t, NewArgon2id(stronger).NeedsRehash(hash))
+	assert.NoError(t, NewArgon2id(stronger).Compare(hash, "secret"))
+}
+
+// Тест совместимости: после смены алгоритма по умолчанию на Argon2id старые хеши bcrypt
+// продолжают проверяться и требуют перехеширования, и наоборот
+func TestCrossCompatibility(t *testing.T) {
+	bcryptHasher, err := NewBcrypt(bcrypt.MinCost)
+	require.NoError(t, err)
+	argonHasher := NewArgon2id(testArgon2idParams)
+
+	bcryptHash, err := bcryptHasher.Hash("secret")
+	require.NoError(t, err)
+	assert.NoError(t, argonHasher.Compare(bcryptHash, "secret"))
+	assert.ErrorIs(t, argonHasher.Compare(bcryptHash, "wrong"), ErrMismatch)
+	assert.True(t, argonHasher.NeedsRehash(bcryptHash))
+
+	argonHash, err := argonHasher.Hash("secret")
+	require.NoError(t, err)
+	assert.NoError(t, bcryptHasher.Compare(argonHash, "secret"))
+	assert.True(t, bcryptHasher.NeedsRehash(argonHash))
+
+	// Хеш, созданный bcrypt напрямую со стоимостью по умолчанию, как до появления пакета
+	legacy, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.DefaultCost)
+	require.NoError(t, err)
+	assert.NoError(t, argonHasher.Compare(string(legacy), "secret"))
+}
+
+// Тест хешей неизвестного или поврежденного формата
+func TestCompare_UnknownFormat(t *testing.T) {
+	hasher := NewArgon2id(testArgon2idParams)
+	for _, hash := range []string{
+		"",
+		"plain",
+		"$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=0,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5",
+		"$2b$04$short",
+	} {
+		assert.ErrorIs(t, hasher.Compare(hash, "secret"), ErrUnknownFormat, hash)
+		assert.True(t, hasher.NeedsRehash(hash), hash)
+	}
+}
+
+// Тест выбора алгоритма по имени
+func TestNew(t *testing.T) {
+	hasher, err := New(AlgorithmBcrypt, bcrypt.MinCost)
+	require.NoError(t, err)
+	assert.IsType(t, &Bcrypt{}, hasher)
+
+	hasher, err = New(AlgorithmArgon2id, 0)
+	require.NoError(t, err)
+	assert.IsType(t, &Argon2id{}, hasher)
+
+	_, err = New("md5", 0)
+	assert.Error(t, err)
+	_, err = New(AlgorithmBcrypt, 100)
+	assert.Error(t, err)
+}
+
+// Тест перца: хеш с перцем проверяется, неверный пароль и другой перец отклоняются,
+// хеш без перца принимается и требует перехеширования
+func TestPeppered(t *testing.T) {
+	bcryptHasher, err := NewBcrypt(bcrypt.MinCost)
+	require.NoError(t, err)
+	hasher := WithPepper(bcryptHasher, "pepper")
+
+	hash, err := hasher.Hash("secret")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, pepperV1Prefix+"$2a$"), hash)
+	assert.NoError(t, hasher.Compare(hash, "secret"))
+	assert.ErrorIs(t, hasher.Compare(hash, "wrong"), ErrMismatch)
+	assert.False(t, hasher.NeedsRehash(hash))
+
+	// Хеш, вычисленный с другим перцем, не совпадает, а без перца не распознается
+	assert.ErrorIs(t, WithPepper(bcryptHasher, "other").Compare(hash, "secret"), ErrMismatch)
+	assert.ErrorIs(t, bcryptHasher.Compare(hash, "secret"), ErrUnknownFormat)
+
+	legacy, err := bcryptHasher.Hash("secret")
+	require.NoError(t, err)
+	assert.NoError(t, hasher.Compare(legacy, "secret"))
+	assert.ErrorIs(t, hasher.Compare(legacy, "wrong"), ErrMismatch)
+	assert.True(t, hasher.NeedsRehash(legacy))
+
+	// Перехеширование по базовому Hasher сохраняется и для хеша с перцем
+	assert.True(t, WithPepper(NewArgon2id(testArgon2idParams), "pepper").NeedsRehash(hash))
+
+	assert.Same(t, bcryptHasher, WithPepper(bcryptHasher, ""))
+	secret := WithPepper(bcryptHasher, "6e3f")
+	assert.NotContains(t, fmt.Sprintf("%v %+v", secret, secret), "6e3f")
+}
@@ -0,0 +1,69 @@
+package password
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// pepperV1Prefix предшествует хешу, вычисленному по HMAC-SHA256 пароля с перцем
+// (схема версии 1): "$pepper1" и хеш базового алгоритма, например "$pepper1$2a$12$...".
+const pepperV1Prefix = "$pepper1"
+
+// Peppered добавляет к паролю перед хешированием секрет сервера (перец), который не хранится
+// в базе данных: без него утекшие хеши нельзя перебрать. Пароль заменяется на HMAC-SHA256
+// с ключом-перцем в base64, а хеш базового Hasher помечается префиксом версии схемы.
+// Хеши без префикса, сохраненные до включения перца, проверяются по исходному паролю
+// и требуют перехеширования, поэтому заменяются при следующем входе.
+type Peppered struct {
+	hasher Hasher
+	pepper []byte
+}
+
+// WithPepper возвращает hasher, добавляющий перец pepper; при пустом pepper возвращается
+// hasher без изменений.
+func WithPepper(hasher Hasher, pepper string) Hasher {
+	if pepper == "" {
+		return hasher
+	}
+	return &Peppered{hasher: hasher, pepper: []byte(pepper)}
+}
+
+// Hash возвращает хеш пароля с перцем.
+func (p *Peppered) Hash(password string) (string, error) {
+	hash, err := p.hasher.Hash(p.mix(password))
+	if err != nil {
+		return "", err
+	}
+	return pepperV1Prefix + hash, nil
+}
+
+// Compare проверяет пароль по хешу с перцем или по хешу без перца, сохраненному ранее.
+// Хеш, вычисленный с другим перцем, не совпадает с паролем (ErrMismatch).
+func (p *Peppered) Compare(hash, password string) error {
+	if inner, ok := strings.CutPrefix(hash, pepperV1Prefix); ok {
+		return p.hasher.Compare(inner, p.mix(password))
+	}
+	return p.hasher.Compare(hash, password)
+}
+
+// NeedsRehash сообщает, что хеш вычислен без перца или требует перехеширования базовым Hasher.
+func (p *Peppered) NeedsRehash(hash string) bool {
+	inner, ok := strings.CutPrefix(hash, pepperV1Prefix)
+	return !ok || p.hasher.NeedsRehash(inner)
+}
+
+// String описывает Hasher без перца, чтобы он не попал в журнал при форматировании.
+func (p *Peppered) String() string {
+	return fmt.Sprintf("peppered %T", p.hasher)
+}
+
+// mix возвращает HMAC-SHA256 пароля с ключом-перцем в base64. Результат короче 72 байт,
+// которые учитывает bcrypt, и не содержит нулевых байтов.
+func (p *Peppered) mix(password string) string {
+	mac := hmac.New(sha256.New, p.pepper)
+	mac.Write([]byte(password))
+	return base64.RawStdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	"auth-service/internal/model"
+)
+
+// DeviceRepository определяет интерфейс для работы с известными устройствами пользователей.
+
+type DeviceRepository interface {
+	// Remember сохраняет устройство пользователя и возвращает true, если устройство с таким
+	// отпечатком встречается впервые. Для известного устройства обновляются UserAgent, IP
+	// и LastSeenAt, а FirstSeenAt остается прежним.
+	Remember(ctx context.Context, device *model.KnownDevice) (bool, error)
+	// ListByUser возвращает устройства пользователя от последних использованных к давним;
+	// устройства с одинаковым временем — по убыванию отпечатка.
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*model.KnownDevice, error)
+	// DeleteByUser удаляет все устройства пользователя и возвращает их количество.
+	DeleteByUser(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+// deviceRepository реализует интерфейс DeviceRepository для работы с базой данных через bun.
+
+type deviceRepository struct {
+	db *bun.DB
+	queryTimeout
+}
+
+// NewDeviceRepository создает новый экземпляр репозитория известных устройств.
+// Принимает подключение к базе данных через bun.DB и те же параметры, что NewUserRepository.
+
+func NewDeviceRepository(db *bun.DB, opts ...Option) DeviceRepository {
+	return &deviceRepository{db: db, queryTimeout: newQueryTimeout(opts)}
+}
+
+// Remember вставляет устройство, а при конфликте по (user_id, fingerprint) обновляет
+// данные последнего входа. Вставка и обновление различаются по системному столбцу xmax:
+// у только что вставленной строки он равен нулю.
+
+func (r *deviceRepository) Remember(ctx context.Context, device *model.KnownDevice) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var inserted bool
+	err := r.db.NewInsert().Model(device).
+		On("CONFLICT (user_id, fingerprint) DO UPDATE").
+		Set("user_agent = EXCLUDED.user_agent").
+		Set("ip = EXCLUDED.ip").
+		Set("last_seen_at = EXCLUDED.last_seen_at").
+		Returning("(xmax = 0)").
+		Scan(ctx, &inserted)
+	if err != nil {
+		return false, fmt.Errorf("remember device of user %s: %w", device.UserID, mapError(ctx, err))
+	}
+	return inserted, nil
+}
+
+// ListByUser возвращает известные устройства пользователя.
+
+func (r *deviceRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*model.KnownDevice, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var devices []*model.KnownDevice
+	err := r.db.NewSelect().Model(&devices).
+		Where("user_id = ?", userID).
+		Order("last_seen_at DESC", "fingerprint DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list devices of user %s: %w", userID, mapError(ctx, err))
+	}
+	return devices, nil
+}
+
+// DeleteByUser удаляет все известные устройства пользователя.
+
+func (r *deviceRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewDelete().Model((*model.KnownDevice)(nil)).Where("user_id = ?", userID).Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("delete devices of user %s: %w", userID, mapError(ctx, err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("delete devices of user %s: %w", userID, err)
+	}
+	return int(n), nil
+}
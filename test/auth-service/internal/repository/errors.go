@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/uptrace/bun/driver/pgdriver"
+
+	"common/querybuilder"
+)
+
+var (
+	// ErrNotFound возвращается, если запрошенная запись отсутствует в базе данных.
+	ErrNotFound = errors.New("record not found")
+	// ErrAlreadyExists возвращается, если запись с таким ключом уже существует.
+	ErrAlreadyExists = errors.New("record already exists")
+	// ErrTimeout возвращается, если запрос к базе данных не уложился в отведенное время.
+	ErrTimeout = errors.New("database query timed out")
+	// ErrInvalidFilter оборачивают ошибки фильтра списка: поле, оператор или значение,
+	// не разрешенные реестром полей модели (querybuilder.FilterError).
+	ErrInvalidFilter = querybuilder.ErrInvalidFilter
+)
+
+// Коды ошибок PostgreSQL, которые репозиторий приводит к собственным ошибкам
+const (
+	// pgQueryCanceled — отмена запроса по statement_timeout или запросу клиента.
+	pgQueryCanceled = "57014"
+	// pgUniqueViolation — нарушение ограничения уникальности.
+	pgUniqueViolation = "23505"
+)
+
+// mapError приводит ошибки драйвера к ошибкам репозитория: отсутствие строк — к ErrNotFound,
+// нарушение уникальности — к ErrAlreadyExists, истечение времени запроса — к ErrTimeout.
+// Остальные ошибки, в том числе отмена запроса вызывающим (например, при закрытии
+// соединения клиентом), возвращаются как есть.
+func mapError(ctx context.Context, err error) error {
+	if err == nil || errors.Is(err, ErrTimeout) || errors.Is(err, ErrNotFound) || errors.Is(err, ErrAlreadyExists) {
+		return err
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	var pgErr pgdriver.Error
+	if errors.As(err, &pgErr) && pgErr.Field('C') == pgUniqueViolation {
+		return fmt.Errorf("%w: %v", ErrAlreadyExists, err)
+	}
+	if errors.Is(ctx.Err(), context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &pgErr) && pgErr.Field('C') == pgQueryCanceled) {
+		return fmt.Errorf("%w: %v", ErrTimeout, err)
+	}
+	return err
+}
+
+// checkAffected возвращает ErrNotFound, если запрос изменения не затронул ни одной строки.
+func checkAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	"auth-service/internal/model"
+)
+
+// ExternalIdentityRepository определяет интерфейс для работы с учетными записями внешних
+// провайдеров OIDC, связанными с пользователями.
+
+type ExternalIdentityRepository interface {
+	// Create связывает учетную запись провайдера с пользователем; уже связанная
+	// учетная запись — ErrAlreadyExists.
+	Create(ctx context.Context, identity *model.ExternalIdentity) error
+	// Get возвращает связь учетной записи subject провайдера provider; отсутствующая — ErrNotFound.
+	Get(ctx context.Context, provider, subject string) (*model.ExternalIdentity, error)
+	// DeleteByUser удаляет все связи пользователя и возвращает их количество.
+	DeleteByUser(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+// externalIdentityRepository реализует интерфейс ExternalIdentityRepository для работы
+// с базой данных через bun.
+
+type externalIdentityRepository struct {
+	db *bun.DB
+	queryTimeout
+}
+
+// NewExternalIdentityRepository создает новый экземпляр репозитория внешних учетных записей.
+// Принимает подключение к базе данных через bun.DB и те же параметры, что NewUserRepository.
+
+func NewExternalIdentityRepository(db *bun.DB, opts ...Option) ExternalIdentityRepository {
+	return &externalIdentityRepository{db: db, queryTimeout: newQueryTimeout(opts)}
+}
+
+// Create сохраняет связь внешней учетной записи с пользователем.
+
+func (r *externalIdentityRepository) Create(ctx context.Context, identity *model.ExternalIdentity) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.NewInsert().Model(identity).Exec(ctx); err != nil {
+		return fmt.Errorf("create external identity of user %s: %w", identity.UserID, mapError(ctx, err))
+	}
+	return nil
+}
+
+// Get возвращает связь внешней учетной записи по провайдеру и ID у провайдера.
+
+func (r *externalIdentityRepository) Get(ctx context.Context, provider, subject string) (*model.ExternalIdentity, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	identity := &model.ExternalIdentity{}
+	err := r.db.NewSelect().Model(identity).
+		Where("provider = ?", provider).
+		Where("subject = ?", subject).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get external identity of provider %s: %w", provider, mapError(ctx, err))
+	}
+	return identity, nil
+}
+
+// DeleteByUser удаляет все связи внешних учетных записей с пользователем.
+
+func (r *externalIdentityRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewDelete().Model((*model.ExternalIdentity)(nil)).Where("user_id = ?", userID).Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("delete external identities of user %s: %w", userID, mapError(ctx, err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("delete external identities of user %s: %w", userID, err)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"auth-service/internal/model"
+)
+
+// inMemoryDeviceRepository хранит известные устройства в памяти процесса.
+// Повторяет поведение deviceRepository.
+
+type inMemoryDeviceRepository struct {
+	mu      sync.RWMutex
+	devices map[uuid.UUID]map[string]*model.KnownDevice
+}
+
+// NewInMemoryDeviceRepository создает репозиторий известных устройств без базы данных.
+// Используется в тестах и в режиме DEV_INMEMORY; данные не сохраняются между запусками.
+
+func NewInMemoryDeviceRepository() DeviceRepository {
+	return &inMemoryDeviceRepository{devices: make(map[uuid.UUID]map[string]*model.KnownDevice)}
+}
+
+// Remember сохраняет копию устройства или обновляет данные последнего входа известного устройства.
+
+func (r *inMemoryDeviceRepository) Remember(ctx context.Context, device *model.KnownDevice) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byFingerprint, ok := r.devices[device.UserID]
+	if !ok {
+		byFingerprint = make(map[string]*model.KnownDevice)
+		r.devices[device.UserID] = byFingerprint
+	}
+	if stored, ok := byFingerprint[device.Fingerprint]; ok {
+		stored.UserAgent = device.UserAgent
+		stored.IP = device.IP
+		stored.LastSeenAt = device.LastSeenAt
+		return false, nil
+	}
+
+	stored := *device
+	byFingerprint[device.Fingerprint] = &stored
+	return true, nil
+}
+
+// ListByUser возвращает копии известных устройств пользователя.
+
+func (r *inMemoryDeviceRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*model.KnownDevice, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var devices []*model.KnownDevice
+	for _, stored := range r.devices[userID] {
+		device := *stored
+		devices = append(devices, &device)
+	}
+	slices.SortFunc(devices, func(a, b *model.KnownDevice) int {
+		return cmp.Or(b.LastSeenAt.Compare(a.LastSeenAt), strings.Compare(b.Fingerprint, a.Fingerprint))
+	})
+	return devices, nil
+}
+
+// DeleteByUser удаляет все известные устройства пользователя.
+
+func (r *inMemoryDeviceRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := len(r.devices[userID])
+	delete(r.devices, userID)
+	return deleted, nil
+}
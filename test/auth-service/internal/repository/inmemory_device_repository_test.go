@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/model"
+)
+
+// Тест известных устройств: повторный вход обновляет последний вход, не меняя первый
+func TestInMemoryDeviceRepository(t *testing.T) {
+	repo := NewInMemoryDeviceRepository()
+	ctx := context.Background()
+	now := time.Now()
+	userID := uuid.New()
+
+	isNew, err := repo.Remember(ctx, &model.KnownDevice{UserID: userID, Fingerprint: "f1", IP: "192.0.2.1", FirstSeenAt: now, LastSeenAt: now})
+	require.NoError(t, err)
+	assert.True(t, isNew)
+	isNew, err = repo.Remember(ctx, &model.KnownDevice{UserID: userID, Fingerprint: "f2", FirstSeenAt: now, LastSeenAt: now.Add(time.Minute)})
+	require.NoError(t, err)
+	assert.True(t, isNew)
+
+	later := now.Add(2 * time.Minute)
+	isNew, err = repo.Remember(ctx, &model.KnownDevice{UserID: userID, Fingerprint: "f1", IP: "192.0.2.7", FirstSeenAt: later, LastSeenAt: later})
+	require.NoError(t, err)
+	assert.False(t, isNew)
+
+	devices, err := repo.ListByUser(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, devices, 2)
+	assert.Equal(t, "f1", devices[0].Fingerprint)
+	assert.Equal(t, "192.0.2.7", devices[0].IP)
+	assert.True(t, devices[0].FirstSeenAt.Equal(now))
+
+	devices, err = repo.ListByUser(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.Empty(t, devices)
+}
+
+// Тест порядка устройств с одинаковым временем последнего входа: устройства упорядочиваются
+// по убыванию отпечатка, как в SQL-запросе
+func TestInMemoryDeviceRepository_TieBreak(t *testing.T) {
+	repo := NewInMemoryDeviceRepository()
+	ctx := context.Background()
+	now := time.Now()
+	userID := uuid.New()
+	for _, fingerprint := range []string{"c", "a", "e", "b", "d"} {
+		_, err := repo.Remember(ctx, &model.KnownDevice{UserID: userID, Fingerprint: fingerprint, FirstSeenAt: now, LastSeenAt: now})
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < 5; i++ {
+		devices, err := repo.ListByUser(ctx, userID)
+		require.NoError(t, err)
+		fingerprints := make([]string, len(devices))
+		for i, device := range devices {
+			fingerprints[i] = device.Fingerprint
+		}
+		assert.Equal(t, []string{"e", "d", "c", "b", "a"}, fingerprints)
+	}
+}
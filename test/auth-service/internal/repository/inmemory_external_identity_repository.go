@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"auth-service/internal/model"
+)
+
+// externalIdentityKey — ключ внешней учетной записи: провайдер и ID у провайдера.
+type externalIdentityKey struct {
+	provider, subject string
+}
+
+// inMemoryExternalIdentityRepository хранит связи внешних учетных записей в памяти процесса.
+// Повторяет поведение externalIdentityRepository.
+
+type inMemoryExternalIdentityRepository struct {
+	mu         sync.RWMutex
+	identities map[externalIdentityKey]*model.ExternalIdentity
+}
+
+// NewInMemoryExternalIdentityRepository создает репозиторий внешних учетных записей без базы
+// данных. Используется в тестах и в режиме DEV_INMEMORY; данные не сохраняются между запусками.
+
+func NewInMemoryExternalIdentityRepository() ExternalIdentityRepository {
+	return &inMemoryExternalIdentityRepository{identities: make(map[externalIdentityKey]*model.ExternalIdentity)}
+}
+
+// Create сохраняет копию связи внешней учетной записи с пользователем.
+
+func (r *inMemoryExternalIdentityRepository) Create(ctx context.Context, identity *model.ExternalIdentity) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := externalIdentityKey{identity.Provider, identity.Subject}
+	if _, ok := r.identities[key]; ok {
+		return ErrAlreadyExists
+	}
+	stored := *identity
+	r.identities[key] = &stored
+	return nil
+}
+
+// Get возвращает копию связи внешней учетной записи.
+
+func (r *inMemoryExternalIdentityRepository) Get(ctx context.Context, provider, subject string) (*model.ExternalIdentity, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.identities[externalIdentityKey{provider, subject}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	identity := *stored
+	return &identity, nil
+}
+
+// DeleteByUser удаляет все связи внешних учетных записей с пользователем.
+
+func (r *inMemoryExternalIdentityRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int
+	for key, identity := range r.identities {
+		if identity.UserID == userID {
+			delete(r.identities, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
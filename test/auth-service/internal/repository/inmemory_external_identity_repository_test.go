@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/model"
+)
+
+// Тест внешних учетных записей: учетная запись связывается с пользователем один раз
+// и определяется парой провайдер и ID у провайдера
+func TestInMemoryExternalIdentityRepository(t *testing.T) {
+	repo := NewInMemoryExternalIdentityRepository()
+	ctx := context.Background()
+	userID := uuid.New()
+
+	require.NoError(t, repo.Create(ctx, &model.ExternalIdentity{Provider: "corp", Subject: "s1", UserID: userID}))
+	require.NoError(t, repo.Create(ctx, &model.ExternalIdentity{Provider: "partner", Subject: "s1", UserID: userID}))
+	err := repo.Create(ctx, &model.ExternalIdentity{Provider: "corp", Subject: "s1", UserID: uuid.New()})
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+
+	identity, err := repo.Get(ctx, "corp", "s1")
+	require.NoError(t, err)
+	assert.Equal(t, userID, identity.UserID)
+	_, err = repo.Get(ctx, "corp", "s2")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	deleted, err := repo.DeleteByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	_, err = repo.Get(ctx, "partner", "s1")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"auth-service/internal/model"
+)
+
+// inMemoryOrganizationRepository хранит организации и их участников в памяти процесса.
+// Повторяет поведение organizationRepository.
+
+type inMemoryOrganizationRepository struct {
+	mu      sync.RWMutex
+	orgs    map[uuid.UUID]*model.Organization
+	members map[uuid.UUID]*model.OrganizationMember
+	invites map[uuid.UUID]*model.OrganizationInvite
+}
+
+// NewInMemoryOrganizationRepository создает репозиторий организаций без базы данных.
+// Используется в тестах и в режиме DEV_INMEMORY; данные не сохраняются между запусками.
+
+func NewInMemoryOrganizationRepository() OrganizationRepository {
+	return &inMemoryOrganizationRepository{
+		orgs:    make(map[uuid.UUID]*model.Organization),
+		members: make(map[uuid.UUID]*model.OrganizationMember),
+		invites: make(map[uuid.UUID]*model.OrganizationInvite),
+	}
+}
+
+// Create сохраняет копии организации и ее владельца, заполняя ID организации, если он не задан.
+
+func (r *inMemoryOrganizationRepository) Create(ctx context.Context, org *model.Organization, owner *model.OrganizationMember) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.members[owner.UserID]; ok {
+		return ErrAlreadyExists
+	}
+	if org.ID == uuid.Nil {
+		org.ID = uuid.New()
+	}
+	owner.OrgID = org.ID
+
+	storedOrg, storedOwner := *org, *owner
+	r.orgs[org.ID] = &storedOrg
+	r.members[owner.UserID] = &storedOwner
+	return nil
+}
+
+// GetMembership возвращает копию участия пользователя в организации.
+
+func (r *inMemoryOrganizationRepository) GetMembership(ctx context.Context, userID uuid.UUID) (*model.OrganizationMember, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.members[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	member := *stored
+	return &member, nil
+}
+
+// AddMember сохраняет копию участника.
+
+func (r *inMemoryOrganizationRepository) AddMember(ctx context.Context, member *model.OrganizationMember) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.members[member.UserID]; ok {
+		return ErrAlreadyExists
+	}
+	stored := *member
+	r.members[member.UserID] = &stored
+	return nil
+}
+
+// DeleteByUser удаляет участие пользователя в организации.
+
+func (r *inMemoryOrganizationRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.members[userID]; !ok {
+		return 0, nil
+	}
+	delete(r.members, userID)
+	return 1, nil
+}
+
+// CreateInvite сохраняет копию приглашения, заполняя ID, если он не задан. Хеш кода
+// уникален, как в таблице organization_invites.
+
+func (r *inMemoryOrganizationRepository) CreateInvite(ctx context.Context, invite *model.OrganizationInvite) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.invites {
+		if stored.CodeHash == invite.CodeHash {
+			return ErrAlreadyExists
+		}
+	}
+	if invite.ID == uuid.Nil {
+		invite.ID = uuid.New()
+	}
+	stored := *invite
+	r.invites[invite.ID] = &stored
+	return nil
+}
+
+// ListInvites возвращает копии приглашений организации, от новых к старым.
+
+func (r *inMemoryOrganizationRepository) ListInvites(ctx context.Context, orgID uuid.UUID) ([]*model.OrganizationInvite, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var invites []*model.OrganizationInvite
+	for _, stored := range r.invites {
+		if stored.OrgID == orgID {
+			invite := *stored
+			invites = append(invites, &invite)
+		}
+	}
+	slices.SortFunc(invites, func(a, b *model.OrganizationInvite) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), bytes.Compare(b.ID[:], a.ID[:]))
+	})
+	return invites, nil
+}
+
+// GetInviteByCodeHash возвращает копию приглашения с хешем кода codeHash.
+
+func (r *inMemoryOrganizationRepository) GetInviteByCodeHash(ctx context.Context, codeHash string) (*model.OrganizationInvite, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, stored := range r.invites {
+		if stored.CodeHash == codeHash {
+			invite := *stored
+			return &invite, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// RevokeInvite отмечает непринятое приглашение отозванным, сохраняя время первого отзыва.
+
+func (r *inMemoryOrganizationRepository) RevokeInvite(ctx context.Context, orgID, inviteID uuid.UUID, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.invites[inviteID]
+	if !ok || stored.OrgID != orgID || stored.AcceptedAt != nil {
+		return ErrNotFound
+	}
+	if stored.RevokedAt == nil {
+		stored.RevokedAt = &at
+	}
+	return nil
+}
+
+// AcceptInvite отмечает приглашение принятым и сохраняет копию участника.
+
+func (r *inMemoryOrganizationRepository) AcceptInvite(ctx context.Context, invite *model.OrganizationInvite, member *model.OrganizationMember) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.invites[invite.ID]
+	if !ok || stored.AcceptedAt != nil || stored.RevokedAt != nil {
+		return ErrNotFound
+	}
+	if _, ok := r.members[member.UserID]; ok {
+		return ErrAlreadyExists
+	}
+	acceptedBy, acceptedAt := member.UserID, member.CreatedAt
+	stored.AcceptedBy, stored.AcceptedAt = &acceptedBy, &acceptedAt
+	invite.AcceptedBy, invite.AcceptedAt = &acceptedBy, &acceptedAt
+	storedMember := *member
+	r.members[member.UserID] = &storedMember
+	return nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/model"
+)
+
+// Тест организаций: пользователь состоит не более чем в одной организации
+func TestInMemoryOrganizationRepository(t *testing.T) {
+	repo := NewInMemoryOrganizationRepository()
+	ctx := context.Background()
+	now := time.Now()
+	ownerID, memberID := uuid.New(), uuid.New()
+
+	org := &model.Organization{Name: "Рога и копыта", CreatedAt: now}
+	require.NoError(t, repo.Create(ctx, org, &model.OrganizationMember{UserID: ownerID, Role: model.OrgRoleOwner, CreatedAt: now}))
+	assert.NotEqual(t, uuid.Nil, org.ID)
+	err := repo.Create(ctx, &model.Organization{Name: "Другая"}, &model.OrganizationMember{UserID: ownerID, Role: model.OrgRoleOwner})
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+
+	require.NoError(t, repo.AddMember(ctx, &model.OrganizationMember{OrgID: org.ID, UserID: memberID, Role: model.OrgRoleMember, CreatedAt: now}))
+	assert.ErrorIs(t, repo.AddMember(ctx, &model.OrganizationMember{OrgID: org.ID, UserID: ownerID, Role: model.OrgRoleMember}), ErrAlreadyExists)
+
+	membership, err := repo.GetMembership(ctx, ownerID)
+	require.NoError(t, err)
+	assert.Equal(t, org.ID, membership.OrgID)
+	assert.Equal(t, model.OrgRoleOwner, membership.Role)
+	membership, err = repo.GetMembership(ctx, memberID)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrgRoleMember, membership.Role)
+
+	deleted, err := repo.DeleteByUser(ctx, memberID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	_, err = repo.GetMembership(ctx, memberID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	deleted, err = repo.DeleteByUser(ctx, memberID)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+}
+
+// Тест приглашений: приглашение принимается один раз, отозванное не принимается,
+// принятое нельзя отозвать
+func TestInMemoryOrganizationRepository_Invites(t *testing.T) {
+	repo := NewInMemoryOrganizationRepository()
+	ctx := context.Background()
+	now := time.Now()
+	ownerID := uuid.New()
+	org := &model.Organization{Name: "Рога и копыта", CreatedAt: now}
+	require.NoError(t, repo.Create(ctx, org, &model.OrganizationMember{UserID: ownerID, Role: model.OrgRoleOwner, CreatedAt: now}))
+
+	first := &model.OrganizationInvite{OrgID: org.ID, CodeHash: "first", CreatedBy: ownerID, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	second := &model.OrganizationInvite{OrgID: org.ID, CodeHash: "second", CreatedBy: ownerID, CreatedAt: now.Add(time.Second), ExpiresAt: now.Add(time.Hour)}
+	require.NoError(t, repo.CreateInvite(ctx, first))
+	require.NoError(t, repo.CreateInvite(ctx, second))
+	assert.ErrorIs(t, repo.CreateInvite(ctx, &model.OrganizationInvite{OrgID: org.ID, CodeHash: "first"}), ErrAlreadyExists)
+
+	invites, err := repo.ListInvites(ctx, org.ID)
+	require.NoError(t, err)
+	require.Len(t, invites, 2)
+	assert.Equal(t, second.ID, invites[0].ID)
+
+	invite, err := repo.GetInviteByCodeHash(ctx, "first")
+	require.NoError(t, err)
+	memberID := uuid.New()
+	require.NoError(t, repo.AcceptInvite(ctx, invite, &model.OrganizationMember{OrgID: org.ID, UserID: memberID, Role: model.OrgRoleMember, CreatedAt: now}))
+	assert.Equal(t, memberID, *invite.AcceptedBy)
+	err = repo.AcceptInvite(ctx, invite, &model.OrganizationMember{OrgID: org.ID, UserID: uuid.New(), Role: model.OrgRoleMember, CreatedAt: now})
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, repo.RevokeInvite(ctx, org.ID, first.ID, now), ErrNotFound)
+
+	// Участник другой организации не принимает приглашение, и оно остается действующим
+	err = repo.AcceptInvite(ctx, second, &model.OrganizationMember{OrgID: org.ID, UserID: memberID, Role: model.OrgRoleMember, CreatedAt: now})
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+	assert.ErrorIs(t, repo.RevokeInvite(ctx, uuid.New(), second.ID, now), ErrNotFound)
+	require.NoError(t, repo.RevokeInvite(ctx, org.ID, second.ID, now))
+	require.NoError(t, repo.RevokeInvite(ctx, org.ID, second.ID, now.Add(time.Minute)))
+	err = repo.AcceptInvite(ctx, second, &model.OrganizationMember{OrgID: org.ID, UserID: uuid.New(), Role: model.OrgRoleMember, CreatedAt: now})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	revoked, err := repo.GetInviteByCodeHash(ctx, "second")
+	require.NoError(t, err)
+	assert.Equal(t, now, *revoked.RevokedAt)
+	assert.Equal(t, model.InviteStatusRevoked, revoked.Status(now))
+	_, err = repo.GetInviteByCodeHash(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// Тест порядка приглашений с одинаковым временем создания: приглашения упорядочиваются
+// по убыванию ID, как в SQL-запросе
+func TestInMemoryOrganizationRepository_InvitesTieBreak(t *testing.T) {
+	repo := NewInMemoryOrganizationRepository()
+	ctx := context.Background()
+	now := time.Now()
+	orgID := uuid.New()
+	var want []uuid.UUID
+	for i := 0; i < 10; i++ {
+		invite := &model.OrganizationInvite{OrgID: orgID, CodeHash: fmt.Sprintf("code%d", i), CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+		require.NoError(t, repo.CreateInvite(ctx, invite))
+		want = append(want, invite.ID)
+	}
+	slices.SortFunc(want, func(a, b uuid.UUID) int { return bytes.Compare(b[:], a[:]) })
+
+	for i := 0; i < 5; i++ {
+		invites, err := repo.ListInvites(ctx, orgID)
+		require.NoError(t, err)
+		got := make([]uuid.UUID, len(invites))
+		for i, invite := range invites {
+			got[i] = invite.ID
+		}
+		assert.Equal(t, want, got)
+	}
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"auth-service/internal/model"
+)
+
+// inMemorySessionRepository хранит сеансы в памяти процесса.
+// Повторяет поведение sessionRepository, включая условия Rotate и Revoke.
+
+type inMemorySessionRepository struct {
+	mu     sync.RWMutex
+	byID   map[uuid.UUID]*model.Session
+	byHash map[string]uuid.UUID
+}
+
+// NewInMemorySessionRepository создает репозиторий сеансов без базы данных.
+// Используется в тестах и в режиме DEV_INMEMORY; данные не сохраняются между запусками.
+
+func NewInMemorySessionRepository() SessionRepository {
+	return &inMemorySessionRepository{
+		byID:   make(map[uuid.UUID]*model.Session),
+		byHash: make(map[string]uuid.UUID),
+	}
+}
+
+// Create сохраняет новый сеанс, заполняя ID, если он не задан.
+
+func (r *inMemorySessionRepository) Create(ctx context.Context, session *model.Session) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byHash[session.RefreshTokenHash]; ok {
+		return ErrAlreadyExists
+	}
+	if session.ID == uuid.Nil {
+		session.ID = uuid.New()
+	}
+	if _, ok := r.byID[session.ID]; ok {
+		return ErrAlreadyExists
+	}
+
+	stored := *session
+	r.byID[session.ID] = &stored
+	r.byHash[session.RefreshTokenHash] = session.ID
+	return nil
+}
+
+// GetByID возвращает копию сеанса с указанным ID.
+
+func (r *inMemorySessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.byID[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	session := *stored
+	return &session, nil
+}
+
+// GetByRefreshTokenHash возвращает копию сеанса с указанным хешем refresh-токена.
+
+func (r *inMemorySessionRepository) GetByRefreshTokenHash(ctx context.Context, hash string) (*model.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.byHash[hash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	session := *r.byID[id]
+	return &session, nil
+}
+
+// ListActive возвращает копии действующих сеансов пользователя.
+
+func (r *inMemorySessionRepository) ListActive(ctx context.Context, userID uuid.UUID, now time.Time) ([]*model.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sessions []*model.Session
+	for _, stored := range r.byID {
+		if stored.UserID == userID && stored.Active(now) {
+			session := *stored
+			sessions = append(sessions, &session)
+		}
+	}
+	slices.SortFunc(sessions, func(a, b *model.Session) int {
+		return cmp.Or(b.LastUsedAt.Compare(a.LastUsedAt), bytes.Compare(b.ID[:], a.ID[:]))
+	})
+	return sessions, nil
+}
+
+// ListByUser возвращает копии всех сеансов пользователя.
+
+func (r *inMemorySessionRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*model.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sessions []*model.Session
+	for _, stored := range r.byID {
+		if stored.UserID == userID {
+			session := *stored
+			sessions = append(sessions, &session)
+		}
+	}
+	slices.SortFunc(sessions, func(a, b *model.Session) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), bytes.Compare(b.ID[:], a.ID[:]))
+	})
+	return sessions, nil
+}
+
+// Rotate заменяет refresh-токен неотозванного сеанса, если прежний хеш совпадает.
+
+func (r *inMemorySessionRepository) Rotate(ctx context.Context, session *model.Session, oldHash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.byID[session.ID]
+	if !ok || !stored.RevokedAt.IsZero() || stored.RefreshTokenHash != oldHash {
+		return ErrNotFound
+	}
+	delete(r.byHash, oldHash)
+	r.byHash[session.RefreshTokenHash] = session.ID
+	stored.RefreshTokenHash = session.RefreshTokenHash
+	stored.IP = session.IP
+	stored.LastUsedAt = session.LastUsedAt
+	stored.ExpiresAt = session.ExpiresAt
+	return nil
+}
+
+// Revoke отмечает неотозванный сеанс пользователя отозванным.
+
+func (r *inMemorySessionRepository) Revoke(ctx context.Context, userID, id uuid.UUID, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.byID[id]
+	if !ok || stored.UserID != userID || !stored.RevokedAt.IsZero() {
+		return ErrNotFound
+	}
+	stored.RevokedAt = at
+	return nil
+}
+
+// RevokeAll отмечает отозванными все неотозванные сеансы пользователя, кроме exceptID.
+
+func (r *inMemorySessionRepository) RevokeAll(ctx context.Context, userID, exceptID uuid.UUID, at time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	revoked := 0
+	for id, stored := range r.byID {
+		if stored.UserID == userID && id != exceptID && stored.RevokedAt.IsZero() {
+			stored.RevokedAt = at
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+// PurgeExpiredBefore удаляет до limit сеансов, истекших или отозванных раньше before.
+
+func (r *inMemorySessionRepository) PurgeExpiredBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := 0
+	for id, stored := range r.byID {
+		if purged == limit {
+			break
+		}
+		revoked := !stored.RevokedAt.IsZero() && stored.RevokedAt.Before(before)
+		if stored.ExpiresAt.Before(before) || revoked {
+			delete(r.byID, id)
+			delete(r.byHash, stored.RefreshTokenHash)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// DeleteByUser удаляет все сеансы пользователя.
+
+func (r *inMemorySessionRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for id, stored := range r.byID {
+		if stored.UserID == userID {
+			delete(r.byID, id)
+			delete(r.byHash, stored.RefreshTokenHash)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
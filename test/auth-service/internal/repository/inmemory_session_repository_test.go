@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/model"
+)
+
+// Тест сеансов: замена refresh-токена только по прежнему хешу, отзыв и список действующих сеансов
+func TestInMemorySessionRepository(t *testing.T) {
+	repo := NewInMemorySessionRepository()
+	ctx := context.Background()
+	now := time.Now()
+	userID := uuid.New()
+
+	first := &model.Session{UserID: userID, RefreshTokenHash: "h1", LastUsedAt: now, ExpiresAt: now.Add(time.Hour)}
+	require.NoError(t, repo.Create(ctx, first))
+	assert.NotEqual(t, uuid.Nil, first.ID)
+	second := &model.Session{UserID: userID, RefreshTokenHash: "h2", LastUsedAt: now.Add(time.Minute), ExpiresAt: now.Add(time.Hour)}
+	require.NoError(t, repo.Create(ctx, second))
+	assert.ErrorIs(t, repo.Create(ctx, &model.Session{UserID: userID, RefreshTokenHash: "h1"}), ErrAlreadyExists)
+
+	rotated := *first
+	rotated.RefreshTokenHash = "h3"
+	rotated.LastUsedAt = now.Add(2 * time.Minute)
+	assert.ErrorIs(t, repo.Rotate(ctx, &rotated, "wrong"), ErrNotFound)
+	require.NoError(t, repo.Rotate(ctx, &rotated, "h1"))
+	_, err := repo.GetByRefreshTokenHash(ctx, "h1")
+	assert.ErrorIs(t, err, ErrNotFound)
+	byHash, err := repo.GetByRefreshTokenHash(ctx, "h3")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, byHash.ID)
+
+	sessions, err := repo.ListActive(ctx, userID, now)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, first.ID, sessions[0].ID)
+
+	assert.ErrorIs(t, repo.Revoke(ctx, uuid.New(), first.ID, now), ErrNotFound)
+	require.NoError(t, repo.Revoke(ctx, userID, first.ID, now))
+	assert.ErrorIs(t, repo.Revoke(ctx, userID, first.ID, now), ErrNotFound)
+	assert.ErrorIs(t, repo.Rotate(ctx, &rotated, "h3"), ErrNotFound)
+
+	revoked, err := repo.RevokeAll(ctx, userID, uuid.Nil, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, revoked)
+
+	sessions, err = repo.ListActive(ctx, userID, now)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+// Тест удаления устаревших сеансов: удаляются только истекшие или давно отозванные сеансы в пределах limit
+func TestInMemorySessionRepository_PurgeExpiredBefore(t *testing.T) {
+	repo := NewInMemorySessionRepository()
+	ctx := context.Background()
+	before := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+
+	expired := &model.Session{UserID: userID, RefreshTokenHash: "h1", ExpiresAt: before.Add(-time.Hour)}
+	revoked := &model.Session{UserID: userID, RefreshTokenHash: "h2", ExpiresAt: before.Add(time.Hour), RevokedAt: before.Add(-time.Minute)}
+	recent := &model.Session{UserID: userID, RefreshTokenHash: "h3", ExpiresAt: before.Add(time.Hour), RevokedAt: before.Add(time.Minute)}
+	active := &model.Session{UserID: userID, RefreshTokenHash: "h4", ExpiresAt: before.Add(time.Hour)}
+	for _, session := range []*model.Session{expired, revoked, recent, active} {
+		require.NoError(t, repo.Create(ctx, session))
+	}
+
+	n, err := repo.PurgeExpiredBefore(ctx, before, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	n, err = repo.PurgeExpiredBefore(ctx, before, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	for _, hash := range []string{"h1", "h2"} {
+		_, err := repo.GetByRefreshTokenHash(ctx, hash)
+		assert.ErrorIs(t, err, ErrNotFound, hash)
+	}
+	for _, hash := range []string{"h3", "h4"} {
+		_, err := repo.GetByRefreshTokenHash(ctx, hash)
+		assert.NoError(t, err, hash)
+	}
+}
+
+// Тест порядка сеансов с одинаковым временем: сеансы упорядочиваются по убыванию ID,
+// как в SQL-запросе, и порядок не меняется от запроса к запросу
+func TestInMemorySessionRepository_TieBreak(t *testing.T) {
+	repo := NewInMemorySessionRepository()
+	ctx := context.Background()
+	now := time.Now()
+	userID := uuid.New()
+	for i := 0; i < 20; i++ {
+		session := &model.Session{UserID: userID, RefreshTokenHash: fmt.Sprintf("h%d", i), CreatedAt: now, LastUsedAt: now, ExpiresAt: now.Add(time.Hour)}
+		require.NoError(t, repo.Create(ctx, session))
+	}
+	ids := func(sessions []*model.Session) []uuid.UUID {
+		result := make([]uuid.UUID, len(sessions))
+		for i, session := range sessions {
+			result[i] = session.ID
+		}
+		return result
+	}
+
+	active, err := repo.ListActive(ctx, userID, now)
+	require.NoError(t, err)
+	require.Len(t, active, 20)
+	assert.True(t, slices.IsSortedFunc(ids(active), func(a, b uuid.UUID) int { return bytes.Compare(b[:], a[:]) }))
+	all, err := repo.ListByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, ids(active), ids(all))
+
+	for i := 0; i < 5; i++ {
+		again, err := repo.ListActive(ctx, userID, now)
+		require.NoError(t, err)
+		assert.Equal(t, ids(active), ids(again))
+	}
+}
@@ -0,0 +1,334 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"auth-service/internal/model"
+)
+
+// inMemoryUserRepository хранит пользователей в памяти процесса.
+// Повторяет поведение userRepository: ErrNotFound для отсутствующих пользователей,
+// ErrAlreadyExists для занятого имени или email и значения по умолчанию для ID, роли и даты создания.
+
+type inMemoryUserRepository struct {
+	mu         sync.RWMutex
+	byID       map[uuid.UUID]*model.User
+	byUsername map[string]uuid.UUID
+	byEmail    map[string]uuid.UUID
+}
+
+// NewInMemoryUserRepository создает репозиторий пользователей без базы данных.
+// Используется в тестах и в режиме DEV_INMEMORY; данные не сохраняются между запусками.
+
+func NewInMemoryUserRepository() UserRepository {
+	return &inMemoryUserRepository{
+		byID:       make(map[uuid.UUID]*model.User),
+		byUsername: make(map[string]uuid.UUID),
+		byEmail:    make(map[string]uuid.UUID),
+	}
+}
+
+// Create сохраняет нового пользователя, заполняя ID, роль и дату создания, если они не заданы.
+
+func (r *inMemoryUserRepository) Create(ctx context.Context, user *model.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byUsername[user.Username]; ok {
+		return ErrAlreadyExists
+	}
+	if _, ok := r.byEmail[user.Email]; ok && user.Email != "" {
+		return ErrAlreadyExists
+	}
+	if user.ID == uuid.Nil {
+		user.ID = uuid.New()
+	}
+	if _, ok := r.byID[user.ID]; ok {
+		return ErrAlreadyExists
+	}
+	if user.Role == "" {
+		user.Role = model.RoleUser
+	}
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = time.Now()
+	}
+
+	stored := *user
+	r.byID[user.ID] = &stored
+	r.byUsername[user.Username] = user.ID
+	if user.Email != "" {
+		r.byEmail[user.Email] = user.ID
+	}
+	return nil
+}
+
+// GetByUsername возвращает копию пользователя с указанным именем.
+
+func (r *inMemoryUserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.byUsername[username]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	user := *r.byID[id]
+	return &user, nil
+}
+
+// GetByID возвращает копию пользователя с указанным ID.
+
+func (r *inMemoryUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.byID[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	user := *stored
+	return &user, nil
+}
+
+// GetByIDs возвращает копии найденных пользователей с указанными ID; как и запрос
+// WHERE id IN (...), возвращает каждого пользователя один раз.
+
+func (r *inMemoryUserRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]*model.User, 0, len(ids))
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if stored, ok := r.byID[id]; ok && !seen[id] {
+			seen[id] = true
+			user := *stored
+			users = append(users, &user)
+		}
+	}
+	return users, nil
+}
+
+// List возвращает копии пользователей страницы, удовлетворяющих фильтру, и их общее число.
+
+func (r *inMemoryUserRepository) List(ctx context.Context, filter model.UserFilter) ([]*model.User, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	// Фильтр проверяется так же, как при построении запроса к базе данных
+	if err := userFields.Validate(userFilterSpec(filter)); err != nil {
+		return nil, 0, fmt.Errorf("list users: %w", err)
+	}
+	users := r.filter(filter)
+	total := len(users)
+	users = users[min(filter.Offset, total):]
+	if filter.Limit > 0 && filter.Limit < len(users) {
+		users = users[:filter.Limit]
+	}
+	return users, total, nil
+}
+
+// ForEach вызывает fn для копий пользователей, удовлетворяющих фильтру. Список копируется
+// до обхода, поэтому fn может обращаться к репозиторию.
+
+func (r *inMemoryUserRepository) ForEach(ctx context.Context, filter model.UserFilter, fn func(*model.User) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := userFields.Validate(userFilterSpec(filter)); err != nil {
+		return fmt.Errorf("iterate users: %w", err)
+	}
+	users := r.filter(filter)
+	if filter.Limit > 0 && filter.Offset+filter.Limit < len(users) {
+		users = users[:filter.Offset+filter.Limit]
+	}
+	for _, user := range users[min(filter.Offset, len(users)):] {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filter возвращает копии пользователей, удовлетворяющих условиям фильтра, в порядке
+// сортировки, как userRepository: по полю сортировки, затем по ID.
+
+func (r *inMemoryUserRepository) filter(filter model.UserFilter) []*model.User {
+	r.mu.RLock()
+	users := make([]*model.User, 0, len(r.byID))
+	for _, stored := range r.byID {
+		if filter.CreatedFrom != nil && stored.CreatedAt.Before(*filter.CreatedFrom) {
+			continue
+		}
+		if filter.CreatedTo != nil && !stored.CreatedAt.Before(*filter.CreatedTo) {
+			continue
+		}
+		if !strings.HasPrefix(stored.Username, filter.UsernamePrefix) {
+			continue
+		}
+		user := *stored
+		users = append(users, &user)
+	}
+	r.mu.RUnlock()
+
+	slices.SortFunc(users, func(a, b *model.User) int {
+		var c int
+		if filter.SortBy == model.UserSortByUsername {
+			c = strings.Compare(a.Username, b.Username)
+		} else {
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		}
+		if c == 0 {
+			c = strings.Compare(a.ID.String(), b.ID.String())
+		}
+		if filter.SortDesc {
+			return -c
+		}
+		return c
+	})
+	return users
+}
+
+// GetByEmail возвращает копию пользователя с указанным email.
+
+func (r *inMemoryUserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.byEmail[email]
+	if !ok || email == "" {
+		return nil, ErrNotFound
+	}
+	user := *r.byID[id]
+	return &user, nil
+}
+
+// UpdateEmail устанавливает новый неподтвержденный email и токен его подтверждения.
+
+func (r *inMemoryUserRepository) UpdateEmail(ctx context.Context, id uuid.UUID, email, verificationHash string, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.byID[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if owner, ok := r.byEmail[email]; ok && owner != id {
+		return ErrAlreadyExists
+	}
+	delete(r.byEmail, user.Email)
+	r.byEmail[email] = id
+	user.Email = email
+	user.EmailVerified = false
+	user.EmailVerificationHash = verificationHash
+	user.EmailVerificationExpiresAt = expiresAt
+	return nil
+}
+
+// UpdatePassword заменяет хеш пароля пользователя.
+
+func (r *inMemoryUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.byID[id]
+	if !ok {
+		return ErrNotFound
+	}
+	user.PasswordHash = passwordHash
+	return nil
+}
+
+// ConfirmEmail отмечает email подтвержденным, если хеш токена совпадает.
+
+func (r *inMemoryUserRepository) ConfirmEmail(ctx context.Context, id uuid.UUID, verificationHash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.byID[id]
+	if !ok || user.EmailVerificationHash == "" || user.EmailVerificationHash != verificationHash {
+		return ErrNotFound
+	}
+	user.EmailVerified = true
+	user.EmailVerificationHash = ""
+	user.EmailVerificationExpiresAt = time.Time{}
+	return nil
+}
+
+// PurgeExpiredVerificationsBefore удаляет токены подтверждения email, истекшие раньше before.
+
+func (r *inMemoryUserRepository) PurgeExpiredVerificationsBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := 0
+	for _, user := range r.byID {
+		if purged == limit {
+			break
+		}
+		if !user.EmailVerificationExpiresAt.IsZero() && user.EmailVerificationExpiresAt.Before(before) {
+			user.EmailVerificationHash = ""
+			user.EmailVerificationExpiresAt = time.Time{}
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Delete удаляет пользователя вместе с записями индексов по имени и email.
+
+func (r *inMemoryUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.byID[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(r.byID, id)
+	delete(r.byUsername, user.Username)
+	if user.Email != "" {
+		delete(r.byEmail, user.Email)
+	}
+	return nil
+}
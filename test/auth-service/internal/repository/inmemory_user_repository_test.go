@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/model"
+)
+
+// Тест создания и получения пользователя: заполняются значения по умолчанию
+func TestInMemoryUserRepository_CreateAndGet(t *testing.T) {
+	repo := NewInMemoryUserRepository()
+	ctx := context.Background()
+
+	user := &model.User{Username: "alice", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(ctx, user))
+	assert.NotEqual(t, uuid.Nil, user.ID)
+	assert.Equal(t, model.RoleUser, user.Role)
+	assert.False(t, user.CreatedAt.IsZero())
+
+	byName, err := repo.GetByUsername(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, byName.ID)
+
+	byID, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", byID.Username)
+
+	// Изменение возвращенной копии не затрагивает хранимые данные
+	byID.Role = model.RoleAdmin
+	again, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleUser, again.Role)
+}
+
+// Тест пакетного получения: неизвестные ID пропускаются, повторяющиеся учитываются один раз
+func TestInMemoryUserRepository_GetByIDs(t *testing.T) {
+	repo := NewInMemoryUserRepository()
+	ctx := context.Background()
+
+	alice := &model.User{Username: "alice"}
+	bob := &model.User{Username: "bob"}
+	require.NoError(t, repo.Create(ctx, alice))
+	require.NoError(t, repo.Create(ctx, bob))
+
+	users, err := repo.GetByIDs(ctx, []uuid.UUID{bob.ID, uuid.New(), alice.ID, bob.ID})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "bob", users[0].Username)
+	assert.Equal(t, "alice", users[1].Username)
+
+	users, err = repo.GetByIDs(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+}
+
+// Тест ошибок: отсутствующий пользователь и занятое имя
+func TestInMemoryUserRepository_Errors(t *testing.T) {
+	repo := NewInMemoryUserRepository()
+	ctx := context.Background()
+
+	_, err := repo.GetByUsername(ctx, "nobody")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = repo.GetByID(ctx, uuid.New())
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, repo.Create(ctx, &model.User{Username: "alice"}))
+	err = repo.Create(ctx, &model.User{Username: "alice"})
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+}
+
+// Тест email: уникальность, смена email со сбросом подтверждения и одноразовое подтверждение
+func TestInMemoryUserRepository_Email(t *testing.T) {
+	repo := NewInMemoryUserRepository()
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	alice := &model.User{Username: "alice", Email: "alice@example.com"}
+	require.NoError(t, repo.Create(ctx, alice))
+	bob := &model.User{Username: "bob"}
+	require.NoError(t, repo.Create(ctx, bob))
+	assert.ErrorIs(t, repo.Create(ctx, &model.User{Username: "eve", Email: "alice@example.com"}), ErrAlreadyExists)
+
+	byEmail, err := repo.GetByEmail(ctx, "alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, byEmail.ID)
+	_, err = repo.GetByEmail(ctx, "")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.ErrorIs(t, repo.UpdateEmail(ctx, bob.ID, "alice@example.com", "hash", expiresAt), ErrAlreadyExists)
+	require.NoError(t, repo.UpdateEmail(ctx, bob.ID, "bob@example.com", "hash", expiresAt))
+	assert.ErrorIs(t, repo.UpdateEmail(ctx, uuid.New(), "new@example.com", "hash", expiresAt), ErrNotFound)
+
+	assert.ErrorIs(t, repo.ConfirmEmail(ctx, bob.ID, "other"), ErrNotFound)
+	require.NoError(t, repo.ConfirmEmail(ctx, bob.ID, "hash"))
+	assert.ErrorIs(t, repo.ConfirmEmail(ctx, bob.ID, "hash"), ErrNotFound)
+
+	stored, err := repo.GetByID(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "bob@example.com", stored.Email)
+	assert.True(t, stored.EmailVerified)
+	assert.Empty(t, stored.EmailVerificationHash)
+}
+
+// Тест удаления истекших токенов подтверждения email: действующие токены сохраняются
+func TestInMemoryUserRepository_PurgeExpiredVerificationsBefore(t *testing.T) {
+	repo := NewInMemoryUserRepository()
+	ctx := context.Background()
+	before := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	expired := &model.User{Username: "alice"}
+	valid := &model.User{Username: "bob"}
+	require.NoError(t, repo.Create(ctx, expired))
+	require.NoError(t, repo.Create(ctx, valid))
+	require.NoError(t, repo.UpdateEmail(ctx, expired.ID, "alice@example.com", "h1", before.Add(-time.Hour)))
+	require.NoError(t, repo.UpdateEmail(ctx, valid.ID, "bob@example.com", "h2", before.Add(time.Hour)))
+
+	n, err := repo.PurgeExpiredVerificationsBefore(ctx, before, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	stored, err := repo.GetByID(ctx, expired.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.EmailVerificationHash)
+	assert.Equal(t, "alice@example.com", stored.Email)
+	assert.False(t, stored.EmailVerified)
+	require.NoError(t, repo.ConfirmEmail(ctx, valid.ID, "h2"))
+}
+
+// Тест удаления пользователя: имя и email освобождаются, повторное удаление — ErrNotFound
+func TestInMemoryUserRepository_Delete(t *testing.T) {
+	repo := NewInMemoryUserRepository()
+	ctx := context.Background()
+
+	user := &model.User{Username: "alice", Email: "alice@example.com"}
+	require.NoError(t, repo.Create(ctx, user))
+	require.NoError(t, repo.Delete(ctx, user.ID))
+
+	_, err := repo.GetByID(ctx, user.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, user.ID), ErrNotFound)
+	require.NoError(t, repo.Create(ctx, &model.User{Username: "alice", Email: "alice@example.com"}))
+}
+
+// Тест списка пользователей: сочетания условий отбора, сортировка, пагинация и пустой результат
+func TestInMemoryUserRepository_List(t *testing.T) {
+	repo := NewInMemoryUserRepository()
+	ctx := context.Background()
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, username := range []string{"bob", "alice", "alex", "carol"} {
+		require.NoError(t, repo.Create(ctx, &model.User{Username: username, CreatedAt: start.Add(time.Duration(i) * time.Hour)}))
+	}
+	usernames := func(users []*model.User) []string {
+		names := make([]string, 0, len(users))
+		for _, user := range users {
+			names = append(names, user.Username)
+		}
+		return names
+	}
+	from, to := start.Add(time.Hour), start.Add(3*time.Hour)
+
+	tests := []struct {
+		name   string
+		filter model.UserFilter
+		want   []string
+		total  int
+	}{
+		{"по умолчанию по времени регистрации", model.UserFilter{}, []string{"bob", "alice", "alex", "carol"}, 4},
+		{"сначала новые", model.UserFilter{SortDesc: true}, []string{"carol", "alex", "alice", "bob"}, 4},
+		{"по имени", model.UserFilter{SortBy: model.UserSortByUsername}, []string{"alex", "alice", "bob", "carol"}, 4},
+		{"по префиксу имени", model.UserFilter{UsernamePrefix: "al"}, []string{"alice", "alex"}, 2},
+		{"по периоду регистрации", model.UserFilter{CreatedFrom: &from, CreatedTo: &to}, []string{"alice", "alex"}, 2},
+		{"префикс и период", model.UserFilter{UsernamePrefix: "al", CreatedFrom: &from, CreatedTo: &to, SortBy: model.UserSortByUsername}, []string{"alex", "alice"}, 2},
+		{"страница", model.UserFilter{SortBy: model.UserSortByUsername, Limit: 2, Offset: 1}, []string{"alice", "bob"}, 4},
+		{"за пределами списка", model.UserFilter{Offset: 10}, []string{}, 4},
+		{"пустой результат", model.UserFilter{UsernamePrefix: "zed"}, []string{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, total, err := repo.List(ctx, tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, usernames(users))
+			assert.Equal(t, tt.total, total)
+
+			var exported []*model.User
+			require.NoError(t, repo.ForEach(ctx, tt.filter, func(user *model.User) error {
+				exported = append(exported, user)
+				return nil
+			}))
+			assert.Equal(t, tt.want, usernames(exported))
+		})
+	}
+
+	// Ошибка fn прекращает обход
+	var visited int
+	err := repo.ForEach(ctx, model.UserFilter{}, func(*model.User) error {
+		visited++
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, 1, visited)
+
+	// Поле сортировки не из реестра отклоняется так же, как при запросе к базе данных
+	_, _, err = repo.List(ctx, model.UserFilter{SortBy: "password_hash"})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+	err = repo.ForEach(ctx, model.UserFilter{Limit: -1}, func(*model.User) error { return nil })
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
+
+// Тест страниц списка пользователей с одинаковым временем регистрации: порядок по ID
+// одинаков при повторных запросах, а страницы не пересекаются и не теряют пользователей
+func TestInMemoryUserRepository_ListTieBreak(t *testing.T) {
+	repo := NewInMemoryUserRepository()
+	ctx := context.Background()
+	createdAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, repo.Create(ctx, &model.User{Username: fmt.Sprintf("user%d", i), CreatedAt: createdAt}))
+	}
+
+	for _, desc := range []bool{false, true} {
+		all, total, err := repo.List(ctx, model.UserFilter{SortDesc: desc})
+		require.NoError(t, err)
+		require.Equal(t, 10, total)
+		assert.True(t, slices.IsSortedFunc(all, func(a, b *model.User) int {
+			if desc {
+				a, b = b, a
+			}
+			return bytes.Compare(a.ID[:], b.ID[:])
+		}))
+
+		var paged []*model.User
+		for offset := 0; offset < total; offset += 3 {
+			page, _, err := repo.List(ctx, model.UserFilter{SortDesc: desc, Limit: 3, Offset: offset})
+			require.NoError(t, err)
+			paged = append(paged, page...)
+		}
+		assert.Equal(t, all, paged)
+	}
+}
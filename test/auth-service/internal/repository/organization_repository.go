@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	"auth-service/internal/model"
+)
+
+// OrganizationRepository определяет интерфейс для работы с организациями и их участниками.
+// Пользователь состоит не более чем в одной организации: добавление участника, который уже
+// состоит в организации, возвращает ErrAlreadyExists.
+
+type OrganizationRepository interface {
+	// Create сохраняет организацию вместе с ее владельцем. Если владелец уже состоит
+	// в организации, возвращает ErrAlreadyExists, и организация не создается.
+	Create(ctx context.Context, org *model.Organization, owner *model.OrganizationMember) error
+	// GetMembership возвращает участие пользователя в организации или ErrNotFound,
+	// если пользователь не состоит ни в одной организации.
+	GetMembership(ctx context.Context, userID uuid.UUID) (*model.OrganizationMember, error)
+	// AddMember добавляет участника в существующую организацию.
+	AddMember(ctx context.Context, member *model.OrganizationMember) error
+	// DeleteByUser удаляет участие пользователя в организации и возвращает число удаленных записей.
+	DeleteByUser(ctx context.Context, userID uuid.UUID) (int, error)
+
+	// CreateInvite сохраняет приглашение в организацию.
+	CreateInvite(ctx context.Context, invite *model.OrganizationInvite) error
+	// ListInvites возвращает приглашения организации, от новых к старым; приглашения
+	// с одинаковым временем создания — по убыванию ID.
+	ListInvites(ctx context.Context, orgID uuid.UUID) ([]*model.OrganizationInvite, error)
+	// GetInviteByCodeHash возвращает приглашение по хешу кода или ErrNotFound.
+	GetInviteByCodeHash(ctx context.Context, codeHash string) (*model.OrganizationInvite, error)
+	// RevokeInvite отмечает приглашение организации отозванным в момент at. Уже отозванное
+	// приглашение не изменяется; для неизвестного или принятого возвращает ErrNotFound.
+	RevokeInvite(ctx context.Context, orgID, inviteID uuid.UUID, at time.Time) error
+	// AcceptInvite отмечает приглашение принятым участником member и добавляет его
+	// в организацию. Возвращает ErrNotFound, если приглашение уже принято или отозвано,
+	// и ErrAlreadyExists, если участник уже состоит в организации; в обоих случаях
+	// приглашение не изменяется.
+	AcceptInvite(ctx context.Context, invite *model.OrganizationInvite, member *model.OrganizationMember) error
+}
+
+// organizationRepository реализует интерфейс OrganizationRepository для работы с базой данных через bun.
+
+type organizationRepository struct {
+	db *bun.DB
+	queryTimeout
+}
+
+// NewOrganizationRepository создает новый экземпляр репозитория организаций.
+// Принимает подключение к базе данных через bun.DB и те же параметры, что NewUserRepository.
+
+func NewOrganizationRepository(db *bun.DB, opts ...Option) OrganizationRepository {
+	return &organizationRepository{db: db, queryTimeout: newQueryTimeout(opts)}
+}
+
+// Create сохраняет организацию и запись о ее владельце в одной транзакции. Уникальность
+// user_id в organization_members не дает создать организацию пользователю, который уже
+// состоит в другой.
+
+func (r *organizationRepository) Create(ctx context.Context, org *model.Organization, owner *model.OrganizationMember) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(org).Returning("id").Exec(ctx); err != nil {
+			return err
+		}
+		owner.OrgID = org.ID
+		_, err := tx.NewInsert().Model(owner).Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("create organization of user %s: %w", owner.UserID, mapError(ctx, err))
+	}
+	return nil
+}
+
+// GetMembership извлекает участие пользователя в организации.
+
+func (r *organizationRepository) GetMembership(ctx context.Context, userID uuid.UUID) (*model.OrganizationMember, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	member := new(model.OrganizationMember)
+	err := r.db.NewSelect().Model(member).Where("user_id = ?", userID).Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get membership of user %s: %w", userID, mapError(ctx, err))
+	}
+	return member, nil
+}
+
+// AddMember сохраняет участника организации.
+
+func (r *organizationRepository) AddMember(ctx context.Context, member *model.OrganizationMember) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.NewInsert().Model(member).Exec(ctx); err != nil {
+		return fmt.Errorf("add user %s to organization %s: %w", member.UserID, member.OrgID, mapError(ctx, err))
+	}
+	return nil
+}
+
+// DeleteByUser удаляет участие пользователя в организации.
+
+func (r *organizationRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewDelete().Model((*model.OrganizationMember)(nil)).Where("user_id = ?", userID).Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("delete membership of user %s: %w", userID, mapError(ctx, err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("delete membership of user %s: %w", userID, err)
+	}
+	return int(n), nil
+}
+
+// CreateInvite сохраняет приглашение в организацию.
+
+func (r *organizationRepository) CreateInvite(ctx context.Context, invite *model.OrganizationInvite) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.NewInsert().Model(invite).Returning("id").Exec(ctx); err != nil {
+		return fmt.Errorf("create invite to organization %s: %w", invite.OrgID, mapError(ctx, err))
+	}
+	return nil
+}
+
+// ListInvites извлекает приглашения организации, сортируя их по времени создания и ID.
+
+func (r *organizationRepository) ListInvites(ctx context.Context, orgID uuid.UUID) ([]*model.OrganizationInvite, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var invites []*model.OrganizationInvite
+	err := r.db.NewSelect().Model(&invites).Where("org_id = ?", orgID).Order("created_at DESC", "id DESC").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list invites of organization %s: %w", orgID, mapError(ctx, err))
+	}
+	return invites, nil
+}
+
+// GetInviteByCodeHash извлекает приглашение по хешу кода.
+
+func (r *organizationRepository) GetInviteByCodeHash(ctx context.Context, codeHash string) (*model.OrganizationInvite, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	invite := new(model.OrganizationInvite)
+	if err := r.db.NewSelect().Model(invite).Where("code_hash = ?", codeHash).Scan(ctx); err != nil {
+		return nil, fmt.Errorf("get invite: %w", mapError(ctx, err))
+	}
+	return invite, nil
+}
+
+// RevokeInvite отмечает непринятое приглашение отозванным. COALESCE сохраняет время
+// первого отзыва, поэтому повторный отзыв находит строку и не меняет ее.
+
+func (r *organizationRepository) RevokeInvite(ctx context.Context, orgID, inviteID uuid.UUID, at time.Time) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewUpdate().Model((*model.OrganizationInvite)(nil)).
+		Set("revoked_at = COALESCE(revoked_at, ?)", at).
+		Where("id = ?", inviteID).
+		Where("org_id = ?", orgID).
+		Where("accepted_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("revoke invite %s: %w", inviteID, mapError(ctx, err))
+	}
+	if err := checkAffected(res); err != nil {
+		return fmt.Errorf("revoke invite %s: %w", inviteID, err)
+	}
+	return nil
+}
+
+// AcceptInvite в одной транзакции отмечает приглашение принятым и добавляет участника.
+// Условие на accepted_at и revoked_at не дает принять приглашение дважды при одновременных
+// запросах: второй запрос не находит строку для обновления.
+
+func (r *organizationRepository) AcceptInvite(ctx context.Context, invite *model.OrganizationInvite, member *model.OrganizationMember) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewUpdate().Model((*model.OrganizationInvite)(nil)).
+			Set("accepted_by = ?", member.UserID).
+			Set("accepted_at = ?", member.CreatedAt).
+			Where("id = ?", invite.ID).
+			Where("accepted_at IS NULL").
+			Where("revoked_at IS NULL").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if err := checkAffected(res); err != nil {
+			return err
+		}
+		_, err = tx.NewInsert().Model(member).Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("accept invite %s by user %s: %w", invite.ID, member.UserID, mapError(ctx, err))
+	}
+	acceptedBy, acceptedAt := member.UserID, member.CreatedAt
+	invite.AcceptedBy, invite.AcceptedAt = &acceptedBy, &acceptedAt
+	return nil
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	"auth-service/internal/model"
+)
+
+// SessionRepository определяет интерфейс для работы с сеансами пользователей.
+// Методы получения и изменения возвращают ErrNotFound, если сеанс отсутствует
+// или не удовлетворяет условию изменения; остальные ошибки базы данных возвращаются
+// обернутыми с описанием операции.
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *model.Session) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Session, error)
+	GetByRefreshTokenHash(ctx context.Context, hash string) (*model.Session, error)
+	// ListActive возвращает неотозванные сеансы пользователя, не истекшие к моменту now,
+	// от последних использованных к давним; сеансы с одинаковым временем — по убыванию ID.
+	ListActive(ctx context.Context, userID uuid.UUID, now time.Time) ([]*model.Session, error)
+	// ListByUser возвращает все сеансы пользователя, включая отозванные и истекшие,
+	// от новых к старым; сеансы с одинаковым временем создания — по убыванию ID.
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*model.Session, error)
+	// Rotate сохраняет новые RefreshTokenHash, IP, LastUsedAt и ExpiresAt сеанса, если он
+	// не отозван и хеш его refresh-токена все еще равен oldHash; иначе возвращает ErrNotFound.
+	Rotate(ctx context.Context, session *model.Session, oldHash string) error
+	// Revoke отзывает неотозванный сеанс пользователя; чужой или уже отозванный сеанс — ErrNotFound.
+	Revoke(ctx context.Context, userID, id uuid.UUID, at time.Time) error
+	// RevokeAll отзывает все неотозванные сеансы пользователя, кроме exceptID,
+	// и возвращает их количество.
+	RevokeAll(ctx context.Context, userID, exceptID uuid.UUID, at time.Time) (int, error)
+	// PurgeExpiredBefore удаляет не более limit сеансов, истекших или отозванных раньше before,
+	// и возвращает их количество.
+	PurgeExpiredBefore(ctx context.Context, before time.Time, limit int) (int, error)
+	// DeleteByUser удаляет все сеансы пользователя и возвращает их количество.
+	DeleteByUser(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+// sessionRepository реализует интерфейс SessionRepository для работы с базой данных через bun.
+
+type sessionRepository struct {
+	db *bun.DB
+	queryTimeout
+}
+
+// NewSessionRepository создает новый экземпляр репозитория сеансов.
+// Принимает подключение к базе данных через bun.DB и те же параметры, что NewUserRepository.
+
+func NewSessionRepository(db *bun.DB, opts ...Option) SessionRepository {
+	return &sessionRepository{db: db, queryTimeout: newQueryTimeout(opts)}
+}
+
+// Create сохраняет новый сеанс в базу данных.
+
+func (r *sessionRepository) Create(ctx context.Context, session *model.Session) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.NewInsert().Model(session).Exec(ctx); err != nil {
+		return fmt.Errorf("create session: %w", mapError(ctx, err))
+	}
+	return nil
+}
+
+// GetByID извлекает сеанс из базы данных по его ID.
+
+func (r *sessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Session, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	session := new(model.Session)
+	err := r.db.NewSelect().Model(session).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get session %s: %w", id, mapError(ctx, err))
+	}
+	return session, nil
+}
+
+// GetByRefreshTokenHash извлекает сеанс из базы данных по хешу refresh-токена.
+
+func (r *sessionRepository) GetByRefreshTokenHash(ctx context.Context, hash string) (*model.Session, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	session := new(model.Session)
+	err := r.db.NewSelect().Model(session).Where("refresh_token_hash = ?", hash).Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get session by refresh token: %w", mapError(ctx, err))
+	}
+	return session, nil
+}
+
+// ListActive возвращает действующие сеансы пользователя.
+
+func (r *sessionRepository) ListActive(ctx context.Context, userID uuid.UUID, now time.Time) ([]*model.Session, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var sessions []*model.Session
+	err := r.db.NewSelect().Model(&sessions).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Where("expires_at > ?", now).
+		Order("last_used_at DESC", "id DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions of user %s: %w", userID, mapError(ctx, err))
+	}
+	return sessions, nil
+}
+
+// ListByUser возвращает все сеансы пользователя.
+
+func (r *sessionRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*model.Session, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var sessions []*model.Session
+	err := r.db.NewSelect().Model(&sessions).
+		Where("user_id = ?", userID).
+		Order("created_at DESC", "id DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list all sessions of user %s: %w", userID, mapError(ctx, err))
+	}
+	return sessions, nil
+}
+
+// Rotate заменяет refresh-токен сеанса одним запросом с проверкой прежнего хеша,
+// поэтому один и тот же refresh-токен не может быть использован дважды.
+
+func (r *sessionRepository) Rotate(ctx context.Context, session *model.Session, oldHash string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewUpdate().Model(session).
+		Column("refresh_token_hash", "ip", "last_used_at", "expires_at").
+		WherePK().
+		Where("refresh_token_hash = ?", oldHash).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("rotate session %s: %w", session.ID, mapError(ctx, err))
+	}
+	if err := checkAffected(res); err != nil {
+		return fmt.Errorf("rotate session %s: %w", session.ID, err)
+	}
+	return nil
+}
+
+// Revoke отмечает сеанс пользователя отозванным.
+
+func (r *sessionRepository) Revoke(ctx context.Context, userID, id uuid.UUID, at time.Time) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewUpdate().Model((*model.Session)(nil)).
+		Set("revoked_at = ?", at).
+		Where("id = ?", id).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("revoke session %s: %w", id, mapError(ctx, err))
+	}
+	if err := checkAffected(res); err != nil {
+		return fmt.Errorf("revoke session %s: %w", id, err)
+	}
+	return nil
+}
+
+// RevokeAll отмечает отозванными все сеансы пользователя, кроме exceptID.
+
+func (r *sessionRepository) RevokeAll(ctx context.Context, userID, exceptID uuid.UUID, at time.Time) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewUpdate().Model((*model.Session)(nil)).
+		Set("revoked_at = ?", at).
+		Where("user_id = ?", userID).
+		Where("id <> ?", exceptID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("revoke sessions of user %s: %w", userID, mapError(ctx, err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("revoke sessions of user %s: %w", userID, err)
+	}
+	return int(n), nil
+}
+
+// PurgeExpiredBefore удаляет пакет давно истекших и отозванных сеансов. Размер пакета
+// ограничивает время удаления и число строк, блокируемых одним запросом.
+
+func (r *sessionRepository) PurgeExpiredBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	expired := r.db.NewSelect().Model((*model.Session)(nil)).
+		Column("id").
+		Where("expires_at < ?", before).
+		WhereOr("revoked_at < ?", before).
+		Limit(limit)
+	res, err := r.db.NewDelete().Model((*model.Session)(nil)).
+		Where("id IN (?)", expired).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("purge expired sessions: %w", mapError(ctx, err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("purge expired sessions: %w", err)
+	}
+	return int(n), nil
+}
+
+// DeleteByUser удаляет все сеансы пользователя.
+
+func (r *sessionRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewDelete().Model((*model.Session)(nil)).Where("user_id = ?", userID).Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("delete sessions of user %s: %w", userID, mapError(ctx, err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("delete sessions of user %s: %w", userID, err)
+	}
+	return int(n), nil
+}
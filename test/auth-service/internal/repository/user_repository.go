@@ -1,64 +1,336 @@
-package repository
-
-import (
-	"auth-service/internal/model"
-	"context"
-
-	"github.com/google/uuid"
-	"github.com/uptrace/bun"
-)
-
-// UserRepository определяет интерфейс для работы с данными пользователей.
-// Предоставляет методы для создания и получения пользователей из базы данных.
-
-type UserRepository interface {
-	Create(ctx context.Context, user *model.User) error
-	GetByUsername(ctx context.Context, username string) (*model.User, error)
-	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
-}
-
-// userRepository реализует интерфейс UserRepository для работы с базой данных через bun.
-// Использует контекст для управления временем выполнения операций.
-
-type userRepository struct {
-	db *bun.DB
-}
-
-// NewUserRepository создает новый экземпляр репозитория пользователей.
-// Принимает подключение к базе данных через bun.DB.
-
-func NewUserRepository(db *bun.DB) UserRepository {
-	return &userRepository{db: db}
-}
-
-// Create сохраняет нового пользователя в базу данных.
-// Использует контекст для отмены операции при необходимости.
-
-func (r *userRepository) Create(ctx context.Context, user *model.User) error {
-	_, err := r.db.NewInsert().Model(user).Exec(ctx)
-	return err
-}
-
-// GetByUsername извлекает пользователя из базы данных по его имени.
-// Использует контекст для отмены операции при необходимости.
-
-func (r *userRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
-	user := new(model.User)
-	err := r.db.NewSelect().Model(user).Where("username = ?", username).Scan(ctx)
-	if err != nil {
-		return nil, err
-	}
-	return user, nil
-}
-
-// GetByID извлекает пользователя из базы данных по его ID.
-// Использует контекст для отмены операции при необходимости.
-
-func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
-	user := new(model.User)
-	err := r.db.NewSelect().Model(user).Where("id = ?", id).Scan(ctx)
-	if err != nil {
-		return nil, err
-	}
-	return user, nil
-}
+package repository
+
+import (
+	"auth-service/internal/model"
+	"common/querybuilder"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// UserRepository определяет интерфейс для работы с данными пользователей.
+// Предоставляет методы для создания и получения пользователей из базы данных.
+// Методы получения и изменения возвращают ErrNotFound, если пользователь отсутствует,
+// Create и UpdateEmail — ErrAlreadyExists, если имя пользователя или email заняты;
+// остальные ошибки базы данных возвращаются обернутыми с описанием операции.
+
+type UserRepository interface {
+	Create(ctx context.Context, user *model.User) error
+	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	// GetByIDs возвращает найденных пользователей из ids; неизвестные ID пропускаются.
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*model.User, error)
+	// List возвращает страницу пользователей, удовлетворяющих фильтру, и их общее число.
+	// Пользователи с одинаковым значением поля сортировки упорядочиваются по ID в том же
+	// направлении, поэтому порядок одинаков при повторных запросах и страницы не пересекаются.
+	List(ctx context.Context, filter model.UserFilter) ([]*model.User, int, error)
+	// ForEach вызывает fn для каждого пользователя, удовлетворяющего фильтру, в порядке
+	// сортировки, не загружая весь список в память. Ошибка fn прерывает обход и возвращается.
+	// Время обхода не ограничивается DefaultQueryTimeout: его ограничивает контекст вызывающего.
+	ForEach(ctx context.Context, filter model.UserFilter, fn func(*model.User) error) error
+	// UpdateEmail устанавливает новый неподтвержденный email и токен его подтверждения.
+	UpdateEmail(ctx context.Context, id uuid.UUID, email, verificationHash string, expiresAt time.Time) error
+	// ConfirmEmail отмечает email подтвержденным и удаляет токен подтверждения, если хеш
+	// токена пользователя совпадает с verificationHash; иначе возвращает ErrNotFound.
+	ConfirmEmail(ctx context.Context, id uuid.UUID, verificationHash string) error
+	// UpdatePassword заменяет хеш пароля пользователя.
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	// PurgeExpiredVerificationsBefore удаляет токены подтверждения email, истекшие раньше before,
+	// не более чем у limit пользователей и возвращает количество таких пользователей.
+	PurgeExpiredVerificationsBefore(ctx context.Context, before time.Time, limit int) (int, error)
+	// Delete удаляет пользователя; отсутствующий пользователь — ErrNotFound.
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// userRepository реализует интерфейс UserRepository для работы с базой данных через bun.
+// Использует контекст для управления временем выполнения операций.
+
+type userRepository struct {
+	db *bun.DB
+	queryTimeout
+}
+
+// NewUserRepository создает новый экземпляр репозитория пользователей.
+// Принимает подключение к базе данных через bun.DB; по умолчанию время выполнения
+// каждого запроса ограничено DefaultQueryTimeout.
+
+func NewUserRepository(db *bun.DB, opts ...Option) UserRepository {
+	return &userRepository{db: db, queryTimeout: newQueryTimeout(opts)}
+}
+
+// Create сохраняет нового пользователя в базу данных.
+// Использует контекст для отмены операции при необходимости.
+
+func (r *userRepository) Create(ctx context.Context, user *model.User) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.NewInsert().Model(user).Exec(ctx); err != nil {
+		return fmt.Errorf("create user: %w", mapError(ctx, err))
+	}
+	return nil
+}
+
+// GetByUsername извлекает пользователя из базы данных по его имени.
+// Использует контекст для отмены операции при необходимости.
+
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	user := new(model.User)
+	err := r.db.NewSelect().Model(user).Where("username = ?", username).Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get user by username: %w", mapError(ctx, err))
+	}
+	return user, nil
+}
+
+// GetByID извлекает пользователя из базы данных по его ID.
+// Использует контекст для отмены операции при необходимости.
+
+func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	user := new(model.User)
+	err := r.db.NewSelect().Model(user).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get user %s: %w", id, mapError(ctx, err))
+	}
+	return user, nil
+}
+
+// GetByIDs извлекает из базы данных пользователей с указанными ID одним запросом.
+
+func (r *userRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*model.User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var users []*model.User
+	err := r.db.NewSelect().Model(&users).Where("id IN (?)", bun.In(ids)).Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get users: %w", mapError(ctx, err))
+	}
+	return users, nil
+}
+
+// userFields — поля пользователя, по которым разрешены отбор и сортировка списка.
+// Ключи совпадают со значениями UserFilter.SortBy.
+
+var userFields = querybuilder.NewFields(map[string]querybuilder.Field{
+	"id":                      {Column: "id", Sortable: true},
+	model.UserSortByCreatedAt: {Column: "created_at", Ops: querybuilder.Ordered, Sortable: true},
+	model.UserSortByUsername:  {Column: "username", Ops: []querybuilder.Op{querybuilder.Eq, querybuilder.Prefix}, Sortable: true},
+})
+
+// List получает страницу пользователей, удовлетворяющих фильтру, и их общее число одним запросом.
+
+func (r *userRepository) List(ctx context.Context, filter model.UserFilter) ([]*model.User, int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var users []*model.User
+	q, err := applyUserFilter(r.db.NewSelect().Model(&users), filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list users: %w", err)
+	}
+	total, err := q.ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list users: %w", mapError(ctx, err))
+	}
+	return users, total, nil
+}
+
+// ForEach читает пользователей, удовлетворяющих фильтру, построчно из одного запроса.
+
+func (r *userRepository) ForEach(ctx context.Context, filter model.UserFilter, fn func(*model.User) error) error {
+	q, err := applyUserFilter(r.db.NewSelect().Model((*model.User)(nil)), filter)
+	if err != nil {
+		return fmt.Errorf("iterate users: %w", err)
+	}
+	rows, err := q.Rows(ctx)
+	if err != nil {
+		return fmt.Errorf("iterate users: %w", mapError(ctx, err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		user := new(model.User)
+		if err := r.db.ScanRow(ctx, rows, user); err != nil {
+			return fmt.Errorf("scan user: %w", mapError(ctx, err))
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate users: %w", mapError(ctx, err))
+	}
+	return nil
+}
+
+// userFilterSpec переводит фильтр списка пользователей в описание отбора для userFields.
+// При равных значениях поля сортировки пользователи упорядочиваются по ID, чтобы страницы
+// не пересекались; пустое поле сортировки означает сортировку по времени создания.
+
+func userFilterSpec(filter model.UserFilter) querybuilder.FilterSpec {
+	var spec querybuilder.FilterSpec
+	if filter.CreatedFrom != nil {
+		spec.Conditions = append(spec.Conditions, querybuilder.Where(model.UserSortByCreatedAt, querybuilder.Gte, *filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		spec.Conditions = append(spec.Conditions, querybuilder.Where(model.UserSortByCreatedAt, querybuilder.Lt, *filter.CreatedTo))
+	}
+	if filter.UsernamePrefix != "" {
+		spec.Conditions = append(spec.Conditions, querybuilder.Where(model.UserSortByUsername, querybuilder.Prefix, filter.UsernamePrefix))
+	}
+
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = model.UserSortByCreatedAt
+	}
+	spec.Sort = []querybuilder.Sort{{Field: sortBy, Desc: filter.SortDesc}, {Field: "id", Desc: filter.SortDesc}}
+	spec.Limit, spec.Offset = filter.Limit, filter.Offset
+	return spec
+}
+
+// applyUserFilter добавляет к запросу условия отбора, сортировку и пагинацию из фильтра.
+// Для поля сортировки не из реестра userFields возвращается ошибка, оборачивающая ErrInvalidFilter.
+
+func applyUserFilter(q *bun.SelectQuery, filter model.UserFilter) (*bun.SelectQuery, error) {
+	return userFields.Apply(q, userFilterSpec(filter))
+}
+
+// GetByEmail извлекает пользователя из базы данных по email.
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	user := new(model.User)
+	err := r.db.NewSelect().Model(user).Where("email = ?", email).Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get user by email: %w", mapError(ctx, err))
+	}
+	return user, nil
+}
+
+// UpdateEmail устанавливает пользователю новый email, сбрасывает признак подтверждения
+// и сохраняет хеш токена подтверждения со сроком действия.
+
+func (r *userRepository) UpdateEmail(ctx context.Context, id uuid.UUID, email, verificationHash string, expiresAt time.Time) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewUpdate().Model((*model.User)(nil)).
+		Set("email = ?", email).
+		Set("email_verified = FALSE").
+		Set("email_verification_hash = ?", verificationHash).
+		Set("email_verification_expires_at = ?", expiresAt).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("update email of user %s: %w", id, mapError(ctx, err))
+	}
+	if err := checkAffected(res); err != nil {
+		return fmt.Errorf("update email of user %s: %w", id, err)
+	}
+	return nil
+}
+
+// UpdatePassword заменяет хеш пароля пользователя, например после перехеширования
+// с новыми параметрами.
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewUpdate().Model((*model.User)(nil)).
+		Set("password_hash = ?", passwordHash).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("update password of user %s: %w", id, mapError(ctx, err))
+	}
+	if err := checkAffected(res); err != nil {
+		return fmt.Errorf("update password of user %s: %w", id, err)
+	}
+	return nil
+}
+
+// ConfirmEmail отмечает email подтвержденным одним запросом с проверкой хеша токена,
+// поэтому один и тот же токен не может быть использован дважды.
+
+func (r *userRepository) ConfirmEmail(ctx context.Context, id uuid.UUID, verificationHash string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewUpdate().Model((*model.User)(nil)).
+		Set("email_verified = TRUE").
+		Set("email_verification_hash = NULL").
+		Set("email_verification_expires_at = NULL").
+		Where("id = ?", id).
+		Where("email_verification_hash = ?", verificationHash).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("confirm email of user %s: %w", id, mapError(ctx, err))
+	}
+	if err := checkAffected(res); err != nil {
+		return fmt.Errorf("confirm email of user %s: %w", id, err)
+	}
+	return nil
+}
+
+// PurgeExpiredVerificationsBefore удаляет пакет давно истекших токенов подтверждения email.
+// Email остается неподтвержденным; для подтверждения пользователь запрашивает новый токен.
+
+func (r *userRepository) PurgeExpiredVerificationsBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	expired := r.db.NewSelect().Model((*model.User)(nil)).
+		Column("id").
+		Where("email_verification_expires_at < ?", before).
+		Limit(limit)
+	res, err := r.db.NewUpdate().Model((*model.User)(nil)).
+		Set("email_verification_hash = NULL").
+		Set("email_verification_expires_at = NULL").
+		Where("id IN (?)", expired).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("purge expired email verifications: %w", mapError(ctx, err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("purge expired email verifications: %w", err)
+	}
+	return int(n), nil
+}
+
+// Delete удаляет пользователя. Сеансы и известные устройства пользователя в базе данных
+// удаляются вместе с ним (ON DELETE CASCADE).
+
+func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewDelete().Model((*model.User)(nil)).Where("id = ?", id).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("delete user %s: %w", id, mapError(ctx, err))
+	}
+	if err := checkAffected(res); err != nil {
+		return fmt.Errorf("delete user %s: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+// Package retention периодически удаляет из базы данных сервиса аутентификации записи,
+// срок хранения которых истек: давно истекшие или отозванные сеансы с их refresh-токенами
+// и истекшие токены подтверждения email.
+//
+// Записи удаляются пакетами с паузой между ними, чтобы не блокировать таблицы надолго.
+// Удаление идемпотентно, поэтому одновременный запуск в нескольких экземплярах сервиса
+// безопасен: экземпляры лишь делят между собой удаляемые строки.
+package retention
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"time"
+
+	"auth-service/internal/repository"
+	"common/clock"
+)
+
+// Параметры удаления по умолчанию
+const (
+	DefaultInterval   = time.Hour
+	DefaultBatchSize  = 1000
+	DefaultBatchPause = 100 * time.Millisecond
+)
+
+// Категории удаляемых записей; используются в журнале и в именах показателей
+const (
+	CategorySessions           = "sessions"
+	CategoryEmailVerifications = "email_verifications"
+)
+
+// stats — показатели удаления в /debug/vars: <категория>_purged_total — всего удалено записей,
+// <категория>_last_run — удалено последним запуском.
+var stats = expvar.NewMap("retention")
+
+// Config задает удаление устаревших записей.
+type Config struct {
+	// Period — срок хранения: удаляются записи, истекшие или отозванные раньше чем Period назад.
+	Period time.Duration
+	// Interval — период запуска; 0 означает DefaultInterval.
+	Interval time.Duration
+	// BatchSize — число записей, удаляемых одним запросом; 0 означает DefaultBatchSize.
+	BatchSize int
+	// BatchPause — пауза между пакетами; 0 означает DefaultBatchPause.
+	BatchPause time.Duration
+	// Clock — источник текущего времени; nil означает системное время.
+	Clock clock.Clock
+}
+
+// category — вид удаляемых записей и функция удаления одного пакета.
+type category struct {
+	name  string
+	purge func(ctx context.Context, before time.Time, limit int) (int, error)
+}
+
+// Purger удаляет устаревшие записи по расписанию.
+type Purger struct {
+	cfg        Config
+	categories []category
+}
+
+// New создает Purger для сеансов sessions и токенов подтверждения email пользователей users.
+func New(sessions repository.SessionRepository, users repository.UserRepository, cfg Config) *Purger {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.BatchPause <= 0 {
+		cfg.BatchPause = DefaultBatchPause
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clock.Real
+	}
+	return &Purger{cfg: cfg, categories: []category{
+		{name: CategorySessions, purge: sessions.PurgeExpiredBefore},
+		{name: CategoryEmailVerifications, purge: users.PurgeExpiredVerificationsBefore},
+	}}
+}
+
+// Run удаляет устаревшие записи каждые Config.Interval и блокируется до отмены ctx.
+// Отмена прерывает текущий запуск между пакетами; Run возвращается после его завершения.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if ctx.Err() != nil {
+				return
+			}
+			if _, err := p.PurgeOnce(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("retention: purge failed: %v", err)
+			}
+		}
+	}
+}
+
+// PurgeOnce удаляет устаревшие записи всех категорий и возвращает число удаленных записей
+// по категориям. Ошибка в одной категории не мешает удалению записей остальных.
+func (p *Purger) PurgeOnce(ctx context.Context) (map[string]int, error) {
+	before := p.cfg.Clock.Now().Add(-p.cfg.Period)
+	purged := make(map[string]int, len(p.categories))
+	var firstErr error
+	for _, c := range p.categories {
+		n, err := p.purge(ctx, c, before)
+		purged[c.name] = n
+
+		lastRun := new(expvar.Int)
+		lastRun.Set(int64(n))
+		stats.Set(c.name+"_last_run", lastRun)
+		stats.Add(c.name+"_purged_total", int64(n))
+		if n > 0 {
+			log.Printf("retention: purged %d %s older than %s", n, c.name, before.Format(time.RFC3339))
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return purged, firstErr
+}
+
+// purge удаляет записи категории пакетами, пока пакет заполняется целиком.
+func (p *Purger) purge(ctx context.Context, c category, before time.Time) (int, error) {
+	total := 0
+	for {
+		n, err := c.purge(ctx, before, p.cfg.BatchSize)
+		total += n
+		if err != nil || n < p.cfg.BatchSize {
+			return total, err
+		}
+		select {
+		case <-ctx.Done():
+			return total, ctx.Err()
+		case <-time.After(p.cfg.BatchPause):
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"common/clock"
+)
+
+// Тест удаления: записи старше срока хранения удаляются пакетами, показатели обновляются
+func TestPurgeOnce(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	sessions := repository.NewInMemorySessionRepository()
+	users := repository.NewInMemoryUserRepository()
+
+	for i := range 5 {
+		require.NoError(t, sessions.Create(ctx, &model.Session{
+			UserID:           uuid.New(),
+			RefreshTokenHash: uuid.NewString(),
+			ExpiresAt:        now.Add(-48*time.Hour + time.Duration(i)*20*time.Hour),
+		}))
+	}
+	user := &model.User{Username: "alice"}
+	require.NoError(t, users.Create(ctx, user))
+	require.NoError(t, users.UpdateEmail(ctx, user.ID, "alice@example.com", "hash", now.Add(-48*time.Hour)))
+
+	purger := New(sessions, users, Config{
+		Period:     24 * time.Hour,
+		BatchSize:  1,
+		BatchPause: time.Millisecond,
+		Clock:      clock.NewFake(now),
+	})
+
+	purged, err := purger.PurgeOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{CategorySessions: 2, CategoryEmailVerifications: 1}, purged)
+	assert.Equal(t, "2", stats.Get(CategorySessions+"_last_run").String())
+
+	// Повторный запуск ничего не удаляет
+	purged, err = purger.PurgeOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{CategorySessions: 0, CategoryEmailVerifications: 0}, purged)
+	assert.Equal(t, "0", stats.Get(CategorySessions+"_last_run").String())
+}
+
+// Тест отмены: удаление прекращается между пакетами и возвращает ошибку контекста
+func TestPurgeOnce_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	purger := New(repository.NewInMemorySessionRepository(), repository.NewInMemoryUserRepository(), Config{Period: time.Hour})
+
+	_, err := purger.PurgeOnce(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
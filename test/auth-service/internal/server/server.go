@@ -0,0 +1,207 @@
+// Package server собирает gRPC-сервер сервиса аутентификации: перехватчики,
+// обработчик AuthService, проверку состояния и рефлексию.
+package server
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"log"
+	"path"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	pb "api/auth"
+	"auth-service/internal/faults"
+	"auth-service/internal/handler"
+	"auth-service/internal/internalauth"
+	"auth-service/internal/service"
+	"auth-service/internal/validation"
+	"common/slo"
+)
+
+// Config содержит параметры gRPC-сервера.
+type Config struct {
+	// InternalTokens — секреты внутренних сервисов, с которыми принимаются вызовы
+	// (текущий и, во время смены, предыдущий). Если все пустые, проверка отключается.
+	InternalTokens []string
+	// Health — сервер проверки состояния; nil означает новый сервер в состоянии SERVING.
+	// Передается, чтобы при остановке перевести его в NOT_SERVING.
+	Health *health.Server
+	// MaxRecvMsgSize и MaxSendMsgSize ограничивают размер принимаемого и отправляемого
+	// сообщения в байтах; сообщение больше предела отклоняется с codes.ResourceExhausted.
+	// 0 — значения gRPC по умолчанию (4 МиБ на прием, без ограничения на отправку).
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+	// MaxConcurrentStreams ограничивает число одновременных вызовов в одном соединении;
+	// 0 — без ограничения.
+	MaxConcurrentStreams uint32
+	// KeepaliveMinTime — минимальный интервал keepalive-пингов клиента: соединение клиента,
+	// пингующего чаще, закрывается. KeepalivePermitWithoutStream разрешает пинги без активных
+	// вызовов. 0 — политика gRPC по умолчанию (5 минут, без пингов вне вызовов).
+	KeepaliveMinTime             time.Duration
+	KeepalivePermitWithoutStream bool
+	// ConnectionTimeout ограничивает установку соединения; 0 — 120 секунд, как в gRPC.
+	ConnectionTimeout time.Duration
+	// DefaultTimeout ограничивает время выполнения вызова, для метода которого нет значения
+	// в MethodTimeouts. MethodTimeouts задает ограничение по короткому имени метода
+	// (например, "ValidateToken"). 0 — без ограничения.
+	DefaultTimeout time.Duration
+	MethodTimeouts map[string]time.Duration
+	// Faults вносит сбои в вызовы для проверки устойчивости клиентов; nil вне сред
+	// разработки и тестирования.
+	Faults *faults.Injector
+	// SLO учитывает вызовы в целях уровня обслуживания; nil, если цели не заданы.
+	SLO *slo.Registry
+}
+
+// DefaultMaxMsgSize — предел размера сообщения, который main.go задает по умолчанию
+// для MaxRecvMsgSize и MaxSendMsgSize.
+const DefaultMaxMsgSize = 4 << 20
+
+// DefaultTimeout — ограничение времени вызова, которое main.go задает по умолчанию
+// для методов без собственного значения.
+const DefaultTimeout = 5 * time.Second
+
+// DefaultMethodTimeouts — ограничения времени вызова, отличные от DefaultTimeout: проверка токена
+// должна отвечать быстро, а регистрация и вход дольше из-за хеширования пароля. Вход через
+// OIDC может ждать загрузки ключей провайдера.
+var DefaultMethodTimeouts = map[string]time.Duration{
+	"ValidateToken":     2 * time.Second,
+	"Register":          10 * time.Second,
+	"Login":             10 * time.Second,
+	"VerifyPassword":    10 * time.Second,
+	"ExchangeOIDCToken": 10 * time.Second,
+}
+
+// deadlineStats — число вызовов в /debug/vars, завершившихся codes.DeadlineExceeded,
+// по полному имени метода. Рост показателя указывает на системное замедление.
+var deadlineStats = expvar.NewMap("grpc_deadline_exceeded")
+
+// New создает gRPC-сервер сервиса аутентификации с зарегистрированными сервисами.
+func New(authService service.AuthService, cfg Config) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{contextInterceptor}
+	// Цели уровня обслуживания учитывают вызовы вместе с превышением срока и внесенными сбоями
+	if cfg.SLO != nil {
+		interceptors = append(interceptors, cfg.SLO.UnaryServerInterceptor())
+	}
+	interceptors = append(interceptors, loggingInterceptor, timeoutInterceptor(cfg.DefaultTimeout, cfg.MethodTimeouts))
+	// Задержки, внесенные перехватчиком сбоев, учитываются в сроке вызова
+	if cfg.Faults != nil {
+		interceptors = append(interceptors, cfg.Faults.UnaryServerInterceptor())
+	}
+
+	// Потоковые вызовы (ExportUsers, StreamUsers) не ограничиваются по времени: выгрузка длится,
+	// пока клиент читает поток
+	streamInterceptors := []grpc.StreamServerInterceptor{streamLoggingInterceptor}
+
+	// Вызовы принимаются только от внутренних сервисов, знающих секрет
+	for _, token := range cfg.InternalTokens {
+		if token != "" {
+			interceptors = append(interceptors,
+				internalauth.UnaryServerInterceptor(cfg.InternalTokens, internalauth.DefaultAllowedMethods))
+			streamInterceptors = append(streamInterceptors,
+				internalauth.StreamServerInterceptor(cfg.InternalTokens, internalauth.DefaultAllowedMethods))
+			break
+		}
+	}
+	// Некорректные запросы отклоняются до вызова обработчика
+	interceptors = append(interceptors, validation.UnaryServerInterceptor)
+	opts := append(serverOptions(cfg, interceptors), grpc.ChainStreamInterceptor(streamInterceptors...))
+	server := grpc.NewServer(opts...)
+
+	// Регистрируем рефлексию и проверку состояния для gRPC
+	reflection.Register(server)
+	healthServer := cfg.Health
+	if healthServer == nil {
+		healthServer = health.NewServer()
+	}
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	pb.RegisterAuthServiceServer(server, handler.NewAuthHandler(authService))
+	return server
+}
+
+// serverOptions собирает параметры gRPC-сервера; нулевые значения cfg не меняют
+// значений gRPC по умолчанию.
+func serverOptions(cfg Config, interceptors []grpc.UnaryServerInterceptor) []grpc.ServerOption {
+	opts := []grpc.ServerOption{grpc.ChainUnaryInterceptor(interceptors...)}
+	if cfg.MaxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSize))
+	}
+	if cfg.MaxSendMsgSize > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(cfg.MaxSendMsgSize))
+	}
+	if cfg.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(cfg.MaxConcurrentStreams))
+	}
+	if cfg.KeepaliveMinTime > 0 {
+		opts = append(opts, grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cfg.KeepaliveMinTime,
+			PermitWithoutStream: cfg.KeepalivePermitWithoutStream,
+		}))
+	}
+	if cfg.ConnectionTimeout > 0 {
+		opts = append(opts, grpc.ConnectionTimeout(cfg.ConnectionTimeout))
+	}
+	return opts
+}
+
+// contextInterceptor отклоняет вызовы, контекст которых уже отменен.
+func contextInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// timeoutInterceptor возвращает перехватчик, ограничивающий время вызова значением из methods
+// для его метода или defaultTimeout; 0 — без ограничения. Ошибка вызова, не уложившегося
+// в отведенное время, заменяется на codes.DeadlineExceeded: запросы к базе данных отменяются
+// вместе с контекстом, и обработчик иначе вернул бы codes.Internal.
+func timeoutInterceptor(defaultTimeout time.Duration, methods map[string]time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		timeout, ok := methods[path.Base(info.FullMethod)]
+		if !ok {
+			timeout = defaultTimeout
+		}
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = status.Error(codes.DeadlineExceeded, "request timed out")
+		}
+		if status.Code(err) == codes.DeadlineExceeded {
+			deadlineStats.Add(info.FullMethod, 1)
+		}
+		return resp, err
+	}
+}
+
+// loggingInterceptor логирует каждый унарный вызов: метод, код ответа и время выполнения.
+// Вызовы через HTTP-шлюз также проходят через этот перехватчик.
+func loggingInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	log.Printf("%s %s %s", info.FullMethod, status.Code(err), time.Since(start))
+	return resp, err
+}
+
+// streamLoggingInterceptor логирует каждый потоковый вызов так же, как loggingInterceptor.
+func streamLoggingInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	log.Printf("%s %s %s", info.FullMethod, status.Code(err), time.Since(start))
+	return err
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"expvar"
+	"net"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "api/auth"
+	"auth-service/internal/internalauth"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"common/buildinfo"
+)
+
+// startServer запускает собранный сервер с репозиторием в памяти поверх bufconn
+// и возвращает соединение с ним.
+
+func startServer(t *testing.T, cfg Config) *grpc.ClientConn {
+	authService := service.NewAuthService(repository.NewInMemoryUserRepository(), "test-key")
+	lis := bufconn.Listen(1024 * 1024)
+	server := New(authService, cfg)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// TestServer_Smoke проверяет регистрацию и проверку токена через собранный сервер
+// без секрета внутренних сервисов.
+
+func TestServer_Smoke(t *testing.T) {
+	client := pb.NewAuthServiceClient(startServer(t, Config{}))
+
+	reg, err := client.Register(context.Background(), &pb.RegisterRequest{Username: "user", Password: "password"})
+	require.NoError(t, err)
+
+	resp, err := client.ValidateToken(context.Background(), &pb.ValidateTokenRequest{Token: reg.Token})
+	require.NoError(t, err)
+	assert.True(t, resp.Valid)
+	assert.Equal(t, reg.UserId, resp.UserId)
+}
+
+// TestServer_InternalToken проверяет, что при заданном секрете вызовы без него отклоняются,
+// а проверка состояния доступна.
+
+func TestServer_InternalToken(t *testing.T) {
+	conn := startServer(t, Config{InternalTokens: []string{"secret", ""}})
+	client := pb.NewAuthServiceClient(conn)
+
+	_, err := client.ValidateToken(context.Background(), &pb.ValidateTokenRequest{Token: "jwt"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), internalauth.MetadataKey, "secret")
+	resp, err := client.ValidateToken(ctx, &pb.ValidateTokenRequest{Token: "jwt"})
+	require.NoError(t, err)
+	assert.False(t, resp.Valid)
+
+	health, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, health.Status)
+}
+
+// TestServer_GetVersion проверяет, что сведения о сборке доступны без секрета внутренних
+// сервисов и совпадают со значениями, заданными при сборке (по умолчанию dev).
+
+func TestServer_GetVersion(t *testing.T) {
+	client := pb.NewAuthServiceClient(startServer(t, Config{InternalTokens: []string{"secret"}}))
+
+	resp, err := client.GetVersion(context.Background(), &pb.GetVersionRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "dev", resp.Version)
+	assert.Equal(t, "dev", resp.Commit)
+	assert.Equal(t, "dev", resp.BuildDate)
+	assert.Equal(t, runtime.Version(), resp.GoVersion)
+
+	version, commit := buildinfo.Version, buildinfo.Commit
+	t.Cleanup(func() { buildinfo.Version, buildinfo.Commit = version, commit })
+	buildinfo.Version, buildinfo.Commit = "1.4.0", "0a1b2c3"
+	resp, err = client.GetVersion(context.Background(), &pb.GetVersionRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "1.4.0", resp.Version)
+	assert.Equal(t, "0a1b2c3", resp.Commit)
+}
+
+// TestServer_MaxRecvMsgSize проверяет, что сообщение больше MaxRecvMsgSize отклоняется
+// с codes.ResourceExhausted, а сообщение в пределах лимита обрабатывается.
+
+func TestServer_MaxRecvMsgSize(t *testing.T) {
+	client := pb.NewAuthServiceClient(startServer(t, Config{MaxRecvMsgSize: 1024, MaxConcurrentStreams: 10}))
+
+	_, err := client.Register(context.Background(), &pb.RegisterRequest{Username: "user", Password: strings.Repeat("x", 2048)})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = client.Register(context.Background(), &pb.RegisterRequest{Username: "user", Password: "password"})
+	assert.NoError(t, err)
+}
+
+// TestServer_Validation проверяет, что некорректный запрос отклоняется с codes.InvalidArgument
+// и деталями google.rpc.BadRequest по каждому нарушенному полю.
+
+func TestServer_Validation(t *testing.T) {
+	client := pb.NewAuthServiceClient(startServer(t, Config{}))
+
+	_, err := client.Login(context.Background(), &pb.LoginRequest{Password: strings.Repeat("x", 257)})
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	require.Len(t, st.Details(), 1)
+	details, ok := st.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	var fields []string
+	for _, violation := range details.FieldViolations {
+		fields = append(fields, violation.Field)
+	}
+	assert.Equal(t, []string{"username", "password"}, fields)
+}
+
+// TestTimeoutInterceptor проверяет, что вызов ограничивается временем своего метода,
+// ошибка по истечении времени заменяется на codes.DeadlineExceeded и учитывается в показателе.
+
+func TestTimeoutInterceptor(t *testing.T) {
+	interceptor := timeoutInterceptor(time.Hour, map[string]time.Duration{"ValidateToken": 10 * time.Millisecond})
+	method := pb.AuthService_ValidateToken_FullMethodName
+	blocking := func(ctx context.Context, _ any) (any, error) {
+		<-ctx.Done()
+		return nil, status.Error(codes.Internal, "query canceled")
+	}
+	hits := func() int64 {
+		if v, ok := deadlineStats.Get(method).(*expvar.Int); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	before := hits()
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, blocking)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Equal(t, before+1, hits())
+
+	var deadline time.Time
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: pb.AuthService_Register_FullMethodName},
+		func(ctx context.Context, _ any) (any, error) {
+			deadline, _ = ctx.Deadline()
+			return nil, nil
+		})
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), deadline, time.Minute)
+}
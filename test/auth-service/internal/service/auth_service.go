@@ -1,147 +1,571 @@
-package service
-
-import (
-	"context"
-	"errors"
-	"time"
-
-	"github.com/dgrijalva/jwt-go"
-	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
-
-	"auth-service/internal/model"
-	"auth-service/internal/repository"
-)
-
-var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrUserAlreadyExists  = errors.New("user already exists")
-	ErrInvalidToken       = errors.New("invalid token")
-)
-
-// AuthService определяет интерфейс для аутентификационных операций.
-// Предоставляет методы для регистрации, входа в систему и проверки токенов.
-
-type AuthService interface {
-	Register(ctx context.Context, username, password string) (string, uuid.UUID, error)
-	Login(ctx context.Context, username, password string) (string, uuid.UUID, error)
-	ValidateToken(ctx context.Context, token string) (uuid.UUID, error)
-}
-
-// authService реализует интерфейс AuthService для обработки аутентификационных операций.
-// Использует репозиторий для работы с данными пользователей и JWT для аутентификации.
-
-type authService struct {
-	userRepo repository.UserRepository
-	jwtKey   []byte
-}
-
-// NewAuthService создает новый экземпляр сервиса аутентификации.
-// Принимает репозиторий пользователей и ключ для подписи JWT-токенов.
-
-func NewAuthService(userRepo repository.UserRepository, jwtKey string) AuthService {
-	return &authService{userRepo: userRepo, jwtKey: []byte(jwtKey)}
-}
-
-// Register регистрирует нового пользователя в системе.
-// Проверяет уникальность имени пользователя, хеширует пароль и создает запись в базе данных.
-// Генерирует JWT-токен для успешной регистрации.
-
-func (s *authService) Register(ctx context.Context, username, password string) (string, uuid.UUID, error) {
-	existingUser, err := s.userRepo.GetByUsername(ctx, username)
-	if err == nil && existingUser != nil {
-		return "", uuid.Nil, ErrUserAlreadyExists
-	}
-
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", uuid.Nil, err
-	}
-
-	user := &model.User{
-		Username:     username,
-		PasswordHash: string(hashedPassword),
-	}
-
-	if err := s.userRepo.Create(ctx, user); err != nil {
-		return "", uuid.Nil, err
-	}
-
-	token, err := s.generateToken(user.ID)
-	if err != nil {
-		return "", uuid.Nil, err
-	}
-
-	return token, user.ID, nil
-}
-
-// Login аутентифицирует пользователя по имени и паролю.
-// Проверяет существование пользователя и корректность пароля.
-// Генерирует JWT-токен при успешной аутентификации.
-
-func (s *authService) Login(ctx context.Context, username, password string) (string, uuid.UUID, error) {
-	user, err := s.userRepo.GetByUsername(ctx, username)
-	if err != nil {
-		return "", uuid.Nil, ErrInvalidCredentials
-	}
-
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
-	if err != nil {
-		return "", uuid.Nil, ErrInvalidCredentials
-	}
-
-	token, err := s.generateToken(user.ID)
-	if err != nil {
-		return "", uuid.Nil, err
-	}
-
-	return token, user.ID, nil
-}
-
-// ValidateToken проверяет действительность JWT-токена и возвращает ID пользователя.
-// Проверяет подпись токена, срок действия и существование пользователя.
-
-func (s *authService) ValidateToken(ctx context.Context, tokenString string) (uuid.UUID, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return s.jwtKey, nil
-	})
-
-	if err != nil || !token.Valid {
-		return uuid.Nil, ErrInvalidToken
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return uuid.Nil, ErrInvalidToken
-	}
-
-	userID, err := uuid.Parse(claims["sub"].(string))
-	if err != nil {
-		return uuid.Nil, ErrInvalidToken
-	}
-
-	_, err = s.userRepo.GetByID(ctx, userID)
-	if err != nil {
-		return uuid.Nil, ErrInvalidToken
-	}
-
-	return userID, nil
-}
-
-// generateToken генерирует JWT-токен для указанного ID пользователя.
-// Устанавливает срок действия токена на 24 часа.
-
-func (s *authService) generateToken(userID uuid.UUID) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
-
-	claims := token.Claims.(jwt.MapClaims)
-	claims["sub"] = userID.String()
-	claims["exp"] = time.Now().Add(time.Hour * 24).Unix()
-
-	tokenString, err := token.SignedString(s.jwtKey)
-	if err != nil {
-		return "", err
-	}
-
-	return tokenString, nil
-}
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"auth-service/internal/audit"
+	"auth-service/internal/mailer"
+	"auth-service/internal/model"
+	"auth-service/internal/oidc"
+	"auth-service/internal/password"
+	"auth-service/internal/repository"
+	"common/clock"
+)
+
+var (
+	ErrInvalidCredentials       = errors.New("invalid credentials")
+	ErrUserAlreadyExists        = errors.New("user already exists")
+	ErrInvalidToken             = errors.New("invalid token")
+	ErrUserNotFound             = errors.New("user not found")
+	ErrInvalidEmail             = errors.New("invalid email")
+	ErrEmailAlreadyExists       = errors.New("email already in use")
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrSessionNotFound          = errors.New("session not found")
+	ErrTooManyUsers             = errors.New("too many user IDs")
+	ErrInvalidUserFilter        = errors.New("invalid user filter")
+	ErrOrganizationsDisabled    = errors.New("organizations are disabled")
+	ErrInvalidOrganizationName  = errors.New("invalid organization name")
+	ErrAlreadyInOrganization    = errors.New("user already belongs to an organization")
+	ErrNotOrganizationOwner     = errors.New("only the organization owner can invite members")
+	ErrInvalidInviteTTL         = errors.New("invalid invite lifetime")
+	ErrInviteNotFound           = errors.New("invite not found")
+	ErrInvalidInvite            = errors.New("invalid or expired invite code")
+	ErrNotAdmin                 = errors.New("only administrators can impersonate users")
+	ErrImpersonateAdmin         = errors.New("administrators cannot be impersonated")
+	ErrOIDCDisabled             = errors.New("OIDC login is disabled")
+	ErrUnknownProvider          = oidc.ErrUnknownProvider
+	ErrInvalidIDToken           = oidc.ErrInvalidToken
+	ErrProviderUnavailable      = oidc.ErrProviderUnavailable
+)
+
+// AuthService определяет интерфейс для аутентификационных операций.
+// Предоставляет методы для регистрации, входа в систему, проверки токенов и управления сеансами.
+// Списки возвращаются в детерминированном порядке: записи с одинаковым значением поля
+// сортировки упорядочиваются по ID (устройства — по отпечатку), поэтому повторный запрос
+// возвращает тот же порядок, а страницы ListUsers не пересекаются и не теряют записи.
+
+type AuthService interface {
+	// Register регистрирует пользователя; email необязателен и требует подтверждения.
+	Register(ctx context.Context, username, password, email string, client ClientInfo) (*Tokens, error)
+	Login(ctx context.Context, username, password string, client ClientInfo) (*Tokens, error)
+	Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*Tokens, error)
+	ValidateToken(ctx context.Context, token string) (*TokenClaims, error)
+	InvalidateUser(userID uuid.UUID)
+	CacheStats() CacheStats
+
+	GetUser(ctx context.Context, userID uuid.UUID) (*model.User, error)
+	GetUsers(ctx context.Context, userIDs []uuid.UUID) ([]*model.User, error)
+	// ListUsers возвращает страницу пользователей для администратора и их общее число.
+	ListUsers(ctx context.Context, filter model.UserFilter) ([]*model.User, int, error)
+	// ExportUsers вызывает fn для каждого пользователя, удовлетворяющего фильтру, в порядке ListUsers.
+	ExportUsers(ctx context.Context, filter model.UserFilter, fn func(*model.User) error) error
+	// StreamUsers передает в fn тех же пользователей пакетами по batchSize, читая каждый пакет
+	// отдельным запросом после обработки предыдущего.
+	StreamUsers(ctx context.Context, filter model.UserFilter, batchSize int, fn func([]*model.User) error) error
+	UpdateEmail(ctx context.Context, userID uuid.UUID, email string) error
+	VerifyEmail(ctx context.Context, userID uuid.UUID, token string) error
+
+	// ListSessions возвращает действующие сеансы пользователя от последних использованных к давним.
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]*model.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	RevokeAllSessions(ctx context.Context, userID, exceptSessionID uuid.UUID) (int, error)
+	// ListDevices возвращает устройства пользователя от последних использованных к давним.
+	ListDevices(ctx context.Context, userID uuid.UUID) ([]*model.KnownDevice, error)
+
+	// ExportUserData выгружает данные пользователя не чаще раза в ExportInterval.
+	ExportUserData(ctx context.Context, userID uuid.UUID) (*UserExport, error)
+	// VerifyPassword проверяет пароль пользователя перед удалением учетной записи.
+	VerifyPassword(ctx context.Context, userID uuid.UUID, password string) error
+	// EraseUser удаляет пользователя и его данные; повторный вызов завершается без ошибки.
+	EraseUser(ctx context.Context, userID uuid.UUID) error
+	// ImpersonateUser выпускает администратору короткоживущий токен для работы от имени пользователя.
+	ImpersonateUser(ctx context.Context, adminToken string, userID uuid.UUID, client ClientInfo) (*Tokens, error)
+	// ExchangeOIDCToken выполняет вход по ID-токену внешнего провайдера OIDC; доступен,
+	// только если сервис создан с WithOIDC, иначе возвращает ErrOIDCDisabled.
+	ExchangeOIDCToken(ctx context.Context, provider, idToken string, client ClientInfo) (*Tokens, error)
+
+	// Организации доступны, только если сервис создан с WithOrganizations,
+	// иначе методы возвращают ErrOrganizationsDisabled
+
+	CreateOrganization(ctx context.Context, userID uuid.UUID, name string) (*model.Organization, error)
+	InviteToOrganization(ctx context.Context, orgID, inviterID uuid.UUID, username string) (*model.OrganizationMember, error)
+	CreateInvite(ctx context.Context, orgID, ownerID uuid.UUID, ttl time.Duration) (*model.OrganizationInvite, string, error)
+	ListInvites(ctx context.Context, orgID, ownerID uuid.UUID) ([]*model.OrganizationInvite, error)
+	RevokeInvite(ctx context.Context, orgID, ownerID, inviteID uuid.UUID) error
+	AcceptInvite(ctx context.Context, userID uuid.UUID, code string) (*model.OrganizationMember, error)
+}
+
+// TokenClaims содержит данные пользователя, извлеченные из действительного токена.
+// SessionID равен uuid.Nil для токенов, выпущенных до появления сеансов; токены без срока
+// действия не принимаются. ExpiresIn — оставшееся время действия токена по часам сервиса
+// на момент проверки; 0, если срок истек и токен принят в пределах допустимого расхождения
+// часов. OrgID и OrgRole — текущее участие
+// пользователя в организации, а не записанное в токене; OrgID равен uuid.Nil, а OrgRole пуст,
+// если пользователь не состоит в организации или организации отключены. ImpersonatedBy — ID
+// администратора, выпустившего токен через ImpersonateUser, или uuid.Nil для обычного токена.
+
+type TokenClaims struct {
+	UserID         uuid.UUID
+	Role           string
+	SessionID      uuid.UUID
+	ExpiresAt      time.Time
+	ExpiresIn      time.Duration
+	OrgID          uuid.UUID
+	OrgRole        string
+	ImpersonatedBy uuid.UUID
+}
+
+// authService реализует интерфейс AuthService для обработки аутентификационных операций.
+// Использует репозиторий для работы с данными пользователей и JWT для аутентификации.
+
+type authService struct {
+	userRepo     repository.UserRepository
+	sessions     repository.SessionRepository
+	devices      repository.DeviceRepository
+	orgs         repository.OrganizationRepository
+	oidc         *oidc.Verifier
+	identities   repository.ExternalIdentityRepository
+	audit        audit.Recorder
+	notifier     DeviceNotifier
+	jwtKey       []byte
+	tokenLeeway  time.Duration
+	tokenParser  *jwt.Parser
+	passwords    password.Hasher
+	clock        clock.Clock
+	mailer       mailer.Mailer
+	userCacheTTL time.Duration
+	users        *userCache
+	memberships  *membershipCache
+	exports      *exportLimiter
+	// dummyHash — хеш случайного пароля для проверки при входе несуществующего пользователя;
+	// вычисляется при первом обращении текущим Hasher, поэтому проверка занимает столько же
+	// времени, сколько проверка пароля существующего пользователя.
+	dummyHash func() string
+	// hashing ограничивает число одновременных операций с паролями (WithMaxConcurrentHashes).
+	hashing hashLimiter
+}
+
+// Option задает необязательный параметр сервиса аутентификации.
+
+type Option func(*authService)
+
+// WithUserCacheTTL включает кэширование подтвержденных ID пользователей при проверке токенов.
+// Пока запись в кэше действительна, ValidateToken не обращается к таблице users, поэтому
+// удаленный или заблокированный пользователь может пользоваться выданным токеном до ttl,
+// если его запись не была сброшена через InvalidateUser. С тем же ttl кэшируется участие
+// пользователей в организациях. Значение 0 отключает кэш.
+
+func WithUserCacheTTL(ttl time.Duration) Option {
+	return func(s *authService) {
+		s.userCacheTTL = ttl
+	}
+}
+
+// WithClock задает часы, по которым определяются срок действия токенов и записей кэша.
+// По умолчанию используется системное время.
+
+func WithClock(c clock.Clock) Option {
+	return func(s *authService) {
+		s.clock = c
+	}
+}
+
+// WithPasswordHasher задает хеширование паролей. По умолчанию используется bcrypt
+// со стоимостью bcrypt.DefaultCost. Хеши, полученные другим алгоритмом или с другими
+// параметрами, продолжают проверяться и перехешируются при успешном входе.
+
+func WithPasswordHasher(h password.Hasher) Option {
+	return func(s *authService) {
+		s.passwords = h
+	}
+}
+
+// WithMaxConcurrentHashes ограничивает число одновременных операций хеширования и проверки
+// паролей (регистрация, вход, смена и проверка пароля) значением n. Операции сверх n ждут
+// свободного слота до отмены контекста запроса и завершаются с ErrHashCapacity.
+// Значение 0 (по умолчанию) снимает ограничение.
+
+func WithMaxConcurrentHashes(n int) Option {
+	return func(s *authService) {
+		s.hashing = newHashLimiter(n)
+	}
+}
+
+// WithMailer задает отправку писем с токенами подтверждения email.
+// По умолчанию письма записываются в журнал (mailer.LogMailer).
+
+func WithMailer(m mailer.Mailer) Option {
+	return func(s *authService) {
+		s.mailer = m
+	}
+}
+
+// WithSessionRepository задает хранилище сеансов. По умолчанию сеансы хранятся
+// в памяти процесса (repository.NewInMemorySessionRepository) и теряются при перезапуске.
+
+func WithSessionRepository(repo repository.SessionRepository) Option {
+	return func(s *authService) {
+		s.sessions = repo
+	}
+}
+
+// WithDeviceRepository задает хранилище известных устройств пользователей. По умолчанию
+// устройства хранятся в памяти процесса (repository.NewInMemoryDeviceRepository).
+
+func WithDeviceRepository(repo repository.DeviceRepository) Option {
+	return func(s *authService) {
+		s.devices = repo
+	}
+}
+
+// WithAuditRecorder задает журнал аудита входов. По умолчанию события записываются
+// в стандартный журнал (audit.LogRecorder).
+
+func WithAuditRecorder(r audit.Recorder) Option {
+	return func(s *authService) {
+		s.audit = r
+	}
+}
+
+// WithDeviceNotifier задает уведомление пользователя о входе с нового устройства.
+// По умолчанию уведомления не отправляются (NopDeviceNotifier).
+
+func WithDeviceNotifier(n DeviceNotifier) Option {
+	return func(s *authService) {
+		s.notifier = n
+	}
+}
+
+// WithOrganizations включает организации с хранилищем repo: методы организаций и приглашений,
+// claim org с ролью пользователя в выпускаемых токенах и текущее участие в организации
+// в результате ValidateToken. По умолчанию организации отключены.
+
+func WithOrganizations(repo repository.OrganizationRepository) Option {
+	return func(s *authService) {
+		s.orgs = repo
+	}
+}
+
+// WithOIDC включает вход по ID-токенам внешних провайдеров OIDC (ExchangeOIDCToken):
+// verifier проверяет токены, а identities хранит связи внешних учетных записей
+// с пользователями. По умолчанию вход через OIDC отключен.
+
+func WithOIDC(verifier *oidc.Verifier, identities repository.ExternalIdentityRepository) Option {
+	return func(s *authService) {
+		s.oidc = verifier
+		s.identities = identities
+	}
+}
+
+// NewAuthService создает новый экземпляр сервиса аутентификации.
+// Принимает репозиторий пользователей, ключ для подписи JWT-токенов и необязательные параметры.
+
+func NewAuthService(userRepo repository.UserRepository, jwtKey string, opts ...Option) AuthService {
+	s := &authService{
+		userRepo: userRepo,
+		jwtKey:   []byte(jwtKey),
+		clock:    clock.Real,
+		mailer:   mailer.LogMailer{},
+		audit:    audit.LogRecorder{},
+		notifier: NopDeviceNotifier{},
+
+		tokenLeeway: DefaultTokenLeeway,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	// События аудита учитываются в показателях независимо от выбранного журнала
+	s.audit = audit.Counted(s.audit)
+	if s.sessions == nil {
+		s.sessions = repository.NewInMemorySessionRepository()
+	}
+	if s.devices == nil {
+		s.devices = repository.NewInMemoryDeviceRepository()
+	}
+	if s.passwords == nil {
+		s.passwords, _ = password.NewBcrypt(bcrypt.DefaultCost)
+	}
+	s.dummyHash = sync.OnceValue(func() string {
+		hash, err := s.passwords.Hash(uuid.NewString())
+		if err != nil {
+			log.Printf("failed to compute dummy password hash: %v", err)
+		}
+		return hash
+	})
+	s.tokenParser = newTokenParser(s.clock.Now, s.tokenLeeway)
+	s.users = newUserCache(s.userCacheTTL, s.clock)
+	s.memberships = newMembershipCache(s.userCacheTTL, s.clock)
+	s.exports = newExportLimiter(ExportInterval, s.clock.Now)
+	return s
+}
+
+// Register регистрирует нового пользователя в системе.
+// Проверяет уникальность имени пользователя и email, хеширует пароль и создает запись в базе данных.
+// Если указан email, отправляет письмо с токеном его подтверждения.
+// Создает сеанс пользователя и выпускает для него пару токенов.
+// Занятое имя отклоняется до хеширования пароля, поэтому такой ответ приходит быстрее
+// успешной регистрации. Время ответа не выравнивается: ErrUserAlreadyExists и так сообщает,
+// что имя занято, и по времени нельзя узнать больше, чем из самого ответа.
+
+func (s *authService) Register(ctx context.Context, username, password, email string, client ClientInfo) (*Tokens, error) {
+	existingUser, err := s.userRepo.GetByUsername(ctx, username)
+	if err == nil && existingUser != nil {
+		return nil, ErrUserAlreadyExists
+	}
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("look up user by username: %w", err)
+	}
+
+	var verification emailVerification
+	if email != "" {
+		if email, err = normalizeEmail(email); err != nil {
+			return nil, err
+		}
+		if err := s.checkEmailAvailable(ctx, email, uuid.Nil); err != nil {
+			return nil, err
+		}
+		if verification, err = s.newEmailVerification(); err != nil {
+			return nil, err
+		}
+	}
+
+	hashedPassword, err := s.hashPassword(ctx, password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		ID:                         model.NewID(),
+		Username:                   username,
+		PasswordHash:               hashedPassword,
+		Role:                       model.RoleUser,
+		Email:                      email,
+		EmailVerificationHash:      verification.hash,
+		EmailVerificationExpiresAt: verification.expiresAt,
+	}
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		// Пользователь с тем же именем мог быть создан параллельным запросом после проверки выше
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, ErrUserAlreadyExists
+		}
+		return nil, fmt.Errorf("create user %s: %w", user.ID, err)
+	}
+
+	if email != "" {
+		s.sendEmailVerification(ctx, email, verification.token)
+	}
+
+	tokens, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	// Устройство регистрации считается известным, чтобы первый вход с него не был отмечен как новый
+	if _, _, err := s.rememberDevice(ctx, user.ID, client); err != nil {
+		log.Printf("failed to remember device of user %s: %v", user.ID, err)
+	}
+	return tokens, nil
+}
+
+// Login аутентифицирует пользователя по имени и паролю.
+// Проверяет существование пользователя и корректность пароля.
+// При успешной аутентификации создает новый сеанс и выпускает для него пару токенов,
+// записывает вход в журнал аудита и отмечает вход с нового устройства. Хеш пароля,
+// полученный с устаревшими параметрами, заменяется хешем с текущими. Для несуществующего
+// пользователя пароль проверяется по фиктивному хешу, чтобы время ответа не выдавало,
+// существует ли пользователь. У пользователя, созданного при входе через OIDC, пароля нет,
+// и пароль для него так же проверяется по фиктивному хешу.
+
+func (s *authService) Login(ctx context.Context, username, password string, client ClientInfo) (*Tokens, error) {
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			// Без проверки пароля ответ для несуществующего пользователя приходит заметно
+			// быстрее, и по времени ответа можно перебирать имена пользователей
+			if err := s.comparePassword(ctx, s.dummyHash(), password); errors.Is(err, ErrHashCapacity) {
+				return nil, err
+			}
+			s.recordLoginFailure(ctx, uuid.Nil, audit.ReasonUnknownUser, client)
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("look up user by username: %w", err)
+	}
+
+	hash := user.PasswordHash
+	if hash == "" {
+		hash = s.dummyHash()
+	}
+	if err := s.comparePassword(ctx, hash, password); err != nil {
+		if errors.Is(err, ErrHashCapacity) {
+			return nil, err
+		}
+		s.recordLoginFailure(ctx, user.ID, audit.ReasonWrongPassword, client)
+		return nil, ErrInvalidCredentials
+	}
+	if s.passwords.NeedsRehash(user.PasswordHash) {
+		s.rehashPassword(ctx, user.ID, password)
+	}
+
+	tokens, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	s.recordLogin(ctx, user, tokens.SessionID, client)
+	return tokens, nil
+}
+
+// rehashPassword сохраняет хеш пароля с текущими параметрами. Ошибка только записывается
+// в журнал: вход уже выполнен, а перехеширование повторится при следующем входе.
+
+func (s *authService) rehashPassword(ctx context.Context, userID uuid.UUID, password string) {
+	hash, err := s.hashPassword(ctx, password)
+	if err == nil {
+		err = s.userRepo.UpdatePassword(ctx, userID, hash)
+	}
+	if err != nil {
+		log.Printf("failed to rehash password of user %s: %v", userID, err)
+	}
+}
+
+// ValidateToken проверяет действительность JWT-токена и возвращает ID и роль пользователя.
+// Проверяет алгоритм и подпись токена, сроки (exp обязателен, iat и nbf — если заданы)
+// по часам сервиса, издателя и получателя, существование пользователя и, если токен
+// выпущен для сеанса, что сеанс не отозван.
+
+func (s *authService) ValidateToken(ctx context.Context, tokenString string) (*TokenClaims, error) {
+	claims := &accessClaims{}
+	token, err := s.tokenParser.ParseWithClaims(tokenString, claims, func(*jwt.Token) (any, error) {
+		return s.jwtKey, nil
+	})
+	if err != nil || !token.Valid || !checkIssuer(claims) {
+		return nil, ErrInvalidToken
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// Подпись подтверждает выдачу токена, но пользователь мог быть удален после этого.
+	// Существование проверяется по базе данных, если пользователь не найден в кэше
+	if !s.users.contains(userID) {
+		if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, ErrInvalidToken
+			}
+			return nil, err
+		}
+		s.users.add(userID)
+	}
+
+	// Токены, выпущенные до появления сеансов, не содержат claim sid и действуют до истечения срока
+	var sessionID uuid.UUID
+	if claims.SessionID != "" {
+		if sessionID, err = uuid.Parse(claims.SessionID); err != nil {
+			return nil, ErrInvalidToken
+		}
+		if err := s.checkSession(ctx, sessionID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Токены, выпущенные до появления ролей, не содержат claim role
+	role := claims.Role
+	if role == "" {
+		role = model.RoleUser
+	}
+
+	result := &TokenClaims{
+		UserID:    userID,
+		Role:      role,
+		SessionID: sessionID,
+		ExpiresAt: claims.ExpiresAt.Time,
+		ExpiresIn: max(claims.ExpiresAt.Sub(s.clock.Now()), 0),
+	}
+	if claims.ImpersonatedBy != "" {
+		if result.ImpersonatedBy, err = uuid.Parse(claims.ImpersonatedBy); err != nil {
+			return nil, ErrInvalidToken
+		}
+	}
+	// Участие в организации могло измениться после выпуска токена, поэтому claim org
+	// не используется: организация и роль определяются по текущему участию
+	member, err := s.cachedMembership(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member != nil {
+		result.OrgID, result.OrgRole = member.OrgID, member.Role
+	}
+	return result, nil
+}
+
+// InvalidateUser сбрасывает кэшированное подтверждение существования пользователя
+// и его участие в организации. Должен вызываться при удалении или блокировке пользователя.
+
+func (s *authService) InvalidateUser(userID uuid.UUID) {
+	s.users.invalidate(userID)
+	s.memberships.invalidate(userID)
+}
+
+// CacheStats возвращает статистику обращений к кэшу пользователей.
+
+func (s *authService) CacheStats() CacheStats {
+	return s.users.stats()
+}
+
+// generateToken генерирует JWT-токен для указанного пользователя.
+// Токен содержит ID и роль пользователя, ID сеанса (если sessionID не uuid.Nil) и организацию
+// пользователя с его ролью в ней (если member не nil), срок действия токена — AccessTokenTTL.
+// Вместе с токеном возвращает момент истечения его срока, записанный в claim exp.
+
+func (s *authService) generateToken(user *model.User, sessionID uuid.UUID, member *model.OrganizationMember) (string, time.Time, error) {
+	return s.signToken(user, sessionID, member, uuid.Nil, AccessTokenTTL)
+}
+
+// signToken выпускает токен, как generateToken, со сроком действия ttl. Если impersonatedBy
+// не uuid.Nil, токен содержит claim impersonated_by с ID администратора, выпустившего его.
+// Каждый токен получает уникальный ID (claim jti).
+
+func (s *authService) signToken(user *model.User, sessionID uuid.UUID, member *model.OrganizationMember, impersonatedBy uuid.UUID, ttl time.Duration) (string, time.Time, error) {
+	now := time.Unix(s.clock.Now().Unix(), 0).UTC()
+	expiresAt := now.Add(ttl)
+
+	claims := &accessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    TokenIssuer,
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{TokenAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+		Role: user.Role,
+	}
+	if sessionID != uuid.Nil {
+		claims.SessionID = sessionID.String()
+	}
+	if member != nil {
+		claims.OrgID = member.OrgID.String()
+		claims.OrgRole = member.Role
+	}
+	if impersonatedBy != uuid.Nil {
+		claims.ImpersonatedBy = impersonatedBy.String()
+	}
+
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.jwtKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tokenString, expiresAt, nil
+}
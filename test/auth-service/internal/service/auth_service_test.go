@@ -0,0 +1,490 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"auth-service/internal/model"
+	"auth-service/internal/password"
+	"auth-service/internal/repository"
+	"common/clock"
+)
+
+const testJWTKey = "test-key"
+
+// fakeUserRepository хранит пользователей в памяти, считает обращения к GetByID
+// и при необходимости имитирует задержку базы данных.
+type fakeUserRepository struct {
+	users    map[uuid.UUID]*model.User
+	latency  time.Duration
+	err      error
+	getCalls atomic.Int64
+}
+
+func newFakeUserRepository() *fakeUserRepository {
+	return &fakeUserRepository{users: make(map[uuid.UUID]*model.User)}
+}
+
+func (r *fakeUserRepository) Create(_ context.Context, user *model.User) error {
+	if user.ID == uuid.Nil {
+		user.ID = uuid.New()
+	}
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *fakeUserRepository) GetByUsername(_ context.Context, username string) (*model.User, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for _, user := range r.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeUserRepository) GetByID(_ context.Context, id uuid.UUID) (*model.User, error) {
+	r.getCalls.Add(1)
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.latency > 0 {
+		time.Sleep(r.latency)
+	}
+	user, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepository) GetByIDs(_ context.Context, ids []uuid.UUID) ([]*model.User, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	var users []*model.User
+	for _, id := range ids {
+		if user, ok := r.users[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeUserRepository) List(ctx context.Context, filter model.UserFilter) ([]*model.User, int, error) {
+	if r.err != nil {
+		return nil, 0, r.err
+	}
+	return r.snapshot(ctx).List(ctx, filter)
+}
+
+func (r *fakeUserRepository) ForEach(ctx context.Context, filter model.UserFilter, fn func(*model.User) error) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.snapshot(ctx).ForEach(ctx, filter, fn)
+}
+
+// snapshot копирует пользователей в репозиторий в памяти, отбирающий и сортирующий их по фильтру.
+func (r *fakeUserRepository) snapshot(ctx context.Context) repository.UserRepository {
+	repo := repository.NewInMemoryUserRepository()
+	for _, user := range r.users {
+		copied := *user
+		_ = repo.Create(ctx, &copied)
+	}
+	return repo
+}
+
+func (r *fakeUserRepository) GetByEmail(_ context.Context, email string) (*model.User, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeUserRepository) UpdateEmail(_ context.Context, id uuid.UUID, email, verificationHash string, expiresAt time.Time) error {
+	user, ok := r.users[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	user.Email = email
+	user.EmailVerified = false
+	user.EmailVerificationHash = verificationHash
+	user.EmailVerificationExpiresAt = expiresAt
+	return nil
+}
+
+func (r *fakeUserRepository) UpdatePassword(_ context.Context, id uuid.UUID, passwordHash string) error {
+	user, ok := r.users[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	user.PasswordHash = passwordHash
+	return nil
+}
+
+func (r *fakeUserRepository) ConfirmEmail(_ context.Context, id uuid.UUID, verificationHash string) error {
+	user, ok := r.users[id]
+	if !ok || user.EmailVerificationHash == "" || user.EmailVerificationHash != verificationHash {
+		return repository.ErrNotFound
+	}
+	user.EmailVerified = true
+	user.EmailVerificationHash = ""
+	user.EmailVerificationExpiresAt = time.Time{}
+	return nil
+}
+
+func (r *fakeUserRepository) PurgeExpiredVerificationsBefore(context.Context, time.Time, int) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeUserRepository) Delete(_ context.Context, id uuid.UUID) error {
+	if r.err != nil {
+		return r.err
+	}
+	if _, ok := r.users[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
+
+// issueToken создает пользователя напрямую в репозитории и выпускает для него токен.
+func issueToken(t testing.TB, svc AuthService, repo *fakeUserRepository) (string, uuid.UUID) {
+	t.Helper()
+	user := &model.User{Username: "user", Role: model.RoleUser}
+	require.NoError(t, repo.Create(context.Background(), user))
+	token, _, err := svc.(*authService).generateToken(user, uuid.Nil, nil)
+	require.NoError(t, err)
+	return token, user.ID
+}
+
+// Тест проверки токена без кэша: каждая проверка обращается к базе данных
+func TestValidateToken_WithoutCache(t *testing.T) {
+	repo := newFakeUserRepository()
+	svc := NewAuthService(repo, testJWTKey)
+	token, userID := issueToken(t, svc, repo)
+
+	for i := 0; i < 3; i++ {
+		claims, err := svc.ValidateToken(context.Background(), token)
+		require.NoError(t, err)
+		assert.Equal(t, userID, claims.UserID)
+	}
+
+	assert.Equal(t, int64(3), repo.getCalls.Load())
+	assert.Equal(t, CacheStats{}, svc.CacheStats())
+}
+
+// Тест проверки токена с кэшем: к базе данных обращается только первая проверка
+func TestValidateToken_CacheHit(t *testing.T) {
+	repo := newFakeUserRepository()
+	svc := NewAuthService(repo, testJWTKey, WithUserCacheTTL(time.Minute))
+	token, _ := issueToken(t, svc, repo)
+
+	for i := 0; i < 3; i++ {
+		_, err := svc.ValidateToken(context.Background(), token)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int64(1), repo.getCalls.Load())
+	stats := svc.CacheStats()
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 1}, stats)
+	assert.InDelta(t, 2.0/3.0, stats.HitRate(), 0.001)
+}
+
+// Тест истечения TTL: удаленный пользователь перестает проходить проверку после истечения записи
+func TestValidateToken_CacheExpiry(t *testing.T) {
+	repo := newFakeUserRepository()
+	fake := clock.NewFake(time.Now())
+	svc := NewAuthService(repo, testJWTKey, WithUserCacheTTL(time.Minute), WithClock(fake))
+	token, userID := issueToken(t, svc, repo)
+
+	_, err := svc.ValidateToken(context.Background(), token)
+	require.NoError(t, err)
+
+	// Пока запись действительна, удаление пользователя не влияет на проверку
+	delete(repo.users, userID)
+	_, err = svc.ValidateToken(context.Background(), token)
+	assert.NoError(t, err)
+
+	fake.Advance(time.Minute)
+	_, err = svc.ValidateToken(context.Background(), token)
+	assert.Equal(t, ErrInvalidToken, err)
+}
+
+// Тест срока действия токена: токен действителен ровно 24 часа с момента выдачи по часам сервиса
+func TestValidateToken_Expiry(t *testing.T) {
+	repo := newFakeUserRepository()
+	fake := clock.NewFake(time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC))
+	svc := NewAuthService(repo, testJWTKey, WithClock(fake), WithTokenLeeway(0))
+	token, userID := issueToken(t, svc, repo)
+
+	fake.Advance(24*time.Hour - time.Second)
+	claims, err := svc.ValidateToken(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+	assert.True(t, claims.ExpiresAt.Equal(fake.Now().Add(time.Second)), claims.ExpiresAt)
+	assert.Equal(t, time.Second, claims.ExpiresIn)
+
+	fake.Advance(time.Second)
+	_, err = svc.ValidateToken(context.Background(), token)
+	assert.Equal(t, ErrInvalidToken, err)
+
+	// Токен, выданный позже, действителен в тот же момент
+	token, _, err = svc.(*authService).generateToken(&model.User{ID: userID, Role: model.RoleUser}, uuid.Nil, nil)
+	require.NoError(t, err)
+	_, err = svc.ValidateToken(context.Background(), token)
+	assert.NoError(t, err)
+}
+
+// Тест явной инвалидации: после InvalidateUser удаление пользователя учитывается сразу
+func TestValidateToken_InvalidateUser(t *testing.T) {
+	repo := newFakeUserRepository()
+	svc := NewAuthService(repo, testJWTKey, WithUserCacheTTL(time.Hour))
+	token, userID := issueToken(t, svc, repo)
+
+	_, err := svc.ValidateToken(context.Background(), token)
+	require.NoError(t, err)
+
+	delete(repo.users, userID)
+	svc.InvalidateUser(userID)
+
+	_, err = svc.ValidateToken(context.Background(), token)
+	assert.Equal(t, ErrInvalidToken, err)
+}
+
+// Тест истечения времени запроса при входе: ошибка не маскируется под неверные учетные данные
+func TestLogin_Timeout(t *testing.T) {
+	repo := newFakeUserRepository()
+	repo.err = repository.ErrTimeout
+	svc := NewAuthService(repo, testJWTKey)
+
+	_, err := svc.Login(context.Background(), "user", "password", ClientInfo{})
+
+	assert.ErrorIs(t, err, repository.ErrTimeout)
+}
+
+// Тест сбоя базы данных: ошибка не маскируется под неверные учетные данные или недействительный токен
+func TestDatabaseFailure_NotMasked(t *testing.T) {
+	repo := newFakeUserRepository()
+	svc := NewAuthService(repo, testJWTKey)
+	token, _ := issueToken(t, svc, repo)
+	dbErr := errors.New("connection refused")
+	repo.err = dbErr
+
+	_, err := svc.Login(context.Background(), "user", "password", ClientInfo{})
+	assert.ErrorIs(t, err, dbErr)
+
+	_, err = svc.ValidateToken(context.Background(), token)
+	assert.ErrorIs(t, err, dbErr)
+
+	_, err = svc.Register(context.Background(), "new-user", "password", "", ClientInfo{})
+	assert.ErrorIs(t, err, dbErr)
+}
+
+// Тест входа несуществующего пользователя: отсутствие записи означает неверные учетные данные
+func TestLogin_UnknownUser(t *testing.T) {
+	svc := NewAuthService(newFakeUserRepository(), testJWTKey)
+
+	_, err := svc.Login(context.Background(), "nobody", "password", ClientInfo{})
+
+	assert.Equal(t, ErrInvalidCredentials, err)
+}
+
+// Тест перехеширования пароля: после смены алгоритма на Argon2id старый хеш bcrypt
+// принимается при входе и заменяется хешем Argon2id, а при неверном пароле не меняется
+func TestLogin_RehashesPassword(t *testing.T) {
+	repo := repository.NewInMemoryUserRepository()
+	ctx := context.Background()
+	bcryptHasher, err := password.NewBcrypt(bcrypt.MinCost)
+	require.NoError(t, err)
+	userID := register(t, NewAuthService(repo, testJWTKey, WithPasswordHasher(bcryptHasher)), "user", "")
+	user, err := repo.GetByID(ctx, userID)
+	require.NoError(t, err)
+	bcryptHash := user.PasswordHash
+
+	argonHasher := password.NewArgon2id(password.Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	svc := NewAuthService(repo, testJWTKey, WithPasswordHasher(argonHasher))
+
+	_, err = svc.Login(ctx, "user", "wrong", ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	user, err = repo.GetByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, bcryptHash, user.PasswordHash)
+
+	login(t, svc, "laptop")
+	user, err = repo.GetByID(ctx, userID)
+	require.NoError(t, err)
+	assert.False(t, argonHasher.NeedsRehash(user.PasswordHash))
+	argonHash := user.PasswordHash
+
+	// Хеш с текущими параметрами не перезаписывается; пароль проверяется и прежним сервисом
+	login(t, svc, "laptop")
+	user, err = repo.GetByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, argonHash, user.PasswordHash)
+	assert.NoError(t, svc.VerifyPassword(ctx, userID, "password"))
+	assert.NoError(t, NewAuthService(repo, testJWTKey, WithPasswordHasher(bcryptHasher)).VerifyPassword(ctx, userID, "password"))
+}
+
+// Тест включения перца: хеш без перца принимается при входе и заменяется хешем с перцем,
+// после чего вход возможен только с тем же перцем
+func TestLogin_UpgradesToPepperedHash(t *testing.T) {
+	repo := repository.NewInMemoryUserRepository()
+	ctx := context.Background()
+	bcryptHasher, err := password.NewBcrypt(bcrypt.MinCost)
+	require.NoError(t, err)
+	userID := register(t, NewAuthService(repo, testJWTKey, WithPasswordHasher(bcryptHasher)), "user", "")
+
+	peppered := password.WithPepper(bcryptHasher, "pepper")
+	svc := NewAuthService(repo, testJWTKey, WithPasswordHasher(peppered))
+	login(t, svc, "laptop")
+	user, err := repo.GetByID(ctx, userID)
+	require.NoError(t, err)
+	assert.False(t, peppered.NeedsRehash(user.PasswordHash))
+
+	login(t, svc, "laptop")
+	assert.NoError(t, svc.VerifyPassword(ctx, userID, "password"))
+	_, err = NewAuthService(repo, testJWTKey, WithPasswordHasher(password.WithPepper(bcryptHasher, "other"))).
+		Login(ctx, "user", "password", ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+// spyHasher считает вызовы Hash и запоминает хеши, переданные в Compare.
+type spyHasher struct {
+	password.Hasher
+	hashes   int
+	compared []string
+}
+
+func (h *spyHasher) Hash(password string) (string, error) {
+	h.hashes++
+	return h.Hasher.Hash(password)
+}
+
+func (h *spyHasher) Compare(hash, password string) error {
+	h.compared = append(h.compared, hash)
+	return h.Hasher.Compare(hash, password)
+}
+
+// Тест защиты от перебора имен по времени ответа: для несуществующего пользователя пароль
+// проверяется по фиктивному хешу с параметрами текущего Hasher, который вычисляется один раз
+func TestLogin_UnknownUserComparesDummyHash(t *testing.T) {
+	bcryptHasher, err := password.NewBcrypt(bcrypt.MinCost)
+	require.NoError(t, err)
+	spy := &spyHasher{Hasher: bcryptHasher}
+	svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey, WithPasswordHasher(spy))
+	ctx := context.Background()
+	register(t, svc, "user", "")
+	spy.hashes = 0
+
+	_, err = svc.Login(ctx, "user", "wrong", ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	require.Len(t, spy.compared, 1)
+
+	for range 2 {
+		_, err = svc.Login(ctx, "unknown", "password", ClientInfo{})
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	require.Len(t, spy.compared, 3)
+	assert.Equal(t, 1, spy.hashes)
+	assert.Equal(t, spy.compared[1], spy.compared[2])
+	assert.False(t, bcryptHasher.NeedsRehash(spy.compared[1]))
+}
+
+// Бенчмарк пропускной способности ValidateToken с кэшем и без него.
+// Задержка репозитория имитирует запрос к PostgreSQL по сети.
+func BenchmarkValidateToken(b *testing.B) {
+	for _, bench := range []struct {
+		name string
+		opts []Option
+	}{
+		{"db", nil},
+		{"cache", []Option{WithUserCacheTTL(time.Minute)}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			repo := newFakeUserRepository()
+			repo.latency = 200 * time.Microsecond
+			svc := NewAuthService(repo, testJWTKey, bench.opts...)
+			token, _ := issueToken(b, svc, repo)
+			ctx := context.Background()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := svc.ValidateToken(ctx, token); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "validations/s")
+		})
+	}
+}
+
+// BenchmarkValidateTokenInMemory измеряет накладные расходы проверки токена без задержки
+// базы данных: разбор JWT, поиск пользователя и сеанса в in-memory репозиториях, с кэшем
+// пользователей и без него.
+// Параллельные варианты показывают конкуренцию за блокировки репозитория и кэша.
+func BenchmarkValidateTokenInMemory(b *testing.B) {
+	for _, bench := range []struct {
+		name string
+		opts []Option
+	}{
+		{"nocache", nil},
+		{"cache", []Option{WithUserCacheTTL(time.Minute)}},
+	} {
+		svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey, bench.opts...)
+		tokens, err := svc.Register(context.Background(), "bench-user", "password", "", ClientInfo{})
+		require.NoError(b, err)
+		token := tokens.AccessToken
+
+		b.Run(bench.name, func(b *testing.B) {
+			ctx := context.Background()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := svc.ValidateToken(ctx, token); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(bench.name+"-parallel", func(b *testing.B) {
+			ctx := context.Background()
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := svc.ValidateToken(ctx, token); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+// Тест ID пользователей: ID назначается сервисом до сохранения, это UUID версии 7,
+// и ID зарегистрированных позже пользователей больше
+func TestRegister_TimeOrderedIDs(t *testing.T) {
+	svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey)
+
+	first := register(t, svc, "first", "")
+	second := register(t, svc, "second", "")
+
+	assert.Equal(t, uuid.Version(7), first.Version())
+	assert.Equal(t, uuid.Version(7), second.Version())
+	assert.Less(t, first.String(), second.String())
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/netip"
+
+	"github.com/google/uuid"
+
+	"auth-service/internal/audit"
+	"auth-service/internal/model"
+)
+
+// maxUserAgentLength — максимальная длина сохраняемого User-Agent в символах.
+const maxUserAgentLength = 512
+
+// Размеры подсетей, адреса внутри которых считаются одним устройством
+const (
+	ipv4ClassBits = 24
+	ipv6ClassBits = 48
+)
+
+// DeviceNotifier уведомляет пользователя о входе с нового устройства, например письмом.
+// Ошибка уведомления записывается в журнал и не прерывает вход.
+
+type DeviceNotifier interface {
+	NotifyNewDevice(ctx context.Context, user *model.User, device *model.KnownDevice) error
+}
+
+// NopDeviceNotifier — уведомитель по умолчанию, который ничего не делает.
+
+type NopDeviceNotifier struct{}
+
+// NotifyNewDevice реализует DeviceNotifier.
+
+func (NopDeviceNotifier) NotifyNewDevice(context.Context, *model.User, *model.KnownDevice) error {
+	return nil
+}
+
+// ListDevices возвращает известные устройства пользователя.
+
+func (s *authService) ListDevices(ctx context.Context, userID uuid.UUID) ([]*model.KnownDevice, error) {
+	return s.devices.ListByUser(ctx, userID)
+}
+
+// recordLogin записывает событие входа в журнал аудита и запоминает устройство клиента.
+// Если устройство новое, дополнительно записывает событие new_device_login и уведомляет
+// пользователя. Ошибки сохранения устройства и уведомления не прерывают вход.
+
+func (s *authService) recordLogin(ctx context.Context, user *model.User, sessionID uuid.UUID, client ClientInfo) {
+	event := audit.Event{
+		Type:      audit.EventLogin,
+		Time:      s.clock.Now().UTC(),
+		UserID:    user.ID,
+		SessionID: sessionID,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+	}
+	s.audit.Record(ctx, event)
+
+	device, isNew, err := s.rememberDevice(ctx, user.ID, client)
+	if err != nil {
+		log.Printf("failed to remember device of user %s: %v", user.ID, err)
+		return
+	}
+	if !isNew {
+		return
+	}
+
+	event.Type = audit.EventNewDeviceLogin
+	s.audit.Record(ctx, event)
+	if err := s.notifier.NotifyNewDevice(ctx, user, device); err != nil {
+		log.Printf("failed to notify user %s about new device: %v", user.ID, err)
+	}
+}
+
+// recordLoginFailure записывает в журнал аудита неудачную попытку входа пользователя userID
+// (uuid.Nil, если пользователя нет) по причине reason.
+
+func (s *authService) recordLoginFailure(ctx context.Context, userID uuid.UUID, reason string, client ClientInfo) {
+	s.audit.Record(ctx, audit.Event{
+		Type:      audit.EventLoginFailed,
+		Time:      s.clock.Now().UTC(),
+		UserID:    userID,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Reason:    reason,
+	})
+}
+
+// rememberDevice сохраняет устройство клиента и сообщает, встречается ли оно впервые.
+// Клиент без IP-адреса и User-Agent не определяет устройство и не сохраняется.
+
+func (s *authService) rememberDevice(ctx context.Context, userID uuid.UUID, client ClientInfo) (*model.KnownDevice, bool, error) {
+	if client.IP == "" && client.UserAgent == "" {
+		return nil, false, nil
+	}
+
+	now := s.clock.Now().UTC()
+	device := &model.KnownDevice{
+		UserID:      userID,
+		Fingerprint: deviceFingerprint(client),
+		UserAgent:   truncateRunes(client.UserAgent, maxUserAgentLength),
+		IP:          client.IP,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+	}
+	isNew, err := s.devices.Remember(ctx, device)
+	if err != nil {
+		return nil, false, err
+	}
+	return device, isNew, nil
+}
+
+// deviceFingerprint возвращает SHA-256 хеш User-Agent и подсети IP-адреса клиента
+// в шестнадцатеричном виде.
+
+func deviceFingerprint(client ClientInfo) string {
+	sum := sha256.Sum256([]byte(client.UserAgent + "\n" + ipClass(client.IP)))
+	return hex.EncodeToString(sum[:])
+}
+
+// ipClass возвращает подсеть адреса: /24 для IPv4 и /48 для IPv6, чтобы смена адреса
+// у того же провайдера не считалась новым устройством. Нераспознанный адрес возвращается как есть.
+
+func ipClass(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	addr = addr.Unmap()
+	bits := ipv6ClassBits
+	if addr.Is4() {
+		bits = ipv4ClassBits
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ip
+	}
+	return prefix.String()
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/audit"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"common/clock"
+)
+
+// captureRecorder запоминает события аудита.
+type captureRecorder struct {
+	events []audit.Event
+}
+
+func (r *captureRecorder) Record(_ context.Context, event audit.Event) {
+	r.events = append(r.events, event)
+}
+
+// types возвращает типы записанных событий и очищает список.
+func (r *captureRecorder) types() []string {
+	var types []string
+	for _, event := range r.events {
+		types = append(types, event.Type)
+	}
+	r.events = nil
+	return types
+}
+
+// captureNotifier запоминает устройства, о входе с которых был уведомлен пользователь.
+type captureNotifier struct {
+	devices []*model.KnownDevice
+	err     error
+}
+
+func (n *captureNotifier) NotifyNewDevice(_ context.Context, _ *model.User, device *model.KnownDevice) error {
+	n.devices = append(n.devices, device)
+	return n.err
+}
+
+// Тест определения нового устройства: вход из той же подсети с тем же браузером не считается новым,
+// а вход с другого браузера записывает событие new_device_login и уведомляет пользователя
+func TestLogin_NewDevice(t *testing.T) {
+	recorder := &captureRecorder{}
+	notifier := &captureNotifier{}
+	fake := clock.NewFake(time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC))
+	svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey,
+		WithAuditRecorder(recorder), WithDeviceNotifier(notifier), WithClock(fake))
+	ctx := context.Background()
+
+	firefox := ClientInfo{IP: "192.0.2.1", UserAgent: "Firefox"}
+	tokens, err := svc.Register(ctx, "user", "password", "", firefox)
+	require.NoError(t, err)
+	assert.Empty(t, recorder.types())
+
+	fake.Advance(time.Minute)
+	_, err = svc.Login(ctx, "user", "password", ClientInfo{IP: "192.0.2.77", UserAgent: "Firefox"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{audit.EventLogin}, recorder.types())
+	assert.Empty(t, notifier.devices)
+
+	chrome := ClientInfo{IP: "198.51.100.7", UserAgent: "Chrome"}
+	fake.Advance(time.Minute)
+	_, err = svc.Login(ctx, "user", "password", chrome)
+	require.NoError(t, err)
+	assert.Equal(t, []string{audit.EventLogin, audit.EventNewDeviceLogin}, recorder.types())
+	require.Len(t, notifier.devices, 1)
+	assert.Equal(t, "Chrome", notifier.devices[0].UserAgent)
+
+	// Ошибка уведомления не прерывает вход, а устройство уже считается известным
+	notifier.err = errors.New("smtp unavailable")
+	fake.Advance(time.Minute)
+	_, err = svc.Login(ctx, "user", "password", ClientInfo{IP: "203.0.113.9", UserAgent: "Chrome"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{audit.EventLogin, audit.EventNewDeviceLogin}, recorder.types())
+	fake.Advance(time.Minute)
+	_, err = svc.Login(ctx, "user", "password", chrome)
+	require.NoError(t, err)
+	assert.Equal(t, []string{audit.EventLogin}, recorder.types())
+
+	devices, err := svc.ListDevices(ctx, tokens.UserID)
+	require.NoError(t, err)
+	require.Len(t, devices, 3)
+	assert.Equal(t, "198.51.100.7", devices[0].IP)
+	assert.Equal(t, "192.0.2.77", devices[2].IP)
+}
+
+// Тест подсети адреса, определяющей устройство
+func TestIPClass(t *testing.T) {
+	assert.Equal(t, "192.0.2.0/24", ipClass("192.0.2.77"))
+	assert.Equal(t, "192.0.2.0/24", ipClass("::ffff:192.0.2.77"))
+	assert.Equal(t, "2001:db8:1::/48", ipClass("2001:db8:1:2::5"))
+	assert.Equal(t, "unknown", ipClass("unknown"))
+}
+
+// Тест неудачных попыток входа: событие login_failed записывается с причиной и учитывается
+// в показателе auth_failures
+func TestLogin_FailureRecorded(t *testing.T) {
+	recorder := &captureRecorder{}
+	svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey, WithAuditRecorder(recorder))
+	ctx := context.Background()
+	client := ClientInfo{IP: "192.0.2.1", UserAgent: "Firefox"}
+	tokens, err := svc.Register(ctx, "user", "password", "", client)
+	require.NoError(t, err)
+	failures := func(reason string) int64 {
+		if v, ok := expvar.Get("auth_failures").(*expvar.Map).Get(reason).(*expvar.Int); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	unknown, wrong := failures(audit.ReasonUnknownUser), failures(audit.ReasonWrongPassword)
+
+	_, err = svc.Login(ctx, "user", "wrong", client)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = svc.Login(ctx, "nobody", "password", client)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	require.Len(t, recorder.events, 2)
+	assert.Equal(t, audit.EventLoginFailed, recorder.events[0].Type)
+	assert.Equal(t, audit.ReasonWrongPassword, recorder.events[0].Reason)
+	assert.Equal(t, tokens.UserID, recorder.events[0].UserID)
+	assert.Equal(t, audit.ReasonUnknownUser, recorder.events[1].Reason)
+	assert.Equal(t, uuid.Nil, recorder.events[1].UserID)
+	assert.Equal(t, unknown+1, failures(audit.ReasonUnknownUser))
+	assert.Equal(t, wrong+1, failures(audit.ReasonWrongPassword))
+}
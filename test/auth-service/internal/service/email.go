@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"auth-service/internal/mailer"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+)
+
+// EmailVerificationTTL — срок действия токена подтверждения email.
+const EmailVerificationTTL = 24 * time.Hour
+
+// MaxGetUsers — наибольшее число ID в одном запросе GetUsers.
+const MaxGetUsers = 100
+
+// maxEmailLength — максимальная длина адреса по RFC 5321.
+const maxEmailLength = 254
+
+// emailVerification — одноразовый токен подтверждения email. Пользователю отправляется token,
+// в базе данных хранится только его хеш.
+type emailVerification struct {
+	token     string
+	hash      string
+	expiresAt time.Time
+}
+
+// GetUser возвращает пользователя по ID.
+
+func (s *authService) GetUser(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUsers возвращает пользователей с указанными ID одним запросом к базе данных;
+// неизвестные ID пропускаются, повторяющиеся учитываются один раз. Допускается не более
+// MaxGetUsers ID; пустой список не требует обращения к базе данных.
+
+func (s *authService) GetUsers(ctx context.Context, userIDs []uuid.UUID) ([]*model.User, error) {
+	if len(userIDs) > MaxGetUsers {
+		return nil, ErrTooManyUsers
+	}
+
+	unique := make([]uuid.UUID, 0, len(userIDs))
+	seen := make(map[uuid.UUID]bool, len(userIDs))
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) == 0 {
+		return nil, nil
+	}
+	return s.userRepo.GetByIDs(ctx, unique)
+}
+
+// UpdateEmail устанавливает пользователю новый email и отправляет на него токен подтверждения.
+// До подтверждения email считается неподтвержденным, в том числе если он совпадает с прежним.
+
+func (s *authService) UpdateEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return err
+	}
+	if err := s.checkEmailAvailable(ctx, email, userID); err != nil {
+		return err
+	}
+	verification, err := s.newEmailVerification()
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdateEmail(ctx, userID, email, verification.hash, verification.expiresAt); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrUserNotFound
+		case errors.Is(err, repository.ErrAlreadyExists):
+			// Email мог быть занят параллельным запросом после проверки выше
+			return ErrEmailAlreadyExists
+		}
+		return err
+	}
+
+	s.sendEmailVerification(ctx, email, verification.token)
+	return nil
+}
+
+// VerifyEmail подтверждает email пользователя одноразовым токеном из письма.
+// Возвращает ErrInvalidVerificationToken, если токен не совпадает, уже использован или истек.
+
+func (s *authService) VerifyEmail(ctx context.Context, userID uuid.UUID, token string) error {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerificationHash == "" || !s.clock.Now().Before(user.EmailVerificationExpiresAt) {
+		return ErrInvalidVerificationToken
+	}
+
+	if err := s.userRepo.ConfirmEmail(ctx, userID, hashToken(token)); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidVerificationToken
+		}
+		return err
+	}
+	return nil
+}
+
+// checkEmailAvailable возвращает ErrEmailAlreadyExists, если email принадлежит
+// другому пользователю, а не userID.
+
+func (s *authService) checkEmailAvailable(ctx context.Context, email string, userID uuid.UUID) error {
+	owner, err := s.userRepo.GetByEmail(ctx, email)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return nil
+	case err != nil:
+		return err
+	case owner.ID != userID:
+		return ErrEmailAlreadyExists
+	}
+	return nil
+}
+
+// newEmailVerification создает случайный токен подтверждения со сроком действия EmailVerificationTTL.
+
+func (s *authService) newEmailVerification() (emailVerification, error) {
+	token, err := randomToken()
+	if err != nil {
+		return emailVerification{}, err
+	}
+	return emailVerification{
+		token:     token,
+		hash:      hashToken(token),
+		expiresAt: s.clock.Now().Add(EmailVerificationTTL),
+	}, nil
+}
+
+// sendEmailVerification отправляет письмо с токеном подтверждения. Ошибка отправки
+// не отменяет изменение email: пользователь может запросить письмо повторно, сменив email.
+
+func (s *authService) sendEmailVerification(ctx context.Context, email, token string) {
+	err := s.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Подтверждение email",
+		Body:    "Код подтверждения email: " + token,
+	})
+	if err != nil {
+		log.Printf("failed to send email verification to %s: %v", email, err)
+	}
+}
+
+// normalizeEmail проверяет формат email и приводит его к нижнему регистру.
+// Допускается только адрес без отображаемого имени (user@example.com).
+
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if len(email) > maxEmailLength {
+		return "", ErrInvalidEmail
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || !strings.Contains(email[strings.LastIndex(email, "@")+1:], ".") {
+		return "", ErrInvalidEmail
+	}
+	return email, nil
+}
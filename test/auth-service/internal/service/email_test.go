@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/mailer"
+	"common/clock"
+)
+
+// captureMailer запоминает отправленные письма вместо отправки.
+type captureMailer struct {
+	sent []mailer.Message
+}
+
+func (m *captureMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// lastToken возвращает токен подтверждения из последнего отправленного письма.
+func (m *captureMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	require.NotEmpty(t, m.sent)
+	body := m.sent[len(m.sent)-1].Body
+	return body[strings.LastIndex(body, " ")+1:]
+}
+
+// register регистрирует пользователя с паролем "password" и возвращает его ID.
+func register(t *testing.T, svc AuthService, username, email string) uuid.UUID {
+	t.Helper()
+	tokens, err := svc.Register(context.Background(), username, "password", email, ClientInfo{})
+	require.NoError(t, err)
+	return tokens.UserID
+}
+
+// Тест подтверждения email: токен из письма подтверждает адрес и не может быть использован повторно
+func TestVerifyEmail(t *testing.T) {
+	repo := newFakeUserRepository()
+	mail := &captureMailer{}
+	svc := NewAuthService(repo, testJWTKey, WithMailer(mail))
+	ctx := context.Background()
+
+	userID := register(t, svc, "user", " User@Example.com ")
+	require.Len(t, mail.sent, 1)
+	assert.Equal(t, "user@example.com", mail.sent[0].To)
+
+	user, err := svc.GetUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", user.Email)
+	assert.False(t, user.EmailVerified)
+	assert.NotContains(t, user.EmailVerificationHash, mail.lastToken(t), "в базе хранится только хеш токена")
+
+	assert.ErrorIs(t, svc.VerifyEmail(ctx, userID, "wrong-token"), ErrInvalidVerificationToken)
+
+	token := mail.lastToken(t)
+	require.NoError(t, svc.VerifyEmail(ctx, userID, token))
+	user, err = svc.GetUser(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, user.EmailVerified)
+
+	assert.ErrorIs(t, svc.VerifyEmail(ctx, userID, token), ErrInvalidVerificationToken)
+}
+
+// Тест срока действия токена подтверждения: по истечении EmailVerificationTTL токен отклоняется,
+// а смена email выпускает новый токен и снимает подтверждение
+func TestVerifyEmail_Expired(t *testing.T) {
+	repo := newFakeUserRepository()
+	mail := &captureMailer{}
+	fake := clock.NewFake(time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC))
+	svc := NewAuthService(repo, testJWTKey, WithMailer(mail), WithClock(fake))
+	ctx := context.Background()
+
+	userID := register(t, svc, "user", "user@example.com")
+
+	fake.Advance(EmailVerificationTTL)
+	assert.ErrorIs(t, svc.VerifyEmail(ctx, userID, mail.lastToken(t)), ErrInvalidVerificationToken)
+
+	require.NoError(t, svc.UpdateEmail(ctx, userID, "new@example.com"))
+	require.Len(t, mail.sent, 2)
+	assert.Equal(t, "new@example.com", mail.sent[1].To)
+
+	fake.Advance(EmailVerificationTTL - time.Second)
+	require.NoError(t, svc.VerifyEmail(ctx, userID, mail.lastToken(t)))
+}
+
+// Тест проверки email: неверный формат и адрес другого пользователя отклоняются,
+// собственный адрес можно указать повторно
+func TestUpdateEmail_Validation(t *testing.T) {
+	repo := newFakeUserRepository()
+	svc := NewAuthService(repo, testJWTKey, WithMailer(&captureMailer{}))
+	ctx := context.Background()
+
+	firstID := register(t, svc, "first", "first@example.com")
+	secondID := register(t, svc, "second", "")
+
+	for _, email := range []string{"", "no-at-sign", "user@localhost", "Name <user@example.com>", strings.Repeat("a", 250) + "@example.com"} {
+		assert.ErrorIs(t, svc.UpdateEmail(ctx, secondID, email), ErrInvalidEmail, email)
+	}
+
+	assert.ErrorIs(t, svc.UpdateEmail(ctx, secondID, "FIRST@example.com"), ErrEmailAlreadyExists)
+	_, err := svc.Register(ctx, "third", "password", "first@example.com", ClientInfo{})
+	assert.ErrorIs(t, err, ErrEmailAlreadyExists)
+
+	assert.NoError(t, svc.UpdateEmail(ctx, firstID, "first@example.com"))
+	assert.ErrorIs(t, svc.UpdateEmail(ctx, uuid.New(), "other@example.com"), ErrUserNotFound)
+}
+
+// Тест пакетного получения пользователей: повторяющиеся ID учитываются один раз,
+// пустой список не обращается к базе данных, длинный список отклоняется
+func TestGetUsers(t *testing.T) {
+	repo := newFakeUserRepository()
+	svc := NewAuthService(repo, testJWTKey)
+	ctx := context.Background()
+
+	alice := register(t, svc, "alice", "")
+	users, err := svc.GetUsers(ctx, []uuid.UUID{alice, uuid.New(), alice})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "alice", users[0].Username)
+
+	repo.err = errors.New("connection refused")
+	users, err = svc.GetUsers(ctx, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+
+	_, err = svc.GetUsers(ctx, make([]uuid.UUID, MaxGetUsers+1))
+	assert.ErrorIs(t, err, ErrTooManyUsers)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"auth-service/internal/audit"
+	"auth-service/internal/repository"
+)
+
+// VerifyPassword проверяет пароль пользователя перед необратимой операцией с учетной записью.
+// Возвращает ErrUserNotFound, если пользователь не найден, ErrInvalidCredentials,
+// если пароль не совпадает, и ErrHashCapacity, если проверка не началась до отмены ctx.
+
+func (s *authService) VerifyPassword(ctx context.Context, userID uuid.UUID, password string) error {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.comparePassword(ctx, user.PasswordHash, password); err != nil {
+		if errors.Is(err, ErrHashCapacity) {
+			return err
+		}
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// EraseUser безвозвратно удаляет пользователя вместе с его сеансами, известными устройствами,
+// связями с внешними учетными записями OIDC и участием в организации (сама организация остается у других участников).
+// Сначала удаляются сеансы, поэтому выданные токены перестают действовать, даже если удаление
+// прервется. Операция идемпотентна: повторный вызов дозавершает прерванное удаление, а для уже
+// удаленного пользователя завершается без ошибки.
+
+func (s *authService) EraseUser(ctx context.Context, userID uuid.UUID) error {
+	if _, err := s.sessions.DeleteByUser(ctx, userID); err != nil {
+		return err
+	}
+	if _, err := s.devices.DeleteByUser(ctx, userID); err != nil {
+		return err
+	}
+	if s.orgs != nil {
+		if _, err := s.orgs.DeleteByUser(ctx, userID); err != nil {
+			return err
+		}
+	}
+	if s.identities != nil {
+		if _, err := s.identities.DeleteByUser(ctx, userID); err != nil {
+			return err
+		}
+	}
+	err := s.userRepo.Delete(ctx, userID)
+	s.InvalidateUser(userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.audit.Record(ctx, audit.Event{Type: audit.EventUserErased, Time: s.clock.Now(), UserID: userID})
+	return nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/repository"
+)
+
+// Тест проверки пароля перед удалением учетной записи
+func TestVerifyPassword(t *testing.T) {
+	svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey)
+	ctx := context.Background()
+	userID := register(t, svc, "user", "")
+
+	assert.NoError(t, svc.VerifyPassword(ctx, userID, "password"))
+	assert.ErrorIs(t, svc.VerifyPassword(ctx, userID, "wrong"), ErrInvalidCredentials)
+	require.NoError(t, svc.EraseUser(ctx, userID))
+	assert.ErrorIs(t, svc.VerifyPassword(ctx, userID, "password"), ErrUserNotFound)
+}
+
+// Тест удаления пользователя: токены перестают действовать, сеансы и устройства удаляются,
+// имя пользователя освобождается, повторное удаление завершается без ошибки
+func TestEraseUser(t *testing.T) {
+	sessions := repository.NewInMemorySessionRepository()
+	devices := repository.NewInMemoryDeviceRepository()
+	svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey,
+		WithSessionRepository(sessions), WithDeviceRepository(devices), WithUserCacheTTL(time.Hour))
+	ctx := context.Background()
+	userID := register(t, svc, "user", "user@example.com")
+	tokens := login(t, svc, "laptop")
+	_, err := svc.ValidateToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.EraseUser(ctx, userID))
+
+	_, err = svc.ValidateToken(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = svc.Refresh(ctx, tokens.RefreshToken, ClientInfo{})
+	assert.Error(t, err)
+	remaining, err := sessions.ListByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+	known, err := devices.ListByUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, known)
+
+	assert.NoError(t, svc.EraseUser(ctx, userID))
+	register(t, svc, "user", "user@example.com")
+}
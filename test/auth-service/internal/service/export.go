@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"auth-service/internal/model"
+)
+
+// ExportInterval — минимальный интервал между выгрузками данных одного пользователя.
+const ExportInterval = time.Hour
+
+// ErrExportRateLimited возвращается, если данные пользователя уже выгружались в течение ExportInterval.
+var ErrExportRateLimited = errors.New("user data export rate limited")
+
+// UserExport — все данные пользователя, хранящиеся в сервисе аутентификации: учетная запись,
+// все сеансы (в том числе отозванные и истекшие) и известные устройства. Хеш пароля, хеш
+// токена подтверждения email и хеши refresh-токенов в выгрузку не входят.
+// События аудита в сервисе не хранятся, поэтому история входов представлена сеансами и устройствами.
+
+type UserExport struct {
+	User     *model.User
+	Sessions []*model.Session
+	Devices  []*model.KnownDevice
+}
+
+// ExportUserData выгружает данные пользователя по запросу субъекта данных.
+// Возвращает ErrUserNotFound, если пользователь не найден, и ErrExportRateLimited,
+// если его данные уже выгружались в течение ExportInterval.
+
+func (s *authService) ExportUserData(ctx context.Context, userID uuid.UUID) (*UserExport, error) {
+	if !s.exports.reserve(userID) {
+		return nil, ErrExportRateLimited
+	}
+	export, err := s.exportUserData(ctx, userID)
+	if err != nil {
+		// Неудачная выгрузка не расходует попытку пользователя
+		s.exports.release(userID)
+		return nil, err
+	}
+	return export, nil
+}
+
+func (s *authService) exportUserData(ctx context.Context, userID uuid.UUID) (*UserExport, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.sessions.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := s.devices.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	redacted := *user
+	redacted.PasswordHash = ""
+	redacted.EmailVerificationHash = ""
+	for _, session := range sessions {
+		session.RefreshTokenHash = ""
+	}
+	return &UserExport{User: &redacted, Sessions: sessions, Devices: devices}, nil
+}
+
+// exportLimiter разрешает не более одной выгрузки данных пользователя за interval.
+// Ограничение действует в пределах одного экземпляра сервиса.
+
+type exportLimiter struct {
+	interval time.Duration
+	now      func() time.Time
+
+	mu   sync.Mutex
+	last map[uuid.UUID]time.Time
+}
+
+func newExportLimiter(interval time.Duration, now func() time.Time) *exportLimiter {
+	return &exportLimiter{interval: interval, now: now, last: make(map[uuid.UUID]time.Time)}
+}
+
+// reserve отмечает выгрузку данных пользователя и сообщает, разрешена ли она.
+// Записи старше interval удаляются, поэтому размер карты ограничен числом выгрузок за interval.
+
+func (l *exportLimiter) reserve(userID uuid.UUID) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for id, at := range l.last {
+		if now.Sub(at) >= l.interval {
+			delete(l.last, id)
+		}
+	}
+	if _, ok := l.last[userID]; ok {
+		return false
+	}
+	l.last[userID] = now
+	return true
+}
+
+// release отменяет отметку о выгрузке данных пользователя.
+
+func (l *exportLimiter) release(userID uuid.UUID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.last, userID)
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/repository"
+	"common/clock"
+)
+
+// Тест выгрузки данных: в выгрузку попадают все сеансы и устройства, хеши пароля и токенов удаляются,
+// повторная выгрузка разрешается только через ExportInterval
+func TestExportUserData(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey, WithClock(clk))
+	ctx := context.Background()
+	userID := register(t, svc, "user", "user@example.com")
+	laptop := login(t, svc, "laptop")
+	require.NoError(t, svc.RevokeSession(ctx, userID, laptop.SessionID))
+
+	export, err := svc.ExportUserData(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "user", export.User.Username)
+	assert.Equal(t, "user@example.com", export.User.Email)
+	assert.Empty(t, export.User.PasswordHash)
+	assert.Empty(t, export.User.EmailVerificationHash)
+	assert.False(t, export.User.EmailVerificationExpiresAt.IsZero())
+	require.Len(t, export.Sessions, 2)
+	for _, session := range export.Sessions {
+		assert.Empty(t, session.RefreshTokenHash)
+	}
+	assert.Len(t, export.Devices, 1)
+
+	// Выгрузка не изменяет хранимые данные
+	user, err := svc.GetUser(ctx, userID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, user.PasswordHash)
+
+	_, err = svc.ExportUserData(ctx, userID)
+	assert.ErrorIs(t, err, ErrExportRateLimited)
+	clk.Advance(ExportInterval)
+	_, err = svc.ExportUserData(ctx, userID)
+	assert.NoError(t, err)
+}
+
+// Тест неудачной выгрузки: отсутствующий пользователь не расходует попытку
+func TestExportUserData_NotFound(t *testing.T) {
+	svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	_, err := svc.ExportUserData(ctx, userID)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	_, err = svc.ExportUserData(ctx, userID)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+)
+
+// ErrHashCapacity возвращается, если хеширование пароля не началось до отмены контекста
+// запроса: все слоты WithMaxConcurrentHashes заняты.
+var ErrHashCapacity = errors.New("too many concurrent password hash operations")
+
+// hashStats — показатели хеширования паролей в /debug/vars: waiting — число операций,
+// ожидающих слота, in_flight — число выполняемых операций, rejected — число операций,
+// отклоненных с ErrHashCapacity.
+var hashStats = expvar.NewMap("password_hashing")
+
+// hashLimiter ограничивает число одновременных операций хеширования и проверки паролей.
+// Хеширование bcrypt и Argon2id занимает процессор на десятки миллисекунд, и без ограничения
+// всплеск регистраций и входов вытесняет быстрые запросы вроде ValidateToken.
+// Нулевой hashLimiter не ограничивает операции.
+
+type hashLimiter struct {
+	slots chan struct{}
+}
+
+// newHashLimiter создает ограничение на n одновременных операций; n <= 0 — без ограничения.
+
+func newHashLimiter(n int) hashLimiter {
+	if n <= 0 {
+		return hashLimiter{}
+	}
+	return hashLimiter{slots: make(chan struct{}, n)}
+}
+
+// do выполняет fn, дождавшись свободного слота. Если контекст отменяется раньше,
+// fn не вызывается и возвращается ErrHashCapacity.
+
+func (l hashLimiter) do(ctx context.Context, fn func() error) error {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			hashStats.Add("waiting", 1)
+			select {
+			case l.slots <- struct{}{}:
+				hashStats.Add("waiting", -1)
+			case <-ctx.Done():
+				hashStats.Add("waiting", -1)
+				hashStats.Add("rejected", 1)
+				return ErrHashCapacity
+			}
+		}
+		defer func() { <-l.slots }()
+	}
+	hashStats.Add("in_flight", 1)
+	defer hashStats.Add("in_flight", -1)
+	return fn()
+}
+
+// hashPassword хеширует пароль с учетом ограничения WithMaxConcurrentHashes.
+
+func (s *authService) hashPassword(ctx context.Context, password string) (string, error) {
+	var hash string
+	err := s.hashing.do(ctx, func() error {
+		var err error
+		hash, err = s.passwords.Hash(password)
+		return err
+	})
+	return hash, err
+}
+
+// comparePassword проверяет пароль по хешу с учетом ограничения WithMaxConcurrentHashes.
+// Возвращает ErrHashCapacity, если проверка не началась до отмены контекста, иначе
+// результат Hasher.Compare.
+
+func (s *authService) comparePassword(ctx context.Context, hash, password string) error {
+	var compareErr error
+	if err := s.hashing.do(ctx, func() error {
+		compareErr = s.passwords.Compare(hash, password)
+		return nil
+	}); err != nil {
+		return err
+	}
+	return compareErr
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"expvar"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/password"
+	"auth-service/internal/repository"
+)
+
+// blockingHasher хеширует пароль "slow" только после закрытия release, сообщая о начале
+// хеширования в started; остальные пароли хешируются сразу.
+type blockingHasher struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *blockingHasher) Hash(pw string) (string, error) {
+	if pw == "slow" {
+		h.started <- struct{}{}
+		<-h.release
+	}
+	return "plain:" + pw, nil
+}
+
+func (h *blockingHasher) Compare(hash, pw string) error {
+	if hash != "plain:"+pw {
+		return password.ErrMismatch
+	}
+	return nil
+}
+
+func (h *blockingHasher) NeedsRehash(string) bool { return false }
+
+// spinHasher имитирует стоимость хеширования, занимая процессор на cost.
+type spinHasher struct {
+	cost time.Duration
+}
+
+func (h spinHasher) spin() {
+	sum := sha256.Sum256(nil)
+	for start := time.Now(); time.Since(start) < h.cost; {
+		sum = sha256.Sum256(sum[:])
+	}
+}
+
+func (h spinHasher) Hash(pw string) (string, error) {
+	h.spin()
+	return "spin:" + pw, nil
+}
+
+func (h spinHasher) Compare(hash, pw string) error {
+	h.spin()
+	if hash != "spin:"+pw {
+		return password.ErrMismatch
+	}
+	return nil
+}
+
+func (h spinHasher) NeedsRehash(string) bool { return false }
+
+// hashStat возвращает значение показателя хеширования паролей.
+func hashStat(name string) int64 {
+	if v, ok := hashStats.Get(name).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+// Тест ограничения хеширования: регистрация сверх WithMaxConcurrentHashes ждет слота
+// до истечения срока контекста и завершается с ErrHashCapacity, а ValidateToken
+// выполняется без ожидания
+func TestRegister_HashCapacity(t *testing.T) {
+	hasher := &blockingHasher{started: make(chan struct{}, 1), release: make(chan struct{})}
+	svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey,
+		WithPasswordHasher(hasher), WithMaxConcurrentHashes(1))
+	ctx := context.Background()
+	tokens, err := svc.Register(ctx, "user", "password", "", ClientInfo{})
+	require.NoError(t, err)
+
+	// Первая регистрация занимает единственный слот
+	registered := make(chan error, 1)
+	go func() {
+		_, err := svc.Register(ctx, "slow-user", "slow", "", ClientInfo{})
+		registered <- err
+	}()
+	<-hasher.started
+
+	waiting, rejected := hashStat("waiting"), hashStat("rejected")
+	rejectedErr := make(chan error, 1)
+	go func() {
+		deadlineCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+		_, err := svc.Register(deadlineCtx, "other-user", "password", "", ClientInfo{})
+		rejectedErr <- err
+	}()
+	require.Eventually(t, func() bool { return hashStat("waiting") == waiting+1 }, time.Second, time.Millisecond)
+
+	claims, err := svc.ValidateToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, tokens.UserID, claims.UserID)
+
+	assert.ErrorIs(t, <-rejectedErr, ErrHashCapacity)
+	assert.Equal(t, waiting, hashStat("waiting"))
+	assert.Equal(t, rejected+1, hashStat("rejected"))
+	loginCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = svc.Login(loginCtx, "user", "password", ClientInfo{})
+	assert.ErrorIs(t, err, ErrHashCapacity)
+
+	close(hasher.release)
+	require.NoError(t, <-registered)
+	_, err = svc.Login(ctx, "user", "password", ClientInfo{})
+	assert.NoError(t, err)
+}
+
+// Бенчмарк задержки ValidateToken во время всплеска регистраций с синтетической стоимостью
+// хеширования 5ms. Регистрации выполняются в 4×GOMAXPROCS горутинах со сроком 50ms;
+// без ограничения они занимают все процессоры, с ограничением GOMAXPROCS/2 часть процессоров
+// остается проверке токенов. Метрика p99-ns — 99-й перцентиль задержки ValidateToken.
+func BenchmarkValidateToken_RegisterSaturated(b *testing.B) {
+	procs := max(runtime.GOMAXPROCS(0), 2)
+	for _, bench := range []struct {
+		name  string
+		limit int
+	}{
+		{"unlimited", 0},
+		{"limited", procs / 2},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey,
+				WithPasswordHasher(spinHasher{cost: 5 * time.Millisecond}), WithMaxConcurrentHashes(bench.limit))
+			tokens, err := svc.Register(context.Background(), "bench-user", "password", "", ClientInfo{})
+			require.NoError(b, err)
+
+			stop := make(chan struct{})
+			var wg sync.WaitGroup
+			for range 4 * procs {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for {
+						select {
+						case <-stop:
+							return
+						default:
+						}
+						ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+						_, _ = svc.Register(ctx, uuid.NewString(), "password", "", ClientInfo{})
+						cancel()
+					}
+				}()
+			}
+
+			ctx := context.Background()
+			latencies := make([]time.Duration, 0, b.N)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				start := time.Now()
+				if _, err := svc.ValidateToken(ctx, tokens.AccessToken); err != nil {
+					b.Fatal(err)
+				}
+				latencies = append(latencies, time.Since(start))
+			}
+			b.StopTimer()
+			close(stop)
+			wg.Wait()
+
+			slices.Sort(latencies)
+			b.ReportMetric(float64(latencies[len(latencies)*99/100]), "p99-ns")
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"auth-service/internal/audit"
+	"auth-service/internal/model"
+)
+
+// ImpersonationTokenTTL — срок действия токена, выпускаемого ImpersonateUser.
+const ImpersonationTokenTTL = 15 * time.Minute
+
+// ImpersonateUser выпускает администратору, владельцу токена adminToken, токен для работы
+// от имени пользователя userID, чтобы служба поддержки видела то же, что и пользователь.
+// Токен действует ImpersonationTokenTTL, не привязан к сеансу и не продлевается: refresh-токен
+// не выпускается. Claim impersonated_by с ID администратора возвращается ValidateToken в
+// TokenClaims.ImpersonatedBy. Выпуск токена записывается в журнал аудита событием
+// audit.EventUserImpersonated.
+//
+// Возвращает ErrInvalidToken, если adminToken недействителен, ErrNotAdmin, если его владелец
+// не администратор или сам работает от имени другого пользователя, ErrUserNotFound, если
+// пользователя нет, и ErrImpersonateAdmin, если пользователь — администратор.
+
+func (s *authService) ImpersonateUser(ctx context.Context, adminToken string, userID uuid.UUID, client ClientInfo) (*Tokens, error) {
+	admin, err := s.ValidateToken(ctx, adminToken)
+	if err != nil {
+		return nil, err
+	}
+	if admin.Role != model.RoleAdmin || admin.ImpersonatedBy != uuid.Nil {
+		return nil, ErrNotAdmin
+	}
+
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == model.RoleAdmin {
+		return nil, ErrImpersonateAdmin
+	}
+	member, err := s.membership(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	token, expiresAt, err := s.signToken(user, uuid.Nil, member, admin.UserID, ImpersonationTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, audit.Event{
+		Type:      audit.EventUserImpersonated,
+		Time:      s.clock.Now().UTC(),
+		UserID:    user.ID,
+		ActorID:   admin.UserID,
+		SessionID: admin.SessionID,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+	})
+	return &Tokens{AccessToken: token, UserID: user.ID, ExpiresAt: expiresAt}, nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/audit"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"common/clock"
+)
+
+// Тест работы от имени пользователя: токен выпускается на ImpersonationTokenTTL от имени
+// пользователя, ValidateToken возвращает ID администратора, а выпуск записывается в аудит
+func TestImpersonateUser(t *testing.T) {
+	start := time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	repo := repository.NewInMemoryUserRepository()
+	recorder := &captureRecorder{}
+	svc := NewAuthService(repo, testJWTKey, WithClock(fake), WithAuditRecorder(recorder))
+	ctx := context.Background()
+	userID := register(t, svc, "user", "")
+	admin := &model.User{Username: "admin", Role: model.RoleAdmin}
+	require.NoError(t, repo.Create(ctx, admin))
+	adminToken, _, err := svc.(*authService).generateToken(admin, uuid.Nil, nil)
+	require.NoError(t, err)
+	recorder.events = nil
+
+	tokens, err := svc.ImpersonateUser(ctx, adminToken, userID, ClientInfo{IP: "192.0.2.1", UserAgent: "curl/8.0"})
+	require.NoError(t, err)
+	assert.Equal(t, userID, tokens.UserID)
+	assert.Equal(t, start.Add(ImpersonationTokenTTL), tokens.ExpiresAt)
+	assert.Empty(t, tokens.RefreshToken)
+
+	claims, err := svc.ValidateToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+	assert.Equal(t, model.RoleUser, claims.Role)
+	assert.Equal(t, admin.ID, claims.ImpersonatedBy)
+
+	require.Len(t, recorder.events, 1)
+	event := recorder.events[0]
+	assert.Equal(t, audit.EventUserImpersonated, event.Type)
+	assert.Equal(t, userID, event.UserID)
+	assert.Equal(t, admin.ID, event.ActorID)
+	assert.Equal(t, "192.0.2.1", event.IP)
+
+	// Обычный токен не содержит администратора, а токен работы от имени пользователя истекает
+	// через ImpersonationTokenTTL с учетом допустимого расхождения часов
+	adminClaims, err := svc.ValidateToken(ctx, adminToken)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Nil, adminClaims.ImpersonatedBy)
+	fake.Advance(ImpersonationTokenTTL + DefaultTokenLeeway + time.Second)
+	_, err = svc.ValidateToken(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+// Тест ограничений: выдать себя за пользователя может только администратор, и только
+// за пользователя без роли администратора; отказ не записывается в аудит
+func TestImpersonateUser_Forbidden(t *testing.T) {
+	repo := repository.NewInMemoryUserRepository()
+	recorder := &captureRecorder{}
+	svc := NewAuthService(repo, testJWTKey, WithAuditRecorder(recorder))
+	ctx := context.Background()
+	userID := register(t, svc, "user", "")
+	userTokens := login(t, svc, "laptop")
+	admin := &model.User{Username: "admin", Role: model.RoleAdmin}
+	other := &model.User{Username: "other-admin", Role: model.RoleAdmin}
+	require.NoError(t, repo.Create(ctx, admin))
+	require.NoError(t, repo.Create(ctx, other))
+	adminToken, _, err := svc.(*authService).generateToken(admin, uuid.Nil, nil)
+	require.NoError(t, err)
+	recorder.events = nil
+
+	_, err = svc.ImpersonateUser(ctx, userTokens.AccessToken, userID, ClientInfo{})
+	assert.ErrorIs(t, err, ErrNotAdmin)
+	_, err = svc.ImpersonateUser(ctx, "invalid", userID, ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = svc.ImpersonateUser(ctx, adminToken, other.ID, ClientInfo{})
+	assert.ErrorIs(t, err, ErrImpersonateAdmin)
+	_, err = svc.ImpersonateUser(ctx, adminToken, admin.ID, ClientInfo{})
+	assert.ErrorIs(t, err, ErrImpersonateAdmin)
+	_, err = svc.ImpersonateUser(ctx, adminToken, uuid.New(), ClientInfo{})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	assert.Empty(t, recorder.events)
+}
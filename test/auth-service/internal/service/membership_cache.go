@@ -0,0 +1,82 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"auth-service/internal/model"
+	"common/clock"
+)
+
+// membershipEntry — кэшированное участие пользователя; member равен nil, если пользователь
+// не состоит в организации.
+type membershipEntry struct {
+	member    *model.OrganizationMember
+	expiresAt time.Time
+}
+
+// membershipCache хранит участие пользователей в организациях, которое ValidateToken
+// сообщает вместо записанного в токене. Изменения членства в этом сервисе сбрасывают запись
+// сразу, остальные вступают в силу не позже чем через ttl. Нулевой *membershipCache
+// отключает кэширование.
+type membershipCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.RWMutex
+	entries map[uuid.UUID]membershipEntry
+}
+
+func newMembershipCache(ttl time.Duration, clk clock.Clock) *membershipCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &membershipCache{ttl: ttl, now: clk.Now, entries: make(map[uuid.UUID]membershipEntry)}
+}
+
+// get возвращает кэшированное участие пользователя и признак действующей записи.
+func (c *membershipCache) get(id uuid.UUID) (*model.OrganizationMember, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.RLock()
+	entry, ok := c.entries[id]
+	c.mu.RUnlock()
+	if !ok || !c.now().Before(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.member, true
+}
+
+// add запоминает участие пользователя (nil — не состоит в организации) на время ttl.
+func (c *membershipCache) add(id uuid.UUID, member *model.OrganizationMember) {
+	if c == nil {
+		return
+	}
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= userCacheMaxEntries {
+		for key, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= userCacheMaxEntries {
+			clear(c.entries)
+		}
+	}
+	c.entries[id] = membershipEntry{member: member, expiresAt: now.Add(c.ttl)}
+}
+
+// invalidate удаляет участие пользователя из кэша после его изменения.
+func (c *membershipCache) invalidate(id uuid.UUID) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	delete(c.entries, id)
+	c.mu.Unlock()
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"auth-service/internal/audit"
+	"auth-service/internal/model"
+	"auth-service/internal/oidc"
+	"auth-service/internal/repository"
+)
+
+// Имена пользователей, создаваемых при первом входе через OIDC
+const (
+	// maxExternalUsernameLength — наибольшая длина имени в символах, как у имени при регистрации.
+	maxExternalUsernameLength = 64
+	// externalUsernameAttempts — число попыток подобрать свободное имя со случайным окончанием.
+	externalUsernameAttempts = 5
+)
+
+// ExchangeOIDCToken выполняет вход по ID-токену idToken внешнего провайдера provider
+// и, как Login, создает сеанс и выпускает пару токенов сервиса.
+//
+// Пользователь определяется по связи внешней учетной записи (провайдер и claim sub) с
+// пользователем. При первом входе учетная запись связывается с пользователем, подтвердившим
+// тот же email, если это разрешено для провайдера (oidc.ProviderConfig.LinkVerifiedEmail)
+// и провайдер подтвердил email, иначе создается новый пользователь без пароля. Имя нового
+// пользователя берется из claim preferred_username или email и дополняется случайным
+// окончанием, если занято.
+//
+// Возвращает ErrOIDCDisabled, если вход через OIDC не включен, ErrUnknownProvider для
+// неизвестного провайдера, ErrInvalidIDToken для недействительного токена (попытка входа
+// записывается в журнал аудита) и ErrProviderUnavailable, если ключи провайдера недоступны.
+
+func (s *authService) ExchangeOIDCToken(ctx context.Context, provider, idToken string, client ClientInfo) (*Tokens, error) {
+	if s.oidc == nil {
+		return nil, ErrOIDCDisabled
+	}
+	identity, err := s.oidc.Verify(ctx, provider, idToken)
+	if err != nil {
+		if errors.Is(err, ErrInvalidIDToken) {
+			s.recordLoginFailure(ctx, uuid.Nil, audit.ReasonInvalidIDToken, client)
+		}
+		return nil, err
+	}
+
+	user, err := s.externalUser(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	s.recordLogin(ctx, user, tokens.SessionID, client)
+	return tokens, nil
+}
+
+// externalUser возвращает пользователя, связанного с внешней учетной записью, а при первом
+// входе связывает учетную запись с существующим или новым пользователем.
+
+func (s *authService) externalUser(ctx context.Context, identity *oidc.Identity) (*model.User, error) {
+	link, err := s.identities.Get(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		return s.GetUser(ctx, link.UserID)
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	email := verifiedEmail(identity)
+	user, created, err := s.userForIdentity(ctx, identity, email)
+	if err != nil {
+		return nil, err
+	}
+	err = s.identities.Create(ctx, &model.ExternalIdentity{
+		Provider:  identity.Provider,
+		Subject:   identity.Subject,
+		UserID:    user.ID,
+		Email:     email,
+		CreatedAt: s.clock.Now().UTC(),
+	})
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		// Параллельный вход той же учетной записи успел ее связать; созданный для нее
+		// пользователь больше не нужен
+		if created {
+			if err := s.userRepo.Delete(ctx, user.ID); err != nil {
+				log.Printf("failed to delete duplicate external user %s: %v", user.ID, err)
+			}
+		}
+		if link, err = s.identities.Get(ctx, identity.Provider, identity.Subject); err != nil {
+			return nil, err
+		}
+		return s.GetUser(ctx, link.UserID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("link external identity to user %s: %w", user.ID, err)
+	}
+	return user, nil
+}
+
+// userForIdentity возвращает пользователя с подтвержденным email, если провайдеру разрешено
+// связывание по email, иначе создает нового пользователя. created сообщает, создан ли пользователь.
+
+func (s *authService) userForIdentity(ctx context.Context, identity *oidc.Identity, email string) (*model.User, bool, error) {
+	if cfg, _ := s.oidc.Provider(identity.Provider); cfg.LinkVerifiedEmail && email != "" {
+		user, err := s.userRepo.GetByEmail(ctx, email)
+		if err == nil && user.EmailVerified {
+			return user, false, nil
+		}
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, false, fmt.Errorf("look up user by email: %w", err)
+		}
+	}
+
+	user, err := s.createExternalUser(ctx, identity, email)
+	if err != nil {
+		return nil, false, err
+	}
+	return user, true, nil
+}
+
+// createExternalUser создает пользователя без пароля для внешней учетной записи. Email,
+// подтвержденный провайдером, сохраняется подтвержденным, если его не использует другой
+// пользователь. Занятое имя дополняется случайным окончанием.
+
+func (s *authService) createExternalUser(ctx context.Context, identity *oidc.Identity, email string) (*model.User, error) {
+	if email != "" {
+		if err := s.checkEmailAvailable(ctx, email, uuid.Nil); err != nil {
+			if !errors.Is(err, ErrEmailAlreadyExists) {
+				return nil, err
+			}
+			email = ""
+		}
+	}
+
+	base := externalUsername(identity)
+	for attempt := 0; attempt < externalUsernameAttempts; attempt++ {
+		username := base
+		if attempt > 0 {
+			suffix, err := randomSuffix()
+			if err != nil {
+				return nil, err
+			}
+			username = truncateRunes(base, maxExternalUsernameLength-len(suffix)-1) + "-" + suffix
+		}
+		user := &model.User{
+			ID:            model.NewID(),
+			Username:      username,
+			Role:          model.RoleUser,
+			Email:         email,
+			EmailVerified: email != "",
+		}
+		err := s.userRepo.Create(ctx, user)
+		if err == nil {
+			return user, nil
+		}
+		if !errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, fmt.Errorf("create external user: %w", err)
+		}
+		// Email мог занять параллельный запрос; имя подбирается дальше без него
+		if email != "" {
+			if _, err := s.userRepo.GetByEmail(ctx, email); err == nil {
+				email = ""
+			}
+		}
+	}
+	return nil, fmt.Errorf("create external user: no free username for %q", base)
+}
+
+// verifiedEmail возвращает нормализованный email учетной записи, если провайдер его подтвердил.
+
+func verifiedEmail(identity *oidc.Identity) string {
+	if !identity.EmailVerified || identity.Email == "" {
+		return ""
+	}
+	email, err := normalizeEmail(identity.Email)
+	if err != nil {
+		return ""
+	}
+	return email
+}
+
+// externalUsername возвращает имя нового пользователя: preferred_username, часть email до "@"
+// или имя провайдера.
+
+func externalUsername(identity *oidc.Identity) string {
+	name := strings.TrimSpace(identity.PreferredUsername)
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+		name = strings.TrimSpace(name)
+	}
+	if name == "" {
+		name = identity.Provider
+	}
+	return truncateRunes(name, maxExternalUsernameLength)
+}
+
+// randomSuffix возвращает случайное окончание имени пользователя из 6 шестнадцатеричных цифр.
+
+func randomSuffix() (string, error) {
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/audit"
+	"auth-service/internal/model"
+	"auth-service/internal/oidc"
+	"auth-service/internal/oidc/oidctest"
+	"auth-service/internal/repository"
+)
+
+// newOIDCService создает сервис с входом через провайдеров "corp" (связывание по email
+// разрешено) и "partner" поддельного издателя issuer.
+func newOIDCService(t *testing.T, issuer *oidctest.Issuer, repo repository.UserRepository, opts ...Option) AuthService {
+	t.Helper()
+	verifier, err := oidc.NewVerifier([]oidc.ProviderConfig{
+		{Name: "corp", Issuer: issuer.URL, ClientID: oidctest.ClientID, LinkVerifiedEmail: true},
+		{Name: "partner", Issuer: issuer.URL, ClientID: oidctest.ClientID},
+	})
+	require.NoError(t, err)
+	opts = append(opts, WithOIDC(verifier, repository.NewInMemoryExternalIdentityRepository()))
+	return NewAuthService(repo, testJWTKey, opts...)
+}
+
+// Тест входа через OIDC: при первом входе создается пользователь без пароля с подтвержденным
+// email, повторный вход возвращает того же пользователя, а пароль для него не подходит
+func TestExchangeOIDCToken(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	repo := repository.NewInMemoryUserRepository()
+	recorder := &captureRecorder{}
+	svc := newOIDCService(t, issuer, repo, WithAuditRecorder(recorder))
+	ctx := context.Background()
+
+	claims := issuer.Claims("subject-1")
+	claims["preferred_username"] = "jdoe"
+	claims["email"] = "JDoe@Example.com"
+	claims["email_verified"] = true
+	tokens, err := svc.ExchangeOIDCToken(ctx, "corp", issuer.Token(t, claims), ClientInfo{IP: "192.0.2.1"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	user, err := repo.GetByID(ctx, tokens.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, "jdoe", user.Username)
+	assert.Equal(t, "jdoe@example.com", user.Email)
+	assert.True(t, user.EmailVerified)
+	assert.Empty(t, user.PasswordHash)
+
+	tokenClaims, err := svc.ValidateToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, tokenClaims.UserID)
+	require.NotEmpty(t, recorder.events)
+	assert.Equal(t, audit.EventLogin, recorder.events[0].Type)
+
+	// Повторный вход определяется по sub, даже если у провайдера сменились имя и email
+	claims = issuer.Claims("subject-1")
+	claims["preferred_username"] = "john"
+	again, err := svc.ExchangeOIDCToken(ctx, "corp", issuer.Token(t, claims), ClientInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, again.UserID)
+
+	_, err = svc.Login(ctx, "jdoe", "", ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+// Тест связывания по email: учетная запись провайдера, которому это разрешено, связывается
+// с пользователем, подтвердившим тот же email; для другого провайдера создается новый
+// пользователь без занятого email и со свободным именем
+func TestExchangeOIDCToken_LinkByEmail(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	repo := repository.NewInMemoryUserRepository()
+	svc := newOIDCService(t, issuer, repo)
+	ctx := context.Background()
+	existing := &model.User{ID: model.NewID(), Username: "jdoe", Email: "jdoe@example.com", EmailVerified: true}
+	require.NoError(t, repo.Create(ctx, existing))
+
+	claims := issuer.Claims("corp-subject")
+	claims["email"] = "jdoe@example.com"
+	claims["email_verified"] = true
+	tokens, err := svc.ExchangeOIDCToken(ctx, "corp", issuer.Token(t, claims), ClientInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, tokens.UserID)
+
+	claims = issuer.Claims("partner-subject")
+	claims["email"] = "jdoe@example.com"
+	claims["email_verified"] = true
+	tokens, err = svc.ExchangeOIDCToken(ctx, "partner", issuer.Token(t, claims), ClientInfo{})
+	require.NoError(t, err)
+	assert.NotEqual(t, existing.ID, tokens.UserID)
+	user, err := repo.GetByID(ctx, tokens.UserID)
+	require.NoError(t, err)
+	assert.Regexp(t, `^jdoe-[0-9a-f]{6}$`, user.Username)
+	assert.Empty(t, user.Email)
+
+	// Неподтвержденный провайдером email не связывает учетные записи
+	claims = issuer.Claims("corp-unverified")
+	claims["email"] = "jdoe@example.com"
+	tokens, err = svc.ExchangeOIDCToken(ctx, "corp", issuer.Token(t, claims), ClientInfo{})
+	require.NoError(t, err)
+	assert.NotEqual(t, existing.ID, tokens.UserID)
+}
+
+// Тест ошибок входа через OIDC: отключенный вход, неизвестный провайдер и недействительный
+// токен, попытка входа с которым записывается в аудит; после удаления пользователя его
+// внешняя учетная запись связывается с новым пользователем
+func TestExchangeOIDCToken_Errors(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	ctx := context.Background()
+	token := issuer.Token(t, issuer.Claims("subject-1"))
+
+	disabled := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey)
+	_, err := disabled.ExchangeOIDCToken(ctx, "corp", token, ClientInfo{})
+	assert.ErrorIs(t, err, ErrOIDCDisabled)
+
+	recorder := &captureRecorder{}
+	svc := newOIDCService(t, issuer, repository.NewInMemoryUserRepository(), WithAuditRecorder(recorder))
+	_, err = svc.ExchangeOIDCToken(ctx, "unknown", token, ClientInfo{})
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+
+	claims := issuer.Claims("subject-1")
+	claims["aud"] = "another-client"
+	_, err = svc.ExchangeOIDCToken(ctx, "corp", issuer.Token(t, claims), ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+	require.Len(t, recorder.events, 1)
+	assert.Equal(t, audit.EventLoginFailed, recorder.events[0].Type)
+	assert.Equal(t, audit.ReasonInvalidIDToken, recorder.events[0].Reason)
+
+	first, err := svc.ExchangeOIDCToken(ctx, "corp", token, ClientInfo{})
+	require.NoError(t, err)
+	require.NoError(t, svc.EraseUser(ctx, first.UserID))
+	second, err := svc.ExchangeOIDCToken(ctx, "corp", token, ClientInfo{})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.UserID, second.UserID)
+}
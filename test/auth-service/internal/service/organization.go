@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+)
+
+// maxOrganizationNameLength — максимальная длина названия организации в символах.
+const maxOrganizationNameLength = 100
+
+// Срок действия приглашений в организацию
+const (
+	// DefaultInviteTTL — срок действия приглашения, если он не указан.
+	DefaultInviteTTL = 7 * 24 * time.Hour
+	// MaxInviteTTL — наибольший срок действия приглашения.
+	MaxInviteTTL = 30 * 24 * time.Hour
+)
+
+// CreateOrganization создает организацию, владельцем которой становится пользователь userID.
+// Название очищается от пробелов по краям и не должно быть пустым или длиннее
+// maxOrganizationNameLength символов. Возвращает ErrAlreadyInOrganization, если пользователь
+// уже состоит в организации.
+
+func (s *authService) CreateOrganization(ctx context.Context, userID uuid.UUID, name string) (*model.Organization, error) {
+	if s.orgs == nil {
+		return nil, ErrOrganizationsDisabled
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxOrganizationNameLength {
+		return nil, ErrInvalidOrganizationName
+	}
+	if _, err := s.GetUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now().UTC()
+	org := &model.Organization{ID: uuid.New(), Name: name, CreatedAt: now}
+	owner := &model.OrganizationMember{UserID: userID, Role: model.OrgRoleOwner, CreatedAt: now}
+	if err := s.orgs.Create(ctx, org, owner); err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, ErrAlreadyInOrganization
+		}
+		return nil, err
+	}
+	s.memberships.invalidate(userID)
+	return org, nil
+}
+
+// InviteToOrganization добавляет пользователя username в организацию orgID с ролью участника.
+// Приглашать может только владелец организации, иначе возвращается ErrNotOrganizationOwner
+// (в том числе если организации не существует). Возвращает ErrUserNotFound для неизвестного
+// имени и ErrAlreadyInOrganization, если пользователь уже состоит в организации.
+
+func (s *authService) InviteToOrganization(ctx context.Context, orgID, inviterID uuid.UUID, username string) (*model.OrganizationMember, error) {
+	if err := s.requireOwner(ctx, orgID, inviterID); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	member := &model.OrganizationMember{OrgID: orgID, UserID: user.ID, Role: model.OrgRoleMember, CreatedAt: s.clock.Now().UTC()}
+	if err := s.orgs.AddMember(ctx, member); err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, ErrAlreadyInOrganization
+		}
+		return nil, err
+	}
+	s.memberships.invalidate(user.ID)
+	return member, nil
+}
+
+// CreateInvite создает одноразовое приглашение в организацию orgID и возвращает его вместе
+// с кодом, который больше нигде не возвращается: сохраняется только хеш кода. Срок действия
+// ttl <= 0 заменяется на DefaultInviteTTL, больше MaxInviteTTL — ErrInvalidInviteTTL.
+// Создавать приглашения может только владелец организации.
+
+func (s *authService) CreateInvite(ctx context.Context, orgID, ownerID uuid.UUID, ttl time.Duration) (*model.OrganizationInvite, string, error) {
+	if ttl > MaxInviteTTL {
+		return nil, "", ErrInvalidInviteTTL
+	}
+	if ttl <= 0 {
+		ttl = DefaultInviteTTL
+	}
+	if err := s.requireOwner(ctx, orgID, ownerID); err != nil {
+		return nil, "", err
+	}
+
+	code, err := randomToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := s.clock.Now().UTC()
+	invite := &model.OrganizationInvite{
+		ID:        uuid.New(),
+		OrgID:     orgID,
+		CodeHash:  hashToken(code),
+		CreatedBy: ownerID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := s.orgs.CreateInvite(ctx, invite); err != nil {
+		return nil, "", err
+	}
+	return invite, code, nil
+}
+
+// ListInvites возвращает приглашения организации orgID, от новых к старым.
+// Просматривать приглашения может только владелец организации.
+
+func (s *authService) ListInvites(ctx context.Context, orgID, ownerID uuid.UUID) ([]*model.OrganizationInvite, error) {
+	if err := s.requireOwner(ctx, orgID, ownerID); err != nil {
+		return nil, err
+	}
+	return s.orgs.ListInvites(ctx, orgID)
+}
+
+// RevokeInvite отзывает приглашение inviteID организации orgID; повторный отзыв не является
+// ошибкой. Возвращает ErrInviteNotFound для неизвестного или уже принятого приглашения.
+// Отзывать приглашения может только владелец организации.
+
+func (s *authService) RevokeInvite(ctx context.Context, orgID, ownerID, inviteID uuid.UUID) error {
+	if err := s.requireOwner(ctx, orgID, ownerID); err != nil {
+		return err
+	}
+	if err := s.orgs.RevokeInvite(ctx, orgID, inviteID, s.clock.Now().UTC()); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInviteNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// AcceptInvite принимает приглашение с кодом code: пользователь userID становится участником
+// организации. Повторное принятие тем же пользователем возвращает его участие, поэтому
+// повтор запроса безопасен. Возвращает ErrInvalidInvite, если код неизвестен, истек, отозван
+// или использован другим пользователем, и ErrAlreadyInOrganization, если пользователь уже
+// состоит в организации.
+
+func (s *authService) AcceptInvite(ctx context.Context, userID uuid.UUID, code string) (*model.OrganizationMember, error) {
+	if s.orgs == nil {
+		return nil, ErrOrganizationsDisabled
+	}
+	invite, err := s.orgs.GetInviteByCodeHash(ctx, hashToken(code))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidInvite
+		}
+		return nil, err
+	}
+	if invite.AcceptedBy != nil && *invite.AcceptedBy == userID {
+		return s.acceptedMembership(ctx, invite, userID)
+	}
+	if invite.Status(s.clock.Now()) != model.InviteStatusPending {
+		return nil, ErrInvalidInvite
+	}
+	if _, err := s.GetUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	member := &model.OrganizationMember{OrgID: invite.OrgID, UserID: userID, Role: model.OrgRoleMember, CreatedAt: s.clock.Now().UTC()}
+	if err := s.orgs.AcceptInvite(ctx, invite, member); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrAlreadyExists):
+			return nil, ErrAlreadyInOrganization
+		case errors.Is(err, repository.ErrNotFound):
+			// Приглашение приняли или отозвали после чтения; если его принял этот же
+			// пользователь в параллельном запросе, возвращается его участие
+			invite, err := s.orgs.GetInviteByCodeHash(ctx, invite.CodeHash)
+			if err == nil && invite.AcceptedBy != nil && *invite.AcceptedBy == userID {
+				return s.acceptedMembership(ctx, invite, userID)
+			}
+			return nil, ErrInvalidInvite
+		}
+		return nil, err
+	}
+	s.memberships.invalidate(userID)
+	return member, nil
+}
+
+// acceptedMembership возвращает участие пользователя, ранее принявшего приглашение invite.
+// Если пользователь с тех пор покинул организацию, приглашение считается использованным.
+
+func (s *authService) acceptedMembership(ctx context.Context, invite *model.OrganizationInvite, userID uuid.UUID) (*model.OrganizationMember, error) {
+	member, err := s.membership(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil || member.OrgID != invite.OrgID {
+		return nil, ErrInvalidInvite
+	}
+	return member, nil
+}
+
+// requireOwner возвращает ErrNotOrganizationOwner, если пользователь userID не владелец
+// организации orgID (в том числе если организации не существует).
+
+func (s *authService) requireOwner(ctx context.Context, orgID, userID uuid.UUID) error {
+	if s.orgs == nil {
+		return ErrOrganizationsDisabled
+	}
+	owner, err := s.orgs.GetMembership(ctx, userID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	if err != nil || owner.OrgID != orgID || owner.Role != model.OrgRoleOwner {
+		return ErrNotOrganizationOwner
+	}
+	return nil
+}
+
+// membership возвращает участие пользователя в организации для claim токена или nil,
+// если организации отключены или пользователь не состоит в организации.
+
+func (s *authService) membership(ctx context.Context, userID uuid.UUID) (*model.OrganizationMember, error) {
+	if s.orgs == nil {
+		return nil, nil
+	}
+	member, err := s.orgs.GetMembership(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	return member, err
+}
+
+// cachedMembership возвращает участие пользователя в организации из кэша, а при его
+// отсутствии — из хранилища, запоминая результат.
+
+func (s *authService) cachedMembership(ctx context.Context, userID uuid.UUID) (*model.OrganizationMember, error) {
+	if s.orgs == nil {
+		return nil, nil
+	}
+	if member, ok := s.memberships.get(userID); ok {
+		return member, nil
+	}
+	member, err := s.membership(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.memberships.add(userID, member)
+	return member, nil
+}
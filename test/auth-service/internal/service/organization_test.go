@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"common/clock"
+)
+
+// Тест организаций: владелец приглашает участника, организация и роль учитываются при проверке
+// токенов, выпущенных и до вступления, пользователь состоит не более чем в одной организации
+func TestOrganizations(t *testing.T) {
+	orgs := repository.NewInMemoryOrganizationRepository()
+	svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey, WithOrganizations(orgs))
+	ctx := context.Background()
+	ownerID := register(t, svc, "owner", "")
+	memberID := register(t, svc, "user", "")
+	beforeJoin := login(t, svc, "laptop")
+
+	_, err := svc.CreateOrganization(ctx, ownerID, "   ")
+	assert.ErrorIs(t, err, ErrInvalidOrganizationName)
+	_, err = svc.CreateOrganization(ctx, ownerID, strings.Repeat("я", maxOrganizationNameLength+1))
+	assert.ErrorIs(t, err, ErrInvalidOrganizationName)
+	_, err = svc.CreateOrganization(ctx, uuid.New(), "Рога и копыта")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	org, err := svc.CreateOrganization(ctx, ownerID, " Рога и копыта ")
+	require.NoError(t, err)
+	assert.Equal(t, "Рога и копыта", org.Name)
+	_, err = svc.CreateOrganization(ctx, ownerID, "Другая")
+	assert.ErrorIs(t, err, ErrAlreadyInOrganization)
+
+	// Приглашать может только владелец своей организации
+	_, err = svc.InviteToOrganization(ctx, org.ID, memberID, "owner")
+	assert.ErrorIs(t, err, ErrNotOrganizationOwner)
+	_, err = svc.InviteToOrganization(ctx, uuid.New(), ownerID, "user")
+	assert.ErrorIs(t, err, ErrNotOrganizationOwner)
+	_, err = svc.InviteToOrganization(ctx, org.ID, ownerID, "nobody")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	member, err := svc.InviteToOrganization(ctx, org.ID, ownerID, "user")
+	require.NoError(t, err)
+	assert.Equal(t, memberID, member.UserID)
+	assert.Equal(t, model.OrgRoleMember, member.Role)
+	_, err = svc.InviteToOrganization(ctx, org.ID, ownerID, "user")
+	assert.ErrorIs(t, err, ErrAlreadyInOrganization)
+	_, err = svc.InviteToOrganization(ctx, org.ID, memberID, "owner")
+	assert.ErrorIs(t, err, ErrNotOrganizationOwner)
+
+	// Токен, выпущенный до вступления, не содержит claim org, но проверка сообщает текущее участие
+	claims, err := svc.ValidateToken(ctx, beforeJoin.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, org.ID, claims.OrgID)
+	assert.Equal(t, model.OrgRoleMember, claims.OrgRole)
+	refreshed, err := svc.Refresh(ctx, beforeJoin.RefreshToken, ClientInfo{})
+	require.NoError(t, err)
+	claims, err = svc.ValidateToken(ctx, refreshed.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, org.ID, claims.OrgID)
+	assert.Equal(t, model.OrgRoleMember, claims.OrgRole)
+
+	tokens, err := svc.Login(ctx, "owner", "password", ClientInfo{})
+	require.NoError(t, err)
+	claims, err = svc.ValidateToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, org.ID, claims.OrgID)
+	assert.Equal(t, model.OrgRoleOwner, claims.OrgRole)
+
+	// Удаление пользователя удаляет его участие в организации
+	require.NoError(t, svc.EraseUser(ctx, memberID))
+	_, err = orgs.GetMembership(ctx, memberID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+// Тест отключенных организаций: методы отклоняются, а claim org в токене не учитывается
+func TestOrganizations_Disabled(t *testing.T) {
+	orgs := repository.NewInMemoryOrganizationRepository()
+	users := repository.NewInMemoryUserRepository()
+	sessions := repository.NewInMemorySessionRepository()
+	enabled := NewAuthService(users, testJWTKey, WithSessionRepository(sessions), WithOrganizations(orgs))
+	disabled := NewAuthService(users, testJWTKey, WithSessionRepository(sessions))
+	ctx := context.Background()
+	ownerID := register(t, enabled, "owner", "")
+
+	_, err := disabled.CreateOrganization(ctx, ownerID, "Рога и копыта")
+	assert.ErrorIs(t, err, ErrOrganizationsDisabled)
+	_, err = disabled.InviteToOrganization(ctx, uuid.New(), ownerID, "user")
+	assert.ErrorIs(t, err, ErrOrganizationsDisabled)
+	_, _, err = disabled.CreateInvite(ctx, uuid.New(), ownerID, 0)
+	assert.ErrorIs(t, err, ErrOrganizationsDisabled)
+	_, err = disabled.AcceptInvite(ctx, ownerID, "code")
+	assert.ErrorIs(t, err, ErrOrganizationsDisabled)
+
+	_, err = enabled.CreateOrganization(ctx, ownerID, "Рога и копыта")
+	require.NoError(t, err)
+	tokens, err := enabled.Login(ctx, "owner", "password", ClientInfo{})
+	require.NoError(t, err)
+	claims, err := disabled.ValidateToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Nil, claims.OrgID)
+	assert.Empty(t, claims.OrgRole)
+}
+
+// Тест приглашений: код хранится в виде хеша, принимается один раз, истекший и отозванный
+// код отклоняется, повторное принятие тем же пользователем возвращает его участие
+func TestOrganizationInvites(t *testing.T) {
+	orgs := repository.NewInMemoryOrganizationRepository()
+	fake := clock.NewFake(time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC))
+	svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey, WithOrganizations(orgs), WithClock(fake))
+	ctx := context.Background()
+	ownerID := register(t, svc, "owner", "")
+	memberID := register(t, svc, "user", "")
+	otherID := register(t, svc, "other", "")
+	org, err := svc.CreateOrganization(ctx, ownerID, "Рога и копыта")
+	require.NoError(t, err)
+
+	// Создавать приглашения может только владелец, срок действия ограничен
+	_, _, err = svc.CreateInvite(ctx, org.ID, memberID, 0)
+	assert.ErrorIs(t, err, ErrNotOrganizationOwner)
+	_, _, err = svc.CreateInvite(ctx, org.ID, ownerID, MaxInviteTTL+time.Second)
+	assert.ErrorIs(t, err, ErrInvalidInviteTTL)
+
+	invite, code, err := svc.CreateInvite(ctx, org.ID, ownerID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, fake.Now().Add(DefaultInviteTTL), invite.ExpiresAt)
+	assert.NotEqual(t, code, invite.CodeHash)
+	assert.Equal(t, hashToken(code), invite.CodeHash)
+
+	_, err = svc.AcceptInvite(ctx, memberID, "unknown")
+	assert.ErrorIs(t, err, ErrInvalidInvite)
+	member, err := svc.AcceptInvite(ctx, memberID, code)
+	require.NoError(t, err)
+	assert.Equal(t, org.ID, member.OrgID)
+	assert.Equal(t, model.OrgRoleMember, member.Role)
+
+	// Повторное принятие тем же пользователем безопасно, другим — отклоняется
+	again, err := svc.AcceptInvite(ctx, memberID, code)
+	require.NoError(t, err)
+	assert.Equal(t, member.CreatedAt, again.CreatedAt)
+	_, err = svc.AcceptInvite(ctx, otherID, code)
+	assert.ErrorIs(t, err, ErrInvalidInvite)
+	assert.ErrorIs(t, svc.RevokeInvite(ctx, org.ID, ownerID, invite.ID), ErrInviteNotFound)
+
+	// Отозванный код не принимается
+	fake.Advance(time.Minute)
+	revoked, revokedCode, err := svc.CreateInvite(ctx, org.ID, ownerID, time.Hour)
+	require.NoError(t, err)
+	assert.ErrorIs(t, svc.RevokeInvite(ctx, org.ID, memberID, revoked.ID), ErrNotOrganizationOwner)
+	require.NoError(t, svc.RevokeInvite(ctx, org.ID, ownerID, revoked.ID))
+	require.NoError(t, svc.RevokeInvite(ctx, org.ID, ownerID, revoked.ID))
+	assert.ErrorIs(t, svc.RevokeInvite(ctx, org.ID, ownerID, uuid.New()), ErrInviteNotFound)
+	_, err = svc.AcceptInvite(ctx, otherID, revokedCode)
+	assert.ErrorIs(t, err, ErrInvalidInvite)
+
+	// Истекший код не принимается
+	fake.Advance(time.Minute)
+	_, expiredCode, err := svc.CreateInvite(ctx, org.ID, ownerID, time.Hour)
+	require.NoError(t, err)
+	fake.Advance(time.Hour)
+	_, err = svc.AcceptInvite(ctx, otherID, expiredCode)
+	assert.ErrorIs(t, err, ErrInvalidInvite)
+
+	// Участник другой организации не может принять приглашение
+	_, err = svc.CreateOrganization(ctx, otherID, "Другая")
+	require.NoError(t, err)
+	_, pendingCode, err := svc.CreateInvite(ctx, org.ID, ownerID, 0)
+	require.NoError(t, err)
+	_, err = svc.AcceptInvite(ctx, otherID, pendingCode)
+	assert.ErrorIs(t, err, ErrAlreadyInOrganization)
+
+	invites, err := svc.ListInvites(ctx, org.ID, ownerID)
+	require.NoError(t, err)
+	require.Len(t, invites, 4)
+	statuses := make([]string, 0, len(invites))
+	for _, invite := range invites {
+		statuses = append(statuses, invite.Status(fake.Now()))
+	}
+	assert.Equal(t, []string{model.InviteStatusPending, model.InviteStatusExpired, model.InviteStatusRevoked, model.InviteStatusAccepted}, statuses)
+	_, err = svc.ListInvites(ctx, org.ID, memberID)
+	assert.ErrorIs(t, err, ErrNotOrganizationOwner)
+}
+
+// Тест кэша участия: при включенном кэше пользователей принятие приглашения сразу
+// учитывается при проверке ранее выпущенного токена
+func TestOrganizationInvites_MembershipCache(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC))
+	svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey,
+		WithOrganizations(repository.NewInMemoryOrganizationRepository()), WithUserCacheTTL(time.Hour), WithClock(fake))
+	ctx := context.Background()
+	ownerID := register(t, svc, "owner", "")
+	register(t, svc, "user", "")
+	tokens := login(t, svc, "laptop")
+
+	claims, err := svc.ValidateToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Nil, claims.OrgID)
+
+	org, err := svc.CreateOrganization(ctx, ownerID, "Рога и копыта")
+	require.NoError(t, err)
+	_, code, err := svc.CreateInvite(ctx, org.ID, ownerID, 0)
+	require.NoError(t, err)
+	member, err := svc.AcceptInvite(ctx, claims.UserID, code)
+	require.NoError(t, err)
+
+	claims, err = svc.ValidateToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, member.OrgID, claims.OrgID)
+	assert.Equal(t, model.OrgRoleMember, claims.OrgRole)
+
+	require.NoError(t, svc.EraseUser(ctx, ownerID))
+	claims, err = svc.ValidateToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, member.OrgID, claims.OrgID)
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+)
+
+// Сроки действия токенов
+const (
+	// AccessTokenTTL — срок действия access-токена (JWT).
+	AccessTokenTTL = 24 * time.Hour
+	// RefreshTokenTTL — срок действия refresh-токена; каждое обновление продлевает сеанс на этот срок.
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// maxDeviceLabelLength — максимальная длина описания устройства в символах.
+const maxDeviceLabelLength = 255
+
+// ClientInfo описывает клиента, выполняющего вход или обновление токенов.
+// Сохраняется в сеансе, чтобы пользователь мог отличить свои устройства;
+// IP и UserAgent также определяют отпечаток устройства при входе.
+
+type ClientInfo struct {
+	IP          string
+	DeviceLabel string
+	UserAgent   string
+}
+
+// Tokens — результат регистрации, входа или обновления токенов.
+
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+	UserID       uuid.UUID
+	SessionID    uuid.UUID
+	// ExpiresAt — срок действия access-токена.
+	ExpiresAt time.Time
+}
+
+// Refresh выпускает новую пару токенов по refresh-токену. Refresh-токен одноразовый:
+// прежний токен заменяется новым, а повторное использование прежнего отклоняется.
+// Возвращает ErrInvalidToken, если токен неизвестен, уже использован, сеанс отозван или истек.
+
+func (s *authService) Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*Tokens, error) {
+	oldHash := hashToken(refreshToken)
+	session, err := s.sessions.GetByRefreshTokenHash(ctx, oldHash)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	now := s.clock.Now().UTC()
+	if !session.Active(now) {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, session.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	newToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	session.RefreshTokenHash = hashToken(newToken)
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(RefreshTokenTTL)
+	if client.IP != "" {
+		session.IP = client.IP
+	}
+
+	if err := s.sessions.Rotate(ctx, session, oldHash); err != nil {
+		// Токен мог быть использован или сеанс отозван параллельным запросом после проверки выше
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, user, session.ID, newToken)
+}
+
+// ListSessions возвращает действующие сеансы пользователя.
+
+func (s *authService) ListSessions(ctx context.Context, userID uuid.UUID) ([]*model.Session, error) {
+	return s.sessions.ListActive(ctx, userID, s.clock.Now())
+}
+
+// RevokeSession отзывает сеанс пользователя. Refresh-токен сеанса и все выпущенные
+// по нему access-токены перестают действовать сразу.
+// Возвращает ErrSessionNotFound, если сеанс не принадлежит пользователю или уже отозван.
+
+func (s *authService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if err := s.sessions.Revoke(ctx, userID, sessionID, s.clock.Now().UTC()); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// RevokeAllSessions отзывает все сеансы пользователя, кроме exceptSessionID (обычно текущего;
+// uuid.Nil — отозвать все), и возвращает количество отозванных сеансов.
+
+func (s *authService) RevokeAllSessions(ctx context.Context, userID, exceptSessionID uuid.UUID) (int, error) {
+	return s.sessions.RevokeAll(ctx, userID, exceptSessionID, s.clock.Now().UTC())
+}
+
+// startSession создает сеанс пользователя и выпускает для него пару токенов.
+
+func (s *authService) startSession(ctx context.Context, user *model.User, client ClientInfo) (*Tokens, error) {
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now().UTC()
+	session := &model.Session{
+		ID:               uuid.New(),
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(refreshToken),
+		DeviceLabel:      truncateRunes(client.DeviceLabel, maxDeviceLabelLength),
+		IP:               client.IP,
+		CreatedAt:        now,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(RefreshTokenTTL),
+	}
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, user, session.ID, refreshToken)
+}
+
+// issueTokens выпускает access-токен сеанса и собирает его вместе с refresh-токеном.
+// Claim org отражает участие пользователя в организации на момент выпуска и нужен клиентам,
+// читающим токен; ValidateToken сообщает текущее участие независимо от него.
+
+func (s *authService) issueTokens(ctx context.Context, user *model.User, sessionID uuid.UUID, refreshToken string) (*Tokens, error) {
+	member, err := s.membership(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, expiresAt, err := s.generateToken(user, sessionID, member)
+	if err != nil {
+		return nil, err
+	}
+	return &Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		UserID:       user.ID,
+		SessionID:    sessionID,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// checkSession возвращает ErrInvalidToken, если сеанс access-токена отозван, удален
+// или принадлежит другому пользователю. Результат не кэшируется, чтобы отзыв сеанса
+// вступал в силу сразу.
+
+func (s *authService) checkSession(ctx context.Context, sessionID, userID uuid.UUID) error {
+	session, err := s.sessions.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidToken
+		}
+		return err
+	}
+	if session.UserID != userID || !session.RevokedAt.IsZero() {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// randomToken возвращает случайный токен из 32 байт в кодировке base64url.
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken возвращает SHA-256 хеш одноразового токена в шестнадцатеричном виде.
+// Токены случайные и длинные, поэтому медленное хеширование, как для паролей, не требуется.
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// truncateRunes обрезает строку до n символов, не разрывая многобайтовые символы.
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/repository"
+	"common/clock"
+)
+
+// login выполняет вход пользователя "user" с паролем "password" с указанного устройства.
+func login(t *testing.T, svc AuthService, device string) *Tokens {
+	t.Helper()
+	tokens, err := svc.Login(context.Background(), "user", "password", ClientInfo{IP: "192.0.2.1", DeviceLabel: device})
+	require.NoError(t, err)
+	return tokens
+}
+
+// Тест отзыва сеанса: refresh- и access-токены отозванного сеанса перестают действовать сразу,
+// а другие сеансы пользователя продолжают работать
+func TestRevokeSession(t *testing.T) {
+	svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey)
+	ctx := context.Background()
+	userID := register(t, svc, "user", "")
+
+	laptop := login(t, svc, "laptop")
+	phone := login(t, svc, "phone")
+	assert.NotEqual(t, laptop.SessionID, phone.SessionID)
+
+	claims, err := svc.ValidateToken(ctx, laptop.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, laptop.SessionID, claims.SessionID)
+
+	require.NoError(t, svc.RevokeSession(ctx, userID, laptop.SessionID))
+
+	_, err = svc.ValidateToken(ctx, laptop.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = svc.Refresh(ctx, laptop.RefreshToken, ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.ErrorIs(t, svc.RevokeSession(ctx, userID, laptop.SessionID), ErrSessionNotFound)
+
+	_, err = svc.ValidateToken(ctx, phone.AccessToken)
+	assert.NoError(t, err)
+	refreshed, err := svc.Refresh(ctx, phone.RefreshToken, ClientInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, phone.SessionID, refreshed.SessionID)
+	_, err = svc.ValidateToken(ctx, refreshed.AccessToken)
+	assert.NoError(t, err)
+}
+
+// Тест одноразовости refresh-токена: после обновления прежний токен отклоняется
+func TestRefresh_RotatesToken(t *testing.T) {
+	svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey)
+	ctx := context.Background()
+	register(t, svc, "user", "")
+	first := login(t, svc, "laptop")
+
+	second, err := svc.Refresh(ctx, first.RefreshToken, ClientInfo{IP: "198.51.100.7"})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	_, err = svc.Refresh(ctx, first.RefreshToken, ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = svc.Refresh(ctx, "unknown", ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	sessions, err := svc.ListSessions(ctx, first.UserID)
+	require.NoError(t, err)
+	// Сеанс регистрации и сеанс входа; последним использован обновленный сеанс
+	require.Len(t, sessions, 2)
+	assert.Equal(t, first.SessionID, sessions[0].ID)
+	assert.Equal(t, "laptop", sessions[0].DeviceLabel)
+	assert.Equal(t, "198.51.100.7", sessions[0].IP)
+}
+
+// Тест срока действия сеанса: без обновления refresh-токен истекает через RefreshTokenTTL,
+// а каждое обновление продлевает сеанс; срок access-токена возвращается вместе с токенами
+func TestRefresh_Expiry(t *testing.T) {
+	start := time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey, WithClock(fake))
+	ctx := context.Background()
+	register(t, svc, "user", "")
+	tokens := login(t, svc, "laptop")
+	assert.Equal(t, start.Add(AccessTokenTTL), tokens.ExpiresAt)
+	claims, err := svc.ValidateToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.True(t, tokens.ExpiresAt.Equal(claims.ExpiresAt))
+
+	fake.Advance(RefreshTokenTTL - time.Hour)
+	tokens, err = svc.Refresh(ctx, tokens.RefreshToken, ClientInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, fake.Now().Add(AccessTokenTTL), tokens.ExpiresAt)
+
+	fake.Advance(RefreshTokenTTL - time.Hour)
+	tokens, err = svc.Refresh(ctx, tokens.RefreshToken, ClientInfo{})
+	require.NoError(t, err)
+
+	fake.Advance(RefreshTokenTTL)
+	_, err = svc.Refresh(ctx, tokens.RefreshToken, ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	sessions, err := svc.ListSessions(ctx, tokens.UserID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+// Тест выхода на всех устройствах: отзываются все сеансы, кроме текущего
+func TestRevokeAllSessions(t *testing.T) {
+	svc := NewAuthService(repository.NewInMemoryUserRepository(), testJWTKey)
+	ctx := context.Background()
+	userID := register(t, svc, "user", "")
+	current := login(t, svc, "laptop")
+	other := login(t, svc, "phone")
+
+	revoked, err := svc.RevokeAllSessions(ctx, userID, current.SessionID)
+	require.NoError(t, err)
+	// Сеанс регистрации и сеанс phone
+	assert.Equal(t, 2, revoked)
+
+	_, err = svc.ValidateToken(ctx, other.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = svc.ValidateToken(ctx, current.AccessToken)
+	assert.NoError(t, err)
+
+	sessions, err := svc.ListSessions(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, current.SessionID, sessions[0].ID)
+
+	// Сеансы другого пользователя не затрагиваются
+	assert.ErrorIs(t, svc.RevokeSession(ctx, uuid.New(), current.SessionID), ErrSessionNotFound)
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Зарегистрированные claims выпускаемых токенов доступа
+const (
+	// TokenIssuer — издатель токенов (claim iss).
+	TokenIssuer = "auth-service"
+	// TokenAudience — получатель токенов (claim aud).
+	TokenAudience = "call-service"
+)
+
+// DefaultTokenLeeway — допустимое расхождение часов при проверке сроков токена по умолчанию.
+// Сервисы работают на разных хостах, и без запаса только что выпущенный токен может
+// оказаться еще не действительным (iat, nbf) по часам проверяющего экземпляра.
+const DefaultTokenLeeway = 30 * time.Second
+
+// tokenAlgorithms — допустимые алгоритмы подписи токенов; токены с другим алгоритмом,
+// в том числе "none", отклоняются до проверки подписи.
+var tokenAlgorithms = []string{jwt.SigningMethodHS256.Alg()}
+
+// accessClaims — claims токена доступа: зарегистрированные claims JWT (sub, exp, iat, nbf,
+// jti, iss, aud) и claims сервиса. Токены, выпущенные до перехода на зарегистрированные
+// claims, содержат только sub, exp и claims сервиса и принимаются до истечения срока.
+
+type accessClaims struct {
+	jwt.RegisteredClaims
+	Role           string `json:"role,omitempty"`
+	SessionID      string `json:"sid,omitempty"`
+	OrgID          string `json:"org,omitempty"`
+	OrgRole        string `json:"org_role,omitempty"`
+	ImpersonatedBy string `json:"impersonated_by,omitempty"`
+}
+
+// WithTokenLeeway задает допустимое расхождение часов при проверке сроков токена
+// (exp, iat, nbf). По умолчанию — DefaultTokenLeeway; 0 отключает запас.
+
+func WithTokenLeeway(leeway time.Duration) Option {
+	return func(s *authService) {
+		s.tokenLeeway = leeway
+	}
+}
+
+// newTokenParser создает разборщик токенов доступа: алгоритм подписи должен входить
+// в tokenAlgorithms, claim exp обязателен, а сроки проверяются по часам сервиса
+// с расхождением не больше leeway.
+
+func newTokenParser(now func() time.Time, leeway time.Duration) *jwt.Parser {
+	return jwt.NewParser(
+		jwt.WithValidMethods(tokenAlgorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(leeway),
+		jwt.WithTimeFunc(now),
+	)
+}
+
+// checkIssuer проверяет издателя и получателя токена. Токены, выпущенные до появления
+// claims iss и aud, их не содержат и принимаются.
+
+func checkIssuer(claims *accessClaims) bool {
+	if claims.Issuer != "" && claims.Issuer != TokenIssuer {
+		return false
+	}
+	if len(claims.Audience) == 0 {
+		return true
+	}
+	for _, audience := range claims.Audience {
+		if audience == TokenAudience {
+			return true
+		}
+	}
+	return false
+}
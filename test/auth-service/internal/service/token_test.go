@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"common/clock"
+)
+
+// legacyToken выпущен библиотекой dgrijalva/jwt-go до перехода на зарегистрированные claims
+// с ключом testJWTKey: claims sub (legacyUserID), role "admin" и exp 2026-01-01T00:00:00Z,
+// без iat, nbf, jti, iss и aud.
+const legacyToken = "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9." +
+	"eyJleHAiOjE3NjcyMjU2MDAsInJvbGUiOiJhZG1pbiIsInN1YiI6IjAxOTViNmUyLTdjMWEtN2QzZS05ZjAwLTFhMmIzYzRkNWU2ZiJ9." +
+	"0x4ijNei-yXWfj0Q98IJD9r4HQoJjE9JJtWROl_XSk8"
+
+var legacyUserID = uuid.MustParse("0195b6e2-7c1a-7d3e-9f00-1a2b3c4d5e6f")
+
+// Тест совместимости: токен, выпущенный до перехода на зарегистрированные claims,
+// принимается до истечения срока
+func TestValidateToken_LegacyToken(t *testing.T) {
+	repo := newFakeUserRepository()
+	require.NoError(t, repo.Create(context.Background(), &model.User{ID: legacyUserID, Username: "admin", Role: model.RoleAdmin}))
+	fake := clock.NewFake(time.Date(2025, 12, 31, 23, 0, 0, 0, time.UTC))
+	svc := NewAuthService(repo, testJWTKey, WithClock(fake))
+
+	claims, err := svc.ValidateToken(context.Background(), legacyToken)
+	require.NoError(t, err)
+	assert.Equal(t, legacyUserID, claims.UserID)
+	assert.Equal(t, model.RoleAdmin, claims.Role)
+	assert.Equal(t, uuid.Nil, claims.SessionID)
+	assert.True(t, claims.ExpiresAt.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)), claims.ExpiresAt)
+
+	fake.Advance(time.Hour + DefaultTokenLeeway)
+	_, err = svc.ValidateToken(context.Background(), legacyToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+// Тест зарегистрированных claims выпускаемого токена
+func TestGenerateToken_RegisteredClaims(t *testing.T) {
+	repo := newFakeUserRepository()
+	fake := clock.NewFake(time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC))
+	svc := NewAuthService(repo, testJWTKey, WithClock(fake))
+	token, userID := issueToken(t, svc, repo)
+	other, _, err := svc.(*authService).generateToken(&model.User{ID: userID}, uuid.Nil, nil)
+	require.NoError(t, err)
+
+	claims := &accessClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(token, claims)
+	require.NoError(t, err)
+	assert.Equal(t, userID.String(), claims.Subject)
+	assert.Equal(t, TokenIssuer, claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{TokenAudience}, claims.Audience)
+	assert.True(t, claims.IssuedAt.Equal(fake.Now()))
+	assert.True(t, claims.NotBefore.Equal(fake.Now()))
+	assert.True(t, claims.ExpiresAt.Equal(fake.Now().Add(AccessTokenTTL)))
+	assert.NotEmpty(t, claims.ID)
+
+	otherClaims := &accessClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(other, otherClaims)
+	require.NoError(t, err)
+	assert.NotEqual(t, claims.ID, otherClaims.ID)
+}
+
+// Тест строгой проверки: отклоняются токены с недопустимым алгоритмом, без exp,
+// с чужим издателем или получателем и выпущенные в будущем
+func TestValidateToken_Strict(t *testing.T) {
+	repo := newFakeUserRepository()
+	fake := clock.NewFake(time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC))
+	svc := NewAuthService(repo, testJWTKey, WithClock(fake))
+	_, userID := issueToken(t, svc, repo)
+	now := fake.Now()
+	valid := func() jwt.RegisteredClaims {
+		return jwt.RegisteredClaims{Subject: userID.String(), ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour))}
+	}
+	sign := func(t *testing.T, method jwt.SigningMethod, key any, modify func(*accessClaims)) string {
+		claims := &accessClaims{RegisteredClaims: valid()}
+		modify(claims)
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		require.NoError(t, err)
+		return token
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"HS512", sign(t, jwt.SigningMethodHS512, []byte(testJWTKey), func(*accessClaims) {})},
+		{"none", sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, func(*accessClaims) {})},
+		{"другой ключ", sign(t, jwt.SigningMethodHS256, []byte("other-key"), func(*accessClaims) {})},
+		{"без exp", sign(t, jwt.SigningMethodHS256, []byte(testJWTKey), func(c *accessClaims) { c.ExpiresAt = nil })},
+		{"чужой издатель", sign(t, jwt.SigningMethodHS256, []byte(testJWTKey), func(c *accessClaims) { c.Issuer = "other" })},
+		{"чужой получатель", sign(t, jwt.SigningMethodHS256, []byte(testJWTKey), func(c *accessClaims) { c.Audience = jwt.ClaimStrings{"other"} })},
+		{"iat в будущем", sign(t, jwt.SigningMethodHS256, []byte(testJWTKey), func(c *accessClaims) { c.IssuedAt = jwt.NewNumericDate(now.Add(time.Minute)) })},
+		{"nbf в будущем", sign(t, jwt.SigningMethodHS256, []byte(testJWTKey), func(c *accessClaims) { c.NotBefore = jwt.NewNumericDate(now.Add(time.Minute)) })},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.ValidateToken(context.Background(), tt.token)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+
+	_, err := svc.ValidateToken(context.Background(), sign(t, jwt.SigningMethodHS256, []byte(testJWTKey), func(*accessClaims) {}))
+	assert.NoError(t, err)
+}
+
+// Тест допустимого расхождения часов: токен принимается в пределах leeway после истечения
+// и до наступления nbf
+func TestValidateToken_Leeway(t *testing.T) {
+	repo := newFakeUserRepository()
+	fake := clock.NewFake(time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC))
+	svc := NewAuthService(repo, testJWTKey, WithClock(fake), WithTokenLeeway(30*time.Second))
+	token, _ := issueToken(t, svc, repo)
+
+	fake.Advance(-10 * time.Second)
+	_, err := svc.ValidateToken(context.Background(), token)
+	assert.NoError(t, err)
+
+	fake.Advance(AccessTokenTTL + 30*time.Second)
+	claims, err := svc.ValidateToken(context.Background(), token)
+	require.NoError(t, err)
+	assert.Zero(t, claims.ExpiresIn)
+
+	fake.Advance(10 * time.Second)
+	_, err = svc.ValidateToken(context.Background(), token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+// Тест расхождения часов между экземплярами: токен, выпущенный экземпляром, часы которого
+// спешат относительно проверяющего на 20 секунд, принимается с запасом по умолчанию,
+// а при расхождении в 2 минуты отклоняется; срок из ответа Login совпадает с claim exp
+func TestValidateToken_ClockSkew(t *testing.T) {
+	issued := time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		behind time.Duration
+		valid  bool
+	}{
+		{"отстает на 20 секунд", 20 * time.Second, true},
+		{"отстает на 2 минуты", 2 * time.Minute, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeUserRepository()
+			sessions := repository.NewInMemorySessionRepository()
+			issuerClock := clock.NewFake(issued)
+			validatorClock := clock.NewFake(issued.Add(-tt.behind))
+			issuer := NewAuthService(repo, testJWTKey, WithClock(issuerClock), WithSessionRepository(sessions))
+			validator := NewAuthService(repo, testJWTKey, WithClock(validatorClock), WithSessionRepository(sessions))
+			register(t, issuer, "user", "")
+
+			tokens, err := issuer.Login(context.Background(), "user", "password", ClientInfo{})
+			require.NoError(t, err)
+			assert.Equal(t, issued.Add(AccessTokenTTL), tokens.ExpiresAt)
+
+			claims, err := validator.ValidateToken(context.Background(), tokens.AccessToken)
+			if !tt.valid {
+				assert.ErrorIs(t, err, ErrInvalidToken)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, claims.ExpiresAt.Equal(tokens.ExpiresAt), claims.ExpiresAt)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"common/clock"
+)
+
+// userCacheMaxEntries ограничивает размер кэша; при превышении из него удаляются устаревшие записи.
+const userCacheMaxEntries = 100000
+
+// CacheStats содержит счетчики обращений к кэшу существующих пользователей.
+type CacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// HitRate возвращает долю попаданий в кэш от общего числа обращений.
+func (s CacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// userCache хранит ID пользователей, существование которых уже подтверждено базой данных.
+// Запись действует в течение ttl, поэтому удаление или блокировка пользователя без явной
+// инвалидации вступает в силу не позже чем через ttl. Нулевой *userCache отключает кэширование.
+type userCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.RWMutex
+	expires map[uuid.UUID]time.Time
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func newUserCache(ttl time.Duration, clk clock.Clock) *userCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &userCache{ttl: ttl, now: clk.Now, expires: make(map[uuid.UUID]time.Time)}
+}
+
+// contains сообщает, есть ли в кэше действующая запись для пользователя.
+func (c *userCache) contains(id uuid.UUID) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.RLock()
+	expiresAt, ok := c.expires[id]
+	c.mu.RUnlock()
+	if ok && c.now().Before(expiresAt) {
+		c.hits.Add(1)
+		return true
+	}
+	c.misses.Add(1)
+	return false
+}
+
+// add запоминает пользователя на время ttl.
+func (c *userCache) add(id uuid.UUID) {
+	if c == nil {
+		return
+	}
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.expires) >= userCacheMaxEntries {
+		for key, expiresAt := range c.expires {
+			if !now.Before(expiresAt) {
+				delete(c.expires, key)
+			}
+		}
+		if len(c.expires) >= userCacheMaxEntries {
+			clear(c.expires)
+		}
+	}
+	c.expires[id] = now.Add(c.ttl)
+}
+
+// invalidate удаляет пользователя из кэша, чтобы следующая проверка обратилась к базе данных.
+func (c *userCache) invalidate(id uuid.UUID) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	delete(c.expires, id)
+	c.mu.Unlock()
+}
+
+func (c *userCache) stats() CacheStats {
+	if c == nil {
+		return CacheStats{}
+	}
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"auth-service/internal/model"
+)
+
+// DefaultListUsersLimit — размер страницы ListUsers, если он не задан.
+const DefaultListUsersLimit = 50
+
+// MaxListUsersLimit — наибольший размер страницы ListUsers; список целиком выгружается
+// через ExportUsers или StreamUsers.
+const MaxListUsersLimit = 500
+
+// DefaultStreamUsersBatch — размер пакета StreamUsers, если он не задан. Наибольший размер
+// пакета — MaxListUsersLimit.
+const DefaultStreamUsersBatch = 100
+
+// ListUsers возвращает страницу пользователей, удовлетворяющих фильтру, и их общее число.
+// Нулевой Limit означает DefaultListUsersLimit. Некорректный фильтр — ErrInvalidUserFilter.
+
+func (s *authService) ListUsers(ctx context.Context, filter model.UserFilter) ([]*model.User, int, error) {
+	if err := validateUserFilter(filter); err != nil {
+		return nil, 0, err
+	}
+	if filter.Limit > MaxListUsersLimit {
+		return nil, 0, fmt.Errorf("%w: limit must not exceed %d", ErrInvalidUserFilter, MaxListUsersLimit)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DefaultListUsersLimit
+	}
+	return s.userRepo.List(ctx, filter)
+}
+
+// ExportUsers вызывает fn для каждого пользователя, удовлetворяющего фильтру, в порядке
+// сортировки. Пользователи читаются из базы данных построчно, поэтому выгрузка не зависит
+// от их числа по памяти; Limit и Offset фильтра не используются.
+
+func (s *authService) ExportUsers(ctx context.Context, filter model.UserFilter, fn func(*model.User) error) error {
+	if err := validateUserFilter(filter); err != nil {
+		return err
+	}
+	filter.Limit, filter.Offset = 0, 0
+	return s.userRepo.ForEach(ctx, filter, fn)
+}
+
+// StreamUsers передает в fn пользователей, удовлетворяющих фильтру, пакетами по batchSize
+// в порядке сортировки; нулевой batchSize означает DefaultStreamUsersBatch. Каждый пакет
+// читается из базы данных отдельным запросом, и следующий запрос выполняется только после
+// возврата из fn, поэтому между пакетами не удерживается ни курсор, ни соединение, а в памяти
+// находится не больше одного пакета. Последний пакет может быть неполным; пустых пакетов
+// fn не получает. Отмена ctx или ошибка fn прекращает чтение. Limit и Offset фильтра
+// не используются.
+
+func (s *authService) StreamUsers(ctx context.Context, filter model.UserFilter, batchSize int, fn func([]*model.User) error) error {
+	if err := validateUserFilter(filter); err != nil {
+		return err
+	}
+	if batchSize < 0 || batchSize > MaxListUsersLimit {
+		return fmt.Errorf("%w: batch size must be between 0 and %d", ErrInvalidUserFilter, MaxListUsersLimit)
+	}
+	if batchSize == 0 {
+		batchSize = DefaultStreamUsersBatch
+	}
+
+	filter.Limit = batchSize
+	for filter.Offset = 0; ; filter.Offset += batchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		batch := make([]*model.User, 0, batchSize)
+		err := s.userRepo.ForEach(ctx, filter, func(user *model.User) error {
+			batch = append(batch, user)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(batch) > 0 {
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+	}
+}
+
+// validateUserFilter проверяет поле сортировки, пагинацию и период регистрации фильтра.
+
+func validateUserFilter(filter model.UserFilter) error {
+	switch filter.SortBy {
+	case "", model.UserSortByCreatedAt, model.UserSortByUsername:
+	default:
+		return fmt.Errorf("%w: unknown sort field %q", ErrInvalidUserFilter, filter.SortBy)
+	}
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return fmt.Errorf("%w: limit and offset must not be negative", ErrInvalidUserFilter)
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return fmt.Errorf("%w: created_from must be before created_to", ErrInvalidUserFilter)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-service/internal/model"
+)
+
+// Тест списка пользователей: размер страницы по умолчанию, общее число и проверка фильтра
+func TestListUsers(t *testing.T) {
+	repo := newFakeUserRepository()
+	svc := NewAuthService(repo, testJWTKey)
+	ctx := context.Background()
+
+	// Пользователи добавляются в хранилище напрямую: регистрация хеширует пароль
+	for i := 0; i < DefaultListUsersLimit+5; i++ {
+		username := fmt.Sprintf("user%c%c", 'a'+i/26, 'a'+i%26)
+		require.NoError(t, repo.Create(ctx, &model.User{ID: uuid.New(), Username: username}))
+	}
+
+	users, total, err := svc.ListUsers(ctx, model.UserFilter{})
+	require.NoError(t, err)
+	assert.Len(t, users, DefaultListUsersLimit)
+	assert.Equal(t, DefaultListUsersLimit+5, total)
+
+	users, total, err = svc.ListUsers(ctx, model.UserFilter{UsernamePrefix: "userc", SortBy: model.UserSortByUsername, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "userca", users[0].Username)
+	assert.Equal(t, 3, total)
+
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+	for _, filter := range []model.UserFilter{
+		{SortBy: "password_hash"},
+		{Limit: MaxListUsersLimit + 1},
+		{Limit: -1},
+		{Offset: -1},
+		{CreatedFrom: &now, CreatedTo: &earlier},
+	} {
+		_, _, err := svc.ListUsers(ctx, filter)
+		assert.ErrorIs(t, err, ErrInvalidUserFilter, "%+v", filter)
+	}
+}
+
+// Тест выгрузки пользователей: пагинация не применяется, неверный фильтр отклоняется
+func TestExportUsers(t *testing.T) {
+	svc := NewAuthService(newFakeUserRepository(), testJWTKey)
+	ctx := context.Background()
+
+	register(t, svc, "alice", "")
+	register(t, svc, "bob", "")
+
+	var exported []string
+	err := svc.ExportUsers(ctx, model.UserFilter{SortBy: model.UserSortByUsername, Limit: 1, Offset: 1}, func(user *model.User) error {
+		exported = append(exported, user.Username)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, exported)
+
+	err = svc.ExportUsers(ctx, model.UserFilter{SortBy: "email"}, func(*model.User) error { return nil })
+	assert.ErrorIs(t, err, ErrInvalidUserFilter)
+}
+
+// queryCountingUserRepository считает запросы ForEach к хранилищу пользователей.
+type queryCountingUserRepository struct {
+	*fakeUserRepository
+	queries int
+}
+
+func (r *queryCountingUserRepository) ForEach(ctx context.Context, filter model.UserFilter, fn func(*model.User) error) error {
+	r.queries++
+	return r.fakeUserRepository.ForEach(ctx, filter, fn)
+}
+
+// Тест потоковой выгрузки пользователей на границах пакетов: каждый пользователь передается
+// ровно один раз, пустых пакетов нет, а после полного последнего пакета выполняется один
+// пустой запрос
+func TestStreamUsers_BatchBoundaries(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		users   int
+		batches []int
+		queries int
+	}{
+		{users: 0, batches: nil, queries: 1},
+		{users: 2, batches: []int{2}, queries: 1},
+		{users: 3, batches: []int{3}, queries: 2},
+		{users: 4, batches: []int{3, 1}, queries: 2},
+		{users: 6, batches: []int{3, 3}, queries: 3},
+	} {
+		repo := &queryCountingUserRepository{fakeUserRepository: newFakeUserRepository()}
+		svc := NewAuthService(repo, testJWTKey)
+		var want []string
+		for i := 0; i < tc.users; i++ {
+			username := fmt.Sprintf("user%d", i)
+			require.NoError(t, repo.Create(ctx, &model.User{Username: username}))
+			want = append(want, username)
+		}
+
+		var batches []int
+		var got []string
+		err := svc.StreamUsers(ctx, model.UserFilter{SortBy: model.UserSortByUsername, Limit: 1, Offset: 5}, 3, func(users []*model.User) error {
+			batches = append(batches, len(users))
+			for _, user := range users {
+				got = append(got, user.Username)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, tc.batches, batches, "users: %d", tc.users)
+		assert.Equal(t, want, got, "users: %d", tc.users)
+		assert.Equal(t, tc.queries, repo.queries, "users: %d", tc.users)
+	}
+}
+
+// Тест прекращения потоковой выгрузки: ошибка fn и отмена контекста прекращают чтение пакетов,
+// неверный размер пакета отклоняется
+func TestStreamUsers_Stop(t *testing.T) {
+	repo := &queryCountingUserRepository{fakeUserRepository: newFakeUserRepository()}
+	svc := NewAuthService(repo, testJWTKey)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, repo.Create(ctx, &model.User{Username: fmt.Sprintf("user%d", i)}))
+	}
+
+	err := svc.StreamUsers(ctx, model.UserFilter{}, 2, func([]*model.User) error { return assert.AnError })
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, 1, repo.queries)
+
+	repo.queries = 0
+	err = svc.StreamUsers(ctx, model.UserFilter{}, 2, func([]*model.User) error {
+		cancel()
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, repo.queries)
+
+	for _, batchSize := range []int{-1, MaxListUsersLimit + 1} {
+		err = svc.StreamUsers(context.Background(), model.UserFilter{}, batchSize, func([]*model.User) error { return nil })
+		assert.ErrorIs(t, err, ErrInvalidUserFilter)
+	}
+}
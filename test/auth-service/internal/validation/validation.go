@@ -0,0 +1,177 @@
+// Package validation проверяет запросы к AuthService до вызова обработчика.
+//
+// Правила каждого типа запроса описаны в Validate: обязательные поля, наибольшая длина
+// строк и число элементов. Перехватчик UnaryServerInterceptor отклоняет запрос, нарушающий
+// правила, с кодом InvalidArgument и деталями google.rpc.BadRequest по каждому полю, поэтому
+// слишком длинные пароль или токен не доходят до bcrypt и разбора JWT. Формат ID проверяют
+// обработчики, которым все равно нужно преобразовать их в UUID.
+package validation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "api/auth"
+	"auth-service/internal/service"
+)
+
+// Наибольшая длина полей запроса. Имя пользователя и email считаются в символах,
+// пароль и токены — в байтах.
+const (
+	MaxUsernameLength = 64
+	MaxPasswordLength = 256
+	MaxEmailLength    = 254
+	// MaxTokenLength ограничивает токены доступа, refresh-токены, токены подтверждения email,
+	// коды приглашений и ID-токены провайдеров OIDC.
+	MaxTokenLength = 8192
+	// MaxProviderLength ограничивает имя провайдера OIDC.
+	MaxProviderLength = 64
+)
+
+// FieldViolation описывает нарушение правила в одном поле запроса.
+type FieldViolation struct {
+	// Field — имя поля в .proto.
+	Field       string
+	Description string
+}
+
+// Error — ошибка проверки запроса со всеми нарушениями.
+type Error struct {
+	Violations []FieldViolation
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		parts = append(parts, v.Field+": "+v.Description)
+	}
+	return "invalid request: " + strings.Join(parts, "; ")
+}
+
+// Status возвращает статус InvalidArgument с деталями google.rpc.BadRequest.
+func (e *Error) Status() *status.Status {
+	st := status.New(codes.InvalidArgument, e.Error())
+	details := &errdetails.BadRequest{}
+	for _, v := range e.Violations {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+	if withDetails, err := st.WithDetails(details); err == nil {
+		return withDetails
+	}
+	return st
+}
+
+// UnaryServerInterceptor проверяет запрос через Validate и при нарушениях отвечает
+// InvalidArgument, не вызывая обработчик.
+func UnaryServerInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := Validate(req); err != nil {
+		return nil, err.Status().Err()
+	}
+	return handler(ctx, req)
+}
+
+// Validate проверяет запрос к AuthService. Возвращает nil для корректного запроса и для типов
+// без правил.
+func Validate(req any) *Error {
+	v := &validator{}
+	switch r := req.(type) {
+	case *pb.RegisterRequest:
+		v.username("username", r.Username)
+		v.password("password", r.Password)
+		v.maxRunes("email", r.Email, MaxEmailLength)
+	case *pb.LoginRequest:
+		v.username("username", r.Username)
+		v.password("password", r.Password)
+	case *pb.ValidateTokenRequest:
+		v.token("token", r.Token)
+	case *pb.RefreshRequest:
+		v.token("refresh_token", r.RefreshToken)
+	case *pb.GetUsersRequest:
+		if len(r.UserIds) > service.MaxGetUsers {
+			v.add("user_ids", fmt.Sprintf("must contain at most %d IDs", service.MaxGetUsers))
+		}
+	case *pb.UpdateEmailRequest:
+		v.maxRunes("email", r.Email, MaxEmailLength)
+	case *pb.VerifyEmailRequest:
+		v.token("token", r.Token)
+	case *pb.VerifyPasswordRequest:
+		v.maxBytes("password", r.Password, MaxPasswordLength)
+	case *pb.InviteToOrganizationRequest:
+		v.username("username", r.Username)
+	case *pb.CreateInviteRequest:
+		if r.TtlSeconds < 0 {
+			v.add("ttl_seconds", "must not be negative")
+		}
+	case *pb.AcceptInviteRequest:
+		v.token("code", r.Code)
+	case *pb.ImpersonateUserRequest:
+		v.token("admin_token", r.AdminToken)
+	case *pb.ExchangeOIDCTokenRequest:
+		if v.required("provider", r.Provider) {
+			v.maxRunes("provider", r.Provider, MaxProviderLength)
+		}
+		v.token("id_token", r.IdToken)
+	}
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &Error{Violations: v.violations}
+}
+
+// validator накапливает нарушения правил.
+type validator struct {
+	violations []FieldViolation
+}
+
+func (v *validator) add(field, description string) {
+	v.violations = append(v.violations, FieldViolation{Field: field, Description: description})
+}
+
+// required отмечает пустое поле и сообщает, заполнено ли оно.
+func (v *validator) required(field, value string) bool {
+	if value == "" {
+		v.add(field, "is required")
+		return false
+	}
+	return true
+}
+
+func (v *validator) maxRunes(field, value string, limit int) {
+	if utf8.RuneCountInString(value) > limit {
+		v.add(field, fmt.Sprintf("must be at most %d characters", limit))
+	}
+}
+
+func (v *validator) maxBytes(field, value string, limit int) {
+	if len(value) > limit {
+		v.add(field, fmt.Sprintf("must be at most %d bytes", limit))
+	}
+}
+
+func (v *validator) username(field, value string) {
+	if v.required(field, value) {
+		v.maxRunes(field, value, MaxUsernameLength)
+	}
+}
+
+func (v *validator) password(field, value string) {
+	if v.required(field, value) {
+		v.maxBytes(field, value, MaxPasswordLength)
+	}
+}
+
+func (v *validator) token(field, value string) {
+	if v.required(field, value) {
+		v.maxBytes(field, value, MaxTokenLength)
+	}
+}
@@ -0,0 +1,88 @@
+package validation
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "api/auth"
+	"auth-service/internal/service"
+)
+
+// Тест правил проверки запросов: для каждого запроса ожидаемый список полей с нарушениями
+func TestValidate(t *testing.T) {
+	tooManyIDs := make([]string, service.MaxGetUsers+1)
+	tests := []struct {
+		name string
+		req  any
+		want []string
+	}{
+		{"register", &pb.RegisterRequest{Username: "user", Password: "password", Email: "user@example.com"}, nil},
+		{"register empty", &pb.RegisterRequest{}, []string{"username", "password"}},
+		{"register username at limit", &pb.RegisterRequest{Username: strings.Repeat("я", MaxUsernameLength), Password: "password"}, nil},
+		{"register username too long", &pb.RegisterRequest{Username: strings.Repeat("u", MaxUsernameLength+1), Password: "password"}, []string{"username"}},
+		{"register password too long", &pb.RegisterRequest{Username: "user", Password: strings.Repeat("p", MaxPasswordLength+1)}, []string{"password"}},
+		{"register email too long", &pb.RegisterRequest{Username: "user", Password: "password", Email: strings.Repeat("e", MaxEmailLength+1)}, []string{"email"}},
+		{"login", &pb.LoginRequest{Username: "user", Password: "password"}, nil},
+		{"login no password", &pb.LoginRequest{Username: "user"}, []string{"password"}},
+		{"login password at limit", &pb.LoginRequest{Username: "user", Password: strings.Repeat("p", MaxPasswordLength)}, nil},
+		{"validate token", &pb.ValidateTokenRequest{Token: "jwt"}, nil},
+		{"validate token empty", &pb.ValidateTokenRequest{}, []string{"token"}},
+		{"validate token too long", &pb.ValidateTokenRequest{Token: strings.Repeat("t", MaxTokenLength+1)}, []string{"token"}},
+		{"refresh empty", &pb.RefreshRequest{}, []string{"refresh_token"}},
+		{"refresh too long", &pb.RefreshRequest{RefreshToken: strings.Repeat("t", MaxTokenLength+1)}, []string{"refresh_token"}},
+		{"get users", &pb.GetUsersRequest{UserIds: tooManyIDs[:service.MaxGetUsers]}, nil},
+		{"get users too many", &pb.GetUsersRequest{UserIds: tooManyIDs}, []string{"user_ids"}},
+		{"update email too long", &pb.UpdateEmailRequest{Email: strings.Repeat("e", MaxEmailLength+1)}, []string{"email"}},
+		{"verify email empty", &pb.VerifyEmailRequest{UserId: "id"}, []string{"token"}},
+		{"verify password too long", &pb.VerifyPasswordRequest{Password: strings.Repeat("p", MaxPasswordLength+1)}, []string{"password"}},
+		{"invite no username", &pb.InviteToOrganizationRequest{}, []string{"username"}},
+		{"create invite negative ttl", &pb.CreateInviteRequest{TtlSeconds: -1}, []string{"ttl_seconds"}},
+		{"accept invite empty", &pb.AcceptInviteRequest{}, []string{"code"}},
+		{"impersonate no admin token", &pb.ImpersonateUserRequest{UserId: "id"}, []string{"admin_token"}},
+		{"oidc", &pb.ExchangeOIDCTokenRequest{Provider: "corp", IdToken: "token"}, nil},
+		{"oidc empty", &pb.ExchangeOIDCTokenRequest{}, []string{"provider", "id_token"}},
+		{"oidc long provider", &pb.ExchangeOIDCTokenRequest{Provider: strings.Repeat("p", MaxProviderLength+1), IdToken: "token"}, []string{"provider"}},
+		{"request without rules", &pb.ListSessionsRequest{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.req)
+			if tt.want == nil {
+				assert.Nil(t, err)
+				return
+			}
+			require.NotNil(t, err)
+			var fields []string
+			for _, violation := range err.Violations {
+				fields = append(fields, violation.Field)
+			}
+			assert.Equal(t, tt.want, fields)
+		})
+	}
+}
+
+// Тест перехватчика: некорректный запрос отклоняется без вызова обработчика, корректный передается ему
+func TestUnaryServerInterceptor(t *testing.T) {
+	called := false
+	handler := func(context.Context, any) (any, error) {
+		called = true
+		return "ok", nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: pb.AuthService_Login_FullMethodName}
+
+	_, err := UnaryServerInterceptor(context.Background(), &pb.LoginRequest{}, info, handler)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.False(t, called)
+
+	resp, err := UnaryServerInterceptor(context.Background(), &pb.LoginRequest{Username: "user", Password: "password"}, info, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+	assert.True(t, called)
+}
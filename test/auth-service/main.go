@@ -1,104 +1,385 @@
-package main
-
-import (
-	"context"
-	"database/sql"
-	"fmt"
-	"log"
-	"net"
-	"os"
-
-	"auth-service/internal/handler"
-	pb "auth-service/internal/proto"
-	"auth-service/internal/repository"
-	"auth-service/internal/service"
-
-	"github.com/uptrace/bun"
-	"github.com/uptrace/bun/dialect/pgdialect"
-	"github.com/uptrace/bun/driver/pgdriver"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/reflection"
-)
-
-// Основная функция программы, которая запускает gRPC-сервер аутентификации.
-// Устанавливает соединение с базой данных PostgreSQL, создает сервисы и запускает сервер.
-func main() {
-	// Загружаем конфигурационные параметры из переменных окружения
-	dbHost := getEnv("DB_HOST", "postgres")
-	dbPort := getEnv("DB_PORT", "5432")
-	dbUser := getEnv("DB_USER", "postgres")
-	dbPassword := getEnv("DB_PASSWORD", "postgres")
-	dbName := getEnv("DB_NAME", "auth_service")
-	jwtKey := getEnv("JWT_KEY", "59347add01aacae058d36f0593c39412cec5630e66fbc290ecb933024514189d60da0cbc9b3184b721373415cee4eccf4aeff3e6c1518d97cd38c8e83dd58a17896841a6e8f36e999cff36bb56b8bf91844082a64c0ff92c618cdb484e7fb54773731d41d73d78eb72056a1c5411781b928018a5ae930cdd07253b061edfbaf437054d6c76d5b105318fe5d6ff56b868de0da03be72332ae752cf0e05e757718e9404ac4d1fc69c301f316602658ae242e19025da4ea8f96ab5b7910597e25fc02b5a9660729b888d66f0e0bf93a685172e91a0d0029c75610421bb51b8a5c436090208119e327fe5235e4d5d3ce34d09de562eb887c23257514ca65a3b759f1")
-	grpcPort := getEnv("GRPC_PORT", "50051")
-
-	// Формируем строку подключения к PostgreSQL
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		dbUser, dbPassword, dbHost, dbPort, dbName)
-
-	// Создаем подключение к базе данных
-	sqldb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn)))
-	db := bun.NewDB(sqldb, pgdialect.New())
-
-	// Проверяем соединение с базой данных
-	if err := checkDatabaseConnection(db); err != nil {
-		log.Fatalf("Cannot proceed due to database connection failure: %v", err)
-	}
-
-	// Создаем репозиторий и сервис для работы с пользователями
-	userRepo := repository.NewUserRepository(db)
-	authService := service.NewAuthService(userRepo, jwtKey)
-
-	// Создаем TCP-соединение для gRPC-сервера
-	lis, err := net.Listen("tcp", ":"+grpcPort)
-	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
-	}
-
-	// Создаем gRPC-сервер с обработчиком контекста
-	grpcServer := grpc.NewServer(
-		grpc.ChainUnaryInterceptor(
-			func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-				if err := ctx.Err(); err != nil {
-					return nil, err
-				}
-				return handler(ctx, req)
-			},
-		),
-	)
-
-	// Регистрируем рефлексию для gRPC
-	reflection.Register(grpcServer)
-
-	// Создаем и регистрируем обработчик аутентификации
-	authHandler := handler.NewAuthHandler(authService)
-	pb.RegisterAuthServiceServer(grpcServer, authHandler)
-
-	// Запускаем сервер
-	log.Printf("Starting gRPC server on port %s", grpcPort)
-	if err := grpcServer.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
-	}
-}
-
-// Проверяет соединение с базой данных.
-// Возвращает ошибку, если соединение невозможно установить.
-func checkDatabaseConnection(db *bun.DB) error {
-	err := db.Ping()
-	if err != nil {
-		log.Printf("Database connection test failed: %v", err)
-		return err
-	}
-	log.Println("Database connection successful!")
-	return nil
-}
-
-// Получает значение переменной окружения с указанным именем.
-// Если переменная не установлена, возвращает значение по умолчанию.
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
-	}
-	return value
-}
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expvar"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	pb "api/auth"
+	"auth-service/internal/faults"
+	"auth-service/internal/gateway"
+	"auth-service/internal/oidc"
+	"auth-service/internal/password"
+	"auth-service/internal/repository"
+	"auth-service/internal/retention"
+	"auth-service/internal/server"
+	"auth-service/internal/service"
+	"common/buildinfo"
+	"common/clientip"
+	"common/diagnostics"
+	"common/env"
+	"common/selfcheck"
+	"common/slo"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+)
+
+// Основная функция программы, которая запускает gRPC-сервер аутентификации.
+// Устанавливает соединение с базой данных PostgreSQL, создает сервисы и запускает сервер.
+// С флагом --check только проверяет готовность к запуску и завершается с кодом 0 или 1.
+func main() {
+	check := flag.Bool("check", false, "check configuration and dependencies, then exit")
+	jsonOutput := flag.Bool("json", false, "print the --check report as JSON")
+	flag.Parse()
+
+	// Загружаем конфигурационные параметры из переменных окружения
+	dbHost := env.Get("DB_HOST", "postgres")
+	dbPort := env.Get("DB_PORT", "5432")
+	dbUser := env.Get("DB_USER", "postgres")
+	dbPassword := env.Get("DB_PASSWORD", "postgres")
+	dbName := env.Get("DB_NAME", "auth_service")
+	jwtKey := env.Get("JWT_KEY", "59347add01aacae058d36f0593c39412cec5630e66fbc290ecb933024514189d60da0cbc9b3184b721373415cee4eccf4aeff3e6c1518d97cd38c8e83dd58a17896841a6e8f36e999cff36bb56b8bf91844082a64c0ff92c618cdb484e7fb54773731d41d73d78eb72056a1c5411781b928018a5ae930cdd07253b061edfbaf437054d6c76d5b105318fe5d6ff56b868de0da03be72332ae752cf0e05e757718e9404ac4d1fc69c301f316602658ae242e19025da4ea8f96ab5b7910597e25fc02b5a9660729b888d66f0e0bf93a685172e91a0d0029c75610421bb51b8a5c436090208119e327fe5235e4d5d3ce34d09de562eb887c23257514ca65a3b759f1")
+	grpcPort := env.Get("GRPC_PORT", "50051")
+	httpPort := env.Get("HTTP_PORT", "8081")
+	// Время, в течение которого подтвержденный пользователь не перепроверяется в базе данных.
+	// Удаление пользователя вступает в силу для уже выданных токенов не позже чем через это время
+	userCacheTTL := env.Duration("USER_CACHE_TTL", 30*time.Second)
+	// Допустимое расхождение часов экземпляров сервиса при проверке сроков токенов
+	tokenLeeway := env.Duration("JWT_LEEWAY", service.DefaultTokenLeeway)
+	queryTimeout := env.Duration("DB_QUERY_TIMEOUT", repository.DefaultQueryTimeout)
+	slowQueryThreshold := env.Duration("DB_SLOW_QUERY_THRESHOLD", 500*time.Millisecond)
+	enablePprof := env.Get("ENABLE_PPROF", "false") == "true"
+	debugPort := env.Get("DEBUG_PORT", "6061")
+	// Секрет внутренних сервисов; предыдущий секрет принимается во время его смены
+	internalToken := env.Secret("INTERNAL_TOKEN")
+	previousInternalToken := env.Secret("INTERNAL_TOKEN_PREVIOUS")
+
+	// Удаление давно истекших сеансов и токенов подтверждения email по умолчанию отключено
+	retentionCfg := retention.Config{
+		Period:     env.Duration("RETENTION_PERIOD", 0),
+		Interval:   env.Duration("RETENTION_INTERVAL", retention.DefaultInterval),
+		BatchSize:  env.Int("RETENTION_BATCH_SIZE", retention.DefaultBatchSize),
+		BatchPause: env.Duration("RETENTION_BATCH_PAUSE", retention.DefaultBatchPause),
+	}
+
+	// В режиме DEV_INMEMORY сервис работает без PostgreSQL, данные хранятся в памяти
+	devInMemory := env.Get("DEV_INMEMORY", "false") == "true"
+	// Организации (общая очередь заявок нескольких пользователей) по умолчанию отключены
+	organizationsEnabled := env.Get("ORGANIZATIONS_ENABLED", "false") == "true"
+	// Формируем строку подключения к PostgreSQL
+	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		dbUser, dbPassword, dbHost, dbPort, dbName)
+	// Вход по ID-токенам внешних провайдеров OIDC: список имен провайдеров через запятую,
+	// параметры каждого — в переменных OIDC_<ИМЯ>_ISSUER, OIDC_<ИМЯ>_CLIENT_ID и других
+	oidcProviders, err := oidc.ParseProviders(env.Get("OIDC_PROVIDERS", ""), os.Getenv)
+	if err != nil {
+		log.Fatalf("Invalid OIDC configuration: %v", err)
+	}
+	passwordHasher := env.Get("PASSWORD_HASHER", password.AlgorithmBcrypt)
+	bcryptCost := env.Int("BCRYPT_COST", 12)
+
+	// С флагом --check сервис только проверяет готовность к запуску и завершается
+	if *check {
+		selfcheck.Exit(selfcheck.Run(context.Background(), checkProbes(checkConfig{
+			DSN:            dsn,
+			DevInMemory:    devInMemory,
+			MigrationsDir:  env.Get("MIGRATIONS_DIR", "migrations"),
+			JWTKey:         jwtKey,
+			PasswordHasher: passwordHasher,
+			BcryptCost:     bcryptCost,
+		})), *jsonOutput)
+	}
+
+	// Сведения о сборке записываются в журнал первыми, чтобы по журналу было видно,
+	// какая версия сервиса запущена
+	slog.Info("Starting auth-service", "build", buildinfo.Get())
+
+	// Хеширование паролей: bcrypt (стоимость BCRYPT_COST) или argon2id. Хеши с другими
+	// параметрами продолжают приниматься и перехешируются при входе
+	passwords, err := password.New(passwordHasher, bcryptCost)
+	if err != nil {
+		log.Fatalf("Invalid password hashing configuration: %v", err)
+	}
+	// Перец — секрет сервера, без которого утекшие хеши паролей нельзя перебрать.
+	// Хеши без перца принимаются и перехешируются при входе
+	pepper := env.Secret("PASSWORD_PEPPER")
+	if pepper == "" && env.Get("APP_ENV", "development") == "production" {
+		log.Println("PASSWORD_PEPPER is not set: password hashes are stored without a pepper")
+	}
+	passwords = password.WithPepper(passwords, pepper)
+
+	// Создаем репозиторий и сервис для работы с пользователями
+	var userRepo repository.UserRepository
+	var sessionRepo repository.SessionRepository
+	var deviceRepo repository.DeviceRepository
+	var orgRepo repository.OrganizationRepository
+	var identityRepo repository.ExternalIdentityRepository
+	var sqldb *sql.DB
+	if devInMemory {
+		log.Println("DEV_INMEMORY is enabled: users are kept in memory and lost on restart")
+		userRepo = repository.NewInMemoryUserRepository()
+		sessionRepo = repository.NewInMemorySessionRepository()
+		deviceRepo = repository.NewInMemoryDeviceRepository()
+		orgRepo = repository.NewInMemoryOrganizationRepository()
+		identityRepo = repository.NewInMemoryExternalIdentityRepository()
+	} else {
+		// Создаем подключение к базе данных
+		sqldb = sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn)))
+		db := bun.NewDB(sqldb, pgdialect.New())
+		db.AddQueryHook(&repository.SlowQueryHook{Threshold: slowQueryThreshold})
+
+		// Проверяем соединение с базой данных
+		if err := checkDatabaseConnection(db); err != nil {
+			log.Fatalf("Cannot proceed due to database connection failure: %v", err)
+		}
+
+		userRepo = repository.NewUserRepository(db, repository.WithQueryTimeout(queryTimeout))
+		sessionRepo = repository.NewSessionRepository(db, repository.WithQueryTimeout(queryTimeout))
+		deviceRepo = repository.NewDeviceRepository(db, repository.WithQueryTimeout(queryTimeout))
+		orgRepo = repository.NewOrganizationRepository(db, repository.WithQueryTimeout(queryTimeout))
+		identityRepo = repository.NewExternalIdentityRepository(db, repository.WithQueryTimeout(queryTimeout))
+	}
+	authOpts := []service.Option{
+		service.WithUserCacheTTL(userCacheTTL),
+		service.WithSessionRepository(sessionRepo),
+		service.WithDeviceRepository(deviceRepo),
+		service.WithPasswordHasher(passwords),
+		service.WithTokenLeeway(tokenLeeway),
+		service.WithMaxConcurrentHashes(env.Int("MAX_CONCURRENT_HASHES", runtime.GOMAXPROCS(0))),
+	}
+	if organizationsEnabled {
+		authOpts = append(authOpts, service.WithOrganizations(orgRepo))
+	}
+	if len(oidcProviders) > 0 {
+		verifier, err := oidc.NewVerifier(oidcProviders, oidc.WithLeeway(tokenLeeway))
+		if err != nil {
+			log.Fatalf("Invalid OIDC configuration: %v", err)
+		}
+		authOpts = append(authOpts, service.WithOIDC(verifier, identityRepo))
+		log.Printf("OIDC login is enabled for %d providers", len(oidcProviders))
+	}
+	authService := service.NewAuthService(userRepo, jwtKey, authOpts...)
+
+	// Публикуем статистику кэша пользователей, она доступна по адресу /debug/vars HTTP-шлюза
+	expvar.Publish("user_cache", expvar.Func(func() any {
+		stats := authService.CacheStats()
+		return map[string]any{"hits": stats.Hits, "misses": stats.Misses, "hit_rate": stats.HitRate()}
+	}))
+
+	// Публикуем сведения о сборке, число горутин и состояние пула соединений с базой данных
+	buildinfo.Publish()
+	diagnostics.PublishRuntimeStats(sqldb)
+
+	// Запускаем удаление устаревших записей; оно останавливается вместе с серверами
+	retentionCtx, stopRetention := context.WithCancel(context.Background())
+	retentionDone := make(chan struct{})
+	if retentionCfg.Period > 0 {
+		purger := retention.New(sessionRepo, userRepo, retentionCfg)
+		go func() {
+			defer close(retentionDone)
+			purger.Run(retentionCtx)
+		}()
+	} else {
+		close(retentionDone)
+	}
+
+	// Создаем TCP-соединение для gRPC-сервера
+	lis, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		log.Fatalf("failed to listen: %v", err)
+	}
+
+	// Вызовы принимаются только от внутренних сервисов, знающих секрет. Без секрета
+	// (например, при локальной разработке) проверка отключается
+	if internalToken == "" && previousInternalToken == "" {
+		log.Println("INTERNAL_TOKEN is not set: internal service authentication is disabled")
+	}
+	healthServer := health.NewServer()
+	// Цели уровня обслуживания учитываются перехватчиком, показатели отдает HTTP-шлюз
+	objectives := sloRegistry()
+	grpcServer := server.New(authService, server.Config{
+		InternalTokens: []string{internalToken, previousInternalToken},
+		Health:         healthServer,
+		// Ограничения защищают сервер от клиентов, открывающих слишком много вызовов
+		// или отправляющих слишком большие сообщения
+		MaxRecvMsgSize:               env.Int("GRPC_MAX_RECV_MSG_SIZE", server.DefaultMaxMsgSize),
+		MaxSendMsgSize:               env.Int("GRPC_MAX_SEND_MSG_SIZE", server.DefaultMaxMsgSize),
+		MaxConcurrentStreams:         uint32(env.Int("GRPC_MAX_CONCURRENT_STREAMS", 100)),
+		KeepaliveMinTime:             env.Duration("GRPC_KEEPALIVE_MIN_TIME", 30*time.Second),
+		KeepalivePermitWithoutStream: env.Get("GRPC_KEEPALIVE_PERMIT_WITHOUT_STREAM", "false") == "true",
+		ConnectionTimeout:            env.Duration("GRPC_CONNECTION_TIMEOUT", 10*time.Second),
+		// Обработчик не должен занимать горутину дольше, чем клиент готов ждать ответа
+		DefaultTimeout: env.Duration("GRPC_DEFAULT_TIMEOUT", server.DefaultTimeout),
+		MethodTimeouts: getEnvTimeouts("GRPC_METHOD_TIMEOUTS", server.DefaultMethodTimeouts),
+		Faults:         faultInjector(),
+		SLO:            objectives,
+	})
+
+	// Запускаем gRPC-сервер
+	go func() {
+		log.Printf("Starting gRPC server on port %s", grpcPort)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("failed to serve: %v", err)
+		}
+	}()
+
+	// HTTP-шлюз обращается к gRPC-серверу того же процесса, поэтому запросы
+	// через него проходят те же перехватчики, что и обычные gRPC-вызовы. Секрет
+	// внутренних сервисов шлюз не добавляет: вызывающий передает его сам
+	// в заголовке X-Internal-Token
+	gatewayConn, err := grpc.NewClient("localhost:"+grpcPort, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("failed to create gateway client: %v", err)
+	}
+	defer gatewayConn.Close()
+
+	// IP клиента для сеансов шлюз определяет по X-Forwarded-For только от прокси из TRUSTED_PROXIES
+	trustedProxies, err := clientip.ParseTrustedProxies(env.List("TRUSTED_PROXIES", ""))
+	if err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/", gateway.New(pb.NewAuthServiceClient(gatewayConn), trustedProxies))
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	if objectives != nil {
+		mux.Handle("GET /metrics", objectives.MetricsHandler())
+	}
+
+	httpServer := &http.Server{
+		Addr:              ":" + httpPort,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		log.Printf("Starting HTTP gateway on port %s", httpPort)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("failed to serve HTTP gateway: %v", err)
+		}
+	}()
+
+	// Сервер pprof запускается на отдельном порту только при ENABLE_PPROF=true.
+	// WriteTimeout не задается: снятие профиля CPU по умолчанию длится 30 секунд
+	var debugServer *http.Server
+	if enablePprof {
+		debugServer = &http.Server{
+			Addr:              ":" + debugPort,
+			Handler:           diagnostics.NewHandler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			log.Printf("Starting diagnostics server on port %s", debugPort)
+			if err := debugServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("diagnostics server stopped: %v", err)
+			}
+		}()
+	}
+
+	// Ожидаем сигнал завершения и останавливаем серверы
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down servers...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		log.Printf("HTTP gateway shutdown error: %v", err)
+	}
+	if debugServer != nil {
+		_ = debugServer.Close()
+	}
+	healthServer.Shutdown()
+	grpcServer.GracefulStop()
+	stopRetention()
+	select {
+	case <-retentionDone:
+	case <-ctx.Done():
+		log.Printf("retention purge did not stop in time: %v", ctx.Err())
+	}
+	log.Println("Servers stopped")
+}
+
+// Проверяет соединение с базой данных.
+// Возвращает ошибку, если соединение невозможно установить.
+func checkDatabaseConnection(db *bun.DB) error {
+	err := db.Ping()
+	if err != nil {
+		log.Printf("Database connection test failed: %v", err)
+		return err
+	}
+	log.Println("Database connection successful!")
+	return nil
+}
+
+// Создает перехватчик сбоев по переменным FAULT_INJECTION_ENABLED и FAULT_INJECTION_RULES.
+// Возвращает nil, если внесение сбоев не включено или сервис запущен в production-режиме.
+func faultInjector() *faults.Injector {
+	if env.Get("FAULT_INJECTION_ENABLED", "false") != "true" {
+		return nil
+	}
+	if env.Get("APP_ENV", "development") == "production" {
+		log.Println("FAULT_INJECTION_ENABLED is ignored in production")
+		return nil
+	}
+	rules, err := faults.ParseRules(env.Get("FAULT_INJECTION_RULES", ""))
+	if err != nil {
+		log.Fatalf("invalid FAULT_INJECTION_RULES: %v", err)
+	}
+	log.Printf("fault injection is enabled with %d rules", len(rules))
+	return faults.NewInjector(rules)
+}
+
+// Создает учет целей уровня обслуживания по переменным SLO_OBJECTIVES и SLO_PERIOD.
+// Возвращает nil, если цели не заданы.
+func sloRegistry() *slo.Registry {
+	objectives, err := slo.ParseObjectives(env.Get("SLO_OBJECTIVES", ""))
+	if err != nil {
+		log.Fatalf("invalid SLO_OBJECTIVES: %v", err)
+	}
+	if len(objectives) == 0 {
+		return nil
+	}
+	registry, err := slo.New(objectives, env.Duration("SLO_PERIOD", slo.DefaultPeriod), nil)
+	if err != nil {
+		log.Fatalf("failed to create SLO registry: %v", err)
+	}
+	return registry
+}
+
+// Получает ограничения времени по методам из переменной окружения в формате
+// "ValidateToken=1s,Register=15s" и дополняет ими defaults. Некорректные элементы пропускаются.
+func getEnvTimeouts(key string, defaults map[string]time.Duration) map[string]time.Duration {
+	timeouts := make(map[string]time.Duration, len(defaults))
+	for method, timeout := range defaults {
+		timeouts[method] = timeout
+	}
+	for _, item := range env.List(key, "") {
+		method, value, _ := strings.Cut(item, "=")
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			log.Printf("Invalid timeout %q in %s, skipping: %v", item, key, err)
+			continue
+		}
+		timeouts[strings.TrimSpace(method)] = timeout
+	}
+	return timeouts
+}
@@ -1,2 +1,2 @@
--- auth-service/migrations/000001_create_users_table.down.sql
+-- auth-service/migrations/000001_create_users_table.down.sql
 DROP TABLE users;
\ No newline at end of file
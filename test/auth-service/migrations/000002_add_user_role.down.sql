@@ -0,0 +1,2 @@
+-- auth-service/migrations/000002_add_user_role.down.sql
+ALTER TABLE users DROP COLUMN role;
\ No newline at end of file
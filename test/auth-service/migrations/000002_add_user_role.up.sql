@@ -0,0 +1,2 @@
+-- auth-service/migrations/000002_add_user_role.up.sql
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user';
@@ -0,0 +1,6 @@
+-- auth-service/migrations/000003_add_user_email.down.sql
+ALTER TABLE users
+    DROP COLUMN email_verification_expires_at,
+    DROP COLUMN email_verification_hash,
+    DROP COLUMN email_verified,
+    DROP COLUMN email;
@@ -0,0 +1,6 @@
+-- auth-service/migrations/000003_add_user_email.up.sql
+ALTER TABLE users
+    ADD COLUMN email VARCHAR(254) UNIQUE,
+    ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN email_verification_hash VARCHAR(64),
+    ADD COLUMN email_verification_expires_at TIMESTAMP WITH TIME ZONE;
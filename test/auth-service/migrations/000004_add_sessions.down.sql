@@ -0,0 +1,2 @@
+-- auth-service/migrations/000004_add_sessions.down.sql
+DROP TABLE sessions;
@@ -0,0 +1,2 @@
+-- auth-service/migrations/000005_add_known_devices.down.sql
+DROP TABLE known_devices;
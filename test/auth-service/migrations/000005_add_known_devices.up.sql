@@ -0,0 +1,10 @@
+-- auth-service/migrations/000005_add_known_devices.up.sql
+CREATE TABLE known_devices (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    fingerprint VARCHAR(64) NOT NULL,
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    first_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, fingerprint)
+);
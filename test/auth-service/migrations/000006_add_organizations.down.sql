@@ -0,0 +1,3 @@
+-- auth-service/migrations/000006_add_organizations.down.sql
+DROP TABLE organization_members;
+DROP TABLE organizations;
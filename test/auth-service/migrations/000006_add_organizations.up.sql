@@ -0,0 +1,15 @@
+-- auth-service/migrations/000006_add_organizations.up.sql
+CREATE TABLE organizations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Пользователь состоит не более чем в одной организации
+CREATE TABLE organization_members (
+    org_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL UNIQUE REFERENCES users (id) ON DELETE CASCADE,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'member')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (org_id, user_id)
+);
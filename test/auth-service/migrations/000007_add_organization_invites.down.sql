@@ -0,0 +1,2 @@
+-- auth-service/migrations/000007_add_organization_invites.down.sql
+DROP TABLE organization_invites;
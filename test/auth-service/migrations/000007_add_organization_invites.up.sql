@@ -0,0 +1,15 @@
+-- auth-service/migrations/000007_add_organization_invites.up.sql
+-- Приглашения в организации; код хранится в виде хеша SHA-256
+CREATE TABLE organization_invites (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_by UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    accepted_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX organization_invites_org_id_idx ON organization_invites (org_id);
@@ -0,0 +1,3 @@
+-- auth-service/migrations/000008_add_users_listing_indexes.down.sql
+DROP INDEX users_username_pattern_idx;
+DROP INDEX users_created_at_idx;
@@ -0,0 +1,6 @@
+-- auth-service/migrations/000008_add_users_listing_indexes.up.sql
+-- Индексы списка пользователей для администраторов: сортировка и отбор по времени регистрации
+-- и отбор по началу имени (LIKE 'префикс%' использует индекс text_pattern_ops при любой
+-- правиле сортировки базы данных)
+CREATE INDEX users_created_at_idx ON users (created_at, id);
+CREATE INDEX users_username_pattern_idx ON users (username text_pattern_ops);
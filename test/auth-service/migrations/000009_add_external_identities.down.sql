@@ -0,0 +1,2 @@
+-- auth-service/migrations/000009_add_external_identities.down.sql
+DROP TABLE external_identities;
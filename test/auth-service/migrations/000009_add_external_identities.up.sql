@@ -0,0 +1,13 @@
+-- auth-service/migrations/000009_add_external_identities.up.sql
+-- Учетные записи внешних провайдеров OIDC, связанные с пользователями; учетная запись
+-- определяется постоянным ID у провайдера (claim sub)
+CREATE TABLE external_identities (
+    provider VARCHAR(64) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    email VARCHAR(254),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX external_identities_user_id_idx ON external_identities (user_id);
@@ -0,0 +1,184 @@
+// Package local открывает сервис аутентификации для вызовов в том же процессе, без gRPC.
+// Его использует объединенный бинарник call-service (call-service/cmd/monolith), которому
+// не нужен отдельный процесс сервиса аутентификации.
+//
+// Пакеты internal недоступны другим модулям, поэтому local повторяет нужные типы
+// internal/service и internal/model через псевдонимы, а ошибки сервиса переводит в статусы
+// gRPC с теми же кодами, что и gRPC-обработчик: вызывающий обрабатывает их так же, как
+// ответы отдельного сервиса.
+package local
+
+import (
+	"errors"
+	"time"
+
+	"github.com/uptrace/bun"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"auth-service/internal/model"
+	"auth-service/internal/oidc"
+	"auth-service/internal/password"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+)
+
+// Типы сервиса аутентификации, доступные вызывающим из других модулей
+type (
+	AuthService        = service.AuthService
+	ClientInfo         = service.ClientInfo
+	Tokens             = service.Tokens
+	TokenClaims        = service.TokenClaims
+	UserExport         = service.UserExport
+	User               = model.User
+	UserFilter         = model.UserFilter
+	Session            = model.Session
+	KnownDevice        = model.KnownDevice
+	Organization       = model.Organization
+	OrganizationMember = model.OrganizationMember
+	OrganizationInvite = model.OrganizationInvite
+	OIDCProvider       = oidc.ProviderConfig
+)
+
+// ParseOIDCProviders читает конфигурацию провайдеров OIDC из переменных окружения так же,
+// как отдельный сервис аутентификации (см. oidc.ParseProviders).
+var ParseOIDCProviders = oidc.ParseProviders
+
+// ErrInvalidToken возвращается AuthService.ValidateToken для недействительного токена.
+// Это не ошибка вызова: gRPC-обработчик отвечает на нее Valid: false.
+var ErrInvalidToken = service.ErrInvalidToken
+
+// DefaultBcryptCost — стоимость bcrypt по умолчанию, как у отдельного сервиса аутентификации.
+const DefaultBcryptCost = 12
+
+// MaxGetUsers — наибольшее число ID в одном вызове AuthService.GetUsers.
+const MaxGetUsers = service.MaxGetUsers
+
+// Config задает сервис аутентификации, работающий в процессе вызывающего.
+type Config struct {
+	// JWTKey — ключ подписи токенов доступа.
+	JWTKey string
+	// DB — база данных сервиса аутентификации с примененными миграциями auth-service;
+	// nil означает хранение пользователей в памяти.
+	DB *bun.DB
+	// QueryTimeout ограничивает запросы к DB (0 — repository.DefaultQueryTimeout).
+	QueryTimeout time.Duration
+	// UserCacheTTL — время, в течение которого подтвержденный пользователь не перепроверяется
+	// в базе данных; 0 отключает кэш.
+	UserCacheTTL time.Duration
+	// TokenLeeway — допустимое расхождение часов при проверке сроков токенов
+	// (0 — service.DefaultTokenLeeway).
+	TokenLeeway time.Duration
+	// PasswordHasher — алгоритм хеширования паролей (bcrypt или argon2id, пустое значение — bcrypt),
+	// BcryptCost — стоимость bcrypt (0 — DefaultBcryptCost).
+	PasswordHasher string
+	BcryptCost     int
+	// Organizations включает организации пользователей.
+	Organizations bool
+	// PasswordPepper — секрет, добавляемый к паролям перед хешированием; пустое значение
+	// отключает перец.
+	PasswordPepper string
+	// MaxConcurrentHashes ограничивает число одновременных операций с паролями;
+	// 0 — без ограничения.
+	MaxConcurrentHashes int
+	// OIDCProviders включает вход по ID-токенам этих провайдеров OIDC; пустой список
+	// отключает вход через OIDC.
+	OIDCProviders []OIDCProvider
+}
+
+// New создает сервис аутентификации с репозиториями в cfg.DB или в памяти.
+func New(cfg Config) (AuthService, error) {
+	if cfg.PasswordHasher == "" {
+		cfg.PasswordHasher = password.AlgorithmBcrypt
+	}
+	if cfg.BcryptCost == 0 {
+		cfg.BcryptCost = DefaultBcryptCost
+	}
+	if cfg.TokenLeeway == 0 {
+		cfg.TokenLeeway = service.DefaultTokenLeeway
+	}
+	passwords, err := password.New(cfg.PasswordHasher, cfg.BcryptCost)
+	if err != nil {
+		return nil, err
+	}
+	passwords = password.WithPepper(passwords, cfg.PasswordPepper)
+
+	var userRepo repository.UserRepository
+	var sessionRepo repository.SessionRepository
+	var deviceRepo repository.DeviceRepository
+	var orgRepo repository.OrganizationRepository
+	var identityRepo repository.ExternalIdentityRepository
+	if cfg.DB == nil {
+		userRepo = repository.NewInMemoryUserRepository()
+		sessionRepo = repository.NewInMemorySessionRepository()
+		deviceRepo = repository.NewInMemoryDeviceRepository()
+		orgRepo = repository.NewInMemoryOrganizationRepository()
+		identityRepo = repository.NewInMemoryExternalIdentityRepository()
+	} else {
+		userRepo = repository.NewUserRepository(cfg.DB, repository.WithQueryTimeout(cfg.QueryTimeout))
+		sessionRepo = repository.NewSessionRepository(cfg.DB, repository.WithQueryTimeout(cfg.QueryTimeout))
+		deviceRepo = repository.NewDeviceRepository(cfg.DB, repository.WithQueryTimeout(cfg.QueryTimeout))
+		orgRepo = repository.NewOrganizationRepository(cfg.DB, repository.WithQueryTimeout(cfg.QueryTimeout))
+		identityRepo = repository.NewExternalIdentityRepository(cfg.DB, repository.WithQueryTimeout(cfg.QueryTimeout))
+	}
+
+	opts := []service.Option{
+		service.WithUserCacheTTL(cfg.UserCacheTTL),
+		service.WithSessionRepository(sessionRepo),
+		service.WithDeviceRepository(deviceRepo),
+		service.WithPasswordHasher(passwords),
+		service.WithTokenLeeway(cfg.TokenLeeway),
+		service.WithMaxConcurrentHashes(cfg.MaxConcurrentHashes),
+	}
+	if cfg.Organizations {
+		opts = append(opts, service.WithOrganizations(orgRepo))
+	}
+	if len(cfg.OIDCProviders) > 0 {
+		verifier, err := oidc.NewVerifier(cfg.OIDCProviders, oidc.WithLeeway(cfg.TokenLeeway))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, service.WithOIDC(verifier, identityRepo))
+	}
+	return service.NewAuthService(userRepo, cfg.JWTKey, opts...), nil
+}
+
+// Status переводит ошибку AuthService в статус gRPC с тем же кодом и сообщением, что
+// и gRPC-обработчик сервиса аутентификации. Неизвестные ошибки становятся codes.Internal
+// с сообщением message; nil возвращается без изменений.
+func Status(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidCredentials), errors.Is(err, service.ErrInvalidToken):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, service.ErrInvalidIDToken):
+		return status.Error(codes.Unauthenticated, "invalid ID token")
+	case errors.Is(err, service.ErrUserAlreadyExists), errors.Is(err, service.ErrEmailAlreadyExists),
+		errors.Is(err, service.ErrAlreadyInOrganization):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrSessionNotFound),
+		errors.Is(err, service.ErrInviteNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidEmail), errors.Is(err, service.ErrInvalidVerificationToken),
+		errors.Is(err, service.ErrTooManyUsers), errors.Is(err, service.ErrInvalidOrganizationName),
+		errors.Is(err, service.ErrInvalidInviteTTL), errors.Is(err, service.ErrInvalidInvite),
+		errors.Is(err, service.ErrInvalidUserFilter), errors.Is(err, service.ErrUnknownProvider):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrNotAdmin), errors.Is(err, service.ErrImpersonateAdmin),
+		errors.Is(err, service.ErrNotOrganizationOwner):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, service.ErrExportRateLimited):
+		return status.Error(codes.ResourceExhausted, "user data was exported recently")
+	case errors.Is(err, service.ErrHashCapacity):
+		return status.Error(codes.ResourceExhausted, "too many password operations in progress")
+	case errors.Is(err, service.ErrOrganizationsDisabled), errors.Is(err, service.ErrOIDCDisabled):
+		return status.Error(codes.Unimplemented, err.Error())
+	case errors.Is(err, service.ErrProviderUnavailable):
+		return status.Error(codes.Unavailable, "identity provider unavailable")
+	case errors.Is(err, repository.ErrTimeout):
+		return status.Error(codes.DeadlineExceeded, "request timed out")
+	}
+	return status.Error(codes.Internal, message)
+}
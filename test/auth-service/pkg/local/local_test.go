@@ -0,0 +1,74 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+)
+
+// Тест сервиса в памяти: зарегистрированный пользователь входит, а его токен проходит проверку
+func TestNew_InMemory(t *testing.T) {
+	svc, err := New(Config{JWTKey: "test-key", BcryptCost: bcrypt.MinCost, Organizations: true})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	registered, err := svc.Register(ctx, "alice", "password", "", ClientInfo{IP: "192.0.2.1"})
+	require.NoError(t, err)
+	tokens, err := svc.Login(ctx, "alice", "password", ClientInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, registered.UserID, tokens.UserID)
+
+	claims, err := svc.ValidateToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, registered.UserID, claims.UserID)
+	_, err = svc.ValidateToken(ctx, "bad-token")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Организации включены параметром Organizations
+	_, err = svc.CreateOrganization(ctx, registered.UserID, "Acme")
+	assert.NoError(t, err)
+}
+
+// Тест неверных параметров хеширования паролей
+func TestNew_InvalidPasswordHasher(t *testing.T) {
+	_, err := New(Config{JWTKey: "test-key", PasswordHasher: "md5"})
+	assert.Error(t, err)
+}
+
+// Тест перевода ошибок сервиса в статусы gRPC с кодами gRPC-обработчика
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{service.ErrInvalidCredentials, codes.Unauthenticated},
+		{service.ErrUserAlreadyExists, codes.AlreadyExists},
+		{service.ErrAlreadyInOrganization, codes.AlreadyExists},
+		{fmt.Errorf("get user: %w", service.ErrUserNotFound), codes.NotFound},
+		{service.ErrSessionNotFound, codes.NotFound},
+		{service.ErrInvalidInvite, codes.InvalidArgument},
+		{service.ErrNotOrganizationOwner, codes.PermissionDenied},
+		{service.ErrExportRateLimited, codes.ResourceExhausted},
+		{service.ErrOrganizationsDisabled, codes.Unimplemented},
+		{repository.ErrTimeout, codes.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		st, ok := status.FromError(Status(tt.err, "failed"))
+		require.True(t, ok, tt.err)
+		assert.Equal(t, tt.code, st.Code(), tt.err)
+	}
+
+	st := status.Convert(Status(fmt.Errorf("connection reset"), "failed to login user"))
+	assert.Equal(t, codes.Internal, st.Code())
+	assert.Equal(t, "failed to login user", st.Message())
+	assert.NoError(t, Status(nil, "failed"))
+}
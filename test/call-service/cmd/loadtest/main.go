@@ -0,0 +1,116 @@
+// Команда loadtest создает нагрузку на gRPC API сервиса аутентификации или HTTP API
+// сервиса заявок и печатает задержки (p50/p95/p99) и долю ошибок по каждому типу запросов.
+//
+// Каждый воркер регистрирует собственного пользователя и в цикле выполняет операции
+// выбранного сценария до истечения времени теста.
+//
+// Пример (из директории test\call-service, при запущенных сервисах):
+//
+//	go run ./cmd/loadtest -scenario validate-heavy -concurrency 50 -duration 30s
+//	go run ./cmd/loadtest -scenario crud -concurrency 20 -duration 1m
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/pkg/authclient"
+)
+
+func main() {
+	names := make([]string, 0, len(scenarios))
+	for name := range scenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	scenario := flag.String("scenario", "validate-heavy", "load scenario: "+strings.Join(names, ", "))
+	concurrency := flag.Int("concurrency", 10, "number of concurrent workers")
+	duration := flag.Duration("duration", 30*time.Second, "test duration")
+	authAddr := flag.String("auth-addr", "localhost:50051", "auth-service gRPC address")
+	internalToken := flag.String("internal-token", os.Getenv("INTERNAL_TOKEN"), "auth-service internal token")
+	httpAddr := flag.String("http-addr", "http://localhost:8080", "call-service HTTP base URL")
+	listSize := flag.Int("list-size", 50, "calls created per worker before the list scenario")
+	flag.Parse()
+
+	ops, ok := scenarios[*scenario]
+	if !ok {
+		log.Fatalf("unknown scenario %q, expected one of: %s", *scenario, strings.Join(names, ", "))
+	}
+	if *concurrency <= 0 || *duration <= 0 {
+		log.Fatal("concurrency and duration must be positive")
+	}
+
+	authConn, err := authclient.Dial(*authAddr)
+	if err != nil {
+		log.Fatalf("failed to connect to auth service: %v", err)
+	}
+	defer authConn.Close()
+	authClient := authclient.NewAuthClientWithConn(authConn, authclient.Options{InternalToken: *internalToken})
+
+	httpClient := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency},
+	}
+
+	// Подготовка пользователей не входит в измерения
+	workers := make([]*worker, *concurrency)
+	for i := range workers {
+		w := &worker{
+			auth:     authClient,
+			http:     httpClient,
+			baseURL:  strings.TrimRight(*httpAddr, "/"),
+			username: "loadtest-" + uuid.NewString(),
+			password: "password",
+			rnd:      rand.New(rand.NewSource(int64(i))),
+			stats:    stats{},
+		}
+		w.token, _, err = authClient.Register(context.Background(), w.username, w.password)
+		if err != nil {
+			log.Fatalf("failed to register load test user: %v", err)
+		}
+		if *scenario == "list" {
+			if err := prepareList(context.Background(), w, *listSize); err != nil {
+				log.Fatalf("failed to prepare calls: %v", err)
+			}
+		}
+		workers[i] = w
+	}
+
+	log.Printf("running %s with %d workers for %v", *scenario, *concurrency, *duration)
+
+	// По истечении времени воркеры не начинают новых операций, но дожидаются начатых,
+	// чтобы прерванные запросы не попадали в статистику как ошибки
+	start := time.Now()
+	deadline := start.Add(*duration)
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func(w *worker) {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				_ = pick(ops, w.rnd).run(context.Background(), w)
+			}
+		}(w)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	total := stats{}
+	for _, w := range workers {
+		total.merge(w.stats)
+	}
+	fmt.Printf("scenario=%s concurrency=%d elapsed=%v\n\n", *scenario, *concurrency, elapsed.Round(time.Millisecond))
+	total.report(os.Stdout, elapsed)
+}
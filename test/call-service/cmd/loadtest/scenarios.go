@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+
+	"call-service/pkg/authclient"
+)
+
+// op — одна операция сценария; weight задает относительную частоту ее выбора.
+type op struct {
+	weight int
+	run    func(ctx context.Context, w *worker) error
+}
+
+// scenarios — доступные сценарии нагрузки. Сценарии login-heavy и validate-heavy
+// нагружают gRPC API сервиса аутентификации, crud и list — HTTP API сервиса заявок.
+var scenarios = map[string][]op{
+	"login-heavy":    {{weight: 8, run: login}, {weight: 2, run: validate}},
+	"validate-heavy": {{weight: 1, run: login}, {weight: 19, run: validate}},
+	"crud":           {{weight: 1, run: crud}},
+	"list":           {{weight: 1, run: list}},
+}
+
+// errInvalidToken — сервис аутентификации не подтвердил токен, выданный при входе.
+var errInvalidToken = errors.New("token is not valid")
+
+// worker выполняет операции сценария от имени одного пользователя.
+type worker struct {
+	auth     authclient.AuthClient
+	http     *http.Client
+	baseURL  string
+	username string
+	password string
+	token    string
+	rnd      *rand.Rand
+	stats    stats
+}
+
+func login(ctx context.Context, w *worker) error {
+	return w.stats.measure("auth.Login", func() error {
+		token, _, err := w.auth.Login(ctx, w.username, w.password)
+		if err == nil {
+			w.token = token
+		}
+		return err
+	})
+}
+
+func validate(ctx context.Context, w *worker) error {
+	return w.stats.measure("auth.ValidateToken", func() error {
+		valid, _, err := w.auth.ValidateToken(ctx, w.token)
+		if err == nil && !valid {
+			return errInvalidToken
+		}
+		return err
+	})
+}
+
+// crud проходит полный жизненный цикл заявки: создание, чтение, смена статуса, удаление.
+func crud(ctx context.Context, w *worker) error {
+	var call struct {
+		ID string `json:"id"`
+	}
+	err := w.stats.measure("POST /calls", func() error {
+		return w.do(ctx, http.MethodPost, "/calls", w.fakeCall(), http.StatusCreated, &call)
+	})
+	if err != nil {
+		return err
+	}
+
+	path := "/calls/" + call.ID
+	steps := []struct {
+		name, method, path string
+		body               any
+	}{
+		{"GET /calls/:id", http.MethodGet, path, nil},
+		{"PATCH /calls/:id/status", http.MethodPatch, path + "/status", map[string]string{"status": "closed"}},
+		{"DELETE /calls/:id", http.MethodDelete, path, nil},
+	}
+	for _, step := range steps {
+		err := w.stats.measure(step.name, func() error {
+			return w.do(ctx, step.method, step.path, step.body, http.StatusOK, nil)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func list(ctx context.Context, w *worker) error {
+	return w.stats.measure("GET /calls", func() error {
+		return w.do(ctx, http.MethodGet, "/calls?limit=20", nil, http.StatusOK, nil)
+	})
+}
+
+// prepareList создает заявки пользователя, чтобы сценарию list было что читать.
+func prepareList(ctx context.Context, w *worker, n int) error {
+	for i := 0; i < n; i++ {
+		if err := w.do(ctx, http.MethodPost, "/calls", w.fakeCall(), http.StatusCreated, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// do выполняет HTTP-запрос с токеном пользователя и проверяет код ответа.
+func (w *worker) do(ctx context.Context, method, path string, body any, wantStatus int, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, w.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+w.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != wantStatus {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("%s %s: unexpected status %d", method, path, resp.StatusCode)
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
+
+func (w *worker) fakeCall() map[string]string {
+	return map[string]string{
+		"client_name":  "Нагрузочный Тест",
+		"phone_number": fmt.Sprintf("+7999%07d", w.rnd.Intn(10_000_000)),
+		"description":  "Заявка нагрузочного теста",
+	}
+}
+
+// pick выбирает операцию сценария с учетом весов.
+func pick(ops []op, rnd *rand.Rand) op {
+	total := 0
+	for _, o := range ops {
+		total += o.weight
+	}
+	n := rnd.Intn(total)
+	for _, o := range ops {
+		if n < o.weight {
+			return o
+		}
+		n -= o.weight
+	}
+	return ops[len(ops)-1]
+}
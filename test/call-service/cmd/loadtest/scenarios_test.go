@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Операции выбираются пропорционально весам
+func TestPick(t *testing.T) {
+	var heavy, light int
+	ops := []op{
+		{weight: 1, run: func(context.Context, *worker) error { light++; return nil }},
+		{weight: 9, run: func(context.Context, *worker) error { heavy++; return nil }},
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		_ = pick(ops, rnd).run(context.Background(), nil)
+	}
+	assert.InDelta(t, 9000, heavy, 300)
+	assert.Equal(t, 10000, heavy+light)
+}
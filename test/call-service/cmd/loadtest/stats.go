@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// opStats накапливает результаты запросов одного типа.
+type opStats struct {
+	latencies []time.Duration
+	errors    int
+}
+
+// stats — результаты запросов по типам; каждый воркер ведет свой экземпляр без блокировок,
+// после завершения они объединяются через merge.
+type stats map[string]*opStats
+
+// measure выполняет запрос fn и записывает его длительность и результат под именем name.
+func (s stats) measure(name string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	op := s[name]
+	if op == nil {
+		op = &opStats{}
+		s[name] = op
+	}
+	op.latencies = append(op.latencies, time.Since(start))
+	if err != nil {
+		op.errors++
+	}
+	return err
+}
+
+func (s stats) merge(other stats) {
+	for name, o := range other {
+		op := s[name]
+		if op == nil {
+			op = &opStats{}
+			s[name] = op
+		}
+		op.latencies = append(op.latencies, o.latencies...)
+		op.errors += o.errors
+	}
+}
+
+// percentile возвращает p-й перцентиль (0 < p <= 1) отсортированных длительностей
+// методом ближайшего ранга.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
+
+// report печатает таблицу с количеством запросов, долей ошибок, пропускной способностью
+// и перцентилями задержки для каждого типа запросов.
+func (s stats) report(w io.Writer, elapsed time.Duration) {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "op\trequests\terrors\terror rate\treq/s\tp50\tp95\tp99\tmax\t")
+	for _, name := range names {
+		op := s[name]
+		sorted := append([]time.Duration(nil), op.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		n := len(sorted)
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f%%\t%.1f\t%v\t%v\t%v\t%v\t\n",
+			name, n, op.errors,
+			100*float64(op.errors)/float64(max(n, 1)),
+			float64(n)/elapsed.Seconds(),
+			round(percentile(sorted, 0.50)),
+			round(percentile(sorted, 0.95)),
+			round(percentile(sorted, 0.99)),
+			round(percentile(sorted, 1)))
+	}
+	tw.Flush()
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	assert.Equal(t, 50*time.Millisecond, percentile(sorted, 0.50))
+	assert.Equal(t, 95*time.Millisecond, percentile(sorted, 0.95))
+	assert.Equal(t, 99*time.Millisecond, percentile(sorted, 0.99))
+	assert.Equal(t, 100*time.Millisecond, percentile(sorted, 1))
+	assert.Equal(t, time.Millisecond, percentile(sorted[:1], 0.99))
+	assert.Zero(t, percentile(nil, 0.5))
+}
+
+// Результаты воркеров объединяются, ошибки учитываются в отчете
+func TestStatsMergeAndReport(t *testing.T) {
+	a, b := stats{}, stats{}
+	_ = a.measure("login", func() error { return nil })
+	_ = b.measure("login", func() error { return errors.New("failed") })
+	_ = b.measure("validate", func() error { return nil })
+
+	a.merge(b)
+	assert.Len(t, a["login"].latencies, 2)
+	assert.Equal(t, 1, a["login"].errors)
+	assert.Len(t, a["validate"].latencies, 1)
+
+	var out bytes.Buffer
+	a.report(&out, time.Second)
+	assert.Contains(t, out.String(), "50.00%")
+	assert.Contains(t, out.String(), "validate")
+}
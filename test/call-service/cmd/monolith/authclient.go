@@ -0,0 +1,592 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"auth-service/pkg/local"
+
+	"call-service/pkg/authclient"
+)
+
+// localAuthClient реализует authclient.AuthClient вызовами сервиса аутентификации в том же
+// процессе. Входные данные проверяются, а ошибки возвращаются так же, как через gRPC-клиент:
+// обработчики call-service не отличают его от отдельного сервиса.
+
+type localAuthClient struct {
+	auth local.AuthService
+}
+
+// newLocalAuthClient создает клиент аутентификации поверх сервиса в том же процессе.
+
+func newLocalAuthClient(auth local.AuthService) authclient.AuthClient {
+	return &localAuthClient{auth: auth}
+}
+
+var (
+	errInvalidUserID    = status.Error(codes.InvalidArgument, "invalid user ID")
+	errInvalidOrgID     = status.Error(codes.InvalidArgument, "invalid organization ID")
+	errInvalidSessionID = status.Error(codes.InvalidArgument, "invalid session ID")
+)
+
+func (c *localAuthClient) Register(ctx context.Context, username, password string) (string, string, error) {
+	result, err := c.RegisterFull(ctx, username, password)
+	if err != nil {
+		return "", "", err
+	}
+	return result.Token, result.UserID, nil
+}
+
+func (c *localAuthClient) RegisterFull(ctx context.Context, username, password string) (*authclient.AuthResult, error) {
+	if username == "" || password == "" {
+		return nil, authclient.FromStatus(status.Error(codes.InvalidArgument, "username and password are required"))
+	}
+	tokens, err := c.auth.Register(ctx, username, password, "", clientInfo(ctx))
+	if err != nil {
+		return nil, authclient.FromStatus(local.Status(err, "failed to register user"))
+	}
+	return authResult(tokens, tokens.RefreshToken), nil
+}
+
+func (c *localAuthClient) Login(ctx context.Context, username, password string) (string, string, error) {
+	result, err := c.LoginFull(ctx, username, password)
+	if err != nil {
+		return "", "", err
+	}
+	return result.Token, result.UserID, nil
+}
+
+func (c *localAuthClient) LoginFull(ctx context.Context, username, password string) (*authclient.AuthResult, error) {
+	if username == "" || password == "" {
+		return nil, authclient.FromStatus(status.Error(codes.InvalidArgument, "username and password are required"))
+	}
+	tokens, err := c.auth.Login(ctx, username, password, clientInfo(ctx))
+	if err != nil {
+		return nil, authclient.FromStatus(local.Status(err, "failed to login user"))
+	}
+	return authResult(tokens, tokens.RefreshToken), nil
+}
+
+func (c *localAuthClient) ImpersonateUser(ctx context.Context, adminToken, userID string) (*authclient.AuthResult, error) {
+	if adminToken == "" {
+		return nil, authclient.FromStatus(status.Error(codes.InvalidArgument, "admin token is required"))
+	}
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, authclient.FromStatus(errInvalidUserID)
+	}
+	tokens, err := c.auth.ImpersonateUser(ctx, adminToken, id, clientInfo(ctx))
+	if err != nil {
+		return nil, authclient.FromStatus(local.Status(err, "failed to impersonate user"))
+	}
+	// Токен работы от имени пользователя выдается без refresh-токена
+	return authResult(tokens, ""), nil
+}
+
+func (c *localAuthClient) ExchangeOIDCToken(ctx context.Context, provider, idToken string) (*authclient.AuthResult, error) {
+	if provider == "" || idToken == "" {
+		return nil, authclient.FromStatus(status.Error(codes.InvalidArgument, "provider and id_token are required"))
+	}
+	tokens, err := c.auth.ExchangeOIDCToken(ctx, provider, idToken, clientInfo(ctx))
+	if err != nil {
+		return nil, authclient.FromStatus(local.Status(err, "failed to exchange ID token"))
+	}
+	return authResult(tokens, tokens.RefreshToken), nil
+}
+
+// authResult собирает AuthResult из токенов сервиса аутентификации; время истечения
+// округляется до секунд, как в ответе gRPC.
+
+func authResult(tokens *local.Tokens, refreshToken string) *authclient.AuthResult {
+	return &authclient.AuthResult{
+		Token:        tokens.AccessToken,
+		UserID:       tokens.UserID.String(),
+		RefreshToken: refreshToken,
+		ExpiresAt:    time.Unix(tokens.ExpiresAt.Unix(), 0),
+	}
+}
+
+func (c *localAuthClient) ValidateToken(ctx context.Context, token string) (bool, string, error) {
+	info, err := c.ValidateTokenFull(ctx, token)
+	if err != nil {
+		return false, "", err
+	}
+	return info.Valid, info.UserID, nil
+}
+
+func (c *localAuthClient) ValidateTokenFull(ctx context.Context, token string) (*authclient.TokenInfo, error) {
+	if token == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+	claims, err := c.auth.ValidateToken(ctx, token)
+	if errors.Is(err, local.ErrInvalidToken) {
+		return &authclient.TokenInfo{Valid: false}, nil
+	}
+	if err != nil {
+		return nil, local.Status(err, "failed to validate token")
+	}
+
+	info := &authclient.TokenInfo{
+		Valid:  true,
+		UserID: claims.UserID.String(),
+		Role:   claims.Role,
+	}
+	if claims.SessionID != uuid.Nil {
+		info.SessionID = claims.SessionID.String()
+	}
+	if !claims.ExpiresAt.IsZero() {
+		info.ExpiresAt = time.Unix(claims.ExpiresAt.Unix(), 0)
+		info.ExpiresIn = claims.ExpiresIn.Truncate(time.Second)
+	}
+	if claims.OrgID != uuid.Nil {
+		info.OrgID = claims.OrgID.String()
+		info.OrgRole = claims.OrgRole
+	}
+	if claims.ImpersonatedBy != uuid.Nil {
+		info.ImpersonatedBy = claims.ImpersonatedBy.String()
+	}
+	return info, nil
+}
+
+func (c *localAuthClient) ListSessions(ctx context.Context, userID string) ([]authclient.SessionInfo, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errInvalidUserID
+	}
+	sessions, err := c.auth.ListSessions(ctx, id)
+	if err != nil {
+		return nil, local.Status(err, "failed to list sessions")
+	}
+	result := make([]authclient.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, sessionInfo(session))
+	}
+	return result, nil
+}
+
+func (c *localAuthClient) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return errInvalidUserID
+	}
+	session, err := uuid.Parse(sessionID)
+	if err != nil {
+		return errInvalidSessionID
+	}
+	return local.Status(c.auth.RevokeSession(ctx, id, session), "failed to revoke session")
+}
+
+func (c *localAuthClient) RevokeAllSessions(ctx context.Context, userID, exceptSessionID string) (int, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return 0, errInvalidUserID
+	}
+	var except uuid.UUID
+	if exceptSessionID != "" {
+		if except, err = uuid.Parse(exceptSessionID); err != nil {
+			return 0, errInvalidSessionID
+		}
+	}
+	revoked, err := c.auth.RevokeAllSessions(ctx, id, except)
+	if err != nil {
+		return 0, local.Status(err, "failed to revoke sessions")
+	}
+	return revoked, nil
+}
+
+func (c *localAuthClient) ListDevices(ctx context.Context, userID string) ([]authclient.DeviceInfo, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errInvalidUserID
+	}
+	devices, err := c.auth.ListDevices(ctx, id)
+	if err != nil {
+		return nil, local.Status(err, "failed to list devices")
+	}
+	result := make([]authclient.DeviceInfo, 0, len(devices))
+	for _, device := range devices {
+		result = append(result, deviceInfo(device))
+	}
+	return result, nil
+}
+
+func (c *localAuthClient) GetUser(ctx context.Context, userID string) (*authclient.UserInfo, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errInvalidUserID
+	}
+	user, err := c.auth.GetUser(ctx, id)
+	if err != nil {
+		return nil, local.Status(err, "failed to get user")
+	}
+	return &authclient.UserInfo{
+		UserID:        user.ID.String(),
+		Username:      user.Username,
+		Role:          user.Role,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+	}, nil
+}
+
+// GetUsers, как и gRPC-клиент, запрашивает повторяющиеся ID один раз и делит длинные списки
+// на вызовы не больше чем по local.MaxGetUsers ID.
+
+func (c *localAuthClient) GetUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]authclient.UserInfo, error) {
+	ids := make([]uuid.UUID, 0, len(userIDs))
+	seen := make(map[uuid.UUID]bool, len(userIDs))
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	result := make(map[uuid.UUID]authclient.UserInfo, len(ids))
+	for start := 0; start < len(ids); start += local.MaxGetUsers {
+		users, err := c.auth.GetUsers(ctx, ids[start:min(start+local.MaxGetUsers, len(ids))])
+		if err != nil {
+			return nil, local.Status(err, "failed to get users")
+		}
+		for _, user := range users {
+			result[user.ID] = authclient.UserInfo{
+				UserID:    user.ID.String(),
+				Username:  user.Username,
+				CreatedAt: user.CreatedAt,
+			}
+		}
+	}
+	return result, nil
+}
+
+func (c *localAuthClient) ListUsers(ctx context.Context, filter authclient.UserFilter, limit, offset int) ([]authclient.UserInfo, int, error) {
+	f := userFilter(filter)
+	f.Limit, f.Offset = limit, offset
+	users, total, err := c.auth.ListUsers(ctx, f)
+	if err != nil {
+		return nil, 0, authclient.FromStatus(local.Status(err, "failed to list users"))
+	}
+	result := make([]authclient.UserInfo, 0, len(users))
+	for _, user := range users {
+		result = append(result, userInfo(user))
+	}
+	return result, total, nil
+}
+
+// ExportUsers возвращает ошибку fn без изменений, а ошибку сервиса — так же, как gRPC-клиент.
+
+func (c *localAuthClient) ExportUsers(ctx context.Context, filter authclient.UserFilter, fn func(authclient.UserInfo) error) error {
+	var fnErr error
+	err := c.auth.ExportUsers(ctx, userFilter(filter), func(user *local.User) error {
+		fnErr = fn(userInfo(user))
+		return fnErr
+	})
+	if err != nil && fnErr == nil {
+		return authclient.FromStatus(local.Status(err, "failed to export users"))
+	}
+	return err
+}
+
+// StreamUsers читает пакеты сервиса в отдельной горутине и передает их итератору по одному:
+// следующий пакет читается, только когда итератор запросил его, как при управлении потоком gRPC.
+// Close итератора отменяет чтение и завершает горутину.
+
+func (c *localAuthClient) StreamUsers(ctx context.Context, filter authclient.UserFilter, batchSize int) *authclient.UserIterator {
+	ctx, cancel := context.WithCancel(ctx)
+	batches := make(chan []authclient.UserInfo)
+	done := make(chan error, 1)
+	started := false
+	next := func() ([]authclient.UserInfo, error) {
+		if !started {
+			started = true
+			go func() {
+				done <- c.auth.StreamUsers(ctx, userFilter(filter), batchSize, func(users []*local.User) error {
+					batch := make([]authclient.UserInfo, 0, len(users))
+					for _, user := range users {
+						batch = append(batch, userInfo(user))
+					}
+					select {
+					case batches <- batch:
+						return nil
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				})
+			}()
+		}
+		select {
+		case batch := <-batches:
+			return batch, nil
+		case err := <-done:
+			if err == nil {
+				return nil, io.EOF
+			}
+			return nil, authclient.FromStatus(local.Status(err, "failed to stream users"))
+		}
+	}
+	return authclient.NewUserIterator(next, cancel)
+}
+
+// userFilter переводит условия отбора клиента в local.UserFilter так же, как gRPC-обработчик.
+
+func userFilter(filter authclient.UserFilter) local.UserFilter {
+	result := local.UserFilter{
+		UsernamePrefix: filter.UsernamePrefix,
+		SortBy:         filter.SortBy,
+		SortDesc:       !filter.Ascending,
+	}
+	if !filter.CreatedFrom.IsZero() {
+		result.CreatedFrom = &filter.CreatedFrom
+	}
+	if !filter.CreatedTo.IsZero() {
+		result.CreatedTo = &filter.CreatedTo
+	}
+	return result
+}
+
+// userInfo переводит пользователя в authclient.UserInfo.
+
+func userInfo(user *local.User) authclient.UserInfo {
+	return authclient.UserInfo{
+		UserID:        user.ID.String(),
+		Username:      user.Username,
+		Role:          user.Role,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		CreatedAt:     user.CreatedAt,
+	}
+}
+
+func (c *localAuthClient) UpdateEmail(ctx context.Context, userID, email string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return errInvalidUserID
+	}
+	return local.Status(c.auth.UpdateEmail(ctx, id, email), "failed to update email")
+}
+
+func (c *localAuthClient) VerifyEmail(ctx context.Context, userID, token string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return errInvalidUserID
+	}
+	if token == "" {
+		return status.Error(codes.InvalidArgument, "token is required")
+	}
+	return local.Status(c.auth.VerifyEmail(ctx, id, token), "failed to verify email")
+}
+
+func (c *localAuthClient) ExportUserData(ctx context.Context, userID string) (*authclient.UserExport, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errInvalidUserID
+	}
+	export, err := c.auth.ExportUserData(ctx, id)
+	if err != nil {
+		return nil, local.Status(err, "failed to export user data")
+	}
+
+	user := export.User
+	result := &authclient.UserExport{
+		User:                       userInfo(user),
+		EmailVerificationExpiresAt: user.EmailVerificationExpiresAt,
+		Sessions:                   make([]authclient.SessionInfo, 0, len(export.Sessions)),
+		Devices:                    make([]authclient.DeviceInfo, 0, len(export.Devices)),
+	}
+	for _, session := range export.Sessions {
+		result.Sessions = append(result.Sessions, sessionInfo(session))
+	}
+	for _, device := range export.Devices {
+		result.Devices = append(result.Devices, deviceInfo(device))
+	}
+	return result, nil
+}
+
+func (c *localAuthClient) VerifyPassword(ctx context.Context, userID, password string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return authclient.FromStatus(errInvalidUserID)
+	}
+	return authclient.FromStatus(local.Status(c.auth.VerifyPassword(ctx, id, password), "failed to verify password"))
+}
+
+func (c *localAuthClient) EraseUser(ctx context.Context, userID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return authclient.FromStatus(errInvalidUserID)
+	}
+	return authclient.FromStatus(local.Status(c.auth.EraseUser(ctx, id), "failed to erase user"))
+}
+
+func (c *localAuthClient) CreateOrganization(ctx context.Context, userID, name string) (*authclient.OrganizationInfo, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errInvalidUserID
+	}
+	org, err := c.auth.CreateOrganization(ctx, id, name)
+	if err != nil {
+		return nil, local.Status(err, "failed to create organization")
+	}
+	return &authclient.OrganizationInfo{ID: org.ID.String(), Name: org.Name, CreatedAt: org.CreatedAt}, nil
+}
+
+func (c *localAuthClient) InviteToOrganization(ctx context.Context, orgID, inviterID, username string) (*authclient.MemberInfo, error) {
+	org, inviter, err := parseOrgOwner(orgID, inviterID)
+	if err != nil {
+		return nil, err
+	}
+	if username == "" {
+		return nil, status.Error(codes.InvalidArgument, "username is required")
+	}
+	member, err := c.auth.InviteToOrganization(ctx, org, inviter, username)
+	if err != nil {
+		return nil, local.Status(err, "failed to invite user")
+	}
+	return memberInfo(member), nil
+}
+
+func (c *localAuthClient) CreateInvite(ctx context.Context, orgID, ownerID string, ttl time.Duration) (*authclient.InviteInfo, string, error) {
+	org, owner, err := parseOrgOwner(orgID, ownerID)
+	if err != nil {
+		return nil, "", err
+	}
+	if ttl < 0 {
+		return nil, "", status.Error(codes.InvalidArgument, "invalid invite lifetime")
+	}
+	// Срок действия передается в секундах, как в запросе gRPC
+	invite, code, err := c.auth.CreateInvite(ctx, org, owner, ttl.Truncate(time.Second))
+	if err != nil {
+		return nil, "", local.Status(err, "failed to create invite")
+	}
+	info := inviteInfo(invite)
+	return &info, code, nil
+}
+
+func (c *localAuthClient) ListInvites(ctx context.Context, orgID, ownerID string) ([]authclient.InviteInfo, error) {
+	org, owner, err := parseOrgOwner(orgID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	invites, err := c.auth.ListInvites(ctx, org, owner)
+	if err != nil {
+		return nil, local.Status(err, "failed to list invites")
+	}
+	result := make([]authclient.InviteInfo, 0, len(invites))
+	for _, invite := range invites {
+		result = append(result, inviteInfo(invite))
+	}
+	return result, nil
+}
+
+func (c *localAuthClient) RevokeInvite(ctx context.Context, orgID, ownerID, inviteID string) error {
+	org, owner, err := parseOrgOwner(orgID, ownerID)
+	if err != nil {
+		return err
+	}
+	invite, err := uuid.Parse(inviteID)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "invalid invite ID")
+	}
+	return local.Status(c.auth.RevokeInvite(ctx, org, owner, invite), "failed to revoke invite")
+}
+
+func (c *localAuthClient) AcceptInvite(ctx context.Context, userID, code string) (*authclient.MemberInfo, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errInvalidUserID
+	}
+	if code == "" {
+		return nil, status.Error(codes.InvalidArgument, "invite code is required")
+	}
+	member, err := c.auth.AcceptInvite(ctx, id, code)
+	if err != nil {
+		return nil, local.Status(err, "failed to accept invite")
+	}
+	return memberInfo(member), nil
+}
+
+// Close ничего не делает: сервис аутентификации принадлежит процессу, а не клиенту.
+
+func (c *localAuthClient) Close() error {
+	return nil
+}
+
+// clientInfo возвращает данные клиента, сохраненные обработчиком call-service в контексте.
+
+func clientInfo(ctx context.Context) local.ClientInfo {
+	info, _ := authclient.ClientInfoFromContext(ctx)
+	return local.ClientInfo{IP: info.IP, DeviceLabel: info.DeviceLabel, UserAgent: info.UserAgent}
+}
+
+// parseOrgOwner разбирает ID организации и ее владельца (или пригласившего пользователя).
+
+func parseOrgOwner(orgID, userID string) (uuid.UUID, uuid.UUID, error) {
+	org, err := uuid.Parse(orgID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errInvalidOrgID
+	}
+	user, err := uuid.Parse(userID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errInvalidUserID
+	}
+	return org, user, nil
+}
+
+func sessionInfo(session *local.Session) authclient.SessionInfo {
+	return authclient.SessionInfo{
+		ID:          session.ID.String(),
+		DeviceLabel: session.DeviceLabel,
+		IP:          session.IP,
+		CreatedAt:   session.CreatedAt,
+		LastUsedAt:  session.LastUsedAt,
+		ExpiresAt:   session.ExpiresAt,
+		RevokedAt:   session.RevokedAt,
+	}
+}
+
+func deviceInfo(device *local.KnownDevice) authclient.DeviceInfo {
+	return authclient.DeviceInfo{
+		Fingerprint: device.Fingerprint,
+		UserAgent:   device.UserAgent,
+		IP:          device.IP,
+		FirstSeenAt: device.FirstSeenAt,
+		LastSeenAt:  device.LastSeenAt,
+	}
+}
+
+func memberInfo(member *local.OrganizationMember) *authclient.MemberInfo {
+	return &authclient.MemberInfo{
+		OrgID:    member.OrgID.String(),
+		UserID:   member.UserID.String(),
+		Role:     member.Role,
+		JoinedAt: member.CreatedAt,
+	}
+}
+
+// inviteInfo преобразует приглашение; состояние определяется на текущий момент.
+
+func inviteInfo(invite *local.OrganizationInvite) authclient.InviteInfo {
+	info := authclient.InviteInfo{
+		ID:        invite.ID.String(),
+		OrgID:     invite.OrgID.String(),
+		CreatedBy: invite.CreatedBy.String(),
+		CreatedAt: invite.CreatedAt,
+		ExpiresAt: invite.ExpiresAt,
+		Status:    invite.Status(time.Now()),
+	}
+	if invite.AcceptedBy != nil {
+		info.AcceptedBy = invite.AcceptedBy.String()
+	}
+	if invite.AcceptedAt != nil {
+		info.AcceptedAt = *invite.AcceptedAt
+	}
+	if invite.RevokedAt != nil {
+		info.RevokedAt = *invite.RevokedAt
+	}
+	return info
+}
@@ -0,0 +1,128 @@
+// Команда monolith запускает сервис заявок вместе с сервисом аутентификации в одном процессе.
+// Вызовы сервиса аутентификации выполняются напрямую, без gRPC, поэтому для небольших
+// установок и локальной разработки достаточно одного бинарника.
+//
+// Параметры сервиса заявок задаются теми же переменными окружения, что и для отдельного
+// сервиса; AUTH_SERVICE_ADDR и INTERNAL_TOKEN не используются. GRPC_ENABLED=false отключает
+// gRPC API сервиса заявок. Сервис аутентификации использует базу данных AUTH_DB_NAME
+// (по умолчанию auth_service) на том же сервере PostgreSQL; его gRPC-сервер, HTTP-шлюз
+// и удаление устаревших сеансов в объединенном бинарнике не запускаются.
+//
+// Пример (из директории test\call-service\cmd\monolith):
+//
+//	DEV_INMEMORY=true go run .
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+
+	"auth-service/pkg/local"
+
+	"call-service/internal/app"
+	"common/buildinfo"
+	"common/diagnostics"
+	"common/env"
+)
+
+func main() {
+	cfg, err := app.LoadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if env.Get("GRPC_ENABLED", "true") == "false" {
+		cfg.GRPCAddr = ""
+	}
+
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(cfg.LogLevel)
+	logger := app.NewLogger(logLevel)
+	slog.SetDefault(logger)
+	logger.Info("starting call-service monolith", "build", buildinfo.Get())
+
+	authCfg := local.Config{
+		JWTKey:              env.Get("JWT_KEY", ""),
+		QueryTimeout:        cfg.QueryTimeout,
+		UserCacheTTL:        env.Duration("USER_CACHE_TTL", 30*time.Second),
+		TokenLeeway:         env.Duration("JWT_LEEWAY", 0),
+		PasswordHasher:      env.Get("PASSWORD_HASHER", ""),
+		BcryptCost:          env.Int("BCRYPT_COST", local.DefaultBcryptCost),
+		Organizations:       cfg.Organizations,
+		PasswordPepper:      env.Get("PASSWORD_PEPPER", ""),
+		MaxConcurrentHashes: env.Int("MAX_CONCURRENT_HASHES", runtime.GOMAXPROCS(0)),
+	}
+	if authCfg.JWTKey == "" {
+		// Без постоянного ключа токены перестают действовать после перезапуска
+		authCfg.JWTKey = randomKey()
+		log.Println("JWT_KEY is not set: using a random key, issued tokens are invalidated on restart")
+	}
+	// Вход по ID-токенам внешних провайдеров OIDC настраивается так же, как в сервисе аутентификации
+	authCfg.OIDCProviders, err = local.ParseOIDCProviders(env.Get("OIDC_PROVIDERS", ""), os.Getenv)
+	if err != nil {
+		log.Fatalf("Invalid OIDC configuration: %v", err)
+	}
+	var authDB *bun.DB
+	if !cfg.DevInMemory {
+		dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+			env.Get("DB_USER", "postgres"), env.Get("DB_PASSWORD", "postgres"),
+			env.Get("DB_HOST", "postgres"), env.Get("DB_PORT", "5432"), env.Get("AUTH_DB_NAME", "auth_service"))
+		authDB = bun.NewDB(sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn))), pgdialect.New())
+		defer authDB.Close()
+		authCfg.DB = authDB
+	}
+	authService, err := local.New(authCfg)
+	if err != nil {
+		log.Fatalf("failed to create auth service: %v", err)
+	}
+
+	a, err := app.New(cfg, app.Deps{AuthClient: newLocalAuthClient(authService), Logger: logger, LogLevel: logLevel})
+	if err != nil {
+		log.Fatalf("failed to create application: %v", err)
+	}
+
+	// Показатели среды выполнения: сведения о сборке, число горутин и состояние пула соединений
+	// с базой данных
+	buildinfo.Publish()
+	diagnostics.PublishRuntimeStats(a.DB())
+
+	// SIGHUP перечитывает конфигурацию и файл флагов без перезапуска
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if _, _, err := a.ReloadConfig(); err != nil {
+				log.Printf("failed to reload config: %v", err)
+			}
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := a.Run(ctx); err != nil {
+		log.Fatalf("application stopped with error: %v", err)
+	}
+	log.Println("Servers stopped")
+}
+
+// randomKey возвращает случайный ключ подписи токенов.
+func randomKey() string {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatalf("failed to generate JWT key: %v", err)
+	}
+	return hex.EncodeToString(key)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"auth-service/pkg/local"
+
+	"call-service/internal/app"
+	"call-service/internal/blobstore"
+	"call-service/pkg/authclient"
+)
+
+// newTestHandler собирает объединенное приложение: заявки и пользователи хранятся в памяти,
+// gRPC API отключен.
+
+func newTestHandler(t *testing.T) http.Handler {
+	gin.SetMode(gin.TestMode)
+	authService, err := local.New(local.Config{JWTKey: "test-key", BcryptCost: bcrypt.MinCost})
+	require.NoError(t, err)
+
+	a, err := app.New(app.Config{
+		HTTPAddr:        "127.0.0.1:0",
+		DevInMemory:     true,
+		AttachmentStore: blobstore.Config{Dir: t.TempDir()},
+	}, app.Deps{
+		AuthClient: newLocalAuthClient(authService),
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.NoError(t, err)
+	assert.Nil(t, a.GRPCAddr())
+	return a.Handler()
+}
+
+func doRequest(t *testing.T, handler http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+// TestMonolith_EndToEnd проверяет путь пользователя через HTTP API объединенного бинарника:
+// регистрацию, вход, создание и просмотр заявок и список сеансов.
+
+func TestMonolith_EndToEnd(t *testing.T) {
+	handler := newTestHandler(t)
+	credentials := `{"username":"alice","password":"secret-password"}`
+
+	w := doRequest(t, handler, http.MethodPost, "/register", "", credentials)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var registered struct {
+		Token  string `json:"token"`
+		UserID string `json:"user_id"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &registered))
+	require.NotEmpty(t, registered.Token)
+
+	// Повторная регистрация с тем же именем отклоняется
+	w = doRequest(t, handler, http.MethodPost, "/register", "", credentials)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doRequest(t, handler, http.MethodPost, "/login", "", credentials)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var loggedIn struct {
+		Token  string `json:"token"`
+		UserID string `json:"user_id"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &loggedIn))
+	assert.Equal(t, registered.UserID, loggedIn.UserID)
+
+	w = doRequest(t, handler, http.MethodPost, "/calls", loggedIn.Token,
+		`{"client_name":"Test Client","phone_number":"+1234567890","description":"Test Description"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = doRequest(t, handler, http.MethodGet, "/calls", loggedIn.Token, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var calls []map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &calls))
+	require.Len(t, calls, 1)
+	assert.Equal(t, "Test Client", calls[0]["client_name"])
+
+	// Регистрация и вход создали по сеансу
+	w = doRequest(t, handler, http.MethodGet, "/me/sessions", loggedIn.Token, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var sessions []map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sessions))
+	assert.Len(t, sessions, 2)
+}
+
+// TestMonolith_Unauthorized проверяет отказ при неверном пароле и недействительном токене.
+
+func TestMonolith_Unauthorized(t *testing.T) {
+	handler := newTestHandler(t)
+
+	w := doRequest(t, handler, http.MethodPost, "/register", "", `{"username":"bob","password":"secret-password"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = doRequest(t, handler, http.MethodPost, "/login", "", `{"username":"bob","password":"wrong-password"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doRequest(t, handler, http.MethodGet, "/calls", "bad-token", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// streamRecorder передает в done результат каждого вызова StreamUsers сервиса аутентификации.
+
+type streamRecorder struct {
+	local.AuthService
+	done chan error
+}
+
+func (s *streamRecorder) StreamUsers(ctx context.Context, filter local.UserFilter, batchSize int, fn func([]*local.User) error) error {
+	err := s.AuthService.StreamUsers(ctx, filter, batchSize, fn)
+	s.done <- err
+	return err
+}
+
+// TestLocalAuthClient_StreamUsers проверяет потоковую выгрузку пользователей без gRPC: все
+// пакеты читаются по порядку, а закрытие итератора до конца выгрузки прекращает чтение
+// пакетов сервисом.
+
+func TestLocalAuthClient_StreamUsers(t *testing.T) {
+	authService, err := local.New(local.Config{JWTKey: "test-key", BcryptCost: bcrypt.MinCost})
+	require.NoError(t, err)
+	recorder := &streamRecorder{AuthService: authService, done: make(chan error, 1)}
+	client := newLocalAuthClient(recorder)
+	ctx := context.Background()
+	for _, username := range []string{"carol", "alice", "dave", "bob"} {
+		_, _, err := client.Register(ctx, username, "secret-password")
+		require.NoError(t, err)
+	}
+	filter := authclient.UserFilter{SortBy: authclient.UserSortByUsername, Ascending: true}
+
+	it := client.StreamUsers(ctx, filter, 2)
+	var usernames []string
+	for {
+		user, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		usernames = append(usernames, user.Username)
+	}
+	assert.Equal(t, []string{"alice", "bob", "carol", "dave"}, usernames)
+	assert.NoError(t, <-recorder.done)
+
+	it = client.StreamUsers(ctx, filter, 1)
+	user, err := it.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Username)
+	require.NoError(t, it.Close())
+	select {
+	case err := <-recorder.done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("StreamUsers did not stop after Close")
+	}
+	_, err = it.Next()
+	assert.Equal(t, io.EOF, err)
+
+	_, err = client.StreamUsers(ctx, filter, authclient.MaxListUsersLimit+1).Next()
+	assert.ErrorIs(t, err, authclient.ErrInvalidArgument)
+	<-recorder.done
+}
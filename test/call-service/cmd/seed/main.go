@@ -0,0 +1,83 @@
+// Команда seed наполняет базу данных сервиса заявок демонстрационными данными.
+//
+// Пользователи регистрируются через настоящий сервис аутентификации, заявки создаются
+// через сервисный слой, поэтому к ним применяются те же проверки, что и к запросам API.
+// Повторный запуск не создает дубликатов: существующие пользователи входят в систему,
+// а заявки досоздаются до нужного количества.
+//
+// Пример (из директории test\call-service, при запущенных PostgreSQL и auth-service):
+//
+//	go run ./cmd/seed -users 10 -calls 50
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+
+	"call-service/internal/repository"
+	"call-service/pkg/authclient"
+	"common/env"
+)
+
+func main() {
+	defaultDSN := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		env.Get("DB_USER", "postgres"),
+		env.Get("DB_PASSWORD", "postgres"),
+		env.Get("DB_HOST", "localhost"),
+		env.Get("DB_PORT", "5432"),
+		env.Get("DB_NAME", "call_service"))
+
+	users := flag.Int("users", 5, "number of users to create")
+	calls := flag.Int("calls", 20, "number of calls per user")
+	password := flag.String("password", "password", "password of created users")
+	authAddr := flag.String("auth-addr", env.Get("AUTH_SERVICE_ADDR", "localhost:50051"), "auth-service gRPC address")
+	internalToken := flag.String("internal-token", os.Getenv("INTERNAL_TOKEN"), "auth-service internal token")
+	dsn := flag.String("dsn", defaultDSN, "call-service PostgreSQL DSN")
+	days := flag.Int("days", 30, "created_at values are spread over this many last days")
+	randSeed := flag.Int64("seed", 1, "random seed for generated data")
+	flag.Parse()
+
+	if *users <= 0 || *calls < 0 || *days <= 0 {
+		log.Fatal("users and days must be positive, calls must be non-negative")
+	}
+
+	ctx := context.Background()
+
+	authConn, err := authclient.Dial(*authAddr)
+	if err != nil {
+		log.Fatalf("failed to connect to auth service: %v", err)
+	}
+	defer authConn.Close()
+	authClient := authclient.NewAuthClientWithConn(authConn, authclient.Options{InternalToken: *internalToken})
+
+	db := bun.NewDB(sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(*dsn))), pgdialect.New())
+	defer db.Close()
+
+	s := newSeeder(authClient, repository.NewCallRepository(db), seederConfig{
+		Users:    *users,
+		Calls:    *calls,
+		Password: *password,
+		Span:     time.Duration(*days) * 24 * time.Hour,
+		Seed:     *randSeed,
+	})
+
+	demo, err := s.Run(ctx)
+	if err != nil {
+		log.Fatalf("seed failed: %v", err)
+	}
+
+	fmt.Println("Demo user:")
+	fmt.Printf("  username: %s\n", demo.Username)
+	fmt.Printf("  password: %s\n", *password)
+	fmt.Printf("  user_id:  %s\n", demo.UserID)
+	fmt.Printf("  token:    %s\n", demo.Token)
+}
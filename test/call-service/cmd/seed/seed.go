@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+)
+
+// closedShare — доля создаваемых заявок, которые сразу переводятся в статус "closed".
+const closedShare = 0.3
+
+// seederConfig задает объем и параметры генерируемых данных.
+type seederConfig struct {
+	Users    int
+	Calls    int
+	Password string
+	// Span — период до текущего момента, по которому распределяются даты создания заявок.
+	Span time.Duration
+	Seed int64
+}
+
+// seedUser — пользователь, для которого созданы заявки.
+type seedUser struct {
+	Username string
+	UserID   uuid.UUID
+	Token    string
+}
+
+// seeder создает пользователей через сервис аутентификации и заявки через сервисный слой.
+type seeder struct {
+	auth  authclient.AuthClient
+	calls service.CallService
+	cfg   seederConfig
+	rnd   *rand.Rand
+}
+
+func newSeeder(auth authclient.AuthClient, repo repository.CallRepository, cfg seederConfig) *seeder {
+	rnd := rand.New(rand.NewSource(cfg.Seed))
+	repo = &backdatingRepository{CallRepository: repo, rnd: rnd, span: cfg.Span, now: time.Now}
+	return &seeder{
+		auth:  auth,
+		calls: service.NewCallService(repo),
+		cfg:   cfg,
+		rnd:   rnd,
+	}
+}
+
+// Run создает недостающих пользователей и заявки и возвращает первого пользователя
+// как демонстрационного.
+func (s *seeder) Run(ctx context.Context) (*seedUser, error) {
+	var demo *seedUser
+	for i := 1; i <= s.cfg.Users; i++ {
+		user, err := s.ensureUser(ctx, fmt.Sprintf("seed-user-%d", i))
+		if err != nil {
+			return nil, err
+		}
+		created, err := s.ensureCalls(ctx, user.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("seed calls of %s: %w", user.Username, err)
+		}
+		log.Printf("%s: %d calls created", user.Username, created)
+		if demo == nil {
+			demo = user
+		}
+	}
+	return demo, nil
+}
+
+// ensureUser регистрирует пользователя, а если он уже существует — выполняет вход.
+func (s *seeder) ensureUser(ctx context.Context, username string) (*seedUser, error) {
+	token, userID, err := s.auth.Register(ctx, username, s.cfg.Password)
+	if status.Code(err) == codes.AlreadyExists {
+		token, userID, err = s.auth.Login(ctx, username, s.cfg.Password)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("authenticate %s: %w", username, err)
+	}
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID of %s: %w", username, err)
+	}
+	return &seedUser{Username: username, UserID: id, Token: token}, nil
+}
+
+// ensureCalls досоздает заявки пользователя до cfg.Calls и закрывает часть новых заявок.
+// Возвращает количество созданных заявок.
+func (s *seeder) ensureCalls(ctx context.Context, userID uuid.UUID) (int, error) {
+	_, total, err := s.calls.GetAllCalls(ctx, userID, model.CallFilter{Limit: 1})
+	if err != nil {
+		return 0, err
+	}
+	missing := s.cfg.Calls - total
+	if missing <= 0 {
+		return 0, nil
+	}
+
+	reqs := make([]*model.CreateCallRequest, missing)
+	for i := range reqs {
+		reqs[i] = s.fakeCall()
+	}
+	calls, err := s.calls.CreateCalls(ctx, reqs, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, call := range calls {
+		if s.rnd.Float64() >= closedShare {
+			continue
+		}
+		if err := s.calls.UpdateCallStatus(ctx, call.ID, model.CallStatusClosed, userID); err != nil {
+			return 0, err
+		}
+	}
+	return len(calls), nil
+}
+
+var (
+	firstNames = []string{"Иван", "Петр", "Анна", "Мария", "Алексей", "Ольга", "Сергей", "Елена", "Дмитрий", "Наталья"}
+	lastNames  = []string{"Иванов", "Петров", "Смирнов", "Кузнецов", "Попов", "Соколов", "Лебедев", "Козлов", "Новиков", "Морозов"}
+	problems   = []string{
+		"Не работает интернет",
+		"Низкая скорость соединения",
+		"Не приходит счет за услуги",
+		"Нужно сменить тариф",
+		"Не работает телевидение",
+		"Просит перезвонить по поводу подключения",
+		"Ошибка при оплате картой",
+		"Требуется выезд мастера",
+	}
+)
+
+// fakeCall генерирует правдоподобные данные заявки.
+func (s *seeder) fakeCall() *model.CreateCallRequest {
+	first := firstNames[s.rnd.Intn(len(firstNames))]
+	last := lastNames[s.rnd.Intn(len(lastNames))]
+	// Фамилии в списке мужские; для женских имен добавляем окончание
+	if strings.HasSuffix(first, "а") || strings.HasSuffix(first, "я") {
+		last += "а"
+	}
+	return &model.CreateCallRequest{
+		ClientName:  first + " " + last,
+		PhoneNumber: fmt.Sprintf("+7%03d%07d", 900+s.rnd.Intn(100), s.rnd.Intn(10_000_000)),
+		Description: problems[s.rnd.Intn(len(problems))],
+	}
+}
+
+// backdatingRepository распределяет даты создания новых заявок по периоду span до текущего
+// момента, чтобы демонстрационные данные выглядели накопленными за время работы.
+type backdatingRepository struct {
+	repository.CallRepository
+	rnd  *rand.Rand
+	span time.Duration
+	now  func() time.Time
+}
+
+func (r *backdatingRepository) Create(ctx context.Context, call *model.Call) error {
+	r.backdate(call)
+	return r.CallRepository.Create(ctx, call)
+}
+
+func (r *backdatingRepository) CreateMany(ctx context.Context, calls []*model.Call) error {
+	for _, call := range calls {
+		r.backdate(call)
+	}
+	return r.CallRepository.CreateMany(ctx, calls)
+}
+
+func (r *backdatingRepository) backdate(call *model.Call) {
+	call.CreatedAt = r.now().Add(-time.Duration(r.rnd.Int63n(int64(r.span)))).UTC().Truncate(time.Second)
+}
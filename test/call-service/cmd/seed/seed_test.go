@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"call-service/pkg/authclient"
+)
+
+// fakeAuthClient хранит пользователей в памяти и повторяет коды ошибок сервиса аутентификации.
+type fakeAuthClient struct {
+	authclient.AuthClient
+	users map[string]string
+}
+
+func (c *fakeAuthClient) Register(ctx context.Context, username, password string) (string, string, error) {
+	if _, ok := c.users[username]; ok {
+		return "", "", status.Error(codes.AlreadyExists, "user already exists")
+	}
+	c.users[username] = uuid.NewString()
+	return "token-" + username, c.users[username], nil
+}
+
+func (c *fakeAuthClient) Login(ctx context.Context, username, password string) (string, string, error) {
+	userID, ok := c.users[username]
+	if !ok {
+		return "", "", status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	return "token-" + username, userID, nil
+}
+
+// Повторный запуск не создает дубликатов пользователей и заявок
+func TestSeederIdempotent(t *testing.T) {
+	auth := &fakeAuthClient{users: map[string]string{}}
+	repo := repository.NewInMemoryCallRepository()
+	cfg := seederConfig{Users: 3, Calls: 10, Password: "password", Span: 30 * 24 * time.Hour, Seed: 1}
+
+	demo, err := newSeeder(auth, repo, cfg).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "seed-user-1", demo.Username)
+	assert.Len(t, auth.users, 3)
+
+	again, err := newSeeder(auth, repo, cfg).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, demo.UserID, again.UserID)
+
+	_, total, err := repo.List(context.Background(), model.CallFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 30, total)
+
+	// При увеличении количества заявки досоздаются
+	cfg.Calls = 12
+	_, err = newSeeder(auth, repo, cfg).Run(context.Background())
+	require.NoError(t, err)
+	_, total, err = repo.List(context.Background(), model.CallFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 36, total)
+}
+
+// Сгенерированные заявки проходят валидацию, имеют разные статусы и даты создания в пределах периода
+func TestSeederData(t *testing.T) {
+	auth := &fakeAuthClient{users: map[string]string{}}
+	repo := repository.NewInMemoryCallRepository()
+	span := 7 * 24 * time.Hour
+	start := time.Now()
+
+	_, err := newSeeder(auth, repo, seederConfig{Users: 1, Calls: 50, Password: "password", Span: span, Seed: 42}).Run(context.Background())
+	require.NoError(t, err)
+
+	calls, _, err := repo.List(context.Background(), model.CallFilter{})
+	require.NoError(t, err)
+	require.Len(t, calls, 50)
+
+	statuses := map[model.CallStatus]int{}
+	dates := map[time.Time]bool{}
+	for _, call := range calls {
+		statuses[call.Status]++
+		dates[call.CreatedAt] = true
+		assert.NotEmpty(t, call.ClientName)
+		assert.Regexp(t, `^\+79\d{9}$`, call.PhoneNumber)
+		assert.False(t, call.CreatedAt.After(start))
+		assert.True(t, call.CreatedAt.After(start.Add(-span-time.Second)))
+	}
+	assert.Positive(t, statuses["open"])
+	assert.Positive(t, statuses["closed"])
+	assert.Greater(t, len(dates), 40)
+}
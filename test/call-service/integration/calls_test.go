@@ -0,0 +1,386 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/handler"
+	"call-service/internal/model"
+	"call-service/internal/repository"
+)
+
+// doRequest выполняет запрос к HTTP API и, если передан out, разбирает JSON-ответ.
+func doRequest(t *testing.T, method, path, token string, body any, out any) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body))
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if out != nil && w.Code < 300 {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+	}
+	return w
+}
+
+type authResponse struct {
+	Token    string `json:"token"`
+	UserID   string `json:"user_id"`
+	Username string `json:"-"`
+}
+
+// registerAndLogin регистрирует пользователя со случайным именем и возвращает токен, полученный при входе.
+func registerAndLogin(t *testing.T) authResponse {
+	t.Helper()
+	username := "user-" + uuid.NewString()
+	credentials := map[string]string{"username": username, "password": "password"}
+
+	var registered authResponse
+	w := doRequest(t, http.MethodPost, "/register", "", credentials, &registered)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	loggedIn := login(t, username)
+	require.Equal(t, registered.UserID, loggedIn.UserID)
+	return loggedIn
+}
+
+// login выполняет вход пользователя username и возвращает новый токен.
+func login(t *testing.T, username string) authResponse {
+	t.Helper()
+	var loggedIn authResponse
+	w := doRequest(t, http.MethodPost, "/login", "", map[string]string{"username": username, "password": "password"}, &loggedIn)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	loggedIn.Username = username
+	return loggedIn
+}
+
+// Сквозной сценарий: регистрация → вход → создание → список → звонок → смена статуса → удаление
+func TestCallLifecycle(t *testing.T) {
+	user := registerAndLogin(t)
+
+	var created model.Call
+	w := doRequest(t, http.MethodPost, "/calls", user.Token, map[string]string{
+		"client_name":  "Иван Иванов",
+		"phone_number": "+79990000001",
+		"description":  "Не работает интернет",
+	}, &created)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, user.UserID, created.UserID.String())
+	assert.Equal(t, model.CallStatusOpen, created.Status)
+
+	var calls []model.Call
+	w = doRequest(t, http.MethodGet, "/calls?status=open", user.Token, nil, &calls)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Total-Count"))
+	require.Len(t, calls, 1)
+	assert.Equal(t, created.ID, calls[0].ID)
+
+	var dialed struct {
+		TrackingID        uuid.UUID `json:"tracking_id"`
+		ProviderReference string    `json:"provider_reference"`
+	}
+	w = doRequest(t, http.MethodPost, "/calls/"+created.ID.String()+"/dial", user.Token, nil, &dialed)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	assert.Equal(t, "log-"+dialed.TrackingID.String(), dialed.ProviderReference)
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "photo.png")
+	require.NoError(t, err)
+	_, err = part.Write(png)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+	req := httptest.NewRequest(http.MethodPost, "/calls/"+created.ID.String()+"/attachments", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+user.Token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var attachment model.CallAttachment
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &attachment))
+	attachmentPath := "/calls/" + created.ID.String() + "/attachments/" + attachment.ID.String()
+	w = doRequest(t, http.MethodGet, attachmentPath, user.Token, nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, png, w.Body.Bytes())
+
+	w = doRequest(t, http.MethodPatch, "/calls/"+created.ID.String()+"/status", user.Token, map[string]string{"status": "closed"}, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var fetched model.Call
+	w = doRequest(t, http.MethodGet, "/calls/"+created.ID.String(), user.Token, nil, &fetched)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, model.CallStatusClosed, fetched.Status)
+
+	w = doRequest(t, http.MethodDelete, "/calls/"+created.ID.String(), user.Token, nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doRequest(t, http.MethodGet, "/calls/"+created.ID.String(), user.Token, nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doRequest(t, http.MethodGet, attachmentPath, user.Token, nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// Число запросов к базе данных при получении страницы заявок: запрос заявок и запрос
+// их общего количества. Рост числа запросов означает появление N+1
+func TestListCalls_DBQueries(t *testing.T) {
+	user := registerAndLogin(t)
+	for i := 0; i < 3; i++ {
+		w := doRequest(t, http.MethodPost, "/calls", user.Token, map[string]string{
+			"client_name":  "Клиент",
+			"phone_number": "+79990000001",
+		}, nil)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	accessLog.Reset()
+	var calls []model.Call
+	w := doRequest(t, http.MethodGet, "/calls?limit=2", user.Token, nil, &calls)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, calls, 2)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(accessLog.Bytes()), &entry), accessLog.String())
+	assert.Equal(t, "/calls", entry["route"])
+	assert.EqualValues(t, 2, entry["db_queries"])
+	assert.Positive(t, entry["db_time_ms"])
+}
+
+// Сводка по истории в списке заявок: последнее изменение статуса без попыток звонка,
+// с условиями фильтра и пагинацией списка
+func TestListCalls_Summary(t *testing.T) {
+	user := registerAndLogin(t)
+	ids := make([]string, 3)
+	for i := range ids {
+		var created model.Call
+		w := doRequest(t, http.MethodPost, "/calls", user.Token, map[string]string{
+			"client_name":  "Клиент " + string(rune('А'+i)),
+			"phone_number": "+79990000001",
+		}, &created)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		ids[i] = created.ID.String()
+	}
+	for _, status := range []string{"in_progress", "closed"} {
+		w := doRequest(t, http.MethodPatch, "/calls/"+ids[0]+"/status", user.Token, map[string]string{"status": status}, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	w := doRequest(t, http.MethodPatch, "/calls/"+ids[1]+"/status", user.Token, map[string]string{"status": "in_progress"}, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doRequest(t, http.MethodPost, "/calls/"+ids[1]+"/dial", user.Token, nil, nil)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	var calls []model.Call
+	w = doRequest(t, http.MethodGet, "/calls?include=summary&sort=client_name&order=asc&limit=2", user.Token, nil, &calls)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "3", w.Header().Get("X-Total-Count"))
+	require.Len(t, calls, 2)
+	for _, call := range calls {
+		require.NotNil(t, call.Summary)
+		require.NotNil(t, call.Summary.LastStatusChangeAt)
+		assert.Equal(t, user.UserID, call.Summary.LastStatusChangedBy.String())
+	}
+
+	w = doRequest(t, http.MethodGet, "/calls?include=summary&status=open", user.Token, nil, &calls)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, calls, 1)
+	assert.Equal(t, ids[2], calls[0].ID.String())
+	assert.Equal(t, &model.CallSummary{}, calls[0].Summary)
+}
+
+// Пакетная вставка на границе пакетов (500 заявок в пакете) сохраняет все заявки, а ID
+// и дата создания, не заданные у части заявок пакета, заполняются базой данных
+func TestCreateMany_ChunkBoundary(t *testing.T) {
+	user := registerAndLogin(t)
+	userID := uuid.MustParse(user.UserID)
+	repo := repository.NewCallRepository(callDB)
+	ctx := context.Background()
+	calls := make([]*model.Call, 502)
+	for i := range calls {
+		calls[i] = &model.Call{ClientName: "Клиент", PhoneNumber: "+79990000001", Status: model.CallStatusOpen, UserID: userID, CreatedBy: userID}
+		if i%2 == 0 {
+			calls[i].ID = uuid.New()
+			calls[i].CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		}
+	}
+	preset := calls[500].ID
+	require.NoError(t, repo.CreateMany(ctx, calls))
+
+	assert.Equal(t, preset, calls[500].ID)
+	for _, call := range calls {
+		require.NotEqual(t, uuid.Nil, call.ID)
+		require.False(t, call.CreatedAt.IsZero())
+	}
+	stored, err := repo.GetAllByUserID(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, stored, len(calls))
+	last, err := repo.GetByID(ctx, calls[501].ID)
+	require.NoError(t, err)
+	assert.Equal(t, userID, last.UserID)
+}
+
+// Заявки с одинаковым временем создания и попытки звонка с одинаковым временем возвращаются
+// в одном порядке при повторных запросах, а страницы по смещению и по позиции не пересекаются
+// и не теряют записей
+func TestListCalls_TieBreak(t *testing.T) {
+	user := registerAndLogin(t)
+	userID := uuid.MustParse(user.UserID)
+	repo := repository.NewCallRepository(callDB)
+	ctx := context.Background()
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	calls := make([]*model.Call, 7)
+	for i := range calls {
+		calls[i] = &model.Call{ID: uuid.New(), ClientName: "Клиент", PhoneNumber: "+79990000001", Status: model.CallStatusOpen, UserID: userID, CreatedBy: userID, CreatedAt: createdAt}
+	}
+	require.NoError(t, repo.CreateMany(ctx, calls))
+
+	ids := func(calls []model.Call) []string {
+		out := make([]string, len(calls))
+		for i, call := range calls {
+			out[i] = call.ID.String()
+		}
+		return out
+	}
+	for _, order := range []string{"asc", "desc"} {
+		var all, again []model.Call
+		w := doRequest(t, http.MethodGet, "/calls?order="+order, user.Token, nil, &all)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Len(t, all, len(calls))
+		sorted := slices.Sorted(slices.Values(ids(all)))
+		if order == "desc" {
+			slices.Reverse(sorted)
+		}
+		assert.Equal(t, sorted, ids(all))
+		w = doRequest(t, http.MethodGet, "/calls?order="+order, user.Token, nil, &again)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, ids(all), ids(again))
+
+		var paged []model.Call
+		for offset := 0; offset < len(calls); offset += 3 {
+			var page []model.Call
+			w := doRequest(t, http.MethodGet, fmt.Sprintf("/calls?order=%s&limit=3&offset=%d", order, offset), user.Token, nil, &page)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			paged = append(paged, page...)
+		}
+		assert.Equal(t, ids(all), ids(paged))
+	}
+
+	callID := calls[0].ID
+	for i := 0; i < 5; i++ {
+		dialID := uuid.New()
+		require.NoError(t, repo.AddDialAttempt(ctx, &model.CallStatusChange{
+			CallID: callID, OldStatus: model.CallStatusOpen, NewStatus: model.CallStatusOpen,
+			ChangedBy: userID, ChangedAt: createdAt, DialID: &dialID,
+		}))
+	}
+	var all []model.CallActivity
+	w := doRequest(t, http.MethodGet, "/calls/"+callID.String()+"/activity", user.Token, nil, &all)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, all, 5)
+	var paged []model.CallActivity
+	path := "/calls/" + callID.String() + "/activity?limit=2"
+	for {
+		var page []model.CallActivity
+		w := doRequest(t, http.MethodGet, path, user.Token, nil, &page)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		paged = append(paged, page...)
+		next := w.Header().Get(handler.NextCursorHeader)
+		if next == "" {
+			break
+		}
+		path = "/calls/" + callID.String() + "/activity?limit=2&after=" + url.QueryEscape(next)
+	}
+	assert.Equal(t, all, paged)
+}
+
+// Пользователь не может читать, изменять и удалять чужие заявки
+func TestCrossUserForbidden(t *testing.T) {
+	owner := registerAndLogin(t)
+	other := registerAndLogin(t)
+
+	var created model.Call
+	w := doRequest(t, http.MethodPost, "/calls", owner.Token, map[string]string{
+		"client_name":  "Петр Петров",
+		"phone_number": "+79990000002",
+		"description":  "Нужна консультация",
+	}, &created)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	path := "/calls/" + created.ID.String()
+	assert.Equal(t, http.StatusForbidden, doRequest(t, http.MethodGet, path, other.Token, nil, nil).Code)
+	assert.Equal(t, http.StatusForbidden, doRequest(t, http.MethodPatch, path+"/status", other.Token, map[string]string{"status": "closed"}, nil).Code)
+	assert.Equal(t, http.StatusForbidden, doRequest(t, http.MethodDelete, path, other.Token, nil, nil).Code)
+
+	var calls []model.Call
+	w = doRequest(t, http.MethodGet, "/calls", other.Token, nil, &calls)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, calls)
+
+	// Запросы без токена и с поддельным токеном отклоняются настоящим сервисом аутентификации
+	assert.Equal(t, http.StatusUnauthorized, doRequest(t, http.MethodGet, "/calls", "", nil, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, doRequest(t, http.MethodGet, "/calls", "forged-token", nil, nil).Code)
+}
+
+// Повторная регистрация того же имени отклоняется с кодом 409
+func TestDuplicateRegister(t *testing.T) {
+	credentials := map[string]string{"username": "user-" + uuid.NewString(), "password": "password"}
+	require.Equal(t, http.StatusCreated, doRequest(t, http.MethodPost, "/register", "", credentials, nil).Code)
+	assert.Equal(t, http.StatusConflict, doRequest(t, http.MethodPost, "/register", "", credentials, nil).Code)
+}
+
+// Вход по /api/v2/login возвращает тип и срок действия токена и refresh-токен,
+// а выданный токен принимается API заявок
+func TestLoginV2(t *testing.T) {
+	user := registerAndLogin(t)
+
+	var resp handler.AuthResponseV2
+	w := doRequest(t, http.MethodPost, "/api/v2/login", "", map[string]string{"username": user.Username, "password": "password"}, &resp)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, user.UserID, resp.UserID)
+	assert.Equal(t, handler.TokenTypeBearer, resp.TokenType)
+	assert.NotEmpty(t, resp.RefreshToken)
+	assert.WithinDuration(t, time.Now().Add(handler.DefaultAccessTokenTTL), resp.ExpiresAt, time.Minute)
+	assert.Equal(t, http.StatusOK, doRequest(t, http.MethodGet, "/calls", resp.Token, nil, nil).Code)
+}
+
+// Удаление учетной записи: после подтверждения паролем пользователь удаляется в сервисе
+// аутентификации, его токен и пароль перестают действовать
+func TestEraseAccount(t *testing.T) {
+	user := registerAndLogin(t)
+	w := doRequest(t, http.MethodPost, "/calls", user.Token, map[string]string{
+		"client_name":  "Иван Иванов",
+		"phone_number": "+79990000003",
+		"description":  "Перезвонить вечером",
+	}, nil)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = doRequest(t, http.MethodDelete, "/me?erase=true", user.Token, map[string]string{"password": "wrong"}, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	var erased map[string]string
+	w = doRequest(t, http.MethodDelete, "/me?erase=true", user.Token, map[string]string{"password": "password"}, &erased)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "erased", erased["status"])
+
+	assert.Equal(t, http.StatusUnauthorized, doRequest(t, http.MethodGet, "/calls", user.Token, nil, nil).Code)
+}
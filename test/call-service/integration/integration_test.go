@@ -0,0 +1,214 @@
+//go:build integration
+
+// Package integration содержит сквозные тесты сервиса заявок с настоящими PostgreSQL
+// и сервисом аутентификации. PostgreSQL запускается в контейнере через testcontainers-go,
+// сервис аутентификации собирается и запускается на свободном порту через testutil.StartAuthService.
+//
+// Запуск (требуется Docker): go test -tags=integration ./integration/...
+package integration
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+
+	"call-service/internal/blobstore"
+	"call-service/internal/handler"
+	"call-service/internal/middleware"
+	"call-service/internal/openapi"
+	"call-service/internal/repository"
+	"call-service/internal/service"
+	"call-service/internal/telephony"
+	"call-service/internal/testutil"
+	"call-service/pkg/authclient"
+)
+
+const (
+	dbUser     = "postgres"
+	dbPassword = "postgres"
+)
+
+// router — HTTP API сервиса заявок, собранный так же, как в main, поверх настоящих зависимостей.
+var router *gin.Engine
+
+// accessLog — журнал запросов к router в формате JSON. Тесты выполняются последовательно,
+// поэтому запись последнего запроса — последняя строка журнала.
+var accessLog bytes.Buffer
+
+// callDB — база данных сервиса заявок с примененными миграциями.
+var callDB *bun.DB
+
+func TestMain(m *testing.M) {
+	if !dockerAvailable() {
+		log.Print("docker is not available, skipping integration tests")
+		os.Exit(0)
+	}
+
+	code, err := run(m)
+	if err != nil {
+		log.Printf("integration setup failed: %v", err)
+		code = 1
+	}
+	os.Exit(code)
+}
+
+func run(m *testing.M) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// PostgreSQL с базами обоих сервисов
+	pg, err := postgres.Run(ctx, "postgres:15-alpine",
+		postgres.WithDatabase("call_service"),
+		postgres.WithUsername(dbUser),
+		postgres.WithPassword(dbPassword),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(time.Minute)),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("start postgres: %w", err)
+	}
+	defer func() { _ = testcontainers.TerminateContainer(pg) }()
+
+	host, err := pg.Host(ctx)
+	if err != nil {
+		return 0, err
+	}
+	port, err := pg.MappedPort(ctx, "5432/tcp")
+	if err != nil {
+		return 0, err
+	}
+
+	callDB = openDB(host, port.Port(), "call_service")
+	defer callDB.Close()
+	callDB.AddQueryHook(&repository.QueryStatsHook{})
+	if _, err := callDB.ExecContext(ctx, "CREATE DATABASE auth_service"); err != nil {
+		return 0, fmt.Errorf("create auth database: %w", err)
+	}
+	authDB := openDB(host, port.Port(), "auth_service")
+	defer authDB.Close()
+
+	if err := migrate(ctx, callDB, "../migrations"); err != nil {
+		return 0, err
+	}
+	if err := migrate(ctx, authDB, filepath.Join(testutil.AuthServiceDir(), "migrations")); err != nil {
+		return 0, err
+	}
+
+	// Сервис аутентификации запускается отдельным процессом, как в docker-compose
+	authAddr, stopAuth, err := testutil.StartAuthService(ctx,
+		"DB_HOST="+host,
+		"DB_PORT="+port.Port(),
+		"DB_USER="+dbUser,
+		"DB_PASSWORD="+dbPassword,
+		"DB_NAME=auth_service",
+		"JWT_KEY=integration-test-key",
+		"ORGANIZATIONS_ENABLED=true",
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer stopAuth()
+
+	authClient, err := authclient.NewAuthClient(authAddr)
+	if err != nil {
+		return 0, err
+	}
+	defer authClient.Close()
+
+	// Вложения хранятся во временном каталоге, который удаляется после тестов
+	attachmentsDir, err := os.MkdirTemp("", "call-service-attachments-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(attachmentsDir)
+	attachmentStore, err := blobstore.NewFileStore(attachmentsDir)
+	if err != nil {
+		return 0, err
+	}
+
+	callRepo := repository.NewCallRepository(callDB)
+	attachmentService := service.NewAttachmentService(repository.NewAttachmentRepository(callDB), callRepo, attachmentStore, service.AttachmentConfig{})
+	callService := service.NewCallService(callRepo, service.WithDialer(telephony.LogDialer{}, 0), service.WithAttachments(attachmentService))
+	apiKeyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(callDB))
+	savedViewService := service.NewSavedViewService(repository.NewSavedViewRepository(callDB))
+	erasureService := service.NewErasureService(repository.NewErasureRepository(callDB), callRepo, apiKeyService, attachmentService, authClient, service.ErasureConfig{SavedViews: savedViewService})
+	authMiddleware := middleware.NewAuthMiddlewareWithConfig(authClient, middleware.AuthConfig{Organizations: true})
+	gin.SetMode(gin.TestMode)
+	router = gin.New()
+	router.Use(middleware.AccessLog(middleware.AccessLogConfig{Logger: slog.New(slog.NewJSONHandler(&accessLog, nil))}))
+	handler.RegisterRoutes(router, handler.Routes{
+		Auth:           handler.NewAuthHandler(authClient),
+		Calls:          handler.NewCallHandler(callService, authClient),
+		Attachments:    handler.NewAttachmentHandler(attachmentService),
+		Admin:          handler.NewAdminHandler(callService),
+		Profile:        handler.NewProfileHandler(authClient),
+		APIKeys:        handler.NewAPIKeyHandler(apiKeyService),
+		Views:          handler.NewSavedViewHandler(savedViewService),
+		UserData:       handler.NewUserDataHandler(callService, authClient, erasureService),
+		Impersonation:  handler.NewImpersonationHandler(authClient),
+		Users:          handler.NewAdminUsersHandler(authClient),
+		Organizations:  handler.NewOrganizationHandler(authClient, authMiddleware),
+		Docs:           handler.NewDocsHandler(openapi.JSON()),
+		AuthMiddleware: authMiddleware,
+	})
+
+	return m.Run(), nil
+}
+
+// dockerAvailable проверяет, доступен ли Docker; testcontainers паникует, если не находит его.
+func dockerAvailable() (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	provider, err := testcontainers.NewDockerProvider()
+	if err != nil {
+		return false
+	}
+	defer provider.Close()
+	return provider.Health(context.Background()) == nil
+}
+
+func openDB(host, port, name string) *bun.DB {
+	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", dbUser, dbPassword, host, port, name)
+	return bun.NewDB(sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn))), pgdialect.New())
+}
+
+// migrate применяет все *.up.sql файлы каталога в лексикографическом порядке.
+func migrate(ctx context.Context, db *bun.DB, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		query, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx, string(query)); err != nil {
+			return fmt.Errorf("apply %s: %w", file, err)
+		}
+	}
+	return nil
+}
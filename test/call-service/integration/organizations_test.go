@@ -0,0 +1,108 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/handler"
+	"call-service/internal/model"
+)
+
+// Общая очередь заявок организации: участники сразу, без повторного входа, видят и изменяют
+// заявки друг друга,
+// удалить заявку может ее владелец или владелец организации, в том числе заявку участника,
+// заявки, созданные до вступления в организацию, остаются личными
+func TestOrganizationSharedCalls(t *testing.T) {
+	owner := registerAndLogin(t)
+	member := registerAndLogin(t)
+	outsider := registerAndLogin(t)
+	newCall := map[string]string{"client_name": "Иван Иванов", "phone_number": "+79990000003", "description": "Общая очередь"}
+
+	var before model.Call
+	require.Equal(t, http.StatusCreated, doRequest(t, http.MethodPost, "/calls", member.Token, newCall, &before).Code)
+
+	var org handler.OrganizationResponse
+	w := doRequest(t, http.MethodPost, "/organizations", owner.Token, map[string]string{"name": "Рога и копыта"}, &org)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = doRequest(t, http.MethodPost, "/organizations/"+org.ID+"/members", owner.Token, map[string]string{"username": member.Username}, nil)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = doRequest(t, http.MethodPost, "/organizations/"+org.ID+"/members", member.Token, map[string]string{"username": outsider.Username}, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var shared model.Call
+	require.Equal(t, http.StatusCreated, doRequest(t, http.MethodPost, "/calls", owner.Token, newCall, &shared).Code)
+	require.NotNil(t, shared.OrgID)
+	assert.Equal(t, org.ID, shared.OrgID.String())
+
+	path := "/calls/" + shared.ID.String()
+	assert.Equal(t, http.StatusOK, doRequest(t, http.MethodGet, path, member.Token, nil, nil).Code)
+	assert.Equal(t, http.StatusOK, doRequest(t, http.MethodPatch, path+"/status", member.Token, map[string]string{"status": "in_progress"}, nil).Code)
+	assert.Equal(t, http.StatusForbidden, doRequest(t, http.MethodDelete, path, member.Token, nil, nil).Code)
+	assert.Equal(t, http.StatusForbidden, doRequest(t, http.MethodGet, path, outsider.Token, nil, nil).Code)
+	assert.Equal(t, http.StatusForbidden, doRequest(t, http.MethodGet, "/calls/"+before.ID.String(), owner.Token, nil, nil).Code)
+
+	var calls []model.Call
+	require.Equal(t, http.StatusOK, doRequest(t, http.MethodGet, "/calls", member.Token, nil, &calls).Code)
+	assert.Len(t, calls, 2)
+	require.Equal(t, http.StatusOK, doRequest(t, http.MethodGet, "/calls", owner.Token, nil, &calls).Code)
+	require.Len(t, calls, 1)
+	assert.Equal(t, shared.ID, calls[0].ID)
+
+	assert.Equal(t, http.StatusOK, doRequest(t, http.MethodDelete, path, owner.Token, nil, nil).Code)
+
+	var memberCall model.Call
+	require.Equal(t, http.StatusCreated, doRequest(t, http.MethodPost, "/calls", member.Token, newCall, &memberCall).Code)
+	memberPath := "/calls/" + memberCall.ID.String()
+	assert.Equal(t, http.StatusOK, doRequest(t, http.MethodPatch, memberPath+"/status", owner.Token, map[string]string{"status": "closed"}, nil).Code)
+	assert.Equal(t, http.StatusOK, doRequest(t, http.MethodDelete, memberPath, owner.Token, nil, nil).Code)
+}
+
+// Приглашения в организацию: код принимается один раз и сразу открывает доступ к заявкам
+// организации, отозванный код не принимается, повторное принятие безопасно
+func TestOrganizationInvites(t *testing.T) {
+	owner := registerAndLogin(t)
+	member := registerAndLogin(t)
+	outsider := registerAndLogin(t)
+
+	var org handler.OrganizationResponse
+	w := doRequest(t, http.MethodPost, "/organizations", owner.Token, map[string]string{"name": "Рога и копыта"}, &org)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	invitesPath := "/organizations/" + org.ID + "/invites"
+
+	var invite handler.InviteResponse
+	w = doRequest(t, http.MethodPost, invitesPath, owner.Token, nil, &invite)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.NotEmpty(t, invite.Code)
+	assert.Equal(t, http.StatusForbidden, doRequest(t, http.MethodPost, invitesPath, member.Token, nil, nil).Code)
+
+	var shared model.Call
+	newCall := map[string]string{"client_name": "Иван Иванов", "phone_number": "+79990000004"}
+	require.Equal(t, http.StatusCreated, doRequest(t, http.MethodPost, "/calls", owner.Token, newCall, &shared).Code)
+	assert.Equal(t, http.StatusForbidden, doRequest(t, http.MethodGet, "/calls/"+shared.ID.String(), member.Token, nil, nil).Code)
+
+	accept := map[string]string{"code": invite.Code}
+	var joined handler.MemberResponse
+	w = doRequest(t, http.MethodPost, "/invites/accept", member.Token, accept, &joined)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, org.ID, joined.OrgID)
+	assert.Equal(t, http.StatusOK, doRequest(t, http.MethodGet, "/calls/"+shared.ID.String(), member.Token, nil, nil).Code)
+	assert.Equal(t, http.StatusOK, doRequest(t, http.MethodPost, "/invites/accept", member.Token, accept, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, http.MethodPost, "/invites/accept", outsider.Token, accept, nil).Code)
+
+	var revoked handler.InviteResponse
+	require.Equal(t, http.StatusCreated, doRequest(t, http.MethodPost, invitesPath, owner.Token, nil, &revoked).Code)
+	require.Equal(t, http.StatusOK, doRequest(t, http.MethodDelete, invitesPath+"/"+revoked.ID, owner.Token, nil, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, http.MethodPost, "/invites/accept", outsider.Token, map[string]string{"code": revoked.Code}, nil).Code)
+
+	var invites []handler.InviteResponse
+	require.Equal(t, http.StatusOK, doRequest(t, http.MethodGet, invitesPath, owner.Token, nil, &invites).Code)
+	require.Len(t, invites, 2)
+	assert.Equal(t, "revoked", invites[0].Status)
+	assert.Equal(t, "accepted", invites[1].Status)
+	assert.Empty(t, invites[0].Code)
+}
@@ -0,0 +1,97 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/repository"
+)
+
+// hammer вызывает Reserve каждого ограничителя из нескольких горутин до истечения duration
+// и возвращает число разрешенных запросов по каждому ограничителю.
+func hammer(t *testing.T, limiters []*repository.PostgresRateLimiter, key string, duration time.Duration) []int64 {
+	t.Helper()
+	allowed := make([]int64, len(limiters))
+	deadline := time.Now().Add(duration)
+	var wg sync.WaitGroup
+	for i, limiter := range limiters {
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for time.Now().Before(deadline) {
+					_, ok, err := limiter.Reserve(context.Background(), key)
+					if !assert.NoError(t, err) {
+						return
+					}
+					if ok {
+						atomic.AddInt64(&allowed[i], 1)
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	return allowed
+}
+
+// TestPostgresRateLimiter_SharedLimit проверяет, что два ограничителя с общей базой данных,
+// как два экземпляра сервиса, вместе разрешают не больше лимита за окно.
+func TestPostgresRateLimiter_SharedLimit(t *testing.T) {
+	const limit = 10
+	limiters := []*repository.PostgresRateLimiter{
+		repository.NewPostgresRateLimiter(callDB, "test_shared", limit, time.Hour),
+		repository.NewPostgresRateLimiter(callDB, "test_shared", limit, time.Hour),
+	}
+
+	allowed := hammer(t, limiters, "user-1", 500*time.Millisecond)
+	assert.EqualValues(t, limit, allowed[0]+allowed[1])
+
+	// Отказ сообщает время до конца окна
+	wait, ok, err := limiters[1].Reserve(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Greater(t, wait, time.Duration(0))
+	assert.LessOrEqual(t, wait, time.Hour)
+
+	// Возвращенная попытка доступна другому экземпляру, счетчики других ключей независимы
+	require.NoError(t, limiters[0].Release(context.Background(), "user-1"))
+	_, ok, err = limiters[1].Reserve(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	_, ok, err = limiters[1].Reserve(context.Background(), "user-2")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+// TestPostgresRateLimiter_Rate проверяет суммарную частоту разрешенных запросов двух ограничителей
+// с окном в секунду: за каждое затронутое окно разрешается ровно limit запросов, поэтому
+// за 2,5 секунды их не меньше двух и не больше четырех лимитов, а истекшие окна удаляются.
+func TestPostgresRateLimiter_Rate(t *testing.T) {
+	const limit = 5
+	limiters := []*repository.PostgresRateLimiter{
+		repository.NewPostgresRateLimiter(callDB, "test_rate", limit, time.Second),
+		repository.NewPostgresRateLimiter(callDB, "test_rate", limit, time.Second),
+	}
+
+	allowed := hammer(t, limiters, "user-1", 2500*time.Millisecond)
+	total := allowed[0] + allowed[1]
+	assert.Zero(t, total%limit, "each window must allow exactly the limit, got %d", total)
+	assert.GreaterOrEqual(t, total, int64(2*limit))
+	assert.LessOrEqual(t, total, int64(4*limit))
+	assert.Positive(t, allowed[0])
+	assert.Positive(t, allowed[1])
+
+	var windows int
+	require.NoError(t, callDB.NewRaw("SELECT count(*) FROM rate_limit_counters WHERE scope = 'test_rate'").
+		Scan(context.Background(), &windows))
+	assert.Equal(t, 1, windows)
+}
@@ -0,0 +1,87 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+	"call-service/internal/repository"
+)
+
+// Очередь доставок подписок в PostgreSQL: ListDue возвращает наступившие доставки вместе
+// с подпиской, RetryFailed повторяет не больше limit давних неудавшихся доставок,
+// DeleteFinishedBefore не трогает ожидающие доставки, а удаление подписки удаляет ее доставки
+func TestWebhookRepository_DeliveryQueue(t *testing.T) {
+	repo := repository.NewWebhookRepository(callDB)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	webhook := &model.Webhook{UserID: uuid.New(), URL: "https://example.com/hooks", Secret: "secret", CreatedAt: now}
+	require.NoError(t, repo.Create(ctx, webhook))
+
+	delivery := func(status model.WebhookDeliveryStatus, at time.Time) *model.WebhookDelivery {
+		return &model.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventType:     "call.callback_due",
+			Payload:       []byte(`{"type":"call.callback_due"}`),
+			Status:        status,
+			NextAttemptAt: at,
+			CreatedAt:     at,
+			UpdatedAt:     at,
+		}
+	}
+	old := now.Add(-48 * time.Hour)
+	deliveries := []*model.WebhookDelivery{
+		delivery(model.WebhookDeliveryPending, now.Add(-time.Minute)),
+		delivery(model.WebhookDeliveryPending, now.Add(time.Hour)),
+		delivery(model.WebhookDeliveryFailed, old),
+		delivery(model.WebhookDeliveryFailed, old.Add(time.Second)),
+		delivery(model.WebhookDeliveryFailed, old.Add(2*time.Second)),
+		delivery(model.WebhookDeliveryDelivered, old),
+	}
+	require.NoError(t, repo.CreateDeliveries(ctx, deliveries))
+
+	due, err := repo.ListDue(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, deliveries[0].ID, due[0].ID)
+	require.NotNil(t, due[0].Webhook)
+	assert.Equal(t, webhook.Secret, due[0].Webhook.Secret)
+	assert.JSONEq(t, `{"type":"call.callback_due"}`, string(due[0].Payload))
+
+	failed, err := repo.ListDeliveries(ctx, webhook.ID, model.WebhookDeliveryFailed, 10)
+	require.NoError(t, err)
+	require.Len(t, failed, 3)
+	assert.Equal(t, deliveries[4].ID, failed[0].ID)
+
+	// Повторяются две самые давние неудавшиеся доставки
+	retried, err := repo.RetryFailed(ctx, webhook.ID, now, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, retried)
+	due, err = repo.ListDue(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Len(t, due, 3)
+	retriedDelivery, err := repo.GetDelivery(ctx, webhook.ID, deliveries[2].ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.WebhookDeliveryPending, retriedDelivery.Status)
+	assert.Zero(t, retriedDelivery.Attempts)
+
+	// Ожидающая доставка не повторяется вручную
+	assert.ErrorIs(t, repo.RetryDelivery(ctx, webhook.ID, deliveries[0].ID, now), repository.ErrNotFound)
+	require.NoError(t, repo.RetryDelivery(ctx, webhook.ID, deliveries[4].ID, now))
+
+	purged, err := repo.DeleteFinishedBefore(ctx, now.Add(-time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	require.NoError(t, repo.Delete(ctx, webhook.UserID, webhook.ID))
+	left, err := repo.ListDeliveries(ctx, webhook.ID, "", 10)
+	require.NoError(t, err)
+	assert.Empty(t, left)
+}
@@ -0,0 +1,914 @@
+// Package app собирает сервис заявок из компонентов: подключение к базе данных,
+// репозитории, сервисы, обработчики, middleware, HTTP-маршрутизатор и gRPC-сервер.
+//
+// main загружает конфигурацию и вызывает Run; тесты создают приложение через New,
+// подставляя клиент аутентификации и базу данных через Deps.
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+	"google.golang.org/grpc"
+
+	"call-service/internal/blobstore"
+	"call-service/internal/cache"
+	"call-service/internal/captcha"
+	"call-service/internal/events"
+	"call-service/internal/featureflags"
+	"call-service/internal/grpcserver"
+	"call-service/internal/handler"
+	"call-service/internal/middleware"
+	"call-service/internal/model"
+	"call-service/internal/notify"
+	"call-service/internal/openapi"
+	"call-service/internal/repository"
+	"call-service/internal/safehttp"
+	"call-service/internal/scheduler"
+	"call-service/internal/service"
+	"call-service/internal/telephony"
+	"call-service/pkg/authclient"
+	"common/clientip"
+	"common/diagnostics"
+	"common/slo"
+)
+
+// shutdownTimeout ограничивает время корректной остановки серверов в Run.
+const shutdownTimeout = 10 * time.Second
+
+// webhookValidationTimeout ограничивает проверку адреса webhook при запуске.
+const webhookValidationTimeout = 5 * time.Second
+
+// Config содержит параметры приложения. Нулевые значения параметров middleware
+// заменяются значениями по умолчанию соответствующих пакетов.
+type Config struct {
+	// HTTPAddr и GRPCAddr — адреса HTTP API и gRPC API (например, ":8080"); пустой GRPCAddr
+	// отключает gRPC API.
+	HTTPAddr string
+	GRPCAddr string
+	// DebugAddr — адрес сервера pprof и /debug/vars; пустая строка отключает его.
+	DebugAddr string
+	// LogLevel — уровень журнала. Меняется без перезапуска через Deps.LogLevel.
+	LogLevel slog.Level
+
+	// DSN — строка подключения к PostgreSQL. Не используется, если задан Deps.DB или DevInMemory.
+	DSN string
+	// ReadDSN — строка подключения к реплике PostgreSQL для запросов чтения заявок; пустая
+	// строка означает, что все запросы выполняются по DSN. Используется только вместе с DSN.
+	ReadDSN string
+	// DevInMemory включает хранение заявок в памяти вместо PostgreSQL.
+	DevInMemory        bool
+	QueryTimeout       time.Duration
+	SlowQueryThreshold time.Duration
+
+	// AuthServiceAddr — адрес сервиса аутентификации или несколько адресов через запятую
+	// (см. authclient.Dial); вызовы распределяются по всем экземплярам, отвечающим SERVING.
+	// AuthResolveInterval — период повторного разрешения имен в адресе (0 —
+	// authclient.DefaultResolveInterval). Не используются, если задан Deps.AuthClient.
+	AuthServiceAddr     string
+	AuthResolveInterval time.Duration
+	Auth                authclient.Options
+	// AuthTokenSources — порядок источников токена доступа; nil означает middleware.DefaultTokenSources.
+	AuthTokenSources []middleware.TokenSource
+	// AuthStaleTTL включает режим деградации при недоступности сервиса аутентификации
+	// (см. middleware.AuthConfig.StaleTTL); 0 отключает его.
+	AuthStaleTTL time.Duration
+	// Organizations включает организации: маршруты /organizations и доступ участников
+	// организации к ее заявкам (см. middleware.AuthConfig.Organizations). Организации
+	// должны быть включены и в сервисе аутентификации.
+	Organizations bool
+	// AuthCookie задает установку cookie с токеном при входе и регистрации.
+	AuthCookie handler.AuthCookieConfig
+	// ResolveUsernames включает имена авторов (created_by_name, updated_by_name) в ответах
+	// с заявками; имена кэшируются на UsernameCacheTTL (0 — service.DefaultUsernameCacheTTL).
+	ResolveUsernames bool
+	UsernameCacheTTL time.Duration
+
+	TrustedProxies           []string
+	AccessLogSkipPaths       []string
+	AccessLogSuccessSampling int
+	CompressMinSize          int
+	RequestTimeout           time.Duration
+	RequestTimeoutSkipPaths  []string
+	// WSMaxConnectionsPerUser — наибольшее число одновременных соединений GET /calls/ws одного
+	// пользователя (0 — events.DefaultMaxPerUser).
+	WSMaxConnectionsPerUser int
+	// SwaggerUI включает страницу Swagger UI; в production-режиме она отключена.
+	SwaggerUI bool
+	// Maintenance — начальное состояние режима обслуживания, который затем переключается
+	// запросом POST /admin/maintenance (см. middleware.Maintenance). MaintenanceRetryAfter —
+	// значение Retry-After в отклоненных ответах (0 — middleware.DefaultMaintenanceRetryAfter).
+	Maintenance           bool
+	MaintenanceRetryAfter time.Duration
+	// FeatureFlags и FeatureFlagsFile — флаги постепенного включения поведения в формате
+	// featureflags.NewStore. Файл перечитывается методами ReloadFeatureFlags и ReloadConfig
+	// и запросом POST /admin/feature-flags/reload.
+	FeatureFlags     string
+	FeatureFlagsFile string
+	// FaultInjection включает внесение сбоев в HTTP-запросы (см. middleware.FaultInjector)
+	// с начальными правилами FaultRules; правила заменяются запросом PUT /admin/faults.
+	// Без FaultInjection middleware не подключается.
+	FaultInjection bool
+	FaultRules     []middleware.FaultRule
+
+	// StaleCallsAfter включает автоматическое закрытие заявок, открытых дольше этого времени;
+	// 0 отключает его. Проверка выполняется каждые StaleCallsInterval
+	// (0 — scheduler.DefaultStaleCallsInterval), в режиме StaleCallsDryRun заявки
+	// только записываются в журнал.
+	StaleCallsAfter    time.Duration
+	StaleCallsInterval time.Duration
+	StaleCallsDryRun   bool
+
+	// CallbackWebhookURL — общий адрес для событий notify.EventCallbackDue о наступлении
+	// времени повторного звонка; пустой — события получают только подписки владельца заявки
+	// (см. Webhooks). Проверка выполняется каждые CallbacksInterval
+	// (0 — scheduler.DefaultCallbacksInterval). Если задан
+	// CallbackWebhookSecret, тела запросов подписываются (см. пакет pkg/webhook/signing).
+	// Запросы к webhook отправляются клиентом safehttp с ограничениями CallbackWebhookPolicy:
+	// адрес проверяется при запуске и при каждом соединении.
+	CallbackWebhookURL    string
+	CallbackWebhookSecret string
+	CallbacksInterval     time.Duration
+	CallbackWebhookPolicy safehttp.Policy
+
+	// Webhooks — параметры подписок пользователей на события заявок (/webhooks). Адреса подписок
+	// проверяются, а запросы к ним отправляются с ограничениями CallbackWebhookPolicy; поле
+	// Webhooks.Policy не используется. Очередь доставки отправляется каждые
+	// WebhookDeliveriesInterval (0 — scheduler.DefaultWebhookDeliveriesInterval), завершенные
+	// доставки старше Webhooks.Retention удаляет задача очистки, которая выполняется каждые
+	// PurgeInterval (0 — scheduler.DefaultPurgeInterval).
+	Webhooks                  service.WebhookConfig
+	WebhookDeliveriesInterval time.Duration
+	PurgeInterval             time.Duration
+
+	// NotifyChannels — каналы уведомлений пользователей (model.NotificationChannel*) о передаче
+	// заявки, наступлении времени повторного звонка и ошибках webhook; пустой список отключает
+	// уведомления. Канал email отправляет письма на подтвержденный адрес пользователя через
+	// SMTP-сервер NotifySMTP, канал telegram — сообщения бота NotifyTelegram в чат, привязанный
+	// через POST /me/notifications/telegram. Уведомления доставляются в фоне с параметрами
+	// NotifyDispatcher.
+	NotifyChannels   []string
+	NotifySMTP       notify.SMTPConfig
+	NotifyTelegram   notify.TelegramConfig
+	NotifyDispatcher notify.DispatcherConfig
+
+	// ErasurePolicy — обработка заявок пользователя при удалении его учетной записи;
+	// пустое значение — model.ErasurePolicyAnonymize. Прерванные удаления завершаются
+	// каждые ErasuresInterval (0 — scheduler.DefaultErasuresInterval).
+	ErasurePolicy    model.ErasurePolicy
+	ErasuresInterval time.Duration
+
+	// Telephony — провайдер исходящих звонков (POST /calls/:id/dial); по умолчанию заглушка,
+	// которая только записывает звонок в журнал. Не используется, если задан Deps.Dialer.
+	// Время вызова провайдера ограничено DialTimeout (0 — service.DefaultDialTimeout).
+	Telephony   telephony.Config
+	DialTimeout time.Duration
+
+	// AttachmentStore — хранилище содержимого вложений заявок; не используется, если задан
+	// Deps.AttachmentStore. Attachments задает ограничения размера и типа вложений.
+	AttachmentStore blobstore.Config
+	Attachments     service.AttachmentConfig
+
+	// APIAuditQueueSize — размер очереди журнала изменяющих запросов (0 —
+	// service.DefaultAPIAuditQueueSize); записи сверх очереди отбрасываются.
+	APIAuditQueueSize int
+
+	// MigrationsDir — каталог миграций, с последней из которых CheckProbes сравнивает
+	// версию схемы базы данных.
+	MigrationsDir string
+
+	// WarmupConnections — число соединений с базой данных, которые Run открывает до того,
+	// как /readyz сообщит о готовности; пул сохраняет их как простаивающие. WarmupAuth
+	// устанавливает соединение с сервисом аутентификации проверкой заведомо недействительного
+	// токена. Прогрев ограничен WarmupTimeout (0 — DefaultWarmupTimeout); его ошибки
+	// записываются в журнал и не мешают запуску.
+	WarmupConnections int
+	WarmupAuth        bool
+	WarmupTimeout     time.Duration
+	// DrainDelay — время между переводом /readyz в состояние 503 и началом остановки серверов
+	// при отмене контекста Run, за которое балансировщик выводит экземпляр из ротации.
+	DrainDelay time.Duration
+	// RateLimitBackend — хранилище счетчиков ограничения выгрузок данных пользователя:
+	// middleware.RateLimitMemory (пустое значение) ограничивает каждый экземпляр отдельно,
+	// middleware.RateLimitPostgres — все экземпляры с общей базой данных вместе.
+	// UserDataExportInterval — окно ограничения, одна выгрузка за окно (0 — handler.UserDataExportInterval).
+	RateLimitBackend       string
+	UserDataExportInterval time.Duration
+	// PublicStatusIPLimit и PublicStatusRefLimit — число запросов статуса заявки по коду
+	// (GET /public/calls/:ref/status) с одного IP-адреса и к одному коду за
+	// handler.PublicStatusWindow (0 — handler.DefaultPublicStatusIPLimit
+	// и handler.DefaultPublicStatusRefLimit); счетчики хранятся в RateLimitBackend.
+	// PublicStatusMinResponseTime — время, раньше которого не отправляется ответ (см.
+	// handler.PublicStatusConfig).
+	PublicStatusIPLimit         int
+	PublicStatusRefLimit        int
+	PublicStatusMinResponseTime time.Duration
+	// Captcha — проверка CAPTCHA при регистрации и после CaptchaLoginFailures неудачных попыток
+	// входа с одним именем пользователя за CaptchaLoginWindow (0 — handler.DefaultCaptchaLoginFailures
+	// и handler.DefaultCaptchaLoginWindow); счетчики хранятся в RateLimitBackend. По умолчанию
+	// проверка отключена.
+	Captcha              captcha.Config
+	CaptchaLoginFailures int
+	CaptchaLoginWindow   time.Duration
+	// Cache — кэш проверок токенов для режима деградации и счетчиков ограничителя
+	// middleware.RateLimitCache. При недоступности Redis кэш проверок токенов переходит на память
+	// процесса, а ограничитель поступает согласно RateLimitFallback: middleware.RateLimitFallbackOpen
+	// (пустое значение), middleware.RateLimitFallbackClosed или middleware.RateLimitFallbackMemory.
+	Cache             cache.Config
+	RateLimitFallback string
+	// ResponseCacheTTL — время жизни кэшированных ответов GET /calls (0 — ответы не кэшируются).
+	// Ответы хранятся в Redis, если Cache задает его, иначе в отдельном кэше в памяти процесса:
+	// тогда изменения заявок на других экземплярах сервиса видны по истечении ResponseCacheTTL.
+	ResponseCacheTTL time.Duration
+	// SLOObjectives — цели уровня обслуживания маршрутов HTTP API и методов gRPC с бюджетом ошибок
+	// за SLOPeriod (0 — slo.DefaultPeriod); без целей учет не ведется, а маршруты GET /admin/slo
+	// и GET /metrics не регистрируются.
+	SLOObjectives []slo.Objective
+	SLOPeriod     time.Duration
+}
+
+// Deps содержит внешние зависимости приложения. Незаданные зависимости создаются по Config;
+// переданные зависимости остаются во владении вызывающего и не закрываются при остановке.
+type Deps struct {
+	AuthClient      authclient.AuthClient
+	DB              *bun.DB
+	Dialer          telephony.Dialer
+	AttachmentStore blobstore.Store
+	// Logger — логгер журнала запросов; nil означает slog.Default().
+	Logger *slog.Logger
+	// LogLevel — уровень логгера, который ReloadConfig устанавливает по Config.LogLevel;
+	// nil означает, что уровень меняется только перезапуском.
+	LogLevel *slog.LevelVar
+}
+
+// App — собранное приложение с HTTP-, gRPC- и, при необходимости, диагностическим сервером.
+type App struct {
+	cfg        Config
+	sqldb      *sql.DB
+	router     *gin.Engine
+	flags      *featureflags.Store
+	authClient authclient.AuthClient
+	// readiness — состояние /readyz: готов после прогрева, не готов перед остановкой.
+	readiness *handler.Readiness
+
+	httpServer  *http.Server
+	grpcServer  *grpc.Server
+	debugServer *http.Server
+	// scheduler — фоновые задачи; stopScheduler равен nil, пока они не запущены в Run.
+	scheduler     *scheduler.Scheduler
+	stopScheduler context.CancelFunc
+	schedulerDone chan struct{}
+
+	listenOnce  sync.Once
+	listenErr   error
+	httpLis     net.Listener
+	grpcLis     net.Listener
+	debugLis    net.Listener
+	closers     []func() error
+	shutdownErr error
+	stopOnce    sync.Once
+
+	// Компоненты с параметрами, которые ReloadConfig меняет без перезапуска; nil, если
+	// компонент не используется. reloadMu упорядочивает перечитывания конфигурации.
+	reloadMu      sync.Mutex
+	logLevel      *slog.LevelVar
+	maintenance   *middleware.Maintenance
+	exportLimiter middleware.Limiter
+	usernames     *service.UserDirectory
+	// callEvents — соединения WebSocket с событиями заявок; закрываются в Shutdown.
+	callEvents *handler.CallEventsHandler
+}
+
+// New создает приложение: подключается к базе данных и сервису аутентификации (если они
+// не переданы в deps) и собирает обработчики и серверы. Порты занимаются в Listen или Run.
+func New(cfg Config, deps Deps) (*App, error) {
+	flags, err := featureflags.NewStore(cfg.FeatureFlags, cfg.FeatureFlagsFile)
+	if err != nil {
+		return nil, err
+	}
+	a := &App{cfg: cfg, flags: flags, readiness: &handler.Readiness{}, logLevel: deps.LogLevel}
+
+	// Инициализация репозиториев. В режиме DevInMemory сервис работает без PostgreSQL
+	var callRepo repository.CallRepository
+	var apiKeyRepo repository.APIKeyRepository
+	var savedViewRepo repository.SavedViewRepository
+	var notificationRepo repository.NotificationRepository
+	var erasureRepo repository.ErasureRepository
+	var webhookRepo repository.WebhookRepository
+	var attachmentRepo repository.AttachmentRepository
+	var apiAuditRepo repository.APIAuditRepository
+	// healthChecks — проверки соединений с базами данных для /healthz
+	var healthChecks []handler.HealthCheck
+	// primaryDB — основная база данных; nil в режиме DevInMemory
+	var primaryDB *bun.DB
+	switch {
+	case deps.DB != nil:
+		a.sqldb = deps.DB.DB
+		primaryDB = deps.DB
+		healthChecks = append(healthChecks, handler.HealthCheck{Name: "database", Check: deps.DB.PingContext})
+		callRepo = repository.NewCallRepository(deps.DB, repository.WithQueryTimeout(cfg.QueryTimeout))
+		apiKeyRepo = repository.NewAPIKeyRepository(deps.DB, repository.WithQueryTimeout(cfg.QueryTimeout))
+		savedViewRepo = repository.NewSavedViewRepository(deps.DB, repository.WithQueryTimeout(cfg.QueryTimeout))
+		notificationRepo = repository.NewNotificationRepository(deps.DB, repository.WithQueryTimeout(cfg.QueryTimeout))
+		erasureRepo = repository.NewErasureRepository(deps.DB, repository.WithQueryTimeout(cfg.QueryTimeout))
+		webhookRepo = repository.NewWebhookRepository(deps.DB, repository.WithQueryTimeout(cfg.QueryTimeout))
+		attachmentRepo = repository.NewAttachmentRepository(deps.DB, repository.WithQueryTimeout(cfg.QueryTimeout))
+		apiAuditRepo = repository.NewAPIAuditRepository(deps.DB, repository.WithQueryTimeout(cfg.QueryTimeout))
+	case cfg.DevInMemory:
+		log.Println("DEV_INMEMORY is enabled: calls are kept in memory and lost on restart")
+		callRepo = repository.NewInMemoryCallRepository()
+		apiKeyRepo = repository.NewInMemoryAPIKeyRepository()
+		savedViewRepo = repository.NewInMemorySavedViewRepository()
+		notificationRepo = repository.NewInMemoryNotificationRepository()
+		erasureRepo = repository.NewInMemoryErasureRepository()
+		webhookRepo = repository.NewInMemoryWebhookRepository()
+		attachmentRepo = repository.NewInMemoryAttachmentRepository()
+		apiAuditRepo = repository.NewInMemoryAPIAuditRepository()
+	default:
+		a.sqldb = sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(cfg.DSN)))
+		db := bun.NewDB(a.sqldb, pgdialect.New())
+		db.AddQueryHook(&repository.SlowQueryHook{Threshold: cfg.SlowQueryThreshold})
+		db.AddQueryHook(&repository.QueryStatsHook{})
+		a.closers = append(a.closers, db.Close)
+		healthChecks = append(healthChecks, handler.HealthCheck{Name: "database", Check: db.PingContext})
+		primaryDB = db
+
+		// Реплика для запросов чтения заявок
+		var replica *bun.DB
+		if cfg.ReadDSN != "" {
+			replica = bun.NewDB(sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(cfg.ReadDSN))), pgdialect.New())
+			replica.AddQueryHook(&repository.SlowQueryHook{Threshold: cfg.SlowQueryThreshold})
+			replica.AddQueryHook(&repository.QueryStatsHook{})
+			a.closers = append(a.closers, replica.Close)
+			healthChecks = append(healthChecks, handler.HealthCheck{Name: "database_replica", Check: replica.PingContext})
+		}
+		callRepo = repository.NewCallRepositoryWithReplica(db, replica, repository.WithQueryTimeout(cfg.QueryTimeout))
+		apiKeyRepo = repository.NewAPIKeyRepository(db, repository.WithQueryTimeout(cfg.QueryTimeout))
+		savedViewRepo = repository.NewSavedViewRepository(db, repository.WithQueryTimeout(cfg.QueryTimeout))
+		notificationRepo = repository.NewNotificationRepository(db, repository.WithQueryTimeout(cfg.QueryTimeout))
+		erasureRepo = repository.NewErasureRepository(db, repository.WithQueryTimeout(cfg.QueryTimeout))
+		webhookRepo = repository.NewWebhookRepository(db, repository.WithQueryTimeout(cfg.QueryTimeout))
+		attachmentRepo = repository.NewAttachmentRepository(db, repository.WithQueryTimeout(cfg.QueryTimeout))
+		apiAuditRepo = repository.NewAPIAuditRepository(db, repository.WithQueryTimeout(cfg.QueryTimeout))
+	}
+
+	// Кэш. Недоступность Redis не делает сервис неработоспособным: компоненты переходят на резервное
+	// поведение, поэтому проверка в /healthz необязательная
+	cacheStore, err := cache.New(cfg.Cache)
+	if err != nil {
+		a.close()
+		return nil, err
+	}
+	// tokenCache — кэш проверок токенов; nil — память процесса
+	var tokenCache cache.Store
+	if redisStore, ok := cacheStore.(*cache.Redis); ok {
+		a.closers = append(a.closers, redisStore.Close)
+		healthChecks = append(healthChecks, handler.HealthCheck{Name: "cache", Check: redisStore.Ping, Optional: true})
+		tokenCache = cache.WithFallback("auth_tokens", redisStore, cache.NewMemory(0, nil))
+	}
+
+	// Журнал изменяющих запросов закрывается раньше базы данных, чтобы сохранить записи из очереди
+	apiAuditLog := service.NewAPIAuditLog(apiAuditRepo, cfg.APIAuditQueueSize)
+	a.closers = append(a.closers, apiAuditLog.Close)
+
+	// Клиент аутентификации. Соединение создается отдельно от клиента,
+	// чтобы его могли использовать и другие клиенты внутренних gRPC-сервисов
+	authClient := deps.AuthClient
+	if authClient == nil {
+		authConn, err := authclient.Dial(cfg.AuthServiceAddr, authclient.WithResolveInterval(cfg.AuthResolveInterval))
+		if err != nil {
+			a.close()
+			return nil, fmt.Errorf("connect to auth service: %w", err)
+		}
+		a.closers = append(a.closers, authConn.Close)
+		authClient = authclient.NewAuthClientWithConn(authConn, cfg.Auth)
+	}
+	a.authClient = authClient
+
+	dialer := deps.Dialer
+	if dialer == nil {
+		var err error
+		if dialer, err = telephony.New(cfg.Telephony); err != nil {
+			a.close()
+			return nil, err
+		}
+	}
+
+	attachmentStore := deps.AttachmentStore
+	if attachmentStore == nil {
+		var err error
+		if attachmentStore, err = blobstore.New(cfg.AttachmentStore); err != nil {
+			a.close()
+			return nil, err
+		}
+	}
+	attachmentService := service.NewAttachmentService(attachmentRepo, callRepo, attachmentStore, cfg.Attachments)
+
+	// События заявок передаются клиентам GET /calls/ws этого экземпляра сервиса
+	broker := events.NewBroker(events.Config{MaxPerUser: cfg.WSMaxConnectionsPerUser})
+	callOpts := []service.Option{
+		service.WithDialer(dialer, cfg.DialTimeout),
+		service.WithAttachments(attachmentService),
+		service.WithFeatureFlags(flags),
+		service.WithEvents(broker),
+	}
+	if cfg.ResolveUsernames {
+		a.usernames = service.NewUserDirectory(authClient, cfg.UsernameCacheTTL, nil)
+		callOpts = append(callOpts, service.WithUserDirectory(a.usernames))
+	}
+	// responseCache — кэш ответов списка заявок; nil, если он отключен. Кэш в памяти отделен
+	// от cacheStore, чтобы ответы не вытесняли счетчики ограничителя
+	var responseCache *middleware.ResponseCache
+	erasureCfg := service.ErasureConfig{Policy: cfg.ErasurePolicy}
+	if cfg.ResponseCacheTTL > 0 {
+		responseStore := cache.Store(cache.NewMemory(0, nil))
+		if _, ok := cacheStore.(*cache.Redis); ok {
+			responseStore = cacheStore
+		}
+		responseCache = middleware.NewResponseCache(responseStore, cfg.ResponseCacheTTL)
+		callOpts = append(callOpts, service.WithCacheInvalidator(responseCache))
+		erasureCfg.Invalidator = responseCache
+	}
+
+	// Уведомления пользователей. Очередь уведомлений закрывается раньше базы данных,
+	// чтобы отправить уже поставленные в нее уведомления
+	notificationService := service.NewNotificationService(notificationRepo, cfg.NotifyChannels)
+	// notifier — уведомления пользователям; nil, если каналы не заданы
+	var notifier notify.Notifier
+	// telegram — бот уведомлений; nil, если канал telegram не задан
+	var telegram *notify.Telegram
+	if len(cfg.NotifyChannels) > 0 {
+		channels := make(map[string]notify.Notifier, len(cfg.NotifyChannels))
+		for _, name := range cfg.NotifyChannels {
+			var err error
+			switch name {
+			case model.NotificationChannelEmail:
+				channels[name], err = notify.NewEmail(cfg.NotifySMTP, authClient)
+			case model.NotificationChannelTelegram:
+				telegram, err = notify.NewTelegram(cfg.NotifyTelegram, notificationService)
+				channels[name] = telegram
+			default:
+				err = fmt.Errorf("unknown notification channel %q", name)
+			}
+			if err != nil {
+				a.close()
+				return nil, err
+			}
+		}
+		dispatcher := notify.NewDispatcher(channels, notificationService, cfg.NotifyDispatcher)
+		a.closers = append(a.closers, dispatcher.Close)
+		notifier = dispatcher
+		callOpts = append(callOpts, service.WithNotifier(dispatcher))
+	}
+
+	callService := service.NewCallService(callRepo, callOpts...)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
+	savedViewService := service.NewSavedViewService(savedViewRepo)
+	erasureCfg.SavedViews = savedViewService
+	erasureCfg.Notifications = notificationService
+	webhookCfg := cfg.Webhooks
+	webhookCfg.Policy = cfg.CallbackWebhookPolicy
+	webhookService := service.NewWebhookService(webhookRepo, webhookCfg)
+	erasureCfg.Webhooks = webhookService
+	erasureService := service.NewErasureService(erasureRepo, callRepo, apiKeyService, attachmentService, authClient, erasureCfg)
+
+	// Ограничение частоты выгрузок данных пользователя
+	exportInterval := cfg.UserDataExportInterval
+	if exportInterval <= 0 {
+		exportInterval = handler.UserDataExportInterval
+	}
+	var exportLimiter middleware.Limiter
+	// newLimiter создает ограничитель на limit запросов с одним ключом за окно window
+	// в хранилище RateLimitBackend; scope разделяет счетчики разных ограничителей
+	var newLimiter func(scope string, limit int, window time.Duration) middleware.Limiter
+	switch cfg.RateLimitBackend {
+	case "", middleware.RateLimitMemory:
+		limiterStore := cache.NewMemory(0, nil)
+		newLimiter = func(scope string, limit int, window time.Duration) middleware.Limiter {
+			return middleware.NewStoreLimiter(limiterStore, "rate:"+scope+":", limit, window, nil)
+		}
+		exportLimiter = middleware.NewRateLimiter(exportInterval, nil)
+	case middleware.RateLimitPostgres:
+		if primaryDB == nil {
+			a.close()
+			return nil, fmt.Errorf("rate limit backend %q requires a database", cfg.RateLimitBackend)
+		}
+		newLimiter = func(scope string, limit int, window time.Duration) middleware.Limiter {
+			return repository.NewPostgresRateLimiter(primaryDB, scope, limit, window, repository.WithQueryTimeout(cfg.QueryTimeout))
+		}
+		exportLimiter = newLimiter("user_data_export", 1, exportInterval)
+	case middleware.RateLimitCache:
+		limiterStore := cacheStore
+		if cfg.RateLimitFallback == middleware.RateLimitFallbackMemory {
+			limiterStore = cache.WithFallback("rate_limit", cacheStore, cache.NewMemory(0, nil))
+		}
+		failClosed := false
+		switch cfg.RateLimitFallback {
+		case "", middleware.RateLimitFallbackOpen, middleware.RateLimitFallbackMemory:
+		case middleware.RateLimitFallbackClosed:
+			failClosed = true
+		default:
+			a.close()
+			return nil, fmt.Errorf("unknown rate limit fallback %q: expected open, closed or memory", cfg.RateLimitFallback)
+		}
+		newLimiter = func(scope string, limit int, window time.Duration) middleware.Limiter {
+			var limiter middleware.Limiter = middleware.NewStoreLimiter(limiterStore, "rate:"+scope+":", limit, window, nil)
+			if failClosed {
+				limiter = middleware.FailClosed(limiter, time.Minute)
+			}
+			return limiter
+		}
+		exportLimiter = newLimiter("user_data_export", 1, exportInterval)
+	default:
+		a.close()
+		return nil, fmt.Errorf("unknown rate limit backend %q: expected memory, postgres or cache", cfg.RateLimitBackend)
+	}
+	publicStatusIPLimit, publicStatusRefLimit := cfg.PublicStatusIPLimit, cfg.PublicStatusRefLimit
+	if publicStatusIPLimit <= 0 {
+		publicStatusIPLimit = handler.DefaultPublicStatusIPLimit
+	}
+	if publicStatusRefLimit <= 0 {
+		publicStatusRefLimit = handler.DefaultPublicStatusRefLimit
+	}
+	publicStatus := handler.NewPublicStatusHandler(callService, handler.PublicStatusConfig{
+		IPLimiter:       newLimiter("public_status_ip", publicStatusIPLimit, handler.PublicStatusWindow),
+		RefLimiter:      newLimiter("public_status_ref", publicStatusRefLimit, handler.PublicStatusWindow),
+		MinResponseTime: cfg.PublicStatusMinResponseTime,
+	})
+
+	// Проверка CAPTCHA при регистрации и после неудачных попыток входа
+	captchaVerifier, err := captcha.New(cfg.Captcha)
+	if err != nil {
+		a.close()
+		return nil, err
+	}
+	var captchaCfg handler.CaptchaConfig
+	if captchaVerifier != nil {
+		loginFailures, loginWindow := cfg.CaptchaLoginFailures, cfg.CaptchaLoginWindow
+		if loginFailures <= 0 {
+			loginFailures = handler.DefaultCaptchaLoginFailures
+		}
+		if loginWindow <= 0 {
+			loginWindow = handler.DefaultCaptchaLoginWindow
+		}
+		captchaCfg = handler.CaptchaConfig{
+			Verifier:      captchaVerifier,
+			LoginFailures: newLimiter("login_failures", loginFailures, loginWindow),
+		}
+	}
+
+	// Учет целей уровня обслуживания
+	var sloRegistry *slo.Registry
+	if len(cfg.SLOObjectives) > 0 {
+		if sloRegistry, err = slo.New(cfg.SLOObjectives, cfg.SLOPeriod, nil); err != nil {
+			a.close()
+			return nil, err
+		}
+	}
+
+	// Маршрутизатор. X-Forwarded-For учитывается только от прокси из TrustedProxies
+	trustedProxies, err := clientip.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		a.close()
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	a.router = gin.New()
+	if err := a.router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		a.close()
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
+	// IP клиента, идентификатор запроса и структурированный журнал запросов
+	a.router.Use(gin.Recovery(), middleware.TrustProxies(trustedProxies), middleware.RequestID(), middleware.AccessLog(middleware.AccessLogConfig{
+		Logger:          deps.Logger,
+		SkipPaths:       cfg.AccessLogSkipPaths,
+		SuccessSampling: cfg.AccessLogSuccessSampling,
+	}))
+
+	// Учет запросов в целях уровня обслуживания; время ответа учитывается вместе со сжатием
+	if sloRegistry != nil {
+		a.router.Use(middleware.SLO(sloRegistry))
+	}
+
+	// Сжатие JSON и CSV ответов для клиентов, поддерживающих gzip/deflate
+	a.router.Use(middleware.Compress(middleware.CompressConfig{MinSize: cfg.CompressMinSize}))
+
+	// Ограничение времени обработки запроса; срок передается в репозитории и клиент аутентификации
+	a.router.Use(middleware.Timeout(middleware.TimeoutConfig{
+		Timeout:   cfg.RequestTimeout,
+		SkipPaths: cfg.RequestTimeoutSkipPaths,
+	}))
+
+	// Регистрация маршрутов API и документации
+	authMiddleware := middleware.NewAuthMiddlewareWithConfig(authClient, middleware.AuthConfig{
+		TokenSources:  cfg.AuthTokenSources,
+		APIKeys:       apiKeyService,
+		StaleTTL:      cfg.AuthStaleTTL,
+		Organizations: cfg.Organizations,
+		Flags:         flags,
+		Cache:         tokenCache,
+	})
+	maintenance := middleware.NewMaintenance(cfg.Maintenance, cfg.MaintenanceRetryAfter)
+	a.maintenance, a.exportLimiter = maintenance, exportLimiter
+	var faults *middleware.FaultInjector
+	if cfg.FaultInjection {
+		faults = middleware.NewFaultInjector(cfg.FaultRules, deps.Logger)
+	}
+	var sloHandler *handler.SLOHandler
+	if sloRegistry != nil {
+		sloHandler = handler.NewSLOHandler(sloRegistry)
+	}
+	var organizations *handler.OrganizationHandler
+	if cfg.Organizations {
+		organizations = handler.NewOrganizationHandler(authClient, authMiddleware)
+	}
+	a.callEvents = handler.NewCallEventsHandler(broker, authMiddleware, handler.CallEventsConfig{})
+	handler.RegisterRoutes(a.router, handler.Routes{
+		Auth:           handler.NewAuthHandlerWithConfig(authClient, handler.AuthHandlerConfig{Cookie: cfg.AuthCookie, Captcha: captchaCfg}),
+		Calls:          handler.NewCallHandler(callService, authClient),
+		Events:         a.callEvents,
+		Attachments:    handler.NewAttachmentHandler(attachmentService),
+		Admin:          handler.NewAdminHandler(callService),
+		Profile:        handler.NewProfileHandler(authClient),
+		APIKeys:        handler.NewAPIKeyHandler(apiKeyService),
+		Views:          handler.NewSavedViewHandler(savedViewService),
+		UserData:       handler.NewUserDataHandler(callService, authClient, erasureService),
+		Notifications:  handler.NewNotificationHandler(notificationService, telegram),
+		Webhooks:       handler.NewWebhookHandler(webhookService),
+		PublicStatus:   publicStatus,
+		Impersonation:  handler.NewImpersonationHandler(authClient),
+		Users:          handler.NewAdminUsersHandler(authClient),
+		Organizations:  organizations,
+		Docs:           handler.NewDocsHandler(openapi.JSON()),
+		FeatureFlags:   handler.NewFeatureFlagsHandler(flags),
+		Config:         handler.NewConfigHandler(a),
+		Maintenance:    maintenance,
+		Faults:         faults,
+		Health:         handler.NewHealthHandlerWithMaintenance(maintenance, healthChecks...),
+		SLO:            sloHandler,
+		AuthMiddleware: authMiddleware,
+		AuditLog:       handler.NewAuditLogHandler(apiAuditLog),
+		SwaggerUI:      cfg.SwaggerUI,
+		Readiness:      a.readiness,
+		ExportLimiter:  exportLimiter,
+		ResponseCache:  responseCache,
+	})
+
+	// Фоновые задачи. Блокировки в PostgreSQL не дают нескольким экземплярам выполнять задачу одновременно.
+	// Завершение прерванных удалений учетных записей, доставка событий подпискам и очистка
+	// устаревших данных выполняются всегда
+	jobs := []scheduler.Job{
+		scheduler.ErasuresJob(erasureService, cfg.ErasuresInterval),
+		scheduler.WebhookDeliveriesJob(webhookService, cfg.WebhookDeliveriesInterval),
+		scheduler.PurgeJob(webhookService, cfg.PurgeInterval),
+	}
+	if cfg.StaleCallsAfter > 0 {
+		jobs = append(jobs, scheduler.StaleCallsJob(callService, scheduler.StaleCallsConfig{
+			OlderThan: cfg.StaleCallsAfter,
+			Interval:  cfg.StaleCallsInterval,
+			DryRun:    cfg.StaleCallsDryRun,
+		}))
+	}
+	// О наступлении времени повторного звонка уведомляются webhook, подписки владельца заявки
+	// и сам владелец
+	callbacks := notify.Callbacks{Subscriptions: webhookService, Users: notifier}
+	if cfg.CallbackWebhookURL != "" {
+		// Адрес, нарушающий ограничения, не дает запустить сервис; ошибка разрешения имени
+		// только записывается в журнал, так как адрес проверяется и при каждом соединении
+		ctx, cancel := context.WithTimeout(context.Background(), webhookValidationTimeout)
+		err := cfg.CallbackWebhookPolicy.ValidateURL(ctx, cfg.CallbackWebhookURL)
+		cancel()
+		if reason := safehttp.ReasonOf(err); reason != "" && reason != safehttp.ReasonTimeout {
+			a.close()
+			return nil, fmt.Errorf("invalid callback webhook url: %w", err)
+		} else if err != nil {
+			log.Printf("callback webhook url is not checked: %v", err)
+		}
+		callbacks.Webhook = notify.NewWebhookWithClient(cfg.CallbackWebhookURL, cfg.CallbackWebhookSecret, cfg.CallbackWebhookPolicy.Client())
+	}
+	jobs = append(jobs, scheduler.CallbacksJob(callService, callbacks, cfg.CallbacksInterval))
+	locker := scheduler.NewLocalLocker()
+	if a.sqldb != nil {
+		locker = scheduler.NewPostgresLocker(a.sqldb)
+	}
+	a.scheduler = scheduler.New(locker, jobs...)
+
+	a.httpServer = &http.Server{Handler: a.router, ReadHeaderTimeout: 10 * time.Second}
+	if cfg.GRPCAddr != "" {
+		var opts []grpc.ServerOption
+		if sloRegistry != nil {
+			opts = append(opts, grpc.ChainUnaryInterceptor(sloRegistry.UnaryServerInterceptor()))
+		}
+		a.grpcServer = grpcserver.NewServerWithConfig(callService, authClient, grpcserver.AuthConfig{Organizations: cfg.Organizations}, opts...)
+	}
+	if cfg.DebugAddr != "" {
+		// WriteTimeout не задается: снятие профиля CPU по умолчанию длится 30 секунд
+		a.debugServer = &http.Server{Handler: diagnostics.NewHandler(), ReadHeaderTimeout: 10 * time.Second}
+	}
+	return a, nil
+}
+
+// Handler возвращает HTTP-обработчик приложения.
+func (a *App) Handler() http.Handler {
+	return a.router
+}
+
+// ReloadFeatureFlags перечитывает файл флагов (Config.FeatureFlagsFile). При ошибке
+// продолжают действовать прежние значения.
+func (a *App) ReloadFeatureFlags() error {
+	return a.flags.Reload()
+}
+
+// DB возвращает пул соединений с базой данных или nil в режиме DevInMemory.
+func (a *App) DB() *sql.DB {
+	return a.sqldb
+}
+
+// Listen занимает порты HTTP-, gRPC- и диагностического серверов. Повторные вызовы
+// возвращают результат первого. Вызывать Listen перед Run нужно, только если адреса
+// требуются до запуска серверов (например, при порте 0 в тестах).
+func (a *App) Listen() error {
+	a.listenOnce.Do(func() {
+		var err error
+		if a.httpLis, err = net.Listen("tcp", a.cfg.HTTPAddr); err != nil {
+			a.listenErr = fmt.Errorf("listen HTTP: %w", err)
+			return
+		}
+		if a.grpcServer != nil {
+			if a.grpcLis, err = net.Listen("tcp", a.cfg.GRPCAddr); err != nil {
+				a.httpLis.Close()
+				a.listenErr = fmt.Errorf("listen gRPC: %w", err)
+				return
+			}
+		}
+		if a.debugServer != nil {
+			if a.debugLis, err = net.Listen("tcp", a.cfg.DebugAddr); err != nil {
+				a.httpLis.Close()
+				if a.grpcLis != nil {
+					a.grpcLis.Close()
+				}
+				a.listenErr = fmt.Errorf("listen diagnostics: %w", err)
+			}
+		}
+	})
+	return a.listenErr
+}
+
+// HTTPAddr возвращает адрес HTTP-сервера после Listen.
+func (a *App) HTTPAddr() net.Addr {
+	return a.httpLis.Addr()
+}
+
+// GRPCAddr возвращает адрес gRPC-сервера после Listen или nil, если gRPC API отключен.
+func (a *App) GRPCAddr() net.Addr {
+	if a.grpcLis == nil {
+		return nil
+	}
+	return a.grpcLis.Addr()
+}
+
+// Run запускает серверы и блокируется до отмены ctx или ошибки одного из серверов,
+// после чего останавливает приложение через Shutdown. /readyz сообщает о готовности после
+// прогрева (см. Config.WarmupConnections), а при отмене ctx перед остановкой серверов
+// выдерживается Config.DrainDelay.
+func (a *App) Run(ctx context.Context) error {
+	if err := a.Listen(); err != nil {
+		a.close()
+		return err
+	}
+
+	errs := make(chan error, 3)
+	go func() {
+		log.Printf("Starting HTTP server on %s", a.httpLis.Addr())
+		if err := a.httpServer.Serve(a.httpLis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errs <- fmt.Errorf("serve HTTP: %w", err)
+		}
+	}()
+	if a.grpcServer != nil {
+		go func() {
+			log.Printf("Starting gRPC server on %s", a.grpcLis.Addr())
+			if err := a.grpcServer.Serve(a.grpcLis); err != nil {
+				errs <- fmt.Errorf("serve gRPC: %w", err)
+			}
+		}()
+	}
+	if a.debugServer != nil {
+		go func() {
+			log.Printf("Starting diagnostics server on %s", a.debugLis.Addr())
+			if err := a.debugServer.Serve(a.debugLis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("diagnostics server stopped: %v", err)
+			}
+		}()
+	}
+
+	// Задачи останавливаются в Shutdown, после остановки серверов
+	var schedulerCtx context.Context
+	schedulerCtx, a.stopScheduler = context.WithCancel(context.WithoutCancel(ctx))
+	a.schedulerDone = make(chan struct{})
+	go func() {
+		defer close(a.schedulerDone)
+		a.scheduler.Run(schedulerCtx)
+	}()
+
+	// Экземпляр становится готовым после прогрева соединений
+	warmupCtx, stopWarmup := context.WithCancel(ctx)
+	warmupDone := make(chan struct{})
+	go func() {
+		defer close(warmupDone)
+		a.warmup(warmupCtx)
+		if warmupCtx.Err() == nil {
+			a.readiness.SetReady(true)
+		}
+	}()
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+	case runErr = <-errs:
+	}
+	stopWarmup()
+	<-warmupDone
+	if runErr == nil {
+		a.lameDuck()
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+	return errors.Join(runErr, a.Shutdown(shutdownCtx))
+}
+
+// Shutdown останавливает серверы, дожидаясь завершения текущих запросов до отмены ctx,
+// и закрывает соединения, созданные приложением. Повторные вызовы возвращают результат первого.
+func (a *App) Shutdown(ctx context.Context) error {
+	a.stopOnce.Do(func() {
+		log.Println("Shutting down servers...")
+		var errs []error
+		if err := a.httpServer.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown HTTP: %w", err))
+		}
+		// http.Server.Shutdown не ждет соединений WebSocket, они закрываются отдельно
+		if err := a.callEvents.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("close websocket connections: %w", err))
+		}
+
+		// GracefulStop не учитывает ctx, поэтому по его истечении сервер останавливается принудительно
+		if a.grpcServer != nil {
+			stopped := make(chan struct{})
+			go func() {
+				a.grpcServer.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				a.grpcServer.Stop()
+				errs = append(errs, fmt.Errorf("shutdown gRPC: %w", ctx.Err()))
+			}
+		}
+
+		// Выполняющаяся задача получает отмену и завершается до закрытия соединения с базой данных
+		if a.stopScheduler != nil {
+			a.stopScheduler()
+			select {
+			case <-a.schedulerDone:
+			case <-ctx.Done():
+				errs = append(errs, fmt.Errorf("stop scheduler: %w", ctx.Err()))
+			}
+		}
+
+		if a.debugServer != nil {
+			_ = a.debugServer.Close()
+		}
+		// Порты, занятые в Listen без последующего Run, освобождаются здесь
+		for _, lis := range []net.Listener{a.httpLis, a.grpcLis, a.debugLis} {
+			if lis != nil {
+				_ = lis.Close()
+			}
+		}
+		errs = append(errs, a.close())
+		a.shutdownErr = errors.Join(errs...)
+	})
+	return a.shutdownErr
+}
+
+// close закрывает соединения, созданные приложением, в обратном порядке.
+func (a *App) close() error {
+	var errs []error
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		errs = append(errs, a.closers[i]())
+	}
+	a.closers = nil
+	return errors.Join(errs...)
+}
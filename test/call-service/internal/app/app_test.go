@@ -0,0 +1,239 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/blobstore"
+	"call-service/internal/cache"
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/safehttp"
+	"call-service/pkg/authclient"
+)
+
+// TestApp_Smoke запускает приложение с заявками в памяти и mock-клиентом аутентификации,
+// создает заявку через HTTP API и проверяет корректную остановку при отмене контекста.
+
+func TestApp_Smoke(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authClient := mocks.NewMockAuthClient(gomock.NewController(t))
+	authClient.EXPECT().ValidateTokenFull(gomock.Any(), "test-token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: uuid.NewString(), Role: middleware.RoleUser}, nil)
+
+	a, err := New(Config{
+		HTTPAddr:        "127.0.0.1:0",
+		GRPCAddr:        "127.0.0.1:0",
+		DevInMemory:     true,
+		AttachmentStore: blobstore.Config{Dir: t.TempDir()},
+	}, Deps{
+		AuthClient: authClient,
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.NoError(t, err)
+	require.NoError(t, a.Listen())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+
+	req, _ := http.NewRequest("POST", "http://"+a.HTTPAddr().String()+"/calls",
+		strings.NewReader(`{"client_name":"Test Client","phone_number":"+1234567890","description":"Test Description"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test-token")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get(middleware.RequestIDHeader))
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("application did not stop")
+	}
+}
+
+// TestApp_WarmupAndLameDuck проверяет, что /readyz возвращает 503 до завершения прогрева
+// соединения с сервисом аутентификации, а после отмены контекста снова возвращает 503,
+// пока сервер продолжает отвечать в течение DrainDelay.
+
+func TestApp_WarmupAndLameDuck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	release := make(chan struct{})
+	authClient := mocks.NewMockAuthClient(gomock.NewController(t))
+	authClient.EXPECT().ValidateToken(gomock.Any(), warmupToken).DoAndReturn(func(context.Context, string) (bool, string, error) {
+		<-release
+		return false, "", nil
+	})
+
+	a, err := New(Config{
+		HTTPAddr:        "127.0.0.1:0",
+		DevInMemory:     true,
+		AttachmentStore: blobstore.Config{Dir: t.TempDir()},
+		WarmupAuth:      true,
+		DrainDelay:      300 * time.Millisecond,
+	}, Deps{
+		AuthClient: authClient,
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.NoError(t, err)
+	require.NoError(t, a.Listen())
+	get := func(path string) int {
+		resp, err := http.Get("http://" + a.HTTPAddr().String() + path)
+		if err != nil {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return get("/healthz") == http.StatusOK }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, http.StatusServiceUnavailable, get("/readyz"))
+	close(release)
+	require.Eventually(t, func() bool { return get("/readyz") == http.StatusOK }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.Eventually(t, func() bool { return get("/readyz") == http.StatusServiceUnavailable }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, http.StatusOK, get("/healthz"))
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("application did not stop")
+	}
+}
+
+// fakeConnector открывает соединения-заглушки и считает их.
+
+type fakeConnector struct {
+	opened atomic.Int32
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	c.opened.Add(1)
+	return fakeConn{}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver { return nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+// TestWarmDB проверяет, что прогрев открывает заданное число соединений и пул сохраняет их.
+
+func TestWarmDB(t *testing.T) {
+	connector := &fakeConnector{}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	require.NoError(t, warmDB(context.Background(), db, 4))
+	assert.Equal(t, int32(4), connector.opened.Load())
+	assert.Equal(t, 4, db.Stats().Idle)
+}
+
+// TestNew_RateLimitBackend проверяет, что New отклоняет неизвестное хранилище счетчиков
+// ограничения частоты, хранилище PostgreSQL без базы данных и неизвестное поведение
+// при недоступности кэша.
+
+func TestNew_RateLimitBackend(t *testing.T) {
+	for _, cfg := range []Config{
+		{RateLimitBackend: "redis"},
+		{RateLimitBackend: middleware.RateLimitPostgres},
+		{RateLimitBackend: middleware.RateLimitCache, RateLimitFallback: "retry"},
+	} {
+		cfg.DevInMemory = true
+		cfg.AttachmentStore = blobstore.Config{Dir: t.TempDir()}
+		_, err := New(cfg, Deps{AuthClient: mocks.NewMockAuthClient(gomock.NewController(t))})
+		assert.ErrorContains(t, err, "rate limit", cfg.RateLimitBackend)
+	}
+}
+
+// TestApp_RedisCache проверяет, что с кэшем в Redis /healthz сообщает о его состоянии,
+// а недоступность Redis не делает ответ неуспешным.
+
+func TestApp_RedisCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := miniredis.RunT(t)
+	a, err := New(Config{
+		DevInMemory:      true,
+		AttachmentStore:  blobstore.Config{Dir: t.TempDir()},
+		Cache:            cache.Config{Backend: cache.BackendRedis, Redis: cache.RedisConfig{Addr: server.Addr()}},
+		RateLimitBackend: middleware.RateLimitCache,
+	}, Deps{AuthClient: mocks.NewMockAuthClient(gomock.NewController(t))})
+	require.NoError(t, err)
+	defer a.close()
+
+	health := func() (int, string) {
+		w := httptest.NewRecorder()
+		a.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		var body struct {
+			Checks map[string]string `json:"checks"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body.Checks["cache"]
+	}
+	code, state := health()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", state)
+
+	server.Close()
+	code, state = health()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "unavailable", state)
+}
+
+// TestNew_CallbackWebhookURL проверяет, что New отклоняет адрес webhook во внутренней сети
+// и адрес http, если он не разрешен, и принимает адрес из сети, разрешенной оператором.
+
+func TestNew_CallbackWebhookURL(t *testing.T) {
+	newApp := func(url string, policy safehttp.Policy) error {
+		a, err := New(Config{
+			DevInMemory:           true,
+			AttachmentStore:       blobstore.Config{Dir: t.TempDir()},
+			CallbackWebhookURL:    url,
+			CallbackWebhookPolicy: policy,
+		}, Deps{AuthClient: mocks.NewMockAuthClient(gomock.NewController(t))})
+		if err == nil {
+			a.close()
+		}
+		return err
+	}
+
+	assert.ErrorContains(t, newApp("https://169.254.169.254/hook", safehttp.Policy{}), safehttp.ReasonBlockedAddress)
+	assert.ErrorContains(t, newApp("http://203.0.113.10/hook", safehttp.Policy{}), safehttp.ReasonInsecureScheme)
+	assert.NoError(t, newApp("http://10.0.0.5/hook", safehttp.Policy{
+		AllowHTTP:       true,
+		AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+	}))
+}
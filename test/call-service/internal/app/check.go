@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/uptrace/bun/driver/pgdriver"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"call-service/internal/blobstore"
+	"call-service/internal/cache"
+	"call-service/internal/featureflags"
+	"call-service/internal/telephony"
+	"call-service/pkg/authclient"
+	"common/selfcheck"
+)
+
+// CheckProbes возвращает проверки готовности к запуску с конфигурацией cfg (флаг --check):
+// флаги, провайдер телефонии и хранилище вложений создаются так же, как в New, проверяются
+// соединения с базой данных, репликой и Redis, актуальность миграций и доступность сервиса
+// аутентификации с секретом внутренних сервисов. В режиме DevInMemory база данных
+// не проверяется.
+func CheckProbes(cfg Config) []selfcheck.Probe {
+	probes := []selfcheck.Probe{
+		{Name: "feature_flags", Run: func(context.Context) error {
+			_, err := featureflags.NewStore(cfg.FeatureFlags, cfg.FeatureFlagsFile)
+			return err
+		}},
+		{Name: "telephony", Run: func(context.Context) error {
+			_, err := telephony.New(cfg.Telephony)
+			return err
+		}},
+		{Name: "attachments", Run: func(context.Context) error {
+			_, err := blobstore.New(cfg.AttachmentStore)
+			return err
+		}},
+	}
+	if !cfg.DevInMemory {
+		probes = append(probes,
+			selfcheck.Probe{Name: "database", Run: func(ctx context.Context) error {
+				return withDB(cfg.DSN, func(db *sql.DB) error { return db.PingContext(ctx) })
+			}},
+			selfcheck.Probe{Name: "migrations", Run: func(ctx context.Context) error {
+				return withDB(cfg.DSN, func(db *sql.DB) error {
+					return selfcheck.MigrationsUpToDate(ctx, db, cfg.MigrationsDir)
+				})
+			}},
+		)
+		if cfg.ReadDSN != "" {
+			probes = append(probes, selfcheck.Probe{Name: "database_replica", Run: func(ctx context.Context) error {
+				return withDB(cfg.ReadDSN, func(db *sql.DB) error { return db.PingContext(ctx) })
+			}})
+		}
+	}
+	if cfg.Cache.Backend == cache.BackendRedis {
+		probes = append(probes, selfcheck.Probe{Name: "cache", Run: func(ctx context.Context) error {
+			store, err := cache.NewRedis(cfg.Cache.Redis)
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+			return store.Ping(ctx)
+		}})
+	}
+	probes = append(probes, selfcheck.Probe{Name: "auth_service", Run: func(ctx context.Context) error {
+		return checkAuthService(ctx, cfg)
+	}})
+	return probes
+}
+
+// withDB открывает соединение с базой данных dsn на время вызова fn.
+func withDB(dsn string, fn func(db *sql.DB) error) error {
+	db := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn)))
+	defer db.Close()
+	return fn(db)
+}
+
+// checkAuthService проверяет, что сервис аутентификации отвечает SERVING и принимает
+// вызовы с секретом внутренних сервисов: проверка заведомо недействительного токена
+// должна завершиться без ошибки.
+func checkAuthService(ctx context.Context, cfg Config) error {
+	conn, err := authclient.Dial(cfg.AuthServiceAddr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	health, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	if health.Status != healthpb.HealthCheckResponse_SERVING {
+		return errors.New("auth service is " + health.Status.String())
+	}
+	_, _, err = authclient.NewAuthClientWithConn(conn, cfg.Auth).ValidateToken(ctx, "selfcheck")
+	return err
+}
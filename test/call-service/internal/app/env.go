@@ -0,0 +1,342 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"call-service/internal/blobstore"
+	"call-service/internal/cache"
+	"call-service/internal/captcha"
+	"call-service/internal/events"
+	"call-service/internal/handler"
+	"call-service/internal/middleware"
+	"call-service/internal/model"
+	"call-service/internal/notify"
+	"call-service/internal/repository"
+	"call-service/internal/safehttp"
+	"call-service/internal/scheduler"
+	"call-service/internal/service"
+	"call-service/internal/telephony"
+	"call-service/pkg/authclient"
+	"common/env"
+	"common/slo"
+)
+
+// configMu упорядочивает вызовы LoadConfig; configFile — значения из файла CONFIG_FILE
+// на время загрузки конфигурации.
+var (
+	configMu   sync.Mutex
+	configFile map[string]string
+)
+
+// LoadConfig загружает конфигурацию приложения из переменных окружения. Ее используют
+// и отдельный call-service, и объединенный с сервисом аутентификации бинарник cmd/monolith.
+//
+// Если задана переменная CONFIG_FILE, значения из этого файла заменяют переменные окружения
+// с теми же именами. Файл, в отличие от окружения процесса, можно изменить во время работы:
+// App.ReloadConfig загружает конфигурацию заново и применяет изменившиеся динамические параметры.
+func LoadConfig() (Config, error) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	values, err := readConfigFile(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return Config{}, err
+	}
+	configFile = values
+	defer func() { configFile = nil }()
+
+	// Получение переменных окружения для конфигурации
+	production := settings.Get("APP_ENV", "development") == "production"
+	dbHost := settings.Get("DB_HOST", "postgres")
+	dbPort := settings.Get("DB_PORT", "5432")
+	dbUser := settings.Get("DB_USER", "postgres")
+	dbPassword := settings.Get("DB_PASSWORD", "postgres")
+	dbName := settings.Get("DB_NAME", "call_service")
+
+	cfg := Config{
+		HTTPAddr: ":" + settings.Get("HTTP_PORT", "8080"),
+		GRPCAddr: ":" + settings.Get("GRPC_PORT", "50052"),
+		DSN: fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+			dbUser, dbPassword, dbHost, dbPort, dbName),
+		// Реплика для запросов чтения заявок; по умолчанию все запросы выполняются в основной базе
+		ReadDSN: settings.Get("DB_READ_DSN", ""),
+		// В режиме DEV_INMEMORY сервис работает без PostgreSQL, данные хранятся в памяти
+		DevInMemory:        settings.Get("DEV_INMEMORY", "false") == "true",
+		QueryTimeout:       settings.Duration("DB_QUERY_TIMEOUT", repository.DefaultQueryTimeout),
+		SlowQueryThreshold: settings.Duration("DB_SLOW_QUERY_THRESHOLD", 500*time.Millisecond),
+		AuthServiceAddr:    settings.Get("AUTH_SERVICE_ADDR", "localhost:50051"),
+		// Экземпляры сервиса аутентификации, добавленные в DNS, начинают получать вызовы
+		// не позже чем через AUTH_SERVICE_RESOLVE_INTERVAL
+		AuthResolveInterval: settings.Duration("AUTH_SERVICE_RESOLVE_INTERVAL", authclient.DefaultResolveInterval),
+		Auth: authclient.Options{
+			MutationTimeout:   settings.Duration("AUTH_MUTATION_TIMEOUT", authclient.DefaultMutationTimeout),
+			ValidationTimeout: settings.Duration("AUTH_VALIDATION_TIMEOUT", authclient.DefaultValidationTimeout),
+			MaxRecvMsgSize:    settings.Int("AUTH_MAX_RECV_MSG_SIZE", authclient.DefaultMaxMsgSize),
+			MaxSendMsgSize:    settings.Int("AUTH_MAX_SEND_MSG_SIZE", authclient.DefaultMaxMsgSize),
+			InternalToken:     settings.Secret("INTERNAL_TOKEN"),
+		},
+		// X-Forwarded-For учитывается только от прокси из TRUSTED_PROXIES
+		TrustedProxies:           settings.List("TRUSTED_PROXIES", ""),
+		AccessLogSkipPaths:       settings.List("ACCESS_LOG_SKIP_PATHS", "/healthz,/metrics"),
+		AccessLogSuccessSampling: settings.Int("ACCESS_LOG_SUCCESS_SAMPLING", 1),
+		CompressMinSize:          settings.Int("COMPRESS_MIN_SIZE", middleware.DefaultCompressMinSize),
+		RequestTimeout:           settings.Duration("REQUEST_TIMEOUT", middleware.DefaultRequestTimeout),
+		RequestTimeoutSkipPaths:  settings.List("REQUEST_TIMEOUT_SKIP_PATHS", "/calls/export,/calls/ws,/me/export,/admin/users/export,/admin/users/:id/export"),
+		WSMaxConnectionsPerUser:  settings.Int("WS_MAX_CONNECTIONS_PER_USER", events.DefaultMaxPerUser),
+		SwaggerUI:                !production,
+		// Режим обслуживания при запуске; во время работы переключается через POST /admin/maintenance
+		Maintenance:           settings.Get("MAINTENANCE_MODE", "false") == "true",
+		MaintenanceRetryAfter: settings.Duration("MAINTENANCE_RETRY_AFTER", middleware.DefaultMaintenanceRetryAfter),
+		// Флаги постепенного включения поведения; файл перечитывается по SIGHUP
+		FeatureFlags:     settings.Get("FEATURE_FLAGS", ""),
+		FeatureFlagsFile: settings.Get("FEATURE_FLAGS_FILE", ""),
+		// Cookie с токеном для браузерных клиентов; без TLS нужно явно задать AUTH_COOKIE_SECURE=false
+		AuthCookie: handler.AuthCookieConfig{
+			Enabled:  settings.Get("AUTH_COOKIE", "false") == "true",
+			Secure:   settings.Get("AUTH_COOKIE_SECURE", "true") == "true",
+			SameSite: getEnvSameSite("AUTH_COOKIE_SAMESITE", http.SameSiteLaxMode),
+			MaxAge:   settings.Duration("AUTH_COOKIE_MAX_AGE", handler.DefaultAccessTokenTTL),
+		},
+		// Режим деградации при недоступности сервиса аутентификации по умолчанию отключен
+		AuthStaleTTL: settings.Duration("AUTH_STALE_TTL", 0),
+		// Организации по умолчанию отключены; их нужно включить и в сервисе аутентификации
+		Organizations:    settings.Get("ORGANIZATIONS_ENABLED", "false") == "true",
+		ResolveUsernames: settings.Get("RESOLVE_USERNAMES", "true") == "true",
+		UsernameCacheTTL: settings.Duration("USERNAME_CACHE_TTL", service.DefaultUsernameCacheTTL),
+		// Автоматическое закрытие заявок, открытых дольше STALE_CALLS_DAYS дней, по умолчанию отключено
+		StaleCallsAfter:    time.Duration(settings.Int("STALE_CALLS_DAYS", 0)) * 24 * time.Hour,
+		StaleCallsInterval: settings.Duration("STALE_CALLS_CHECK_INTERVAL", scheduler.DefaultStaleCallsInterval),
+		StaleCallsDryRun:   settings.Get("STALE_CALLS_DRY_RUN", "false") == "true",
+		// Уведомления о повторных звонках отправляются, только если задан CALLBACK_WEBHOOK_URL
+		CallbackWebhookURL:    settings.Get("CALLBACK_WEBHOOK_URL", ""),
+		CallbackWebhookSecret: settings.Secret("CALLBACK_WEBHOOK_SECRET"),
+		CallbacksInterval:     settings.Duration("CALLBACK_CHECK_INTERVAL", scheduler.DefaultCallbacksInterval),
+		// Webhook не может обращаться во внутреннюю сеть, кроме сетей WEBHOOK_ALLOWED_NETWORKS;
+		// в production-режиме допускается только https
+		CallbackWebhookPolicy: safehttp.Policy{
+			AllowHTTP:       settings.Get("WEBHOOK_ALLOW_HTTP", strconv.FormatBool(!production)) == "true",
+			MaxRedirects:    settings.Int("WEBHOOK_MAX_REDIRECTS", safehttp.DefaultMaxRedirects),
+			MaxResponseSize: int64(settings.Int("WEBHOOK_MAX_RESPONSE_SIZE", safehttp.DefaultMaxResponseSize)),
+			Timeout:         settings.Duration("WEBHOOK_TIMEOUT", notify.DefaultWebhookTimeout),
+		},
+		// Доставки событий подпискам повторяются WEBHOOK_MAX_ATTEMPTS раз с удваивающейся
+		// задержкой и хранятся WEBHOOK_DELIVERY_RETENTION_DAYS дней после завершения
+		Webhooks: service.WebhookConfig{
+			MaxAttempts: settings.Int("WEBHOOK_MAX_ATTEMPTS", service.DefaultWebhookMaxAttempts),
+			RetryDelay:  settings.Duration("WEBHOOK_RETRY_DELAY", service.DefaultWebhookRetryDelay),
+			Retention:   time.Duration(settings.Int("WEBHOOK_DELIVERY_RETENTION_DAYS", int(service.DefaultWebhookRetention/(24*time.Hour)))) * 24 * time.Hour,
+		},
+		WebhookDeliveriesInterval: settings.Duration("WEBHOOK_DELIVERY_INTERVAL", scheduler.DefaultWebhookDeliveriesInterval),
+		PurgeInterval:             settings.Duration("PURGE_INTERVAL", scheduler.DefaultPurgeInterval),
+		// Уведомления пользователей по умолчанию отключены; NOTIFY_CHANNELS=email,telegram
+		// включает каналы, каждый из которых настраивается своими переменными
+		NotifyChannels: settings.List("NOTIFY_CHANNELS", ""),
+		NotifySMTP: notify.SMTPConfig{
+			Addr:     settings.Get("NOTIFY_SMTP_ADDR", ""),
+			From:     settings.Get("NOTIFY_SMTP_FROM", ""),
+			Username: settings.Get("NOTIFY_SMTP_USERNAME", ""),
+			Password: settings.Secret("NOTIFY_SMTP_PASSWORD"),
+		},
+		NotifyTelegram: notify.TelegramConfig{
+			Token:         settings.Secret("NOTIFY_TELEGRAM_BOT_TOKEN"),
+			BotName:       settings.Get("NOTIFY_TELEGRAM_BOT_NAME", ""),
+			WebhookSecret: settings.Secret("NOTIFY_TELEGRAM_WEBHOOK_SECRET"),
+			APIURL:        settings.Get("NOTIFY_TELEGRAM_API_URL", notify.DefaultTelegramAPIURL),
+		},
+		NotifyDispatcher: notify.DispatcherConfig{
+			Workers:     settings.Int("NOTIFY_WORKERS", notify.DefaultNotifyWorkers),
+			QueueSize:   settings.Int("NOTIFY_QUEUE_SIZE", notify.DefaultNotifyQueueSize),
+			Attempts:    settings.Int("NOTIFY_ATTEMPTS", notify.DefaultNotifyAttempts),
+			RetryDelay:  settings.Duration("NOTIFY_RETRY_DELAY", notify.DefaultNotifyRetryDelay),
+			SendTimeout: settings.Duration("NOTIFY_SEND_TIMEOUT", notify.DefaultNotifySendTimeout),
+		},
+		// Заявки удаленного пользователя по умолчанию обезличиваются; ERASURE_POLICY=delete удаляет их
+		ErasurePolicy:    model.ErasurePolicy(settings.Get("ERASURE_POLICY", string(model.ErasurePolicyAnonymize))),
+		ErasuresInterval: settings.Duration("ERASURE_RECONCILE_INTERVAL", scheduler.DefaultErasuresInterval),
+		// Без TELEPHONY_PROVIDER исходящие звонки только записываются в журнал
+		Telephony: telephony.Config{
+			Provider: settings.Get("TELEPHONY_PROVIDER", telephony.ProviderLog),
+			URL:      settings.Get("TELEPHONY_URL", ""),
+			Token:    settings.Get("TELEPHONY_TOKEN", ""),
+		},
+		DialTimeout: settings.Duration("TELEPHONY_TIMEOUT", service.DefaultDialTimeout),
+		// Вложения заявок по умолчанию хранятся в каталоге ATTACHMENTS_DIR; ATTACHMENT_STORE=s3
+		// переключает хранение на S3-совместимое хранилище
+		AttachmentStore: blobstore.Config{
+			Backend: settings.Get("ATTACHMENT_STORE", blobstore.BackendLocal),
+			Dir:     settings.Get("ATTACHMENTS_DIR", "data/attachments"),
+			S3: blobstore.S3Config{
+				Endpoint:  settings.Get("S3_ENDPOINT", ""),
+				Region:    settings.Get("S3_REGION", ""),
+				Bucket:    settings.Get("S3_BUCKET", ""),
+				AccessKey: settings.Get("S3_ACCESS_KEY", ""),
+				SecretKey: settings.Secret("S3_SECRET_KEY"),
+			},
+		},
+		Attachments: service.AttachmentConfig{
+			MaxSize:      int64(settings.Int("ATTACHMENT_MAX_SIZE", service.DefaultAttachmentMaxSize)),
+			AllowedTypes: settings.List("ATTACHMENT_ALLOWED_TYPES", strings.Join(service.DefaultAttachmentTypes, ",")),
+		},
+		APIAuditQueueSize: settings.Int("API_AUDIT_QUEUE_SIZE", service.DefaultAPIAuditQueueSize),
+		MigrationsDir:     settings.Get("MIGRATIONS_DIR", "migrations"),
+		// Прогрев соединений перед готовностью и задержка перед остановкой при обновлении
+		WarmupConnections: settings.Int("WARMUP_DB_CONNECTIONS", 5),
+		WarmupAuth:        settings.Get("WARMUP_AUTH", "true") == "true",
+		WarmupTimeout:     settings.Duration("WARMUP_TIMEOUT", DefaultWarmupTimeout),
+		DrainDelay:        settings.Duration("DRAIN_DELAY", 5*time.Second),
+		// Ограничение выгрузок данных пользователя; RATE_LIMIT_BACKEND=postgres делает его общим
+		// для всех экземпляров сервиса
+		RateLimitBackend:       settings.Get("RATE_LIMIT_BACKEND", middleware.RateLimitMemory),
+		UserDataExportInterval: settings.Duration("USER_DATA_EXPORT_INTERVAL", handler.UserDataExportInterval),
+		// Проверка статуса заявки по коду без учетной записи ограничена по IP-адресу и коду
+		PublicStatusIPLimit:         settings.Int("PUBLIC_STATUS_IP_LIMIT", handler.DefaultPublicStatusIPLimit),
+		PublicStatusRefLimit:        settings.Int("PUBLIC_STATUS_REF_LIMIT", handler.DefaultPublicStatusRefLimit),
+		PublicStatusMinResponseTime: settings.Duration("PUBLIC_STATUS_MIN_RESPONSE_TIME", handler.DefaultPublicStatusMinResponseTime),
+		RateLimitFallback:           settings.Get("RATE_LIMIT_FALLBACK", middleware.RateLimitFallbackOpen),
+		// Проверка CAPTCHA по умолчанию отключена; CAPTCHA_PROVIDER=recaptcha или hcaptcha включает
+		// ее при регистрации и после CAPTCHA_LOGIN_FAILURES неудачных попыток входа
+		Captcha: captcha.Config{
+			Provider:      settings.Get("CAPTCHA_PROVIDER", ""),
+			Secret:        settings.Secret("CAPTCHA_SECRET"),
+			VerifyURL:     settings.Get("CAPTCHA_VERIFY_URL", ""),
+			Timeout:       settings.Duration("CAPTCHA_TIMEOUT", captcha.DefaultTimeout),
+			FailurePolicy: settings.Get("CAPTCHA_FAILURE_POLICY", captcha.FailOpen),
+		},
+		CaptchaLoginFailures: settings.Int("CAPTCHA_LOGIN_FAILURES", handler.DefaultCaptchaLoginFailures),
+		CaptchaLoginWindow:   settings.Duration("CAPTCHA_LOGIN_WINDOW", handler.DefaultCaptchaLoginWindow),
+		// Кэш по умолчанию хранится в памяти процесса; CACHE_BACKEND=redis делает его общим
+		// для всех экземпляров сервиса
+		Cache: cache.Config{
+			Backend: settings.Get("CACHE_BACKEND", cache.BackendMemory),
+			Redis: cache.RedisConfig{
+				Addr:         settings.Get("REDIS_ADDR", "localhost:6379"),
+				Password:     settings.Secret("REDIS_PASSWORD"),
+				DB:           settings.Int("REDIS_DB", 0),
+				KeyPrefix:    settings.Get("REDIS_KEY_PREFIX", "call-service:"),
+				PoolSize:     settings.Int("REDIS_POOL_SIZE", 0),
+				MinIdleConns: settings.Int("REDIS_MIN_IDLE_CONNS", 0),
+				Timeout:      settings.Duration("REDIS_TIMEOUT", cache.DefaultRedisTimeout),
+			},
+		},
+		// Кэш ответов списка заявок по умолчанию отключен; RESPONSE_CACHE_TTL включает его
+		ResponseCacheTTL: settings.Duration("RESPONSE_CACHE_TTL", 0),
+	}
+	// Уровень журнала по умолчанию info; меняется без перезапуска (см. App.ReloadConfig)
+	logLevel := settings.Get("LOG_LEVEL", "info")
+	if err := cfg.LogLevel.UnmarshalText([]byte(logLevel)); err != nil {
+		return cfg, fmt.Errorf("invalid LOG_LEVEL %q: %w", logLevel, err)
+	}
+	if !cfg.ErasurePolicy.Valid() {
+		return cfg, fmt.Errorf("invalid ERASURE_POLICY %q: expected anonymize or delete", cfg.ErasurePolicy)
+	}
+	tokenSources, err := middleware.ParseTokenSources(settings.List("AUTH_TOKEN_SOURCES", ""))
+	if err != nil {
+		return cfg, fmt.Errorf("invalid AUTH_TOKEN_SOURCES: %w", err)
+	}
+	cfg.AuthTokenSources = tokenSources
+	// Цели уровня обслуживания, например
+	// SLO_OBJECTIVES="name=list_calls,endpoint=GET /calls,target=99.9,latency=300ms"
+	if cfg.SLOObjectives, err = slo.ParseObjectives(settings.Get("SLO_OBJECTIVES", "")); err != nil {
+		return cfg, fmt.Errorf("invalid SLO_OBJECTIVES: %w", err)
+	}
+	cfg.SLOPeriod = settings.Duration("SLO_PERIOD", slo.DefaultPeriod)
+	// Заглушка принимает любой ответ и предназначена только для разработки
+	if production && cfg.Captcha.Provider == captcha.ProviderStub {
+		return cfg, fmt.Errorf("CAPTCHA_PROVIDER=%s is not allowed in production", captcha.ProviderStub)
+	}
+	// Внесение сбоев предназначено для сред разработки и тестирования; в production-режиме
+	// FAULT_INJECTION_ENABLED не действует
+	if settings.Get("FAULT_INJECTION_ENABLED", "false") == "true" {
+		if production {
+			log.Printf("FAULT_INJECTION_ENABLED is ignored in production")
+		} else {
+			rules, err := middleware.ParseFaultRules(settings.Get("FAULT_INJECTION_RULES", ""))
+			if err != nil {
+				return cfg, fmt.Errorf("invalid FAULT_INJECTION_RULES: %w", err)
+			}
+			cfg.FaultInjection = true
+			cfg.FaultRules = rules
+		}
+	}
+	for _, network := range settings.List("WEBHOOK_ALLOWED_NETWORKS", "") {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid WEBHOOK_ALLOWED_NETWORKS: %w", err)
+		}
+		cfg.CallbackWebhookPolicy.AllowedNetworks = append(cfg.CallbackWebhookPolicy.AllowedNetworks, prefix)
+	}
+	// Сервер pprof запускается на отдельном порту только при ENABLE_PPROF=true
+	if settings.Get("ENABLE_PPROF", "false") == "true" {
+		cfg.DebugAddr = ":" + settings.Get("DEBUG_PORT", "6060")
+	}
+	return cfg, nil
+}
+
+// NewLogger создает логгер, пишущий JSON в stdout, с уровнем level. Чтобы уровень можно было
+// менять без перезапуска, передается *slog.LevelVar, который затем передается и в Deps.LogLevel.
+func NewLogger(level slog.Leveler) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+}
+
+// readConfigFile читает файл конфигурации со строками "ИМЯ=значение"; пустые строки и строки,
+// начинающиеся с #, пропускаются. Пустой путь означает, что файла нет.
+func readConfigFile(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CONFIG_FILE: %w", err)
+	}
+	values := make(map[string]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid CONFIG_FILE line %d: expected NAME=value", i+1)
+		}
+		values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return values, nil
+}
+
+// settings — параметры конфигурации: значения из файла CONFIG_FILE или переменные окружения.
+var settings = env.Source(lookupEnv)
+
+// lookupEnv возвращает значение параметра из файла CONFIG_FILE или, если в файле его нет,
+// из переменной окружения.
+func lookupEnv(key string) string {
+	if value, ok := configFile[key]; ok {
+		return value
+	}
+	return os.Getenv(key)
+}
+
+// getEnvSameSite получает режим SameSite cookie из переменной окружения: lax, strict или none.
+// Если переменная не установлена, возвращается defaultValue; некорректное значение завершает работу.
+func getEnvSameSite(key string, defaultValue http.SameSite) http.SameSite {
+	switch value := strings.ToLower(lookupEnv(key)); value {
+	case "":
+		return defaultValue
+	case "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		log.Fatalf("invalid %s %q: expected lax, strict or none", key, value)
+		return defaultValue
+	}
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"expvar"
+	"log"
+	"time"
+)
+
+// DefaultWarmupTimeout ограничивает прогрев, если Config.WarmupTimeout не задан.
+const DefaultWarmupTimeout = 10 * time.Second
+
+// warmupToken — заведомо недействительный токен, проверка которого при прогреве
+// устанавливает соединение с сервисом аутентификации.
+const warmupToken = "warmup"
+
+// defaultMaxIdleConns — число простаивающих соединений, которое database/sql хранит по умолчанию.
+const defaultMaxIdleConns = 2
+
+// lifecycleStats — показатели запуска и остановки в /debug/vars: warmup_duration_ms —
+// длительность последнего прогрева, warmup_errors — число ошибок прогрева, lame_duck —
+// 1, пока экземпляр выведен из ротации перед остановкой.
+var lifecycleStats = expvar.NewMap("lifecycle")
+
+// warmup открывает Config.WarmupConnections соединений с базой данных и, если задан
+// Config.WarmupAuth, проверяет токен warmupToken, чтобы первые запросы не ждали установки
+// соединений. Ошибки записываются в журнал.
+func (a *App) warmup(ctx context.Context) {
+	timeout := a.cfg.WarmupTimeout
+	if timeout <= 0 {
+		timeout = DefaultWarmupTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	log.Println("Warm-up started")
+	if a.sqldb != nil && a.cfg.WarmupConnections > 0 {
+		if err := warmDB(ctx, a.sqldb, a.cfg.WarmupConnections); err != nil {
+			lifecycleStats.Add("warmup_errors", 1)
+			log.Printf("warm-up: database: %v", err)
+		}
+	}
+	if a.cfg.WarmupAuth {
+		// Ответ не важен: недействительный токен — ожидаемый результат
+		if _, _, err := a.authClient.ValidateToken(ctx, warmupToken); err != nil {
+			lifecycleStats.Add("warmup_errors", 1)
+			log.Printf("warm-up: auth service: %v", err)
+		}
+	}
+	elapsed := time.Since(start)
+	durationMs := new(expvar.Int)
+	durationMs.Set(elapsed.Milliseconds())
+	lifecycleStats.Set("warmup_duration_ms", durationMs)
+	log.Printf("Warm-up finished in %s", elapsed.Round(time.Millisecond))
+}
+
+// warmDB открывает n соединений одновременно и возвращает их в пул, разрешив пулу
+// хранить n простаивающих соединений, если это больше значения database/sql по умолчанию.
+func warmDB(ctx context.Context, db *sql.DB, n int) error {
+	if n > defaultMaxIdleConns {
+		db.SetMaxIdleConns(n)
+	}
+	conns := make([]*sql.Conn, 0, n)
+	defer func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	}()
+	for range n {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		conns = append(conns, conn)
+		if err := conn.PingContext(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lameDuck переводит /readyz в состояние 503 и ждет Config.DrainDelay, чтобы балансировщик
+// перестал направлять запросы до закрытия портов.
+func (a *App) lameDuck() {
+	a.readiness.SetReady(false)
+	if a.cfg.DrainDelay <= 0 {
+		return
+	}
+	lifecycleStats.Add("lame_duck", 1)
+	defer lifecycleStats.Add("lame_duck", -1)
+	log.Printf("Lame-duck mode: draining for %s before shutdown", a.cfg.DrainDelay)
+	time.Sleep(a.cfg.DrainDelay)
+}
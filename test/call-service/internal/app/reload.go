@@ -0,0 +1,105 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+
+	"call-service/internal/handler"
+	"call-service/internal/middleware"
+)
+
+// dynamicSettings — параметры Config, которые ReloadConfig применяет без перезапуска.
+// Изменения остальных параметров требуют перезапуска.
+var dynamicSettings = map[string]bool{
+	"LogLevel":               true,
+	"UserDataExportInterval": true,
+	"FeatureFlags":           true,
+	"FeatureFlagsFile":       true,
+	"Maintenance":            true,
+	"UsernameCacheTTL":       true,
+}
+
+// ReloadConfig загружает конфигурацию заново (см. LoadConfig) и применяет изменившиеся
+// динамические параметры: уровень журнала, интервал ограничения выгрузок данных, флаги,
+// режим обслуживания и время кэширования имен пользователей. Параметры применяются вместе:
+// если конфигурацию или файл флагов не удалось прочитать, продолжают действовать прежние
+// значения всех параметров. Изменения остальных параметров (адресов, строк подключения,
+// секретов) не применяются и записываются в журнал как требующие перезапуска.
+//
+// Возвращает имена примененных параметров и параметров, требующих перезапуска, как они
+// названы в Config. Файл флагов перечитывается при каждом вызове, а режим обслуживания
+// переключается, только если изменилось значение в конфигурации, чтобы не отменить
+// переключение запросом POST /admin/maintenance.
+func (a *App) ReloadConfig() (applied, restartRequired []string, err error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("load config: %w", err)
+	}
+
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	// Сравнение с действующей конфигурацией. Параметр, который компонент не может изменить
+	// (например, уровень журнала без Deps.LogLevel), тоже требует перезапуска
+	old, updated := reflect.ValueOf(a.cfg), reflect.ValueOf(cfg)
+	for i := 0; i < old.NumField(); i++ {
+		name := old.Type().Field(i).Name
+		if reflect.DeepEqual(old.Field(i).Interface(), updated.Field(i).Interface()) {
+			continue
+		}
+		if dynamicSettings[name] && a.canApply(name) {
+			applied = append(applied, name)
+		} else {
+			restartRequired = append(restartRequired, name)
+		}
+	}
+
+	// Файл флагов — единственный источник, чтение которого может завершиться ошибкой,
+	// поэтому он применяется первым
+	if err := a.flags.Update(cfg.FeatureFlags, cfg.FeatureFlagsFile); err != nil {
+		return nil, nil, err
+	}
+	for _, name := range applied {
+		switch name {
+		case "LogLevel":
+			a.logLevel.Set(cfg.LogLevel)
+			a.cfg.LogLevel = cfg.LogLevel
+		case "UserDataExportInterval":
+			interval := cfg.UserDataExportInterval
+			if interval <= 0 {
+				interval = handler.UserDataExportInterval
+			}
+			a.exportLimiter.(middleware.IntervalSetter).SetInterval(interval)
+			a.cfg.UserDataExportInterval = cfg.UserDataExportInterval
+		case "FeatureFlags", "FeatureFlagsFile":
+			a.cfg.FeatureFlags, a.cfg.FeatureFlagsFile = cfg.FeatureFlags, cfg.FeatureFlagsFile
+		case "Maintenance":
+			a.maintenance.SetEnabled(cfg.Maintenance)
+			a.cfg.Maintenance = cfg.Maintenance
+		case "UsernameCacheTTL":
+			if a.usernames != nil {
+				a.usernames.SetTTL(cfg.UsernameCacheTTL)
+			}
+			a.cfg.UsernameCacheTTL = cfg.UsernameCacheTTL
+		}
+	}
+
+	log.Printf("Configuration reloaded: applied %v", applied)
+	for _, name := range restartRequired {
+		log.Printf("Configuration reload: %s changed and is not applied, restart the service to apply it", name)
+	}
+	return applied, restartRequired, nil
+}
+
+// canApply сообщает, может ли приложение применить динамический параметр name без перезапуска.
+func (a *App) canApply(name string) bool {
+	switch name {
+	case "LogLevel":
+		return a.logLevel != nil
+	case "UserDataExportInterval":
+		_, ok := a.exportLimiter.(middleware.IntervalSetter)
+		return ok
+	}
+	return true
+}
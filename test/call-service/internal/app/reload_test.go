@@ -0,0 +1,85 @@
+package app
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/mocks"
+)
+
+// TestApp_ReloadConfig проверяет, что перечитывание конфигурации меняет уровень журнала
+// для следующих запросов и переключает режим обслуживания, изменение адреса не применяется
+// и требует перезапуска, а ошибка в конфигурации сохраняет прежние значения.
+
+func TestApp_ReloadConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "call-service.env")
+	writeConfig := func(lines ...string) {
+		lines = append([]string{"# Конфигурация теста", "DEV_INMEMORY=true", "ATTACHMENTS_DIR=" + dir}, lines...)
+		require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600))
+	}
+	t.Setenv("CONFIG_FILE", path)
+	writeConfig("LOG_LEVEL=warn")
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	require.Equal(t, slog.LevelWarn, cfg.LogLevel)
+
+	var logs bytes.Buffer
+	level := new(slog.LevelVar)
+	level.Set(cfg.LogLevel)
+	a, err := New(cfg, Deps{
+		AuthClient: mocks.NewMockAuthClient(gomock.NewController(t)),
+		Logger:     slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: level})),
+		LogLevel:   level,
+	})
+	require.NoError(t, err)
+	defer a.close()
+	request := func() {
+		w := httptest.NewRecorder()
+		a.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// Успешные запросы записываются с уровнем info и при уровне warn не попадают в журнал
+	request()
+	assert.NotContains(t, logs.String(), `"path":"/api/v1/openapi.json"`)
+
+	writeConfig("LOG_LEVEL=debug", "HTTP_PORT=9090")
+	applied, restartRequired, err := a.ReloadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"LogLevel"}, applied)
+	assert.Equal(t, []string{"HTTPAddr"}, restartRequired)
+	request()
+	assert.Contains(t, logs.String(), `"path":"/api/v1/openapi.json"`)
+
+	writeConfig("LOG_LEVEL=debug", "HTTP_PORT=9090", "MAINTENANCE_MODE=true")
+	applied, restartRequired, err = a.ReloadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Maintenance"}, applied)
+	assert.Equal(t, []string{"HTTPAddr"}, restartRequired)
+	assert.True(t, a.maintenance.Enabled())
+
+	// Некорректный уровень или файл флагов не меняют ни один параметр
+	for _, lines := range [][]string{
+		{"LOG_LEVEL=verbose"},
+		{"LOG_LEVEL=error", "MAINTENANCE_MODE=false", "FEATURE_FLAGS_FILE=" + filepath.Join(dir, "missing.json")},
+	} {
+		writeConfig(lines...)
+		_, _, err = a.ReloadConfig()
+		assert.Error(t, err, lines)
+		assert.Equal(t, slog.LevelDebug, level.Level())
+		assert.True(t, a.maintenance.Enabled())
+	}
+}
@@ -0,0 +1,70 @@
+// Package blobstore хранит содержимое файлов (вложений заявок) вне базы данных.
+// Хранилище выбирается конфигурацией: каталог локальной файловой системы или
+// S3-совместимое объектное хранилище.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Хранилища, которые можно выбрать в Config.Backend
+const (
+	// BackendLocal — каталог локальной файловой системы (FileStore).
+	BackendLocal = "local"
+	// BackendS3 — S3-совместимое объектное хранилище (S3Store).
+	BackendS3 = "s3"
+)
+
+// ErrNotFound возвращается, если объекта с запрошенным ключом нет в хранилище.
+var ErrNotFound = errors.New("blob not found")
+
+// Store хранит объекты по ключам. Ключ — путь из непустых сегментов через "/"
+// без сегментов "." и ".." (например, "calls/<id>/<id>").
+type Store interface {
+	// Put сохраняет size байт из r под ключом key, заменяя существующий объект.
+	// Если r содержит другое число байт, объект не сохраняется.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get возвращает содержимое объекта; закрыть его должен вызывающий.
+	// Отсутствующий объект — ErrNotFound.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete удаляет объект; удаление отсутствующего объекта не является ошибкой.
+	Delete(ctx context.Context, key string) error
+}
+
+// Config задает хранилище объектов.
+type Config struct {
+	// Backend — BackendLocal (по умолчанию) или BackendS3.
+	Backend string
+	// Dir — каталог хранилища BackendLocal.
+	Dir string
+	// S3 — параметры хранилища BackendS3.
+	S3 S3Config
+}
+
+// New создает хранилище cfg.Backend.
+func New(cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case "", BackendLocal:
+		return NewFileStore(cfg.Dir)
+	case BackendS3:
+		return NewS3Store(cfg.S3)
+	}
+	return nil, fmt.Errorf("blobstore: unknown backend %q", cfg.Backend)
+}
+
+// checkKey проверяет, что ключ не выходит за пределы хранилища.
+func checkKey(key string) error {
+	if key == "" || strings.ContainsRune(key, '\\') {
+		return fmt.Errorf("blobstore: invalid key %q", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("blobstore: invalid key %q", key)
+		}
+	}
+	return nil
+}
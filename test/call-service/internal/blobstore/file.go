@@ -0,0 +1,116 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileStore хранит объекты в файлах каталога: ключ становится относительным путем файла.
+// Объект сначала записывается во временный файл и переименовывается после записи,
+// поэтому Get никогда не возвращает частично записанный объект.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore создает FileStore в каталоге dir, создавая каталог при необходимости.
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		return nil, errors.New("blobstore: directory is required for the local backend")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("blobstore: create directory: %w", err)
+	}
+	return &FileStore{dir: filepath.Clean(dir)}, nil
+}
+
+// Put записывает объект в файл.
+func (s *FileStore) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("blobstore: create directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("blobstore: create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	// ctx проверяется между блоками, чтобы отмена прерывала запись большого объекта
+	n, err := io.Copy(tmp, ctxReader{ctx: ctx, r: r})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("blobstore: write %s: %w", key, err)
+	}
+	if n != size {
+		return fmt.Errorf("blobstore: write %s: got %d bytes, expected %d", key, n, size)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("blobstore: write %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get открывает файл объекта.
+func (s *FileStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("blobstore: open %s: %w", key, err)
+	}
+	return f, nil
+}
+
+// Delete удаляет файл объекта и ставшие пустыми каталоги над ним.
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("blobstore: delete %s: %w", key, err)
+	}
+	// Непустой каталог не удаляется, на этом очистка и заканчивается
+	for dir := filepath.Dir(path); dir != s.dir && strings.HasPrefix(dir, s.dir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) path(key string) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// ctxReader прекращает чтение после отмены ctx.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
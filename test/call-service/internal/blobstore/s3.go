@@ -0,0 +1,247 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Параметры запросов к S3-совместимому хранилищу
+const (
+	// defaultS3Region — регион подписи, если он не задан; MinIO и большинство
+	// S3-совместимых хранилищ принимают его независимо от расположения.
+	defaultS3Region = "us-east-1"
+	// unsignedPayload — значение x-amz-content-sha256 для тела, не входящего в подпись:
+	// содержимое передается потоком, и хеш заранее не известен.
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	// maxS3ErrorSize ограничивает часть ответа с ошибкой, которая попадает в ее описание.
+	maxS3ErrorSize = 1 << 10
+)
+
+// S3Config задает S3-совместимое хранилище.
+type S3Config struct {
+	// Endpoint — адрес хранилища, например https://s3.eu-central-1.amazonaws.com
+	// или http://minio:9000. Бакет указывается в пути запроса (path-style).
+	Endpoint string
+	// Region — регион подписи запросов; пустое значение — us-east-1.
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3Store хранит объекты в бакете S3-совместимого хранилища. Запросы подписываются
+// AWS Signature Version 4; время запроса ограничивает ctx.
+type S3Store struct {
+	endpoint *url.URL
+	cfg      S3Config
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3Store создает S3Store по cfg.
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("blobstore: endpoint and bucket are required for the s3 backend")
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("blobstore: invalid s3 endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Region == "" {
+		cfg.Region = defaultS3Region
+	}
+	return &S3Store{endpoint: endpoint, cfg: cfg, client: &http.Client{}, now: time.Now}, nil
+}
+
+// Put загружает объект запросом PUT.
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	resp, err := s.do(ctx, http.MethodPut, key, r, size)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(http.MethodPut, key, resp)
+	}
+	return nil
+}
+
+// Get скачивает объект запросом GET.
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	defer resp.Body.Close()
+	return nil, s3Error(http.MethodGet, key, resp)
+}
+
+// Delete удаляет объект запросом DELETE. S3 отвечает на удаление отсутствующего
+// объекта так же, как на удаление существующего.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return s3Error(http.MethodDelete, key, resp)
+}
+
+// do отправляет подписанный запрос к объекту key.
+func (s *S3Store) do(ctx context.Context, method, key string, body io.Reader, size int64) (*http.Response, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.cfg.Bucket + "/" + key
+	u.RawPath = escapePath(u.Path)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("blobstore: build %s request: %w", method, err)
+	}
+	if body != nil {
+		// Без известной длины запрос ушел бы с chunked-кодированием, которое S3 не принимает
+		req.Body = io.NopCloser(body)
+		req.ContentLength = size
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+	signV4(req, s.cfg.AccessKey, s.cfg.SecretKey, s.cfg.Region, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("blobstore: %s %s: %w", method, key, err)
+	}
+	return resp, nil
+}
+
+// s3Error описывает неуспешный ответ хранилища вместе с началом его тела, в котором
+// S3 передает код и описание ошибки.
+func s3Error(method, key string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxS3ErrorSize))
+	return fmt.Errorf("blobstore: %s %s: status %d: %s", method, key, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// signV4 подписывает запрос AWS Signature Version 4 для сервиса s3: добавляет заголовки
+// X-Amz-Date, X-Amz-Content-Sha256 (если он не задан, тело не подписывается) и Authorization.
+// В подпись входят заголовок Host и все заголовки запроса на момент подписи.
+func signV4(req *http.Request, accessKey, secretKey, region string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	payloadHash := req.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash == "" {
+		payloadHash = unsignedPayload
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	names := []string{"host"}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "authorization" {
+			continue
+		}
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[name] = strings.Join(trimmed, ",")
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapePath(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	for _, part := range []string{region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/"+scope+
+		",SignedHeaders="+signedHeaders+",Signature="+signature)
+}
+
+// canonicalQuery возвращает параметры запроса в порядке имен, как требует подпись.
+func canonicalQuery(query url.Values) string {
+	pairs := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, v := range values {
+			pairs = append(pairs, escape(name, true)+"="+escape(v, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// escapePath кодирует путь по правилам подписи: все символы, кроме букв, цифр,
+// "-", ".", "_", "~" и разделителя "/".
+func escapePath(path string) string {
+	return escape(path, false)
+}
+
+func escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hexSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
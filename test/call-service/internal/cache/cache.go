@@ -0,0 +1,61 @@
+// Package cache хранит короткоживущие значения, общие для компонентов сервиса: кэш проверок
+// токенов и счетчики ограничения частоты запросов. Хранилище выбирается конфигурацией:
+// память процесса (у каждого экземпляра сервиса свои значения) или Redis (значения общие
+// для всех экземпляров).
+//
+// Хранилище Redis может быть недоступно. Компоненты решают сами, что делать в этом случае:
+// продолжить работу с памятью процесса (WithFallback) или разрешить либо отклонить запрос.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Хранилища, которые можно выбрать в Config.Backend
+const (
+	// BackendMemory — память процесса (Memory).
+	BackendMemory = "memory"
+	// BackendRedis — Redis (Redis).
+	BackendRedis = "redis"
+)
+
+// DefaultMaxEntries — наибольшее число значений в хранилище Memory по умолчанию.
+const DefaultMaxEntries = 10000
+
+// Store хранит значения по ключам с ограниченным временем жизни. Ошибка означает,
+// что хранилище недоступно; отсутствие значения ошибкой не является.
+type Store interface {
+	// Get возвращает значение ключа key и true или nil и false, если значения нет или оно истекло.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set сохраняет значение на время ttl; 0 — без ограничения времени жизни.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Incr увеличивает целочисленное значение ключа на delta и возвращает результат.
+	// Отсутствующий ключ считается равным 0 и получает время жизни ttl; время жизни
+	// существующего ключа не продлевается.
+	Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error)
+	// Del удаляет ключи; удаление отсутствующего ключа не является ошибкой.
+	Del(ctx context.Context, keys ...string) error
+}
+
+// Config задает хранилище.
+type Config struct {
+	// Backend — BackendMemory (по умолчанию) или BackendRedis.
+	Backend string
+	// MaxEntries ограничивает хранилище BackendMemory; 0 — DefaultMaxEntries.
+	MaxEntries int
+	// Redis — параметры хранилища BackendRedis.
+	Redis RedisConfig
+}
+
+// New создает хранилище cfg.Backend.
+func New(cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case "", BackendMemory:
+		return NewMemory(cfg.MaxEntries, nil), nil
+	case BackendRedis:
+		return NewRedis(cfg.Redis)
+	}
+	return nil, fmt.Errorf("cache: unknown backend %q", cfg.Backend)
+}
@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"context"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"common/clock"
+)
+
+// testStore проверяет общее поведение хранилища: чтение и запись, время жизни значений
+// и счетчиков и удаление. advance сдвигает время хранилища.
+func testStore(t *testing.T, store Store, advance func(time.Duration)) {
+	ctx := context.Background()
+
+	_, ok, err := store.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, store.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, store.Set(ctx, "b", []byte("2"), 0))
+	value, ok, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	n, err := store.Incr(ctx, "counter", 1, 30*time.Second)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	n, err = store.Incr(ctx, "counter", 2, time.Hour)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, n)
+	n, err = store.Incr(ctx, "counter", -1, time.Hour)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+	value, _, err = store.Get(ctx, "counter")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("2"), value)
+
+	// Время жизни счетчика задается при создании и не продлевается
+	advance(45 * time.Second)
+	_, ok, _ = store.Get(ctx, "counter")
+	assert.False(t, ok)
+	_, ok, _ = store.Get(ctx, "a")
+	assert.True(t, ok)
+	advance(15 * time.Second)
+	_, ok, _ = store.Get(ctx, "a")
+	assert.False(t, ok)
+	_, ok, _ = store.Get(ctx, "b")
+	assert.True(t, ok)
+
+	require.NoError(t, store.Del(ctx, "b", "missing"))
+	_, ok, _ = store.Get(ctx, "b")
+	assert.False(t, ok)
+}
+
+// Тест хранилища в памяти процесса
+func TestMemory(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	testStore(t, NewMemory(0, clk), clk.Advance)
+}
+
+// Тест хранилища в памяти процесса: при заполнении удаляются истекшие значения,
+// а если их нет — все значения
+func TestMemory_MaxEntries(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewMemory(2, clk)
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "short", nil, time.Second))
+	require.NoError(t, store.Set(ctx, "long", nil, time.Hour))
+	clk.Advance(time.Minute)
+	require.NoError(t, store.Set(ctx, "new", nil, time.Hour))
+	_, ok, _ := store.Get(ctx, "long")
+	assert.True(t, ok)
+
+	require.NoError(t, store.Set(ctx, "newest", nil, time.Hour))
+	_, ok, _ = store.Get(ctx, "long")
+	assert.False(t, ok)
+	_, ok, _ = store.Get(ctx, "newest")
+	assert.True(t, ok)
+}
+
+// Тест хранилища Redis на miniredis: ключи получают префикс
+func TestRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	store, err := New(Config{Backend: BackendRedis, Redis: RedisConfig{Addr: server.Addr(), KeyPrefix: "test:"}})
+	require.NoError(t, err)
+	defer store.(*Redis).Close()
+
+	require.NoError(t, store.(*Redis).Ping(context.Background()))
+	testStore(t, store, server.FastForward)
+
+	require.NoError(t, store.Set(context.Background(), "key", []byte("value"), 0))
+	assert.True(t, server.Exists("test:key"))
+}
+
+// Тест выбора хранилища: Redis требует адреса, неизвестное хранилище отклоняется
+func TestNew(t *testing.T) {
+	store, err := New(Config{})
+	require.NoError(t, err)
+	assert.IsType(t, &Memory{}, store)
+
+	_, err = New(Config{Backend: BackendRedis})
+	assert.Error(t, err)
+	_, err = New(Config{Backend: "memcached"})
+	assert.Error(t, err)
+}
+
+// Тест резервного хранилища: пока Redis недоступен, операции выполняются в памяти процесса
+// и учитываются в /debug/vars, после восстановления снова выполняются в Redis
+func TestFallback(t *testing.T) {
+	server := miniredis.RunT(t)
+	redisStore, err := NewRedis(RedisConfig{Addr: server.Addr(), Timeout: 100 * time.Millisecond})
+	require.NoError(t, err)
+	defer redisStore.Close()
+	memory := NewMemory(0, nil)
+	store := WithFallback("test", redisStore, memory)
+	ctx := context.Background()
+
+	n, err := store.Incr(ctx, "counter", 1, time.Minute)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	assert.True(t, server.Exists("counter"))
+
+	server.Close()
+	n, err = store.Incr(ctx, "counter", 1, time.Minute)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	require.NoError(t, store.Set(ctx, "key", []byte("value"), time.Minute))
+	value, ok, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value"), value)
+	assert.Positive(t, fallbackStats.Get("test").(*expvar.Int).Value())
+
+	// Клиент Redis повторяет соединение не сразу после ошибки
+	require.NoError(t, server.Restart())
+	require.Eventually(t, func() bool { return redisStore.Ping(ctx) == nil }, 5*time.Second, 50*time.Millisecond)
+	_, err = store.Incr(ctx, "counter", 1, time.Minute)
+	require.NoError(t, err)
+	counter, err := server.Get("counter")
+	require.NoError(t, err)
+	assert.Equal(t, "2", counter)
+	_, ok, err = store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
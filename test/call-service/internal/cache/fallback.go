@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// fallbackStats — операции, выполненные резервным хранилищем из-за ошибки основного,
+// публикуемые в /debug/vars.
+var fallbackStats = expvar.NewMap("cache_fallback")
+
+// Fallback выполняет операции в основном хранилище, а при его ошибке — в резервном.
+// Значения, сохраненные в резервном хранилище, пока основное недоступно, не переносятся
+// в основное после его восстановления.
+type Fallback struct {
+	primary  Store
+	fallback Store
+	// name — имя компонента в журнале и в /debug/vars.
+	name    string
+	failing atomic.Bool
+}
+
+// WithFallback возвращает хранилище компонента name, которое при ошибке primary выполняет
+// операцию в fallback. Переход на резервное хранилище и возврат к основному записываются в журнал.
+func WithFallback(name string, primary, fallback Store) *Fallback {
+	return &Fallback{primary: primary, fallback: fallback, name: name}
+}
+
+// Get возвращает значение ключа key.
+func (f *Fallback) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, ok, err := f.primary.Get(ctx, key)
+	if f.failed(err) {
+		return f.fallback.Get(ctx, key)
+	}
+	return value, ok, nil
+}
+
+// Set сохраняет значение на время ttl.
+func (f *Fallback) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := f.primary.Set(ctx, key, value, ttl); f.failed(err) {
+		return f.fallback.Set(ctx, key, value, ttl)
+	}
+	return nil
+}
+
+// Incr увеличивает значение ключа key на delta.
+func (f *Fallback) Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	n, err := f.primary.Incr(ctx, key, delta, ttl)
+	if f.failed(err) {
+		return f.fallback.Incr(ctx, key, delta, ttl)
+	}
+	return n, nil
+}
+
+// Del удаляет ключи из обоих хранилищ, чтобы после восстановления основного хранилища
+// не осталось значений, удаленных во время его недоступности.
+func (f *Fallback) Del(ctx context.Context, keys ...string) error {
+	f.failed(f.primary.Del(ctx, keys...))
+	return f.fallback.Del(ctx, keys...)
+}
+
+// failed учитывает результат операции основного хранилища и сообщает, что она не выполнена.
+func (f *Fallback) failed(err error) bool {
+	if err == nil {
+		if f.failing.CompareAndSwap(true, false) {
+			log.Printf("cache %s: primary store recovered", f.name)
+		}
+		return false
+	}
+	fallbackStats.Add(f.name, 1)
+	if f.failing.CompareAndSwap(false, true) {
+		log.Printf("cache %s: primary store failed, using fallback: %v", f.name, err)
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"common/clock"
+)
+
+// memoryEntry — значение с моментом истечения; нулевой expiresAt — без ограничения.
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// Memory хранит значения в памяти процесса. Когда число значений достигает предела,
+// истекшие значения удаляются, а если их нет — хранилище очищается целиком: это кэш,
+// и потеря значений допустима. Операции никогда не возвращают ошибку.
+type Memory struct {
+	maxEntries int
+	clock      clock.Clock
+
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+// NewMemory создает хранилище не более чем на maxEntries значений (0 — DefaultMaxEntries);
+// nil clk означает системное время.
+func NewMemory(maxEntries int, clk clock.Clock) *Memory {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	if clk == nil {
+		clk = clock.Real
+	}
+	return &Memory{maxEntries: maxEntries, clock: clk, entries: make(map[string]memoryEntry)}
+}
+
+// Get возвращает значение ключа key.
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.lookup(key, m.clock.Now())
+	return entry.value, ok, nil
+}
+
+// Set сохраняет копию value на время ttl.
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.clock.Now()
+	m.put(key, memoryEntry{value: append([]byte(nil), value...), expiresAt: expiresAt(now, ttl)}, now)
+	return nil
+}
+
+// Incr увеличивает значение ключа key на delta. Значение хранится в десятичном виде, как в Redis;
+// значение, которое не является числом, считается равным 0.
+func (m *Memory) Incr(_ context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.clock.Now()
+	entry, ok := m.lookup(key, now)
+	if !ok {
+		entry.expiresAt = expiresAt(now, ttl)
+	}
+	n, _ := strconv.ParseInt(string(entry.value), 10, 64)
+	n += delta
+	entry.value = strconv.AppendInt(nil, n, 10)
+	m.put(key, entry, now)
+	return n, nil
+}
+
+// Del удаляет ключи.
+func (m *Memory) Del(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+// lookup возвращает неистекшее значение ключа; истекшее значение удаляется.
+func (m *Memory) lookup(key string, now time.Time) (memoryEntry, bool) {
+	entry, ok := m.entries[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+	if !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+		delete(m.entries, key)
+		return memoryEntry{}, false
+	}
+	return entry, true
+}
+
+// put сохраняет значение, освобождая место, если хранилище заполнено.
+func (m *Memory) put(key string, entry memoryEntry, now time.Time) {
+	if _, ok := m.entries[key]; !ok && len(m.entries) >= m.maxEntries {
+		for k, e := range m.entries {
+			if !e.expiresAt.IsZero() && !now.Before(e.expiresAt) {
+				delete(m.entries, k)
+			}
+		}
+		if len(m.entries) >= m.maxEntries {
+			clear(m.entries)
+		}
+	}
+	m.entries[key] = entry
+}
+
+// expiresAt возвращает момент истечения значения со временем жизни ttl.
+func expiresAt(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(ttl)
+}
@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisTimeout — ограничение времени соединения с Redis и каждой операции по умолчанию.
+// Оно короче, чем у базы данных: недоступный кэш не должен задерживать запросы.
+const DefaultRedisTimeout = 500 * time.Millisecond
+
+// RedisConfig задает соединение с Redis.
+type RedisConfig struct {
+	// Addr — адрес сервера в виде host:port.
+	Addr     string
+	Password string
+	DB       int
+	// KeyPrefix добавляется ко всем ключам, чтобы сервис мог делить Redis с другими.
+	KeyPrefix string
+	// PoolSize — наибольшее число соединений; 0 — значение go-redis по умолчанию
+	// (10 на каждый процессор). MinIdleConns — число соединений, которые пул держит открытыми.
+	PoolSize     int
+	MinIdleConns int
+	// Timeout ограничивает установку соединения и каждую операцию; 0 — DefaultRedisTimeout.
+	Timeout time.Duration
+}
+
+// incrScript увеличивает значение и задает время жизни, если у ключа его нет, за одну операцию,
+// чтобы счетчик не остался без времени жизни при обрыве соединения между командами.
+var incrScript = redis.NewScript(`
+local n = redis.call('INCRBY', KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call('PTTL', KEYS[1]) == -1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return n`)
+
+// Redis хранит значения в Redis; значения общие для всех экземпляров сервиса с одним KeyPrefix.
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedis создает хранилище Redis. Соединения устанавливаются при первой операции.
+func NewRedis(cfg RedisConfig) (*Redis, error) {
+	if cfg.Addr == "" {
+		return nil, errors.New("cache: redis address is required")
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRedisTimeout
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:         cfg.Addr,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		PoolSize:     cfg.PoolSize,
+		MinIdleConns: cfg.MinIdleConns,
+		DialTimeout:  timeout,
+		ReadTimeout:  timeout,
+		WriteTimeout: timeout,
+		PoolTimeout:  timeout,
+	})
+	return &Redis{client: client, prefix: cfg.KeyPrefix}, nil
+}
+
+// Get возвращает значение ключа key.
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set сохраняет значение на время ttl.
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, r.prefix+key, value, max(ttl, 0)).Err()
+}
+
+// Incr увеличивает значение ключа key на delta.
+func (r *Redis) Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	return incrScript.Run(ctx, r.client, []string{r.prefix + key}, delta, ttl.Milliseconds()).Int64()
+}
+
+// Del удаляет ключи.
+func (r *Redis) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = r.prefix + key
+	}
+	return r.client.Del(ctx, prefixed...).Err()
+}
+
+// Ping проверяет соединение с Redis.
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// Close закрывает соединения с Redis.
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,132 @@
+// Package captcha проверяет ответ пользователя на проверку «я не робот» (CAPTCHA) у провайдера:
+// reCAPTCHA или hCaptcha. Провайдер выбирается конфигурацией; для разработки есть заглушка,
+// которая принимает любой ответ.
+package captcha
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Провайдеры, которые можно выбрать в Config.Provider
+const (
+	// ProviderStub — заглушка StubVerifier для разработки.
+	ProviderStub = "stub"
+	// ProviderReCAPTCHA — Google reCAPTCHA.
+	ProviderReCAPTCHA = "recaptcha"
+	// ProviderHCaptcha — hCaptcha.
+	ProviderHCaptcha = "hcaptcha"
+)
+
+// Поведение при недоступности провайдера, которое можно выбрать в Config.FailurePolicy
+const (
+	// FailOpen — ответ пользователя считается верным: недоступность провайдера
+	// не останавливает регистрацию и вход.
+	FailOpen = "open"
+	// FailClosed — проверка не проходит с ErrUnavailable.
+	FailClosed = "closed"
+)
+
+// Адреса проверки ответов провайдеров по умолчанию
+const (
+	ReCAPTCHAVerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+	HCaptchaVerifyURL  = "https://api.hcaptcha.com/siteverify"
+)
+
+// DefaultTimeout ограничивает время запроса к провайдеру, если Config.Timeout не задан.
+const DefaultTimeout = 3 * time.Second
+
+var (
+	// ErrMissingToken возвращается, если пользователь не прошел проверку и ответа нет.
+	ErrMissingToken = errors.New("captcha token is required")
+	// ErrInvalidToken возвращается, если провайдер отклонил ответ: он неверный, истек
+	// или уже использован.
+	ErrInvalidToken = errors.New("invalid captcha token")
+	// ErrUnavailable возвращается, если провайдер не ответил или ответил ошибкой.
+	ErrUnavailable = errors.New("captcha provider unavailable")
+)
+
+// Verifier проверяет ответ пользователя token, полученный в браузере от провайдера.
+// remoteIP — IP-адрес пользователя; провайдер может учитывать его при проверке.
+type Verifier interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// Config задает провайдера проверки.
+type Config struct {
+	// Provider — ProviderStub, ProviderReCAPTCHA или ProviderHCaptcha; пустая строка
+	// отключает проверку.
+	Provider string
+	// Secret — секретный ключ сайта у провайдера.
+	Secret string
+	// VerifyURL — адрес проверки ответа; пустая строка — адрес провайдера по умолчанию.
+	VerifyURL string
+	// Timeout ограничивает время запроса к провайдеру; 0 — DefaultTimeout.
+	Timeout time.Duration
+	// FailurePolicy — FailOpen (по умолчанию) или FailClosed.
+	FailurePolicy string
+}
+
+// New создает Verifier провайдера cfg.Provider с поведением cfg.FailurePolicy
+// при недоступности провайдера. Для пустого cfg.Provider возвращает nil: проверка отключена.
+func New(cfg Config) (Verifier, error) {
+	failOpen := false
+	switch cfg.FailurePolicy {
+	case "", FailOpen:
+		failOpen = true
+	case FailClosed:
+	default:
+		return nil, fmt.Errorf("captcha: unknown failure policy %q: expected open or closed", cfg.FailurePolicy)
+	}
+
+	var verifier Verifier
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case ProviderStub:
+		return StubVerifier{}, nil
+	case ProviderReCAPTCHA, ProviderHCaptcha:
+		if cfg.Secret == "" {
+			return nil, fmt.Errorf("captcha: secret is required for the %s provider", cfg.Provider)
+		}
+		url := cfg.VerifyURL
+		if url == "" {
+			url = ReCAPTCHAVerifyURL
+			if cfg.Provider == ProviderHCaptcha {
+				url = HCaptchaVerifyURL
+			}
+		}
+		verifier = NewHTTPVerifier(url, cfg.Secret, cfg.Timeout)
+	default:
+		return nil, fmt.Errorf("captcha: unknown provider %q", cfg.Provider)
+	}
+	if failOpen {
+		verifier = openVerifier{verifier}
+	}
+	return verifier, nil
+}
+
+// StubVerifier — заглушка провайдера для разработки: принимает любой ответ, в том числе пустой.
+type StubVerifier struct{}
+
+// Verify всегда завершается успешно.
+func (StubVerifier) Verify(context.Context, string, string) error {
+	return nil
+}
+
+// openVerifier принимает ответ пользователя, если провайдер недоступен (FailOpen).
+type openVerifier struct {
+	Verifier
+}
+
+func (v openVerifier) Verify(ctx context.Context, token, remoteIP string) error {
+	err := v.Verifier.Verify(ctx, token, remoteIP)
+	if errors.Is(err, ErrUnavailable) {
+		log.Printf("captcha check skipped: %v", err)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package captcha
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест проверки у провайдера: форма с секретом, ответом и IP пользователя, отказ провайдера
+// как ErrInvalidToken, пустой ответ без запроса и неверный секрет как ErrUnavailable
+func TestHTTPVerifier(t *testing.T) {
+	var requests int
+	var response string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "secret", r.PostForm.Get("secret"))
+		assert.Equal(t, "user-token", r.PostForm.Get("response"))
+		assert.Equal(t, "192.0.2.1", r.PostForm.Get("remoteip"))
+		fmt.Fprint(w, response)
+	}))
+	defer srv.Close()
+	verifier := NewHTTPVerifier(srv.URL, "secret", 0)
+	ctx := context.Background()
+
+	response = `{"success":true}`
+	require.NoError(t, verifier.Verify(ctx, "user-token", "192.0.2.1"))
+
+	response = `{"success":false,"error-codes":["timeout-or-duplicate"]}`
+	assert.ErrorIs(t, verifier.Verify(ctx, "user-token", "192.0.2.1"), ErrInvalidToken)
+
+	response = `{"success":false,"error-codes":["invalid-input-secret"]}`
+	assert.ErrorIs(t, verifier.Verify(ctx, "user-token", "192.0.2.1"), ErrUnavailable)
+
+	assert.ErrorIs(t, verifier.Verify(ctx, "", "192.0.2.1"), ErrMissingToken)
+	assert.Equal(t, 3, requests)
+}
+
+// Тест недоступного провайдера: ответ с ошибкой и истечение собственного срока запроса
+// возвращают ErrUnavailable, а политика FailOpen принимает ответ пользователя
+func TestVerifier_FailurePolicy(t *testing.T) {
+	slow := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-slow
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	defer close(slow)
+	ctx := context.Background()
+
+	closed, err := New(Config{Provider: ProviderHCaptcha, Secret: "secret", VerifyURL: srv.URL, FailurePolicy: FailClosed})
+	require.NoError(t, err)
+	assert.ErrorIs(t, closed.Verify(ctx, "user-token", ""), ErrUnavailable)
+
+	start := time.Now()
+	timeout := NewHTTPVerifier(srv.URL+"/slow", "secret", 50*time.Millisecond)
+	assert.ErrorIs(t, timeout.Verify(ctx, "user-token", ""), ErrUnavailable)
+	assert.Less(t, time.Since(start), time.Second)
+
+	open, err := New(Config{Provider: ProviderReCAPTCHA, Secret: "secret", VerifyURL: srv.URL})
+	require.NoError(t, err)
+	assert.NoError(t, open.Verify(ctx, "user-token", ""))
+	// Отказ провайдера не зависит от политики
+	assert.ErrorIs(t, open.Verify(ctx, "", ""), ErrMissingToken)
+}
+
+// Тест выбора провайдера по конфигурации
+func TestNew(t *testing.T) {
+	verifier, err := New(Config{})
+	require.NoError(t, err)
+	assert.Nil(t, verifier)
+
+	verifier, err = New(Config{Provider: ProviderStub})
+	require.NoError(t, err)
+	assert.NoError(t, verifier.Verify(context.Background(), "", ""))
+
+	_, err = New(Config{Provider: ProviderReCAPTCHA})
+	assert.Error(t, err, "secret is required")
+	_, err = New(Config{Provider: "turnstile"})
+	assert.Error(t, err)
+	_, err = New(Config{Provider: ProviderStub, FailurePolicy: "sometimes"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxResponseSize ограничивает размер ответа провайдера, который читает HTTPVerifier.
+const maxResponseSize = 64 << 10
+
+// siteverifyResponse — ответ провайдера на проверку. Формат у reCAPTCHA и hCaptcha общий.
+type siteverifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// HTTPVerifier проверяет ответ пользователя запросом к API провайдера: POST-запрос с формой
+// {secret, response, remoteip}, ответ {"success": true|false, "error-codes": [...]}.
+// Так работают и reCAPTCHA, и hCaptcha. Время запроса ограничено собственным сроком,
+// чтобы медленный провайдер не занимал весь срок запроса пользователя.
+type HTTPVerifier struct {
+	url     string
+	secret  string
+	timeout time.Duration
+	client  *http.Client
+}
+
+// NewHTTPVerifier создает HTTPVerifier с адресом проверки url и секретным ключом secret;
+// timeout ограничивает время запроса (0 — DefaultTimeout).
+func NewHTTPVerifier(url, secret string, timeout time.Duration) *HTTPVerifier {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &HTTPVerifier{url: url, secret: secret, timeout: timeout, client: &http.Client{}}
+}
+
+// Verify проверяет ответ token у провайдера. Пустой ответ отклоняется с ErrMissingToken
+// без запроса, отказ провайдера возвращается как ErrInvalidToken, ошибка соединения,
+// истечение срока и ответ не 2xx — как ErrUnavailable.
+func (v *HTTPVerifier) Verify(ctx context.Context, token, remoteIP string) error {
+	if token == "" {
+		return ErrMissingToken
+	}
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	form := url.Values{"secret": {v.secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("build captcha request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: status %d", ErrUnavailable, resp.StatusCode)
+	}
+
+	var result siteverifyResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&result); err != nil {
+		return fmt.Errorf("%w: decode response: %v", ErrUnavailable, err)
+	}
+	if !result.Success {
+		// Неверный секретный ключ — ошибка конфигурации сервиса, а не ответа пользователя
+		for _, code := range result.ErrorCodes {
+			if code == "invalid-input-secret" || code == "missing-input-secret" {
+				return fmt.Errorf("%w: provider rejected the secret key", ErrUnavailable)
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrInvalidToken, strings.Join(result.ErrorCodes, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+// Package events доставляет события об изменении заявок подключенным клиентам в пределах
+// одного экземпляра сервиса. Сервисный слой публикует событие после успешного изменения,
+// а Broker передает его подпискам пользователей, которые могут читать заявку: владельцу
+// и участникам организации заявки.
+//
+// Доставка не гарантируется: события не сохраняются, подписки других экземпляров сервиса
+// их не получают, а подписка, не успевающая читать события, отключается. Клиент после
+// переподключения перечитывает нужные заявки через HTTP API.
+package events
+
+import (
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/model"
+)
+
+// Виды событий (Event.Type).
+const (
+	CallCreated = "call.created"
+	CallUpdated = "call.updated"
+	CallDeleted = "call.deleted"
+)
+
+// DefaultBufferSize — число событий, ожидающих отправки одной подписке, по умолчанию.
+const DefaultBufferSize = 64
+
+// DefaultMaxPerUser — наибольшее число одновременных подписок одного пользователя по умолчанию.
+const DefaultMaxPerUser = 5
+
+// Причины завершения подписки (Subscription.Err)
+var (
+	ErrTooManySubscriptions = errors.New("too many subscriptions")
+	ErrSlowConsumer         = errors.New("subscriber is too slow")
+)
+
+// subscriptionsDropped — число подписок, отключенных из-за переполнения буфера.
+var subscriptionsDropped = expvar.NewInt("call_events_slow_consumers")
+
+// Event — событие об изменении заявки. Call — заявка после изменения; nil у CallDeleted.
+// UserIDs и OrgID определяют получателей: пользователи UserIDs и участники организации OrgID.
+
+type Event struct {
+	Type   string
+	CallID uuid.UUID
+	At     time.Time
+	Call   *model.Call
+	// UserIDs — владелец заявки, а при передаче заявки — прежний и новый владельцы.
+	UserIDs []uuid.UUID
+	OrgID   *uuid.UUID
+}
+
+// Publisher публикует события об изменении заявок. Publish не блокируется.
+
+type Publisher interface {
+	Publish(e Event)
+}
+
+// Config задает параметры Broker.
+
+type Config struct {
+	// BufferSize — число событий, ожидающих отправки одной подписке; 0 — DefaultBufferSize.
+	BufferSize int
+	// MaxPerUser — наибольшее число одновременных подписок пользователя; 0 — DefaultMaxPerUser.
+	MaxPerUser int
+}
+
+// Broker передает опубликованные события подпискам. Методы безопасны для одновременного вызова.
+
+type Broker struct {
+	cfg Config
+
+	mu      sync.Mutex
+	subs    map[*Subscription]struct{}
+	perUser map[uuid.UUID]int
+}
+
+// NewBroker создает Broker с параметрами cfg.
+
+func NewBroker(cfg Config) *Broker {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = DefaultBufferSize
+	}
+	if cfg.MaxPerUser <= 0 {
+		cfg.MaxPerUser = DefaultMaxPerUser
+	}
+	return &Broker{cfg: cfg, subs: make(map[*Subscription]struct{}), perUser: make(map[uuid.UUID]int)}
+}
+
+// Subscribe подписывает пользователя userID, состоящего в организации orgID (nil — вне
+// организации), на события заявок, которые он может читать. Возвращает
+// ErrTooManySubscriptions, если у пользователя уже Config.MaxPerUser подписок.
+
+func (b *Broker) Subscribe(userID uuid.UUID, orgID *uuid.UUID) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.perUser[userID] >= b.cfg.MaxPerUser {
+		return nil, ErrTooManySubscriptions
+	}
+	s := &Subscription{broker: b, userID: userID, orgID: orgID, events: make(chan Event, b.cfg.BufferSize)}
+	b.subs[s] = struct{}{}
+	b.perUser[userID]++
+	return s, nil
+}
+
+// Subscriptions возвращает число действующих подписок пользователя userID.
+
+func (b *Broker) Subscriptions(userID uuid.UUID) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.perUser[userID]
+}
+
+// Publish передает событие подпискам его получателей. Подписка, буфер которой заполнен,
+// отключается с ошибкой ErrSlowConsumer, чтобы медленный клиент не задерживал остальных
+// и не накапливал события в памяти.
+
+func (b *Broker) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subs {
+		if !s.receives(e) {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			subscriptionsDropped.Add(1)
+			b.remove(s, ErrSlowConsumer)
+		}
+	}
+}
+
+// remove завершает подписку с причиной err; вызывается с захваченной блокировкой
+func (b *Broker) remove(s *Subscription, err error) {
+	if _, ok := b.subs[s]; !ok {
+		return
+	}
+	delete(b.subs, s)
+	if b.perUser[s.userID]--; b.perUser[s.userID] == 0 {
+		delete(b.perUser, s.userID)
+	}
+	s.err = err
+	close(s.events)
+}
+
+// Subscription — подписка пользователя на события заявок.
+
+type Subscription struct {
+	broker *Broker
+	userID uuid.UUID
+	orgID  *uuid.UUID
+	events chan Event
+	// err — причина завершения подписки брокером; читается после закрытия events.
+	err error
+}
+
+// Events возвращает канал событий подписки. Канал закрывается при завершении подписки;
+// причину сообщает Err.
+
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err возвращает причину завершения подписки после закрытия канала Events: ErrSlowConsumer
+// или nil, если подписку завершил Close.
+
+func (s *Subscription) Err() error {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	return s.err
+}
+
+// Close завершает подписку. Повторные вызовы ничего не делают.
+
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s, nil)
+}
+
+// receives сообщает, является ли пользователь подписки получателем события
+func (s *Subscription) receives(e Event) bool {
+	for _, id := range e.UserIDs {
+		if id == s.userID {
+			return true
+		}
+	}
+	return e.OrgID != nil && s.orgID != nil && *e.OrgID == *s.orgID
+}
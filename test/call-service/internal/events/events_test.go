@@ -0,0 +1,81 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест доставки события владельцу заявки и участникам ее организации
+func TestBroker_DeliversToOwnerAndOrganization(t *testing.T) {
+	broker := NewBroker(Config{})
+	ownerID, memberID, strangerID, orgID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	owner, err := broker.Subscribe(ownerID, nil)
+	require.NoError(t, err)
+	member, err := broker.Subscribe(memberID, &orgID)
+	require.NoError(t, err)
+	stranger, err := broker.Subscribe(strangerID, nil)
+	require.NoError(t, err)
+
+	callID := uuid.New()
+	broker.Publish(Event{Type: CallCreated, CallID: callID, UserIDs: []uuid.UUID{ownerID}, OrgID: &orgID})
+
+	for _, sub := range []*Subscription{owner, member} {
+		select {
+		case e := <-sub.Events():
+			assert.Equal(t, callID, e.CallID)
+		default:
+			t.Fatal("event was not delivered")
+		}
+	}
+	assert.Empty(t, stranger.Events())
+}
+
+// Тест ограничения числа подписок пользователя: после Close подписаться снова можно
+func TestBroker_MaxPerUser(t *testing.T) {
+	broker := NewBroker(Config{MaxPerUser: 2})
+	userID := uuid.New()
+
+	first, err := broker.Subscribe(userID, nil)
+	require.NoError(t, err)
+	_, err = broker.Subscribe(userID, nil)
+	require.NoError(t, err)
+	_, err = broker.Subscribe(userID, nil)
+	assert.ErrorIs(t, err, ErrTooManySubscriptions)
+
+	first.Close()
+	first.Close()
+	assert.Equal(t, 1, broker.Subscriptions(userID))
+	_, err = broker.Subscribe(userID, nil)
+	assert.NoError(t, err)
+}
+
+// Тест отключения подписки с заполненным буфером: Publish не блокируется, канал закрывается
+// с ошибкой ErrSlowConsumer, остальные подписки продолжают получать события
+func TestBroker_DropsSlowConsumer(t *testing.T) {
+	broker := NewBroker(Config{BufferSize: 1})
+	userID := uuid.New()
+	slow, err := broker.Subscribe(userID, nil)
+	require.NoError(t, err)
+	fast, err := broker.Subscribe(userID, nil)
+	require.NoError(t, err)
+
+	e := Event{Type: CallUpdated, CallID: uuid.New(), UserIDs: []uuid.UUID{userID}}
+	broker.Publish(e)
+	<-fast.Events()
+	broker.Publish(e)
+
+	_, ok := <-slow.Events()
+	assert.True(t, ok, "buffered event is still delivered")
+	_, ok = <-slow.Events()
+	assert.False(t, ok)
+	assert.ErrorIs(t, slow.Err(), ErrSlowConsumer)
+
+	_, ok = <-fast.Events()
+	assert.True(t, ok)
+	assert.NoError(t, fast.Err())
+	assert.Equal(t, 1, broker.Subscriptions(userID))
+}
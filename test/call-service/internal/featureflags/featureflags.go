@@ -0,0 +1,245 @@
+// Package featureflags включает новое поведение сервиса постепенно, без изменения кода
+// и перезапуска: флаги задаются переменной окружения и JSON-файлом, файл перечитывается
+// во время работы, а флаг может быть включен для доли пользователей.
+//
+// Компоненты получают Flags при создании и проверяют флаг по контексту запроса:
+//
+//	if flags.Enabled(ctx, featureflags.ResolveUsernames) { ... }
+//
+// Пользователь запроса попадает в контекст через WithUser (это делает middleware
+// аутентификации). Доля пользователей определяется хешем от имени флага и ID пользователя,
+// поэтому один и тот же пользователь всегда получает одно и то же значение флага.
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// Flag — имя флага в конфигурации.
+type Flag string
+
+// Флаги, которые проверяет сервис
+const (
+	// DegradedAuth разрешает принимать токены по кэшированной проверке, пока сервис
+	// аутентификации недоступен (см. middleware.AuthConfig.StaleTTL).
+	DegradedAuth Flag = "degraded_auth"
+	// ResolveUsernames включает имена авторов в ответах с заявками (см. service.WithUserDirectory).
+	ResolveUsernames Flag = "resolve_usernames"
+)
+
+// defaults — значения флагов, не заданных в конфигурации. Флаги, добавленные
+// к уже работающему поведению, по умолчанию включены, чтобы его сохранить.
+var defaults = map[Flag]Rule{
+	DegradedAuth:     {Enabled: true},
+	ResolveUsernames: {Enabled: true},
+}
+
+// Flags сообщает, включен ли флаг для запроса с контекстом ctx.
+type Flags interface {
+	Enabled(ctx context.Context, flag Flag) bool
+}
+
+// Rule — значение флага.
+type Rule struct {
+	// Enabled включает флаг для всех запросов.
+	Enabled bool `json:"enabled"`
+	// Percentage включает выключенный флаг для доли пользователей от 0 до 100. Запросы
+	// без пользователя в контексте (например, фоновые задачи) в долю не попадают.
+	Percentage int `json:"percentage,omitempty"`
+}
+
+// enabled сообщает, включен ли флаг flag с этим значением для пользователя userID.
+func (r Rule) enabled(flag Flag, userID uuid.UUID, hasUser bool) bool {
+	if r.Enabled {
+		return true
+	}
+	if r.Percentage <= 0 || !hasUser {
+		return false
+	}
+	return bucket(flag, userID) < r.Percentage
+}
+
+// bucket возвращает номер от 0 до 99, в который попадает пользователь для флага flag.
+// Имя флага входит в хеш, чтобы разные флаги включались для разных пользователей.
+func bucket(flag Flag, userID uuid.UUID) int {
+	h := fnv.New32a()
+	h.Write([]byte(flag))
+	h.Write(userID[:])
+	return int(h.Sum32() % 100)
+}
+
+type userKey struct{}
+
+// WithUser возвращает контекст с ID пользователя запроса для долевого включения флагов.
+func WithUser(ctx context.Context, userID uuid.UUID) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+// userFromContext возвращает ID пользователя, сохраненный WithUser.
+func userFromContext(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(userKey{}).(uuid.UUID)
+	return userID, ok
+}
+
+// Store — флаги из переменной окружения и JSON-файла. Значения из файла заменяют значения
+// из переменной окружения. Reload перечитывает файл, Update заменяет оба источника;
+// проверки флагов не блокируются.
+type Store struct {
+	// mu упорядочивает Reload и Update и защищает env и file.
+	mu    sync.Mutex
+	env   map[Flag]Rule
+	file  string
+	rules atomic.Pointer[map[Flag]Rule]
+}
+
+// NewStore создает флаги из значения переменной окружения env и файла file; пустая строка
+// означает, что источник не используется. Формат env — список через запятую элементов
+// "имя=on", "имя=off" или "имя=N%" (доля пользователей). Файл содержит объект JSON, ключи
+// которого — имена флагов, а значения — Rule: {"resolve_usernames": {"percentage": 10}}.
+func NewStore(env, file string) (*Store, error) {
+	rules, err := parseEnv(env)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{env: rules, file: file}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload перечитывает файл флагов. При ошибке продолжают действовать прежние значения.
+func (s *Store) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rules, err := load(s.env, s.file)
+	if err != nil {
+		return err
+	}
+	s.rules.Store(&rules)
+	return nil
+}
+
+// Update заменяет значение переменной окружения и путь к файлу флагов (в формате NewStore)
+// и перечитывает файл. При ошибке продолжают действовать прежние источники и значения.
+func (s *Store) Update(env, file string) error {
+	envRules, err := parseEnv(env)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rules, err := load(envRules, file)
+	if err != nil {
+		return err
+	}
+	s.env, s.file = envRules, file
+	s.rules.Store(&rules)
+	return nil
+}
+
+// load объединяет флаги из переменной окружения env и файла file.
+func load(env map[Flag]Rule, file string) (map[Flag]Rule, error) {
+	rules := maps.Clone(env)
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read feature flags: %w", err)
+		}
+		var fileRules map[Flag]Rule
+		if err := json.Unmarshal(data, &fileRules); err != nil {
+			return nil, fmt.Errorf("parse feature flags %s: %w", file, err)
+		}
+		for flag, rule := range fileRules {
+			if err := validate(flag, rule); err != nil {
+				return nil, err
+			}
+			rules[flag] = rule
+		}
+	}
+	return rules, nil
+}
+
+// Enabled сообщает, включен ли флаг для пользователя запроса.
+func (s *Store) Enabled(ctx context.Context, flag Flag) bool {
+	rule, ok := (*s.rules.Load())[flag]
+	if !ok {
+		rule = defaults[flag]
+	}
+	userID, hasUser := userFromContext(ctx)
+	return rule.enabled(flag, userID, hasUser)
+}
+
+// Rules возвращает действующие значения всех известных и заданных флагов.
+func (s *Store) Rules() map[Flag]Rule {
+	rules := maps.Clone(defaults)
+	maps.Copy(rules, *s.rules.Load())
+	return rules
+}
+
+// parseEnv разбирает значение переменной окружения с флагами.
+func parseEnv(env string) (map[Flag]Rule, error) {
+	rules := make(map[Flag]Rule)
+	for _, item := range strings.Split(env, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid feature flag %q: expected name=on, name=off or name=N%%", item)
+		}
+		var rule Rule
+		switch value {
+		case "on":
+			rule.Enabled = true
+		case "off":
+		default:
+			percentage, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+			if err != nil || !strings.HasSuffix(value, "%") {
+				return nil, fmt.Errorf("invalid feature flag %q: expected name=on, name=off or name=N%%", item)
+			}
+			rule.Percentage = percentage
+		}
+		flag := Flag(strings.TrimSpace(name))
+		if err := validate(flag, rule); err != nil {
+			return nil, err
+		}
+		rules[flag] = rule
+	}
+	return rules, nil
+}
+
+// validate проверяет имя и значение флага.
+func validate(flag Flag, rule Rule) error {
+	if flag == "" {
+		return fmt.Errorf("invalid feature flag: empty name")
+	}
+	if rule.Percentage < 0 || rule.Percentage > 100 {
+		return fmt.Errorf("invalid feature flag %s: percentage must be between 0 and 100", flag)
+	}
+	return nil
+}
+
+// Static — флаги с фиксированными значениями для тестов: флаг включен для всех запросов,
+// если его значение true. Флаги, не заданные в Static, получают значения по умолчанию.
+type Static map[Flag]bool
+
+// Enabled реализует Flags.
+func (s Static) Enabled(_ context.Context, flag Flag) bool {
+	if enabled, ok := s[flag]; ok {
+		return enabled
+	}
+	return defaults[flag].Enabled
+}
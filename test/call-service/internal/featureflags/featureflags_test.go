@@ -0,0 +1,114 @@
+package featureflags
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStore_EnvAndFile проверяет значения по умолчанию, переменную окружения и файл,
+// значения которого заменяют значения из переменной окружения.
+
+func TestStore_EnvAndFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "flags.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"new_export": {"enabled": true}}`), 0o600))
+
+	store, err := NewStore("resolve_usernames=off, new_export=off, beta=25%", path)
+	require.NoError(t, err)
+	assert.True(t, store.Enabled(ctx, DegradedAuth))
+	assert.False(t, store.Enabled(ctx, ResolveUsernames))
+	assert.True(t, store.Enabled(ctx, "new_export"))
+	assert.False(t, store.Enabled(ctx, "beta"))
+	assert.False(t, store.Enabled(ctx, "unknown"))
+	assert.Equal(t, map[Flag]Rule{
+		DegradedAuth:     {Enabled: true},
+		ResolveUsernames: {},
+		"new_export":     {Enabled: true},
+		"beta":           {Percentage: 25},
+	}, store.Rules())
+
+	for _, env := range []string{"beta", "beta=yes", "beta=101%", "=on"} {
+		_, err := NewStore(env, "")
+		assert.Error(t, err, env)
+	}
+	_, err = NewStore("", filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+// TestStore_Reload проверяет, что Reload применяет новый файл, а при ошибке сохраняет
+// прежние значения.
+
+func TestStore_Reload(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "flags.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"resolve_usernames": {"enabled": false}}`), 0o600))
+	store, err := NewStore("", path)
+	require.NoError(t, err)
+	assert.False(t, store.Enabled(ctx, ResolveUsernames))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{}`), 0o600))
+	require.NoError(t, store.Reload())
+	assert.True(t, store.Enabled(ctx, ResolveUsernames))
+
+	for _, content := range []string{`{"resolve_usernames": {"enabled": false`, `{"resolve_usernames": {"percentage": -1}}`} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		assert.Error(t, store.Reload(), content)
+		assert.True(t, store.Enabled(ctx, ResolveUsernames))
+	}
+}
+
+// TestStore_Update проверяет, что Update заменяет значение переменной окружения и файл,
+// а при ошибке сохраняет прежние источники.
+
+func TestStore_Update(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStore("resolve_usernames=off", "")
+	require.NoError(t, err)
+	assert.False(t, store.Enabled(ctx, ResolveUsernames))
+
+	require.NoError(t, store.Update("resolve_usernames=on", ""))
+	assert.True(t, store.Enabled(ctx, ResolveUsernames))
+
+	assert.Error(t, store.Update("resolve_usernames=maybe", ""))
+	assert.Error(t, store.Update("resolve_usernames=off", filepath.Join(t.TempDir(), "missing.json")))
+	require.NoError(t, store.Reload())
+	assert.True(t, store.Enabled(ctx, ResolveUsernames))
+}
+
+// TestStore_Percentage проверяет долевое включение: значение флага для пользователя
+// не меняется между проверками, доля включенных пользователей близка к заданной,
+// а запросы без пользователя в долю не попадают.
+
+func TestStore_Percentage(t *testing.T) {
+	store, err := NewStore("beta=30%", "")
+	require.NoError(t, err)
+	assert.False(t, store.Enabled(context.Background(), "beta"))
+
+	enabled := 0
+	for i := 0; i < 1000; i++ {
+		ctx := WithUser(context.Background(), uuid.New())
+		first := store.Enabled(ctx, "beta")
+		assert.Equal(t, first, store.Enabled(ctx, "beta"))
+		if first {
+			enabled++
+		}
+	}
+	assert.InDelta(t, 300, enabled, 60)
+}
+
+// TestStatic проверяет тестовую реализацию: заданные значения и значения по умолчанию.
+
+func TestStatic(t *testing.T) {
+	ctx := context.Background()
+	flags := Static{ResolveUsernames: false, "beta": true}
+	assert.False(t, flags.Enabled(ctx, ResolveUsernames))
+	assert.True(t, flags.Enabled(ctx, "beta"))
+	assert.True(t, flags.Enabled(ctx, DegradedAuth))
+	assert.False(t, flags.Enabled(ctx, "unknown"))
+}
@@ -0,0 +1,147 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"call-service/internal/middleware"
+	"call-service/pkg/authclient"
+)
+
+// principalKey — ключ контекста, под которым хранится middleware.Principal аутентифицированного
+// пользователя.
+type principalKey struct{}
+
+// AuthConfig задает параметры перехватчиков аутентификации.
+type AuthConfig struct {
+	// Organizations включает доступ к заявкам организации так же, как
+	// middleware.AuthConfig.Organizations: текущая организация пользователя, сообщенная при проверке
+	// токена, сохраняется в контексте вызова (см. tenant). Выключено — каждый пользователь видит
+	// только свои заявки.
+	Organizations bool
+}
+
+// publicMethodPrefixes перечисляет сервисы, доступные без токена (рефлексия для grpcui/grpcurl).
+var publicMethodPrefixes = []string{
+	"/grpc.reflection.",
+}
+
+// AuthInterceptor возвращает unary-перехватчик, проверяющий bearer-токен из метаданных
+// authorization через сервис аутентификации и сохраняющий пользователя в контексте.
+// Работает аналогично middleware.AuthMiddleware для HTTP API: контекст содержит те же данные
+// для сервисного слоя (middleware.ContextWithPrincipal), в том числе администратора,
+// работающего от имени пользователя, и организацию пользователя при cfg.Organizations.
+func AuthInterceptor(authClient authclient.AuthClient, cfg AuthConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, authClient, cfg, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// AuthStreamInterceptor возвращает потоковый перехватчик, проверяющий токен так же, как
+// AuthInterceptor, до вызова обработчика; контекст потока содержит ID и роль пользователя.
+func AuthStreamInterceptor(authClient authclient.AuthClient, cfg AuthConfig) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(stream.Context(), authClient, cfg, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authenticatedStream подменяет контекст потока контекстом с данными пользователя.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate проверяет bearer-токен из метаданных вызова method и возвращает контекст
+// с данными пользователя. Метаданные разбираются так же, как заголовок Authorization
+// в HTTP API (middleware.ParseBearer). Публичные методы (publicMethodPrefixes) не проверяются.
+func authenticate(ctx context.Context, authClient authclient.AuthClient, cfg AuthConfig, method string) (context.Context, error) {
+	for _, prefix := range publicMethodPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return ctx, nil
+		}
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "authorization metadata is required")
+	}
+
+	token, ok := middleware.ParseBearer(values[0])
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization metadata format")
+	}
+	if len(token) > middleware.MaxTokenLength {
+		return nil, status.Error(codes.Unauthenticated, "token is too long")
+	}
+
+	tokenInfo, err := authClient.ValidateTokenFull(ctx, token)
+	if err != nil || tokenInfo == nil || !tokenInfo.Valid {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	userID, err := uuid.Parse(tokenInfo.UserID)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid user ID")
+	}
+
+	principal := middleware.Principal{
+		UserID:     userID,
+		Role:       tokenInfo.Role,
+		AuthMethod: middleware.AuthMethodJWT,
+		SessionID:  tokenInfo.SessionID,
+	}
+	if principal.Role == "" {
+		principal.Role = middleware.RoleUser
+	}
+	if cfg.Organizations && tokenInfo.OrgID != "" {
+		if principal.OrgID, err = uuid.Parse(tokenInfo.OrgID); err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		principal.OrgRole = tokenInfo.OrgRole
+	}
+	if tokenInfo.ImpersonatedBy != "" {
+		if principal.ImpersonatedBy, err = uuid.Parse(tokenInfo.ImpersonatedBy); err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+	}
+
+	ctx = middleware.ContextWithPrincipal(ctx, principal)
+	return context.WithValue(ctx, principalKey{}, principal), nil
+}
+
+// PrincipalFromContext извлекает пользователя, сохраненного AuthInterceptor или AuthStreamInterceptor.
+func PrincipalFromContext(ctx context.Context) (middleware.Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(middleware.Principal)
+	return p, ok
+}
+
+// UserIDFromContext извлекает ID пользователя, сохраненный AuthInterceptor или AuthStreamInterceptor.
+func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	p, ok := PrincipalFromContext(ctx)
+	return p.UserID, ok
+}
+
+// RoleFromContext извлекает роль пользователя, сохраненную AuthInterceptor.
+func RoleFromContext(ctx context.Context) string {
+	p, _ := PrincipalFromContext(ctx)
+	return p.Role
+}
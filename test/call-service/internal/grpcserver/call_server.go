@@ -0,0 +1,300 @@
+// Package grpcserver реализует gRPC API сервиса заявок для внутренних сервисов.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "api/call"
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+)
+
+const (
+	// DefaultListLimit — размер страницы ListCalls, если клиент его не указал.
+	DefaultListLimit = 50
+	// MaxListLimit — максимальный размер страницы ListCalls и пакета StreamCalls.
+	MaxListLimit = 500
+)
+
+// CallServer реализует gRPC-сервис CallService поверх service.CallService.
+type CallServer struct {
+	pb.UnimplementedCallServiceServer
+	callService service.CallService
+}
+
+// NewCallServer создает новый экземпляр CallServer.
+func NewCallServer(callService service.CallService) *CallServer {
+	return &CallServer{callService: callService}
+}
+
+// NewServer создает gRPC-сервер с перехватчиками аутентификации обычных и потоковых вызовов,
+// зарегистрированным CallService и рефлексией. Организации не учитываются.
+func NewServer(callService service.CallService, authClient authclient.AuthClient, opts ...grpc.ServerOption) *grpc.Server {
+	return NewServerWithConfig(callService, authClient, AuthConfig{}, opts...)
+}
+
+// NewServerWithConfig создает gRPC-сервер, как NewServer, с параметрами аутентификации cfg.
+func NewServerWithConfig(callService service.CallService, authClient authclient.AuthClient, cfg AuthConfig, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(AuthInterceptor(authClient, cfg)),
+		grpc.ChainStreamInterceptor(AuthStreamInterceptor(authClient, cfg)),
+	}, opts...)
+	server := grpc.NewServer(opts...)
+	pb.RegisterCallServiceServer(server, NewCallServer(callService))
+	reflection.Register(server)
+	return server
+}
+
+// CreateCall создает заявку от имени аутентифицированного пользователя.
+func (s *CallServer) CreateCall(ctx context.Context, req *pb.CreateCallRequest) (*pb.Call, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	if req.ClientName == "" || req.PhoneNumber == "" || req.Description == "" {
+		return nil, status.Error(codes.InvalidArgument, "client_name, phone_number and description are required")
+	}
+
+	createReq := &model.CreateCallRequest{
+		ClientName:  req.ClientName,
+		PhoneNumber: req.PhoneNumber,
+		Description: req.Description,
+	}
+	if req.CallbackAt != nil {
+		callbackAt := req.CallbackAt.AsTime()
+		createReq.CallbackAt = &callbackAt
+	}
+	call, err := s.callService.CreateCall(ctx, createReq, userID)
+	if err != nil {
+		return nil, toStatus(err, "failed to create call")
+	}
+
+	return toProto(call), nil
+}
+
+// GetCall возвращает заявку пользователя по её ID.
+func (s *CallServer) GetCall(ctx context.Context, req *pb.GetCallRequest) (*pb.Call, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid call ID")
+	}
+
+	call, err := s.callService.GetCallByID(ctx, id, userID)
+	if err != nil {
+		return nil, toStatus(err, "failed to get call")
+	}
+
+	return toProto(call), nil
+}
+
+// ListCalls возвращает страницу заявок пользователя с учетом фильтра.
+func (s *CallServer) ListCalls(ctx context.Context, req *pb.ListCallsRequest) (*pb.ListCallsResponse, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	filter, err := toFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	calls, total, err := s.callService.GetAllCalls(ctx, userID, filter)
+	if err != nil {
+		return nil, toStatus(err, "failed to get calls")
+	}
+
+	resp := &pb.ListCallsResponse{
+		Calls: make([]*pb.Call, 0, len(calls)),
+		Total: int32(total),
+	}
+	for _, call := range calls {
+		resp.Calls = append(resp.Calls, toProto(call))
+	}
+	return resp, nil
+}
+
+// StreamCalls отправляет все заявки пользователя, удовлетворяющие фильтру, пакетами
+// по batch_size (0 — service.DefaultStreamCallsBatch). Каждый пакет читается после того, как
+// предыдущий принят потоком: Send блокируется, пока клиент не освободит окно управления
+// потоком, поэтому медленный клиент задерживает чтение, а отмена вызова прекращает его.
+func (s *CallServer) StreamCalls(req *pb.StreamCallsRequest, stream pb.CallService_StreamCallsServer) error {
+	ctx := stream.Context()
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	filter, err := toFilter(&pb.ListCallsRequest{
+		Status:      req.Status,
+		PhoneNumber: req.PhoneNumber,
+		CreatedFrom: req.CreatedFrom,
+		CreatedTo:   req.CreatedTo,
+		Sort:        req.Sort,
+		Ascending:   req.Ascending,
+	})
+	if err != nil {
+		return err
+	}
+	if req.BatchSize < 0 || req.BatchSize > MaxListLimit {
+		return status.Errorf(codes.InvalidArgument, "batch_size must be between 0 and %d", MaxListLimit)
+	}
+
+	err = s.callService.StreamCalls(ctx, userID, filter, int(req.BatchSize), func(calls []*model.Call) error {
+		resp := &pb.StreamCallsResponse{Calls: make([]*pb.Call, 0, len(calls))}
+		for _, call := range calls {
+			resp.Calls = append(resp.Calls, toProto(call))
+		}
+		return stream.Send(resp)
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		// Ошибка отправки сообщения уже содержит статус gRPC
+		if st, ok := status.FromError(err); ok {
+			return st.Err()
+		}
+		return toStatus(err, "failed to stream calls")
+	}
+	return nil
+}
+
+// UpdateCallStatus обновляет статус заявки пользователя.
+func (s *CallServer) UpdateCallStatus(ctx context.Context, req *pb.UpdateCallStatusRequest) (*pb.UpdateCallStatusResponse, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid call ID")
+	}
+
+	if err := s.callService.UpdateCallStatus(ctx, id, model.ParseCallStatus(req.Status), userID); err != nil {
+		return nil, toStatus(err, "failed to update call status")
+	}
+
+	return &pb.UpdateCallStatusResponse{}, nil
+}
+
+// DeleteCall удаляет заявку пользователя.
+func (s *CallServer) DeleteCall(ctx context.Context, req *pb.DeleteCallRequest) (*pb.DeleteCallResponse, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid call ID")
+	}
+
+	if err := s.callService.DeleteCall(ctx, id, userID); err != nil {
+		return nil, toStatus(err, "failed to delete call")
+	}
+
+	return &pb.DeleteCallResponse{}, nil
+}
+
+// toFilter переводит параметры запроса ListCalls в фильтр сервисного слоя.
+func toFilter(req *pb.ListCallsRequest) (model.CallFilter, error) {
+	filter := model.CallFilter{
+		Status:      model.ParseCallStatus(req.Status),
+		PhoneNumber: req.PhoneNumber,
+		SortBy:      model.SortByCreatedAt,
+		SortDesc:    !req.Ascending,
+		Limit:       DefaultListLimit,
+		Offset:      int(req.Offset),
+	}
+
+	switch req.Sort {
+	case "":
+	case model.SortByCreatedAt, model.SortByClientName, model.SortByStatus:
+		filter.SortBy = req.Sort
+	default:
+		return filter, status.Error(codes.InvalidArgument, "invalid sort field")
+	}
+
+	if req.Limit < 0 || req.Limit > MaxListLimit {
+		return filter, status.Errorf(codes.InvalidArgument, "limit must be between 0 and %d", MaxListLimit)
+	}
+	if req.Limit > 0 {
+		filter.Limit = int(req.Limit)
+	}
+	if req.Offset < 0 {
+		return filter, status.Error(codes.InvalidArgument, "offset must be non-negative")
+	}
+
+	if req.CreatedFrom != nil {
+		t := req.CreatedFrom.AsTime()
+		filter.CreatedFrom = &t
+	}
+	if req.CreatedTo != nil {
+		t := req.CreatedTo.AsTime()
+		filter.CreatedTo = &t
+	}
+
+	return filter, nil
+}
+
+// toProto переводит заявку в gRPC-сообщение.
+func toProto(call *model.Call) *pb.Call {
+	msg := &pb.Call{
+		Id:          call.ID.String(),
+		ClientName:  call.ClientName,
+		PhoneNumber: call.PhoneNumber,
+		Description: call.Description,
+		Status:      string(call.Status),
+		CreatedAt:   timestamppb.New(call.CreatedAt),
+		UserId:      call.UserID.String(),
+		CreatedBy:   call.CreatedBy.String(),
+	}
+	if call.UpdatedBy != nil {
+		msg.UpdatedBy = call.UpdatedBy.String()
+	}
+	if call.CallbackAt != nil {
+		msg.CallbackAt = timestamppb.New(*call.CallbackAt)
+	}
+	return msg
+}
+
+// toStatus переводит ошибку сервисного слоя в gRPC-статус.
+func toStatus(err error, internalMessage string) error {
+	switch {
+	case errors.Is(err, service.ErrCallNotFound):
+		return status.Error(codes.NotFound, "call not found")
+	case errors.Is(err, service.ErrForbidden):
+		return status.Error(codes.PermissionDenied, "access denied")
+	case errors.Is(err, service.ErrInvalidPhoneNumber):
+		return status.Error(codes.InvalidArgument, "invalid phone number format")
+	case errors.Is(err, service.ErrDescriptionTooLong):
+		return status.Errorf(codes.InvalidArgument, "description must not exceed %d characters", model.MaxDescriptionLength)
+	case errors.Is(err, service.ErrInvalidStatus):
+		return status.Error(codes.InvalidArgument, "invalid status")
+	case errors.Is(err, service.ErrCallbackInPast):
+		return status.Error(codes.InvalidArgument, "callback time must be in the future")
+	case errors.Is(err, repository.ErrTimeout):
+		return status.Error(codes.DeadlineExceeded, "request timed out")
+	case errors.Is(err, repository.ErrInvalidFilter):
+		return status.Error(codes.InvalidArgument, "unsupported filter")
+	}
+	return status.Error(codes.Internal, internalMessage)
+}
@@ -0,0 +1,395 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "api/call"
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"call-service/internal/service"
+	"call-service/internal/tenant"
+	"call-service/pkg/authclient"
+	"call-service/pkg/callclient"
+)
+
+// errorReporter сообщает о неожиданных вызовах моков паникой с текстом ошибки gomock. Моки
+// вызываются из горутин gRPC-сервера, где t.Fatalf завершил бы только обработчик, и RPC
+// завис бы до тайм-аута теста.
+
+type errorReporter struct {
+	*testing.T
+}
+
+func (r errorReporter) Fatalf(format string, args ...any) {
+	r.Helper()
+	r.Errorf(format, args...)
+	panic(fmt.Sprintf(format, args...))
+}
+
+func newController(t *testing.T) *gomock.Controller {
+	return gomock.NewController(errorReporter{t})
+}
+
+// startServer запускает gRPC-сервер поверх bufconn и возвращает подключенный клиент.
+
+func startServer(t *testing.T, callService service.CallService, authClient authclient.AuthClient) pb.CallServiceClient {
+	return startServerWithConfig(t, callService, authClient, AuthConfig{})
+}
+
+// startServerWithConfig запускает gRPC-сервер с параметрами аутентификации cfg.
+
+func startServerWithConfig(t *testing.T, callService service.CallService, authClient authclient.AuthClient, cfg AuthConfig) pb.CallServiceClient {
+	lis := bufconn.Listen(1024 * 1024)
+	server := NewServerWithConfig(callService, authClient, cfg)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return pb.NewCallServiceClient(conn)
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+// TestAuthInterceptor_Rejects проверяет отклонение запросов без метаданных, с неверным форматом,
+// с недействительным токеном и при недоступности сервиса аутентификации.
+
+func TestAuthInterceptor_Rejects(t *testing.T) {
+	ctrl := newController(t)
+	mockCallService := mocks.NewMockCallService(ctrl)
+	mockAuth := mocks.NewMockAuthClient(ctrl)
+	client := startServer(t, mockCallService, mockAuth)
+
+	mockAuth.EXPECT().ValidateTokenFull(gomock.Any(), "invalid-token").Return(&authclient.TokenInfo{Valid: false}, nil)
+	mockAuth.EXPECT().ValidateTokenFull(gomock.Any(), "broken-backend").Return(nil, errors.New("connection refused"))
+	mockAuth.EXPECT().ValidateTokenFull(gomock.Any(), "bad-user-id").Return(&authclient.TokenInfo{Valid: true, UserID: "not-a-uuid"}, nil)
+
+	contexts := map[string]context.Context{
+		"missing metadata": context.Background(),
+		"invalid format":   metadata.AppendToOutgoingContext(context.Background(), "authorization", "Token abc"),
+		"extra fields":     metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer abc def"),
+		"token too long":   withToken(strings.Repeat("a", middleware.MaxTokenLength+1)),
+		"invalid token":    withToken("invalid-token"),
+		"backend error":    withToken("broken-backend"),
+		"bad user id":      withToken("bad-user-id"),
+	}
+
+	for name, ctx := range contexts {
+		_, err := client.GetCall(ctx, &pb.GetCallRequest{Id: uuid.New().String()})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err), name)
+	}
+}
+
+// TestAuthInterceptor_InjectsUserID проверяет, что ID пользователя из токена
+// передается в сервис заявок через контекст.
+
+func TestAuthInterceptor_InjectsUserID(t *testing.T) {
+	ctrl := newController(t)
+	mockCallService := mocks.NewMockCallService(ctrl)
+	mockAuth := mocks.NewMockAuthClient(ctrl)
+	client := startServer(t, mockCallService, mockAuth)
+	testUserID := uuid.New()
+
+	mockAuth.EXPECT().ValidateTokenFull(gomock.Any(), "test-token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: testUserID.String(), Role: "user"}, nil)
+	testCall := &model.Call{
+		ID:          uuid.New(),
+		ClientName:  "Test Client",
+		PhoneNumber: "+1234567890",
+		Description: "Test Description",
+		Status:      "open",
+		UserID:      testUserID,
+	}
+	mockCallService.EXPECT().CreateCall(gomock.Any(), gomock.Cond(func(req *model.CreateCallRequest) bool {
+		return req.ClientName == "Test Client" && req.PhoneNumber == "+1234567890"
+	}), testUserID).Return(testCall, nil)
+
+	resp, err := client.CreateCall(withToken("test-token"), &pb.CreateCallRequest{
+		ClientName:  "Test Client",
+		PhoneNumber: "+1234567890",
+		Description: "Test Description",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, testCall.ID.String(), resp.Id)
+	assert.Equal(t, testUserID.String(), resp.UserId)
+}
+
+// TestAuthInterceptor_Impersonation проверяет, что изменение заявки по gRPC с токеном,
+// выпущенным для работы администратора от имени пользователя, записывается в историю
+// с администратором, как и в HTTP API.
+
+func TestAuthInterceptor_Impersonation(t *testing.T) {
+	ctrl := newController(t)
+	mockAuth := mocks.NewMockAuthClient(ctrl)
+	callService := service.NewCallService(repository.NewInMemoryCallRepository())
+	client := startServer(t, callService, mockAuth)
+	userID, adminID := uuid.New(), uuid.New()
+
+	mockAuth.EXPECT().ValidateTokenFull(gomock.Any(), "impersonation-token").Return(&authclient.TokenInfo{
+		Valid: true, UserID: userID.String(), Role: "user", ImpersonatedBy: adminID.String(),
+	}, nil).Times(2)
+
+	created, err := client.CreateCall(withToken("impersonation-token"), &pb.CreateCallRequest{
+		ClientName: "Иван", PhoneNumber: "+79990000001", Description: "Звонок",
+	})
+	require.NoError(t, err)
+	_, err = client.UpdateCallStatus(withToken("impersonation-token"), &pb.UpdateCallStatusRequest{Id: created.Id, Status: "closed"})
+	require.NoError(t, err)
+
+	activity, _, err := callService.GetCallActivity(context.Background(), uuid.MustParse(created.Id), userID, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, activity, 1)
+	assert.Equal(t, userID, activity[0].ActorID)
+	assert.Equal(t, &adminID, activity[0].ImpersonatedBy)
+}
+
+// TestAuthInterceptor_Organizations проверяет, что при включенных организациях заявка,
+// созданная по gRPC, относится к организации пользователя и доступна другим ее участникам,
+// как и в HTTP API, а без организаций видна только владельцу.
+
+func TestAuthInterceptor_Organizations(t *testing.T) {
+	ctrl := newController(t)
+	mockAuth := mocks.NewMockAuthClient(ctrl)
+	callService := service.NewCallService(repository.NewInMemoryCallRepository())
+	orgClient := startServerWithConfig(t, callService, mockAuth, AuthConfig{Organizations: true})
+	plainClient := startServer(t, callService, mockAuth)
+	ownerID, memberID, orgID := uuid.New(), uuid.New(), uuid.New()
+
+	mockAuth.EXPECT().ValidateTokenFull(gomock.Any(), "owner-token").Return(&authclient.TokenInfo{
+		Valid: true, UserID: ownerID.String(), Role: "user", OrgID: orgID.String(), OrgRole: tenant.RoleOwner,
+	}, nil).AnyTimes()
+	mockAuth.EXPECT().ValidateTokenFull(gomock.Any(), "member-token").Return(&authclient.TokenInfo{
+		Valid: true, UserID: memberID.String(), Role: "user", OrgID: orgID.String(), OrgRole: tenant.RoleMember,
+	}, nil).AnyTimes()
+
+	created, err := orgClient.CreateCall(withToken("member-token"), &pb.CreateCallRequest{
+		ClientName: "Иван", PhoneNumber: "+79990000001", Description: "Звонок",
+	})
+	require.NoError(t, err)
+	call, err := callService.GetCallByIDAdmin(context.Background(), uuid.MustParse(created.Id))
+	require.NoError(t, err)
+	require.NotNil(t, call.OrgID)
+	assert.Equal(t, orgID, *call.OrgID)
+
+	got, err := orgClient.GetCall(withToken("owner-token"), &pb.GetCallRequest{Id: created.Id})
+	require.NoError(t, err)
+	assert.Equal(t, created.Id, got.Id)
+
+	_, err = plainClient.GetCall(withToken("owner-token"), &pb.GetCallRequest{Id: created.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+// TestAuthInterceptor_LenientScheme проверяет, что метаданные authorization разбираются
+// так же, как заголовок Authorization в HTTP API: схема без учета регистра, лишние пробелы
+// вокруг схемы и токена не учитываются.
+
+func TestAuthInterceptor_LenientScheme(t *testing.T) {
+	ctrl := newController(t)
+	mockCallService := mocks.NewMockCallService(ctrl)
+	mockAuth := mocks.NewMockAuthClient(ctrl)
+	client := startServer(t, mockCallService, mockAuth)
+	testUserID := uuid.New()
+
+	mockAuth.EXPECT().ValidateTokenFull(gomock.Any(), "test-token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: testUserID.String(), Role: "user"}, nil).Times(2)
+	mockCallService.EXPECT().GetCallByID(gomock.Any(), gomock.Any(), testUserID).Return(nil, service.ErrCallNotFound).Times(2)
+
+	for _, value := range []string{"bearer test-token", "  BEARER   test-token  "} {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", value)
+		_, err := client.GetCall(ctx, &pb.GetCallRequest{Id: uuid.New().String()})
+		assert.Equal(t, codes.NotFound, status.Code(err), value)
+	}
+}
+
+// TestCallServer_ErrorMapping проверяет перевод ошибок сервисного слоя в gRPC-коды.
+
+func TestCallServer_ErrorMapping(t *testing.T) {
+	ctrl := newController(t)
+	mockCallService := mocks.NewMockCallService(ctrl)
+	mockAuth := mocks.NewMockAuthClient(ctrl)
+	client := startServer(t, mockCallService, mockAuth)
+	testUserID := uuid.New()
+
+	mockAuth.EXPECT().ValidateTokenFull(gomock.Any(), "test-token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: testUserID.String()}, nil).AnyTimes()
+
+	cases := map[error]codes.Code{
+		service.ErrCallNotFound: codes.NotFound,
+		service.ErrForbidden:    codes.PermissionDenied,
+		errors.New("db down"):   codes.Internal,
+		fmt.Errorf("load call: %w", &service.NotFoundError{Resource: "call", ID: uuid.New()}): codes.NotFound,
+		fmt.Errorf("get call: %w", repository.ErrTimeout):                                     codes.DeadlineExceeded,
+	}
+	for serviceErr, want := range cases {
+		callID := uuid.New()
+		mockCallService.EXPECT().GetCallByID(gomock.Any(), callID, testUserID).Return(nil, serviceErr)
+
+		_, err := client.GetCall(withToken("test-token"), &pb.GetCallRequest{Id: callID.String()})
+		assert.Equal(t, want, status.Code(err), serviceErr.Error())
+	}
+
+	_, err := client.GetCall(withToken("test-token"), &pb.GetCallRequest{Id: "not-a-uuid"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// TestCallServer_ListCalls проверяет передачу пагинации и сортировки в фильтр сервиса.
+
+func TestCallServer_ListCalls(t *testing.T) {
+	ctrl := newController(t)
+	mockCallService := mocks.NewMockCallService(ctrl)
+	mockAuth := mocks.NewMockAuthClient(ctrl)
+	client := startServer(t, mockCallService, mockAuth)
+	testUserID := uuid.New()
+
+	mockAuth.EXPECT().ValidateTokenFull(gomock.Any(), "test-token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: testUserID.String()}, nil).AnyTimes()
+	mockCallService.EXPECT().GetAllCalls(gomock.Any(), testUserID, gomock.Cond(func(f model.CallFilter) bool {
+		return f.Status == "open" && f.SortBy == model.SortByClientName && !f.SortDesc &&
+			f.Limit == 2 && f.Offset == 4
+	})).Return([]*model.Call{{ID: uuid.New(), UserID: testUserID}}, 5, nil)
+
+	resp, err := client.ListCalls(withToken("test-token"), &pb.ListCallsRequest{
+		Status:    "open",
+		Sort:      model.SortByClientName,
+		Ascending: true,
+		Limit:     2,
+		Offset:    4,
+	})
+
+	require.NoError(t, err)
+	assert.Len(t, resp.Calls, 1)
+	assert.EqualValues(t, 5, resp.Total)
+
+	_, err = client.ListCalls(withToken("test-token"), &pb.ListCallsRequest{Limit: MaxListLimit + 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// TestCallServer_StreamCalls проверяет передачу фильтра и размера пакета в сервис, получение
+// всех заявок через итератор на границах пакетов, проверку размера пакета и аутентификацию
+// потокового вызова.
+
+func TestCallServer_StreamCalls(t *testing.T) {
+	ctrl := newController(t)
+	mockCallService := mocks.NewMockCallService(ctrl)
+	mockAuth := mocks.NewMockAuthClient(ctrl)
+	client := startServer(t, mockCallService, mockAuth)
+	testUserID := uuid.New()
+
+	mockAuth.EXPECT().ValidateTokenFull(gomock.Any(), "test-token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: testUserID.String()}, nil).AnyTimes()
+	mockAuth.EXPECT().ValidateTokenFull(gomock.Any(), "invalid-token").Return(&authclient.TokenInfo{Valid: false}, nil)
+
+	var ids []uuid.UUID
+	for i := 0; i < 5; i++ {
+		ids = append(ids, uuid.New())
+	}
+	mockCallService.EXPECT().StreamCalls(gomock.Any(), testUserID, gomock.Cond(func(f model.CallFilter) bool {
+		return f.Status == "open" && f.SortBy == model.SortByClientName && !f.SortDesc
+	}), 2, gomock.Any()).DoAndReturn(func(_ context.Context, _ uuid.UUID, _ model.CallFilter, batchSize int, fn func([]*model.Call) error) error {
+		for start := 0; start < len(ids); start += batchSize {
+			var batch []*model.Call
+			for _, id := range ids[start:min(start+batchSize, len(ids))] {
+				batch = append(batch, &model.Call{ID: id, UserID: testUserID})
+			}
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	it := callclient.StreamCalls(withToken("test-token"), client, &pb.StreamCallsRequest{
+		Status:    "open",
+		Sort:      model.SortByClientName,
+		Ascending: true,
+		BatchSize: 2,
+	})
+	defer it.Close()
+	var got []uuid.UUID
+	for {
+		call, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		got = append(got, uuid.MustParse(call.Id))
+	}
+	assert.Equal(t, ids, got)
+
+	_, err := callclient.StreamCalls(withToken("test-token"), client, &pb.StreamCallsRequest{BatchSize: MaxListLimit + 1}).Next()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = callclient.StreamCalls(withToken("invalid-token"), client, &pb.StreamCallsRequest{}).Next()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+// TestCallServer_StreamCalls_Cancel проверяет, что закрытие итератора клиентом до конца потока
+// прекращает выгрузку на сервере: fn возвращает ошибку, и сервис перестает читать пакеты.
+
+func TestCallServer_StreamCalls_Cancel(t *testing.T) {
+	ctrl := newController(t)
+	mockCallService := mocks.NewMockCallService(ctrl)
+	mockAuth := mocks.NewMockAuthClient(ctrl)
+	client := startServer(t, mockCallService, mockAuth)
+	testUserID := uuid.New()
+
+	mockAuth.EXPECT().ValidateTokenFull(gomock.Any(), "test-token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: testUserID.String()}, nil)
+	done := make(chan error, 1)
+	mockCallService.EXPECT().StreamCalls(gomock.Any(), testUserID, gomock.Any(), 0, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, _ model.CallFilter, _ int, fn func([]*model.Call) error) error {
+			// Пакеты отправляются без конца, пока fn не вернет ошибку
+			for {
+				if err := fn([]*model.Call{{ID: uuid.New(), UserID: testUserID}}); err != nil {
+					done <- err
+					return err
+				}
+			}
+		})
+
+	it := callclient.StreamCalls(withToken("test-token"), client, &pb.StreamCallsRequest{})
+	for i := 0; i < 3; i++ {
+		_, err := it.Next()
+		require.NoError(t, err)
+	}
+	require.NoError(t, it.Close())
+
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server kept streaming after the client closed the iterator")
+	}
+	_, err := it.Next()
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/internal/model"
+	"call-service/internal/service"
+)
+
+// DefaultAdminPageLimit — размер страницы списка заявок администратора по умолчанию.
+const DefaultAdminPageLimit = 50
+
+// AdminHandler обрабатывает HTTP запросы администраторов к заявкам всех пользователей.
+// Доступ к маршрутам ограничивается middleware RequireRole.
+type AdminHandler struct {
+	callService service.CallService
+}
+
+// NewAdminHandler создает новый экземпляр AdminHandler.
+func NewAdminHandler(callService service.CallService) *AdminHandler {
+	return &AdminHandler{callService: callService}
+}
+
+// ListCalls обрабатывает GET запрос на получение заявок всех пользователей.
+// Помимо общих параметров фильтрации поддерживает отбор по user_id.
+func (h *AdminHandler) ListCalls(c *gin.Context) {
+	filter, err := parseCallFilter(c, DefaultAdminPageLimit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	if filter.UserID, err = parseUserIDQuery(c); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	calls, total, err := h.callService.ListCallsAdmin(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidStatus) {
+			c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidStatus))
+			return
+		}
+		writeServerError(c, err, i18n.GetCallsFailed)
+		return
+	}
+
+	writeCallList(c, calls, total, filter)
+}
+
+// GetCall обрабатывает GET запрос на получение любой заявки по её ID.
+func (h *AdminHandler) GetCall(c *gin.Context) {
+	id := middleware.GetUUIDParam(c, "id")
+
+	call, err := h.callService.GetCallByIDAdmin(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrCallNotFound) {
+			c.JSON(http.StatusNotFound, i18n.Response(c, i18n.CallNotFound))
+			return
+		}
+		writeServerError(c, err, i18n.GetCallFailed)
+		return
+	}
+
+	writeCall(c, http.StatusOK, call)
+}
+
+// UpdateCallStatus обрабатывает PATCH запрос на принудительное обновление статуса заявки.
+func (h *AdminHandler) UpdateCallStatus(c *gin.Context) {
+	id := middleware.GetUUIDParam(c, "id")
+
+	var req model.UpdateCallStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	adminID, _ := middleware.GetUserID(c)
+	err := h.callService.UpdateCallStatusAdmin(c.Request.Context(), id, model.ParseCallStatus(req.Status), adminID)
+	if err != nil {
+		if errors.Is(err, service.ErrCallNotFound) {
+			c.JSON(http.StatusNotFound, i18n.Response(c, i18n.CallNotFound))
+			return
+		}
+		if errors.Is(err, service.ErrInvalidStatus) {
+			c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidStatus))
+			return
+		}
+		writeServerError(c, err, i18n.UpdateCallStatusFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "status updated successfully"})
+}
+
+// ReassignCall обрабатывает PATCH запрос на передачу заявки другому пользователю.
+func (h *AdminHandler) ReassignCall(c *gin.Context) {
+	id := middleware.GetUUIDParam(c, "id")
+
+	var req model.ReassignCallRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	adminID, _ := middleware.GetUserID(c)
+	err := h.callService.ReassignCall(c.Request.Context(), id, req.UserID, adminID)
+	if err != nil {
+		if errors.Is(err, service.ErrCallNotFound) {
+			c.JSON(http.StatusNotFound, i18n.Response(c, i18n.CallNotFound))
+			return
+		}
+		writeServerError(c, err, i18n.ReassignCallFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "call reassigned successfully"})
+}
@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/model"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+)
+
+const (
+	adminToken = "admin-token"
+	userToken  = "user-token"
+)
+
+// adminUserID — ID администратора, которому принадлежит adminToken.
+var adminUserID = uuid.MustParse("9b2f6c1e-3a4d-4e5f-8a6b-7c8d9e0f1a2b")
+
+// setupAdminRouter настраивает маршрутизатор со всеми маршрутами API и mock-сервисами.
+// Токен adminToken принадлежит администратору, userToken — обычному пользователю.
+
+func setupAdminRouter(callService service.CallService, authClient *mocks.MockAuthClient) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, Routes{
+		Auth:           NewAuthHandler(authClient),
+		Calls:          NewCallHandler(callService, authClient),
+		Admin:          NewAdminHandler(callService),
+		Impersonation:  NewImpersonationHandler(authClient),
+		Users:          NewAdminUsersHandler(authClient),
+		Profile:        NewProfileHandler(authClient),
+		APIKeys:        NewAPIKeyHandler(nil),
+		Views:          NewSavedViewHandler(nil),
+		UserData:       NewUserDataHandler(callService, authClient, nil),
+		Docs:           NewDocsHandler(nil),
+		AuthMiddleware: middleware.NewAuthMiddleware(authClient),
+	})
+
+	authClient.EXPECT().ValidateTokenFull(gomock.Any(), adminToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: adminUserID.String(), Role: middleware.RoleAdmin}, nil).AnyTimes()
+	authClient.EXPECT().ValidateTokenFull(gomock.Any(), userToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: uuid.New().String(), Role: middleware.RoleUser}, nil).AnyTimes()
+	return router
+}
+
+// TestAdminRoutes_ForbiddenForUsers проверяет, что обычный пользователь получает 403
+// на всех маршрутах администратора, а сервис при этом не вызывается.
+
+func TestAdminRoutes_ForbiddenForUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCallService := mocks.NewMockCallService(ctrl)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	router := setupAdminRouter(mockCallService, mockAuthClient)
+	callID := uuid.New().String()
+
+	requests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"GET", "/admin/calls", ""},
+		{"GET", "/admin/calls/" + callID, ""},
+		{"PATCH", "/admin/calls/" + callID + "/status", `{"status": "closed"}`},
+		{"PATCH", "/admin/calls/" + callID + "/assignee", `{"user_id": "` + uuid.New().String() + `"}`},
+		{"GET", "/admin/users", ""},
+		{"GET", "/admin/users/export", ""},
+		{"GET", "/admin/users/" + uuid.New().String() + "/export", ""},
+		{"POST", "/admin/users/" + uuid.New().String() + "/impersonate", ""},
+	}
+
+	for _, r := range requests {
+		req, _ := http.NewRequest(r.method, r.path, bytes.NewBufferString(r.body))
+		req.Header.Set("Authorization", "Bearer "+userToken)
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code, "%s %s", r.method, r.path)
+	}
+}
+
+// TestAdminListCalls проверяет получение заявок всех пользователей администратором
+// с передачей фильтра, сортировки и пагинации в сервис.
+
+func TestAdminListCalls(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCallService := mocks.NewMockCallService(ctrl)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	router := setupAdminRouter(mockCallService, mockAuthClient)
+	ownerID := uuid.New()
+
+	testCalls := []*model.Call{
+		{ID: uuid.New(), ClientName: "Client 1", Status: "closed", UserID: ownerID},
+	}
+	mockCallService.EXPECT().ListCallsAdmin(gomock.Any(), gomock.Cond(func(f model.CallFilter) bool {
+		return f.UserID != nil && *f.UserID == ownerID &&
+			f.Status == "closed" &&
+			f.SortBy == model.SortByClientName && !f.SortDesc &&
+			f.Limit == 10 && f.Offset == 20
+	})).Return(testCalls, 21, nil)
+
+	req, _ := http.NewRequest("GET", "/admin/calls?user_id="+ownerID.String()+
+		"&status=closed&sort=client_name&order=asc&limit=10&offset=20", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	printRequestResponse(t, req, w)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "21", w.Header().Get(TotalCountHeader))
+	var response []*model.Call
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response, 1)
+}
+
+// TestAdminListCalls_InvalidQuery проверяет отклонение некорректных параметров списка.
+
+func TestAdminListCalls_InvalidQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCallService := mocks.NewMockCallService(ctrl)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	router := setupAdminRouter(mockCallService, mockAuthClient)
+
+	for _, query := range []string{"limit=0", "limit=501", "offset=-1", "sort=password", "order=up", "user_id=bad", "created_from=yesterday"} {
+		req, _ := http.NewRequest("GET", "/admin/calls?"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+// TestAdminGetCall проверяет получение чужой заявки администратором без проверки владельца.
+
+func TestAdminGetCall(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCallService := mocks.NewMockCallService(ctrl)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	router := setupAdminRouter(mockCallService, mockAuthClient)
+	testCall := &model.Call{ID: uuid.New(), ClientName: "Client", Status: "open", UserID: uuid.New()}
+
+	mockCallService.EXPECT().GetCallByIDAdmin(gomock.Any(), testCall.ID).Return(testCall, nil)
+
+	req, _ := http.NewRequest("GET", "/admin/calls/"+testCall.ID.String(), nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response model.Call
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, testCall.UserID, response.UserID)
+}
+
+// TestAdminUpdateCallStatus проверяет принудительное обновление статуса администратором.
+
+func TestAdminUpdateCallStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCallService := mocks.NewMockCallService(ctrl)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	router := setupAdminRouter(mockCallService, mockAuthClient)
+	callID := uuid.New()
+
+	mockCallService.EXPECT().UpdateCallStatusAdmin(gomock.Any(), callID, model.CallStatusClosed, adminUserID).Return(nil)
+
+	req, _ := http.NewRequest("PATCH", "/admin/calls/"+callID.String()+"/status", bytes.NewBufferString(`{"status": "closed"}`))
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestAdminReassignCall проверяет передачу заявки другому пользователю и обработку 404.
+
+func TestAdminReassignCall(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCallService := mocks.NewMockCallService(ctrl)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	router := setupAdminRouter(mockCallService, mockAuthClient)
+	callID := uuid.New()
+	missingID := uuid.New()
+	newOwner := uuid.New()
+
+	mockCallService.EXPECT().ReassignCall(gomock.Any(), callID, newOwner, adminUserID).Return(nil)
+	mockCallService.EXPECT().ReassignCall(gomock.Any(), missingID, newOwner, adminUserID).Return(service.ErrCallNotFound)
+
+	body := `{"user_id": "` + newOwner.String() + `"}`
+	for id, want := range map[uuid.UUID]int{callID: http.StatusOK, missingID: http.StatusNotFound} {
+		req, _ := http.NewRequest("PATCH", "/admin/calls/"+id.String()+"/assignee", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, want, w.Code)
+	}
+}
+
+// TestRoutes_InvalidUUIDParams проверяет, что маршруты с ID заявки или вложения в пути
+// отвечают 400 с кодом ошибки параметра на любой не канонический UUID, не вызывая сервис.
+
+func TestRoutes_InvalidUUIDParams(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCallService := mocks.NewMockCallService(ctrl)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	router := setupAdminRouter(mockCallService, mockAuthClient)
+	callID := uuid.New().String()
+
+	requests := []struct {
+		method string
+		path   string
+		code   string
+	}{
+		{"GET", "/calls/42", "invalid_call_id"},
+		{"PATCH", "/calls/%20" + callID + "/status", "invalid_call_id"},
+		{"PATCH", "/calls/not-a-uuid/callback", "invalid_call_id"},
+		{"POST", "/calls/42/dial", "invalid_call_id"},
+		{"POST", "/calls/42/attachments", "invalid_call_id"},
+		{"GET", "/calls/42/attachments/" + uuid.New().String(), "invalid_call_id"},
+		{"GET", "/calls/" + callID + "/attachments/42", "invalid_attachment_id"},
+		{"DELETE", "/calls/42", "invalid_call_id"},
+		{"GET", "/admin/calls/42", "invalid_call_id"},
+		{"PATCH", "/admin/calls/42/status", "invalid_call_id"},
+		{"PATCH", "/admin/calls/42/assignee", "invalid_call_id"},
+	}
+
+	for _, r := range requests {
+		req, _ := http.NewRequest(r.method, r.path, nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, r.method+" "+r.path)
+		var response map[string]string
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, r.code, response["code"], r.method+" "+r.path)
+	}
+}
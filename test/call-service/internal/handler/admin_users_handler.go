@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"encoding/csv"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/i18n"
+	"call-service/pkg/authclient"
+)
+
+// DefaultAdminUsersLimit — размер страницы списка пользователей по умолчанию.
+const DefaultAdminUsersLimit = 50
+
+// usersExportHeader — заголовок CSV-файла выгрузки пользователей.
+var usersExportHeader = []string{"user_id", "username", "role", "email", "email_verified", "created_at"}
+
+// AdminUserResponse — пользователь в списке администратора.
+type AdminUserResponse struct {
+	UserID        string    `json:"user_id"`
+	Username      string    `json:"username"`
+	Role          string    `json:"role"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AdminUsersHandler обрабатывает HTTP запросы администраторов к списку пользователей.
+// Пользователи хранятся в сервисе аутентификации; доступ к маршрутам ограничивается
+// middleware RequireRole.
+type AdminUsersHandler struct {
+	authClient authclient.AuthClient
+}
+
+// NewAdminUsersHandler создает новый экземпляр AdminUsersHandler.
+func NewAdminUsersHandler(authClient authclient.AuthClient) *AdminUsersHandler {
+	return &AdminUsersHandler{authClient: authClient}
+}
+
+// ListUsers обрабатывает GET запрос на получение страницы пользователей. Общее количество
+// пользователей, удовлетворяющих фильтру, передается в заголовке X-Total-Count.
+// Параметры фильтрации описаны в parseUserFilter; limit — от 1 до authclient.MaxListUsersLimit.
+func (h *AdminUsersHandler) ListUsers(c *gin.Context) {
+	filter, err := parseUserFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+	limit, offset, err := parseUserPage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	users, total, err := h.authClient.ListUsers(c.Request.Context(), filter, limit, offset)
+	if err != nil {
+		writeAuthError(c, err, i18n.ListUsersFailed)
+		return
+	}
+
+	resp := make([]AdminUserResponse, len(users))
+	for i, user := range users {
+		resp[i] = AdminUserResponse{
+			UserID:        user.UserID,
+			Username:      user.Username,
+			Role:          user.Role,
+			Email:         user.Email,
+			EmailVerified: user.EmailVerified,
+			CreatedAt:     user.CreatedAt,
+		}
+	}
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, resp)
+}
+
+// ExportUsers обрабатывает GET запрос на выгрузку пользователей в формате CSV. Принимает
+// те же параметры фильтрации, что и список пользователей, и выгружает всех подходящих
+// пользователей: сервис аутентификации отправляет их потоком (StreamUsers), а строки
+// отправляются клиенту по мере получения. Следующий пакет пользователей запрашивается только
+// после записи предыдущего, поэтому медленный клиент замедляет выгрузку, не накапливая
+// пакеты в памяти, а отключение клиента прекращает ее. Пустой результат — файл из одной
+// строки заголовка.
+func (h *AdminUsersHandler) ExportUsers(c *gin.Context) {
+	filter, err := parseUserFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	it := h.authClient.StreamUsers(c.Request.Context(), filter, 0)
+	defer it.Close()
+
+	// Заголовки ответа отправляются только вместе с первой строкой, чтобы ошибки,
+	// возникшие до начала выгрузки, можно было вернуть обычным JSON-ответом
+	w := csv.NewWriter(c.Writer)
+	rows := 0
+	for {
+		user, nextErr := it.Next()
+		if nextErr != nil {
+			if nextErr != io.EOF {
+				err = nextErr
+			}
+			break
+		}
+		if rows == 0 {
+			writeUsersExportHeader(c, w)
+		}
+		rows++
+		if err = w.Write(userRecord(*user)); err != nil {
+			break
+		}
+		if rows%exportFlushRows == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+		if err = w.Error(); err != nil {
+			break
+		}
+	}
+
+	if err != nil && rows == 0 {
+		writeAuthError(c, err, i18n.ExportUsersFailed)
+		return
+	}
+	if err != nil {
+		// Часть файла уже отправлена, поэтому статус ответа изменить нельзя
+		log.Printf("users export interrupted after %d rows: %v", rows, err)
+		c.Abort()
+		return
+	}
+
+	if rows == 0 {
+		writeUsersExportHeader(c, w)
+	}
+	w.Flush()
+}
+
+// parseUserFilter разбирает параметры строки запроса в условия отбора пользователей.
+//
+// Поддерживаемые параметры: created_from и created_to (RFC3339), username_prefix,
+// sort (created_at, username) и order (asc, desc; по умолчанию desc).
+func parseUserFilter(c *gin.Context) (authclient.UserFilter, error) {
+	filter := authclient.UserFilter{UsernamePrefix: c.Query("username_prefix")}
+
+	from, err := parseTimeQuery(c, "created_from")
+	if err != nil {
+		return filter, err
+	}
+	if from != nil {
+		filter.CreatedFrom = *from
+	}
+	to, err := parseTimeQuery(c, "created_to")
+	if err != nil {
+		return filter, err
+	}
+	if to != nil {
+		filter.CreatedTo = *to
+	}
+
+	switch sortBy := c.Query("sort"); sortBy {
+	case "", authclient.UserSortByCreatedAt, authclient.UserSortByUsername:
+		filter.SortBy = sortBy
+	default:
+		return filter, i18n.New(i18n.InvalidSortField)
+	}
+
+	switch c.Query("order") {
+	case "", "desc":
+	case "asc":
+		filter.Ascending = true
+	default:
+		return filter, i18n.New(i18n.InvalidSortOrder)
+	}
+	return filter, nil
+}
+
+// parseUserPage разбирает параметры limit и offset списка пользователей.
+func parseUserPage(c *gin.Context) (limit, offset int, err error) {
+	limit = DefaultAdminUsersLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > authclient.MaxListUsersLimit {
+			return 0, 0, i18n.New(i18n.InvalidLimit, authclient.MaxListUsersLimit)
+		}
+	}
+	if value := c.Query("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return 0, 0, i18n.New(i18n.InvalidOffset)
+		}
+	}
+	return limit, offset, nil
+}
+
+// writeUsersExportHeader устанавливает заголовки ответа и записывает строку заголовка CSV.
+func writeUsersExportHeader(c *gin.Context, w *csv.Writer) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="users.csv"`)
+	c.Status(http.StatusOK)
+	_ = w.Write(usersExportHeader)
+}
+
+// userRecord преобразует пользователя в строку CSV в порядке колонок usersExportHeader.
+func userRecord(user authclient.UserInfo) []string {
+	return []string{
+		user.UserID,
+		user.Username,
+		user.Role,
+		user.Email,
+		strconv.FormatBool(user.EmailVerified),
+		user.CreatedAt.Format(time.RFC3339),
+	}
+}
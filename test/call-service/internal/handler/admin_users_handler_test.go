@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"call-service/internal/i18n"
+	"call-service/internal/mocks"
+	"call-service/pkg/authclient"
+)
+
+// testUsers — пользователи, которых возвращает mock-клиент аутентификации.
+var testUsers = []authclient.UserInfo{
+	{UserID: "5f0c3e2a-1b4d-4c6e-8f7a-9b0c1d2e3f4a", Username: "alice", Role: "admin", Email: "alice@example.com", EmailVerified: true, CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+	{UserID: "6a1d4f3b-2c5e-4d7f-9a8b-0c1d2e3f4a5b", Username: "alex", Role: "user", CreatedAt: time.Date(2026, 2, 3, 4, 5, 6, 0, time.UTC)},
+}
+
+// TestAdminListUsers проверяет передачу фильтра и пагинации в сервис аутентификации
+// для разных сочетаний параметров, общее количество в заголовке и пустой список.
+
+func TestAdminListUsers(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		query  string
+		filter authclient.UserFilter
+		limit  int
+		offset int
+		users  []authclient.UserInfo
+		total  int
+	}{
+		{"без параметров", "", authclient.UserFilter{}, DefaultAdminUsersLimit, 0, testUsers, 2},
+		{"префикс имени и сортировка по имени", "?username_prefix=al&sort=username&order=asc",
+			authclient.UserFilter{UsernamePrefix: "al", SortBy: authclient.UserSortByUsername, Ascending: true}, DefaultAdminUsersLimit, 0, testUsers, 2},
+		{"период регистрации и страница", "?created_from=2026-01-01T00:00:00Z&created_to=2026-03-01T00:00:00Z&limit=1&offset=1",
+			authclient.UserFilter{CreatedFrom: from, CreatedTo: to}, 1, 1, testUsers[1:], 2},
+		{"все условия", "?created_from=2026-01-01T00:00:00Z&username_prefix=al&sort=created_at&order=desc&limit=500",
+			authclient.UserFilter{CreatedFrom: from, UsernamePrefix: "al", SortBy: authclient.UserSortByCreatedAt}, 500, 0, testUsers, 2},
+		{"пустой результат", "?username_prefix=zed", authclient.UserFilter{UsernamePrefix: "zed"}, DefaultAdminUsersLimit, 0, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockAuthClient := mocks.NewMockAuthClient(ctrl)
+			router := setupAdminRouter(nil, mockAuthClient)
+			mockAuthClient.EXPECT().ListUsers(gomock.Any(), tt.filter, tt.limit, tt.offset).Return(tt.users, tt.total, nil)
+
+			w := doInMemoryRequest(t, router, "GET", "/admin/users"+tt.query, adminToken, "")
+
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			assert.Equal(t, strconv.Itoa(tt.total), w.Header().Get(TotalCountHeader))
+			var resp []AdminUserResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			require.Len(t, resp, len(tt.users))
+			for i, user := range tt.users {
+				assert.Equal(t, user.Username, resp[i].Username)
+				assert.True(t, user.CreatedAt.Equal(resp[i].CreatedAt))
+			}
+			assert.NotContains(t, w.Body.String(), "null")
+		})
+	}
+}
+
+// TestAdminListUsers_Errors проверяет отклонение неверных параметров без вызова сервиса
+// аутентификации и ответы на его ошибки.
+
+func TestAdminListUsers_Errors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	router := setupAdminRouter(nil, mockAuthClient)
+
+	for query, code := range map[string]i18n.Code{
+		"?sort=email":             i18n.InvalidSortField,
+		"?order=up":               i18n.InvalidSortOrder,
+		"?limit=0":                i18n.InvalidLimit,
+		"?limit=501":              i18n.InvalidLimit,
+		"?offset=-1":              i18n.InvalidOffset,
+		"?created_from=yesterday": i18n.InvalidTime,
+	} {
+		w := doInMemoryRequest(t, router, "GET", "/admin/users"+query, adminToken, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		assert.Contains(t, w.Body.String(), string(code), query)
+	}
+
+	mockAuthClient.EXPECT().ListUsers(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, 0, authclient.FromStatus(status.Error(codes.InvalidArgument, "invalid user filter: created_from must be before created_to")))
+	w := doInMemoryRequest(t, router, "GET", "/admin/users?created_from=2026-03-01T00:00:00Z&created_to=2026-01-01T00:00:00Z", adminToken, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockAuthClient.EXPECT().ListUsers(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, 0, authclient.FromStatus(status.Error(codes.Unavailable, "connection refused")))
+	w = doInMemoryRequest(t, router, "GET", "/admin/users", adminToken, "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	mockAuthClient.EXPECT().ListUsers(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, 0, status.Error(codes.Internal, "failed to list users"))
+	w = doInMemoryRequest(t, router, "GET", "/admin/users", adminToken, "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), string(i18n.ListUsersFailed))
+}
+
+// streamUsers возвращает итератор, отдающий users пакетами по два, а затем ошибку err
+// или io.EOF, если err равна nil.
+func streamUsers(users []authclient.UserInfo, err error) *authclient.UserIterator {
+	return authclient.NewUserIterator(func() ([]authclient.UserInfo, error) {
+		if len(users) == 0 {
+			if err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		batch := users[:min(2, len(users))]
+		users = users[len(batch):]
+		return batch, nil
+	}, func() {})
+}
+
+// TestAdminExportUsers проверяет выгрузку пользователей в CSV с фильтром из параметров запроса
+// и выгрузку пустого результата — файла из одной строки заголовка.
+
+func TestAdminExportUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	router := setupAdminRouter(nil, mockAuthClient)
+
+	mockAuthClient.EXPECT().StreamUsers(gomock.Any(),
+		authclient.UserFilter{UsernamePrefix: "al", SortBy: authclient.UserSortByUsername, Ascending: true, CreatedTo: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		0).Return(streamUsers(testUsers, nil))
+
+	w := doInMemoryRequest(t, router, "GET", "/admin/users/export?username_prefix=al&sort=username&order=asc&created_to=2026-03-01T00:00:00Z", adminToken, "")
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "users.csv")
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		usersExportHeader,
+		{testUsers[0].UserID, "alice", "admin", "alice@example.com", "true", "2026-01-02T03:04:05Z"},
+		{testUsers[1].UserID, "alex", "user", "", "false", "2026-02-03T04:05:06Z"},
+	}, records)
+
+	// Пустой результат
+	mockAuthClient.EXPECT().StreamUsers(gomock.Any(), authclient.UserFilter{UsernamePrefix: "zed"}, 0).
+		Return(streamUsers(nil, nil))
+	w = doInMemoryRequest(t, router, "GET", "/admin/users/export?username_prefix=zed", adminToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, strings.Join(usersExportHeader, ",")+"\n", w.Body.String())
+}
+
+// TestAdminExportUsers_Errors проверяет, что ошибки до первой строки возвращаются
+// JSON-ответом, а неверные параметры отклоняются без вызова сервиса аутентификации.
+
+func TestAdminExportUsers_Errors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	router := setupAdminRouter(nil, mockAuthClient)
+
+	w := doInMemoryRequest(t, router, "GET", "/admin/users/export?sort=role", adminToken, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), string(i18n.InvalidSortField))
+
+	mockAuthClient.EXPECT().StreamUsers(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(streamUsers(nil, authclient.FromStatus(status.Error(codes.Unavailable, "connection refused"))))
+	w = doInMemoryRequest(t, router, "GET", "/admin/users/export", adminToken, "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+
+	// Ошибка после первых строк обрывает уже начатый файл, а поток закрывается
+	sent, closed := false, false
+	mockAuthClient.EXPECT().StreamUsers(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(authclient.NewUserIterator(func() ([]authclient.UserInfo, error) {
+			if sent {
+				return nil, status.Error(codes.Internal, "failed to stream users")
+			}
+			sent = true
+			return testUsers, nil
+		}, func() { closed = true }))
+	w = doInMemoryRequest(t, router, "GET", "/admin/users/export", adminToken, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.True(t, closed)
+}
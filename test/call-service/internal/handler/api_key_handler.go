@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/internal/model"
+	"call-service/internal/service"
+)
+
+// APIKeyHandler обрабатывает HTTP запросы текущего пользователя к его API-ключам.
+type APIKeyHandler struct {
+	apiKeys service.APIKeyService
+}
+
+// NewAPIKeyHandler создает новый экземпляр APIKeyHandler.
+func NewAPIKeyHandler(apiKeys service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeys: apiKeys}
+}
+
+// CreateAPIKeyRequest содержит название нового ключа и необязательный срок его действия.
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyResponse описывает API-ключ без самого ключа. Prefix — открытая часть ключа,
+// по которой пользователь может отличить свои ключи.
+type APIKeyResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Revoked    bool       `json:"revoked"`
+}
+
+// CreateAPIKeyResponse возвращает созданный ключ. Key показывается только в этом ответе.
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// CreateAPIKey обрабатывает POST запрос на создание API-ключа текущего пользователя.
+// Запрос, аутентифицированный API-ключом, не может создавать новые ключи, чтобы утекший ключ
+// нельзя было продлить, выпустив замену. Администратор, работающий от имени пользователя,
+// тоже не может создать ключ: ключ действовал бы дольше токена.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	principal, _ := middleware.GetPrincipal(c)
+	if principal.AuthMethod == middleware.AuthMethodAPIKey {
+		c.JSON(http.StatusForbidden, i18n.Response(c, i18n.APIKeyCreationForbidden))
+		return
+	}
+	if rejectImpersonation(c) {
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+	var expiresAt time.Time
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+
+	key, plain, err := h.apiKeys.CreateAPIKey(c.Request.Context(), principal.UserID, req.Name, expiresAt)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAPIKeyName) {
+			c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidAPIKeyName))
+			return
+		}
+		if errors.Is(err, service.ErrInvalidAPIKeyExpiry) {
+			c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidAPIKeyExpiry))
+			return
+		}
+		writeServerError(c, err, i18n.CreateAPIKeyFailed)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, key.ID.String())
+	c.JSON(http.StatusCreated, CreateAPIKeyResponse{APIKeyResponse: newAPIKeyResponse(key), Key: plain})
+}
+
+// ListAPIKeys обрабатывает GET запрос на получение API-ключей текущего пользователя,
+// включая отозванные и истекшие.
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	keys, err := h.apiKeys.ListAPIKeys(c.Request.Context(), userID)
+	if err != nil {
+		writeServerError(c, err, i18n.ListAPIKeysFailed)
+		return
+	}
+
+	response := make([]APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, newAPIKeyResponse(key))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeAPIKey обрабатывает DELETE запрос на отзыв API-ключа текущего пользователя.
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	id := middleware.GetUUIDParam(c, "id")
+
+	if err := h.apiKeys.RevokeAPIKey(c.Request.Context(), userID, id); err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, i18n.Response(c, i18n.APIKeyNotFound))
+			return
+		}
+		writeServerError(c, err, i18n.RevokeAPIKeyFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
+
+// newAPIKeyResponse преобразует ключ в ответ; нулевые даты передаются как null.
+func newAPIKeyResponse(key *model.APIKey) APIKeyResponse {
+	response := APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    service.APIKeyPrefix + key.Prefix,
+		CreatedAt: key.CreatedAt,
+		Revoked:   key.Revoked,
+	}
+	if !key.ExpiresAt.IsZero() {
+		response.ExpiresAt = &key.ExpiresAt
+	}
+	if !key.LastUsedAt.IsZero() {
+		response.LastUsedAt = &key.LastUsedAt
+	}
+	return response
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/repository"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+)
+
+// setupAPIKeyRouter настраивает маршрутизатор с маршрутами /me/api-keys поверх настоящего
+// сервиса API-ключей с хранилищем в памяти. Токен userToken принадлежит пользователю userID.
+
+func setupAPIKeyRouter(t *testing.T, userID uuid.UUID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	authClient := mocks.NewMockAuthClient(gomock.NewController(t))
+	authClient.EXPECT().ValidateTokenFull(gomock.Any(), userToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: userID.String(), Role: middleware.RoleUser}, nil).AnyTimes()
+
+	apiKeys := service.NewAPIKeyService(repository.NewInMemoryAPIKeyRepository())
+	router := gin.New()
+	RegisterRoutes(router, Routes{
+		Auth:    NewAuthHandler(authClient),
+		Calls:   NewCallHandler(nil, authClient),
+		Admin:   NewAdminHandler(nil),
+		Profile: NewProfileHandler(authClient),
+		APIKeys: NewAPIKeyHandler(apiKeys),
+		Docs:    NewDocsHandler(nil),
+		AuthMiddleware: middleware.NewAuthMiddlewareWithConfig(authClient, middleware.AuthConfig{
+			TokenSources: middleware.DefaultTokenSources,
+			APIKeys:      apiKeys,
+		}),
+	})
+	return router
+}
+
+func doAPIKeyRequest(router *gin.Engine, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestAPIKeys проверяет выпуск ключа, доступ по нему, список ключей и отзыв,
+// после которого ключ перестает действовать.
+
+func TestAPIKeys(t *testing.T) {
+	userID := uuid.New()
+	router := setupAPIKeyRouter(t, userID)
+	bearer := http.Header{"Authorization": {"Bearer " + userToken}}
+
+	w := doAPIKeyRequest(router, "POST", "/me/api-keys", `{"name": "ci"}`, bearer)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created CreateAPIKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "ci", created.Name)
+	assert.True(t, len(created.Key) > len(created.Prefix))
+	assert.Equal(t, created.Prefix, created.Key[:len(created.Prefix)])
+	assert.Nil(t, created.ExpiresAt)
+
+	// Ключ аутентифицирует запросы от имени пользователя
+	byKey := http.Header{middleware.APIKeyHeader: {created.Key}}
+	w = doAPIKeyRequest(router, "GET", "/me/api-keys", "", byKey)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var keys []APIKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &keys))
+	require.Len(t, keys, 1)
+	assert.Equal(t, created.ID, keys[0].ID)
+	assert.Equal(t, created.Prefix, keys[0].Prefix)
+	assert.False(t, keys[0].Revoked)
+	assert.NotContains(t, w.Body.String(), created.Key)
+
+	w = doAPIKeyRequest(router, "DELETE", "/me/api-keys/"+created.ID.String(), "", bearer)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doAPIKeyRequest(router, "GET", "/me/api-keys", "", byKey)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestAPIKeys_Errors проверяет ответы на некорректные запросы и запрет выпуска ключа по ключу.
+
+func TestAPIKeys_Errors(t *testing.T) {
+	userID := uuid.New()
+	router := setupAPIKeyRouter(t, userID)
+	bearer := http.Header{"Authorization": {"Bearer " + userToken}}
+
+	assert.Equal(t, http.StatusBadRequest, doAPIKeyRequest(router, "POST", "/me/api-keys", `{}`, bearer).Code)
+	assert.Equal(t, http.StatusBadRequest,
+		doAPIKeyRequest(router, "POST", "/me/api-keys", `{"name": "old", "expires_at": "2000-01-01T00:00:00Z"}`, bearer).Code)
+	assert.Equal(t, http.StatusBadRequest, doAPIKeyRequest(router, "DELETE", "/me/api-keys/not-a-uuid", "", bearer).Code)
+	assert.Equal(t, http.StatusNotFound, doAPIKeyRequest(router, "DELETE", "/me/api-keys/"+uuid.NewString(), "", bearer).Code)
+
+	w := doAPIKeyRequest(router, "POST", "/me/api-keys", `{"name": "ci"}`, bearer)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created CreateAPIKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	w = doAPIKeyRequest(router, "POST", "/me/api-keys", `{"name": "copy"}`, http.Header{middleware.APIKeyHeader: {created.Key}})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doAPIKeyRequest(router, "GET", "/me/api-keys", "", http.Header{middleware.APIKeyHeader: {created.Key + "x"}})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
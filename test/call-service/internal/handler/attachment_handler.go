@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/internal/service"
+)
+
+// multipartOverhead — запас к наибольшему размеру вложения на заголовки и границы
+// multipart-запроса: тело запроса большего размера не читается.
+const multipartOverhead = 64 << 10
+
+// AttachmentHandler обрабатывает HTTP запросы к вложениям заявок текущего пользователя.
+type AttachmentHandler struct {
+	attachments service.AttachmentService
+}
+
+// NewAttachmentHandler создает новый экземпляр AttachmentHandler.
+func NewAttachmentHandler(attachments service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{attachments: attachments}
+}
+
+// UploadAttachment обрабатывает POST запрос на прикрепление файла к заявке. Файл передается
+// в поле file формы multipart/form-data; тип файла определяется по содержимому.
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, i18n.Response(c, i18n.Unauthorized))
+		return
+	}
+	callID := middleware.GetUUIDParam(c, "id")
+
+	maxSize := h.attachments.MaxSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+	file, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, i18n.Response(c, i18n.AttachmentTooLarge, maxSize))
+			return
+		}
+		c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.AttachmentRequired))
+		return
+	}
+	content, err := file.Open()
+	if err != nil {
+		writeServerError(c, err, i18n.UploadAttachmentFailed)
+		return
+	}
+	defer content.Close()
+
+	attachment, err := h.attachments.UploadAttachment(c.Request.Context(), callID, userID, &service.AttachmentUpload{
+		FileName:    file.Filename,
+		ContentType: file.Header.Get("Content-Type"),
+		Size:        file.Size,
+		Content:     content,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCallNotFound):
+			c.JSON(http.StatusNotFound, i18n.Response(c, i18n.CallNotFound))
+		case errors.Is(err, service.ErrForbidden):
+			c.JSON(http.StatusForbidden, i18n.Response(c, i18n.AccessDenied))
+		case errors.Is(err, service.ErrAttachmentTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, i18n.Response(c, i18n.AttachmentTooLarge, maxSize))
+		case errors.Is(err, service.ErrAttachmentTypeMismatch):
+			c.JSON(http.StatusUnsupportedMediaType, i18n.Response(c, i18n.AttachmentTypeMismatch))
+		case errors.Is(err, service.ErrAttachmentTypeNotAllowed):
+			c.JSON(http.StatusUnsupportedMediaType, i18n.Response(c, i18n.AttachmentTypeNotAllowed))
+		default:
+			writeServerError(c, err, i18n.UploadAttachmentFailed)
+		}
+		return
+	}
+
+	middleware.SetAuditResourceID(c, attachment.ID.String())
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// GetAttachment обрабатывает GET запрос на скачивание вложения заявки. Содержимое передается
+// с типом, определенным при загрузке, и как вложение, а не для показа в браузере.
+func (h *AttachmentHandler) GetAttachment(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, i18n.Response(c, i18n.Unauthorized))
+		return
+	}
+	callID := middleware.GetUUIDParam(c, "id")
+	id := middleware.GetUUIDParam(c, "aid")
+
+	attachment, content, err := h.attachments.OpenAttachment(c.Request.Context(), callID, id, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCallNotFound):
+			c.JSON(http.StatusNotFound, i18n.Response(c, i18n.CallNotFound))
+		case errors.Is(err, service.ErrForbidden):
+			c.JSON(http.StatusForbidden, i18n.Response(c, i18n.AccessDenied))
+		case errors.Is(err, service.ErrAttachmentNotFound):
+			c.JSON(http.StatusNotFound, i18n.Response(c, i18n.AttachmentNotFound))
+		default:
+			writeServerError(c, err, i18n.GetAttachmentFailed)
+		}
+		return
+	}
+	defer content.Close()
+
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, map[string]string{
+		"Content-Disposition":    disposition,
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private",
+	})
+}
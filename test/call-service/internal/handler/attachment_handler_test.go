@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/blobstore"
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+)
+
+// testPNG — начало PNG-файла, по которому тип определяется как image/png.
+var testPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR" + strings.Repeat("\x00", 64))
+
+// setupAttachmentRouter создает маршрутизатор с вложениями в каталоге dir и наибольшим
+// размером вложения maxSize; заявки владельца "owner-token" хранятся в памяти.
+func setupAttachmentRouter(t *testing.T, dir string, maxSize int64) (*gin.Engine, service.CallService, uuid.UUID) {
+	ownerID, otherID := uuid.New(), uuid.New()
+	mockAuthClient := mocks.NewMockAuthClient(gomock.NewController(t))
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "owner-token").Return(&authclient.TokenInfo{Valid: true, UserID: ownerID.String(), Role: middleware.RoleUser}, nil).AnyTimes()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "other-token").Return(&authclient.TokenInfo{Valid: true, UserID: otherID.String(), Role: middleware.RoleUser}, nil).AnyTimes()
+
+	store, err := blobstore.NewFileStore(dir)
+	require.NoError(t, err)
+	callRepo := repository.NewInMemoryCallRepository()
+	attachments := service.NewAttachmentService(repository.NewInMemoryAttachmentRepository(), callRepo, store, service.AttachmentConfig{MaxSize: maxSize})
+	callService := service.NewCallService(callRepo, service.WithAttachments(attachments))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, Routes{
+		Calls:          NewCallHandler(callService, mockAuthClient),
+		Attachments:    NewAttachmentHandler(attachments),
+		AuthMiddleware: middleware.NewAuthMiddleware(mockAuthClient),
+	})
+	return router, callService, ownerID
+}
+
+// doUploadRequest загружает файл content с типом contentType в поле file.
+func doUploadRequest(t *testing.T, router http.Handler, path, token, contentType string, content []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="фото клиента.png"`)
+	header.Set("Content-Type", contentType)
+	part, err := form.CreatePart(header)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestAttachments проверяет загрузку и скачивание вложения, проверку владельца, отказ
+// для слишком большого файла и файла, не совпадающего с указанным типом, и удаление
+// вложений вместе с заявкой.
+
+func TestAttachments(t *testing.T) {
+	dir := t.TempDir()
+	router, callService, ownerID := setupAttachmentRouter(t, dir, 1024)
+	call, err := callService.CreateCall(t.Context(), &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001"}, ownerID)
+	require.NoError(t, err)
+	path := "/calls/" + call.ID.String() + "/attachments"
+
+	w := doUploadRequest(t, router, path, "owner-token", "image/png", testPNG)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var attachment model.CallAttachment
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &attachment))
+	assert.Equal(t, "image/png", attachment.ContentType)
+	assert.Equal(t, int64(len(testPNG)), attachment.Size)
+	assert.NotContains(t, w.Body.String(), "storage_key")
+
+	w = doInMemoryRequest(t, router, http.MethodGet, path+"/"+attachment.ID.String(), "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, testPNG, w.Body.Bytes())
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment; filename*=utf-8''")
+
+	w = doInMemoryRequest(t, router, http.MethodGet, path+"/"+attachment.ID.String(), "other-token", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doUploadRequest(t, router, path, "other-token", "image/png", testPNG)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doInMemoryRequest(t, router, http.MethodGet, path+"/"+uuid.NewString(), "owner-token", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "attachment_not_found")
+	w = doInMemoryRequest(t, router, http.MethodGet, path+"/invalid", "owner-token", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Файл больше наибольшего размера, в том числе тело больше размера с запасом на multipart
+	w = doUploadRequest(t, router, path, "owner-token", "image/png", append(testPNG, make([]byte, 1024)...))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "attachment_too_large")
+	w = doUploadRequest(t, router, path, "owner-token", "image/png", append(testPNG, make([]byte, 128<<10)...))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// Содержимое не совпадает с указанным типом
+	w = doUploadRequest(t, router, path, "owner-token", "image/png", []byte("%PDF-1.4\n"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	assert.Contains(t, w.Body.String(), "attachment_type_mismatch")
+
+	w = doInMemoryRequest(t, router, http.MethodPost, path, "owner-token", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "attachment_required")
+
+	// Удаление заявки удаляет файлы ее вложений
+	w = doInMemoryRequest(t, router, http.MethodDelete, "/calls/"+call.ID.String(), "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
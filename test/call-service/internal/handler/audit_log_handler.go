@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/i18n"
+	"call-service/internal/model"
+	"call-service/internal/service"
+)
+
+// SecuritySummaryWindow — период, за который строится сводка отклоненных запросов.
+const SecuritySummaryWindow = time.Hour
+
+// AuditLogHandler обрабатывает HTTP запросы администраторов к журналу изменяющих запросов.
+// Доступ к маршрутам ограничивается middleware RequireRole.
+type AuditLogHandler struct {
+	auditLog *service.APIAuditLog
+}
+
+// NewAuditLogHandler создает новый экземпляр AuditLogHandler.
+func NewAuditLogHandler(auditLog *service.APIAuditLog) *AuditLogHandler {
+	return &AuditLogHandler{auditLog: auditLog}
+}
+
+// ListAuditLog обрабатывает GET запрос на получение записей журнала изменяющих запросов
+// от новых к старым. Поддерживает параметры user_id, from и to (RFC3339, полуинтервал
+// [from, to)), limit и offset; общее количество записей возвращается в X-Total-Count.
+func (h *AuditLogHandler) ListAuditLog(c *gin.Context) {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	entries, total, err := h.auditLog.List(c.Request.Context(), filter)
+	if err != nil {
+		writeServerError(c, err, i18n.GetAuditLogFailed)
+		return
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, entries)
+}
+
+// SecuritySummary обрабатывает GET запрос сводки изменяющих запросов, отклоненных за последний
+// SecuritySummaryWindow: число ответов 401, 403 и 429 по причинам и по маршрутам.
+func (h *AuditLogHandler) SecuritySummary(c *gin.Context) {
+	summary, err := h.auditLog.SecuritySummary(c.Request.Context(), SecuritySummaryWindow)
+	if err != nil {
+		writeServerError(c, err, i18n.GetSecuritySummaryFailed)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// parseAuditFilter разбирает параметры строки запроса в фильтр журнала изменяющих запросов.
+// Если limit не передан, используется DefaultAdminPageLimit.
+func parseAuditFilter(c *gin.Context) (model.APIAuditFilter, error) {
+	filter := model.APIAuditFilter{Limit: DefaultAdminPageLimit}
+
+	var err error
+	if filter.UserID, err = parseUserIDQuery(c); err != nil {
+		return filter, err
+	}
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		return filter, err
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxPageLimit {
+			return filter, i18n.New(i18n.InvalidLimit, MaxPageLimit)
+		}
+		filter.Limit = limit
+	}
+
+	if value := c.Query("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return filter, i18n.New(i18n.InvalidOffset)
+		}
+		filter.Offset = offset
+	}
+
+	return filter, nil
+}
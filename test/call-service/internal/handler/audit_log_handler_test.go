@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+)
+
+// TestAuditLog проверяет, что изменяющие запросы попадают в журнал с ID созданного объекта,
+// а администратор получает записи пользователя постранично; чтение не записывается,
+// а пользователь без роли администратора журнал не видит.
+
+func TestAuditLog(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userID := uuid.New()
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), adminToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: adminUserID.String(), Role: middleware.RoleAdmin}, nil).AnyTimes()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), userToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: userID.String(), Role: middleware.RoleUser}, nil).AnyTimes()
+	callService := service.NewCallService(repository.NewInMemoryCallRepository())
+	auditLog := service.NewAPIAuditLog(repository.NewInMemoryAPIAuditRepository(), 0)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, Routes{
+		Auth:           NewAuthHandler(mockAuthClient),
+		Calls:          NewCallHandler(callService, mockAuthClient),
+		Admin:          NewAdminHandler(callService),
+		Docs:           NewDocsHandler(nil),
+		AuthMiddleware: middleware.NewAuthMiddleware(mockAuthClient),
+		AuditLog:       NewAuditLogHandler(auditLog),
+	})
+
+	createCall := `{"client_name":"Test Client","phone_number":"+1234567890","description":"Test Description"}`
+	var callIDs []string
+	for i := 0; i < 2; i++ {
+		w := doInMemoryRequest(t, router, http.MethodPost, "/calls", userToken, createCall)
+		require.Equal(t, http.StatusCreated, w.Code)
+		var call map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &call))
+		callIDs = append(callIDs, call["id"].(string))
+	}
+	w := doInMemoryRequest(t, router, http.MethodGet, "/calls", userToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doInMemoryRequest(t, router, http.MethodPatch, "/admin/calls/"+callIDs[0]+"/status", adminToken, `{"status":"closed"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	// Close сохраняет записи из очереди; List читает их из репозитория
+	require.NoError(t, auditLog.Close())
+
+	w = doInMemoryRequest(t, router, http.MethodGet, "/admin/audit-log", userToken, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doInMemoryRequest(t, router, http.MethodGet, "/admin/audit-log", adminToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "3", w.Header().Get(TotalCountHeader))
+
+	w = doInMemoryRequest(t, router, http.MethodGet, "/admin/audit-log?user_id="+userID.String()+"&limit=1", adminToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get(TotalCountHeader))
+	var entries []model.APIAuditEntry
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, http.MethodPost, entries[0].Method)
+	assert.Equal(t, "/calls", entries[0].Route)
+	assert.Equal(t, http.StatusCreated, entries[0].Status)
+	assert.Contains(t, callIDs, entries[0].ResourceID)
+	assert.Len(t, entries[0].BodyHash, 64)
+
+	w = doInMemoryRequest(t, router, http.MethodGet, "/admin/audit-log?user_id="+adminUserID.String(), adminToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "/admin/calls/:id/status", entries[0].Route)
+	assert.Equal(t, callIDs[0], entries[0].ResourceID)
+
+	// Некорректные параметры фильтра
+	for _, query := range []string{"user_id=bad", "from=yesterday", "limit=0", "offset=-1"} {
+		w = doInMemoryRequest(t, router, http.MethodGet, "/admin/audit-log?"+query, adminToken, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+// TestSecuritySummary проверяет сводку отклоненных изменяющих запросов: неудачный вход
+// и изменение маршрута администратора пользователем учитываются по причинам и маршрутам,
+// а пользователь без роли администратора сводку не видит.
+
+func TestSecuritySummary(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), adminToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: adminUserID.String(), Role: middleware.RoleAdmin}, nil).AnyTimes()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), userToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: uuid.NewString(), Role: middleware.RoleUser}, nil).AnyTimes()
+	mockAuthClient.EXPECT().LoginFull(gomock.Any(), "user", "wrong").
+		Return(nil, authclient.FromStatus(status.Error(codes.Unauthenticated, "invalid credentials"))).Times(2)
+	callService := service.NewCallService(repository.NewInMemoryCallRepository())
+	auditLog := service.NewAPIAuditLog(repository.NewInMemoryAPIAuditRepository(), 0)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, Routes{
+		Auth:           NewAuthHandler(mockAuthClient),
+		Calls:          NewCallHandler(callService, mockAuthClient),
+		Admin:          NewAdminHandler(callService),
+		Docs:           NewDocsHandler(nil),
+		AuthMiddleware: middleware.NewAuthMiddleware(mockAuthClient),
+		AuditLog:       NewAuditLogHandler(auditLog),
+	})
+
+	for range 2 {
+		w := doInMemoryRequest(t, router, http.MethodPost, "/login", "", `{"username":"user","password":"wrong"}`)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	w := doInMemoryRequest(t, router, http.MethodPatch, "/admin/calls/"+uuid.NewString()+"/status", userToken, `{"status":"closed"}`)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.NoError(t, auditLog.Close())
+
+	w = doInMemoryRequest(t, router, http.MethodGet, "/admin/security/summary", userToken, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doInMemoryRequest(t, router, http.MethodGet, "/admin/security/summary", adminToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var summary model.SecuritySummary
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(t, map[string]int{
+		model.SecurityReasonUnauthorized: 2,
+		model.SecurityReasonForbidden:    1,
+		model.SecurityReasonRateLimited:  0,
+	}, summary.Reasons)
+	assert.Equal(t, []model.SecurityRouteCount{
+		{Method: http.MethodPost, Route: "/login", Reason: model.SecurityReasonUnauthorized, Count: 2},
+		{Method: http.MethodPatch, Route: "/admin/calls/:id/status", Reason: model.SecurityReasonForbidden, Count: 1},
+	}, summary.Routes)
+	assert.Equal(t, SecuritySummaryWindow, summary.To.Sub(summary.From))
+}
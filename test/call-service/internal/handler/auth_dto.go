@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"time"
+
+	"call-service/pkg/authclient"
+)
+
+// TokenTypeBearer — тип токена в AuthResponseV2: токен передается в заголовке
+// Authorization: Bearer <token>.
+const TokenTypeBearer = "Bearer"
+
+// RegisterRequest содержит данные для регистрации нового пользователя.
+// Поля помечены как обязательные для валидации.
+type RegisterRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	// CaptchaToken — ответ на проверку CAPTCHA, если она включена (см. CaptchaConfig).
+	CaptchaToken string `json:"captcha_token"`
+}
+
+// LoginRequest содержит данные для входа в систему.
+// Поля помечены как обязательные для валидации.
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	// CaptchaToken — ответ на проверку CAPTCHA; нужен после нескольких неудачных попыток
+	// входа (см. CaptchaConfig).
+	CaptchaToken string `json:"captcha_token"`
+}
+
+// OIDCLoginRequest содержит ID-токен внешнего провайдера OIDC для входа в систему.
+// Provider — имя провайдера из конфигурации сервиса аутентификации.
+type OIDCLoginRequest struct {
+	Provider string `json:"provider" binding:"required"`
+	IDToken  string `json:"id_token" binding:"required"`
+}
+
+// AuthResponse возвращает данные об успешной аутентификации по устаревшим маршрутам
+// /register и /login. Формат не меняется до их отключения; новые поля добавляются
+// только в AuthResponseV2.
+type AuthResponse struct {
+	Token  string `json:"token"`
+	UserID string `json:"user_id"`
+}
+
+// AuthResponseV2 возвращает данные об успешной аутентификации по маршрутам /api/v2.
+// RefreshToken отсутствует, если сервис аутентификации его не выпустил.
+type AuthResponseV2 struct {
+	Token        string    `json:"token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	UserID       string    `json:"user_id"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+}
+
+// newAuthResponse преобразует результат аутентификации в ответ устаревших маршрутов.
+func newAuthResponse(result *authclient.AuthResult) AuthResponse {
+	return AuthResponse{
+		Token:  result.Token,
+		UserID: result.UserID,
+	}
+}
+
+// newAuthResponseV2 преобразует результат аутентификации в ответ AuthResponseV2 со сроком
+// действия токена expiresAt.
+func newAuthResponseV2(result *authclient.AuthResult, expiresAt time.Time) AuthResponseV2 {
+	return AuthResponseV2{
+		Token:        result.Token,
+		TokenType:    TokenTypeBearer,
+		ExpiresAt:    expiresAt.UTC(),
+		UserID:       result.UserID,
+		RefreshToken: result.RefreshToken,
+	}
+}
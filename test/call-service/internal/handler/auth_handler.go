@@ -1,79 +1,286 @@
-package handler
-
-import (
-	"net/http"
-
-	"github.com/gin-gonic/gin"
-
-	"call-service/pkg/authclient"
-)
-
-// AuthHandler обрабатывает запросы аутентификации через HTTP API.
-// Использует клиент для взаимодействия с сервисом аутентификации.
-type AuthHandler struct {
-	authClient authclient.AuthClient
-}
-
-// NewAuthHandler создает новый экземпляр обработчика аутентификации.
-// Принимает клиент для взаимодействия с сервисом аутентификации.
-func NewAuthHandler(authClient authclient.AuthClient) *AuthHandler {
-	return &AuthHandler{authClient: authClient}
-}
-
-// RegisterRequest содержит данные для регистрации нового пользователя.
-// Поля помечены как обязательные для валидации.
-type RegisterRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
-}
-
-// LoginRequest содержит данные для входа в систему.
-// Поля помечены как обязательные для валидации.
-type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
-}
-
-// AuthResponse возвращает данные об успешной аутентификации.
-type AuthResponse struct {
-	Token  string `json:"token"`
-	UserID string `json:"user_id"`
-}
-
-// Register обрабатывает запрос на регистрацию нового пользователя.
-// Принимает JSON с данными пользователя и возвращает токен и ID при успешной регистрации.
-func (h *AuthHandler) Register(c *gin.Context) {
-	var req RegisterRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	token, userID, err := h.authClient.Register(c.Request.Context(), req.Username, req.Password)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	c.JSON(http.StatusCreated, AuthResponse{
-		Token:  token,
-		UserID: userID,
-	})
-}
-
-// Login обрабатывает запрос на вход в систему.
-// Принимает JSON с данными пользователя и возвращает токен и ID при успешной аутентификации.
-func (h *AuthHandler) Login(c *gin.Context) {
-	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	token, userID, err := h.authClient.Login(c.Request.Context(), req.Username, req.Password)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	c.JSON(http.StatusOK, AuthResponse{
-		Token:  token,
-		UserID: userID,
-	})
-}
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/cache"
+	"call-service/internal/captcha"
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/pkg/authclient"
+)
+
+// DefaultAccessTokenTTL — срок действия access-токена, выпускаемого сервисом аутентификации.
+// Используется как Max-Age cookie с токеном, если срок не задан в AuthCookieConfig.
+const DefaultAccessTokenTTL = 24 * time.Hour
+
+// Проверка CAPTCHA при входе по умолчанию (см. CaptchaConfig).
+const (
+	// DefaultCaptchaLoginFailures — число неудачных попыток входа с одним именем пользователя
+	// за DefaultCaptchaLoginWindow, после которого вход требует проверки CAPTCHA.
+	DefaultCaptchaLoginFailures = 5
+	DefaultCaptchaLoginWindow   = 15 * time.Minute
+)
+
+// AuthCookieConfig задает установку HttpOnly cookie с токеном доступа (middleware.AccessTokenCookie)
+// при успешных входе и регистрации. Токен по-прежнему возвращается и в теле ответа.
+type AuthCookieConfig struct {
+	// Enabled включает установку cookie.
+	Enabled bool
+	// Secure запрещает браузеру передавать cookie по HTTP без TLS.
+	Secure bool
+	// SameSite ограничивает передачу cookie в межсайтовых запросах; 0 означает http.SameSiteLaxMode.
+	SameSite http.SameSite
+	// MaxAge — время жизни cookie; должно совпадать со сроком действия токена.
+	// 0 означает DefaultAccessTokenTTL.
+	MaxAge time.Duration
+}
+
+// CaptchaConfig задает проверку CAPTCHA против автоматических регистраций и подбора паролей.
+// Регистрация требует ответа на проверку всегда, вход — после нескольких неудачных попыток
+// с тем же именем пользователя.
+type CaptchaConfig struct {
+	// Verifier проверяет ответ пользователя у провайдера; nil отключает проверку.
+	Verifier captcha.Verifier
+	// LoginFailures учитывает неудачные попытки входа по имени пользователя: когда лимит
+	// исчерпан, вход требует проверки CAPTCHA до окончания окна. nil — ограничитель в памяти
+	// процесса на DefaultCaptchaLoginFailures попыток за DefaultCaptchaLoginWindow.
+	LoginFailures middleware.Limiter
+}
+
+// AuthHandlerConfig задает необязательное поведение AuthHandler.
+type AuthHandlerConfig struct {
+	Cookie  AuthCookieConfig
+	Captcha CaptchaConfig
+}
+
+// AuthHandler обрабатывает запросы аутентификации через HTTP API.
+// Использует клиент для взаимодействия с сервисом аутентификации.
+type AuthHandler struct {
+	authClient authclient.AuthClient
+	cookie     AuthCookieConfig
+	captcha    CaptchaConfig
+}
+
+// NewAuthHandler создает новый экземпляр обработчика аутентификации.
+// Принимает клиент для взаимодействия с сервисом аутентификации.
+func NewAuthHandler(authClient authclient.AuthClient) *AuthHandler {
+	return NewAuthHandlerWithCookie(authClient, AuthCookieConfig{})
+}
+
+// NewAuthHandlerWithCookie создает обработчик аутентификации, который при входе и регистрации
+// дополнительно устанавливает cookie с токеном доступа согласно cookie.
+func NewAuthHandlerWithCookie(authClient authclient.AuthClient, cookie AuthCookieConfig) *AuthHandler {
+	return NewAuthHandlerWithConfig(authClient, AuthHandlerConfig{Cookie: cookie})
+}
+
+// NewAuthHandlerWithConfig создает обработчик аутентификации с cookie и проверкой CAPTCHA
+// согласно cfg.
+func NewAuthHandlerWithConfig(authClient authclient.AuthClient, cfg AuthHandlerConfig) *AuthHandler {
+	cookie := cfg.Cookie
+	if cookie.SameSite == 0 {
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	if cookie.MaxAge <= 0 {
+		cookie.MaxAge = DefaultAccessTokenTTL
+	}
+	if cfg.Captcha.Verifier != nil && cfg.Captcha.LoginFailures == nil {
+		cfg.Captcha.LoginFailures = middleware.NewStoreLimiter(cache.NewMemory(0, nil), "",
+			DefaultCaptchaLoginFailures, DefaultCaptchaLoginWindow, nil)
+	}
+	return &AuthHandler{authClient: authClient, cookie: cookie, captcha: cfg.Captcha}
+}
+
+// Register обрабатывает запрос на регистрацию нового пользователя по устаревшему маршруту
+// /register и возвращает ответ в прежнем формате AuthResponse. Новые клиенты используют RegisterV2.
+func (h *AuthHandler) Register(c *gin.Context) {
+	if result, ok := h.register(c); ok {
+		c.JSON(http.StatusCreated, newAuthResponse(result))
+	}
+}
+
+// RegisterV2 обрабатывает запрос на регистрацию нового пользователя и возвращает AuthResponseV2
+// со сроком действия токена и refresh-токеном.
+func (h *AuthHandler) RegisterV2(c *gin.Context) {
+	if result, ok := h.register(c); ok {
+		c.JSON(http.StatusCreated, newAuthResponseV2(result, h.expiresAt(c, result)))
+	}
+}
+
+// Login обрабатывает запрос на вход в систему по устаревшему маршруту /login и возвращает
+// ответ в прежнем формате AuthResponse. Новые клиенты используют LoginV2.
+func (h *AuthHandler) Login(c *gin.Context) {
+	if result, ok := h.login(c); ok {
+		c.JSON(http.StatusOK, newAuthResponse(result))
+	}
+}
+
+// LoginV2 обрабатывает запрос на вход в систему и возвращает AuthResponseV2 со сроком действия
+// токена и refresh-токеном.
+func (h *AuthHandler) LoginV2(c *gin.Context) {
+	if result, ok := h.login(c); ok {
+		c.JSON(http.StatusOK, newAuthResponseV2(result, h.expiresAt(c, result)))
+	}
+}
+
+// LoginOIDC обрабатывает запрос на вход по ID-токену внешнего провайдера OIDC и возвращает
+// AuthResponseV2, как LoginV2. При первом входе сервис аутентификации создает пользователя
+// или связывает учетную запись провайдера с существующим. Неизвестный провайдер — 400,
+// недействительный ID-токен — 401, вход через OIDC не включен — 501, недоступность сервиса
+// аутентификации или ключей провайдера — 503.
+func (h *AuthHandler) LoginOIDC(c *gin.Context) {
+	var req OIDCLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+	result, err := h.authClient.ExchangeOIDCToken(clientContext(c), req.Provider, req.IDToken)
+	if err != nil {
+		writeAuthError(c, err, i18n.LoginFailed)
+		return
+	}
+	h.setTokenCookie(c, result.Token)
+	c.JSON(http.StatusOK, newAuthResponseV2(result, h.expiresAt(c, result)))
+}
+
+// register регистрирует пользователя по JSON с данными из тела запроса и, если включено,
+// устанавливает cookie с токеном. При ошибке пишет ответ сам и возвращает false: некорректный
+// запрос — 400, непройденная проверка CAPTCHA — 403, занятое имя пользователя — 409,
+// недоступность сервиса аутентификации — 503.
+func (h *AuthHandler) register(c *gin.Context) (*authclient.AuthResult, bool) {
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return nil, false
+	}
+	if h.captcha.Verifier != nil && !h.verifyCaptcha(c, req.CaptchaToken) {
+		return nil, false
+	}
+	result, err := h.authClient.RegisterFull(clientContext(c), req.Username, req.Password)
+	if err != nil {
+		writeAuthError(c, err, i18n.RegisterFailed)
+		return nil, false
+	}
+	h.setTokenCookie(c, result.Token)
+	return result, true
+}
+
+// login выполняет вход по JSON с данными пользователя из тела запроса и, если включено,
+// устанавливает cookie с токеном. При ошибке пишет ответ сам и возвращает false: некорректный
+// запрос — 400, неверные учетные данные — 401, непройденная проверка CAPTCHA — 403,
+// недоступность сервиса аутентификации — 503.
+func (h *AuthHandler) login(c *gin.Context) (*authclient.AuthResult, bool) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return nil, false
+	}
+	failureKey, counted := "", false
+	if h.captcha.Verifier != nil {
+		failureKey = strings.ToLower(req.Username)
+		var required bool
+		counted, required = h.reserveLoginFailure(c, failureKey)
+		if required && !h.verifyCaptcha(c, req.CaptchaToken) {
+			return nil, false
+		}
+	}
+	result, err := h.authClient.LoginFull(clientContext(c), req.Username, req.Password)
+	if counted && !errors.Is(err, authclient.ErrInvalidCredentials) {
+		// Попытка учитывается заранее и возвращается, если вход не отклонен из-за неверного пароля
+		if err := h.captcha.LoginFailures.Release(context.WithoutCancel(c.Request.Context()), failureKey); err != nil {
+			log.Printf("failed to release login attempt: %v", err)
+		}
+	}
+	if err != nil {
+		writeAuthError(c, err, i18n.LoginFailed)
+		return nil, false
+	}
+	h.setTokenCookie(c, result.Token)
+	return result, true
+}
+
+// reserveLoginFailure заранее учитывает попытку входа с именем key как неудачную. counted
+// сообщает, учтена ли попытка, required — что лимит неудачных попыток исчерпан и вход требует
+// проверки CAPTCHA. Если счетчики недоступны, попытка не учитывается и проверка не требуется.
+func (h *AuthHandler) reserveLoginFailure(c *gin.Context, key string) (counted, required bool) {
+	_, ok, err := h.captcha.LoginFailures.Reserve(c.Request.Context(), key)
+	if err != nil {
+		log.Printf("login failures check failed, captcha is not required: %v", err)
+		return false, false
+	}
+	return ok, !ok
+}
+
+// verifyCaptcha проверяет ответ на CAPTCHA token. При ошибке пишет ответ сам и возвращает
+// false: отсутствующий ответ — 403 captcha_required, отклоненный провайдером — 403
+// invalid_captcha, недоступность провайдера при политике captcha.FailClosed — 503
+// captcha_unavailable.
+func (h *AuthHandler) verifyCaptcha(c *gin.Context, token string) bool {
+	err := h.captcha.Verifier.Verify(c.Request.Context(), token, middleware.ClientIP(c))
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, captcha.ErrMissingToken):
+		c.JSON(http.StatusForbidden, i18n.Response(c, i18n.CaptchaRequired))
+	case errors.Is(err, captcha.ErrInvalidToken):
+		c.JSON(http.StatusForbidden, i18n.Response(c, i18n.InvalidCaptcha))
+	default:
+		log.Printf("captcha check failed: %v", err)
+		c.JSON(http.StatusServiceUnavailable, i18n.Response(c, i18n.CaptchaUnavailable))
+	}
+	return false
+}
+
+// expiresAt возвращает срок действия выпущенного токена: из ответа сервиса аутентификации,
+// а если прежняя версия сервиса его не вернула — из claims токена, полученных проверкой.
+// Если и проверка не дала срока, срок отсчитывается от текущего момента по времени жизни cookie,
+// которое совпадает со сроком действия токена.
+func (h *AuthHandler) expiresAt(c *gin.Context, result *authclient.AuthResult) time.Time {
+	if !result.ExpiresAt.IsZero() {
+		return result.ExpiresAt
+	}
+	info, err := h.authClient.ValidateTokenFull(c.Request.Context(), result.Token)
+	if err == nil && info.Valid && !info.ExpiresAt.IsZero() {
+		return info.ExpiresAt
+	}
+	return time.Now().Add(h.cookie.MaxAge)
+}
+
+// setTokenCookie устанавливает HttpOnly cookie с токеном доступа, если это включено в конфигурации.
+func (h *AuthHandler) setTokenCookie(c *gin.Context, token string) {
+	if !h.cookie.Enabled {
+		return
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     middleware.AccessTokenCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(h.cookie.MaxAge / time.Second),
+		Secure:   h.cookie.Secure,
+		HttpOnly: true,
+		SameSite: h.cookie.SameSite,
+	})
+}
+
+// clientContext возвращает контекст запроса с данными клиента для сеанса, создаваемого
+// сервисом аутентификации: IP клиента, его User-Agent и описанием устройства из заголовка
+// X-Device-Label или, если он не задан, из User-Agent. По IP и User-Agent сервис
+// аутентификации определяет вход с нового устройства.
+func clientContext(c *gin.Context) context.Context {
+	device := c.GetHeader("X-Device-Label")
+	if device == "" {
+		device = c.Request.UserAgent()
+	}
+	return authclient.WithClientInfo(c.Request.Context(), authclient.ClientInfo{
+		IP:          middleware.ClientIP(c),
+		DeviceLabel: device,
+		UserAgent:   c.Request.UserAgent(),
+	})
+}
@@ -0,0 +1,365 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"call-service/internal/cache"
+	"call-service/internal/captcha"
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/pkg/authclient"
+	"common/clientip"
+)
+
+// setupAuthRouter настраивает маршрутизатор с маршрутами входа и регистрации.
+
+func setupAuthRouter(h *AuthHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/register", h.Register)
+	router.POST("/login", h.Login)
+	router.POST("/api/v2/register", h.RegisterV2)
+	router.POST("/api/v2/login", h.LoginV2)
+	return router
+}
+
+func doAuthRequest(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", path, strings.NewReader(`{"username":"user","password":"password"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestAuthHandler_TokenCookie проверяет, что при включенной cookie вход и регистрация
+// устанавливают HttpOnly cookie с токеном и по-прежнему возвращают токен в теле ответа.
+
+func TestAuthHandler_TokenCookie(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	mockAuthClient.EXPECT().LoginFull(gomock.Any(), "user", "password").Return(&authclient.AuthResult{Token: "login.token", UserID: "user-1"}, nil).Times(2)
+	mockAuthClient.EXPECT().RegisterFull(gomock.Any(), "user", "password").Return(&authclient.AuthResult{Token: "register.token", UserID: "user-1"}, nil)
+
+	router := setupAuthRouter(NewAuthHandlerWithCookie(mockAuthClient, AuthCookieConfig{
+		Enabled:  true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   time.Hour,
+	}))
+
+	w := doAuthRequest(router, "/login")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"token":"login.token","user_id":"user-1"}`, w.Body.String())
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, middleware.AccessTokenCookie, cookies[0].Name)
+	assert.Equal(t, "login.token", cookies[0].Value)
+	assert.Equal(t, 3600, cookies[0].MaxAge)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.True(t, cookies[0].Secure)
+	assert.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite)
+
+	w = doAuthRequest(router, "/register")
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Len(t, w.Result().Cookies(), 1)
+	assert.Equal(t, "register.token", w.Result().Cookies()[0].Value)
+
+	// По умолчанию cookie не устанавливается
+	w = doAuthRequest(setupAuthRouter(NewAuthHandler(mockAuthClient)), "/login")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+}
+
+// TestAuthHandler_Errors проверяет коды ответа на ошибки сервиса аутентификации при входе
+// и регистрации и то, что текст ошибки gRPC не передается клиенту.
+
+func TestAuthHandler_Errors(t *testing.T) {
+	tests := []struct {
+		code       codes.Code
+		wantStatus int
+		wantError  string
+		wantCode   string
+	}{
+		{codes.AlreadyExists, http.StatusConflict, "user already exists", "user_exists"},
+		{codes.Unauthenticated, http.StatusUnauthorized, "invalid credentials", "invalid_credentials"},
+		{codes.InvalidArgument, http.StatusBadRequest, "username and password are required", "credentials_required"},
+		{codes.Unavailable, http.StatusServiceUnavailable, "auth service unavailable", "auth_unavailable"},
+		{codes.DeadlineExceeded, http.StatusGatewayTimeout, "request timed out", "request_timed_out"},
+		{codes.Internal, http.StatusInternalServerError, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockAuthClient := mocks.NewMockAuthClient(ctrl)
+			err := authclient.FromStatus(status.Error(tt.code, tt.wantError))
+			mockAuthClient.EXPECT().RegisterFull(gomock.Any(), "user", "password").Return(nil, err).Times(2)
+			mockAuthClient.EXPECT().LoginFull(gomock.Any(), "user", "password").Return(nil, err).Times(2)
+			router := setupAuthRouter(NewAuthHandler(mockAuthClient))
+
+			for _, path := range []string{"/register", "/login", "/api/v2/register", "/api/v2/login"} {
+				w := doAuthRequest(router, path)
+				assert.Equal(t, tt.wantStatus, w.Code, path)
+				assert.NotContains(t, w.Body.String(), "rpc error", path)
+				if tt.wantError != "" {
+					assert.JSONEq(t, `{"error":"`+tt.wantError+`","code":"`+tt.wantCode+`"}`, w.Body.String(), path)
+				}
+			}
+		})
+	}
+}
+
+// jsonKeys возвращает имена полей JSON-объекта из тела ответа.
+
+func jsonKeys(t *testing.T, body []byte) []string {
+	t.Helper()
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(body, &fields))
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// TestAuthHandler_ResponseKeys проверяет точный набор полей ответов: маршруты без версии
+// возвращают прежний формат и не получают новых полей, маршруты /api/v2 — AuthResponseV2.
+// Новое поле в ответе должно сопровождаться изменением этого теста.
+
+func TestAuthHandler_ResponseKeys(t *testing.T) {
+	expiresAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	result := &authclient.AuthResult{Token: "jwt", UserID: "user-1", RefreshToken: "refresh", ExpiresAt: expiresAt}
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	mockAuthClient.EXPECT().RegisterFull(gomock.Any(), "user", "password").Return(result, nil).Times(2)
+	mockAuthClient.EXPECT().LoginFull(gomock.Any(), "user", "password").Return(result, nil).Times(2)
+	router := setupAuthRouter(NewAuthHandler(mockAuthClient))
+
+	for _, path := range []string{"/register", "/login"} {
+		w := doAuthRequest(router, path)
+		assert.ElementsMatch(t, []string{"token", "user_id"}, jsonKeys(t, w.Body.Bytes()), path)
+	}
+	for _, path := range []string{"/api/v2/register", "/api/v2/login"} {
+		w := doAuthRequest(router, path)
+		assert.ElementsMatch(t, []string{"token", "token_type", "expires_at", "user_id", "refresh_token"}, jsonKeys(t, w.Body.Bytes()), path)
+		assert.JSONEq(t, `{"token":"jwt","token_type":"Bearer","expires_at":"2025-03-01T09:00:00Z","user_id":"user-1","refresh_token":"refresh"}`, w.Body.String(), path)
+	}
+}
+
+// TestAuthHandler_ExpiresAtFallback проверяет срок действия в AuthResponseV2, если сервис
+// аутентификации не вернул его в ответе на вход: срок берется из проверки токена, а без него
+// отсчитывается по времени жизни cookie; refresh-токен без значения в ответ не попадает.
+
+func TestAuthHandler_ExpiresAtFallback(t *testing.T) {
+	expiresAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	mockAuthClient.EXPECT().LoginFull(gomock.Any(), "user", "password").Return(&authclient.AuthResult{Token: "jwt", UserID: "user-1"}, nil).Times(2)
+	gomock.InOrder(
+		mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "jwt").Return(&authclient.TokenInfo{Valid: true, UserID: "user-1", ExpiresAt: expiresAt}, nil),
+		mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "jwt").Return(nil, authclient.ErrUnavailable),
+	)
+	router := setupAuthRouter(NewAuthHandlerWithCookie(mockAuthClient, AuthCookieConfig{MaxAge: time.Hour}))
+
+	w := doAuthRequest(router, "/api/v2/login")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"token":"jwt","token_type":"Bearer","expires_at":"2025-03-01T12:00:00Z","user_id":"user-1"}`, w.Body.String())
+
+	w = doAuthRequest(router, "/api/v2/login")
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp AuthResponseV2
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), resp.ExpiresAt, time.Minute)
+}
+
+// TestAuthHandler_LoginOIDC проверяет вход по ID-токену провайдера OIDC: успешный вход
+// возвращает AuthResponseV2 и устанавливает cookie, запрос без ID-токена отклоняется
+// без вызова сервиса аутентификации, а ошибки сервиса передаются с кодами API.
+
+func TestAuthHandler_LoginOIDC(t *testing.T) {
+	expiresAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	mockAuthClient.EXPECT().ExchangeOIDCToken(gomock.Any(), "corp", "id.token").Return(&authclient.AuthResult{
+		Token: "jwt", UserID: "user-1", RefreshToken: "refresh", ExpiresAt: expiresAt,
+	}, nil)
+	router := gin.New()
+	router.POST("/login/oidc", NewAuthHandlerWithCookie(mockAuthClient, AuthCookieConfig{Enabled: true}).LoginOIDC)
+	doOIDCLogin := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/login/oidc", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := doOIDCLogin(`{"provider":"corp","id_token":"id.token"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"token":"jwt","token_type":"Bearer","expires_at":"2025-03-01T12:00:00Z","user_id":"user-1","refresh_token":"refresh"}`, w.Body.String())
+	require.Len(t, w.Result().Cookies(), 1)
+	assert.Equal(t, "jwt", w.Result().Cookies()[0].Value)
+
+	w = doOIDCLogin(`{"provider":"corp"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	errorTests := []struct {
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{status.Error(codes.InvalidArgument, "unknown identity provider"), http.StatusBadRequest, "unknown_identity_provider"},
+		{status.Error(codes.Unauthenticated, "invalid ID token"), http.StatusUnauthorized, "invalid_credentials"},
+		{status.Error(codes.Unimplemented, "OIDC login is disabled"), http.StatusNotImplemented, "oidc_login_disabled"},
+		{status.Error(codes.Unavailable, "identity provider unavailable"), http.StatusServiceUnavailable, "auth_unavailable"},
+	}
+	for _, tt := range errorTests {
+		mockAuthClient.EXPECT().ExchangeOIDCToken(gomock.Any(), "corp", "id.token").Return(nil, authclient.FromStatus(tt.err))
+		w := doOIDCLogin(`{"provider":"corp","id_token":"id.token"}`)
+		assert.Equal(t, tt.wantStatus, w.Code, tt.wantCode)
+		assert.Contains(t, w.Body.String(), `"code":"`+tt.wantCode+`"`)
+	}
+}
+
+// captchaFunc — captcha.Verifier из функции.
+type captchaFunc func(token string) error
+
+func (f captchaFunc) Verify(_ context.Context, token, _ string) error {
+	return f(token)
+}
+
+// testCaptcha принимает ответ "ok", отклоняет остальные и сообщает о недоступности
+// провайдера для ответа "down".
+var testCaptcha = captchaFunc(func(token string) error {
+	switch token {
+	case "ok":
+		return nil
+	case "":
+		return captcha.ErrMissingToken
+	case "down":
+		return captcha.ErrUnavailable
+	}
+	return captcha.ErrInvalidToken
+})
+
+func doCaptchaRequest(router *gin.Engine, path, password, token string) *httptest.ResponseRecorder {
+	body := `{"username":"user","password":"` + password + `","captcha_token":"` + token + `"}`
+	req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestAuthHandler_CaptchaRegister проверяет, что при включенной проверке CAPTCHA регистрация
+// без ответа, с отклоненным ответом или при недоступном провайдере не вызывает сервис
+// аутентификации.
+
+func TestAuthHandler_CaptchaRegister(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	mockAuthClient.EXPECT().RegisterFull(gomock.Any(), "user", "password").Return(&authclient.AuthResult{Token: "jwt", UserID: "user-1"}, nil)
+	router := setupAuthRouter(NewAuthHandlerWithConfig(mockAuthClient, AuthHandlerConfig{
+		Captcha: CaptchaConfig{Verifier: testCaptcha},
+	}))
+
+	tests := []struct {
+		token      string
+		wantStatus int
+		wantCode   string
+	}{
+		{"", http.StatusForbidden, "captcha_required"},
+		{"wrong", http.StatusForbidden, "invalid_captcha"},
+		{"down", http.StatusServiceUnavailable, "captcha_unavailable"},
+	}
+	for _, tt := range tests {
+		w := doCaptchaRequest(router, "/register", "password", tt.token)
+		assert.Equal(t, tt.wantStatus, w.Code, tt.token)
+		assert.Contains(t, w.Body.String(), `"code":"`+tt.wantCode+`"`, tt.token)
+	}
+
+	w := doCaptchaRequest(router, "/register", "password", "ok")
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+// TestAuthHandler_CaptchaClientIP проверяет, что провайдер CAPTCHA получает IP клиента,
+// определенный по доверенным прокси: X-Forwarded-For от недоверенного адреса не учитывается.
+
+func TestAuthHandler_CaptchaClientIP(t *testing.T) {
+	mockAuthClient := mocks.NewMockAuthClient(gomock.NewController(t))
+	var remoteIPs []string
+	verifier := captchaVerifierFunc(func(_ context.Context, _, remoteIP string) error {
+		remoteIPs = append(remoteIPs, remoteIP)
+		return captcha.ErrInvalidToken
+	})
+	proxies, err := clientip.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.TrustProxies(proxies))
+	router.POST("/register", NewAuthHandlerWithConfig(mockAuthClient, AuthHandlerConfig{
+		Captcha: CaptchaConfig{Verifier: verifier},
+	}).Register)
+
+	for _, remoteAddr := range []string{"192.0.2.1:1234", "10.0.0.1:1234"} {
+		req, _ := http.NewRequest("POST", "/register", strings.NewReader(`{"username":"user","password":"password","captcha_token":"token"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	}
+	assert.Equal(t, []string{"192.0.2.1", "203.0.113.7"}, remoteIPs)
+}
+
+// captchaVerifierFunc — captcha.Verifier из функции с полным набором аргументов.
+type captchaVerifierFunc func(ctx context.Context, token, remoteIP string) error
+
+func (f captchaVerifierFunc) Verify(ctx context.Context, token, remoteIP string) error {
+	return f(ctx, token, remoteIP)
+}
+
+// TestAuthHandler_CaptchaLogin проверяет, что вход требует проверки CAPTCHA только после
+// исчерпания лимита неудачных попыток с тем же именем пользователя, а успешный вход
+// и ошибки, не связанные с паролем, попытку не расходуют.
+
+func TestAuthHandler_CaptchaLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	mockAuthClient.EXPECT().LoginFull(gomock.Any(), "user", "wrong").Return(nil, authclient.ErrInvalidCredentials).Times(2)
+	mockAuthClient.EXPECT().LoginFull(gomock.Any(), "user", "password").Return(&authclient.AuthResult{Token: "jwt", UserID: "user-1", ExpiresAt: time.Now().Add(time.Hour)}, nil).Times(3)
+	mockAuthClient.EXPECT().LoginFull(gomock.Any(), "user", "busy").Return(nil, authclient.ErrUnavailable)
+	router := setupAuthRouter(NewAuthHandlerWithConfig(mockAuthClient, AuthHandlerConfig{
+		Captcha: CaptchaConfig{
+			Verifier:      testCaptcha,
+			LoginFailures: middleware.NewStoreLimiter(cache.NewMemory(0, nil), "", 2, time.Minute, nil),
+		},
+	}))
+
+	// Успешный вход и недоступность сервиса аутентификации не считаются неудачными попытками
+	require.Equal(t, http.StatusOK, doCaptchaRequest(router, "/login", "password", "").Code)
+	require.Equal(t, http.StatusServiceUnavailable, doCaptchaRequest(router, "/login", "busy", "").Code)
+
+	for i := 0; i < 2; i++ {
+		require.Equal(t, http.StatusUnauthorized, doCaptchaRequest(router, "/login", "wrong", "").Code)
+	}
+	w := doCaptchaRequest(router, "/api/v2/login", "password", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"captcha_required"`)
+	assert.Equal(t, http.StatusForbidden, doCaptchaRequest(router, "/login", "password", "wrong").Code)
+
+	assert.Equal(t, http.StatusOK, doCaptchaRequest(router, "/login", "password", "ok").Code)
+	assert.Equal(t, http.StatusOK, doCaptchaRequest(router, "/api/v2/login", "password", "ok").Code)
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/testutil"
+	"call-service/pkg/authclient"
+)
+
+// contractUser — зарегистрированный пользователь, на котором проверяются контракты.
+
+type contractUser struct {
+	username string
+	password string
+	token    string
+	userID   string
+}
+
+// authContract описывает поведение сервиса аутентификации, на которое опирается сервис заявок.
+// Каждый случай проверяется на настоящем клиенте и на mocks.MockAuthClient, настроенном через mockSetup
+// так же, как в тестах обработчиков.
+
+type authContract struct {
+	name      string
+	mockSetup func(m *mocks.MockAuthClient, u contractUser)
+	check     func(t *testing.T, client authclient.AuthClient, u contractUser)
+}
+
+// unknownUserID — ID, которого нет в сервисе аутентификации.
+var unknownUserID = uuid.MustParse("00000000-0000-4000-8000-000000000000")
+
+var authContracts = []authContract{
+	{
+		name: "invalid token is not an error",
+		mockSetup: func(m *mocks.MockAuthClient, u contractUser) {
+			m.EXPECT().ValidateTokenFull(gomock.Any(), "invalid-token").Return(&authclient.TokenInfo{Valid: false}, nil)
+			m.EXPECT().ValidateToken(gomock.Any(), "invalid-token").Return(false, "", nil)
+		},
+		check: func(t *testing.T, client authclient.AuthClient, u contractUser) {
+			info, err := client.ValidateTokenFull(context.Background(), "invalid-token")
+			require.NoError(t, err)
+			assert.Equal(t, &authclient.TokenInfo{Valid: false, UserID: "", Role: ""}, info)
+
+			valid, userID, err := client.ValidateToken(context.Background(), "invalid-token")
+			require.NoError(t, err)
+			assert.False(t, valid)
+			assert.Empty(t, userID)
+		},
+	},
+	{
+		name: "valid token carries user id and role",
+		mockSetup: func(m *mocks.MockAuthClient, u contractUser) {
+			m.EXPECT().ValidateTokenFull(gomock.Any(), u.token).Return(&authclient.TokenInfo{Valid: true, UserID: u.userID, Role: middleware.RoleUser}, nil)
+		},
+		check: func(t *testing.T, client authclient.AuthClient, u contractUser) {
+			info, err := client.ValidateTokenFull(context.Background(), u.token)
+			require.NoError(t, err)
+			assert.True(t, info.Valid)
+			assert.Equal(t, u.userID, info.UserID)
+			assert.Equal(t, middleware.RoleUser, info.Role)
+			_, err = uuid.Parse(info.UserID)
+			assert.NoError(t, err)
+		},
+	},
+	{
+		name: "duplicate register yields AlreadyExists",
+		mockSetup: func(m *mocks.MockAuthClient, u contractUser) {
+			m.EXPECT().Register(gomock.Any(), u.username, u.password).
+				Return("", "", authclient.FromStatus(status.Error(codes.AlreadyExists, "user already exists")))
+		},
+		check: func(t *testing.T, client authclient.AuthClient, u contractUser) {
+			token, userID, err := client.Register(context.Background(), u.username, u.password)
+			assert.Equal(t, codes.AlreadyExists, status.Code(err))
+			assert.ErrorIs(t, err, authclient.ErrUserExists)
+			assert.Empty(t, token)
+			assert.Empty(t, userID)
+		},
+	},
+	{
+		name: "wrong password yields Unauthenticated",
+		mockSetup: func(m *mocks.MockAuthClient, u contractUser) {
+			m.EXPECT().Login(gomock.Any(), u.username, "wrong-password").
+				Return("", "", authclient.FromStatus(status.Error(codes.Unauthenticated, "invalid credentials")))
+		},
+		check: func(t *testing.T, client authclient.AuthClient, u contractUser) {
+			token, userID, err := client.Login(context.Background(), u.username, "wrong-password")
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+			assert.ErrorIs(t, err, authclient.ErrInvalidCredentials)
+			assert.Empty(t, token)
+			assert.Empty(t, userID)
+		},
+	},
+	{
+		name: "unknown user yields Unauthenticated",
+		mockSetup: func(m *mocks.MockAuthClient, u contractUser) {
+			m.EXPECT().Login(gomock.Any(), "unknown-"+u.username, u.password).
+				Return("", "", authclient.FromStatus(status.Error(codes.Unauthenticated, "invalid credentials")))
+		},
+		check: func(t *testing.T, client authclient.AuthClient, u contractUser) {
+			_, _, err := client.Login(context.Background(), "unknown-"+u.username, u.password)
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+			assert.ErrorIs(t, err, authclient.ErrInvalidCredentials)
+		},
+	},
+	{
+		name: "get users skips unknown ids",
+		mockSetup: func(m *mocks.MockAuthClient, u contractUser) {
+			userID := uuid.MustParse(u.userID)
+			m.EXPECT().GetUsers(gomock.Any(), []uuid.UUID{userID, unknownUserID, userID}).
+				Return(map[uuid.UUID]authclient.UserInfo{userID: {UserID: u.userID, Username: u.username, CreatedAt: time.Now()}}, nil)
+		},
+		check: func(t *testing.T, client authclient.AuthClient, u contractUser) {
+			userID := uuid.MustParse(u.userID)
+			users, err := client.GetUsers(context.Background(), []uuid.UUID{userID, unknownUserID, userID})
+			require.NoError(t, err)
+			require.Len(t, users, 1)
+			assert.Equal(t, u.username, users[userID].Username)
+			assert.False(t, users[userID].CreatedAt.IsZero())
+		},
+	},
+	{
+		name: "login returns registered user id",
+		mockSetup: func(m *mocks.MockAuthClient, u contractUser) {
+			m.EXPECT().Login(gomock.Any(), u.username, u.password).Return(u.token, u.userID, nil)
+		},
+		check: func(t *testing.T, client authclient.AuthClient, u contractUser) {
+			token, userID, err := client.Login(context.Background(), u.username, u.password)
+			require.NoError(t, err)
+			assert.NotEmpty(t, token)
+			assert.Equal(t, u.userID, userID)
+		},
+	},
+	{
+		name: "login full returns refresh token and expiry",
+		mockSetup: func(m *mocks.MockAuthClient, u contractUser) {
+			m.EXPECT().LoginFull(gomock.Any(), u.username, u.password).Return(&authclient.AuthResult{
+				Token:        u.token,
+				UserID:       u.userID,
+				RefreshToken: "refresh-token",
+				ExpiresAt:    time.Now().Add(DefaultAccessTokenTTL),
+			}, nil)
+		},
+		check: func(t *testing.T, client authclient.AuthClient, u contractUser) {
+			result, err := client.LoginFull(context.Background(), u.username, u.password)
+			require.NoError(t, err)
+			assert.NotEmpty(t, result.Token)
+			assert.Equal(t, u.userID, result.UserID)
+			assert.NotEmpty(t, result.RefreshToken)
+			assert.WithinDuration(t, time.Now().Add(DefaultAccessTokenTTL), result.ExpiresAt, time.Minute)
+		},
+	},
+}
+
+// TestAuthClientContract проверяет контракты authContracts на настоящем сервисе аутентификации
+// (в режиме DEV_INMEMORY) и на mocks.MockAuthClient. Если сервис аутентификации изменит коды ошибок
+// или формат ответов, тест упадет, и моки в тестах обработчиков нужно будет привести в соответствие.
+
+func TestAuthClientContract(t *testing.T) {
+	if testing.Short() {
+		t.Skip("contract tests build and start auth-service")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	addr, stop, err := testutil.StartAuthService(ctx, "DEV_INMEMORY=true")
+	require.NoError(t, err)
+	t.Cleanup(stop)
+
+	client, err := authclient.NewAuthClient(addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	user := contractUser{username: "contract-" + uuid.NewString(), password: "password"}
+	user.token, user.userID, err = client.Register(ctx, user.username, user.password)
+	require.NoError(t, err)
+
+	for _, contract := range authContracts {
+		t.Run(contract.name+"/auth-service", func(t *testing.T) {
+			contract.check(t, client, user)
+		})
+		t.Run(contract.name+"/mock", func(t *testing.T) {
+			mockAuthClient := mocks.NewMockAuthClient(gomock.NewController(t))
+			contract.mockSetup(mockAuthClient, user)
+			contract.check(t, mockAuthClient, user)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/internal/model"
+	"call-service/internal/service"
+)
+
+// DefaultActivityPageLimit — количество записей ленты действий с заявкой по умолчанию.
+const DefaultActivityPageLimit = 50
+
+// NextCursorHeader — заголовок ответа с позицией следующей страницы ленты; отсутствует
+// на последней странице.
+const NextCursorHeader = "X-Next-Cursor"
+
+// GetCallActivity обрабатывает GET запрос на получение ленты действий с заявкой: изменений
+// статуса, попыток звонка и передач заявки в порядке времени. Страница задается параметрами
+// limit и after — значением заголовка X-Next-Cursor предыдущей страницы. Доступ к ленте —
+// как к самой заявке.
+func (h *CallHandler) GetCallActivity(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, i18n.Response(c, i18n.Unauthorized))
+		return
+	}
+
+	id := middleware.GetUUIDParam(c, "id")
+
+	limit := DefaultActivityPageLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxPageLimit {
+			c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidLimit, MaxPageLimit))
+			return
+		}
+	}
+	var after *model.ActivityCursor
+	if value := c.Query("after"); value != "" {
+		cursor, err := decodeActivityCursor(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidCursor))
+			return
+		}
+		after = &cursor
+	}
+
+	activity, next, err := h.callService.GetCallActivity(c.Request.Context(), id, userID, after, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrCallNotFound) {
+			c.JSON(http.StatusNotFound, i18n.Response(c, i18n.CallNotFound))
+			return
+		}
+		if errors.Is(err, service.ErrForbidden) {
+			c.JSON(http.StatusForbidden, i18n.Response(c, i18n.AccessDenied))
+			return
+		}
+		writeServerError(c, err, i18n.GetCallActivityFailed)
+		return
+	}
+
+	if next != nil {
+		c.Header(NextCursorHeader, encodeActivityCursor(*next))
+	}
+	if activity == nil {
+		activity = []*model.CallActivity{}
+	}
+	c.JSON(http.StatusOK, activity)
+}
+
+// encodeActivityCursor кодирует позицию в ленте в непрозрачную для клиента строку.
+func encodeActivityCursor(cursor model.ActivityCursor) string {
+	raw := cursor.At.UTC().Format(time.RFC3339Nano) + "|" + cursor.Type + "|" + cursor.Key
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeActivityCursor разбирает позицию, закодированную encodeActivityCursor.
+func decodeActivityCursor(s string) (model.ActivityCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return model.ActivityCursor{}, err
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 || parts[2] == "" {
+		return model.ActivityCursor{}, errors.New("malformed activity cursor")
+	}
+	switch parts[1] {
+	case model.ActivityAssignment, model.ActivityDialAttempt, model.ActivityStatusChange:
+	default:
+		return model.ActivityCursor{}, errors.New("unknown activity type in cursor")
+	}
+	at, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return model.ActivityCursor{}, err
+	}
+	return model.ActivityCursor{At: at, Type: parts[1], Key: parts[2]}, nil
+}
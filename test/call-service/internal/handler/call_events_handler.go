@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"call-service/internal/events"
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+)
+
+// Параметры соединений WebSocket по умолчанию (см. CallEventsConfig).
+const (
+	DefaultWSHandshakeTimeout = 10 * time.Second
+	DefaultWSPingInterval     = 30 * time.Second
+	DefaultWSWriteTimeout     = 10 * time.Second
+)
+
+// Типы служебных сообщений соединения WebSocket; сообщения о заявках имеют тип события
+// (events.CallCreated, events.CallUpdated, events.CallDeleted).
+const (
+	// WSMessageAuth — первое сообщение клиента, не передавшего токен в заголовке
+	// Authorization: {"type": "auth", "token": "<токен доступа>"}.
+	WSMessageAuth = "auth"
+	// WSMessageReady — сообщение сервера о том, что подписка оформлена и события,
+	// опубликованные после него, будут доставлены.
+	WSMessageReady = "ready"
+)
+
+// CallEventsConfig задает параметры соединений WebSocket.
+type CallEventsConfig struct {
+	// HandshakeTimeout — время, за которое клиент без заголовка Authorization должен прислать
+	// сообщение WSMessageAuth; 0 — DefaultWSHandshakeTimeout.
+	HandshakeTimeout time.Duration
+	// PingInterval — период проверки соединения сообщениями ping; 0 — DefaultWSPingInterval.
+	PingInterval time.Duration
+	// WriteTimeout — время на отправку одного сообщения или ответ pong; клиент, не уложившийся
+	// в него, отключается. 0 — DefaultWSWriteTimeout.
+	WriteTimeout time.Duration
+}
+
+// CallEventsHandler передает клиентам по WebSocket события о создании, изменении и удалении
+// доступных им заявок.
+type CallEventsHandler struct {
+	broker *events.Broker
+	auth   *middleware.AuthMiddleware
+	cfg    CallEventsConfig
+
+	// ctx отменяется в Shutdown; conns учитывает открытые соединения.
+	ctx    context.Context
+	stop   context.CancelFunc
+	mu     sync.Mutex
+	closed bool
+	conns  sync.WaitGroup
+}
+
+// NewCallEventsHandler создает новый экземпляр CallEventsHandler. auth проверяет токен,
+// переданный первым сообщением соединения.
+func NewCallEventsHandler(broker *events.Broker, auth *middleware.AuthMiddleware, cfg CallEventsConfig) *CallEventsHandler {
+	if cfg.HandshakeTimeout <= 0 {
+		cfg.HandshakeTimeout = DefaultWSHandshakeTimeout
+	}
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = DefaultWSPingInterval
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = DefaultWSWriteTimeout
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	return &CallEventsHandler{broker: broker, auth: auth, cfg: cfg, ctx: ctx, stop: stop}
+}
+
+// CallEventMessage — сообщение о событии заявки. Call — заявка после изменения;
+// отсутствует в событии удаления.
+type CallEventMessage struct {
+	Type   string        `json:"type"`
+	CallID uuid.UUID     `json:"call_id"`
+	At     time.Time     `json:"at"`
+	Call   *CallResponse `json:"call,omitempty"`
+}
+
+// wsControlMessage — служебное сообщение соединения: WSMessageAuth от клиента
+// или WSMessageReady от сервера.
+type wsControlMessage struct {
+	Type  string `json:"type"`
+	Token string `json:"token,omitempty"`
+}
+
+// Subscribe обрабатывает GET /calls/ws: устанавливает соединение WebSocket и передает
+// в него события заявок, доступных пользователю. Токен передается в заголовке Authorization
+// (или другим способом, принятым AuthMiddleware) либо первым сообщением WSMessageAuth.
+// Превышение числа соединений пользователя отклоняется ответом 429 до установки соединения
+// или закрытием с кодом 1008 после него; клиент, не успевающий читать события, отключается
+// с кодом 1013, а при остановке сервиса соединения закрываются с кодом 1001.
+func (h *CallEventsHandler) Subscribe(c *gin.Context) {
+	if !h.track() {
+		c.JSON(http.StatusServiceUnavailable, i18n.Response(c, i18n.ShuttingDown))
+		return
+	}
+	defer h.conns.Done()
+
+	var sub *events.Subscription
+	if principal, ok := middleware.GetPrincipal(c); ok {
+		var err error
+		if sub, err = h.broker.Subscribe(principal.UserID, principalOrg(principal)); err != nil {
+			c.JSON(http.StatusTooManyRequests, i18n.Response(c, i18n.TooManyConnections))
+			return
+		}
+		defer sub.Close()
+	}
+
+	conn, err := websocket.Accept(c.Writer, c.Request, nil)
+	if err != nil {
+		// Accept уже отправил ответ с ошибкой
+		return
+	}
+	defer conn.CloseNow()
+
+	if sub == nil {
+		if sub = h.handshake(c, conn); sub == nil {
+			return
+		}
+		defer sub.Close()
+	}
+
+	h.serve(c, conn, sub)
+}
+
+// Shutdown закрывает открытые соединения с кодом 1001 и ждет завершения их обработки,
+// но не дольше срока ctx. Новые соединения после вызова отклоняются ответом 503.
+func (h *CallEventsHandler) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	h.mu.Unlock()
+	h.stop()
+
+	finished := make(chan struct{})
+	go func() {
+		h.conns.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// track учитывает новое соединение; false после вызова Shutdown.
+func (h *CallEventsHandler) track() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	h.conns.Add(1)
+	return true
+}
+
+// handshake получает токен первым сообщением соединения, проверяет его и подписывает
+// пользователя на события. При ошибке закрывает соединение с кодом ошибки API в причине
+// закрытия и возвращает nil.
+func (h *CallEventsHandler) handshake(c *gin.Context, conn *websocket.Conn) *events.Subscription {
+	// Истечение ctx чтения разрывает соединение без кода закрытия, поэтому по истечении
+	// времени и при остановке сервиса соединение закрывается параллельно ожидающему чтению
+	timeout := time.AfterFunc(h.cfg.HandshakeTimeout, func() {
+		conn.Close(websocket.StatusPolicyViolation, string(i18n.TokenRequired))
+	})
+	stopShutdown := context.AfterFunc(h.ctx, func() {
+		conn.Close(websocket.StatusGoingAway, "")
+	})
+	var msg wsControlMessage
+	err := wsjson.Read(c.Request.Context(), conn, &msg)
+	timeout.Stop()
+	stopShutdown()
+	if err != nil {
+		return nil
+	}
+	if msg.Type != WSMessageAuth {
+		conn.Close(websocket.StatusPolicyViolation, string(i18n.TokenRequired))
+		return nil
+	}
+	if err := h.auth.Authenticate(c, msg.Token); err != nil {
+		var coded *i18n.Error
+		errors.As(err, &coded)
+		status := websocket.StatusPolicyViolation
+		if coded.Code == i18n.AuthUnavailable {
+			status = websocket.StatusTryAgainLater
+		}
+		conn.Close(status, string(coded.Code))
+		return nil
+	}
+
+	principal, _ := middleware.GetPrincipal(c)
+	sub, err := h.broker.Subscribe(principal.UserID, principalOrg(principal))
+	if err != nil {
+		conn.Close(websocket.StatusPolicyViolation, string(i18n.TooManyConnections))
+		return nil
+	}
+	return sub
+}
+
+// serve передает события подписки в соединение до его закрытия клиентом, отключения
+// подписки брокером или остановки сервиса.
+func (h *CallEventsHandler) serve(c *gin.Context, conn *websocket.Conn, sub *events.Subscription) {
+	// Клиент после подтверждения не присылает сообщений; CloseRead читает служебные кадры
+	// (pong, close) и отменяет ctx при закрытии соединения
+	ctx := conn.CloseRead(c.Request.Context())
+	lang := i18n.Lang(c.GetHeader("Accept-Language"))
+	userID, _ := middleware.GetUserID(c)
+
+	if err := h.write(ctx, conn, wsControlMessage{Type: WSMessageReady}); err != nil {
+		return
+	}
+
+	ping := time.NewTicker(h.cfg.PingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-h.ctx.Done():
+			conn.Close(websocket.StatusGoingAway, "")
+			return
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			pingCtx, cancel := context.WithTimeout(ctx, h.cfg.WriteTimeout)
+			err := conn.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				return
+			}
+		case e, ok := <-sub.Events():
+			if !ok {
+				log.Printf("websocket client of user %s is too slow, disconnecting: %v", userID, sub.Err())
+				conn.Close(websocket.StatusTryAgainLater, "")
+				return
+			}
+			msg := CallEventMessage{Type: e.Type, CallID: e.CallID, At: e.At}
+			if e.Call != nil {
+				resp := newCallResponse(e.Call, lang)
+				msg.Call = &resp
+			}
+			if err := h.write(ctx, conn, msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// write отправляет сообщение в соединение за время WriteTimeout.
+func (h *CallEventsHandler) write(ctx context.Context, conn *websocket.Conn, v any) error {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.WriteTimeout)
+	defer cancel()
+	return wsjson.Write(ctx, conn, v)
+}
+
+// principalOrg возвращает организацию пользователя или nil, если он не состоит в организации.
+func principalOrg(principal middleware.Principal) *uuid.UUID {
+	if principal.OrgID == uuid.Nil {
+		return nil
+	}
+	return &principal.OrgID
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/events"
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+)
+
+// setupEventsServer запускает HTTP-сервер с маршрутами POST /calls и GET /calls/ws, настоящим
+// сервисом заявок с репозиторием в памяти и брокером событий с параметрами brokerCfg.
+// Токен "owner-token" принадлежит возвращаемому пользователю, "bad-token" недействителен.
+
+func setupEventsServer(t *testing.T, brokerCfg events.Config, cfg CallEventsConfig) (*httptest.Server, *CallEventsHandler, *events.Broker, uuid.UUID) {
+	ownerID := uuid.New()
+	mockAuthClient := mocks.NewMockAuthClient(gomock.NewController(t))
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "owner-token").Return(&authclient.TokenInfo{Valid: true, UserID: ownerID.String(), Role: middleware.RoleUser}, nil).AnyTimes()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "bad-token").Return(&authclient.TokenInfo{Valid: false}, nil).AnyTimes()
+
+	broker := events.NewBroker(brokerCfg)
+	callService := service.NewCallService(repository.NewInMemoryCallRepository(), service.WithEvents(broker))
+	authMiddleware := middleware.NewAuthMiddleware(mockAuthClient)
+	eventsHandler := NewCallEventsHandler(broker, authMiddleware, cfg)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/calls", authMiddleware.AuthRequired(), NewCallHandler(callService, mockAuthClient).CreateCall)
+	router.GET("/calls/ws", authMiddleware.DeferredAuth(), eventsHandler.Subscribe)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server, eventsHandler, broker, ownerID
+}
+
+// dialEvents устанавливает соединение с GET /calls/ws; пустой token — без заголовка Authorization.
+
+func dialEvents(t *testing.T, ctx context.Context, server *httptest.Server, token string) (*websocket.Conn, *http.Response, error) {
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	conn, resp, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http")+"/calls/ws", &websocket.DialOptions{HTTPHeader: header})
+	if conn != nil {
+		t.Cleanup(func() { conn.CloseNow() })
+	}
+	return conn, resp, err
+}
+
+// readReady читает подтверждение подписки.
+
+func readReady(t *testing.T, ctx context.Context, conn *websocket.Conn) {
+	var msg wsControlMessage
+	require.NoError(t, wsjson.Read(ctx, conn, &msg))
+	require.Equal(t, WSMessageReady, msg.Type)
+}
+
+// TestCallEvents_ReceivesCreatedCall проверяет доставку события о заявке, созданной
+// через HTTP API, клиенту с токеном в заголовке Authorization.
+
+func TestCallEvents_ReceivesCreatedCall(t *testing.T) {
+	server, _, _, ownerID := setupEventsServer(t, events.Config{}, CallEventsConfig{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := dialEvents(t, ctx, server, "owner-token")
+	require.NoError(t, err)
+	readReady(t, ctx, conn)
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/calls",
+		strings.NewReader(`{"client_name":"Test Client","phone_number":"+1234567890","description":"Test Description"}`))
+	req.Header.Set("Authorization", "Bearer owner-token")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var msg CallEventMessage
+	require.NoError(t, wsjson.Read(ctx, conn, &msg))
+	assert.Equal(t, events.CallCreated, msg.Type)
+	require.NotNil(t, msg.Call)
+	assert.Equal(t, msg.CallID, msg.Call.ID)
+	assert.Equal(t, "Test Client", msg.Call.ClientName)
+	assert.Equal(t, ownerID, msg.Call.UserID)
+	assert.Equal(t, model.CallStatusOpen, msg.Call.Status)
+}
+
+// TestCallEvents_FirstMessageAuth проверяет передачу токена первым сообщением соединения:
+// действительный токен оформляет подписку, недействительный закрывает соединение с кодом 1008
+// и кодом ошибки в причине закрытия.
+
+func TestCallEvents_FirstMessageAuth(t *testing.T) {
+	server, _, broker, ownerID := setupEventsServer(t, events.Config{}, CallEventsConfig{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := dialEvents(t, ctx, server, "")
+	require.NoError(t, err)
+	require.NoError(t, wsjson.Write(ctx, conn, wsControlMessage{Type: WSMessageAuth, Token: "owner-token"}))
+	readReady(t, ctx, conn)
+	assert.Equal(t, 1, broker.Subscriptions(ownerID))
+
+	conn, _, err = dialEvents(t, ctx, server, "")
+	require.NoError(t, err)
+	require.NoError(t, wsjson.Write(ctx, conn, wsControlMessage{Type: WSMessageAuth, Token: "bad-token"}))
+	_, _, err = conn.Read(ctx)
+	var closeErr websocket.CloseError
+	require.True(t, errors.As(err, &closeErr), "unexpected error: %v", err)
+	assert.Equal(t, websocket.StatusPolicyViolation, closeErr.Code)
+	assert.Equal(t, "invalid_token", closeErr.Reason)
+}
+
+// TestCallEvents_HandshakeTimeout проверяет закрытие соединения клиента, не приславшего токен.
+
+func TestCallEvents_HandshakeTimeout(t *testing.T) {
+	server, _, _, _ := setupEventsServer(t, events.Config{}, CallEventsConfig{HandshakeTimeout: 50 * time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := dialEvents(t, ctx, server, "")
+	require.NoError(t, err)
+	_, _, err = conn.Read(ctx)
+	assert.Equal(t, websocket.StatusPolicyViolation, websocket.CloseStatus(err))
+}
+
+// TestCallEvents_TooManyConnections проверяет отказ 429 при превышении числа соединений пользователя.
+
+func TestCallEvents_TooManyConnections(t *testing.T) {
+	server, _, _, _ := setupEventsServer(t, events.Config{MaxPerUser: 1}, CallEventsConfig{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := dialEvents(t, ctx, server, "owner-token")
+	require.NoError(t, err)
+	readReady(t, ctx, conn)
+
+	_, resp, err := dialEvents(t, ctx, server, "owner-token")
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+}
+
+// TestCallEvents_SlowReaderDisconnected проверяет отключение клиента, который не читает события:
+// брокер отменяет его подписку, не блокируя публикацию, а сервер закрывает соединение.
+
+func TestCallEvents_SlowReaderDisconnected(t *testing.T) {
+	server, _, broker, ownerID := setupEventsServer(t, events.Config{BufferSize: 1}, CallEventsConfig{WriteTimeout: 200 * time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, _, err := dialEvents(t, ctx, server, "owner-token")
+	require.NoError(t, err)
+	readReady(t, ctx, conn)
+
+	// Крупные события быстро заполняют буферы TCP-соединения, после чего заполняется
+	// буфер подписки
+	call := &model.Call{ID: uuid.New(), UserID: ownerID, Status: model.CallStatusOpen, Description: strings.Repeat("x", 1<<20)}
+	require.Eventually(t, func() bool {
+		broker.Publish(events.Event{Type: events.CallUpdated, CallID: call.ID, Call: call, UserIDs: []uuid.UUID{ownerID}})
+		return broker.Subscriptions(ownerID) == 0
+	}, 5*time.Second, time.Millisecond)
+
+	// Клиент дочитывает отправленные события и получает закрытие соединения, а не истечение ctx
+	conn.SetReadLimit(2 << 20)
+	for err == nil {
+		_, _, err = conn.Read(ctx)
+	}
+	assert.NoError(t, ctx.Err())
+}
+
+// TestCallEvents_Shutdown проверяет закрытие соединений с кодом 1001 при остановке сервиса
+// и отказ 503 новым соединениям.
+
+func TestCallEvents_Shutdown(t *testing.T) {
+	server, eventsHandler, _, _ := setupEventsServer(t, events.Config{}, CallEventsConfig{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := dialEvents(t, ctx, server, "owner-token")
+	require.NoError(t, err)
+	readReady(t, ctx, conn)
+
+	closed := make(chan error, 1)
+	go func() {
+		_, _, err := conn.Read(ctx)
+		closed <- err
+	}()
+	require.NoError(t, eventsHandler.Shutdown(ctx))
+	assert.Equal(t, websocket.StatusGoingAway, websocket.CloseStatus(<-closed))
+
+	_, resp, err := dialEvents(t, ctx, server, "owner-token")
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
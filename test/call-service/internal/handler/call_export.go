@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/internal/model"
+	"call-service/internal/service"
+)
+
+// exportFlushRows — количество строк CSV, после которого накопленные данные отправляются клиенту.
+const exportFlushRows = 100
+
+// exportHeader — заголовок CSV-файла выгрузки заявок.
+var exportHeader = []string{"id", "client_name", "phone_number", "description", "status", "created_at", "user_id"}
+
+// ExportCalls обрабатывает GET запрос на выгрузку заявок пользователя в формате CSV.
+// Принимает те же параметры фильтрации и сортировки, что и список заявок; без limit
+// выгружаются все заявки. Строки читаются из базы данных и отправляются клиенту
+// по мере обхода, поэтому объем выгрузки не ограничен памятью сервиса.
+func (h *CallHandler) ExportCalls(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, i18n.Response(c, i18n.Unauthorized))
+		return
+	}
+
+	filter, err := parseCallFilter(c, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	// Заголовки ответа отправляются только вместе с первой строкой, чтобы ошибки,
+	// возникшие до начала выгрузки, можно было вернуть обычным JSON-ответом
+	w := csv.NewWriter(c.Writer)
+	rows := 0
+	err = h.callService.ForEachCall(c.Request.Context(), userID, filter, func(call *model.Call) error {
+		if rows == 0 {
+			writeExportHeader(c, w)
+		}
+		rows++
+		if err := w.Write(callRecord(call)); err != nil {
+			return err
+		}
+		if rows%exportFlushRows == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+		return w.Error()
+	})
+
+	if err != nil && rows == 0 {
+		if errors.Is(err, service.ErrInvalidStatus) {
+			c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidStatus))
+			return
+		}
+		writeServerError(c, err, i18n.ExportCallsFailed)
+		return
+	}
+	if err != nil {
+		// Часть файла уже отправлена, поэтому статус ответа изменить нельзя
+		log.Printf("call export interrupted after %d rows: %v", rows, err)
+		c.Abort()
+		return
+	}
+
+	if rows == 0 {
+		writeExportHeader(c, w)
+	}
+	w.Flush()
+}
+
+// writeExportHeader устанавливает заголовки ответа и записывает строку заголовка CSV.
+func writeExportHeader(c *gin.Context, w *csv.Writer) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="calls.csv"`)
+	c.Status(http.StatusOK)
+	_ = w.Write(exportHeader)
+}
+
+// callRecord преобразует заявку в строку CSV в порядке колонок exportHeader.
+func callRecord(call *model.Call) []string {
+	return []string{
+		call.ID.String(),
+		call.ClientName,
+		call.PhoneNumber,
+		call.Description,
+		string(call.Status),
+		call.CreatedAt.Format(time.RFC3339),
+		call.UserID.String(),
+	}
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"call-service/internal/i18n"
+	"call-service/internal/model"
+)
+
+// MaxPageLimit — максимальное количество заявок, возвращаемых за один запрос.
+const MaxPageLimit = 500
+
+// Значения параметра include списка заявок: IncludeSummary добавляет сводку по истории заявок,
+// IncludeFullDescription — полные описания вместо их начала.
+const (
+	IncludeSummary         = "summary"
+	IncludeFullDescription = "full_description"
+)
+
+// TotalCountHeader — заголовок ответа с общим количеством заявок, удовлетворяющих фильтру.
+const TotalCountHeader = "X-Total-Count"
+
+// callFilterParams — параметры строки запроса, которые разбирает parseCallFilter. Фильтры
+// сохраненных представлений проверяются по этому перечню, поэтому при изменении параметров
+// списка заявок он меняется вместе с parseCallQuery, а при переименовании или удалении
+// параметра увеличивается model.SavedViewSchemaVersion.
+var callFilterParams = []string{
+	"status", "phone_number", "created_from", "created_to", "sort", "order", "limit", "offset", "include",
+}
+
+// callQueryKey — ключ контекста запроса с параметрами списка заявок, объединенными
+// с фильтром сохраненного представления (см. SavedViewHandler.ApplyView).
+const callQueryKey = "call_query"
+
+// parseCallFilter разбирает параметры строки запроса в фильтр списка заявок. Если запрос
+// ссылается на сохраненное представление, разбираются параметры, объединенные с его фильтром.
+//
+// Поддерживаемые параметры: status, phone_number, created_from и created_to (RFC3339),
+// sort (created_at, client_name, status, callback_at), order (asc, desc), limit, offset
+// и include (значения через запятую: summary, full_description).
+// Если limit не передан, используется defaultLimit (0 — без ограничения).
+func parseCallFilter(c *gin.Context, defaultLimit int) (model.CallFilter, error) {
+	if query, ok := c.Get(callQueryKey); ok {
+		return parseCallQuery(query.(url.Values), defaultLimit)
+	}
+	return parseCallQuery(c.Request.URL.Query(), defaultLimit)
+}
+
+// parseCallQuery разбирает параметры query в фильтр списка заявок (см. parseCallFilter).
+func parseCallQuery(query url.Values, defaultLimit int) (model.CallFilter, error) {
+	filter := model.CallFilter{
+		Status:      model.ParseCallStatus(query.Get("status")),
+		PhoneNumber: query.Get("phone_number"),
+		SortBy:      model.SortByCreatedAt,
+		SortDesc:    true,
+		Limit:       defaultLimit,
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseTime(query.Get("created_from"), "created_from"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = parseTime(query.Get("created_to"), "created_to"); err != nil {
+		return filter, err
+	}
+
+	if sortBy := query.Get("sort"); sortBy != "" {
+		switch sortBy {
+		case model.SortByCreatedAt, model.SortByClientName, model.SortByStatus, model.SortByCallbackAt:
+			filter.SortBy = sortBy
+		default:
+			return filter, i18n.New(i18n.InvalidSortField)
+		}
+	}
+
+	switch query.Get("order") {
+	case "", "desc":
+		filter.SortDesc = true
+	case "asc":
+		filter.SortDesc = false
+	default:
+		return filter, i18n.New(i18n.InvalidSortOrder)
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxPageLimit {
+			return filter, i18n.New(i18n.InvalidLimit, MaxPageLimit)
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return filter, i18n.New(i18n.InvalidOffset)
+		}
+		filter.Offset = offset
+	}
+
+	if value := query.Get("include"); value != "" {
+		for _, include := range strings.Split(value, ",") {
+			switch strings.TrimSpace(include) {
+			case IncludeSummary:
+				filter.IncludeSummary = true
+			case IncludeFullDescription:
+				filter.IncludeFullDescription = true
+			default:
+				return filter, i18n.New(i18n.InvalidInclude, IncludeSummary+", "+IncludeFullDescription)
+			}
+		}
+	}
+
+	return filter, nil
+}
+
+// parseTimeQuery разбирает необязательный параметр строки запроса в формате RFC3339.
+func parseTimeQuery(c *gin.Context, name string) (*time.Time, error) {
+	return parseTime(c.Query(name), name)
+}
+
+// parseTime разбирает необязательное значение value параметра name в формате RFC3339.
+func parseTime(value, name string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, i18n.New(i18n.InvalidTime, name)
+	}
+	return &t, nil
+}
+
+// parseUserIDQuery разбирает необязательный параметр user_id строки запроса.
+func parseUserIDQuery(c *gin.Context) (*uuid.UUID, error) {
+	value := c.Query("user_id")
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, i18n.New(i18n.InvalidUserID)
+	}
+	return &id, nil
+}
+
+// writeCallList отправляет страницу заявок с названиями статусов на языке клиента
+// и общее количество в заголовке X-Total-Count. Полные описания заявок передаются только
+// с filter.IncludeFullDescription.
+func writeCallList(c *gin.Context, calls []*model.Call, total int, filter model.CallFilter) {
+	lang := i18n.Lang(c.GetHeader("Accept-Language"))
+	resp := make([]CallResponse, len(calls))
+	for i, call := range calls {
+		resp[i] = newCallListItem(call, lang, filter.IncludeFullDescription)
+	}
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, resp)
+}
+
+// writeCall отправляет заявку с названием статуса на языке клиента.
+func writeCall(c *gin.Context, code int, call *model.Call) {
+	c.JSON(code, newCallResponse(call, i18n.Lang(c.GetHeader("Accept-Language"))))
+}
@@ -1,178 +1,318 @@
-package handler
-
-import (
-	"net/http"
-
-	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-
-	"call-service/internal/middleware"
-	"call-service/internal/model"
-	"call-service/internal/service"
-	"call-service/pkg/authclient"
-)
-
-// CallHandler представляет обработчик HTTP запросов для работы с заявками
-
-type CallHandler struct {
-	callService service.CallService
-	authClient  authclient.AuthClient
-}
-
-// NewCallHandler создает новый экземпляр CallHandler
-
-func NewCallHandler(callService service.CallService, authClient authclient.AuthClient) *CallHandler {
-	return &CallHandler{callService: callService, authClient: authClient}
-}
-
-// CreateCall обрабатывает POST запрос на создание новой заявки
-
-func (h *CallHandler) CreateCall(c *gin.Context) {
-	userID, exists := middleware.GetUserID(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-
-	var req model.CreateCallRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	call, err := h.callService.CreateCall(c.Request.Context(), &req, userID)
-	if err != nil {
-		if err == service.ErrInvalidPhoneNumber {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid phone number format"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create call"})
-		return
-	}
-
-	c.JSON(http.StatusCreated, call)
-}
-
-// GetCall обрабатывает GET запрос на получение информации о заявке
-
-func (h *CallHandler) GetCall(c *gin.Context) {
-	userID, exists := middleware.GetUserID(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-
-	id, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid call ID"})
-		return
-	}
-
-	call, err := h.callService.GetCallByID(c.Request.Context(), id, userID)
-	if err != nil {
-		if err == service.ErrCallNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "call not found"})
-			return
-		}
-		if err == service.ErrForbidden {
-			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get call"})
-		return
-	}
-
-	c.JSON(http.StatusOK, call)
-}
-
-// GetAllCalls обрабатывает GET запрос на получение списка всех заявок пользователя
-
-func (h *CallHandler) GetAllCalls(c *gin.Context) {
-	userID, exists := middleware.GetUserID(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-
-	calls, err := h.callService.GetAllCalls(c.Request.Context(), userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get calls"})
-		return
-	}
-
-	c.JSON(http.StatusOK, calls)
-}
-
-// UpdateCallStatus обрабатывает PATCH запрос на обновление статуса заявки
-
-func (h *CallHandler) UpdateCallStatus(c *gin.Context) {
-	userID, exists := middleware.GetUserID(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-
-	id, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid call ID"})
-		return
-	}
-
-	var req model.UpdateCallStatusRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	err = h.callService.UpdateCallStatus(c.Request.Context(), id, req.Status, userID)
-	if err != nil {
-		if err == service.ErrCallNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "call not found"})
-			return
-		}
-		if err == service.ErrForbidden {
-			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
-			return
-		}
-		if err == service.ErrInvalidStatus {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update call status"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "status updated successfully"})
-}
-
-// DeleteCall обрабатывает DELETE запрос на удаление заявки
-
-func (h *CallHandler) DeleteCall(c *gin.Context) {
-	userID, exists := middleware.GetUserID(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-
-	id, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid call ID"})
-		return
-	}
-
-	err = h.callService.DeleteCall(c.Request.Context(), id, userID)
-	if err != nil {
-		if err == service.ErrCallNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "call not found"})
-			return
-		}
-		if err == service.ErrForbidden {
-			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete call"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "call deleted successfully"})
-}
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/internal/model"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+)
+
+// CallHandler представляет обработчик HTTP запросов для работы с заявками
+
+type CallHandler struct {
+	callService service.CallService
+	authClient  authclient.AuthClient
+}
+
+// NewCallHandler создает новый экземпляр CallHandler
+
+func NewCallHandler(callService service.CallService, authClient authclient.AuthClient) *CallHandler {
+	return &CallHandler{callService: callService, authClient: authClient}
+}
+
+// CreateCall обрабатывает POST запрос на создание новой заявки
+
+func (h *CallHandler) CreateCall(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, i18n.Response(c, i18n.Unauthorized))
+		return
+	}
+
+	var req model.CreateCallRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+	// Интеграции, которые не могут заранее проверить длину описания, передают truncate=true
+	req.Truncate = c.Query("truncate") == "true"
+
+	call, err := h.callService.CreateCall(c.Request.Context(), &req, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPhoneNumber) {
+			c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidPhoneNumber))
+			return
+		}
+		if errors.Is(err, service.ErrDescriptionTooLong) {
+			c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.DescriptionTooLong, model.MaxDescriptionLength))
+			return
+		}
+		if errors.Is(err, service.ErrCallbackInPast) {
+			c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.CallbackInPast))
+			return
+		}
+		writeServerError(c, err, i18n.CreateCallFailed)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, call.ID.String())
+	writeCall(c, http.StatusCreated, call)
+}
+
+// GetCall обрабатывает GET запрос на получение информации о заявке
+
+func (h *CallHandler) GetCall(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, i18n.Response(c, i18n.Unauthorized))
+		return
+	}
+
+	id := middleware.GetUUIDParam(c, "id")
+
+	call, err := h.callService.GetCallByID(c.Request.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrCallNotFound) {
+			c.JSON(http.StatusNotFound, i18n.Response(c, i18n.CallNotFound))
+			return
+		}
+		if errors.Is(err, service.ErrForbidden) {
+			c.JSON(http.StatusForbidden, i18n.Response(c, i18n.AccessDenied))
+			return
+		}
+		writeServerError(c, err, i18n.GetCallFailed)
+		return
+	}
+
+	writeCall(c, http.StatusOK, call)
+}
+
+// GetAllCalls обрабатывает GET запрос на получение списка заявок пользователя.
+// Поддерживает фильтрацию, сортировку и пагинацию через параметры строки запроса.
+
+func (h *CallHandler) GetAllCalls(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, i18n.Response(c, i18n.Unauthorized))
+		return
+	}
+
+	filter, err := parseCallFilter(c, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	calls, total, err := h.callService.GetAllCalls(c.Request.Context(), userID, filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidStatus) {
+			c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidStatus))
+			return
+		}
+		writeServerError(c, err, i18n.GetCallsFailed)
+		return
+	}
+
+	writeCallList(c, calls, total, filter)
+}
+
+// GetDueCalls обрабатывает GET запрос на получение незакрытых заявок пользователя, время
+// повторного звонка которых наступило. По умолчанию заявки упорядочены по времени звонка,
+// начиная с самого раннего; остальные параметры строки запроса — как у GetAllCalls.
+
+func (h *CallHandler) GetDueCalls(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, i18n.Response(c, i18n.Unauthorized))
+		return
+	}
+
+	filter, err := parseCallFilter(c, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+	if c.Query("sort") == "" {
+		filter.SortBy = model.SortByCallbackAt
+		filter.SortDesc = c.Query("order") == "desc"
+	}
+
+	calls, total, err := h.callService.GetDueCalls(c.Request.Context(), userID, filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidStatus) {
+			c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidStatus))
+			return
+		}
+		writeServerError(c, err, i18n.GetDueCallsFailed)
+		return
+	}
+
+	writeCallList(c, calls, total, filter)
+}
+
+// UpdateCallStatus обрабатывает PATCH запрос на обновление статуса заявки
+
+func (h *CallHandler) UpdateCallStatus(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, i18n.Response(c, i18n.Unauthorized))
+		return
+	}
+
+	id := middleware.GetUUIDParam(c, "id")
+
+	var req model.UpdateCallStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	err := h.callService.UpdateCallStatus(c.Request.Context(), id, model.ParseCallStatus(req.Status), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrCallNotFound) {
+			c.JSON(http.StatusNotFound, i18n.Response(c, i18n.CallNotFound))
+			return
+		}
+		if errors.Is(err, service.ErrForbidden) {
+			c.JSON(http.StatusForbidden, i18n.Response(c, i18n.AccessDenied))
+			return
+		}
+		if errors.Is(err, service.ErrInvalidStatus) {
+			c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidStatus))
+			return
+		}
+		writeServerError(c, err, i18n.UpdateCallStatusFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "status updated successfully"})
+}
+
+// UpdateCallback обрабатывает PATCH запрос на назначение времени повторного звонка.
+// Время передается в формате RFC3339 со смещением часового пояса; null снимает звонок.
+
+func (h *CallHandler) UpdateCallback(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, i18n.Response(c, i18n.Unauthorized))
+		return
+	}
+
+	id := middleware.GetUUIDParam(c, "id")
+
+	var req model.UpdateCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	err := h.callService.UpdateCallback(c.Request.Context(), id, req.CallbackAt, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrCallNotFound) {
+			c.JSON(http.StatusNotFound, i18n.Response(c, i18n.CallNotFound))
+			return
+		}
+		if errors.Is(err, service.ErrForbidden) {
+			c.JSON(http.StatusForbidden, i18n.Response(c, i18n.AccessDenied))
+			return
+		}
+		if errors.Is(err, service.ErrCallbackInPast) {
+			c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.CallbackInPast))
+			return
+		}
+		if errors.Is(err, service.ErrCallClosed) {
+			c.JSON(http.StatusConflict, i18n.Response(c, i18n.CallClosed))
+			return
+		}
+		writeServerError(c, err, i18n.UpdateCallbackFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "callback updated successfully"})
+}
+
+// DialCallResponse — ответ на запрос исходящего звонка: ID попытки в истории заявки
+// и ссылка провайдера телефонии на звонок.
+
+type DialCallResponse struct {
+	TrackingID        uuid.UUID `json:"tracking_id"`
+	ProviderReference string    `json:"provider_reference"`
+}
+
+// DialCall обрабатывает POST запрос на исходящий звонок клиенту заявки через провайдера
+// телефонии. Провайдер только начинает звонок, поэтому ответ — 202; отказ или недоступность
+// провайдера возвращается как 502 с сообщением провайдера.
+
+func (h *CallHandler) DialCall(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, i18n.Response(c, i18n.Unauthorized))
+		return
+	}
+
+	id := middleware.GetUUIDParam(c, "id")
+
+	attempt, err := h.callService.DialCall(c.Request.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrCallNotFound) {
+			c.JSON(http.StatusNotFound, i18n.Response(c, i18n.CallNotFound))
+			return
+		}
+		if errors.Is(err, service.ErrForbidden) {
+			c.JSON(http.StatusForbidden, i18n.Response(c, i18n.AccessDenied))
+			return
+		}
+		if errors.Is(err, service.ErrCallClosed) {
+			c.JSON(http.StatusConflict, i18n.Response(c, i18n.CallClosed))
+			return
+		}
+		if errors.Is(err, service.ErrDialingDisabled) {
+			c.JSON(http.StatusServiceUnavailable, i18n.Response(c, i18n.DialingDisabled))
+			return
+		}
+		var dialErr *service.DialError
+		if errors.As(err, &dialErr) {
+			c.JSON(http.StatusBadGateway, i18n.Response(c, i18n.TelephonyError, dialErr.Message))
+			return
+		}
+		writeServerError(c, err, i18n.DialCallFailed)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, DialCallResponse{TrackingID: *attempt.DialID, ProviderReference: attempt.DialReference})
+}
+
+// DeleteCall обрабатывает DELETE запрос на удаление заявки
+
+func (h *CallHandler) DeleteCall(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, i18n.Response(c, i18n.Unauthorized))
+		return
+	}
+
+	id := middleware.GetUUIDParam(c, "id")
+
+	err := h.callService.DeleteCall(c.Request.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrCallNotFound) {
+			c.JSON(http.StatusNotFound, i18n.Response(c, i18n.CallNotFound))
+			return
+		}
+		if errors.Is(err, service.ErrForbidden) {
+			c.JSON(http.StatusForbidden, i18n.Response(c, i18n.AccessDenied))
+			return
+		}
+		writeServerError(c, err, i18n.DeleteCallFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "call deleted successfully"})
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+)
+
+// failingCallRepository имитирует репозиторий, все методы поиска которого возвращают заданную ошибку.
+
+type failingCallRepository struct {
+	repository.CallRepository
+	err error
+}
+
+func (r *failingCallRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Call, error) {
+	return nil, fmt.Errorf("get call %s: %w", id, r.err)
+}
+
+// slowCallRepository имитирует медленную базу данных: List отвечает через delay
+// или возвращает ошибку, как только отменяется контекст запроса.
+
+type slowCallRepository struct {
+	repository.CallRepository
+	delay    time.Duration
+	canceled chan error
+}
+
+func (r *slowCallRepository) List(ctx context.Context, filter model.CallFilter) ([]*model.Call, int, error) {
+	select {
+	case <-time.After(r.delay):
+		return nil, 0, nil
+	case <-ctx.Done():
+		r.canceled <- ctx.Err()
+		return nil, 0, ctx.Err()
+	}
+}
+
+// TestRequestTimeout_SlowRepository проверяет, что при истечении срока запроса middleware.Timeout
+// репозиторий получает отмену контекста, а клиент — ответ 503, не дожидаясь медленного запроса.
+
+func TestRequestTimeout_SlowRepository(t *testing.T) {
+	testUserID := uuid.New()
+	mockAuthClient := mocks.NewMockAuthClient(gomock.NewController(t))
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "test-token").Return(&authclient.TokenInfo{Valid: true, UserID: testUserID.String(), Role: middleware.RoleUser}, nil)
+	repo := &slowCallRepository{delay: time.Minute, canceled: make(chan error, 1)}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Timeout(middleware.TimeoutConfig{Timeout: 50 * time.Millisecond}))
+	calls := router.Group("/calls", middleware.NewAuthMiddleware(mockAuthClient).AuthRequired())
+	calls.GET("", NewCallHandler(service.NewCallService(repo), mockAuthClient).GetAllCalls)
+
+	req, _ := http.NewRequest("GET", "/calls", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+	w := httptest.NewRecorder()
+	start := time.Now()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"error":"request timed out","code":"request_timed_out"}`, w.Body.String())
+	assert.Less(t, time.Since(start), 5*time.Second)
+	select {
+	case err := <-repo.canceled:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	default:
+		t.Fatal("repository did not observe request cancellation")
+	}
+}
+
+// TestRepositoryErrorStatusCodes проверяет коды ответа при ошибках репозитория.
+// Тестирует, что отсутствие заявки дает 404, а сбой базы данных — 500 или 504, но не 404.
+
+func TestRepositoryErrorStatusCodes(t *testing.T) {
+	testUserID := uuid.New()
+	testToken := "test-token"
+	testCallID := uuid.New()
+
+	repoErrors := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"not found", repository.ErrNotFound, http.StatusNotFound},
+		{"connection failure", errors.New("driver: bad connection"), http.StatusInternalServerError},
+		{"timeout", repository.ErrTimeout, http.StatusGatewayTimeout},
+	}
+	requests := []struct {
+		method string
+		body   string
+	}{
+		{"GET", ""},
+		{"PATCH", `{"status":"closed"}`},
+		{"DELETE", ""},
+	}
+
+	for _, repoErr := range repoErrors {
+		for _, r := range requests {
+			t.Run(repoErr.name+" "+r.method, func(t *testing.T) {
+				mockAuthClient := mocks.NewMockAuthClient(gomock.NewController(t))
+				mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), testToken).Return(&authclient.TokenInfo{Valid: true, UserID: testUserID.String(), Role: middleware.RoleUser}, nil)
+				callService := service.NewCallService(&failingCallRepository{err: repoErr.err})
+				router := setupRouter(callService, mockAuthClient)
+
+				// Создаем запрос
+				path := "/calls/" + testCallID.String()
+				if r.method == "PATCH" {
+					path += "/status"
+				}
+				req, _ := http.NewRequest(r.method, path, strings.NewReader(r.body))
+				req.Header.Set("Authorization", "Bearer "+testToken)
+
+				// Выполняем запрос
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				// Проверяем результат
+				assert.Equal(t, repoErr.want, w.Code)
+			})
+		}
+	}
+}
@@ -0,0 +1,530 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/cache"
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"call-service/internal/service"
+	"call-service/internal/telephony"
+	"call-service/pkg/authclient"
+	"common/clock"
+)
+
+// Тесты этого файла повторяют сценарии TestCreateCall, TestGetAllCalls, TestUpdateCallStatus,
+// TestDeleteCall и TestGetCall_Forbidden, но вместо mock-сервиса используют настоящий сервис
+// заявок с репозиторием в памяти. Так проверяется, что ожидания mock-объектов совпадают
+// с поведением реальной реализации.
+
+// setupInMemoryRouter создает маршрутизатор с настоящим сервисом заявок и репозиторием в памяти.
+// Токены "owner-token" и "other-token" принадлежат двум разным пользователям;
+// opts передаются сервису заявок (например, управляемые часы).
+
+func setupInMemoryRouter(t *testing.T, opts ...service.Option) (*gin.Engine, uuid.UUID) {
+	ownerID, otherID := uuid.New(), uuid.New()
+	mockAuthClient := mocks.NewMockAuthClient(gomock.NewController(t))
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "owner-token").Return(&authclient.TokenInfo{Valid: true, UserID: ownerID.String(), Role: middleware.RoleUser}, nil).AnyTimes()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "other-token").Return(&authclient.TokenInfo{Valid: true, UserID: otherID.String(), Role: middleware.RoleUser}, nil).AnyTimes()
+
+	callService := service.NewCallService(repository.NewInMemoryCallRepository(), opts...)
+	return setupRouter(callService, mockAuthClient), ownerID
+}
+
+// doInMemoryRequest выполняет запрос от имени пользователя с указанным токеном.
+
+func doInMemoryRequest(t *testing.T, router *gin.Engine, method, path, token, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	printRequestResponse(t, req, w)
+	return w
+}
+
+// TestInMemory_CallLifecycle проверяет создание, получение, список, обновление статуса и удаление заявки.
+
+func TestInMemory_CallLifecycle(t *testing.T) {
+	router, ownerID := setupInMemoryRouter(t)
+
+	// Создание заявки
+	w := doInMemoryRequest(t, router, "POST", "/calls", "owner-token",
+		`{"client_name":"Test Client","phone_number":"+1234567890","description":"Test Description"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created model.Call
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, ownerID, created.UserID)
+	assert.Equal(t, model.CallStatusOpen, created.Status)
+
+	// Получение заявки
+	w = doInMemoryRequest(t, router, "GET", "/calls/"+created.ID.String(), "owner-token", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Список заявок
+	w = doInMemoryRequest(t, router, "GET", "/calls", "owner-token", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get(TotalCountHeader))
+
+	// Обновление статуса
+	w = doInMemoryRequest(t, router, "PATCH", "/calls/"+created.ID.String()+"/status", "owner-token", `{"status":"closed"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doInMemoryRequest(t, router, "GET", "/calls?status=closed", "owner-token", "")
+	assert.Equal(t, "1", w.Header().Get(TotalCountHeader))
+
+	// Удаление заявки
+	w = doInMemoryRequest(t, router, "DELETE", "/calls/"+created.ID.String(), "owner-token", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doInMemoryRequest(t, router, "GET", "/calls/"+created.ID.String(), "owner-token", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// TestInMemory_Validation проверяет отклонение некорректного номера телефона и статуса.
+
+func TestInMemory_Validation(t *testing.T) {
+	router, _ := setupInMemoryRouter(t)
+
+	w := doInMemoryRequest(t, router, "POST", "/calls", "owner-token",
+		`{"client_name":"Test Client","phone_number":"invalid","description":"Test Description"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doInMemoryRequest(t, router, "POST", "/calls", "owner-token",
+		`{"client_name":"Test Client","phone_number":"+1234567890","description":"Test Description"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created model.Call
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	w = doInMemoryRequest(t, router, "PATCH", "/calls/"+created.ID.String()+"/status", "owner-token", `{"status":"invalid"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestInMemory_Forbidden проверяет, что пользователь не может работать с чужой заявкой.
+
+func TestInMemory_Forbidden(t *testing.T) {
+	router, _ := setupInMemoryRouter(t)
+
+	w := doInMemoryRequest(t, router, "POST", "/calls", "owner-token",
+		`{"client_name":"Test Client","phone_number":"+1234567890","description":"Test Description"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created model.Call
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	w = doInMemoryRequest(t, router, "GET", "/calls/"+created.ID.String(), "other-token", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doInMemoryRequest(t, router, "PATCH", "/calls/"+created.ID.String()+"/status", "other-token", `{"status":"closed"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doInMemoryRequest(t, router, "DELETE", "/calls/"+created.ID.String(), "other-token", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Чужие заявки не попадают в список
+	w = doInMemoryRequest(t, router, "GET", "/calls", "other-token", "")
+	assert.Equal(t, "0", w.Header().Get(TotalCountHeader))
+}
+
+// TestInMemory_LocalizedErrors проверяет, что язык сообщения об ошибке выбирается по заголовку
+// Accept-Language (с английским по умолчанию), а код ошибки от языка не зависит.
+
+func TestInMemory_LocalizedErrors(t *testing.T) {
+	router, _ := setupInMemoryRouter(t)
+	doLocalized := func(lang, token, body string) map[string]string {
+		req, _ := http.NewRequest("POST", "/calls", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if lang != "" {
+			req.Header.Set("Accept-Language", lang)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+	invalidPhone := `{"client_name":"Test Client","phone_number":"invalid phone","description":"Test Description"}`
+
+	assert.Equal(t, map[string]string{"error": "неверный формат номера телефона", "code": "invalid_phone_number"},
+		doLocalized("ru", "owner-token", invalidPhone))
+	assert.Equal(t, map[string]string{"error": "неверный формат номера телефона", "code": "invalid_phone_number"},
+		doLocalized("ru-RU,ru;q=0.9,en;q=0.8", "owner-token", invalidPhone))
+	assert.Equal(t, map[string]string{"error": "invalid phone number format", "code": "invalid_phone_number"},
+		doLocalized("", "owner-token", invalidPhone))
+	assert.Equal(t, map[string]string{"error": "invalid phone number format", "code": "invalid_phone_number"},
+		doLocalized("de-DE,fr;q=0.9", "owner-token", invalidPhone))
+
+	// Ошибки проверки полей и middleware аутентификации тоже переводятся
+	assert.Equal(t, map[string]string{"error": "поле phone_number обязательно", "code": "field_required"},
+		doLocalized("ru", "owner-token", `{"client_name":"Test Client","description":"Test Description"}`))
+	assert.Equal(t, map[string]string{"error": "field phone_number is required", "code": "field_required"},
+		doLocalized("en", "owner-token", `{"client_name":"Test Client","description":"Test Description"}`))
+	assert.Equal(t, map[string]string{"error": "требуется заголовок Authorization, API-ключ или cookie с токеном доступа", "code": "token_required"},
+		doLocalized("ru", "", invalidPhone))
+}
+
+// TestInMemory_LegacyStatuses проверяет, что устаревшие значения статусов на русском языке
+// принимаются в запросах, а ответы содержат канонический статус и его название на языке клиента.
+
+func TestInMemory_LegacyStatuses(t *testing.T) {
+	router, _ := setupInMemoryRouter(t)
+	w := doInMemoryRequest(t, router, "POST", "/calls", "owner-token",
+		`{"client_name":"Test Client","phone_number":"+1234567890","description":"Test Description"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created CallResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "Open", created.StatusLabel)
+
+	w = doInMemoryRequest(t, router, "PATCH", "/calls/"+created.ID.String()+"/status", "owner-token", `{"status":"закрыта"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	req, _ := http.NewRequest("GET", "/calls?status="+url.QueryEscape("закрыта"), nil)
+	req.Header.Set("Authorization", "Bearer owner-token")
+	req.Header.Set("Accept-Language", "ru")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var calls []CallResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &calls))
+	require.Len(t, calls, 1)
+	assert.Equal(t, model.CallStatusClosed, calls[0].Status)
+	assert.Equal(t, "Закрыта", calls[0].StatusLabel)
+
+	w = doInMemoryRequest(t, router, "PATCH", "/calls/"+created.ID.String()+"/status", "owner-token", `{"status":"в работе"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doInMemoryRequest(t, router, "PATCH", "/calls/"+created.ID.String()+"/status", "owner-token", `{"status":"in_progress"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestInMemory_Callbacks проверяет назначение повторного звонка: время со смещением хранится в UTC,
+// заявка попадает в /calls/due после наступления времени, а закрытие заявки снимает звонок.
+
+func TestInMemory_Callbacks(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC))
+	router, _ := setupInMemoryRouter(t, service.WithClock(fake))
+
+	w := doInMemoryRequest(t, router, "POST", "/calls", "owner-token",
+		`{"client_name":"Test Client","phone_number":"+1234567890","description":"Test Description","callback_at":"2025-03-01T08:00:00+03:00"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error": "callback time must be in the future", "code": "callback_in_past"}`, w.Body.String())
+
+	w = doInMemoryRequest(t, router, "POST", "/calls", "owner-token",
+		`{"client_name":"Test Client","phone_number":"+1234567890","description":"Test Description","callback_at":"2025-03-01T12:30:00+03:00"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"callback_at":"2025-03-01T09:30:00Z"`)
+	var created model.Call
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	// Время звонка еще не наступило
+	w = doInMemoryRequest(t, router, "GET", "/calls/due", "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get(TotalCountHeader))
+
+	fake.Advance(time.Hour)
+	w = doInMemoryRequest(t, router, "GET", "/calls/due", "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get(TotalCountHeader))
+	w = doInMemoryRequest(t, router, "GET", "/calls/due", "other-token", "")
+	assert.Equal(t, "0", w.Header().Get(TotalCountHeader))
+
+	// Закрытие снимает звонок, назначить новый на закрытую заявку нельзя
+	w = doInMemoryRequest(t, router, "PATCH", "/calls/"+created.ID.String()+"/status", "owner-token", `{"status":"closed"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doInMemoryRequest(t, router, "GET", "/calls/due", "owner-token", "")
+	assert.Equal(t, "0", w.Header().Get(TotalCountHeader))
+	w = doInMemoryRequest(t, router, "GET", "/calls/"+created.ID.String(), "owner-token", "")
+	assert.NotContains(t, w.Body.String(), "callback_at")
+	w = doInMemoryRequest(t, router, "PATCH", "/calls/"+created.ID.String()+"/callback", "owner-token", `{"callback_at":"2025-03-02T10:00:00Z"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Снять звонок можно в любом статусе
+	w = doInMemoryRequest(t, router, "PATCH", "/calls/"+created.ID.String()+"/callback", "owner-token", `{"callback_at":null}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestInMemory_DialCall проверяет исходящий звонок через провайдера телефонии: 202 с ID попытки,
+// 403 для чужой заявки, 502 с сообщением провайдера без номера телефона и 409 для закрытой заявки.
+
+func TestInMemory_DialCall(t *testing.T) {
+	dialer := mocks.NewMockDialer(gomock.NewController(t))
+	router, _ := setupInMemoryRouter(t, service.WithDialer(dialer, 0))
+
+	w := doInMemoryRequest(t, router, "POST", "/calls", "owner-token", `{"client_name":"Test Client","phone_number":"+1234567890","description":"Test Description"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created model.Call
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	path := "/calls/" + created.ID.String() + "/dial"
+
+	w = doInMemoryRequest(t, router, "POST", path, "other-token", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	gomock.InOrder(
+		dialer.EXPECT().Dial(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req telephony.DialRequest) (string, error) {
+			assert.Equal(t, created.ID, req.CallID)
+			assert.Equal(t, "+1234567890", req.PhoneNumber)
+			return "sip-" + req.TrackingID.String(), nil
+		}),
+		dialer.EXPECT().Dial(gomock.Any(), gomock.Any()).
+			Return("", &telephony.ProviderError{Provider: telephony.ProviderHTTP, Message: "number +1234567890\nis blocked"}),
+	)
+
+	w = doInMemoryRequest(t, router, "POST", path, "owner-token", "")
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var resp DialCallResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotEqual(t, uuid.Nil, resp.TrackingID)
+	assert.Equal(t, "sip-"+resp.TrackingID.String(), resp.ProviderReference)
+
+	w = doInMemoryRequest(t, router, "POST", path, "owner-token", "")
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Contains(t, w.Body.String(), "telephony_error")
+	assert.Contains(t, w.Body.String(), "number *** is blocked")
+	assert.NotContains(t, w.Body.String(), "+1234567890")
+
+	w = doInMemoryRequest(t, router, "PATCH", "/calls/"+created.ID.String()+"/status", "owner-token", `{"status":"closed"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doInMemoryRequest(t, router, "POST", path, "owner-token", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+// TestInMemory_ListSummary проверяет сводку по истории в списке заявок: поле summary
+// появляется только при include=summary, неизвестное значение include отклоняется.
+
+func TestInMemory_ListSummary(t *testing.T) {
+	router, ownerID := setupInMemoryRouter(t)
+
+	w := doInMemoryRequest(t, router, "POST", "/calls", "owner-token", `{"client_name":"Test Client","phone_number":"+1234567890","description":"Test Description"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created model.Call
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	w = doInMemoryRequest(t, router, "PATCH", "/calls/"+created.ID.String()+"/status", "owner-token", `{"status":"in_progress"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doInMemoryRequest(t, router, "GET", "/calls", "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "summary")
+
+	w = doInMemoryRequest(t, router, "GET", "/calls?include=summary&status=in_progress", "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var calls []model.Call
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &calls))
+	require.Len(t, calls, 1)
+	require.NotNil(t, calls[0].Summary)
+	assert.Equal(t, &ownerID, calls[0].Summary.LastStatusChangedBy)
+	assert.NotNil(t, calls[0].Summary.LastStatusChangeAt)
+
+	w = doInMemoryRequest(t, router, "GET", "/calls?include=notes", "owner-token", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_include")
+}
+
+// TestInMemory_DescriptionLimit проверяет ограничение длины описания: слишком длинное описание
+// отклоняется с кодом description_too_long, а с truncate=true обрезается с многоточием
+// и флагом truncated. В списке передается начало описания, полное — только с
+// include=full_description и в ответе с одной заявкой.
+
+func TestInMemory_DescriptionLimit(t *testing.T) {
+	router, _ := setupInMemoryRouter(t)
+	description := strings.Repeat("ж", model.MaxDescriptionLength+1)
+	body := `{"client_name":"Test Client","phone_number":"+1234567890","description":"` + description + `"}`
+
+	w := doInMemoryRequest(t, router, "POST", "/calls", "owner-token", body)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "description_too_long")
+
+	w = doInMemoryRequest(t, router, "POST", "/calls?truncate=true", "owner-token", body)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created CallResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.True(t, created.Truncated)
+	assert.Equal(t, model.MaxDescriptionLength, utf8.RuneCountInString(created.Description))
+	assert.True(t, strings.HasSuffix(created.Description, model.TruncationMarker))
+
+	// Описание допустимой длины не обрезается и флага truncated нет
+	w = doInMemoryRequest(t, router, "POST", "/calls?truncate=true", "owner-token", `{"client_name":"Test Client","phone_number":"+1234567890","description":"Test Description"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.NotContains(t, w.Body.String(), "truncated")
+
+	w = doInMemoryRequest(t, router, "GET", "/calls/"+created.ID.String(), "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var call CallResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &call))
+	assert.Equal(t, created.Description, call.Description)
+	assert.False(t, call.Truncated)
+
+	w = doInMemoryRequest(t, router, "GET", "/calls?sort=created_at&order=asc", "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var calls []CallResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &calls))
+	require.Len(t, calls, 2)
+	assert.Empty(t, calls[0].Description)
+	assert.Equal(t, model.DescriptionPreviewLength, utf8.RuneCountInString(calls[0].DescriptionPreview))
+	assert.Equal(t, "Test Description", calls[1].DescriptionPreview)
+
+	w = doInMemoryRequest(t, router, "GET", "/calls?sort=created_at&order=asc&include=summary,full_description", "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &calls))
+	require.Len(t, calls, 2)
+	assert.Equal(t, created.Description, calls[0].Description)
+	assert.NotEmpty(t, calls[0].DescriptionPreview)
+}
+
+// TestInMemory_CallActivity проверяет ленту действий с заявкой: попытки звонка со временем
+// из часов сервиса встают между изменениями статуса по времени, а не по порядку записи,
+// страницы читаются по заголовку X-Next-Cursor без пропусков и повторов, чужая заявка
+// и неверные параметры страницы отклоняются.
+
+func TestInMemory_CallActivity(t *testing.T) {
+	fake := clock.NewFake(time.Now().Add(-time.Hour))
+	dialer := mocks.NewMockDialer(gomock.NewController(t))
+	dialer.EXPECT().Dial(gomock.Any(), gomock.Any()).Return("sip-1", nil).Times(2)
+	router, ownerID := setupInMemoryRouter(t, service.WithClock(fake), service.WithDialer(dialer, 0))
+
+	w := doInMemoryRequest(t, router, "POST", "/calls", "owner-token", `{"client_name":"Test Client","phone_number":"+1234567890","description":"Test Description"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created model.Call
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	path := "/calls/" + created.ID.String()
+
+	// Первый звонок — на час раньше изменений статуса, второй — на час позже,
+	// хотя записан до закрытия заявки
+	require.Equal(t, http.StatusAccepted, doInMemoryRequest(t, router, "POST", path+"/dial", "owner-token", "").Code)
+	require.Equal(t, http.StatusOK, doInMemoryRequest(t, router, "PATCH", path+"/status", "owner-token", `{"status":"in_progress"}`).Code)
+	fake.Set(time.Now().Add(time.Hour))
+	require.Equal(t, http.StatusAccepted, doInMemoryRequest(t, router, "POST", path+"/dial", "owner-token", "").Code)
+	require.Equal(t, http.StatusOK, doInMemoryRequest(t, router, "PATCH", path+"/status", "owner-token", `{"status":"closed"}`).Code)
+
+	var activity []model.CallActivity
+	query := "?limit=1"
+	for range 10 {
+		w = doInMemoryRequest(t, router, "GET", path+"/activity"+query, "owner-token", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var page []model.CallActivity
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		activity = append(activity, page...)
+		next := w.Header().Get(NextCursorHeader)
+		if next == "" {
+			break
+		}
+		query = "?limit=1&after=" + url.QueryEscape(next)
+	}
+	require.Len(t, activity, 4)
+	types := make([]string, len(activity))
+	for i, item := range activity {
+		types[i] = item.Type
+		assert.Equal(t, ownerID, item.ActorID)
+	}
+	assert.Equal(t, []string{model.ActivityDialAttempt, model.ActivityStatusChange, model.ActivityStatusChange, model.ActivityDialAttempt}, types)
+	assert.Equal(t, model.CallStatusClosed, activity[2].NewStatus)
+	assert.Equal(t, "sip-1", activity[3].DialReference)
+
+	w = doInMemoryRequest(t, router, "GET", path+"/activity", "other-token", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doInMemoryRequest(t, router, "GET", path+"/activity?after=bm90LWEtY3Vyc29y", "owner-token", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_cursor")
+	w = doInMemoryRequest(t, router, "GET", path+"/activity?limit=0", "owner-token", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doInMemoryRequest(t, router, "GET", "/calls/"+uuid.NewString()+"/activity", "owner-token", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// TestInMemory_ResponseCache проверяет кэш ответов списка заявок с настоящим сервисом:
+// повторный запрос отдается из кэша, создание и удаление заявки сбрасывают ответы владельца,
+// а ответы другого пользователя из кэша не отдаются.
+
+func TestInMemory_ResponseCache(t *testing.T) {
+	ownerID, otherID := uuid.New(), uuid.New()
+	mockAuthClient := mocks.NewMockAuthClient(gomock.NewController(t))
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "owner-token").Return(&authclient.TokenInfo{Valid: true, UserID: ownerID.String(), Role: middleware.RoleUser}, nil).AnyTimes()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "other-token").Return(&authclient.TokenInfo{Valid: true, UserID: otherID.String(), Role: middleware.RoleUser}, nil).AnyTimes()
+	responseCache := middleware.NewResponseCache(cache.NewMemory(0, nil), time.Minute)
+	callService := service.NewCallService(repository.NewInMemoryCallRepository(), service.WithCacheInvalidator(responseCache))
+	callHandler := NewCallHandler(callService, mockAuthClient)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	calls := router.Group("/calls")
+	calls.Use(middleware.NewAuthMiddleware(mockAuthClient).AuthRequired())
+	calls.POST("", callHandler.CreateCall)
+	calls.GET("", Routes{ResponseCache: responseCache}.cached("calls", TotalCountHeader), callHandler.GetAllCalls)
+	calls.DELETE("/:id", middleware.BindUUIDParam("id", i18n.InvalidCallID), callHandler.DeleteCall)
+
+	w := doInMemoryRequest(t, router, "GET", "/calls", "owner-token", "")
+	assert.Equal(t, "MISS", w.Header().Get(middleware.CacheHeader))
+	w = doInMemoryRequest(t, router, "GET", "/calls", "owner-token", "")
+	assert.Equal(t, "HIT", w.Header().Get(middleware.CacheHeader))
+	assert.Equal(t, "0", w.Header().Get(TotalCountHeader))
+
+	w = doInMemoryRequest(t, router, "POST", "/calls", "owner-token", `{"client_name":"Test Client","phone_number":"+1234567890","description":"Test Description"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created model.Call
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	w = doInMemoryRequest(t, router, "GET", "/calls", "owner-token", "")
+	assert.Equal(t, "MISS", w.Header().Get(middleware.CacheHeader))
+	assert.Equal(t, "1", w.Header().Get(TotalCountHeader))
+	w = doInMemoryRequest(t, router, "GET", "/calls", "other-token", "")
+	assert.Equal(t, "MISS", w.Header().Get(middleware.CacheHeader))
+	assert.Equal(t, "0", w.Header().Get(TotalCountHeader))
+
+	w = doInMemoryRequest(t, router, "DELETE", "/calls/"+created.ID.String(), "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doInMemoryRequest(t, router, "GET", "/calls", "owner-token", "")
+	assert.Equal(t, "MISS", w.Header().Get(middleware.CacheHeader))
+	assert.Equal(t, "0", w.Header().Get(TotalCountHeader))
+	w = doInMemoryRequest(t, router, "GET", "/calls", "other-token", "")
+	assert.Equal(t, "HIT", w.Header().Get(middleware.CacheHeader))
+}
+
+// TestInMemory_ResponseCacheCloseStale проверяет, что закрытие давно открытых заявок сбрасывает
+// кэшированный список владельца: следующий запрос формируется заново и видит закрытую заявку.
+
+func TestInMemory_ResponseCacheCloseStale(t *testing.T) {
+	ownerID := uuid.New()
+	mockAuthClient := mocks.NewMockAuthClient(gomock.NewController(t))
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "owner-token").Return(&authclient.TokenInfo{Valid: true, UserID: ownerID.String(), Role: middleware.RoleUser}, nil).AnyTimes()
+	fake := clock.NewFake(time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC))
+	responseCache := middleware.NewResponseCache(cache.NewMemory(0, nil), time.Hour)
+	callService := service.NewCallService(repository.NewInMemoryCallRepository(), service.WithClock(fake), service.WithCacheInvalidator(responseCache))
+	callHandler := NewCallHandler(callService, mockAuthClient)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	calls := router.Group("/calls")
+	calls.Use(middleware.NewAuthMiddleware(mockAuthClient).AuthRequired())
+	calls.POST("", callHandler.CreateCall)
+	calls.GET("", Routes{ResponseCache: responseCache}.cached("calls", TotalCountHeader), callHandler.GetAllCalls)
+
+	w := doInMemoryRequest(t, router, "POST", "/calls", "owner-token", `{"client_name":"Test Client","phone_number":"+1234567890","description":"Test Description"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	w = doInMemoryRequest(t, router, "GET", "/calls?status=open", "owner-token", "")
+	assert.Equal(t, "MISS", w.Header().Get(middleware.CacheHeader))
+	assert.Equal(t, "1", w.Header().Get(TotalCountHeader))
+	w = doInMemoryRequest(t, router, "GET", "/calls?status=open", "owner-token", "")
+	assert.Equal(t, "HIT", w.Header().Get(middleware.CacheHeader))
+
+	fake.Advance(73 * time.Hour)
+	closed, err := callService.CloseStaleCalls(context.Background(), 72*time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 1, closed)
+
+	w = doInMemoryRequest(t, router, "GET", "/calls?status=open", "owner-token", "")
+	assert.Equal(t, "MISS", w.Header().Get(middleware.CacheHeader))
+	assert.Equal(t, "0", w.Header().Get(TotalCountHeader))
+}
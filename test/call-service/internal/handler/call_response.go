@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/i18n"
+	"call-service/internal/model"
+)
+
+// CallResponse — заявка в ответах HTTP API. Ответы собираются из model.Call функцией
+// newCallResponse, а не сериализацией модели, поэтому новые колонки таблицы calls не попадают
+// в API, пока их не добавят сюда. Необязательные поля — указатели или строки с omitempty
+// и отсутствуют в ответе, а не передаются нулевыми значениями; updated_by передается всегда
+// и равен null, пока заявку не изменяли. В списках вместо полного описания передается его
+// начало description_preview (см. newCallListItem); truncated передается только в ответе
+// на создание заявки, описание которой было обрезано.
+type CallResponse struct {
+	ID                 uuid.UUID            `json:"id"`
+	Ref                string               `json:"ref,omitempty"`
+	ClientName         string               `json:"client_name"`
+	PhoneNumber        string               `json:"phone_number"`
+	Description        string               `json:"description,omitempty"`
+	DescriptionPreview string               `json:"description_preview,omitempty"`
+	Truncated          bool                 `json:"truncated,omitempty"`
+	Status             model.CallStatus     `json:"status"`
+	StatusLabel        string               `json:"status_label"`
+	CreatedAt          time.Time            `json:"created_at"`
+	UserID             uuid.UUID            `json:"user_id"`
+	OrgID              *uuid.UUID           `json:"org_id,omitempty"`
+	CreatedBy          uuid.UUID            `json:"created_by"`
+	UpdatedBy          *uuid.UUID           `json:"updated_by"`
+	CreatedByName      string               `json:"created_by_name,omitempty"`
+	UpdatedByName      string               `json:"updated_by_name,omitempty"`
+	CallbackAt         *time.Time           `json:"callback_at,omitempty"`
+	Summary            *CallSummaryResponse `json:"summary,omitempty"`
+}
+
+// CallSummaryResponse — сводка по истории заявки в списках с include=summary.
+type CallSummaryResponse struct {
+	LastStatusChangeAt  *time.Time `json:"last_status_change_at"`
+	LastStatusChangedBy *uuid.UUID `json:"last_status_changed_by"`
+}
+
+// newCallResponse преобразует заявку в ответ API с названием статуса на языке lang.
+func newCallResponse(call *model.Call, lang string) CallResponse {
+	resp := CallResponse{
+		ID:            call.ID,
+		Ref:           call.Ref,
+		ClientName:    call.ClientName,
+		PhoneNumber:   call.PhoneNumber,
+		Description:   call.Description,
+		Status:        call.Status,
+		StatusLabel:   i18n.CallStatusLabel(lang, string(call.Status)),
+		CreatedAt:     call.CreatedAt,
+		UserID:        call.UserID,
+		OrgID:         nonNilUUID(call.OrgID),
+		CreatedBy:     call.CreatedBy,
+		UpdatedBy:     nonNilUUID(call.UpdatedBy),
+		CreatedByName: call.CreatedByName,
+		UpdatedByName: call.UpdatedByName,
+		CallbackAt:    nonZeroTime(call.CallbackAt),
+		Truncated:     call.Truncated,
+	}
+	if call.Summary != nil {
+		resp.Summary = &CallSummaryResponse{
+			LastStatusChangeAt:  nonZeroTime(call.Summary.LastStatusChangeAt),
+			LastStatusChangedBy: nonNilUUID(call.Summary.LastStatusChangedBy),
+		}
+	}
+	return resp
+}
+
+// newCallListItem преобразует заявку в элемент списка: передается начало описания длиной
+// model.DescriptionPreviewLength, а полное описание — только если fullDescription
+// (include=full_description).
+func newCallListItem(call *model.Call, lang string, fullDescription bool) CallResponse {
+	resp := newCallResponse(call, lang)
+	resp.DescriptionPreview, _ = model.TruncateText(call.Description, model.DescriptionPreviewLength)
+	if !fullDescription {
+		resp.Description = ""
+	}
+	return resp
+}
+
+// nonNilUUID возвращает nil вместо указателя на нулевой UUID.
+func nonNilUUID(id *uuid.UUID) *uuid.UUID {
+	if id == nil || *id == uuid.Nil {
+		return nil
+	}
+	return id
+}
+
+// nonZeroTime возвращает nil вместо указателя на нулевое время.
+func nonZeroTime(t *time.Time) *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+)
+
+// marshalCallResponse возвращает ответ с заявкой в виде разобранного JSON-объекта.
+
+func marshalCallResponse(t *testing.T, call *model.Call, lang string) map[string]any {
+	t.Helper()
+	data, err := json.Marshal(newCallResponse(call, lang))
+	require.NoError(t, err)
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	return fields
+}
+
+// TestNewCallResponse_OmitsEmptyOptionalFields проверяет, что незаданные необязательные поля,
+// в том числе указатели на нулевые UUID и время, отсутствуют в ответе, updated_by равен null,
+// а служебные поля модели в ответ не попадают.
+
+func TestNewCallResponse_OmitsEmptyOptionalFields(t *testing.T) {
+	notifiedAt := time.Now()
+	call := &model.Call{
+		ID:                 uuid.New(),
+		ClientName:         "Иван",
+		Status:             model.CallStatusOpen,
+		CreatedAt:          time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		UserID:             uuid.New(),
+		OrgID:              &uuid.Nil,
+		CallbackAt:         &time.Time{},
+		CallbackNotifiedAt: &notifiedAt,
+	}
+
+	fields := marshalCallResponse(t, call, "ru")
+
+	assert.Equal(t, "Открыта", fields["status_label"])
+	assert.Equal(t, "2025-03-01T12:00:00Z", fields["created_at"])
+	assert.Contains(t, fields, "updated_by")
+	assert.Nil(t, fields["updated_by"])
+	for _, name := range []string{"org_id", "callback_at", "created_by_name", "updated_by_name", "summary", "callback_notified_at"} {
+		assert.NotContains(t, fields, name)
+	}
+}
+
+// TestNewCallResponse_AllFields проверяет имена полей ответа для заявки со всеми заданными полями.
+
+func TestNewCallResponse_AllFields(t *testing.T) {
+	orgID, updatedBy := uuid.New(), uuid.New()
+	callbackAt := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+	call := &model.Call{
+		ID:            uuid.New(),
+		ClientName:    "Иван",
+		PhoneNumber:   "+79990000001",
+		Description:   "Не работает интернет",
+		Status:        model.CallStatusInProgress,
+		UserID:        uuid.New(),
+		OrgID:         &orgID,
+		CreatedBy:     uuid.New(),
+		UpdatedBy:     &updatedBy,
+		CreatedByName: "ivan",
+		UpdatedByName: "admin",
+		CallbackAt:    &callbackAt,
+		Summary:       &model.CallSummary{LastStatusChangedBy: &updatedBy},
+	}
+
+	fields := marshalCallResponse(t, call, "en")
+
+	assert.Equal(t, call.ID.String(), fields["id"])
+	assert.Equal(t, "in_progress", fields["status"])
+	assert.Equal(t, "In progress", fields["status_label"])
+	assert.Equal(t, orgID.String(), fields["org_id"])
+	assert.Equal(t, updatedBy.String(), fields["updated_by"])
+	assert.Equal(t, "admin", fields["updated_by_name"])
+	assert.Equal(t, "2025-03-01T09:30:00Z", fields["callback_at"])
+	assert.Equal(t, map[string]any{
+		"last_status_change_at":  nil,
+		"last_status_changed_by": updatedBy.String(),
+	}, fields["summary"])
+	assert.Len(t, fields, 15)
+}
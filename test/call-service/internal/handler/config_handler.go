@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/i18n"
+)
+
+// ConfigReloader перечитывает конфигурацию и возвращает имена примененных параметров
+// и параметров, изменения которых требуют перезапуска (см. app.App.ReloadConfig).
+type ConfigReloader interface {
+	ReloadConfig() (applied, restartRequired []string, err error)
+}
+
+// ConfigReloadResponse — результат перечитывания конфигурации.
+type ConfigReloadResponse struct {
+	Applied         []string `json:"applied"`
+	RestartRequired []string `json:"restart_required"`
+}
+
+// ConfigHandler перечитывает конфигурацию сервиса без перезапуска. Доступ к маршруту
+// ограничивается middleware RequireRole.
+type ConfigHandler struct {
+	reloader ConfigReloader
+}
+
+// NewConfigHandler создает новый экземпляр ConfigHandler.
+func NewConfigHandler(reloader ConfigReloader) *ConfigHandler {
+	return &ConfigHandler{reloader: reloader}
+}
+
+// ReloadConfig обрабатывает POST запрос на перечитывание конфигурации. Если конфигурацию
+// не удалось прочитать, прежние значения продолжают действовать, а клиент получает 500.
+// Конфигурация перечитывается только в экземпляре, получившем запрос.
+func (h *ConfigHandler) ReloadConfig(c *gin.Context) {
+	applied, restartRequired, err := h.reloader.ReloadConfig()
+	if err != nil {
+		log.Printf("failed to reload config: %v", err)
+		c.JSON(http.StatusInternalServerError, i18n.Response(c, i18n.ReloadConfigFailed))
+		return
+	}
+	// Пустые списки передаются как [], а не null
+	c.JSON(http.StatusOK, ConfigReloadResponse{
+		Applied:         append([]string{}, applied...),
+		RestartRequired: append([]string{}, restartRequired...),
+	})
+}
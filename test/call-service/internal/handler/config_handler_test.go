@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/pkg/authclient"
+)
+
+// fakeConfigReloader — ConfigReloader с заданным результатом.
+type fakeConfigReloader struct {
+	applied, restartRequired []string
+	err                      error
+	calls                    int
+}
+
+func (r *fakeConfigReloader) ReloadConfig() ([]string, []string, error) {
+	r.calls++
+	return r.applied, r.restartRequired, r.err
+}
+
+// TestReloadConfig проверяет, что конфигурацию перечитывает только администратор, в том числе
+// в режиме обслуживания, а ответ перечисляет примененные параметры и параметры, требующие перезапуска.
+
+func TestReloadConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), adminToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: adminUserID.String(), Role: middleware.RoleAdmin}, nil).AnyTimes()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), userToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: uuid.New().String(), Role: middleware.RoleUser}, nil).AnyTimes()
+	reloader := &fakeConfigReloader{applied: []string{"LogLevel"}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, Routes{
+		Auth:           NewAuthHandler(mockAuthClient),
+		Docs:           NewDocsHandler(nil),
+		Config:         NewConfigHandler(reloader),
+		Maintenance:    middleware.NewMaintenance(true, 0),
+		AuthMiddleware: middleware.NewAuthMiddleware(mockAuthClient),
+	})
+
+	assert.Equal(t, http.StatusForbidden, doInMemoryRequest(t, router, http.MethodPost, "/admin/config/reload", userToken, "").Code)
+	assert.Zero(t, reloader.calls)
+
+	w := doInMemoryRequest(t, router, http.MethodPost, "/admin/config/reload", adminToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"applied": ["LogLevel"], "restart_required": []}`, w.Body.String())
+
+	reloader.err = errors.New("invalid LOG_LEVEL")
+	w = doInMemoryRequest(t, router, http.MethodPost, "/admin/config/reload", adminToken, "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "reload_config_failed")
+}
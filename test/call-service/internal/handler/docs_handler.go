@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// swaggerUIPage — страница Swagger UI, загружающая спецификацию сервиса.
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+  <meta charset="utf-8">
+  <title>Call Service API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "/api/v1/openapi.json", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>`
+
+// DocsHandler отдает спецификацию OpenAPI и страницу Swagger UI.
+type DocsHandler struct {
+	spec []byte
+}
+
+// NewDocsHandler создает обработчик документации для переданной спецификации в формате JSON.
+func NewDocsHandler(spec []byte) *DocsHandler {
+	return &DocsHandler{spec: spec}
+}
+
+// OpenAPISpec возвращает спецификацию OpenAPI.
+func (h *DocsHandler) OpenAPISpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", h.spec)
+}
+
+// SwaggerUI возвращает страницу Swagger UI.
+func (h *DocsHandler) SwaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/internal/repository"
+	"call-service/pkg/authclient"
+	"common/querybuilder"
+)
+
+// writeServerError отправляет ответ на непредвиденную ошибку сервисного слоя:
+// 503, если истек срок обработки всего HTTP-запроса (middleware.Timeout), 504, если
+// не уложился в отведенное время отдельный запрос к базе данных, 400, если фильтр списка
+// не допускается реестром полей репозитория, иначе 500 с кодом code.
+func writeServerError(c *gin.Context, err error, code i18n.Code) {
+	if middleware.DeadlineExceeded(c) {
+		c.JSON(http.StatusServiceUnavailable, i18n.Response(c, i18n.RequestTimedOut))
+		return
+	}
+	if errors.Is(err, repository.ErrTimeout) {
+		c.JSON(http.StatusGatewayTimeout, i18n.Response(c, i18n.RequestTimedOut))
+		return
+	}
+	var filterErr *querybuilder.FilterError
+	if errors.As(err, &filterErr) {
+		c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidFilter, filterErr.Field))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, i18n.Response(c, code))
+}
+
+// authMessageCodes сопоставляет сообщения ошибок сервиса аутентификации с кодами ошибок API.
+var authMessageCodes = map[string]i18n.Code{
+	"username and password are required":             i18n.CredentialsRequired,
+	"invalid email":                                  i18n.InvalidEmail,
+	"email already in use":                           i18n.EmailInUse,
+	"invalid or expired verification token":          i18n.InvalidVerificationToken,
+	"invalid session ID":                             i18n.InvalidSessionID,
+	"invalid user ID":                                i18n.InvalidUserID,
+	"session not found":                              i18n.SessionNotFound,
+	"user not found":                                 i18n.UserNotFound,
+	"invalid organization ID":                        i18n.InvalidOrganizationID,
+	"invalid organization name":                      i18n.InvalidOrganizationName,
+	"user already belongs to an organization":        i18n.AlreadyInOrganization,
+	"only the organization owner can invite members": i18n.NotOrganizationOwner,
+	"organizations are disabled":                     i18n.OrganizationsDisabled,
+	"invalid invite ID":                              i18n.InvalidInviteID,
+	"invalid invite lifetime":                        i18n.InvalidInviteExpiry,
+	"invalid or expired invite code":                 i18n.InvalidInvite,
+	"invite not found":                               i18n.InviteNotFound,
+	"administrators cannot be impersonated":          i18n.CannotImpersonateAdmin,
+	"unknown identity provider":                      i18n.UnknownIdentityProvider,
+	"OIDC login is disabled":                         i18n.OIDCLoginDisabled,
+}
+
+// writeAuthError отправляет ответ на ошибку вызова сервиса аутентификации. Ошибки клиента
+// (неверные данные, конфликт, отсутствие записи) передаются с кодом, соответствующим сообщению
+// сервиса аутентификации, неверные учетные данные — как 401, отказ в доступе — как 403,
+// отключенная в сервисе аутентификации функция — как 501, недоступность сервиса
+// аутентификации — как 503, истечение срока вызова — как 504, превышение ограничения частоты
+// вызовов — как 429, остальные ошибки — как 500 с кодом code.
+// Текст ошибки gRPC с транспортными подробностями клиенту не передается.
+func writeAuthError(c *gin.Context, err error, code i18n.Code) {
+	if middleware.DeadlineExceeded(c) {
+		c.JSON(http.StatusServiceUnavailable, i18n.Response(c, i18n.RequestTimedOut))
+		return
+	}
+	switch {
+	case errors.Is(err, authclient.ErrUserExists):
+		c.JSON(http.StatusConflict, i18n.Response(c, i18n.UserExists))
+		return
+	case errors.Is(err, authclient.ErrInvalidCredentials):
+		c.JSON(http.StatusUnauthorized, i18n.Response(c, i18n.InvalidCredentials))
+		return
+	case errors.Is(err, authclient.ErrUnavailable):
+		c.JSON(http.StatusServiceUnavailable, i18n.Response(c, i18n.AuthUnavailable))
+		return
+	}
+	st := status.Convert(err)
+	switch st.Code() {
+	case codes.InvalidArgument:
+		c.JSON(http.StatusBadRequest, i18n.Response(c, authMessageCode(st.Message(), i18n.InvalidRequest)))
+	case codes.AlreadyExists:
+		c.JSON(http.StatusConflict, i18n.Response(c, authMessageCode(st.Message(), i18n.Conflict)))
+	case codes.NotFound:
+		c.JSON(http.StatusNotFound, i18n.Response(c, authMessageCode(st.Message(), i18n.NotFound)))
+	case codes.PermissionDenied:
+		c.JSON(http.StatusForbidden, i18n.Response(c, authMessageCode(st.Message(), i18n.AccessDenied)))
+	case codes.Unimplemented:
+		c.JSON(http.StatusNotImplemented, i18n.Response(c, authMessageCode(st.Message(), code)))
+	case codes.Unavailable:
+		c.JSON(http.StatusServiceUnavailable, i18n.Response(c, i18n.AuthUnavailable))
+	case codes.DeadlineExceeded:
+		c.JSON(http.StatusGatewayTimeout, i18n.Response(c, i18n.RequestTimedOut))
+	case codes.ResourceExhausted:
+		c.JSON(http.StatusTooManyRequests, i18n.Response(c, i18n.TooManyRequests))
+	default:
+		c.JSON(http.StatusInternalServerError, i18n.Response(c, code))
+	}
+}
+
+// authMessageCode возвращает код ошибки API для сообщения сервиса аутентификации
+// или fallback, если сообщение неизвестно.
+func authMessageCode(message string, fallback i18n.Code) i18n.Code {
+	if code, ok := authMessageCodes[message]; ok {
+		return code
+	}
+	return fallback
+}
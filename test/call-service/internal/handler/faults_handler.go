@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+)
+
+// FaultsRequest — новые правила внесения сбоев в формате middleware.ParseFaultRule;
+// пустой список отключает внесение сбоев.
+type FaultsRequest struct {
+	Rules []string `json:"rules" binding:"required"`
+}
+
+// FaultsResponse — действующие правила внесения сбоев.
+type FaultsResponse struct {
+	Rules []string `json:"rules"`
+}
+
+// FaultsHandler показывает и заменяет правила внесения сбоев. Маршруты регистрируются
+// только вне production-режима, доступ к ним ограничивается middleware RequireRole.
+type FaultsHandler struct {
+	faults *middleware.FaultInjector
+}
+
+// NewFaultsHandler создает новый экземпляр FaultsHandler.
+func NewFaultsHandler(faults *middleware.FaultInjector) *FaultsHandler {
+	return &FaultsHandler{faults: faults}
+}
+
+// GetFaults обрабатывает GET запрос действующих правил внесения сбоев.
+func (h *FaultsHandler) GetFaults(c *gin.Context) {
+	c.JSON(http.StatusOK, faultsResponse(h.faults.Rules()))
+}
+
+// SetFaults обрабатывает PUT запрос на замену правил внесения сбоев и возвращает новые
+// правила. Если хотя бы одно правило неверно, прежние правила продолжают действовать.
+// Правила заменяются только в экземпляре, получившем запрос.
+func (h *FaultsHandler) SetFaults(c *gin.Context) {
+	var req FaultsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	rules := make([]middleware.FaultRule, 0, len(req.Rules))
+	for _, s := range req.Rules {
+		rule, err := middleware.ParseFaultRule(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidField, "rules"))
+			return
+		}
+		rules = append(rules, rule)
+	}
+	h.faults.SetRules(rules)
+	c.JSON(http.StatusOK, faultsResponse(h.faults.Rules()))
+}
+
+// faultsResponse преобразует правила в ответ API.
+func faultsResponse(rules []middleware.FaultRule) FaultsResponse {
+	resp := FaultsResponse{Rules: make([]string, 0, len(rules))}
+	for _, rule := range rules {
+		resp.Rules = append(resp.Rules, rule.String())
+	}
+	return resp
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/repository"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+)
+
+// TestFaults проверяет замену правил внесения сбоев администратором: неверное правило
+// отклоняется без изменения действующих, новые правила сразу применяются к запросам,
+// а сами правила остаются доступными, чтобы сбои можно было отключить.
+
+func TestFaults(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), adminToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: adminUserID.String(), Role: middleware.RoleAdmin}, nil).AnyTimes()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), userToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: uuid.New().String(), Role: middleware.RoleUser}, nil).AnyTimes()
+	callService := service.NewCallService(repository.NewInMemoryCallRepository())
+	faults := middleware.NewFaultInjector(nil, nil)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, Routes{
+		Auth:           NewAuthHandler(mockAuthClient),
+		Calls:          NewCallHandler(callService, mockAuthClient),
+		Admin:          NewAdminHandler(callService),
+		Docs:           NewDocsHandler(nil),
+		Faults:         faults,
+		AuthMiddleware: middleware.NewAuthMiddleware(mockAuthClient),
+	})
+
+	// Изменять правила может только администратор
+	w := doInMemoryRequest(t, router, http.MethodPut, "/admin/faults", userToken, `{"rules": []}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doInMemoryRequest(t, router, http.MethodPut, "/admin/faults", adminToken, `{"rules": ["GET /calls 2 reset"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, faults.Rules())
+
+	w = doInMemoryRequest(t, router, http.MethodPut, "/admin/faults", adminToken, `{"rules": ["* * 1 status=503"]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"rules": ["* * 1 status=503"]}`, w.Body.String())
+	w = doInMemoryRequest(t, router, http.MethodGet, "/calls", userToken, "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	w = doInMemoryRequest(t, router, http.MethodGet, "/admin/faults", adminToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doInMemoryRequest(t, router, http.MethodPut, "/admin/faults", adminToken, `{"rules": []}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doInMemoryRequest(t, router, http.MethodGet, "/calls", userToken, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/featureflags"
+	"call-service/internal/i18n"
+)
+
+// FeatureFlagsResponse — действующие значения флагов.
+type FeatureFlagsResponse struct {
+	Flags map[featureflags.Flag]featureflags.Rule `json:"flags"`
+}
+
+// FeatureFlagsHandler показывает и перечитывает флаги постепенного включения поведения.
+// Доступ к маршрутам ограничивается middleware RequireRole.
+type FeatureFlagsHandler struct {
+	flags *featureflags.Store
+}
+
+// NewFeatureFlagsHandler создает новый экземпляр FeatureFlagsHandler.
+func NewFeatureFlagsHandler(flags *featureflags.Store) *FeatureFlagsHandler {
+	return &FeatureFlagsHandler{flags: flags}
+}
+
+// GetFeatureFlags обрабатывает GET запрос действующих значений флагов.
+func (h *FeatureFlagsHandler) GetFeatureFlags(c *gin.Context) {
+	c.JSON(http.StatusOK, FeatureFlagsResponse{Flags: h.flags.Rules()})
+}
+
+// ReloadFeatureFlags обрабатывает POST запрос на перечитывание файла флагов и возвращает
+// новые значения. Если файл не удалось прочитать, прежние значения продолжают действовать,
+// а клиент получает 500. Флаги перечитываются только в экземпляре, получившем запрос.
+func (h *FeatureFlagsHandler) ReloadFeatureFlags(c *gin.Context) {
+	if err := h.flags.Reload(); err != nil {
+		log.Printf("failed to reload feature flags: %v", err)
+		c.JSON(http.StatusInternalServerError, i18n.Response(c, i18n.ReloadFeatureFlagsFailed))
+		return
+	}
+	c.JSON(http.StatusOK, FeatureFlagsResponse{Flags: h.flags.Rules()})
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/featureflags"
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/pkg/authclient"
+)
+
+// TestFeatureFlags проверяет просмотр и перечитывание флагов администратором: новые значения
+// применяются после перечитывания, а ошибка чтения файла сохраняет прежние.
+
+func TestFeatureFlags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), adminToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: adminUserID.String(), Role: middleware.RoleAdmin}, nil).AnyTimes()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), userToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: uuid.New().String(), Role: middleware.RoleUser}, nil).AnyTimes()
+	path := filepath.Join(t.TempDir(), "flags.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"resolve_usernames": {"enabled": false}}`), 0o600))
+	flags, err := featureflags.NewStore("", path)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, Routes{
+		Auth:           NewAuthHandler(mockAuthClient),
+		Docs:           NewDocsHandler(nil),
+		FeatureFlags:   NewFeatureFlagsHandler(flags),
+		AuthMiddleware: middleware.NewAuthMiddleware(mockAuthClient),
+	})
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/admin/feature-flags", userToken).Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/admin/feature-flags/reload", userToken).Code)
+	w := do(http.MethodGet, "/admin/feature-flags", adminToken)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"flags": {"degraded_auth": {"enabled": true}, "resolve_usernames": {"enabled": false}}}`, w.Body.String())
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"beta": {"enabled": false, "percentage": 10}}`), 0o600))
+	w = do(http.MethodPost, "/admin/feature-flags/reload", adminToken)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"flags": {"degraded_auth": {"enabled": true}, "resolve_usernames": {"enabled": true}, "beta": {"enabled": false, "percentage": 10}}}`, w.Body.String())
+
+	require.NoError(t, os.Remove(path))
+	w = do(http.MethodPost, "/admin/feature-flags/reload", adminToken)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, flags.Rules(), featureflags.Flag("beta"))
+}
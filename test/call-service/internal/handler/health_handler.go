@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/middleware"
+)
+
+// healthCheckTimeout ограничивает время одной проверки зависимости в /healthz.
+const healthCheckTimeout = 2 * time.Second
+
+// Состояния сервиса и его зависимостей в ответе /healthz
+const (
+	healthOK          = "ok"
+	healthUnavailable = "unavailable"
+	// healthDegraded — недоступны только необязательные зависимости.
+	healthDegraded = "degraded"
+)
+
+// HealthCheck — проверка доступности зависимости сервиса, например соединения с базой данных.
+type HealthCheck struct {
+	// Name — имя зависимости в ответе /healthz.
+	Name  string
+	Check func(ctx context.Context) error
+	// Optional — сервис продолжает работать без зависимости (например, без кэша в Redis),
+	// поэтому ее недоступность не делает ответ /healthz неуспешным.
+	Optional bool
+}
+
+// HealthHandler отвечает на проверки состояния сервиса балансировщиком и оркестратором.
+type HealthHandler struct {
+	checks      []HealthCheck
+	maintenance *middleware.Maintenance
+}
+
+// NewHealthHandler создает обработчик, выполняющий переданные проверки при каждом запросе.
+func NewHealthHandler(checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// NewHealthHandlerWithMaintenance создает обработчик, который кроме проверок сообщает
+// о режиме обслуживания в поле maintenance ответа.
+func NewHealthHandlerWithMaintenance(maintenance *middleware.Maintenance, checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks, maintenance: maintenance}
+}
+
+// Health обрабатывает GET запрос состояния сервиса. Возвращает 200, если все проверки прошли,
+// и 503, если хотя бы одна обязательная зависимость недоступна. Если недоступны только
+// необязательные зависимости, возвращается 200 с состоянием degraded. Ответ содержит состояние каждой зависимости;
+// причины ошибок записываются только в журнал. Режим обслуживания на код ответа не влияет:
+// сервис продолжает отвечать на чтение.
+func (h *HealthHandler) Health(c *gin.Context) {
+	status := healthOK
+	checks := make(map[string]string, len(h.checks))
+	for _, check := range h.checks {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), healthCheckTimeout)
+		err := check.Check(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("health check %s failed: %v", check.Name, err)
+			checks[check.Name] = healthUnavailable
+			if !check.Optional {
+				status = healthUnavailable
+			} else if status == healthOK {
+				status = healthDegraded
+			}
+			continue
+		}
+		checks[check.Name] = healthOK
+	}
+
+	code := http.StatusOK
+	if status == healthUnavailable {
+		code = http.StatusServiceUnavailable
+	}
+	response := gin.H{"status": status, "checks": checks}
+	if h.maintenance != nil {
+		response["maintenance"] = h.maintenance.Enabled()
+	}
+	c.JSON(code, response)
+}
+
+// Readiness — готовность экземпляра принимать трафик, которую сообщает /readyz. В отличие
+// от /healthz, экземпляр не готов, пока не завершен прогрев при запуске, и перестает быть
+// готовым перед остановкой, чтобы балансировщик успел вывести его из ротации.
+// Нулевое значение — не готов.
+type Readiness struct {
+	ready atomic.Bool
+}
+
+// SetReady задает готовность экземпляра.
+func (r *Readiness) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+// IsReady сообщает, готов ли экземпляр принимать трафик.
+func (r *Readiness) IsReady() bool {
+	return r.ready.Load()
+}
+
+// Ready обрабатывает GET запрос готовности. Возвращает 200, если экземпляр готов,
+// и 503 во время прогрева и остановки.
+func (r *Readiness) Ready(c *gin.Context) {
+	if !r.IsReady() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": healthUnavailable})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": healthOK})
+}
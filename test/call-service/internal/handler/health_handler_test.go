@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHealth проверяет, что /healthz возвращает 503 и отмечает недоступную зависимость,
+// если хотя бы одна проверка не прошла, и не раскрывает причину ошибки.
+
+func TestHealth(t *testing.T) {
+	var replicaErr error
+	health := NewHealthHandler(
+		HealthCheck{Name: "database", Check: func(context.Context) error { return nil }},
+		HealthCheck{Name: "database_replica", Check: func(context.Context) error { return replicaErr }},
+	)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, Routes{Health: health})
+
+	get := func() (int, map[string]any) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+
+	code, body := get()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body["status"])
+	assert.Equal(t, map[string]any{"database": "ok", "database_replica": "ok"}, body["checks"])
+
+	replicaErr = errors.New("dial tcp 10.0.0.2:5432: connection refused")
+	code, body = get()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unavailable", body["status"])
+	assert.Equal(t, map[string]any{"database": "ok", "database_replica": "unavailable"}, body["checks"])
+	assert.NotContains(t, body, "error")
+}
+
+// TestHealth_Optional проверяет, что недоступность необязательной зависимости отмечается
+// состоянием degraded без ошибки, а обязательной — по-прежнему приводит к 503.
+
+func TestHealth_Optional(t *testing.T) {
+	var databaseErr error
+	health := NewHealthHandler(
+		HealthCheck{Name: "database", Check: func(context.Context) error { return databaseErr }},
+		HealthCheck{Name: "cache", Optional: true, Check: func(context.Context) error { return errors.New("connection refused") }},
+	)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, Routes{Health: health})
+
+	get := func() (int, map[string]any) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+
+	code, body := get()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "degraded", body["status"])
+	assert.Equal(t, map[string]any{"database": "ok", "cache": "unavailable"}, body["checks"])
+
+	databaseErr = errors.New("connection refused")
+	code, body = get()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unavailable", body["status"])
+}
+
+// TestReadiness проверяет, что /readyz возвращает 503, пока экземпляр не готов, и 200 после SetReady.
+
+func TestReadiness(t *testing.T) {
+	readiness := &Readiness{}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, Routes{Readiness: readiness})
+
+	get := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, get())
+	readiness.SetReady(true)
+	assert.Equal(t, http.StatusOK, get())
+	readiness.SetReady(false)
+	assert.Equal(t, http.StatusServiceUnavailable, get())
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/pkg/authclient"
+)
+
+// ImpersonationHandler выдает администраторам токены для работы от имени пользователя,
+// чтобы служба поддержки видела то же, что и пользователь. Доступ к маршруту ограничивается
+// middleware RequireRole.
+type ImpersonationHandler struct {
+	authClient authclient.AuthClient
+}
+
+// NewImpersonationHandler создает новый экземпляр ImpersonationHandler.
+func NewImpersonationHandler(authClient authclient.AuthClient) *ImpersonationHandler {
+	return &ImpersonationHandler{authClient: authClient}
+}
+
+// ImpersonateUser обрабатывает POST запрос на выпуск токена для работы от имени пользователя.
+// Сервис аутентификации проверяет токен администратора из запроса, выпускает токен
+// на 15 минут и записывает выпуск в журнал аудита. Запросы с этим токеном записываются
+// в журнал запросов и историю статусов заявок с ID администратора. Пользователь-администратор — 403.
+func (h *ImpersonationHandler) ImpersonateUser(c *gin.Context) {
+	userID := middleware.GetUUIDParam(c, "id")
+
+	result, err := h.authClient.ImpersonateUser(c.Request.Context(), middleware.GetAccessToken(c), userID.String())
+	if err != nil {
+		writeAuthError(c, err, i18n.ImpersonateUserFailed)
+		return
+	}
+	c.JSON(http.StatusOK, newAuthResponseV2(result, result.ExpiresAt))
+}
+
+// rejectImpersonation отвечает 403, если запрос выполняется администратором от имени пользователя,
+// и сообщает, что запрос отклонен. Так защищаются действия, которые пережили бы короткий срок
+// действия токена: выпуск API-ключей, смена email и завершение сеансов пользователя.
+func rejectImpersonation(c *gin.Context) bool {
+	principal, _ := middleware.GetPrincipal(c)
+	if principal.ImpersonatedBy == uuid.Nil {
+		return false
+	}
+	c.JSON(http.StatusForbidden, i18n.Response(c, i18n.ImpersonationForbidden))
+	return true
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"call-service/internal/i18n"
+	"call-service/internal/mocks"
+	"call-service/pkg/authclient"
+)
+
+// TestImpersonateUser проверяет выдачу администратору токена для работы от имени пользователя:
+// в сервис аутентификации передается токен администратора, ответ имеет формат AuthResponseV2
+// без refresh-токена.
+
+func TestImpersonateUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	router := setupAdminRouter(nil, mockAuthClient)
+	userID := uuid.New()
+	expiresAt := time.Date(2026, 10, 16, 12, 15, 0, 0, time.UTC)
+
+	mockAuthClient.EXPECT().ImpersonateUser(gomock.Any(), adminToken, userID.String()).
+		Return(&authclient.AuthResult{Token: "impersonation-token", UserID: userID.String(), ExpiresAt: expiresAt}, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/admin/users/"+userID.String()+"/impersonate", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "impersonation-token", response["token"])
+	assert.Equal(t, TokenTypeBearer, response["token_type"])
+	assert.Equal(t, userID.String(), response["user_id"])
+	assert.Equal(t, "2026-10-16T12:15:00Z", response["expires_at"])
+	assert.NotContains(t, response, "refresh_token")
+}
+
+// TestImpersonateUser_Errors проверяет ответы на некорректный ID, в том числе на UUID
+// в неканоническом виде, и отказы сервиса аутентификации.
+
+func TestImpersonateUser_Errors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	router := setupAdminRouter(nil, mockAuthClient)
+	adminID, missingID := uuid.New(), uuid.New()
+
+	mockAuthClient.EXPECT().ImpersonateUser(gomock.Any(), adminToken, adminID.String()).
+		Return(nil, status.Error(codes.PermissionDenied, "administrators cannot be impersonated"))
+	mockAuthClient.EXPECT().ImpersonateUser(gomock.Any(), adminToken, missingID.String()).
+		Return(nil, status.Error(codes.NotFound, "user not found"))
+
+	tests := []struct {
+		userID string
+		status int
+		code   i18n.Code
+	}{
+		{"bad", http.StatusBadRequest, i18n.InvalidUserID},
+		{"{" + adminID.String() + "}", http.StatusBadRequest, i18n.InvalidUserID},
+		{"urn:uuid:" + adminID.String(), http.StatusBadRequest, i18n.InvalidUserID},
+		{strings.ReplaceAll(adminID.String(), "-", ""), http.StatusBadRequest, i18n.InvalidUserID},
+		{adminID.String(), http.StatusForbidden, i18n.CannotImpersonateAdmin},
+		{missingID.String(), http.StatusNotFound, i18n.UserNotFound},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodPost, "/admin/users/"+tt.userID+"/impersonate", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.status, w.Code, tt.userID)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, string(tt.code), response["code"], tt.userID)
+	}
+}
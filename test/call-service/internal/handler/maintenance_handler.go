@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+)
+
+// MaintenanceRequest — тело запроса переключения режима обслуживания.
+type MaintenanceRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// MaintenanceResponse — состояние режима обслуживания.
+type MaintenanceResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+// MaintenanceHandler переключает режим обслуживания. Доступ к маршруту ограничивается
+// middleware RequireRole.
+type MaintenanceHandler struct {
+	maintenance *middleware.Maintenance
+}
+
+// NewMaintenanceHandler создает новый экземпляр MaintenanceHandler.
+func NewMaintenanceHandler(maintenance *middleware.Maintenance) *MaintenanceHandler {
+	return &MaintenanceHandler{maintenance: maintenance}
+}
+
+// SetMaintenance обрабатывает POST запрос на включение или выключение режима обслуживания
+// и возвращает новое состояние. Режим переключается только в экземпляре, получившем запрос.
+func (h *MaintenanceHandler) SetMaintenance(c *gin.Context) {
+	var req MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	h.maintenance.SetEnabled(*req.Enabled)
+	c.JSON(http.StatusOK, MaintenanceResponse{Enabled: h.maintenance.Enabled()})
+}
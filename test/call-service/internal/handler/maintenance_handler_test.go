@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/repository"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+)
+
+// TestMaintenance проверяет переключение режима обслуживания администратором: пока режим
+// включен, POST /calls получает 503 с Retry-After, а GET /calls и /healthz продолжают работать;
+// после выключения заявки снова создаются.
+
+func TestMaintenance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), adminToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: adminUserID.String(), Role: middleware.RoleAdmin}, nil).AnyTimes()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), userToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: uuid.New().String(), Role: middleware.RoleUser}, nil).AnyTimes()
+	callService := service.NewCallService(repository.NewInMemoryCallRepository())
+	maintenance := middleware.NewMaintenance(false, 0)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, Routes{
+		Auth:           NewAuthHandler(mockAuthClient),
+		Calls:          NewCallHandler(callService, mockAuthClient),
+		Admin:          NewAdminHandler(callService),
+		Docs:           NewDocsHandler(nil),
+		Maintenance:    maintenance,
+		Health:         NewHealthHandlerWithMaintenance(maintenance),
+		AuthMiddleware: middleware.NewAuthMiddleware(mockAuthClient),
+	})
+	createCall := `{"client_name":"Test Client","phone_number":"+1234567890","description":"Test Description"}`
+	health := func() map[string]any {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+	assert.Equal(t, false, health()["maintenance"])
+
+	// Переключать режим может только администратор
+	w := doInMemoryRequest(t, router, http.MethodPost, "/admin/maintenance", userToken, `{"enabled": true}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doInMemoryRequest(t, router, http.MethodPost, "/admin/maintenance", adminToken, `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.False(t, maintenance.Enabled())
+
+	w = doInMemoryRequest(t, router, http.MethodPost, "/admin/maintenance", adminToken, `{"enabled": true}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"enabled": true}`, w.Body.String())
+	assert.Equal(t, true, health()["maintenance"])
+
+	w = doInMemoryRequest(t, router, http.MethodPost, "/calls", userToken, createCall)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	var errResponse map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResponse))
+	assert.Equal(t, string(i18n.MaintenanceMode), errResponse["code"])
+	w = doInMemoryRequest(t, router, http.MethodGet, "/calls", userToken, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Выключение режима: переключатель доступен и в режиме обслуживания
+	w = doInMemoryRequest(t, router, http.MethodPost, "/admin/maintenance", adminToken, `{"enabled": false}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doInMemoryRequest(t, router, http.MethodPost, "/calls", userToken, createCall)
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/internal/model"
+	"call-service/internal/notify"
+	"call-service/internal/service"
+)
+
+// Ответы бота Telegram на команду /start с кодом привязки
+const (
+	telegramLinkedReply      = "Notifications from the call service will be sent to this chat."
+	telegramInvalidCodeReply = "The link code is invalid or expired. Request a new link in your profile."
+)
+
+// NotificationHandler обрабатывает HTTP запросы текущего пользователя к настройкам уведомлений
+// и привязке чата Telegram, а также запросы Telegram к webhook бота.
+type NotificationHandler struct {
+	notifications service.NotificationService
+	telegram      *notify.Telegram
+}
+
+// NewNotificationHandler создает новый экземпляр NotificationHandler. telegram — бот
+// уведомлений; nil, если уведомления в Telegram не настроены.
+func NewNotificationHandler(notifications service.NotificationService, telegram *notify.Telegram) *NotificationHandler {
+	return &NotificationHandler{notifications: notifications, telegram: telegram}
+}
+
+// NotificationPreferenceRequest включает или отключает уведомления о событии event
+// по каналу channel.
+type NotificationPreferenceRequest struct {
+	Event   string `json:"event" binding:"required"`
+	Channel string `json:"channel" binding:"required"`
+	Enabled *bool  `json:"enabled" binding:"required"`
+}
+
+// UpdateNotificationsRequest содержит изменяемые настройки уведомлений; настройки,
+// не переданные в запросе, не меняются.
+type UpdateNotificationsRequest struct {
+	Preferences []NotificationPreferenceRequest `json:"preferences" binding:"required,dive"`
+}
+
+// NotificationPreferenceResponse описывает настройку уведомлений о событии по каналу.
+type NotificationPreferenceResponse struct {
+	Event   string `json:"event"`
+	Channel string `json:"channel"`
+	Enabled bool   `json:"enabled"`
+}
+
+// TelegramLinkResponse описывает привязку чата Telegram.
+type TelegramLinkResponse struct {
+	Linked   bool       `json:"linked"`
+	LinkedAt *time.Time `json:"linked_at,omitempty"`
+}
+
+// NotificationSettingsResponse описывает настройки уведомлений пользователя: каналы,
+// включенные в сервисе, настройки каждого события по этим каналам и привязку Telegram.
+type NotificationSettingsResponse struct {
+	Channels    []string                         `json:"channels"`
+	Preferences []NotificationPreferenceResponse `json:"preferences"`
+	Telegram    TelegramLinkResponse             `json:"telegram"`
+}
+
+// TelegramLinkCodeResponse содержит код привязки чата, который пользователь передает боту
+// командой /start <code>, и ссылку на бота с этим кодом, если имя бота задано.
+type TelegramLinkCodeResponse struct {
+	Code      string    `json:"code"`
+	URL       string    `json:"url,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// telegramUpdate — часть обновления Bot API, которую обрабатывает webhook бота.
+type telegramUpdate struct {
+	Message *struct {
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		Text string `json:"text"`
+	} `json:"message"`
+}
+
+// GetNotifications обрабатывает GET запрос на получение настроек уведомлений текущего пользователя.
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	prefs, err := h.notifications.ListPreferences(c.Request.Context(), userID)
+	if err != nil {
+		writeServerError(c, err, i18n.GetNotificationsFailed)
+		return
+	}
+	h.writeSettings(c, prefs, i18n.GetNotificationsFailed)
+}
+
+// UpdateNotifications обрабатывает PUT запрос на изменение настроек уведомлений текущего
+// пользователя и возвращает все его настройки.
+func (h *NotificationHandler) UpdateNotifications(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req UpdateNotificationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+	prefs := make([]model.NotificationPreference, 0, len(req.Preferences))
+	for _, pref := range req.Preferences {
+		prefs = append(prefs, model.NotificationPreference{Event: pref.Event, Channel: pref.Channel, Enabled: *pref.Enabled})
+	}
+
+	updated, err := h.notifications.UpdatePreferences(c.Request.Context(), userID, prefs)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidNotificationPreference) {
+			c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidNotificationPreference))
+			return
+		}
+		writeServerError(c, err, i18n.UpdateNotificationsFailed)
+		return
+	}
+	h.writeSettings(c, updated, i18n.UpdateNotificationsFailed)
+}
+
+// CreateTelegramLink обрабатывает POST запрос на привязку чата Telegram: возвращает
+// одноразовый код, который пользователь передает боту. Новый код отменяет прежний.
+func (h *NotificationHandler) CreateTelegramLink(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	if h.telegram == nil {
+		c.JSON(http.StatusServiceUnavailable, i18n.Response(c, i18n.TelegramDisabled))
+		return
+	}
+	code, expiresAt, err := h.notifications.CreateTelegramLinkCode(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrTelegramDisabled) {
+			c.JSON(http.StatusServiceUnavailable, i18n.Response(c, i18n.TelegramDisabled))
+			return
+		}
+		writeServerError(c, err, i18n.LinkTelegramFailed)
+		return
+	}
+
+	c.JSON(http.StatusCreated, TelegramLinkCodeResponse{Code: code, URL: h.telegram.LinkURL(code), ExpiresAt: expiresAt})
+}
+
+// DeleteTelegramLink обрабатывает DELETE запрос на отвязку чата Telegram текущего пользователя.
+func (h *NotificationHandler) DeleteTelegramLink(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	if err := h.notifications.UnlinkTelegram(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, service.ErrTelegramNotLinked) {
+			c.JSON(http.StatusNotFound, i18n.Response(c, i18n.TelegramNotLinked))
+			return
+		}
+		writeServerError(c, err, i18n.UnlinkTelegramFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Telegram chat unlinked"})
+}
+
+// TelegramWebhook обрабатывает POST запрос Telegram с обновлением бота. Запрос должен
+// содержать секрет webhook в заголовке notify.TelegramSecretHeader. Команда /start <code>
+// привязывает чат к пользователю кода, и бот отвечает в чат о результате; остальные
+// обновления пропускаются. Неверный код не считается ошибкой запроса, чтобы Telegram
+// не повторял его, а внутренняя ошибка возвращается с кодом 500 для повтора.
+func (h *NotificationHandler) TelegramWebhook(c *gin.Context) {
+	if h.telegram == nil {
+		c.JSON(http.StatusServiceUnavailable, i18n.Response(c, i18n.TelegramDisabled))
+		return
+	}
+	if !h.telegram.VerifyWebhook(c.GetHeader(notify.TelegramSecretHeader)) {
+		c.JSON(http.StatusUnauthorized, i18n.Response(c, i18n.InvalidWebhookSecret))
+		return
+	}
+	var update telegramUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+	if update.Message == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Update ignored"})
+		return
+	}
+	command, code, _ := strings.Cut(strings.TrimSpace(update.Message.Text), " ")
+	if command != "/start" || code == "" {
+		c.JSON(http.StatusOK, gin.H{"message": "Update ignored"})
+		return
+	}
+
+	chatID := update.Message.Chat.ID
+	reply := telegramLinkedReply
+	link, err := h.notifications.LinkTelegram(c.Request.Context(), strings.TrimSpace(code), chatID)
+	switch {
+	case errors.Is(err, service.ErrInvalidTelegramLinkCode):
+		reply = telegramInvalidCodeReply
+	case err != nil:
+		writeServerError(c, err, i18n.TelegramWebhookFailed)
+		return
+	default:
+		middleware.SetAuditResourceID(c, link.UserID.String())
+	}
+	// Ответ в чат не влияет на привязку, поэтому его ошибка только записывается в журнал
+	if err := h.telegram.SendMessage(c.Request.Context(), chatID, reply); err != nil {
+		log.Printf("failed to reply to telegram chat %d: %v", chatID, err)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Update processed"})
+}
+
+// writeSettings отвечает настройками prefs и состоянием привязки Telegram.
+func (h *NotificationHandler) writeSettings(c *gin.Context, prefs []*model.NotificationPreference, code i18n.Code) {
+	userID, _ := middleware.GetUserID(c)
+
+	response := NotificationSettingsResponse{
+		Channels:    h.notifications.EnabledChannels(),
+		Preferences: make([]NotificationPreferenceResponse, 0, len(prefs)),
+	}
+	if response.Channels == nil {
+		response.Channels = []string{}
+	}
+	for _, pref := range prefs {
+		response.Preferences = append(response.Preferences, NotificationPreferenceResponse{
+			Event:   pref.Event,
+			Channel: pref.Channel,
+			Enabled: pref.Enabled,
+		})
+	}
+	link, err := h.notifications.GetTelegramLink(c.Request.Context(), userID)
+	switch {
+	case err == nil:
+		response.Telegram = TelegramLinkResponse{Linked: true, LinkedAt: &link.LinkedAt}
+	case !errors.Is(err, service.ErrTelegramNotLinked):
+		writeServerError(c, err, code)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
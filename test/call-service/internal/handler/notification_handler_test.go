@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/model"
+	"call-service/internal/notify"
+	"call-service/internal/repository"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+)
+
+// telegramWebhookSecret — секрет webhook бота в тестах уведомлений.
+const telegramWebhookSecret = "webhook-secret"
+
+// telegramReply — сообщение, отправленное ботом через Bot API.
+type telegramReply struct {
+	ChatID int64  `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+// setupNotificationRouter настраивает маршрутизатор с маршрутами уведомлений поверх настоящего
+// сервиса настроек с хранилищем в памяти и бота Telegram, Bot API которого заменен тестовым
+// сервером. Возвращает функцию, отдающую сообщения, отправленные ботом.
+
+func setupNotificationRouter(t *testing.T) (*gin.Engine, func() []telegramReply) {
+	gin.SetMode(gin.TestMode)
+	authClient := mocks.NewMockAuthClient(gomock.NewController(t))
+	authClient.EXPECT().ValidateTokenFull(gomock.Any(), "owner-token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: uuid.New().String(), Role: middleware.RoleUser}, nil).AnyTimes()
+
+	var mu sync.Mutex
+	var replies []telegramReply
+	botAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reply telegramReply
+		_ = json.NewDecoder(r.Body).Decode(&reply)
+		mu.Lock()
+		replies = append(replies, reply)
+		mu.Unlock()
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(botAPI.Close)
+
+	notifications := service.NewNotificationService(repository.NewInMemoryNotificationRepository(),
+		[]string{model.NotificationChannelEmail, model.NotificationChannelTelegram})
+	telegram, err := notify.NewTelegram(notify.TelegramConfig{
+		Token:         "bot-token",
+		BotName:       "calls_bot",
+		WebhookSecret: telegramWebhookSecret,
+		APIURL:        botAPI.URL,
+	}, notifications)
+	require.NoError(t, err)
+
+	router := gin.New()
+	RegisterRoutes(router, Routes{
+		Auth:           NewAuthHandler(authClient),
+		Calls:          NewCallHandler(service.NewCallService(repository.NewInMemoryCallRepository()), authClient),
+		Notifications:  NewNotificationHandler(notifications, telegram),
+		Admin:          NewAdminHandler(nil),
+		Docs:           NewDocsHandler(nil),
+		AuthMiddleware: middleware.NewAuthMiddleware(authClient),
+	})
+	return router, func() []telegramReply {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]telegramReply(nil), replies...)
+	}
+}
+
+// doTelegramWebhook отправляет обновление бота на webhook с секретом secret.
+func doTelegramWebhook(t *testing.T, router *gin.Engine, secret, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodPost, "/telegram/webhook", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(notify.TelegramSecretHeader, secret)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	printRequestResponse(t, req, w)
+	return w
+}
+
+// TestNotifications_Preferences проверяет, что по умолчанию уведомления включены для всех
+// событий и каналов, изменение сохраняет только переданные настройки, а неизвестные
+// события и каналы отклоняются.
+
+func TestNotifications_Preferences(t *testing.T) {
+	router, _ := setupNotificationRouter(t)
+
+	w := doInMemoryRequest(t, router, "GET", "/me/notifications", "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var settings NotificationSettingsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+	assert.Equal(t, []string{model.NotificationChannelEmail, model.NotificationChannelTelegram}, settings.Channels)
+	require.Len(t, settings.Preferences, len(model.NotificationEvents)*2)
+	for _, pref := range settings.Preferences {
+		assert.True(t, pref.Enabled)
+	}
+	assert.False(t, settings.Telegram.Linked)
+
+	w = doInMemoryRequest(t, router, "PUT", "/me/notifications", "owner-token",
+		`{"preferences":[{"event":"call_assigned","channel":"email","enabled":false}]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+	for _, pref := range settings.Preferences {
+		disabled := pref.Event == model.NotificationCallAssigned && pref.Channel == model.NotificationChannelEmail
+		assert.Equal(t, !disabled, pref.Enabled, pref)
+	}
+
+	tests := []struct {
+		body string
+		code string
+	}{
+		{`{"preferences":[{"event":"call_assigned","channel":"sms","enabled":true}]}`, "invalid_notification_preference"},
+		{`{"preferences":[{"event":"lunch","channel":"email","enabled":true}]}`, "invalid_notification_preference"},
+		{`{"preferences":[{"event":"call_assigned","channel":"email"}]}`, "field_required"},
+	}
+	for _, tt := range tests {
+		w := doInMemoryRequest(t, router, "PUT", "/me/notifications", "owner-token", tt.body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, tt.body)
+		assert.Contains(t, w.Body.String(), tt.code, tt.body)
+	}
+}
+
+// TestNotifications_TelegramLink проверяет привязку чата Telegram: код из профиля,
+// переданный боту командой /start, привязывает чат один раз, webhook без секрета
+// отклоняется, а отвязанный чат нельзя отвязать повторно.
+
+func TestNotifications_TelegramLink(t *testing.T) {
+	router, replies := setupNotificationRouter(t)
+
+	w := doInMemoryRequest(t, router, "POST", "/me/notifications/telegram", "owner-token", "")
+	require.Equal(t, http.StatusCreated, w.Code)
+	var link TelegramLinkCodeResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &link))
+	assert.Equal(t, "https://t.me/calls_bot?start="+link.Code, link.URL)
+
+	update := `{"message":{"chat":{"id":42},"text":"/start ` + link.Code + `"}}`
+	w = doTelegramWebhook(t, router, "wrong-secret", update)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, replies())
+
+	w = doTelegramWebhook(t, router, telegramWebhookSecret, update)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doTelegramWebhook(t, router, telegramWebhookSecret, update)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doTelegramWebhook(t, router, telegramWebhookSecret, `{"message":{"chat":{"id":42},"text":"hello"}}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []telegramReply{
+		{ChatID: 42, Text: telegramLinkedReply},
+		{ChatID: 42, Text: telegramInvalidCodeReply},
+	}, replies())
+
+	w = doInMemoryRequest(t, router, "GET", "/me/notifications", "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var settings NotificationSettingsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+	assert.True(t, settings.Telegram.Linked)
+
+	w = doInMemoryRequest(t, router, "DELETE", "/me/notifications/telegram", "owner-token", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doInMemoryRequest(t, router, "DELETE", "/me/notifications/telegram", "owner-token", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "telegram_not_linked")
+}
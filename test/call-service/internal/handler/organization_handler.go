@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/pkg/authclient"
+)
+
+// OrganizationHandler обрабатывает HTTP запросы к организациям пользователей. Организации
+// хранятся в сервисе аутентификации; участники организации видят и изменяют заявки друг друга.
+type OrganizationHandler struct {
+	authClient authclient.AuthClient
+	auth       *middleware.AuthMiddleware
+}
+
+// NewOrganizationHandler создает новый экземпляр OrganizationHandler. После изменения участия
+// пользователя в организации его кэшированные проверки токенов в auth сбрасываются; auth
+// может быть nil.
+func NewOrganizationHandler(authClient authclient.AuthClient, auth *middleware.AuthMiddleware) *OrganizationHandler {
+	return &OrganizationHandler{authClient: authClient, auth: auth}
+}
+
+// CreateOrganizationRequest содержит название новой организации.
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// InviteMemberRequest содержит имя пользователя, приглашаемого в организацию.
+type InviteMemberRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// OrganizationResponse описывает организацию.
+type OrganizationResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateInviteRequest содержит необязательный срок действия приглашения: не позже чем через
+// 30 дней, по умолчанию — через 7 дней.
+type CreateInviteRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// AcceptInviteRequest содержит код приглашения.
+type AcceptInviteRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// InviteResponse описывает приглашение в организацию. Status — "pending", "accepted",
+// "revoked" или "expired". Code передается только в ответе на создание приглашения.
+type InviteResponse struct {
+	ID         string     `json:"id"`
+	OrgID      string     `json:"org_id"`
+	Code       string     `json:"code,omitempty"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	Status     string     `json:"status"`
+	AcceptedBy string     `json:"accepted_by,omitempty"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// MemberResponse описывает участника организации. Role — "owner" или "member".
+type MemberResponse struct {
+	OrgID    string    `json:"org_id"`
+	UserID   string    `json:"user_id"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// CreateOrganization обрабатывает POST запрос на создание организации, владельцем которой
+// становится текущий пользователь.
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	org, err := h.authClient.CreateOrganization(c.Request.Context(), userID.String(), req.Name)
+	if err != nil {
+		writeAuthError(c, err, i18n.CreateOrganizationFailed)
+		return
+	}
+	h.invalidate(userID)
+
+	middleware.SetAuditResourceID(c, org.ID)
+	c.JSON(http.StatusCreated, OrganizationResponse{
+		ID:        org.ID,
+		Name:      org.Name,
+		CreatedAt: org.CreatedAt,
+	})
+}
+
+// InviteMember обрабатывает POST запрос на добавление пользователя в организацию с ролью
+// участника. Приглашать может только владелец организации.
+func (h *OrganizationHandler) InviteMember(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	orgID := middleware.GetUUIDParam(c, "id")
+
+	var req InviteMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	member, err := h.authClient.InviteToOrganization(c.Request.Context(), orgID.String(), userID.String(), req.Username)
+	if err != nil {
+		writeAuthError(c, err, i18n.InviteMemberFailed)
+		return
+	}
+
+	if id, err := uuid.Parse(member.UserID); err == nil {
+		h.invalidate(id)
+	}
+	c.JSON(http.StatusCreated, memberResponse(member))
+}
+
+// CreateInvite обрабатывает POST запрос на создание одноразового приглашения в организацию.
+// Код приглашения возвращается только в этом ответе. Создавать приглашения может только
+// владелец организации.
+func (h *OrganizationHandler) CreateInvite(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	orgID := middleware.GetUUIDParam(c, "id")
+
+	// Тело запроса необязательно: без него приглашение получает срок по умолчанию
+	var req CreateInviteRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+			return
+		}
+	}
+	var ttl time.Duration
+	if req.ExpiresAt != nil {
+		ttl = time.Until(*req.ExpiresAt)
+		if ttl <= 0 {
+			c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidInviteExpiry))
+			return
+		}
+		// Сервис аутентификации принимает срок в секундах; округление вверх не дает
+		// приглашению истечь раньше указанного времени
+		ttl = (ttl + time.Second - 1).Truncate(time.Second)
+	}
+
+	invite, code, err := h.authClient.CreateInvite(c.Request.Context(), orgID.String(), userID.String(), ttl)
+	if err != nil {
+		writeAuthError(c, err, i18n.CreateInviteFailed)
+		return
+	}
+
+	resp := inviteResponse(invite)
+	resp.Code = code
+	middleware.SetAuditResourceID(c, resp.ID)
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListInvites обрабатывает GET запрос на получение приглашений организации, от новых
+// к старым. Коды приглашений не возвращаются.
+func (h *OrganizationHandler) ListInvites(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	orgID := middleware.GetUUIDParam(c, "id")
+
+	invites, err := h.authClient.ListInvites(c.Request.Context(), orgID.String(), userID.String())
+	if err != nil {
+		writeAuthError(c, err, i18n.ListInvitesFailed)
+		return
+	}
+
+	resp := make([]InviteResponse, 0, len(invites))
+	for i := range invites {
+		resp = append(resp, inviteResponse(&invites[i]))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RevokeInvite обрабатывает DELETE запрос на отзыв непринятого приглашения.
+func (h *OrganizationHandler) RevokeInvite(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	orgID := middleware.GetUUIDParam(c, "id")
+	inviteID := middleware.GetUUIDParam(c, "inviteId")
+
+	if err := h.authClient.RevokeInvite(c.Request.Context(), orgID.String(), userID.String(), inviteID.String()); err != nil {
+		writeAuthError(c, err, i18n.RevokeInviteFailed)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "invite revoked"})
+}
+
+// AcceptInvite обрабатывает POST запрос на принятие приглашения: текущий пользователь
+// становится участником организации. Повторное принятие тем же пользователем возвращает
+// его участие.
+func (h *OrganizationHandler) AcceptInvite(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req AcceptInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	member, err := h.authClient.AcceptInvite(c.Request.Context(), userID.String(), req.Code)
+	if err != nil {
+		writeAuthError(c, err, i18n.AcceptInviteFailed)
+		return
+	}
+	h.invalidate(userID)
+	c.JSON(http.StatusOK, memberResponse(member))
+}
+
+// invalidate сбрасывает кэшированные проверки токенов пользователя после изменения
+// его участия в организации.
+func (h *OrganizationHandler) invalidate(userID uuid.UUID) {
+	if h.auth != nil {
+		h.auth.InvalidateUser(userID)
+	}
+}
+
+// memberResponse преобразует участника организации в ответ API.
+func memberResponse(member *authclient.MemberInfo) MemberResponse {
+	return MemberResponse{
+		OrgID:    member.OrgID,
+		UserID:   member.UserID,
+		Role:     member.Role,
+		JoinedAt: member.JoinedAt,
+	}
+}
+
+// inviteResponse преобразует приглашение в ответ API без кода.
+func inviteResponse(invite *authclient.InviteInfo) InviteResponse {
+	resp := InviteResponse{
+		ID:         invite.ID,
+		OrgID:      invite.OrgID,
+		CreatedBy:  invite.CreatedBy,
+		CreatedAt:  invite.CreatedAt,
+		ExpiresAt:  invite.ExpiresAt,
+		Status:     invite.Status,
+		AcceptedBy: invite.AcceptedBy,
+	}
+	if !invite.AcceptedAt.IsZero() {
+		resp.AcceptedAt = &invite.AcceptedAt
+	}
+	if !invite.RevokedAt.IsZero() {
+		resp.RevokedAt = &invite.RevokedAt
+	}
+	return resp
+}
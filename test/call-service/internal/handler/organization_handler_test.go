@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/pkg/authclient"
+)
+
+// setupOrganizationRouter настраивает маршрутизатор с маршрутами /organizations, где токен
+// userToken принадлежит пользователю userID.
+
+func setupOrganizationRouter(authClient *mocks.MockAuthClient, userID uuid.UUID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, Routes{
+		Auth:           NewAuthHandler(authClient),
+		Calls:          NewCallHandler(nil, authClient),
+		Admin:          NewAdminHandler(nil),
+		Profile:        NewProfileHandler(authClient),
+		APIKeys:        NewAPIKeyHandler(nil),
+		UserData:       NewUserDataHandler(nil, authClient, nil),
+		Organizations:  NewOrganizationHandler(authClient, nil),
+		Docs:           NewDocsHandler(nil),
+		AuthMiddleware: middleware.NewAuthMiddleware(authClient),
+	})
+
+	authClient.EXPECT().ValidateTokenFull(gomock.Any(), userToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: userID.String(), Role: middleware.RoleUser}, nil).AnyTimes()
+	return router
+}
+
+// TestOrganizations проверяет создание организации и приглашение участника, а также ответы
+// на отказы сервиса аутентификации.
+
+func TestOrganizations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	userID, orgID, memberID := uuid.New(), uuid.New(), uuid.New()
+	createdAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	router := setupOrganizationRouter(mockAuthClient, userID)
+
+	mockAuthClient.EXPECT().CreateOrganization(gomock.Any(), userID.String(), "Рога и копыта").
+		Return(&authclient.OrganizationInfo{ID: orgID.String(), Name: "Рога и копыта", CreatedAt: createdAt}, nil)
+	w := doProfileRequest(router, http.MethodPost, "/organizations", `{"name": "Рога и копыта"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var org OrganizationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &org))
+	assert.Equal(t, OrganizationResponse{ID: orgID.String(), Name: "Рога и копыта", CreatedAt: createdAt}, org)
+
+	mockAuthClient.EXPECT().InviteToOrganization(gomock.Any(), orgID.String(), userID.String(), "petr").
+		Return(&authclient.MemberInfo{OrgID: orgID.String(), UserID: memberID.String(), Role: "member", JoinedAt: createdAt}, nil)
+	w = doProfileRequest(router, http.MethodPost, "/organizations/"+orgID.String()+"/members", `{"username": "petr"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var member MemberResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &member))
+	assert.Equal(t, memberID.String(), member.UserID)
+	assert.Equal(t, "member", member.Role)
+
+	w = doProfileRequest(router, http.MethodPost, "/organizations/invalid/members", `{"username": "petr"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_organization_id")
+	w = doProfileRequest(router, http.MethodPost, "/organizations", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantBody string
+	}{
+		{"not owner", status.Error(codes.PermissionDenied, "only the organization owner can invite members"), http.StatusForbidden, "not_organization_owner"},
+		{"already member", status.Error(codes.AlreadyExists, "user already belongs to an organization"), http.StatusConflict, "already_in_organization"},
+		{"user not found", status.Error(codes.NotFound, "user not found"), http.StatusNotFound, "user_not_found"},
+		{"disabled", status.Error(codes.Unimplemented, "organizations are disabled"), http.StatusNotImplemented, "organizations_disabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthClient.EXPECT().InviteToOrganization(gomock.Any(), orgID.String(), userID.String(), "petr").Return(nil, tt.err)
+			w := doProfileRequest(router, http.MethodPost, "/organizations/"+orgID.String()+"/members", `{"username": "petr"}`)
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+// TestOrganizationInvites проверяет создание, просмотр, отзыв и принятие приглашений: код
+// возвращается только при создании, отказы сервиса аутентификации передаются клиенту.
+
+func TestOrganizationInvites(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	userID, orgID, inviteID := uuid.New(), uuid.New(), uuid.New()
+	createdAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	router := setupOrganizationRouter(mockAuthClient, userID)
+	invite := authclient.InviteInfo{
+		ID:        inviteID.String(),
+		OrgID:     orgID.String(),
+		CreatedBy: userID.String(),
+		CreatedAt: createdAt,
+		ExpiresAt: createdAt.Add(7 * 24 * time.Hour),
+		Status:    "pending",
+	}
+	invitesPath := "/organizations/" + orgID.String() + "/invites"
+
+	// Без тела запроса приглашение получает срок по умолчанию
+	mockAuthClient.EXPECT().CreateInvite(gomock.Any(), orgID.String(), userID.String(), time.Duration(0)).Return(&invite, "secret-code", nil)
+	w := doProfileRequest(router, http.MethodPost, invitesPath, "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created InviteResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "secret-code", created.Code)
+	assert.Equal(t, "pending", created.Status)
+	assert.Nil(t, created.AcceptedAt)
+
+	expiresAt := time.Now().Add(48 * time.Hour)
+	mockAuthClient.EXPECT().CreateInvite(gomock.Any(), orgID.String(), userID.String(), gomock.Any()).
+		DoAndReturn(func(_ any, _, _ string, ttl time.Duration) (*authclient.InviteInfo, string, error) {
+			assert.Zero(t, ttl%time.Second)
+			assert.InDelta(t, float64(48*time.Hour), float64(ttl), float64(2*time.Second))
+			return &invite, "other-code", nil
+		})
+	w = doProfileRequest(router, http.MethodPost, invitesPath, `{"expires_at": "`+expiresAt.Format(time.RFC3339Nano)+`"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = doProfileRequest(router, http.MethodPost, invitesPath, `{"expires_at": "2020-01-01T00:00:00Z"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_invite_expiry")
+
+	accepted := invite
+	accepted.Status, accepted.AcceptedBy, accepted.AcceptedAt = "accepted", uuid.NewString(), createdAt.Add(time.Hour)
+	mockAuthClient.EXPECT().ListInvites(gomock.Any(), orgID.String(), userID.String()).Return([]authclient.InviteInfo{accepted}, nil)
+	w = doProfileRequest(router, http.MethodGet, invitesPath, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var invites []InviteResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &invites))
+	require.Len(t, invites, 1)
+	assert.Empty(t, invites[0].Code)
+	assert.Equal(t, accepted.AcceptedBy, invites[0].AcceptedBy)
+	require.NotNil(t, invites[0].AcceptedAt)
+	assert.Nil(t, invites[0].RevokedAt)
+
+	mockAuthClient.EXPECT().RevokeInvite(gomock.Any(), orgID.String(), userID.String(), inviteID.String()).Return(nil)
+	w = doProfileRequest(router, http.MethodDelete, invitesPath+"/"+inviteID.String(), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doProfileRequest(router, http.MethodDelete, invitesPath+"/invalid", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_invite_id")
+	mockAuthClient.EXPECT().RevokeInvite(gomock.Any(), orgID.String(), userID.String(), inviteID.String()).
+		Return(status.Error(codes.NotFound, "invite not found"))
+	w = doProfileRequest(router, http.MethodDelete, invitesPath+"/"+inviteID.String(), "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "invite_not_found")
+
+	mockAuthClient.EXPECT().AcceptInvite(gomock.Any(), userID.String(), "secret-code").
+		Return(&authclient.MemberInfo{OrgID: orgID.String(), UserID: userID.String(), Role: "member", JoinedAt: createdAt}, nil)
+	w = doProfileRequest(router, http.MethodPost, "/invites/accept", `{"code": "secret-code"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var member MemberResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &member))
+	assert.Equal(t, orgID.String(), member.OrgID)
+	w = doProfileRequest(router, http.MethodPost, "/invites/accept", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantBody string
+	}{
+		{"invalid code", status.Error(codes.InvalidArgument, "invalid or expired invite code"), http.StatusBadRequest, "invalid_invite"},
+		{"already member", status.Error(codes.AlreadyExists, "user already belongs to an organization"), http.StatusConflict, "already_in_organization"},
+		{"disabled", status.Error(codes.Unimplemented, "organizations are disabled"), http.StatusNotImplemented, "organizations_disabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthClient.EXPECT().AcceptInvite(gomock.Any(), userID.String(), "used-code").Return(nil, tt.err)
+			w := doProfileRequest(router, http.MethodPost, "/invites/accept", `{"code": "used-code"}`)
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+
+	mockAuthClient.EXPECT().CreateInvite(gomock.Any(), orgID.String(), userID.String(), gomock.Any()).
+		Return(nil, "", status.Error(codes.InvalidArgument, "invalid invite lifetime"))
+	w = doProfileRequest(router, http.MethodPost, invitesPath, `{"expires_at": "`+time.Now().Add(60*24*time.Hour).Format(time.RFC3339)+`"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_invite_expiry")
+}
+
+// TestAcceptInvite_InvalidatesStaleAuth проверяет, что после принятия приглашения кэшированная
+// проверка токена с прежним участием не используется при недоступности сервиса аутентификации.
+
+func TestAcceptInvite_InvalidatesStaleAuth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	userID := uuid.New()
+	auth := middleware.NewAuthMiddlewareWithConfig(mockAuthClient, middleware.AuthConfig{StaleTTL: time.Minute, Organizations: true})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, Routes{
+		Organizations:  NewOrganizationHandler(mockAuthClient, auth),
+		AuthMiddleware: auth,
+	})
+
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), userToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: userID.String(), Role: middleware.RoleUser}, nil)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), userToken).
+		Return(nil, status.Error(codes.Unavailable, "connection refused")).AnyTimes()
+	mockAuthClient.EXPECT().AcceptInvite(gomock.Any(), userID.String(), "secret-code").
+		Return(&authclient.MemberInfo{OrgID: uuid.NewString(), UserID: userID.String(), Role: "member"}, nil)
+
+	require.Equal(t, http.StatusOK, doProfileRequest(router, http.MethodPost, "/invites/accept", `{"code": "secret-code"}`).Code)
+	assert.Equal(t, http.StatusServiceUnavailable, doProfileRequest(router, http.MethodPost, "/invites/accept", `{"code": "secret-code"}`).Code)
+}
+
+// TestOrganizations_Disabled проверяет, что без обработчика организаций маршруты не регистрируются.
+
+func TestOrganizations_Disabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	router := setupProfileRouter(mockAuthClient, uuid.New())
+
+	w := doProfileRequest(router, http.MethodPost, "/organizations", `{"name": "Рога и копыта"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
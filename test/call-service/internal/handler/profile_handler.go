@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/pkg/authclient"
+)
+
+// ProfileHandler обрабатывает HTTP запросы текущего пользователя к собственной учетной записи.
+// Данные учетной записи хранятся в сервисе аутентификации.
+type ProfileHandler struct {
+	authClient authclient.AuthClient
+}
+
+// NewProfileHandler создает новый экземпляр ProfileHandler.
+func NewProfileHandler(authClient authclient.AuthClient) *ProfileHandler {
+	return &ProfileHandler{authClient: authClient}
+}
+
+// UpdateEmailRequest содержит новый email пользователя.
+type UpdateEmailRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// VerifyEmailRequest содержит токен подтверждения email из письма.
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// EmailResponse возвращает email пользователя и признак его подтверждения.
+// Пустой email означает, что адрес не указан.
+type EmailResponse struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// SessionResponse описывает действующий сеанс пользователя. Current отмечает сеанс,
+// которому принадлежит токен текущего запроса.
+type SessionResponse struct {
+	ID          string    `json:"id"`
+	DeviceLabel string    `json:"device_label"`
+	IP          string    `json:"ip"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+	Current     bool      `json:"current"`
+}
+
+// DeviceResponse описывает устройство, с которого пользователь уже входил.
+// Вход с устройства не из этого списка отмечается сервисом аутентификации как вход с нового устройства.
+type DeviceResponse struct {
+	Fingerprint string    `json:"fingerprint"`
+	UserAgent   string    `json:"user_agent"`
+	IP          string    `json:"ip"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+// GetEmail обрабатывает GET запрос на получение email текущего пользователя.
+func (h *ProfileHandler) GetEmail(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	user, err := h.authClient.GetUser(c.Request.Context(), userID.String())
+	if err != nil {
+		writeAuthError(c, err, i18n.GetEmailFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, EmailResponse{
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+	})
+}
+
+// UpdateEmail обрабатывает PUT запрос на смену email текущего пользователя.
+// Новый адрес считается неподтвержденным, пока пользователь не передаст токен из письма в VerifyEmail.
+// Администратор, работающий от имени пользователя, email не меняет (403).
+func (h *ProfileHandler) UpdateEmail(c *gin.Context) {
+	if rejectImpersonation(c) {
+		return
+	}
+	userID, _ := middleware.GetUserID(c)
+
+	var req UpdateEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	if err := h.authClient.UpdateEmail(c.Request.Context(), userID.String(), req.Email); err != nil {
+		writeAuthError(c, err, i18n.UpdateEmailFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "verification email sent"})
+}
+
+// VerifyEmail обрабатывает POST запрос на подтверждение email текущего пользователя.
+// Администратор, работающий от имени пользователя, email не подтверждает (403).
+func (h *ProfileHandler) VerifyEmail(c *gin.Context) {
+	if rejectImpersonation(c) {
+		return
+	}
+	userID, _ := middleware.GetUserID(c)
+
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	if err := h.authClient.VerifyEmail(c.Request.Context(), userID.String(), req.Token); err != nil {
+		writeAuthError(c, err, i18n.VerifyEmailFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email verified"})
+}
+
+// ListSessions обрабатывает GET запрос на получение действующих сеансов текущего пользователя.
+func (h *ProfileHandler) ListSessions(c *gin.Context) {
+	principal, _ := middleware.GetPrincipal(c)
+
+	sessions, err := h.authClient.ListSessions(c.Request.Context(), principal.UserID.String())
+	if err != nil {
+		writeAuthError(c, err, i18n.ListSessionsFailed)
+		return
+	}
+
+	response := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, SessionResponse{
+			ID:          session.ID,
+			DeviceLabel: session.DeviceLabel,
+			IP:          session.IP,
+			CreatedAt:   session.CreatedAt,
+			LastUsedAt:  session.LastUsedAt,
+			Current:     session.ID == principal.SessionID,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ListDevices обрабатывает GET запрос на получение известных устройств текущего пользователя.
+func (h *ProfileHandler) ListDevices(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	devices, err := h.authClient.ListDevices(c.Request.Context(), userID.String())
+	if err != nil {
+		writeAuthError(c, err, i18n.ListDevicesFailed)
+		return
+	}
+
+	response := make([]DeviceResponse, 0, len(devices))
+	for _, device := range devices {
+		response = append(response, DeviceResponse{
+			Fingerprint: device.Fingerprint,
+			UserAgent:   device.UserAgent,
+			IP:          device.IP,
+			FirstSeenAt: device.FirstSeenAt,
+			LastSeenAt:  device.LastSeenAt,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeSession обрабатывает DELETE запрос на завершение сеанса текущего пользователя.
+// Токены сеанса перестают действовать сразу, в том числе если это сеанс текущего запроса.
+// Администратор, работающий от имени пользователя, сеансы не завершает (403).
+func (h *ProfileHandler) RevokeSession(c *gin.Context) {
+	if rejectImpersonation(c) {
+		return
+	}
+	userID, _ := middleware.GetUserID(c)
+
+	sessionID := middleware.GetUUIDParam(c, "id")
+
+	if err := h.authClient.RevokeSession(c.Request.Context(), userID.String(), sessionID.String()); err != nil {
+		writeAuthError(c, err, i18n.RevokeSessionFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
+
+// RevokeOtherSessions обрабатывает DELETE запрос на завершение всех сеансов текущего
+// пользователя, кроме сеанса текущего запроса ("выйти на всех других устройствах").
+// Администратор, работающий от имени пользователя, сеансы не завершает (403).
+func (h *ProfileHandler) RevokeOtherSessions(c *gin.Context) {
+	if rejectImpersonation(c) {
+		return
+	}
+	principal, _ := middleware.GetPrincipal(c)
+
+	revoked, err := h.authClient.RevokeAllSessions(c.Request.Context(), principal.UserID.String(), principal.SessionID)
+	if err != nil {
+		writeAuthError(c, err, i18n.RevokeSessionsFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
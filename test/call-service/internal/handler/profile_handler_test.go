@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/pkg/authclient"
+)
+
+// currentSessionID — сеанс, которому принадлежит токен userToken в setupProfileRouter.
+const currentSessionID = "6f1c1f5e-8d43-4d6f-9a3e-1b2c3d4e5f60"
+
+// setupProfileRouter настраивает маршрутизатор с маршрутами /me, где токен userToken
+// принадлежит пользователю userID и сеансу currentSessionID.
+
+func setupProfileRouter(authClient *mocks.MockAuthClient, userID uuid.UUID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, Routes{
+		Auth:           NewAuthHandler(authClient),
+		Calls:          NewCallHandler(nil, authClient),
+		Admin:          NewAdminHandler(nil),
+		Profile:        NewProfileHandler(authClient),
+		APIKeys:        NewAPIKeyHandler(nil),
+		UserData:       NewUserDataHandler(nil, authClient, nil),
+		Docs:           NewDocsHandler(nil),
+		AuthMiddleware: middleware.NewAuthMiddleware(authClient),
+	})
+
+	authClient.EXPECT().ValidateTokenFull(gomock.Any(), userToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: userID.String(), Role: middleware.RoleUser, SessionID: currentSessionID}, nil).AnyTimes()
+	return router
+}
+
+func doProfileRequest(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer "+userToken)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestProfileEmail проверяет получение, смену и подтверждение email текущего пользователя.
+
+func TestProfileEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	userID := uuid.New()
+	router := setupProfileRouter(mockAuthClient, userID)
+
+	mockAuthClient.EXPECT().GetUser(gomock.Any(), userID.String()).
+		Return(&authclient.UserInfo{UserID: userID.String(), Email: "user@example.com", EmailVerified: true}, nil)
+	mockAuthClient.EXPECT().UpdateEmail(gomock.Any(), userID.String(), "new@example.com").Return(nil)
+	mockAuthClient.EXPECT().VerifyEmail(gomock.Any(), userID.String(), "token").Return(nil)
+
+	w := doProfileRequest(router, "GET", "/me/email", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var response EmailResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, EmailResponse{Email: "user@example.com", EmailVerified: true}, response)
+
+	w = doProfileRequest(router, "PUT", "/me/email", `{"email": "new@example.com"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doProfileRequest(router, "POST", "/me/email/verify", `{"token": "token"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestProfileEmail_ErrorMapping проверяет перевод кодов gRPC сервиса аутентификации в HTTP-статусы.
+
+func TestProfileEmail_ErrorMapping(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	userID := uuid.New()
+	router := setupProfileRouter(mockAuthClient, userID)
+
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{status.Error(codes.InvalidArgument, "invalid email"), http.StatusBadRequest},
+		{status.Error(codes.AlreadyExists, "email already in use"), http.StatusConflict},
+		{status.Error(codes.NotFound, "user not found"), http.StatusNotFound},
+		{status.Error(codes.DeadlineExceeded, "deadline exceeded"), http.StatusGatewayTimeout},
+		{errors.New("connection refused"), http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		mockAuthClient.EXPECT().UpdateEmail(gomock.Any(), userID.String(), "user@example.com").Return(tc.err)
+
+		w := doProfileRequest(router, "PUT", "/me/email", `{"email": "user@example.com"}`)
+		assert.Equal(t, tc.want, w.Code, tc.err.Error())
+	}
+
+	// Без обязательных полей сервис аутентификации не вызывается
+	assert.Equal(t, http.StatusBadRequest, doProfileRequest(router, "PUT", "/me/email", `{}`).Code)
+	assert.Equal(t, http.StatusBadRequest, doProfileRequest(router, "POST", "/me/email/verify", `{}`).Code)
+}
+
+// TestProfileSessions проверяет список сеансов с отметкой текущего, завершение отдельного сеанса
+// и завершение всех сеансов, кроме текущего.
+
+func TestProfileSessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	userID := uuid.New()
+	router := setupProfileRouter(mockAuthClient, userID)
+	otherSessionID := uuid.New().String()
+	createdAt := time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC)
+
+	mockAuthClient.EXPECT().ListSessions(gomock.Any(), userID.String()).Return([]authclient.SessionInfo{
+		{ID: currentSessionID, DeviceLabel: "laptop", IP: "192.0.2.1", CreatedAt: createdAt, LastUsedAt: createdAt},
+		{ID: otherSessionID, DeviceLabel: "phone", IP: "192.0.2.2", CreatedAt: createdAt, LastUsedAt: createdAt},
+	}, nil)
+	mockAuthClient.EXPECT().RevokeSession(gomock.Any(), userID.String(), otherSessionID).Return(nil)
+	mockAuthClient.EXPECT().RevokeSession(gomock.Any(), userID.String(), otherSessionID).
+		Return(status.Error(codes.NotFound, "session not found"))
+	mockAuthClient.EXPECT().RevokeAllSessions(gomock.Any(), userID.String(), currentSessionID).Return(2, nil)
+
+	w := doProfileRequest(router, "GET", "/me/sessions", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var sessions []SessionResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sessions))
+	require.Len(t, sessions, 2)
+	assert.True(t, sessions[0].Current)
+	assert.False(t, sessions[1].Current)
+	assert.Equal(t, "phone", sessions[1].DeviceLabel)
+
+	assert.Equal(t, http.StatusOK, doProfileRequest(router, "DELETE", "/me/sessions/"+otherSessionID, "").Code)
+	assert.Equal(t, http.StatusNotFound, doProfileRequest(router, "DELETE", "/me/sessions/"+otherSessionID, "").Code)
+	assert.Equal(t, http.StatusBadRequest, doProfileRequest(router, "DELETE", "/me/sessions/not-a-uuid", "").Code)
+
+	w = doProfileRequest(router, "DELETE", "/me/sessions", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"revoked": 2}`, w.Body.String())
+}
+
+// TestProfileDevices проверяет список известных устройств текущего пользователя.
+
+func TestProfileDevices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	userID := uuid.New()
+	router := setupProfileRouter(mockAuthClient, userID)
+	seenAt := time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC)
+
+	mockAuthClient.EXPECT().ListDevices(gomock.Any(), userID.String()).Return([]authclient.DeviceInfo{
+		{Fingerprint: "f1", UserAgent: "Firefox", IP: "192.0.2.1", FirstSeenAt: seenAt, LastSeenAt: seenAt},
+	}, nil)
+	mockAuthClient.EXPECT().ListDevices(gomock.Any(), userID.String()).
+		Return(nil, status.Error(codes.DeadlineExceeded, "deadline exceeded"))
+
+	w := doProfileRequest(router, "GET", "/me/devices", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"fingerprint":"f1","user_agent":"Firefox","ip":"192.0.2.1",
+		"first_seen_at":"2025-03-22T12:00:00Z","last_seen_at":"2025-03-22T12:00:00Z"}]`, w.Body.String())
+
+	assert.Equal(t, http.StatusGatewayTimeout, doProfileRequest(router, "GET", "/me/devices", "").Code)
+}
+
+// TestProfile_ImpersonationForbidden проверяет, что администратор, работающий от имени
+// пользователя, не может выпустить API-ключ, сменить или подтвердить email и завершить сеансы
+// пользователя: эти действия пережили бы короткий срок действия токена.
+
+func TestProfile_ImpersonationForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	userID, adminID := uuid.New(), uuid.New()
+	router := setupProfileRouter(mockAuthClient, userID)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "impersonation-token").Return(&authclient.TokenInfo{
+		Valid: true, UserID: userID.String(), Role: middleware.RoleUser, SessionID: currentSessionID, ImpersonatedBy: adminID.String(),
+	}, nil).AnyTimes()
+
+	requests := []struct{ method, path, body string }{
+		{http.MethodPost, "/me/api-keys", `{"name":"ci"}`},
+		{http.MethodPut, "/me/email", `{"email":"admin@example.com"}`},
+		{http.MethodPost, "/me/email/verify", `{"token":"token"}`},
+		{http.MethodDelete, "/me/sessions/" + currentSessionID, ""},
+		{http.MethodDelete, "/me/sessions", ""},
+	}
+	for _, r := range requests {
+		req, _ := http.NewRequest(r.method, r.path, bytes.NewBufferString(r.body))
+		req.Header.Set("Authorization", "Bearer impersonation-token")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code, r.method+" "+r.path)
+		assert.Contains(t, w.Body.String(), "impersonation_forbidden", r.method+" "+r.path)
+	}
+}
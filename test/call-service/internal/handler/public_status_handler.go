@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/cache"
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/internal/model"
+	"call-service/internal/service"
+)
+
+// Ограничения проверки статуса заявки по коду по умолчанию (см. PublicStatusConfig).
+const (
+	// PublicStatusWindow — окно, за которое считаются запросы с одного IP-адреса и к одному коду.
+	PublicStatusWindow                 = time.Minute
+	DefaultPublicStatusIPLimit         = 10
+	DefaultPublicStatusRefLimit        = 5
+	DefaultPublicStatusMinResponseTime = 100 * time.Millisecond
+)
+
+// PublicStatusConfig задает ограничения проверки статуса заявки по коду.
+type PublicStatusConfig struct {
+	// IPLimiter и RefLimiter ограничивают запросы с одного IP-адреса и к одному коду заявки.
+	// Учитывается каждый запрос, в том числе с неизвестным кодом (middleware.Throttle), поэтому
+	// отказ не зависит от того, существует ли заявка. nil — ограничитель в памяти процесса
+	// на DefaultPublicStatusIPLimit и DefaultPublicStatusRefLimit запросов за PublicStatusWindow.
+	IPLimiter  middleware.Limiter
+	RefLimiter middleware.Limiter
+	// MinResponseTime — время, раньше которого не отправляется ответ ни о найденной,
+	// ни о ненайденной заявке, чтобы существующий код нельзя было отличить по времени
+	// ответа; 0 — ответ без задержки.
+	MinResponseTime time.Duration
+}
+
+// PublicStatusHandler обрабатывает запросы клиентов без учетной записи к статусу заявки
+// по ее коду (model.Call.Ref). Ответ содержит только статус и время создания заявки.
+type PublicStatusHandler struct {
+	calls service.CallService
+	cfg   PublicStatusConfig
+}
+
+// NewPublicStatusHandler создает новый экземпляр PublicStatusHandler.
+func NewPublicStatusHandler(calls service.CallService, cfg PublicStatusConfig) *PublicStatusHandler {
+	if cfg.IPLimiter == nil {
+		cfg.IPLimiter = middleware.NewStoreLimiter(cache.NewMemory(0, nil), "", DefaultPublicStatusIPLimit, PublicStatusWindow, nil)
+	}
+	if cfg.RefLimiter == nil {
+		cfg.RefLimiter = middleware.NewStoreLimiter(cache.NewMemory(0, nil), "", DefaultPublicStatusRefLimit, PublicStatusWindow, nil)
+	}
+	return &PublicStatusHandler{calls: calls, cfg: cfg}
+}
+
+// PublicCallStatusResponse — статус заявки в ответе на проверку по коду.
+type PublicCallStatusResponse struct {
+	Status      model.CallStatus `json:"status"`
+	StatusLabel string           `json:"status_label"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// limits возвращает middleware, ограничивающие запросы с одного IP-адреса и к одному коду.
+// Строка, которая не может быть кодом, ограничивается только по IP-адресу.
+func (h *PublicStatusHandler) limits() []gin.HandlerFunc {
+	return []gin.HandlerFunc{
+		middleware.Throttle(h.cfg.IPLimiter, middleware.ClientIP),
+		middleware.Throttle(h.cfg.RefLimiter, func(c *gin.Context) string {
+			ref, _ := model.NormalizeCallRef(c.Param("ref"))
+			return ref
+		}),
+	}
+}
+
+// GetStatus обрабатывает GET запрос на получение статуса заявки по коду. Неизвестный
+// и некорректный код одинаково отклоняются с кодом 404.
+func (h *PublicStatusHandler) GetStatus(c *gin.Context) {
+	deadline := time.Now().Add(h.cfg.MinResponseTime)
+
+	var call *model.Call
+	ref, ok := model.NormalizeCallRef(c.Param("ref"))
+	err := service.ErrCallNotFound
+	if ok {
+		call, err = h.calls.GetCallByRef(c.Request.Context(), ref)
+	}
+	if !h.waitUntil(c, deadline) {
+		return
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrCallNotFound) {
+			c.JSON(http.StatusNotFound, i18n.Response(c, i18n.CallNotFound))
+			return
+		}
+		writeServerError(c, err, i18n.GetCallFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, PublicCallStatusResponse{
+		Status:      call.Status,
+		StatusLabel: i18n.CallStatusLabel(i18n.Lang(c.GetHeader("Accept-Language")), string(call.Status)),
+		CreatedAt:   call.CreatedAt,
+	})
+}
+
+// waitUntil ждет наступления deadline; возвращает false, если запрос отменен раньше.
+func (h *PublicStatusHandler) waitUntil(c *gin.Context, deadline time.Time) bool {
+	wait := time.Until(deadline)
+	if wait <= 0 {
+		return true
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-c.Request.Context().Done():
+		return false
+	}
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/cache"
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/repository"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+)
+
+// setupPublicStatusRouter настраивает маршрутизатор с маршрутами /calls и проверкой статуса
+// по коду поверх настоящего сервиса заявок с хранилищем в памяти. Запросы статуса ограничены
+// ipLimit запросами с одного IP-адреса и двумя запросами к одному коду в минуту.
+// Токен "owner-token" принадлежит владельцу заявок.
+
+func setupPublicStatusRouter(t *testing.T, ipLimit int, minResponseTime time.Duration) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	authClient := mocks.NewMockAuthClient(gomock.NewController(t))
+	authClient.EXPECT().ValidateTokenFull(gomock.Any(), "owner-token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: uuid.New().String(), Role: middleware.RoleUser}, nil).AnyTimes()
+
+	calls := service.NewCallService(repository.NewInMemoryCallRepository())
+	router := gin.New()
+	RegisterRoutes(router, Routes{
+		Auth:  NewAuthHandler(authClient),
+		Calls: NewCallHandler(calls, authClient),
+		PublicStatus: NewPublicStatusHandler(calls, PublicStatusConfig{
+			IPLimiter:       middleware.NewStoreLimiter(cache.NewMemory(0, nil), "ip:", ipLimit, PublicStatusWindow, nil),
+			RefLimiter:      middleware.NewStoreLimiter(cache.NewMemory(0, nil), "ref:", 2, PublicStatusWindow, nil),
+			MinResponseTime: minResponseTime,
+		}),
+		Admin:          NewAdminHandler(nil),
+		Docs:           NewDocsHandler(nil),
+		AuthMiddleware: middleware.NewAuthMiddleware(authClient),
+	})
+	return router
+}
+
+// createCallRef создает заявку владельцем и возвращает ее код.
+func createCallRef(t *testing.T, router *gin.Engine) string {
+	w := doInMemoryRequest(t, router, "POST", "/calls", "owner-token",
+		`{"client_name":"Иван","phone_number":"+79990000001","description":"Перезвонить"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var call CallResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &call))
+	require.Len(t, call.Ref, 8)
+	return call.Ref
+}
+
+// doPublicStatus запрашивает статус заявки с кодом ref без токена с адреса remoteAddr.
+func doPublicStatus(router *gin.Engine, ref, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/public/calls/"+ref+"/status", nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestPublicStatus_GetStatus проверяет, что по коду без учетной записи возвращаются только
+// статус и время создания заявки, код принимается в нижнем регистре и с дефисом, а неизвестный
+// и некорректный код отклоняются одинаково и не быстрее найденной заявки.
+
+func TestPublicStatus_GetStatus(t *testing.T) {
+	minResponseTime := 30 * time.Millisecond
+	router := setupPublicStatusRouter(t, 100, minResponseTime)
+	ref := createCallRef(t, router)
+
+	start := time.Now()
+	w := doPublicStatus(router, strings.ToLower(ref[:4]+"-"+ref[4:]), "192.0.2.1:1234")
+	assert.GreaterOrEqual(t, time.Since(start), minResponseTime)
+	require.Equal(t, http.StatusOK, w.Code)
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.ElementsMatch(t, []string{"status", "status_label", "created_at"}, keys(body))
+	assert.Equal(t, "open", body["status"])
+
+	for _, unknown := range []string{"00000000", "not-a-ref"} {
+		start := time.Now()
+		w := doPublicStatus(router, unknown, "192.0.2.1:1234")
+		assert.GreaterOrEqual(t, time.Since(start), minResponseTime, unknown)
+		assert.Equal(t, http.StatusNotFound, w.Code, unknown)
+		assert.Contains(t, w.Body.String(), "call_not_found", unknown)
+	}
+}
+
+// TestPublicStatus_RateLimit проверяет, что запросы к одному коду ограничены одинаково
+// для существующей и неизвестной заявки, так что ответ 429 не выдает существование кода,
+// а запросы с одного IP-адреса ограничены независимо от кода.
+
+func TestPublicStatus_RateLimit(t *testing.T) {
+	router := setupPublicStatusRouter(t, 6, 0)
+	ref := createCallRef(t, router)
+	const addr = "192.0.2.1:1234"
+
+	assert.Equal(t, http.StatusOK, doPublicStatus(router, ref, addr).Code)
+	assert.Equal(t, http.StatusOK, doPublicStatus(router, ref, addr).Code)
+	known := doPublicStatus(router, ref, addr)
+	assert.Equal(t, http.StatusNotFound, doPublicStatus(router, "00000000", addr).Code)
+	assert.Equal(t, http.StatusNotFound, doPublicStatus(router, "00000000", addr).Code)
+	unknown := doPublicStatus(router, "00000000", addr)
+
+	assert.Equal(t, http.StatusTooManyRequests, known.Code)
+	assert.Equal(t, http.StatusTooManyRequests, unknown.Code)
+	assert.Equal(t, known.Body.String(), unknown.Body.String())
+	assert.Equal(t, known.Header().Get("Retry-After"), unknown.Header().Get("Retry-After"))
+
+	// Лимит адреса исчерпан: новый код отклоняется, запросы с другого адреса выполняются
+	assert.Equal(t, http.StatusTooManyRequests, doPublicStatus(router, "11111111", addr).Code)
+	assert.Equal(t, http.StatusNotFound, doPublicStatus(router, "11111111", "198.51.100.7:1234").Code)
+}
+
+// TestPublicStatus_SpoofedForwardedFor проверяет, что лимит IP-адреса нельзя обойти,
+// подставляя разные адреса в X-Forwarded-For: без доверенных прокси заголовок не учитывается.
+
+func TestPublicStatus_SpoofedForwardedFor(t *testing.T) {
+	router := setupPublicStatusRouter(t, 2, 0)
+
+	codes := make([]int, 0, 3)
+	for i := range 3 {
+		req := httptest.NewRequest(http.MethodGet, "/public/calls/0000000"+strconv.Itoa(i)+"/status", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Forwarded-For", "203.0.113."+strconv.Itoa(i+1))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+	assert.Equal(t, []int{http.StatusNotFound, http.StatusNotFound, http.StatusTooManyRequests}, codes)
+}
+
+// keys возвращает ключи объекта JSON.
+func keys(m map[string]any) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
@@ -0,0 +1,342 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+)
+
+// Routes содержит обработчики и middleware, из которых собирается HTTP API.
+type Routes struct {
+	Auth  *AuthHandler
+	Calls *CallHandler
+	// Events — события заявок по WebSocket (GET /calls/ws).
+	Events      *CallEventsHandler
+	Attachments *AttachmentHandler
+	Admin       *AdminHandler
+	Profile     *ProfileHandler
+	APIKeys     *APIKeyHandler
+	// Views — сохраненные представления списка заявок (/calls/views и GET /calls?view=).
+	Views    *SavedViewHandler
+	UserData *UserDataHandler
+	// Notifications — настройки уведомлений (/me/notifications) и webhook бота Telegram;
+	// nil, если маршруты не нужны.
+	Notifications *NotificationHandler
+	// Webhooks — подписки на события заявок и их очередь доставки (/webhooks); nil, если
+	// маршруты не нужны.
+	Webhooks *WebhookHandler
+	// PublicStatus — проверка статуса заявки клиентом по коду без учетной записи; nil,
+	// если маршрут не нужен.
+	PublicStatus *PublicStatusHandler
+	// Impersonation — выпуск токенов для работы администратора от имени пользователя.
+	Impersonation *ImpersonationHandler
+	// Users — список и выгрузка пользователей сервиса аутентификации для администраторов.
+	Users *AdminUsersHandler
+	// Organizations — обработчик организаций; nil, если организации отключены.
+	Organizations *OrganizationHandler
+	Docs          *DocsHandler
+	// FeatureFlags — просмотр и перечитывание флагов; nil, если маршруты не нужны.
+	FeatureFlags *FeatureFlagsHandler
+	// Config — перечитывание конфигурации без перезапуска; nil, если маршрут не нужен.
+	Config *ConfigHandler
+	// Maintenance — переключатель режима обслуживания; nil, если режим не используется.
+	Maintenance *middleware.Maintenance
+	// Faults — внесение сбоев для проверки устойчивости клиентов; nil вне сред разработки
+	// и тестирования.
+	Faults *middleware.FaultInjector
+	// Health — обработчик /healthz; nil, если проверка состояния не нужна.
+	Health *HealthHandler
+	// SLO — сводка целей уровня обслуживания (GET /admin/slo) и показатели в формате Prometheus
+	// (GET /metrics); nil, если цели не заданы.
+	SLO            *SLOHandler
+	AuthMiddleware *middleware.AuthMiddleware
+	// AuditLog — журнал изменяющих запросов; nil, если журнал отключен.
+	AuditLog *AuditLogHandler
+	// ResponseCache — кэш ответов дорогих маршрутов чтения (GET /calls); nil, если ответы
+	// не кэшируются.
+	ResponseCache *middleware.ResponseCache
+
+	// SwaggerUI включает страницу Swagger UI; в production-режиме она отключена.
+	SwaggerUI bool
+	// Readiness — состояние для /readyz; nil, если проверка готовности не нужна.
+	Readiness *Readiness
+	// ExportLimiter ограничивает выгрузки данных пользователя; nil означает ограничитель в памяти
+	// процесса с интервалом UserDataExportInterval.
+	ExportLimiter middleware.Limiter
+}
+
+// RegisterRoutes регистрирует все маршруты HTTP API в маршрутизаторе.
+// Каждый маршрут должен быть описан в спецификации пакета openapi.
+func RegisterRoutes(router *gin.Engine, r Routes) {
+	// Журнал изменяющих запросов подключается первым, чтобы в него попадали и отклоненные
+	// запросы. Тела запросов с паролями, ID-токенами, кодами подтверждения, приглашений и привязки
+	// Telegram не хешируются
+	if r.AuditLog != nil {
+		router.Use(middleware.Audit(middleware.AuditConfig{
+			Recorder: r.AuditLog.auditLog,
+			RedactPaths: []string{
+				"/register", "/login", "/api/v2/register", "/api/v2/login", "/login/oidc", "/me/email/verify", "/invites/accept",
+				"/telegram/webhook"},
+		}))
+	}
+
+	// Режим обслуживания отклоняет изменяющие запросы ко всем маршрутам, кроме входа
+	// и регистрации (они не изменяют данные сервиса заявок), самого переключателя
+	// и перечитывания флагов и конфигурации
+	if r.Maintenance != nil {
+		router.Use(r.Maintenance.Reject(
+			"/register", "/login", "/api/v2/register", "/api/v2/login", "/login/oidc", "/admin/maintenance",
+			"/admin/feature-flags/reload", "/admin/config/reload"))
+	}
+
+	// Внесение сбоев подключается после middleware Timeout, чтобы задержки учитывались
+	// в сроке запроса. Правила остаются доступными, чтобы сбои можно было отключить
+	if r.Faults != nil {
+		router.Use(r.Faults.Inject("/admin/faults"))
+	}
+
+	// Регистрация маршрутов аутентификации; маршруты без версии возвращают прежний формат
+	// ответа и сохраняются до отключения
+	root := withHead(router)
+	root.POST("/register", r.Auth.Register)
+	root.POST("/login", r.Auth.Login)
+	root.POST("/api/v2/register", r.Auth.RegisterV2)
+	root.POST("/api/v2/login", r.Auth.LoginV2)
+	root.POST("/login/oidc", r.Auth.LoginOIDC)
+
+	// Выгрузка данных пользователя ограничена для каждого пользователя (по умолчанию не чаще раза
+	// в UserDataExportInterval), выгрузки самим пользователем и администратором учитываются вместе
+	exportLimiter := r.ExportLimiter
+	if exportLimiter == nil {
+		exportLimiter = middleware.NewRateLimiter(UserDataExportInterval, nil)
+	}
+	exportLimit := middleware.Limit(exportLimiter, exportRateLimitKey)
+	callID := middleware.BindUUIDParam("id", i18n.InvalidCallID)
+	attachmentID := middleware.BindUUIDParam("aid", i18n.InvalidAttachmentID)
+	viewID := middleware.BindUUIDParam("id", i18n.InvalidSavedViewID)
+	userID := middleware.BindUUIDParam("id", i18n.InvalidUserID)
+
+	// Группа маршрутов для работы с вызовами
+	calls := withHead(router.Group("/calls"))
+	calls.Use(r.AuthMiddleware.AuthRequired())
+	{
+		calls.POST("", r.Calls.CreateCall)
+		calls.GET("", r.cached("calls", TotalCountHeader), r.Views.ApplyView, r.Calls.GetAllCalls)
+		calls.GET("/export", r.Calls.ExportCalls)
+		calls.GET("/due", r.Calls.GetDueCalls)
+		calls.GET("/views", r.Views.ListViews)
+		calls.POST("/views", r.Views.CreateView)
+		calls.GET("/views/:id", viewID, r.Views.GetView)
+		calls.PUT("/views/:id", viewID, r.Views.UpdateView)
+		calls.DELETE("/views/:id", viewID, r.Views.DeleteView)
+		calls.GET("/:id", callID, r.Calls.GetCall)
+		calls.PATCH("/:id/status", callID, r.Calls.UpdateCallStatus)
+		calls.PATCH("/:id/callback", callID, r.Calls.UpdateCallback)
+		calls.POST("/:id/dial", callID, r.Calls.DialCall)
+		calls.GET("/:id/activity", callID, r.Calls.GetCallActivity)
+		calls.POST("/:id/attachments", callID, r.Attachments.UploadAttachment)
+		calls.GET("/:id/attachments/:aid", callID, attachmentID, r.Attachments.GetAttachment)
+		calls.DELETE("/:id", callID, r.Calls.DeleteCall)
+	}
+
+	// События заявок по WebSocket. Маршрут зарегистрирован вне группы /calls: токен можно
+	// передать первым сообщением соединения, поэтому запрос без токена не отклоняется
+	router.GET("/calls/ws", r.AuthMiddleware.DeferredAuth(), r.Events.Subscribe)
+
+	// Группа маршрутов для работы с учетной записью текущего пользователя
+	me := withHead(router.Group("/me"))
+	me.Use(r.AuthMiddleware.AuthRequired())
+	{
+		me.GET("/email", r.Profile.GetEmail)
+		me.PUT("/email", r.Profile.UpdateEmail)
+		me.POST("/email/verify", r.Profile.VerifyEmail)
+		me.GET("/sessions", r.Profile.ListSessions)
+		me.DELETE("/sessions", r.Profile.RevokeOtherSessions)
+		me.DELETE("/sessions/:id", middleware.BindUUIDParam("id", i18n.InvalidSessionID), r.Profile.RevokeSession)
+		me.GET("/devices", r.Profile.ListDevices)
+		me.GET("/export", exportLimit, r.UserData.ExportMyData)
+		me.DELETE("", r.UserData.EraseMyAccount)
+		me.POST("/api-keys", r.APIKeys.CreateAPIKey)
+		me.GET("/api-keys", r.APIKeys.ListAPIKeys)
+		me.DELETE("/api-keys/:id", middleware.BindUUIDParam("id", i18n.InvalidAPIKeyID), r.APIKeys.RevokeAPIKey)
+	}
+
+	// Настройки уведомлений и привязка чата Telegram. Webhook бота вызывается Telegram
+	// без токена пользователя и проверяет секрет webhook
+	if r.Notifications != nil {
+		me.GET("/notifications", r.Notifications.GetNotifications)
+		me.PUT("/notifications", r.Notifications.UpdateNotifications)
+		me.POST("/notifications/telegram", r.Notifications.CreateTelegramLink)
+		me.DELETE("/notifications/telegram", r.Notifications.DeleteTelegramLink)
+		root.POST("/telegram/webhook", r.Notifications.TelegramWebhook)
+	}
+
+	// Группа маршрутов для работы с подписками на события заявок и их доставками
+	if r.Webhooks != nil {
+		webhookID := middleware.BindUUIDParam("id", i18n.InvalidWebhookID)
+		deliveryID := middleware.BindUUIDParam("deliveryId", i18n.InvalidWebhookDeliveryID)
+		webhooks := withHead(router.Group("/webhooks"))
+		webhooks.Use(r.AuthMiddleware.AuthRequired())
+		{
+			webhooks.GET("", r.Webhooks.ListWebhooks)
+			webhooks.POST("", r.Webhooks.CreateWebhook)
+			webhooks.DELETE("/:id", webhookID, r.Webhooks.DeleteWebhook)
+			webhooks.GET("/:id/deliveries", webhookID, r.Webhooks.ListDeliveries)
+			webhooks.POST("/:id/deliveries/:deliveryId/retry", webhookID, deliveryID, r.Webhooks.RetryDelivery)
+			webhooks.POST("/:id/retry-failed", webhookID, r.Webhooks.RetryFailed)
+		}
+	}
+
+	// Группа маршрутов для работы с организациями пользователей
+	if r.Organizations != nil {
+		orgID := middleware.BindUUIDParam("id", i18n.InvalidOrganizationID)
+		inviteID := middleware.BindUUIDParam("inviteId", i18n.InvalidInviteID)
+		orgs := withHead(router.Group("/organizations"))
+		orgs.Use(r.AuthMiddleware.AuthRequired())
+		{
+			orgs.POST("", r.Organizations.CreateOrganization)
+			orgs.POST("/:id/members", orgID, r.Organizations.InviteMember)
+			orgs.POST("/:id/invites", orgID, r.Organizations.CreateInvite)
+			orgs.GET("/:id/invites", orgID, r.Organizations.ListInvites)
+			orgs.DELETE("/:id/invites/:inviteId", orgID, inviteID, r.Organizations.RevokeInvite)
+		}
+		root.POST("/invites/accept", r.AuthMiddleware.AuthRequired(), r.Organizations.AcceptInvite)
+	}
+
+	// Группа маршрутов администратора для работы с заявками всех пользователей
+	admin := withHead(router.Group("/admin"))
+	admin.Use(r.AuthMiddleware.AuthRequired(), middleware.RequireRole(middleware.RoleAdmin))
+	{
+		admin.GET("/calls", r.Admin.ListCalls)
+		admin.GET("/calls/:id", callID, r.Admin.GetCall)
+		admin.PATCH("/calls/:id/status", callID, r.Admin.UpdateCallStatus)
+		admin.PATCH("/calls/:id/assignee", callID, r.Admin.ReassignCall)
+		admin.GET("/users", r.Users.ListUsers)
+		admin.GET("/users/export", r.Users.ExportUsers)
+		admin.GET("/users/:id/export", userID, exportLimit, r.UserData.ExportUserData)
+		admin.POST("/users/:id/impersonate", userID, r.Impersonation.ImpersonateUser)
+		if r.Maintenance != nil {
+			admin.POST("/maintenance", NewMaintenanceHandler(r.Maintenance).SetMaintenance)
+		}
+		if r.Faults != nil {
+			faults := NewFaultsHandler(r.Faults)
+			admin.GET("/faults", faults.GetFaults)
+			admin.PUT("/faults", faults.SetFaults)
+		}
+		if r.FeatureFlags != nil {
+			admin.GET("/feature-flags", r.FeatureFlags.GetFeatureFlags)
+			admin.POST("/feature-flags/reload", r.FeatureFlags.ReloadFeatureFlags)
+		}
+		if r.Config != nil {
+			admin.POST("/config/reload", r.Config.ReloadConfig)
+		}
+		if r.AuditLog != nil {
+			admin.GET("/audit-log", r.AuditLog.ListAuditLog)
+			admin.GET("/security/summary", r.AuditLog.SecuritySummary)
+		}
+		if r.SLO != nil {
+			admin.GET("/slo", r.SLO.GetSummary)
+		}
+	}
+
+	// Статус заявки по коду для клиентов без учетной записи. Запросы ограничиваются по IP-адресу
+	// и коду, чтобы коды нельзя было подобрать перебором
+	if r.PublicStatus != nil {
+		root.GET("/public/calls/:ref/status", append(r.PublicStatus.limits(), r.PublicStatus.GetStatus)...)
+	}
+
+	// Проверка состояния сервиса, без аутентификации
+	if r.Health != nil {
+		root.GET("/healthz", r.Health.Health)
+	}
+	if r.Readiness != nil {
+		root.GET("/readyz", r.Readiness.Ready)
+	}
+	if r.SLO != nil {
+		root.GET("/metrics", r.SLO.Metrics)
+	}
+	root.GET("/version", GetVersion)
+
+	// Документация API
+	root.GET("/api/v1/openapi.json", r.Docs.OpenAPISpec)
+	if r.SwaggerUI {
+		root.GET("/swagger", r.Docs.SwaggerUI)
+	}
+
+	// Неизвестный путь и неподдерживаемый метод возвращают ошибку в общем формате API.
+	// Заголовок Allow для ответа 405 gin заполняет по таблице маршрутов
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(routeNotFound)
+	router.NoMethod(methodNotAllowed)
+}
+
+// headRouter оборачивает группу маршрутов и для каждого GET-маршрута регистрирует
+// такой же HEAD-маршрут: обработчик выполняется как для GET, а тело ответа отбрасывается.
+type headRouter struct {
+	gin.IRouter
+}
+
+// withHead возвращает группу маршрутов r с автоматической поддержкой HEAD.
+func withHead(r gin.IRouter) headRouter {
+	return headRouter{IRouter: r}
+}
+
+// GET регистрирует обработчики handlers для GET и HEAD запросов к path.
+func (r headRouter) GET(path string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	routes := r.IRouter.GET(path, handlers...)
+	r.IRouter.HEAD(path, append([]gin.HandlerFunc{discardBody}, handlers...)...)
+	return routes
+}
+
+// cached возвращает middleware кэша ответов маршрута route с сохранением заголовков headers
+// или пустой обработчик, если кэш ответов отключен. Кэш подключается до ApplyView:
+// изменение сохраненного представления становится видно по истечении времени жизни кэша.
+func (r Routes) cached(route string, headers ...string) gin.HandlerFunc {
+	if r.ResponseCache == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return r.ResponseCache.Cache(route, headers...)
+}
+
+// discardBody подменяет writer ответа так, что тело ответа на HEAD запрос не отправляется,
+// а код ответа и заголовки сохраняются.
+func discardBody(c *gin.Context) {
+	c.Writer = headResponseWriter{ResponseWriter: c.Writer}
+	c.Next()
+}
+
+// headResponseWriter отправляет код ответа и заголовки, отбрасывая тело.
+type headResponseWriter struct {
+	gin.ResponseWriter
+}
+
+func (w headResponseWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	return len(data), nil
+}
+
+func (w headResponseWriter) WriteString(s string) (int, error) {
+	w.WriteHeaderNow()
+	return len(s), nil
+}
+
+// routeNotFound отвечает 404 на запрос к неизвестному пути.
+func routeNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, i18n.Response(c, i18n.NotFound))
+}
+
+// methodNotAllowed отвечает на запрос к известному пути неподдерживаемым методом: 405
+// с заголовком Allow, а на OPTIONS — 204 с тем же заголовком. OPTIONS поддерживается
+// для всех путей и поэтому добавляется в Allow.
+func methodNotAllowed(c *gin.Context) {
+	allow := c.Writer.Header().Get("Allow") + ", " + http.MethodOptions
+	c.Header("Allow", allow)
+	if c.Request.Method == http.MethodOptions {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusMethodNotAllowed, i18n.Response(c, i18n.MethodNotAllowed))
+}
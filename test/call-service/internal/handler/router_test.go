@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/mocks"
+	"call-service/internal/model"
+)
+
+// TestRoutes_MethodNotAllowed проверяет, что неподдерживаемый метод на существующем пути
+// возвращает 405 с заголовком Allow и ошибкой в общем формате, а OPTIONS — 204 с тем же заголовком.
+
+func TestRoutes_MethodNotAllowed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	router := setupAdminRouter(mocks.NewMockCallService(ctrl), mocks.NewMockAuthClient(ctrl))
+	path := "/calls/" + uuid.New().String()
+
+	req, _ := http.NewRequest("PUT", path, nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, HEAD, DELETE, OPTIONS", w.Header().Get("Allow"))
+	var response map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "method_not_allowed", response["code"])
+	assert.NotEmpty(t, response["error"])
+
+	req, _ = http.NewRequest("OPTIONS", path, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "GET, HEAD, DELETE, OPTIONS", w.Header().Get("Allow"))
+	assert.Empty(t, w.Body.String())
+}
+
+// TestRoutes_Head проверяет, что HEAD-запрос к GET-маршруту выполняет обработчик
+// и возвращает код ответа и заголовки без тела.
+
+func TestRoutes_Head(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCallService := mocks.NewMockCallService(ctrl)
+	router := setupAdminRouter(mockCallService, mocks.NewMockAuthClient(ctrl))
+	calls := []*model.Call{{ID: uuid.New(), UserID: adminUserID, Status: model.CallStatusOpen}}
+	mockCallService.EXPECT().GetAllCalls(gomock.Any(), adminUserID, gomock.Any()).Return(calls, len(calls), nil)
+
+	req, _ := http.NewRequest("HEAD", "/calls", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get(TotalCountHeader))
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	assert.Empty(t, w.Body.String())
+}
+
+// TestRoutes_NotFound проверяет, что запрос к неизвестному пути возвращает 404
+// с ошибкой в общем формате.
+
+func TestRoutes_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	router := setupAdminRouter(mocks.NewMockCallService(ctrl), mocks.NewMockAuthClient(ctrl))
+
+	for _, method := range []string{"GET", "POST", "OPTIONS"} {
+		req, _ := http.NewRequest(method, "/no-such-path", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, method)
+		assert.Empty(t, w.Header().Get("Allow"), method)
+		var response map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), method)
+		assert.Equal(t, "not_found", response["code"], method)
+	}
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/internal/model"
+	"call-service/internal/service"
+)
+
+// SavedViewHandler обрабатывает HTTP запросы текущего пользователя к его сохраненным
+// представлениям — именованным фильтрам списка заявок GET /calls.
+type SavedViewHandler struct {
+	views service.SavedViewService
+}
+
+// NewSavedViewHandler создает новый экземпляр SavedViewHandler.
+func NewSavedViewHandler(views service.SavedViewService) *SavedViewHandler {
+	return &SavedViewHandler{views: views}
+}
+
+// SavedViewRequest содержит название представления и его фильтр — параметры строки запроса
+// GET /calls со строковыми значениями, например {"status": "open", "sort": "callback_at"}.
+type SavedViewRequest struct {
+	Name   string            `json:"name" binding:"required"`
+	Filter map[string]string `json:"filter"`
+}
+
+// SavedViewResponse описывает сохраненное представление.
+type SavedViewResponse struct {
+	ID        uuid.UUID         `json:"id"`
+	Name      string            `json:"name"`
+	Filter    map[string]string `json:"filter"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// CreateView обрабатывает POST запрос на сохранение представления текущего пользователя.
+func (h *SavedViewHandler) CreateView(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	req, ok := bindSavedViewRequest(c)
+	if !ok {
+		return
+	}
+
+	view, err := h.views.CreateView(c.Request.Context(), userID, req.Name, req.Filter)
+	if err != nil {
+		writeSavedViewError(c, err, i18n.CreateSavedViewFailed)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, view.ID.String())
+	c.JSON(http.StatusCreated, newSavedViewResponse(view))
+}
+
+// ListViews обрабатывает GET запрос на получение представлений текущего пользователя,
+// упорядоченных по названию.
+func (h *SavedViewHandler) ListViews(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	views, err := h.views.ListViews(c.Request.Context(), userID)
+	if err != nil {
+		writeServerError(c, err, i18n.ListSavedViewsFailed)
+		return
+	}
+
+	response := make([]SavedViewResponse, 0, len(views))
+	for _, view := range views {
+		response = append(response, newSavedViewResponse(view))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetView обрабатывает GET запрос на получение представления текущего пользователя.
+func (h *SavedViewHandler) GetView(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	view, err := h.views.GetView(c.Request.Context(), userID, middleware.GetUUIDParam(c, "id"))
+	if err != nil {
+		writeSavedViewError(c, err, i18n.GetSavedViewFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, newSavedViewResponse(view))
+}
+
+// UpdateView обрабатывает PUT запрос на замену названия и фильтра представления текущего
+// пользователя.
+func (h *SavedViewHandler) UpdateView(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	req, ok := bindSavedViewRequest(c)
+	if !ok {
+		return
+	}
+
+	view, err := h.views.UpdateView(c.Request.Context(), userID, middleware.GetUUIDParam(c, "id"), req.Name, req.Filter)
+	if err != nil {
+		writeSavedViewError(c, err, i18n.UpdateSavedViewFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, newSavedViewResponse(view))
+}
+
+// DeleteView обрабатывает DELETE запрос на удаление представления текущего пользователя.
+func (h *SavedViewHandler) DeleteView(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	if err := h.views.DeleteView(c.Request.Context(), userID, middleware.GetUUIDParam(c, "id")); err != nil {
+		writeSavedViewError(c, err, i18n.DeleteSavedViewFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Saved view deleted"})
+}
+
+// ApplyView подключается перед CallHandler.GetAllCalls и подставляет в запрос фильтр
+// сохраненного представления из параметра view: параметры, переданные в строке запроса
+// явно, заменяют одноименные параметры фильтра. Дальше список строится как обычно.
+// Запрос без параметра view передается дальше без изменений.
+func (h *SavedViewHandler) ApplyView(c *gin.Context) {
+	query := c.Request.URL.Query()
+	value := query.Get("view")
+	if value == "" {
+		return
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidSavedViewID))
+		return
+	}
+	userID, _ := middleware.GetUserID(c)
+
+	view, err := h.views.GetView(c.Request.Context(), userID, id)
+	if err != nil {
+		writeSavedViewError(c, err, i18n.GetSavedViewFailed)
+		c.Abort()
+		return
+	}
+
+	query.Del("view")
+	for name, value := range view.Filter {
+		if !query.Has(name) {
+			query.Set(name, value)
+		}
+	}
+	c.Set(callQueryKey, query)
+}
+
+// bindSavedViewRequest разбирает тело запроса и проверяет фильтр представления; при ошибке
+// отправляет ответ 400 и возвращает false.
+func bindSavedViewRequest(c *gin.Context) (SavedViewRequest, bool) {
+	var req SavedViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return req, false
+	}
+	if err := validateViewFilter(req.Filter); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return req, false
+	}
+	return req, true
+}
+
+// validateViewFilter проверяет фильтр представления так же, как параметры списка заявок.
+// Параметры не из callFilterParams отклоняются, чтобы в хранилище не попадали фильтры,
+// которые не удастся применить или преобразовать при изменении списка заявок.
+func validateViewFilter(filter map[string]string) error {
+	query := make(url.Values, len(filter))
+	for _, name := range slices.Sorted(maps.Keys(filter)) {
+		if !slices.Contains(callFilterParams, name) {
+			return i18n.New(i18n.UnknownViewFilterParam, name)
+		}
+		query.Set(name, filter[name])
+	}
+
+	parsed, err := parseCallQuery(query, 0)
+	if err != nil {
+		return err
+	}
+	if parsed.Status != "" && !parsed.Status.Valid() {
+		return i18n.New(i18n.InvalidStatus)
+	}
+	return nil
+}
+
+// writeSavedViewError отправляет ответ с ошибкой операции с представлением; code — код
+// ошибки для непредвиденных ошибок.
+func writeSavedViewError(c *gin.Context, err error, code i18n.Code) {
+	switch {
+	case errors.Is(err, service.ErrSavedViewNotFound):
+		c.JSON(http.StatusNotFound, i18n.Response(c, i18n.SavedViewNotFound))
+	case errors.Is(err, service.ErrInvalidSavedViewName):
+		c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidSavedViewName))
+	case errors.Is(err, service.ErrSavedViewNameTaken):
+		c.JSON(http.StatusConflict, i18n.Response(c, i18n.SavedViewNameTaken))
+	case errors.Is(err, service.ErrTooManySavedViews):
+		c.JSON(http.StatusConflict, i18n.Response(c, i18n.TooManySavedViews, service.MaxSavedViews))
+	case errors.Is(err, service.ErrUnsupportedSavedView):
+		c.JSON(http.StatusConflict, i18n.Response(c, i18n.UnsupportedSavedView))
+	default:
+		writeServerError(c, err, code)
+	}
+}
+
+// newSavedViewResponse преобразует представление в ответ.
+func newSavedViewResponse(view *model.SavedView) SavedViewResponse {
+	return SavedViewResponse{
+		ID:        view.ID,
+		Name:      view.Name,
+		Filter:    view.Filter,
+		CreatedAt: view.CreatedAt,
+		UpdatedAt: view.UpdatedAt,
+	}
+}
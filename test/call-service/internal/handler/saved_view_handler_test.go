@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/repository"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+)
+
+// setupSavedViewRouter настраивает маршрутизатор с маршрутами /calls поверх настоящих
+// сервисов заявок и представлений с хранилищами в памяти. Токены "owner-token"
+// и "other-token" принадлежат двум разным пользователям.
+
+func setupSavedViewRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	authClient := mocks.NewMockAuthClient(gomock.NewController(t))
+	for _, token := range []string{"owner-token", "other-token"} {
+		authClient.EXPECT().ValidateTokenFull(gomock.Any(), token).
+			Return(&authclient.TokenInfo{Valid: true, UserID: uuid.New().String(), Role: middleware.RoleUser}, nil).AnyTimes()
+	}
+
+	router := gin.New()
+	RegisterRoutes(router, Routes{
+		Auth:           NewAuthHandler(authClient),
+		Calls:          NewCallHandler(service.NewCallService(repository.NewInMemoryCallRepository()), authClient),
+		Views:          NewSavedViewHandler(service.NewSavedViewService(repository.NewInMemorySavedViewRepository())),
+		Admin:          NewAdminHandler(nil),
+		Docs:           NewDocsHandler(nil),
+		AuthMiddleware: middleware.NewAuthMiddleware(authClient),
+	})
+	return router
+}
+
+// TestSavedViews_CRUD проверяет создание, список, изменение и удаление представлений,
+// уникальность названия и доступ только владельца.
+
+func TestSavedViews_CRUD(t *testing.T) {
+	router := setupSavedViewRouter(t)
+
+	w := doInMemoryRequest(t, router, "POST", "/calls/views", "owner-token", `{"name":"open","filter":{"status":"open","sort":"client_name"}}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var view SavedViewResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &view))
+	assert.Equal(t, map[string]string{"status": "open", "sort": "client_name"}, view.Filter)
+
+	w = doInMemoryRequest(t, router, "POST", "/calls/views", "owner-token", `{"name":"open"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "saved_view_name_taken")
+
+	w = doInMemoryRequest(t, router, "POST", "/calls/views", "owner-token", `{"name":"due","filter":{}}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	w = doInMemoryRequest(t, router, "GET", "/calls/views", "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var views []SavedViewResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &views))
+	require.Len(t, views, 2)
+	assert.Equal(t, "due", views[0].Name)
+
+	path := "/calls/views/" + view.ID.String()
+	w = doInMemoryRequest(t, router, "GET", path, "other-token", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doInMemoryRequest(t, router, "PUT", path, "owner-token", `{"name":"due"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = doInMemoryRequest(t, router, "PUT", path, "owner-token", `{"name":"closed","filter":{"status":"closed"}}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doInMemoryRequest(t, router, "GET", path, "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var updated SavedViewResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, "closed", updated.Name)
+	assert.Equal(t, map[string]string{"status": "closed"}, updated.Filter)
+
+	w = doInMemoryRequest(t, router, "DELETE", path, "owner-token", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doInMemoryRequest(t, router, "DELETE", path, "owner-token", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doInMemoryRequest(t, router, "GET", "/calls/views/not-a-uuid", "owner-token", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestSavedViews_Validation проверяет, что фильтр проверяется по параметрам списка заявок:
+// неизвестные параметры и неверные значения отклоняются, а число представлений ограничено.
+
+func TestSavedViews_Validation(t *testing.T) {
+	router := setupSavedViewRouter(t)
+
+	tests := []struct {
+		body string
+		code string
+	}{
+		{`{"name":"x","filter":{"color":"red"}}`, "unknown_view_filter_param"},
+		{`{"name":"x","filter":{"view":"open"}}`, "unknown_view_filter_param"},
+		{`{"name":"x","filter":{"status":"lost"}}`, "invalid_status"},
+		{`{"name":"x","filter":{"sort":"phone_number"}}`, "invalid_sort_field"},
+		{`{"name":"x","filter":{"limit":"0"}}`, "invalid_limit"},
+		{`{"name":"x","filter":{"created_from":"yesterday"}}`, "invalid_time"},
+		{`{"name":"x","filter":{"limit":10}}`, "invalid_request_body"},
+		{`{"name":"  ","filter":{}}`, "invalid_saved_view_name"},
+	}
+	for _, tt := range tests {
+		w := doInMemoryRequest(t, router, "POST", "/calls/views", "owner-token", tt.body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, tt.body)
+		assert.Contains(t, w.Body.String(), tt.code, tt.body)
+	}
+
+	for i := range service.MaxSavedViews {
+		w := doInMemoryRequest(t, router, "POST", "/calls/views", "owner-token", fmt.Sprintf(`{"name":"view %d"}`, i))
+		require.Equal(t, http.StatusCreated, w.Code)
+	}
+	w := doInMemoryRequest(t, router, "POST", "/calls/views", "owner-token", `{"name":"one more"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "too_many_saved_views")
+}
+
+// TestSavedViews_ApplyToList проверяет GET /calls?view=: список строится по фильтру
+// представления, а параметры строки запроса заменяют одноименные параметры фильтра.
+
+func TestSavedViews_ApplyToList(t *testing.T) {
+	router := setupSavedViewRouter(t)
+	for _, body := range []string{
+		`{"client_name":"Anna","phone_number":"+79990000001","description":"a"}`,
+		`{"client_name":"Boris","phone_number":"+79990000001","description":"b"}`,
+		`{"client_name":"Clara","phone_number":"+79990000002","description":"c"}`,
+	} {
+		require.Equal(t, http.StatusCreated, doInMemoryRequest(t, router, "POST", "/calls", "owner-token", body).Code)
+	}
+
+	w := doInMemoryRequest(t, router, "POST", "/calls/views", "owner-token",
+		`{"name":"first client","filter":{"phone_number":"+79990000001","sort":"client_name","order":"asc"}}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var view SavedViewResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &view))
+
+	names := func(w *httptest.ResponseRecorder) []string {
+		var calls []CallResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &calls))
+		var names []string
+		for _, call := range calls {
+			names = append(names, call.ClientName)
+		}
+		return names
+	}
+
+	w = doInMemoryRequest(t, router, "GET", "/calls?view="+view.ID.String(), "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"Anna", "Boris"}, names(w))
+	assert.Equal(t, "2", w.Header().Get(TotalCountHeader))
+
+	w = doInMemoryRequest(t, router, "GET", "/calls?view="+view.ID.String()+"&order=desc&limit=1", "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"Boris"}, names(w))
+	assert.Equal(t, "2", w.Header().Get(TotalCountHeader))
+
+	w = doInMemoryRequest(t, router, "GET", "/calls?view="+view.ID.String()+"&phone_number=%2B79990000002", "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"Clara"}, names(w))
+
+	w = doInMemoryRequest(t, router, "GET", "/calls?view="+view.ID.String(), "other-token", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doInMemoryRequest(t, router, "GET", "/calls?view=latest", "owner-token", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"common/slo"
+)
+
+// SLOHandler отдает состояние целей уровня обслуживания: сводку для администраторов
+// и показатели в формате Prometheus.
+type SLOHandler struct {
+	registry *slo.Registry
+}
+
+// NewSLOHandler создает новый экземпляр SLOHandler.
+func NewSLOHandler(registry *slo.Registry) *SLOHandler {
+	return &SLOHandler{registry: registry}
+}
+
+// GetSummary обрабатывает GET запрос сводки целей: доля ошибок и скорость расходования
+// бюджета ошибок в каждом окне и остаток бюджета за период.
+func (h *SLOHandler) GetSummary(c *gin.Context) {
+	c.JSON(http.StatusOK, h.registry.Summary())
+}
+
+// Metrics обрабатывает GET запрос показателей целей в текстовом формате Prometheus.
+func (h *SLOHandler) Metrics(c *gin.Context) {
+	c.Header("Content-Type", slo.ContentType)
+	c.Status(http.StatusOK)
+	_ = h.registry.WriteMetrics(c.Writer)
+}
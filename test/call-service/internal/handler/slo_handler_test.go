@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/repository"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+	"common/slo"
+)
+
+// TestSLO проверяет сводку целей уровня обслуживания: ошибки сервера, внесенные правилом
+// сбоя, расходуют бюджет цели маршрута, сводка доступна только администратору,
+// а показатели в формате Prometheus — без аутентификации.
+
+func TestSLO(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), adminToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: adminUserID.String(), Role: middleware.RoleAdmin}, nil).AnyTimes()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), userToken).
+		Return(&authclient.TokenInfo{Valid: true, UserID: uuid.New().String(), Role: middleware.RoleUser}, nil).AnyTimes()
+	callService := service.NewCallService(repository.NewInMemoryCallRepository())
+	registry, err := slo.New([]slo.Objective{{Name: "list_calls", Endpoint: "GET /calls", Target: 0.9}}, 0, nil)
+	require.NoError(t, err)
+	faults := middleware.NewFaultInjector(nil, nil)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.SLO(registry))
+	RegisterRoutes(router, Routes{
+		Auth:           NewAuthHandler(mockAuthClient),
+		Calls:          NewCallHandler(callService, mockAuthClient),
+		Admin:          NewAdminHandler(callService),
+		Docs:           NewDocsHandler(nil),
+		Faults:         faults,
+		SLO:            NewSLOHandler(registry),
+		AuthMiddleware: middleware.NewAuthMiddleware(mockAuthClient),
+	})
+
+	// Три успешных запроса и один с ошибкой сервера: доля ошибок 25% при допустимых 10%
+	for i := 0; i < 3; i++ {
+		w := doInMemoryRequest(t, router, http.MethodGet, "/calls", userToken, "")
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+	rule, err := middleware.ParseFaultRule("GET /calls 1 status=503")
+	require.NoError(t, err)
+	faults.SetRules([]middleware.FaultRule{rule})
+	w := doInMemoryRequest(t, router, http.MethodGet, "/calls", userToken, "")
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	faults.SetRules(nil)
+
+	w = doInMemoryRequest(t, router, http.MethodGet, "/admin/slo", userToken, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doInMemoryRequest(t, router, http.MethodGet, "/admin/slo", adminToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var summary slo.Summary
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	require.Len(t, summary.Objectives, 1)
+	assert.Equal(t, slo.WindowSummary{Window: "5m", Total: 4, Bad: 1, ErrorRatio: 0.25, BurnRate: 2.5}, summary.Objectives[0].Windows[0])
+
+	w = doInMemoryRequest(t, router, http.MethodGet, "/metrics", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, slo.ContentType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `slo_burn_rate{objective="list_calls",endpoint="GET /calls",window="5m",version="dev",commit="dev"} 2.5`)
+}
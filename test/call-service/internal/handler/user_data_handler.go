@@ -0,0 +1,320 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/internal/model"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+)
+
+// UserDataExportInterval — минимальный интервал между выгрузками данных одного пользователя по умолчанию.
+// Совпадает с ограничением сервиса аутентификации на вызов ExportUserData.
+const UserDataExportInterval = time.Hour
+
+// UserDataHandler обрабатывает HTTP запросы субъекта данных: выгрузку всех данных пользователя
+// и удаление его учетной записи. Данные учетной записи запрашиваются у сервиса аутентификации,
+// заявки и история их статусов читаются из базы данных по мере отправки ответа.
+//
+// Ответ на выгрузку — один JSON-документ:
+//
+//	{
+//	  "exported_at": "...",
+//	  "account": {...},         // учетная запись без хешей пароля и токенов
+//	  "sessions": [...],        // все сеансы, включая отозванные и истекшие
+//	  "devices": [...],         // устройства, с которых пользователь входил
+//	  "calls": [...],           // заявки пользователя
+//	  "status_history": [...]   // история статусов его заявок и его изменения в чужих заявках
+//	}
+//
+// Если выгрузка прервется после начала ответа, документ останется незавершенным.
+type UserDataHandler struct {
+	callService service.CallService
+	authClient  authclient.AuthClient
+	erasures    service.ErasureService
+}
+
+// NewUserDataHandler создает новый экземпляр UserDataHandler.
+func NewUserDataHandler(callService service.CallService, authClient authclient.AuthClient, erasures service.ErasureService) *UserDataHandler {
+	return &UserDataHandler{callService: callService, authClient: authClient, erasures: erasures}
+}
+
+// EraseAccountRequest содержит пароль пользователя, подтверждающий удаление учетной записи.
+type EraseAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// Состояния удаления учетной записи в ответе EraseMyAccount
+const (
+	// EraseStatusErased — учетная запись и данные пользователя удалены.
+	EraseStatusErased = "erased"
+	// EraseStatusPending — удаление начато, но прервано; сервис завершит его в фоне.
+	EraseStatusPending = "pending"
+)
+
+// EraseAccountResponse сообщает, завершено ли удаление учетной записи.
+type EraseAccountResponse struct {
+	Status string `json:"status"`
+}
+
+// AccountExport — учетная запись пользователя в выгрузке данных.
+type AccountExport struct {
+	UserID        string `json:"user_id"`
+	Username      string `json:"username"`
+	Role          string `json:"role"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	// CreatedAt отсутствует, если сервис аутентификации не сообщил время регистрации.
+	CreatedAt                  *time.Time `json:"created_at,omitempty"`
+	EmailVerificationExpiresAt *time.Time `json:"email_verification_expires_at,omitempty"`
+}
+
+// SessionExport — сеанс пользователя в выгрузке данных. RevokedAt отсутствует у неотозванных сеансов.
+type SessionExport struct {
+	ID          string     `json:"id"`
+	DeviceLabel string     `json:"device_label"`
+	IP          string     `json:"ip"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  time.Time  `json:"last_used_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// userDataHead — часть выгрузки, полученная от сервиса аутентификации.
+type userDataHead struct {
+	ExportedAt time.Time        `json:"exported_at"`
+	Account    AccountExport    `json:"account"`
+	Sessions   []SessionExport  `json:"sessions"`
+	Devices    []DeviceResponse `json:"devices"`
+}
+
+// ExportMyData обрабатывает GET запрос на выгрузку всех данных текущего пользователя.
+func (h *UserDataHandler) ExportMyData(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, i18n.Response(c, i18n.Unauthorized))
+		return
+	}
+	h.export(c, userID)
+}
+
+// ExportUserData обрабатывает GET запрос администратора на выгрузку всех данных пользователя.
+func (h *UserDataHandler) ExportUserData(c *gin.Context) {
+	h.export(c, middleware.GetUUIDParam(c, "id"))
+}
+
+func (h *UserDataHandler) export(c *gin.Context, userID uuid.UUID) {
+	ctx := c.Request.Context()
+
+	account, err := h.authClient.ExportUserData(ctx, userID.String())
+	if err != nil {
+		writeAuthError(c, err, i18n.ExportUserDataFailed)
+		return
+	}
+	head, err := json.Marshal(newUserDataHead(account))
+	if err != nil {
+		writeServerError(c, err, i18n.ExportUserDataFailed)
+		return
+	}
+
+	// Заголовки ответа отправляются только вместе с первой заявкой, чтобы ошибку
+	// первого запроса к базе данных можно было вернуть обычным JSON-ответом
+	w := &jsonArrayWriter{c: c}
+	started := false
+	start := func() {
+		if started {
+			return
+		}
+		started = true
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="user-data-%s.json"`, userID))
+		c.Status(http.StatusOK)
+		// Документ продолжается после полей userDataHead, поэтому закрывающая скобка отбрасывается
+		w.raw(head[:len(head)-1])
+		w.raw([]byte(`,"calls":[`))
+	}
+
+	lang := i18n.Lang(c.GetHeader("Accept-Language"))
+	err = h.callService.ForEachCall(ctx, userID, model.CallFilter{}, func(call *model.Call) error {
+		start()
+		return w.item(newCallResponse(call, lang))
+	})
+	if err != nil && !started {
+		writeServerError(c, err, i18n.ExportUserDataFailed)
+		return
+	}
+	if err == nil {
+		start()
+		w.next([]byte(`],"status_history":[`))
+		err = h.callService.ForEachStatusChange(ctx, userID, func(change *model.CallStatusChange) error {
+			return w.item(change)
+		})
+	}
+	if err == nil {
+		w.raw([]byte("]}"))
+		err = w.err
+	}
+	if err != nil {
+		// Часть документа уже отправлена, поэтому статус ответа изменить нельзя
+		log.Printf("user data export for %s interrupted after %d rows: %v", userID, w.rows, err)
+		c.Abort()
+		return
+	}
+	c.Writer.Flush()
+}
+
+// EraseMyAccount обрабатывает DELETE запрос на удаление учетной записи текущего пользователя.
+// Удаление необратимо, поэтому требует параметра erase=true и пароля пользователя. Если удаление
+// прервалось после проверки пароля (например, сервис аутентификации недоступен), ответ — 202,
+// и удаление завершается в фоне.
+func (h *UserDataHandler) EraseMyAccount(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, i18n.Response(c, i18n.Unauthorized))
+		return
+	}
+	if c.Query("erase") != "true" {
+		c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.EraseNotConfirmed))
+		return
+	}
+	var req EraseAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.authClient.VerifyPassword(ctx, userID.String(), req.Password); err != nil {
+		// 401 означал бы недействительный токен, поэтому неверный пароль возвращается как 403
+		if errors.Is(err, authclient.ErrInvalidCredentials) {
+			c.JSON(http.StatusForbidden, i18n.Response(c, i18n.InvalidPassword))
+			return
+		}
+		writeAuthError(c, err, i18n.EraseAccountFailed)
+		return
+	}
+
+	erased, err := h.erasures.Erase(ctx, userID)
+	if err != nil {
+		writeServerError(c, err, i18n.EraseAccountFailed)
+		return
+	}
+	if !erased {
+		c.JSON(http.StatusAccepted, EraseAccountResponse{Status: EraseStatusPending})
+		return
+	}
+	c.JSON(http.StatusOK, EraseAccountResponse{Status: EraseStatusErased})
+}
+
+// newUserDataHead преобразует данные сервиса аутентификации в начало выгрузки.
+func newUserDataHead(account *authclient.UserExport) userDataHead {
+	head := userDataHead{
+		ExportedAt: time.Now().UTC(),
+		Account: AccountExport{
+			UserID:                     account.User.UserID,
+			Username:                   account.User.Username,
+			Role:                       account.User.Role,
+			Email:                      account.User.Email,
+			EmailVerified:              account.User.EmailVerified,
+			CreatedAt:                  optionalTime(account.User.CreatedAt),
+			EmailVerificationExpiresAt: optionalTime(account.EmailVerificationExpiresAt),
+		},
+		Sessions: make([]SessionExport, 0, len(account.Sessions)),
+		Devices:  make([]DeviceResponse, 0, len(account.Devices)),
+	}
+	for _, session := range account.Sessions {
+		head.Sessions = append(head.Sessions, SessionExport{
+			ID:          session.ID,
+			DeviceLabel: session.DeviceLabel,
+			IP:          session.IP,
+			CreatedAt:   session.CreatedAt,
+			LastUsedAt:  session.LastUsedAt,
+			ExpiresAt:   optionalTime(session.ExpiresAt),
+			RevokedAt:   optionalTime(session.RevokedAt),
+		})
+	}
+	for _, device := range account.Devices {
+		head.Devices = append(head.Devices, DeviceResponse{
+			Fingerprint: device.Fingerprint,
+			UserAgent:   device.UserAgent,
+			IP:          device.IP,
+			FirstSeenAt: device.FirstSeenAt,
+			LastSeenAt:  device.LastSeenAt,
+		})
+	}
+	return head
+}
+
+// optionalTime возвращает nil для нулевого времени, чтобы поле не попадало в ответ.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// jsonArrayWriter записывает элементы JSON-массивов в ответ по мере обхода и отправляет
+// накопленные данные клиенту каждые exportFlushRows элементов. Первая ошибка записи
+// сохраняется в err, последующие записи пропускаются.
+type jsonArrayWriter struct {
+	c     *gin.Context
+	rows  int
+	items int
+	err   error
+}
+
+// item записывает элемент текущего массива.
+func (w *jsonArrayWriter) item(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if w.items > 0 {
+		w.raw([]byte(","))
+	}
+	w.raw(data)
+	w.items++
+	w.rows++
+	if w.rows%exportFlushRows == 0 {
+		w.c.Writer.Flush()
+	}
+	return w.err
+}
+
+// next завершает текущий массив разделителем sep, за которым начинается следующий.
+func (w *jsonArrayWriter) next(sep []byte) {
+	w.raw(sep)
+	w.items = 0
+}
+
+// raw записывает данные без изменений.
+func (w *jsonArrayWriter) raw(data []byte) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = w.c.Writer.Write(data)
+}
+
+// exportRateLimitKey возвращает ключ ограничения частоты выгрузок — ID пользователя, чьи данные
+// выгружаются, поэтому выгрузки самим пользователем и администратором учитываются вместе.
+// ID из пути проверяется BindUUIDParam до ограничения частоты.
+func exportRateLimitKey(c *gin.Context) string {
+	if id := middleware.GetUUIDParam(c, "id"); id != uuid.Nil {
+		return id.String()
+	}
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		return ""
+	}
+	return userID.String()
+}
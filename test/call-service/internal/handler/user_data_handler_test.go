@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"call-service/internal/blobstore"
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+)
+
+// exportedUserData — выгрузка данных пользователя в том виде, в котором ее получает клиент.
+type exportedUserData struct {
+	ExportedAt    time.Time                 `json:"exported_at"`
+	Account       AccountExport             `json:"account"`
+	Sessions      []SessionExport           `json:"sessions"`
+	Devices       []DeviceResponse          `json:"devices"`
+	Calls         []*model.Call             `json:"calls"`
+	StatusHistory []*model.CallStatusChange `json:"status_history"`
+}
+
+func doExportRequest(router http.Handler, token, path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestExportMyData проверяет выгрузку данных текущего пользователя: документ содержит данные
+// сервиса аутентификации, все заявки и историю статусов, размер ответа ограничен объемом данных,
+// повторная выгрузка в течение часа отклоняется с кодом 429 и для администратора.
+
+func TestExportMyData(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	callService := service.NewCallService(repository.NewInMemoryCallRepository())
+	router := setupAdminRouter(callService, mockAuthClient)
+	userID := uuid.New()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "export-token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: userID.String(), Role: "user"}, nil).AnyTimes()
+
+	// Заявок больше, чем exportFlushRows, чтобы ответ отправлялся несколькими частями
+	const calls = 2*exportFlushRows + 50
+	for range calls {
+		_, err := callService.CreateCall(ctx, &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001"}, userID)
+		require.NoError(t, err)
+	}
+	first, _, err := callService.GetAllCalls(ctx, userID, model.CallFilter{Limit: 1})
+	require.NoError(t, err)
+	require.NoError(t, callService.UpdateCallStatus(ctx, first[0].ID, model.CallStatusClosed, userID))
+	_, err = callService.CreateCall(ctx, &model.CreateCallRequest{ClientName: "Чужая", PhoneNumber: "+79990000002"}, uuid.New())
+	require.NoError(t, err)
+
+	revokedAt := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	mockAuthClient.EXPECT().ExportUserData(gomock.Any(), userID.String()).Return(&authclient.UserExport{
+		User:     authclient.UserInfo{UserID: userID.String(), Username: "user", Role: "user", Email: "user@example.com"},
+		Sessions: []authclient.SessionInfo{{ID: uuid.NewString(), IP: "192.0.2.1", RevokedAt: revokedAt}},
+		Devices:  []authclient.DeviceInfo{{Fingerprint: "fp", UserAgent: "curl"}},
+	}, nil)
+
+	w := doExportRequest(router, "export-token", "/me/export")
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	assert.Less(t, w.Body.Len(), calls*1024)
+	var export exportedUserData
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &export))
+	assert.Equal(t, "user", export.Account.Username)
+	require.Len(t, export.Sessions, 1)
+	require.NotNil(t, export.Sessions[0].RevokedAt)
+	assert.True(t, revokedAt.Equal(*export.Sessions[0].RevokedAt))
+	assert.Len(t, export.Devices, 1)
+	assert.Len(t, export.Calls, calls)
+	for _, call := range export.Calls {
+		assert.Equal(t, userID, call.UserID)
+	}
+	require.Len(t, export.StatusHistory, 1)
+	assert.Equal(t, first[0].ID, export.StatusHistory[0].CallID)
+
+	w = doExportRequest(router, "export-token", "/me/export")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "3600", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "too_many_requests")
+
+	w = doExportRequest(router, adminToken, "/admin/users/"+userID.String()+"/export")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+// TestExportUserData_Errors проверяет ответы администратору при ошибках: неверный ID, неизвестный
+// пользователь и ограничение сервиса аутентификации. Неудачная выгрузка не расходует попытку.
+
+func TestExportUserData_Errors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	router := setupAdminRouter(service.NewCallService(repository.NewInMemoryCallRepository()), mockAuthClient)
+	userID := uuid.New()
+	path := "/admin/users/" + userID.String() + "/export"
+
+	w := doExportRequest(router, adminToken, "/admin/users/not-a-uuid/export")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	gomock.InOrder(
+		mockAuthClient.EXPECT().ExportUserData(gomock.Any(), userID.String()).
+			Return(nil, status.Error(codes.NotFound, "user not found")),
+		mockAuthClient.EXPECT().ExportUserData(gomock.Any(), userID.String()).
+			Return(nil, status.Error(codes.ResourceExhausted, "user data was exported recently")),
+		mockAuthClient.EXPECT().ExportUserData(gomock.Any(), userID.String()).
+			Return(&authclient.UserExport{User: authclient.UserInfo{UserID: userID.String()}}, nil),
+	)
+
+	w = doExportRequest(router, adminToken, path)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "user_not_found")
+
+	w = doExportRequest(router, adminToken, path)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	w = doExportRequest(router, adminToken, path)
+	require.Equal(t, http.StatusOK, w.Code)
+	var export exportedUserData
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &export))
+	assert.Empty(t, export.Calls)
+	assert.Empty(t, export.StatusHistory)
+}
+
+func doEraseRequest(router http.Handler, token, query, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodDelete, "/me"+query, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestEraseMyAccount проверяет удаление учетной записи: без erase=true и с неверным паролем
+// удаление не начинается, при сбое сервиса аутентификации ответ — 202, после успешного
+// удаления заявки пользователя обезличены.
+
+func TestEraseMyAccount(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	callRepo := repository.NewInMemoryCallRepository()
+	callService := service.NewCallService(callRepo)
+	store, err := blobstore.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	attachments := service.NewAttachmentService(repository.NewInMemoryAttachmentRepository(), callRepo, store, service.AttachmentConfig{})
+	erasures := service.NewErasureService(repository.NewInMemoryErasureRepository(), callRepo,
+		service.NewAPIKeyService(repository.NewInMemoryAPIKeyRepository()), attachments, mockAuthClient, service.ErasureConfig{})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, Routes{
+		Auth:           NewAuthHandler(mockAuthClient),
+		Calls:          NewCallHandler(callService, mockAuthClient),
+		Admin:          NewAdminHandler(callService),
+		Profile:        NewProfileHandler(mockAuthClient),
+		APIKeys:        NewAPIKeyHandler(nil),
+		UserData:       NewUserDataHandler(callService, mockAuthClient, erasures),
+		Docs:           NewDocsHandler(nil),
+		AuthMiddleware: middleware.NewAuthMiddleware(mockAuthClient),
+	})
+
+	userID := uuid.New()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "erase-token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: userID.String(), Role: "user"}, nil).AnyTimes()
+	call, err := callService.CreateCall(ctx, &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001"}, userID)
+	require.NoError(t, err)
+
+	w := doEraseRequest(router, "erase-token", "", `{"password":"secret"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "erase_not_confirmed")
+
+	w = doEraseRequest(router, "erase-token", "?erase=true", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	gomock.InOrder(
+		mockAuthClient.EXPECT().VerifyPassword(gomock.Any(), userID.String(), "wrong").
+			Return(authclient.FromStatus(status.Error(codes.Unauthenticated, "invalid password"))),
+		mockAuthClient.EXPECT().VerifyPassword(gomock.Any(), userID.String(), "secret").Return(nil),
+		mockAuthClient.EXPECT().EraseUser(gomock.Any(), userID.String()).
+			Return(authclient.FromStatus(status.Error(codes.Unavailable, "connection refused"))),
+		mockAuthClient.EXPECT().VerifyPassword(gomock.Any(), userID.String(), "secret").Return(nil),
+		mockAuthClient.EXPECT().EraseUser(gomock.Any(), userID.String()).Return(nil),
+	)
+
+	w = doEraseRequest(router, "erase-token", "?erase=true", `{"password":"wrong"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_password")
+
+	w = doEraseRequest(router, "erase-token", "?erase=true", `{"password":"secret"}`)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.JSONEq(t, `{"status":"pending"}`, w.Body.String())
+
+	// Повторный запрос продолжает начатое удаление
+	w = doEraseRequest(router, "erase-token", "?erase=true", `{"password":"secret"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"erased"}`, w.Body.String())
+
+	stored, err := callRepo.GetByID(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ErasedClientName, stored.ClientName)
+	assert.Empty(t, stored.PhoneNumber)
+}
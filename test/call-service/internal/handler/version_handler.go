@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"common/buildinfo"
+)
+
+// versionCacheMaxAge — время, в течение которого клиенты и прокси могут не запрашивать
+// сведения о сборке повторно. Сведения не меняются до перезапуска, но после развертывания
+// новой версии ответ должен устареть быстро.
+const versionCacheMaxAge = time.Minute
+
+// GetVersion обрабатывает GET запрос сведений о сборке сервиса: версии, коммита, даты сборки
+// и версии Go. Маршрут не требует аутентификации, а ответ можно кэшировать.
+func GetVersion(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(versionCacheMaxAge.Seconds())))
+	c.JSON(http.StatusOK, buildinfo.Get())
+}
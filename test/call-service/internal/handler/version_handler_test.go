@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/middleware"
+	"common/buildinfo"
+)
+
+// TestGetVersion проверяет, что GET /version без аутентификации возвращает значения,
+// заданные при сборке (по умолчанию dev), и разрешает кэширование ответа.
+
+func TestGetVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, Routes{Docs: NewDocsHandler(nil), AuthMiddleware: middleware.NewAuthMiddleware(nil)})
+
+	get := func() buildinfo.Info {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+		var info buildinfo.Info
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+		return info
+	}
+
+	assert.Equal(t, buildinfo.Info{Version: "dev", Commit: "dev", BuildDate: "dev", GoVersion: runtime.Version()}, get())
+
+	version, commit, date := buildinfo.Version, buildinfo.Commit, buildinfo.Date
+	t.Cleanup(func() { buildinfo.Version, buildinfo.Commit, buildinfo.Date = version, commit, date })
+	buildinfo.Version, buildinfo.Commit, buildinfo.Date = "1.4.0", "0a1b2c3", "2026-10-16T12:00:00Z"
+	assert.Equal(t, buildinfo.Info{Version: "1.4.0", Commit: "0a1b2c3", BuildDate: "2026-10-16T12:00:00Z", GoVersion: runtime.Version()}, get())
+}
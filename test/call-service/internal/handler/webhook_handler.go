@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"call-service/internal/i18n"
+	"call-service/internal/middleware"
+	"call-service/internal/model"
+	"call-service/internal/safehttp"
+	"call-service/internal/service"
+)
+
+// maxPayloadPreview — наибольшая длина тела события в списке доставок, байт.
+const maxPayloadPreview = 512
+
+// WebhookHandler обрабатывает HTTP запросы текущего пользователя к его подпискам на события
+// заявок и их очереди доставки: просмотр неудавшихся доставок и ручной повтор.
+type WebhookHandler struct {
+	webhooks service.WebhookService
+}
+
+// NewWebhookHandler создает новый экземпляр WebhookHandler.
+func NewWebhookHandler(webhooks service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhooks: webhooks}
+}
+
+// CreateWebhookRequest содержит адрес, на который отправляются события заявок.
+type CreateWebhookRequest struct {
+	URL string `json:"url" binding:"required"`
+}
+
+// WebhookResponse описывает подписку; секрет подписи возвращается только при создании.
+type WebhookResponse struct {
+	ID        uuid.UUID `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDeliveryResponse описывает доставку события подписке. PayloadPreview — начало тела
+// события не длиннее maxPayloadPreview байт, FailureReason и LastError — код причины
+// и описание последней неудачной попытки.
+type WebhookDeliveryResponse struct {
+	ID             uuid.UUID                   `json:"id"`
+	EventType      string                      `json:"event_type"`
+	Status         model.WebhookDeliveryStatus `json:"status"`
+	Attempts       int                         `json:"attempts"`
+	NextAttemptAt  *time.Time                  `json:"next_attempt_at,omitempty"`
+	FailureReason  string                      `json:"failure_reason,omitempty"`
+	LastError      string                      `json:"last_error,omitempty"`
+	PayloadPreview string                      `json:"payload_preview"`
+	PayloadSize    int                         `json:"payload_size"`
+	CreatedAt      time.Time                   `json:"created_at"`
+	UpdatedAt      time.Time                   `json:"updated_at"`
+}
+
+// CreateWebhook обрабатывает POST запрос на создание подписки текущего пользователя.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorResponse(c, err))
+		return
+	}
+
+	webhook, err := h.webhooks.CreateWebhook(c.Request.Context(), userID, req.URL)
+	if err != nil {
+		writeWebhookError(c, err, i18n.CreateWebhookFailed)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, webhook.ID.String())
+	response := newWebhookResponse(webhook)
+	response.Secret = webhook.Secret
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListWebhooks обрабатывает GET запрос на получение подписок текущего пользователя.
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	webhooks, err := h.webhooks.ListWebhooks(c.Request.Context(), userID)
+	if err != nil {
+		writeServerError(c, err, i18n.ListWebhooksFailed)
+		return
+	}
+
+	response := make([]WebhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		response = append(response, newWebhookResponse(webhook))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteWebhook обрабатывает DELETE запрос на удаление подписки текущего пользователя.
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	if err := h.webhooks.DeleteWebhook(c.Request.Context(), userID, middleware.GetUUIDParam(c, "id")); err != nil {
+		writeWebhookError(c, err, i18n.DeleteWebhookFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+}
+
+// ListDeliveries обрабатывает GET запрос на получение доставок подписки текущего пользователя
+// от новых к давним; параметр status оставляет доставки в одном состоянии, например failed.
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	status := model.WebhookDeliveryStatus(c.Query("status"))
+	if status != "" && !status.Valid() {
+		c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidWebhookDeliveryStatus))
+		return
+	}
+
+	deliveries, err := h.webhooks.ListDeliveries(c.Request.Context(), userID, middleware.GetUUIDParam(c, "id"), status)
+	if err != nil {
+		writeWebhookError(c, err, i18n.ListWebhookDeliveriesFailed)
+		return
+	}
+
+	response := make([]WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		response = append(response, newWebhookDeliveryResponse(delivery))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RetryDelivery обрабатывает POST запрос на повтор неудавшейся доставки подписки текущего
+// пользователя. Доставка снова ставится в очередь и отправляется как новая.
+func (h *WebhookHandler) RetryDelivery(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	webhookID, deliveryID := middleware.GetUUIDParam(c, "id"), middleware.GetUUIDParam(c, "deliveryId")
+	if err := h.webhooks.RetryDelivery(c.Request.Context(), userID, webhookID, deliveryID); err != nil {
+		writeWebhookError(c, err, i18n.RetryWebhookDeliveryFailed)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, deliveryID.String())
+	c.JSON(http.StatusAccepted, gin.H{"retried": 1})
+}
+
+// RetryFailed обрабатывает POST запрос на повтор неудавшихся доставок подписки текущего
+// пользователя: за один запрос повторяется не больше service.MaxWebhookBulkRetry давних доставок.
+func (h *WebhookHandler) RetryFailed(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	retried, err := h.webhooks.RetryFailed(c.Request.Context(), userID, middleware.GetUUIDParam(c, "id"))
+	if err != nil {
+		writeWebhookError(c, err, i18n.RetryWebhookDeliveryFailed)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"retried": retried})
+}
+
+// writeWebhookError отправляет ответ с ошибкой операции с подпиской; code — код ошибки
+// для непредвиденных ошибок.
+func writeWebhookError(c *gin.Context, err error, code i18n.Code) {
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound):
+		c.JSON(http.StatusNotFound, i18n.Response(c, i18n.WebhookNotFound))
+	case errors.Is(err, service.ErrInvalidWebhookURL):
+		c.JSON(http.StatusBadRequest, i18n.Response(c, i18n.InvalidWebhookURL, safehttp.ReasonOf(err)))
+	case errors.Is(err, service.ErrTooManyWebhooks):
+		c.JSON(http.StatusConflict, i18n.Response(c, i18n.TooManyWebhooks, service.MaxWebhooks))
+	case errors.Is(err, service.ErrWebhookDeliveryNotFound):
+		c.JSON(http.StatusNotFound, i18n.Response(c, i18n.WebhookDeliveryNotFound))
+	case errors.Is(err, service.ErrWebhookDeliveryNotFailed):
+		c.JSON(http.StatusConflict, i18n.Response(c, i18n.WebhookDeliveryNotFailed))
+	default:
+		writeServerError(c, err, code)
+	}
+}
+
+// newWebhookResponse преобразует подписку в ответ без секрета подписи.
+func newWebhookResponse(webhook *model.Webhook) WebhookResponse {
+	return WebhookResponse{ID: webhook.ID, URL: webhook.URL, CreatedAt: webhook.CreatedAt}
+}
+
+// newWebhookDeliveryResponse преобразует доставку в ответ; время следующей попытки
+// указывается только для ожидающих доставок.
+func newWebhookDeliveryResponse(delivery *model.WebhookDelivery) WebhookDeliveryResponse {
+	response := WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		FailureReason:  delivery.FailureReason,
+		LastError:      delivery.LastError,
+		PayloadPreview: payloadPreview(delivery.Payload),
+		PayloadSize:    len(delivery.Payload),
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+	if delivery.Status == model.WebhookDeliveryPending {
+		response.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	return response
+}
+
+// payloadPreview возвращает начало тела события не длиннее maxPayloadPreview байт,
+// не разрезая символы UTF-8.
+func payloadPreview(payload []byte) string {
+	if len(payload) <= maxPayloadPreview {
+		return string(payload)
+	}
+	preview := payload[:maxPayloadPreview]
+	for len(preview) > 0 && !utf8.Valid(preview) {
+		preview = preview[:len(preview)-1]
+	}
+	return string(preview)
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/middleware"
+	"call-service/internal/mocks"
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"call-service/internal/safehttp"
+	"call-service/internal/service"
+	"call-service/pkg/authclient"
+	"common/clock"
+)
+
+// setupWebhookRouter настраивает маршрутизатор с маршрутами /webhooks поверх настоящего
+// сервиса подписок с хранилищем в памяти, двумя попытками доставки и часами clock.Fake.
+// Токен "owner-token" принадлежит пользователю ownerID, "other-token" — другому пользователю.
+
+func setupWebhookRouter(t *testing.T) (*gin.Engine, service.WebhookService, *clock.Fake, uuid.UUID) {
+	gin.SetMode(gin.TestMode)
+	ownerID := uuid.New()
+	authClient := mocks.NewMockAuthClient(gomock.NewController(t))
+	for token, userID := range map[string]uuid.UUID{"owner-token": ownerID, "other-token": uuid.New()} {
+		authClient.EXPECT().ValidateTokenFull(gomock.Any(), token).
+			Return(&authclient.TokenInfo{Valid: true, UserID: userID.String(), Role: middleware.RoleUser}, nil).AnyTimes()
+	}
+
+	clk := clock.NewFake(time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC))
+	webhooks := service.NewWebhookService(repository.NewInMemoryWebhookRepository(), service.WebhookConfig{
+		Policy:      safehttp.Policy{AllowHTTP: true, AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}},
+		MaxAttempts: 2,
+		RetryDelay:  time.Minute,
+		Clock:       clk,
+	})
+
+	router := gin.New()
+	RegisterRoutes(router, Routes{
+		Auth:           NewAuthHandler(authClient),
+		Calls:          NewCallHandler(service.NewCallService(repository.NewInMemoryCallRepository()), authClient),
+		Admin:          NewAdminHandler(nil),
+		Docs:           NewDocsHandler(nil),
+		Webhooks:       NewWebhookHandler(webhooks),
+		AuthMiddleware: middleware.NewAuthMiddleware(authClient),
+	})
+	return router, webhooks, clk, ownerID
+}
+
+// TestWebhooks_FailedDeliveries проверяет просмотр неудавшихся доставок с началом тела события
+// и причиной отказа, ручной повтор одной доставки и всех неудавшихся доставок подписки:
+// получатель отклоняет первые запросы, а после повтора принимает событие.
+
+func TestWebhooks_FailedDeliveries(t *testing.T) {
+	router, webhooks, clk, ownerID := setupWebhookRouter(t)
+	var requests atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 4 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	w := doInMemoryRequest(t, router, "POST", "/webhooks", "owner-token", fmt.Sprintf(`{"url":%q}`, receiver.URL))
+	require.Equal(t, http.StatusCreated, w.Code)
+	var webhook WebhookResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &webhook))
+	assert.NotEmpty(t, webhook.Secret)
+
+	// Два события, каждое отклонено дважды
+	ctx := context.Background()
+	for _, name := range []string{"First", "Second"} {
+		require.NoError(t, webhooks.CallbackDue(ctx, &model.Call{ID: uuid.New(), UserID: ownerID, ClientName: name}))
+		clk.Advance(time.Second)
+	}
+	for range 2 {
+		_, err := webhooks.DispatchDue(ctx)
+		require.NoError(t, err)
+		clk.Advance(time.Minute)
+	}
+	require.Equal(t, int32(4), requests.Load())
+
+	path := "/webhooks/" + webhook.ID.String()
+	w = doInMemoryRequest(t, router, "GET", path+"/deliveries?status=failed", "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var failed []WebhookDeliveryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &failed))
+	require.Len(t, failed, 2)
+	assert.Contains(t, failed[0].PayloadPreview, `"client_name":"Second"`)
+	assert.Equal(t, "status", failed[0].FailureReason)
+	assert.Contains(t, failed[0].LastError, "503")
+	assert.Equal(t, 2, failed[0].Attempts)
+	assert.Nil(t, failed[0].NextAttemptAt)
+
+	// Чужая подписка не найдена, неизвестное состояние отклоняется
+	w = doInMemoryRequest(t, router, "GET", path+"/deliveries", "other-token", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doInMemoryRequest(t, router, "POST", path+"/retry-failed", "other-token", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doInMemoryRequest(t, router, "GET", path+"/deliveries?status=lost", "owner-token", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_webhook_delivery_status")
+
+	retryPath := path + "/deliveries/" + failed[1].ID.String() + "/retry"
+	w = doInMemoryRequest(t, router, "POST", retryPath, "other-token", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doInMemoryRequest(t, router, "POST", retryPath, "owner-token", "")
+	require.Equal(t, http.StatusAccepted, w.Code)
+	assert.JSONEq(t, `{"retried":1}`, w.Body.String())
+	w = doInMemoryRequest(t, router, "POST", retryPath, "owner-token", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "webhook_delivery_not_failed")
+
+	delivered, err := webhooks.DispatchDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+
+	w = doInMemoryRequest(t, router, "POST", path+"/retry-failed", "owner-token", "")
+	require.Equal(t, http.StatusAccepted, w.Code)
+	assert.JSONEq(t, `{"retried":1}`, w.Body.String())
+	delivered, err = webhooks.DispatchDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+
+	w = doInMemoryRequest(t, router, "GET", path+"/deliveries?status=delivered", "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var deliveries []WebhookDeliveryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &deliveries))
+	assert.Len(t, deliveries, 2)
+}
+
+// TestWebhooks_CRUD проверяет создание, список и удаление подписок, отказ в небезопасном адресе
+// и доступ только владельца.
+
+func TestWebhooks_CRUD(t *testing.T) {
+	router, _, _, _ := setupWebhookRouter(t)
+
+	w := doInMemoryRequest(t, router, "POST", "/webhooks", "owner-token", `{"url":"ftp://127.0.0.1/hooks"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_webhook_url")
+
+	w = doInMemoryRequest(t, router, "POST", "/webhooks", "owner-token", `{"url":"http://127.0.0.1:8080/hooks"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var webhook WebhookResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &webhook))
+
+	w = doInMemoryRequest(t, router, "GET", "/webhooks", "owner-token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var webhooks []WebhookResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &webhooks))
+	require.Len(t, webhooks, 1)
+	assert.Empty(t, webhooks[0].Secret)
+	assert.False(t, strings.Contains(w.Body.String(), webhook.Secret))
+
+	w = doInMemoryRequest(t, router, "GET", "/webhooks", "other-token", "")
+	assert.JSONEq(t, `[]`, w.Body.String())
+	w = doInMemoryRequest(t, router, "DELETE", "/webhooks/"+webhook.ID.String(), "other-token", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doInMemoryRequest(t, router, "DELETE", "/webhooks/"+webhook.ID.String(), "owner-token", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doInMemoryRequest(t, router, "GET", "/webhooks/"+webhook.ID.String()+"/deliveries", "owner-token", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,175 @@
+package i18n
+
+// Code — код ошибки в ответе API. Коды не зависят от языка ответа и не меняются
+// между версиями, поэтому клиенты должны опираться на них, а не на текст сообщения.
+
+type Code string
+
+// Ошибки аутентификации и доступа
+const (
+	Unauthorized             Code = "unauthorized"
+	AccessDenied             Code = "access_denied"
+	TokenRequired            Code = "token_required"
+	MalformedAuthHeader      Code = "malformed_authorization_header"
+	MalformedTokenCookie     Code = "malformed_token_cookie"
+	TokenTooLong             Code = "token_too_long"
+	InvalidToken             Code = "invalid_token"
+	InvalidAPIKey            Code = "invalid_api_key"
+	InvalidCredentials       Code = "invalid_credentials"
+	CredentialsRequired      Code = "credentials_required"
+	UserExists               Code = "user_exists"
+	UserNotFound             Code = "user_not_found"
+	AuthUnavailable          Code = "auth_unavailable"
+	InvalidEmail             Code = "invalid_email"
+	EmailInUse               Code = "email_in_use"
+	InvalidVerificationToken Code = "invalid_verification_token"
+	InvalidSessionID         Code = "invalid_session_id"
+	SessionNotFound          Code = "session_not_found"
+	APIKeyCreationForbidden  Code = "api_key_creation_forbidden"
+	InvalidAPIKeyID          Code = "invalid_api_key_id"
+	APIKeyNotFound           Code = "api_key_not_found"
+	InvalidAPIKeyName        Code = "invalid_api_key_name"
+	InvalidAPIKeyExpiry      Code = "invalid_api_key_expiry"
+	RequestTimedOut          Code = "request_timed_out"
+	InvalidRequest           Code = "invalid_request"
+	Conflict                 Code = "conflict"
+	NotFound                 Code = "not_found"
+	TooManyRequests          Code = "too_many_requests"
+	TooManyConnections       Code = "too_many_connections"
+	InvalidPassword          Code = "invalid_password"
+	DialingDisabled          Code = "dialing_disabled"
+	TelephonyError           Code = "telephony_error"
+	OrganizationsDisabled    Code = "organizations_disabled"
+	NotOrganizationOwner     Code = "not_organization_owner"
+	AlreadyInOrganization    Code = "already_in_organization"
+	InvalidInvite            Code = "invalid_invite"
+	CannotImpersonateAdmin   Code = "cannot_impersonate_admin"
+	ImpersonationForbidden   Code = "impersonation_forbidden"
+	UnknownIdentityProvider  Code = "unknown_identity_provider"
+	OIDCLoginDisabled        Code = "oidc_login_disabled"
+	CaptchaRequired          Code = "captcha_required"
+	InvalidCaptcha           Code = "invalid_captcha"
+	CaptchaUnavailable       Code = "captcha_unavailable"
+	MaintenanceMode          Code = "maintenance_mode"
+	ShuttingDown             Code = "shutting_down"
+	MethodNotAllowed         Code = "method_not_allowed"
+	FaultInjected            Code = "fault_injected"
+)
+
+// Ошибки проверки запроса
+const (
+	InvalidRequestBody Code = "invalid_request_body"
+	FieldRequired      Code = "field_required"
+	InvalidField       Code = "invalid_field"
+	InvalidCallID      Code = "invalid_call_id"
+	InvalidUserID      Code = "invalid_user_id"
+	InvalidPhoneNumber Code = "invalid_phone_number"
+	DescriptionTooLong Code = "description_too_long"
+	InvalidStatus      Code = "invalid_status"
+	InvalidSortField   Code = "invalid_sort_field"
+	InvalidSortOrder   Code = "invalid_sort_order"
+	InvalidLimit       Code = "invalid_limit"
+	InvalidOffset      Code = "invalid_offset"
+	InvalidInclude     Code = "invalid_include"
+	InvalidFilter      Code = "invalid_filter"
+	InvalidTime        Code = "invalid_time"
+	InvalidCursor      Code = "invalid_cursor"
+	CallNotFound       Code = "call_not_found"
+	CallbackInPast     Code = "callback_in_past"
+	CallClosed         Code = "call_closed"
+	EraseNotConfirmed  Code = "erase_not_confirmed"
+
+	InvalidAttachmentID      Code = "invalid_attachment_id"
+	AttachmentRequired       Code = "attachment_required"
+	AttachmentTooLarge       Code = "attachment_too_large"
+	AttachmentTypeNotAllowed Code = "attachment_type_not_allowed"
+	AttachmentTypeMismatch   Code = "attachment_type_mismatch"
+	AttachmentNotFound       Code = "attachment_not_found"
+
+	InvalidOrganizationID   Code = "invalid_organization_id"
+	InvalidOrganizationName Code = "invalid_organization_name"
+	InvalidInviteID         Code = "invalid_invite_id"
+	InvalidInviteExpiry     Code = "invalid_invite_expiry"
+	InviteNotFound          Code = "invite_not_found"
+
+	InvalidSavedViewID     Code = "invalid_saved_view_id"
+	InvalidSavedViewName   Code = "invalid_saved_view_name"
+	UnknownViewFilterParam Code = "unknown_view_filter_param"
+	SavedViewNotFound      Code = "saved_view_not_found"
+	SavedViewNameTaken     Code = "saved_view_name_taken"
+	TooManySavedViews      Code = "too_many_saved_views"
+	UnsupportedSavedView   Code = "unsupported_saved_view"
+
+	InvalidNotificationPreference Code = "invalid_notification_preference"
+	TelegramDisabled              Code = "telegram_disabled"
+	TelegramNotLinked             Code = "telegram_not_linked"
+	InvalidWebhookSecret          Code = "invalid_webhook_secret"
+
+	InvalidWebhookID             Code = "invalid_webhook_id"
+	InvalidWebhookURL            Code = "invalid_webhook_url"
+	InvalidWebhookDeliveryID     Code = "invalid_webhook_delivery_id"
+	InvalidWebhookDeliveryStatus Code = "invalid_webhook_delivery_status"
+	WebhookNotFound              Code = "webhook_not_found"
+	TooManyWebhooks              Code = "too_many_webhooks"
+	WebhookDeliveryNotFound      Code = "webhook_delivery_not_found"
+	WebhookDeliveryNotFailed     Code = "webhook_delivery_not_failed"
+)
+
+// Внутренние ошибки: код определяет операцию, которая не удалась
+const (
+	CreateCallFailed            Code = "create_call_failed"
+	GetCallFailed               Code = "get_call_failed"
+	GetCallsFailed              Code = "get_calls_failed"
+	ExportCallsFailed           Code = "export_calls_failed"
+	UpdateCallStatusFailed      Code = "update_call_status_failed"
+	UpdateCallbackFailed        Code = "update_callback_failed"
+	GetDueCallsFailed           Code = "get_due_calls_failed"
+	DialCallFailed              Code = "dial_call_failed"
+	GetCallActivityFailed       Code = "get_call_activity_failed"
+	ReassignCallFailed          Code = "reassign_call_failed"
+	DeleteCallFailed            Code = "delete_call_failed"
+	UploadAttachmentFailed      Code = "upload_attachment_failed"
+	GetAttachmentFailed         Code = "get_attachment_failed"
+	RegisterFailed              Code = "register_failed"
+	LoginFailed                 Code = "login_failed"
+	GetEmailFailed              Code = "get_email_failed"
+	UpdateEmailFailed           Code = "update_email_failed"
+	VerifyEmailFailed           Code = "verify_email_failed"
+	ListSessionsFailed          Code = "list_sessions_failed"
+	RevokeSessionFailed         Code = "revoke_session_failed"
+	RevokeSessionsFailed        Code = "revoke_sessions_failed"
+	ListDevicesFailed           Code = "list_devices_failed"
+	ExportUserDataFailed        Code = "export_user_data_failed"
+	EraseAccountFailed          Code = "erase_account_failed"
+	CreateAPIKeyFailed          Code = "create_api_key_failed"
+	ListAPIKeysFailed           Code = "list_api_keys_failed"
+	RevokeAPIKeyFailed          Code = "revoke_api_key_failed"
+	CreateOrganizationFailed    Code = "create_organization_failed"
+	InviteMemberFailed          Code = "invite_member_failed"
+	CreateInviteFailed          Code = "create_invite_failed"
+	ListInvitesFailed           Code = "list_invites_failed"
+	RevokeInviteFailed          Code = "revoke_invite_failed"
+	AcceptInviteFailed          Code = "accept_invite_failed"
+	ImpersonateUserFailed       Code = "impersonate_user_failed"
+	ListUsersFailed             Code = "list_users_failed"
+	ExportUsersFailed           Code = "export_users_failed"
+	CreateSavedViewFailed       Code = "create_saved_view_failed"
+	ListSavedViewsFailed        Code = "list_saved_views_failed"
+	GetSavedViewFailed          Code = "get_saved_view_failed"
+	UpdateSavedViewFailed       Code = "update_saved_view_failed"
+	DeleteSavedViewFailed       Code = "delete_saved_view_failed"
+	GetNotificationsFailed      Code = "get_notifications_failed"
+	UpdateNotificationsFailed   Code = "update_notifications_failed"
+	LinkTelegramFailed          Code = "link_telegram_failed"
+	UnlinkTelegramFailed        Code = "unlink_telegram_failed"
+	TelegramWebhookFailed       Code = "telegram_webhook_failed"
+	CreateWebhookFailed         Code = "create_webhook_failed"
+	ListWebhooksFailed          Code = "list_webhooks_failed"
+	DeleteWebhookFailed         Code = "delete_webhook_failed"
+	ListWebhookDeliveriesFailed Code = "list_webhook_deliveries_failed"
+	RetryWebhookDeliveryFailed  Code = "retry_webhook_delivery_failed"
+	ReloadFeatureFlagsFailed    Code = "reload_feature_flags_failed"
+	ReloadConfigFailed          Code = "reload_config_failed"
+	GetAuditLogFailed           Code = "get_audit_log_failed"
+	GetSecuritySummaryFailed    Code = "get_security_summary_failed"
+)
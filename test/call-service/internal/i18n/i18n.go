@@ -0,0 +1,159 @@
+// Package i18n формирует ответы API с ошибками и названия статусов заявок на языке клиента.
+//
+// Ответ с ошибкой содержит стабильный код (поле code) и сообщение (поле error) на языке,
+// выбранном по заголовку Accept-Language. Сообщения хранятся в каталогах locales/<язык>.json,
+// встроенных в исполняемый файл; если язык клиента не поддерживается или в его каталоге
+// нет сообщения, используется английский.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// DefaultLang — язык сообщений, если клиент не указал поддерживаемый язык.
+const DefaultLang = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// catalogs содержит сообщения по языкам и кодам.
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[Code]string {
+	entries, err := locales.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	catalogs := make(map[string]map[Code]string, len(entries))
+	for _, entry := range entries {
+		data, err := locales.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var messages map[Code]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", entry.Name(), err))
+		}
+		catalogs[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+	return catalogs
+}
+
+// В сообщениях об ошибках проверки полей используются имена полей из тегов json.
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// Lang выбирает язык сообщений по значению заголовка Accept-Language: из поддерживаемых языков
+// берется язык с наибольшим весом q, региональные варианты (ru-RU) сводятся к языку.
+// Если поддерживаемых языков в заголовке нет, возвращается DefaultLang.
+
+func Lang(acceptLanguage string) string {
+	best, bestQ := DefaultLang, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := catalogs[lang]; ok && q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// Message возвращает сообщение с кодом code на языке lang, подставляя args в формат сообщения.
+// Если сообщения нет ни в каталоге lang, ни в каталоге DefaultLang, возвращается сам код.
+
+func Message(lang string, code Code, args ...any) string {
+	message, ok := catalogs[lang][code]
+	if !ok {
+		if message, ok = catalogs[DefaultLang][code]; !ok {
+			return string(code)
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// CallStatusLabel возвращает название статуса заявки на языке lang. Названия хранятся
+// в каталогах под ключами call_status.<статус>; для неизвестного статуса возвращается сам статус.
+
+func CallStatusLabel(lang, status string) string {
+	code := Code("call_status." + status)
+	if _, ok := catalogs[DefaultLang][code]; !ok {
+		return status
+	}
+	return Message(lang, code)
+}
+
+// Response возвращает тело ответа с ошибкой code: {"error": <сообщение>, "code": <код>}.
+// Язык сообщения выбирается по заголовку Accept-Language запроса.
+
+func Response(c *gin.Context, code Code, args ...any) gin.H {
+	return gin.H{"error": Message(Lang(c.GetHeader("Accept-Language")), code, args...), "code": code}
+}
+
+// Error — ошибка с кодом, которую можно вернуть из вспомогательных функций
+// и передать клиенту через ErrorResponse. Текст ошибки — сообщение на DefaultLang.
+
+type Error struct {
+	Code Code
+	Args []any
+}
+
+// New создает ошибку с кодом code и аргументами сообщения args.
+
+func New(code Code, args ...any) error {
+	return &Error{Code: code, Args: args}
+}
+
+func (e *Error) Error() string {
+	return Message(DefaultLang, e.Code, e.Args...)
+}
+
+// ErrorResponse возвращает тело ответа для ошибки разбора запроса: кода *Error,
+// ошибки проверки полей (FieldRequired, InvalidField с именем первого неверного поля)
+// или InvalidRequestBody для остальных ошибок.
+
+func ErrorResponse(c *gin.Context, err error) gin.H {
+	var coded *Error
+	if errors.As(err, &coded) {
+		return Response(c, coded.Code, coded.Args...)
+	}
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) && len(fieldErrs) > 0 {
+		if fieldErrs[0].Tag() == "required" {
+			return Response(c, FieldRequired, fieldErrs[0].Field())
+		}
+		return Response(c, InvalidField, fieldErrs[0].Field())
+	}
+	return Response(c, InvalidRequestBody)
+}
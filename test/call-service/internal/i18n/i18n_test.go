@@ -0,0 +1,54 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLang проверяет выбор языка по заголовку Accept-Language.
+
+func TestLang(t *testing.T) {
+	tests := map[string]string{
+		"":                        "en",
+		"ru":                      "ru",
+		"RU-ru":                   "ru",
+		"en-US,en;q=0.9,ru;q=0.8": "en",
+		"de,ru;q=0.5,en;q=0.3":    "ru",
+		"fr, *;q=0.1":             "en",
+		"ru;q=invalid, en;q=0.1":  "en",
+	}
+	for header, want := range tests {
+		assert.Equal(t, want, Lang(header), header)
+	}
+}
+
+// TestCatalogs проверяет, что каталоги всех языков содержат сообщения для тех же кодов,
+// что и английский.
+
+func TestCatalogs(t *testing.T) {
+	for lang, messages := range catalogs {
+		assert.Len(t, messages, len(catalogs[DefaultLang]), lang)
+		for code := range catalogs[DefaultLang] {
+			assert.NotEmpty(t, messages[code], "%s: %s", lang, code)
+		}
+	}
+	assert.Contains(t, catalogs, "ru")
+}
+
+// TestMessage проверяет подстановку аргументов и запасные варианты для неизвестных языка и кода.
+
+func TestMessage(t *testing.T) {
+	assert.Equal(t, "limit должен быть от 1 до 500", Message("ru", InvalidLimit, 500))
+	assert.Equal(t, "call not found", Message("de", CallNotFound))
+	assert.Equal(t, "unknown_code", Message("ru", Code("unknown_code")))
+	assert.Equal(t, "created_from must be in RFC3339 format", New(InvalidTime, "created_from").Error())
+}
+
+// TestCallStatusLabel проверяет названия статусов заявки.
+
+func TestCallStatusLabel(t *testing.T) {
+	assert.Equal(t, "В работе", CallStatusLabel("ru", "in_progress"))
+	assert.Equal(t, "Cancelled", CallStatusLabel("en", "cancelled"))
+	assert.Equal(t, "unknown", CallStatusLabel("ru", "unknown"))
+}
@@ -0,0 +1,36 @@
+// Package impersonation передает ID администратора, работающего от имени пользователя,
+// от middleware аутентификации к сервисному слою и репозиториям через контекст запроса.
+//
+// Администратор получает токен пользователя через сервис аутентификации (ImpersonateUser);
+// такой токен при проверке сообщает ID администратора. Изменения, сделанные с этим токеном,
+// выполняются от имени пользователя, а администратор записывается в историю статусов заявок.
+package impersonation
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type impersonatorKey struct{}
+
+// WithImpersonator возвращает контекст с ID администратора, работающего от имени пользователя.
+func WithImpersonator(ctx context.Context, adminID uuid.UUID) context.Context {
+	return context.WithValue(ctx, impersonatorKey{}, adminID)
+}
+
+// FromContext возвращает ID администратора, сохраненный WithImpersonator. Второй результат
+// false, если запрос выполняется пользователем самостоятельно.
+func FromContext(ctx context.Context) (uuid.UUID, bool) {
+	adminID, ok := ctx.Value(impersonatorKey{}).(uuid.UUID)
+	return adminID, ok
+}
+
+// Impersonator возвращает указатель на ID администратора из контекста для записи в историю
+// или nil, если запрос выполняется пользователем самостоятельно.
+func Impersonator(ctx context.Context) *uuid.UUID {
+	if adminID, ok := FromContext(ctx); ok {
+		return &adminID
+	}
+	return nil
+}
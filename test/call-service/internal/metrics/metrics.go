@@ -0,0 +1,165 @@
+// Package metrics публикует в /debug/vars счетчики с метками, по которым можно строить
+// оповещения, например о всплеске отклоненных запросов к одному маршруту.
+//
+// Число сочетаний меток ограничено: значения метки с перечнем допустимых значений вне
+// перечня учитываются как Other, а после MaxSeries сочетаний новые сочетания учитываются
+// с Other во всех метках. К каждому сочетанию прикладывается exemplar — идентификатор
+// последнего учтенного запроса, по которому событие находится в журнале запросов
+// и в трассировке, если она ведется.
+package metrics
+
+import (
+	"expvar"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Other — значение метки вне перечня допустимых значений.
+const Other = "other"
+
+// MaxSeries — наибольшее число сочетаний меток одного счетчика.
+const MaxSeries = 1000
+
+// Label — метка счетчика.
+type Label struct {
+	Name string
+	// Values — допустимые значения метки. Пустой перечень означает, что значения ограничивает
+	// вызывающий код (например, метка — шаблон зарегистрированного маршрута).
+	Values []string
+}
+
+// Exemplar — пример события, учтенного счетчиком.
+type Exemplar struct {
+	// ID — идентификатор запроса или трассировки.
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+}
+
+// Series — значение счетчика для одного сочетания меток.
+type Series struct {
+	Labels   map[string]string `json:"labels"`
+	Count    int64             `json:"count"`
+	Exemplar *Exemplar         `json:"exemplar,omitempty"`
+}
+
+// CounterVec — счетчик с метками. Методы безопасны для одновременного вызова.
+type CounterVec struct {
+	labels  []Label
+	allowed []map[string]bool
+
+	mu     sync.Mutex
+	series map[string]*Series
+}
+
+// NewCounterVec создает счетчик с метками labels и публикует его в /debug/vars под именем
+// name как список Series. Как и expvar.Publish, паникует, если имя уже занято.
+func NewCounterVec(name string, labels ...Label) *CounterVec {
+	v := &CounterVec{labels: labels, series: make(map[string]*Series)}
+	for _, label := range labels {
+		var allowed map[string]bool
+		if len(label.Values) > 0 {
+			allowed = make(map[string]bool, len(label.Values))
+			for _, value := range label.Values {
+				allowed[value] = true
+			}
+		}
+		v.allowed = append(v.allowed, allowed)
+	}
+	expvar.Publish(name, expvar.Func(func() any { return v.Snapshot() }))
+	return v
+}
+
+// Inc увеличивает счетчик для значений меток values, перечисленных в порядке меток,
+// и запоминает exemplar; пустой exemplar не заменяет прежний.
+func (v *CounterVec) Inc(exemplar string, values ...string) {
+	values = v.bound(values)
+	key := strings.Join(values, "\x00")
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.series[key]
+	if !ok {
+		if len(v.series) >= MaxSeries {
+			values = v.other()
+			key = strings.Join(values, "\x00")
+			s, ok = v.series[key]
+		}
+		if !ok {
+			s = &Series{Labels: v.labelMap(values)}
+			v.series[key] = s
+		}
+	}
+	s.Count++
+	if exemplar != "" {
+		s.Exemplar = &Exemplar{ID: exemplar, Time: time.Now().UTC()}
+	}
+}
+
+// Value возвращает значение счетчика для значений меток values.
+func (v *CounterVec) Value(values ...string) int64 {
+	key := strings.Join(v.bound(values), "\x00")
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok := v.series[key]; ok {
+		return s.Count
+	}
+	return 0
+}
+
+// Snapshot возвращает копию значений счетчика, упорядоченных по значениям меток.
+func (v *CounterVec) Snapshot() []Series {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	snapshot := make([]Series, 0, len(keys))
+	for _, key := range keys {
+		s := *v.series[key]
+		if s.Exemplar != nil {
+			exemplar := *s.Exemplar
+			s.Exemplar = &exemplar
+		}
+		snapshot = append(snapshot, s)
+	}
+	return snapshot
+}
+
+// bound приводит значения меток к допустимым: недостающие значения считаются пустыми,
+// лишние отбрасываются, значения вне перечня заменяются на Other.
+func (v *CounterVec) bound(values []string) []string {
+	bounded := make([]string, len(v.labels))
+	for i := range v.labels {
+		if i < len(values) {
+			bounded[i] = values[i]
+		}
+		if v.allowed[i] != nil && !v.allowed[i][bounded[i]] {
+			bounded[i] = Other
+		}
+	}
+	return bounded
+}
+
+// other возвращает значения меток, под которыми учитываются сочетания сверх MaxSeries.
+func (v *CounterVec) other() []string {
+	values := make([]string, len(v.labels))
+	for i := range values {
+		values[i] = Other
+	}
+	return values
+}
+
+// labelMap сопоставляет значения меток их именам.
+func (v *CounterVec) labelMap(values []string) map[string]string {
+	labels := make(map[string]string, len(values))
+	for i, label := range v.labels {
+		labels[label.Name] = values[i]
+	}
+	return labels
+}
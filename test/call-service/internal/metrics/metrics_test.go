@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест ограничения меток: значения вне перечня учитываются как other, сочетания сверх
+// MaxSeries — с other во всех метках, а счетчик публикуется в /debug/vars с exemplar
+func TestCounterVec(t *testing.T) {
+	counter := NewCounterVec("test_counter",
+		Label{Name: "reason", Values: []string{"forbidden", "unauthorized"}},
+		Label{Name: "route"},
+	)
+
+	counter.Inc("req-1", "forbidden", "/calls")
+	counter.Inc("req-2", "forbidden", "/calls")
+	counter.Inc("", "forbidden", "/calls")
+	counter.Inc("req-3", "bogus", "/calls")
+	assert.Equal(t, int64(3), counter.Value("forbidden", "/calls"))
+	assert.Equal(t, int64(1), counter.Value(Other, "/calls"))
+	assert.Equal(t, int64(1), counter.Value("anything", "/calls"))
+
+	var published []Series
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("test_counter").String()), &published))
+	require.Len(t, published, 2)
+	assert.Equal(t, map[string]string{"reason": "forbidden", "route": "/calls"}, published[0].Labels)
+	require.NotNil(t, published[0].Exemplar)
+	assert.Equal(t, "req-2", published[0].Exemplar.ID)
+
+	for i := range MaxSeries {
+		counter.Inc("", "unauthorized", "/route/"+strconv.Itoa(i))
+	}
+	assert.Len(t, counter.Snapshot(), MaxSeries+1)
+	assert.Equal(t, int64(2), counter.Value(Other, Other))
+}
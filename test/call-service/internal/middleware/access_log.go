@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"call-service/internal/repository"
+)
+
+// AccessLogConfig содержит параметры журнала запросов.
+type AccessLogConfig struct {
+	// Logger — логгер, в который пишутся записи; nil означает slog.Default().
+	Logger *slog.Logger
+	// SkipPaths — пути, запросы к которым не записываются (например, /healthz и /metrics).
+	SkipPaths []string
+	// SuccessSampling — записывается каждый N-й успешный (2xx) ответ; 0 и 1 означают все.
+	// Ответы с остальными кодами записываются всегда.
+	SuccessSampling int
+}
+
+// AccessLog возвращает middleware, записывающее каждый запрос одной структурированной записью:
+// метод, шаблон маршрута, путь, код ответа, длительность, размер ответа, ID пользователя и способ аутентификации
+// (если запрос прошел аутентификацию), ID администратора, работающего от имени пользователя (impersonated_by),
+// идентификатор запроса, IP клиента, а также число запросов
+// к базе данных (db_queries) и их суммарную длительность (db_time_ms). Запросы к базе данных
+// учитываются хуком repository.QueryStatsHook по контексту запроса.
+//
+// Уровень записи зависит от кода ответа: 5xx — Error, 4xx — Warn, остальные — Info,
+// поэтому уровень логгера ограничивает объем журнала. IP клиента определяется через
+// ClientIP и учитывает X-Forwarded-For только от доверенных прокси, заданных в TrustProxies.
+func AccessLog(cfg AccessLogConfig) gin.HandlerFunc {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	skip := make(map[string]bool, len(cfg.SkipPaths))
+	for _, path := range cfg.SkipPaths {
+		skip[path] = true
+	}
+	var successCount atomic.Uint64
+
+	return func(c *gin.Context) {
+		if skip[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		reqCtx, queryStats := repository.WithQueryStats(c.Request.Context())
+		c.Request = c.Request.WithContext(reqCtx)
+		start := time.Now()
+		c.Next()
+		latency := time.Since(start)
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		case status < http.StatusMultipleChoices && cfg.SuccessSampling > 1:
+			if (successCount.Add(1)-1)%uint64(cfg.SuccessSampling) != 0 {
+				return
+			}
+		}
+
+		ctx := context.Background()
+		if !logger.Enabled(ctx, level) {
+			return
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(latency.Microseconds())/1000),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+			slog.String("client_ip", ClientIP(c)),
+			slog.Int64("db_queries", queryStats.Queries()),
+			slog.Float64("db_time_ms", float64(queryStats.Elapsed().Microseconds())/1000),
+		}
+		if requestID := GetRequestID(c); requestID != "" {
+			attrs = append(attrs, slog.String("request_id", requestID))
+		}
+		if principal, ok := GetPrincipal(c); ok {
+			attrs = append(attrs,
+				slog.String("user_id", principal.UserID.String()),
+				slog.String("auth_method", string(principal.AuthMethod)))
+			if principal.Degraded {
+				attrs = append(attrs, slog.Bool("auth_degraded", true))
+			}
+			if principal.ImpersonatedBy != uuid.Nil {
+				attrs = append(attrs, slog.String("impersonated_by", principal.ImpersonatedBy.String()))
+			}
+		}
+		logger.LogAttrs(ctx, level, "http request", attrs...)
+	}
+}
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+
+	"call-service/internal/repository"
+	"common/clientip"
+)
+
+// setupAccessLogRouter создает маршрутизатор с журналом запросов, пишущим JSON в buf.
+// Маршрут /calls/:id имитирует аутентифицированный запрос пользователя userID.
+
+func setupAccessLogRouter(buf *bytes.Buffer, cfg AccessLogConfig, userID uuid.UUID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg.Logger = slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	proxies, _ := clientip.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	router := gin.New()
+	router.Use(TrustProxies(proxies), RequestID(), AccessLog(cfg))
+	router.GET("/calls/:id", func(c *gin.Context) {
+		setPrincipal(c, Principal{UserID: userID, Role: RoleUser, AuthMethod: AuthMethodJWT})
+		c.String(http.StatusOK, "hello")
+	})
+	router.GET("/fail", func(c *gin.Context) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed"})
+	})
+	router.GET("/healthz", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+// logEntries разбирает записи журнала, по одной JSON-записи в строке.
+
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// TestAccessLog_Fields проверяет состав полей записи для аутентифицированного запроса
+// через доверенный прокси.
+
+func TestAccessLog_Fields(t *testing.T) {
+	var buf bytes.Buffer
+	userID := uuid.New()
+	router := setupAccessLogRouter(&buf, AccessLogConfig{}, userID)
+
+	req := httptest.NewRequest(http.MethodGet, "/calls/42", nil)
+	req.RemoteAddr = "10.0.0.1:12345"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.Header.Set(RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, "INFO", entry["level"])
+	assert.Equal(t, "http request", entry["msg"])
+	assert.Equal(t, "GET", entry["method"])
+	assert.Equal(t, "/calls/:id", entry["route"])
+	assert.Equal(t, "/calls/42", entry["path"])
+	assert.EqualValues(t, 200, entry["status"])
+	assert.EqualValues(t, 5, entry["bytes"])
+	assert.Equal(t, "203.0.113.7", entry["client_ip"])
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.Equal(t, userID.String(), entry["user_id"])
+	assert.Equal(t, "jwt", entry["auth_method"])
+	assert.Contains(t, entry, "latency_ms")
+	assert.EqualValues(t, 0, entry["db_queries"])
+	assert.EqualValues(t, 0, entry["db_time_ms"])
+	assert.NotContains(t, entry, "impersonated_by")
+	assert.Equal(t, "req-1", w.Header().Get(RequestIDHeader))
+}
+
+// TestAccessLog_DBQueries проверяет, что запись содержит число и длительность запросов
+// к базе данных, учтенных хуком repository.QueryStatsHook по контексту запроса.
+
+func TestAccessLog_DBQueries(t *testing.T) {
+	var buf bytes.Buffer
+	router := setupAccessLogRouter(&buf, AccessLogConfig{}, uuid.New())
+	hook := &repository.QueryStatsHook{}
+	router.GET("/db", func(c *gin.Context) {
+		for i := 0; i < 3; i++ {
+			hook.AfterQuery(c.Request.Context(), &bun.QueryEvent{Query: "SELECT 1", StartTime: time.Now().Add(-2 * time.Millisecond)})
+		}
+		c.Status(http.StatusOK)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/db", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/calls/1", nil))
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 2)
+	assert.EqualValues(t, 3, entries[0]["db_queries"])
+	assert.GreaterOrEqual(t, entries[0]["db_time_ms"], 6.0)
+	assert.EqualValues(t, 0, entries[1]["db_queries"])
+}
+
+// TestAccessLog_UntrustedProxy проверяет, что X-Forwarded-For от недоверенного адреса игнорируется.
+
+func TestAccessLog_UntrustedProxy(t *testing.T) {
+	var buf bytes.Buffer
+	router := setupAccessLogRouter(&buf, AccessLogConfig{}, uuid.New())
+
+	req := httptest.NewRequest(http.MethodGet, "/calls/42", nil)
+	req.RemoteAddr = "198.51.100.2:12345"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "198.51.100.2", entries[0]["client_ip"])
+	assert.NotEmpty(t, entries[0]["request_id"])
+}
+
+// TestAccessLog_LevelsAndSkipPaths проверяет уровень записи по коду ответа
+// и исключение служебных путей.
+
+func TestAccessLog_LevelsAndSkipPaths(t *testing.T) {
+	var buf bytes.Buffer
+	router := setupAccessLogRouter(&buf, AccessLogConfig{SkipPaths: []string{"/healthz"}}, uuid.New())
+
+	for _, path := range []string{"/healthz", "/fail", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "ERROR", entries[0]["level"])
+	assert.Equal(t, "/fail", entries[0]["route"])
+	assert.Equal(t, "WARN", entries[1]["level"])
+	assert.Equal(t, "", entries[1]["route"])
+	assert.Equal(t, "/missing", entries[1]["path"])
+	assert.NotContains(t, entries[1], "user_id")
+}
+
+// TestAccessLog_SuccessSampling проверяет, что из успешных ответов записывается каждый N-й,
+// а ошибки записываются всегда.
+
+func TestAccessLog_SuccessSampling(t *testing.T) {
+	var buf bytes.Buffer
+	router := setupAccessLogRouter(&buf, AccessLogConfig{SuccessSampling: 3}, uuid.New())
+
+	for i := 0; i < 6; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/calls/1", nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 3)
+	assert.EqualValues(t, 200, entries[0]["status"])
+	assert.EqualValues(t, 200, entries[1]["status"])
+	assert.EqualValues(t, 500, entries[2]["status"])
+}
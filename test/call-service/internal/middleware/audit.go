@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"call-service/internal/metrics"
+	"call-service/internal/model"
+)
+
+// securityEvents — число запросов к зарегистрированным маршрутам, отклоненных с кодом 401,
+// 403 или 429, в /debug/vars по причине (model.SecurityReasons) и шаблону маршрута; exemplar —
+// идентификатор последнего такого запроса. По показателю строятся оповещения о всплеске
+// неудачных входов и попыток доступа к чужим заявкам.
+var securityEvents = metrics.NewCounterVec("security_events",
+	metrics.Label{Name: "reason", Values: model.SecurityReasons},
+	metrics.Label{Name: "route"},
+)
+
+// auditResourceKey — ключ контекста gin, под которым SetAuditResourceID сохраняет ID объекта.
+const auditResourceKey = "audit_resource_id"
+
+// AuditRecorder принимает записи журнала изменяющих запросов. Record не должен блокировать
+// запрос: запись, которую не удалось принять, отбрасывается.
+
+type AuditRecorder interface {
+	Record(entry *model.APIAuditEntry) bool
+}
+
+// AuditConfig содержит параметры журнала изменяющих запросов.
+
+type AuditConfig struct {
+	// Recorder — получатель записей журнала.
+	Recorder AuditRecorder
+	// RedactPaths — шаблоны маршрутов (например, /login), тела запросов к которым содержат
+	// учетные данные или одноразовые коды и не хешируются: хеш короткого секрета легко подобрать.
+	RedactPaths []string
+}
+
+// Audit возвращает middleware, которое записывает в журнал каждый запрос POST, PUT, PATCH
+// и DELETE к зарегистрированному маршруту: метод, шаблон маршрута, ID объекта, пользователя
+// и способ аутентификации, ID администратора, работающего от имени пользователя, код ответа,
+// SHA-256 тела запроса, IP клиента и идентификатор запроса.
+//
+// Тело хешируется по мере чтения обработчиком, без буферизации, поэтому хеш покрывает
+// прочитанную часть тела — все тело для принятых запросов. ID объекта — значение последнего
+// параметра пути, если обработчик не задал его через SetAuditResourceID (например, ID созданной
+// заявки). Пользователь определяется после обработки запроса, поэтому middleware подключается
+// до AuthRequired. Запросы к неизвестным путям не записываются.
+//
+// Кроме того, middleware учитывает в показателе security_events все отклоненные запросы
+// к зарегистрированным маршрутам, в том числе запросы чтения, которые в журнал не попадают.
+
+func Audit(cfg AuditConfig) gin.HandlerFunc {
+	redact := make(map[string]bool, len(cfg.RedactPaths))
+	for _, path := range cfg.RedactPaths {
+		redact[path] = true
+	}
+
+	return func(c *gin.Context) {
+		if c.FullPath() == "" {
+			c.Next()
+			return
+		}
+		if !isMutating(c.Request.Method) {
+			c.Next()
+			countRejected(c)
+			return
+		}
+
+		route := c.FullPath()
+		var body *hashingReader
+		if !redact[route] && c.Request.Body != nil && c.Request.Body != http.NoBody {
+			body = &hashingReader{ReadCloser: c.Request.Body, hash: sha256.New()}
+			c.Request.Body = body
+		}
+		c.Next()
+
+		entry := &model.APIAuditEntry{
+			Method:       c.Request.Method,
+			Route:        route,
+			ResourceID:   auditResourceID(c),
+			Status:       c.Writer.Status(),
+			BodyRedacted: redact[route],
+			ClientIP:     ClientIP(c),
+			RequestID:    GetRequestID(c),
+		}
+		if body != nil {
+			entry.BodyHash = hex.EncodeToString(body.hash.Sum(nil))
+		}
+		if principal, ok := GetPrincipal(c); ok {
+			entry.UserID = &principal.UserID
+			entry.AuthMethod = string(principal.AuthMethod)
+			if principal.ImpersonatedBy != uuid.Nil {
+				entry.ImpersonatedBy = &principal.ImpersonatedBy
+			}
+		}
+		cfg.Recorder.Record(entry)
+		countRejected(c)
+	}
+}
+
+// countRejected учитывает запрос в показателе security_events, если он отклонен.
+
+func countRejected(c *gin.Context) {
+	if reason := model.SecurityReason(c.Writer.Status()); reason != "" {
+		securityEvents.Inc(GetRequestID(c), reason, c.FullPath())
+	}
+}
+
+// SetAuditResourceID задает ID объекта запроса для журнала изменяющих запросов, например ID
+// созданного объекта, которого нет в пути запроса.
+
+func SetAuditResourceID(c *gin.Context, id string) {
+	c.Set(auditResourceKey, id)
+}
+
+// auditResourceID возвращает ID объекта, заданный обработчиком, или значение последнего
+// параметра пути.
+
+func auditResourceID(c *gin.Context) string {
+	if id := c.GetString(auditResourceKey); id != "" {
+		return id
+	}
+	if len(c.Params) == 0 {
+		return ""
+	}
+	return c.Params[len(c.Params)-1].Value
+}
+
+// isMutating сообщает, изменяет ли запрос с методом method данные.
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// hashingReader вычисляет хеш тела запроса по мере его чтения.
+
+type hashingReader struct {
+	io.ReadCloser
+	hash hash.Hash
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	return n, err
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+)
+
+// auditRecorderStub сохраняет принятые записи журнала.
+
+type auditRecorderStub struct {
+	entries []*model.APIAuditEntry
+}
+
+func (r *auditRecorderStub) Record(entry *model.APIAuditEntry) bool {
+	r.entries = append(r.entries, entry)
+	return true
+}
+
+// setupAuditRouter создает маршрутизатор с журналом изменяющих запросов. Маршруты /calls
+// имитируют аутентифицированные запросы пользователя userID, /login — запрос без пользователя
+// с учетными данными в теле.
+
+func setupAuditRouter(recorder AuditRecorder, userID uuid.UUID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID(), Audit(AuditConfig{Recorder: recorder, RedactPaths: []string{"/login"}}))
+	authenticated := func(c *gin.Context) {
+		setPrincipal(c, Principal{UserID: userID, Role: RoleUser, AuthMethod: AuthMethodJWT})
+	}
+	router.POST("/calls", authenticated, func(c *gin.Context) {
+		_, _ = io.ReadAll(c.Request.Body)
+		SetAuditResourceID(c, "new-call")
+		c.Status(http.StatusCreated)
+	})
+	router.DELETE("/calls/:id", authenticated, func(c *gin.Context) {
+		c.Status(http.StatusForbidden)
+	})
+	router.GET("/calls/:id", authenticated, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.POST("/login", func(c *gin.Context) {
+		_, _ = io.ReadAll(c.Request.Body)
+		c.Status(http.StatusUnauthorized)
+	})
+	return router
+}
+
+// TestAudit_RecordsMutatingRequest проверяет состав записи для изменяющего запроса.
+
+func TestAudit_RecordsMutatingRequest(t *testing.T) {
+	recorder := &auditRecorderStub{}
+	userID := uuid.New()
+	router := setupAuditRouter(recorder, userID)
+
+	body := `{"title":"demo"}`
+	req := httptest.NewRequest(http.MethodPost, "/calls", strings.NewReader(body))
+	req.RemoteAddr = "203.0.113.7:12345"
+	req.Header.Set(RequestIDHeader, "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Len(t, recorder.entries, 1)
+	entry := recorder.entries[0]
+	sum := sha256.Sum256([]byte(body))
+	assert.Equal(t, http.MethodPost, entry.Method)
+	assert.Equal(t, "/calls", entry.Route)
+	assert.Equal(t, "new-call", entry.ResourceID)
+	assert.Equal(t, http.StatusCreated, entry.Status)
+	assert.Equal(t, hex.EncodeToString(sum[:]), entry.BodyHash)
+	assert.False(t, entry.BodyRedacted)
+	assert.Equal(t, "203.0.113.7", entry.ClientIP)
+	assert.Equal(t, "req-1", entry.RequestID)
+	require.NotNil(t, entry.UserID)
+	assert.Equal(t, userID, *entry.UserID)
+	assert.Equal(t, "jwt", entry.AuthMethod)
+	assert.Nil(t, entry.ImpersonatedBy)
+}
+
+// TestAudit_ResourceIDFromPath проверяет, что ID объекта берется из пути запроса, а отказ
+// в доступе тоже записывается.
+
+func TestAudit_ResourceIDFromPath(t *testing.T) {
+	recorder := &auditRecorderStub{}
+	router := setupAuditRouter(recorder, uuid.New())
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/calls/42", nil))
+
+	require.Len(t, recorder.entries, 1)
+	assert.Equal(t, "/calls/:id", recorder.entries[0].Route)
+	assert.Equal(t, "42", recorder.entries[0].ResourceID)
+	assert.Equal(t, http.StatusForbidden, recorder.entries[0].Status)
+	assert.Empty(t, recorder.entries[0].BodyHash)
+}
+
+// TestAudit_RedactsCredentials проверяет, что тело запроса к маршруту с учетными данными
+// не хешируется.
+
+func TestAudit_RedactsCredentials(t *testing.T) {
+	recorder := &auditRecorderStub{}
+	router := setupAuditRouter(recorder, uuid.New())
+
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"password":"secret"}`))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Len(t, recorder.entries, 1)
+	assert.True(t, recorder.entries[0].BodyRedacted)
+	assert.Empty(t, recorder.entries[0].BodyHash)
+	assert.Nil(t, recorder.entries[0].UserID)
+	assert.Equal(t, http.StatusUnauthorized, recorder.entries[0].Status)
+}
+
+// TestAudit_SkipsReadsAndUnknownRoutes проверяет, что чтение и запросы к неизвестным путям
+// не записываются.
+
+func TestAudit_SkipsReadsAndUnknownRoutes(t *testing.T) {
+	recorder := &auditRecorderStub{}
+	router := setupAuditRouter(recorder, uuid.New())
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/calls/42", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/unknown", nil))
+
+	assert.Empty(t, recorder.entries)
+}
+
+// TestAudit_CountsRejectedRequests проверяет, что отклоненные запросы, в том числе запросы
+// чтения, учитываются в показателе security_events по причине и маршруту с идентификатором
+// запроса в exemplar.
+
+func TestAudit_CountsRejectedRequests(t *testing.T) {
+	router := setupAuditRouter(&auditRecorderStub{}, uuid.New())
+	router.GET("/calls", func(c *gin.Context) { c.Status(http.StatusForbidden) })
+	forbidden := securityEvents.Value(model.SecurityReasonForbidden, "/calls")
+	deleted := securityEvents.Value(model.SecurityReasonForbidden, "/calls/:id")
+	unauthorized := securityEvents.Value(model.SecurityReasonUnauthorized, "/login")
+
+	req := httptest.NewRequest(http.MethodGet, "/calls", nil)
+	req.Header.Set(RequestIDHeader, "req-forbidden")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/calls/42", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("{}")))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/calls/42", nil))
+
+	assert.Equal(t, forbidden+1, securityEvents.Value(model.SecurityReasonForbidden, "/calls"))
+	assert.Equal(t, deleted+1, securityEvents.Value(model.SecurityReasonForbidden, "/calls/:id"))
+	assert.Equal(t, unauthorized+1, securityEvents.Value(model.SecurityReasonUnauthorized, "/login"))
+	for _, series := range securityEvents.Snapshot() {
+		if series.Labels["route"] == "/calls" && series.Labels["reason"] == model.SecurityReasonForbidden {
+			require.NotNil(t, series.Exemplar)
+			assert.Equal(t, "req-forbidden", series.Exemplar.ID)
+		}
+	}
+}
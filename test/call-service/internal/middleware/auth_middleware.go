@@ -1,69 +1,441 @@
-package middleware
-
-import (
-	"net/http"
-	"strings"
-
-	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-
-	"call-service/pkg/authclient"
-)
-
-// AuthMiddleware представляет middleware для проверки аутентификации в HTTP запросах
-
-type AuthMiddleware struct {
-	authClient authclient.AuthClient
-}
-
-// NewAuthMiddleware создает новый экземпляр middleware для аутентификации
-
-func NewAuthMiddleware(authClient authclient.AuthClient) *AuthMiddleware {
-	return &AuthMiddleware{authClient: authClient}
-}
-
-// AuthRequired возвращает обработчик middleware, который проверяет наличие и валидность токена аутентификации
-
-func (m *AuthMiddleware) AuthRequired() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
-			return
-		}
-
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header format"})
-			return
-		}
-
-		token := parts[1]
-
-		valid, userID, err := m.authClient.ValidateToken(c.Request.Context(), token)
-		if err != nil || !valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			return
-		}
-
-		uuidObj, err := uuid.Parse(userID)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
-			return
-		}
-
-		c.Set("userID", uuidObj)
-		c.Next()
-	}
-}
-
-// GetUserID извлекает ID пользователя из контекста запроса
-
-func GetUserID(c *gin.Context) (uuid.UUID, bool) {
-	userID, exists := c.Get("userID")
-	if !exists {
-		return uuid.Nil, false
-	}
-
-	return userID.(uuid.UUID), true
-}
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"call-service/internal/cache"
+	"call-service/internal/featureflags"
+	"call-service/internal/i18n"
+	"call-service/pkg/authclient"
+	"common/clock"
+)
+
+// Роли пользователей, возвращаемые сервисом аутентификации
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// TokenSource — место в запросе, из которого AuthMiddleware берет токен доступа.
+
+type TokenSource string
+
+const (
+	// TokenSourceHeader — заголовок Authorization: Bearer <token>.
+	TokenSourceHeader TokenSource = "header"
+	// TokenSourceCookie — cookie AccessTokenCookie, которую устанавливают /login и /register.
+	TokenSourceCookie TokenSource = "cookie"
+	// TokenSourceAPIKey — заголовок APIKeyHeader с API-ключом; используется, только если
+	// в AuthConfig задан APIKeys.
+	TokenSourceAPIKey TokenSource = "api_key"
+)
+
+// APIKeyHeader — заголовок, в котором автоматизированные клиенты передают API-ключ.
+const APIKeyHeader = "X-API-Key"
+
+// AccessTokenCookie — имя cookie с токеном доступа для браузерных клиентов.
+const AccessTokenCookie = "access_token"
+
+// MaxTokenLength — максимальная длина токена, API-ключа или значения cookie в байтах.
+// Более длинные значения отклоняются до обращения к сервису аутентификации.
+const MaxTokenLength = 8 << 10
+
+// DefaultTokenSources — порядок источников токена по умолчанию: заголовок Authorization
+// имеет приоритет над API-ключом, а API-ключ — над cookie.
+var DefaultTokenSources = []TokenSource{TokenSourceHeader, TokenSourceAPIKey, TokenSourceCookie}
+
+// Ошибки извлечения и проверки токена; клиенту возвращается код ошибки из authErrorCodes
+var (
+	errTokenRequired   = errors.New("authorization header, API key or access token cookie is required")
+	errMalformedHeader = errors.New("invalid authorization header format")
+	errTokenTooLong    = errors.New("token is too long")
+	errMalformedCookie = errors.New("invalid access token cookie")
+	errInvalidToken    = errors.New("invalid token")
+	errInvalidUserID   = errors.New("invalid user ID")
+	errInvalidAPIKey   = errors.New("invalid API key")
+	errAuthUnavailable = errors.New("auth service unavailable")
+)
+
+// authErrorCodes сопоставляет ошибки проверки токена с кодами ошибок API.
+var authErrorCodes = map[error]i18n.Code{
+	errTokenRequired:   i18n.TokenRequired,
+	errMalformedHeader: i18n.MalformedAuthHeader,
+	errTokenTooLong:    i18n.TokenTooLong,
+	errMalformedCookie: i18n.MalformedTokenCookie,
+	errInvalidToken:    i18n.InvalidToken,
+	errInvalidUserID:   i18n.InvalidUserID,
+	errInvalidAPIKey:   i18n.InvalidAPIKey,
+	errAuthUnavailable: i18n.AuthUnavailable,
+}
+
+// ParseTokenSources разбирает список источников токена (например, из переменной окружения).
+// Пустой список означает DefaultTokenSources.
+
+func ParseTokenSources(values []string) ([]TokenSource, error) {
+	if len(values) == 0 {
+		return DefaultTokenSources, nil
+	}
+	sources := make([]TokenSource, 0, len(values))
+	for _, value := range values {
+		source := TokenSource(strings.ToLower(strings.TrimSpace(value)))
+		if source != TokenSourceHeader && source != TokenSourceCookie && source != TokenSourceAPIKey {
+			return nil, fmt.Errorf("unknown token source %q", value)
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// AuthConfig задает параметры AuthMiddleware.
+
+type AuthConfig struct {
+	// TokenSources — источники токена в порядке приоритета. Используется первый источник,
+	// присутствующий в запросе, даже если он задан некорректно; nil означает DefaultTokenSources.
+	TokenSources []TokenSource
+	// APIKeys определяет владельцев API-ключей; nil отключает аутентификацию по API-ключу.
+	APIKeys APIKeyResolver
+	// StaleTTL включает режим деградации: если сервис аутентификации недоступен, токен,
+	// успешно проверенный не раньше StaleTTL назад, принимается по кэшированной проверке,
+	// а Principal запроса получает признак Degraded. Токены, отклоненные сервисом
+	// аутентификации, из кэша не принимаются. 0 отключает режим деградации.
+	StaleTTL time.Duration
+	// Clock задает часы для кэша проверок и срока действия токена в Principal; nil — системное время.
+	Clock clock.Clock
+	// Organizations включает доступ к заявкам организации: текущая организация пользователя,
+	// сообщенная при проверке токена, сохраняется в Principal и в контексте запроса (см. tenant).
+	// Выключено — организация не учитывается, и каждый пользователь видит только свои заявки.
+	Organizations bool
+	// Flags — флаги постепенного включения поведения; флаг featureflags.DegradedAuth
+	// разрешает режим деградации для пользователя токена. nil — значения флагов по умолчанию.
+	Flags featureflags.Flags
+	// Cache — хранилище кэша проверок для режима деградации; nil — память процесса.
+	// Общее хранилище (Redis) позволяет принимать токен, проверенный другим экземпляром сервиса.
+	Cache cache.Store
+}
+
+// APIKeyResolver определяет владельца API-ключа. Возвращает ошибку, если ключ неизвестен,
+// отозван или истек.
+
+type APIKeyResolver interface {
+	ResolveAPIKey(ctx context.Context, key string) (uuid.UUID, error)
+}
+
+// AuthMiddleware представляет middleware для проверки аутентификации в HTTP запросах
+
+type AuthMiddleware struct {
+	authClient authclient.AuthClient
+	apiKeys    APIKeyResolver
+	sources    []TokenSource
+	orgs       bool
+	flags      featureflags.Flags
+	clock      clock.Clock
+	// stale — кэш проверок токенов для режима деградации; nil, если режим отключен.
+	stale *staleCache
+}
+
+// NewAuthMiddleware создает новый экземпляр middleware для аутентификации
+// с порядком источников токена по умолчанию
+
+func NewAuthMiddleware(authClient authclient.AuthClient) *AuthMiddleware {
+	return NewAuthMiddlewareWithConfig(authClient, AuthConfig{})
+}
+
+// NewAuthMiddlewareWithConfig создает middleware для аутентификации с заданными параметрами
+
+func NewAuthMiddlewareWithConfig(authClient authclient.AuthClient, cfg AuthConfig) *AuthMiddleware {
+	sources := cfg.TokenSources
+	if len(sources) == 0 {
+		sources = DefaultTokenSources
+	}
+	flags := cfg.Flags
+	if flags == nil {
+		flags = featureflags.Static{}
+	}
+	clk := cfg.Clock
+	if clk == nil {
+		clk = clock.Real
+	}
+	m := &AuthMiddleware{authClient: authClient, apiKeys: cfg.APIKeys, sources: sources, orgs: cfg.Organizations, flags: flags, clock: clk}
+	if cfg.StaleTTL > 0 {
+		m.stale = newStaleCache(cfg.StaleTTL, clk, cfg.Cache)
+	}
+	return m
+}
+
+// AuthRequired возвращает обработчик middleware, который проверяет наличие и валидность токена аутентификации.
+// Если токен не удалось проверить из-за недоступности сервиса аутентификации, возвращается 503,
+// в остальных случаях — 401; код ошибки различает причину отказа.
+
+func (m *AuthMiddleware) AuthRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := m.authenticate(c); err != nil {
+			abortUnauthenticated(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// DeferredAuth возвращает обработчик middleware для маршрутов, где токен можно передать
+// после установки соединения (WebSocket): токен, присутствующий в запросе, проверяется
+// так же, как в AuthRequired, а запрос без токена передается обработчику без Principal.
+// Обработчик получает токен от клиента и проверяет его методом Authenticate.
+
+func (m *AuthMiddleware) DeferredAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := m.authenticate(c); err != nil && !errors.Is(err, errTokenRequired) {
+			abortUnauthenticated(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// Authenticate проверяет токен доступа, полученный обработчиком не из запроса, и сохраняет
+// в контексте Principal так же, как AuthRequired. Ошибка — *i18n.Error с кодом причины
+// отказа; i18n.AuthUnavailable означает недоступность сервиса аутентификации.
+
+func (m *AuthMiddleware) Authenticate(c *gin.Context, token string) error {
+	err := errTokenRequired
+	switch {
+	case len(token) > MaxTokenLength:
+		err = errTokenTooLong
+	case token != "":
+		err = m.validate(c, token)
+	}
+	if err != nil {
+		return i18n.New(authErrorCodes[err])
+	}
+	return nil
+}
+
+// abortUnauthenticated прерывает запрос с ошибкой аутентификации err: 503, если сервис
+// аутентификации недоступен, и 401 в остальных случаях.
+
+func abortUnauthenticated(c *gin.Context, err error) {
+	code := http.StatusUnauthorized
+	if errors.Is(err, errAuthUnavailable) {
+		code = http.StatusServiceUnavailable
+	}
+	c.AbortWithStatusJSON(code, i18n.Response(c, authErrorCodes[err]))
+}
+
+// OptionalAuth возвращает обработчик middleware для маршрутов, доступных и без аутентификации.
+// Если запрос содержит действительный токен, сохраняет данные пользователя так же, как AuthRequired;
+// отсутствующий или недействительный токен не прерывает запрос, и обработчик различает
+// эти случаи по второму результату GetPrincipal.
+
+func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_ = m.authenticate(c)
+		c.Next()
+	}
+}
+
+// authenticate проверяет токен или API-ключ запроса и сохраняет в контексте Principal.
+// Запрос с API-ключом получает роль RoleUser независимо от роли владельца
+// и пустой ID сеанса без организации; ID пользователя сохраняется так же, как для токена.
+// Если сервис аутентификации недоступен и включен режим деградации (AuthConfig.StaleTTL,
+// флаг featureflags.DegradedAuth), сохраняется Principal из последней успешной проверки токена.
+
+func (m *AuthMiddleware) authenticate(c *gin.Context) error {
+	source, token, err := m.token(c)
+	if err != nil {
+		return err
+	}
+
+	if source == TokenSourceAPIKey {
+		userID, err := m.apiKeys.ResolveAPIKey(c.Request.Context(), token)
+		if err != nil {
+			return errInvalidAPIKey
+		}
+		setPrincipal(c, Principal{UserID: userID, Role: RoleUser, AuthMethod: AuthMethodAPIKey})
+		return nil
+	}
+	return m.validate(c, token)
+}
+
+// validate проверяет токен доступа в сервисе аутентификации и сохраняет в контексте Principal
+// и токен.
+
+func (m *AuthMiddleware) validate(c *gin.Context, token string) error {
+	info, err := m.authClient.ValidateTokenFull(c.Request.Context(), token)
+	if err != nil && isTransportError(err) {
+		if m.stale == nil {
+			return errAuthUnavailable
+		}
+		principal, ok := m.stale.lookup(c.Request.Context(), token)
+		if ok {
+			ok = m.flags.Enabled(featureflags.WithUser(c.Request.Context(), principal.UserID), featureflags.DegradedAuth)
+		}
+		if !ok {
+			degradedAuth.Add("rejected", 1)
+			return errAuthUnavailable
+		}
+		degradedAuth.Add("served", 1)
+		setPrincipal(c, principal)
+		c.Set(accessTokenKey, token)
+		return nil
+	}
+	if err != nil || info == nil || !info.Valid {
+		if err == nil && m.stale != nil {
+			m.stale.forget(c.Request.Context(), token)
+		}
+		return errInvalidToken
+	}
+
+	uuidObj, err := uuid.Parse(info.UserID)
+	if err != nil {
+		return errInvalidUserID
+	}
+
+	role := info.Role
+	if role == "" {
+		role = RoleUser
+	}
+
+	principal := Principal{
+		UserID:         uuidObj,
+		Role:           role,
+		AuthMethod:     AuthMethodJWT,
+		SessionID:      info.SessionID,
+		TokenExpiresAt: m.tokenExpiresAt(info),
+	}
+	if m.orgs && info.OrgID != "" {
+		orgID, err := uuid.Parse(info.OrgID)
+		if err != nil {
+			return errInvalidToken
+		}
+		principal.OrgID = orgID
+		principal.OrgRole = info.OrgRole
+	}
+	if info.ImpersonatedBy != "" {
+		if principal.ImpersonatedBy, err = uuid.Parse(info.ImpersonatedBy); err != nil {
+			return errInvalidToken
+		}
+	}
+	if m.stale != nil {
+		m.stale.store(c.Request.Context(), token, principal)
+	}
+	setPrincipal(c, principal)
+	c.Set(accessTokenKey, token)
+	return nil
+}
+
+// tokenExpiresAt возвращает срок действия токена по часам call-service: оставшееся время,
+// сообщенное сервисом аутентификации, отсчитывается от текущего момента, поэтому расхождение
+// часов сервисов не продлевает и не сокращает кэшированную проверку. Если оставшееся время
+// не сообщено, используется срок из токена.
+
+func (m *AuthMiddleware) tokenExpiresAt(info *authclient.TokenInfo) time.Time {
+	if info.ExpiresIn > 0 {
+		return m.clock.Now().Add(info.ExpiresIn)
+	}
+	return info.ExpiresAt
+}
+
+// InvalidateUser удаляет кэшированные проверки токенов пользователя, чтобы при недоступности
+// сервиса аутентификации не использовались устаревшие данные, например организация до
+// принятия приглашения. Без режима деградации ничего не делает.
+
+func (m *AuthMiddleware) InvalidateUser(userID uuid.UUID) {
+	if m.stale != nil {
+		m.stale.forgetUser(context.Background(), userID)
+	}
+}
+
+// token извлекает токен из первого источника, присутствующего в запросе, и возвращает его вместе с источником.
+// Токен длиннее MaxTokenLength отклоняется с ошибкой errTokenTooLong.
+
+func (m *AuthMiddleware) token(c *gin.Context) (TokenSource, string, error) {
+	source, token, err := m.findToken(c)
+	if err == nil && len(token) > MaxTokenLength {
+		return source, "", errTokenTooLong
+	}
+	return source, token, err
+}
+
+// findToken извлекает токен из первого источника, присутствующего в запросе.
+// Заголовок Authorization разбирается функцией ParseBearer.
+
+func (m *AuthMiddleware) findToken(c *gin.Context) (TokenSource, string, error) {
+	for _, source := range m.sources {
+		switch source {
+		case TokenSourceHeader:
+			authHeader := c.GetHeader("Authorization")
+			if authHeader == "" {
+				continue
+			}
+			token, ok := ParseBearer(authHeader)
+			if !ok {
+				return source, "", errMalformedHeader
+			}
+			return source, token, nil
+		case TokenSourceAPIKey:
+			key := c.GetHeader(APIKeyHeader)
+			if key == "" || m.apiKeys == nil {
+				continue
+			}
+			return source, key, nil
+		case TokenSourceCookie:
+			cookie, err := c.Request.Cookie(AccessTokenCookie)
+			if err != nil {
+				continue
+			}
+			if !isTokenValue(cookie.Value) {
+				return source, "", errMalformedCookie
+			}
+			return source, cookie.Value, nil
+		}
+	}
+	return "", "", errTokenRequired
+}
+
+// isTokenValue сообщает, может ли значение быть JWT: непустая строка из символов base64url и точек.
+
+func isTokenValue(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// RequireRole возвращает обработчик middleware, который пропускает только пользователей с указанной ролью.
+// Должен подключаться после AuthRequired; пользователям с другой ролью возвращается 403.
+
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok || principal.Role != role {
+			c.AbortWithStatusJSON(http.StatusForbidden, i18n.Response(c, i18n.AccessDenied))
+			return
+		}
+		c.Next()
+	}
+}
+
+// ParseBearer извлекает токен из значения заголовка Authorization (или метаданных authorization
+// gRPC) со схемой Bearer. Схема сравнивается без учета регистра, а пробелы вокруг схемы
+// и токена не учитываются. Второй результат false, если значение задано в другом формате.
+
+func ParseBearer(value string) (string, bool) {
+	parts := strings.Fields(value)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return "", false
+	}
+	return parts[1], true
+}
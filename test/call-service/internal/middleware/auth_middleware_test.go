@@ -0,0 +1,678 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"call-service/internal/cache"
+	"call-service/internal/featureflags"
+	"call-service/internal/i18n"
+	"call-service/internal/impersonation"
+	"call-service/internal/mocks"
+	"call-service/internal/tenant"
+	"call-service/pkg/authclient"
+	"common/clock"
+)
+
+// setupAuthRouter настраивает маршрутизатор с обязательной (/private), необязательной (/public)
+// и отложенной (/deferred) аутентификацией. Маршруты возвращают ID пользователя или "anonymous";
+// /deferred без токена в запросе проверяет токен из параметра token методом Authenticate.
+
+func setupAuthRouter(m *AuthMiddleware) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	whoami := func(c *gin.Context) {
+		userID, ok := GetUserID(c)
+		if !ok {
+			c.String(http.StatusOK, "anonymous")
+			return
+		}
+		c.String(http.StatusOK, userID.String())
+	}
+	router.GET("/private", m.AuthRequired(), whoami)
+	router.GET("/public", m.OptionalAuth(), whoami)
+	router.GET("/deferred", m.DeferredAuth(), func(c *gin.Context) {
+		if _, ok := GetPrincipal(c); !ok && c.Query("token") != "" {
+			if err := m.Authenticate(c, c.Query("token")); err != nil {
+				c.String(http.StatusOK, string(err.(*i18n.Error).Code))
+				return
+			}
+		}
+		whoami(c)
+	})
+	return router
+}
+
+// doAuthRequest выполняет запрос с заданными заголовком Authorization и cookie access_token
+// (пустое значение — без заголовка или cookie).
+
+func doAuthRequest(router *gin.Engine, path, header, cookie string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	if header != "" {
+		req.Header.Set("Authorization", header)
+	}
+	if cookie != "" {
+		req.Header.Set("Cookie", AccessTokenCookie+"="+cookie)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// expectToken настраивает проверку токена, принадлежащего пользователю userID.
+
+func expectToken(m *mocks.MockAuthClient, token string, userID uuid.UUID) {
+	m.EXPECT().ValidateTokenFull(gomock.Any(), token).
+		Return(&authclient.TokenInfo{Valid: true, UserID: userID.String(), Role: RoleUser}, nil).AnyTimes()
+}
+
+// TestAuthRequired_TokenSources проверяет, что заголовок Authorization имеет приоритет над cookie,
+// а cookie используется, если заголовка нет.
+
+func TestAuthRequired_TokenSources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	headerUser, cookieUser := uuid.New(), uuid.New()
+	expectToken(mockAuthClient, "header.token", headerUser)
+	expectToken(mockAuthClient, "cookie.token", cookieUser)
+	router := setupAuthRouter(NewAuthMiddleware(mockAuthClient))
+
+	w := doAuthRequest(router, "/private", "Bearer header.token", "cookie.token")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, headerUser.String(), w.Body.String())
+
+	w = doAuthRequest(router, "/private", "", "cookie.token")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, cookieUser.String(), w.Body.String())
+
+	// Некорректный заголовок не заменяется cookie
+	w = doAuthRequest(router, "/private", "Token header.token", "cookie.token")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{"error":"invalid authorization header format","code":"malformed_authorization_header"}`, w.Body.String())
+
+	w = doAuthRequest(router, "/private", "", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// При обратном порядке источников cookie имеет приоритет над заголовком
+	router = setupAuthRouter(NewAuthMiddlewareWithConfig(mockAuthClient, AuthConfig{
+		TokenSources: []TokenSource{TokenSourceCookie, TokenSourceHeader},
+	}))
+	w = doAuthRequest(router, "/private", "Bearer header.token", "cookie.token")
+	assert.Equal(t, cookieUser.String(), w.Body.String())
+
+	// Источник, не включенный в конфигурацию, не используется
+	router = setupAuthRouter(NewAuthMiddlewareWithConfig(mockAuthClient, AuthConfig{
+		TokenSources: []TokenSource{TokenSourceHeader},
+	}))
+	assert.Equal(t, http.StatusUnauthorized, doAuthRequest(router, "/private", "", "cookie.token").Code)
+}
+
+// TestAuthRequired_MalformedCookie проверяет, что cookie с недопустимым для JWT значением
+// отклоняется без обращения к сервису аутентификации.
+
+func TestAuthRequired_MalformedCookie(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	router := setupAuthRouter(NewAuthMiddleware(mockAuthClient))
+
+	for _, cookie := range []string{`""`, "not a token", "a.b.c%00", "a.b,c"} {
+		w := doAuthRequest(router, "/private", "", cookie)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, cookie)
+		assert.JSONEq(t, `{"error":"invalid access token cookie","code":"malformed_token_cookie"}`, w.Body.String(), cookie)
+	}
+
+	// Недействительный токен правильного формата проверяется сервисом аутентификации
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "expired.token").Return(&authclient.TokenInfo{Valid: false}, nil)
+	w := doAuthRequest(router, "/private", "", "expired.token")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{"error":"invalid token","code":"invalid_token"}`, w.Body.String())
+}
+
+// TestAuthRequired_AuthorizationHeader проверяет разбор заголовка Authorization: схема
+// сравнивается без учета регистра, лишние пробелы допускаются, а код ошибки различает
+// отсутствующий заголовок, неверный формат, слишком длинный токен, недействительный токен
+// и недоступность сервиса аутентификации.
+
+func TestAuthRequired_AuthorizationHeader(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	userID := uuid.New()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "good.token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: userID.String(), Role: RoleUser}, nil).AnyTimes()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "revoked.token").
+		Return(&authclient.TokenInfo{Valid: false}, nil).AnyTimes()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "down.token").
+		Return(nil, status.Error(codes.Unavailable, "connection refused")).AnyTimes()
+	router := setupAuthRouter(NewAuthMiddleware(mockAuthClient))
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+		code   string
+	}{
+		{name: "стандартный", header: "Bearer good.token", status: http.StatusOK},
+		{name: "схема в нижнем регистре", header: "bearer good.token", status: http.StatusOK},
+		{name: "схема в верхнем регистре", header: "BEARER good.token", status: http.StatusOK},
+		{name: "двойной пробел", header: "Bearer  good.token", status: http.StatusOK},
+		{name: "табуляция", header: "Bearer\tgood.token", status: http.StatusOK},
+		{name: "пробелы по краям", header: "  Bearer good.token  ", status: http.StatusOK},
+		{name: "нет заголовка", header: "", status: http.StatusUnauthorized, code: "token_required"},
+		{name: "только пробелы", header: "   ", status: http.StatusUnauthorized, code: "malformed_authorization_header"},
+		{name: "нет токена", header: "Bearer", status: http.StatusUnauthorized, code: "malformed_authorization_header"},
+		{name: "другая схема", header: "Basic dXNlcjpwYXNz", status: http.StatusUnauthorized, code: "malformed_authorization_header"},
+		{name: "лишняя часть", header: "Bearer good.token extra", status: http.StatusUnauthorized, code: "malformed_authorization_header"},
+		{name: "слишком длинный токен", header: "Bearer " + strings.Repeat("a", MaxTokenLength+1), status: http.StatusUnauthorized, code: "token_too_long"},
+		{name: "недействительный токен", header: "Bearer revoked.token", status: http.StatusUnauthorized, code: "invalid_token"},
+		{name: "сервис недоступен", header: "Bearer down.token", status: http.StatusServiceUnavailable, code: "auth_unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doAuthRequest(router, "/private", tt.header, "")
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.code == "" {
+				assert.Equal(t, userID.String(), w.Body.String())
+				return
+			}
+			var response map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.code, response["code"])
+		})
+	}
+}
+
+// fakeAPIKeys сопоставляет API-ключи с владельцами.
+
+type fakeAPIKeys map[string]uuid.UUID
+
+func (f fakeAPIKeys) ResolveAPIKey(_ context.Context, key string) (uuid.UUID, error) {
+	userID, ok := f[key]
+	if !ok {
+		return uuid.Nil, errors.New("unknown key")
+	}
+	return userID, nil
+}
+
+// TestAuthRequired_APIKey проверяет аутентификацию по заголовку X-API-Key: владелец ключа
+// сохраняется так же, как пользователь токена, с ролью обычного пользователя, а заголовок
+// Authorization имеет приоритет над ключом.
+
+func TestAuthRequired_APIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	headerUser, keyOwner := uuid.New(), uuid.New()
+	expectToken(mockAuthClient, "header.token", headerUser)
+	m := NewAuthMiddlewareWithConfig(mockAuthClient, AuthConfig{APIKeys: fakeAPIKeys{"csk_key": keyOwner}})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/whoami", m.AuthRequired(), func(c *gin.Context) {
+		principal, _ := GetPrincipal(c)
+		c.JSON(http.StatusOK, gin.H{"user_id": principal.UserID, "role": principal.Role,
+			"session_id": principal.SessionID, "auth_method": principal.AuthMethod})
+	})
+	do := func(authorization, apiKey string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/whoami", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		req.Header.Set(APIKeyHeader, apiKey)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("", "csk_key")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"user_id":"`+keyOwner.String()+`","role":"user","session_id":"","auth_method":"api_key"}`, w.Body.String())
+
+	w = do("Bearer header.token", "csk_key")
+	assert.Contains(t, w.Body.String(), headerUser.String())
+
+	w = do("", "csk_unknown")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{"error":"invalid API key","code":"invalid_api_key"}`, w.Body.String())
+
+	// Без APIKeys в конфигурации заголовок X-API-Key не принимается
+	router = setupAuthRouter(NewAuthMiddleware(mockAuthClient))
+	req, _ := http.NewRequest("GET", "/private", nil)
+	req.Header.Set(APIKeyHeader, "csk_key")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestOptionalAuth проверяет, что необязательная аутентификация сохраняет пользователя
+// действительного токена и не прерывает запрос без токена или с недействительным токеном.
+
+func TestOptionalAuth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	userID := uuid.New()
+	expectToken(mockAuthClient, "valid.token", userID)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "invalid.token").Return(&authclient.TokenInfo{Valid: false}, nil)
+	router := setupAuthRouter(NewAuthMiddleware(mockAuthClient))
+
+	tests := []struct {
+		name   string
+		header string
+		cookie string
+		want   string
+	}{
+		{"header", "Bearer valid.token", "", userID.String()},
+		{"cookie", "", "valid.token", userID.String()},
+		{"no token", "", "", "anonymous"},
+		{"invalid token", "Bearer invalid.token", "", "anonymous"},
+		{"malformed header", "Bearer", "valid.token", "anonymous"},
+		{"malformed cookie", "", "not a token", "anonymous"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doAuthRequest(router, "/public", tt.header, tt.cookie)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.want, w.Body.String())
+		})
+	}
+}
+
+// TestDeferredAuth проверяет, что отложенная аутентификация отклоняет недействительный токен
+// в запросе, пропускает запрос без токена, а Authenticate проверяет токен, переданный позже.
+
+func TestDeferredAuth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	userID := uuid.New()
+	expectToken(mockAuthClient, "valid.token", userID)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "invalid.token").Return(&authclient.TokenInfo{Valid: false}, nil).AnyTimes()
+	router := setupAuthRouter(NewAuthMiddleware(mockAuthClient))
+
+	w := doAuthRequest(router, "/deferred", "Bearer valid.token", "")
+	assert.Equal(t, userID.String(), w.Body.String())
+	w = doAuthRequest(router, "/deferred", "Bearer invalid.token", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doAuthRequest(router, "/deferred", "", "")
+	assert.Equal(t, "anonymous", w.Body.String())
+
+	w = doAuthRequest(router, "/deferred?token=valid.token", "", "")
+	assert.Equal(t, userID.String(), w.Body.String())
+	w = doAuthRequest(router, "/deferred?token=invalid.token", "", "")
+	assert.Equal(t, string(i18n.InvalidToken), w.Body.String())
+}
+
+// TestParseTokenSources проверяет разбор порядка источников токена из конфигурации.
+
+func TestParseTokenSources(t *testing.T) {
+	sources, err := ParseTokenSources(nil)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTokenSources, sources)
+
+	sources, err = ParseTokenSources([]string{"Cookie", " header ", "api_key"})
+	require.NoError(t, err)
+	assert.Equal(t, []TokenSource{TokenSourceCookie, TokenSourceHeader, TokenSourceAPIKey}, sources)
+
+	_, err = ParseTokenSources([]string{"query"})
+	assert.Error(t, err)
+}
+
+// TestAuthRequired_Principal проверяет, что AuthRequired сохраняет все данные токена в Principal,
+// а функции GetUserID, GetRole и GetSessionID возвращают те же значения.
+
+func TestAuthRequired_Principal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	userID := uuid.New()
+	expiresAt := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "admin.token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: userID.String(), Role: RoleAdmin, SessionID: "session-1", ExpiresAt: expiresAt}, nil)
+
+	var principal Principal
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/private", NewAuthMiddleware(mockAuthClient).AuthRequired(), func(c *gin.Context) {
+		var ok bool
+		principal, ok = GetPrincipal(c)
+		require.True(t, ok)
+		gotUserID, _ := GetUserID(c)
+		assert.Equal(t, principal.UserID, gotUserID)
+		assert.Equal(t, principal.Role, GetRole(c))
+		assert.Equal(t, principal.SessionID, GetSessionID(c))
+		c.Status(http.StatusOK)
+	})
+
+	w := doAuthRequest(router, "/private", "Bearer admin.token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, Principal{
+		UserID:         userID,
+		Role:           RoleAdmin,
+		AuthMethod:     AuthMethodJWT,
+		SessionID:      "session-1",
+		TokenExpiresAt: expiresAt,
+	}, principal)
+}
+
+// degradedCount возвращает значение показателя режима деградации.
+
+func degradedCount(name string) int64 {
+	if v, ok := degradedAuth.Get(name).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+// TestAuthRequired_StaleWhileError моделирует недоступность сервиса аутентификации: недавно
+// проверенный токен принимается с признаком Degraded до истечения StaleTTL, а токены,
+// отклоненные сервисом, и токены без успешной проверки — нет.
+
+func TestAuthRequired_StaleWhileError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	userID := uuid.New()
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	valid := &authclient.TokenInfo{Valid: true, UserID: userID.String(), Role: RoleUser}
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "good.token").Return(valid, nil)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "good.token").Return(nil, unavailable).AnyTimes()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "revoked.token").Return(valid, nil)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "revoked.token").Return(&authclient.TokenInfo{Valid: false}, nil)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "revoked.token").Return(nil, unavailable).AnyTimes()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "new.token").Return(nil, unavailable).AnyTimes()
+
+	fake := clock.NewFake(time.Now())
+	m := NewAuthMiddlewareWithConfig(mockAuthClient, AuthConfig{StaleTTL: 30 * time.Second, Clock: fake})
+	var principal Principal
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/private", m.AuthRequired(), func(c *gin.Context) {
+		principal, _ = GetPrincipal(c)
+		c.Status(http.StatusOK)
+	})
+
+	require.Equal(t, http.StatusOK, doAuthRequest(router, "/private", "Bearer good.token", "").Code)
+	assert.False(t, principal.Degraded)
+	require.Equal(t, http.StatusOK, doAuthRequest(router, "/private", "Bearer revoked.token", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, doAuthRequest(router, "/private", "Bearer revoked.token", "").Code)
+
+	// Сервис аутентификации недоступен
+	served, rejected := degradedCount("served"), degradedCount("rejected")
+	fake.Advance(29 * time.Second)
+	w := doAuthRequest(router, "/private", "Bearer good.token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, userID, principal.UserID)
+	assert.True(t, principal.Degraded)
+	assert.Equal(t, http.StatusServiceUnavailable, doAuthRequest(router, "/private", "Bearer revoked.token", "").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, doAuthRequest(router, "/private", "Bearer new.token", "").Code)
+
+	fake.Advance(time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, doAuthRequest(router, "/private", "Bearer good.token", "").Code)
+	assert.Equal(t, served+1, degradedCount("served"))
+	assert.Equal(t, rejected+3, degradedCount("rejected"))
+
+	// По умолчанию режим деградации отключен
+	router = setupAuthRouter(NewAuthMiddleware(mockAuthClient))
+	assert.Equal(t, http.StatusServiceUnavailable, doAuthRequest(router, "/private", "Bearer good.token", "").Code)
+}
+
+// TestAuthRequired_StaleDisabledByFlag проверяет, что выключенный флаг
+// featureflags.DegradedAuth отключает режим деградации при заданном StaleTTL.
+
+func TestAuthRequired_StaleDisabledByFlag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "good.token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: uuid.NewString()}, nil)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "good.token").
+		Return(nil, status.Error(codes.Unavailable, "connection refused")).AnyTimes()
+	router := setupAuthRouter(NewAuthMiddlewareWithConfig(mockAuthClient, AuthConfig{
+		StaleTTL: time.Minute,
+		Flags:    featureflags.Static{featureflags.DegradedAuth: false},
+	}))
+
+	require.Equal(t, http.StatusOK, doAuthRequest(router, "/private", "Bearer good.token", "").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, doAuthRequest(router, "/private", "Bearer good.token", "").Code)
+}
+
+// TestAuthRequired_StaleTokenExpired проверяет, что по кэшированной проверке не принимается
+// токен с истекшим сроком действия.
+
+func TestAuthRequired_StaleTokenExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	fake := clock.NewFake(time.Now())
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "short.token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: uuid.NewString(), ExpiresAt: fake.Now().Add(10 * time.Second)}, nil)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "short.token").
+		Return(nil, status.Error(codes.DeadlineExceeded, "deadline exceeded")).AnyTimes()
+	router := setupAuthRouter(NewAuthMiddlewareWithConfig(mockAuthClient, AuthConfig{StaleTTL: time.Minute, Clock: fake}))
+
+	require.Equal(t, http.StatusOK, doAuthRequest(router, "/private", "Bearer short.token", "").Code)
+	fake.Advance(5 * time.Second)
+	assert.Equal(t, http.StatusOK, doAuthRequest(router, "/private", "Bearer short.token", "").Code)
+	fake.Advance(5 * time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, doAuthRequest(router, "/private", "Bearer short.token", "").Code)
+}
+
+// TestAuthRequired_StaleTokenExpiresIn проверяет, что срок действия кэшированной проверки
+// отсчитывается по оставшемуся времени, сообщенному сервисом аутентификации, а не по сроку
+// из токена: часы сервиса аутентификации отстают на минуту.
+
+func TestAuthRequired_StaleTokenExpiresIn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	fake := clock.NewFake(time.Now())
+	info := &authclient.TokenInfo{
+		Valid:     true,
+		UserID:    uuid.NewString(),
+		ExpiresAt: fake.Now().Add(-time.Minute + 10*time.Second),
+		ExpiresIn: 10 * time.Second,
+	}
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "skewed.token").Return(info, nil)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "skewed.token").
+		Return(nil, status.Error(codes.Unavailable, "connection refused")).AnyTimes()
+	m := NewAuthMiddlewareWithConfig(mockAuthClient, AuthConfig{StaleTTL: time.Minute, Clock: fake})
+
+	var principal Principal
+	router := gin.New()
+	router.GET("/private", m.AuthRequired(), func(c *gin.Context) {
+		principal, _ = GetPrincipal(c)
+		c.Status(http.StatusOK)
+	})
+	require.Equal(t, http.StatusOK, doAuthRequest(router, "/private", "Bearer skewed.token", "").Code)
+	assert.Equal(t, fake.Now().Add(10*time.Second), principal.TokenExpiresAt)
+
+	fake.Advance(5 * time.Second)
+	assert.Equal(t, http.StatusOK, doAuthRequest(router, "/private", "Bearer skewed.token", "").Code)
+	assert.True(t, principal.Degraded)
+	fake.Advance(5 * time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, doAuthRequest(router, "/private", "Bearer skewed.token", "").Code)
+}
+
+// TestAuthRequired_InvalidateUser проверяет, что после InvalidateUser кэшированные проверки
+// токенов пользователя не используются при недоступности сервиса аутентификации, а проверки
+// других пользователей сохраняются.
+
+func TestAuthRequired_InvalidateUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	userID, otherID := uuid.New(), uuid.New()
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	for token, id := range map[string]uuid.UUID{"laptop.token": userID, "phone.token": userID, "other.token": otherID} {
+		mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), token).Return(&authclient.TokenInfo{Valid: true, UserID: id.String()}, nil)
+		mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), token).Return(nil, unavailable).AnyTimes()
+	}
+	m := NewAuthMiddlewareWithConfig(mockAuthClient, AuthConfig{StaleTTL: time.Minute})
+	router := setupAuthRouter(m)
+
+	for _, token := range []string{"laptop.token", "phone.token", "other.token"} {
+		require.Equal(t, http.StatusOK, doAuthRequest(router, "/private", "Bearer "+token, "").Code)
+	}
+	m.InvalidateUser(userID)
+	assert.Equal(t, http.StatusServiceUnavailable, doAuthRequest(router, "/private", "Bearer laptop.token", "").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, doAuthRequest(router, "/private", "Bearer phone.token", "").Code)
+	assert.Equal(t, http.StatusOK, doAuthRequest(router, "/private", "Bearer other.token", "").Code)
+
+	// Без режима деградации InvalidateUser ничего не делает
+	NewAuthMiddleware(mockAuthClient).InvalidateUser(userID)
+}
+
+// TestAuthRequired_StaleSharedCache проверяет, что с общим кэшем в Redis токен, проверенный
+// одним экземпляром сервиса, принимается другим при недоступности сервиса аутентификации,
+// InvalidateUser на одном экземпляре действует на оба, а при недоступном Redis кэш проверок
+// продолжает работать в памяти процесса.
+
+func TestAuthRequired_StaleSharedCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	userID := uuid.New()
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	gomock.InOrder(
+		mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "good.token").
+			Return(&authclient.TokenInfo{Valid: true, UserID: userID.String()}, nil),
+		mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "good.token").Return(nil, unavailable).Times(2),
+		mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "good.token").
+			Return(&authclient.TokenInfo{Valid: true, UserID: userID.String()}, nil),
+		mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "good.token").Return(nil, unavailable).AnyTimes(),
+	)
+	server := miniredis.RunT(t)
+	redisStore, err := cache.NewRedis(cache.RedisConfig{Addr: server.Addr(), Timeout: 100 * time.Millisecond})
+	require.NoError(t, err)
+	defer redisStore.Close()
+	newInstance := func() (*AuthMiddleware, *gin.Engine) {
+		m := NewAuthMiddlewareWithConfig(mockAuthClient, AuthConfig{
+			StaleTTL: time.Minute,
+			Cache:    cache.WithFallback("test_auth", redisStore, cache.NewMemory(0, nil)),
+		})
+		return m, setupAuthRouter(m)
+	}
+	first, firstRouter := newInstance()
+	_, secondRouter := newInstance()
+
+	require.Equal(t, http.StatusOK, doAuthRequest(firstRouter, "/private", "Bearer good.token", "").Code)
+	assert.Equal(t, http.StatusOK, doAuthRequest(secondRouter, "/private", "Bearer good.token", "").Code)
+	first.InvalidateUser(userID)
+	assert.Equal(t, http.StatusServiceUnavailable, doAuthRequest(secondRouter, "/private", "Bearer good.token", "").Code)
+
+	server.Close()
+	require.Equal(t, http.StatusOK, doAuthRequest(firstRouter, "/private", "Bearer good.token", "").Code)
+	assert.Equal(t, http.StatusOK, doAuthRequest(firstRouter, "/private", "Bearer good.token", "").Code)
+}
+
+// TestAuthRequired_Organizations проверяет, что организация из токена сохраняется в Principal
+// и в контексте запроса, только если организации включены.
+
+func TestAuthRequired_Organizations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	userID, orgID := uuid.New(), uuid.New()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "member.token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: userID.String(), OrgID: orgID.String(), OrgRole: tenant.RoleOwner}, nil).AnyTimes()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "broken.token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: userID.String(), OrgID: "not-a-uuid"}, nil).AnyTimes()
+
+	tests := []struct {
+		name          string
+		organizations bool
+		token         string
+		wantCode      int
+		wantOrg       bool
+	}{
+		{"enabled", true, "member.token", http.StatusOK, true},
+		{"disabled", false, "member.token", http.StatusOK, false},
+		{"invalid organization ID", true, "broken.token", http.StatusUnauthorized, false},
+		{"invalid organization ID ignored when disabled", false, "broken.token", http.StatusOK, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var principal Principal
+			var membership tenant.Membership
+			var hasMembership bool
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			m := NewAuthMiddlewareWithConfig(mockAuthClient, AuthConfig{Organizations: tt.organizations})
+			router.GET("/private", m.AuthRequired(), func(c *gin.Context) {
+				principal, _ = GetPrincipal(c)
+				membership, hasMembership = tenant.FromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			w := doAuthRequest(router, "/private", "Bearer "+tt.token, "")
+			require.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantOrg, hasMembership)
+			if tt.wantOrg {
+				assert.Equal(t, orgID, principal.OrgID)
+				assert.Equal(t, tenant.RoleOwner, principal.OrgRole)
+				assert.Equal(t, tenant.Membership{OrgID: orgID, Role: tenant.RoleOwner}, membership)
+			} else {
+				assert.Equal(t, uuid.Nil, principal.OrgID)
+			}
+		})
+	}
+}
+
+// TestAuthRequired_Impersonation проверяет токен, выпущенный администратору для работы от имени
+// пользователя: запрос выполняется от имени пользователя, ID администратора сохраняется
+// в Principal и контексте запроса и записывается в журнал запросов; токен с некорректным
+// ID администратора отклоняется.
+
+func TestAuthRequired_Impersonation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthClient := mocks.NewMockAuthClient(ctrl)
+	userID, adminID := uuid.New(), uuid.New()
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "impersonation.token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: userID.String(), Role: RoleUser, ImpersonatedBy: adminID.String()}, nil)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "user.token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: userID.String(), Role: RoleUser}, nil)
+	mockAuthClient.EXPECT().ValidateTokenFull(gomock.Any(), "broken.token").
+		Return(&authclient.TokenInfo{Valid: true, UserID: userID.String(), ImpersonatedBy: "not-a-uuid"}, nil)
+
+	var buf bytes.Buffer
+	var principal Principal
+	var impersonator uuid.UUID
+	var impersonated bool
+	var token string
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	router.GET("/private", AccessLog(AccessLogConfig{Logger: logger}), NewAuthMiddleware(mockAuthClient).AuthRequired(), func(c *gin.Context) {
+		principal, _ = GetPrincipal(c)
+		impersonator, impersonated = impersonation.FromContext(c.Request.Context())
+		token = GetAccessToken(c)
+		c.Status(http.StatusOK)
+	})
+
+	w := doAuthRequest(router, "/private", "Bearer impersonation.token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, userID, principal.UserID)
+	assert.Equal(t, adminID, principal.ImpersonatedBy)
+	assert.True(t, impersonated)
+	assert.Equal(t, adminID, impersonator)
+	assert.Equal(t, "impersonation.token", token)
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, adminID.String(), entries[0]["impersonated_by"])
+
+	w = doAuthRequest(router, "/private", "Bearer user.token", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, uuid.Nil, principal.ImpersonatedBy)
+	assert.False(t, impersonated)
+
+	w = doAuthRequest(router, "/private", "Bearer broken.token", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
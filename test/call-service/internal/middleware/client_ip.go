@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/netip"
+
+	"github.com/gin-gonic/gin"
+
+	"common/clientip"
+)
+
+// clientIPKey — ключ контекста gin, под которым TrustProxies сохраняет IP клиента.
+const clientIPKey = "client_ip"
+
+// TrustProxies возвращает middleware, которое определяет IP клиента и сохраняет его для ClientIP.
+// Адрес определяется по правилу крайнего правого недоверенного адреса (см. пакет clientip):
+// X-Forwarded-For учитывается только от прокси из proxies.
+
+func TrustProxies(proxies []netip.Prefix) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(clientIPKey, clientip.FromRequest(c.Request, proxies))
+		c.Next()
+	}
+}
+
+// ClientIP возвращает IP клиента, определенный TrustProxies. Без TrustProxies прокси
+// не доверяют, и возвращается адрес соединения.
+
+func ClientIP(c *gin.Context) string {
+	if ip := c.GetString(clientIPKey); ip != "" {
+		return ip
+	}
+	return clientip.FromRequest(c.Request, nil)
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"common/clientip"
+)
+
+// TestClientIP проверяет, что TrustProxies учитывает X-Forwarded-For только от доверенных прокси;
+// само правило определения адреса проверяется в пакете clientip.
+
+func TestClientIP(t *testing.T) {
+	proxies, err := clientip.ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	require.NoError(t, err)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(TrustProxies(proxies))
+	router.GET("/ip", func(c *gin.Context) {
+		c.String(http.StatusOK, ClientIP(c))
+	})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		want       string
+	}{
+		{name: "прямое соединение", remoteAddr: "203.0.113.7:5000", want: "203.0.113.7"},
+		{name: "через доверенный прокси", remoteAddr: "10.0.0.1:5000", xff: []string{"203.0.113.7"}, want: "203.0.113.7"},
+		{name: "подмена от недоверенного источника", remoteAddr: "198.51.100.2:5000", xff: []string{"203.0.113.7"}, want: "198.51.100.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.xff {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Body.String())
+		})
+	}
+}
+
+// TestClientIP_WithoutTrustProxies проверяет, что без TrustProxies X-Forwarded-For не учитывается.
+
+func TestClientIP_WithoutTrustProxies(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.RemoteAddr = "10.0.0.1:5000"
+	c.Request.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+	assert.Equal(t, "10.0.0.1", ClientIP(c))
+}
@@ -0,0 +1,246 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultCompressMinSize — минимальный размер ответа в байтах, начиная с которого он сжимается.
+const DefaultCompressMinSize = 1024
+
+// CompressConfig содержит параметры middleware сжатия ответов.
+type CompressConfig struct {
+	// MinSize — минимальный размер тела ответа для сжатия. Ответы меньшего размера
+	// отправляются как есть, потому что накладные расходы сжатия для них не окупаются.
+	MinSize int
+	// Level — уровень сжатия gzip/deflate; 0 означает уровень по умолчанию.
+	Level int
+}
+
+// compressibleTypes перечисляет типы содержимого, которые имеет смысл сжимать.
+// text/event-stream сюда намеренно не входит: потоковые события не должны буферизоваться.
+var compressibleTypes = map[string]bool{
+	"application/json": true,
+	"text/csv":         true,
+}
+
+// Compress возвращает middleware, сжимающее JSON и CSV ответы с учетом заголовка Accept-Encoding.
+//
+// Тело ответа буферизуется до достижения порога MinSize; если обработчик завершился раньше,
+// ответ отправляется без сжатия. Потоковые обработчики, вызывающие Flush, сжимаются сразу,
+// а каждый Flush сбрасывает накопленный сжатый блок клиенту. Ответы, для которых обработчик
+// сам установил Content-Encoding, не изменяются.
+func Compress(cfg CompressConfig) gin.HandlerFunc {
+	if cfg.MinSize <= 0 {
+		cfg.MinSize = DefaultCompressMinSize
+	}
+	if cfg.Level == 0 {
+		cfg.Level = gzip.DefaultCompression
+	}
+	pools := map[string]*sync.Pool{
+		"gzip": {New: func() any {
+			w, _ := gzip.NewWriterLevel(io.Discard, cfg.Level)
+			return w
+		}},
+		"deflate": {New: func() any {
+			w, _ := flate.NewWriter(io.Discard, cfg.Level)
+			return w
+		}},
+	}
+
+	return func(c *gin.Context) {
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{
+			ResponseWriter: c.Writer,
+			encoding:       encoding,
+			minSize:        cfg.MinSize,
+			pool:           pools[encoding],
+		}
+		c.Writer = w
+		defer w.finish()
+
+		c.Next()
+	}
+}
+
+// compressWriter буферизует ответ до принятия решения о сжатии и затем пишет
+// в исходный ResponseWriter либо напрямую, либо через gzip/deflate кодировщик.
+type compressWriter struct {
+	gin.ResponseWriter
+
+	encoding string
+	minSize  int
+	pool     *sync.Pool
+
+	buf     bytes.Buffer
+	decided bool
+	enc     resettableWriter
+}
+
+// resettableWriter — общий интерфейс gzip.Writer и flate.Writer.
+type resettableWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Write буферизует данные до достижения порога, после чего принимает решение о сжатии.
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		return w.writeOut(data)
+	}
+	w.buf.Write(data)
+	if w.buf.Len() >= w.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// WriteString реализует gin.ResponseWriter через Write.
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written сообщает, начата ли запись ответа, с учетом буферизованных данных.
+func (w *compressWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// Flush принимает решение о сжатии без учета порога и сбрасывает сжатый блок клиенту.
+// Вызывается потоковыми обработчиками на границах фрагментов.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(true); err != nil {
+			return
+		}
+	}
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Hijack передает управление соединением исходному ResponseWriter.
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	return w.ResponseWriter.Hijack()
+}
+
+// decide выбирает режим записи и отправляет буферизованные данные.
+// При allowCompress == false ответ всегда отправляется без сжатия.
+func (w *compressWriter) decide(allowCompress bool) error {
+	w.decided = true
+	header := w.Header()
+
+	if w.compressible() {
+		header.Add("Vary", "Accept-Encoding")
+		if allowCompress {
+			header.Set("Content-Encoding", w.encoding)
+			header.Del("Content-Length")
+			enc := w.pool.Get().(resettableWriter)
+			enc.Reset(w.ResponseWriter)
+			w.enc = enc
+		}
+	}
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.writeOut(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// compressible сообщает, подходит ли ответ для сжатия по статусу и заголовкам.
+func (w *compressWriter) compressible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || w.ResponseWriter.Written() {
+		return false
+	}
+	switch status := w.Status(); {
+	case status < http.StatusOK, status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return compressibleTypes[mediaType]
+}
+
+func (w *compressWriter) writeOut(data []byte) (int, error) {
+	if w.enc != nil {
+		return w.enc.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// finish завершает ответ после выполнения обработчика: отправляет короткие ответы
+// без сжатия и закрывает кодировщик, возвращая его в пул.
+func (w *compressWriter) finish() {
+	if !w.decided {
+		_ = w.decide(w.buf.Len() >= w.minSize)
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+		w.enc.Reset(io.Discard)
+		w.pool.Put(w.enc)
+		w.enc = nil
+	}
+}
+
+// negotiateEncoding выбирает кодировку ответа по заголовку Accept-Encoding.
+// Возвращает "gzip", "deflate" или пустую строку, если клиент не принимает сжатие
+// (в том числе при явном Accept-Encoding: identity). При равных весах предпочитается gzip.
+func negotiateEncoding(header string) string {
+	if header == "" {
+		return ""
+	}
+	weights := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		weights[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range []string{"gzip", "deflate"} {
+		q, ok := weights[encoding]
+		if !ok {
+			q, ok = weights["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
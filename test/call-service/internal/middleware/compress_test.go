@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+)
+
+// makeCalls создает список заявок заданного размера для проверки сжатия.
+
+func makeCalls(n int) []*model.Call {
+	userID := uuid.New()
+	calls := make([]*model.Call, n)
+	for i := range calls {
+		calls[i] = &model.Call{
+			ID:          uuid.New(),
+			ClientName:  fmt.Sprintf("Client %d", i),
+			PhoneNumber: fmt.Sprintf("+7900%07d", i),
+			Description: "Клиент сообщает о проблеме с подключением услуги",
+			Status:      "open",
+			CreatedAt:   time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC).Add(time.Duration(i) * time.Minute),
+			UserID:      userID,
+		}
+	}
+	return calls
+}
+
+// setupCompressRouter настраивает маршрутизатор с middleware сжатия и тестовыми маршрутами.
+
+func setupCompressRouter(calls []*model.Call) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Compress(CompressConfig{MinSize: 1024}))
+	router.GET("/calls", func(c *gin.Context) {
+		c.JSON(http.StatusOK, calls)
+	})
+	router.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "ok"})
+	})
+	router.GET("/encoded", func(c *gin.Context) {
+		c.Header("Content-Encoding", "br")
+		c.Data(http.StatusOK, "application/json", bytes.Repeat([]byte("x"), 4096))
+	})
+	router.GET("/events", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/event-stream", bytes.Repeat([]byte("data: x\n\n"), 1024))
+	})
+	return router
+}
+
+func doGet(router *gin.Engine, path, acceptEncoding string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestCompress_Gzip проверяет сжатие большого списка заявок и корректность распаковки.
+
+func TestCompress_Gzip(t *testing.T) {
+	calls := makeCalls(100)
+	router := setupCompressRouter(calls)
+
+	w := doGet(router, "/calls", "gzip, deflate")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Contains(t, w.Header().Values("Vary"), "Accept-Encoding")
+
+	reader, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+
+	var response []*model.Call
+	require.NoError(t, json.Unmarshal(body, &response))
+	assert.Len(t, response, len(calls))
+}
+
+// TestCompress_Deflate проверяет выбор deflate, если клиент предпочитает его.
+
+func TestCompress_Deflate(t *testing.T) {
+	router := setupCompressRouter(makeCalls(100))
+
+	w := doGet(router, "/calls", "gzip;q=0.5, deflate")
+
+	assert.Equal(t, "deflate", w.Header().Get("Content-Encoding"))
+	body, err := io.ReadAll(flate.NewReader(w.Body))
+	require.NoError(t, err)
+	assert.True(t, json.Valid(body))
+}
+
+// TestCompress_IdentityHonored проверяет, что Accept-Encoding: identity и нулевой вес
+// gzip отключают сжатие.
+
+func TestCompress_IdentityHonored(t *testing.T) {
+	calls := makeCalls(100)
+	router := setupCompressRouter(calls)
+	want, _ := json.Marshal(calls)
+
+	for _, acceptEncoding := range []string{"", "identity", "gzip;q=0, identity", "*;q=0"} {
+		w := doGet(router, "/calls", acceptEncoding)
+
+		assert.Equal(t, http.StatusOK, w.Code, acceptEncoding)
+		assert.Empty(t, w.Header().Get("Content-Encoding"), acceptEncoding)
+		assert.JSONEq(t, string(want), w.Body.String(), acceptEncoding)
+	}
+}
+
+// TestCompress_SkipsSmallResponses проверяет, что ответы меньше порога не сжимаются.
+
+func TestCompress_SkipsSmallResponses(t *testing.T) {
+	router := setupCompressRouter(nil)
+
+	w := doGet(router, "/small", "gzip")
+
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.JSONEq(t, `{"message":"ok"}`, w.Body.String())
+}
+
+// TestCompress_RespectsExistingEncoding проверяет, что уже закодированный ответ не сжимается повторно,
+// а потоковые события (SSE) передаются без сжатия.
+
+func TestCompress_RespectsExistingEncoding(t *testing.T) {
+	router := setupCompressRouter(nil)
+
+	w := doGet(router, "/encoded", "gzip")
+	assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, 4096, w.Body.Len())
+
+	w = doGet(router, "/events", "gzip")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.True(t, strings.HasPrefix(w.Body.String(), "data: x"))
+}
+
+// TestCompress_StreamingFlush проверяет, что потоковый обработчик (например, экспорт CSV)
+// получает сжатые фрагменты на каждом Flush, не дожидаясь конца ответа.
+
+func TestCompress_StreamingFlush(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Compress(CompressConfig{MinSize: 1024}))
+
+	w := httptest.NewRecorder()
+	var firstChunk []byte
+	router.GET("/export", func(c *gin.Context) {
+		c.Header("Content-Type", "text/csv")
+		c.Status(http.StatusOK)
+		_, _ = c.Writer.WriteString("id,client_name\n")
+		c.Writer.Flush()
+
+		// После Flush клиент уже должен получить первый фрагмент целиком
+		reader, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+		if err == nil {
+			firstChunk, _ = io.ReadAll(reader)
+		}
+
+		_, _ = c.Writer.WriteString("1,John Doe\n")
+		c.Writer.Flush()
+	})
+
+	req, _ := http.NewRequest("GET", "/export", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.True(t, w.Flushed)
+	assert.Equal(t, "id,client_name\n", string(firstChunk))
+
+	reader, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "id,client_name\n1,John Doe\n", string(body))
+}
+
+// BenchmarkCompress_5kCalls сравнивает размер ответа со списком из 5000 заявок
+// без сжатия и со сжатием gzip.
+
+func BenchmarkCompress_5kCalls(b *testing.B) {
+	router := setupCompressRouter(makeCalls(5000))
+
+	identity := doGet(router, "/calls", "identity")
+	compressed := doGet(router, "/calls", "gzip")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		doGet(router, "/calls", "gzip")
+	}
+	b.ReportMetric(float64(identity.Body.Len()), "identity-bytes")
+	b.ReportMetric(float64(compressed.Body.Len()), "gzip-bytes")
+}
@@ -0,0 +1,263 @@
+package middleware
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/i18n"
+)
+
+// faultsInjected — число внесенных сбоев по видам (latency, status, reset), публикуемое
+// в /debug/vars.
+var faultsInjected = expvar.NewMap("faults_injected")
+
+// FaultRule — правило внесения сбоя в запросы к маршруту. Сбои вносятся только в средах
+// разработки и тестирования, чтобы проверить поведение клиентов и повторных попыток.
+
+type FaultRule struct {
+	// Method — HTTP-метод запроса; "*" означает любой метод.
+	Method string
+	// Route — шаблон маршрута gin (например, /calls/:id) или путь запроса; "*" означает
+	// любой маршрут.
+	Route string
+	// Probability — доля запросов от 0 до 1, в которые вносится сбой.
+	Probability float64
+	// Latency — задержка перед обработкой запроса. Задержка учитывается в сроке
+	// middleware Timeout и прерывается при его истечении.
+	Latency time.Duration
+	// Status — код ответа 4xx или 5xx, которым запрос отклоняется после задержки;
+	// 0 — запрос обрабатывается как обычно.
+	Status int
+	// Reset — после задержки соединение разрывается без ответа.
+	Reset bool
+}
+
+// ParseFaultRules разбирает правила, разделенные точкой с запятой, в формате ParseFaultRule.
+// Пустая строка означает отсутствие правил.
+
+func ParseFaultRules(s string) ([]FaultRule, error) {
+	var rules []FaultRule
+	for _, item := range strings.Split(s, ";") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		rule, err := ParseFaultRule(item)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ParseFaultRule разбирает правило вида "МЕТОД МАРШРУТ ДОЛЯ СБОЙ...", где сбой — latency=200ms,
+// status=503 или reset, например "POST /calls 0.1 latency=200ms status=503". Задержку можно
+// сочетать с кодом ответа или разрывом соединения.
+
+func ParseFaultRule(s string) (FaultRule, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 4 {
+		return FaultRule{}, fmt.Errorf("fault rule %q: expected method, route, probability and fault", s)
+	}
+	rule := FaultRule{Method: strings.ToUpper(fields[0]), Route: fields[1]}
+	probability, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil || probability <= 0 || probability > 1 {
+		return FaultRule{}, fmt.Errorf("fault rule %q: probability must be in (0, 1]", s)
+	}
+	rule.Probability = probability
+
+	for _, fault := range fields[3:] {
+		name, value, _ := strings.Cut(fault, "=")
+		switch name {
+		case "latency":
+			latency, err := time.ParseDuration(value)
+			if err != nil || latency <= 0 {
+				return FaultRule{}, fmt.Errorf("fault rule %q: invalid latency %q", s, value)
+			}
+			rule.Latency = latency
+		case "status":
+			status, err := strconv.Atoi(value)
+			if err != nil || status < 400 || status > 599 {
+				return FaultRule{}, fmt.Errorf("fault rule %q: status must be 4xx or 5xx", s)
+			}
+			rule.Status = status
+		case "reset":
+			rule.Reset = true
+		default:
+			return FaultRule{}, fmt.Errorf("fault rule %q: unknown fault %q", s, fault)
+		}
+	}
+	if rule.Status != 0 && rule.Reset {
+		return FaultRule{}, fmt.Errorf("fault rule %q: status and reset are mutually exclusive", s)
+	}
+	return rule, nil
+}
+
+// String возвращает правило в формате ParseFaultRule.
+
+func (r FaultRule) String() string {
+	return r.Method + " " + r.Route + " " + strconv.FormatFloat(r.Probability, 'f', -1, 64) + " " + r.fault()
+}
+
+// fault описывает вносимый сбой для журнала и String.
+func (r FaultRule) fault() string {
+	var faults []string
+	if r.Latency > 0 {
+		faults = append(faults, "latency="+r.Latency.String())
+	}
+	if r.Status != 0 {
+		faults = append(faults, "status="+strconv.Itoa(r.Status))
+	}
+	if r.Reset {
+		faults = append(faults, "reset")
+	}
+	return strings.Join(faults, " ")
+}
+
+// matches сообщает, относится ли правило к запросу с методом method к маршруту route и пути path.
+func (r FaultRule) matches(method, route, path string) bool {
+	return (r.Method == "*" || r.Method == method) && (r.Route == "*" || r.Route == route || r.Route == path)
+}
+
+// FaultInjector вносит сбои в HTTP-запросы по правилам FaultRule: задержки, ответы с кодом
+// ошибки и разрывы соединения. Правила заменяются во время работы (например, запросом
+// PUT /admin/faults) и действуют в пределах одного экземпляра сервиса. Без правил
+// middleware Inject только передает запрос дальше.
+
+type FaultInjector struct {
+	rules  atomic.Pointer[[]FaultRule]
+	random func() float64
+	logger *slog.Logger
+}
+
+// NewFaultInjector создает FaultInjector с начальными правилами rules. Каждый внесенный сбой
+// записывается в logger с идентификатором запроса; nil означает slog.Default().
+
+func NewFaultInjector(rules []FaultRule, logger *slog.Logger) *FaultInjector {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	f := &FaultInjector{random: rand.Float64, logger: logger}
+	f.SetRules(rules)
+	return f
+}
+
+// Rules возвращает действующие правила.
+
+func (f *FaultInjector) Rules() []FaultRule {
+	return *f.rules.Load()
+}
+
+// SetRules заменяет действующие правила; пустой список отключает внесение сбоев.
+
+func (f *FaultInjector) SetRules(rules []FaultRule) {
+	rules = append([]FaultRule{}, rules...)
+	f.rules.Store(&rules)
+	f.logger.Warn("fault injection rules updated", "rules", len(rules))
+}
+
+// Inject возвращает middleware, вносящее сбои в запросы. К запросу применяется первое
+// подходящее по методу и маршруту правило, с вероятностью Probability. Сбой записывается
+// в журнал с идентификатором запроса, поэтому middleware подключается после RequestID.
+// В запросы к путям или шаблонам маршрутов из skipPaths (например, к самим правилам)
+// сбои не вносятся.
+
+func (f *FaultInjector) Inject(skipPaths ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(skipPaths))
+	for _, path := range skipPaths {
+		skip[path] = true
+	}
+
+	return func(c *gin.Context) {
+		rules := *f.rules.Load()
+		if len(rules) == 0 || skip[c.Request.URL.Path] || skip[c.FullPath()] {
+			c.Next()
+			return
+		}
+		for _, rule := range rules {
+			if !rule.matches(c.Request.Method, c.FullPath(), c.Request.URL.Path) {
+				continue
+			}
+			if f.random() < rule.Probability {
+				f.inject(c, rule)
+			}
+			break
+		}
+		if !c.IsAborted() {
+			c.Next()
+		}
+	}
+}
+
+// inject вносит в запрос сбой по правилу rule.
+func (f *FaultInjector) inject(c *gin.Context, rule FaultRule) {
+	f.logger.Warn("fault injected",
+		"request_id", GetRequestID(c),
+		"method", c.Request.Method,
+		"route", c.FullPath(),
+		"fault", rule.fault(),
+	)
+
+	if rule.Latency > 0 {
+		faultsInjected.Add("latency", 1)
+		timer := time.NewTimer(rule.Latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-c.Request.Context().Done():
+			// Срок запроса истек или клиент отключился: ответ 503 отправит middleware Timeout
+			c.Abort()
+			return
+		}
+	}
+
+	switch {
+	case rule.Status != 0:
+		faultsInjected.Add("status", 1)
+		c.AbortWithStatusJSON(rule.Status, i18n.Response(c, i18n.FaultInjected))
+	case rule.Reset:
+		faultsInjected.Add("reset", 1)
+		if c.Request.ProtoMajor != 1 {
+			// Соединение HTTP/2 нельзя перехватить: вместо разрыва клиент получает 502
+			c.AbortWithStatusJSON(http.StatusBadGateway, i18n.Response(c, i18n.FaultInjected))
+			return
+		}
+		c.Abort()
+		if err := resetConnection(c); err != nil {
+			f.logger.Warn("fault injection: connection reset failed", "request_id", GetRequestID(c), "error", err)
+		}
+	}
+}
+
+// resetConnection закрывает соединение запроса без ответа. Для TCP-соединения закрытие
+// отправляет клиенту RST вместо FIN.
+func resetConnection(c *gin.Context) (err error) {
+	if c.Writer.Written() {
+		return errors.New("response already written")
+	}
+	// gin паникует, если исходный ResponseWriter не поддерживает перехват соединения
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("hijack connection: %v", r)
+		}
+	}()
+	conn, _, err := c.Writer.Hijack()
+	if err != nil {
+		return err
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		_ = tcp.SetLinger(0)
+	}
+	return conn.Close()
+}
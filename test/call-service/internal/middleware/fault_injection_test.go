@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// faultRouter возвращает маршрутизатор с идентификатором запроса, ограничением времени
+// и внесением сбоев faults (nil — без внесения сбоев).
+func faultRouter(faults *FaultInjector) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID(), Timeout(TimeoutConfig{Timeout: 50 * time.Millisecond}))
+	if faults != nil {
+		router.Use(faults.Inject("/faults"))
+	}
+	router.GET("/calls/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/calls", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	router.PUT("/faults", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+// TestParseFaultRules проверяет разбор правил внесения сбоев и отклонение неверных правил.
+
+func TestParseFaultRules(t *testing.T) {
+	rules, err := ParseFaultRules("post /calls 0.1 latency=200ms status=503; GET /calls/:id 1 reset ;")
+	require.NoError(t, err)
+	assert.Equal(t, []FaultRule{
+		{Method: "POST", Route: "/calls", Probability: 0.1, Latency: 200 * time.Millisecond, Status: 503},
+		{Method: "GET", Route: "/calls/:id", Probability: 1, Reset: true},
+	}, rules)
+	assert.Equal(t, "POST /calls 0.1 latency=200ms status=503", rules[0].String())
+
+	rules, err = ParseFaultRules("")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	for _, s := range []string{
+		"* * 0.5",
+		"* * 0 reset",
+		"* * 1.5 reset",
+		"* * 0.5 status=200",
+		"* * 0.5 latency=-1s",
+		"* * 0.5 drop",
+		"* * 0.5 status=503 reset",
+	} {
+		_, err := ParseFaultRules(s)
+		assert.Error(t, err, s)
+	}
+}
+
+// TestFaultInjector_Probability проверяет, что сбой вносится в долю запросов, близкую
+// к Probability, только к маршруту из правила, а каждый сбой записывается в журнал
+// с идентификатором запроса.
+
+func TestFaultInjector_Probability(t *testing.T) {
+	var logs bytes.Buffer
+	faults := NewFaultInjector([]FaultRule{
+		{Method: http.MethodGet, Route: "/calls/:id", Probability: 0.2, Status: http.StatusServiceUnavailable},
+	}, slog.New(slog.NewTextHandler(&logs, nil)))
+	faults.random = rand.New(rand.NewPCG(1, 2)).Float64
+	router := faultRouter(faults)
+
+	const requests = 1000
+	var failed int
+	for range requests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/calls/42", nil))
+		if w.Code == http.StatusServiceUnavailable {
+			assert.Contains(t, w.Body.String(), "fault_injected")
+			failed++
+		}
+	}
+	assert.InDelta(t, 0.2*requests, failed, 0.05*requests)
+	assert.Equal(t, failed, bytes.Count(logs.Bytes(), []byte("fault injected")))
+
+	// Запрос с известным идентификатором попадает в журнал
+	faults.SetRules([]FaultRule{{Method: "*", Route: "*", Probability: 1, Status: http.StatusTeapot}})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/calls", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Contains(t, logs.String(), "request_id=req-123")
+
+	// В запросы к правилам сбои не вносятся, пустой список правил отключает сбои
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/faults", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	faults.SetRules(nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/calls", nil))
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+// TestFaultInjector_LatencyAndReset проверяет, что задержка прерывается сроком запроса
+// и клиент получает 503, а разрыв соединения приводит к ошибке у клиента.
+
+func TestFaultInjector_LatencyAndReset(t *testing.T) {
+	faults := NewFaultInjector([]FaultRule{
+		{Method: http.MethodGet, Route: "/calls/:id", Probability: 1, Latency: time.Minute},
+		{Method: http.MethodPost, Route: "/calls", Probability: 1, Reset: true},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	srv := httptest.NewServer(faultRouter(faults))
+	defer srv.Close()
+
+	start := time.Now()
+	resp, err := http.Get(srv.URL + "/calls/42")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	_, err = http.Post(srv.URL+"/calls", "application/json", nil)
+	assert.Error(t, err)
+}
+
+// TestFaultInjector_NoRulesAllocs проверяет, что без правил middleware не добавляет
+// выделений памяти к обработке запроса.
+
+func TestFaultInjector_NoRulesAllocs(t *testing.T) {
+	allocs := func(router *gin.Engine) float64 {
+		req := httptest.NewRequest(http.MethodGet, "/calls/42", nil)
+		w := httptest.NewRecorder()
+		return testing.AllocsPerRun(100, func() { router.ServeHTTP(w, req) })
+	}
+	baseline := allocs(faultRouter(nil))
+	disabled := allocs(faultRouter(NewFaultInjector(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))))
+	assert.Equal(t, baseline, disabled)
+}
+
+// BenchmarkFaultInjector_Baseline и BenchmarkFaultInjector_NoRules сравнивают обработку
+// запроса без middleware внесения сбоев и с ним без правил.
+
+func BenchmarkFaultInjector_Baseline(b *testing.B) {
+	benchmarkFaultRouter(b, faultRouter(nil))
+}
+
+func BenchmarkFaultInjector_NoRules(b *testing.B) {
+	benchmarkFaultRouter(b, faultRouter(NewFaultInjector(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))))
+}
+
+func benchmarkFaultRouter(b *testing.B, router *gin.Engine) {
+	req := httptest.NewRequest(http.MethodGet, "/calls/42", nil)
+	w := httptest.NewRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(w, req)
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"expvar"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/i18n"
+)
+
+// DefaultMaintenanceRetryAfter — значение заголовка Retry-After в режиме обслуживания по умолчанию.
+const DefaultMaintenanceRetryAfter = time.Minute
+
+// maintenanceMode — показатель режима обслуживания, публикуемый в /debug/vars:
+// 1, если режим включен, и 0 — если выключен.
+var maintenanceMode = new(expvar.Int)
+
+func init() {
+	expvar.Publish("maintenance_mode", maintenanceMode)
+}
+
+// Maintenance — переключатель режима обслуживания. Пока режим включен, API продолжает
+// отвечать на чтение, а изменяющие запросы отклоняются middleware Reject, например
+// на время миграций базы данных. Режим переключается во время работы и действует
+// в пределах одного экземпляра сервиса.
+
+type Maintenance struct {
+	enabled    atomic.Bool
+	retryAfter time.Duration
+}
+
+// NewMaintenance создает переключатель с начальным состоянием enabled. retryAfter —
+// значение заголовка Retry-After в отклоненных ответах; 0 означает DefaultMaintenanceRetryAfter.
+
+func NewMaintenance(enabled bool, retryAfter time.Duration) *Maintenance {
+	if retryAfter <= 0 {
+		retryAfter = DefaultMaintenanceRetryAfter
+	}
+	m := &Maintenance{retryAfter: retryAfter}
+	m.SetEnabled(enabled)
+	return m
+}
+
+// Enabled сообщает, включен ли режим обслуживания.
+
+func (m *Maintenance) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// SetEnabled включает или выключает режим обслуживания.
+
+func (m *Maintenance) SetEnabled(enabled bool) {
+	if m.enabled.Swap(enabled) != enabled {
+		log.Printf("maintenance mode enabled: %t", enabled)
+	}
+	if enabled {
+		maintenanceMode.Set(1)
+	} else {
+		maintenanceMode.Set(0)
+	}
+}
+
+// Reject возвращает middleware, которое в режиме обслуживания отклоняет изменяющие запросы
+// (все методы, кроме GET, HEAD и OPTIONS) с кодом 503 и заголовком Retry-After. Запросы
+// к путям или шаблонам маршрутов из skipPaths (например, к переключателю режима) не отклоняются,
+// как и запросы к незарегистрированным маршрутам, которые получают 404.
+
+func (m *Maintenance) Reject(skipPaths ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(skipPaths))
+	for _, path := range skipPaths {
+		skip[path] = true
+	}
+	retryAfter := strconv.Itoa(int(math.Ceil(m.retryAfter.Seconds())))
+
+	return func(c *gin.Context) {
+		if !m.Enabled() || c.FullPath() == "" || skip[c.Request.URL.Path] || skip[c.FullPath()] {
+			c.Next()
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", retryAfter)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, i18n.Response(c, i18n.MaintenanceMode))
+	}
+}
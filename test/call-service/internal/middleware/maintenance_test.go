@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMaintenance_Reject проверяет, что в режиме обслуживания отклоняются только изменяющие
+// запросы к зарегистрированным маршрутам, кроме пропускаемых, а показатель maintenance_mode
+// отражает текущее состояние.
+
+func TestMaintenance_Reject(t *testing.T) {
+	maintenance := NewMaintenance(true, 1500*time.Millisecond)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(maintenance.Reject("/login"))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/calls", ok)
+	router.HEAD("/calls", ok)
+	router.POST("/calls", ok)
+	router.DELETE("/calls/:id", ok)
+	router.POST("/login", ok)
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	assert.EqualValues(t, 1, maintenanceMode.Value())
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		assert.Equal(t, http.StatusOK, do(method, "/calls").Code, method)
+	}
+	w := do(http.MethodPost, "/calls")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusServiceUnavailable, do(http.MethodDelete, "/calls/1").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/login").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/missing").Code)
+
+	maintenance.SetEnabled(false)
+	assert.EqualValues(t, 0, maintenanceMode.Value())
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/calls").Code)
+}
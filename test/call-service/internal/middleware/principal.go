@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"call-service/internal/featureflags"
+	"call-service/internal/impersonation"
+	"call-service/internal/tenant"
+)
+
+// principalKey — ключ контекста gin, под которым AuthMiddleware сохраняет Principal.
+const principalKey = "principal"
+
+// accessTokenKey — ключ контекста gin, под которым AuthMiddleware сохраняет токен доступа запроса.
+const accessTokenKey = "access_token"
+
+// AuthMethod — способ, которым аутентифицирован запрос.
+
+type AuthMethod string
+
+const (
+	// AuthMethodJWT — токен доступа, выпущенный сервисом аутентификации.
+	AuthMethodJWT AuthMethod = "jwt"
+	// AuthMethodAPIKey — API-ключ пользователя.
+	AuthMethodAPIKey AuthMethod = "api_key"
+)
+
+// Principal описывает пользователя, от имени которого выполняется запрос.
+// SessionID пуст для API-ключей и токенов, выпущенных до появления сеансов;
+// TokenExpiresAt — срок действия токена по часам call-service; нулевое время для API-ключей
+// и токенов без срока действия.
+// Degraded означает, что сервис аутентификации был недоступен и токен принят
+// по кэшированной проверке (см. AuthConfig.StaleTTL).
+// OrgID — организация пользователя, OrgRole — его роль в ней (tenant.RoleOwner или
+// tenant.RoleMember); OrgID равен uuid.Nil, если пользователь не состоит в организации
+// или организации отключены (см. AuthConfig.Organizations). ImpersonatedBy — ID администратора,
+// работающего от имени пользователя с токеном, выпущенным сервисом аутентификации
+// (ImpersonateUser), или uuid.Nil.
+
+type Principal struct {
+	UserID         uuid.UUID
+	Role           string
+	AuthMethod     AuthMethod
+	SessionID      string
+	TokenExpiresAt time.Time
+	Degraded       bool
+	OrgID          uuid.UUID
+	OrgRole        string
+	ImpersonatedBy uuid.UUID
+}
+
+// GetPrincipal возвращает пользователя запроса. Второй результат false,
+// если запрос не прошел аутентификацию.
+
+func GetPrincipal(c *gin.Context) (Principal, bool) {
+	value, exists := c.Get(principalKey)
+	if !exists {
+		return Principal{}, false
+	}
+	return value.(Principal), true
+}
+
+// setPrincipal сохраняет пользователя запроса в контексте, а его членство в организации
+// и администратора, работающего от его имени, — в контексте HTTP-запроса, откуда их получает
+// сервисный слой.
+
+func setPrincipal(c *gin.Context, principal Principal) {
+	c.Set(principalKey, principal)
+	c.Request = c.Request.WithContext(ContextWithPrincipal(c.Request.Context(), principal))
+}
+
+// ContextWithPrincipal возвращает контекст с данными пользователя principal, которые читает
+// сервисный слой: пользователем для флагов, членством в организации и администратором,
+// работающим от его имени. Используется и перехватчиками gRPC API.
+
+func ContextWithPrincipal(ctx context.Context, principal Principal) context.Context {
+	ctx = featureflags.WithUser(ctx, principal.UserID)
+	if principal.OrgID != uuid.Nil {
+		ctx = tenant.WithMembership(ctx, tenant.Membership{OrgID: principal.OrgID, Role: principal.OrgRole})
+	}
+	if principal.ImpersonatedBy != uuid.Nil {
+		ctx = impersonation.WithImpersonator(ctx, principal.ImpersonatedBy)
+	}
+	return ctx
+}
+
+// GetAccessToken возвращает токен доступа, с которым аутентифицирован запрос, для вызовов
+// сервиса аутентификации от имени пользователя. Пустая строка — запрос с API-ключом
+// или без аутентификации.
+
+func GetAccessToken(c *gin.Context) string {
+	return c.GetString(accessTokenKey)
+}
+
+// GetUserID извлекает ID пользователя из контекста запроса
+
+func GetUserID(c *gin.Context) (uuid.UUID, bool) {
+	principal, ok := GetPrincipal(c)
+	return principal.UserID, ok
+}
+
+// GetRole извлекает роль пользователя из контекста запроса.
+// Возвращает пустую строку, если запрос не прошел аутентификацию.
+
+func GetRole(c *gin.Context) string {
+	principal, _ := GetPrincipal(c)
+	return principal.Role
+}
+
+// GetSessionID извлекает ID сеанса, которому принадлежит токен запроса.
+// Возвращает пустую строку для токенов, выпущенных до появления сеансов, и API-ключей.
+
+func GetSessionID(c *gin.Context) string {
+	principal, _ := GetPrincipal(c)
+	return principal.SessionID
+}
@@ -0,0 +1,278 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/cache"
+	"call-service/internal/i18n"
+	"common/clock"
+)
+
+// Способы хранения счетчиков ограничения частоты запросов.
+const (
+	// RateLimitMemory — счетчики в памяти процесса; каждый экземпляр сервиса ограничивает
+	// запросы независимо от других.
+	RateLimitMemory = "memory"
+	// RateLimitPostgres — общие счетчики в PostgreSQL; ограничение действует на все экземпляры
+	// сервиса вместе.
+	RateLimitPostgres = "postgres"
+	// RateLimitCache — счетчики в кэше сервиса (StoreLimiter); с кэшем в Redis ограничение
+	// действует на все экземпляры сервиса вместе.
+	RateLimitCache = "cache"
+)
+
+// Поведение ограничителя RateLimitCache при недоступности кэша.
+const (
+	// RateLimitFallbackOpen — запросы выполняются без ограничения (см. Limit).
+	RateLimitFallbackOpen = "open"
+	// RateLimitFallbackClosed — запросы отклоняются (см. FailClosed).
+	RateLimitFallbackClosed = "closed"
+	// RateLimitFallbackMemory — счетчики ведутся в памяти процесса, и каждый экземпляр
+	// ограничивает запросы отдельно.
+	RateLimitFallbackMemory = "memory"
+)
+
+// IntervalSetter — ограничитель, интервал (окно) которого можно изменить во время работы,
+// например при перечитывании конфигурации. Новый интервал действует для следующих запросов.
+
+type IntervalSetter interface {
+	SetInterval(interval time.Duration)
+}
+
+// Limiter учитывает запросы с одним ключом и решает, можно ли выполнить следующий.
+
+type Limiter interface {
+	// Reserve учитывает запрос с ключом key. Если лимит исчерпан, возвращает время до следующей
+	// попытки и false; в этом случае запрос не учитывается.
+	Reserve(ctx context.Context, key string) (time.Duration, bool, error)
+	// Release отменяет учет запроса с ключом key, разрешенного Reserve.
+	Release(ctx context.Context, key string) error
+}
+
+// Limit возвращает middleware, которое отклоняет запрос с кодом 429 и заголовком Retry-After,
+// если limiter исчерпал лимит для ключа запроса. Ключ запроса возвращает key; запросы с пустым
+// ключом не ограничиваются. Неудачный запрос (код ответа 4xx или 5xx) не расходует попытку.
+// Если limiter недоступен, запрос выполняется без ограничения: отказ хранилища счетчиков
+// не должен останавливать API.
+
+func Limit(limiter Limiter, key func(*gin.Context) string) gin.HandlerFunc {
+	return limit(limiter, key, true)
+}
+
+// Throttle работает как Limit, но учитывает каждый запрос, в том числе неудачный. Так
+// ограничивается перебор, при котором почти все запросы завершаются ошибкой (например, 404).
+
+func Throttle(limiter Limiter, key func(*gin.Context) string) gin.HandlerFunc {
+	return limit(limiter, key, false)
+}
+
+// limit возвращает middleware Limit; releaseFailed возвращает попытку неудачного запроса.
+func limit(limiter Limiter, key func(*gin.Context) string, releaseFailed bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k := key(c)
+		if k == "" {
+			c.Next()
+			return
+		}
+
+		wait, ok, err := limiter.Reserve(c.Request.Context(), k)
+		if err != nil {
+			log.Printf("rate limit check failed, request allowed: %v", err)
+			c.Next()
+			return
+		}
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, i18n.Response(c, i18n.TooManyRequests))
+			return
+		}
+
+		c.Next()
+
+		if releaseFailed && c.Writer.Status() >= http.StatusBadRequest {
+			// Контекст запроса может быть уже отменен, а попытку нужно вернуть в любом случае
+			if err := limiter.Release(context.WithoutCancel(c.Request.Context()), k); err != nil {
+				log.Printf("rate limit release failed: %v", err)
+			}
+		}
+	}
+}
+
+// RateLimiter разрешает не более одного запроса с одним ключом (например, ID пользователя)
+// за интервал. Ограничение действует в пределах одного экземпляра сервиса. Один RateLimiter
+// можно подключить к нескольким маршрутам, чтобы они делили общее ограничение.
+
+type RateLimiter struct {
+	interval time.Duration
+	clock    clock.Clock
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// NewRateLimiter создает RateLimiter с интервалом interval; nil clk означает системное время.
+
+func NewRateLimiter(interval time.Duration, clk clock.Clock) *RateLimiter {
+	if clk == nil {
+		clk = clock.Real
+	}
+	return &RateLimiter{interval: interval, clock: clk, last: make(map[string]time.Time)}
+}
+
+// Limit возвращает middleware, ограничивающее запросы этим RateLimiter (см. Limit).
+
+func (l *RateLimiter) Limit(key func(*gin.Context) string) gin.HandlerFunc {
+	return Limit(l, key)
+}
+
+// SetInterval заменяет интервал ограничения.
+
+func (l *RateLimiter) SetInterval(interval time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.interval = interval
+}
+
+// Reserve отмечает запрос с ключом k. Если предыдущий запрос был меньше интервала назад,
+// возвращает время до следующей попытки и false. Записи старше интервала удаляются,
+// поэтому размер карты ограничен числом запросов за интервал. Ошибок не возвращает.
+
+func (l *RateLimiter) Reserve(_ context.Context, k string) (time.Duration, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	for key, at := range l.last {
+		if now.Sub(at) >= l.interval {
+			delete(l.last, key)
+		}
+	}
+	if at, ok := l.last[k]; ok {
+		return l.interval - now.Sub(at), false, nil
+	}
+	l.last[k] = now
+	return 0, true, nil
+}
+
+// Release отменяет отметку о запросе с ключом k.
+
+func (l *RateLimiter) Release(_ context.Context, k string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.last, k)
+	return nil
+}
+
+// StoreLimiter разрешает не более limit запросов с одним ключом за фиксированное окно времени,
+// храня счетчики в cache.Store. С общим хранилищем (Redis) ограничители всех экземпляров сервиса
+// с одним префиксом делят один лимит. Как у любых фиксированных окон, на их границе за время,
+// равное окну, может пройти до удвоенного лимита. Границы окон считаются по часам экземпляра,
+// поэтому расхождение часов экземпляров сдвигает их относительно друг друга.
+
+type StoreLimiter struct {
+	store  cache.Store
+	prefix string
+	limit  int64
+	// window — длительность окна в наносекундах; заменяется SetInterval.
+	window atomic.Int64
+	clock  clock.Clock
+}
+
+// NewStoreLimiter создает ограничитель на limit запросов за окно window с ключами счетчиков,
+// начинающимися с prefix; nil clk означает системное время.
+
+func NewStoreLimiter(store cache.Store, prefix string, limit int, window time.Duration, clk clock.Clock) *StoreLimiter {
+	if clk == nil {
+		clk = clock.Real
+	}
+	l := &StoreLimiter{store: store, prefix: prefix, limit: int64(limit), clock: clk}
+	l.window.Store(int64(window))
+	return l
+}
+
+// SetInterval заменяет длительность окна. Счетчики окон прежней длительности перестают
+// учитываться, поэтому после замены лимит начинается заново.
+
+func (l *StoreLimiter) SetInterval(window time.Duration) {
+	l.window.Store(int64(window))
+}
+
+// Reserve увеличивает счетчик текущего окна для ключа key. Если лимит превышен, увеличение
+// отменяется и возвращается время до начала следующего окна.
+
+func (l *StoreLimiter) Reserve(ctx context.Context, key string) (time.Duration, bool, error) {
+	window := time.Duration(l.window.Load())
+	now := l.clock.Now()
+	start := now.Truncate(window)
+	counter := l.counterKey(key, start)
+	n, err := l.store.Incr(ctx, counter, 1, window)
+	if err != nil {
+		return 0, false, err
+	}
+	if n > l.limit {
+		if _, err := l.store.Incr(ctx, counter, -1, window); err != nil {
+			log.Printf("rate limit counter rollback failed: %v", err)
+		}
+		return start.Add(window).Sub(now), false, nil
+	}
+	return 0, true, nil
+}
+
+// Release уменьшает счетчик текущего окна для ключа key. Если окно сменилось после Reserve,
+// уменьшается счетчик нового окна; эта погрешность не превышает одного запроса.
+
+func (l *StoreLimiter) Release(ctx context.Context, key string) error {
+	window := time.Duration(l.window.Load())
+	_, err := l.store.Incr(ctx, l.counterKey(key, l.clock.Now().Truncate(window)), -1, window)
+	return err
+}
+
+// counterKey возвращает ключ счетчика окна, начинающегося в start.
+
+func (l *StoreLimiter) counterKey(key string, start time.Time) string {
+	return l.prefix + key + ":" + strconv.FormatInt(start.Unix(), 10)
+}
+
+// FailClosed возвращает Limiter, который отклоняет запрос с Retry-After, равным retryAfter,
+// если limiter вернул ошибку, вместо выполнения запроса без ограничения.
+
+func FailClosed(limiter Limiter, retryAfter time.Duration) Limiter {
+	return failClosed{limiter: limiter, retryAfter: retryAfter}
+}
+
+// failClosed реализует FailClosed.
+
+type failClosed struct {
+	limiter    Limiter
+	retryAfter time.Duration
+}
+
+func (f failClosed) Reserve(ctx context.Context, key string) (time.Duration, bool, error) {
+	wait, ok, err := f.limiter.Reserve(ctx, key)
+	if err != nil {
+		log.Printf("rate limit check failed, request rejected: %v", err)
+		return f.retryAfter, false, nil
+	}
+	return wait, ok, nil
+}
+
+func (f failClosed) Release(ctx context.Context, key string) error {
+	return f.limiter.Release(ctx, key)
+}
+
+// SetInterval заменяет интервал исходного ограничителя, если он это поддерживает.
+
+func (f failClosed) SetInterval(interval time.Duration) {
+	if setter, ok := f.limiter.(IntervalSetter); ok {
+		setter.SetInterval(interval)
+	}
+}
@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/cache"
+	"common/clock"
+)
+
+// TestRateLimiter проверяет ограничение одним запросом с ключом за интервал: повторный запрос
+// получает 429 с Retry-After, другие ключи не ограничиваются, неудачный запрос не расходует попытку.
+
+func TestRateLimiter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	limit := NewRateLimiter(time.Hour, clk).Limit(func(c *gin.Context) string {
+		return c.Query("key")
+	})
+	router := gin.New()
+	router.GET("/ok", limit, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "ok"})
+	})
+	router.GET("/fail", limit, func(c *gin.Context) {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "fail"})
+	})
+
+	assert.Equal(t, http.StatusOK, doGet(router, "/ok?key=a", "").Code)
+	clk.Advance(20 * time.Minute)
+	w := doGet(router, "/ok?key=a", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2400", w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusTooManyRequests, doGet(router, "/fail?key=a", "").Code)
+	assert.Equal(t, http.StatusOK, doGet(router, "/ok?key=b", "").Code)
+	assert.Equal(t, http.StatusOK, doGet(router, "/ok", "").Code)
+	assert.Equal(t, http.StatusOK, doGet(router, "/ok", "").Code)
+
+	clk.Advance(40 * time.Minute)
+	assert.Equal(t, http.StatusOK, doGet(router, "/ok?key=a", "").Code)
+
+	assert.Equal(t, http.StatusInternalServerError, doGet(router, "/fail?key=c", "").Code)
+	assert.Equal(t, http.StatusOK, doGet(router, "/ok?key=c", "").Code)
+}
+
+// TestRateLimiter_SetInterval проверяет, что новый интервал действует для следующих запросов,
+// в том числе через FailClosed.
+
+func TestRateLimiter_SetInterval(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	limiter := NewRateLimiter(time.Hour, clk)
+	_, ok, _ := limiter.Reserve(ctx, "a")
+	require.True(t, ok)
+
+	FailClosed(limiter, time.Minute).(IntervalSetter).SetInterval(10 * time.Minute)
+	clk.Advance(5 * time.Minute)
+	wait, ok, _ := limiter.Reserve(ctx, "a")
+	assert.False(t, ok)
+	assert.Equal(t, 5*time.Minute, wait)
+	clk.Advance(5 * time.Minute)
+	_, ok, _ = limiter.Reserve(ctx, "a")
+	assert.True(t, ok)
+}
+
+// fakeLimiter — Limiter с заданным ответом Reserve, запоминающий ключи отмененных запросов.
+
+type fakeLimiter struct {
+	wait     time.Duration
+	ok       bool
+	err      error
+	released []string
+}
+
+func (l *fakeLimiter) Reserve(context.Context, string) (time.Duration, bool, error) {
+	return l.wait, l.ok, l.err
+}
+
+func (l *fakeLimiter) Release(_ context.Context, key string) error {
+	l.released = append(l.released, key)
+	return nil
+}
+
+// TestLimit проверяет middleware с произвольным Limiter: отказ с Retry-After, возврат попытки
+// неудачного запроса (кроме Throttle) и выполнение запроса без ограничения при ошибке
+// хранилища счетчиков.
+
+func TestLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := &fakeLimiter{}
+	limit := Limit(limiter, func(c *gin.Context) string { return c.Query("key") })
+	router := gin.New()
+	router.GET("/ok", limit, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "ok"})
+	})
+	router.GET("/fail", limit, func(c *gin.Context) {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "fail"})
+	})
+	router.GET("/missing", Throttle(limiter, func(c *gin.Context) string { return c.Query("key") }), func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "missing"})
+	})
+
+	limiter.wait = 1500 * time.Millisecond
+	w := doGet(router, "/ok?key=a", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	limiter.ok = true
+	assert.Equal(t, http.StatusOK, doGet(router, "/ok?key=a", "").Code)
+	assert.Equal(t, http.StatusInternalServerError, doGet(router, "/fail?key=b", "").Code)
+	assert.Equal(t, []string{"b"}, limiter.released)
+	assert.Equal(t, http.StatusNotFound, doGet(router, "/missing?key=c", "").Code)
+	assert.Equal(t, []string{"b"}, limiter.released)
+
+	// Недоступное хранилище счетчиков не блокирует запросы
+	limiter.ok, limiter.err = false, errors.New("database is down")
+	assert.Equal(t, http.StatusOK, doGet(router, "/ok?key=a", "").Code)
+}
+
+// TestStoreLimiter проверяет, что два ограничителя с общим Redis, как два экземпляра сервиса,
+// вместе разрешают не больше лимита за окно, отказ сообщает время до следующего окна,
+// а в новом окне лимит восстанавливается.
+
+func TestStoreLimiter(t *testing.T) {
+	server := miniredis.RunT(t)
+	store, err := cache.NewRedis(cache.RedisConfig{Addr: server.Addr()})
+	require.NoError(t, err)
+	defer store.Close()
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 10, 0, 0, time.UTC))
+	first := NewStoreLimiter(store, "export:", 2, time.Hour, clk)
+	second := NewStoreLimiter(store, "export:", 2, time.Hour, clk)
+	ctx := context.Background()
+
+	_, ok, err := first.Reserve(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	_, ok, _ = second.Reserve(ctx, "a")
+	assert.True(t, ok)
+	wait, ok, err := first.Reserve(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 50*time.Minute, wait)
+	_, ok, _ = second.Reserve(ctx, "b")
+	assert.True(t, ok)
+
+	// Возвращенная попытка доступна другому экземпляру
+	require.NoError(t, first.Release(ctx, "a"))
+	_, ok, _ = second.Reserve(ctx, "a")
+	assert.True(t, ok)
+	_, ok, _ = second.Reserve(ctx, "a")
+	assert.False(t, ok)
+
+	clk.Advance(50 * time.Minute)
+	server.FastForward(50 * time.Minute)
+	_, ok, _ = first.Reserve(ctx, "a")
+	assert.True(t, ok)
+
+	// Недоступный Redis: ошибка для Limit, отказ для FailClosed
+	server.Close()
+	_, _, err = first.Reserve(ctx, "c")
+	assert.Error(t, err)
+	wait, ok, err = FailClosed(first, time.Minute).Reserve(ctx, "c")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, time.Minute, wait)
+}
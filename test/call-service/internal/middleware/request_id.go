@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader — заголовок запроса и ответа с идентификатором запроса.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength ограничивает длину идентификатора, принятого от клиента.
+const maxRequestIDLength = 128
+
+// RequestID возвращает middleware, присваивающее запросу идентификатор. Идентификатор
+// берется из заголовка X-Request-ID, если клиент или балансировщик его передал и он
+// корректен, иначе генерируется новый. Идентификатор возвращается в заголовке ответа
+// и доступен обработчикам через GetRequestID.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Set("requestID", id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// GetRequestID возвращает идентификатор запроса, присвоенный middleware RequestID.
+func GetRequestID(c *gin.Context) string {
+	return c.GetString("requestID")
+}
+
+// validRequestID допускает только непустые идентификаторы разумной длины из печатных
+// ASCII-символов, чтобы значение из заголовка нельзя было использовать для подделки журнала.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRequestID проверяет, что корректный идентификатор клиента сохраняется,
+// а отсутствующий или некорректный заменяется сгенерированным.
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, GetRequestID(c))
+	})
+
+	for name, header := range map[string]string{
+		"missing":  "",
+		"spaces":   "bad id",
+		"too long": strings.Repeat("a", maxRequestIDLength+1),
+		"newline":  "id\nforged",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set(RequestIDHeader, header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		_, err := uuid.Parse(w.Body.String())
+		assert.NoError(t, err, name)
+		assert.Equal(t, w.Body.String(), w.Header().Get(RequestIDHeader), name)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "upstream-42")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "upstream-42", w.Body.String())
+}
@@ -0,0 +1,232 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"expvar"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"call-service/internal/cache"
+	"call-service/internal/i18n"
+	"call-service/internal/tenant"
+)
+
+// CacheHeader — заголовок ответа маршрута с кэшем ответов: HIT, если ответ взят из кэша,
+// и MISS, если ответ сформирован обработчиком.
+const CacheHeader = "X-Cache"
+
+// maxCachedResponseSize — наибольший размер тела ответа, сохраняемого в кэше; большие ответы
+// отдаются без кэширования.
+const maxCachedResponseSize = 1 << 20
+
+// Префиксы ключей кэша ответов в cache.Store.
+const (
+	responseCachePrefix     = "response:"
+	responseNamespacePrefix = "response:ns:"
+)
+
+// responseCacheStats — показатели кэша ответов в /debug/vars: hits и misses — запросы,
+// обслуженные из кэша и обработчиком, errors — ошибки хранилища.
+var responseCacheStats = expvar.NewMap("response_cache")
+
+// cachedResponse — ответ, сохраненный в кэше.
+
+type cachedResponse struct {
+	Status int                 `json:"status"`
+	Header map[string][]string `json:"header"`
+	Body   []byte              `json:"body"`
+}
+
+// ResponseCache хранит ответы дорогих маршрутов чтения в cache.Store в течение короткого
+// времени. Кэш подключается к маршруту явно (Cache) и применяется только к запросам,
+// прошедшим аутентификацию.
+//
+// Ключ ответа содержит ID пользователя, поэтому ответ одного пользователя не отдается
+// другому, а также язык ответа и строку запроса с параметрами в каноническом порядке.
+// Кроме того, ключ содержит организацию и роль пользователя в ней, а также пространства имен
+// пользователя и организации — значения,
+// которые InvalidateCalls заменяет после изменения заявок. Прежние ответы после этого
+// не находятся и истекают сами. Пространства имен читаются до вызова обработчика, а заменяются
+// после сохранения изменения, поэтому ответ, сформированный одновременно с изменением,
+// сохраняется под прежним пространством имен и следующему запросу не отдается.
+//
+// Ошибки хранилища записываются в журнал: запрос обрабатывается без кэша.
+
+type ResponseCache struct {
+	store cache.Store
+	ttl   time.Duration
+}
+
+// NewResponseCache создает кэш ответов в store со временем жизни ответа ttl.
+func NewResponseCache(store cache.Store, ttl time.Duration) *ResponseCache {
+	return &ResponseCache{store: store, ttl: ttl}
+}
+
+// Cache возвращает middleware кэша ответов маршрута route (имя маршрута входит в ключ).
+// Сохраняются только ответы 200 вместе с Content-Type и заголовками headers, например
+// общим числом записей списка.
+func (rc *ResponseCache) Cache(route string, headers ...string) gin.HandlerFunc {
+	headers = append([]string{"Content-Type"}, headers...)
+	return func(c *gin.Context) {
+		userID, ok := GetUserID(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		ctx := c.Request.Context()
+		key, err := rc.key(ctx, route, userID, c)
+		if err != nil {
+			rc.failed("read response cache namespace", err)
+			c.Next()
+			return
+		}
+
+		data, found, err := rc.store.Get(ctx, key)
+		if err != nil {
+			rc.failed("read cached response", err)
+		}
+		var cached cachedResponse
+		if found && json.Unmarshal(data, &cached) == nil {
+			responseCacheStats.Add("hits", 1)
+			for name, values := range cached.Header {
+				c.Writer.Header()[name] = values
+			}
+			c.Header(CacheHeader, "HIT")
+			c.Status(cached.Status)
+			_, _ = c.Writer.Write(cached.Body)
+			c.Abort()
+			return
+		}
+
+		responseCacheStats.Add("misses", 1)
+		c.Header(CacheHeader, "MISS")
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if w.Status() != http.StatusOK || w.overflow || c.IsAborted() {
+			return
+		}
+		cached = cachedResponse{Status: http.StatusOK, Header: make(map[string][]string), Body: w.body.Bytes()}
+		for _, name := range headers {
+			if values := c.Writer.Header().Values(name); len(values) > 0 {
+				cached.Header[http.CanonicalHeaderKey(name)] = values
+			}
+		}
+		data, err = json.Marshal(cached)
+		if err == nil {
+			err = rc.store.Set(ctx, key, data, rc.ttl)
+		}
+		if err != nil {
+			rc.failed("store response in cache", err)
+		}
+	}
+}
+
+// InvalidateCalls заменяет пространства имен пользователей userIDs и организации orgID
+// (nil — без организации), после чего их кэшированные ответы больше не отдаются.
+// Вызывается сервисом заявок после изменения заявок. Замена выполняется и после отмены
+// ctx: изменение уже сохранено.
+func (rc *ResponseCache) InvalidateCalls(ctx context.Context, userIDs []uuid.UUID, orgID *uuid.UUID) {
+	ctx = context.WithoutCancel(ctx)
+	for _, userID := range userIDs {
+		rc.bump(ctx, userNamespace(userID))
+	}
+	if orgID != nil {
+		rc.bump(ctx, orgNamespace(*orgID))
+	}
+}
+
+// bump заменяет значение пространства имен случайным. Значение хранится ttl: после его
+// истечения пространство имен снова пусто, но ответы, сохраненные до замены, к этому времени
+// тоже истекли.
+func (rc *ResponseCache) bump(ctx context.Context, namespace string) {
+	if err := rc.store.Set(ctx, namespace, []byte(uuid.NewString()), rc.ttl); err != nil {
+		rc.failed("invalidate cached responses", err)
+	}
+}
+
+// key возвращает ключ ответа на запрос пользователя userID к маршруту route.
+func (rc *ResponseCache) key(ctx context.Context, route string, userID uuid.UUID, c *gin.Context) (string, error) {
+	userNS, err := rc.namespace(ctx, userNamespace(userID))
+	if err != nil {
+		return "", err
+	}
+	orgNS := "-"
+	if m, ok := tenant.FromContext(ctx); ok {
+		version, err := rc.namespace(ctx, orgNamespace(m.OrgID))
+		if err != nil {
+			return "", err
+		}
+		orgNS = m.OrgID.String() + "." + m.Role + "." + version
+	}
+	// Query().Encode() упорядочивает параметры по имени
+	sum := sha256.Sum256([]byte(i18n.Lang(c.GetHeader("Accept-Language")) + "\x00" + c.Request.URL.Query().Encode()))
+	return responseCachePrefix + strings.Join([]string{route, userID.String(), userNS, orgNS, hex.EncodeToString(sum[:])}, ":"), nil
+}
+
+// namespace возвращает текущее значение пространства имен; пустое пространство имен — "0".
+func (rc *ResponseCache) namespace(ctx context.Context, key string) (string, error) {
+	value, ok, err := rc.store.Get(ctx, key)
+	if err != nil || !ok {
+		return "0", err
+	}
+	return string(value), nil
+}
+
+// failed учитывает ошибку хранилища в /debug/vars и записывает ее в журнал.
+func (rc *ResponseCache) failed(action string, err error) {
+	responseCacheStats.Add("errors", 1)
+	log.Printf("%s: %v", action, err)
+}
+
+func userNamespace(userID uuid.UUID) string {
+	return responseNamespacePrefix + "user:" + userID.String()
+}
+
+func orgNamespace(orgID uuid.UUID) string {
+	return responseNamespacePrefix + "org:" + orgID.String()
+}
+
+// recordingWriter передает ответ дальше и копирует тело, пока оно не больше
+// maxCachedResponseSize.
+
+type recordingWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+// Write передает data дальше и копирует в тело ответа.
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.record(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString передает s дальше и копирует в тело ответа.
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *recordingWriter) record(data []byte) {
+	if w.overflow {
+		return
+	}
+	if w.body.Len()+len(data) > maxCachedResponseSize {
+		w.overflow = true
+		w.body = bytes.Buffer{}
+		return
+	}
+	w.body.Write(data)
+}
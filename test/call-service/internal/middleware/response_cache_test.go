@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/cache"
+)
+
+// setupResponseCacheRouter настраивает маршрутизатор, в котором пользователь запроса задается
+// заголовком X-User-ID, а GET /calls кэшируется и отвечает счетчиком вызовов обработчика.
+
+func setupResponseCacheRouter(rc *ResponseCache, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if id, err := uuid.Parse(c.GetHeader("X-User-ID")); err == nil {
+			setPrincipal(c, Principal{UserID: id, Role: RoleUser, AuthMethod: AuthMethodJWT})
+		}
+		c.Next()
+	})
+	router.GET("/calls", rc.Cache("calls", "X-Total-Count"), handler)
+	return router
+}
+
+// countingHandler возвращает обработчик, который отвечает номером своего вызова.
+
+func countingHandler(calls *atomic.Int32) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		n := calls.Add(1)
+		c.Header("X-Total-Count", "1")
+		c.Header("X-Debug", "handler")
+		c.JSON(http.StatusOK, gin.H{"call": n})
+	}
+}
+
+// getCalls выполняет GET /calls с параметрами query от имени пользователя userID.
+
+func getCalls(router http.Handler, userID uuid.UUID, query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/calls"+query, nil)
+	req.Header.Set("X-User-ID", userID.String())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// Тест повторного запроса: ответ берется из кэша вместе с заявленными заголовками
+func TestResponseCache_HitAndMiss(t *testing.T) {
+	var calls atomic.Int32
+	router := setupResponseCacheRouter(NewResponseCache(cache.NewMemory(0, nil), time.Minute), countingHandler(&calls))
+	userID := uuid.New()
+
+	first := getCalls(router, userID, "?status=open&page=1")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "MISS", first.Header().Get(CacheHeader))
+
+	// Параметры в другом порядке дают тот же ключ
+	second := getCalls(router, userID, "?page=1&status=open")
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, "HIT", second.Header().Get(CacheHeader))
+	assert.JSONEq(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "1", second.Header().Get("X-Total-Count"))
+	assert.Equal(t, "application/json; charset=utf-8", second.Header().Get("Content-Type"))
+	// Незаявленные заголовки не сохраняются
+	assert.Empty(t, second.Header().Get("X-Debug"))
+
+	third := getCalls(router, userID, "?page=2&status=open")
+	assert.Equal(t, "MISS", third.Header().Get(CacheHeader))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+// Тест разделения кэша: ответ одного пользователя не отдается другому
+func TestResponseCache_PerUser(t *testing.T) {
+	var calls atomic.Int32
+	router := setupResponseCacheRouter(NewResponseCache(cache.NewMemory(0, nil), time.Minute), countingHandler(&calls))
+
+	getCalls(router, uuid.New(), "")
+	w := getCalls(router, uuid.New(), "")
+
+	assert.Equal(t, "MISS", w.Header().Get(CacheHeader))
+	assert.JSONEq(t, `{"call":2}`, w.Body.String())
+}
+
+// Тест: ошибки и запросы без пользователя не кэшируются
+func TestResponseCache_SkipsErrorsAndAnonymous(t *testing.T) {
+	var calls atomic.Int32
+	router := setupResponseCacheRouter(NewResponseCache(cache.NewMemory(0, nil), time.Minute), func(c *gin.Context) {
+		calls.Add(1)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed"})
+	})
+	userID := uuid.New()
+
+	getCalls(router, userID, "")
+	w := getCalls(router, userID, "")
+	assert.Equal(t, "MISS", w.Header().Get(CacheHeader))
+
+	req := httptest.NewRequest(http.MethodGet, "/calls", nil)
+	anonymous := httptest.NewRecorder()
+	router.ServeHTTP(anonymous, req)
+	assert.Empty(t, anonymous.Header().Get(CacheHeader))
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+// Тест сброса: после InvalidateCalls ответы пользователя формируются заново,
+// а ответы других пользователей остаются в кэше
+func TestResponseCache_InvalidateCalls(t *testing.T) {
+	var calls atomic.Int32
+	rc := NewResponseCache(cache.NewMemory(0, nil), time.Minute)
+	router := setupResponseCacheRouter(rc, countingHandler(&calls))
+	userID, otherID := uuid.New(), uuid.New()
+	getCalls(router, userID, "")
+	getCalls(router, otherID, "")
+
+	rc.InvalidateCalls(context.Background(), []uuid.UUID{userID}, nil)
+
+	w := getCalls(router, userID, "")
+	assert.Equal(t, "MISS", w.Header().Get(CacheHeader))
+	assert.JSONEq(t, `{"call":3}`, w.Body.String())
+	assert.Equal(t, "HIT", getCalls(router, otherID, "").Header().Get(CacheHeader))
+}
+
+// Тест одновременного изменения: ответ, сформированный до сброса пространства имен,
+// сохраняется под прежним пространством имен и следующему запросу не отдается
+func TestResponseCache_InvalidateDuringRequest(t *testing.T) {
+	var calls atomic.Int32
+	rc := NewResponseCache(cache.NewMemory(0, nil), time.Minute)
+	userID := uuid.New()
+	started, release := make(chan struct{}), make(chan struct{})
+	router := setupResponseCacheRouter(rc, func(c *gin.Context) {
+		// Первый запрос читает данные до изменения и завершается после сброса
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		c.JSON(http.StatusOK, gin.H{"call": calls.Load()})
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- getCalls(router, userID, "") }()
+	<-started
+	rc.InvalidateCalls(context.Background(), []uuid.UUID{userID}, nil)
+	close(release)
+	stale := <-done
+	require.Equal(t, "MISS", stale.Header().Get(CacheHeader))
+
+	w := getCalls(router, userID, "")
+	assert.Equal(t, "MISS", w.Header().Get(CacheHeader))
+	assert.JSONEq(t, `{"call":2}`, w.Body.String())
+	assert.Equal(t, "HIT", getCalls(router, userID, "").Header().Get(CacheHeader))
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"common/slo"
+)
+
+// SLO возвращает middleware, которое учитывает запросы к маршрутам в целях уровня
+// обслуживания registry. Запрос учитывается по шаблону маршрута (например, "/calls/:id");
+// запросы к незарегистрированным путям не учитываются. Ошибкой сервера считается ответ 5xx.
+func SLO(registry *slo.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		if route := c.FullPath(); route != "" {
+			registry.ObserveRoute(c.Request.Method, route, time.Since(start), c.Writer.Status() >= http.StatusInternalServerError)
+		}
+	}
+}
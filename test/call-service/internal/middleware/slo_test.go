@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"common/slo"
+)
+
+// TestSLO проверяет, что запросы учитываются по шаблону маршрута, ошибкой считается
+// только ответ 5xx, а запросы к неизвестным путям не учитываются.
+
+func TestSLO(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry, err := slo.New([]slo.Objective{{Name: "get_call", Endpoint: "GET /calls/:id", Target: 0.9}}, 0, nil)
+	require.NoError(t, err)
+	router := gin.New()
+	router.Use(SLO(registry))
+	router.GET("/calls/:id", func(c *gin.Context) {
+		switch c.Param("id") {
+		case "missing":
+			c.Status(http.StatusNotFound)
+		case "broken":
+			c.Status(http.StatusInternalServerError)
+		default:
+			c.Status(http.StatusOK)
+		}
+	})
+
+	for _, path := range []string{"/calls/1", "/calls/2", "/calls/missing", "/calls/broken", "/unknown"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	window := registry.Summary().Objectives[0].Windows[0]
+	assert.Equal(t, int64(4), window.Total)
+	assert.Equal(t, int64(1), window.Bad)
+	assert.Equal(t, 2.5, window.BurnRate)
+}
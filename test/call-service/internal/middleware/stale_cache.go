@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"expvar"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"call-service/internal/cache"
+	"common/clock"
+)
+
+// staleCacheSize — максимальное число токенов в кэше проверок, хранящемся в памяти процесса.
+const staleCacheSize = 10000
+
+// Префиксы ключей кэша проверок в cache.Store.
+const (
+	staleTokenPrefix   = "auth:stale:token:"
+	staleRevokedPrefix = "auth:stale:revoked:"
+)
+
+// degradedAuth — показатели режима деградации, публикуемые в /debug/vars:
+// served — запросы, пропущенные по кэшированной проверке токена,
+// rejected — запросы, отклоненные из-за недоступности сервиса аутентификации.
+var degradedAuth = expvar.NewMap("auth_degraded")
+
+// staleEntry — последняя успешная проверка токена.
+
+type staleEntry struct {
+	Principal   Principal `json:"principal"`
+	ValidatedAt time.Time `json:"validated_at"`
+}
+
+// staleCache хранит последние успешные проверки токенов и отдает их, пока сервис
+// аутентификации недоступен. Токены хранятся в виде хешей SHA-256. Если хранилище общее
+// для нескольких экземпляров сервиса (Redis), проверка, выполненная одним экземпляром,
+// доступна остальным. Ошибки хранилища записываются в журнал: запись не сохраняется,
+// а поиск ничего не находит.
+
+type staleCache struct {
+	ttl   time.Duration
+	clock clock.Clock
+	// entries — хранилище проверок и отметок об их отмене.
+	entries cache.Store
+}
+
+// newStaleCache создает кэш проверок в store; nil store означает память процесса.
+
+func newStaleCache(ttl time.Duration, c clock.Clock, store cache.Store) *staleCache {
+	if c == nil {
+		c = clock.Real
+	}
+	if store == nil {
+		store = cache.NewMemory(staleCacheSize, c)
+	}
+	return &staleCache{ttl: ttl, clock: c, entries: store}
+}
+
+// store запоминает успешную проверку токена.
+
+func (s *staleCache) store(ctx context.Context, token string, principal Principal) {
+	data, err := json.Marshal(staleEntry{Principal: principal, ValidatedAt: s.clock.Now()})
+	if err == nil {
+		err = s.entries.Set(ctx, tokenKey(token), data, s.ttl)
+	}
+	if err != nil {
+		log.Printf("store token validation in cache: %v", err)
+	}
+}
+
+// forget удаляет токен, который сервис аутентификации признал недействительным.
+
+func (s *staleCache) forget(ctx context.Context, token string) {
+	if err := s.entries.Del(ctx, tokenKey(token)); err != nil {
+		log.Printf("delete token validation from cache: %v", err)
+	}
+}
+
+// forgetUser отменяет все кэшированные проверки токенов пользователя, например после
+// изменения его участия в организации: запоминается момент отмены, и проверки, выполненные
+// не позже него, не используются. Проверки хранятся не дольше ttl, поэтому и отметка
+// хранится ttl.
+
+func (s *staleCache) forgetUser(ctx context.Context, userID uuid.UUID) {
+	revokedAt, err := s.clock.Now().MarshalText()
+	if err == nil {
+		err = s.entries.Set(ctx, staleRevokedPrefix+userID.String(), revokedAt, s.ttl)
+	}
+	if err != nil {
+		log.Printf("revoke cached token validations of user %s: %v", userID, err)
+	}
+}
+
+// lookup возвращает кэшированного пользователя токена с признаком Degraded, если последняя
+// успешная проверка была не раньше ttl назад, не отменена forgetUser и срок действия токена не истек.
+
+func (s *staleCache) lookup(ctx context.Context, token string) (Principal, bool) {
+	entry, ok := s.get(ctx, token)
+	if !ok {
+		return Principal{}, false
+	}
+	now := s.clock.Now()
+	if !now.Before(entry.ValidatedAt.Add(s.ttl)) {
+		return Principal{}, false
+	}
+	if !entry.Principal.TokenExpiresAt.IsZero() && !now.Before(entry.Principal.TokenExpiresAt) {
+		return Principal{}, false
+	}
+	if s.revoked(ctx, entry) {
+		return Principal{}, false
+	}
+	principal := entry.Principal
+	principal.Degraded = true
+	return principal, true
+}
+
+// get читает кэшированную проверку токена.
+
+func (s *staleCache) get(ctx context.Context, token string) (staleEntry, bool) {
+	data, ok, err := s.entries.Get(ctx, tokenKey(token))
+	if err != nil {
+		log.Printf("read token validation from cache: %v", err)
+		return staleEntry{}, false
+	}
+	var entry staleEntry
+	if !ok || json.Unmarshal(data, &entry) != nil {
+		return staleEntry{}, false
+	}
+	return entry, true
+}
+
+// revoked сообщает, что проверки токенов пользователя отменены после проверки entry.
+// Если отметку не удалось прочитать, проверка считается отмененной.
+
+func (s *staleCache) revoked(ctx context.Context, entry staleEntry) bool {
+	data, ok, err := s.entries.Get(ctx, staleRevokedPrefix+entry.Principal.UserID.String())
+	if err != nil {
+		log.Printf("read revoked token validations from cache: %v", err)
+		return true
+	}
+	if !ok {
+		return false
+	}
+	var revokedAt time.Time
+	if revokedAt.UnmarshalText(data) != nil {
+		return true
+	}
+	return !entry.ValidatedAt.After(revokedAt)
+}
+
+// tokenKey возвращает ключ кэшированной проверки токена.
+
+func tokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return staleTokenPrefix + hex.EncodeToString(sum[:])
+}
+
+// isTransportError сообщает, что проверка токена не выполнена из-за недоступности
+// или сбоя сервиса аутентификации, а не потому, что токен отклонен.
+
+func isTransportError(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted,
+		codes.Aborted, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
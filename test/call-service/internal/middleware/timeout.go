@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"call-service/internal/i18n"
+)
+
+// DefaultRequestTimeout — ограничение времени обработки одного HTTP-запроса по умолчанию.
+const DefaultRequestTimeout = 10 * time.Second
+
+// TimeoutConfig содержит параметры middleware ограничения времени запроса.
+type TimeoutConfig struct {
+	// Timeout — ограничение времени обработки запроса; 0 означает DefaultRequestTimeout.
+	Timeout time.Duration
+	// SkipPaths — пути или шаблоны маршрутов (например, /admin/users/:id/export), запросы
+	// к которым не ограничиваются по времени (например, потоковая выгрузка /calls/export).
+	SkipPaths []string
+}
+
+// Timeout возвращает middleware, ограничивающее время обработки запроса: контекст
+// c.Request.Context() получает срок cfg.Timeout, поэтому сервисный слой, репозитории
+// и клиент сервиса аутентификации прерывают работу при его истечении.
+//
+// Обработчик выполняется в той же горутине, и middleware не может прервать его
+// принудительно; оно полагается на то, что обработчик учитывает отмену контекста.
+// Если срок истек, а обработчик ничего не записал, клиенту отправляется 503.
+func Timeout(cfg TimeoutConfig) gin.HandlerFunc {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultRequestTimeout
+	}
+	skip := make(map[string]bool, len(cfg.SkipPaths))
+	for _, path := range cfg.SkipPaths {
+		skip[path] = true
+	}
+
+	return func(c *gin.Context) {
+		if skip[c.Request.URL.Path] || skip[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.Timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, i18n.Response(c, i18n.RequestTimedOut))
+		}
+	}
+}
+
+// DeadlineExceeded сообщает, истек ли срок обработки запроса, установленный middleware Timeout.
+// Отмена запроса клиентом истечением срока не считается.
+func DeadlineExceeded(c *gin.Context) bool {
+	return errors.Is(c.Request.Context().Err(), context.DeadlineExceeded)
+}
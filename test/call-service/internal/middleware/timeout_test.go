@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupTimeoutRouter настраивает маршрутизатор с ограничением времени запроса 20ms.
+// Маршрут /slow ждет отмены контекста и ничего не записывает, /fast отвечает сразу,
+// /export пропускается middleware и сообщает, есть ли у контекста срок.
+
+func setupTimeoutRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Timeout(TimeoutConfig{Timeout: 20 * time.Millisecond, SkipPaths: []string{"/export"}}))
+	router.GET("/slow", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+	})
+	router.GET("/fast", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "ok"})
+	})
+	router.GET("/export", func(c *gin.Context) {
+		_, hasDeadline := c.Request.Context().Deadline()
+		c.JSON(http.StatusOK, gin.H{"deadline": hasDeadline})
+	})
+	return router
+}
+
+// TestTimeout проверяет ответ 503 при истечении срока, отсутствие влияния на быстрые
+// запросы и пропуск путей из SkipPaths.
+
+func TestTimeout(t *testing.T) {
+	router := setupTimeoutRouter()
+
+	w := doGet(router, "/slow", "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"error":"request timed out","code":"request_timed_out"}`, w.Body.String())
+
+	w = doGet(router, "/fast", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"message":"ok"}`, w.Body.String())
+
+	w = doGet(router, "/export", "")
+	assert.JSONEq(t, `{"deadline":false}`, w.Body.String())
+}
+
+// TestTimeout_HandlerResponseKept проверяет, что ответ, записанный обработчиком после
+// истечения срока, не заменяется ответом 503.
+
+func TestTimeout_HandlerResponseKept(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Timeout(TimeoutConfig{Timeout: time.Millisecond}))
+	router.GET("/", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		assert.True(t, DeadlineExceeded(c))
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "request timed out"})
+	})
+
+	w := doGet(router, "/", "")
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.JSONEq(t, `{"error":"request timed out"}`, w.Body.String())
+}
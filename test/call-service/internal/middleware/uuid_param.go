@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"call-service/internal/i18n"
+)
+
+// uuidParamKeyPrefix — префикс ключей контекста gin, под которыми BindUUIDParam сохраняет
+// разобранные параметры пути.
+const uuidParamKeyPrefix = "uuid_param:"
+
+// errNonCanonicalUUID — UUID записан не в каноническом виде.
+var errNonCanonicalUUID = errors.New("uuid must be in canonical form")
+
+// BindUUIDParam возвращает middleware, которое разбирает параметр пути param как UUID
+// и сохраняет его для GetUUIDParam. Если параметр не является UUID, запрос прерывается
+// с кодом 400 и кодом ошибки code (например, i18n.InvalidCallID).
+//
+// Принимается только канонический вид из 36 символов в любом регистре; пробелы вокруг
+// значения, фигурные скобки, префикс urn:uuid: и запись без дефисов отклоняются,
+// чтобы один и тот же ресурс не адресовался несколькими путями.
+
+func BindUUIDParam(param string, code i18n.Code) gin.HandlerFunc {
+	key := uuidParamKeyPrefix + param
+	return func(c *gin.Context) {
+		id, err := parseCanonicalUUID(c.Param(param))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, i18n.Response(c, code))
+			return
+		}
+		c.Set(key, id)
+		c.Next()
+	}
+}
+
+// GetUUIDParam возвращает параметр пути param, разобранный BindUUIDParam. Маршрут должен
+// подключать BindUUIDParam для этого параметра; иначе возвращается uuid.Nil.
+
+func GetUUIDParam(c *gin.Context, param string) uuid.UUID {
+	value, _ := c.Get(uuidParamKeyPrefix + param)
+	id, _ := value.(uuid.UUID)
+	return id
+}
+
+// parseCanonicalUUID разбирает UUID в каноническом виде xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx.
+
+func parseCanonicalUUID(s string) (uuid.UUID, error) {
+	if len(s) != 36 {
+		return uuid.Nil, errNonCanonicalUUID
+	}
+	return uuid.Parse(s)
+}
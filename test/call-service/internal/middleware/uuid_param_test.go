@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/i18n"
+)
+
+// setupUUIDParamRouter создает маршрутизатор с маршрутом /calls/:id/attachments/:aid,
+// который возвращает разобранные параметры пути.
+
+func setupUUIDParamRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/calls/:id/attachments/:aid",
+		BindUUIDParam("id", i18n.InvalidCallID),
+		BindUUIDParam("aid", i18n.InvalidAttachmentID),
+		func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{
+				"id":  GetUUIDParam(c, "id"),
+				"aid": GetUUIDParam(c, "aid"),
+			})
+		})
+	return router
+}
+
+// TestBindUUIDParam проверяет разбор параметров пути: UUID в любом регистре принимается
+// и передается обработчику, остальные значения отклоняются с кодом ошибки параметра.
+
+func TestBindUUIDParam(t *testing.T) {
+	router := setupUUIDParamRouter()
+	callID := uuid.New()
+	attachmentID := uuid.New()
+
+	tests := []struct {
+		name string
+		id   string
+		aid  string
+		code i18n.Code
+	}{
+		{name: "канонический вид", id: callID.String(), aid: attachmentID.String()},
+		{name: "верхний регистр", id: strings.ToUpper(callID.String()), aid: attachmentID.String()},
+		{name: "не UUID", id: "42", aid: attachmentID.String(), code: i18n.InvalidCallID},
+		{name: "лишний символ", id: callID.String() + "0", aid: attachmentID.String(), code: i18n.InvalidCallID},
+		{name: "пробелы", id: "%20" + callID.String() + "%20", aid: attachmentID.String(), code: i18n.InvalidCallID},
+		{name: "фигурные скобки", id: "%7B" + callID.String() + "%7D", aid: attachmentID.String(), code: i18n.InvalidCallID},
+		{name: "urn", id: "urn:uuid:" + callID.String(), aid: attachmentID.String(), code: i18n.InvalidCallID},
+		{name: "без дефисов", id: strings.ReplaceAll(callID.String(), "-", ""), aid: attachmentID.String(), code: i18n.InvalidCallID},
+		{name: "второй параметр", id: callID.String(), aid: "abc", code: i18n.InvalidAttachmentID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/calls/"+tt.id+"/attachments/"+tt.aid, nil))
+
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			if tt.code != "" {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, string(tt.code), body["code"])
+				return
+			}
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, callID.String(), body["id"])
+			assert.Equal(t, attachmentID.String(), body["aid"])
+		})
+	}
+}
+
+// TestGetUUIDParam_NotBound проверяет, что без BindUUIDParam возвращается uuid.Nil.
+
+func TestGetUUIDParam_NotBound(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	assert.Equal(t, uuid.Nil, GetUUIDParam(c, "id"))
+}
@@ -0,0 +1,119 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../service/api_key_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=../service/api_key_service.go -destination=api_key_service.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "call-service/internal/model"
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAPIKeyService is a mock of APIKeyService interface.
+type MockAPIKeyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockAPIKeyServiceMockRecorder is the mock recorder for MockAPIKeyService.
+type MockAPIKeyServiceMockRecorder struct {
+	mock *MockAPIKeyService
+}
+
+// NewMockAPIKeyService creates a new mock instance.
+func NewMockAPIKeyService(ctrl *gomock.Controller) *MockAPIKeyService {
+	mock := &MockAPIKeyService{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyService) EXPECT() *MockAPIKeyServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockAPIKeyService) CreateAPIKey(ctx context.Context, userID uuid.UUID, name string, expiresAt time.Time) (*model.APIKey, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", ctx, userID, name, expiresAt)
+	ret0, _ := ret[0].(*model.APIKey)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockAPIKeyServiceMockRecorder) CreateAPIKey(ctx, userID, name, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockAPIKeyService)(nil).CreateAPIKey), ctx, userID, name, expiresAt)
+}
+
+// DeleteUserAPIKeys mocks base method.
+func (m *MockAPIKeyService) DeleteUserAPIKeys(ctx context.Context, userID uuid.UUID) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserAPIKeys", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUserAPIKeys indicates an expected call of DeleteUserAPIKeys.
+func (mr *MockAPIKeyServiceMockRecorder) DeleteUserAPIKeys(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserAPIKeys", reflect.TypeOf((*MockAPIKeyService)(nil).DeleteUserAPIKeys), ctx, userID)
+}
+
+// ListAPIKeys mocks base method.
+func (m *MockAPIKeyService) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]*model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAPIKeys", ctx, userID)
+	ret0, _ := ret[0].([]*model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAPIKeys indicates an expected call of ListAPIKeys.
+func (mr *MockAPIKeyServiceMockRecorder) ListAPIKeys(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeys", reflect.TypeOf((*MockAPIKeyService)(nil).ListAPIKeys), ctx, userID)
+}
+
+// ResolveAPIKey mocks base method.
+func (m *MockAPIKeyService) ResolveAPIKey(ctx context.Context, key string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveAPIKey", ctx, key)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveAPIKey indicates an expected call of ResolveAPIKey.
+func (mr *MockAPIKeyServiceMockRecorder) ResolveAPIKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveAPIKey", reflect.TypeOf((*MockAPIKeyService)(nil).ResolveAPIKey), ctx, key)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockAPIKeyService) RevokeAPIKey(ctx context.Context, userID, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockAPIKeyServiceMockRecorder) RevokeAPIKey(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockAPIKeyService)(nil).RevokeAPIKey), ctx, userID, id)
+}
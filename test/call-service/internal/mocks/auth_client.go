@@ -0,0 +1,475 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../../pkg/authclient/client.go
+//
+// Generated by this command:
+//
+//	mockgen -source=../../pkg/authclient/client.go -destination=auth_client.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	authclient "call-service/pkg/authclient"
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuthClient is a mock of AuthClient interface.
+type MockAuthClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthClientMockRecorder
+	isgomock struct{}
+}
+
+// MockAuthClientMockRecorder is the mock recorder for MockAuthClient.
+type MockAuthClientMockRecorder struct {
+	mock *MockAuthClient
+}
+
+// NewMockAuthClient creates a new mock instance.
+func NewMockAuthClient(ctrl *gomock.Controller) *MockAuthClient {
+	mock := &MockAuthClient{ctrl: ctrl}
+	mock.recorder = &MockAuthClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthClient) EXPECT() *MockAuthClientMockRecorder {
+	return m.recorder
+}
+
+// AcceptInvite mocks base method.
+func (m *MockAuthClient) AcceptInvite(ctx context.Context, userID, code string) (*authclient.MemberInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptInvite", ctx, userID, code)
+	ret0, _ := ret[0].(*authclient.MemberInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptInvite indicates an expected call of AcceptInvite.
+func (mr *MockAuthClientMockRecorder) AcceptInvite(ctx, userID, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptInvite", reflect.TypeOf((*MockAuthClient)(nil).AcceptInvite), ctx, userID, code)
+}
+
+// Close mocks base method.
+func (m *MockAuthClient) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockAuthClientMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockAuthClient)(nil).Close))
+}
+
+// CreateInvite mocks base method.
+func (m *MockAuthClient) CreateInvite(ctx context.Context, orgID, ownerID string, ttl time.Duration) (*authclient.InviteInfo, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInvite", ctx, orgID, ownerID, ttl)
+	ret0, _ := ret[0].(*authclient.InviteInfo)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateInvite indicates an expected call of CreateInvite.
+func (mr *MockAuthClientMockRecorder) CreateInvite(ctx, orgID, ownerID, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvite", reflect.TypeOf((*MockAuthClient)(nil).CreateInvite), ctx, orgID, ownerID, ttl)
+}
+
+// CreateOrganization mocks base method.
+func (m *MockAuthClient) CreateOrganization(ctx context.Context, userID, name string) (*authclient.OrganizationInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrganization", ctx, userID, name)
+	ret0, _ := ret[0].(*authclient.OrganizationInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrganization indicates an expected call of CreateOrganization.
+func (mr *MockAuthClientMockRecorder) CreateOrganization(ctx, userID, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganization", reflect.TypeOf((*MockAuthClient)(nil).CreateOrganization), ctx, userID, name)
+}
+
+// EraseUser mocks base method.
+func (m *MockAuthClient) EraseUser(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EraseUser indicates an expected call of EraseUser.
+func (mr *MockAuthClientMockRecorder) EraseUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUser", reflect.TypeOf((*MockAuthClient)(nil).EraseUser), ctx, userID)
+}
+
+// ExchangeOIDCToken mocks base method.
+func (m *MockAuthClient) ExchangeOIDCToken(ctx context.Context, provider, idToken string) (*authclient.AuthResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExchangeOIDCToken", ctx, provider, idToken)
+	ret0, _ := ret[0].(*authclient.AuthResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExchangeOIDCToken indicates an expected call of ExchangeOIDCToken.
+func (mr *MockAuthClientMockRecorder) ExchangeOIDCToken(ctx, provider, idToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExchangeOIDCToken", reflect.TypeOf((*MockAuthClient)(nil).ExchangeOIDCToken), ctx, provider, idToken)
+}
+
+// ExportUserData mocks base method.
+func (m *MockAuthClient) ExportUserData(ctx context.Context, userID string) (*authclient.UserExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUserData", ctx, userID)
+	ret0, _ := ret[0].(*authclient.UserExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportUserData indicates an expected call of ExportUserData.
+func (mr *MockAuthClientMockRecorder) ExportUserData(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserData", reflect.TypeOf((*MockAuthClient)(nil).ExportUserData), ctx, userID)
+}
+
+// ExportUsers mocks base method.
+func (m *MockAuthClient) ExportUsers(ctx context.Context, filter authclient.UserFilter, fn func(authclient.UserInfo) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUsers", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportUsers indicates an expected call of ExportUsers.
+func (mr *MockAuthClientMockRecorder) ExportUsers(ctx, filter, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUsers", reflect.TypeOf((*MockAuthClient)(nil).ExportUsers), ctx, filter, fn)
+}
+
+// GetUser mocks base method.
+func (m *MockAuthClient) GetUser(ctx context.Context, userID string) (*authclient.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, userID)
+	ret0, _ := ret[0].(*authclient.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockAuthClientMockRecorder) GetUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockAuthClient)(nil).GetUser), ctx, userID)
+}
+
+// GetUsers mocks base method.
+func (m *MockAuthClient) GetUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]authclient.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsers", ctx, userIDs)
+	ret0, _ := ret[0].(map[uuid.UUID]authclient.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsers indicates an expected call of GetUsers.
+func (mr *MockAuthClientMockRecorder) GetUsers(ctx, userIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockAuthClient)(nil).GetUsers), ctx, userIDs)
+}
+
+// ImpersonateUser mocks base method.
+func (m *MockAuthClient) ImpersonateUser(ctx context.Context, adminToken, userID string) (*authclient.AuthResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImpersonateUser", ctx, adminToken, userID)
+	ret0, _ := ret[0].(*authclient.AuthResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImpersonateUser indicates an expected call of ImpersonateUser.
+func (mr *MockAuthClientMockRecorder) ImpersonateUser(ctx, adminToken, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImpersonateUser", reflect.TypeOf((*MockAuthClient)(nil).ImpersonateUser), ctx, adminToken, userID)
+}
+
+// InviteToOrganization mocks base method.
+func (m *MockAuthClient) InviteToOrganization(ctx context.Context, orgID, inviterID, username string) (*authclient.MemberInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InviteToOrganization", ctx, orgID, inviterID, username)
+	ret0, _ := ret[0].(*authclient.MemberInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InviteToOrganization indicates an expected call of InviteToOrganization.
+func (mr *MockAuthClientMockRecorder) InviteToOrganization(ctx, orgID, inviterID, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InviteToOrganization", reflect.TypeOf((*MockAuthClient)(nil).InviteToOrganization), ctx, orgID, inviterID, username)
+}
+
+// ListDevices mocks base method.
+func (m *MockAuthClient) ListDevices(ctx context.Context, userID string) ([]authclient.DeviceInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDevices", ctx, userID)
+	ret0, _ := ret[0].([]authclient.DeviceInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDevices indicates an expected call of ListDevices.
+func (mr *MockAuthClientMockRecorder) ListDevices(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDevices", reflect.TypeOf((*MockAuthClient)(nil).ListDevices), ctx, userID)
+}
+
+// ListInvites mocks base method.
+func (m *MockAuthClient) ListInvites(ctx context.Context, orgID, ownerID string) ([]authclient.InviteInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInvites", ctx, orgID, ownerID)
+	ret0, _ := ret[0].([]authclient.InviteInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInvites indicates an expected call of ListInvites.
+func (mr *MockAuthClientMockRecorder) ListInvites(ctx, orgID, ownerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInvites", reflect.TypeOf((*MockAuthClient)(nil).ListInvites), ctx, orgID, ownerID)
+}
+
+// ListSessions mocks base method.
+func (m *MockAuthClient) ListSessions(ctx context.Context, userID string) ([]authclient.SessionInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessions", ctx, userID)
+	ret0, _ := ret[0].([]authclient.SessionInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSessions indicates an expected call of ListSessions.
+func (mr *MockAuthClientMockRecorder) ListSessions(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockAuthClient)(nil).ListSessions), ctx, userID)
+}
+
+// ListUsers mocks base method.
+func (m *MockAuthClient) ListUsers(ctx context.Context, filter authclient.UserFilter, limit, offset int) ([]authclient.UserInfo, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, filter, limit, offset)
+	ret0, _ := ret[0].([]authclient.UserInfo)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockAuthClientMockRecorder) ListUsers(ctx, filter, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockAuthClient)(nil).ListUsers), ctx, filter, limit, offset)
+}
+
+// Login mocks base method.
+func (m *MockAuthClient) Login(ctx context.Context, username, password string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Login", ctx, username, password)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Login indicates an expected call of Login.
+func (mr *MockAuthClientMockRecorder) Login(ctx, username, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthClient)(nil).Login), ctx, username, password)
+}
+
+// LoginFull mocks base method.
+func (m *MockAuthClient) LoginFull(ctx context.Context, username, password string) (*authclient.AuthResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginFull", ctx, username, password)
+	ret0, _ := ret[0].(*authclient.AuthResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginFull indicates an expected call of LoginFull.
+func (mr *MockAuthClientMockRecorder) LoginFull(ctx, username, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginFull", reflect.TypeOf((*MockAuthClient)(nil).LoginFull), ctx, username, password)
+}
+
+// Register mocks base method.
+func (m *MockAuthClient) Register(ctx context.Context, username, password string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Register", ctx, username, password)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockAuthClientMockRecorder) Register(ctx, username, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockAuthClient)(nil).Register), ctx, username, password)
+}
+
+// RegisterFull mocks base method.
+func (m *MockAuthClient) RegisterFull(ctx context.Context, username, password string) (*authclient.AuthResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterFull", ctx, username, password)
+	ret0, _ := ret[0].(*authclient.AuthResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterFull indicates an expected call of RegisterFull.
+func (mr *MockAuthClientMockRecorder) RegisterFull(ctx, username, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterFull", reflect.TypeOf((*MockAuthClient)(nil).RegisterFull), ctx, username, password)
+}
+
+// RevokeAllSessions mocks base method.
+func (m *MockAuthClient) RevokeAllSessions(ctx context.Context, userID, exceptSessionID string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAllSessions", ctx, userID, exceptSessionID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAllSessions indicates an expected call of RevokeAllSessions.
+func (mr *MockAuthClientMockRecorder) RevokeAllSessions(ctx, userID, exceptSessionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllSessions", reflect.TypeOf((*MockAuthClient)(nil).RevokeAllSessions), ctx, userID, exceptSessionID)
+}
+
+// RevokeInvite mocks base method.
+func (m *MockAuthClient) RevokeInvite(ctx context.Context, orgID, ownerID, inviteID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeInvite", ctx, orgID, ownerID, inviteID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeInvite indicates an expected call of RevokeInvite.
+func (mr *MockAuthClientMockRecorder) RevokeInvite(ctx, orgID, ownerID, inviteID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeInvite", reflect.TypeOf((*MockAuthClient)(nil).RevokeInvite), ctx, orgID, ownerID, inviteID)
+}
+
+// RevokeSession mocks base method.
+func (m *MockAuthClient) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, userID, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockAuthClientMockRecorder) RevokeSession(ctx, userID, sessionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockAuthClient)(nil).RevokeSession), ctx, userID, sessionID)
+}
+
+// StreamUsers mocks base method.
+func (m *MockAuthClient) StreamUsers(ctx context.Context, filter authclient.UserFilter, batchSize int) *authclient.UserIterator {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamUsers", ctx, filter, batchSize)
+	ret0, _ := ret[0].(*authclient.UserIterator)
+	return ret0
+}
+
+// StreamUsers indicates an expected call of StreamUsers.
+func (mr *MockAuthClientMockRecorder) StreamUsers(ctx, filter, batchSize any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamUsers", reflect.TypeOf((*MockAuthClient)(nil).StreamUsers), ctx, filter, batchSize)
+}
+
+// UpdateEmail mocks base method.
+func (m *MockAuthClient) UpdateEmail(ctx context.Context, userID, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEmail", ctx, userID, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEmail indicates an expected call of UpdateEmail.
+func (mr *MockAuthClientMockRecorder) UpdateEmail(ctx, userID, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEmail", reflect.TypeOf((*MockAuthClient)(nil).UpdateEmail), ctx, userID, email)
+}
+
+// ValidateToken mocks base method.
+func (m *MockAuthClient) ValidateToken(ctx context.Context, token string) (bool, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateToken", ctx, token)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ValidateToken indicates an expected call of ValidateToken.
+func (mr *MockAuthClientMockRecorder) ValidateToken(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateToken", reflect.TypeOf((*MockAuthClient)(nil).ValidateToken), ctx, token)
+}
+
+// ValidateTokenFull mocks base method.
+func (m *MockAuthClient) ValidateTokenFull(ctx context.Context, token string) (*authclient.TokenInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateTokenFull", ctx, token)
+	ret0, _ := ret[0].(*authclient.TokenInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateTokenFull indicates an expected call of ValidateTokenFull.
+func (mr *MockAuthClientMockRecorder) ValidateTokenFull(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateTokenFull", reflect.TypeOf((*MockAuthClient)(nil).ValidateTokenFull), ctx, token)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockAuthClient) VerifyEmail(ctx context.Context, userID, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, userID, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockAuthClientMockRecorder) VerifyEmail(ctx, userID, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockAuthClient)(nil).VerifyEmail), ctx, userID, token)
+}
+
+// VerifyPassword mocks base method.
+func (m *MockAuthClient) VerifyPassword(ctx context.Context, userID, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyPassword", ctx, userID, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyPassword indicates an expected call of VerifyPassword.
+func (mr *MockAuthClientMockRecorder) VerifyPassword(ctx, userID, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyPassword", reflect.TypeOf((*MockAuthClient)(nil).VerifyPassword), ctx, userID, password)
+}
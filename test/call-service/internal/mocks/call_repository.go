@@ -0,0 +1,336 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../repository/call_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=../repository/call_repository.go -destination=call_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "call-service/internal/model"
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCallRepository is a mock of CallRepository interface.
+type MockCallRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCallRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockCallRepositoryMockRecorder is the mock recorder for MockCallRepository.
+type MockCallRepositoryMockRecorder struct {
+	mock *MockCallRepository
+}
+
+// NewMockCallRepository creates a new mock instance.
+func NewMockCallRepository(ctrl *gomock.Controller) *MockCallRepository {
+	mock := &MockCallRepository{ctrl: ctrl}
+	mock.recorder = &MockCallRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCallRepository) EXPECT() *MockCallRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddDialAttempt mocks base method.
+func (m *MockCallRepository) AddDialAttempt(ctx context.Context, attempt *model.CallStatusChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddDialAttempt", ctx, attempt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddDialAttempt indicates an expected call of AddDialAttempt.
+func (mr *MockCallRepositoryMockRecorder) AddDialAttempt(ctx, attempt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDialAttempt", reflect.TypeOf((*MockCallRepository)(nil).AddDialAttempt), ctx, attempt)
+}
+
+// CloseStale mocks base method.
+func (m *MockCallRepository) CloseStale(ctx context.Context, before time.Time, limit int, actorID uuid.UUID) ([]*model.Call, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseStale", ctx, before, limit, actorID)
+	ret0, _ := ret[0].([]*model.Call)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloseStale indicates an expected call of CloseStale.
+func (mr *MockCallRepositoryMockRecorder) CloseStale(ctx, before, limit, actorID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseStale", reflect.TypeOf((*MockCallRepository)(nil).CloseStale), ctx, before, limit, actorID)
+}
+
+// Create mocks base method.
+func (m *MockCallRepository) Create(ctx context.Context, call *model.Call) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, call)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockCallRepositoryMockRecorder) Create(ctx, call any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockCallRepository)(nil).Create), ctx, call)
+}
+
+// CreateMany mocks base method.
+func (m *MockCallRepository) CreateMany(ctx context.Context, calls []*model.Call) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMany", ctx, calls)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMany indicates an expected call of CreateMany.
+func (mr *MockCallRepositoryMockRecorder) CreateMany(ctx, calls any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMany", reflect.TypeOf((*MockCallRepository)(nil).CreateMany), ctx, calls)
+}
+
+// Delete mocks base method.
+func (m *MockCallRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockCallRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCallRepository)(nil).Delete), ctx, id)
+}
+
+// EraseByUserID mocks base method.
+func (m *MockCallRepository) EraseByUserID(ctx context.Context, userID uuid.UUID, policy model.ErasurePolicy) ([]*model.Call, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseByUserID", ctx, userID, policy)
+	ret0, _ := ret[0].([]*model.Call)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EraseByUserID indicates an expected call of EraseByUserID.
+func (mr *MockCallRepositoryMockRecorder) EraseByUserID(ctx, userID, policy any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseByUserID", reflect.TypeOf((*MockCallRepository)(nil).EraseByUserID), ctx, userID, policy)
+}
+
+// ForEachByUserID mocks base method.
+func (m *MockCallRepository) ForEachByUserID(ctx context.Context, userID uuid.UUID, filter model.CallFilter, fn func(*model.Call) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForEachByUserID", ctx, userID, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForEachByUserID indicates an expected call of ForEachByUserID.
+func (mr *MockCallRepositoryMockRecorder) ForEachByUserID(ctx, userID, filter, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForEachByUserID", reflect.TypeOf((*MockCallRepository)(nil).ForEachByUserID), ctx, userID, filter, fn)
+}
+
+// ForEachStatusChangeByUserID mocks base method.
+func (m *MockCallRepository) ForEachStatusChangeByUserID(ctx context.Context, userID uuid.UUID, fn func(*model.CallStatusChange) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForEachStatusChangeByUserID", ctx, userID, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForEachStatusChangeByUserID indicates an expected call of ForEachStatusChangeByUserID.
+func (mr *MockCallRepositoryMockRecorder) ForEachStatusChangeByUserID(ctx, userID, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForEachStatusChangeByUserID", reflect.TypeOf((*MockCallRepository)(nil).ForEachStatusChangeByUserID), ctx, userID, fn)
+}
+
+// GetAllByUserID mocks base method.
+func (m *MockCallRepository) GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*model.Call, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllByUserID", ctx, userID)
+	ret0, _ := ret[0].([]*model.Call)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllByUserID indicates an expected call of GetAllByUserID.
+func (mr *MockCallRepositoryMockRecorder) GetAllByUserID(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByUserID", reflect.TypeOf((*MockCallRepository)(nil).GetAllByUserID), ctx, userID)
+}
+
+// GetByID mocks base method.
+func (m *MockCallRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Call, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*model.Call)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockCallRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockCallRepository)(nil).GetByID), ctx, id)
+}
+
+// GetByRef mocks base method.
+func (m *MockCallRepository) GetByRef(ctx context.Context, ref string) (*model.Call, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByRef", ctx, ref)
+	ret0, _ := ret[0].(*model.Call)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByRef indicates an expected call of GetByRef.
+func (mr *MockCallRepositoryMockRecorder) GetByRef(ctx, ref any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByRef", reflect.TypeOf((*MockCallRepository)(nil).GetByRef), ctx, ref)
+}
+
+// List mocks base method.
+func (m *MockCallRepository) List(ctx context.Context, filter model.CallFilter) ([]*model.Call, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter)
+	ret0, _ := ret[0].([]*model.Call)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockCallRepositoryMockRecorder) List(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockCallRepository)(nil).List), ctx, filter)
+}
+
+// ListActivity mocks base method.
+func (m *MockCallRepository) ListActivity(ctx context.Context, callID uuid.UUID, after *model.ActivityCursor, limit int) ([]*model.CallActivity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListActivity", ctx, callID, after, limit)
+	ret0, _ := ret[0].([]*model.CallActivity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListActivity indicates an expected call of ListActivity.
+func (mr *MockCallRepositoryMockRecorder) ListActivity(ctx, callID, after, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActivity", reflect.TypeOf((*MockCallRepository)(nil).ListActivity), ctx, callID, after, limit)
+}
+
+// ListDueCallbacks mocks base method.
+func (m *MockCallRepository) ListDueCallbacks(ctx context.Context, now time.Time, limit int) ([]*model.Call, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDueCallbacks", ctx, now, limit)
+	ret0, _ := ret[0].([]*model.Call)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDueCallbacks indicates an expected call of ListDueCallbacks.
+func (mr *MockCallRepositoryMockRecorder) ListDueCallbacks(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDueCallbacks", reflect.TypeOf((*MockCallRepository)(nil).ListDueCallbacks), ctx, now, limit)
+}
+
+// ListStatusChanges mocks base method.
+func (m *MockCallRepository) ListStatusChanges(ctx context.Context, callID uuid.UUID) ([]*model.CallStatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStatusChanges", ctx, callID)
+	ret0, _ := ret[0].([]*model.CallStatusChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStatusChanges indicates an expected call of ListStatusChanges.
+func (mr *MockCallRepositoryMockRecorder) ListStatusChanges(ctx, callID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStatusChanges", reflect.TypeOf((*MockCallRepository)(nil).ListStatusChanges), ctx, callID)
+}
+
+// ListWithSummary mocks base method.
+func (m *MockCallRepository) ListWithSummary(ctx context.Context, filter model.CallFilter) ([]*model.Call, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWithSummary", ctx, filter)
+	ret0, _ := ret[0].([]*model.Call)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListWithSummary indicates an expected call of ListWithSummary.
+func (mr *MockCallRepositoryMockRecorder) ListWithSummary(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWithSummary", reflect.TypeOf((*MockCallRepository)(nil).ListWithSummary), ctx, filter)
+}
+
+// MarkCallbackNotified mocks base method.
+func (m *MockCallRepository) MarkCallbackNotified(ctx context.Context, id uuid.UUID, callbackAt, notifiedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkCallbackNotified", ctx, id, callbackAt, notifiedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkCallbackNotified indicates an expected call of MarkCallbackNotified.
+func (mr *MockCallRepositoryMockRecorder) MarkCallbackNotified(ctx, id, callbackAt, notifiedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkCallbackNotified", reflect.TypeOf((*MockCallRepository)(nil).MarkCallbackNotified), ctx, id, callbackAt, notifiedAt)
+}
+
+// Reassign mocks base method.
+func (m *MockCallRepository) Reassign(ctx context.Context, id, userID, actorID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reassign", ctx, id, userID, actorID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reassign indicates an expected call of Reassign.
+func (mr *MockCallRepositoryMockRecorder) Reassign(ctx, id, userID, actorID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reassign", reflect.TypeOf((*MockCallRepository)(nil).Reassign), ctx, id, userID, actorID)
+}
+
+// SetCallback mocks base method.
+func (m *MockCallRepository) SetCallback(ctx context.Context, id uuid.UUID, callbackAt *time.Time, actorID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCallback", ctx, id, callbackAt, actorID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCallback indicates an expected call of SetCallback.
+func (mr *MockCallRepositoryMockRecorder) SetCallback(ctx, id, callbackAt, actorID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCallback", reflect.TypeOf((*MockCallRepository)(nil).SetCallback), ctx, id, callbackAt, actorID)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockCallRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status model.CallStatus, actorID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, id, status, actorID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockCallRepositoryMockRecorder) UpdateStatus(ctx, id, status, actorID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockCallRepository)(nil).UpdateStatus), ctx, id, status, actorID)
+}
@@ -0,0 +1,376 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../service/call_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=../service/call_service.go -destination=call_service.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "call-service/internal/model"
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCallService is a mock of CallService interface.
+type MockCallService struct {
+	ctrl     *gomock.Controller
+	recorder *MockCallServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockCallServiceMockRecorder is the mock recorder for MockCallService.
+type MockCallServiceMockRecorder struct {
+	mock *MockCallService
+}
+
+// NewMockCallService creates a new mock instance.
+func NewMockCallService(ctrl *gomock.Controller) *MockCallService {
+	mock := &MockCallService{ctrl: ctrl}
+	mock.recorder = &MockCallServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCallService) EXPECT() *MockCallServiceMockRecorder {
+	return m.recorder
+}
+
+// CloseStaleCalls mocks base method.
+func (m *MockCallService) CloseStaleCalls(ctx context.Context, olderThan time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseStaleCalls", ctx, olderThan)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloseStaleCalls indicates an expected call of CloseStaleCalls.
+func (mr *MockCallServiceMockRecorder) CloseStaleCalls(ctx, olderThan any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseStaleCalls", reflect.TypeOf((*MockCallService)(nil).CloseStaleCalls), ctx, olderThan)
+}
+
+// CreateCall mocks base method.
+func (m *MockCallService) CreateCall(ctx context.Context, req *model.CreateCallRequest, userID uuid.UUID) (*model.Call, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCall", ctx, req, userID)
+	ret0, _ := ret[0].(*model.Call)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCall indicates an expected call of CreateCall.
+func (mr *MockCallServiceMockRecorder) CreateCall(ctx, req, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCall", reflect.TypeOf((*MockCallService)(nil).CreateCall), ctx, req, userID)
+}
+
+// CreateCalls mocks base method.
+func (m *MockCallService) CreateCalls(ctx context.Context, reqs []*model.CreateCallRequest, userID uuid.UUID) ([]*model.Call, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCalls", ctx, reqs, userID)
+	ret0, _ := ret[0].([]*model.Call)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCalls indicates an expected call of CreateCalls.
+func (mr *MockCallServiceMockRecorder) CreateCalls(ctx, reqs, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCalls", reflect.TypeOf((*MockCallService)(nil).CreateCalls), ctx, reqs, userID)
+}
+
+// DeleteCall mocks base method.
+func (m *MockCallService) DeleteCall(ctx context.Context, id, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCall", ctx, id, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCall indicates an expected call of DeleteCall.
+func (mr *MockCallServiceMockRecorder) DeleteCall(ctx, id, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCall", reflect.TypeOf((*MockCallService)(nil).DeleteCall), ctx, id, userID)
+}
+
+// DialCall mocks base method.
+func (m *MockCallService) DialCall(ctx context.Context, id, userID uuid.UUID) (*model.CallStatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DialCall", ctx, id, userID)
+	ret0, _ := ret[0].(*model.CallStatusChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DialCall indicates an expected call of DialCall.
+func (mr *MockCallServiceMockRecorder) DialCall(ctx, id, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DialCall", reflect.TypeOf((*MockCallService)(nil).DialCall), ctx, id, userID)
+}
+
+// ForEachCall mocks base method.
+func (m *MockCallService) ForEachCall(ctx context.Context, userID uuid.UUID, filter model.CallFilter, fn func(*model.Call) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForEachCall", ctx, userID, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForEachCall indicates an expected call of ForEachCall.
+func (mr *MockCallServiceMockRecorder) ForEachCall(ctx, userID, filter, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForEachCall", reflect.TypeOf((*MockCallService)(nil).ForEachCall), ctx, userID, filter, fn)
+}
+
+// ForEachStatusChange mocks base method.
+func (m *MockCallService) ForEachStatusChange(ctx context.Context, userID uuid.UUID, fn func(*model.CallStatusChange) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForEachStatusChange", ctx, userID, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForEachStatusChange indicates an expected call of ForEachStatusChange.
+func (mr *MockCallServiceMockRecorder) ForEachStatusChange(ctx, userID, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForEachStatusChange", reflect.TypeOf((*MockCallService)(nil).ForEachStatusChange), ctx, userID, fn)
+}
+
+// GetAllCalls mocks base method.
+func (m *MockCallService) GetAllCalls(ctx context.Context, userID uuid.UUID, filter model.CallFilter) ([]*model.Call, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllCalls", ctx, userID, filter)
+	ret0, _ := ret[0].([]*model.Call)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAllCalls indicates an expected call of GetAllCalls.
+func (mr *MockCallServiceMockRecorder) GetAllCalls(ctx, userID, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllCalls", reflect.TypeOf((*MockCallService)(nil).GetAllCalls), ctx, userID, filter)
+}
+
+// GetCallActivity mocks base method.
+func (m *MockCallService) GetCallActivity(ctx context.Context, id, userID uuid.UUID, after *model.ActivityCursor, limit int) ([]*model.CallActivity, *model.ActivityCursor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCallActivity", ctx, id, userID, after, limit)
+	ret0, _ := ret[0].([]*model.CallActivity)
+	ret1, _ := ret[1].(*model.ActivityCursor)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetCallActivity indicates an expected call of GetCallActivity.
+func (mr *MockCallServiceMockRecorder) GetCallActivity(ctx, id, userID, after, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCallActivity", reflect.TypeOf((*MockCallService)(nil).GetCallActivity), ctx, id, userID, after, limit)
+}
+
+// GetCallByID mocks base method.
+func (m *MockCallService) GetCallByID(ctx context.Context, id, userID uuid.UUID) (*model.Call, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCallByID", ctx, id, userID)
+	ret0, _ := ret[0].(*model.Call)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCallByID indicates an expected call of GetCallByID.
+func (mr *MockCallServiceMockRecorder) GetCallByID(ctx, id, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCallByID", reflect.TypeOf((*MockCallService)(nil).GetCallByID), ctx, id, userID)
+}
+
+// GetCallByIDAdmin mocks base method.
+func (m *MockCallService) GetCallByIDAdmin(ctx context.Context, id uuid.UUID) (*model.Call, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCallByIDAdmin", ctx, id)
+	ret0, _ := ret[0].(*model.Call)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCallByIDAdmin indicates an expected call of GetCallByIDAdmin.
+func (mr *MockCallServiceMockRecorder) GetCallByIDAdmin(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCallByIDAdmin", reflect.TypeOf((*MockCallService)(nil).GetCallByIDAdmin), ctx, id)
+}
+
+// GetCallByRef mocks base method.
+func (m *MockCallService) GetCallByRef(ctx context.Context, ref string) (*model.Call, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCallByRef", ctx, ref)
+	ret0, _ := ret[0].(*model.Call)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCallByRef indicates an expected call of GetCallByRef.
+func (mr *MockCallServiceMockRecorder) GetCallByRef(ctx, ref any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCallByRef", reflect.TypeOf((*MockCallService)(nil).GetCallByRef), ctx, ref)
+}
+
+// GetDueCalls mocks base method.
+func (m *MockCallService) GetDueCalls(ctx context.Context, userID uuid.UUID, filter model.CallFilter) ([]*model.Call, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueCalls", ctx, userID, filter)
+	ret0, _ := ret[0].([]*model.Call)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetDueCalls indicates an expected call of GetDueCalls.
+func (mr *MockCallServiceMockRecorder) GetDueCalls(ctx, userID, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueCalls", reflect.TypeOf((*MockCallService)(nil).GetDueCalls), ctx, userID, filter)
+}
+
+// ListCallsAdmin mocks base method.
+func (m *MockCallService) ListCallsAdmin(ctx context.Context, filter model.CallFilter) ([]*model.Call, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCallsAdmin", ctx, filter)
+	ret0, _ := ret[0].([]*model.Call)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListCallsAdmin indicates an expected call of ListCallsAdmin.
+func (mr *MockCallServiceMockRecorder) ListCallsAdmin(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCallsAdmin", reflect.TypeOf((*MockCallService)(nil).ListCallsAdmin), ctx, filter)
+}
+
+// NotifyDueCallbacks mocks base method.
+func (m *MockCallService) NotifyDueCallbacks(ctx context.Context, notify func(context.Context, *model.Call) error) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NotifyDueCallbacks", ctx, notify)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NotifyDueCallbacks indicates an expected call of NotifyDueCallbacks.
+func (mr *MockCallServiceMockRecorder) NotifyDueCallbacks(ctx, notify any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NotifyDueCallbacks", reflect.TypeOf((*MockCallService)(nil).NotifyDueCallbacks), ctx, notify)
+}
+
+// ReassignCall mocks base method.
+func (m *MockCallService) ReassignCall(ctx context.Context, id, userID, actorID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReassignCall", ctx, id, userID, actorID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReassignCall indicates an expected call of ReassignCall.
+func (mr *MockCallServiceMockRecorder) ReassignCall(ctx, id, userID, actorID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReassignCall", reflect.TypeOf((*MockCallService)(nil).ReassignCall), ctx, id, userID, actorID)
+}
+
+// StreamCalls mocks base method.
+func (m *MockCallService) StreamCalls(ctx context.Context, userID uuid.UUID, filter model.CallFilter, batchSize int, fn func([]*model.Call) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamCalls", ctx, userID, filter, batchSize, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamCalls indicates an expected call of StreamCalls.
+func (mr *MockCallServiceMockRecorder) StreamCalls(ctx, userID, filter, batchSize, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamCalls", reflect.TypeOf((*MockCallService)(nil).StreamCalls), ctx, userID, filter, batchSize, fn)
+}
+
+// UpdateCallStatus mocks base method.
+func (m *MockCallService) UpdateCallStatus(ctx context.Context, id uuid.UUID, status model.CallStatus, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCallStatus", ctx, id, status, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCallStatus indicates an expected call of UpdateCallStatus.
+func (mr *MockCallServiceMockRecorder) UpdateCallStatus(ctx, id, status, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCallStatus", reflect.TypeOf((*MockCallService)(nil).UpdateCallStatus), ctx, id, status, userID)
+}
+
+// UpdateCallStatusAdmin mocks base method.
+func (m *MockCallService) UpdateCallStatusAdmin(ctx context.Context, id uuid.UUID, status model.CallStatus, actorID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCallStatusAdmin", ctx, id, status, actorID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCallStatusAdmin indicates an expected call of UpdateCallStatusAdmin.
+func (mr *MockCallServiceMockRecorder) UpdateCallStatusAdmin(ctx, id, status, actorID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCallStatusAdmin", reflect.TypeOf((*MockCallService)(nil).UpdateCallStatusAdmin), ctx, id, status, actorID)
+}
+
+// UpdateCallback mocks base method.
+func (m *MockCallService) UpdateCallback(ctx context.Context, id uuid.UUID, callbackAt *time.Time, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCallback", ctx, id, callbackAt, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCallback indicates an expected call of UpdateCallback.
+func (mr *MockCallServiceMockRecorder) UpdateCallback(ctx, id, callbackAt, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCallback", reflect.TypeOf((*MockCallService)(nil).UpdateCallback), ctx, id, callbackAt, userID)
+}
+
+// MockCallCacheInvalidator is a mock of CallCacheInvalidator interface.
+type MockCallCacheInvalidator struct {
+	ctrl     *gomock.Controller
+	recorder *MockCallCacheInvalidatorMockRecorder
+	isgomock struct{}
+}
+
+// MockCallCacheInvalidatorMockRecorder is the mock recorder for MockCallCacheInvalidator.
+type MockCallCacheInvalidatorMockRecorder struct {
+	mock *MockCallCacheInvalidator
+}
+
+// NewMockCallCacheInvalidator creates a new mock instance.
+func NewMockCallCacheInvalidator(ctrl *gomock.Controller) *MockCallCacheInvalidator {
+	mock := &MockCallCacheInvalidator{ctrl: ctrl}
+	mock.recorder = &MockCallCacheInvalidatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCallCacheInvalidator) EXPECT() *MockCallCacheInvalidatorMockRecorder {
+	return m.recorder
+}
+
+// InvalidateCalls mocks base method.
+func (m *MockCallCacheInvalidator) InvalidateCalls(ctx context.Context, userIDs []uuid.UUID, orgID *uuid.UUID) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "InvalidateCalls", ctx, userIDs, orgID)
+}
+
+// InvalidateCalls indicates an expected call of InvalidateCalls.
+func (mr *MockCallCacheInvalidatorMockRecorder) InvalidateCalls(ctx, userIDs, orgID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateCalls", reflect.TypeOf((*MockCallCacheInvalidator)(nil).InvalidateCalls), ctx, userIDs, orgID)
+}
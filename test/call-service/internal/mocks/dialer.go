@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../telephony/telephony.go
+//
+// Generated by this command:
+//
+//	mockgen -source=../telephony/telephony.go -destination=dialer.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	telephony "call-service/internal/telephony"
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDialer is a mock of Dialer interface.
+type MockDialer struct {
+	ctrl     *gomock.Controller
+	recorder *MockDialerMockRecorder
+	isgomock struct{}
+}
+
+// MockDialerMockRecorder is the mock recorder for MockDialer.
+type MockDialerMockRecorder struct {
+	mock *MockDialer
+}
+
+// NewMockDialer creates a new mock instance.
+func NewMockDialer(ctrl *gomock.Controller) *MockDialer {
+	mock := &MockDialer{ctrl: ctrl}
+	mock.recorder = &MockDialerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDialer) EXPECT() *MockDialerMockRecorder {
+	return m.recorder
+}
+
+// Dial mocks base method.
+func (m *MockDialer) Dial(ctx context.Context, req telephony.DialRequest) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Dial", ctx, req)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Dial indicates an expected call of Dial.
+func (mr *MockDialerMockRecorder) Dial(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dial", reflect.TypeOf((*MockDialer)(nil).Dial), ctx, req)
+}
@@ -0,0 +1,12 @@
+// Package mocks содержит сгенерированные mockgen моки интерфейсов сервиса заявок.
+//
+// После изменения интерфейсов моки нужно перегенерировать командой
+// go generate ./internal/mocks, иначе тест TestMocksUpToDate не пройдет.
+package mocks
+
+//go:generate go tool mockgen -source=../../pkg/authclient/client.go -destination=auth_client.go -package=mocks
+//go:generate go tool mockgen -source=../service/call_service.go -destination=call_service.go -package=mocks
+//go:generate go tool mockgen -source=../repository/call_repository.go -destination=call_repository.go -package=mocks
+//go:generate go tool mockgen -source=../service/api_key_service.go -destination=api_key_service.go -package=mocks
+//go:generate go tool mockgen -source=../telephony/telephony.go -destination=dialer.go -package=mocks
+//go:generate go tool mockgen -source=../notify/notifier.go -destination=notifier.go -package=mocks
@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../notify/notifier.go
+//
+// Generated by this command:
+//
+//	mockgen -source=../notify/notifier.go -destination=notifier.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	notify "call-service/internal/notify"
+	context "context"
+	reflect "reflect"
+
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockNotifier is a mock of Notifier interface.
+type MockNotifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotifierMockRecorder
+	isgomock struct{}
+}
+
+// MockNotifierMockRecorder is the mock recorder for MockNotifier.
+type MockNotifierMockRecorder struct {
+	mock *MockNotifier
+}
+
+// NewMockNotifier creates a new mock instance.
+func NewMockNotifier(ctrl *gomock.Controller) *MockNotifier {
+	mock := &MockNotifier{ctrl: ctrl}
+	mock.recorder = &MockNotifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotifier) EXPECT() *MockNotifierMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m *MockNotifier) Send(ctx context.Context, userID uuid.UUID, n notify.Notification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", ctx, userID, n)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockNotifierMockRecorder) Send(ctx, userID, n any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockNotifier)(nil).Send), ctx, userID, n)
+}
+
+// MockPreferences is a mock of Preferences interface.
+type MockPreferences struct {
+	ctrl     *gomock.Controller
+	recorder *MockPreferencesMockRecorder
+	isgomock struct{}
+}
+
+// MockPreferencesMockRecorder is the mock recorder for MockPreferences.
+type MockPreferencesMockRecorder struct {
+	mock *MockPreferences
+}
+
+// NewMockPreferences creates a new mock instance.
+func NewMockPreferences(ctrl *gomock.Controller) *MockPreferences {
+	mock := &MockPreferences{ctrl: ctrl}
+	mock.recorder = &MockPreferencesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPreferences) EXPECT() *MockPreferencesMockRecorder {
+	return m.recorder
+}
+
+// Channels mocks base method.
+func (m *MockPreferences) Channels(ctx context.Context, userID uuid.UUID, event string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Channels", ctx, userID, event)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Channels indicates an expected call of Channels.
+func (mr *MockPreferencesMockRecorder) Channels(ctx, userID, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Channels", reflect.TypeOf((*MockPreferences)(nil).Channels), ctx, userID, event)
+}
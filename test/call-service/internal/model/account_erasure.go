@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErasurePolicy определяет, что происходит с заявками пользователя при удалении его учетной записи.
+
+type ErasurePolicy string
+
+const (
+	// ErasurePolicyAnonymize — заявки остаются в статистике, но имя и телефон клиента
+	// заменяются, а описание и запланированный звонок удаляются.
+	ErasurePolicyAnonymize ErasurePolicy = "anonymize"
+	// ErasurePolicyDelete — заявки удаляются вместе с историей статусов.
+	ErasurePolicyDelete ErasurePolicy = "delete"
+)
+
+// ErasedClientName — имя клиента в обезличенных заявках.
+const ErasedClientName = "erased"
+
+// Valid сообщает, является ли p известной политикой.
+
+func (p ErasurePolicy) Valid() bool {
+	return p == ErasurePolicyAnonymize || p == ErasurePolicyDelete
+}
+
+// ErasureState — шаг удаления учетной записи, на котором оно остановилось.
+
+type ErasureState string
+
+const (
+	// ErasureStatePending — удаление начато, сервис аутентификации еще не подтвердил
+	// удаление пользователя.
+	ErasureStatePending ErasureState = "pending"
+	// ErasureStateAuthErased — пользователь удален в сервисе аутентификации, осталось
+	// обработать его данные в сервисе заявок.
+	ErasureStateAuthErased ErasureState = "auth_erased"
+)
+
+// AccountErasure — незавершенное удаление учетной записи пользователя. Запись создается
+// до обращения к сервису аутентификации и удаляется после обработки заявок, поэтому
+// удаление, прерванное сбоем на любом шаге, можно найти и завершить повторно.
+// Policy фиксируется при создании записи, чтобы повторная попытка не зависела от смены
+// настроек; Attempts и LastError описывают неудачные попытки.
+
+type AccountErasure struct {
+	UserID    uuid.UUID     `bun:"user_id,pk,type:uuid"`
+	Policy    ErasurePolicy `bun:"policy,notnull"`
+	State     ErasureState  `bun:"state,notnull"`
+	Attempts  int           `bun:"attempts,notnull"`
+	LastError string        `bun:"last_error,notnull"`
+	CreatedAt time.Time     `bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt time.Time     `bun:"updated_at,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// APIAuditEntry — запись журнала изменяющих запросов HTTP API: кто, когда, каким запросом
+// и с каким результатом пытался изменить данные. Route — шаблон маршрута (например,
+// /calls/:id/status), ResourceID — ID изменяемого или созданного объекта, если он известен.
+// UserID равен nil для запросов без аутентификации (вход, регистрация). BodyHash — SHA-256
+// тела запроса в шестнадцатеричном виде; тела запросов с учетными данными и кодами
+// не хешируются, и у таких записей BodyRedacted равен true.
+
+type APIAuditEntry struct {
+	bun.BaseModel `bun:"table:api_audit_log" json:"-"`
+
+	ID             uuid.UUID  `bun:"id,pk,type:uuid" json:"id"`
+	CreatedAt      time.Time  `bun:"created_at,notnull" json:"created_at"`
+	Method         string     `bun:"method,notnull" json:"method"`
+	Route          string     `bun:"route,notnull" json:"route"`
+	ResourceID     string     `bun:"resource_id,nullzero" json:"resource_id,omitempty"`
+	UserID         *uuid.UUID `bun:"user_id,type:uuid" json:"user_id,omitempty"`
+	AuthMethod     string     `bun:"auth_method,nullzero" json:"auth_method,omitempty"`
+	ImpersonatedBy *uuid.UUID `bun:"impersonated_by,type:uuid" json:"impersonated_by,omitempty"`
+	Status         int        `bun:"status,notnull" json:"status"`
+	BodyHash       string     `bun:"body_hash,nullzero" json:"body_hash,omitempty"`
+	BodyRedacted   bool       `bun:"body_redacted,notnull" json:"body_redacted"`
+	ClientIP       string     `bun:"client_ip,notnull" json:"client_ip"`
+	RequestID      string     `bun:"request_id,nullzero" json:"request_id,omitempty"`
+}
+
+// APIAuditFilter задает отбор записей журнала изменяющих запросов: по пользователю
+// и по времени записи в полуинтервале [From, To). Записи возвращаются от новых к старым.
+
+type APIAuditFilter struct {
+	UserID *uuid.UUID
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
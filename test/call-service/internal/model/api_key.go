@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey — ключ доступа к API для автоматизированных клиентов. Сам ключ не хранится:
+// по Prefix ключ находится, а KeyHash подтверждает, что предъявлен именно он.
+// Нулевые ExpiresAt и LastUsedAt означают бессрочный и еще не использованный ключ.
+
+type APIKey struct {
+	ID         uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	UserID     uuid.UUID `bun:"user_id,notnull,type:uuid"`
+	Name       string    `bun:"name,notnull"`
+	Prefix     string    `bun:"prefix,notnull,unique"`
+	KeyHash    string    `bun:"key_hash,notnull"`
+	CreatedAt  time.Time `bun:"created_at,notnull,default:current_timestamp"`
+	ExpiresAt  time.Time `bun:"expires_at,nullzero"`
+	LastUsedAt time.Time `bun:"last_used_at,nullzero"`
+	Revoked    bool      `bun:"revoked,notnull"`
+}
+
+// Active сообщает, можно ли аутентифицироваться ключом в момент now.
+
+func (k *APIKey) Active(now time.Time) bool {
+	return !k.Revoked && (k.ExpiresAt.IsZero() || now.Before(k.ExpiresAt))
+}
@@ -1,27 +1,136 @@
-package model
-
-import (
-	"time"
-
-	"github.com/google/uuid"
-)
-
-type Call struct {
-	ID          uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
-	ClientName  string    `bun:"client_name,notnull" json:"client_name"`
-	PhoneNumber string    `bun:"phone_number,notnull" json:"phone_number"`
-	Description string    `bun:"description,notnull" json:"description"`
-	Status      string    `bun:"status,notnull" json:"status"`
-	CreatedAt   time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
-	UserID      uuid.UUID `bun:"user_id,notnull" json:"user_id"`
-}
-
-type CreateCallRequest struct {
-	ClientName  string `json:"client_name" binding:"required"`
-	PhoneNumber string `json:"phone_number" binding:"required"`
-	Description string `json:"description" binding:"required"`
-}
-
-type UpdateCallStatusRequest struct {
-	Status string `json:"status" binding:"required"`
-}
+package model
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Call — заявка. UserID — владелец заявки, CreatedBy и UpdatedBy — пользователи, которые
+// создали заявку и последними изменили ее (например, администратор, передавший заявку);
+// UpdatedBy равен nil, пока заявку не изменяли. CreatedByName и UpdatedByName заполняются
+// сервисом, если он настроен на получение имен пользователей, и не хранятся в базе данных.
+// CallbackAt — время, на которое запланирован повторный звонок клиенту (в UTC); nil, если
+// звонок не запланирован. При закрытии или отмене заявки запланированный звонок снимается.
+// CallbackNotifiedAt — время отправки уведомления о наступлении CallbackAt; в API не передается.
+// OrgID — организация, участникам которой доступна заявка; nil для заявок, созданных вне
+// организации, — они доступны только владельцу.
+// Summary — сводка по истории заявки; заполняется только в списках по запросу (CallFilter.IncludeSummary).
+// Ref — код заявки, по которому клиент узнает ее статус без учетной записи (NewCallRef);
+// пустой у заявок, созданных до появления кодов.
+// Truncated — описание было обрезано при создании заявки (CreateCallRequest.Truncate);
+// не хранится в базе данных.
+
+type Call struct {
+	ID                 uuid.UUID    `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	Ref                string       `bun:"ref,nullzero" json:"ref,omitempty"`
+	ClientName         string       `bun:"client_name,notnull" json:"client_name"`
+	PhoneNumber        string       `bun:"phone_number,notnull" json:"phone_number"`
+	Description        string       `bun:"description,notnull" json:"description"`
+	Status             CallStatus   `bun:"status,notnull" json:"status"`
+	CreatedAt          time.Time    `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+	UserID             uuid.UUID    `bun:"user_id,notnull" json:"user_id"`
+	OrgID              *uuid.UUID   `bun:"org_id,type:uuid" json:"org_id,omitempty"`
+	CreatedBy          uuid.UUID    `bun:"created_by,type:uuid,notnull" json:"created_by"`
+	UpdatedBy          *uuid.UUID   `bun:"updated_by,type:uuid" json:"updated_by"`
+	CreatedByName      string       `bun:"-" json:"created_by_name,omitempty"`
+	UpdatedByName      string       `bun:"-" json:"updated_by_name,omitempty"`
+	CallbackAt         *time.Time   `bun:"callback_at" json:"callback_at,omitempty"`
+	CallbackNotifiedAt *time.Time   `bun:"callback_notified_at" json:"-"`
+	Summary            *CallSummary `bun:"-" json:"summary,omitempty"`
+	Truncated          bool         `bun:"-" json:"-"`
+}
+
+// CallSummary — сводка по истории заявки для списков: время и автор последнего изменения
+// статуса (попытки звонка не учитываются). Поля равны nil, если статус заявки не менялся.
+
+type CallSummary struct {
+	LastStatusChangeAt  *time.Time `json:"last_status_change_at"`
+	LastStatusChangedBy *uuid.UUID `json:"last_status_changed_by"`
+}
+
+type CreateCallRequest struct {
+	ClientName  string `json:"client_name" binding:"required"`
+	PhoneNumber string `json:"phone_number" binding:"required"`
+	Description string `json:"description" binding:"required"`
+	// CallbackAt — время повторного звонка в формате RFC3339 со смещением часового пояса
+	CallbackAt *time.Time `json:"callback_at"`
+	// Truncate — описание длиннее MaxDescriptionLength обрезается, а не отклоняется;
+	// задается параметром запроса truncate=true
+	Truncate bool `json:"-"`
+}
+
+// MaxDescriptionLength — наибольшая длина описания заявки в символах; совпадает с размером
+// колонки calls.description.
+const MaxDescriptionLength = 8000
+
+// DescriptionPreviewLength — длина начала описания, которое передается в списках заявок
+// вместо полного описания.
+const DescriptionPreviewLength = 200
+
+// TruncationMarker — знак, которым заканчивается обрезанный текст.
+const TruncationMarker = "…"
+
+// TruncateText обрезает s до max символов, заменяя последний символ на TruncationMarker.
+// Возвращает s без изменений и false, если s не длиннее max символов.
+func TruncateText(s string, max int) (string, bool) {
+	if utf8.RuneCountInString(s) <= max {
+		return s, false
+	}
+	n := 0
+	for i := range s {
+		if n == max-1 {
+			return s[:i] + TruncationMarker, true
+		}
+		n++
+	}
+	return s, false
+}
+
+type UpdateCallStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// UpdateCallbackRequest задает время повторного звонка; null снимает запланированный звонок.
+
+type UpdateCallbackRequest struct {
+	CallbackAt *time.Time `json:"callback_at"`
+}
+
+type ReassignCallRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+}
+
+// CallFilter задает условия отбора, сортировку и пагинацию списка заявок.
+// Нулевые значения полей означают отсутствие соответствующего условия.
+
+type CallFilter struct {
+	UserID *uuid.UUID
+	// OrgID вместе с UserID отбирает заявки пользователя и заявки его организации
+	OrgID       *uuid.UUID
+	Status      CallStatus
+	PhoneNumber string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// CallbackDue отбирает незакрытые заявки, время повторного звонка которых не позже указанного
+	CallbackDue *time.Time
+	SortBy      string
+	SortDesc    bool
+	Limit       int
+	Offset      int
+	// IncludeSummary заполняет Call.Summary заявок списка; читается тем же запросом, что и заявки
+	IncludeSummary bool
+	// IncludeFullDescription — в ответе со списком передаются полные описания заявок, а не
+	// только их начало; на выборку не влияет
+	IncludeFullDescription bool
+}
+
+// Поля, по которым допускается сортировка списка заявок
+
+const (
+	SortByCreatedAt  = "created_at"
+	SortByClientName = "client_name"
+	SortByStatus     = "status"
+	SortByCallbackAt = "callback_at"
+)
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Виды записей ленты действий с заявкой (CallActivity.Type).
+
+const (
+	ActivityAssignment   = "assignment"
+	ActivityDialAttempt  = "dial_attempt"
+	ActivityStatusChange = "status_change"
+)
+
+// CallActivity — запись ленты действий с заявкой: изменение статуса, попытка звонка
+// или передача заявки. Type определяет, какие из полей заполнены: OldStatus и NewStatus —
+// у изменения статуса, DialID и DialReference — у попытки звонка, OldUserID и NewUserID —
+// у передачи. ActorID — автор действия; ActorName, OldUserName и NewUserName заполняет
+// сервисный слой, если справочник пользователей включен.
+//
+// Записи упорядочены по (At, Type, Key); Key — ключ записи в ее источнике, дополненный
+// нулями до одинаковой длины, чтобы строки сравнивались как числа.
+
+type CallActivity struct {
+	Type           string     `bun:"type" json:"type"`
+	At             time.Time  `bun:"at" json:"at"`
+	Key            string     `bun:"key" json:"-"`
+	ActorID        uuid.UUID  `bun:"actor_id,type:uuid" json:"actor_id"`
+	ActorName      string     `bun:"-" json:"actor_name,omitempty"`
+	ImpersonatedBy *uuid.UUID `bun:"impersonated_by,type:uuid" json:"impersonated_by,omitempty"`
+	OldStatus      CallStatus `bun:"old_status" json:"old_status,omitempty"`
+	NewStatus      CallStatus `bun:"new_status" json:"new_status,omitempty"`
+	DialID         *uuid.UUID `bun:"dial_id,type:uuid" json:"dial_id,omitempty"`
+	DialReference  string     `bun:"dial_reference" json:"dial_reference,omitempty"`
+	OldUserID      *uuid.UUID `bun:"old_user_id,type:uuid" json:"old_user_id,omitempty"`
+	OldUserName    string     `bun:"-" json:"old_user_name,omitempty"`
+	NewUserID      *uuid.UUID `bun:"new_user_id,type:uuid" json:"new_user_id,omitempty"`
+	NewUserName    string     `bun:"-" json:"new_user_name,omitempty"`
+}
+
+// Cursor возвращает позицию записи в ленте.
+
+func (a *CallActivity) Cursor() ActivityCursor {
+	return ActivityCursor{At: a.At, Type: a.Type, Key: a.Key}
+}
+
+// ActivityCursor — позиция в ленте действий с заявкой: следующая страница начинается
+// с записи, идущей после позиции в порядке (At, Type, Key).
+
+type ActivityCursor struct {
+	At   time.Time
+	Type string
+	Key  string
+}
+
+// Before сообщает, идет ли позиция c в ленте раньше позиции other.
+
+func (c ActivityCursor) Before(other ActivityCursor) bool {
+	if !c.At.Equal(other.At) {
+		return c.At.Before(other.At)
+	}
+	if c.Type != other.Type {
+		return c.Type < other.Type
+	}
+	return c.Key < other.Key
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CallAssignment — запись истории передачи заявки: кто и когда передал заявку от OldUserID
+// к NewUserID.
+
+type CallAssignment struct {
+	ID        int64     `bun:"id,pk,autoincrement" json:"-"`
+	CallID    uuid.UUID `bun:"call_id,type:uuid,notnull" json:"call_id"`
+	OldUserID uuid.UUID `bun:"old_user_id,type:uuid,notnull" json:"old_user_id"`
+	NewUserID uuid.UUID `bun:"new_user_id,type:uuid,notnull" json:"new_user_id"`
+	ChangedBy uuid.UUID `bun:"changed_by,type:uuid,notnull" json:"changed_by"`
+	ChangedAt time.Time `bun:"changed_at,notnull,default:current_timestamp" json:"changed_at"`
+}
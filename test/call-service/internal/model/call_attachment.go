@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CallAttachment — файл, прикрепленный к заявке (например, фотография или PDF-документ).
+// В базе данных хранится только описание файла, содержимое — в хранилище вложений
+// по ключу StorageKey. ContentType определен по содержимому файла при загрузке.
+
+type CallAttachment struct {
+	ID          uuid.UUID `bun:"id,pk,type:uuid" json:"id"`
+	CallID      uuid.UUID `bun:"call_id,type:uuid,notnull" json:"call_id"`
+	FileName    string    `bun:"file_name,notnull" json:"file_name"`
+	ContentType string    `bun:"content_type,notnull" json:"content_type"`
+	Size        int64     `bun:"size,notnull" json:"size"`
+	StorageKey  string    `bun:"storage_key,notnull" json:"-"`
+	UploadedBy  uuid.UUID `bun:"uploaded_by,type:uuid,notnull" json:"uploaded_by"`
+	CreatedAt   time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"crypto/rand"
+	"strings"
+)
+
+// CallRefLength — длина кода заявки в символах.
+const CallRefLength = 8
+
+// callRefAlphabet — алфавит base32 Крокфорда: цифры и латинские буквы без I, L, O и U,
+// которые легко спутать с другими символами при чтении и вводе кода.
+const callRefAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewCallRef возвращает новый код заявки — 8 символов base32 Крокфорда из 40 случайных бит.
+// Код сообщается клиенту, чтобы он мог узнать статус заявки без учетной записи
+// (GET /public/calls/:ref/status). Уникальность кода проверяет база данных: при совпадении
+// с кодом другой заявки сервисный слой создает заявку с новым кодом.
+
+func NewCallRef() string {
+	var buf [CallRefLength * 5 / 8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic(err)
+	}
+	var bits uint64
+	for _, b := range buf {
+		bits = bits<<8 | uint64(b)
+	}
+	ref := make([]byte, CallRefLength)
+	for i := CallRefLength - 1; i >= 0; i-- {
+		ref[i] = callRefAlphabet[bits&31]
+		bits >>= 5
+	}
+	return string(ref)
+}
+
+// NormalizeCallRef приводит код заявки, введенный клиентом, к виду, в котором он хранится:
+// удаляет дефисы и пробелы, переводит буквы в верхний регистр и заменяет O на 0, а I и L на 1,
+// как принято в base32 Крокфорда. Возвращает false, если строка не может быть кодом заявки.
+
+func NormalizeCallRef(s string) (string, bool) {
+	ref := make([]byte, 0, CallRefLength)
+	for _, r := range strings.ToUpper(s) {
+		switch r {
+		case '-', ' ':
+			continue
+		case 'O':
+			r = '0'
+		case 'I', 'L':
+			r = '1'
+		}
+		if r > 127 || !strings.ContainsRune(callRefAlphabet, r) || len(ref) == CallRefLength {
+			return "", false
+		}
+		ref = append(ref, byte(r))
+	}
+	if len(ref) != CallRefLength {
+		return "", false
+	}
+	return string(ref), true
+}
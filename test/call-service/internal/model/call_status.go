@@ -0,0 +1,55 @@
+package model
+
+// CallStatus — статус заявки. В базе данных и в ответах API хранятся канонические значения;
+// название статуса на языке клиента возвращается отдельно (поле status_label ответов HTTP API).
+
+type CallStatus string
+
+// Статусы заявки
+
+const (
+	CallStatusOpen       CallStatus = "open"
+	CallStatusInProgress CallStatus = "in_progress"
+	CallStatusClosed     CallStatus = "closed"
+	CallStatusCancelled  CallStatus = "cancelled"
+)
+
+// CallStatuses — все статусы заявки в порядке жизненного цикла.
+var CallStatuses = []CallStatus{CallStatusOpen, CallStatusInProgress, CallStatusClosed, CallStatusCancelled}
+
+// legacyCallStatuses сопоставляет прежние значения статусов на русском языке с каноническими.
+// Устаревшие значения принимаются во входных данных API в течение одного цикла устаревания.
+var legacyCallStatuses = map[string]CallStatus{
+	"открыта": CallStatusOpen,
+	"закрыта": CallStatusClosed,
+}
+
+// LegacyCallStatuses — устаревшие значения статусов, которые еще принимаются во входных данных API.
+var LegacyCallStatuses = []string{"открыта", "закрыта"}
+
+// ParseCallStatus переводит значение статуса из входных данных API в CallStatus, заменяя
+// устаревшие значения каноническими. Неизвестное значение возвращается без изменений,
+// его отклоняет проверка Valid.
+
+func ParseCallStatus(value string) CallStatus {
+	if status, ok := legacyCallStatuses[value]; ok {
+		return status
+	}
+	return CallStatus(value)
+}
+
+// Valid сообщает, является ли значение одним из статусов CallStatuses.
+
+func (s CallStatus) Valid() bool {
+	switch s {
+	case CallStatusOpen, CallStatusInProgress, CallStatusClosed, CallStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// Final сообщает, завершена ли работа с заявкой: заявка закрыта или отменена.
+
+func (s CallStatus) Final() bool {
+	return s == CallStatusClosed || s == CallStatusCancelled
+}
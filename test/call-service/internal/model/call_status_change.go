@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SystemActorID — автор изменений, которые сервис выполняет сам, без запроса пользователя
+// (например, автоматическое закрытие давно открытых заявок). Такого пользователя нет
+// в сервисе аутентификации; в ответах API его имя — SystemActorName.
+
+var SystemActorID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// SystemActorName — имя, под которым SystemActorID показывается в ответах API.
+const SystemActorName = "system"
+
+// CallStatusChange — запись истории статусов заявки: кто, когда и с какого статуса
+// на какой изменил статус заявки. В истории также хранятся попытки исходящего звонка
+// клиенту: у такой записи заполнен DialID, а статус заявки не меняется.
+
+type CallStatusChange struct {
+	ID        int64      `bun:"id,pk,autoincrement" json:"-"`
+	CallID    uuid.UUID  `bun:"call_id,type:uuid,notnull" json:"call_id"`
+	OldStatus CallStatus `bun:"old_status,notnull" json:"old_status"`
+	NewStatus CallStatus `bun:"new_status,notnull" json:"new_status"`
+	ChangedBy uuid.UUID  `bun:"changed_by,type:uuid,notnull" json:"changed_by"`
+	ChangedAt time.Time  `bun:"changed_at,notnull,default:current_timestamp" json:"changed_at"`
+	// DialID — ID попытки звонка, возвращенный клиенту; DialReference — ссылка провайдера
+	// телефонии на звонок.
+	DialID        *uuid.UUID `bun:"dial_id,type:uuid" json:"dial_id,omitempty"`
+	DialReference string     `bun:"dial_reference,nullzero" json:"dial_reference,omitempty"`
+	// ImpersonatedBy — администратор, выполнивший изменение от имени ChangedBy с токеном,
+	// выпущенным для работы от имени пользователя; nil, если изменение сделал сам ChangedBy.
+	ImpersonatedBy *uuid.UUID `bun:"impersonated_by,type:uuid" json:"impersonated_by,omitempty"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "github.com/google/uuid"
+
+// NewID возвращает новый идентификатор записи — UUID версии 7. Такие ID упорядочены
+// по времени создания (в пределах процесса — строго возрастают), поэтому новые строки
+// попадают в конец первичного индекса, а не в случайные его страницы.
+//
+// ID назначаются в сервисном слое до сохранения, чтобы они были известны без RETURNING
+// и одинаково заполнялись в PostgreSQL и репозиториях в памяти. Значение по умолчанию
+// gen_random_uuid() в базе данных остается для строк, вставленных без ID.
+
+func NewID() uuid.UUID {
+	return uuid.Must(uuid.NewV7())
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// События, о которых пользователь получает уведомления (NotificationPreference.Event)
+const (
+	// NotificationCallbackDue — наступило время повторного звонка по заявке пользователя.
+	NotificationCallbackDue = "callback_due"
+	// NotificationCallAssigned — пользователю передана заявка.
+	NotificationCallAssigned = "call_assigned"
+	// NotificationWebhookFailed — webhook не принял событие по заявке пользователя.
+	NotificationWebhookFailed = "webhook_failed"
+)
+
+// NotificationEvents — все события уведомлений в порядке вывода в настройках.
+var NotificationEvents = []string{NotificationCallbackDue, NotificationCallAssigned, NotificationWebhookFailed}
+
+// Каналы уведомлений (NotificationPreference.Channel)
+const (
+	NotificationChannelEmail    = "email"
+	NotificationChannelTelegram = "telegram"
+)
+
+// NotificationChannels — все каналы уведомлений в порядке вывода в настройках.
+var NotificationChannels = []string{NotificationChannelEmail, NotificationChannelTelegram}
+
+// NotificationPreference — настройка пользователя: получать ли уведомления о событии Event
+// по каналу Channel. Отсутствие настройки означает, что уведомления включены.
+
+type NotificationPreference struct {
+	UserID    uuid.UUID `bun:"user_id,pk,type:uuid"`
+	Event     string    `bun:"event,pk"`
+	Channel   string    `bun:"channel,pk"`
+	Enabled   bool      `bun:"enabled,notnull"`
+	UpdatedAt time.Time `bun:"updated_at,notnull,default:current_timestamp"`
+}
+
+// TelegramLink — чат Telegram, в который бот отправляет уведомления пользователю.
+
+type TelegramLink struct {
+	UserID   uuid.UUID `bun:"user_id,pk,type:uuid"`
+	ChatID   int64     `bun:"chat_id,notnull"`
+	LinkedAt time.Time `bun:"linked_at,notnull,default:current_timestamp"`
+}
+
+// TelegramLinkCode — одноразовый код привязки чата Telegram. Пользователь передает код боту
+// командой /start; хранится только SHA-256 хеш кода.
+
+type TelegramLinkCode struct {
+	CodeHash  string    `bun:"code_hash,pk"`
+	UserID    uuid.UUID `bun:"user_id,notnull,type:uuid"`
+	ExpiresAt time.Time `bun:"expires_at,notnull"`
+}
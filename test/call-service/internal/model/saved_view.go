@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SavedViewSchemaVersion — текущая версия схемы фильтра сохраненного представления.
+// Версия увеличивается, когда меняются параметры списка заявок GET /calls так, что
+// сохраненные ранее фильтры нужно преобразовать.
+const SavedViewSchemaVersion = 1
+
+// SavedView — именованный фильтр списка заявок пользователя. Filter хранит параметры
+// строки запроса GET /calls (status, sort, limit и другие) в виде строк, как они
+// передаются в запросе; SchemaVersion — версия схемы, по которой фильтр проверен.
+
+type SavedView struct {
+	ID            uuid.UUID         `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	UserID        uuid.UUID         `bun:"user_id,notnull,type:uuid"`
+	Name          string            `bun:"name,notnull"`
+	Filter        map[string]string `bun:"filter,type:jsonb,notnull"`
+	SchemaVersion int               `bun:"schema_version,notnull"`
+	CreatedAt     time.Time         `bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt     time.Time         `bun:"updated_at,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"net/http"
+	"time"
+)
+
+// Причины отклонения запросов, по которым считаются показатели безопасности и строится
+// сводка SecuritySummary. Перечень ограничен, чтобы число меток показателей не росло.
+const (
+	// SecurityReasonUnauthorized — запрос без действительных учетных данных (401), в том
+	// числе неудачный вход.
+	SecurityReasonUnauthorized = "unauthorized"
+	// SecurityReasonForbidden — доступ к чужому объекту или маршруту другой роли (403).
+	SecurityReasonForbidden = "forbidden"
+	// SecurityReasonRateLimited — запрос сверх ограничения частоты (429).
+	SecurityReasonRateLimited = "rate_limited"
+)
+
+// SecurityReasons — все причины отклонения запросов.
+var SecurityReasons = []string{SecurityReasonUnauthorized, SecurityReasonForbidden, SecurityReasonRateLimited}
+
+// securityStatuses сопоставляет коды ответа причинам отклонения.
+var securityStatuses = map[int]string{
+	http.StatusUnauthorized:    SecurityReasonUnauthorized,
+	http.StatusForbidden:       SecurityReasonForbidden,
+	http.StatusTooManyRequests: SecurityReasonRateLimited,
+}
+
+// SecurityReason возвращает причину отклонения запроса с кодом ответа status или пустую
+// строку, если код не означает отклонение.
+
+func SecurityReason(status int) string {
+	return securityStatuses[status]
+}
+
+// SecurityStatuses возвращает коды ответа, означающие отклонение запроса.
+
+func SecurityStatuses() []int {
+	statuses := make([]int, 0, len(securityStatuses))
+	for status := range securityStatuses {
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// APIAuditCount — число записей журнала изменяющих запросов с кодом ответа Status
+// к маршруту Route методом Method.
+
+type APIAuditCount struct {
+	Method string `bun:"method"`
+	Route  string `bun:"route"`
+	Status int    `bun:"status"`
+	Count  int    `bun:"count"`
+}
+
+// SecurityRouteCount — число отклоненных запросов к маршруту по причине.
+
+type SecurityRouteCount struct {
+	Method string `json:"method"`
+	Route  string `json:"route"`
+	Reason string `json:"reason"`
+	Count  int    `json:"count"`
+}
+
+// SecuritySummary — сводка отклоненных изменяющих запросов за полуинтервал [From, To):
+// число по причинам (все причины SecurityReasons, в том числе нулевые) и по маршрутам,
+// от наибольшего числа к наименьшему.
+
+type SecuritySummary struct {
+	From    time.Time            `json:"from"`
+	To      time.Time            `json:"to"`
+	Reasons map[string]int       `json:"reasons"`
+	Routes  []SecurityRouteCount `json:"routes"`
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook — подписка пользователя на события его заявок: события отправляются POST-запросом
+// на URL, тело подписывается секретом Secret (см. пакет pkg/webhook/signing). Секрет
+// возвращается только при создании подписки.
+
+type Webhook struct {
+	ID        uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	UserID    uuid.UUID `bun:"user_id,notnull,type:uuid"`
+	URL       string    `bun:"url,notnull"`
+	Secret    string    `bun:"secret,notnull"`
+	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp"`
+}
+
+// WebhookDeliveryStatus — состояние доставки события подписке.
+
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending — доставка ожидает очередной попытки.
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliveryDelivered — получатель принял событие.
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookDeliveryFailed — попытки исчерпаны; доставку можно повторить вручную.
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+// Valid сообщает, является ли s известным состоянием доставки.
+
+func (s WebhookDeliveryStatus) Valid() bool {
+	return s == WebhookDeliveryPending || s == WebhookDeliveryDelivered || s == WebhookDeliveryFailed
+}
+
+// WebhookDelivery — событие в очереди доставки подписке WebhookID. Payload — тело запроса,
+// подготовленное при постановке в очередь, поэтому повторные попытки отправляют то же событие.
+// Attempts — число неудачных попыток с момента постановки в очередь или ручного повтора,
+// NextAttemptAt — время следующей попытки ожидающей доставки, FailureReason и LastError —
+// код причины (см. notify.DeliveryError) и описание последней неудачной попытки.
+// Webhook заполняется только в списке доставок, ожидающих отправки.
+
+type WebhookDelivery struct {
+	ID            uuid.UUID             `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	WebhookID     uuid.UUID             `bun:"webhook_id,notnull,type:uuid"`
+	EventType     string                `bun:"event_type,notnull"`
+	Payload       json.RawMessage       `bun:"payload,type:jsonb,notnull"`
+	Status        WebhookDeliveryStatus `bun:"status,notnull"`
+	Attempts      int                   `bun:"attempts,notnull"`
+	NextAttemptAt time.Time             `bun:"next_attempt_at,notnull"`
+	FailureReason string                `bun:"failure_reason,notnull"`
+	LastError     string                `bun:"last_error,notnull"`
+	CreatedAt     time.Time             `bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt     time.Time             `bun:"updated_at,notnull,default:current_timestamp"`
+
+	Webhook *Webhook `bun:"rel:belongs-to,join:webhook_id=id"`
+}
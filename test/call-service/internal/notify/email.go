@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/pkg/authclient"
+)
+
+// SMTPConfig задает SMTP-сервер, через который отправляются уведомления по электронной почте.
+type SMTPConfig struct {
+	// Addr — адрес сервера host:port; From — адрес отправителя.
+	Addr string
+	From string
+	// Username и Password — учетные данные для аутентификации PLAIN; пустой Username —
+	// без аутентификации. Пароль передается только по соединению с TLS.
+	Username string
+	Password string
+}
+
+// UserDirectory возвращает профиль пользователя сервиса аутентификации.
+type UserDirectory interface {
+	GetUser(ctx context.Context, userID string) (*authclient.UserInfo, error)
+}
+
+// Email отправляет уведомления на подтвержденный адрес электронной почты из профиля
+// пользователя в сервисе аутентификации. Пользователю без подтвержденного адреса
+// уведомление не отправляется (ErrNoRecipient). Если сервер поддерживает STARTTLS,
+// соединение шифруется.
+type Email struct {
+	cfg   SMTPConfig
+	from  *mail.Address
+	users UserDirectory
+}
+
+// NewEmail создает канал уведомлений по электронной почте.
+func NewEmail(cfg SMTPConfig, users UserDirectory) (*Email, error) {
+	if _, _, err := net.SplitHostPort(cfg.Addr); err != nil {
+		return nil, fmt.Errorf("notify: invalid SMTP address %q: %w", cfg.Addr, err)
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("notify: invalid SMTP sender %q: %w", cfg.From, err)
+	}
+	return &Email{cfg: cfg, from: from, users: users}, nil
+}
+
+// Send отправляет уведомление n пользователю userID.
+func (e *Email) Send(ctx context.Context, userID uuid.UUID, n Notification) error {
+	user, err := e.users.GetUser(ctx, userID.String())
+	if err != nil {
+		return fmt.Errorf("get email of user %s: %w", userID, err)
+	}
+	if user.Email == "" || !user.EmailVerified {
+		return ErrNoRecipient
+	}
+	to, err := mail.ParseAddress(user.Email)
+	if err != nil {
+		return fmt.Errorf("%w: invalid email of user %s", ErrNoRecipient, userID)
+	}
+	msg, err := e.message(to, n)
+	if err != nil {
+		return err
+	}
+	return e.send(ctx, to.Address, msg)
+}
+
+// message составляет письмо в кодировке UTF-8 с телом в quoted-printable.
+func (e *Email) message(to *mail.Address, n Notification) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", e.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	// Переводы строк в теме недопустимы: они позволили бы добавить в письмо заголовки
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.Join(strings.Fields(n.Subject), " ")))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	body := quotedprintable.NewWriter(&buf)
+	if _, err := body.Write([]byte(strings.ReplaceAll(n.Text, "\n", "\r\n"))); err != nil {
+		return nil, fmt.Errorf("encode email body: %w", err)
+	}
+	if err := body.Close(); err != nil {
+		return nil, fmt.Errorf("encode email body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// send передает письмо SMTP-серверу. Отмена ctx закрывает соединение.
+func (e *Email) send(ctx context.Context, to string, msg []byte) error {
+	host, _, _ := net.SplitHostPort(e.cfg.Addr)
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", e.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("connect to SMTP server: %w", err)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("start SMTP session: %w", err)
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("start TLS: %w", err)
+		}
+	}
+	if e.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, host)); err != nil {
+			return fmt.Errorf("authenticate to SMTP server: %w", err)
+		}
+	}
+	if err := client.Mail(e.from.Address); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	return client.Quit()
+}
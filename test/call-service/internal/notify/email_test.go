@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"mime"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/pkg/authclient"
+)
+
+// stubUsers возвращает профили пользователей по ID.
+type stubUsers map[string]*authclient.UserInfo
+
+func (u stubUsers) GetUser(ctx context.Context, userID string) (*authclient.UserInfo, error) {
+	return u[userID], nil
+}
+
+// smtpMessage — письмо, принятое startSMTPServer.
+type smtpMessage struct {
+	from, to string
+	data     string
+}
+
+// startSMTPServer запускает SMTP-сервер без расширений, принимающий одно письмо, и возвращает
+// его адрес и канал с принятым письмом.
+func startSMTPServer(t *testing.T) (string, <-chan smtpMessage) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { lis.Close() })
+	received := make(chan smtpMessage, 1)
+
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP")
+		var msg smtpMessage
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.TrimSpace(line)
+			switch verb := strings.ToUpper(strings.SplitN(cmd, " ", 2)[0]); verb {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "MAIL":
+				msg.from = cmd
+				reply("250 OK")
+			case "RCPT":
+				msg.to = cmd
+				reply("250 OK")
+			case "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				msg.data = data.String()
+				reply("250 OK")
+			case "QUIT":
+				reply("221 Bye")
+				received <- msg
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+	return lis.Addr().String(), received
+}
+
+// Тест письма: уведомление отправляется на подтвержденный адрес из профиля пользователя,
+// а пользователю без подтвержденного адреса не отправляется
+func TestEmail_Send(t *testing.T) {
+	addr, received := startSMTPServer(t)
+	verifiedID, unverifiedID := uuid.New(), uuid.New()
+	users := stubUsers{
+		verifiedID.String():   {Email: "user@example.com", EmailVerified: true},
+		unverifiedID.String(): {Email: "new@example.com"},
+	}
+	email, err := NewEmail(SMTPConfig{Addr: addr, From: "Calls <calls@example.com>"}, users)
+	require.NoError(t, err)
+
+	n := Notification{Event: "call_assigned", Subject: "Заявка\r\nBcc: evil@example.com", Text: "Call was assigned"}
+	assert.ErrorIs(t, email.Send(context.Background(), unverifiedID, n), ErrNoRecipient)
+	require.NoError(t, email.Send(context.Background(), verifiedID, n))
+
+	msg := <-received
+	assert.Equal(t, "MAIL FROM:<calls@example.com>", msg.from)
+	assert.Equal(t, "RCPT TO:<user@example.com>", msg.to)
+	parsed, err := mail.ReadMessage(strings.NewReader(msg.data))
+	require.NoError(t, err)
+	assert.Empty(t, parsed.Header.Get("Bcc"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Заявка Bcc: evil@example.com", subject)
+	assert.Contains(t, msg.data, "Call was assigned")
+}
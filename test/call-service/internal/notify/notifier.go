@@ -0,0 +1,259 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/model"
+	"call-service/internal/worker"
+)
+
+// Параметры доставки уведомлений пользователям по умолчанию (см. DispatcherConfig)
+const (
+	DefaultNotifyWorkers     = 2
+	DefaultNotifyQueueSize   = 1024
+	DefaultNotifyAttempts    = 3
+	DefaultNotifyRetryDelay  = 5 * time.Second
+	DefaultNotifySendTimeout = 10 * time.Second
+	// dispatcherCloseTimeout ограничивает ожидание отправки уведомлений из очереди в Close.
+	dispatcherCloseTimeout = 15 * time.Second
+)
+
+// ErrNoRecipient возвращается каналом, если пользователю некуда отправить уведомление:
+// у него нет подтвержденного адреса электронной почты или привязанного чата Telegram.
+// Такая отправка не повторяется и не считается неудачной.
+var ErrNoRecipient = errors.New("user has no recipient address for the channel")
+
+// notificationStats — показатели уведомлений пользователям в /debug/vars: для каждого канала
+// число отправленных (<канал>_sent), неудачных после всех попыток (<канал>_failed)
+// и пропущенных из-за отсутствия адреса (<канал>_skipped) уведомлений, а также число
+// уведомлений, отброшенных из-за переполнения очереди (dropped).
+var notificationStats = expvar.NewMap("notifications")
+
+// Notification — уведомление пользователю о событии Event (model.Notification*).
+type Notification struct {
+	Event   string
+	Subject string
+	Text    string
+}
+
+// Notifier отправляет уведомление пользователю userID.
+type Notifier interface {
+	Send(ctx context.Context, userID uuid.UUID, n Notification) error
+}
+
+// Preferences определяет, по каким каналам пользователь получает уведомления о событии.
+type Preferences interface {
+	Channels(ctx context.Context, userID uuid.UUID, event string) ([]string, error)
+}
+
+// DispatcherConfig задает параметры доставки уведомлений. Нулевые значения заменяются
+// значениями по умолчанию.
+type DispatcherConfig struct {
+	// Workers — число горутин отправки; QueueSize — число уведомлений, ожидающих отправки.
+	Workers   int
+	QueueSize int
+	// Attempts — число попыток отправки по одному каналу; между попытками выдерживается
+	// RetryDelay, умноженная на номер попытки.
+	Attempts   int
+	RetryDelay time.Duration
+	// SendTimeout ограничивает одну попытку отправки.
+	SendTimeout time.Duration
+}
+
+// delivery — уведомление в очереди Dispatcher.
+type delivery struct {
+	userID       uuid.UUID
+	notification Notification
+}
+
+// Dispatcher доставляет уведомления по каналам, выбранным пользователем. Send только ставит
+// уведомление в очередь пула worker; каналы и настройки пользователя определяются
+// и уведомление отправляется в фоновых горутинах, с повторными попытками. Ошибки доставки
+// записываются в журнал и учитываются в /debug/vars, но не возвращаются вызывающему,
+// поэтому не влияют на запрос, вызвавший уведомление.
+type Dispatcher struct {
+	channels map[string]Notifier
+	prefs    Preferences
+	cfg      DispatcherConfig
+	pool     *worker.Pool[delivery]
+}
+
+// NewDispatcher создает Dispatcher с каналами channels (ключ — model.NotificationChannel*)
+// и настройками пользователей prefs и запускает его горутины. Горутины останавливаются в Close.
+func NewDispatcher(channels map[string]Notifier, prefs Preferences, cfg DispatcherConfig) *Dispatcher {
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultNotifyWorkers
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultNotifyQueueSize
+	}
+	if cfg.Attempts <= 0 {
+		cfg.Attempts = DefaultNotifyAttempts
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = DefaultNotifyRetryDelay
+	}
+	if cfg.SendTimeout <= 0 {
+		cfg.SendTimeout = DefaultNotifySendTimeout
+	}
+	d := &Dispatcher{channels: channels, prefs: prefs, cfg: cfg}
+	d.pool = worker.New(worker.Config{
+		Name:      "notifications",
+		Workers:   cfg.Workers,
+		QueueSize: cfg.QueueSize,
+		Policy:    worker.Drop,
+	}, d.deliver)
+	return d
+}
+
+// Send ставит уведомление пользователю userID в очередь. Возвращает ошибку, только если
+// очередь заполнена или Dispatcher закрыт и уведомление отброшено.
+func (d *Dispatcher) Send(ctx context.Context, userID uuid.UUID, n Notification) error {
+	if err := d.pool.Submit(ctx, delivery{userID: userID, notification: n}); err != nil {
+		notificationStats.Add("dropped", 1)
+		return fmt.Errorf("queue %s notification for user %s: %w", n.Event, userID, err)
+	}
+	return nil
+}
+
+// Close перестает принимать уведомления и ждет отправки уведомлений из очереди, но не дольше
+// dispatcherCloseTimeout: затем повторные попытки прекращаются, а оставшиеся уведомления
+// отбрасываются.
+func (d *Dispatcher) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), dispatcherCloseTimeout)
+	defer cancel()
+	return d.pool.Close(ctx)
+}
+
+// deliver отправляет уведомление по каналам, включенным пользователем. Ошибки каналов
+// возвращаются вместе, и пул записывает их в журнал.
+func (d *Dispatcher) deliver(ctx context.Context, batch []delivery) error {
+	var errs []error
+	for _, item := range batch {
+		channels, err := d.prefs.Channels(ctx, item.userID, item.notification.Event)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("get notification channels of user %s: %w", item.userID, err))
+			continue
+		}
+		for _, channel := range channels {
+			notifier, ok := d.channels[channel]
+			if !ok {
+				continue
+			}
+			if err := d.send(ctx, channel, notifier, item); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// send отправляет уведомление по одному каналу, повторяя неудачные попытки.
+func (d *Dispatcher) send(ctx context.Context, channel string, notifier Notifier, item delivery) error {
+	for attempt := 1; ; attempt++ {
+		sendCtx, cancel := context.WithTimeout(ctx, d.cfg.SendTimeout)
+		err := notifier.Send(sendCtx, item.userID, item.notification)
+		cancel()
+		switch {
+		case err == nil:
+			notificationStats.Add(channel+"_sent", 1)
+			return nil
+		case errors.Is(err, ErrNoRecipient):
+			notificationStats.Add(channel+"_skipped", 1)
+			return nil
+		case attempt < d.cfg.Attempts:
+			log.Printf("notify: attempt %d to send %s notification to user %s via %s failed: %v",
+				attempt, item.notification.Event, item.userID, channel, err)
+			select {
+			case <-time.After(d.cfg.RetryDelay * time.Duration(attempt)):
+				continue
+			case <-ctx.Done():
+			}
+		}
+		notificationStats.Add(channel+"_failed", 1)
+		return fmt.Errorf("send %s notification to user %s via %s: %w", item.notification.Event, item.userID, channel, err)
+	}
+}
+
+// Callbacks уведомляет о наступлении времени повторного звонка webhook, подписки владельца
+// заявки и самого владельца. Если webhook не принял событие, владелец получает уведомление
+// model.NotificationWebhookFailed, а ошибка возвращается, чтобы событие было отправлено
+// повторно; иначе владелец получает уведомление model.NotificationCallbackDue. Ошибки
+// уведомлений владельцу только записываются в журнал.
+type Callbacks struct {
+	// Webhook — получатель событий; nil, если webhook не задан.
+	Webhook CallbackNotifier
+	// Subscriptions ставит событие в очередь доставки подпискам владельца заявки; nil, если
+	// подписки не используются. Ошибка постановки в очередь тоже приводит к повторной отправке.
+	Subscriptions CallbackNotifier
+	// Users — уведомления пользователям; nil, если они отключены.
+	Users Notifier
+}
+
+// CallbackDue отправляет событие о повторном звонке по заявке call.
+func (c Callbacks) CallbackDue(ctx context.Context, call *model.Call) error {
+	if c.Webhook != nil {
+		if err := c.Webhook.CallbackDue(ctx, call); err != nil {
+			c.notify(ctx, call.UserID, WebhookFailedNotification(call, err))
+			return err
+		}
+	}
+	if c.Subscriptions != nil {
+		if err := c.Subscriptions.CallbackDue(ctx, call); err != nil {
+			return err
+		}
+	}
+	c.notify(ctx, call.UserID, CallbackDueNotification(call))
+	return nil
+}
+
+func (c Callbacks) notify(ctx context.Context, userID uuid.UUID, n Notification) {
+	if c.Users == nil {
+		return
+	}
+	if err := c.Users.Send(ctx, userID, n); err != nil {
+		log.Printf("notify: %v", err)
+	}
+}
+
+// CallbackDueNotification возвращает уведомление о наступлении времени повторного звонка.
+func CallbackDueNotification(call *model.Call) Notification {
+	text := fmt.Sprintf("It is time to call back %s (call %s)", call.ClientName, call.ID)
+	if call.CallbackAt != nil {
+		text += ", scheduled for " + call.CallbackAt.UTC().Format(time.RFC3339)
+	}
+	return Notification{Event: model.NotificationCallbackDue, Subject: "Callback is due", Text: text + "."}
+}
+
+// CallAssignedNotification возвращает уведомление о передаче заявки пользователю.
+func CallAssignedNotification(call *model.Call) Notification {
+	return Notification{
+		Event:   model.NotificationCallAssigned,
+		Subject: "A call was assigned to you",
+		Text:    fmt.Sprintf("Call %s from %s was assigned to you.", call.ID, call.ClientName),
+	}
+}
+
+// WebhookFailedNotification возвращает уведомление о том, что webhook не принял событие
+// по заявке. В текст попадает только код причины (см. DeliveryError), без адреса webhook
+// и ответа получателя.
+func WebhookFailedNotification(call *model.Call, err error) Notification {
+	reason := ReasonRequest
+	var deliveryErr *DeliveryError
+	if errors.As(err, &deliveryErr) {
+		reason = deliveryErr.Reason
+	}
+	return Notification{
+		Event:   model.NotificationWebhookFailed,
+		Subject: "Webhook delivery failed",
+		Text: fmt.Sprintf("The callback event for call %s from %s was not delivered to the webhook (reason: %s). It will be retried.",
+			call.ID, call.ClientName, reason),
+	}
+}
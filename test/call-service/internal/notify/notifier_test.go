@@ -0,0 +1,167 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+)
+
+// fakePreferences — настройки уведомлений в памяти: каналы по пользователю и событию.
+type fakePreferences map[uuid.UUID]map[string][]string
+
+func (p fakePreferences) Channels(ctx context.Context, userID uuid.UUID, event string) ([]string, error) {
+	return p[userID][event], nil
+}
+
+// fakeChannel — канал уведомлений, записывающий отправленные уведомления. Первые failures
+// попыток завершаются ошибкой err.
+type fakeChannel struct {
+	mu       sync.Mutex
+	err      error
+	failures int
+	attempts int
+	sent     []Notification
+}
+
+func (c *fakeChannel) Send(ctx context.Context, userID uuid.UUID, n Notification) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.attempts++
+	if c.attempts <= c.failures {
+		return c.err
+	}
+	c.sent = append(c.sent, n)
+	return nil
+}
+
+func (c *fakeChannel) stats() (attempts int, sent []Notification) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.attempts, c.sent
+}
+
+// Тест выбора каналов: уведомление отправляется только по каналам, включенным пользователем
+// для события, и только по каналам, настроенным в Dispatcher
+func TestDispatcher_RoutesByPreferences(t *testing.T) {
+	userID, otherID := uuid.New(), uuid.New()
+	email, telegram := &fakeChannel{}, &fakeChannel{}
+	prefs := fakePreferences{
+		userID: {
+			model.NotificationCallAssigned: {model.NotificationChannelEmail, model.NotificationChannelTelegram},
+			model.NotificationCallbackDue:  {model.NotificationChannelTelegram, "sms"},
+		},
+		otherID: {model.NotificationCallbackDue: {model.NotificationChannelEmail}},
+	}
+	d := NewDispatcher(map[string]Notifier{
+		model.NotificationChannelEmail:    email,
+		model.NotificationChannelTelegram: telegram,
+	}, prefs, DispatcherConfig{})
+
+	ctx := context.Background()
+	require.NoError(t, d.Send(ctx, userID, Notification{Event: model.NotificationCallAssigned, Text: "assigned"}))
+	require.NoError(t, d.Send(ctx, userID, Notification{Event: model.NotificationCallbackDue, Text: "due"}))
+	require.NoError(t, d.Send(ctx, otherID, Notification{Event: model.NotificationCallAssigned, Text: "not enabled"}))
+	require.NoError(t, d.Close())
+
+	_, sent := email.stats()
+	assert.Equal(t, []Notification{{Event: model.NotificationCallAssigned, Text: "assigned"}}, sent)
+	_, sent = telegram.stats()
+	assert.ElementsMatch(t, []Notification{
+		{Event: model.NotificationCallAssigned, Text: "assigned"},
+		{Event: model.NotificationCallbackDue, Text: "due"},
+	}, sent)
+}
+
+// Тест повторных попыток: временная ошибка канала повторяется, отсутствие адреса — нет,
+// а после последней неудачной попытки уведомление учитывается как неудачное
+func TestDispatcher_Retries(t *testing.T) {
+	userID := uuid.New()
+	flaky := &fakeChannel{err: errors.New("temporary"), failures: 2}
+	noRecipient := &fakeChannel{err: ErrNoRecipient, failures: 10}
+	broken := &fakeChannel{err: errors.New("down"), failures: 10}
+	channels := map[string]Notifier{"flaky": flaky, "none": noRecipient, "broken": broken}
+	prefs := fakePreferences{userID: {model.NotificationCallbackDue: {"flaky", "none", "broken"}}}
+	d := NewDispatcher(channels, prefs, DispatcherConfig{Attempts: 3, RetryDelay: time.Millisecond})
+
+	failedBefore := statValue("broken_failed")
+	skippedBefore := statValue("none_skipped")
+	require.NoError(t, d.Send(context.Background(), userID, Notification{Event: model.NotificationCallbackDue}))
+	require.NoError(t, d.Close())
+
+	attempts, sent := flaky.stats()
+	assert.Equal(t, 3, attempts)
+	assert.Len(t, sent, 1)
+	attempts, _ = noRecipient.stats()
+	assert.Equal(t, 1, attempts)
+	attempts, _ = broken.stats()
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, failedBefore+1, statValue("broken_failed"))
+	assert.Equal(t, skippedBefore+1, statValue("none_skipped"))
+}
+
+// Тест уведомлений о повторном звонке: при успешной отправке webhook владелец получает
+// callback_due, при неудачной — webhook_failed без подробностей ошибки, а ошибка возвращается
+func TestCallbacks_CallbackDue(t *testing.T) {
+	call := &model.Call{ID: uuid.New(), UserID: uuid.New(), ClientName: "Client"}
+	users := &recordingNotifier{}
+	webhook := &stubWebhook{}
+	callbacks := Callbacks{Webhook: webhook, Users: users}
+
+	require.NoError(t, callbacks.CallbackDue(context.Background(), call))
+	webhook.err = &DeliveryError{Reason: ReasonStatus, Err: errors.New("https://hooks.internal/secret responded with 502")}
+	assert.Error(t, callbacks.CallbackDue(context.Background(), call))
+
+	require.Len(t, users.sent, 2)
+	assert.Equal(t, call.UserID, users.sent[0].userID)
+	assert.Equal(t, model.NotificationCallbackDue, users.sent[0].notification.Event)
+	assert.Equal(t, model.NotificationWebhookFailed, users.sent[1].notification.Event)
+	assert.Contains(t, users.sent[1].notification.Text, "reason: status")
+	assert.NotContains(t, users.sent[1].notification.Text, "hooks.internal")
+
+	// Без webhook владелец получает уведомление о звонке
+	users.sent = nil
+	require.NoError(t, Callbacks{Users: users}.CallbackDue(context.Background(), call))
+	require.Len(t, users.sent, 1)
+	assert.Equal(t, model.NotificationCallbackDue, users.sent[0].notification.Event)
+
+	// Ошибка постановки в очередь подписок возвращается, владелец не уведомляется
+	users.sent = nil
+	subscriptions := &stubWebhook{err: errors.New("database is unavailable")}
+	assert.Error(t, Callbacks{Subscriptions: subscriptions, Users: users}.CallbackDue(context.Background(), call))
+	assert.Empty(t, users.sent)
+}
+
+// recordingNotifier записывает уведомления, переданные Send.
+type recordingNotifier struct {
+	sent []delivery
+}
+
+func (r *recordingNotifier) Send(ctx context.Context, userID uuid.UUID, n Notification) error {
+	r.sent = append(r.sent, delivery{userID: userID, notification: n})
+	return nil
+}
+
+// stubWebhook возвращает из CallbackDue ошибку err.
+type stubWebhook struct {
+	err error
+}
+
+func (w *stubWebhook) CallbackDue(ctx context.Context, call *model.Call) error {
+	return w.err
+}
+
+// statValue возвращает показатель notifications с именем key.
+func statValue(key string) int64 {
+	if v, ok := notificationStats.Get(key).(interface{ Value() int64 }); ok {
+		return v.Value()
+	}
+	return 0
+}
@@ -0,0 +1,149 @@
+// Package notify передает события заявок внешним системам, например о наступлении
+// времени повторного звонка.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/model"
+	"call-service/internal/safehttp"
+	"call-service/pkg/webhook/signing"
+)
+
+// EventCallbackDue — тип события о наступлении времени повторного звонка по заявке.
+const EventCallbackDue = "call.callback_due"
+
+// DefaultWebhookTimeout — ограничение времени одного вызова webhook по умолчанию.
+const DefaultWebhookTimeout = 10 * time.Second
+
+// Причины неудачной отправки, кроме нарушений safehttp.Policy (коды safehttp.Reason*)
+const (
+	// ReasonStatus — webhook ответил статусом вне диапазона 2xx.
+	ReasonStatus = "status"
+	// ReasonRequest — запрос не выполнен по другой причине, например получатель недоступен.
+	ReasonRequest = "request"
+)
+
+// webhookFailures — неудачные отправки webhook по причинам, публикуемые в /debug/vars.
+var webhookFailures = expvar.NewMap("webhook_failures")
+
+// DeliveryError — неудачная отправка события webhook. Reason — код причины: ReasonStatus,
+// ReasonRequest или код нарушения safehttp.Policy, например safehttp.ReasonBlockedAddress.
+type DeliveryError struct {
+	Reason string
+	Err    error
+}
+
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("webhook delivery failed (%s): %v", e.Reason, e.Err)
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// CallbackNotifier уведомляет о наступлении времени повторного звонка по заявке.
+type CallbackNotifier interface {
+	CallbackDue(ctx context.Context, call *model.Call) error
+}
+
+// Event — тело запроса webhook.
+type Event struct {
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Call       *model.Call `json:"call"`
+}
+
+// Webhook отправляет события POST-запросом с JSON-телом Event на заданный URL.
+// Каждый запрос содержит время отправки (signing.TimestampHeader) и уникальный
+// идентификатор отправки (signing.DeliveryHeader), а при заданном секрете — подпись тела
+// (signing.SignatureHeader), которую получатель проверяет функцией signing.Verify.
+// Ответ со статусом вне диапазона 2xx считается ошибкой. Ошибки отправки возвращаются
+// как *DeliveryError с кодом причины.
+type Webhook struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhook создает webhook с адресом url и секретом подписи secret (пустая строка — запросы
+// не подписываются). Значение timeout <= 0 означает DefaultWebhookTimeout.
+func NewWebhook(url, secret string, timeout time.Duration) *Webhook {
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	return NewWebhookWithClient(url, secret, &http.Client{Timeout: timeout})
+}
+
+// NewWebhookWithClient создает webhook, отправляющий запросы клиентом client, например
+// клиентом safehttp.Policy, который не допускает обращений во внутреннюю сеть.
+func NewWebhookWithClient(url, secret string, client *http.Client) *Webhook {
+	return &Webhook{url: url, secret: secret, client: client}
+}
+
+// CallbackDue отправляет событие EventCallbackDue с заявкой call.
+func (w *Webhook) CallbackDue(ctx context.Context, call *model.Call) error {
+	return w.send(ctx, Event{Type: EventCallbackDue, OccurredAt: time.Now().UTC(), Call: call})
+}
+
+func (w *Webhook) send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", event.Type, err)
+	}
+	return w.Deliver(ctx, uuid.NewString(), event.Type, body)
+}
+
+// Deliver отправляет событие типа eventType с телом body, закодированным заранее, например
+// сохраненным в очереди доставки. deliveryID передается в заголовке signing.DeliveryHeader:
+// повторные отправки одной доставки передают тот же идентификатор, чтобы получатель мог
+// отсечь повторы.
+func (w *Webhook) Deliver(ctx context.Context, deliveryID, eventType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	sentAt := time.Now()
+	req.Header.Set(signing.TimestampHeader, strconv.FormatInt(sentAt.Unix(), 10))
+	req.Header.Set(signing.DeliveryHeader, deliveryID)
+	if w.secret != "" {
+		req.Header.Set(signing.SignatureHeader, signing.Sign(w.secret, sentAt, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return failed(fmt.Errorf("send %s event: %w", eventType, err))
+	}
+	defer resp.Body.Close()
+	// Ответ читается до конца, чтобы соединение можно было использовать повторно;
+	// клиент safehttp ограничивает его размер
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return failed(fmt.Errorf("read %s event response: %w", eventType, err))
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		webhookFailures.Add(ReasonStatus, 1)
+		return &DeliveryError{Reason: ReasonStatus, Err: fmt.Errorf("send %s event: webhook responded with status %d", eventType, resp.StatusCode)}
+	}
+	return nil
+}
+
+// failed возвращает DeliveryError с причиной ошибки err и учитывает ее в /debug/vars.
+func failed(err error) error {
+	reason := safehttp.ReasonOf(err)
+	if reason == "" {
+		reason = ReasonRequest
+	}
+	webhookFailures.Add(reason, 1)
+	return &DeliveryError{Reason: reason, Err: err}
+}
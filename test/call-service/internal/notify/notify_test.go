@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+	"call-service/internal/safehttp"
+	"call-service/pkg/webhook/signing"
+)
+
+// Тест webhook: событие отправляется JSON-телом, статус вне 2xx возвращается как ошибка
+func TestWebhook_CallbackDue(t *testing.T) {
+	var received Event
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	callbackAt := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	call := &model.Call{ID: uuid.New(), CallbackAt: &callbackAt}
+	webhook := NewWebhook(srv.URL, "", time.Second)
+
+	require.NoError(t, webhook.CallbackDue(context.Background(), call))
+	assert.Equal(t, EventCallbackDue, received.Type)
+	assert.Equal(t, call.ID, received.Call.ID)
+	assert.True(t, callbackAt.Equal(*received.Call.CallbackAt))
+
+	status = http.StatusBadGateway
+	err := webhook.CallbackDue(context.Background(), call)
+	assert.ErrorContains(t, err, "status 502")
+	var deliveryErr *DeliveryError
+	require.ErrorAs(t, err, &deliveryErr)
+	assert.Equal(t, ReasonStatus, deliveryErr.Reason)
+}
+
+// Тест подписи webhook: получатель проверяет тело по секрету, а каждая отправка
+// получает новый идентификатор
+func TestWebhook_Signature(t *testing.T) {
+	var deliveries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		signature := r.Header.Get(signing.SignatureHeader)
+		assert.NoError(t, signing.Verify(signature, "secret", body, time.Minute))
+		assert.ErrorIs(t, signing.Verify(signature, "other", body, time.Minute), signing.ErrSignatureMismatch)
+		assert.Contains(t, signature, "t="+r.Header.Get(signing.TimestampHeader)+",")
+		deliveries = append(deliveries, r.Header.Get(signing.DeliveryHeader))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	webhook := NewWebhook(srv.URL, "secret", time.Second)
+	call := &model.Call{ID: uuid.New()}
+
+	require.NoError(t, webhook.CallbackDue(context.Background(), call))
+	require.NoError(t, webhook.CallbackDue(context.Background(), call))
+	require.Len(t, deliveries, 2)
+	assert.NoError(t, uuid.Validate(deliveries[0]))
+	assert.NotEqual(t, deliveries[0], deliveries[1])
+}
+
+// Тест webhook с клиентом safehttp: адрес во внутренней сети отклоняется без запроса
+// с причиной blocked_address, которая учитывается в /debug/vars
+func TestWebhook_BlockedAddress(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer srv.Close()
+	webhook := NewWebhookWithClient(srv.URL, "", safehttp.Policy{AllowHTTP: true}.Client())
+	blocked := func() int64 {
+		if v, ok := webhookFailures.Get(safehttp.ReasonBlockedAddress).(*expvar.Int); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	before := blocked()
+
+	err := webhook.CallbackDue(context.Background(), &model.Call{ID: uuid.New()})
+	var deliveryErr *DeliveryError
+	require.ErrorAs(t, err, &deliveryErr)
+	assert.Equal(t, safehttp.ReasonBlockedAddress, deliveryErr.Reason)
+	assert.Zero(t, hits)
+	assert.Equal(t, before+1, blocked())
+}
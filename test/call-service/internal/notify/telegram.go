@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTelegramAPIURL — адрес Bot API Telegram по умолчанию.
+const DefaultTelegramAPIURL = "https://api.telegram.org"
+
+// TelegramSecretHeader — заголовок, в котором Telegram передает секрет webhook бота.
+const TelegramSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// telegramResponseLimit ограничивает размер читаемого ответа Bot API.
+const telegramResponseLimit = 1 << 20
+
+// TelegramConfig задает бота Telegram, который отправляет уведомления.
+type TelegramConfig struct {
+	// Token — токен бота; BotName — имя бота без @ для ссылки привязки чата.
+	Token   string
+	BotName string
+	// WebhookSecret — секрет, с которым Telegram вызывает webhook бота (параметр secret_token
+	// метода setWebhook); запросы без него отклоняются.
+	WebhookSecret string
+	// APIURL — адрес Bot API; пустая строка — DefaultTelegramAPIURL.
+	APIURL string
+}
+
+// TelegramChats возвращает чат Telegram, привязанный пользователем, или ErrNoRecipient,
+// если чат не привязан.
+type TelegramChats interface {
+	TelegramChat(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+// Telegram отправляет уведомления сообщениями бота в чат, привязанный пользователем.
+// Токен бота входит в адрес запросов, поэтому ошибки соединения возвращаются без адреса.
+type Telegram struct {
+	cfg    TelegramConfig
+	chats  TelegramChats
+	client *http.Client
+}
+
+// NewTelegram создает канал уведомлений через бота Telegram.
+func NewTelegram(cfg TelegramConfig, chats TelegramChats) (*Telegram, error) {
+	if cfg.Token == "" {
+		return nil, errors.New("notify: telegram bot token is required")
+	}
+	if cfg.WebhookSecret == "" {
+		return nil, errors.New("notify: telegram webhook secret is required")
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = DefaultTelegramAPIURL
+	}
+	return &Telegram{cfg: cfg, chats: chats, client: &http.Client{Timeout: DefaultNotifySendTimeout}}, nil
+}
+
+// Send отправляет уведомление n в чат пользователя userID.
+func (t *Telegram) Send(ctx context.Context, userID uuid.UUID, n Notification) error {
+	chatID, err := t.chats.TelegramChat(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return t.SendMessage(ctx, chatID, n.Subject+"\n\n"+n.Text)
+}
+
+// SendMessage отправляет текст text в чат chatID. Отказ Telegram из-за того, что пользователь
+// заблокировал бота или чат не найден, возвращается как ErrNoRecipient.
+func (t *Telegram) SendMessage(ctx context.Context, chatID int64, text string) error {
+	body, err := json.Marshal(map[string]any{"chat_id": chatID, "text": text})
+	if err != nil {
+		return fmt.Errorf("encode telegram message: %w", err)
+	}
+	endpoint := strings.TrimSuffix(t.cfg.APIURL, "/") + "/bot" + t.cfg.Token + "/sendMessage"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("build telegram request: invalid API URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("send telegram message: %w", err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, telegramResponseLimit)).Decode(&result); err != nil {
+		return fmt.Errorf("send telegram message: status %d: decode response: %w", resp.StatusCode, err)
+	}
+	if result.OK {
+		return nil
+	}
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusBadRequest && strings.Contains(result.Description, "chat not found") {
+		return fmt.Errorf("%w: telegram: %s", ErrNoRecipient, result.Description)
+	}
+	return fmt.Errorf("send telegram message: status %d: %s", resp.StatusCode, result.Description)
+}
+
+// LinkURL возвращает ссылку, открывающую чат с ботом и передающую ему код привязки
+// командой /start; пустую строку, если имя бота не задано.
+func (t *Telegram) LinkURL(code string) string {
+	if t.cfg.BotName == "" {
+		return ""
+	}
+	return "https://t.me/" + url.PathEscape(t.cfg.BotName) + "?start=" + url.QueryEscape(code)
+}
+
+// VerifyWebhook сообщает, совпадает ли секрет запроса к webhook бота с заданным.
+func (t *Telegram) VerifyWebhook(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(t.cfg.WebhookSecret)) == 1
+}
@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubChats возвращает привязанные чаты пользователей; непривязанный — ErrNoRecipient.
+type stubChats map[uuid.UUID]int64
+
+func (c stubChats) TelegramChat(ctx context.Context, userID uuid.UUID) (int64, error) {
+	chatID, ok := c[userID]
+	if !ok {
+		return 0, ErrNoRecipient
+	}
+	return chatID, nil
+}
+
+// Тест отправки через бота Telegram: сообщение уходит в привязанный чат, заблокированный
+// бот считается отсутствием получателя, а токен бота не попадает в текст ошибки
+func TestTelegram_Send(t *testing.T) {
+	var chatID int64
+	var text, path string
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		var body struct {
+			ChatID int64  `json:"chat_id"`
+			Text   string `json:"text"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		chatID, text = body.ChatID, body.Text
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte(`{"ok":true}`))
+			return
+		}
+		w.Write([]byte(`{"ok":false,"description":"Forbidden: bot was blocked by the user"}`))
+	}))
+	defer srv.Close()
+
+	linkedID, unlinkedID := uuid.New(), uuid.New()
+	telegram, err := NewTelegram(TelegramConfig{Token: "123:secret", WebhookSecret: "hook", APIURL: srv.URL}, stubChats{linkedID: 42})
+	require.NoError(t, err)
+	n := Notification{Subject: "Callback is due", Text: "Call back Client."}
+
+	require.NoError(t, telegram.Send(context.Background(), linkedID, n))
+	assert.Equal(t, "/bot123:secret/sendMessage", path)
+	assert.Equal(t, int64(42), chatID)
+	assert.Equal(t, "Callback is due\n\nCall back Client.", text)
+	assert.ErrorIs(t, telegram.Send(context.Background(), unlinkedID, n), ErrNoRecipient)
+
+	status = http.StatusForbidden
+	assert.ErrorIs(t, telegram.Send(context.Background(), linkedID, n), ErrNoRecipient)
+
+	srv.Close()
+	err = telegram.Send(context.Background(), linkedID, n)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+}
+
+// Тест проверки секрета webhook и ссылки привязки чата
+func TestTelegram_Webhook(t *testing.T) {
+	telegram, err := NewTelegram(TelegramConfig{Token: "t", BotName: "calls_bot", WebhookSecret: "hook"}, stubChats{})
+	require.NoError(t, err)
+	assert.True(t, telegram.VerifyWebhook("hook"))
+	assert.False(t, telegram.VerifyWebhook(""))
+	assert.Equal(t, "https://t.me/calls_bot?start=abc", telegram.LinkURL("abc"))
+
+	_, err = NewTelegram(TelegramConfig{Token: "t"}, stubChats{})
+	assert.Error(t, err, "webhook secret is required")
+}
@@ -0,0 +1,25 @@
+// Команда gen записывает спецификацию OpenAPI сервиса заявок в файл.
+// Вызывается через go generate из пакета openapi.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"call-service/internal/openapi"
+)
+
+func main() {
+	out := flag.String("out", "openapi.json", "путь к файлу спецификации")
+	flag.Parse()
+
+	data, err := openapi.Marshal(openapi.Spec())
+	if err != nil {
+		log.Fatalf("failed to marshal spec: %v", err)
+	}
+
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		log.Fatalf("failed to write spec: %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+// Package openapi описывает контракт HTTP API сервиса заявок в формате OpenAPI 3.
+//
+// Спецификация строится программно функцией Spec и сохраняется в openapi.json
+// командой go generate на этапе сборки; сервер отдает встроенную копию файла.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+)
+
+//go:generate go run ./gen -out openapi.json
+
+// specJSON содержит спецификацию, сгенерированную на этапе сборки.
+//
+//go:embed openapi.json
+var specJSON []byte
+
+// JSON возвращает сгенерированную на этапе сборки спецификацию в формате JSON.
+func JSON() []byte {
+	return specJSON
+}
+
+// Marshal сериализует спецификацию в стабильный форматированный JSON.
+func Marshal(doc *Document) ([]byte, error) {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
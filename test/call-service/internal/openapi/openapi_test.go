@@ -0,0 +1,103 @@
+package openapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/handler"
+	"call-service/internal/middleware"
+	"call-service/internal/openapi"
+)
+
+// undocumentedRoutes перечисляет служебные маршруты, которые не входят в контракт API.
+var undocumentedRoutes = map[string]bool{
+	"GET /swagger": true,
+	"GET /healthz": true,
+	"GET /readyz":  true,
+	"GET /metrics": true,
+	// Внесение сбоев доступно только в средах разработки и тестирования
+	"GET /admin/faults": true,
+	"PUT /admin/faults": true,
+}
+
+// ginParam находит параметры пути в формате Gin (:id) для перевода в формат OpenAPI ({id}).
+var ginParam = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// TestSpecCoversAllRoutes проверяет, что каждый маршрут из таблицы Gin описан в спецификации.
+// Тест падает, если обработчик добавлен в маршрутизатор без обновления пакета openapi.
+
+func TestSpecCoversAllRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler.RegisterRoutes(router, handler.Routes{
+		Auth:           handler.NewAuthHandler(nil),
+		Calls:          handler.NewCallHandler(nil, nil),
+		Events:         handler.NewCallEventsHandler(nil, nil, handler.CallEventsConfig{}),
+		Admin:          handler.NewAdminHandler(nil),
+		Profile:        handler.NewProfileHandler(nil),
+		APIKeys:        handler.NewAPIKeyHandler(nil),
+		Views:          handler.NewSavedViewHandler(nil),
+		UserData:       handler.NewUserDataHandler(nil, nil, nil),
+		Notifications:  handler.NewNotificationHandler(nil, nil),
+		Webhooks:       handler.NewWebhookHandler(nil),
+		PublicStatus:   handler.NewPublicStatusHandler(nil, handler.PublicStatusConfig{}),
+		Impersonation:  handler.NewImpersonationHandler(nil),
+		Users:          handler.NewAdminUsersHandler(nil),
+		Organizations:  handler.NewOrganizationHandler(nil, nil),
+		Docs:           handler.NewDocsHandler(openapi.JSON()),
+		FeatureFlags:   handler.NewFeatureFlagsHandler(nil),
+		Maintenance:    middleware.NewMaintenance(false, 0),
+		Health:         handler.NewHealthHandler(),
+		SLO:            handler.NewSLOHandler(nil),
+		AuthMiddleware: middleware.NewAuthMiddleware(nil),
+		AuditLog:       handler.NewAuditLogHandler(nil),
+		SwaggerUI:      true,
+		Readiness:      &handler.Readiness{},
+	})
+
+	spec := openapi.Spec()
+	routes := router.Routes()
+	require.NotEmpty(t, routes)
+
+	for _, route := range routes {
+		// HEAD-маршруты повторяют GET-маршруты и отдельно не описываются
+		if route.Method == http.MethodHead || undocumentedRoutes[route.Method+" "+route.Path] {
+			continue
+		}
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		assert.Truef(t, spec.HasOperation(route.Method, path),
+			"route %s %s is registered but missing from the OpenAPI spec", route.Method, route.Path)
+	}
+}
+
+// TestGeneratedSpecUpToDate проверяет, что встроенный openapi.json совпадает с результатом Spec().
+// При расхождении нужно выполнить go generate ./internal/openapi.
+
+func TestGeneratedSpecUpToDate(t *testing.T) {
+	want, err := openapi.Marshal(openapi.Spec())
+	require.NoError(t, err)
+
+	assert.True(t, bytes.Equal(want, openapi.JSON()),
+		"openapi.json is stale, run: go generate ./internal/openapi")
+}
+
+// TestSpecDeclaresBearerAuth проверяет объявление схемы bearer-аутентификации,
+// необходимой для работы "Try it out" в Swagger UI.
+
+func TestSpecDeclaresBearerAuth(t *testing.T) {
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(openapi.JSON(), &doc))
+
+	schemes := doc["components"].(map[string]any)["securitySchemes"].(map[string]any)
+	bearer, ok := schemes[openapi.BearerAuth].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "http", bearer["type"])
+	assert.Equal(t, "bearer", bearer["scheme"])
+}
@@ -0,0 +1,2031 @@
+package openapi
+
+import (
+	"net/http"
+	"strings"
+
+	"call-service/internal/model"
+)
+
+// BearerAuth — имя схемы безопасности для JWT-токена в заголовке Authorization.
+const BearerAuth = "bearerAuth"
+
+// CookieAuth — имя схемы безопасности для JWT-токена в cookie access_token.
+const CookieAuth = "cookieAuth"
+
+// APIKeyAuth — имя схемы безопасности для API-ключа в заголовке X-API-Key.
+const APIKeyAuth = "apiKeyAuth"
+
+// Version — версия контракта HTTP API.
+const Version = "1.0.0"
+
+// Spec строит спецификацию OpenAPI для всех маршрутов сервиса заявок.
+// Каждый маршрут, зарегистрированный в маршрутизаторе, должен быть описан здесь.
+func Spec() *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "Call Service API",
+			Description: "HTTP API для работы с заявками и аутентификации пользователей.",
+			Version:     Version,
+		},
+		Paths: map[string]PathItem{},
+		Components: Components{
+			Schemas: schemas(),
+			SecuritySchemes: map[string]SecurityScheme{
+				BearerAuth: {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "JWT",
+					Description:  "JWT-токен, полученный через /register или /login.",
+				},
+				CookieAuth: {
+					Type:        "apiKey",
+					Name:        "access_token",
+					In:          "cookie",
+					Description: "JWT-токен в HttpOnly cookie, которую /register и /login устанавливают, если это включено в конфигурации (AUTH_COOKIE). Заголовок Authorization имеет приоритет над cookie.",
+				},
+				APIKeyAuth: {
+					Type:        "apiKey",
+					Name:        "X-API-Key",
+					In:          "header",
+					Description: "API-ключ пользователя, выпущенный через POST /me/api-keys. Дает права роли user.",
+				},
+			},
+		},
+	}
+
+	doc.add(http.MethodPost, "/register", &Operation{
+		Tags:        []string{"auth"},
+		Summary:     "Регистрация нового пользователя (прежний формат ответа, используйте /api/v2/register)",
+		OperationID: "register",
+		RequestBody: jsonBody(ref("RegisterRequest")),
+		Responses:   registerResponses("AuthResponse"),
+		Security:    public(),
+		Deprecated:  true,
+	})
+	doc.add(http.MethodPost, "/login", &Operation{
+		Tags:        []string{"auth"},
+		Summary:     "Вход в систему (прежний формат ответа, используйте /api/v2/login)",
+		OperationID: "login",
+		RequestBody: jsonBody(ref("LoginRequest")),
+		Responses:   loginResponses("AuthResponse"),
+		Security:    public(),
+		Deprecated:  true,
+	})
+	doc.add(http.MethodPost, "/api/v2/register", &Operation{
+		Tags:        []string{"auth"},
+		Summary:     "Регистрация нового пользователя",
+		OperationID: "registerV2",
+		RequestBody: jsonBody(ref("RegisterRequest")),
+		Responses:   registerResponses("AuthResponseV2"),
+		Security:    public(),
+	})
+	doc.add(http.MethodPost, "/api/v2/login", &Operation{
+		Tags:        []string{"auth"},
+		Summary:     "Вход в систему",
+		OperationID: "loginV2",
+		RequestBody: jsonBody(ref("LoginRequest")),
+		Responses:   loginResponses("AuthResponseV2"),
+		Security:    public(),
+	})
+	oidcLogin := loginResponses("AuthResponseV2")
+	oidcLogin["400"] = errorResponse("Некорректный запрос или неизвестный провайдер")
+	oidcLogin["401"] = errorResponse("Недействительный ID-токен")
+	delete(oidcLogin, "403")
+	oidcLogin["500"] = errorResponse("Внутренняя ошибка")
+	oidcLogin["501"] = errorResponse("Вход через OIDC отключен в сервисе аутентификации")
+	oidcLogin["503"] = errorResponse("Сервис аутентификации или провайдер OIDC недоступен")
+	doc.add(http.MethodPost, "/login/oidc", &Operation{
+		Tags:        []string{"auth"},
+		Summary:     "Вход по ID-токену внешнего провайдера OIDC",
+		OperationID: "loginOIDC",
+		RequestBody: jsonBody(ref("OIDCLoginRequest")),
+		Responses:   oidcLogin,
+		Security:    public(),
+	})
+
+	doc.add(http.MethodPost, "/calls", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Создание заявки",
+		OperationID: "createCall",
+		Parameters: []Parameter{{
+			Name:        "truncate",
+			In:          "query",
+			Description: "true — описание длиннее 8000 символов обрезается с многоточием в конце, а в ответе передается truncated: true; иначе такое описание отклоняется с кодом description_too_long",
+			Schema:      &Schema{Type: "boolean"},
+		}},
+		RequestBody: jsonBody(ref("CreateCallRequest")),
+		Responses: withAuthErrors(map[string]Response{
+			"201": jsonResponse("Заявка создана", ref("Call")),
+			"400": errorResponse("Некорректный запрос или слишком длинное описание (description_too_long)"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodGet, "/calls", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Список заявок текущего пользователя",
+		OperationID: "listCalls",
+		Parameters: append(listParams(), includeParam(), Parameter{
+			Name:        "view",
+			In:          "query",
+			Description: "ID сохраненного представления: параметры его фильтра подставляются в запрос, явно переданные параметры заменяют одноименные параметры фильтра",
+			Schema:      &Schema{Type: "string", Format: "uuid"},
+		}),
+		Responses: withAuthErrors(map[string]Response{
+			"200": cachedResponse(callListResponse()),
+			"400": errorResponse("Некорректные параметры фильтра или ID представления"),
+			"404": errorResponse("Представление не найдено"),
+			"409": errorResponse("Представление сохранено более новой версией сервиса"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodGet, "/calls/export", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Выгрузка заявок текущего пользователя в CSV",
+		OperationID: "exportCalls",
+		Parameters:  listParams(),
+		Responses: withAuthErrors(map[string]Response{
+			"200": {
+				Description: "CSV-файл с колонками id, client_name, phone_number, description, status, created_at, user_id",
+				Content:     map[string]MediaType{"text/csv": {Schema: &Schema{Type: "string"}}},
+			},
+			"400": errorResponse("Некорректные параметры фильтра"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodGet, "/calls/due", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Незакрытые заявки текущего пользователя с наступившим временем повторного звонка (по умолчанию по возрастанию времени звонка)",
+		OperationID: "listDueCalls",
+		Parameters:  append(listParams(), includeParam()),
+		Responses: withAuthErrors(map[string]Response{
+			"200": callListResponse(),
+			"400": errorResponse("Некорректные параметры фильтра"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodGet, "/calls/ws", &Operation{
+		Tags: []string{"calls"},
+		Summary: "События заявок по WebSocket: после сообщения {\"type\":\"ready\"} сервер присылает CallEvent " +
+			"о создании, изменении и удалении доступных пользователю заявок. Без токена в запросе первым сообщением " +
+			"клиента должно быть {\"type\":\"auth\",\"token\":\"...\"}; при ошибке соединение закрывается " +
+			"с кодом 1008 и кодом ошибки в причине. Медленный клиент отключается с кодом 1013, при остановке " +
+			"сервиса соединение закрывается с кодом 1001",
+		OperationID: "subscribeCallEvents",
+		Responses: withAuthErrors(map[string]Response{
+			"101": {Description: "Соединение WebSocket установлено"},
+			"426": {Description: "Запрос не является запросом установки соединения WebSocket"},
+			"429": errorResponse("Превышено число одновременных соединений пользователя"),
+			"503": errorResponse("Сервис аутентификации недоступен или сервис останавливается"),
+		}),
+		// Токен можно передать первым сообщением соединения
+		Security: []SecurityRequirement{{BearerAuth: {}}, {APIKeyAuth: {}}, {CookieAuth: {}}, {}},
+	})
+	doc.add(http.MethodGet, "/calls/views", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Сохраненные представления текущего пользователя",
+		OperationID: "listSavedViews",
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Представления, по названию", &Schema{Type: "array", Items: ref("SavedView")}),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodPost, "/calls/views", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Сохранение представления — именованного фильтра списка заявок (не больше 20 у пользователя)",
+		OperationID: "createSavedView",
+		RequestBody: jsonBody(ref("SavedViewRequest")),
+		Responses: withAuthErrors(map[string]Response{
+			"201": jsonResponse("Представление сохранено", ref("SavedView")),
+			"400": errorResponse("Некорректное название или фильтр"),
+			"409": errorResponse("Название занято или сохранено 20 представлений"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodGet, "/calls/views/{id}", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Получение сохраненного представления",
+		OperationID: "getSavedView",
+		Parameters:  []Parameter{savedViewIDParam()},
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Представление", ref("SavedView")),
+			"400": errorResponse("Некорректный ID представления"),
+			"404": errorResponse("Представление не найдено"),
+			"409": errorResponse("Представление сохранено более новой версией сервиса"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodPut, "/calls/views/{id}", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Замена названия и фильтра сохраненного представления",
+		OperationID: "updateSavedView",
+		Parameters:  []Parameter{savedViewIDParam()},
+		RequestBody: jsonBody(ref("SavedViewRequest")),
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Представление изменено", ref("SavedView")),
+			"400": errorResponse("Некорректный ID, название или фильтр"),
+			"404": errorResponse("Представление не найдено"),
+			"409": errorResponse("Название занято другим представлением"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodDelete, "/calls/views/{id}", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Удаление сохраненного представления",
+		OperationID: "deleteSavedView",
+		Parameters:  []Parameter{savedViewIDParam()},
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Представление удалено", ref("MessageResponse")),
+			"400": errorResponse("Некорректный ID представления"),
+			"404": errorResponse("Представление не найдено"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodGet, "/calls/{id}", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Получение заявки",
+		OperationID: "getCall",
+		Parameters:  []Parameter{callIDParam()},
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Заявка", ref("Call")),
+			"400": errorResponse("Некорректный ID заявки"),
+			"403": errorResponse("Нет доступа к заявке"),
+			"404": errorResponse("Заявка не найдена"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodPatch, "/calls/{id}/status", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Обновление статуса заявки",
+		OperationID: "updateCallStatus",
+		Parameters:  []Parameter{callIDParam()},
+		RequestBody: jsonBody(ref("UpdateCallStatusRequest")),
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Статус обновлен", ref("MessageResponse")),
+			"400": errorResponse("Некорректный запрос или статус"),
+			"403": errorResponse("Нет доступа к заявке"),
+			"404": errorResponse("Заявка не найдена"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodPatch, "/calls/{id}/callback", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Назначение времени повторного звонка",
+		OperationID: "updateCallCallback",
+		Parameters:  []Parameter{callIDParam()},
+		RequestBody: jsonBody(ref("UpdateCallbackRequest")),
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Время звонка обновлено", ref("MessageResponse")),
+			"400": errorResponse("Некорректный запрос или время звонка не в будущем"),
+			"403": errorResponse("Нет доступа к заявке"),
+			"404": errorResponse("Заявка не найдена"),
+			"409": errorResponse("Заявка закрыта или отменена"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodPost, "/calls/{id}/dial", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Исходящий звонок клиенту заявки через провайдера телефонии",
+		OperationID: "dialCall",
+		Parameters:  []Parameter{callIDParam()},
+		Responses: withAuthErrors(map[string]Response{
+			"202": jsonResponse("Провайдер начал звонок; попытка записана в историю заявки", ref("DialCallResponse")),
+			"400": errorResponse("Некорректный ID заявки"),
+			"403": errorResponse("Нет доступа к заявке"),
+			"404": errorResponse("Заявка не найдена"),
+			"409": errorResponse("Заявка закрыта или отменена"),
+			"500": errorResponse("Внутренняя ошибка"),
+			"502": errorResponse("Провайдер телефонии отказал в звонке или недоступен; сообщение провайдера — в поле error"),
+			"503": errorResponse("Исходящие звонки не настроены"),
+		}),
+	})
+	doc.add(http.MethodPost, "/calls/{id}/attachments", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Прикрепление файла к заявке; тип файла определяется по содержимому",
+		OperationID: "uploadAttachment",
+		Parameters:  []Parameter{callIDParam()},
+		RequestBody: &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{"multipart/form-data": {Schema: &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"file": {Type: "string", Format: "binary",
+						Description: "Файл разрешенного типа (по умолчанию JPEG, PNG, GIF, WebP или PDF); указанный тип части должен совпадать с содержимым"},
+				},
+				Required: []string{"file"},
+			}}},
+		},
+		Responses: withAuthErrors(map[string]Response{
+			"201": jsonResponse("Вложение сохранено", ref("CallAttachment")),
+			"400": errorResponse("Некорректный ID заявки или нет поля file"),
+			"403": errorResponse("Нет доступа к заявке"),
+			"404": errorResponse("Заявка не найдена"),
+			"413": errorResponse("Файл больше наибольшего размера вложения"),
+			"415": errorResponse("Тип файла не разрешен или не совпадает с указанным"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodGet, "/calls/{id}/attachments/{aid}", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Скачивание вложения заявки",
+		OperationID: "getAttachment",
+		Parameters: []Parameter{callIDParam(), {
+			Name:        "aid",
+			In:          "path",
+			Description: "ID вложения",
+			Required:    true,
+			Schema:      &Schema{Type: "string", Format: "uuid"},
+		}},
+		Responses: withAuthErrors(map[string]Response{
+			"200": {
+				Description: "Содержимое файла с типом, определенным при загрузке (Content-Disposition: attachment)",
+				Content:     map[string]MediaType{"application/octet-stream": {Schema: &Schema{Type: "string", Format: "binary"}}},
+			},
+			"400": errorResponse("Некорректный ID заявки или вложения"),
+			"403": errorResponse("Нет доступа к заявке"),
+			"404": errorResponse("Заявка или вложение не найдены"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodGet, "/calls/{id}/activity", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Лента действий с заявкой: изменения статуса, попытки звонка и передачи заявки",
+		OperationID: "getCallActivity",
+		Parameters:  append([]Parameter{callIDParam()}, activityParams()...),
+		Responses: withAuthErrors(map[string]Response{
+			"200": activityResponse(),
+			"400": errorResponse("Некорректный ID заявки, размер страницы или позиция"),
+			"403": errorResponse("Нет доступа к заявке"),
+			"404": errorResponse("Заявка не найдена"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodGet, "/public/calls/{ref}/status", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Статус заявки по коду для клиента без учетной записи; запросы ограничены по IP-адресу и коду",
+		OperationID: "getPublicCallStatus",
+		Parameters: []Parameter{{
+			Name:        "ref",
+			In:          "path",
+			Description: "Код заявки (поле ref заявки); регистр букв и дефисы не учитываются",
+			Required:    true,
+			Schema:      &Schema{Type: "string", Example: "7KQ2M9XD"},
+		}},
+		Responses: map[string]Response{
+			"200": jsonResponse("Статус заявки", ref("PublicCallStatus")),
+			"404": errorResponse("Заявка с таким кодом не найдена или код некорректен"),
+			"429": errorResponse("Превышено число запросов с IP-адреса или к коду; заголовок Retry-After содержит число секунд до следующей попытки"),
+			"500": errorResponse("Внутренняя ошибка"),
+		},
+		Security: public(),
+	})
+	doc.add(http.MethodDelete, "/calls/{id}", &Operation{
+		Tags:        []string{"calls"},
+		Summary:     "Удаление заявки",
+		OperationID: "deleteCall",
+		Parameters:  []Parameter{callIDParam()},
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Заявка и ее вложения удалены", ref("MessageResponse")),
+			"400": errorResponse("Некорректный ID заявки"),
+			"403": errorResponse("Нет доступа к заявке"),
+			"404": errorResponse("Заявка не найдена"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+
+	doc.add(http.MethodGet, "/me/email", &Operation{
+		Tags:        []string{"profile"},
+		Summary:     "Email текущего пользователя",
+		OperationID: "getEmail",
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Email и признак его подтверждения", ref("EmailResponse")),
+			"404": errorResponse("Пользователь не найден"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodPut, "/me/email", &Operation{
+		Tags:        []string{"profile"},
+		Summary:     "Смена email текущего пользователя с отправкой токена подтверждения на новый адрес",
+		OperationID: "updateEmail",
+		RequestBody: jsonBody(ref("UpdateEmailRequest")),
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Письмо с токеном подтверждения отправлено", ref("MessageResponse")),
+			"400": errorResponse("Некорректный запрос или формат email"),
+			"403": errorResponse("Администратор работает от имени пользователя (impersonation_forbidden)"),
+			"404": errorResponse("Пользователь не найден"),
+			"409": errorResponse("Email принадлежит другому пользователю"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodPost, "/me/email/verify", &Operation{
+		Tags:        []string{"profile"},
+		Summary:     "Подтверждение email текущего пользователя",
+		OperationID: "verifyEmail",
+		RequestBody: jsonBody(ref("VerifyEmailRequest")),
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Email подтвержден", ref("MessageResponse")),
+			"400": errorResponse("Некорректный запрос, неверный или истекший токен"),
+			"403": errorResponse("Администратор работает от имени пользователя (impersonation_forbidden)"),
+			"404": errorResponse("Пользователь не найден"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+
+	doc.add(http.MethodGet, "/me/sessions", &Operation{
+		Tags:        []string{"profile"},
+		Summary:     "Действующие сеансы текущего пользователя",
+		OperationID: "listSessions",
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Сеансы, от последних использованных к давним", &Schema{Type: "array", Items: ref("Session")}),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodDelete, "/me/sessions", &Operation{
+		Tags:        []string{"profile"},
+		Summary:     "Завершение всех сеансов текущего пользователя, кроме текущего",
+		OperationID: "revokeOtherSessions",
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Сеансы завершены", ref("RevokeSessionsResponse")),
+			"403": errorResponse("Администратор работает от имени пользователя (impersonation_forbidden)"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodDelete, "/me/sessions/{id}", &Operation{
+		Tags:        []string{"profile"},
+		Summary:     "Завершение сеанса текущего пользователя",
+		OperationID: "revokeSession",
+		Parameters: []Parameter{{
+			Name:        "id",
+			In:          "path",
+			Description: "ID сеанса",
+			Required:    true,
+			Schema:      &Schema{Type: "string", Format: "uuid"},
+		}},
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Сеанс завершен", ref("MessageResponse")),
+			"400": errorResponse("Некорректный ID сеанса"),
+			"403": errorResponse("Администратор работает от имени пользователя (impersonation_forbidden)"),
+			"404": errorResponse("Сеанс не найден или уже завершен"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodGet, "/me/devices", &Operation{
+		Tags:        []string{"profile"},
+		Summary:     "Устройства, с которых текущий пользователь уже входил",
+		OperationID: "listDevices",
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Устройства, от последних использованных к давним", &Schema{Type: "array", Items: ref("Device")}),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodGet, "/me/export", &Operation{
+		Tags:        []string{"profile"},
+		Summary:     "Выгрузка всех данных текущего пользователя одним JSON-документом (не чаще раза в час)",
+		OperationID: "exportMyData",
+		Responses:   withAuthErrors(userDataExportResponses()),
+	})
+	doc.add(http.MethodDelete, "/me", &Operation{
+		Tags: []string{"profile"},
+		Summary: "Удаление учетной записи текущего пользователя вместе с сеансами, устройствами и API-ключами; " +
+			"заявки обезличиваются или удаляются в зависимости от ERASURE_POLICY",
+		OperationID: "eraseMyAccount",
+		Parameters: []Parameter{{
+			Name:        "erase",
+			In:          "query",
+			Description: "Подтверждение удаления; должно быть равно true",
+			Required:    true,
+			Schema:      &Schema{Type: "string", Enum: []string{"true"}},
+		}},
+		RequestBody: jsonBody(ref("EraseAccountRequest")),
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Учетная запись удалена", ref("EraseAccountResponse")),
+			"202": jsonResponse("Удаление начато и будет завершено в фоне", ref("EraseAccountResponse")),
+			"400": errorResponse("Нет параметра erase=true или пароля"),
+			"403": errorResponse("Неверный пароль"),
+			"500": errorResponse("Внутренняя ошибка"),
+			"503": errorResponse("Сервис аутентификации недоступен"),
+		}),
+	})
+	doc.add(http.MethodPost, "/me/api-keys", &Operation{
+		Tags:        []string{"profile"},
+		Summary:     "Выпуск API-ключа текущего пользователя (ключ возвращается только в этом ответе)",
+		OperationID: "createAPIKey",
+		RequestBody: jsonBody(ref("CreateAPIKeyRequest")),
+		Responses: withAuthErrors(map[string]Response{
+			"201": jsonResponse("Ключ выпущен", ref("CreateAPIKeyResponse")),
+			"400": errorResponse("Некорректное имя или срок действия ключа"),
+			"403": errorResponse("Запрос аутентифицирован API-ключом или администратор работает от имени пользователя (impersonation_forbidden)"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodGet, "/me/api-keys", &Operation{
+		Tags:        []string{"profile"},
+		Summary:     "API-ключи текущего пользователя",
+		OperationID: "listAPIKeys",
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Ключи, от новых к старым", &Schema{Type: "array", Items: ref("APIKey")}),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodDelete, "/me/api-keys/{id}", &Operation{
+		Tags:        []string{"profile"},
+		Summary:     "Отзыв API-ключа текущего пользователя",
+		OperationID: "revokeAPIKey",
+		Parameters: []Parameter{{
+			Name:        "id",
+			In:          "path",
+			Description: "ID ключа",
+			Required:    true,
+			Schema:      &Schema{Type: "string", Format: "uuid"},
+		}},
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Ключ отозван", ref("MessageResponse")),
+			"400": errorResponse("Некорректный ID ключа"),
+			"404": errorResponse("Ключ не найден"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodGet, "/me/notifications", &Operation{
+		Tags:        []string{"profile"},
+		Summary:     "Настройки уведомлений текущего пользователя и привязка чата Telegram",
+		OperationID: "getNotifications",
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Настройки уведомлений", ref("NotificationSettings")),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodPut, "/me/notifications", &Operation{
+		Tags:        []string{"profile"},
+		Summary:     "Включение и отключение уведомлений о событиях по каналам; непереданные настройки не меняются",
+		OperationID: "updateNotifications",
+		RequestBody: jsonBody(ref("UpdateNotificationsRequest")),
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Настройки изменены", ref("NotificationSettings")),
+			"400": errorResponse("Некорректное тело запроса, неизвестное событие или канал, не включенный в сервисе"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodPost, "/me/notifications/telegram", &Operation{
+		Tags:        []string{"profile"},
+		Summary:     "Код привязки чата Telegram: пользователь отправляет боту команду /start <code>; новый код отменяет прежний",
+		OperationID: "createTelegramLink",
+		Responses: withAuthErrors(map[string]Response{
+			"201": jsonResponse("Код привязки создан", ref("TelegramLinkCode")),
+			"500": errorResponse("Внутренняя ошибка"),
+			"503": errorResponse("Уведомления в Telegram отключены"),
+		}),
+	})
+	doc.add(http.MethodDelete, "/me/notifications/telegram", &Operation{
+		Tags:        []string{"profile"},
+		Summary:     "Отвязка чата Telegram текущего пользователя",
+		OperationID: "deleteTelegramLink",
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Чат отвязан", ref("MessageResponse")),
+			"404": errorResponse("Чат не привязан"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodPost, "/telegram/webhook", &Operation{
+		Tags:        []string{"profile"},
+		Summary:     "Webhook бота Telegram: команда /start <code> привязывает чат к пользователю кода",
+		OperationID: "telegramWebhook",
+		Parameters: []Parameter{{
+			Name:        "X-Telegram-Bot-Api-Secret-Token",
+			In:          "header",
+			Description: "Секрет webhook, заданный при регистрации webhook бота",
+			Required:    true,
+			Schema:      &Schema{Type: "string"},
+		}},
+		RequestBody: jsonBody(ref("TelegramUpdate")),
+		Responses: map[string]Response{
+			"200": jsonResponse("Обновление обработано или пропущено", ref("MessageResponse")),
+			"400": errorResponse("Некорректное тело запроса"),
+			"401": errorResponse("Неверный секрет webhook"),
+			"500": errorResponse("Внутренняя ошибка"),
+			"503": errorResponse("Уведомления в Telegram отключены"),
+		},
+		Security: public(),
+	})
+
+	doc.add(http.MethodGet, "/webhooks", &Operation{
+		Tags:        []string{"webhooks"},
+		Summary:     "Подписки текущего пользователя на события его заявок",
+		OperationID: "listWebhooks",
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Подписки по времени создания, без секретов", &Schema{Type: "array", Items: ref("Webhook")}),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodPost, "/webhooks", &Operation{
+		Tags:        []string{"webhooks"},
+		Summary:     "Подписка на события заявок (не больше 10 у пользователя); события подписываются возвращенным секретом",
+		OperationID: "createWebhook",
+		RequestBody: jsonBody(ref("CreateWebhookRequest")),
+		Responses: withAuthErrors(map[string]Response{
+			"201": jsonResponse("Подписка создана; секрет возвращается только в этом ответе", ref("Webhook")),
+			"400": errorResponse("Некорректное тело запроса или адрес, нарушающий ограничения исходящих запросов"),
+			"409": errorResponse("Создано 10 подписок"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodDelete, "/webhooks/{id}", &Operation{
+		Tags:        []string{"webhooks"},
+		Summary:     "Удаление подписки вместе с ее доставками",
+		OperationID: "deleteWebhook",
+		Parameters:  []Parameter{webhookIDParam()},
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Подписка удалена", ref("MessageResponse")),
+			"400": errorResponse("Некорректный ID подписки"),
+			"404": errorResponse("Подписка не найдена"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodGet, "/webhooks/{id}/deliveries", &Operation{
+		Tags:        []string{"webhooks"},
+		Summary:     "Последние 100 доставок событий подписке, от новых к давним",
+		OperationID: "listWebhookDeliveries",
+		Parameters: []Parameter{webhookIDParam(), {
+			Name:        "status",
+			In:          "query",
+			Description: "Только доставки в этом состоянии, например failed — неудавшиеся",
+			Schema:      &Schema{Type: "string", Enum: webhookDeliveryStatuses()},
+		}},
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Доставки", &Schema{Type: "array", Items: ref("WebhookDelivery")}),
+			"400": errorResponse("Некорректный ID подписки или состояние доставки"),
+			"404": errorResponse("Подписка не найдена"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodPost, "/webhooks/{id}/deliveries/{deliveryId}/retry", &Operation{
+		Tags:        []string{"webhooks"},
+		Summary:     "Повтор неудавшейся доставки: доставка снова ставится в очередь с полным числом попыток",
+		OperationID: "retryWebhookDelivery",
+		Parameters: []Parameter{webhookIDParam(), {
+			Name:        "deliveryId",
+			In:          "path",
+			Description: "ID доставки",
+			Required:    true,
+			Schema:      &Schema{Type: "string", Format: "uuid"},
+		}},
+		Responses: withAuthErrors(map[string]Response{
+			"202": jsonResponse("Доставка поставлена в очередь", ref("WebhookRetryResult")),
+			"400": errorResponse("Некорректный ID подписки или доставки"),
+			"404": errorResponse("Подписка или доставка не найдена"),
+			"409": errorResponse("Доставка ожидает попытки или уже доставлена"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodPost, "/webhooks/{id}/retry-failed", &Operation{
+		Tags:        []string{"webhooks"},
+		Summary:     "Повтор не больше 100 давних неудавшихся доставок подписки; остальные повторяются следующими запросами",
+		OperationID: "retryFailedWebhookDeliveries",
+		Parameters:  []Parameter{webhookIDParam()},
+		Responses: withAuthErrors(map[string]Response{
+			"202": jsonResponse("Доставки поставлены в очередь", ref("WebhookRetryResult")),
+			"400": errorResponse("Некорректный ID подписки"),
+			"404": errorResponse("Подписка не найдена"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+
+	doc.add(http.MethodPost, "/organizations", &Operation{
+		Tags:        []string{"organizations"},
+		Summary:     "Создание организации, владельцем которой становится текущий пользователь (маршрут есть, только если организации включены)",
+		OperationID: "createOrganization",
+		RequestBody: jsonBody(ref("CreateOrganizationRequest")),
+		Responses: withAuthErrors(map[string]Response{
+			"201": jsonResponse("Организация создана", ref("Organization")),
+			"400": errorResponse("Пустое или слишком длинное название"),
+			"409": errorResponse("Пользователь уже состоит в организации"),
+			"500": errorResponse("Внутренняя ошибка"),
+			"501": errorResponse("Организации отключены в сервисе аутентификации"),
+			"503": errorResponse("Сервис аутентификации недоступен"),
+		}),
+	})
+	doc.add(http.MethodPost, "/organizations/{id}/members", &Operation{
+		Tags:        []string{"organizations"},
+		Summary:     "Приглашение пользователя в организацию с ролью участника (только для владельца организации)",
+		OperationID: "inviteOrganizationMember",
+		Parameters:  []Parameter{orgIDParam()},
+		RequestBody: jsonBody(ref("InviteMemberRequest")),
+		Responses: withAuthErrors(map[string]Response{
+			"201": jsonResponse("Пользователь добавлен в организацию", ref("OrganizationMember")),
+			"400": errorResponse("Некорректный ID организации или тело запроса"),
+			"403": errorResponse("Текущий пользователь не владелец организации"),
+			"404": errorResponse("Пользователь не найден"),
+			"409": errorResponse("Пользователь уже состоит в организации"),
+			"500": errorResponse("Внутренняя ошибка"),
+			"501": errorResponse("Организации отключены в сервисе аутентификации"),
+			"503": errorResponse("Сервис аутентификации недоступен"),
+		}),
+	})
+	doc.add(http.MethodPost, "/organizations/{id}/invites", &Operation{
+		Tags:        []string{"organizations"},
+		Summary:     "Создание одноразового приглашения в организацию (только для владельца организации; код возвращается только в этом ответе)",
+		OperationID: "createOrganizationInvite",
+		Parameters:  []Parameter{orgIDParam()},
+		// Тело необязательно: без него приглашение действует 7 дней
+		RequestBody: &RequestBody{Content: map[string]MediaType{"application/json": {Schema: ref("CreateInviteRequest")}}},
+		Responses: withAuthErrors(map[string]Response{
+			"201": jsonResponse("Приглашение создано", ref("OrganizationInvite")),
+			"400": errorResponse("Некорректный ID организации или срок действия"),
+			"403": errorResponse("Текущий пользователь не владелец организации"),
+			"500": errorResponse("Внутренняя ошибка"),
+			"501": errorResponse("Организации отключены в сервисе аутентификации"),
+			"503": errorResponse("Сервис аутентификации недоступен"),
+		}),
+	})
+	doc.add(http.MethodGet, "/organizations/{id}/invites", &Operation{
+		Tags:        []string{"organizations"},
+		Summary:     "Приглашения организации без кодов (только для владельца организации)",
+		OperationID: "listOrganizationInvites",
+		Parameters:  []Parameter{orgIDParam()},
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Приглашения, от новых к старым", &Schema{Type: "array", Items: ref("OrganizationInvite")}),
+			"400": errorResponse("Некорректный ID организации"),
+			"403": errorResponse("Текущий пользователь не владелец организации"),
+			"500": errorResponse("Внутренняя ошибка"),
+			"501": errorResponse("Организации отключены в сервисе аутентификации"),
+			"503": errorResponse("Сервис аутентификации недоступен"),
+		}),
+	})
+	doc.add(http.MethodDelete, "/organizations/{id}/invites/{inviteId}", &Operation{
+		Tags:        []string{"organizations"},
+		Summary:     "Отзыв непринятого приглашения (только для владельца организации; повторный отзыв не является ошибкой)",
+		OperationID: "revokeOrganizationInvite",
+		Parameters: []Parameter{orgIDParam(), {
+			Name:        "inviteId",
+			In:          "path",
+			Description: "ID приглашения",
+			Required:    true,
+			Schema:      &Schema{Type: "string", Format: "uuid"},
+		}},
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Приглашение отозвано", ref("MessageResponse")),
+			"400": errorResponse("Некорректный ID организации или приглашения"),
+			"403": errorResponse("Текущий пользователь не владелец организации"),
+			"404": errorResponse("Приглашение не найдено или уже принято"),
+			"500": errorResponse("Внутренняя ошибка"),
+			"501": errorResponse("Организации отключены в сервисе аутентификации"),
+			"503": errorResponse("Сервис аутентификации недоступен"),
+		}),
+	})
+	doc.add(http.MethodPost, "/invites/accept", &Operation{
+		Tags:        []string{"organizations"},
+		Summary:     "Принятие приглашения: текущий пользователь становится участником организации (повторное принятие тем же пользователем возвращает его участие)",
+		OperationID: "acceptOrganizationInvite",
+		RequestBody: jsonBody(ref("AcceptInviteRequest")),
+		Responses: withAuthErrors(map[string]Response{
+			"200": jsonResponse("Пользователь состоит в организации", ref("OrganizationMember")),
+			"400": errorResponse("Код неизвестен, истек, отозван или использован другим пользователем"),
+			"409": errorResponse("Пользователь уже состоит в другой организации"),
+			"500": errorResponse("Внутренняя ошибка"),
+			"501": errorResponse("Организации отключены в сервисе аутентификации"),
+			"503": errorResponse("Сервис аутентификации недоступен"),
+		}),
+	})
+
+	doc.add(http.MethodGet, "/admin/calls", &Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Список заявок всех пользователей (только для администраторов)",
+		OperationID: "adminListCalls",
+		Parameters: append(listParams(), includeParam(), Parameter{
+			Name:        "user_id",
+			In:          "query",
+			Description: "Отбор заявок конкретного пользователя",
+			Schema:      &Schema{Type: "string", Format: "uuid"},
+		}),
+		Responses: withAdminErrors(map[string]Response{
+			"200": callListResponse(),
+			"400": errorResponse("Некорректные параметры фильтра"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodGet, "/admin/calls/{id}", &Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Получение любой заявки (только для администраторов)",
+		OperationID: "adminGetCall",
+		Parameters:  []Parameter{callIDParam()},
+		Responses: withAdminErrors(map[string]Response{
+			"200": jsonResponse("Заявка", ref("Call")),
+			"400": errorResponse("Некорректный ID заявки"),
+			"404": errorResponse("Заявка не найдена"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodPatch, "/admin/calls/{id}/status", &Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Принудительное обновление статуса заявки (только для администраторов)",
+		OperationID: "adminUpdateCallStatus",
+		Parameters:  []Parameter{callIDParam()},
+		RequestBody: jsonBody(ref("UpdateCallStatusRequest")),
+		Responses: withAdminErrors(map[string]Response{
+			"200": jsonResponse("Статус обновлен", ref("MessageResponse")),
+			"400": errorResponse("Некорректный запрос или статус"),
+			"404": errorResponse("Заявка не найдена"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+	doc.add(http.MethodPatch, "/admin/calls/{id}/assignee", &Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Передача заявки другому пользователю (только для администраторов)",
+		OperationID: "adminReassignCall",
+		Parameters:  []Parameter{callIDParam()},
+		RequestBody: jsonBody(ref("ReassignCallRequest")),
+		Responses: withAdminErrors(map[string]Response{
+			"200": jsonResponse("Заявка передана", ref("MessageResponse")),
+			"400": errorResponse("Некорректный запрос"),
+			"404": errorResponse("Заявка не найдена"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+
+	doc.add(http.MethodGet, "/admin/users", &Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Список пользователей (только для администраторов)",
+		OperationID: "adminListUsers",
+		Parameters:  userListParams(true),
+		Responses: withAdminErrors(map[string]Response{
+			"200": userListResponse(),
+			"400": errorResponse("Некорректные параметры фильтра"),
+			"500": errorResponse("Внутренняя ошибка"),
+			"503": errorResponse("Сервис аутентификации недоступен"),
+		}),
+	})
+	doc.add(http.MethodGet, "/admin/users/export", &Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Выгрузка пользователей в CSV (только для администраторов)",
+		OperationID: "adminExportUsers",
+		Parameters:  userListParams(false),
+		Responses: withAdminErrors(map[string]Response{
+			"200": {
+				Description: "CSV-файл с колонками user_id, username, role, email, email_verified, created_at; без подходящих пользователей — только строка заголовка",
+				Content:     map[string]MediaType{"text/csv": {Schema: &Schema{Type: "string"}}},
+			},
+			"400": errorResponse("Некорректные параметры фильтра"),
+			"500": errorResponse("Внутренняя ошибка"),
+			"503": errorResponse("Сервис аутентификации недоступен"),
+		}),
+	})
+	doc.add(http.MethodGet, "/admin/users/{id}/export", &Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Выгрузка всех данных пользователя (только для администраторов, не чаще раза в час)",
+		OperationID: "adminExportUserData",
+		Parameters: []Parameter{{
+			Name:        "id",
+			In:          "path",
+			Description: "ID пользователя",
+			Required:    true,
+			Schema:      &Schema{Type: "string", Format: "uuid"},
+		}},
+		Responses: withAdminErrors(userDataExportResponses()),
+	})
+	doc.add(http.MethodPost, "/admin/users/{id}/impersonate", &Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Токен для работы от имени пользователя на 15 минут (только для администраторов, выпуск записывается в журнал аудита)",
+		OperationID: "adminImpersonateUser",
+		Parameters: []Parameter{{
+			Name:        "id",
+			In:          "path",
+			Description: "ID пользователя",
+			Required:    true,
+			Schema:      &Schema{Type: "string", Format: "uuid"},
+		}},
+		Responses: withAdminErrors(map[string]Response{
+			"200": jsonResponse("Токен пользователя; refresh_token не выпускается", ref("AuthResponseV2")),
+			"400": errorResponse("Некорректный ID пользователя"),
+			"404": errorResponse("Пользователь не найден"),
+			"503": errorResponse("Сервис аутентификации недоступен"),
+		}),
+	})
+
+	doc.add(http.MethodPost, "/admin/maintenance", &Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Включение и выключение режима обслуживания (только для администраторов): изменяющие запросы, кроме входа и регистрации, получают 503 с заголовком Retry-After",
+		OperationID: "adminSetMaintenance",
+		RequestBody: jsonBody(ref("MaintenanceRequest")),
+		Responses: withAdminErrors(map[string]Response{
+			"200": jsonResponse("Новое состояние режима обслуживания", ref("MaintenanceResponse")),
+			"400": errorResponse("Некорректный запрос"),
+		}),
+	})
+
+	doc.add(http.MethodGet, "/admin/feature-flags", &Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Действующие значения флагов постепенного включения поведения (только для администраторов)",
+		OperationID: "adminGetFeatureFlags",
+		Responses: withAdminErrors(map[string]Response{
+			"200": jsonResponse("Значения флагов", ref("FeatureFlagsResponse")),
+		}),
+	})
+	doc.add(http.MethodPost, "/admin/feature-flags/reload", &Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Перечитывание файла флагов (только для администраторов)",
+		OperationID: "adminReloadFeatureFlags",
+		Responses: withAdminErrors(map[string]Response{
+			"200": jsonResponse("Новые значения флагов", ref("FeatureFlagsResponse")),
+			"500": errorResponse("Файл флагов не удалось прочитать; действуют прежние значения"),
+		}),
+	})
+	doc.add(http.MethodPost, "/admin/config/reload", &Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Перечитывание конфигурации без перезапуска: уровень журнала, ограничения частоты, флаги, режим обслуживания и время кэширования (только для администраторов)",
+		OperationID: "adminReloadConfig",
+		Responses: withAdminErrors(map[string]Response{
+			"200": jsonResponse("Примененные параметры и параметры, требующие перезапуска", ref("ConfigReloadResponse")),
+			"500": errorResponse("Конфигурацию не удалось прочитать; действуют прежние значения"),
+		}),
+	})
+
+	doc.add(http.MethodGet, "/admin/audit-log", &Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Журнал изменяющих запросов POST, PUT, PATCH и DELETE от новых к старым (только для администраторов)",
+		OperationID: "adminListAuditLog",
+		Parameters:  auditLogParams(),
+		Responses: withAdminErrors(map[string]Response{
+			"200": auditLogResponse(),
+			"400": errorResponse("Некорректные параметры фильтра"),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+
+	doc.add(http.MethodGet, "/admin/security/summary", &Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Сводка изменяющих запросов, отклоненных за последний час с кодами 401, 403 и 429, по причинам и маршрутам (только для администраторов)",
+		OperationID: "adminGetSecuritySummary",
+		Responses: withAdminErrors(map[string]Response{
+			"200": jsonResponse("Сводка отклоненных запросов", ref("SecuritySummary")),
+			"500": errorResponse("Внутренняя ошибка"),
+		}),
+	})
+
+	doc.add(http.MethodGet, "/admin/slo", &Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Выполнение целей уровня обслуживания: скорость расходования бюджета ошибок в окнах и остаток бюджета за период (только для администраторов; маршрут есть, если заданы SLO_OBJECTIVES)",
+		OperationID: "adminGetSLOSummary",
+		Responses: withAdminErrors(map[string]Response{
+			"200": jsonResponse("Состояние целей", ref("SLOSummary")),
+		}),
+	})
+
+	doc.add(http.MethodGet, "/version", &Operation{
+		Tags:        []string{"docs"},
+		Summary:     "Сведения о сборке сервиса; ответ можно кэшировать (Cache-Control: public, max-age=60)",
+		OperationID: "getVersion",
+		Responses: map[string]Response{
+			"200": jsonResponse("Сведения о сборке", ref("BuildInfo")),
+		},
+		Security: public(),
+	})
+	doc.add(http.MethodGet, "/api/v1/openapi.json", &Operation{
+		Tags:        []string{"docs"},
+		Summary:     "Спецификация OpenAPI",
+		OperationID: "getOpenAPISpec",
+		Responses: map[string]Response{
+			"200": jsonResponse("Документ OpenAPI", &Schema{Type: "object"}),
+		},
+		Security: public(),
+	})
+
+	return doc
+}
+
+// add регистрирует операцию для пути и метода.
+// Операции без явно заданной схемы безопасности требуют bearer-токен.
+func (d *Document) add(method, path string, op *Operation) {
+	if op.Security == nil {
+		op.Security = []SecurityRequirement{{BearerAuth: {}}, {APIKeyAuth: {}}, {CookieAuth: {}}}
+	}
+	item, ok := d.Paths[path]
+	if !ok {
+		item = PathItem{}
+		d.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = op
+}
+
+// HasOperation сообщает, описана ли в спецификации операция с указанным методом и путем.
+// Путь передается в формате OpenAPI, например /calls/{id}.
+func (d *Document) HasOperation(method, path string) bool {
+	item, ok := d.Paths[path]
+	if !ok {
+		return false
+	}
+	_, ok = item[strings.ToLower(method)]
+	return ok
+}
+
+// schemas возвращает переиспользуемые схемы запросов и ответов.
+func schemas() map[string]*Schema {
+	maxDescription := model.MaxDescriptionLength
+	return map[string]*Schema{
+		"ErrorResponse": {
+			Type:        "object",
+			Description: "Стандартный формат ошибки. Язык сообщения выбирается по заголовку Accept-Language (ru или en, по умолчанию en).",
+			Properties: map[string]*Schema{
+				"error": {Type: "string", Description: "Описание ошибки на языке клиента", Example: "call not found"},
+				"code":  {Type: "string", Description: "Код ошибки, не зависящий от языка", Example: "call_not_found"},
+			},
+			Required: []string{"error", "code"},
+		},
+		"BuildInfo": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"version":    {Type: "string", Description: "Версия сборки; dev, если не задана при сборке", Example: "1.4.0"},
+				"commit":     {Type: "string", Description: "Коммит, из которого собран сервис; dev, если не задан при сборке"},
+				"build_date": {Type: "string", Description: "Дата сборки; dev, если не задана при сборке", Example: "2026-10-16T12:00:00Z"},
+				"go_version": {Type: "string", Example: "go1.24.1"},
+			},
+			Required: []string{"version", "commit", "build_date", "go_version"},
+		},
+		"MessageResponse": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"message": {Type: "string"},
+			},
+			Required: []string{"message"},
+		},
+		"RegisterRequest": credentialsSchema(),
+		"LoginRequest":    credentialsSchema(),
+		"OIDCLoginRequest": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"provider": {Type: "string", Description: "Имя провайдера из конфигурации сервиса аутентификации"},
+				"id_token": {Type: "string", Description: "ID-токен, выданный провайдером"},
+			},
+			Required: []string{"provider", "id_token"},
+		},
+		"AuthResponse": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"token":   {Type: "string", Description: "JWT-токен доступа"},
+				"user_id": {Type: "string", Format: "uuid"},
+			},
+			Required: []string{"token", "user_id"},
+		},
+		"AuthResponseV2": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"token":         {Type: "string", Description: "JWT-токен доступа"},
+				"token_type":    {Type: "string", Enum: []string{"Bearer"}},
+				"expires_at":    {Type: "string", Format: "date-time", Description: "Срок действия токена доступа"},
+				"user_id":       {Type: "string", Format: "uuid"},
+				"refresh_token": {Type: "string", Description: "Refresh-токен; отсутствует, если сервис аутентификации его не выпустил"},
+			},
+			Required: []string{"token", "token_type", "expires_at", "user_id"},
+		},
+		"EmailResponse": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"email":          {Type: "string", Format: "email", Description: "Пустая строка, если email не указан"},
+				"email_verified": {Type: "boolean"},
+			},
+			Required: []string{"email", "email_verified"},
+		},
+		"UpdateEmailRequest": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"email": {Type: "string", Format: "email", Example: "user@example.com"},
+			},
+			Required: []string{"email"},
+		},
+		"VerifyEmailRequest": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"token": {Type: "string", Description: "Токен подтверждения из письма"},
+			},
+			Required: []string{"token"},
+		},
+		"Session": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id":           {Type: "string", Format: "uuid"},
+				"device_label": {Type: "string", Description: "Заголовок X-Device-Label или User-Agent при входе"},
+				"ip":           {Type: "string"},
+				"created_at":   {Type: "string", Format: "date-time"},
+				"last_used_at": {Type: "string", Format: "date-time"},
+				"current":      {Type: "boolean", Description: "Сеанс, которому принадлежит токен запроса"},
+			},
+			Required: []string{"id", "device_label", "ip", "created_at", "last_used_at", "current"},
+		},
+		"Device": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"fingerprint":   {Type: "string", Description: "Отпечаток устройства: хеш User-Agent и подсети IP-адреса"},
+				"user_agent":    {Type: "string"},
+				"ip":            {Type: "string", Description: "IP-адрес при последнем входе"},
+				"first_seen_at": {Type: "string", Format: "date-time"},
+				"last_seen_at":  {Type: "string", Format: "date-time"},
+			},
+			Required: []string{"fingerprint", "user_agent", "ip", "first_seen_at", "last_seen_at"},
+		},
+		"EraseAccountRequest": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"password": {Type: "string", Description: "Текущий пароль пользователя"},
+			},
+			Required: []string{"password"},
+		},
+		"EraseAccountResponse": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"status": {Type: "string", Enum: []string{"erased", "pending"},
+					Description: "erased — удаление завершено; pending — удаление прервано и будет завершено в фоне"},
+			},
+			Required: []string{"status"},
+		},
+		"UserDataExport": {
+			Type: "object",
+			Description: "Все данные пользователя. Документ отправляется по мере чтения заявок; " +
+				"если выгрузка прервется, документ останется незавершенным.",
+			Properties: map[string]*Schema{
+				"exported_at":    {Type: "string", Format: "date-time"},
+				"account":        ref("AccountExport"),
+				"sessions":       {Type: "array", Items: ref("SessionExport"), Description: "Все сеансы, включая отозванные и истекшие"},
+				"devices":        {Type: "array", Items: ref("Device")},
+				"calls":          {Type: "array", Items: ref("Call"), Description: "Заявки пользователя"},
+				"status_history": {Type: "array", Items: ref("CallStatusChange"), Description: "История статусов заявок пользователя и его изменения в чужих заявках"},
+			},
+			Required: []string{"exported_at", "account", "sessions", "devices", "calls", "status_history"},
+		},
+		"SavedViewRequest": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"name":   {Type: "string", Description: "Название, уникальное у пользователя, не длиннее 100 символов", Example: "Открытые"},
+				"filter": savedViewFilterSchema(),
+			},
+			Required: []string{"name"},
+		},
+		"SavedView": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id":         {Type: "string", Format: "uuid"},
+				"name":       {Type: "string"},
+				"filter":     savedViewFilterSchema(),
+				"created_at": {Type: "string", Format: "date-time"},
+				"updated_at": {Type: "string", Format: "date-time"},
+			},
+			Required: []string{"id", "name", "filter", "created_at", "updated_at"},
+		},
+		"PublicCallStatus": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"status":       {Type: "string", Enum: callStatuses()},
+				"status_label": {Type: "string", Description: "Название статуса на языке клиента (Accept-Language)"},
+				"created_at":   {Type: "string", Format: "date-time"},
+			},
+			Required: []string{"status", "status_label", "created_at"},
+		},
+		"NotificationPreference": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"event":   {Type: "string", Enum: model.NotificationEvents},
+				"channel": {Type: "string", Enum: model.NotificationChannels},
+				"enabled": {Type: "boolean"},
+			},
+			Required: []string{"event", "channel", "enabled"},
+		},
+		"UpdateNotificationsRequest": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"preferences": {Type: "array", Items: ref("NotificationPreference")},
+			},
+			Required: []string{"preferences"},
+		},
+		"NotificationSettings": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"channels": {
+					Type:        "array",
+					Description: "Каналы, включенные в сервисе",
+					Items:       &Schema{Type: "string", Enum: model.NotificationChannels},
+				},
+				"preferences": {
+					Type:        "array",
+					Description: "Настройки каждого события по каждому включенному каналу; по умолчанию уведомления включены",
+					Items:       ref("NotificationPreference"),
+				},
+				"telegram": {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"linked":    {Type: "boolean"},
+						"linked_at": {Type: "string", Format: "date-time"},
+					},
+					Required: []string{"linked"},
+				},
+			},
+			Required: []string{"channels", "preferences", "telegram"},
+		},
+		"CreateWebhookRequest": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"url": {Type: "string", Description: "Адрес https публичного хоста, на который отправляются события", Example: "https://example.com/hooks/calls"},
+			},
+			Required: []string{"url"},
+		},
+		"Webhook": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id":         {Type: "string", Format: "uuid"},
+				"url":        {Type: "string"},
+				"secret":     {Type: "string", Description: "Секрет подписи тела запросов (см. заголовок X-Webhook-Signature); только в ответе на создание"},
+				"created_at": {Type: "string", Format: "date-time"},
+			},
+			Required: []string{"id", "url", "created_at"},
+		},
+		"WebhookDelivery": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id":              {Type: "string", Format: "uuid", Description: "ID доставки; передается в заголовке X-Webhook-Delivery всех попыток"},
+				"event_type":      {Type: "string", Example: "call.callback_due"},
+				"status":          {Type: "string", Enum: webhookDeliveryStatuses()},
+				"attempts":        {Type: "integer", Description: "Неудачные попытки с постановки в очередь или ручного повтора"},
+				"next_attempt_at": {Type: "string", Format: "date-time", Description: "Время следующей попытки; только у ожидающих доставок"},
+				"failure_reason":  {Type: "string", Description: "Код причины последней неудачной попытки: status, request или нарушение ограничений исходящих запросов, например blocked_address"},
+				"last_error":      {Type: "string", Description: "Описание последней неудачной попытки"},
+				"payload_preview": {Type: "string", Description: "Начало тела события, не больше 512 байт"},
+				"payload_size":    {Type: "integer", Description: "Размер тела события в байтах"},
+				"created_at":      {Type: "string", Format: "date-time"},
+				"updated_at":      {Type: "string", Format: "date-time"},
+			},
+			Required: []string{"id", "event_type", "status", "attempts", "payload_preview", "payload_size", "created_at", "updated_at"},
+		},
+		"WebhookRetryResult": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"retried": {Type: "integer", Description: "Число доставок, снова поставленных в очередь"},
+			},
+			Required: []string{"retried"},
+		},
+		"TelegramLinkCode": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"code":       {Type: "string", Description: "Одноразовый код привязки"},
+				"url":        {Type: "string", Description: "Ссылка на бота с кодом; нет, если имя бота не задано"},
+				"expires_at": {Type: "string", Format: "date-time"},
+			},
+			Required: []string{"code", "expires_at"},
+		},
+		"TelegramUpdate": {
+			Type:        "object",
+			Description: "Обновление Bot API; обрабатываются только сообщения с командой /start",
+			Properties: map[string]*Schema{
+				"message": {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"chat": {
+							Type:       "object",
+							Properties: map[string]*Schema{"id": {Type: "integer", Format: "int64"}},
+						},
+						"text": {Type: "string"},
+					},
+				},
+			},
+		},
+		"AdminUser": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"user_id":        {Type: "string", Format: "uuid"},
+				"username":       {Type: "string"},
+				"role":           {Type: "string"},
+				"email":          {Type: "string"},
+				"email_verified": {Type: "boolean"},
+				"created_at":     {Type: "string", Format: "date-time"},
+			},
+			Required: []string{"user_id", "username", "role", "email", "email_verified", "created_at"},
+		},
+		"AccountExport": {
+			Type:        "object",
+			Description: "Учетная запись; хеши пароля и токенов не выгружаются",
+			Properties: map[string]*Schema{
+				"user_id":                       {Type: "string", Format: "uuid"},
+				"username":                      {Type: "string"},
+				"role":                          {Type: "string"},
+				"email":                         {Type: "string"},
+				"email_verified":                {Type: "boolean"},
+				"created_at":                    {Type: "string", Format: "date-time"},
+				"email_verification_expires_at": {Type: "string", Format: "date-time", Description: "Срок действия неиспользованного токена подтверждения email"},
+			},
+			Required: []string{"user_id", "username", "role", "email", "email_verified"},
+		},
+		"SessionExport": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id":           {Type: "string", Format: "uuid"},
+				"device_label": {Type: "string"},
+				"ip":           {Type: "string"},
+				"created_at":   {Type: "string", Format: "date-time"},
+				"last_used_at": {Type: "string", Format: "date-time"},
+				"expires_at":   {Type: "string", Format: "date-time"},
+				"revoked_at":   {Type: "string", Format: "date-time", Description: "Отсутствует у неотозванных сеансов"},
+			},
+			Required: []string{"id", "device_label", "ip", "created_at", "last_used_at"},
+		},
+		"CallStatusChange": {
+			Type:        "object",
+			Description: "Изменение статуса заявки или, если заполнено dial_id, попытка исходящего звонка клиенту",
+			Properties: map[string]*Schema{
+				"call_id":    {Type: "string", Format: "uuid"},
+				"old_status": {Type: "string", Enum: callStatuses()},
+				"new_status": {Type: "string", Enum: callStatuses()},
+				"changed_by": {Type: "string", Format: "uuid", Description: "Автор изменения"},
+				"changed_at": {Type: "string", Format: "date-time"},
+				"dial_id":    {Type: "string", Format: "uuid", Description: "ID попытки звонка; только у попыток звонка"},
+				"dial_reference": {Type: "string",
+					Description: "Ссылка провайдера телефонии на звонок; только у попыток звонка"},
+				"impersonated_by": {Type: "string", Format: "uuid",
+					Description: "Администратор, выполнивший изменение от имени автора; только у изменений, сделанных с токеном для работы от имени пользователя"},
+			},
+			Required: []string{"call_id", "old_status", "new_status", "changed_by", "changed_at"},
+		},
+		"CallActivity": {
+			Type:        "object",
+			Description: "Запись ленты действий с заявкой; набор полей зависит от type",
+			Properties: map[string]*Schema{
+				"type": {Type: "string", Enum: []string{"status_change", "dial_attempt", "assignment"},
+					Description: "Вид записи: изменение статуса, попытка звонка или передача заявки"},
+				"at":         {Type: "string", Format: "date-time"},
+				"actor_id":   {Type: "string", Format: "uuid", Description: "Автор действия"},
+				"actor_name": {Type: "string", Description: "Имя автора; отсутствует, если имена пользователей не заполняются"},
+				"impersonated_by": {Type: "string", Format: "uuid",
+					Description: "Администратор, выполнивший действие от имени автора"},
+				"old_status":     {Type: "string", Enum: callStatuses(), Description: "Только у status_change и dial_attempt"},
+				"new_status":     {Type: "string", Enum: callStatuses(), Description: "Только у status_change и dial_attempt"},
+				"dial_id":        {Type: "string", Format: "uuid", Description: "ID попытки звонка; только у dial_attempt"},
+				"dial_reference": {Type: "string", Description: "Ссылка провайдера телефонии на звонок; только у dial_attempt"},
+				"old_user_id":    {Type: "string", Format: "uuid", Description: "Прежний владелец; только у assignment"},
+				"old_user_name":  {Type: "string", Description: "Имя прежнего владельца"},
+				"new_user_id":    {Type: "string", Format: "uuid", Description: "Новый владелец; только у assignment"},
+				"new_user_name":  {Type: "string", Description: "Имя нового владельца"},
+			},
+			Required: []string{"type", "at", "actor_id"},
+		},
+		"CallEvent": {
+			Type:        "object",
+			Description: "Сообщение соединения GET /calls/ws о событии заявки; call отсутствует у call.deleted",
+			Properties: map[string]*Schema{
+				"type":    {Type: "string", Enum: []string{"call.created", "call.updated", "call.deleted"}},
+				"call_id": {Type: "string", Format: "uuid"},
+				"at":      {Type: "string", Format: "date-time"},
+				"call":    ref("Call"),
+			},
+			Required: []string{"type", "call_id", "at"},
+		},
+		"DialCallResponse": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"tracking_id":        {Type: "string", Format: "uuid", Description: "ID попытки звонка (dial_id в истории заявки)"},
+				"provider_reference": {Type: "string", Description: "Ссылка провайдера телефонии на звонок"},
+			},
+			Required: []string{"tracking_id", "provider_reference"},
+		},
+		"CallAttachment": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id":           {Type: "string", Format: "uuid"},
+				"call_id":      {Type: "string", Format: "uuid"},
+				"file_name":    {Type: "string", Description: "Имя файла без пути"},
+				"content_type": {Type: "string", Description: "Тип, определенный по содержимому файла", Example: "image/png"},
+				"size":         {Type: "integer", Format: "int64", Description: "Размер в байтах"},
+				"uploaded_by":  {Type: "string", Format: "uuid"},
+				"created_at":   {Type: "string", Format: "date-time"},
+			},
+			Required: []string{"id", "call_id", "file_name", "content_type", "size", "uploaded_by", "created_at"},
+		},
+		"CreateAPIKeyRequest": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"name":       {Type: "string", Description: "Назначение ключа, например имя интеграции"},
+				"expires_at": {Type: "string", Format: "date-time", Description: "Срок действия; без него ключ бессрочный"},
+			},
+			Required: []string{"name"},
+		},
+		"CreateOrganizationRequest": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"name": {Type: "string", Description: "Название, не длиннее 100 символов", Example: "Acme"},
+			},
+			Required: []string{"name"},
+		},
+		"InviteMemberRequest": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"username": {Type: "string", Description: "Имя приглашаемого пользователя"},
+			},
+			Required: []string{"username"},
+		},
+		"CreateInviteRequest": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"expires_at": {Type: "string", Format: "date-time", Description: "Срок действия, не позже чем через 30 дней; без него — 7 дней"},
+			},
+		},
+		"AcceptInviteRequest": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"code": {Type: "string", Description: "Код приглашения"},
+			},
+			Required: []string{"code"},
+		},
+		"OrganizationInvite": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id":          {Type: "string", Format: "uuid"},
+				"org_id":      {Type: "string", Format: "uuid"},
+				"code":        {Type: "string", Description: "Код приглашения; только в ответе на создание"},
+				"created_by":  {Type: "string", Format: "uuid"},
+				"created_at":  {Type: "string", Format: "date-time"},
+				"expires_at":  {Type: "string", Format: "date-time"},
+				"status":      {Type: "string", Enum: []string{"pending", "accepted", "revoked", "expired"}},
+				"accepted_by": {Type: "string", Format: "uuid", Description: "Принявший пользователь; нет, пока приглашение не принято"},
+				"accepted_at": {Type: "string", Format: "date-time"},
+				"revoked_at":  {Type: "string", Format: "date-time"},
+			},
+			Required: []string{"id", "org_id", "created_by", "created_at", "expires_at", "status"},
+		},
+		"Organization": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id":         {Type: "string", Format: "uuid"},
+				"name":       {Type: "string"},
+				"created_at": {Type: "string", Format: "date-time"},
+			},
+			Required: []string{"id", "name", "created_at"},
+		},
+		"OrganizationMember": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"org_id":    {Type: "string", Format: "uuid"},
+				"user_id":   {Type: "string", Format: "uuid"},
+				"role":      {Type: "string", Enum: []string{"owner", "member"}},
+				"joined_at": {Type: "string", Format: "date-time"},
+			},
+			Required: []string{"org_id", "user_id", "role", "joined_at"},
+		},
+		"APIKey":               apiKeySchema(),
+		"CreateAPIKeyResponse": createAPIKeyResponseSchema(),
+		"RevokeSessionsResponse": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"revoked": {Type: "integer", Description: "Количество завершенных сеансов"},
+			},
+			Required: []string{"revoked"},
+		},
+		"Call": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id":           {Type: "string", Format: "uuid"},
+				"ref":          {Type: "string", Description: "Код заявки для проверки статуса клиентом (GET /public/calls/{ref}/status); нет у заявок, созданных до появления кодов", Example: "7KQ2M9XD"},
+				"client_name":  {Type: "string"},
+				"phone_number": {Type: "string"},
+				"description": {Type: "string",
+					Description: "Полное описание; в списках передается только при include=full_description"},
+				"description_preview": {Type: "string",
+					Description: "Первые 200 символов описания с многоточием в конце, если описание длиннее; только в списках"},
+				"truncated": {Type: "boolean",
+					Description: "Описание обрезано при создании заявки с truncate=true; только в ответе на создание"},
+				"status":       {Type: "string", Enum: callStatuses()},
+				"status_label": {Type: "string", Description: "Название статуса на языке клиента (Accept-Language)", Example: "Открыта"},
+				"created_at":   {Type: "string", Format: "date-time"},
+				"user_id":      {Type: "string", Format: "uuid", Description: "Владелец заявки"},
+				"org_id": {Type: "string", Format: "uuid",
+					Description: "Организация, участникам которой доступна заявка; отсутствует, если заявка создана вне организации"},
+				"created_by": {Type: "string", Format: "uuid", Description: "Пользователь, создавший заявку"},
+				"updated_by": {Type: "string", Format: "uuid", Nullable: true,
+					Description: "Пользователь, последним изменивший заявку; null, если заявку не изменяли"},
+				"created_by_name": {Type: "string", Description: "Имя создателя; отсутствует, если имя не удалось получить"},
+				"updated_by_name": {Type: "string", Description: "Имя автора последнего изменения; отсутствует, если имя не удалось получить"},
+				"callback_at": {Type: "string", Format: "date-time",
+					Description: "Время повторного звонка в UTC; отсутствует, если звонок не назначен"},
+				"summary": ref("CallSummary"),
+			},
+			Required: []string{"id", "client_name", "phone_number", "status", "status_label", "created_at", "user_id", "created_by", "updated_by"},
+		},
+		"CallSummary": {
+			Type:        "object",
+			Description: "Сводка по истории заявки; только в списках при include=summary",
+			Properties: map[string]*Schema{
+				"last_status_change_at": {Type: "string", Format: "date-time", Nullable: true,
+					Description: "Время последнего изменения статуса; null, если статус не менялся"},
+				"last_status_changed_by": {Type: "string", Format: "uuid", Nullable: true,
+					Description: "Автор последнего изменения статуса; null, если статус не менялся"},
+			},
+			Required: []string{"last_status_change_at", "last_status_changed_by"},
+		},
+		"CreateCallRequest": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"client_name":  {Type: "string", Example: "John Doe"},
+				"phone_number": {Type: "string", Description: "Цифры, '+' и '-'", Example: "+1234567890"},
+				"description":  {Type: "string", MaxLength: &maxDescription, Example: "Issue with service"},
+				"callback_at": {Type: "string", Format: "date-time", Example: "2025-03-01T12:00:00+03:00",
+					Description: "Время повторного звонка в формате RFC3339 со смещением часового пояса; должно быть в будущем"},
+			},
+			Required: []string{"client_name", "phone_number", "description"},
+		},
+		"UpdateCallbackRequest": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"callback_at": {Type: "string", Format: "date-time", Nullable: true, Example: "2025-03-01T12:00:00+03:00",
+					Description: "Время повторного звонка в формате RFC3339 со смещением часового пояса; должно быть в будущем. null снимает звонок"},
+			},
+			Required: []string{"callback_at"},
+		},
+		"FeatureFlagsResponse": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"flags": {
+					Type:        "object",
+					Description: "Значения флагов по именам",
+					AdditionalProperties: &Schema{
+						Type: "object",
+						Properties: map[string]*Schema{
+							"enabled":    {Type: "boolean", Description: "Флаг включен для всех запросов"},
+							"percentage": {Type: "integer", Description: "Доля пользователей (0–100), для которых включен выключенный флаг"},
+						},
+						Required: []string{"enabled"},
+					},
+				},
+			},
+			Required: []string{"flags"},
+		},
+		"ConfigReloadResponse": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"applied":          {Type: "array", Items: &Schema{Type: "string"}, Description: "Измененные параметры, примененные без перезапуска"},
+				"restart_required": {Type: "array", Items: &Schema{Type: "string"}, Description: "Измененные параметры, которые применяются только после перезапуска (адреса, строки подключения, секреты)"},
+			},
+			Required: []string{"applied", "restart_required"},
+		},
+		"MaintenanceRequest": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"enabled": {Type: "boolean", Description: "true включает режим обслуживания, false — выключает"},
+			},
+			Required: []string{"enabled"},
+		},
+		"MaintenanceResponse": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"enabled": {Type: "boolean", Description: "Включен ли режим обслуживания"},
+			},
+			Required: []string{"enabled"},
+		},
+		"APIAuditEntry": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id":              {Type: "string", Format: "uuid"},
+				"created_at":      {Type: "string", Format: "date-time"},
+				"method":          {Type: "string"},
+				"route":           {Type: "string", Description: "Шаблон маршрута, например /calls/:id/status"},
+				"resource_id":     {Type: "string", Description: "ID изменяемого или созданного объекта, если он известен"},
+				"user_id":         {Type: "string", Format: "uuid", Description: "Пользователь; отсутствует у запросов без аутентификации"},
+				"auth_method":     {Type: "string", Enum: []string{"jwt", "api_key"}},
+				"impersonated_by": {Type: "string", Format: "uuid", Description: "Администратор, работающий от имени пользователя"},
+				"status":          {Type: "integer", Description: "Код ответа"},
+				"body_hash":       {Type: "string", Description: "SHA-256 тела запроса в шестнадцатеричном виде"},
+				"body_redacted":   {Type: "boolean", Description: "Тело запроса содержит учетные данные или коды и не хешируется"},
+				"client_ip":       {Type: "string"},
+				"request_id":      {Type: "string"},
+			},
+			Required: []string{"id", "created_at", "method", "route", "status", "body_redacted", "client_ip"},
+		},
+		"SecuritySummary": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"from": {Type: "string", Format: "date-time"},
+				"to":   {Type: "string", Format: "date-time"},
+				"reasons": {
+					Type:                 "object",
+					Description:          "Число отклоненных запросов по причинам: unauthorized (401), forbidden (403), rate_limited (429)",
+					AdditionalProperties: &Schema{Type: "integer"},
+				},
+				"routes": {
+					Type:        "array",
+					Description: "Число отклоненных запросов по маршрутам, от наибольшего к наименьшему",
+					Items: &Schema{
+						Type: "object",
+						Properties: map[string]*Schema{
+							"method": {Type: "string"},
+							"route":  {Type: "string", Description: "Шаблон маршрута, например /calls/:id/status"},
+							"reason": {Type: "string", Enum: model.SecurityReasons},
+							"count":  {Type: "integer"},
+						},
+						Required: []string{"method", "route", "reason", "count"},
+					},
+				},
+			},
+			Required: []string{"from", "to", "reasons", "routes"},
+		},
+		"SLOSummary": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"objectives": {
+					Type:        "array",
+					Description: "Цели в порядке объявления в SLO_OBJECTIVES",
+					Items: &Schema{
+						Type: "object",
+						Properties: map[string]*Schema{
+							"name":     {Type: "string"},
+							"endpoint": {Type: "string", Description: "Маршрут, например GET /calls/:id, или метод gRPC, например GetCall"},
+							"target":   {Type: "number", Description: "Доля хороших запросов, например 0.999"},
+							"latency":  {Type: "string", Description: "Наибольшее время хорошего запроса, например 300ms; нет, если время не учитывается"},
+							"windows": {
+								Type: "array",
+								Items: &Schema{
+									Type: "object",
+									Properties: map[string]*Schema{
+										"window":      {Type: "string", Description: "Окно: 5m, 30m, 1h или 6h"},
+										"total":       {Type: "integer"},
+										"bad":         {Type: "integer"},
+										"error_ratio": {Type: "number"},
+										"burn_rate":   {Type: "number", Description: "Во сколько раз доля ошибок превышает допустимую; при 1 бюджет расходуется ровно за период"},
+									},
+									Required: []string{"window", "total", "bad", "error_ratio", "burn_rate"},
+								},
+							},
+							"budget": {
+								Type: "object",
+								Properties: map[string]*Schema{
+									"period":    {Type: "string", Description: "Период бюджета, например 720h"},
+									"total":     {Type: "integer"},
+									"bad":       {Type: "integer"},
+									"remaining": {Type: "number", Description: "Доля неизрасходованного бюджета; 0 и меньше — бюджет исчерпан"},
+								},
+								Required: []string{"period", "total", "bad", "remaining"},
+							},
+							"good": {Type: "integer", Description: "Хороших запросов с момента запуска"},
+							"bad":  {Type: "integer", Description: "Плохих запросов с момента запуска"},
+						},
+						Required: []string{"name", "endpoint", "target", "windows", "budget", "good", "bad"},
+					},
+				},
+			},
+			Required: []string{"objectives"},
+		},
+		"ReassignCallRequest": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"user_id": {Type: "string", Format: "uuid", Description: "ID нового владельца заявки"},
+			},
+			Required: []string{"user_id"},
+		},
+		"UpdateCallStatusRequest": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"status": {Type: "string", Enum: callStatusInputs(),
+					Description: "Новый статус. Устаревшие значения \"открыта\" и \"закрыта\" принимаются наравне с open и closed"},
+			},
+			Required: []string{"status"},
+		},
+	}
+}
+
+func credentialsSchema() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"username":      {Type: "string"},
+			"password":      {Type: "string", Format: "password"},
+			"captcha_token": {Type: "string", Description: "Ответ на проверку CAPTCHA, если она требуется"},
+		},
+		Required: []string{"username", "password"},
+	}
+}
+
+func apiKeySchema() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":           {Type: "string", Format: "uuid"},
+			"name":         {Type: "string"},
+			"prefix":       {Type: "string", Description: "Начало ключа, по которому его можно узнать"},
+			"created_at":   {Type: "string", Format: "date-time"},
+			"expires_at":   {Type: "string", Format: "date-time", Nullable: true},
+			"last_used_at": {Type: "string", Format: "date-time", Nullable: true},
+			"revoked":      {Type: "boolean"},
+		},
+		Required: []string{"id", "name", "prefix", "created_at", "revoked"},
+	}
+}
+
+// createAPIKeyResponseSchema описывает выпущенный ключ: поля APIKey и сам ключ.
+func createAPIKeyResponseSchema() *Schema {
+	schema := apiKeySchema()
+	schema.Properties["key"] = &Schema{Type: "string", Description: "Ключ целиком; больше нигде не возвращается"}
+	schema.Required = append(schema.Required, "key")
+	return schema
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func jsonBody(schema *Schema) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{"application/json": {Schema: schema}},
+	}
+}
+
+func jsonResponse(description string, schema *Schema) Response {
+	return Response{
+		Description: description,
+		Content:     map[string]MediaType{"application/json": {Schema: schema}},
+	}
+}
+
+// withTokenCookie добавляет к ответу входа или регистрации заголовок Set-Cookie с токеном.
+func withTokenCookie(response Response) Response {
+	response.Headers = map[string]Header{
+		"Set-Cookie": {
+			Description: "Cookie access_token с токеном (HttpOnly); устанавливается, только если включено в конфигурации",
+			Schema:      &Schema{Type: "string"},
+		},
+	}
+	return response
+}
+
+// registerResponses возвращает ответы регистрации с телом успешного ответа schema.
+func registerResponses(schema string) map[string]Response {
+	return map[string]Response{
+		"201": withTokenCookie(jsonResponse("Пользователь зарегистрирован", ref(schema))),
+		"400": errorResponse("Некорректный запрос"),
+		"403": errorResponse("Требуется или не пройдена проверка CAPTCHA (коды captcha_required и invalid_captcha)"),
+		"409": errorResponse("Пользователь с таким именем уже существует"),
+		"503": errorResponse("Сервис аутентификации или проверка CAPTCHA недоступны"),
+	}
+}
+
+// loginResponses возвращает ответы входа с телом успешного ответа schema.
+func loginResponses(schema string) map[string]Response {
+	return map[string]Response{
+		"200": withTokenCookie(jsonResponse("Успешный вход", ref(schema))),
+		"400": errorResponse("Некорректный запрос"),
+		"401": errorResponse("Неверные учетные данные"),
+		"403": errorResponse("После неудачных попыток входа требуется или не пройдена проверка CAPTCHA (коды captcha_required и invalid_captcha)"),
+		"503": errorResponse("Сервис аутентификации или проверка CAPTCHA недоступны"),
+	}
+}
+
+func errorResponse(description string) Response {
+	return jsonResponse(description, ref("ErrorResponse"))
+}
+
+// withAuthErrors добавляет ответы 401 и 503, общие для всех защищенных маршрутов.
+func withAuthErrors(responses map[string]Response) map[string]Response {
+	responses["401"] = errorResponse("Отсутствует или недействителен токен")
+	if _, ok := responses["503"]; !ok {
+		responses["503"] = errorResponse("Сервис аутентификации недоступен")
+	}
+	return responses
+}
+
+// withAdminErrors добавляет ответы 401 и 403, общие для маршрутов администратора.
+func withAdminErrors(responses map[string]Response) map[string]Response {
+	responses["403"] = errorResponse("Требуется роль администратора")
+	return withAuthErrors(responses)
+}
+
+// userDataExportResponses описывает ответы на запрос выгрузки данных пользователя.
+func userDataExportResponses() map[string]Response {
+	return map[string]Response{
+		"200": jsonResponse("Данные пользователя", ref("UserDataExport")),
+		"400": errorResponse("Некорректный ID пользователя"),
+		"404": errorResponse("Пользователь не найден"),
+		"429": errorResponse("Данные пользователя уже выгружались в течение часа; заголовок Retry-After содержит число секунд до следующей попытки"),
+		"500": errorResponse("Внутренняя ошибка"),
+	}
+}
+
+// callListResponse описывает страницу списка заявок с общим количеством в заголовке.
+func callListResponse() Response {
+	response := jsonResponse("Список заявок", &Schema{Type: "array", Items: ref("Call")})
+	response.Headers = map[string]Header{
+		"X-Total-Count": {
+			Description: "Общее количество заявок, удовлетворяющих фильтру",
+			Schema:      &Schema{Type: "integer"},
+		},
+	}
+	return response
+}
+
+// cachedResponse добавляет к ответу заголовок X-Cache маршрута с кэшем ответов.
+func cachedResponse(response Response) Response {
+	response.Headers["X-Cache"] = Header{
+		Description: "HIT — ответ взят из кэша ответов (RESPONSE_CACHE_TTL), MISS — сформирован заново; отсутствует, если кэш отключен",
+		Schema:      &Schema{Type: "string", Enum: []string{"HIT", "MISS"}},
+	}
+	return response
+}
+
+// activityResponse описывает страницу ленты действий с заявкой с позицией следующей
+// страницы в заголовке.
+func activityResponse() Response {
+	response := jsonResponse("Записи ленты в порядке времени", &Schema{Type: "array", Items: ref("CallActivity")})
+	response.Headers = map[string]Header{
+		"X-Next-Cursor": {
+			Description: "Позиция следующей страницы для параметра after; отсутствует на последней странице",
+			Schema:      &Schema{Type: "string"},
+		},
+	}
+	return response
+}
+
+// activityParams описывает параметры постраничного чтения ленты действий с заявкой.
+func activityParams() []Parameter {
+	one, maxLimit := 1, 500
+	return []Parameter{
+		{Name: "limit", In: "query", Description: "Размер страницы (по умолчанию 50)",
+			Schema: &Schema{Type: "integer", Minimum: &one, Maximum: &maxLimit}},
+		{Name: "after", In: "query", Description: "Значение заголовка X-Next-Cursor предыдущей страницы",
+			Schema: &Schema{Type: "string"}},
+	}
+}
+
+// userListResponse описывает страницу пользователей с общим количеством в заголовке.
+func userListResponse() Response {
+	response := jsonResponse("Список пользователей", &Schema{Type: "array", Items: ref("AdminUser")})
+	response.Headers = map[string]Header{
+		"X-Total-Count": {
+			Description: "Общее количество пользователей, удовлетворяющих фильтру",
+			Schema:      &Schema{Type: "integer"},
+		},
+	}
+	return response
+}
+
+// auditLogResponse описывает страницу журнала изменяющих запросов с общим количеством
+// в заголовке.
+func auditLogResponse() Response {
+	response := jsonResponse("Записи журнала", &Schema{Type: "array", Items: ref("APIAuditEntry")})
+	response.Headers = map[string]Header{
+		"X-Total-Count": {
+			Description: "Общее количество записей, удовлетворяющих фильтру",
+			Schema:      &Schema{Type: "integer"},
+		},
+	}
+	return response
+}
+
+// auditLogParams описывает параметры фильтрации и пагинации журнала изменяющих запросов.
+func auditLogParams() []Parameter {
+	one, maxLimit, zero := 1, 500, 0
+	return []Parameter{
+		{Name: "user_id", In: "query", Description: "Отбор записей конкретного пользователя",
+			Schema: &Schema{Type: "string", Format: "uuid"}},
+		{Name: "from", In: "query", Description: "Записи не раньше указанного момента",
+			Schema: &Schema{Type: "string", Format: "date-time"}},
+		{Name: "to", In: "query", Description: "Записи раньше указанного момента",
+			Schema: &Schema{Type: "string", Format: "date-time"}},
+		{Name: "limit", In: "query", Description: "Размер страницы (по умолчанию 50)",
+			Schema: &Schema{Type: "integer", Minimum: &one, Maximum: &maxLimit}},
+		{Name: "offset", In: "query", Description: "Смещение от начала списка",
+			Schema: &Schema{Type: "integer", Minimum: &zero}},
+	}
+}
+
+// listParams описывает параметры фильтрации, сортировки и пагинации списка заявок.
+func listParams() []Parameter {
+	one, maxLimit, zero := 1, 500, 0
+	return []Parameter{
+		{Name: "status", In: "query", Description: "Отбор по статусу; устаревшие значения \"открыта\" и \"закрыта\" принимаются наравне с open и closed",
+			Schema: &Schema{Type: "string", Enum: callStatusInputs()}},
+		{Name: "phone_number", In: "query", Description: "Отбор по номеру телефона",
+			Schema: &Schema{Type: "string"}},
+		{Name: "created_from", In: "query", Description: "Заявки, созданные не раньше указанного момента",
+			Schema: &Schema{Type: "string", Format: "date-time"}},
+		{Name: "created_to", In: "query", Description: "Заявки, созданные раньше указанного момента",
+			Schema: &Schema{Type: "string", Format: "date-time"}},
+		{Name: "sort", In: "query", Description: "Поле сортировки",
+			Schema: &Schema{Type: "string", Enum: []string{"created_at", "client_name", "status", "callback_at"}}},
+		{Name: "order", In: "query", Description: "Направление сортировки (по умолчанию desc)",
+			Schema: &Schema{Type: "string", Enum: []string{"asc", "desc"}}},
+		{Name: "limit", In: "query", Description: "Размер страницы",
+			Schema: &Schema{Type: "integer", Minimum: &one, Maximum: &maxLimit}},
+		{Name: "offset", In: "query", Description: "Смещение от начала списка",
+			Schema: &Schema{Type: "integer", Minimum: &zero}},
+	}
+}
+
+// userListParams описывает параметры фильтрации и сортировки списка пользователей,
+// а при paged — и пагинации.
+func userListParams(paged bool) []Parameter {
+	params := []Parameter{
+		{Name: "created_from", In: "query", Description: "Пользователи, зарегистрированные не раньше указанного момента",
+			Schema: &Schema{Type: "string", Format: "date-time"}},
+		{Name: "created_to", In: "query", Description: "Пользователи, зарегистрированные раньше указанного момента",
+			Schema: &Schema{Type: "string", Format: "date-time"}},
+		{Name: "username_prefix", In: "query", Description: "Начало имени пользователя, с учетом регистра",
+			Schema: &Schema{Type: "string"}},
+		{Name: "sort", In: "query", Description: "Поле сортировки (по умолчанию created_at)",
+			Schema: &Schema{Type: "string", Enum: []string{"created_at", "username"}}},
+		{Name: "order", In: "query", Description: "Направление сортировки (по умолчанию desc)",
+			Schema: &Schema{Type: "string", Enum: []string{"asc", "desc"}}},
+	}
+	if paged {
+		one, maxLimit, zero := 1, 500, 0
+		params = append(params,
+			Parameter{Name: "limit", In: "query", Description: "Размер страницы (по умолчанию 50)",
+				Schema: &Schema{Type: "integer", Minimum: &one, Maximum: &maxLimit}},
+			Parameter{Name: "offset", In: "query", Description: "Смещение от начала списка",
+				Schema: &Schema{Type: "integer", Minimum: &zero}},
+		)
+	}
+	return params
+}
+
+// includeParam описывает параметр include списков заявок.
+func includeParam() Parameter {
+	return Parameter{
+		Name:        "include",
+		In:          "query",
+		Description: "Дополнительные данные заявок через запятую: summary — время и автор последнего изменения статуса (поле summary), full_description — полные описания заявок (поле description) в дополнение к description_preview",
+		Schema:      &Schema{Type: "string", Enum: []string{"summary", "full_description"}},
+	}
+}
+
+// savedViewFilterSchema описывает фильтр сохраненного представления: параметры списка
+// заявок GET /calls со строковыми значениями. Другие параметры отклоняются.
+func savedViewFilterSchema() *Schema {
+	schema := &Schema{
+		Type:        "object",
+		Description: "Параметры списка заявок GET /calls, значения — строки, как в строке запроса",
+		Example:     map[string]string{"status": "open", "sort": "callback_at", "order": "asc"},
+		Properties:  map[string]*Schema{},
+	}
+	for _, param := range append(listParams(), includeParam()) {
+		schema.Properties[param.Name] = &Schema{Type: "string", Description: param.Description}
+	}
+	return schema
+}
+
+func savedViewIDParam() Parameter {
+	return Parameter{
+		Name:        "id",
+		In:          "path",
+		Description: "ID представления",
+		Required:    true,
+		Schema:      &Schema{Type: "string", Format: "uuid"},
+	}
+}
+
+func webhookIDParam() Parameter {
+	return Parameter{
+		Name:        "id",
+		In:          "path",
+		Description: "ID подписки",
+		Required:    true,
+		Schema:      &Schema{Type: "string", Format: "uuid"},
+	}
+}
+
+// webhookDeliveryStatuses возвращает состояния доставки события подписке.
+func webhookDeliveryStatuses() []string {
+	return []string{
+		string(model.WebhookDeliveryPending),
+		string(model.WebhookDeliveryDelivered),
+		string(model.WebhookDeliveryFailed),
+	}
+}
+
+// public возвращает пустое требование безопасности для открытых маршрутов.
+func public() []SecurityRequirement {
+	return []SecurityRequirement{}
+}
+
+func callIDParam() Parameter {
+	return Parameter{
+		Name:        "id",
+		In:          "path",
+		Description: "ID заявки",
+		Required:    true,
+		Schema:      &Schema{Type: "string", Format: "uuid"},
+	}
+}
+
+func orgIDParam() Parameter {
+	return Parameter{
+		Name:        "id",
+		In:          "path",
+		Description: "ID организации",
+		Required:    true,
+		Schema:      &Schema{Type: "string", Format: "uuid"},
+	}
+}
+
+// callStatuses возвращает статусы заявки, которые API возвращает в ответах.
+func callStatuses() []string {
+	statuses := make([]string, len(model.CallStatuses))
+	for i, status := range model.CallStatuses {
+		statuses[i] = string(status)
+	}
+	return statuses
+}
+
+// callStatusInputs возвращает статусы, которые API принимает во входных данных, включая устаревшие.
+func callStatusInputs() []string {
+	return append(callStatuses(), model.LegacyCallStatuses...)
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"call-service/internal/model"
+)
+
+// APIAuditRepository определяет интерфейс для работы с журналом изменяющих запросов HTTP API.
+// Журнал только пополняется: методов изменения и удаления записей нет.
+// Ошибки базы данных возвращаются обернутыми с описанием операции.
+
+type APIAuditRepository interface {
+	// CreateBatch сохраняет записи журнала одним запросом.
+	CreateBatch(ctx context.Context, entries []*model.APIAuditEntry) error
+	// List возвращает записи, удовлетворяющие фильтру, от новых к старым, и общее количество
+	// таких записей без учета пагинации.
+	List(ctx context.Context, filter model.APIAuditFilter) ([]*model.APIAuditEntry, int, error)
+	// CountByStatus возвращает число записей, созданных не раньше since, с кодами ответа
+	// из statuses по методу, маршруту и коду ответа.
+	CountByStatus(ctx context.Context, since time.Time, statuses []int) ([]model.APIAuditCount, error)
+}
+
+// apiAuditRepository реализует интерфейс APIAuditRepository
+
+type apiAuditRepository struct {
+	db *bun.DB
+	queryTimeout
+}
+
+// NewAPIAuditRepository создает новый экземпляр репозитория журнала изменяющих запросов.
+// По умолчанию время выполнения каждого запроса ограничено DefaultQueryTimeout.
+
+func NewAPIAuditRepository(db *bun.DB, opts ...Option) APIAuditRepository {
+	return &apiAuditRepository{db: db, queryTimeout: newQueryTimeout(opts)}
+}
+
+// CreateBatch сохраняет записи журнала изменяющих запросов.
+
+func (r *apiAuditRepository) CreateBatch(ctx context.Context, entries []*model.APIAuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.NewInsert().Model(&entries).Exec(ctx); err != nil {
+		return fmt.Errorf("create %d audit entries: %w", len(entries), mapError(ctx, err))
+	}
+	return nil
+}
+
+// List получает записи журнала изменяющих запросов, удовлетворяющие фильтру.
+
+func (r *apiAuditRepository) List(ctx context.Context, filter model.APIAuditFilter) ([]*model.APIAuditEntry, int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var entries []*model.APIAuditEntry
+	q := r.db.NewSelect().Model(&entries)
+	if filter.UserID != nil {
+		q = q.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.From != nil {
+		q = q.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		q = q.Where("created_at < ?", *filter.To)
+	}
+	q = q.Order("created_at DESC", "id DESC")
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		q = q.Offset(filter.Offset)
+	}
+
+	total, err := q.ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list audit entries: %w", mapError(ctx, err))
+	}
+	return entries, total, nil
+}
+
+// CountByStatus считает записи журнала изменяющих запросов с кодами ответа statuses.
+
+func (r *apiAuditRepository) CountByStatus(ctx context.Context, since time.Time, statuses []int) ([]model.APIAuditCount, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var counts []model.APIAuditCount
+	err := r.db.NewSelect().
+		Model((*model.APIAuditEntry)(nil)).
+		Column("method", "route", "status").
+		ColumnExpr("count(*) AS count").
+		Where("created_at >= ?", since).
+		Where("status IN (?)", bun.In(statuses)).
+		Group("method", "route", "status").
+		Scan(ctx, &counts)
+	if err != nil {
+		return nil, fmt.Errorf("count audit entries by status: %w", mapError(ctx, err))
+	}
+	return counts, nil
+}
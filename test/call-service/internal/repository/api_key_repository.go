@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	"call-service/internal/model"
+)
+
+// APIKeyRepository определяет интерфейс для работы с API-ключами пользователей.
+// Методы, работающие с одним ключом, возвращают ErrNotFound, если ключ отсутствует
+// или не удовлетворяет условию изменения; остальные ошибки базы данных возвращаются
+// обернутыми с описанием операции.
+
+type APIKeyRepository interface {
+	// Create сохраняет ключ; ключ с уже существующим Prefix — ErrAlreadyExists.
+	Create(ctx context.Context, key *model.APIKey) error
+	GetByPrefix(ctx context.Context, prefix string) (*model.APIKey, error)
+	// ListByUserID возвращает все ключи пользователя, включая отозванные, от новых к старым.
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*model.APIKey, error)
+	// Revoke отзывает неотозванный ключ пользователя; чужой или уже отозванный ключ — ErrNotFound.
+	Revoke(ctx context.Context, userID, id uuid.UUID) error
+	// TouchLastUsed сохраняет время последнего использования ключа.
+	TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+	// DeleteByUserID удаляет все ключи пользователя и возвращает их количество.
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+// apiKeyRepository реализует интерфейс APIKeyRepository
+
+type apiKeyRepository struct {
+	db *bun.DB
+	queryTimeout
+}
+
+// NewAPIKeyRepository создает новый экземпляр репозитория API-ключей.
+// По умолчанию время выполнения каждого запроса ограничено DefaultQueryTimeout.
+
+func NewAPIKeyRepository(db *bun.DB, opts ...Option) APIKeyRepository {
+	return &apiKeyRepository{db: db, queryTimeout: newQueryTimeout(opts)}
+}
+
+// Create сохраняет новый ключ в базу данных.
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.NewInsert().Model(key).Returning("id, created_at").Exec(ctx); err != nil {
+		return fmt.Errorf("create api key: %w", mapError(ctx, err))
+	}
+	return nil
+}
+
+// GetByPrefix извлекает ключ по его префиксу.
+
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	key := new(model.APIKey)
+	if err := r.db.NewSelect().Model(key).Where("prefix = ?", prefix).Scan(ctx); err != nil {
+		return nil, fmt.Errorf("get api key by prefix: %w", mapError(ctx, err))
+	}
+	return key, nil
+}
+
+// ListByUserID возвращает ключи пользователя.
+
+func (r *apiKeyRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*model.APIKey, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var keys []*model.APIKey
+	err := r.db.NewSelect().Model(&keys).
+		Where("user_id = ?", userID).
+		Order("created_at DESC", "id").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys of user %s: %w", userID, mapError(ctx, err))
+	}
+	return keys, nil
+}
+
+// Revoke отмечает ключ пользователя отозванным.
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, userID, id uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewUpdate().Model((*model.APIKey)(nil)).
+		Set("revoked = TRUE").
+		Where("id = ?", id).
+		Where("user_id = ?", userID).
+		Where("NOT revoked").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("revoke api key %s: %w", id, mapError(ctx, err))
+	}
+	if err := checkAffected(res); err != nil {
+		return fmt.Errorf("revoke api key %s: %w", id, err)
+	}
+	return nil
+}
+
+// TouchLastUsed обновляет время последнего использования ключа.
+
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewUpdate().Model((*model.APIKey)(nil)).
+		Set("last_used_at = ?", at).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("touch api key %s: %w", id, mapError(ctx, err))
+	}
+	if err := checkAffected(res); err != nil {
+		return fmt.Errorf("touch api key %s: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteByUserID удаляет все ключи пользователя.
+
+func (r *apiKeyRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewDelete().Model((*model.APIKey)(nil)).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("delete api keys of user %s: %w", userID, mapError(ctx, err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("delete api keys of user %s: %w", userID, err)
+	}
+	return int(n), nil
+}
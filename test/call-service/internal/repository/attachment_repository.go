@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	"call-service/internal/model"
+)
+
+// AttachmentRepository определяет интерфейс для работы с описаниями вложений заявок.
+// Методы, работающие с одним вложением, возвращают ErrNotFound, если вложение отсутствует;
+// остальные ошибки базы данных возвращаются обернутыми с описанием операции.
+
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment *model.CallAttachment) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.CallAttachment, error)
+	// ListByCallID возвращает вложения заявки в порядке загрузки.
+	ListByCallID(ctx context.Context, callID uuid.UUID) ([]*model.CallAttachment, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// attachmentRepository реализует интерфейс AttachmentRepository
+
+type attachmentRepository struct {
+	db *bun.DB
+	queryTimeout
+}
+
+// NewAttachmentRepository создает новый экземпляр репозитория вложений.
+// По умолчанию время выполнения каждого запроса ограничено DefaultQueryTimeout.
+
+func NewAttachmentRepository(db *bun.DB, opts ...Option) AttachmentRepository {
+	return &attachmentRepository{db: db, queryTimeout: newQueryTimeout(opts)}
+}
+
+// Create сохраняет описание вложения в базу данных.
+
+func (r *attachmentRepository) Create(ctx context.Context, attachment *model.CallAttachment) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.NewInsert().Model(attachment).Returning("created_at").Exec(ctx); err != nil {
+		return fmt.Errorf("create attachment of call %s: %w", attachment.CallID, mapError(ctx, err))
+	}
+	return nil
+}
+
+// GetByID извлекает описание вложения по ID.
+
+func (r *attachmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.CallAttachment, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	attachment := new(model.CallAttachment)
+	if err := r.db.NewSelect().Model(attachment).Where("id = ?", id).Scan(ctx); err != nil {
+		return nil, fmt.Errorf("get attachment %s: %w", id, mapError(ctx, err))
+	}
+	return attachment, nil
+}
+
+// ListByCallID возвращает описания вложений заявки.
+
+func (r *attachmentRepository) ListByCallID(ctx context.Context, callID uuid.UUID) ([]*model.CallAttachment, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var attachments []*model.CallAttachment
+	err := r.db.NewSelect().Model(&attachments).
+		Where("call_id = ?", callID).
+		Order("created_at", "id").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list attachments of call %s: %w", callID, mapError(ctx, err))
+	}
+	return attachments, nil
+}
+
+// Delete удаляет описание вложения.
+
+func (r *attachmentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewDelete().Model((*model.CallAttachment)(nil)).Where("id = ?", id).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("delete attachment %s: %w", id, mapError(ctx, err))
+	}
+	if err := checkAffected(res); err != nil {
+		return fmt.Errorf("delete attachment %s: %w", id, err)
+	}
+	return nil
+}
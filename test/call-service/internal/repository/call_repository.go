@@ -1,80 +1,716 @@
-package repository
-
-import (
-	"context"
-
-	"github.com/google/uuid"
-	"github.com/uptrace/bun"
-
-	"call-service/internal/model"
-)
-
-// CallRepository определяет интерфейс для работы с заявками в базе данных
-
-type CallRepository interface {
-	Create(ctx context.Context, call *model.Call) error
-	GetByID(ctx context.Context, id uuid.UUID) (*model.Call, error)
-	GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*model.Call, error)
-	UpdateStatus(ctx context.Context, id uuid.UUID, status string) error
-	Delete(ctx context.Context, id uuid.UUID) error
-}
-
-// callRepository реализует интерфейс CallRepository
-
-type callRepository struct {
-	db *bun.DB
-}
-
-// NewCallRepository создает новый экземпляр репозитория
-
-func NewCallRepository(db *bun.DB) CallRepository {
-	return &callRepository{db: db}
-}
-
-// NewCallRepository создает новый экземпляр репозитория
-
-func (r *callRepository) Create(ctx context.Context, call *model.Call) error {
-	_, err := r.db.NewInsert().Model(call).Exec(ctx)
-	return err
-}
-
-// GetByID получает заявку по её ID
-
-func (r *callRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Call, error) {
-	call := new(model.Call)
-	err := r.db.NewSelect().Model(call).Where("id = ?", id).Scan(ctx)
-	if err != nil {
-		return nil, err
-	}
-	return call, nil
-}
-
-// GetAllByUserID получает все заявки пользователя по его ID
-
-func (r *callRepository) GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*model.Call, error) {
-	var calls []*model.Call
-	err := r.db.NewSelect().Model(&calls).Where("user_id = ?", userID).Scan(ctx)
-	if err != nil {
-		return nil, err
-	}
-	return calls, nil
-}
-
-// UpdateStatus обновляет статус заявки
-
-func (r *callRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
-	_, err := r.db.NewUpdate().Model((*model.Call)(nil)).
-		Set("status = ?", status).
-		Where("id = ?", id).
-		Exec(ctx)
-	return err
-}
-
-// Delete удаляет заявку по её ID
-
-func (r *callRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	_, err := r.db.NewDelete().Model((*model.Call)(nil)).
-		Where("id = ?", id).
-		Exec(ctx)
-	return err
-}
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	"call-service/internal/impersonation"
+	"call-service/internal/model"
+	"common/querybuilder"
+)
+
+// CallRepository определяет интерфейс для работы с заявками в базе данных.
+// Методы, работающие с одной заявкой, возвращают ErrNotFound, если заявка отсутствует;
+// остальные ошибки базы данных возвращаются обернутыми с описанием операции.
+// Порядок, в котором методы возвращают несколько записей, полностью определен: при равных
+// значениях полей сортировки записи упорядочиваются по ID, поэтому повторные запросы
+// возвращают записи в том же порядке, а страницы не пересекаются и не теряют записей.
+
+type CallRepository interface {
+	Create(ctx context.Context, call *model.Call) error
+	CreateMany(ctx context.Context, calls []*model.Call) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Call, error)
+	// GetByRef возвращает заявку с кодом ref (model.Call.Ref) или ErrNotFound.
+	GetByRef(ctx context.Context, ref string) (*model.Call, error)
+	// GetAllByUserID возвращает заявки пользователя в порядке создания.
+	GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*model.Call, error)
+	// List возвращает страницу заявок, отсортированную по filter.SortBy, и общее число заявок,
+	// удовлетворяющих фильтру. Заявки с равным значением поля сортировки упорядочиваются
+	// по ID в том же направлении.
+	List(ctx context.Context, filter model.CallFilter) ([]*model.Call, int, error)
+	// ListWithSummary работает как List и заполняет Call.Summary заявок тем же запросом,
+	// без отдельного запроса истории на каждую заявку.
+	ListWithSummary(ctx context.Context, filter model.CallFilter) ([]*model.Call, int, error)
+	ForEachByUserID(ctx context.Context, userID uuid.UUID, filter model.CallFilter, fn func(*model.Call) error) error
+	// UpdateStatus и Reassign записывают actorID в updated_by как автора изменения.
+	// UpdateStatus также добавляет запись в историю статусов, если статус изменился,
+	// а Reassign — запись в историю передачи заявки, если изменился владелец.
+	UpdateStatus(ctx context.Context, id uuid.UUID, status model.CallStatus, actorID uuid.UUID) error
+	Reassign(ctx context.Context, id uuid.UUID, userID uuid.UUID, actorID uuid.UUID) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// CloseStale закрывает не более limit открытых заявок, созданных раньше before,
+	// начиная с самых старых, и возвращает закрытые заявки с заполненными ID, UserID и OrgID.
+	// Автором изменения и записи в истории статусов становится actorID.
+	CloseStale(ctx context.Context, before time.Time, limit int, actorID uuid.UUID) ([]*model.Call, error)
+	// ListStatusChanges возвращает историю статусов заявки в порядке изменений.
+	ListStatusChanges(ctx context.Context, callID uuid.UUID) ([]*model.CallStatusChange, error)
+	// ForEachStatusChangeByUserID последовательно передает в fn записи истории статусов заявок
+	// пользователя и изменения, внесенные им в чужие заявки, в порядке изменений.
+	ForEachStatusChangeByUserID(ctx context.Context, userID uuid.UUID, fn func(*model.CallStatusChange) error) error
+	// ListActivity возвращает до limit записей ленты действий с заявкой (изменений статуса,
+	// попыток звонка и передач) в порядке (At, Type, Key), следующих за позицией after;
+	// nil означает начало ленты.
+	ListActivity(ctx context.Context, callID uuid.UUID, after *model.ActivityCursor, limit int) ([]*model.CallActivity, error)
+	// AddDialAttempt добавляет в историю статусов запись о попытке исходящего звонка
+	// (model.CallStatusChange с заполненным DialID).
+	AddDialAttempt(ctx context.Context, attempt *model.CallStatusChange) error
+	// SetCallback задает время повторного звонка (nil снимает его) и сбрасывает отметку
+	// об отправленном уведомлении.
+	SetCallback(ctx context.Context, id uuid.UUID, callbackAt *time.Time, actorID uuid.UUID) error
+	// ListDueCallbacks возвращает до limit незакрытых заявок, время повторного звонка которых
+	// не позже now и уведомление о котором еще не отправлено, в порядке времени звонка.
+	ListDueCallbacks(ctx context.Context, now time.Time, limit int) ([]*model.Call, error)
+	// MarkCallbackNotified отмечает отправку уведомления о повторном звонке callbackAt.
+	// Если время звонка заявки успели изменить, отметка не ставится.
+	MarkCallbackNotified(ctx context.Context, id uuid.UUID, callbackAt time.Time, notifiedAt time.Time) error
+	// EraseByUserID обезличивает или удаляет, в зависимости от policy, все заявки пользователя
+	// и возвращает обработанные заявки с заполненными ID, UserID и OrgID. Повторный вызов
+	// не изменяет уже обработанные заявки.
+	EraseByUserID(ctx context.Context, userID uuid.UUID, policy model.ErasurePolicy) ([]*model.Call, error)
+}
+
+// callFields — поля заявки, по которым разрешены отбор и сортировка списка. Колонки
+// подставляются в запрос только из этого реестра, никогда напрямую из пользовательского ввода.
+
+var callFields = querybuilder.NewFields(map[string]querybuilder.Field{
+	"id":                   {Column: "id", Sortable: true},
+	"user_id":              {Column: "user_id", Ops: querybuilder.Equality},
+	"org_id":               {Column: "org_id", Ops: querybuilder.Equality},
+	"status":               {Column: "status", Ops: querybuilder.Equality, Sortable: true},
+	"phone_number":         {Column: "phone_number", Ops: querybuilder.Equality},
+	model.SortByCreatedAt:  {Column: "created_at", Ops: querybuilder.Ordered, Sortable: true},
+	model.SortByClientName: {Column: "client_name", Ops: querybuilder.Equality, Sortable: true},
+	model.SortByCallbackAt: {Column: "callback_at", Ops: querybuilder.Ordered, Sortable: true},
+})
+
+// finalStatuses — статусы, при которых работа с заявкой завершена (model.CallStatus.Final).
+
+var finalStatuses = []model.CallStatus{model.CallStatusClosed, model.CallStatusCancelled}
+
+// createManyChunkSize — количество строк в одном многострочном INSERT при пакетной вставке.
+// Ограничивает размер запроса и число параметров в нем.
+
+const createManyChunkSize = 500
+
+// callRepository реализует интерфейс CallRepository
+
+type callRepository struct {
+	db *bun.DB
+	// replica — реплика для запросов чтения; nil, если все запросы выполняются в db.
+	replica *bun.DB
+	queryTimeout
+}
+
+// NewCallRepository создает новый экземпляр репозитория.
+// По умолчанию время выполнения каждого запроса ограничено DefaultQueryTimeout.
+
+func NewCallRepository(db *bun.DB, opts ...Option) CallRepository {
+	return NewCallRepositoryWithReplica(db, nil, opts...)
+}
+
+// NewCallRepositoryWithReplica создает репозиторий, выполняющий запросы чтения (GetByID,
+// GetByRef, GetAllByUserID, List, ListStatusChanges, ListActivity и обходы заявок) в реплике
+// replica, а запись — в основной базе данных db. Реплика может отставать, поэтому чтение, за которым следует
+// запись, выполняется в db с контекстом WithPrimary. Если replica равна nil, все запросы
+// выполняются в db.
+
+func NewCallRepositoryWithReplica(db, replica *bun.DB, opts ...Option) CallRepository {
+	return &callRepository{db: db, replica: replica, queryTimeout: newQueryTimeout(opts)}
+}
+
+// reader возвращает базу данных для запроса чтения: реплику, если она задана и контекст
+// не требует основной базы данных (WithPrimary).
+
+func (r *callRepository) reader(ctx context.Context) *bun.DB {
+	if r.replica == nil || usePrimary(ctx) {
+		return r.db
+	}
+	return r.replica
+}
+
+// Create сохраняет заявку. ID, назначенный сервисным слоем (model.NewID), вставляется
+// как есть; незаданный ID генерирует база данных и возвращает через RETURNING.
+
+func (r *callRepository) Create(ctx context.Context, call *model.Call) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.NewInsert().Model(call).Returning(insertReturning(call)).Exec(ctx); err != nil {
+		return fmt.Errorf("create call: %w", mapError(ctx, err))
+	}
+	return nil
+}
+
+// CreateMany сохраняет заявки пакетами по createManyChunkSize многострочных INSERT в одной
+// транзакции. Либо сохраняются все заявки, либо ни одной. ID и даты создания, не заданные
+// в заявках, генерирует база данных и записывает в переданные структуры; в одном пакете
+// могут быть заявки и с заданными, и с незаданными значениями.
+
+func (r *callRepository) CreateMany(ctx context.Context, calls []*model.Call) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		for start := 0; start < len(calls); start += createManyChunkSize {
+			chunk := calls[start:min(start+createManyChunkSize, len(calls))]
+			if _, err := tx.NewInsert().Model(&chunk).Returning(insertReturning(chunk...)).Exec(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("create %d calls: %w", len(calls), mapError(ctx, err))
+	}
+	return nil
+}
+
+// insertReturning возвращает столбцы RETURNING для вставки заявок calls одним запросом:
+// ID и дату создания, если их генерирует база данных хотя бы для одной заявки. Значения
+// по умолчанию подставляются для каждой строки VALUES отдельно, а RETURNING возвращает
+// все строки, поэтому заданные значения остальных заявок не меняются. У заявок, созданных
+// сервисным слоем, заданы оба значения, и пустая строка отключает RETURNING, который bun
+// иначе добавляет для всех необязательных столбцов со значением nil.
+
+func insertReturning(calls ...*model.Call) string {
+	for _, call := range calls {
+		if call.ID == uuid.Nil || call.CreatedAt.IsZero() {
+			return "id, created_at"
+		}
+	}
+	return ""
+}
+
+// GetByID получает заявку по её ID
+
+func (r *callRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Call, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	call := new(model.Call)
+	err := r.reader(ctx).NewSelect().Model(call).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get call %s: %w", id, mapError(ctx, err))
+	}
+	return call, nil
+}
+
+// GetByRef получает заявку по её коду
+
+func (r *callRepository) GetByRef(ctx context.Context, ref string) (*model.Call, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	call := new(model.Call)
+	err := r.reader(ctx).NewSelect().Model(call).Where("ref = ?", ref).Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get call by ref: %w", mapError(ctx, err))
+	}
+	return call, nil
+}
+
+// GetAllByUserID получает все заявки пользователя по его ID
+
+func (r *callRepository) GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*model.Call, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var calls []*model.Call
+	err := r.reader(ctx).NewSelect().Model(&calls).
+		Where("user_id = ?", userID).
+		Order("created_at ASC", "id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get calls of user %s: %w", userID, mapError(ctx, err))
+	}
+	return calls, nil
+}
+
+// List получает заявки, удовлетворяющие фильтру, и общее количество таких заявок без учета пагинации
+
+func (r *callRepository) List(ctx context.Context, filter model.CallFilter) ([]*model.Call, int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var calls []*model.Call
+	q, err := applyFilter(r.reader(ctx).NewSelect().Model(&calls), filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list calls: %w", err)
+	}
+
+	total, err := q.ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list calls: %w", mapError(ctx, err))
+	}
+	return calls, total, nil
+}
+
+// callSummaryRow — заявка вместе со сводкой по ее истории статусов.
+
+type callSummaryRow struct {
+	model.Call          `bun:",extend"`
+	LastStatusChangeAt  *time.Time `bun:"last_status_change_at"`
+	LastStatusChangedBy *uuid.UUID `bun:"last_status_changed_by,type:uuid"`
+}
+
+// lastStatusChangeJoin присоединяет к каждой заявке последнее изменение ее статуса без попыток
+// звонка. Индекс call_status_changes (call_id, changed_at) позволяет прочитать одну запись
+// на заявку, не перебирая всю историю.
+
+const lastStatusChangeJoin = `LEFT JOIN LATERAL (
+	SELECT changed_at AS last_status_change_at, changed_by AS last_status_changed_by
+	FROM call_status_changes
+	WHERE call_id = "call"."id" AND dial_id IS NULL
+	ORDER BY changed_at DESC, id DESC
+	LIMIT 1
+) AS last_change ON TRUE`
+
+// ListWithSummary получает заявки, удовлетворяющие фильтру, вместе с последним изменением статуса
+// каждой из них одним запросом. Условия фильтра, сортировка и пагинация те же, что в List.
+
+func (r *callRepository) ListWithSummary(ctx context.Context, filter model.CallFilter) ([]*model.Call, int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var rows []callSummaryRow
+	q := r.reader(ctx).NewSelect().Model(&rows).
+		ColumnExpr(`"call".*`).
+		ColumnExpr("last_change.last_status_change_at, last_change.last_status_changed_by").
+		Join(lastStatusChangeJoin)
+	q, err := applyFilter(q, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list calls with summary: %w", err)
+	}
+
+	total, err := q.ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list calls with summary: %w", mapError(ctx, err))
+	}
+	calls := make([]*model.Call, len(rows))
+	for i := range rows {
+		calls[i] = &rows[i].Call
+		calls[i].Summary = &model.CallSummary{
+			LastStatusChangeAt:  rows[i].LastStatusChangeAt,
+			LastStatusChangedBy: rows[i].LastStatusChangedBy,
+		}
+	}
+	return calls, total, nil
+}
+
+// ForEachByUserID последовательно передает в fn заявки пользователя, удовлетворяющие фильтру.
+// Строки читаются из курсора по мере обхода, поэтому весь результат не загружается в память.
+// Обход прекращается при первой ошибке fn или отмене контекста; эта ошибка возвращается вызывающему.
+// Ограничение времени запроса здесь не применяется: продолжительность обхода определяется
+// вызывающим, который может задать ее через контекст.
+
+func (r *callRepository) ForEachByUserID(ctx context.Context, userID uuid.UUID, filter model.CallFilter, fn func(*model.Call) error) error {
+	db := r.reader(ctx)
+	filter.UserID = &userID
+	q, err := applyFilter(db.NewSelect().Model((*model.Call)(nil)), filter)
+	if err != nil {
+		return fmt.Errorf("iterate calls of user %s: %w", userID, err)
+	}
+	rows, err := q.Rows(ctx)
+	if err != nil {
+		return fmt.Errorf("iterate calls of user %s: %w", userID, mapError(ctx, err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		call := new(model.Call)
+		if err := db.ScanRow(ctx, rows, call); err != nil {
+			return fmt.Errorf("scan call: %w", mapError(ctx, err))
+		}
+		if err := fn(call); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate calls of user %s: %w", userID, mapError(ctx, err))
+	}
+	return nil
+}
+
+// callFilterSpec переводит фильтр списка заявок в описание отбора для callFields. Пустое поле
+// сортировки означает сортировку по времени создания. При равных значениях поля сортировки
+// заявки упорядочиваются по ID в том же направлении, поэтому порядок не меняется между
+// запросами, а соседние страницы не пересекаются.
+func callFilterSpec(filter model.CallFilter) querybuilder.FilterSpec {
+	var spec querybuilder.FilterSpec
+	switch {
+	case filter.UserID != nil && filter.OrgID != nil:
+		spec.Conditions = append(spec.Conditions, querybuilder.AnyOf(
+			querybuilder.Where("user_id", querybuilder.Eq, *filter.UserID),
+			querybuilder.Where("org_id", querybuilder.Eq, *filter.OrgID),
+		))
+	case filter.UserID != nil:
+		spec.Conditions = append(spec.Conditions, querybuilder.Where("user_id", querybuilder.Eq, *filter.UserID))
+	case filter.OrgID != nil:
+		spec.Conditions = append(spec.Conditions, querybuilder.Where("org_id", querybuilder.Eq, *filter.OrgID))
+	}
+	if filter.Status != "" {
+		spec.Conditions = append(spec.Conditions, querybuilder.Where("status", querybuilder.Eq, filter.Status))
+	}
+	if filter.PhoneNumber != "" {
+		spec.Conditions = append(spec.Conditions, querybuilder.Where("phone_number", querybuilder.Eq, filter.PhoneNumber))
+	}
+	if filter.CreatedFrom != nil {
+		spec.Conditions = append(spec.Conditions, querybuilder.Where(model.SortByCreatedAt, querybuilder.Gte, *filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		spec.Conditions = append(spec.Conditions, querybuilder.Where(model.SortByCreatedAt, querybuilder.Lt, *filter.CreatedTo))
+	}
+	if filter.CallbackDue != nil {
+		spec.Conditions = append(spec.Conditions,
+			querybuilder.Where(model.SortByCallbackAt, querybuilder.Lte, *filter.CallbackDue),
+			querybuilder.Where("status", querybuilder.NotIn, finalStatuses))
+	}
+
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = model.SortByCreatedAt
+	}
+	spec.Sort = []querybuilder.Sort{{Field: sortBy, Desc: filter.SortDesc}, {Field: "id", Desc: filter.SortDesc}}
+	spec.Limit, spec.Offset = filter.Limit, filter.Offset
+	return spec
+}
+
+// applyFilter добавляет к запросу условия отбора, сортировку и пагинацию из фильтра.
+// Если заданы и UserID, и OrgID, отбираются заявки пользователя и заявки организации.
+// Для поля сортировки не из реестра callFields возвращается ошибка, оборачивающая ErrInvalidFilter.
+
+func applyFilter(q *bun.SelectQuery, filter model.CallFilter) (*bun.SelectQuery, error) {
+	return callFields.Apply(q, callFilterSpec(filter))
+}
+
+// UpdateStatus обновляет статус заявки и в той же транзакции записывает изменение в историю статусов.
+// При закрытии или отмене заявки запланированный повторный звонок снимается.
+
+func (r *callRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status model.CallStatus, actorID uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var old model.CallStatus
+		err := tx.NewSelect().Model((*model.Call)(nil)).
+			Column("status").
+			Where("id = ?", id).
+			For("UPDATE").
+			Scan(ctx, &old)
+		if err != nil {
+			return err
+		}
+		q := tx.NewUpdate().Model((*model.Call)(nil)).
+			Set("status = ?", status).
+			Set("updated_by = ?", actorID).
+			Where("id = ?", id)
+		if status.Final() {
+			// Закрытая заявка не требует повторного звонка
+			q = q.Set("callback_at = NULL")
+		}
+		if _, err = q.Exec(ctx); err != nil || old == status {
+			return err
+		}
+		change := &model.CallStatusChange{
+			CallID:         id,
+			OldStatus:      old,
+			NewStatus:      status,
+			ChangedBy:      actorID,
+			ImpersonatedBy: impersonation.Impersonator(ctx),
+		}
+		_, err = tx.NewInsert().Model(change).Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("update status of call %s: %w", id, mapError(ctx, err))
+	}
+	return nil
+}
+
+// Reassign передает заявку другому пользователю и записывает передачу в историю одним запросом.
+// Прежний владелец читается с блокировкой строки, поэтому одновременные передачи записываются
+// в историю в том порядке, в котором выполнены.
+
+func (r *callRepository) Reassign(ctx context.Context, id uuid.UUID, userID uuid.UUID, actorID uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewRaw(`
+		WITH old AS (
+			SELECT id, user_id FROM calls WHERE id = ? FOR UPDATE
+		), reassigned AS (
+			UPDATE calls SET user_id = ?, updated_by = ?
+			FROM old WHERE calls.id = old.id
+			RETURNING calls.id, old.user_id AS old_user_id
+		), recorded AS (
+			INSERT INTO call_assignments (call_id, old_user_id, new_user_id, changed_by)
+			SELECT id, old_user_id, ?, ? FROM reassigned WHERE old_user_id <> ?
+		)
+		SELECT id FROM reassigned`,
+		id, userID, actorID, userID, actorID, userID,
+	).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("reassign call %s: %w", id, mapError(ctx, err))
+	}
+	return checkAffected(res)
+}
+
+// Delete удаляет заявку по её ID
+
+func (r *callRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewDelete().Model((*model.Call)(nil)).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("delete call %s: %w", id, mapError(ctx, err))
+	}
+	return checkAffected(res)
+}
+
+// CloseStale закрывает пакет давно открытых заявок одним запросом: выбирает до limit заявок,
+// пропуская заблокированные другими транзакциями, меняет их статус и записывает изменения
+// в историю статусов.
+
+func (r *callRepository) CloseStale(ctx context.Context, before time.Time, limit int, actorID uuid.UUID) ([]*model.Call, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	// Изменяющие подзапросы WITH выполняются, даже если основной запрос их не читает
+	closed := []*model.Call{}
+	err := r.db.NewRaw(`
+		WITH stale AS (
+			SELECT id FROM calls
+			WHERE status = ? AND created_at < ?
+			ORDER BY created_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		), closed AS (
+			UPDATE calls SET status = ?, updated_by = ?, callback_at = NULL
+			FROM stale WHERE calls.id = stale.id
+			RETURNING calls.id, calls.user_id, calls.org_id
+		), history AS (
+			INSERT INTO call_status_changes (call_id, old_status, new_status, changed_by)
+			SELECT id, ?, ?, ? FROM closed
+		)
+		SELECT id, user_id, org_id FROM closed`,
+		model.CallStatusOpen, before, limit,
+		model.CallStatusClosed, actorID,
+		model.CallStatusOpen, model.CallStatusClosed, actorID,
+	).Scan(ctx, &closed)
+	if err != nil {
+		return nil, fmt.Errorf("close stale calls: %w", mapError(ctx, err))
+	}
+	return closed, nil
+}
+
+// ListStatusChanges возвращает историю статусов заявки в порядке изменений
+
+func (r *callRepository) ListStatusChanges(ctx context.Context, callID uuid.UUID) ([]*model.CallStatusChange, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var changes []*model.CallStatusChange
+	err := r.reader(ctx).NewSelect().Model(&changes).
+		Where("call_id = ?", callID).
+		Order("changed_at ASC", "id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list status changes of call %s: %w", callID, mapError(ctx, err))
+	}
+	return changes, nil
+}
+
+// ForEachStatusChangeByUserID передает в fn записи истории статусов, связанные с пользователем.
+// Как и ForEachByUserID, читает строки из курсора и не ограничивает время обхода.
+
+func (r *callRepository) ForEachStatusChangeByUserID(ctx context.Context, userID uuid.UUID, fn func(*model.CallStatusChange) error) error {
+	db := r.reader(ctx)
+	owned := db.NewSelect().Model((*model.Call)(nil)).Column("id").Where("user_id = ?", userID)
+	rows, err := db.NewSelect().Model((*model.CallStatusChange)(nil)).
+		Where("call_id IN (?)", owned).
+		WhereOr("changed_by = ?", userID).
+		Order("id ASC").
+		Rows(ctx)
+	if err != nil {
+		return fmt.Errorf("iterate status changes of user %s: %w", userID, mapError(ctx, err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		change := new(model.CallStatusChange)
+		if err := db.ScanRow(ctx, rows, change); err != nil {
+			return fmt.Errorf("scan status change: %w", mapError(ctx, err))
+		}
+		if err := fn(change); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate status changes of user %s: %w", userID, mapError(ctx, err))
+	}
+	return nil
+}
+
+// activityQuery объединяет историю статусов и историю передач заявки в ленту действий.
+// Ключ записи — ее ID, дополненный нулями до 20 знаков (длины наибольшего BIGINT), чтобы
+// сравнение строк совпадало со сравнением ID. Условие позиции и сортировка по (at, type, key)
+// вычисляются над объединением, поэтому страницы ленты не пересекаются и не теряют записи
+// с одинаковым временем.
+
+const activityQuery = `
+	SELECT * FROM (
+		SELECT CASE WHEN dial_id IS NULL THEN ? ELSE ? END AS type,
+			changed_at AS at, lpad(id::text, 20, '0') AS key, changed_by AS actor_id, impersonated_by,
+			old_status, new_status, dial_id, coalesce(dial_reference, '') AS dial_reference,
+			NULL::uuid AS old_user_id, NULL::uuid AS new_user_id
+		FROM call_status_changes WHERE call_id = ?
+		UNION ALL
+		SELECT ?, changed_at, lpad(id::text, 20, '0'), changed_by, NULL,
+			'', '', NULL, '', old_user_id, new_user_id
+		FROM call_assignments WHERE call_id = ?
+	) AS activity
+	WHERE ? OR (at, type, key) > (?, ?, ?)
+	ORDER BY at, type, key
+	LIMIT ?`
+
+// ListActivity получает страницу ленты действий с заявкой одним запросом UNION ALL
+
+func (r *callRepository) ListActivity(ctx context.Context, callID uuid.UUID, after *model.ActivityCursor, limit int) ([]*model.CallActivity, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	// Без позиции первое условие отбирает все записи с начала ленты
+	var position model.ActivityCursor
+	if after != nil {
+		position = *after
+	}
+	var activity []*model.CallActivity
+	err := r.reader(ctx).NewRaw(activityQuery,
+		model.ActivityStatusChange, model.ActivityDialAttempt, callID,
+		model.ActivityAssignment, callID,
+		after == nil, position.At, position.Type, position.Key, limit,
+	).Scan(ctx, &activity)
+	if err != nil {
+		return nil, fmt.Errorf("list activity of call %s: %w", callID, mapError(ctx, err))
+	}
+	return activity, nil
+}
+
+// AddDialAttempt записывает попытку исходящего звонка в историю статусов
+
+func (r *callRepository) AddDialAttempt(ctx context.Context, attempt *model.CallStatusChange) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.NewInsert().Model(attempt).Exec(ctx); err != nil {
+		return fmt.Errorf("add dial attempt to call %s: %w", attempt.CallID, mapError(ctx, err))
+	}
+	return nil
+}
+
+// SetCallback задает время повторного звонка заявки
+
+func (r *callRepository) SetCallback(ctx context.Context, id uuid.UUID, callbackAt *time.Time, actorID uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewUpdate().Model((*model.Call)(nil)).
+		Set("callback_at = ?", callbackAt).
+		Set("callback_notified_at = NULL").
+		Set("updated_by = ?", actorID).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("set callback of call %s: %w", id, mapError(ctx, err))
+	}
+	return checkAffected(res)
+}
+
+// ListDueCallbacks получает заявки с наступившим временем повторного звонка без отправленного уведомления.
+// Запрос выполняется в основной базе данных: по отстающей реплике уже отправленное
+// уведомление было бы отправлено повторно.
+
+func (r *callRepository) ListDueCallbacks(ctx context.Context, now time.Time, limit int) ([]*model.Call, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var calls []*model.Call
+	err := r.db.NewSelect().Model(&calls).
+		Where("callback_at <= ?", now).
+		Where("callback_notified_at IS NULL").
+		Where("status NOT IN (?)", bun.In(finalStatuses)).
+		Order("callback_at ASC", "id ASC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list due callbacks: %w", mapError(ctx, err))
+	}
+	return calls, nil
+}
+
+// MarkCallbackNotified отмечает отправку уведомления о повторном звонке
+
+func (r *callRepository) MarkCallbackNotified(ctx context.Context, id uuid.UUID, callbackAt time.Time, notifiedAt time.Time) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.NewUpdate().Model((*model.Call)(nil)).
+		Set("callback_notified_at = ?", notifiedAt).
+		Where("id = ?", id).
+		Where("callback_at = ?", callbackAt).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("mark callback of call %s notified: %w", id, mapError(ctx, err))
+	}
+	return nil
+}
+
+// EraseByUserID обрабатывает заявки пользователя одним запросом. При удалении история статусов
+// удаляется вместе с заявками (ON DELETE CASCADE); при обезличивании история сохраняется,
+// так как не содержит данных клиента.
+
+func (r *callRepository) EraseByUserID(ctx context.Context, userID uuid.UUID, policy model.ErasurePolicy) ([]*model.Call, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	erased := []*model.Call{}
+	var err error
+	if policy == model.ErasurePolicyDelete {
+		_, err = r.db.NewDelete().Model((*model.Call)(nil)).
+			Where("user_id = ?", userID).
+			Returning("id, user_id, org_id").
+			Exec(ctx, &erased)
+	} else {
+		_, err = r.db.NewUpdate().Model((*model.Call)(nil)).
+			Set("client_name = ?", model.ErasedClientName).
+			Set("phone_number = ''").
+			Set("description = ''").
+			Set("callback_at = NULL").
+			Set("callback_notified_at = NULL").
+			Where("user_id = ?", userID).
+			Where("client_name <> ? OR phone_number <> '' OR description <> '' OR callback_at IS NOT NULL",
+				model.ErasedClientName).
+			Returning("id, user_id, org_id").
+			Exec(ctx, &erased)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erase calls of user %s: %w", userID, mapError(ctx, err))
+	}
+	return erased, nil
+}
@@ -0,0 +1,373 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+
+	"call-service/internal/model"
+)
+
+// fakeConnector — минимальный драйвер database/sql, возвращающий заданное число строк заявок
+// на любой запрос. Считает открытые курсоры, чтобы проверять их освобождение, и все
+// выполненные запросы, чтобы проверять, в какую базу данных они направлены.
+
+type fakeConnector struct {
+	rows       int
+	delay      time.Duration
+	openCursor atomic.Int64
+	lastQuery  atomic.Value
+	queries    atomic.Int64
+	// onQuery, если задана, получает текст каждого запроса.
+	onQuery func(query string)
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) { return &fakeConn{c: c}, nil }
+func (c *fakeConnector) Driver() driver.Driver                        { return nil }
+
+// record учитывает выполненный запрос.
+func (c *fakeConnector) record(query string) {
+	c.lastQuery.Store(query)
+	c.queries.Add(1)
+	if c.onQuery != nil {
+		c.onQuery(query)
+	}
+}
+
+type fakeConn struct{ c *fakeConnector }
+
+func (f *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (f *fakeConn) Close() error                        { return nil }
+func (f *fakeConn) Begin() (driver.Tx, error)           { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func (f *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	f.c.record(query)
+	return driver.RowsAffected(1), nil
+}
+
+func (f *fakeConn) QueryContext(ctx context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	f.c.record(query)
+	if f.c.delay > 0 {
+		// Имитация долгого запроса, который прерывается отменой контекста, как в pgdriver
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(f.c.delay):
+		}
+	}
+	f.c.openCursor.Add(1)
+	return &fakeRows{c: f.c, left: f.c.rows}, nil
+}
+
+type fakeRows struct {
+	c      *fakeConnector
+	left   int
+	closed bool
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"id", "client_name", "phone_number", "description", "status", "created_at", "user_id"}
+}
+
+func (r *fakeRows) Close() error {
+	if !r.closed {
+		r.closed = true
+		r.c.openCursor.Add(-1)
+	}
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.left == 0 {
+		return io.EOF
+	}
+	r.left--
+	dest[0] = uuid.NewString()
+	dest[1] = "Клиент"
+	dest[2] = "+79990000000"
+	dest[3] = "Описание"
+	dest[4] = "open"
+	dest[5] = time.Now()
+	dest[6] = uuid.NewString()
+	return nil
+}
+
+func newFakeRepository(t *testing.T, rows int, opts ...Option) (CallRepository, *fakeConnector, *bun.DB) {
+	t.Helper()
+	connector := &fakeConnector{rows: rows}
+	db := bun.NewDB(sql.OpenDB(connector), pgdialect.New())
+	t.Cleanup(func() { _ = db.Close() })
+	return NewCallRepository(db, opts...), connector, db
+}
+
+// Тест вставки заявок с ID, назначенным сервисным слоем: ID передается в INSERT без RETURNING
+// и не меняется; без ID он генерируется базой данных и возвращается через RETURNING
+func TestCreate_PresetID(t *testing.T) {
+	repo, connector, _ := newFakeRepository(t, 1)
+	ctx := context.Background()
+	newCall := func(id uuid.UUID) *model.Call {
+		return &model.Call{ID: id, ClientName: "Клиент", PhoneNumber: "+79990000000", Status: model.CallStatusOpen,
+			CreatedAt: time.Now(), UserID: uuid.New(), CreatedBy: uuid.New()}
+	}
+
+	id := model.NewID()
+	call := newCall(id)
+	require.NoError(t, repo.Create(ctx, call))
+	query := connector.lastQuery.Load().(string)
+	assert.Contains(t, query, "'"+id.String()+"'")
+	assert.NotContains(t, query, "RETURNING")
+	assert.Equal(t, id, call.ID)
+
+	calls := []*model.Call{newCall(model.NewID()), newCall(model.NewID())}
+	require.NoError(t, repo.CreateMany(ctx, calls))
+	query = connector.lastQuery.Load().(string)
+	assert.Contains(t, query, "'"+calls[1].ID.String()+"'")
+	assert.NotContains(t, query, "RETURNING")
+
+	call = newCall(uuid.Nil)
+	require.NoError(t, repo.Create(ctx, call))
+	assert.Contains(t, connector.lastQuery.Load(), "RETURNING id, created_at")
+	assert.NotEqual(t, uuid.Nil, call.ID)
+}
+
+// Тест границы пакетов CreateMany: заявки вставляются запросом на каждые createManyChunkSize
+// заявок, и RETURNING добавляется в запрос пакета, если ID не задан хотя бы у одной его
+// заявки, а не только у первой
+func TestCreateMany_ChunkBoundary(t *testing.T) {
+	repo, connector, _ := newFakeRepository(t, 0)
+	ctx := context.Background()
+	calls := make([]*model.Call, createManyChunkSize+2)
+	for i := range calls {
+		calls[i] = &model.Call{ID: model.NewID(), ClientName: "Клиент", PhoneNumber: "+79990000000", Status: model.CallStatusOpen,
+			CreatedAt: time.Now(), UserID: uuid.New(), CreatedBy: uuid.New()}
+	}
+	// Во втором пакете ID не задан только у последней заявки
+	calls[len(calls)-1].ID = uuid.Nil
+
+	var queries []string
+	connector.onQuery = func(query string) {
+		if strings.HasPrefix(query, "INSERT") {
+			queries = append(queries, query)
+		}
+	}
+	require.NoError(t, repo.CreateMany(ctx, calls))
+
+	require.Len(t, queries, 2)
+	assert.NotContains(t, queries[0], "RETURNING")
+	assert.Contains(t, queries[0], "'"+calls[createManyChunkSize-1].ID.String()+"'")
+	assert.NotContains(t, queries[0], "'"+calls[createManyChunkSize].ID.String()+"'")
+	assert.Contains(t, queries[1], "'"+calls[createManyChunkSize].ID.String()+"'")
+	assert.Contains(t, queries[1], "RETURNING id, created_at")
+}
+
+// Тест обхода заявок: fn вызывается для каждой строки, запрос содержит фильтр пользователя
+func TestForEachByUserID(t *testing.T) {
+	repo, connector, db := newFakeRepository(t, 5)
+	userID := uuid.New()
+
+	count := 0
+	err := repo.ForEachByUserID(context.Background(), userID, model.CallFilter{Status: "open"}, func(call *model.Call) error {
+		assert.Equal(t, "+79990000000", call.PhoneNumber)
+		count++
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+	assert.Contains(t, connector.lastQuery.Load(), `"user_id" = '`+userID.String()+"'")
+	assert.Equal(t, int64(0), connector.openCursor.Load())
+	assert.Equal(t, 0, db.Stats().InUse)
+}
+
+// Тест досрочного завершения: ошибка fn прекращает обход и освобождает курсор и соединение
+func TestForEachByUserID_StopsOnError(t *testing.T) {
+	repo, connector, db := newFakeRepository(t, 100)
+	stop := errors.New("stop")
+
+	count := 0
+	err := repo.ForEachByUserID(context.Background(), uuid.New(), model.CallFilter{}, func(*model.Call) error {
+		count++
+		if count == 3 {
+			return stop
+		}
+		return nil
+	})
+
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, int64(0), connector.openCursor.Load())
+	assert.Equal(t, 0, db.Stats().InUse)
+}
+
+// Тест отмены контекста во время обхода: обход прекращается без утечки соединения
+func TestForEachByUserID_ContextCanceled(t *testing.T) {
+	repo, connector, db := newFakeRepository(t, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	count := 0
+	err := repo.ForEachByUserID(ctx, uuid.New(), model.CallFilter{}, func(*model.Call) error {
+		count++
+		if count == 2 {
+			cancel()
+		}
+		return nil
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, count, 100)
+	assert.Equal(t, int64(0), connector.openCursor.Load())
+	assert.Eventually(t, func() bool { return db.Stats().InUse == 0 }, time.Second, 10*time.Millisecond)
+}
+
+// Тест списка со сводкой: заявки и последние изменения статуса читаются одним запросом
+// с условиями фильтра и сортировкой списка
+func TestListWithSummary_SingleQuery(t *testing.T) {
+	repo, connector, _ := newFakeRepository(t, 3)
+	userID := uuid.New()
+
+	calls, total, err := repo.ListWithSummary(context.Background(), model.CallFilter{
+		UserID: &userID, Status: model.CallStatusOpen, SortBy: model.SortByClientName,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, calls, 3)
+	for _, call := range calls {
+		require.NotNil(t, call.Summary)
+		assert.Nil(t, call.Summary.LastStatusChangeAt)
+	}
+	assert.Equal(t, int64(1), connector.queries.Load())
+	query := connector.lastQuery.Load().(string)
+	assert.Contains(t, query, "LEFT JOIN LATERAL")
+	assert.Contains(t, query, `"user_id" = '`+userID.String()+"'")
+	assert.Contains(t, query, `"status" = 'open'`)
+	assert.Contains(t, query, `ORDER BY "client_name" ASC, "id" ASC`)
+}
+
+// Тест условий списка: заявки пользователя и организации отбираются одной группой условий,
+// а поле сортировки не из реестра отклоняется без запроса к базе данных
+func TestList_Filter(t *testing.T) {
+	repo, connector, _ := newFakeRepository(t, 1)
+	userID, orgID := uuid.New(), uuid.New()
+	due := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	_, _, err := repo.List(context.Background(), model.CallFilter{UserID: &userID, OrgID: &orgID, CallbackDue: &due, SortDesc: true})
+	require.NoError(t, err)
+	query := connector.lastQuery.Load().(string)
+	assert.Contains(t, query, `("user_id" = '`+userID.String()+`' OR "org_id" = '`+orgID.String()+`')`)
+	assert.Contains(t, query, `"callback_at" <= '2026-10-16 12:00:00+00:00'`)
+	assert.Contains(t, query, `"status" NOT IN ('closed', 'cancelled')`)
+	assert.Contains(t, query, `ORDER BY "created_at" DESC, "id" DESC`)
+
+	queries := connector.queries.Load()
+	_, _, err = repo.List(context.Background(), model.CallFilter{SortBy: "created_at; DROP TABLE calls"})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+	assert.Equal(t, queries, connector.queries.Load())
+}
+
+// Тест порядка выборок заявок: при равных значениях полей сортировки заявки упорядочиваются
+// по ID, чтобы порядок не зависел от плана запроса
+func TestOrder_TieBreak(t *testing.T) {
+	repo, connector, _ := newFakeRepository(t, 0)
+	ctx := context.Background()
+
+	_, err := repo.GetAllByUserID(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.Contains(t, connector.lastQuery.Load().(string), `ORDER BY "created_at" ASC, "id" ASC`)
+
+	_, err = repo.ListDueCallbacks(ctx, time.Now(), 10)
+	require.NoError(t, err)
+	assert.Contains(t, connector.lastQuery.Load().(string), `ORDER BY "callback_at" ASC, "id" ASC`)
+
+	_, err = repo.CloseStale(ctx, time.Now(), 10, uuid.New())
+	require.NoError(t, err)
+	assert.Contains(t, connector.lastQuery.Load().(string), "ORDER BY created_at, id")
+}
+
+// Тест ленты действий: история статусов и передач объединяется одним запросом с позицией,
+// сортировкой и ограничением над объединением, а передача заявки записывается в историю
+// тем же запросом, что и смена владельца
+func TestListActivity_SingleQuery(t *testing.T) {
+	repo, connector, _ := newFakeRepository(t, 0)
+	callID := uuid.New()
+	after := model.ActivityCursor{At: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC), Type: model.ActivityDialAttempt, Key: "00000000000000000042"}
+
+	_, err := repo.ListActivity(context.Background(), callID, &after, 11)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), connector.queries.Load())
+	query := connector.lastQuery.Load().(string)
+	assert.Contains(t, query, "UNION ALL")
+	assert.Contains(t, query, "FALSE OR (at, type, key) > ('2026-10-16 12:00:00+00:00', 'dial_attempt', '00000000000000000042')")
+	assert.Contains(t, query, "ORDER BY at, type, key")
+	assert.Contains(t, query, "LIMIT 11")
+
+	require.NoError(t, repo.Reassign(context.Background(), callID, uuid.New(), uuid.New()))
+	assert.Equal(t, int64(2), connector.queries.Load())
+	assert.Contains(t, connector.lastQuery.Load().(string), "INSERT INTO call_assignments")
+}
+
+// Тест ограничения времени запроса: долгий запрос прерывается и возвращает ErrTimeout
+func TestGetByID_QueryTimeout(t *testing.T) {
+	repo, connector, db := newFakeRepository(t, 1, WithQueryTimeout(20*time.Millisecond))
+	connector.delay = time.Second
+
+	start := time.Now()
+	call, err := repo.GetByID(context.Background(), uuid.New())
+
+	assert.Nil(t, call)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.Less(t, time.Since(start), connector.delay)
+	assert.Eventually(t, func() bool { return db.Stats().InUse == 0 }, time.Second, 10*time.Millisecond)
+}
+
+// Тест отмены запроса вызывающим: ошибка не считается истечением времени
+func TestGetByID_CallerCanceled(t *testing.T) {
+	repo, connector, _ := newFakeRepository(t, 1, WithQueryTimeout(time.Second))
+	connector.delay = time.Second
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, err := repo.GetByID(ctx, uuid.New())
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, ErrTimeout)
+}
+
+// Тест журнала медленных запросов: запись содержит ограничение времени запроса
+func TestSlowQueryHook_LogsTimeout(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	repo, connector, db := newFakeRepository(t, 1, WithQueryTimeout(20*time.Millisecond))
+	db.AddQueryHook(&SlowQueryHook{Threshold: time.Hour})
+	connector.delay = time.Second
+
+	_, err := repo.GetByID(context.Background(), uuid.New())
+
+	require.ErrorIs(t, err, ErrTimeout)
+	assert.Contains(t, buf.String(), "query timed out")
+	assert.Contains(t, buf.String(), "timeout=20ms")
+	assert.Contains(t, buf.String(), `FROM "calls"`)
+}
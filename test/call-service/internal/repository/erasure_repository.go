@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	"call-service/internal/model"
+)
+
+// ErasureRepository определяет интерфейс для работы с незавершенными удалениями учетных записей.
+// Методы, работающие с одной записью, возвращают ErrNotFound, если запись отсутствует;
+// остальные ошибки базы данных возвращаются обернутыми с описанием операции.
+
+type ErasureRepository interface {
+	// Create сохраняет запись об удалении; запись для того же пользователя — ErrAlreadyExists.
+	Create(ctx context.Context, erasure *model.AccountErasure) error
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*model.AccountErasure, error)
+	// SetState сохраняет шаг, на котором остановилось удаление.
+	SetState(ctx context.Context, userID uuid.UUID, state model.ErasureState, at time.Time) error
+	// RecordFailure увеличивает счетчик неудачных попыток и сохраняет описание ошибки.
+	RecordFailure(ctx context.Context, userID uuid.UUID, message string, at time.Time) error
+	// ListStale возвращает до limit записей, не изменявшихся с момента before, от давних к новым.
+	ListStale(ctx context.Context, before time.Time, limit int) ([]*model.AccountErasure, error)
+	// Delete удаляет запись после завершения удаления учетной записи.
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
+
+// erasureRepository реализует интерфейс ErasureRepository
+
+type erasureRepository struct {
+	db *bun.DB
+	queryTimeout
+}
+
+// NewErasureRepository создает новый экземпляр репозитория удалений учетных записей.
+// По умолчанию время выполнения каждого запроса ограничено DefaultQueryTimeout.
+
+func NewErasureRepository(db *bun.DB, opts ...Option) ErasureRepository {
+	return &erasureRepository{db: db, queryTimeout: newQueryTimeout(opts)}
+}
+
+// Create сохраняет новую запись об удалении учетной записи.
+
+func (r *erasureRepository) Create(ctx context.Context, erasure *model.AccountErasure) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.NewInsert().Model(erasure).Returning("created_at, updated_at").Exec(ctx); err != nil {
+		return fmt.Errorf("create erasure of user %s: %w", erasure.UserID, mapError(ctx, err))
+	}
+	return nil
+}
+
+// GetByUserID извлекает запись об удалении учетной записи пользователя.
+
+func (r *erasureRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*model.AccountErasure, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	erasure := new(model.AccountErasure)
+	if err := r.db.NewSelect().Model(erasure).Where("user_id = ?", userID).Scan(ctx); err != nil {
+		return nil, fmt.Errorf("get erasure of user %s: %w", userID, mapError(ctx, err))
+	}
+	return erasure, nil
+}
+
+// SetState сохраняет шаг удаления учетной записи.
+
+func (r *erasureRepository) SetState(ctx context.Context, userID uuid.UUID, state model.ErasureState, at time.Time) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewUpdate().Model((*model.AccountErasure)(nil)).
+		Set("state = ?", state).
+		Set("updated_at = ?", at).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("set erasure state of user %s: %w", userID, mapError(ctx, err))
+	}
+	return checkAffected(res)
+}
+
+// RecordFailure сохраняет неудачную попытку удаления учетной записи.
+
+func (r *erasureRepository) RecordFailure(ctx context.Context, userID uuid.UUID, message string, at time.Time) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewUpdate().Model((*model.AccountErasure)(nil)).
+		Set("attempts = attempts + 1").
+		Set("last_error = ?", message).
+		Set("updated_at = ?", at).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("record erasure failure of user %s: %w", userID, mapError(ctx, err))
+	}
+	return checkAffected(res)
+}
+
+// ListStale получает давно не изменявшиеся записи об удалении учетных записей.
+
+func (r *erasureRepository) ListStale(ctx context.Context, before time.Time, limit int) ([]*model.AccountErasure, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var erasures []*model.AccountErasure
+	err := r.db.NewSelect().Model(&erasures).
+		Where("updated_at < ?", before).
+		Order("updated_at", "user_id").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list stale erasures: %w", mapError(ctx, err))
+	}
+	return erasures, nil
+}
+
+// Delete удаляет запись об удалении учетной записи.
+
+func (r *erasureRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewDelete().Model((*model.AccountErasure)(nil)).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("delete erasure of user %s: %w", userID, mapError(ctx, err))
+	}
+	return checkAffected(res)
+}
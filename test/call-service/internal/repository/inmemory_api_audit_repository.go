@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"call-service/internal/model"
+)
+
+// inMemoryAPIAuditRepository хранит журнал изменяющих запросов в памяти процесса.
+// Повторяет поведение apiAuditRepository.
+
+type inMemoryAPIAuditRepository struct {
+	mu      sync.RWMutex
+	entries []*model.APIAuditEntry
+}
+
+// NewInMemoryAPIAuditRepository создает репозиторий журнала изменяющих запросов без базы данных.
+// Используется в тестах и в режиме DEV_INMEMORY; данные не сохраняются между запусками.
+
+func NewInMemoryAPIAuditRepository() APIAuditRepository {
+	return &inMemoryAPIAuditRepository{}
+}
+
+// CreateBatch сохраняет копии записей журнала.
+
+func (r *inMemoryAPIAuditRepository) CreateBatch(ctx context.Context, entries []*model.APIAuditEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, entry := range entries {
+		stored := *entry
+		r.entries = append(r.entries, &stored)
+	}
+	return nil
+}
+
+// List возвращает копии записей, удовлетворяющих фильтру, от новых к старым.
+
+func (r *inMemoryAPIAuditRepository) List(ctx context.Context, filter model.APIAuditFilter) ([]*model.APIAuditEntry, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []*model.APIAuditEntry
+	for _, stored := range r.entries {
+		if filter.UserID != nil && (stored.UserID == nil || *stored.UserID != *filter.UserID) {
+			continue
+		}
+		if filter.From != nil && stored.CreatedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && !stored.CreatedAt.Before(*filter.To) {
+			continue
+		}
+		entry := *stored
+		entries = append(entries, &entry)
+	}
+	slices.SortFunc(entries, func(a, b *model.APIAuditEntry) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID.String(), a.ID.String()))
+	})
+
+	total := len(entries)
+	entries = entries[min(filter.Offset, total):]
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+	return entries, total, nil
+}
+
+// CountByStatus считает записи с кодами ответа statuses.
+
+func (r *inMemoryAPIAuditRepository) CountByStatus(ctx context.Context, since time.Time, statuses []int) ([]model.APIAuditCount, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[model.APIAuditCount]int)
+	for _, entry := range r.entries {
+		if entry.CreatedAt.Before(since) || !slices.Contains(statuses, entry.Status) {
+			continue
+		}
+		counts[model.APIAuditCount{Method: entry.Method, Route: entry.Route, Status: entry.Status}]++
+	}
+	result := make([]model.APIAuditCount, 0, len(counts))
+	for key, count := range counts {
+		key.Count = count
+		result = append(result, key)
+	}
+	return result, nil
+}
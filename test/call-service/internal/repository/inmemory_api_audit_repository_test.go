@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+)
+
+// Тест журнала изменяющих запросов: отбор по пользователю и полуинтервалу времени,
+// порядок от новых к старым и пагинация с общим количеством
+func TestInMemoryAPIAuditRepository(t *testing.T) {
+	repo := NewInMemoryAPIAuditRepository()
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	userID, otherID := uuid.New(), uuid.New()
+
+	var entries []*model.APIAuditEntry
+	for i := range 3 {
+		entries = append(entries, &model.APIAuditEntry{ID: model.NewID(), CreatedAt: start.Add(time.Duration(i) * time.Minute), Method: "POST", Route: "/calls", UserID: &userID, Status: 201})
+	}
+	entries = append(entries,
+		&model.APIAuditEntry{ID: model.NewID(), CreatedAt: start, Method: "DELETE", Route: "/calls/:id", UserID: &otherID, Status: 204},
+		&model.APIAuditEntry{ID: model.NewID(), CreatedAt: start, Method: "POST", Route: "/login", Status: 401, BodyRedacted: true})
+	require.NoError(t, repo.CreateBatch(ctx, entries))
+
+	page, total, err := repo.List(ctx, model.APIAuditFilter{UserID: &userID, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, page, 2)
+	assert.Equal(t, entries[2].ID, page[0].ID)
+	assert.Equal(t, entries[1].ID, page[1].ID)
+
+	page, _, err = repo.List(ctx, model.APIAuditFilter{UserID: &userID, Limit: 2, Offset: 2})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, entries[0].ID, page[0].ID)
+
+	from, to := start.Add(time.Minute), start.Add(2*time.Minute)
+	page, total, err = repo.List(ctx, model.APIAuditFilter{From: &from, To: &to})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, entries[1].ID, page[0].ID)
+
+	_, total, err = repo.List(ctx, model.APIAuditFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 5, total)
+
+	// Подсчет по кодам ответа учитывает только записи не раньше since
+	require.NoError(t, repo.CreateBatch(ctx, []*model.APIAuditEntry{
+		{ID: model.NewID(), CreatedAt: start.Add(-time.Minute), Method: "POST", Route: "/login", Status: 401},
+		{ID: model.NewID(), CreatedAt: start.Add(time.Minute), Method: "POST", Route: "/login", Status: 401},
+	}))
+	counts, err := repo.CountByStatus(ctx, start, []int{401, 403})
+	require.NoError(t, err)
+	assert.Equal(t, []model.APIAuditCount{{Method: "POST", Route: "/login", Status: 401, Count: 2}}, counts)
+}
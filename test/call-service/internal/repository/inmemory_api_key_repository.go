@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/model"
+)
+
+// inMemoryAPIKeyRepository хранит API-ключи в памяти процесса.
+// Повторяет поведение apiKeyRepository, включая уникальность префикса и условия Revoke.
+
+type inMemoryAPIKeyRepository struct {
+	mu       sync.RWMutex
+	keys     map[uuid.UUID]*model.APIKey
+	byPrefix map[string]uuid.UUID
+}
+
+// NewInMemoryAPIKeyRepository создает репозиторий API-ключей без базы данных.
+// Используется в тестах и в режиме DEV_INMEMORY; данные не сохраняются между запусками.
+
+func NewInMemoryAPIKeyRepository() APIKeyRepository {
+	return &inMemoryAPIKeyRepository{
+		keys:     make(map[uuid.UUID]*model.APIKey),
+		byPrefix: make(map[string]uuid.UUID),
+	}
+}
+
+// Create сохраняет копию ключа, заполняя ID и дату создания, если они не заданы.
+
+func (r *inMemoryAPIKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byPrefix[key.Prefix]; ok {
+		return ErrAlreadyExists
+	}
+	if key.ID == uuid.Nil {
+		key.ID = uuid.New()
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+
+	stored := *key
+	r.keys[key.ID] = &stored
+	r.byPrefix[key.Prefix] = key.ID
+	return nil
+}
+
+// GetByPrefix возвращает копию ключа с указанным префиксом.
+
+func (r *inMemoryAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.byPrefix[prefix]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	key := *r.keys[id]
+	return &key, nil
+}
+
+// ListByUserID возвращает копии ключей пользователя.
+
+func (r *inMemoryAPIKeyRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*model.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var keys []*model.APIKey
+	for _, stored := range r.keys {
+		if stored.UserID == userID {
+			key := *stored
+			keys = append(keys, &key)
+		}
+	}
+	slices.SortFunc(keys, func(a, b *model.APIKey) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return slices.Compare(a.ID[:], b.ID[:])
+	})
+	return keys, nil
+}
+
+// Revoke отмечает неотозванный ключ пользователя отозванным.
+
+func (r *inMemoryAPIKeyRepository) Revoke(ctx context.Context, userID, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.keys[id]
+	if !ok || stored.UserID != userID || stored.Revoked {
+		return ErrNotFound
+	}
+	stored.Revoked = true
+	return nil
+}
+
+// TouchLastUsed обновляет время последнего использования ключа.
+
+func (r *inMemoryAPIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.keys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.LastUsedAt = at
+	return nil
+}
+
+// DeleteByUserID удаляет все ключи пользователя.
+
+func (r *inMemoryAPIKeyRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for id, key := range r.keys {
+		if key.UserID == userID {
+			delete(r.keys, id)
+			delete(r.byPrefix, key.Prefix)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+)
+
+// Тест API-ключей: уникальность префикса, отзыв только своего ключа и список от новых к старым
+func TestInMemoryAPIKeyRepository(t *testing.T) {
+	repo := NewInMemoryAPIKeyRepository()
+	ctx := context.Background()
+	userID := uuid.New()
+	now := time.Now()
+
+	first := &model.APIKey{UserID: userID, Name: "ci", Prefix: "p1", KeyHash: "h1", CreatedAt: now}
+	require.NoError(t, repo.Create(ctx, first))
+	assert.NotEqual(t, uuid.Nil, first.ID)
+	second := &model.APIKey{UserID: userID, Name: "backup", Prefix: "p2", KeyHash: "h2", CreatedAt: now.Add(time.Minute)}
+	require.NoError(t, repo.Create(ctx, second))
+	assert.ErrorIs(t, repo.Create(ctx, &model.APIKey{UserID: userID, Prefix: "p1"}), ErrAlreadyExists)
+
+	stored, err := repo.GetByPrefix(ctx, "p1")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, stored.ID)
+	_, err = repo.GetByPrefix(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, repo.TouchLastUsed(ctx, first.ID, now))
+	assert.ErrorIs(t, repo.Revoke(ctx, uuid.New(), first.ID), ErrNotFound)
+	require.NoError(t, repo.Revoke(ctx, userID, first.ID))
+	assert.ErrorIs(t, repo.Revoke(ctx, userID, first.ID), ErrNotFound)
+
+	keys, err := repo.ListByUserID(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, second.ID, keys[0].ID)
+	assert.True(t, keys[1].Revoked)
+	assert.True(t, keys[1].LastUsedAt.Equal(now))
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/model"
+)
+
+// inMemoryAttachmentRepository хранит описания вложений в памяти процесса.
+// Повторяет поведение attachmentRepository, кроме проверки существования заявки.
+
+type inMemoryAttachmentRepository struct {
+	mu          sync.RWMutex
+	attachments map[uuid.UUID]*model.CallAttachment
+}
+
+// NewInMemoryAttachmentRepository создает репозиторий вложений без базы данных.
+// Используется в тестах и в режиме DEV_INMEMORY; данные не сохраняются между запусками.
+
+func NewInMemoryAttachmentRepository() AttachmentRepository {
+	return &inMemoryAttachmentRepository{attachments: make(map[uuid.UUID]*model.CallAttachment)}
+}
+
+// Create сохраняет копию описания, заполняя ID и дату создания, если они не заданы.
+
+func (r *inMemoryAttachmentRepository) Create(ctx context.Context, attachment *model.CallAttachment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attachment.ID == uuid.Nil {
+		attachment.ID = uuid.New()
+	}
+	if _, ok := r.attachments[attachment.ID]; ok {
+		return ErrAlreadyExists
+	}
+	if attachment.CreatedAt.IsZero() {
+		attachment.CreatedAt = time.Now()
+	}
+
+	stored := *attachment
+	r.attachments[attachment.ID] = &stored
+	return nil
+}
+
+// GetByID возвращает копию описания вложения.
+
+func (r *inMemoryAttachmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.CallAttachment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	attachment, ok := r.attachments[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	result := *attachment
+	return &result, nil
+}
+
+// ListByCallID возвращает копии описаний вложений заявки в порядке загрузки.
+
+func (r *inMemoryAttachmentRepository) ListByCallID(ctx context.Context, callID uuid.UUID) ([]*model.CallAttachment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*model.CallAttachment
+	for _, attachment := range r.attachments {
+		if attachment.CallID == callID {
+			copied := *attachment
+			result = append(result, &copied)
+		}
+	}
+	slices.SortFunc(result, func(a, b *model.CallAttachment) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return slices.Compare(a.ID[:], b.ID[:])
+	})
+	return result, nil
+}
+
+// Delete удаляет описание вложения.
+
+func (r *inMemoryAttachmentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.attachments[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.attachments, id)
+	return nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+)
+
+// Тест описаний вложений: список вложений одной заявки в порядке загрузки и удаление
+func TestInMemoryAttachmentRepository(t *testing.T) {
+	repo := NewInMemoryAttachmentRepository()
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	callID := uuid.New()
+
+	second := &model.CallAttachment{CallID: callID, FileName: "b.png", CreatedAt: start.Add(time.Minute)}
+	first := &model.CallAttachment{CallID: callID, FileName: "a.pdf", CreatedAt: start}
+	require.NoError(t, repo.Create(ctx, second))
+	require.NoError(t, repo.Create(ctx, first))
+	require.NoError(t, repo.Create(ctx, &model.CallAttachment{CallID: uuid.New(), FileName: "c.png"}))
+	assert.ErrorIs(t, repo.Create(ctx, &model.CallAttachment{ID: first.ID}), ErrAlreadyExists)
+
+	list, err := repo.ListByCallID(ctx, callID)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, first.ID, list[0].ID)
+	assert.Equal(t, second.ID, list[1].ID)
+
+	require.NoError(t, repo.Delete(ctx, first.ID))
+	assert.ErrorIs(t, repo.Delete(ctx, first.ID), ErrNotFound)
+	_, err = repo.GetByID(ctx, first.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	stored, err := repo.GetByID(ctx, second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "b.png", stored.FileName)
+}
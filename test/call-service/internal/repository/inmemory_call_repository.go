@@ -0,0 +1,636 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/impersonation"
+	"call-service/internal/model"
+)
+
+// inMemoryCallRepository хранит заявки в памяти процесса.
+// Повторяет поведение callRepository: ErrNotFound для отсутствующих заявок, значения
+// по умолчанию для ID, статуса и даты создания, те же условия фильтра и пагинация.
+// Порядок детерминирован: при равенстве поля сортировки заявки упорядочиваются по ID.
+
+type inMemoryCallRepository struct {
+	mu      sync.RWMutex
+	calls   map[uuid.UUID]*model.Call
+	changes []*model.CallStatusChange
+	// lastChangeID — ID последней записи истории статусов, как последовательность в SQL
+	lastChangeID int64
+	assignments  []*model.CallAssignment
+	// lastAssignmentID — ID последней записи истории передач
+	lastAssignmentID int64
+}
+
+// NewInMemoryCallRepository создает репозиторий заявок без базы данных.
+// Используется в тестах и в режиме DEV_INMEMORY; данные не сохраняются между запусками.
+
+func NewInMemoryCallRepository() CallRepository {
+	return &inMemoryCallRepository{calls: make(map[uuid.UUID]*model.Call)}
+}
+
+// Create сохраняет заявку, заполняя ID, статус и дату создания, если они не заданы
+
+func (r *inMemoryCallRepository) Create(ctx context.Context, call *model.Call) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.insert(call)
+}
+
+// CreateMany сохраняет все заявки или ни одной, если хотя бы одна не может быть сохранена
+
+func (r *inMemoryCallRepository) CreateMany(ctx context.Context, calls []*model.Call) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen := make(map[uuid.UUID]bool, len(calls))
+	seenRefs := make(map[string]bool, len(calls))
+	for _, call := range calls {
+		if call.Ref != "" {
+			if r.refTaken(call.Ref) || seenRefs[call.Ref] {
+				return ErrAlreadyExists
+			}
+			seenRefs[call.Ref] = true
+		}
+		if call.ID == uuid.Nil {
+			continue
+		}
+		if _, ok := r.calls[call.ID]; ok || seen[call.ID] {
+			return ErrAlreadyExists
+		}
+		seen[call.ID] = true
+	}
+	for _, call := range calls {
+		if err := r.insert(call); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insert сохраняет копию заявки; вызывается с захваченной блокировкой
+func (r *inMemoryCallRepository) insert(call *model.Call) error {
+	if call.ID == uuid.Nil {
+		call.ID = uuid.New()
+	}
+	if _, ok := r.calls[call.ID]; ok || call.Ref != "" && r.refTaken(call.Ref) {
+		return ErrAlreadyExists
+	}
+	if call.Status == "" {
+		call.Status = model.CallStatusOpen
+	}
+	if call.CreatedAt.IsZero() {
+		call.CreatedAt = time.Now()
+	}
+	stored := *call
+	// Признак обрезанного описания, как и в callRepository, не сохраняется
+	stored.Truncated = false
+	r.calls[call.ID] = &stored
+	return nil
+}
+
+// refTaken сообщает, есть ли заявка с кодом ref (уникальный индекс calls_ref_idx);
+// вызывается с захваченной блокировкой
+func (r *inMemoryCallRepository) refTaken(ref string) bool {
+	for _, call := range r.calls {
+		if call.Ref == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// GetByID возвращает копию заявки с указанным ID
+
+func (r *inMemoryCallRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Call, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.calls[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	call := *stored
+	return &call, nil
+}
+
+// GetByRef возвращает копию заявки с указанным кодом
+
+func (r *inMemoryCallRepository) GetByRef(ctx context.Context, ref string) (*model.Call, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, stored := range r.calls {
+		if stored.Ref == ref {
+			call := *stored
+			return &call, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// GetAllByUserID возвращает все заявки пользователя, упорядоченные по дате создания
+
+func (r *inMemoryCallRepository) GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*model.Call, error) {
+	calls, _, err := r.List(ctx, model.CallFilter{UserID: &userID})
+	return calls, err
+}
+
+// List возвращает заявки, удовлетворяющие фильтру, и общее количество таких заявок без учета пагинации
+
+func (r *inMemoryCallRepository) List(ctx context.Context, filter model.CallFilter) ([]*model.Call, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	// Фильтр проверяется так же, как при построении запроса к базе данных
+	if err := callFields.Validate(callFilterSpec(filter)); err != nil {
+		return nil, 0, fmt.Errorf("list calls: %w", err)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []*model.Call
+	for _, stored := range r.calls {
+		if matchFilter(stored, filter) {
+			call := *stored
+			matched = append(matched, &call)
+		}
+	}
+	sortCalls(matched, filter)
+
+	total := len(matched)
+	start := min(filter.Offset, total)
+	end := total
+	if filter.Limit > 0 {
+		end = min(start+filter.Limit, total)
+	}
+	return matched[start:end], total, nil
+}
+
+// ListWithSummary возвращает страницу заявок, как List, со сводкой по истории статусов каждой заявки
+
+func (r *inMemoryCallRepository) ListWithSummary(ctx context.Context, filter model.CallFilter) ([]*model.Call, int, error) {
+	calls, total, err := r.List(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	summaries := make(map[uuid.UUID]*model.CallSummary, len(calls))
+	for _, call := range calls {
+		call.Summary = &model.CallSummary{}
+		summaries[call.ID] = call.Summary
+	}
+	// Записи истории хранятся в порядке добавления, поэтому последняя подходящая — самая новая
+	for _, change := range r.changes {
+		summary, ok := summaries[change.CallID]
+		if !ok || change.DialID != nil {
+			continue
+		}
+		if summary.LastStatusChangeAt == nil || !change.ChangedAt.Before(*summary.LastStatusChangeAt) {
+			changedAt, changedBy := change.ChangedAt, change.ChangedBy
+			summary.LastStatusChangeAt, summary.LastStatusChangedBy = &changedAt, &changedBy
+		}
+	}
+	return calls, total, nil
+}
+
+// ForEachByUserID передает в fn заявки пользователя, удовлетворяющие фильтру.
+// Обход выполняется по снимку данных, поэтому fn может обращаться к репозиторию.
+
+func (r *inMemoryCallRepository) ForEachByUserID(ctx context.Context, userID uuid.UUID, filter model.CallFilter, fn func(*model.Call) error) error {
+	filter.UserID = &userID
+	calls, _, err := r.List(ctx, filter)
+	if err != nil {
+		return err
+	}
+	for _, call := range calls {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(call); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateStatus обновляет статус заявки и записывает изменение в историю статусов
+
+func (r *inMemoryCallRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status model.CallStatus, actorID uuid.UUID) error {
+	return r.update(ctx, id, func(call *model.Call) {
+		r.setStatus(call, status, actorID, impersonation.Impersonator(ctx), time.Now())
+	})
+}
+
+// Reassign передает заявку другому пользователю и записывает передачу в историю, если владелец изменился
+
+func (r *inMemoryCallRepository) Reassign(ctx context.Context, id uuid.UUID, userID uuid.UUID, actorID uuid.UUID) error {
+	return r.update(ctx, id, func(call *model.Call) {
+		if call.UserID != userID {
+			r.lastAssignmentID++
+			r.assignments = append(r.assignments, &model.CallAssignment{
+				ID:        r.lastAssignmentID,
+				CallID:    id,
+				OldUserID: call.UserID,
+				NewUserID: userID,
+				ChangedBy: actorID,
+				ChangedAt: time.Now(),
+			})
+		}
+		call.UserID = userID
+		call.UpdatedBy = &actorID
+	})
+}
+
+// Delete удаляет заявку по её ID вместе с историей ее статусов и передач
+
+func (r *inMemoryCallRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.calls[id]; !ok {
+		return ErrNotFound
+	}
+	r.deleteCall(id)
+	return nil
+}
+
+// CloseStale закрывает до limit самых старых открытых заявок, созданных раньше before
+
+func (r *inMemoryCallRepository) CloseStale(ctx context.Context, before time.Time, limit int, actorID uuid.UUID) ([]*model.Call, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var stale []*model.Call
+	for _, call := range r.calls {
+		if call.Status == model.CallStatusOpen && call.CreatedAt.Before(before) {
+			stale = append(stale, call)
+		}
+	}
+	sortCalls(stale, model.CallFilter{})
+	if len(stale) > limit {
+		stale = stale[:limit]
+	}
+	now := time.Now()
+	closed := make([]*model.Call, 0, len(stale))
+	for _, call := range stale {
+		r.setStatus(call, model.CallStatusClosed, actorID, nil, now)
+		copied := *call
+		closed = append(closed, &copied)
+	}
+	return closed, nil
+}
+
+// ListStatusChanges возвращает копии записей истории статусов заявки в порядке изменений
+
+func (r *inMemoryCallRepository) ListStatusChanges(ctx context.Context, callID uuid.UUID) ([]*model.CallStatusChange, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var changes []*model.CallStatusChange
+	for _, stored := range r.changes {
+		if stored.CallID == callID {
+			change := *stored
+			changes = append(changes, &change)
+		}
+	}
+	return changes, nil
+}
+
+// ForEachStatusChangeByUserID передает в fn копии записей истории статусов, связанных с пользователем.
+// Обход выполняется по снимку данных, поэтому fn может обращаться к репозиторию.
+
+func (r *inMemoryCallRepository) ForEachStatusChangeByUserID(ctx context.Context, userID uuid.UUID, fn func(*model.CallStatusChange) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.RLock()
+	var changes []*model.CallStatusChange
+	for _, stored := range r.changes {
+		call, ok := r.calls[stored.CallID]
+		if (ok && call.UserID == userID) || stored.ChangedBy == userID {
+			change := *stored
+			changes = append(changes, &change)
+		}
+	}
+	r.mu.RUnlock()
+
+	for _, change := range changes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListActivity объединяет историю статусов и историю передач заявки и возвращает страницу
+// записей после позиции after в том же порядке, что и запрос activityQuery
+
+func (r *inMemoryCallRepository) ListActivity(ctx context.Context, callID uuid.UUID, after *model.ActivityCursor, limit int) ([]*model.CallActivity, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var activity []*model.CallActivity
+	for _, change := range r.changes {
+		if change.CallID != callID {
+			continue
+		}
+		item := &model.CallActivity{
+			Type:           model.ActivityStatusChange,
+			At:             change.ChangedAt,
+			Key:            fmt.Sprintf("%020d", change.ID),
+			ActorID:        change.ChangedBy,
+			ImpersonatedBy: change.ImpersonatedBy,
+			OldStatus:      change.OldStatus,
+			NewStatus:      change.NewStatus,
+			DialID:         change.DialID,
+			DialReference:  change.DialReference,
+		}
+		if change.DialID != nil {
+			item.Type = model.ActivityDialAttempt
+		}
+		activity = append(activity, item)
+	}
+	for _, assignment := range r.assignments {
+		if assignment.CallID != callID {
+			continue
+		}
+		oldUserID, newUserID := assignment.OldUserID, assignment.NewUserID
+		activity = append(activity, &model.CallActivity{
+			Type:      model.ActivityAssignment,
+			At:        assignment.ChangedAt,
+			Key:       fmt.Sprintf("%020d", assignment.ID),
+			ActorID:   assignment.ChangedBy,
+			OldUserID: &oldUserID,
+			NewUserID: &newUserID,
+		})
+	}
+
+	if after != nil {
+		activity = slices.DeleteFunc(activity, func(item *model.CallActivity) bool {
+			return !after.Before(item.Cursor())
+		})
+	}
+	slices.SortFunc(activity, func(a, b *model.CallActivity) int {
+		switch {
+		case a.Cursor().Before(b.Cursor()):
+			return -1
+		case b.Cursor().Before(a.Cursor()):
+			return 1
+		}
+		return 0
+	})
+	return activity[:min(limit, len(activity))], nil
+}
+
+// AddDialAttempt сохраняет копию записи о попытке звонка, заполняя ID и время, если оно не задано
+
+func (r *inMemoryCallRepository) AddDialAttempt(ctx context.Context, attempt *model.CallStatusChange) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.calls[attempt.CallID]; !ok {
+		return ErrNotFound
+	}
+	r.lastChangeID++
+	attempt.ID = r.lastChangeID
+	if attempt.ChangedAt.IsZero() {
+		attempt.ChangedAt = time.Now()
+	}
+	stored := *attempt
+	r.changes = append(r.changes, &stored)
+	return nil
+}
+
+// SetCallback задает время повторного звонка заявки
+
+func (r *inMemoryCallRepository) SetCallback(ctx context.Context, id uuid.UUID, callbackAt *time.Time, actorID uuid.UUID) error {
+	return r.update(ctx, id, func(call *model.Call) {
+		call.CallbackAt = callbackAt
+		call.CallbackNotifiedAt = nil
+		call.UpdatedBy = &actorID
+	})
+}
+
+// ListDueCallbacks возвращает копии заявок с наступившим временем повторного звонка без отправленного уведомления
+
+func (r *inMemoryCallRepository) ListDueCallbacks(ctx context.Context, now time.Time, limit int) ([]*model.Call, error) {
+	calls, _, err := r.List(ctx, model.CallFilter{CallbackDue: &now, SortBy: model.SortByCallbackAt})
+	if err != nil {
+		return nil, err
+	}
+	due := slices.DeleteFunc(calls, func(call *model.Call) bool {
+		return call.CallbackNotifiedAt != nil
+	})
+	return due[:min(limit, len(due))], nil
+}
+
+// MarkCallbackNotified отмечает отправку уведомления, если время звонка не изменилось
+
+func (r *inMemoryCallRepository) MarkCallbackNotified(ctx context.Context, id uuid.UUID, callbackAt time.Time, notifiedAt time.Time) error {
+	err := r.update(ctx, id, func(call *model.Call) {
+		if call.CallbackAt != nil && call.CallbackAt.Equal(callbackAt) {
+			call.CallbackNotifiedAt = &notifiedAt
+		}
+	})
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// setStatus меняет статус заявки и, если он изменился, добавляет запись в историю статусов
+// с администратором impersonatedBy, работающим от имени actorID (nil — изменение сделал сам actorID).
+// При закрытии или отмене заявки снимает повторный звонок. Вызывается с захваченной блокировкой
+func (r *inMemoryCallRepository) setStatus(call *model.Call, status model.CallStatus, actorID uuid.UUID, impersonatedBy *uuid.UUID, now time.Time) {
+	if call.Status != status {
+		r.lastChangeID++
+		r.changes = append(r.changes, &model.CallStatusChange{
+			ID:             r.lastChangeID,
+			CallID:         call.ID,
+			OldStatus:      call.Status,
+			NewStatus:      status,
+			ChangedBy:      actorID,
+			ChangedAt:      now,
+			ImpersonatedBy: impersonatedBy,
+		})
+	}
+	call.Status = status
+	call.UpdatedBy = &actorID
+	if status.Final() {
+		call.CallbackAt = nil
+	}
+}
+
+// deleteCall удаляет заявку вместе с историей ее статусов и передач, как ON DELETE CASCADE.
+// Вызывается с захваченной блокировкой
+func (r *inMemoryCallRepository) deleteCall(id uuid.UUID) {
+	delete(r.calls, id)
+	r.changes = slices.DeleteFunc(r.changes, func(change *model.CallStatusChange) bool {
+		return change.CallID == id
+	})
+	r.assignments = slices.DeleteFunc(r.assignments, func(assignment *model.CallAssignment) bool {
+		return assignment.CallID == id
+	})
+}
+
+func (r *inMemoryCallRepository) update(ctx context.Context, id uuid.UUID, apply func(*model.Call)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	call, ok := r.calls[id]
+	if !ok {
+		return ErrNotFound
+	}
+	apply(call)
+	return nil
+}
+
+// matchFilter проверяет заявку на соответствие условиям фильтра так же, как applyFilter в SQL
+func matchFilter(call *model.Call, filter model.CallFilter) bool {
+	switch {
+	case !matchOwner(call, filter):
+		return false
+	case filter.Status != "" && call.Status != filter.Status:
+		return false
+	case filter.PhoneNumber != "" && call.PhoneNumber != filter.PhoneNumber:
+		return false
+	case filter.CreatedFrom != nil && call.CreatedAt.Before(*filter.CreatedFrom):
+		return false
+	case filter.CreatedTo != nil && !call.CreatedAt.Before(*filter.CreatedTo):
+		return false
+	case filter.CallbackDue != nil && (call.CallbackAt == nil || call.CallbackAt.After(*filter.CallbackDue) || call.Status.Final()):
+		return false
+	}
+	return true
+}
+
+// matchOwner проверяет условия фильтра по владельцу и организации заявки: если заданы
+// и UserID, и OrgID, подходит заявка пользователя или заявка организации
+func matchOwner(call *model.Call, filter model.CallFilter) bool {
+	inOrg := filter.OrgID != nil && call.OrgID != nil && *call.OrgID == *filter.OrgID
+	switch {
+	case filter.UserID != nil && filter.OrgID != nil:
+		return call.UserID == *filter.UserID || inOrg
+	case filter.UserID != nil:
+		return call.UserID == *filter.UserID
+	case filter.OrgID != nil:
+		return inOrg
+	}
+	return true
+}
+
+// sortCalls упорядочивает заявки по полю сортировки фильтра с дополнительной сортировкой по ID
+func sortCalls(calls []*model.Call, filter model.CallFilter) {
+	slices.SortFunc(calls, func(a, b *model.Call) int {
+		var c int
+		switch filter.SortBy {
+		case model.SortByClientName:
+			c = strings.Compare(a.ClientName, b.ClientName)
+		case model.SortByStatus:
+			c = strings.Compare(string(a.Status), string(b.Status))
+		case model.SortByCallbackAt:
+			c = compareCallbackAt(a.CallbackAt, b.CallbackAt)
+		default:
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		}
+		c = cmp.Or(c, cmp.Compare(a.ID.String(), b.ID.String()))
+		if filter.SortDesc {
+			c = -c
+		}
+		return c
+	})
+}
+
+// compareCallbackAt сравнивает время повторного звонка; заявки без звонка идут после остальных,
+// как NULL при сортировке по возрастанию в PostgreSQL
+func compareCallbackAt(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return a.Compare(*b)
+}
+
+// EraseByUserID обезличивает или удаляет заявки пользователя вместе с их историей статусов и передач
+
+func (r *inMemoryCallRepository) EraseByUserID(ctx context.Context, userID uuid.UUID, policy model.ErasurePolicy) ([]*model.Call, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var erased []*model.Call
+	for id, call := range r.calls {
+		if call.UserID != userID {
+			continue
+		}
+		if policy == model.ErasurePolicyDelete {
+			r.deleteCall(id)
+			erased = append(erased, call)
+			continue
+		}
+		if call.ClientName == model.ErasedClientName && call.PhoneNumber == "" && call.Description == "" && call.CallbackAt == nil {
+			continue
+		}
+		call.ClientName = model.ErasedClientName
+		call.PhoneNumber = ""
+		call.Description = ""
+		call.CallbackAt = nil
+		call.CallbackNotifiedAt = nil
+		copied := *call
+		erased = append(erased, &copied)
+	}
+	return erased, nil
+}
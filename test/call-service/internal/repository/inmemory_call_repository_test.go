@@ -0,0 +1,469 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/impersonation"
+	"call-service/internal/model"
+)
+
+// Тест создания и изменения заявки: значения по умолчанию, автор изменения и ErrNotFound для отсутствующих заявок
+func TestInMemoryCallRepository_CRUD(t *testing.T) {
+	repo := NewInMemoryCallRepository()
+	ctx := context.Background()
+
+	call := &model.Call{ClientName: "Иван", PhoneNumber: "+79990000001", UserID: uuid.New()}
+	require.NoError(t, repo.Create(ctx, call))
+	assert.NotEqual(t, uuid.Nil, call.ID)
+	assert.Equal(t, model.CallStatusOpen, call.Status)
+	assert.False(t, call.CreatedAt.IsZero())
+
+	assert.Nil(t, call.UpdatedBy)
+
+	actorID := uuid.New()
+	require.NoError(t, repo.UpdateStatus(ctx, call.ID, "closed", actorID))
+	stored, err := repo.GetByID(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.CallStatusClosed, stored.Status)
+	require.NotNil(t, stored.UpdatedBy)
+	assert.Equal(t, actorID, *stored.UpdatedBy)
+
+	owner := uuid.New()
+	require.NoError(t, repo.Reassign(ctx, call.ID, owner, actorID))
+	stored, err = repo.GetByID(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, owner, stored.UserID)
+
+	require.NoError(t, repo.Delete(ctx, call.ID))
+	_, err = repo.GetByID(ctx, call.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, call.ID), ErrNotFound)
+	assert.ErrorIs(t, repo.UpdateStatus(ctx, call.ID, "open", actorID), ErrNotFound)
+	assert.ErrorIs(t, repo.Reassign(ctx, call.ID, uuid.New(), actorID), ErrNotFound)
+}
+
+// Тест пакетной вставки: при конфликте ID не сохраняется ни одна заявка
+func TestInMemoryCallRepository_CreateManyAllOrNothing(t *testing.T) {
+	repo := NewInMemoryCallRepository()
+	ctx := context.Background()
+	existing := &model.Call{ClientName: "Иван", UserID: uuid.New()}
+	require.NoError(t, repo.Create(ctx, existing))
+
+	err := repo.CreateMany(ctx, []*model.Call{
+		{ClientName: "Новая", UserID: existing.UserID},
+		{ID: existing.ID, ClientName: "Дубликат", UserID: existing.UserID},
+	})
+
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+	calls, total, err := repo.List(ctx, model.CallFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Len(t, calls, 1)
+}
+
+// Тест кода заявки: поиск по коду и уникальность кода, как у индекса calls_ref_idx
+func TestInMemoryCallRepository_Ref(t *testing.T) {
+	repo := NewInMemoryCallRepository()
+	ctx := context.Background()
+	call := &model.Call{ClientName: "Иван", UserID: uuid.New(), Ref: "7KQ2M9XD"}
+	require.NoError(t, repo.Create(ctx, call))
+	require.NoError(t, repo.Create(ctx, &model.Call{ClientName: "Без кода", UserID: call.UserID}))
+	require.NoError(t, repo.Create(ctx, &model.Call{ClientName: "Без кода", UserID: call.UserID}))
+
+	found, err := repo.GetByRef(ctx, "7KQ2M9XD")
+	require.NoError(t, err)
+	assert.Equal(t, call.ID, found.ID)
+	_, err = repo.GetByRef(ctx, "00000000")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.ErrorIs(t, repo.Create(ctx, &model.Call{ClientName: "Дубликат", Ref: call.Ref}), ErrAlreadyExists)
+	assert.ErrorIs(t, repo.CreateMany(ctx, []*model.Call{
+		{ClientName: "Первая", Ref: "AAAAAAAA"},
+		{ClientName: "Вторая", Ref: "AAAAAAAA"},
+	}), ErrAlreadyExists)
+	_, err = repo.GetByRef(ctx, "AAAAAAAA")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// Тест фильтрации, сортировки и пагинации: результат совпадает с семантикой SQL-репозитория
+func TestInMemoryCallRepository_List(t *testing.T) {
+	repo := NewInMemoryCallRepository()
+	ctx := context.Background()
+	userID := uuid.New()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, name := range []string{"Вера", "Анна", "Борис", "Глеб"} {
+		status := model.CallStatusOpen
+		if i%2 == 1 {
+			status = model.CallStatusClosed
+		}
+		require.NoError(t, repo.Create(ctx, &model.Call{
+			ClientName: name, PhoneNumber: "+7999", Status: status, UserID: userID,
+			CreatedAt: base.Add(time.Duration(i) * time.Hour),
+		}))
+	}
+	require.NoError(t, repo.Create(ctx, &model.Call{ClientName: "Чужая", UserID: uuid.New(), CreatedAt: base}))
+
+	calls, total, err := repo.List(ctx, model.CallFilter{UserID: &userID, SortBy: model.SortByClientName, Limit: 2, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+	require.Len(t, calls, 2)
+	assert.Equal(t, "Борис", calls[0].ClientName)
+	assert.Equal(t, "Вера", calls[1].ClientName)
+
+	from, to := base.Add(time.Hour), base.Add(3*time.Hour)
+	calls, total, err = repo.List(ctx, model.CallFilter{UserID: &userID, Status: "closed", CreatedFrom: &from, CreatedTo: &to, SortDesc: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "Анна", calls[0].ClientName)
+
+	var names []string
+	err = repo.ForEachByUserID(ctx, userID, model.CallFilter{SortDesc: true}, func(call *model.Call) error {
+		names = append(names, call.ClientName)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Глеб", "Борис", "Анна", "Вера"}, names)
+}
+
+// Тест порядка заявок с одинаковым временем создания: повторные запросы возвращают заявки
+// в одном порядке, а страницы по смещению не пересекаются и не теряют заявок
+func TestInMemoryCallRepository_ListTieBreak(t *testing.T) {
+	repo := NewInMemoryCallRepository()
+	ctx := context.Background()
+	userID := uuid.New()
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 9; i++ {
+		require.NoError(t, repo.Create(ctx, &model.Call{ClientName: "Анна", UserID: userID, CreatedAt: createdAt}))
+	}
+
+	for _, sortDesc := range []bool{false, true} {
+		for _, sortBy := range []string{model.SortByCreatedAt, model.SortByClientName} {
+			filter := model.CallFilter{UserID: &userID, SortBy: sortBy, SortDesc: sortDesc}
+			all, _, err := repo.List(ctx, filter)
+			require.NoError(t, err)
+			require.Len(t, all, 9)
+			assert.True(t, slices.IsSortedFunc(all, func(a, b *model.Call) int {
+				c := cmp.Compare(a.ID.String(), b.ID.String())
+				if sortDesc {
+					return -c
+				}
+				return c
+			}))
+
+			again, _, err := repo.List(ctx, filter)
+			require.NoError(t, err)
+			assert.Equal(t, all, again)
+
+			var paged []*model.Call
+			for filter.Limit, filter.Offset = 4, 0; filter.Offset < len(all); filter.Offset += filter.Limit {
+				page, _, err := repo.List(ctx, filter)
+				require.NoError(t, err)
+				paged = append(paged, page...)
+			}
+			assert.Equal(t, all, paged)
+		}
+	}
+}
+
+// Тест вставки с ID, назначенным сервисным слоем: ID сохраняется без изменений,
+// повторная вставка с тем же ID отклоняется
+func TestInMemoryCallRepository_PresetID(t *testing.T) {
+	repo := NewInMemoryCallRepository()
+	ctx := context.Background()
+	id := model.NewID()
+
+	require.NoError(t, repo.Create(ctx, &model.Call{ID: id, ClientName: "Иван", UserID: uuid.New()}))
+	stored, err := repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, id, stored.ID)
+	assert.ErrorIs(t, repo.Create(ctx, &model.Call{ID: id, ClientName: "Петр", UserID: uuid.New()}), ErrAlreadyExists)
+}
+
+// Тест списка со сводкой: фильтр и пагинация как в List, в сводку попадает последнее изменение
+// статуса, попытки звонка не учитываются
+func TestInMemoryCallRepository_ListWithSummary(t *testing.T) {
+	repo := NewInMemoryCallRepository()
+	ctx := context.Background()
+	userID, firstActor, lastActor := uuid.New(), uuid.New(), uuid.New()
+
+	changed := &model.Call{ClientName: "Анна", UserID: userID}
+	untouched := &model.Call{ClientName: "Борис", UserID: userID}
+	for _, call := range []*model.Call{changed, untouched, {ClientName: "Вера", UserID: userID}, {ClientName: "Чужая", UserID: uuid.New()}} {
+		require.NoError(t, repo.Create(ctx, call))
+	}
+	require.NoError(t, repo.UpdateStatus(ctx, changed.ID, model.CallStatusInProgress, firstActor))
+	require.NoError(t, repo.UpdateStatus(ctx, changed.ID, model.CallStatusClosed, lastActor))
+	dialID := uuid.New()
+	require.NoError(t, repo.AddDialAttempt(ctx, &model.CallStatusChange{
+		CallID: changed.ID, OldStatus: model.CallStatusClosed, NewStatus: model.CallStatusClosed,
+		ChangedBy: firstActor, ChangedAt: time.Now().Add(time.Hour), DialID: &dialID,
+	}))
+	history, err := repo.ListStatusChanges(ctx, changed.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+
+	calls, total, err := repo.ListWithSummary(ctx, model.CallFilter{UserID: &userID, SortBy: model.SortByClientName, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, calls, 2)
+	assert.Equal(t, changed.ID, calls[0].ID)
+	require.NotNil(t, calls[0].Summary)
+	assert.Equal(t, &lastActor, calls[0].Summary.LastStatusChangedBy)
+	assert.Equal(t, history[1].ChangedAt, *calls[0].Summary.LastStatusChangeAt)
+	assert.Equal(t, untouched.ID, calls[1].ID)
+	assert.Equal(t, &model.CallSummary{}, calls[1].Summary)
+
+	// Список без сводки не заполняет Summary
+	calls, _, err = repo.List(ctx, model.CallFilter{UserID: &userID})
+	require.NoError(t, err)
+	for _, call := range calls {
+		assert.Nil(t, call.Summary)
+	}
+}
+
+// Тест ленты действий: изменения статуса, попытки звонка и передачи заявки объединяются
+// в порядке времени, записи с одинаковым временем упорядочиваются по виду, а постраничное
+// чтение по позиции последней записи не теряет и не повторяет записи
+func TestInMemoryCallRepository_ListActivity(t *testing.T) {
+	repo := NewInMemoryCallRepository()
+	ctx := context.Background()
+	owner, adminID := uuid.New(), uuid.New()
+	call := &model.Call{ClientName: "Анна", UserID: owner}
+	require.NoError(t, repo.Create(ctx, call))
+
+	require.NoError(t, repo.UpdateStatus(ctx, call.ID, model.CallStatusInProgress, owner))
+	newOwner := uuid.New()
+	require.NoError(t, repo.Reassign(ctx, call.ID, newOwner, adminID))
+	require.NoError(t, repo.Reassign(ctx, call.ID, newOwner, adminID))
+	history, err := repo.ListStatusChanges(ctx, call.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+
+	// Попытка звонка, записанная позже, но с более ранним временем, и попытка с тем же
+	// временем, что и изменение статуса
+	earlyID, tieID := uuid.New(), uuid.New()
+	for _, attempt := range []*model.CallStatusChange{
+		{CallID: call.ID, ChangedBy: owner, ChangedAt: history[0].ChangedAt.Add(-time.Hour), DialID: &earlyID},
+		{CallID: call.ID, ChangedBy: owner, ChangedAt: history[0].ChangedAt, DialID: &tieID},
+	} {
+		attempt.OldStatus, attempt.NewStatus = model.CallStatusInProgress, model.CallStatusInProgress
+		require.NoError(t, repo.AddDialAttempt(ctx, attempt))
+	}
+
+	all, err := repo.ListActivity(ctx, call.ID, nil, 100)
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+	assert.Equal(t, &earlyID, all[0].DialID)
+	assert.Equal(t, model.ActivityDialAttempt, all[1].Type)
+	assert.Equal(t, &tieID, all[1].DialID)
+	assert.Equal(t, model.ActivityStatusChange, all[2].Type)
+	assert.Equal(t, model.CallStatusInProgress, all[2].NewStatus)
+	assert.Equal(t, model.ActivityAssignment, all[3].Type)
+	assert.Equal(t, adminID, all[3].ActorID)
+	assert.Equal(t, &owner, all[3].OldUserID)
+	assert.Equal(t, &newOwner, all[3].NewUserID)
+
+	var paged []*model.CallActivity
+	var after *model.ActivityCursor
+	for {
+		page, err := repo.ListActivity(ctx, call.ID, after, 3)
+		require.NoError(t, err)
+		paged = append(paged, page...)
+		if len(page) < 3 {
+			break
+		}
+		cursor := page[len(page)-1].Cursor()
+		after = &cursor
+	}
+	assert.Equal(t, all, paged)
+
+	// Попытки звонка с одним временем упорядочиваются по ключу, и постраничное чтение
+	// по позиции не теряет и не повторяет их
+	for i := 0; i < 5; i++ {
+		dialID := uuid.New()
+		attempt := &model.CallStatusChange{CallID: call.ID, ChangedBy: owner, ChangedAt: history[0].ChangedAt, DialID: &dialID}
+		attempt.OldStatus, attempt.NewStatus = model.CallStatusInProgress, model.CallStatusInProgress
+		require.NoError(t, repo.AddDialAttempt(ctx, attempt))
+	}
+	all, err = repo.ListActivity(ctx, call.ID, nil, 100)
+	require.NoError(t, err)
+	require.Len(t, all, 9)
+	paged, after = nil, nil
+	for {
+		page, err := repo.ListActivity(ctx, call.ID, after, 2)
+		require.NoError(t, err)
+		paged = append(paged, page...)
+		if len(page) < 2 {
+			break
+		}
+		cursor := page[len(page)-1].Cursor()
+		after = &cursor
+	}
+	assert.Equal(t, all, paged)
+
+	// Удаление заявки удаляет и ее ленту
+	require.NoError(t, repo.Delete(ctx, call.ID))
+	all, err = repo.ListActivity(ctx, call.ID, nil, 100)
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
+
+// Тест истории статусов при работе администратора от имени пользователя: изменение записывается
+// от имени пользователя с ID администратора в ImpersonatedBy
+func TestInMemoryCallRepository_UpdateStatusImpersonated(t *testing.T) {
+	repo := NewInMemoryCallRepository()
+	ctx := context.Background()
+	userID, adminID := uuid.New(), uuid.New()
+	call := &model.Call{ClientName: "Анна", UserID: userID}
+	require.NoError(t, repo.Create(ctx, call))
+
+	require.NoError(t, repo.UpdateStatus(ctx, call.ID, model.CallStatusInProgress, userID))
+	require.NoError(t, repo.UpdateStatus(impersonation.WithImpersonator(ctx, adminID), call.ID, model.CallStatusClosed, userID))
+
+	history, err := repo.ListStatusChanges(ctx, call.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Nil(t, history[0].ImpersonatedBy)
+	assert.Equal(t, userID, history[1].ChangedBy)
+	assert.Equal(t, &adminID, history[1].ImpersonatedBy)
+}
+
+// Тест закрытия давно открытых заявок: закрываются самые старые открытые заявки в пределах limit,
+// изменения записываются в историю статусов от имени actorID
+func TestInMemoryCallRepository_CloseStale(t *testing.T) {
+	repo := NewInMemoryCallRepository()
+	ctx := context.Background()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var ids []uuid.UUID
+	for i, status := range []model.CallStatus{model.CallStatusOpen, model.CallStatusOpen, model.CallStatusInProgress, model.CallStatusOpen} {
+		call := &model.Call{ClientName: "Иван", UserID: uuid.New(), Status: status, CreatedAt: base.Add(time.Duration(i) * time.Hour)}
+		require.NoError(t, repo.Create(ctx, call))
+		ids = append(ids, call.ID)
+	}
+	actorID := uuid.New()
+
+	closed, err := repo.CloseStale(ctx, base.Add(3*time.Hour), 1, actorID)
+	require.NoError(t, err)
+	require.Len(t, closed, 1)
+	assert.Equal(t, ids[0], closed[0].ID)
+	closed, err = repo.CloseStale(ctx, base.Add(3*time.Hour), 10, actorID)
+	require.NoError(t, err)
+	require.Len(t, closed, 1)
+	assert.Equal(t, ids[1], closed[0].ID)
+
+	for i, want := range []model.CallStatus{model.CallStatusClosed, model.CallStatusClosed, model.CallStatusInProgress, model.CallStatusOpen} {
+		stored, err := repo.GetByID(ctx, ids[i])
+		require.NoError(t, err)
+		assert.Equal(t, want, stored.Status, "call %d", i)
+	}
+
+	changes, err := repo.ListStatusChanges(ctx, ids[0])
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, model.CallStatusOpen, changes[0].OldStatus)
+	assert.Equal(t, model.CallStatusClosed, changes[0].NewStatus)
+	assert.Equal(t, actorID, changes[0].ChangedBy)
+
+	// Повторная установка того же статуса не попадает в историю
+	require.NoError(t, repo.UpdateStatus(ctx, ids[0], model.CallStatusClosed, actorID))
+	require.NoError(t, repo.UpdateStatus(ctx, ids[0], model.CallStatusOpen, actorID))
+	changes, err = repo.ListStatusChanges(ctx, ids[0])
+	require.NoError(t, err)
+	assert.Len(t, changes, 2)
+}
+
+// Тест повторных звонков: отбор наступивших звонков, отметка уведомления и снятие звонка при закрытии
+func TestInMemoryCallRepository_Callbacks(t *testing.T) {
+	repo := NewInMemoryCallRepository()
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	due, later := now.Add(-time.Minute), now.Add(time.Hour)
+
+	first := &model.Call{ClientName: "Иван", UserID: uuid.New(), CallbackAt: &due}
+	second := &model.Call{ClientName: "Петр", UserID: uuid.New(), CallbackAt: &later}
+	require.NoError(t, repo.Create(ctx, first))
+	require.NoError(t, repo.Create(ctx, second))
+
+	calls, err := repo.ListDueCallbacks(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, calls, 1)
+	assert.Equal(t, first.ID, calls[0].ID)
+
+	// Отметка не ставится, если время звонка изменилось
+	require.NoError(t, repo.MarkCallbackNotified(ctx, first.ID, later, now))
+	calls, err = repo.ListDueCallbacks(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Len(t, calls, 1)
+	require.NoError(t, repo.MarkCallbackNotified(ctx, first.ID, due, now))
+	calls, err = repo.ListDueCallbacks(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, calls)
+
+	// Новое время звонка сбрасывает отметку об уведомлении
+	require.NoError(t, repo.SetCallback(ctx, first.ID, &due, first.UserID))
+	calls, err = repo.ListDueCallbacks(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Len(t, calls, 1)
+
+	require.NoError(t, repo.UpdateStatus(ctx, first.ID, model.CallStatusCancelled, first.UserID))
+	stored, err := repo.GetByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.CallbackAt)
+	assert.ErrorIs(t, repo.SetCallback(ctx, uuid.New(), nil, first.UserID), ErrNotFound)
+}
+
+// Тест удаления данных пользователя в заявках: обезличивание затрагивает только заявки пользователя
+// и не повторяется, удаление убирает заявки вместе с историей статусов
+func TestInMemoryCallRepository_EraseByUserID(t *testing.T) {
+	repo := NewInMemoryCallRepository()
+	ctx := context.Background()
+	userID := uuid.New()
+	callback := time.Now().Add(time.Hour)
+
+	call := &model.Call{ClientName: "Иван", PhoneNumber: "+79990000001", Description: "Перезвонить", UserID: userID, CallbackAt: &callback}
+	require.NoError(t, repo.Create(ctx, call))
+	require.NoError(t, repo.UpdateStatus(ctx, call.ID, model.CallStatusClosed, userID))
+	other := &model.Call{ClientName: "Петр", PhoneNumber: "+79990000002", UserID: uuid.New()}
+	require.NoError(t, repo.Create(ctx, other))
+
+	erased, err := repo.EraseByUserID(ctx, userID, model.ErasurePolicyAnonymize)
+	require.NoError(t, err)
+	require.Len(t, erased, 1)
+	assert.Equal(t, call.ID, erased[0].ID)
+	assert.Equal(t, userID, erased[0].UserID)
+	stored, err := repo.GetByID(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ErasedClientName, stored.ClientName)
+	assert.Empty(t, stored.PhoneNumber)
+	assert.Empty(t, stored.Description)
+	assert.Nil(t, stored.CallbackAt)
+	assert.Equal(t, model.CallStatusClosed, stored.Status)
+
+	erased, err = repo.EraseByUserID(ctx, userID, model.ErasurePolicyAnonymize)
+	require.NoError(t, err)
+	assert.Zero(t, erased)
+
+	erased, err = repo.EraseByUserID(ctx, userID, model.ErasurePolicyDelete)
+	require.NoError(t, err)
+	require.Len(t, erased, 1)
+	assert.Equal(t, call.ID, erased[0].ID)
+	_, err = repo.GetByID(ctx, call.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	changes, err := repo.ListStatusChanges(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	stored, err = repo.GetByID(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Петр", stored.ClientName)
+}
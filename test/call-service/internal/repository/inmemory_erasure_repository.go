@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/model"
+)
+
+// inMemoryErasureRepository хранит незавершенные удаления учетных записей в памяти процесса.
+// Повторяет поведение erasureRepository.
+
+type inMemoryErasureRepository struct {
+	mu       sync.RWMutex
+	erasures map[uuid.UUID]*model.AccountErasure
+}
+
+// NewInMemoryErasureRepository создает репозиторий удалений учетных записей без базы данных.
+// Используется в тестах и в режиме DEV_INMEMORY; данные не сохраняются между запусками.
+
+func NewInMemoryErasureRepository() ErasureRepository {
+	return &inMemoryErasureRepository{erasures: make(map[uuid.UUID]*model.AccountErasure)}
+}
+
+// Create сохраняет копию записи, заполняя время создания и изменения, если они не заданы.
+
+func (r *inMemoryErasureRepository) Create(ctx context.Context, erasure *model.AccountErasure) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.erasures[erasure.UserID]; ok {
+		return ErrAlreadyExists
+	}
+	if erasure.CreatedAt.IsZero() {
+		erasure.CreatedAt = time.Now()
+	}
+	if erasure.UpdatedAt.IsZero() {
+		erasure.UpdatedAt = erasure.CreatedAt
+	}
+	stored := *erasure
+	r.erasures[erasure.UserID] = &stored
+	return nil
+}
+
+// GetByUserID возвращает копию записи об удалении учетной записи пользователя.
+
+func (r *inMemoryErasureRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*model.AccountErasure, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.erasures[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	erasure := *stored
+	return &erasure, nil
+}
+
+// SetState сохраняет шаг удаления учетной записи.
+
+func (r *inMemoryErasureRepository) SetState(ctx context.Context, userID uuid.UUID, state model.ErasureState, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.erasures[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.State = state
+	stored.UpdatedAt = at
+	return nil
+}
+
+// RecordFailure сохраняет неудачную попытку удаления учетной записи.
+
+func (r *inMemoryErasureRepository) RecordFailure(ctx context.Context, userID uuid.UUID, message string, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.erasures[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.Attempts++
+	stored.LastError = message
+	stored.UpdatedAt = at
+	return nil
+}
+
+// ListStale возвращает копии записей, не изменявшихся с момента before, от давних к новым.
+
+func (r *inMemoryErasureRepository) ListStale(ctx context.Context, before time.Time, limit int) ([]*model.AccountErasure, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var erasures []*model.AccountErasure
+	for _, stored := range r.erasures {
+		if stored.UpdatedAt.Before(before) {
+			erasure := *stored
+			erasures = append(erasures, &erasure)
+		}
+	}
+	slices.SortFunc(erasures, func(a, b *model.AccountErasure) int {
+		return cmp.Or(a.UpdatedAt.Compare(b.UpdatedAt), cmp.Compare(a.UserID.String(), b.UserID.String()))
+	})
+	if len(erasures) > limit {
+		erasures = erasures[:limit]
+	}
+	return erasures, nil
+}
+
+// Delete удаляет запись об удалении учетной записи.
+
+func (r *inMemoryErasureRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.erasures[userID]; !ok {
+		return ErrNotFound
+	}
+	delete(r.erasures, userID)
+	return nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+)
+
+// Тест записей об удалении учетных записей: одна запись на пользователя, учет неудачных попыток
+// и отбор давно не изменявшихся записей от давних к новым
+func TestInMemoryErasureRepository(t *testing.T) {
+	repo := NewInMemoryErasureRepository()
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	first, second := uuid.New(), uuid.New()
+
+	require.NoError(t, repo.Create(ctx, &model.AccountErasure{UserID: first, Policy: model.ErasurePolicyAnonymize, State: model.ErasureStatePending, CreatedAt: start}))
+	require.NoError(t, repo.Create(ctx, &model.AccountErasure{UserID: second, Policy: model.ErasurePolicyDelete, State: model.ErasureStatePending, CreatedAt: start.Add(time.Minute)}))
+	assert.ErrorIs(t, repo.Create(ctx, &model.AccountErasure{UserID: first}), ErrAlreadyExists)
+
+	require.NoError(t, repo.RecordFailure(ctx, first, "unavailable", start.Add(2*time.Minute)))
+	require.NoError(t, repo.RecordFailure(ctx, first, "timeout", start.Add(3*time.Minute)))
+	require.NoError(t, repo.SetState(ctx, second, model.ErasureStateAuthErased, start.Add(time.Minute)))
+	stored, err := repo.GetByUserID(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.Attempts)
+	assert.Equal(t, "timeout", stored.LastError)
+
+	stale, err := repo.ListStale(ctx, start.Add(5*time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, stale, 2)
+	assert.Equal(t, second, stale[0].UserID)
+	assert.Equal(t, model.ErasureStateAuthErased, stale[0].State)
+	assert.Equal(t, first, stale[1].UserID)
+
+	stale, err = repo.ListStale(ctx, start.Add(2*time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, stale, 1)
+	assert.Equal(t, second, stale[0].UserID)
+
+	require.NoError(t, repo.Delete(ctx, first))
+	assert.ErrorIs(t, repo.Delete(ctx, first), ErrNotFound)
+	assert.ErrorIs(t, repo.SetState(ctx, first, model.ErasureStateAuthErased, start), ErrNotFound)
+	_, err = repo.GetByUserID(ctx, first)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/model"
+)
+
+// preferenceKey — ключ настройки уведомлений в памяти.
+type preferenceKey struct {
+	userID  uuid.UUID
+	event   string
+	channel string
+}
+
+// inMemoryNotificationRepository хранит настройки уведомлений и привязки чатов Telegram
+// в памяти процесса.
+
+type inMemoryNotificationRepository struct {
+	mu    sync.RWMutex
+	prefs map[preferenceKey]model.NotificationPreference
+	links map[uuid.UUID]model.TelegramLink
+	// codes — коды привязки по хешу кода.
+	codes map[string]model.TelegramLinkCode
+}
+
+// NewInMemoryNotificationRepository создает репозиторий настроек уведомлений без базы данных.
+// Используется в тестах и в режиме DEV_INMEMORY; данные не сохраняются между запусками.
+
+func NewInMemoryNotificationRepository() NotificationRepository {
+	return &inMemoryNotificationRepository{
+		prefs: make(map[preferenceKey]model.NotificationPreference),
+		links: make(map[uuid.UUID]model.TelegramLink),
+		codes: make(map[string]model.TelegramLinkCode),
+	}
+}
+
+// ListPreferences возвращает копии настроек пользователя, упорядоченные по событию и каналу.
+
+func (r *inMemoryNotificationRepository) ListPreferences(ctx context.Context, userID uuid.UUID) ([]*model.NotificationPreference, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var prefs []*model.NotificationPreference
+	for key, pref := range r.prefs {
+		if key.userID == userID {
+			prefs = append(prefs, &pref)
+		}
+	}
+	slices.SortFunc(prefs, func(a, b *model.NotificationPreference) int {
+		return cmp.Or(cmp.Compare(a.Event, b.Event), cmp.Compare(a.Channel, b.Channel))
+	})
+	return prefs, nil
+}
+
+// SavePreferences сохраняет копии настроек.
+
+func (r *inMemoryNotificationRepository) SavePreferences(ctx context.Context, prefs []*model.NotificationPreference) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, pref := range prefs {
+		r.prefs[preferenceKey{userID: pref.UserID, event: pref.Event, channel: pref.Channel}] = *pref
+	}
+	return nil
+}
+
+// CreateTelegramLinkCode сохраняет код привязки, удаляя прежний код пользователя.
+
+func (r *inMemoryNotificationRepository) CreateTelegramLinkCode(ctx context.Context, code *model.TelegramLinkCode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deleteCodes(code.UserID)
+	r.codes[code.CodeHash] = *code
+	return nil
+}
+
+// ConsumeTelegramLinkCode удаляет код и сохраняет привязку.
+
+func (r *inMemoryNotificationRepository) ConsumeTelegramLinkCode(ctx context.Context, codeHash string, chatID int64, now time.Time) (*model.TelegramLink, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	code, ok := r.codes[codeHash]
+	if !ok || !code.ExpiresAt.After(now) {
+		return nil, ErrNotFound
+	}
+	delete(r.codes, codeHash)
+	link := model.TelegramLink{UserID: code.UserID, ChatID: chatID, LinkedAt: now}
+	r.links[code.UserID] = link
+	return &link, nil
+}
+
+// GetTelegramLink возвращает копию привязки чата пользователя.
+
+func (r *inMemoryNotificationRepository) GetTelegramLink(ctx context.Context, userID uuid.UUID) (*model.TelegramLink, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	link, ok := r.links[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &link, nil
+}
+
+// DeleteTelegramLink удаляет привязку чата пользователя.
+
+func (r *inMemoryNotificationRepository) DeleteTelegramLink(ctx context.Context, userID uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.links[userID]; !ok {
+		return ErrNotFound
+	}
+	delete(r.links, userID)
+	return nil
+}
+
+// DeleteByUserID удаляет настройки, привязку и код привязки пользователя.
+
+func (r *inMemoryNotificationRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key := range r.prefs {
+		if key.userID == userID {
+			delete(r.prefs, key)
+		}
+	}
+	delete(r.links, userID)
+	r.deleteCodes(userID)
+	return nil
+}
+
+// deleteCodes удаляет коды привязки пользователя. Вызывается под блокировкой r.mu.
+func (r *inMemoryNotificationRepository) deleteCodes(userID uuid.UUID) {
+	for hash, code := range r.codes {
+		if code.UserID == userID {
+			delete(r.codes, hash)
+		}
+	}
+}
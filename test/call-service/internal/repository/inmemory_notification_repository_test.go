@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+)
+
+// Тест настроек уведомлений: повторное сохранение заменяет настройку того же события и канала,
+// настройки других пользователей не возвращаются
+func TestInMemoryNotificationRepository_Preferences(t *testing.T) {
+	repo := NewInMemoryNotificationRepository()
+	ctx := context.Background()
+	userID, otherID := uuid.New(), uuid.New()
+
+	require.NoError(t, repo.SavePreferences(ctx, []*model.NotificationPreference{
+		{UserID: userID, Event: model.NotificationCallbackDue, Channel: model.NotificationChannelTelegram, Enabled: false},
+		{UserID: userID, Event: model.NotificationCallAssigned, Channel: model.NotificationChannelEmail, Enabled: false},
+		{UserID: otherID, Event: model.NotificationCallAssigned, Channel: model.NotificationChannelEmail, Enabled: false},
+	}))
+	require.NoError(t, repo.SavePreferences(ctx, []*model.NotificationPreference{
+		{UserID: userID, Event: model.NotificationCallAssigned, Channel: model.NotificationChannelEmail, Enabled: true},
+	}))
+
+	prefs, err := repo.ListPreferences(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, prefs, 2)
+	assert.Equal(t, model.NotificationCallAssigned, prefs[0].Event)
+	assert.True(t, prefs[0].Enabled)
+	assert.Equal(t, model.NotificationCallbackDue, prefs[1].Event)
+	assert.False(t, prefs[1].Enabled)
+
+	require.NoError(t, repo.DeleteByUserID(ctx, userID))
+	prefs, err = repo.ListPreferences(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, prefs)
+	prefs, err = repo.ListPreferences(ctx, otherID)
+	require.NoError(t, err)
+	assert.Len(t, prefs, 1)
+}
+
+// Тест привязки чата Telegram: код используется один раз, новый код заменяет прежний,
+// истекший код не принимается
+func TestInMemoryNotificationRepository_TelegramLink(t *testing.T) {
+	repo := NewInMemoryNotificationRepository()
+	ctx := context.Background()
+	userID := uuid.New()
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.CreateTelegramLinkCode(ctx, &model.TelegramLinkCode{CodeHash: "old", UserID: userID, ExpiresAt: now.Add(time.Minute)}))
+	require.NoError(t, repo.CreateTelegramLinkCode(ctx, &model.TelegramLinkCode{CodeHash: "new", UserID: userID, ExpiresAt: now.Add(time.Minute)}))
+	_, err := repo.ConsumeTelegramLinkCode(ctx, "old", 42, now)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = repo.ConsumeTelegramLinkCode(ctx, "new", 42, now.Add(time.Minute))
+	assert.ErrorIs(t, err, ErrNotFound, "expired code")
+
+	link, err := repo.ConsumeTelegramLinkCode(ctx, "new", 42, now)
+	require.NoError(t, err)
+	assert.Equal(t, userID, link.UserID)
+	_, err = repo.ConsumeTelegramLinkCode(ctx, "new", 42, now)
+	assert.ErrorIs(t, err, ErrNotFound, "code is single-use")
+
+	stored, err := repo.GetTelegramLink(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), stored.ChatID)
+	require.NoError(t, repo.DeleteTelegramLink(ctx, userID))
+	assert.ErrorIs(t, repo.DeleteTelegramLink(ctx, userID), ErrNotFound)
+	_, err = repo.GetTelegramLink(ctx, userID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/model"
+)
+
+// inMemorySavedViewRepository хранит сохраненные представления в памяти процесса.
+// Повторяет поведение savedViewRepository, включая уникальность названия у пользователя.
+
+type inMemorySavedViewRepository struct {
+	mu    sync.RWMutex
+	views map[uuid.UUID]*model.SavedView
+}
+
+// NewInMemorySavedViewRepository создает репозиторий сохраненных представлений без базы данных.
+// Используется в тестах и в режиме DEV_INMEMORY; данные не сохраняются между запусками.
+
+func NewInMemorySavedViewRepository() SavedViewRepository {
+	return &inMemorySavedViewRepository{views: make(map[uuid.UUID]*model.SavedView)}
+}
+
+// Create сохраняет копию представления, заполняя ID и даты, если они не заданы.
+
+func (r *inMemorySavedViewRepository) Create(ctx context.Context, view *model.SavedView) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nameTaken(view) {
+		return ErrAlreadyExists
+	}
+	if view.ID == uuid.Nil {
+		view.ID = uuid.New()
+	}
+	if view.CreatedAt.IsZero() {
+		view.CreatedAt = time.Now()
+	}
+	if view.UpdatedAt.IsZero() {
+		view.UpdatedAt = view.CreatedAt
+	}
+	r.views[view.ID] = copySavedView(view)
+	return nil
+}
+
+// Get возвращает копию представления пользователя.
+
+func (r *inMemorySavedViewRepository) Get(ctx context.Context, userID, id uuid.UUID) (*model.SavedView, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.views[id]
+	if !ok || stored.UserID != userID {
+		return nil, ErrNotFound
+	}
+	return copySavedView(stored), nil
+}
+
+// ListByUserID возвращает копии представлений пользователя.
+
+func (r *inMemorySavedViewRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*model.SavedView, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var views []*model.SavedView
+	for _, stored := range r.views {
+		if stored.UserID == userID {
+			views = append(views, copySavedView(stored))
+		}
+	}
+	slices.SortFunc(views, func(a, b *model.SavedView) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return slices.Compare(a.ID[:], b.ID[:])
+	})
+	return views, nil
+}
+
+// CountByUserID возвращает количество представлений пользователя.
+
+func (r *inMemorySavedViewRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := 0
+	for _, stored := range r.views {
+		if stored.UserID == userID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Update обновляет представление пользователя.
+
+func (r *inMemorySavedViewRepository) Update(ctx context.Context, view *model.SavedView) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.views[view.ID]
+	if !ok || stored.UserID != view.UserID {
+		return ErrNotFound
+	}
+	if r.nameTaken(view) {
+		return ErrAlreadyExists
+	}
+	stored.Name = view.Name
+	stored.Filter = maps.Clone(view.Filter)
+	stored.SchemaVersion = view.SchemaVersion
+	stored.UpdatedAt = view.UpdatedAt
+	return nil
+}
+
+// Delete удаляет представление пользователя.
+
+func (r *inMemorySavedViewRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.views[id]
+	if !ok || stored.UserID != userID {
+		return ErrNotFound
+	}
+	delete(r.views, id)
+	return nil
+}
+
+// DeleteByUserID удаляет все представления пользователя.
+
+func (r *inMemorySavedViewRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for id, view := range r.views {
+		if view.UserID == userID {
+			delete(r.views, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// nameTaken сообщает, занято ли название view другим представлением того же пользователя.
+// Вызывается под блокировкой r.mu.
+func (r *inMemorySavedViewRepository) nameTaken(view *model.SavedView) bool {
+	for id, stored := range r.views {
+		if id != view.ID && stored.UserID == view.UserID && stored.Name == view.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// copySavedView возвращает копию представления, не разделяющую с ним фильтр.
+func copySavedView(view *model.SavedView) *model.SavedView {
+	copied := *view
+	copied.Filter = maps.Clone(view.Filter)
+	return &copied
+}
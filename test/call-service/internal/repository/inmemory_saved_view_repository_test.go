@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+)
+
+// Тест сохраненных представлений: уникальность названия у пользователя, доступ только
+// владельца, список по названию и независимость сохраненного фильтра от переданного
+func TestInMemorySavedViewRepository(t *testing.T) {
+	repo := NewInMemorySavedViewRepository()
+	ctx := context.Background()
+	userID, otherID := uuid.New(), uuid.New()
+
+	open := &model.SavedView{UserID: userID, Name: "open", Filter: map[string]string{"status": "open"}, SchemaVersion: 1}
+	require.NoError(t, repo.Create(ctx, open))
+	assert.NotEqual(t, uuid.Nil, open.ID)
+	open.Filter["status"] = "closed"
+	due := &model.SavedView{UserID: userID, Name: "due", Filter: map[string]string{"sort": "callback_at"}, SchemaVersion: 1}
+	require.NoError(t, repo.Create(ctx, due))
+	assert.ErrorIs(t, repo.Create(ctx, &model.SavedView{UserID: userID, Name: "open"}), ErrAlreadyExists)
+	require.NoError(t, repo.Create(ctx, &model.SavedView{UserID: otherID, Name: "open"}))
+
+	stored, err := repo.Get(ctx, userID, open.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"status": "open"}, stored.Filter)
+	_, err = repo.Get(ctx, otherID, open.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	views, err := repo.ListByUserID(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, views, 2)
+	assert.Equal(t, []string{"due", "open"}, []string{views[0].Name, views[1].Name})
+	n, err := repo.CountByUserID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	due.Name = "open"
+	assert.ErrorIs(t, repo.Update(ctx, due), ErrAlreadyExists)
+	due.Name = "due soon"
+	require.NoError(t, repo.Update(ctx, due))
+	assert.ErrorIs(t, repo.Update(ctx, &model.SavedView{ID: due.ID, UserID: otherID, Name: "x"}), ErrNotFound)
+
+	assert.ErrorIs(t, repo.Delete(ctx, otherID, open.ID), ErrNotFound)
+	require.NoError(t, repo.Delete(ctx, userID, open.ID))
+	deleted, err := repo.DeleteByUserID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	n, err = repo.CountByUserID(ctx, otherID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
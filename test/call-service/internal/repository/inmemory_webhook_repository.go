@@ -0,0 +1,362 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/model"
+)
+
+// inMemoryWebhookRepository хранит подписки на события заявок и очередь доставки в памяти
+// процесса. Повторяет поведение webhookRepository, включая удаление доставок вместе с подпиской.
+
+type inMemoryWebhookRepository struct {
+	mu         sync.RWMutex
+	webhooks   map[uuid.UUID]*model.Webhook
+	deliveries map[uuid.UUID]*model.WebhookDelivery
+}
+
+// NewInMemoryWebhookRepository создает репозиторий подписок без базы данных.
+// Используется в тестах и в режиме DEV_INMEMORY; данные не сохраняются между запусками.
+
+func NewInMemoryWebhookRepository() WebhookRepository {
+	return &inMemoryWebhookRepository{
+		webhooks:   make(map[uuid.UUID]*model.Webhook),
+		deliveries: make(map[uuid.UUID]*model.WebhookDelivery),
+	}
+}
+
+// Create сохраняет копию подписки, заполняя ID и дату создания, если они не заданы.
+
+func (r *inMemoryWebhookRepository) Create(ctx context.Context, webhook *model.Webhook) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if webhook.ID == uuid.Nil {
+		webhook.ID = uuid.New()
+	}
+	if webhook.CreatedAt.IsZero() {
+		webhook.CreatedAt = time.Now()
+	}
+	copied := *webhook
+	r.webhooks[webhook.ID] = &copied
+	return nil
+}
+
+// Get возвращает копию подписки пользователя.
+
+func (r *inMemoryWebhookRepository) Get(ctx context.Context, userID, id uuid.UUID) (*model.Webhook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.webhooks[id]
+	if !ok || stored.UserID != userID {
+		return nil, ErrNotFound
+	}
+	copied := *stored
+	return &copied, nil
+}
+
+// ListByUserID возвращает копии подписок пользователя.
+
+func (r *inMemoryWebhookRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*model.Webhook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var webhooks []*model.Webhook
+	for _, stored := range r.webhooks {
+		if stored.UserID == userID {
+			copied := *stored
+			webhooks = append(webhooks, &copied)
+		}
+	}
+	slices.SortFunc(webhooks, func(a, b *model.Webhook) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return slices.Compare(a.ID[:], b.ID[:])
+	})
+	return webhooks, nil
+}
+
+// CountByUserID возвращает количество подписок пользователя.
+
+func (r *inMemoryWebhookRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := 0
+	for _, stored := range r.webhooks {
+		if stored.UserID == userID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Delete удаляет подписку пользователя вместе с ее доставками.
+
+func (r *inMemoryWebhookRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.webhooks[id]
+	if !ok || stored.UserID != userID {
+		return ErrNotFound
+	}
+	r.delete(id)
+	return nil
+}
+
+// DeleteByUserID удаляет все подписки пользователя вместе с их доставками.
+
+func (r *inMemoryWebhookRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for id, stored := range r.webhooks {
+		if stored.UserID == userID {
+			r.delete(id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// CreateDeliveries сохраняет копии доставок, заполняя ID и даты, если они не заданы.
+// Доставка несуществующей подписки — ошибка, как при нарушении внешнего ключа.
+
+func (r *inMemoryWebhookRepository) CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, delivery := range deliveries {
+		if _, ok := r.webhooks[delivery.WebhookID]; !ok {
+			return fmt.Errorf("create webhook deliveries: webhook %s does not exist", delivery.WebhookID)
+		}
+	}
+	for _, delivery := range deliveries {
+		if delivery.ID == uuid.Nil {
+			delivery.ID = uuid.New()
+		}
+		if delivery.CreatedAt.IsZero() {
+			delivery.CreatedAt = time.Now()
+		}
+		if delivery.UpdatedAt.IsZero() {
+			delivery.UpdatedAt = delivery.CreatedAt
+		}
+		r.deliveries[delivery.ID] = copyWebhookDelivery(delivery)
+	}
+	return nil
+}
+
+// GetDelivery возвращает копию доставки подписки.
+
+func (r *inMemoryWebhookRepository) GetDelivery(ctx context.Context, webhookID, id uuid.UUID) (*model.WebhookDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.deliveries[id]
+	if !ok || stored.WebhookID != webhookID {
+		return nil, ErrNotFound
+	}
+	return copyWebhookDelivery(stored), nil
+}
+
+// ListDeliveries возвращает копии доставок подписки от новых к давним.
+
+func (r *inMemoryWebhookRepository) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status model.WebhookDeliveryStatus, limit int) ([]*model.WebhookDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deliveries := r.filter(func(d *model.WebhookDelivery) bool {
+		return d.WebhookID == webhookID && (status == "" || d.Status == status)
+	}, func(d *model.WebhookDelivery) time.Time { return d.CreatedAt })
+	slices.Reverse(deliveries)
+	return copyWebhookDeliveries(deliveries, limit), nil
+}
+
+// ListDue возвращает копии ожидающих доставок, время попытки которых наступило, с подписками.
+
+func (r *inMemoryWebhookRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deliveries := copyWebhookDeliveries(r.filter(func(d *model.WebhookDelivery) bool {
+		return d.Status == model.WebhookDeliveryPending && !d.NextAttemptAt.After(now)
+	}, func(d *model.WebhookDelivery) time.Time { return d.NextAttemptAt }), limit)
+	for _, delivery := range deliveries {
+		webhook := *r.webhooks[delivery.WebhookID]
+		delivery.Webhook = &webhook
+	}
+	return deliveries, nil
+}
+
+// UpdateDelivery сохраняет результат попытки доставки.
+
+func (r *inMemoryWebhookRepository) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.deliveries[delivery.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.Status = delivery.Status
+	stored.Attempts = delivery.Attempts
+	stored.NextAttemptAt = delivery.NextAttemptAt
+	stored.FailureReason = delivery.FailureReason
+	stored.LastError = delivery.LastError
+	stored.UpdatedAt = delivery.UpdatedAt
+	return nil
+}
+
+// RetryDelivery снова ставит в очередь неудавшуюся доставку.
+
+func (r *inMemoryWebhookRepository) RetryDelivery(ctx context.Context, webhookID, id uuid.UUID, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.deliveries[id]
+	if !ok || stored.WebhookID != webhookID || stored.Status != model.WebhookDeliveryFailed {
+		return ErrNotFound
+	}
+	retryWebhookDelivery(stored, at)
+	return nil
+}
+
+// RetryFailed снова ставит в очередь давние неудавшиеся доставки подписки.
+
+func (r *inMemoryWebhookRepository) RetryFailed(ctx context.Context, webhookID uuid.UUID, at time.Time, limit int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	failed := r.filter(func(d *model.WebhookDelivery) bool {
+		return d.WebhookID == webhookID && d.Status == model.WebhookDeliveryFailed
+	}, func(d *model.WebhookDelivery) time.Time { return d.CreatedAt })
+	failed = failed[:min(limit, len(failed))]
+	for _, delivery := range failed {
+		retryWebhookDelivery(delivery, at)
+	}
+	return len(failed), nil
+}
+
+// DeleteFinishedBefore удаляет завершенные доставки по сроку хранения.
+
+func (r *inMemoryWebhookRepository) DeleteFinishedBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	finished := r.filter(func(d *model.WebhookDelivery) bool {
+		return d.Status != model.WebhookDeliveryPending && d.UpdatedAt.Before(before)
+	}, func(d *model.WebhookDelivery) time.Time { return d.UpdatedAt })
+	finished = finished[:min(limit, len(finished))]
+	for _, delivery := range finished {
+		delete(r.deliveries, delivery.ID)
+	}
+	return len(finished), nil
+}
+
+// delete удаляет подписку и ее доставки. Вызывается под блокировкой r.mu.
+func (r *inMemoryWebhookRepository) delete(id uuid.UUID) {
+	delete(r.webhooks, id)
+	for deliveryID, delivery := range r.deliveries {
+		if delivery.WebhookID == id {
+			delete(r.deliveries, deliveryID)
+		}
+	}
+}
+
+// filter возвращает хранимые доставки, для которых match возвращает true, упорядоченные
+// по времени key и ID. Вызывается под блокировкой r.mu.
+func (r *inMemoryWebhookRepository) filter(match func(*model.WebhookDelivery) bool, key func(*model.WebhookDelivery) time.Time) []*model.WebhookDelivery {
+	var deliveries []*model.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if match(delivery) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	slices.SortFunc(deliveries, func(a, b *model.WebhookDelivery) int {
+		if c := key(a).Compare(key(b)); c != 0 {
+			return c
+		}
+		return slices.Compare(a.ID[:], b.ID[:])
+	})
+	return deliveries
+}
+
+// retryWebhookDelivery снова ставит доставку в очередь, как webhookRepository.retry.
+func retryWebhookDelivery(delivery *model.WebhookDelivery, at time.Time) {
+	delivery.Status = model.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = at
+	delivery.FailureReason = ""
+	delivery.LastError = ""
+	delivery.UpdatedAt = at
+}
+
+// copyWebhookDeliveries возвращает копии первых limit доставок.
+func copyWebhookDeliveries(deliveries []*model.WebhookDelivery, limit int) []*model.WebhookDelivery {
+	deliveries = deliveries[:min(limit, len(deliveries))]
+	copied := make([]*model.WebhookDelivery, len(deliveries))
+	for i, delivery := range deliveries {
+		copied[i] = copyWebhookDelivery(delivery)
+	}
+	return copied
+}
+
+// copyWebhookDelivery возвращает копию доставки, не разделяющую с ней тело события.
+func copyWebhookDelivery(delivery *model.WebhookDelivery) *model.WebhookDelivery {
+	copied := *delivery
+	copied.Payload = slices.Clone(delivery.Payload)
+	copied.Webhook = nil
+	return &copied
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+)
+
+// Тест подписок: доступ только владельца, очередь доставки по времени попытки, ручной повтор
+// только неудавшихся доставок с ограничением числа, очистка завершенных доставок по сроку
+// и удаление доставок вместе с подпиской
+func TestInMemoryWebhookRepository(t *testing.T) {
+	repo := NewInMemoryWebhookRepository()
+	ctx := context.Background()
+	userID, otherID := uuid.New(), uuid.New()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	webhook := &model.Webhook{UserID: userID, URL: "https://example.com/hooks", Secret: "secret"}
+	require.NoError(t, repo.Create(ctx, webhook))
+	require.NoError(t, repo.Create(ctx, &model.Webhook{UserID: otherID, URL: "https://example.org/hooks"}))
+	_, err := repo.Get(ctx, otherID, webhook.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	n, err := repo.CountByUserID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	deliveries := make([]*model.WebhookDelivery, 4)
+	for i := range deliveries {
+		deliveries[i] = &model.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventType:     "call.callback_due",
+			Payload:       json.RawMessage(`{"type":"call.callback_due"}`),
+			Status:        model.WebhookDeliveryPending,
+			NextAttemptAt: now.Add(time.Duration(i) * time.Minute),
+			CreatedAt:     now.Add(time.Duration(i) * time.Second),
+		}
+	}
+	require.NoError(t, repo.CreateDeliveries(ctx, deliveries))
+	assert.Error(t, repo.CreateDeliveries(ctx, []*model.WebhookDelivery{{WebhookID: uuid.New()}}))
+
+	due, err := repo.ListDue(ctx, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	assert.Equal(t, deliveries[0].ID, due[0].ID)
+	require.NotNil(t, due[0].Webhook)
+	assert.Equal(t, "secret", due[0].Webhook.Secret)
+
+	// Три доставки неудачны, одна доставлена
+	for i, delivery := range deliveries {
+		delivery.Status = model.WebhookDeliveryFailed
+		if i == 3 {
+			delivery.Status = model.WebhookDeliveryDelivered
+		}
+		delivery.Attempts, delivery.FailureReason, delivery.UpdatedAt = 5, "status", now
+		require.NoError(t, repo.UpdateDelivery(ctx, delivery))
+	}
+	failed, err := repo.ListDeliveries(ctx, webhook.ID, model.WebhookDeliveryFailed, 10)
+	require.NoError(t, err)
+	require.Len(t, failed, 3)
+	assert.Equal(t, deliveries[2].ID, failed[0].ID, "новые доставки первыми")
+	all, err := repo.ListDeliveries(ctx, webhook.ID, "", 2)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	assert.ErrorIs(t, repo.RetryDelivery(ctx, webhook.ID, deliveries[3].ID, now), ErrNotFound)
+	require.NoError(t, repo.RetryDelivery(ctx, webhook.ID, deliveries[0].ID, now.Add(time.Hour)))
+	retried, err := repo.GetDelivery(ctx, webhook.ID, deliveries[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.WebhookDeliveryPending, retried.Status)
+	assert.Zero(t, retried.Attempts)
+	assert.Empty(t, retried.FailureReason)
+
+	n, err = repo.RetryFailed(ctx, webhook.ID, now.Add(time.Hour), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	stored, err := repo.GetDelivery(ctx, webhook.ID, deliveries[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.WebhookDeliveryPending, stored.Status, "давние доставки повторяются первыми")
+
+	// Очистка не трогает ожидающие доставки и доставки, измененные после срока
+	purged, err := repo.DeleteFinishedBefore(ctx, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+	all, err = repo.ListDeliveries(ctx, webhook.ID, "", 10)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	require.NoError(t, repo.Delete(ctx, userID, webhook.ID))
+	_, err = repo.GetDelivery(ctx, webhook.ID, deliveries[0].ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	deleted, err := repo.DeleteByUserID(ctx, otherID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+}
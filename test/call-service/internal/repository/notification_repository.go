@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	"call-service/internal/model"
+)
+
+// NotificationRepository определяет интерфейс для работы с настройками уведомлений
+// пользователей и привязкой чатов Telegram.
+
+type NotificationRepository interface {
+	// ListPreferences возвращает сохраненные настройки пользователя; событий и каналов
+	// без сохраненной настройки в списке нет.
+	ListPreferences(ctx context.Context, userID uuid.UUID) ([]*model.NotificationPreference, error)
+	// SavePreferences сохраняет настройки, заменяя настройки тех же событий и каналов.
+	SavePreferences(ctx context.Context, prefs []*model.NotificationPreference) error
+	// CreateTelegramLinkCode сохраняет код привязки, заменяя прежний код пользователя.
+	CreateTelegramLinkCode(ctx context.Context, code *model.TelegramLinkCode) error
+	// ConsumeTelegramLinkCode удаляет код с хешем codeHash и привязывает чат chatID
+	// к пользователю кода, заменяя прежнюю привязку. Код, отсутствующий или истекший
+	// к моменту now, — ErrNotFound.
+	ConsumeTelegramLinkCode(ctx context.Context, codeHash string, chatID int64, now time.Time) (*model.TelegramLink, error)
+	GetTelegramLink(ctx context.Context, userID uuid.UUID) (*model.TelegramLink, error)
+	DeleteTelegramLink(ctx context.Context, userID uuid.UUID) error
+	// DeleteByUserID удаляет настройки, привязку и код привязки пользователя.
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+}
+
+// notificationRepository реализует интерфейс NotificationRepository
+
+type notificationRepository struct {
+	db *bun.DB
+	queryTimeout
+}
+
+// NewNotificationRepository создает новый экземпляр репозитория настроек уведомлений.
+// По умолчанию время выполнения каждого запроса ограничено DefaultQueryTimeout.
+
+func NewNotificationRepository(db *bun.DB, opts ...Option) NotificationRepository {
+	return &notificationRepository{db: db, queryTimeout: newQueryTimeout(opts)}
+}
+
+// ListPreferences возвращает настройки пользователя.
+
+func (r *notificationRepository) ListPreferences(ctx context.Context, userID uuid.UUID) ([]*model.NotificationPreference, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var prefs []*model.NotificationPreference
+	err := r.db.NewSelect().Model(&prefs).
+		Where("user_id = ?", userID).
+		Order("event", "channel").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list notification preferences of user %s: %w", userID, mapError(ctx, err))
+	}
+	return prefs, nil
+}
+
+// SavePreferences сохраняет настройки одним запросом.
+
+func (r *notificationRepository) SavePreferences(ctx context.Context, prefs []*model.NotificationPreference) error {
+	if len(prefs) == 0 {
+		return nil
+	}
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.NewInsert().Model(&prefs).
+		On("CONFLICT (user_id, event, channel) DO UPDATE").
+		Set("enabled = EXCLUDED.enabled").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("save notification preferences: %w", mapError(ctx, err))
+	}
+	return nil
+}
+
+// CreateTelegramLinkCode сохраняет код привязки пользователя.
+
+func (r *notificationRepository) CreateTelegramLinkCode(ctx context.Context, code *model.TelegramLinkCode) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	_, err := r.db.NewInsert().Model(code).
+		On("CONFLICT (user_id) DO UPDATE").
+		Set("code_hash = EXCLUDED.code_hash").
+		Set("expires_at = EXCLUDED.expires_at").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("create telegram link code of user %s: %w", code.UserID, mapError(ctx, err))
+	}
+	return nil
+}
+
+// ConsumeTelegramLinkCode удаляет код и сохраняет привязку в одной транзакции, поэтому
+// код нельзя использовать дважды.
+
+func (r *notificationRepository) ConsumeTelegramLinkCode(ctx context.Context, codeHash string, chatID int64, now time.Time) (*model.TelegramLink, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var link *model.TelegramLink
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		code := new(model.TelegramLinkCode)
+		if err := tx.NewDelete().Model(code).
+			Where("code_hash = ?", codeHash).
+			Where("expires_at > ?", now).
+			Returning("user_id").
+			Scan(ctx); err != nil {
+			return err
+		}
+		link = &model.TelegramLink{UserID: code.UserID, ChatID: chatID, LinkedAt: now}
+		_, err := tx.NewInsert().Model(link).
+			On("CONFLICT (user_id) DO UPDATE").
+			Set("chat_id = EXCLUDED.chat_id").
+			Set("linked_at = EXCLUDED.linked_at").
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("consume telegram link code: %w", mapError(ctx, err))
+	}
+	return link, nil
+}
+
+// GetTelegramLink возвращает привязку чата пользователя.
+
+func (r *notificationRepository) GetTelegramLink(ctx context.Context, userID uuid.UUID) (*model.TelegramLink, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	link := new(model.TelegramLink)
+	if err := r.db.NewSelect().Model(link).Where("user_id = ?", userID).Scan(ctx); err != nil {
+		return nil, fmt.Errorf("get telegram link of user %s: %w", userID, mapError(ctx, err))
+	}
+	return link, nil
+}
+
+// DeleteTelegramLink удаляет привязку чата пользователя.
+
+func (r *notificationRepository) DeleteTelegramLink(ctx context.Context, userID uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewDelete().Model((*model.TelegramLink)(nil)).Where("user_id = ?", userID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("delete telegram link of user %s: %w", userID, mapError(ctx, err))
+	}
+	if err := checkAffected(res); err != nil {
+		return fmt.Errorf("delete telegram link of user %s: %w", userID, err)
+	}
+	return nil
+}
+
+// DeleteByUserID удаляет данные уведомлений пользователя в одной транзакции.
+
+func (r *notificationRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		for _, m := range []any{(*model.NotificationPreference)(nil), (*model.TelegramLink)(nil), (*model.TelegramLinkCode)(nil)} {
+			if _, err := tx.NewDelete().Model(m).Where("user_id = ?", userID).Exec(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("delete notification settings of user %s: %w", userID, mapError(ctx, err))
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// queryMetrics — сводные показатели запросов к базе данных по таблицам и операциям,
+// публикуемые в /debug/vars под ключами вида "calls.select".
+var queryMetrics = expvar.NewMap("db_queries")
+
+// otherLabel заменяет в метриках таблицы и операции, не входящие в известные.
+const otherLabel = "other"
+
+// knownTables — таблицы, для которых ведутся отдельные метрики. Остальные запросы, включая
+// запросы без модели (NewRaw), учитываются как "other", чтобы число ключей оставалось ограниченным.
+var knownTables = map[string]bool{
+	"calls":               true,
+	"call_status_changes": true,
+	"call_attachments":    true,
+	"api_keys":            true,
+	"account_erasures":    true,
+}
+
+// knownOperations — операции, для которых ведутся отдельные метрики.
+var knownOperations = map[string]bool{
+	"select": true,
+	"insert": true,
+	"update": true,
+	"delete": true,
+}
+
+// queryDurationBuckets — верхние границы интервалов гистограммы длительности запросов.
+var queryDurationBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// QueryStats — счетчики запросов к базе данных в рамках одного HTTP-запроса.
+// Запросы могут выполняться параллельно, поэтому счетчики атомарны.
+type QueryStats struct {
+	queries atomic.Int64
+	elapsed atomic.Int64
+}
+
+type queryStatsKey struct{}
+
+// WithQueryStats возвращает контекст, запросы с которым учитываются в возвращаемых счетчиках
+// хуком QueryStatsHook.
+func WithQueryStats(ctx context.Context) (context.Context, *QueryStats) {
+	stats := new(QueryStats)
+	return context.WithValue(ctx, queryStatsKey{}, stats), stats
+}
+
+// Queries возвращает число выполненных запросов.
+func (s *QueryStats) Queries() int64 {
+	return s.queries.Load()
+}
+
+// Elapsed возвращает суммарную длительность выполненных запросов.
+func (s *QueryStats) Elapsed() time.Duration {
+	return time.Duration(s.elapsed.Load())
+}
+
+// QueryStatsHook учитывает каждый запрос в счетчиках контекста (WithQueryStats) и в сводных
+// показателях по таблице и операции: числе запросов, числе возвращенных или измененных строк
+// и гистограмме длительности.
+type QueryStatsHook struct{}
+
+var _ bun.QueryHook = (*QueryStatsHook)(nil)
+
+// BeforeQuery реализует bun.QueryHook.
+func (h *QueryStatsHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+// AfterQuery реализует bun.QueryHook.
+func (h *QueryStatsHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	elapsed := time.Since(event.StartTime)
+	if stats, ok := ctx.Value(queryStatsKey{}).(*QueryStats); ok {
+		stats.queries.Add(1)
+		stats.elapsed.Add(int64(elapsed))
+	}
+
+	var rows int64
+	if event.Result != nil {
+		rows, _ = event.Result.RowsAffected()
+	}
+	table, operation := classifyQuery(event)
+	metricsFor(table+"."+operation).observe(elapsed, rows)
+}
+
+// classifyQuery возвращает таблицу и операцию запроса, заменяя неизвестные значения на "other".
+func classifyQuery(event *bun.QueryEvent) (table, operation string) {
+	table, operation = otherLabel, otherLabel
+	if event.IQuery != nil && knownTables[event.IQuery.GetTableName()] {
+		table = event.IQuery.GetTableName()
+	}
+	if op := strings.ToLower(event.Operation()); knownOperations[op] {
+		operation = op
+	}
+	return table, operation
+}
+
+// queryMetricsMu защищает создание показателей в queryMetrics.
+var queryMetricsMu sync.Mutex
+
+// metricsFor возвращает показатели запросов с ключом key, создавая их при первом обращении.
+func metricsFor(key string) *queryHistogram {
+	if h, ok := queryMetrics.Get(key).(*queryHistogram); ok {
+		return h
+	}
+	queryMetricsMu.Lock()
+	defer queryMetricsMu.Unlock()
+	if h, ok := queryMetrics.Get(key).(*queryHistogram); ok {
+		return h
+	}
+	h := &queryHistogram{buckets: make([]int64, len(queryDurationBuckets)+1)}
+	queryMetrics.Set(key, h)
+	return h
+}
+
+// queryHistogram — показатели запросов одной таблицы и операции.
+type queryHistogram struct {
+	mu      sync.Mutex
+	count   int64
+	rows    int64
+	elapsed time.Duration
+	// buckets[i] — число запросов длительностью не больше queryDurationBuckets[i];
+	// последний элемент — число более долгих запросов.
+	buckets []int64
+}
+
+func (h *queryHistogram) observe(elapsed time.Duration, rows int64) {
+	i := 0
+	for i < len(queryDurationBuckets) && elapsed > queryDurationBuckets[i] {
+		i++
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.count++
+	h.rows += rows
+	h.elapsed += elapsed
+	h.buckets[i]++
+}
+
+// String реализует expvar.Var: показатели выводятся объектом JSON, интервалы гистограммы —
+// по их верхней границе.
+func (h *queryHistogram) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	buckets := make(map[string]int64, len(h.buckets))
+	for i, bound := range queryDurationBuckets {
+		buckets[bound.String()] = h.buckets[i]
+	}
+	buckets["+Inf"] = h.buckets[len(queryDurationBuckets)]
+	data, _ := json.Marshal(map[string]any{
+		"count":       h.count,
+		"rows":        h.rows,
+		"duration_ms": float64(h.elapsed.Microseconds()) / 1000,
+		"buckets":     buckets,
+	})
+	return string(data)
+}
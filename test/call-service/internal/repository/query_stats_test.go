@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// queryCount возвращает число запросов, учтенных в метрике key.
+func queryCount(t *testing.T, key string) int64 {
+	t.Helper()
+	h, ok := queryMetrics.Get(key).(*queryHistogram)
+	if !ok {
+		return 0
+	}
+	var data struct {
+		Count int64 `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(h.String()), &data))
+	return data.Count
+}
+
+// Тест учета запросов: запросы с контекстом WithQueryStats учитываются в его счетчиках,
+// метрики ведутся по таблице и операции, неизвестные таблицы учитываются как "other"
+func TestQueryStatsHook(t *testing.T) {
+	repo, _, db := newFakeRepository(t, 3)
+	db.AddQueryHook(&QueryStatsHook{})
+	selects, deletes := queryCount(t, "calls.select"), queryCount(t, "calls.delete")
+	others := queryCount(t, "other.select")
+
+	ctx, stats := WithQueryStats(context.Background())
+	_, err := repo.GetAllByUserID(ctx, uuid.New())
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, uuid.New()))
+	_, err = repo.GetByID(context.Background(), uuid.New())
+	require.NoError(t, err)
+	_, err = db.NewRaw("SELECT 1").Exec(ctx)
+	require.NoError(t, err)
+
+	// GetByID выполнен без счетчиков запроса, но учтен в метриках
+	assert.Equal(t, int64(3), stats.Queries())
+	assert.Positive(t, stats.Elapsed())
+	assert.Equal(t, selects+2, queryCount(t, "calls.select"))
+	assert.Equal(t, deletes+1, queryCount(t, "calls.delete"))
+	assert.Equal(t, others+1, queryCount(t, "other.select"))
+}
+
+// Тест гистограммы: запрос попадает в первый интервал, верхняя граница которого не меньше
+// его длительности, число строк суммируется
+func TestQueryHistogram(t *testing.T) {
+	h := &queryHistogram{buckets: make([]int64, len(queryDurationBuckets)+1)}
+	h.observe(time.Millisecond, 2)
+	h.observe(7*time.Millisecond, 3)
+	h.observe(time.Minute, 0)
+
+	var data struct {
+		Count      int64            `json:"count"`
+		Rows       int64            `json:"rows"`
+		DurationMS float64          `json:"duration_ms"`
+		Buckets    map[string]int64 `json:"buckets"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(h.String()), &data))
+	assert.Equal(t, int64(3), data.Count)
+	assert.Equal(t, int64(5), data.Rows)
+	assert.Equal(t, 60008.0, data.DurationMS)
+	assert.Equal(t, int64(1), data.Buckets["1ms"])
+	assert.Equal(t, int64(0), data.Buckets["5ms"])
+	assert.Equal(t, int64(1), data.Buckets["10ms"])
+	assert.Equal(t, int64(1), data.Buckets["+Inf"])
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// PostgresRateLimiter ограничивает число запросов с одним ключом за фиксированное окно времени,
+// храня счетчики в таблице rate_limit_counters. Экземпляры сервиса с общей базой данных
+// и одинаковой областью scope делят один лимит, поэтому ограничение не зависит от числа реплик.
+//
+// По сравнению с ограничителем в памяти процесса каждая проверка стоит одного запроса к базе
+// данных (несколько миллисекунд), а фиксированные окна дают погрешность на их границе: клиент,
+// потративший лимит в конце окна, может сразу потратить его снова в начале следующего, то есть
+// за время, равное окну, пройдет до удвоенного лимита. Границы окон считаются по часам базы
+// данных, поэтому расхождение часов экземпляров на точность не влияет.
+
+type PostgresRateLimiter struct {
+	db    *bun.DB
+	scope string
+	limit int
+	// window — длительность окна в наносекундах; заменяется SetInterval.
+	window atomic.Int64
+	queryTimeout
+}
+
+// NewPostgresRateLimiter создает ограничитель, разрешающий не более limit запросов с одним ключом
+// за окно window. Ограничители с разными scope ведут независимые счетчики в одной таблице.
+
+func NewPostgresRateLimiter(db *bun.DB, scope string, limit int, window time.Duration, opts ...Option) *PostgresRateLimiter {
+	l := &PostgresRateLimiter{db: db, scope: scope, limit: limit, queryTimeout: newQueryTimeout(opts)}
+	l.window.Store(int64(window))
+	return l
+}
+
+// SetInterval заменяет длительность окна. Счетчики окон прежней длительности перестают
+// учитываться, поэтому после замены лимит начинается заново.
+
+func (l *PostgresRateLimiter) SetInterval(window time.Duration) {
+	l.window.Store(int64(window))
+}
+
+// reserveQuery увеличивает счетчик текущего окна, только если лимит не исчерпан, и возвращает
+// новое значение счетчика (NULL, если запрос отклонен), начало окна и текущее время базы данных.
+// Одновременные запросы разных экземпляров упорядочиваются блокировкой строки счетчика.
+const reserveQuery = `
+WITH win AS (
+	SELECT to_timestamp(floor(extract(epoch FROM now) / ?) * ?) AS start, now
+	FROM (SELECT clock_timestamp() AS now) t
+), upsert AS (
+	INSERT INTO rate_limit_counters (scope, key, window_start, count)
+	SELECT ?, ?, start, 1 FROM win
+	ON CONFLICT (scope, key, window_start) DO UPDATE SET count = rate_limit_counters.count + 1
+	WHERE rate_limit_counters.count < ?
+	RETURNING count
+)
+SELECT (SELECT count FROM upsert), start, now FROM win`
+
+// Reserve учитывает запрос с ключом key. Если лимит текущего окна исчерпан, возвращает время
+// до начала следующего окна и false. При открытии нового окна удаляются счетчики истекших окон.
+
+func (l *PostgresRateLimiter) Reserve(ctx context.Context, key string) (time.Duration, bool, error) {
+	ctx, cancel := l.withTimeout(ctx)
+	defer cancel()
+
+	var count sql.NullInt64
+	var start, now time.Time
+	window := time.Duration(l.window.Load())
+	seconds := window.Seconds()
+	err := l.db.NewRaw(reserveQuery, seconds, seconds, l.scope, key, l.limit).Scan(ctx, &count, &start, &now)
+	if err != nil {
+		return 0, false, fmt.Errorf("reserve rate limit %s: %w", l.scope, mapError(ctx, err))
+	}
+	if !count.Valid {
+		return start.Add(window).Sub(now), false, nil
+	}
+
+	// Запрос уже учтен, поэтому ошибка очистки только записывается в журнал:
+	// истекшие счетчики будут удалены при открытии следующего окна
+	if count.Int64 == 1 {
+		_, err := l.db.NewDelete().TableExpr("rate_limit_counters").
+			Where("scope = ?", l.scope).
+			Where("window_start < ?", start).
+			Exec(ctx)
+		if err != nil {
+			log.Printf("delete expired rate limit counters %s: %v", l.scope, mapError(ctx, err))
+		}
+	}
+	return 0, true, nil
+}
+
+// Release уменьшает счетчик текущего окна для ключа key. Если окно сменилось после Reserve,
+// уменьшается счетчик нового окна; эта погрешность не превышает одного запроса.
+
+func (l *PostgresRateLimiter) Release(ctx context.Context, key string) error {
+	ctx, cancel := l.withTimeout(ctx)
+	defer cancel()
+
+	seconds := time.Duration(l.window.Load()).Seconds()
+	_, err := l.db.NewUpdate().TableExpr("rate_limit_counters").
+		Set("count = count - 1").
+		Where("scope = ?", l.scope).
+		Where("key = ?", key).
+		Where("window_start = to_timestamp(floor(extract(epoch FROM clock_timestamp()) / ?) * ?)", seconds, seconds).
+		Where("count > 0").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("release rate limit %s: %w", l.scope, mapError(ctx, err))
+	}
+	return nil
+}
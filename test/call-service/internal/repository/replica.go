@@ -0,0 +1,20 @@
+package repository
+
+import "context"
+
+// primaryKey — ключ контекста, требующего чтения из основной базы данных.
+type primaryKey struct{}
+
+// WithPrimary возвращает контекст, запросы чтения с которым выполняются в основной базе
+// данных, даже если репозиторию задана реплика. Используется для чтения, за которым следует
+// запись (например, проверки владельца перед изменением заявки): реплика может отставать
+// от основной базы данных.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// usePrimary сообщает, требует ли контекст чтения из основной базы данных.
+func usePrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
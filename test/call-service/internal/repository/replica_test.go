@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+
+	"call-service/internal/model"
+)
+
+// newFakeReplicaRepository создает репозиторий с основной базой данных и репликой на
+// отдельных фиктивных драйверах, чтобы по счетчикам запросов видеть, куда направлен запрос.
+func newFakeReplicaRepository(t *testing.T) (CallRepository, *fakeConnector, *fakeConnector) {
+	t.Helper()
+	primary, replica := &fakeConnector{rows: 1}, &fakeConnector{rows: 1}
+	primaryDB := bun.NewDB(sql.OpenDB(primary), pgdialect.New())
+	replicaDB := bun.NewDB(sql.OpenDB(replica), pgdialect.New())
+	t.Cleanup(func() {
+		_ = primaryDB.Close()
+		_ = replicaDB.Close()
+	})
+	return NewCallRepositoryWithReplica(primaryDB, replicaDB), primary, replica
+}
+
+// Тест разделения запросов: чтение выполняется в реплике, запись и чтение с WithPrimary —
+// в основной базе данных
+func TestCallRepository_ReplicaRouting(t *testing.T) {
+	ctx := context.Background()
+	id, userID := uuid.New(), uuid.New()
+
+	reads := map[string]func(repo CallRepository, ctx context.Context) error{
+		"GetByID": func(repo CallRepository, ctx context.Context) error {
+			_, err := repo.GetByID(ctx, id)
+			return err
+		},
+		"GetAllByUserID": func(repo CallRepository, ctx context.Context) error {
+			_, err := repo.GetAllByUserID(ctx, userID)
+			return err
+		},
+		"List": func(repo CallRepository, ctx context.Context) error {
+			_, _, err := repo.List(ctx, model.CallFilter{UserID: &userID, PhoneNumber: "+79990000000"})
+			return err
+		},
+		"ForEachByUserID": func(repo CallRepository, ctx context.Context) error {
+			return repo.ForEachByUserID(ctx, userID, model.CallFilter{}, func(*model.Call) error { return nil })
+		},
+	}
+	for name, read := range reads {
+		t.Run(name, func(t *testing.T) {
+			repo, primary, replica := newFakeReplicaRepository(t)
+
+			require.NoError(t, read(repo, ctx))
+			assert.Positive(t, replica.queries.Load())
+			assert.Zero(t, primary.queries.Load())
+
+			before := replica.queries.Load()
+			require.NoError(t, read(repo, WithPrimary(ctx)))
+			assert.Positive(t, primary.queries.Load())
+			assert.Equal(t, before, replica.queries.Load())
+		})
+	}
+
+	t.Run("writes", func(t *testing.T) {
+		repo, primary, replica := newFakeReplicaRepository(t)
+
+		require.NoError(t, repo.Reassign(ctx, id, userID, userID))
+		require.NoError(t, repo.SetCallback(ctx, id, nil, userID))
+		require.NoError(t, repo.Delete(ctx, id))
+		_, err := repo.ListDueCallbacks(ctx, time.Now(), 10)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(4), primary.queries.Load())
+		assert.Zero(t, replica.queries.Load())
+	})
+}
+
+// Тест репозитория без реплики: все запросы выполняются в основной базе данных
+func TestCallRepository_WithoutReplica(t *testing.T) {
+	repo, connector, _ := newFakeRepository(t, 1)
+
+	_, err := repo.GetByID(context.Background(), uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), connector.queries.Load())
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	"call-service/internal/model"
+)
+
+// SavedViewRepository определяет интерфейс для работы с сохраненными фильтрами списка заявок.
+// Представление доступно только своему владельцу: методы, работающие с одним представлением,
+// возвращают ErrNotFound, если оно отсутствует или принадлежит другому пользователю.
+
+type SavedViewRepository interface {
+	// Create сохраняет представление; представление пользователя с тем же названием — ErrAlreadyExists.
+	Create(ctx context.Context, view *model.SavedView) error
+	Get(ctx context.Context, userID, id uuid.UUID) (*model.SavedView, error)
+	// ListByUserID возвращает представления пользователя, упорядоченные по названию.
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*model.SavedView, error)
+	CountByUserID(ctx context.Context, userID uuid.UUID) (int, error)
+	// Update сохраняет название, фильтр, версию схемы и время изменения представления;
+	// занятое другим представлением пользователя название — ErrAlreadyExists.
+	Update(ctx context.Context, view *model.SavedView) error
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+	// DeleteByUserID удаляет все представления пользователя и возвращает их количество.
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+// savedViewRepository реализует интерфейс SavedViewRepository
+
+type savedViewRepository struct {
+	db *bun.DB
+	queryTimeout
+}
+
+// NewSavedViewRepository создает новый экземпляр репозитория сохраненных представлений.
+// По умолчанию время выполнения каждого запроса ограничено DefaultQueryTimeout.
+
+func NewSavedViewRepository(db *bun.DB, opts ...Option) SavedViewRepository {
+	return &savedViewRepository{db: db, queryTimeout: newQueryTimeout(opts)}
+}
+
+// Create сохраняет новое представление в базу данных.
+
+func (r *savedViewRepository) Create(ctx context.Context, view *model.SavedView) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.NewInsert().Model(view).Returning("id, created_at, updated_at").Exec(ctx); err != nil {
+		return fmt.Errorf("create saved view: %w", mapError(ctx, err))
+	}
+	return nil
+}
+
+// Get извлекает представление пользователя по ID.
+
+func (r *savedViewRepository) Get(ctx context.Context, userID, id uuid.UUID) (*model.SavedView, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	view := new(model.SavedView)
+	err := r.db.NewSelect().Model(view).
+		Where("id = ?", id).
+		Where("user_id = ?", userID).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get saved view %s: %w", id, mapError(ctx, err))
+	}
+	return view, nil
+}
+
+// ListByUserID возвращает представления пользователя.
+
+func (r *savedViewRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*model.SavedView, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var views []*model.SavedView
+	err := r.db.NewSelect().Model(&views).
+		Where("user_id = ?", userID).
+		Order("name", "id").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list saved views of user %s: %w", userID, mapError(ctx, err))
+	}
+	return views, nil
+}
+
+// CountByUserID возвращает количество представлений пользователя.
+
+func (r *savedViewRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	n, err := r.db.NewSelect().Model((*model.SavedView)(nil)).
+		Where("user_id = ?", userID).
+		Count(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("count saved views of user %s: %w", userID, mapError(ctx, err))
+	}
+	return n, nil
+}
+
+// Update обновляет представление пользователя.
+
+func (r *savedViewRepository) Update(ctx context.Context, view *model.SavedView) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewUpdate().Model(view).
+		Column("name", "filter", "schema_version", "updated_at").
+		Where("id = ?", view.ID).
+		Where("user_id = ?", view.UserID).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("update saved view %s: %w", view.ID, mapError(ctx, err))
+	}
+	if err := checkAffected(res); err != nil {
+		return fmt.Errorf("update saved view %s: %w", view.ID, err)
+	}
+	return nil
+}
+
+// Delete удаляет представление пользователя.
+
+func (r *savedViewRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewDelete().Model((*model.SavedView)(nil)).
+		Where("id = ?", id).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("delete saved view %s: %w", id, mapError(ctx, err))
+	}
+	if err := checkAffected(res); err != nil {
+		return fmt.Errorf("delete saved view %s: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteByUserID удаляет все представления пользователя.
+
+func (r *savedViewRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewDelete().Model((*model.SavedView)(nil)).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("delete saved views of user %s: %w", userID, mapError(ctx, err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("delete saved views of user %s: %w", userID, err)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// DefaultQueryTimeout — ограничение времени выполнения одного запроса по умолчанию.
+const DefaultQueryTimeout = 5 * time.Second
+
+// Option задает необязательный параметр репозитория.
+type Option func(*queryTimeout)
+
+// WithQueryTimeout ограничивает время выполнения каждого запроса репозитория.
+// Значение 0 отключает ограничение, и запрос ограничен только контекстом вызывающего.
+func WithQueryTimeout(timeout time.Duration) Option {
+	return func(t *queryTimeout) {
+		t.timeout = timeout
+	}
+}
+
+// queryTimeout применяет ограничение времени к запросам и приводит ошибки истечения времени к ErrTimeout.
+type queryTimeout struct {
+	timeout time.Duration
+}
+
+func newQueryTimeout(opts []Option) queryTimeout {
+	t := queryTimeout{timeout: DefaultQueryTimeout}
+	for _, opt := range opts {
+		opt(&t)
+	}
+	return t
+}
+
+// withTimeout возвращает контекст запроса с ограничением времени.
+// Отмена родительского контекста по-прежнему прерывает запрос.
+func (t queryTimeout) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, t.timeout)
+}
+
+// SlowQueryHook логирует запросы, выполнявшиеся дольше Threshold, а также запросы,
+// прерванные по истечении времени. В запись включается ограничение времени запроса,
+// чтобы было видно, насколько запрос был близок к нему.
+type SlowQueryHook struct {
+	Threshold time.Duration
+}
+
+var _ bun.QueryHook = (*SlowQueryHook)(nil)
+
+// BeforeQuery реализует bun.QueryHook.
+func (h *SlowQueryHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+// AfterQuery реализует bun.QueryHook.
+func (h *SlowQueryHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	elapsed := time.Since(event.StartTime)
+	timedOut := errors.Is(mapError(ctx, event.Err), ErrTimeout)
+	if !timedOut && (h.Threshold <= 0 || elapsed < h.Threshold) {
+		return
+	}
+
+	budget := "none"
+	if deadline, ok := ctx.Deadline(); ok {
+		budget = deadline.Sub(event.StartTime).Round(time.Millisecond).String()
+	}
+	if timedOut {
+		log.Printf("query timed out: duration=%s timeout=%s query=%s", elapsed.Round(time.Millisecond), budget, event.Query)
+		return
+	}
+	log.Printf("slow query: duration=%s timeout=%s query=%s", elapsed.Round(time.Millisecond), budget, event.Query)
+}
@@ -0,0 +1,336 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	"call-service/internal/model"
+)
+
+// WebhookRepository определяет интерфейс для работы с подписками на события заявок
+// и очередью доставки событий. Подписка доступна только своему владельцу: методы, работающие
+// с одной подпиской, возвращают ErrNotFound, если она отсутствует или принадлежит другому
+// пользователю. Доставки удаляются вместе с подпиской.
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *model.Webhook) error
+	Get(ctx context.Context, userID, id uuid.UUID) (*model.Webhook, error)
+	// ListByUserID возвращает подписки пользователя, упорядоченные по времени создания.
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*model.Webhook, error)
+	CountByUserID(ctx context.Context, userID uuid.UUID) (int, error)
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+	// DeleteByUserID удаляет все подписки пользователя и возвращает их количество.
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) (int, error)
+
+	// CreateDeliveries ставит доставки в очередь.
+	CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error
+	// GetDelivery возвращает доставку подписки webhookID.
+	GetDelivery(ctx context.Context, webhookID, id uuid.UUID) (*model.WebhookDelivery, error)
+	// ListDeliveries возвращает до limit доставок подписки от новых к давним; пустой status —
+	// доставки в любом состоянии.
+	ListDeliveries(ctx context.Context, webhookID uuid.UUID, status model.WebhookDeliveryStatus, limit int) ([]*model.WebhookDelivery, error)
+	// ListDue возвращает до limit ожидающих доставок, время попытки которых наступило к now,
+	// от давних к новым, вместе с подписками (WebhookDelivery.Webhook).
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error)
+	// UpdateDelivery сохраняет состояние, число попыток, время следующей попытки, причину
+	// и описание ошибки и время изменения доставки.
+	UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	// RetryDelivery снова ставит в очередь доставку в состоянии failed: попытки считаются
+	// заново с момента at. Доставка в другом состоянии — ErrNotFound.
+	RetryDelivery(ctx context.Context, webhookID, id uuid.UUID, at time.Time) error
+	// RetryFailed снова ставит в очередь до limit давних доставок подписки в состоянии failed
+	// и возвращает их количество.
+	RetryFailed(ctx context.Context, webhookID uuid.UUID, at time.Time, limit int) (int, error)
+	// DeleteFinishedBefore удаляет до limit доставленных и неудавшихся доставок, не изменявшихся
+	// с момента before, и возвращает их количество.
+	DeleteFinishedBefore(ctx context.Context, before time.Time, limit int) (int, error)
+}
+
+// webhookRepository реализует интерфейс WebhookRepository
+
+type webhookRepository struct {
+	db *bun.DB
+	queryTimeout
+}
+
+// NewWebhookRepository создает новый экземпляр репозитория подписок на события заявок.
+// По умолчанию время выполнения каждого запроса ограничено DefaultQueryTimeout.
+
+func NewWebhookRepository(db *bun.DB, opts ...Option) WebhookRepository {
+	return &webhookRepository{db: db, queryTimeout: newQueryTimeout(opts)}
+}
+
+// Create сохраняет новую подписку в базу данных.
+
+func (r *webhookRepository) Create(ctx context.Context, webhook *model.Webhook) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.NewInsert().Model(webhook).Returning("id, created_at").Exec(ctx); err != nil {
+		return fmt.Errorf("create webhook: %w", mapError(ctx, err))
+	}
+	return nil
+}
+
+// Get извлекает подписку пользователя по ID.
+
+func (r *webhookRepository) Get(ctx context.Context, userID, id uuid.UUID) (*model.Webhook, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	webhook := new(model.Webhook)
+	err := r.db.NewSelect().Model(webhook).
+		Where("id = ?", id).
+		Where("user_id = ?", userID).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get webhook %s: %w", id, mapError(ctx, err))
+	}
+	return webhook, nil
+}
+
+// ListByUserID возвращает подписки пользователя.
+
+func (r *webhookRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*model.Webhook, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var webhooks []*model.Webhook
+	err := r.db.NewSelect().Model(&webhooks).
+		Where("user_id = ?", userID).
+		Order("created_at", "id").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks of user %s: %w", userID, mapError(ctx, err))
+	}
+	return webhooks, nil
+}
+
+// CountByUserID возвращает количество подписок пользователя.
+
+func (r *webhookRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	n, err := r.db.NewSelect().Model((*model.Webhook)(nil)).
+		Where("user_id = ?", userID).
+		Count(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("count webhooks of user %s: %w", userID, mapError(ctx, err))
+	}
+	return n, nil
+}
+
+// Delete удаляет подписку пользователя; доставки удаляются каскадно.
+
+func (r *webhookRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewDelete().Model((*model.Webhook)(nil)).
+		Where("id = ?", id).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("delete webhook %s: %w", id, mapError(ctx, err))
+	}
+	if err := checkAffected(res); err != nil {
+		return fmt.Errorf("delete webhook %s: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteByUserID удаляет все подписки пользователя.
+
+func (r *webhookRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewDelete().Model((*model.Webhook)(nil)).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("delete webhooks of user %s: %w", userID, mapError(ctx, err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("delete webhooks of user %s: %w", userID, err)
+	}
+	return int(n), nil
+}
+
+// CreateDeliveries сохраняет доставки одним запросом.
+
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.NewInsert().Model(&deliveries).Returning("id, created_at, updated_at").Exec(ctx); err != nil {
+		return fmt.Errorf("create webhook deliveries: %w", mapError(ctx, err))
+	}
+	return nil
+}
+
+// GetDelivery извлекает доставку подписки по ID.
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, webhookID, id uuid.UUID) (*model.WebhookDelivery, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	delivery := new(model.WebhookDelivery)
+	err := r.db.NewSelect().Model(delivery).
+		Where("id = ?", id).
+		Where("webhook_id = ?", webhookID).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get webhook delivery %s: %w", id, mapError(ctx, err))
+	}
+	return delivery, nil
+}
+
+// ListDeliveries возвращает доставки подписки.
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status model.WebhookDeliveryStatus, limit int) ([]*model.WebhookDelivery, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var deliveries []*model.WebhookDelivery
+	q := r.db.NewSelect().Model(&deliveries).Where("webhook_id = ?", webhookID)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	err := q.OrderExpr("created_at DESC, id DESC").Limit(limit).Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list deliveries of webhook %s: %w", webhookID, mapError(ctx, err))
+	}
+	return deliveries, nil
+}
+
+// ListDue получает доставки, время попытки которых наступило.
+
+func (r *webhookRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var deliveries []*model.WebhookDelivery
+	err := r.db.NewSelect().Model(&deliveries).
+		Relation("Webhook").
+		Where("webhook_delivery.status = ?", model.WebhookDeliveryPending).
+		Where("webhook_delivery.next_attempt_at <= ?", now).
+		Order("webhook_delivery.next_attempt_at", "webhook_delivery.id").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list due webhook deliveries: %w", mapError(ctx, err))
+	}
+	return deliveries, nil
+}
+
+// UpdateDelivery сохраняет результат попытки доставки.
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.db.NewUpdate().Model(delivery).
+		Column("status", "attempts", "next_attempt_at", "failure_reason", "last_error", "updated_at").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("update webhook delivery %s: %w", delivery.ID, mapError(ctx, err))
+	}
+	if err := checkAffected(res); err != nil {
+		return fmt.Errorf("update webhook delivery %s: %w", delivery.ID, err)
+	}
+	return nil
+}
+
+// RetryDelivery снова ставит в очередь неудавшуюся доставку.
+
+func (r *webhookRepository) RetryDelivery(ctx context.Context, webhookID, id uuid.UUID, at time.Time) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.retry(at).
+		Where("id = ?", id).
+		Where("webhook_id = ?", webhookID).
+		Where("status = ?", model.WebhookDeliveryFailed).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("retry webhook delivery %s: %w", id, mapError(ctx, err))
+	}
+	if err := checkAffected(res); err != nil {
+		return fmt.Errorf("retry webhook delivery %s: %w", id, err)
+	}
+	return nil
+}
+
+// RetryFailed снова ставит в очередь давние неудавшиеся доставки подписки.
+
+func (r *webhookRepository) RetryFailed(ctx context.Context, webhookID uuid.UUID, at time.Time, limit int) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	failed := r.db.NewSelect().Model((*model.WebhookDelivery)(nil)).
+		Column("id").
+		Where("webhook_id = ?", webhookID).
+		Where("status = ?", model.WebhookDeliveryFailed).
+		Order("created_at", "id").
+		Limit(limit)
+	res, err := r.retry(at).
+		Where("id IN (?)", failed).
+		Where("status = ?", model.WebhookDeliveryFailed).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("retry failed deliveries of webhook %s: %w", webhookID, mapError(ctx, err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("retry failed deliveries of webhook %s: %w", webhookID, err)
+	}
+	return int(n), nil
+}
+
+// DeleteFinishedBefore удаляет завершенные доставки по сроку хранения.
+
+func (r *webhookRepository) DeleteFinishedBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	finished := r.db.NewSelect().Model((*model.WebhookDelivery)(nil)).
+		Column("id").
+		Where("status <> ?", model.WebhookDeliveryPending).
+		Where("updated_at < ?", before).
+		Order("updated_at", "id").
+		Limit(limit)
+	res, err := r.db.NewDelete().Model((*model.WebhookDelivery)(nil)).
+		Where("id IN (?)", finished).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("delete finished webhook deliveries: %w", mapError(ctx, err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("delete finished webhook deliveries: %w", err)
+	}
+	return int(n), nil
+}
+
+// retry возвращает запрос, который снова ставит доставки в очередь: попытки считаются
+// заново, ошибка прежних попыток сбрасывается.
+func (r *webhookRepository) retry(at time.Time) *bun.UpdateQuery {
+	return r.db.NewUpdate().Model((*model.WebhookDelivery)(nil)).
+		Set("status = ?", model.WebhookDeliveryPending).
+		Set("attempts = 0").
+		Set("next_attempt_at = ?", at).
+		Set("failure_reason = ''").
+		Set("last_error = ''").
+		Set("updated_at = ?", at)
+}
@@ -0,0 +1,327 @@
+// Package safehttp отправляет HTTP-запросы на адреса, которые задает не сервис, а его
+// пользователи или операторы (например, webhook), так, чтобы через них нельзя было обратиться
+// во внутреннюю сеть (SSRF).
+//
+// Policy проверяет адрес при его регистрации (ValidateURL), а клиент Policy.Client повторяет
+// проверку при каждом соединении: имя разрешается заново, и соединение устанавливается только
+// с проверенным IP-адресом, поэтому смена DNS-записи после регистрации (DNS rebinding)
+// не открывает доступ к внутренним адресам. Клиент ограничивает число перенаправлений,
+// размер ответа и общее время запроса. Нарушения возвращаются как *Error с кодом причины.
+package safehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Коды причин отказа (Error.Reason)
+const (
+	// ReasonInvalidURL — адрес не разбирается или не содержит имени хоста.
+	ReasonInvalidURL = "invalid_url"
+	// ReasonInsecureScheme — схема адреса не https (или не http, если он разрешен).
+	ReasonInsecureScheme = "insecure_scheme"
+	// ReasonBlockedAddress — хост разрешается во внутренний, локальный или служебный адрес.
+	ReasonBlockedAddress = "blocked_address"
+	// ReasonTooManyRedirects — превышено число перенаправлений.
+	ReasonTooManyRedirects = "too_many_redirects"
+	// ReasonResponseTooLarge — ответ больше Policy.MaxResponseSize.
+	ReasonResponseTooLarge = "response_too_large"
+	// ReasonTimeout — запрос не завершился за Policy.Timeout. Возвращается только ReasonOf.
+	ReasonTimeout = "timeout"
+)
+
+// Значения Policy по умолчанию
+const (
+	DefaultMaxRedirects    = 3
+	DefaultMaxResponseSize = 1 << 20
+	DefaultTimeout         = 10 * time.Second
+)
+
+// blockedPrefixes — диапазоны, недоступные клиенту: частные сети, loopback, link-local
+// (включая адрес метаданных облака 169.254.169.254), CGNAT, служебные и multicast-адреса.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// Error — нарушение Policy.
+type Error struct {
+	Reason string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return e.Reason + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ReasonOf возвращает код причины неудачного запроса: Error.Reason для нарушения Policy,
+// ReasonTimeout для истечения времени и пустую строку для остальных ошибок.
+func ReasonOf(err error) string {
+	var policyErr *Error
+	if errors.As(err, &policyErr) {
+		return policyErr.Reason
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ReasonTimeout
+	}
+	return ""
+}
+
+// Blocked сообщает, что адрес входит в диапазон, недоступный клиенту.
+// IPv4-адреса в форме IPv6 (::ffff:a.b.c.d) проверяются как IPv4.
+func Blocked(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolver разрешает имя хоста в IP-адреса; *net.Resolver реализует этот интерфейс.
+type Resolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// Policy задает ограничения исходящих запросов.
+type Policy struct {
+	// AllowHTTP разрешает адреса со схемой http; иначе допускается только https.
+	AllowHTTP bool
+	// AllowedNetworks — сети, доступные несмотря на Blocked, например адрес внутреннего
+	// получателя webhook, явно разрешенный оператором.
+	AllowedNetworks []netip.Prefix
+	// MaxRedirects — наибольшее число перенаправлений; 0 — DefaultMaxRedirects,
+	// отрицательное значение запрещает перенаправления.
+	MaxRedirects int
+	// MaxResponseSize ограничивает тело ответа в байтах; 0 — DefaultMaxResponseSize.
+	MaxResponseSize int64
+	// Timeout ограничивает запрос целиком, включая перенаправления и чтение ответа;
+	// 0 — DefaultTimeout.
+	Timeout time.Duration
+	// Resolver разрешает имена хостов; nil — net.DefaultResolver.
+	Resolver Resolver
+}
+
+// ValidateURL проверяет адрес при регистрации: схему, наличие хоста и IP-адреса, в которые
+// он разрешается сейчас. Нарушение Policy возвращается как *Error; ошибка разрешения имени
+// нарушением не считается, так как адрес все равно проверяется при каждом соединении.
+func (p Policy) ValidateURL(ctx context.Context, rawURL string) error {
+	u, err := p.checkURL(rawURL)
+	if err != nil {
+		return err
+	}
+	addrs, err := p.lookup(ctx, u.Hostname())
+	if err != nil {
+		return err
+	}
+	return p.checkAddrs(u.Hostname(), addrs)
+}
+
+// Client возвращает клиент, выполняющий запросы с ограничениями Policy. Прокси из окружения
+// не используется: запрос через прокси обошел бы проверку адресов.
+func (p Policy) Client() *http.Client {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	maxSize := p.MaxResponseSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxResponseSize
+	}
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           p.dialContext(dialer),
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   timeout,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &http.Client{
+		Transport:     &limitTransport{next: transport, maxSize: maxSize},
+		CheckRedirect: p.checkRedirect,
+		Timeout:       timeout,
+	}
+}
+
+// checkURL проверяет схему адреса и наличие хоста.
+func (p Policy) checkURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, &Error{Reason: ReasonInvalidURL, Err: err}
+	}
+	if u.Hostname() == "" {
+		return nil, &Error{Reason: ReasonInvalidURL, Err: fmt.Errorf("url %q has no host", rawURL)}
+	}
+	switch {
+	case u.Scheme == "https":
+	case u.Scheme == "http" && p.AllowHTTP:
+	default:
+		return nil, &Error{Reason: ReasonInsecureScheme, Err: fmt.Errorf("scheme %q is not allowed", u.Scheme)}
+	}
+	return u, nil
+}
+
+// checkRedirect ограничивает число перенаправлений и проверяет схему нового адреса,
+// чтобы перенаправление не перевело запрос с https на http.
+func (p Policy) checkRedirect(req *http.Request, via []*http.Request) error {
+	limit := p.MaxRedirects
+	if limit == 0 {
+		limit = DefaultMaxRedirects
+	}
+	if len(via) > limit {
+		return &Error{Reason: ReasonTooManyRedirects, Err: fmt.Errorf("stopped after %d redirects", max(limit, 0))}
+	}
+	_, err := p.checkURL(req.URL.String())
+	return err
+}
+
+// dialContext разрешает имя хоста при каждом соединении и соединяется только с адресами,
+// прошедшими проверку, не разрешая имя повторно.
+func (p Policy) dialContext(dialer *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, portText, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		port, err := strconv.ParseUint(portText, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q", portText)
+		}
+		addrs, err := p.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.checkAddrs(host, addrs); err != nil {
+			return nil, err
+		}
+		var dialErr error
+		for _, addr := range addrs {
+			conn, err := dialer.DialContext(ctx, network, netip.AddrPortFrom(addr, uint16(port)).String())
+			if err == nil {
+				return conn, nil
+			}
+			dialErr = err
+		}
+		return nil, dialErr
+	}
+}
+
+// lookup возвращает IP-адреса хоста; IP-адрес в адресе запроса возвращается как есть.
+func (p Policy) lookup(ctx context.Context, host string) ([]netip.Addr, error) {
+	if addr, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		return []netip.Addr{addr}, nil
+	}
+	resolver := p.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	addrs, err := resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+	return addrs, nil
+}
+
+// checkAddrs отклоняет хост, если хотя бы один его адрес недоступен: иначе злоумышленник
+// мог бы вернуть внешний и внутренний адреса вместе.
+func (p Policy) checkAddrs(host string, addrs []netip.Addr) error {
+	for _, addr := range addrs {
+		if Blocked(addr) && !p.allowed(addr) {
+			return &Error{Reason: ReasonBlockedAddress, Err: fmt.Errorf("%s resolves to blocked address %s", host, addr)}
+		}
+	}
+	return nil
+}
+
+func (p Policy) allowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range p.AllowedNetworks {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// limitTransport ограничивает размер тела ответа.
+type limitTransport struct {
+	next    http.RoundTripper
+	maxSize int64
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ContentLength > t.maxSize {
+		resp.Body.Close()
+		return nil, tooLarge(t.maxSize)
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.maxSize, maxSize: t.maxSize}
+	return resp, nil
+}
+
+// limitedBody возвращает ошибку ReasonResponseTooLarge при чтении сверх maxSize байт.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	maxSize   int64
+	exceeded  bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, tooLarge(b.maxSize)
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = 0
+		b.exceeded = true
+		return n, tooLarge(b.maxSize)
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func tooLarge(maxSize int64) error {
+	return &Error{Reason: ReasonResponseTooLarge, Err: fmt.Errorf("response exceeds %d bytes", maxSize)}
+}
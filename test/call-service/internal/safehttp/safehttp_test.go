@@ -0,0 +1,218 @@
+package safehttp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResolver возвращает адреса хоста по очереди: каждый вызов — следующий набор адресов,
+// последний набор повторяется. Так моделируется смена DNS-записи между регистрацией адреса
+// и соединением.
+type fakeResolver struct {
+	mu      sync.Mutex
+	answers map[string][][]netip.Addr
+	calls   map[string]int
+}
+
+func newFakeResolver() *fakeResolver {
+	return &fakeResolver{answers: make(map[string][][]netip.Addr), calls: make(map[string]int)}
+}
+
+func (r *fakeResolver) add(host string, addrs ...string) {
+	var answer []netip.Addr
+	for _, addr := range addrs {
+		answer = append(answer, netip.MustParseAddr(addr))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.answers[host] = append(r.answers[host], answer)
+}
+
+func (r *fakeResolver) LookupNetIP(_ context.Context, _, host string) ([]netip.Addr, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	answers := r.answers[host]
+	if len(answers) == 0 {
+		return nil, fmt.Errorf("lookup %s: no such host", host)
+	}
+	i := min(r.calls[host], len(answers)-1)
+	r.calls[host]++
+	return answers[i], nil
+}
+
+// hostURL заменяет в адресе тестового сервера IP-адрес на имя host.
+func hostURL(t *testing.T, srv *httptest.Server, host string) string {
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	u.Host = host + ":" + u.Port()
+	return u.String()
+}
+
+// Тест проверки адресов: внутренние, локальные и служебные диапазоны отклоняются,
+// в том числе в форме IPv4 внутри IPv6
+func TestBlocked(t *testing.T) {
+	for _, addr := range []string{
+		"127.0.0.1", "10.1.2.3", "172.20.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1",
+		"0.0.0.0", "224.0.0.1", "::1", "fd00::1", "fe80::1", "::ffff:127.0.0.1", "::ffff:10.0.0.1",
+	} {
+		assert.True(t, Blocked(netip.MustParseAddr(addr)), addr)
+	}
+	for _, addr := range []string{"8.8.8.8", "203.0.113.10", "2001:4860:4860::8888", "::ffff:8.8.8.8"} {
+		assert.False(t, Blocked(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+// Тест проверки адреса при регистрации: схема, хост, внутренние адреса по IP и по имени,
+// смешанный ответ DNS и разрешенные оператором сети
+func TestPolicy_ValidateURL(t *testing.T) {
+	resolver := newFakeResolver()
+	resolver.add("public.test", "203.0.113.10")
+	resolver.add("internal.test", "10.0.0.5")
+	resolver.add("mixed.test", "203.0.113.10", "127.0.0.1")
+	policy := Policy{Resolver: resolver}
+	ctx := context.Background()
+
+	assert.NoError(t, policy.ValidateURL(ctx, "https://public.test/hook"))
+	for rawURL, reason := range map[string]string{
+		"http://public.test/hook":             ReasonInsecureScheme,
+		"ftp://public.test/hook":              ReasonInsecureScheme,
+		"https:///hook":                       ReasonInvalidURL,
+		"https://internal.test/hook":          ReasonBlockedAddress,
+		"https://mixed.test/hook":             ReasonBlockedAddress,
+		"https://127.0.0.1/hook":              ReasonBlockedAddress,
+		"https://[::1]/hook":                  ReasonBlockedAddress,
+		"https://169.254.169.254/latest/meta": ReasonBlockedAddress,
+	} {
+		assert.Equal(t, reason, ReasonOf(policy.ValidateURL(ctx, rawURL)), rawURL)
+	}
+
+	// Ошибка DNS — не нарушение: адрес проверяется при соединении
+	err := policy.ValidateURL(ctx, "https://unknown.test/hook")
+	assert.Error(t, err)
+	assert.Empty(t, ReasonOf(err))
+
+	policy.AllowHTTP = true
+	policy.AllowedNetworks = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/24")}
+	assert.NoError(t, policy.ValidateURL(ctx, "http://internal.test/hook"))
+}
+
+// Тест DNS rebinding: имя, разрешавшееся при регистрации во внешний адрес, при соединении
+// разрешается в loopback, и запрос отклоняется, не дойдя до сервера
+func TestClient_DNSRebinding(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer srv.Close()
+	resolver := newFakeResolver()
+	resolver.add("rebind.test", "203.0.113.10")
+	resolver.add("rebind.test", "127.0.0.1")
+	policy := Policy{AllowHTTP: true, Resolver: resolver}
+	target := hostURL(t, srv, "rebind.test")
+
+	require.NoError(t, policy.ValidateURL(context.Background(), target))
+	_, err := policy.Client().Post(target, "application/json", strings.NewReader("{}"))
+	assert.Equal(t, ReasonBlockedAddress, ReasonOf(err))
+	assert.Zero(t, hits)
+}
+
+// Тест клиента: запрос к разрешенному оператором адресу выполняется, перенаправление
+// на внутренний адрес или с https на http и слишком длинная цепочка перенаправлений отклоняются
+func TestClient_Redirects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusNoContent)
+		case "/metadata":
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data", http.StatusFound)
+		case "/insecure":
+			http.Redirect(w, r, "ftp://hook.test/", http.StatusFound)
+		default:
+			http.Redirect(w, r, r.URL.Path+"x", http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+	resolver := newFakeResolver()
+	resolver.add("hook.test", "127.0.0.1")
+	client := Policy{
+		AllowHTTP:       true,
+		AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")},
+		Resolver:        resolver,
+	}.Client()
+	base := hostURL(t, srv, "hook.test")
+
+	resp, err := client.Get(base + "/ok")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	_, err = client.Get(base + "/metadata")
+	assert.Equal(t, ReasonBlockedAddress, ReasonOf(err))
+	_, err = client.Get(base + "/insecure")
+	assert.Equal(t, ReasonInsecureScheme, ReasonOf(err))
+	_, err = client.Get(base + "/loop")
+	assert.Equal(t, ReasonTooManyRedirects, ReasonOf(err))
+}
+
+// Тест ограничений ответа: тело больше MaxResponseSize с известной и неизвестной длиной
+// и запрос дольше Timeout
+func TestClient_Limits(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sized":
+			w.Header().Set("Content-Length", "100")
+			_, _ = w.Write(make([]byte, 100))
+		case "/chunked":
+			for range 10 {
+				_, _ = w.Write(make([]byte, 10))
+				w.(http.Flusher).Flush()
+			}
+		case "/small":
+			_, _ = w.Write(make([]byte, 64))
+		case "/slow":
+			time.Sleep(300 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+	resolver := newFakeResolver()
+	resolver.add("hook.test", "127.0.0.1")
+	client := Policy{
+		AllowHTTP:       true,
+		AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+		MaxResponseSize: 64,
+		Timeout:         100 * time.Millisecond,
+		Resolver:        resolver,
+	}.Client()
+	base := hostURL(t, srv, "hook.test")
+
+	_, err := client.Get(base + "/sized")
+	assert.Equal(t, ReasonResponseTooLarge, ReasonOf(err))
+
+	resp, err := client.Get(base + "/chunked")
+	require.NoError(t, err)
+	_, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, ReasonResponseTooLarge, ReasonOf(err))
+
+	resp, err = client.Get(base + "/small")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Len(t, body, 64)
+
+	_, err = client.Get(base + "/slow")
+	assert.Equal(t, ReasonTimeout, ReasonOf(err))
+}
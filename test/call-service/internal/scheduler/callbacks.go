@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"time"
+
+	"call-service/internal/notify"
+	"call-service/internal/service"
+)
+
+// Параметры задачи уведомлений о повторных звонках
+const (
+	// DefaultCallbacksInterval — период проверки по умолчанию.
+	DefaultCallbacksInterval = time.Minute
+	// callbacksLockKey — ключ блокировки задачи.
+	callbacksLockKey int64 = 2
+)
+
+// callbacksStats — показатели задачи в /debug/vars: число отправленных уведомлений
+// и запусков, завершившихся ошибкой.
+var callbacksStats = expvar.NewMap("callbacks")
+
+// CallbacksJob создает задачу, которая каждые interval (0 — DefaultCallbacksInterval) передает
+// notifier заявки с наступившим временем повторного звонка через CallService.NotifyDueCallbacks.
+func CallbacksJob(calls service.CallService, notifier notify.CallbackNotifier, interval time.Duration) Job {
+	if interval <= 0 {
+		interval = DefaultCallbacksInterval
+	}
+	return Job{
+		Name:     "notify_due_callbacks",
+		Interval: interval,
+		LockKey:  callbacksLockKey,
+		Run: func(ctx context.Context) error {
+			notified, err := calls.NotifyDueCallbacks(ctx, notifier.CallbackDue)
+			callbacksStats.Add("notified_total", int64(notified))
+			if notified > 0 {
+				log.Printf("scheduler: sent %d callback notifications", notified)
+			}
+			if err != nil {
+				callbacksStats.Add("failed_runs", 1)
+			}
+			return err
+		},
+	}
+}
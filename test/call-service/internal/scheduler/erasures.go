@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"time"
+
+	"call-service/internal/service"
+)
+
+// Параметры задачи завершения прерванных удалений учетных записей
+const (
+	// DefaultErasuresInterval — период проверки по умолчанию.
+	DefaultErasuresInterval = time.Minute
+	// erasuresLockKey — ключ блокировки задачи.
+	erasuresLockKey int64 = 3
+)
+
+// erasuresStats — показатели задачи в /debug/vars: число завершенных удалений
+// и запусков, завершившихся ошибкой.
+var erasuresStats = expvar.NewMap("account_erasures")
+
+// ErasuresJob создает задачу, которая каждые interval (0 — DefaultErasuresInterval) завершает
+// прерванные удаления учетных записей через ErasureService.Reconcile.
+func ErasuresJob(erasures service.ErasureService, interval time.Duration) Job {
+	if interval <= 0 {
+		interval = DefaultErasuresInterval
+	}
+	return Job{
+		Name:     "reconcile_account_erasures",
+		Interval: interval,
+		LockKey:  erasuresLockKey,
+		Run: func(ctx context.Context) error {
+			completed, err := erasures.Reconcile(ctx)
+			erasuresStats.Add("completed_total", int64(completed))
+			if completed > 0 {
+				log.Printf("scheduler: completed %d interrupted account erasures", completed)
+			}
+			if err != nil {
+				erasuresStats.Add("failed_runs", 1)
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log"
+	"sync"
+)
+
+// Locker захватывает блокировки задач.
+type Locker interface {
+	// TryLock захватывает блокировку key, не дожидаясь ее освобождения другим владельцем.
+	// Если блокировка захвачена, ok равен true, а unlock освобождает ее.
+	TryLock(ctx context.Context, key int64) (unlock func(), ok bool, err error)
+}
+
+// postgresLocker захватывает рекомендательные блокировки PostgreSQL уровня сеанса.
+type postgresLocker struct {
+	db *sql.DB
+}
+
+// NewPostgresLocker создает Locker на рекомендательных блокировках PostgreSQL
+// (pg_try_advisory_lock): блокировку с одним ключом удерживает только один экземпляр сервиса.
+// Блокировка держит соединение пула до освобождения и снимается базой данных при обрыве
+// соединения, поэтому аварийно завершившийся экземпляр не оставляет ее захваченной.
+func NewPostgresLocker(db *sql.DB) Locker {
+	return &postgresLocker{db: db}
+}
+
+func (l *postgresLocker) TryLock(ctx context.Context, key int64) (func(), bool, error) {
+	// Блокировка уровня сеанса привязана к соединению, поэтому захват и освобождение
+	// выполняются на одном соединении, а не на произвольном соединении пула
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok); err != nil || !ok {
+		conn.Close()
+		return nil, false, err
+	}
+	return func() {
+		// Освобождение не зависит от контекста задачи: он уже может быть отменен
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			log.Printf("scheduler: release lock %d: %v", key, err)
+			// Соединение с неосвобожденной блокировкой не возвращается в пул, а закрывается
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, true, nil
+}
+
+// localLocker захватывает блокировки в памяти процесса.
+type localLocker struct {
+	mu   sync.Mutex
+	held map[int64]bool
+}
+
+// NewLocalLocker создает Locker для единственного экземпляра сервиса, например в режиме
+// DEV_INMEMORY: блокировки не видны другим процессам.
+func NewLocalLocker() Locker {
+	return &localLocker{held: make(map[int64]bool)}
+}
+
+func (l *localLocker) TryLock(_ context.Context, key int64) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[key] {
+		return nil, false, nil
+	}
+	l.held[key] = true
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, key)
+	}, true, nil
+}
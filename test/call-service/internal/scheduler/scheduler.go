@@ -0,0 +1,90 @@
+// Package scheduler периодически запускает фоновые задачи сервиса заявок.
+//
+// Сервис может работать в нескольких экземплярах, поэтому каждый запуск задачи выполняется
+// под блокировкой Locker: с PostgreSQL это рекомендательная блокировка, которую одновременно
+// удерживает только один экземпляр. Экземпляр, не получивший блокировку, пропускает запуск,
+// и задача не выполняется дважды.
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job — периодическая фоновая задача.
+type Job struct {
+	// Name — имя задачи в журнале.
+	Name string
+	// Interval — период запуска задачи; первый запуск выполняется через Interval после старта.
+	Interval time.Duration
+	// LockKey — ключ блокировки задачи; у разных задач ключи должны различаться.
+	LockKey int64
+	// Run выполняет задачу. ctx отменяется при остановке планировщика.
+	Run func(ctx context.Context) error
+}
+
+// Scheduler запускает задачи по их периодам под блокировкой Locker.
+type Scheduler struct {
+	locker Locker
+	jobs   []Job
+}
+
+// New создает планировщик задач jobs.
+func New(locker Locker, jobs ...Job) *Scheduler {
+	return &Scheduler{locker: locker, jobs: jobs}
+}
+
+// Run запускает задачи и блокируется до отмены ctx. Отмена ctx передается в выполняющиеся
+// задачи; Run возвращается только после их завершения, поэтому при остановке сервиса
+// задача не прерывается на полпути закрытием соединения с базой данных.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, job)
+		}()
+	}
+	wg.Wait()
+}
+
+// loop запускает задачу каждые job.Interval до отмены ctx.
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// При одновременной готовности обоих каналов select выбирает случайный
+			if ctx.Err() != nil {
+				return
+			}
+			s.runOnce(ctx, job)
+		}
+	}
+}
+
+// runOnce выполняет задачу, если удалось захватить ее блокировку.
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	unlock, ok, err := s.locker.TryLock(ctx, job.LockKey)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("scheduler: job %s: acquire lock: %v", job.Name, err)
+		}
+		return
+	}
+	if !ok {
+		// Задачу выполняет другой экземпляр сервиса
+		return
+	}
+	defer unlock()
+
+	if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("scheduler: job %s failed: %v", job.Name, err)
+	}
+}
@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест запуска задачи по периоду: задача выполняется повторно, пока не отменен контекст
+func TestScheduler_RunsJobPeriodically(t *testing.T) {
+	var runs atomic.Int32
+	s := New(NewLocalLocker(), Job{Name: "test", Interval: 5 * time.Millisecond, Run: func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return runs.Load() >= 2 }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+}
+
+// Тест блокировки: задача не запускается, пока ее блокировку удерживает другой экземпляр
+func TestScheduler_SkipsLockedJob(t *testing.T) {
+	locker := NewLocalLocker()
+	unlock, ok, err := locker.TryLock(context.Background(), 7)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	var runs atomic.Int32
+	job := Job{Name: "test", Interval: time.Millisecond, LockKey: 7, Run: func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}}
+	s := New(locker, job)
+
+	s.runOnce(context.Background(), job)
+	assert.Zero(t, runs.Load())
+
+	unlock()
+	s.runOnce(context.Background(), job)
+	assert.Equal(t, int32(1), runs.Load())
+}
+
+// Тест остановки: Run дожидается завершения выполняющейся задачи, которая получает отмену контекста
+func TestScheduler_WaitsForRunningJob(t *testing.T) {
+	started := make(chan struct{})
+	var once sync.Once
+	var finished atomic.Bool
+	s := New(NewLocalLocker(), Job{Name: "test", Interval: time.Millisecond, Run: func(ctx context.Context) error {
+		once.Do(func() { close(started) })
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		finished.Store(true)
+		return ctx.Err()
+	}})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	<-started
+	cancel()
+	<-done
+	assert.True(t, finished.Load())
+}
@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"time"
+
+	"call-service/internal/model"
+	"call-service/internal/service"
+	"common/clock"
+)
+
+// Параметры задачи закрытия давно открытых заявок
+const (
+	// DefaultStaleCallsInterval — период проверки по умолчанию.
+	DefaultStaleCallsInterval = time.Hour
+	// staleCallsLockKey — ключ блокировки задачи.
+	staleCallsLockKey int64 = 1
+	// staleCallsDryRunLogLimit — максимальное число заявок, перечисляемых в журнале в режиме DryRun.
+	staleCallsDryRunLogLimit = 100
+)
+
+// Показатели задачи в /debug/vars: число запусков, закрытых заявок за последний запуск и всего,
+// а в режиме DryRun — число заявок, которые были бы закрыты последним запуском.
+var (
+	staleCallsStats          = expvar.NewMap("stale_calls")
+	staleCallsClosedLastRun  = new(expvar.Int)
+	staleCallsWouldCloseLast = new(expvar.Int)
+)
+
+func init() {
+	staleCallsStats.Set("closed_last_run", staleCallsClosedLastRun)
+	staleCallsStats.Set("would_close_last_run", staleCallsWouldCloseLast)
+}
+
+// StaleCallsConfig задает закрытие давно открытых заявок.
+type StaleCallsConfig struct {
+	// OlderThan — возраст открытой заявки, после которого она закрывается.
+	OlderThan time.Duration
+	// Interval — период проверки; 0 означает DefaultStaleCallsInterval.
+	Interval time.Duration
+	// DryRun включает пробный режим: заявки не закрываются, а записываются в журнал.
+	DryRun bool
+	// Clock — источник времени для отбора заявок в режиме DryRun; nil — системное время.
+	Clock clock.Clock
+}
+
+// StaleCallsJob создает задачу, закрывающую заявки, открытые дольше cfg.OlderThan, через
+// CallService.CloseStaleCalls. Закрытие записывается в историю статусов от имени
+// model.SystemActorID.
+func StaleCallsJob(calls service.CallService, cfg StaleCallsConfig) Job {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultStaleCallsInterval
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clock.Real
+	}
+	return Job{
+		Name:     "close_stale_calls",
+		Interval: cfg.Interval,
+		LockKey:  staleCallsLockKey,
+		Run: func(ctx context.Context) error {
+			staleCallsStats.Add("runs", 1)
+			if cfg.DryRun {
+				return logStaleCalls(ctx, calls, cfg)
+			}
+			closed, err := calls.CloseStaleCalls(ctx, cfg.OlderThan)
+			staleCallsClosedLastRun.Set(int64(closed))
+			staleCallsStats.Add("closed_total", int64(closed))
+			if closed > 0 {
+				log.Printf("scheduler: closed %d calls open for more than %s", closed, cfg.OlderThan)
+			}
+			return err
+		},
+	}
+}
+
+// logStaleCalls записывает в журнал заявки, которые задача закрыла бы, не изменяя их.
+func logStaleCalls(ctx context.Context, calls service.CallService, cfg StaleCallsConfig) error {
+	before := cfg.Clock.Now().Add(-cfg.OlderThan)
+	stale, total, err := calls.ListCallsAdmin(ctx, model.CallFilter{
+		Status:    model.CallStatusOpen,
+		CreatedTo: &before,
+		Limit:     staleCallsDryRunLogLimit,
+	})
+	if err != nil {
+		return err
+	}
+	staleCallsWouldCloseLast.Set(int64(total))
+	for _, call := range stale {
+		log.Printf("scheduler: dry run: would close call %s created at %s", call.ID, call.CreatedAt.Format(time.RFC3339))
+	}
+	if total > 0 {
+		log.Printf("scheduler: dry run: %d calls open for more than %s would be closed", total, cfg.OlderThan)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package scheduler
+
+import (
+	"context"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/mocks"
+	"call-service/internal/model"
+	"common/clock"
+)
+
+// Тест задачи закрытия: число закрытых заявок попадает в показатели последнего запуска и общий счетчик
+func TestStaleCallsJob_Close(t *testing.T) {
+	calls := mocks.NewMockCallService(gomock.NewController(t))
+	calls.EXPECT().CloseStaleCalls(gomock.Any(), 30*24*time.Hour).Return(4, nil)
+	var before int64
+	if total, ok := staleCallsStats.Get("closed_total").(*expvar.Int); ok {
+		before = total.Value()
+	}
+
+	job := StaleCallsJob(calls, StaleCallsConfig{OlderThan: 30 * 24 * time.Hour})
+	require.NoError(t, job.Run(context.Background()))
+
+	assert.Equal(t, DefaultStaleCallsInterval, job.Interval)
+	assert.Equal(t, int64(4), staleCallsClosedLastRun.Value())
+	assert.Equal(t, before+4, staleCallsStats.Get("closed_total").(*expvar.Int).Value())
+}
+
+// Тест пробного режима: заявки отбираются по возрасту и не закрываются
+func TestStaleCallsJob_DryRun(t *testing.T) {
+	calls := mocks.NewMockCallService(gomock.NewController(t))
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	calls.EXPECT().ListCallsAdmin(gomock.Any(), gomock.Cond(func(filter model.CallFilter) bool {
+		return filter.Status == model.CallStatusOpen && filter.CreatedTo.Equal(now.Add(-48*time.Hour))
+	})).Return([]*model.Call{{ID: uuid.New(), CreatedAt: now.Add(-72 * time.Hour)}}, 1, nil)
+
+	job := StaleCallsJob(calls, StaleCallsConfig{OlderThan: 48 * time.Hour, DryRun: true, Clock: clock.NewFake(now)})
+	require.NoError(t, job.Run(context.Background()))
+
+	assert.Equal(t, int64(1), staleCallsWouldCloseLast.Value())
+}
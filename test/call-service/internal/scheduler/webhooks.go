@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"time"
+
+	"call-service/internal/service"
+)
+
+// Параметры задач доставки событий подпискам и очистки устаревших данных
+const (
+	// DefaultWebhookDeliveriesInterval — период отправки доставок по умолчанию.
+	DefaultWebhookDeliveriesInterval = 15 * time.Second
+	// DefaultPurgeInterval — период очистки по умолчанию.
+	DefaultPurgeInterval = time.Hour
+	// webhookDeliveriesLockKey и purgeLockKey — ключи блокировки задач.
+	webhookDeliveriesLockKey int64 = 4
+	purgeLockKey             int64 = 5
+)
+
+// webhookDeliveriesStats — показатели задачи отправки в /debug/vars: число доставленных
+// событий и запусков, завершившихся ошибкой.
+var webhookDeliveriesStats = expvar.NewMap("webhook_deliveries")
+
+// purgeStats — показатели задачи очистки в /debug/vars: число удаленных записей по видам
+// и запусков, завершившихся ошибкой.
+var purgeStats = expvar.NewMap("purge")
+
+// WebhookDeliveriesJob создает задачу, которая каждые interval (0 —
+// DefaultWebhookDeliveriesInterval) отправляет подпискам события из очереди доставки
+// через WebhookService.DispatchDue.
+func WebhookDeliveriesJob(webhooks service.WebhookService, interval time.Duration) Job {
+	if interval <= 0 {
+		interval = DefaultWebhookDeliveriesInterval
+	}
+	return Job{
+		Name:     "dispatch_webhook_deliveries",
+		Interval: interval,
+		LockKey:  webhookDeliveriesLockKey,
+		Run: func(ctx context.Context) error {
+			delivered, err := webhooks.DispatchDue(ctx)
+			webhookDeliveriesStats.Add("delivered_total", int64(delivered))
+			if err != nil {
+				webhookDeliveriesStats.Add("failed_runs", 1)
+			}
+			return err
+		},
+	}
+}
+
+// PurgeJob создает задачу, которая каждые interval (0 — DefaultPurgeInterval) удаляет данные
+// с истекшим сроком хранения: завершенные доставки событий подпискам
+// (WebhookService.PurgeDeliveries).
+func PurgeJob(webhooks service.WebhookService, interval time.Duration) Job {
+	if interval <= 0 {
+		interval = DefaultPurgeInterval
+	}
+	return Job{
+		Name:     "purge_expired_data",
+		Interval: interval,
+		LockKey:  purgeLockKey,
+		Run: func(ctx context.Context) error {
+			purged, err := webhooks.PurgeDeliveries(ctx)
+			purgeStats.Add("webhook_deliveries_total", int64(purged))
+			if purged > 0 {
+				log.Printf("scheduler: purged %d expired webhook deliveries", purged)
+			}
+			if err != nil {
+				purgeStats.Add("failed_runs", 1)
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"expvar"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"call-service/internal/worker"
+)
+
+// Параметры журнала изменяющих запросов
+const (
+	// DefaultAPIAuditQueueSize — размер очереди записей, ожидающих сохранения, по умолчанию.
+	DefaultAPIAuditQueueSize = 1024
+	// apiAuditBatchSize — максимальное число записей, сохраняемых одним запросом.
+	apiAuditBatchSize = 100
+	// apiAuditWriteTimeout ограничивает сохранение одной пачки записей.
+	apiAuditWriteTimeout = 5 * time.Second
+)
+
+// apiAuditStats — показатели журнала в /debug/vars: число сохраненных записей, записей,
+// отброшенных из-за переполнения очереди, и записей, которые не удалось сохранить.
+var apiAuditStats = expvar.NewMap("api_audit")
+
+// APIAuditLog записывает изменяющие запросы HTTP API в журнал асинхронно: Record ставит
+// запись в ограниченную очередь пула worker и не ждет базу данных, а одна фоновая горутина
+// сохраняет записи пачками. Если очередь заполнена, запись отбрасывается и учитывается
+// в показателе api_audit.dropped — запрос клиента не задерживается из-за медленной базы данных.
+
+type APIAuditLog struct {
+	repo repository.APIAuditRepository
+	pool *worker.Pool[*model.APIAuditEntry]
+}
+
+// NewAPIAuditLog создает журнал изменяющих запросов с очередью на queueSize записей
+// (0 — DefaultAPIAuditQueueSize) и запускает его фоновую горутину. Горутина
+// останавливается в Close.
+
+func NewAPIAuditLog(repo repository.APIAuditRepository, queueSize int) *APIAuditLog {
+	if queueSize <= 0 {
+		queueSize = DefaultAPIAuditQueueSize
+	}
+	l := &APIAuditLog{repo: repo}
+	l.pool = worker.New(worker.Config{
+		Name:        "api_audit",
+		QueueSize:   queueSize,
+		BatchSize:   apiAuditBatchSize,
+		TaskTimeout: apiAuditWriteTimeout,
+		Policy:      worker.Drop,
+	}, l.write)
+	return l
+}
+
+// Record ставит запись в очередь на сохранение, заполняя ID и время записи, если они
+// не заданы. Возвращает false, если очередь заполнена или журнал закрыт и запись отброшена.
+
+func (l *APIAuditLog) Record(entry *model.APIAuditEntry) bool {
+	if entry.ID == uuid.Nil {
+		entry.ID = model.NewID()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+
+	if err := l.pool.Submit(context.Background(), entry); err != nil {
+		apiAuditStats.Add("dropped", 1)
+		return false
+	}
+	return true
+}
+
+// List возвращает записи журнала, удовлетворяющие фильтру, от новых к старым, и общее
+// количество таких записей. Записи, ожидающие в очереди, не учитываются.
+
+func (l *APIAuditLog) List(ctx context.Context, filter model.APIAuditFilter) ([]*model.APIAuditEntry, int, error) {
+	return l.repo.List(ctx, filter)
+}
+
+// SecuritySummary возвращает сводку изменяющих запросов, отклоненных за последний период
+// window (например, час): число по причинам model.SecurityReasons и по маршрутам.
+// Записи, ожидающие в очереди, не учитываются.
+
+func (l *APIAuditLog) SecuritySummary(ctx context.Context, window time.Duration) (*model.SecuritySummary, error) {
+	to := time.Now().UTC()
+	from := to.Add(-window)
+	counts, err := l.repo.CountByStatus(ctx, from, model.SecurityStatuses())
+	if err != nil {
+		return nil, fmt.Errorf("count rejected requests: %w", err)
+	}
+
+	summary := &model.SecuritySummary{
+		From:    from,
+		To:      to,
+		Reasons: make(map[string]int, len(model.SecurityReasons)),
+		Routes:  make([]model.SecurityRouteCount, 0, len(counts)),
+	}
+	for _, reason := range model.SecurityReasons {
+		summary.Reasons[reason] = 0
+	}
+	for _, count := range counts {
+		reason := model.SecurityReason(count.Status)
+		summary.Reasons[reason] += count.Count
+		summary.Routes = append(summary.Routes, model.SecurityRouteCount{
+			Method: count.Method,
+			Route:  count.Route,
+			Reason: reason,
+			Count:  count.Count,
+		})
+	}
+	slices.SortFunc(summary.Routes, func(a, b model.SecurityRouteCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Route, b.Route),
+			cmp.Compare(a.Method, b.Method), cmp.Compare(a.Reason, b.Reason))
+	})
+	return summary, nil
+}
+
+// Close перестает принимать записи, сохраняет записи, оставшиеся в очереди, и останавливает
+// фоновую горутину. Повторные вызовы только дожидаются остановки.
+
+func (l *APIAuditLog) Close() error {
+	return l.pool.Close(context.Background())
+}
+
+// write сохраняет пачку записей. Ошибка учитывается в показателе api_audit.failed; пул
+// записывает ее в журнал и не сохраняет записи повторно, чтобы очередь не росла
+// при недоступной базе данных.
+
+func (l *APIAuditLog) write(ctx context.Context, batch []*model.APIAuditEntry) error {
+	if err := l.repo.CreateBatch(ctx, batch); err != nil {
+		apiAuditStats.Add("failed", int64(len(batch)))
+		return fmt.Errorf("write audit entries: %w", err)
+	}
+	apiAuditStats.Add("recorded", int64(len(batch)))
+	return nil
+}
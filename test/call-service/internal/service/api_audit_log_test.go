@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"expvar"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+	"call-service/internal/repository"
+)
+
+// blockingAuditRepository сообщает о начале каждого сохранения в started и ждет release.
+type blockingAuditRepository struct {
+	repository.APIAuditRepository
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingAuditRepository) CreateBatch(ctx context.Context, entries []*model.APIAuditEntry) error {
+	r.started <- struct{}{}
+	<-r.release
+	return r.APIAuditRepository.CreateBatch(ctx, entries)
+}
+
+// apiAuditCount возвращает значение показателя журнала изменяющих запросов.
+func apiAuditCount(name string) int64 {
+	if v, ok := apiAuditStats.Get(name).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+// Тест журнала изменяющих запросов: Record заполняет ID и время, Close сохраняет записи,
+// оставшиеся в очереди, а после Close записи отбрасываются
+func TestAPIAuditLog_Close(t *testing.T) {
+	repo := repository.NewInMemoryAPIAuditRepository()
+	auditLog := NewAPIAuditLog(repo, 0)
+	userID := uuid.New()
+
+	for range 3 {
+		assert.True(t, auditLog.Record(&model.APIAuditEntry{Method: "POST", Route: "/calls", UserID: &userID, Status: 201}))
+	}
+	require.NoError(t, auditLog.Close())
+	require.NoError(t, auditLog.Close())
+	assert.False(t, auditLog.Record(&model.APIAuditEntry{Method: "POST", Route: "/calls"}))
+
+	entries, total, err := auditLog.List(context.Background(), model.APIAuditFilter{UserID: &userID})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	for _, entry := range entries {
+		assert.NotEqual(t, uuid.Nil, entry.ID)
+		assert.False(t, entry.CreatedAt.IsZero())
+	}
+}
+
+// Тест переполнения очереди: пока база данных не отвечает, Record не блокируется,
+// а записи сверх размера очереди отбрасываются и учитываются в показателе dropped
+func TestAPIAuditLog_DropsWhenSaturated(t *testing.T) {
+	repo := &blockingAuditRepository{
+		APIAuditRepository: repository.NewInMemoryAPIAuditRepository(),
+		started:            make(chan struct{}, 1),
+		release:            make(chan struct{}),
+	}
+	auditLog := NewAPIAuditLog(repo, 1)
+	dropped := apiAuditCount("dropped")
+
+	// Первая запись передана на сохранение, вторая ждет в очереди, третья отбрасывается
+	require.True(t, auditLog.Record(&model.APIAuditEntry{Route: "/calls"}))
+	<-repo.started
+	assert.True(t, auditLog.Record(&model.APIAuditEntry{Route: "/calls"}))
+	assert.False(t, auditLog.Record(&model.APIAuditEntry{Route: "/calls"}))
+	assert.Equal(t, dropped+1, apiAuditCount("dropped"))
+
+	close(repo.release)
+	require.NoError(t, auditLog.Close())
+	_, total, err := auditLog.List(context.Background(), model.APIAuditFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"common/clock"
+)
+
+// Ошибки операций с API-ключами
+
+var (
+	ErrAPIKeyNotFound      = errors.New("api key not found")
+	ErrInvalidAPIKey       = errors.New("invalid api key")
+	ErrInvalidAPIKeyName   = errors.New("api key name must be 1 to 100 characters long")
+	ErrInvalidAPIKeyExpiry = errors.New("api key expiry must be in the future")
+)
+
+// Формат API-ключа: APIKeyPrefix, затем открытая часть для поиска ключа в хранилище
+// (apiKeyLookupLength шестнадцатеричных символов), "_" и секрет в кодировке base64url.
+const (
+	APIKeyPrefix       = "csk_"
+	apiKeyLookupLength = 12
+	apiKeySecretBytes  = 32
+)
+
+// Параметры API-ключей
+const (
+	// DefaultAPIKeyCacheTTL — время, в течение которого проверенный ключ не перечитывается
+	// из хранилища. Отзыв ключа через этот экземпляр сервиса действует сразу, а на других
+	// экземплярах — не позже чем через это время.
+	DefaultAPIKeyCacheTTL = 30 * time.Second
+	// apiKeyTouchInterval — минимальный интервал между сохранениями времени использования ключа.
+	apiKeyTouchInterval = time.Minute
+	// apiKeyTouchTimeout ограничивает фоновое сохранение времени использования ключа.
+	apiKeyTouchTimeout = 5 * time.Second
+	// apiKeyCacheSize — максимальное число ключей в кэше.
+	apiKeyCacheSize = 10000
+	// maxAPIKeyNameLength — максимальная длина названия ключа в символах.
+	maxAPIKeyNameLength = 100
+)
+
+// APIKeyService определяет интерфейс сервиса API-ключей для автоматизированных клиентов
+
+type APIKeyService interface {
+	// CreateAPIKey создает ключ и возвращает его вместе с самим ключом; ключ больше нигде
+	// не сохраняется и не может быть получен повторно. Нулевой expiresAt — бессрочный ключ.
+	CreateAPIKey(ctx context.Context, userID uuid.UUID, name string, expiresAt time.Time) (*model.APIKey, string, error)
+	ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]*model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, id uuid.UUID) error
+	// DeleteUserAPIKeys удаляет все ключи пользователя при удалении его учетной записи.
+	DeleteUserAPIKeys(ctx context.Context, userID uuid.UUID) (int, error)
+	// ResolveAPIKey возвращает ID владельца действующего ключа или ErrInvalidAPIKey.
+	ResolveAPIKey(ctx context.Context, key string) (uuid.UUID, error)
+}
+
+// apiKeyCacheEntry — проверенный ключ в кэше apiKeyService.
+
+type apiKeyCacheEntry struct {
+	keyID       uuid.UUID
+	userID      uuid.UUID
+	expiresAt   time.Time
+	cachedUntil time.Time
+	touchedAt   time.Time
+}
+
+// apiKeyService реализует интерфейс APIKeyService
+
+type apiKeyService struct {
+	repo     repository.APIKeyRepository
+	clock    clock.Clock
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]*apiKeyCacheEntry
+}
+
+// APIKeyOption задает необязательный параметр сервиса API-ключей.
+
+type APIKeyOption func(*apiKeyService)
+
+// WithAPIKeyClock задает часы, по которым определяются срок действия ключей и записей кэша.
+
+func WithAPIKeyClock(c clock.Clock) APIKeyOption {
+	return func(s *apiKeyService) {
+		s.clock = c
+	}
+}
+
+// WithAPIKeyCacheTTL задает время жизни записей кэша проверенных ключей. Значение 0 отключает кэш.
+
+func WithAPIKeyCacheTTL(ttl time.Duration) APIKeyOption {
+	return func(s *apiKeyService) {
+		s.cacheTTL = ttl
+	}
+}
+
+// NewAPIKeyService создает новый экземпляр сервиса API-ключей
+
+func NewAPIKeyService(repo repository.APIKeyRepository, opts ...APIKeyOption) APIKeyService {
+	s := &apiKeyService{
+		repo:     repo,
+		clock:    clock.Real,
+		cacheTTL: DefaultAPIKeyCacheTTL,
+		cache:    make(map[string]*apiKeyCacheEntry),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// CreateAPIKey создает API-ключ пользователя
+
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, userID uuid.UUID, name string, expiresAt time.Time) (*model.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxAPIKeyNameLength {
+		return nil, "", ErrInvalidAPIKeyName
+	}
+	now := s.clock.Now().UTC()
+	if !expiresAt.IsZero() && !expiresAt.After(now) {
+		return nil, "", ErrInvalidAPIKeyExpiry
+	}
+
+	lookup, secret, err := newAPIKeySecret()
+	if err != nil {
+		return nil, "", err
+	}
+	plain := APIKeyPrefix + lookup + "_" + secret
+
+	key := &model.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    lookup,
+		KeyHash:   hashAPIKey(plain),
+		CreatedAt: now,
+		ExpiresAt: expiresAt.UTC(),
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, plain, nil
+}
+
+// ListAPIKeys возвращает все ключи пользователя, включая отозванные и истекшие
+
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]*model.APIKey, error) {
+	return s.repo.ListByUserID(ctx, userID)
+}
+
+// RevokeAPIKey отзывает ключ пользователя и удаляет его из кэша
+
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, userID, id uuid.UUID) error {
+	if err := s.repo.Revoke(ctx, userID, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+
+	s.mu.Lock()
+	for hash, entry := range s.cache {
+		if entry.keyID == id {
+			delete(s.cache, hash)
+		}
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// DeleteUserAPIKeys удаляет ключи пользователя и сбрасывает их записи в кэше
+
+func (s *apiKeyService) DeleteUserAPIKeys(ctx context.Context, userID uuid.UUID) (int, error) {
+	deleted, err := s.repo.DeleteByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	for hash, entry := range s.cache {
+		if entry.userID == userID {
+			delete(s.cache, hash)
+		}
+	}
+	s.mu.Unlock()
+	return deleted, nil
+}
+
+// ResolveAPIKey находит ключ по открытой части, сравнивает хеш и проверяет, что ключ
+// не отозван и не истек. Время использования ключа сохраняется в фоне не чаще
+// apiKeyTouchInterval, чтобы не замедлять запросы.
+
+func (s *apiKeyService) ResolveAPIKey(ctx context.Context, key string) (uuid.UUID, error) {
+	lookup, ok := parseAPIKey(key)
+	if !ok {
+		return uuid.Nil, ErrInvalidAPIKey
+	}
+	hash := hashAPIKey(key)
+	now := s.clock.Now()
+
+	s.mu.Lock()
+	entry, ok := s.cache[hash]
+	if ok && now.Before(entry.cachedUntil) {
+		if !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+			s.mu.Unlock()
+			return uuid.Nil, ErrInvalidAPIKey
+		}
+		s.touchLocked(entry, now)
+		s.mu.Unlock()
+		return entry.userID, nil
+	}
+	s.mu.Unlock()
+
+	stored, err := s.repo.GetByPrefix(ctx, lookup)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return uuid.Nil, ErrInvalidAPIKey
+		}
+		return uuid.Nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(stored.KeyHash), []byte(hash)) != 1 || !stored.Active(now) {
+		return uuid.Nil, ErrInvalidAPIKey
+	}
+
+	entry = &apiKeyCacheEntry{
+		keyID:       stored.ID,
+		userID:      stored.UserID,
+		expiresAt:   stored.ExpiresAt,
+		cachedUntil: now.Add(s.cacheTTL),
+		touchedAt:   stored.LastUsedAt,
+	}
+	s.mu.Lock()
+	if s.cacheTTL > 0 {
+		s.putLocked(hash, entry, now)
+	}
+	s.touchLocked(entry, now)
+	s.mu.Unlock()
+	return stored.UserID, nil
+}
+
+// putLocked сохраняет запись в кэше. Если кэш заполнен, из него удаляются устаревшие
+// записи, а если их нет — весь кэш. Вызывается под s.mu.
+
+func (s *apiKeyService) putLocked(hash string, entry *apiKeyCacheEntry, now time.Time) {
+	if len(s.cache) >= apiKeyCacheSize {
+		for h, e := range s.cache {
+			if !now.Before(e.cachedUntil) {
+				delete(s.cache, h)
+			}
+		}
+		if len(s.cache) >= apiKeyCacheSize {
+			clear(s.cache)
+		}
+	}
+	s.cache[hash] = entry
+}
+
+// touchLocked запускает фоновое сохранение времени использования ключа, если с прошлого
+// сохранения прошло не меньше apiKeyTouchInterval. Вызывается под s.mu.
+
+func (s *apiKeyService) touchLocked(entry *apiKeyCacheEntry, now time.Time) {
+	if !entry.touchedAt.IsZero() && now.Sub(entry.touchedAt) < apiKeyTouchInterval {
+		return
+	}
+	entry.touchedAt = now
+	go func(id uuid.UUID, at time.Time) {
+		ctx, cancel := context.WithTimeout(context.Background(), apiKeyTouchTimeout)
+		defer cancel()
+		if err := s.repo.TouchLastUsed(ctx, id, at.UTC()); err != nil {
+			log.Printf("failed to update last use of api key %s: %v", id, err)
+		}
+	}(entry.keyID, now)
+}
+
+// newAPIKeySecret возвращает случайные открытую часть и секрет нового ключа.
+
+func newAPIKeySecret() (string, string, error) {
+	buf := make([]byte, apiKeyLookupLength/2+apiKeySecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	lookup := hex.EncodeToString(buf[:apiKeyLookupLength/2])
+	secret := base64.RawURLEncoding.EncodeToString(buf[apiKeyLookupLength/2:])
+	return lookup, secret, nil
+}
+
+// parseAPIKey проверяет формат ключа и возвращает его открытую часть.
+
+func parseAPIKey(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, APIKeyPrefix)
+	if !ok || len(rest) <= apiKeyLookupLength+1 || rest[apiKeyLookupLength] != '_' {
+		return "", false
+	}
+	return rest[:apiKeyLookupLength], true
+}
+
+// hashAPIKey возвращает SHA-256 хеш ключа в шестнадцатеричном виде. Ключи случайные
+// и длинные, поэтому медленное хеширование, как для паролей, не требуется.
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"common/clock"
+)
+
+// countingAPIKeyRepository считает обращения к хранилищу при проверке ключа.
+type countingAPIKeyRepository struct {
+	repository.APIKeyRepository
+	lookups atomic.Int64
+}
+
+func (r *countingAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	r.lookups.Add(1)
+	return r.APIKeyRepository.GetByPrefix(ctx, prefix)
+}
+
+// Тест создания и проверки ключа: ключ возвращается один раз, хранится только хеш,
+// а ключ с тем же префиксом и другим секретом отклоняется
+func TestAPIKey_CreateAndResolve(t *testing.T) {
+	repo := repository.NewInMemoryAPIKeyRepository()
+	svc := NewAPIKeyService(repo)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	key, plain, err := svc.CreateAPIKey(ctx, userID, "  ci  ", time.Time{})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(plain, APIKeyPrefix+key.Prefix+"_"))
+	assert.Equal(t, "ci", key.Name)
+	assert.NotContains(t, key.KeyHash, plain)
+
+	resolved, err := svc.ResolveAPIKey(ctx, plain)
+	require.NoError(t, err)
+	assert.Equal(t, userID, resolved)
+
+	for _, invalid := range []string{"", "csk_", "not-a-key", plain[:len(plain)-1] + "x", APIKeyPrefix + "000000000000_secret"} {
+		_, err := svc.ResolveAPIKey(ctx, invalid)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey, invalid)
+	}
+
+	_, _, err = svc.CreateAPIKey(ctx, userID, " ", time.Time{})
+	assert.ErrorIs(t, err, ErrInvalidAPIKeyName)
+	_, _, err = svc.CreateAPIKey(ctx, userID, "old", time.Now().Add(-time.Minute))
+	assert.ErrorIs(t, err, ErrInvalidAPIKeyExpiry)
+}
+
+// Тест кэша: в пределах времени жизни записи ключ не перечитывается из хранилища,
+// но отзыв и истечение срока действуют сразу
+func TestAPIKey_CacheRevokeAndExpiry(t *testing.T) {
+	start := time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	repo := &countingAPIKeyRepository{APIKeyRepository: repository.NewInMemoryAPIKeyRepository()}
+	svc := NewAPIKeyService(repo, WithAPIKeyClock(fake))
+	ctx := context.Background()
+	userID := uuid.New()
+
+	key, plain, err := svc.CreateAPIKey(ctx, userID, "ci", time.Time{})
+	require.NoError(t, err)
+	for range 3 {
+		_, err := svc.ResolveAPIKey(ctx, plain)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(1), repo.lookups.Load())
+
+	require.NoError(t, svc.RevokeAPIKey(ctx, userID, key.ID))
+	_, err = svc.ResolveAPIKey(ctx, plain)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	assert.ErrorIs(t, svc.RevokeAPIKey(ctx, userID, key.ID), ErrAPIKeyNotFound)
+
+	_, expiring, err := svc.CreateAPIKey(ctx, userID, "temporary", start.Add(time.Minute))
+	require.NoError(t, err)
+	_, err = svc.ResolveAPIKey(ctx, expiring)
+	require.NoError(t, err)
+	fake.Advance(time.Minute)
+	_, err = svc.ResolveAPIKey(ctx, expiring)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+}
+
+// Тест времени последнего использования: сохраняется в фоне и не чаще раза в минуту
+func TestAPIKey_TouchesLastUsed(t *testing.T) {
+	start := time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	svc := NewAPIKeyService(repository.NewInMemoryAPIKeyRepository(), WithAPIKeyClock(fake))
+	ctx := context.Background()
+	userID := uuid.New()
+
+	_, plain, err := svc.CreateAPIKey(ctx, userID, "ci", time.Time{})
+	require.NoError(t, err)
+
+	lastUsed := func() time.Time {
+		keys, err := svc.ListAPIKeys(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		return keys[0].LastUsedAt
+	}
+
+	_, err = svc.ResolveAPIKey(ctx, plain)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return lastUsed().Equal(start) }, time.Second, 10*time.Millisecond)
+
+	fake.Advance(10 * time.Second)
+	_, err = svc.ResolveAPIKey(ctx, plain)
+	require.NoError(t, err)
+	fake.Advance(time.Minute)
+	_, err = svc.ResolveAPIKey(ctx, plain)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return lastUsed().Equal(start.Add(70 * time.Second)) }, time.Second, 10*time.Millisecond)
+}
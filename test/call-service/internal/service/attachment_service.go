@@ -0,0 +1,306 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/blobstore"
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"common/clock"
+)
+
+// Ошибки операций с вложениями заявок
+
+var (
+	ErrAttachmentNotFound       = errors.New("attachment not found")
+	ErrAttachmentTooLarge       = errors.New("attachment is too large")
+	ErrAttachmentTypeNotAllowed = errors.New("attachment type is not allowed")
+	ErrAttachmentTypeMismatch   = errors.New("attachment content does not match its declared type")
+)
+
+// Параметры вложений
+const (
+	// DefaultAttachmentMaxSize — наибольший размер вложения по умолчанию, 10 МиБ.
+	DefaultAttachmentMaxSize = 10 << 20
+	// attachmentSniffLength — число первых байт файла, по которым определяется его тип
+	// (столько использует http.DetectContentType).
+	attachmentSniffLength = 512
+	// maxAttachmentNameLength — наибольшая длина имени файла в символах.
+	maxAttachmentNameLength = 255
+	// declaredTypeUnknown — тип, который клиенты указывают, когда не знают тип файла;
+	// он не сравнивается с определенным по содержимому.
+	declaredTypeUnknown = "application/octet-stream"
+)
+
+// DefaultAttachmentTypes — типы вложений, разрешенные по умолчанию: изображения и PDF.
+var DefaultAttachmentTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp", "application/pdf"}
+
+// AttachmentUpload описывает загружаемый файл. ContentType — тип, указанный клиентом;
+// Size — размер файла, Content должен содержать ровно Size байт.
+
+type AttachmentUpload struct {
+	FileName    string
+	ContentType string
+	Size        int64
+	Content     io.Reader
+}
+
+// AttachmentService определяет интерфейс сервиса вложений заявок.
+//
+// Содержимое вложения сохраняется в хранилище объектов раньше его описания в базе данных,
+// а описание удаляется после содержимого, поэтому сбой хранилища не оставляет описаний
+// без содержимого. При сбое может остаться содержимое без описания, которое никому не видно.
+
+type AttachmentService interface {
+	// MaxSize возвращает наибольший размер вложения в байтах.
+	MaxSize() int64
+	// UploadAttachment прикрепляет файл к заявке пользователя. Тип файла определяется
+	// по содержимому и должен быть разрешен и совпадать с типом, указанным клиентом.
+	UploadAttachment(ctx context.Context, callID, userID uuid.UUID, upload *AttachmentUpload) (*model.CallAttachment, error)
+	// OpenAttachment возвращает описание и содержимое вложения заявки пользователя;
+	// содержимое должен закрыть вызывающий.
+	OpenAttachment(ctx context.Context, callID, id, userID uuid.UUID) (*model.CallAttachment, io.ReadCloser, error)
+	// DeleteCallAttachments удаляет все вложения заявки перед ее удалением.
+	DeleteCallAttachments(ctx context.Context, callID uuid.UUID) error
+	// DeleteUserAttachments удаляет вложения всех заявок пользователя при удалении его учетной
+	// записи и возвращает число удаленных вложений.
+	DeleteUserAttachments(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+// AttachmentConfig задает параметры AttachmentService. Нулевые значения заменяются значениями по умолчанию.
+
+type AttachmentConfig struct {
+	// MaxSize — см. DefaultAttachmentMaxSize.
+	MaxSize int64
+	// AllowedTypes — разрешенные типы вложений; nil — DefaultAttachmentTypes.
+	AllowedTypes []string
+	// Clock — источник времени загрузки; nil — системное время.
+	Clock clock.Clock
+}
+
+// attachmentService реализует интерфейс AttachmentService
+
+type attachmentService struct {
+	attachments repository.AttachmentRepository
+	calls       repository.CallRepository
+	store       blobstore.Store
+	cfg         AttachmentConfig
+	allowed     map[string]bool
+}
+
+// NewAttachmentService создает новый экземпляр сервиса вложений
+
+func NewAttachmentService(attachments repository.AttachmentRepository, calls repository.CallRepository, store blobstore.Store, cfg AttachmentConfig) AttachmentService {
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = DefaultAttachmentMaxSize
+	}
+	if cfg.AllowedTypes == nil {
+		cfg.AllowedTypes = DefaultAttachmentTypes
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clock.Real
+	}
+	allowed := make(map[string]bool, len(cfg.AllowedTypes))
+	for _, t := range cfg.AllowedTypes {
+		allowed[mediaType(t)] = true
+	}
+	return &attachmentService{attachments: attachments, calls: calls, store: store, cfg: cfg, allowed: allowed}
+}
+
+// MaxSize возвращает наибольший размер вложения
+
+func (s *attachmentService) MaxSize() int64 {
+	return s.cfg.MaxSize
+}
+
+// UploadAttachment сохраняет содержимое файла в хранилище, затем его описание. Если описание
+// сохранить не удалось, содержимое удаляется из хранилища.
+
+func (s *attachmentService) UploadAttachment(ctx context.Context, callID, userID uuid.UUID, upload *AttachmentUpload) (*model.CallAttachment, error) {
+	if err := s.checkAccess(ctx, callID, userID, CallUpdate); err != nil {
+		return nil, err
+	}
+	if upload.Size > s.cfg.MaxSize {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	head := make([]byte, attachmentSniffLength)
+	n, err := io.ReadFull(upload.Content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("read attachment: %w", err)
+	}
+	head = head[:n]
+	contentType := mediaType(http.DetectContentType(head))
+	if declared := mediaType(upload.ContentType); declared != "" && declared != declaredTypeUnknown && declared != contentType {
+		return nil, ErrAttachmentTypeMismatch
+	}
+	if !s.allowed[contentType] {
+		return nil, ErrAttachmentTypeNotAllowed
+	}
+
+	attachment := &model.CallAttachment{
+		ID:          uuid.New(),
+		CallID:      callID,
+		FileName:    attachmentName(upload.FileName),
+		ContentType: contentType,
+		Size:        upload.Size,
+		UploadedBy:  userID,
+		CreatedAt:   s.cfg.Clock.Now().UTC().Truncate(time.Microsecond),
+	}
+	attachment.StorageKey = attachmentKey(attachment)
+
+	content := io.LimitReader(io.MultiReader(bytes.NewReader(head), upload.Content), upload.Size)
+	if err := s.store.Put(ctx, attachment.StorageKey, content, upload.Size); err != nil {
+		// Хранилище могло сохранить часть содержимого
+		s.deleteContent(ctx, attachment)
+		return nil, fmt.Errorf("store attachment: %w", err)
+	}
+	if err := s.attachments.Create(ctx, attachment); err != nil {
+		s.deleteContent(ctx, attachment)
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// OpenAttachment проверяет владельца заявки и открывает содержимое вложения
+
+func (s *attachmentService) OpenAttachment(ctx context.Context, callID, id, userID uuid.UUID) (*model.CallAttachment, io.ReadCloser, error) {
+	if err := s.checkAccess(ctx, callID, userID, CallRead); err != nil {
+		return nil, nil, err
+	}
+	attachment, err := s.attachments.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, err
+	}
+	if attachment.CallID != callID {
+		return nil, nil, ErrAttachmentNotFound
+	}
+
+	content, err := s.store.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			log.Printf("content of attachment %s is missing in storage", attachment.ID)
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, fmt.Errorf("open attachment: %w", err)
+	}
+	return attachment, content, nil
+}
+
+// DeleteCallAttachments удаляет содержимое и описание каждого вложения заявки. При ошибке
+// удаление прерывается, а оставшиеся вложения удалит повторный вызов.
+
+func (s *attachmentService) DeleteCallAttachments(ctx context.Context, callID uuid.UUID) error {
+	_, err := s.deleteCallAttachments(ctx, callID)
+	return err
+}
+
+// DeleteUserAttachments удаляет вложения заявок пользователя
+
+func (s *attachmentService) DeleteUserAttachments(ctx context.Context, userID uuid.UUID) (int, error) {
+	var callIDs []uuid.UUID
+	err := s.calls.ForEachByUserID(ctx, userID, model.CallFilter{}, func(call *model.Call) error {
+		callIDs = append(callIDs, call.ID)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, callID := range callIDs {
+		n, err := s.deleteCallAttachments(ctx, callID)
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+func (s *attachmentService) deleteCallAttachments(ctx context.Context, callID uuid.UUID) (int, error) {
+	attachments, err := s.attachments.ListByCallID(ctx, callID)
+	if err != nil {
+		return 0, err
+	}
+	for i, attachment := range attachments {
+		if err := s.store.Delete(ctx, attachment.StorageKey); err != nil {
+			return i, fmt.Errorf("delete attachment %s: %w", attachment.ID, err)
+		}
+		if err := s.attachments.Delete(ctx, attachment.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return i, err
+		}
+	}
+	return len(attachments), nil
+}
+
+// checkAccess проверяет, что заявка существует и пользователь может выполнить с ней action
+// (authorizeCallAccess). Заявка читается из основной базы данных, чтобы к только что
+// созданной заявке можно было сразу прикрепить файл.
+
+func (s *attachmentService) checkAccess(ctx context.Context, callID, userID uuid.UUID, action CallAction) error {
+	call, err := s.calls.GetByID(repository.WithPrimary(ctx), callID)
+	if err != nil {
+		return lookupError("get", callID, err)
+	}
+	return authorizeCallAccess(userPrincipal(ctx, userID), call, action)
+}
+
+// deleteContent удаляет содержимое вложения, описание которого не сохранено. Удаление
+// выполняется, даже если ctx уже отменен; ошибка только записывается в журнал.
+
+func (s *attachmentService) deleteContent(ctx context.Context, attachment *model.CallAttachment) {
+	if err := s.store.Delete(context.WithoutCancel(ctx), attachment.StorageKey); err != nil {
+		log.Printf("failed to delete content of unsaved attachment %s: %v", attachment.ID, err)
+	}
+}
+
+// attachmentKey возвращает ключ содержимого вложения в хранилище.
+
+func attachmentKey(attachment *model.CallAttachment) string {
+	return "calls/" + attachment.CallID.String() + "/" + attachment.ID.String()
+}
+
+// attachmentName приводит имя файла, указанное клиентом, к виду, безопасному для
+// заголовка Content-Disposition: без пути и управляющих символов, не длиннее
+// maxAttachmentNameLength символов.
+
+func attachmentName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	if runes := []rune(name); len(runes) > maxAttachmentNameLength {
+		name = string(runes[:maxAttachmentNameLength])
+	}
+	return name
+}
+
+// mediaType возвращает тип без параметров в нижнем регистре ("text/plain; charset=utf-8" — "text/plain").
+
+func mediaType(contentType string) string {
+	contentType, _, _ = strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/blobstore"
+	"call-service/internal/model"
+	"call-service/internal/repository"
+)
+
+// pngContent — начало PNG-файла, по которому http.DetectContentType определяет image/png.
+var pngContent = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR" + strings.Repeat("\x00", 64))
+
+// memStore хранит объекты в памяти и возвращает putErr из Put, пока он задан.
+type memStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	putErr  error
+	deletes int
+}
+
+func newMemStore() *memStore {
+	return &memStore{objects: make(map[string][]byte)}
+}
+
+func (s *memStore) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.putErr != nil {
+		return s.putErr
+	}
+	if int64(len(data)) != size {
+		return errors.New("size mismatch")
+	}
+	s.objects[key] = data
+	return nil
+}
+
+func (s *memStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, blobstore.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deletes++
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *memStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.objects)
+}
+
+// failingAttachmentRepository не сохраняет описания вложений.
+type failingAttachmentRepository struct {
+	repository.AttachmentRepository
+}
+
+func (failingAttachmentRepository) Create(ctx context.Context, attachment *model.CallAttachment) error {
+	return errors.New("database is unavailable")
+}
+
+// attachmentFixture — сервис вложений с хранилищами в памяти и заявкой владельца.
+type attachmentFixture struct {
+	calls       repository.CallRepository
+	attachments repository.AttachmentRepository
+	store       *memStore
+	svc         AttachmentService
+	call        *model.Call
+}
+
+func newAttachmentFixture(t *testing.T, cfg AttachmentConfig) *attachmentFixture {
+	f := &attachmentFixture{
+		calls:       repository.NewInMemoryCallRepository(),
+		attachments: repository.NewInMemoryAttachmentRepository(),
+		store:       newMemStore(),
+	}
+	f.svc = NewAttachmentService(f.attachments, f.calls, f.store, cfg)
+	f.call = &model.Call{ClientName: "Иван", PhoneNumber: "+79990000001", UserID: uuid.New()}
+	require.NoError(t, f.calls.Create(context.Background(), f.call))
+	return f
+}
+
+// upload загружает файл content с указанным клиентом типом в заявку владельца.
+func (f *attachmentFixture) upload(contentType string, content []byte) (*model.CallAttachment, error) {
+	return f.svc.UploadAttachment(context.Background(), f.call.ID, f.call.UserID, &AttachmentUpload{
+		FileName:    "photo.png",
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		Content:     bytes.NewReader(content),
+	})
+}
+
+// Тест загрузки и чтения вложения: тип определяется по содержимому, имя файла очищается
+// от пути, чужая заявка и вложение другой заявки недоступны
+func TestUploadAttachment(t *testing.T) {
+	f := newAttachmentFixture(t, AttachmentConfig{})
+	ctx := context.Background()
+
+	attachment, err := f.svc.UploadAttachment(ctx, f.call.ID, f.call.UserID, &AttachmentUpload{
+		FileName:    `C:\Users\agent\photo.png`,
+		ContentType: "application/octet-stream",
+		Size:        int64(len(pngContent)),
+		Content:     bytes.NewReader(pngContent),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", attachment.ContentType)
+	assert.Equal(t, "photo.png", attachment.FileName)
+	assert.Equal(t, f.call.UserID, attachment.UploadedBy)
+
+	stored, content, err := f.svc.OpenAttachment(ctx, f.call.ID, attachment.ID, f.call.UserID)
+	require.NoError(t, err)
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	require.NoError(t, content.Close())
+	assert.Equal(t, pngContent, data)
+	assert.Equal(t, attachment.ID, stored.ID)
+
+	_, _, err = f.svc.OpenAttachment(ctx, f.call.ID, attachment.ID, uuid.New())
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = f.svc.UploadAttachment(ctx, f.call.ID, uuid.New(), &AttachmentUpload{Size: 1, Content: strings.NewReader("x")})
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	other := &model.Call{ClientName: "Петр", PhoneNumber: "+79990000002", UserID: f.call.UserID}
+	require.NoError(t, f.calls.Create(ctx, other))
+	_, _, err = f.svc.OpenAttachment(ctx, other.ID, attachment.ID, f.call.UserID)
+	assert.ErrorIs(t, err, ErrAttachmentNotFound)
+}
+
+// Тест ограничения размера: файл больше MaxSize отклоняется до обращения к хранилищу
+func TestUploadAttachment_TooLarge(t *testing.T) {
+	f := newAttachmentFixture(t, AttachmentConfig{MaxSize: 32})
+
+	_, err := f.upload("image/png", pngContent)
+	assert.ErrorIs(t, err, ErrAttachmentTooLarge)
+	assert.Zero(t, f.store.len())
+	assert.Zero(t, f.store.deletes)
+}
+
+// Тест проверки типа: тип определяется по содержимому и должен совпадать с указанным
+// клиентом (кроме application/octet-stream) и входить в AllowedTypes
+func TestUploadAttachment_ContentType(t *testing.T) {
+	f := newAttachmentFixture(t, AttachmentConfig{AllowedTypes: []string{"image/png", "application/pdf"}})
+	pdf := []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n1 0 obj\n<<>>\nendobj\n")
+
+	_, err := f.upload("image/jpeg", pngContent)
+	assert.ErrorIs(t, err, ErrAttachmentTypeMismatch)
+	_, err = f.upload("image/png", pdf)
+	assert.ErrorIs(t, err, ErrAttachmentTypeMismatch)
+	_, err = f.upload("text/plain", []byte("just text"))
+	assert.ErrorIs(t, err, ErrAttachmentTypeNotAllowed)
+	_, err = f.upload("", []byte("GIF89a"+strings.Repeat("\x00", 16)))
+	assert.ErrorIs(t, err, ErrAttachmentTypeNotAllowed)
+	assert.Zero(t, f.store.len())
+
+	attachment, err := f.upload("Application/PDF; charset=binary", pdf)
+	require.NoError(t, err)
+	assert.Equal(t, "application/pdf", attachment.ContentType)
+	attachment, err = f.upload("", pngContent)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", attachment.ContentType)
+}
+
+// Тест сбоев: при ошибке хранилища описание вложения не сохраняется, а при ошибке
+// сохранения описания содержимое удаляется из хранилища
+func TestUploadAttachment_Failures(t *testing.T) {
+	f := newAttachmentFixture(t, AttachmentConfig{})
+	ctx := context.Background()
+
+	f.store.putErr = errors.New("storage is unavailable")
+	_, err := f.upload("image/png", pngContent)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "storage is unavailable")
+	list, err := f.attachments.ListByCallID(ctx, f.call.ID)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	f.store.putErr = nil
+	svc := NewAttachmentService(failingAttachmentRepository{f.attachments}, f.calls, f.store, AttachmentConfig{})
+	_, err = svc.UploadAttachment(ctx, f.call.ID, f.call.UserID, &AttachmentUpload{
+		Size:    int64(len(pngContent)),
+		Content: bytes.NewReader(pngContent),
+	})
+	require.Error(t, err)
+	assert.Zero(t, f.store.len())
+}
+
+// Тест удаления заявки: DeleteCall удаляет содержимое и описания ее вложений
+func TestDeleteCall_RemovesAttachments(t *testing.T) {
+	f := newAttachmentFixture(t, AttachmentConfig{})
+	ctx := context.Background()
+	calls := NewCallService(f.calls, WithAttachments(f.svc))
+
+	attachment, err := f.upload("image/png", pngContent)
+	require.NoError(t, err)
+	require.Equal(t, 1, f.store.len())
+
+	require.NoError(t, calls.DeleteCall(ctx, f.call.ID, f.call.UserID))
+	assert.Zero(t, f.store.len())
+	_, err = f.attachments.GetByID(ctx, attachment.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
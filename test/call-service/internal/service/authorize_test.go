@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"call-service/internal/model"
+	"call-service/internal/tenant"
+)
+
+// Тест матрицы доступа к заявке: для каждой роли пользователя проверяются все действия,
+// поэтому новое действие или правило без строки в матрице не пройдет тест
+func TestAuthorizeCallAccess(t *testing.T) {
+	ownerID, creatorID, strangerID := uuid.New(), uuid.New(), uuid.New()
+	orgID, otherOrgID := uuid.New(), uuid.New()
+	call := &model.Call{ID: uuid.New(), UserID: ownerID, CreatedBy: creatorID, OrgID: &orgID}
+	personal := &model.Call{ID: uuid.New(), UserID: ownerID, CreatedBy: ownerID}
+	member := func(org uuid.UUID, role string) *tenant.Membership {
+		return &tenant.Membership{OrgID: org, Role: role}
+	}
+
+	actions := []CallAction{CallRead, CallUpdate, CallDelete, CallAssign}
+	// Новое действие нужно добавить в actions и в каждую строку матрицы
+	assert.Equal(t, "unknown", CallAction(len(actions)).String())
+	tests := []struct {
+		name      string
+		principal Principal
+		call      *model.Call
+		// allowed — разрешено ли действие, по порядку actions: чтение, изменение, удаление, передача
+		allowed [4]bool
+	}{
+		{"администратор", adminPrincipal(strangerID), call, [4]bool{true, true, true, true}},
+		{"администратор, личная заявка", adminPrincipal(strangerID), personal, [4]bool{true, true, true, true}},
+		{"владелец", Principal{UserID: ownerID}, call, [4]bool{true, true, true, false}},
+		{"владелец, участник организации", Principal{UserID: ownerID, Membership: member(orgID, tenant.RoleMember)}, call, [4]bool{true, true, true, false}},
+		{"владелец, личная заявка", Principal{UserID: ownerID, Membership: member(orgID, tenant.RoleOwner)}, personal, [4]bool{true, true, true, false}},
+		{"создатель, заявка передана", Principal{UserID: creatorID}, call, [4]bool{false, false, false, false}},
+		{"создатель, участник организации", Principal{UserID: creatorID, Membership: member(orgID, tenant.RoleMember)}, call, [4]bool{true, true, true, false}},
+		{"создатель, участник другой организации", Principal{UserID: creatorID, Membership: member(otherOrgID, tenant.RoleMember)}, call, [4]bool{false, false, false, false}},
+		{"участник организации", Principal{UserID: strangerID, Membership: member(orgID, tenant.RoleMember)}, call, [4]bool{true, true, false, false}},
+		{"владелец организации", Principal{UserID: strangerID, Membership: member(orgID, tenant.RoleOwner)}, call, [4]bool{true, true, true, false}},
+		{"владелец организации, личная заявка", Principal{UserID: strangerID, Membership: member(orgID, tenant.RoleOwner)}, personal, [4]bool{false, false, false, false}},
+		{"участник другой организации", Principal{UserID: strangerID, Membership: member(otherOrgID, tenant.RoleMember)}, call, [4]bool{false, false, false, false}},
+		{"владелец другой организации", Principal{UserID: strangerID, Membership: member(otherOrgID, tenant.RoleOwner)}, call, [4]bool{false, false, false, false}},
+		{"посторонний", Principal{UserID: strangerID}, call, [4]bool{false, false, false, false}},
+	}
+	for _, tt := range tests {
+		for i, action := range actions {
+			t.Run(tt.name+"/"+action.String(), func(t *testing.T) {
+				err := authorizeCallAccess(tt.principal, tt.call, action)
+				if tt.allowed[i] {
+					assert.NoError(t, err)
+				} else {
+					assert.ErrorIs(t, err, ErrForbidden)
+				}
+			})
+		}
+	}
+
+	// Неизвестное действие запрещено всем, кроме администратора
+	assert.ErrorIs(t, authorizeCallAccess(Principal{UserID: ownerID}, call, CallAction(100)), ErrForbidden)
+}
+
+// Тест пользователя запроса: членство в организации берется из контекста
+func TestUserPrincipal(t *testing.T) {
+	userID, orgID := uuid.New(), uuid.New()
+	assert.Equal(t, Principal{UserID: userID}, userPrincipal(context.Background(), userID))
+
+	ctx := tenant.WithMembership(context.Background(), tenant.Membership{OrgID: orgID, Role: tenant.RoleOwner})
+	assert.Equal(t, Principal{UserID: userID, Membership: &tenant.Membership{OrgID: orgID, Role: tenant.RoleOwner}}, userPrincipal(ctx, userID))
+}
@@ -1,124 +1,952 @@
-package service
-
-import (
-	"context"
-	"errors"
-	"regexp"
-
-	"github.com/google/uuid"
-
-	"call-service/internal/model"
-	"call-service/internal/repository"
-)
-
-// Константы ошибок для сервисного слоя
-
-var (
-	ErrInvalidPhoneNumber = errors.New("invalid phone number format")
-	ErrCallNotFound       = errors.New("call not found")
-	ErrForbidden          = errors.New("forbidden")
-	ErrInvalidStatus      = errors.New("invalid status")
-)
-
-// Регулярное выражение для валидации номера телефона
-
-var validPhoneRegex = regexp.MustCompile(`^[0-9+\-]+$`)
-
-// CallService определяет интерфейс сервиса для работы с заявками
-
-type CallService interface {
-	CreateCall(ctx context.Context, req *model.CreateCallRequest, userID uuid.UUID) (*model.Call, error)
-	GetCallByID(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*model.Call, error)
-	GetAllCalls(ctx context.Context, userID uuid.UUID) ([]*model.Call, error)
-	UpdateCallStatus(ctx context.Context, id uuid.UUID, status string, userID uuid.UUID) error
-	DeleteCall(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
-}
-
-// callService реализует интерфейс CallService
-
-type callService struct {
-	callRepo repository.CallRepository
-}
-
-// NewCallService создает новый экземпляр сервиса
-
-func NewCallService(callRepo repository.CallRepository) CallService {
-	return &callService{callRepo: callRepo}
-}
-
-// CreateCall создает новую заявку
-
-func (s *callService) CreateCall(ctx context.Context, req *model.CreateCallRequest, userID uuid.UUID) (*model.Call, error) {
-	if !validPhoneRegex.MatchString(req.PhoneNumber) {
-		return nil, ErrInvalidPhoneNumber
-	}
-
-	call := &model.Call{
-		ClientName:  req.ClientName,
-		PhoneNumber: req.PhoneNumber,
-		Description: req.Description,
-		Status:      "открыта",
-		UserID:      userID,
-	}
-
-	if err := s.callRepo.Create(ctx, call); err != nil {
-		return nil, err
-	}
-
-	return call, nil
-}
-
-// GetCallByID получает информацию о заявке по её ID
-
-func (s *callService) GetCallByID(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*model.Call, error) {
-	call, err := s.callRepo.GetByID(ctx, id)
-	if err != nil {
-		return nil, ErrCallNotFound
-	}
-
-	if call.UserID != userID {
-		return nil, ErrForbidden
-	}
-
-	return call, nil
-}
-
-// GetAllCalls получает список всех заявок пользователя
-
-func (s *callService) GetAllCalls(ctx context.Context, userID uuid.UUID) ([]*model.Call, error) {
-	return s.callRepo.GetAllByUserID(ctx, userID)
-}
-
-// UpdateCallStatus обновляет статус заявки
-
-func (s *callService) UpdateCallStatus(ctx context.Context, id uuid.UUID, status string, userID uuid.UUID) error {
-	if status != "открыта" && status != "закрыта" {
-		return ErrInvalidStatus
-	}
-
-	call, err := s.callRepo.GetByID(ctx, id)
-	if err != nil {
-		return ErrCallNotFound
-	}
-
-	if call.UserID != userID {
-		return ErrForbidden
-	}
-
-	return s.callRepo.UpdateStatus(ctx, id, status)
-}
-
-// DeleteCall удаляет заявку
-
-func (s *callService) DeleteCall(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
-	call, err := s.callRepo.GetByID(ctx, id)
-	if err != nil {
-		return ErrCallNotFound
-	}
-
-	if call.UserID != userID {
-		return ErrForbidden
-	}
-
-	return s.callRepo.Delete(ctx, id)
-}
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/events"
+	"call-service/internal/featureflags"
+	"call-service/internal/impersonation"
+	"call-service/internal/model"
+	"call-service/internal/notify"
+	"call-service/internal/repository"
+	"call-service/internal/telephony"
+	"call-service/internal/tenant"
+	"common/clock"
+)
+
+// Константы ошибок для сервисного слоя
+
+var (
+	ErrInvalidPhoneNumber = errors.New("invalid phone number format")
+	// ErrDescriptionTooLong возвращается, если описание длиннее model.MaxDescriptionLength
+	// символов и не задан CreateCallRequest.Truncate.
+	ErrDescriptionTooLong = errors.New("description is too long")
+	ErrCallNotFound       = errors.New("call not found")
+	ErrForbidden          = errors.New("forbidden")
+	ErrInvalidStatus      = errors.New("invalid status")
+	ErrCallbackInPast     = errors.New("callback time must be in the future")
+	ErrCallClosed         = errors.New("call is closed")
+	ErrDialingDisabled    = errors.New("outbound calls are not configured")
+)
+
+// DialError — отказ или недоступность провайдера телефонии при исходящем звонке.
+// Message можно передать клиенту API: оно не содержит номера телефона и внутренних адресов.
+
+type DialError struct {
+	Message string
+	Err     error
+}
+
+func (e *DialError) Error() string {
+	return "dial call: " + e.Err.Error()
+}
+
+func (e *DialError) Unwrap() error {
+	return e.Err
+}
+
+// RowError описывает ошибку валидации отдельной строки при пакетном создании заявок.
+// Index — номер строки во входных данных, начиная с 0.
+
+type RowError struct {
+	Index int
+	Err   error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Index, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// NotFoundError — отсутствие записи Resource с идентификатором ID. Текст ошибки содержит
+// идентификатор для журнала; клиенту API передается только код ошибки. Ошибка заявки
+// удовлетворяет errors.Is(err, ErrCallNotFound).
+
+type NotFoundError struct {
+	Resource string
+	ID       uuid.UUID
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %s not found", e.Resource, e.ID)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrCallNotFound && e.Resource == "call"
+}
+
+// closeStaleBatchSize — число заявок, закрываемых одним запросом в CloseStaleCalls.
+// Ограничивает время блокировки строк и размер транзакции.
+
+const closeStaleBatchSize = 500
+
+// dueCallbacksBatchSize — максимальное число уведомлений о повторных звонках за один вызов
+// NotifyDueCallbacks; остальные заявки обрабатываются следующим вызовом.
+
+const dueCallbacksBatchSize = 100
+
+// DefaultStreamCallsBatch — размер пакета StreamCalls, если он не задан.
+
+const DefaultStreamCallsBatch = 100
+
+// DefaultDialTimeout — ограничение времени вызова провайдера телефонии по умолчанию.
+
+const DefaultDialTimeout = 10 * time.Second
+
+// callRefAttempts — число попыток сохранить заявку с новым кодом, если код совпал с кодом
+// существующей заявки. При 2^40 кодах совпадение маловероятно, и попыток хватает с запасом.
+
+const callRefAttempts = 5
+
+// Регулярное выражение для валидации номера телефона
+
+var validPhoneRegex = regexp.MustCompile(`^[0-9+\-]+$`)
+
+// CallService определяет интерфейс сервиса для работы с заявками. Списки, выгрузки и ленты
+// возвращаются в полностью определенном порядке: записи с равными значениями полей сортировки
+// упорядочиваются по ID, поэтому страницы по смещению и по позиции не пересекаются
+
+type CallService interface {
+	CreateCall(ctx context.Context, req *model.CreateCallRequest, userID uuid.UUID) (*model.Call, error)
+	CreateCalls(ctx context.Context, reqs []*model.CreateCallRequest, userID uuid.UUID) ([]*model.Call, error)
+	GetCallByID(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*model.Call, error)
+	GetAllCalls(ctx context.Context, userID uuid.UUID, filter model.CallFilter) ([]*model.Call, int, error)
+	ForEachCall(ctx context.Context, userID uuid.UUID, filter model.CallFilter, fn func(*model.Call) error) error
+	// StreamCalls передает в fn заявки GetAllCalls без пагинации пакетами по batchSize, читая
+	// каждый пакет отдельным запросом после обработки предыдущего.
+	StreamCalls(ctx context.Context, userID uuid.UUID, filter model.CallFilter, batchSize int, fn func([]*model.Call) error) error
+	ForEachStatusChange(ctx context.Context, userID uuid.UUID, fn func(*model.CallStatusChange) error) error
+	UpdateCallStatus(ctx context.Context, id uuid.UUID, status model.CallStatus, userID uuid.UUID) error
+	DeleteCall(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+	UpdateCallback(ctx context.Context, id uuid.UUID, callbackAt *time.Time, userID uuid.UUID) error
+	GetDueCalls(ctx context.Context, userID uuid.UUID, filter model.CallFilter) ([]*model.Call, int, error)
+	DialCall(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*model.CallStatusChange, error)
+	GetCallActivity(ctx context.Context, id uuid.UUID, userID uuid.UUID, after *model.ActivityCursor, limit int) ([]*model.CallActivity, *model.ActivityCursor, error)
+	// GetCallByRef возвращает заявку с кодом ref (model.Call.Ref) без проверки владельца —
+	// для проверки статуса клиентом без учетной записи. Код нормализуется вызывающим
+	// (model.NormalizeCallRef); отсутствующая заявка — ErrCallNotFound.
+	GetCallByRef(ctx context.Context, ref string) (*model.Call, error)
+
+	// Методы администратора работают с заявками всех пользователей без проверки владельца
+
+	ListCallsAdmin(ctx context.Context, filter model.CallFilter) ([]*model.Call, int, error)
+	GetCallByIDAdmin(ctx context.Context, id uuid.UUID) (*model.Call, error)
+	UpdateCallStatusAdmin(ctx context.Context, id uuid.UUID, status model.CallStatus, actorID uuid.UUID) error
+	ReassignCall(ctx context.Context, id uuid.UUID, userID uuid.UUID, actorID uuid.UUID) error
+
+	// Методы фоновых задач выполняются от имени model.SystemActorID
+
+	CloseStaleCalls(ctx context.Context, olderThan time.Duration) (int, error)
+	NotifyDueCallbacks(ctx context.Context, notify func(context.Context, *model.Call) error) (int, error)
+}
+
+// callService реализует интерфейс CallService
+
+type callService struct {
+	callRepo    repository.CallRepository
+	clock       clock.Clock
+	users       *UserDirectory
+	dialer      telephony.Dialer
+	dialTimeout time.Duration
+	attachments AttachmentService
+	flags       featureflags.Flags
+	events      events.Publisher
+	notifier    notify.Notifier
+	invalidator CallCacheInvalidator
+}
+
+// Option задает необязательный параметр сервиса заявок.
+
+type Option func(*callService)
+
+// WithClock задает часы, по которым определяется время создания заявок.
+// По умолчанию используется системное время.
+
+func WithClock(c clock.Clock) Option {
+	return func(s *callService) {
+		s.clock = c
+	}
+}
+
+// WithUserDirectory включает заполнение имен пользователей CreatedByName и UpdatedByName
+// в заявках, которые возвращают методы чтения. По умолчанию имена не заполняются.
+
+func WithUserDirectory(d *UserDirectory) Option {
+	return func(s *callService) {
+		s.users = d
+	}
+}
+
+// WithDialer включает исходящие звонки клиентам заявок через провайдера телефонии dialer.
+// Время вызова провайдера ограничено timeout (0 — DefaultDialTimeout). По умолчанию звонки
+// отключены и DialCall возвращает ErrDialingDisabled.
+
+func WithDialer(dialer telephony.Dialer, timeout time.Duration) Option {
+	return func(s *callService) {
+		s.dialer = dialer
+		s.dialTimeout = timeout
+	}
+}
+
+// WithAttachments включает удаление вложений заявки вместе с заявкой в DeleteCall.
+// Без него вложения удаляются только каскадом в базе данных, а их содержимое остается
+// в хранилище.
+
+func WithAttachments(attachments AttachmentService) Option {
+	return func(s *callService) {
+		s.attachments = attachments
+	}
+}
+
+// WithFeatureFlags задает флаги постепенного включения поведения: флаг
+// featureflags.ResolveUsernames включает имена пользователей, заданные WithUserDirectory,
+// для пользователя запроса. По умолчанию используются значения флагов по умолчанию.
+
+func WithFeatureFlags(flags featureflags.Flags) Option {
+	return func(s *callService) {
+		s.flags = flags
+	}
+}
+
+// WithEvents включает публикацию событий о создании, изменении и удалении заявок
+// пользователями и администраторами. Закрытие устаревших заявок (CloseStaleCalls) событий
+// не публикует. По умолчанию события не публикуются.
+
+func WithEvents(publisher events.Publisher) Option {
+	return func(s *callService) {
+		s.events = publisher
+	}
+}
+
+// CallCacheInvalidator сбрасывает кэшированные ответы со списками заявок пользователей
+// и организации после изменения заявок.
+
+type CallCacheInvalidator interface {
+	InvalidateCalls(ctx context.Context, userIDs []uuid.UUID, orgID *uuid.UUID)
+}
+
+// WithCacheInvalidator включает сброс кэшированных ответов после изменений заявок, о которых
+// публикуются события (см. WithEvents): сбрасываются ответы тех же пользователей и организации,
+// которые получают событие. После закрытия устаревших заявок (CloseStaleCalls), о котором
+// события не публикуются, сбрасываются ответы владельцев и организаций закрытых заявок.
+
+func WithCacheInvalidator(invalidator CallCacheInvalidator) Option {
+	return func(s *callService) {
+		s.invalidator = invalidator
+	}
+}
+
+// WithNotifier включает уведомления пользователям (например, о передаче им заявки)
+// через notifier. Ошибки постановки уведомления в очередь записываются в журнал
+// и не влияют на результат операции.
+
+func WithNotifier(notifier notify.Notifier) Option {
+	return func(s *callService) {
+		s.notifier = notifier
+	}
+}
+
+// NewCallService создает новый экземпляр сервиса
+
+func NewCallService(callRepo repository.CallRepository, opts ...Option) CallService {
+	s := &callService{callRepo: callRepo, clock: clock.Real}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.dialTimeout <= 0 {
+		s.dialTimeout = DefaultDialTimeout
+	}
+	if s.flags == nil {
+		s.flags = featureflags.Static{}
+	}
+	return s
+}
+
+// CreateCall создает новую заявку
+
+func (s *callService) CreateCall(ctx context.Context, req *model.CreateCallRequest, userID uuid.UUID) (*model.Call, error) {
+	if !validPhoneRegex.MatchString(req.PhoneNumber) {
+		return nil, ErrInvalidPhoneNumber
+	}
+	if !validDescription(req) {
+		return nil, ErrDescriptionTooLong
+	}
+	if !s.validCallback(req.CallbackAt) {
+		return nil, ErrCallbackInPast
+	}
+
+	call := s.newCall(ctx, req, userID)
+
+	err := createWithUniqueRefs([]*model.Call{call}, func() error { return s.callRepo.Create(ctx, call) })
+	if err != nil {
+		return nil, fmt.Errorf("create call %s: %w", call.ID, err)
+	}
+
+	s.publish(ctx, events.CallCreated, call)
+	return call, nil
+}
+
+// CreateCalls создает несколько заявок одной операцией.
+// Все строки проверяются до обращения к базе данных: первая некорректная строка
+// возвращается как *RowError с ее индексом, и ни одна заявка не создается.
+
+func (s *callService) CreateCalls(ctx context.Context, reqs []*model.CreateCallRequest, userID uuid.UUID) ([]*model.Call, error) {
+	for i, req := range reqs {
+		if !validPhoneRegex.MatchString(req.PhoneNumber) {
+			return nil, &RowError{Index: i, Err: ErrInvalidPhoneNumber}
+		}
+		if !validDescription(req) {
+			return nil, &RowError{Index: i, Err: ErrDescriptionTooLong}
+		}
+		if !s.validCallback(req.CallbackAt) {
+			return nil, &RowError{Index: i, Err: ErrCallbackInPast}
+		}
+	}
+
+	calls := make([]*model.Call, len(reqs))
+	for i, req := range reqs {
+		calls[i] = s.newCall(ctx, req, userID)
+	}
+
+	if err := createWithUniqueRefs(calls, func() error { return s.callRepo.CreateMany(ctx, calls) }); err != nil {
+		return nil, fmt.Errorf("create %d calls: %w", len(calls), err)
+	}
+
+	for _, call := range calls {
+		s.publish(ctx, events.CallCreated, call)
+	}
+	return calls, nil
+}
+
+// GetCallByID получает информацию о заявке по её ID
+
+func (s *callService) GetCallByID(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*model.Call, error) {
+	call, err := s.callRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, lookupError("get", id, err)
+	}
+
+	if err := authorizeCallAccess(userPrincipal(ctx, userID), call, CallRead); err != nil {
+		return nil, err
+	}
+
+	s.resolveNames(ctx, call)
+	return call, nil
+}
+
+// GetAllCalls получает список заявок пользователя и его организации с учетом фильтра
+// и пагинации. Возвращает также общее количество заявок, удовлетворяющих фильтру.
+
+func (s *callService) GetAllCalls(ctx context.Context, userID uuid.UUID, filter model.CallFilter) ([]*model.Call, int, error) {
+	if filter.Status != "" && !filter.Status.Valid() {
+		return nil, 0, ErrInvalidStatus
+	}
+
+	filter.UserID = &userID
+	filter.OrgID = orgID(ctx)
+	return s.list(ctx, filter)
+}
+
+// ForEachCall последовательно передает в fn заявки пользователя, удовлетворяющие фильтру,
+// не загружая весь список в память. Используется для выгрузки больших наборов заявок.
+
+func (s *callService) ForEachCall(ctx context.Context, userID uuid.UUID, filter model.CallFilter, fn func(*model.Call) error) error {
+	if filter.Status != "" && !filter.Status.Valid() {
+		return ErrInvalidStatus
+	}
+
+	return s.callRepo.ForEachByUserID(ctx, userID, filter, fn)
+}
+
+// StreamCalls передает в fn заявки, доступные пользователю так же, как в GetAllCalls, пакетами
+// по batchSize в порядке сортировки фильтра; неположительный batchSize означает
+// DefaultStreamCallsBatch. Каждый пакет читается отдельным запросом, и следующий запрос
+// выполняется только после возврата из fn, поэтому между пакетами не удерживается курсор
+// базы данных, а в памяти находится не больше одного пакета. Последний пакет может быть
+// неполным; пустых пакетов fn не получает. Отмена ctx или ошибка fn прекращает чтение.
+// Limit и Offset фильтра не используются.
+
+func (s *callService) StreamCalls(ctx context.Context, userID uuid.UUID, filter model.CallFilter, batchSize int, fn func([]*model.Call) error) error {
+	if filter.Status != "" && !filter.Status.Valid() {
+		return ErrInvalidStatus
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultStreamCallsBatch
+	}
+
+	filter.OrgID = orgID(ctx)
+	filter.Limit = batchSize
+	for filter.Offset = 0; ; filter.Offset += batchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		batch := make([]*model.Call, 0, batchSize)
+		err := s.callRepo.ForEachByUserID(ctx, userID, filter, func(call *model.Call) error {
+			batch = append(batch, call)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(batch) > 0 {
+			s.resolveNames(ctx, batch...)
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+	}
+}
+
+// ForEachStatusChange последовательно передает в fn историю статусов заявок пользователя
+// и изменения, внесенные им в чужие заявки. Используется для выгрузки данных пользователя.
+
+func (s *callService) ForEachStatusChange(ctx context.Context, userID uuid.UUID, fn func(*model.CallStatusChange) error) error {
+	return s.callRepo.ForEachStatusChangeByUserID(ctx, userID, fn)
+}
+
+// UpdateCallStatus обновляет статус заявки
+
+func (s *callService) UpdateCallStatus(ctx context.Context, id uuid.UUID, status model.CallStatus, userID uuid.UUID) error {
+	if !status.Valid() {
+		return ErrInvalidStatus
+	}
+
+	call, err := s.callRepo.GetByID(repository.WithPrimary(ctx), id)
+	if err != nil {
+		return lookupError("get", id, err)
+	}
+
+	if err := authorizeCallAccess(userPrincipal(ctx, userID), call, CallUpdate); err != nil {
+		return err
+	}
+
+	if err := s.callRepo.UpdateStatus(ctx, id, status, userID); err != nil {
+		return lookupError("update status of", id, err)
+	}
+	s.publishUpdated(ctx, id)
+	return nil
+}
+
+// DeleteCall удаляет заявку вместе с ее вложениями. Удалить заявку может ее владелец,
+// а заявку организации также ее создатель и владелец организации (authorizeCallAccess).
+// Если вложения удалить не удалось, заявка не удаляется, чтобы удаление можно было
+// повторить.
+
+func (s *callService) DeleteCall(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	call, err := s.callRepo.GetByID(repository.WithPrimary(ctx), id)
+	if err != nil {
+		return lookupError("get", id, err)
+	}
+
+	if err := authorizeCallAccess(userPrincipal(ctx, userID), call, CallDelete); err != nil {
+		return err
+	}
+
+	if s.attachments != nil {
+		if err := s.attachments.DeleteCallAttachments(ctx, id); err != nil {
+			return fmt.Errorf("delete attachments of call %s: %w", id, err)
+		}
+	}
+
+	if err := s.callRepo.Delete(ctx, id); err != nil {
+		return lookupError("delete", id, err)
+	}
+	s.publish(ctx, events.CallDeleted, call)
+	return nil
+}
+
+// UpdateCallback задает время повторного звонка по заявке пользователя; nil снимает звонок.
+// Время должно быть в будущем; на закрытую или отмененную заявку звонок не назначается.
+
+func (s *callService) UpdateCallback(ctx context.Context, id uuid.UUID, callbackAt *time.Time, userID uuid.UUID) error {
+	if !s.validCallback(callbackAt) {
+		return ErrCallbackInPast
+	}
+
+	call, err := s.callRepo.GetByID(repository.WithPrimary(ctx), id)
+	if err != nil {
+		return lookupError("get", id, err)
+	}
+
+	if err := authorizeCallAccess(userPrincipal(ctx, userID), call, CallUpdate); err != nil {
+		return err
+	}
+	if callbackAt != nil && call.Status.Final() {
+		return ErrCallClosed
+	}
+
+	if err := s.callRepo.SetCallback(ctx, id, normalizeCallback(callbackAt), userID); err != nil {
+		return lookupError("set callback of", id, err)
+	}
+	s.publishUpdated(ctx, id)
+	return nil
+}
+
+// GetDueCalls получает незакрытые заявки пользователя и его организации, время повторного
+// звонка которых наступило
+
+func (s *callService) GetDueCalls(ctx context.Context, userID uuid.UUID, filter model.CallFilter) ([]*model.Call, int, error) {
+	if filter.Status != "" && !filter.Status.Valid() {
+		return nil, 0, ErrInvalidStatus
+	}
+
+	now := s.clock.Now()
+	filter.UserID = &userID
+	filter.OrgID = orgID(ctx)
+	filter.CallbackDue = &now
+	return s.list(ctx, filter)
+}
+
+// DialCall начинает исходящий звонок клиенту незакрытой заявки пользователя и записывает
+// попытку в историю статусов с ссылкой провайдера на звонок. Ошибка провайдера возвращается
+// как *DialError. Вызов провайдера не прерывается отменой ctx (например, если клиент закрыл
+// соединение), чтобы начатый звонок всегда попадал в историю; его время ограничено dialTimeout.
+
+func (s *callService) DialCall(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*model.CallStatusChange, error) {
+	if s.dialer == nil {
+		return nil, ErrDialingDisabled
+	}
+
+	call, err := s.callRepo.GetByID(repository.WithPrimary(ctx), id)
+	if err != nil {
+		return nil, lookupError("get", id, err)
+	}
+
+	if err := authorizeCallAccess(userPrincipal(ctx, userID), call, CallUpdate); err != nil {
+		return nil, err
+	}
+	if call.Status.Final() {
+		return nil, ErrCallClosed
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	dialCtx, cancel := context.WithTimeout(ctx, s.dialTimeout)
+	defer cancel()
+	trackingID := uuid.New()
+	reference, err := s.dialer.Dial(dialCtx, telephony.DialRequest{
+		TrackingID:  trackingID,
+		CallID:      id,
+		PhoneNumber: call.PhoneNumber,
+		UserID:      userID,
+	})
+	if err != nil {
+		return nil, &DialError{Message: telephony.SafeMessage(err, call.PhoneNumber), Err: err}
+	}
+
+	attempt := &model.CallStatusChange{
+		CallID:         id,
+		OldStatus:      call.Status,
+		NewStatus:      call.Status,
+		ChangedBy:      userID,
+		ChangedAt:      s.clock.Now().UTC().Truncate(time.Microsecond),
+		DialID:         &trackingID,
+		DialReference:  reference,
+		ImpersonatedBy: impersonation.Impersonator(ctx),
+	}
+	if err := s.callRepo.AddDialAttempt(ctx, attempt); err != nil {
+		return nil, lookupError(fmt.Sprintf("record dial attempt %s with provider reference %q for", trackingID, reference), id, err)
+	}
+	return attempt, nil
+}
+
+// GetCallActivity получает до limit записей ленты действий с заявкой после позиции after
+// (nil — с начала ленты) и позицию следующей страницы или nil, если страница последняя.
+// Ленту может читать тот же пользователь, что и саму заявку (см. GetCallByID).
+
+func (s *callService) GetCallActivity(ctx context.Context, id uuid.UUID, userID uuid.UUID, after *model.ActivityCursor, limit int) ([]*model.CallActivity, *model.ActivityCursor, error) {
+	call, err := s.callRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, lookupError("get", id, err)
+	}
+
+	if err := authorizeCallAccess(userPrincipal(ctx, userID), call, CallRead); err != nil {
+		return nil, nil, err
+	}
+
+	// Лишняя запись показывает, есть ли следующая страница
+	activity, err := s.callRepo.ListActivity(ctx, id, after, limit+1)
+	if err != nil {
+		return nil, nil, lookupError("list activity of", id, err)
+	}
+	var next *model.ActivityCursor
+	if len(activity) > limit {
+		activity = activity[:limit]
+		cursor := activity[limit-1].Cursor()
+		next = &cursor
+	}
+	s.resolveActivityNames(ctx, activity)
+	return activity, next, nil
+}
+
+// GetCallByRef получает заявку по её коду без проверки владельца
+
+func (s *callService) GetCallByRef(ctx context.Context, ref string) (*model.Call, error) {
+	call, err := s.callRepo.GetByRef(ctx, ref)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCallNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get call by ref: %w", err)
+	}
+	return call, nil
+}
+
+// ListCallsAdmin получает заявки всех пользователей с учетом фильтра и пагинации
+
+func (s *callService) ListCallsAdmin(ctx context.Context, filter model.CallFilter) ([]*model.Call, int, error) {
+	if filter.Status != "" && !filter.Status.Valid() {
+		return nil, 0, ErrInvalidStatus
+	}
+
+	return s.list(ctx, filter)
+}
+
+// GetCallByIDAdmin получает заявку по её ID без проверки владельца
+
+func (s *callService) GetCallByIDAdmin(ctx context.Context, id uuid.UUID) (*model.Call, error) {
+	call, err := s.callRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, lookupError("get", id, err)
+	}
+	if err := authorizeCallAccess(adminPrincipal(uuid.Nil), call, CallRead); err != nil {
+		return nil, err
+	}
+
+	s.resolveNames(ctx, call)
+	return call, nil
+}
+
+// UpdateCallStatusAdmin принудительно обновляет статус заявки без проверки владельца.
+// actorID — администратор, выполняющий изменение.
+
+func (s *callService) UpdateCallStatusAdmin(ctx context.Context, id uuid.UUID, status model.CallStatus, actorID uuid.UUID) error {
+	if !status.Valid() {
+		return ErrInvalidStatus
+	}
+
+	call, err := s.callRepo.GetByID(repository.WithPrimary(ctx), id)
+	if err != nil {
+		return lookupError("get", id, err)
+	}
+	if err := authorizeCallAccess(adminPrincipal(actorID), call, CallUpdate); err != nil {
+		return err
+	}
+
+	if err := s.callRepo.UpdateStatus(ctx, id, status, actorID); err != nil {
+		return lookupError("update status of", id, err)
+	}
+	s.publishUpdated(ctx, id)
+	return nil
+}
+
+// ReassignCall передает заявку другому пользователю. actorID — администратор,
+// выполняющий передачу.
+
+func (s *callService) ReassignCall(ctx context.Context, id uuid.UUID, userID uuid.UUID, actorID uuid.UUID) error {
+	call, err := s.callRepo.GetByID(repository.WithPrimary(ctx), id)
+	if err != nil {
+		return lookupError("get", id, err)
+	}
+	if err := authorizeCallAccess(adminPrincipal(actorID), call, CallAssign); err != nil {
+		return err
+	}
+
+	if err := s.callRepo.Reassign(ctx, id, userID, actorID); err != nil {
+		return lookupError("reassign", id, err)
+	}
+	// Прежний владелец получает событие, чтобы убрать заявку из своего списка
+	s.publishUpdated(ctx, id, call.UserID)
+	// Новый владелец получает уведомление, если заявку передал ему кто-то другой
+	if s.notifier != nil && userID != call.UserID && userID != actorID {
+		if err := s.notifier.Send(ctx, userID, notify.CallAssignedNotification(call)); err != nil {
+			log.Printf("failed to notify user %s about assigned call %s: %v", userID, id, err)
+		}
+	}
+	return nil
+}
+
+// CloseStaleCalls закрывает открытые заявки, созданные раньше чем olderThan назад,
+// пакетами по closeStaleBatchSize и возвращает число закрытых заявок. Изменения
+// записываются в историю статусов от имени model.SystemActorID. При отмене ctx
+// закрытие прекращается после текущего пакета; уже закрытые заявки остаются закрытыми.
+
+func (s *callService) CloseStaleCalls(ctx context.Context, olderThan time.Duration) (int, error) {
+	if olderThan <= 0 {
+		return 0, fmt.Errorf("stale call threshold must be positive, got %s", olderThan)
+	}
+	before := s.clock.Now().Add(-olderThan)
+
+	total := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		closed, err := s.callRepo.CloseStale(ctx, before, closeStaleBatchSize, model.SystemActorID)
+		total += len(closed)
+		invalidateCalls(ctx, s.invalidator, closed)
+		if err != nil {
+			return total, err
+		}
+		if len(closed) < closeStaleBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// NotifyDueCallbacks передает в notify заявки, время повторного звонка которых наступило,
+// и отмечает отправленные уведомления; возвращает число отправленных уведомлений.
+// За один вызов обрабатывается до dueCallbacksBatchSize заявок. Уведомление, которое не удалось
+// отправить, не отмечается и повторяется при следующем вызове; ошибки возвращаются вместе.
+
+func (s *callService) NotifyDueCallbacks(ctx context.Context, notify func(context.Context, *model.Call) error) (int, error) {
+	calls, err := s.callRepo.ListDueCallbacks(ctx, s.clock.Now(), dueCallbacksBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	notified := 0
+	var errs []error
+	for _, call := range calls {
+		if err := ctx.Err(); err != nil {
+			return notified, err
+		}
+		if err := notify(ctx, call); err != nil {
+			errs = append(errs, fmt.Errorf("notify callback of call %s: %w", call.ID, err))
+			continue
+		}
+		if err := s.callRepo.MarkCallbackNotified(ctx, call.ID, *call.CallbackAt, s.clock.Now()); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		notified++
+	}
+	return notified, errors.Join(errs...)
+}
+
+// list получает страницу заявок, при IncludeSummary — со сводкой по истории, и заполняет
+// в них имена пользователей.
+
+func (s *callService) list(ctx context.Context, filter model.CallFilter) ([]*model.Call, int, error) {
+	list := s.callRepo.List
+	if filter.IncludeSummary {
+		list = s.callRepo.ListWithSummary
+	}
+	calls, total, err := list(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.resolveNames(ctx, calls...)
+	return calls, total, nil
+}
+
+// resolveNames заполняет CreatedByName и UpdatedByName одним запросом к справочнику
+// на все заявки, если справочник задан и включен флаг featureflags.ResolveUsernames.
+
+func (s *callService) resolveNames(ctx context.Context, calls ...*model.Call) {
+	if s.users == nil || len(calls) == 0 || !s.flags.Enabled(ctx, featureflags.ResolveUsernames) {
+		return
+	}
+	ids := make([]uuid.UUID, 0, 2*len(calls))
+	for _, call := range calls {
+		ids = append(ids, call.CreatedBy)
+		if call.UpdatedBy != nil {
+			ids = append(ids, *call.UpdatedBy)
+		}
+	}
+	names := s.users.Usernames(ctx, ids)
+	for _, call := range calls {
+		call.CreatedByName = names[call.CreatedBy]
+		if call.UpdatedBy != nil {
+			call.UpdatedByName = names[*call.UpdatedBy]
+		}
+	}
+}
+
+// resolveActivityNames заполняет имена авторов и участников передач в записях ленты действий
+// одним запросом к справочнику, при тех же условиях, что и resolveNames.
+
+func (s *callService) resolveActivityNames(ctx context.Context, activity []*model.CallActivity) {
+	if s.users == nil || len(activity) == 0 || !s.flags.Enabled(ctx, featureflags.ResolveUsernames) {
+		return
+	}
+	ids := make([]uuid.UUID, 0, len(activity))
+	for _, item := range activity {
+		ids = append(ids, item.ActorID)
+		if item.OldUserID != nil && item.NewUserID != nil {
+			ids = append(ids, *item.OldUserID, *item.NewUserID)
+		}
+	}
+	names := s.users.Usernames(ctx, ids)
+	for _, item := range activity {
+		item.ActorName = names[item.ActorID]
+		if item.OldUserID != nil && item.NewUserID != nil {
+			item.OldUserName, item.NewUserName = names[*item.OldUserID], names[*item.NewUserID]
+		}
+	}
+}
+
+// lookupError преобразует отсутствие заявки id в репозитории в *NotFoundError.
+// Остальные ошибки (недоступность базы данных, истечение времени запроса) дополняются
+// операцией op и идентификатором заявки и остаются доступны через errors.Is, чтобы клиент
+// получил 5xx, а не 404.
+
+func lookupError(op string, id uuid.UUID, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, repository.ErrNotFound):
+		return &NotFoundError{Resource: "call", ID: id}
+	}
+	return fmt.Errorf("%s call %s: %w", op, id, err)
+}
+
+// publish передает событие kind о заявке call ее владельцу, участникам ее организации
+// и пользователям users, если включена публикация событий (WithEvents), и сбрасывает
+// их кэшированные ответы (WithCacheInvalidator).
+
+func (s *callService) publish(ctx context.Context, kind string, call *model.Call, users ...uuid.UUID) {
+	userIDs := append([]uuid.UUID{call.UserID}, users...)
+	if s.invalidator != nil {
+		s.invalidator.InvalidateCalls(ctx, userIDs, call.OrgID)
+	}
+	if s.events == nil {
+		return
+	}
+	e := events.Event{
+		Type:    kind,
+		CallID:  call.ID,
+		At:      s.clock.Now(),
+		UserIDs: userIDs,
+		OrgID:   call.OrgID,
+	}
+	if kind != events.CallDeleted {
+		e.Call = call
+	}
+	s.events.Publish(e)
+}
+
+// invalidateCalls сбрасывает в invalidator кэшированные ответы владельцев и организаций
+// заявок calls; ничего не делает, если invalidator равен nil или заявок нет.
+
+func invalidateCalls(ctx context.Context, invalidator CallCacheInvalidator, calls []*model.Call) {
+	if invalidator == nil || len(calls) == 0 {
+		return
+	}
+	users := make([]uuid.UUID, 0, len(calls))
+	seenUsers := make(map[uuid.UUID]bool, len(calls))
+	seenOrgs := make(map[uuid.UUID]bool)
+	for _, call := range calls {
+		if !seenUsers[call.UserID] {
+			seenUsers[call.UserID] = true
+			users = append(users, call.UserID)
+		}
+		if call.OrgID != nil && !seenOrgs[*call.OrgID] {
+			seenOrgs[*call.OrgID] = true
+			invalidator.InvalidateCalls(ctx, nil, call.OrgID)
+		}
+	}
+	invalidator.InvalidateCalls(ctx, users, nil)
+}
+
+// publishUpdated публикует событие об изменении заявки id с ее состоянием после изменения.
+// Если заявку не удалось прочитать, событие не публикуется: изменение уже сохранено,
+// а клиенты получат актуальное состояние при следующем событии или запросе.
+
+func (s *callService) publishUpdated(ctx context.Context, id uuid.UUID, users ...uuid.UUID) {
+	if s.events == nil && s.invalidator == nil {
+		return
+	}
+	call, err := s.callRepo.GetByID(repository.WithPrimary(ctx), id)
+	if err != nil {
+		log.Printf("failed to read call %s for update event: %v", id, err)
+		return
+	}
+	s.publish(ctx, events.CallUpdated, call, users...)
+}
+
+// orgID возвращает организацию пользователя запроса или nil, если он не состоит в организации.
+
+func orgID(ctx context.Context) *uuid.UUID {
+	m, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return &m.OrgID
+}
+
+// newCall создает новую открытую заявку пользователя по данным запроса; пользователь
+// становится и владельцем, и автором заявки, а его организация — организацией заявки.
+// Время создания берется из часов сервиса с точностью PostgreSQL (микросекунды),
+// а не из значения по умолчанию базы данных. Слишком длинное описание обрезается: без
+// req.Truncate такой запрос отклоняется раньше (validDescription).
+
+func (s *callService) newCall(ctx context.Context, req *model.CreateCallRequest, userID uuid.UUID) *model.Call {
+	description, truncated := model.TruncateText(req.Description, model.MaxDescriptionLength)
+	return &model.Call{
+		ID:          model.NewID(),
+		ClientName:  req.ClientName,
+		PhoneNumber: req.PhoneNumber,
+		Description: description,
+		Truncated:   truncated,
+		Status:      model.CallStatusOpen,
+		CreatedAt:   s.clock.Now().UTC().Truncate(time.Microsecond),
+		UserID:      userID,
+		OrgID:       orgID(ctx),
+		CreatedBy:   userID,
+		CallbackAt:  normalizeCallback(req.CallbackAt),
+		Ref:         model.NewCallRef(),
+	}
+}
+
+// createWithUniqueRefs сохраняет заявки calls функцией create. Если код одной из заявок
+// совпал с кодом существующей, заявкам назначаются новые коды и сохранение повторяется,
+// всего не больше callRefAttempts раз.
+
+func createWithUniqueRefs(calls []*model.Call, create func() error) error {
+	err := create()
+	for attempt := 1; errors.Is(err, repository.ErrAlreadyExists) && attempt < callRefAttempts; attempt++ {
+		log.Printf("call ref collision, retrying with new refs (attempt %d)", attempt+1)
+		for _, call := range calls {
+			call.Ref = model.NewCallRef()
+		}
+		err = create()
+	}
+	return err
+}
+
+// validDescription проверяет, что описание не длиннее model.MaxDescriptionLength символов
+// или будет обрезано (req.Truncate).
+
+func validDescription(req *model.CreateCallRequest) bool {
+	return req.Truncate || utf8.RuneCountInString(req.Description) <= model.MaxDescriptionLength
+}
+
+// validCallback проверяет, что время повторного звонка не задано или находится в будущем.
+
+func (s *callService) validCallback(callbackAt *time.Time) bool {
+	return callbackAt == nil || callbackAt.After(s.clock.Now())
+}
+
+// normalizeCallback приводит время повторного звонка к UTC с точностью PostgreSQL (микросекунды).
+
+func normalizeCallback(callbackAt *time.Time) *time.Time {
+	if callbackAt == nil {
+		return nil
+	}
+	t := callbackAt.UTC().Truncate(time.Microsecond)
+	return &t
+}
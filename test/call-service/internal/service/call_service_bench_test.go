@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/model"
+	"call-service/internal/repository"
+)
+
+// Бенчмарки сервисного слоя используют in-memory репозиторий и не требуют PostgreSQL:
+// они показывают накладные расходы валидации и хранения без учета базы данных.
+// go test -bench . ./internal/service
+
+var benchCallRequest = &model.CreateCallRequest{
+	ClientName:  "Иван Иванов",
+	PhoneNumber: "+79990000000",
+	Description: "Заявка для бенчмарка",
+}
+
+func BenchmarkCreateCall(b *testing.B) {
+	svc := NewCallService(repository.NewInMemoryCallRepository())
+	userID := uuid.New()
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := svc.CreateCall(ctx, benchCallRequest, userID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCreateCallParallel(b *testing.B) {
+	svc := NewCallService(repository.NewInMemoryCallRepository())
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		userID := uuid.New()
+		for pb.Next() {
+			if _, err := svc.CreateCall(ctx, benchCallRequest, userID); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
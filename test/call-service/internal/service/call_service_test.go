@@ -0,0 +1,665 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"call-service/internal/events"
+	"call-service/internal/mocks"
+	"call-service/internal/model"
+	"call-service/internal/notify"
+	"call-service/internal/repository"
+	"call-service/internal/telephony"
+	"call-service/internal/tenant"
+	"common/clock"
+)
+
+// Тест пакетного создания: все заявки передаются в репозиторий одним вызовом
+func TestCreateCalls(t *testing.T) {
+	repo := mocks.NewMockCallRepository(gomock.NewController(t))
+	svc := NewCallService(repo)
+	userID := uuid.New()
+
+	reqs := []*model.CreateCallRequest{
+		{ClientName: "Иван", PhoneNumber: "+79990000001", Description: "Первая"},
+		{ClientName: "Петр", PhoneNumber: "+79990000002", Description: "Вторая"},
+	}
+	repo.EXPECT().CreateMany(gomock.Any(), gomock.Cond(func(calls []*model.Call) bool {
+		return len(calls) == 2 && calls[1].PhoneNumber == "+79990000002"
+	})).Return(nil)
+
+	calls, err := svc.CreateCalls(context.Background(), reqs, userID)
+
+	require.NoError(t, err)
+	require.Len(t, calls, 2)
+	for _, call := range calls {
+		assert.Equal(t, userID, call.UserID)
+		assert.Equal(t, model.CallStatusOpen, call.Status)
+	}
+}
+
+// Тест ID заявок: ID назначается сервисом до обращения к репозиторию, это UUID версии 7,
+// и ID созданных позже заявок больше, в том числе внутри одной миллисекунды
+func TestCreateCall_TimeOrderedIDs(t *testing.T) {
+	repo := mocks.NewMockCallRepository(gomock.NewController(t))
+	svc := NewCallService(repo)
+	req := &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001", Description: "Первая"}
+	hasID := func(call *model.Call) bool { return call.ID.Version() == 7 }
+	repo.EXPECT().Create(gomock.Any(), gomock.Cond(hasID)).Return(nil)
+	repo.EXPECT().CreateMany(gomock.Any(), gomock.Cond(func(calls []*model.Call) bool {
+		for _, call := range calls {
+			if !hasID(call) {
+				return false
+			}
+		}
+		return true
+	})).Return(nil)
+
+	first, err := svc.CreateCall(context.Background(), req, uuid.New())
+	require.NoError(t, err)
+	calls, err := svc.CreateCalls(context.Background(), []*model.CreateCallRequest{req, req, req}, uuid.New())
+	require.NoError(t, err)
+
+	prev := first.ID.String()
+	for _, call := range calls {
+		assert.Less(t, prev, call.ID.String())
+		prev = call.ID.String()
+	}
+}
+
+// Тест кода заявки: при совпадении кода с кодом существующей заявки заявка сохраняется
+// с новым кодом, а после callRefAttempts совпадений возвращается ошибка
+func TestCreateCall_RefCollision(t *testing.T) {
+	repo := mocks.NewMockCallRepository(gomock.NewController(t))
+	svc := NewCallService(repo)
+	req := &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001", Description: "Первая"}
+
+	var refs []string
+	record := func(ctx context.Context, call *model.Call) { refs = append(refs, call.Ref) }
+	gomock.InOrder(
+		repo.EXPECT().Create(gomock.Any(), gomock.Any()).Do(record).Return(repository.ErrAlreadyExists),
+		repo.EXPECT().Create(gomock.Any(), gomock.Any()).Do(record).Return(nil),
+	)
+	call, err := svc.CreateCall(context.Background(), req, uuid.New())
+	require.NoError(t, err)
+	require.Len(t, refs, 2)
+	assert.NotEqual(t, refs[0], refs[1])
+	assert.Equal(t, refs[1], call.Ref)
+	_, ok := model.NormalizeCallRef(call.Ref)
+	assert.True(t, ok)
+
+	repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(repository.ErrAlreadyExists).Times(callRefAttempts)
+	_, err = svc.CreateCall(context.Background(), req, uuid.New())
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+}
+
+// Тест пакетного создания с некорректным номером: ошибка содержит индекс строки, репозиторий не вызывается
+func TestCreateCalls_InvalidRow(t *testing.T) {
+	repo := mocks.NewMockCallRepository(gomock.NewController(t))
+	svc := NewCallService(repo)
+
+	reqs := []*model.CreateCallRequest{
+		{ClientName: "Иван", PhoneNumber: "+79990000001", Description: "Первая"},
+		{ClientName: "Петр", PhoneNumber: "не номер", Description: "Вторая"},
+	}
+
+	calls, err := svc.CreateCalls(context.Background(), reqs, uuid.New())
+
+	assert.Nil(t, calls)
+	var rowErr *RowError
+	require.True(t, errors.As(err, &rowErr))
+	assert.Equal(t, 1, rowErr.Index)
+	assert.True(t, errors.Is(err, ErrInvalidPhoneNumber))
+}
+
+// Тест ограничения длины описания: длина считается в символах, слишком длинное описание
+// отклоняется без обращения к репозиторию, а с Truncate обрезается до MaxDescriptionLength
+// символов с многоточием в конце
+func TestCreateCall_DescriptionLength(t *testing.T) {
+	repo := mocks.NewMockCallRepository(gomock.NewController(t))
+	svc := NewCallService(repo)
+	req := &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001", Description: strings.Repeat("я", model.MaxDescriptionLength)}
+
+	repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	call, err := svc.CreateCall(context.Background(), req, uuid.New())
+	require.NoError(t, err)
+	assert.False(t, call.Truncated)
+
+	req.Description += "я"
+	_, err = svc.CreateCall(context.Background(), req, uuid.New())
+	assert.ErrorIs(t, err, ErrDescriptionTooLong)
+	_, err = svc.CreateCalls(context.Background(), []*model.CreateCallRequest{req}, uuid.New())
+	assert.ErrorIs(t, err, ErrDescriptionTooLong)
+
+	req.Truncate = true
+	call, err = svc.CreateCall(context.Background(), req, uuid.New())
+	require.NoError(t, err)
+	assert.True(t, call.Truncated)
+	assert.Equal(t, strings.Repeat("я", model.MaxDescriptionLength-1)+model.TruncationMarker, call.Description)
+}
+
+// Тест пакетного создания: ошибка репозитория возвращается с описанием операции
+func TestCreateCalls_RepositoryError(t *testing.T) {
+	repo := mocks.NewMockCallRepository(gomock.NewController(t))
+	svc := NewCallService(repo)
+	dbErr := errors.New("connection refused")
+
+	repo.EXPECT().CreateMany(gomock.Any(), gomock.Any()).Return(dbErr)
+
+	_, err := svc.CreateCalls(context.Background(), []*model.CreateCallRequest{
+		{ClientName: "Иван", PhoneNumber: "+79990000001", Description: "Первая"},
+	}, uuid.New())
+
+	assert.ErrorIs(t, err, dbErr)
+}
+
+// Тест истечения времени запроса: ошибка не превращается в ErrCallNotFound
+func TestGetCallByID_Timeout(t *testing.T) {
+	repo := mocks.NewMockCallRepository(gomock.NewController(t))
+	svc := NewCallService(repo)
+	id := uuid.New()
+
+	repo.EXPECT().GetByID(gomock.Any(), id).Return(nil, repository.ErrTimeout)
+
+	_, err := svc.GetCallByID(context.Background(), id, uuid.New())
+
+	assert.ErrorIs(t, err, repository.ErrTimeout)
+	assert.NotErrorIs(t, err, ErrCallNotFound)
+	assert.ErrorContains(t, err, "get call "+id.String())
+}
+
+// Тест отсутствующей заявки: ошибка — *NotFoundError с ID заявки в тексте для журнала,
+// которая остается ErrCallNotFound и после дополнения контекстом
+func TestGetCallByID_NotFound(t *testing.T) {
+	svc := NewCallService(repository.NewInMemoryCallRepository())
+	id := uuid.New()
+
+	_, err := svc.GetCallByID(context.Background(), id, uuid.New())
+
+	var notFound *NotFoundError
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, "call", notFound.Resource)
+	assert.Equal(t, id, notFound.ID)
+	assert.EqualError(t, err, "call "+id.String()+" not found")
+	assert.ErrorIs(t, fmt.Errorf("handle request: %w", err), ErrCallNotFound)
+}
+
+// Тест времени создания: заявки получают время часов сервиса, поэтому границы
+// фильтра по дате создания проверяются без ожидания (начало включается, конец — нет)
+func TestCreateCall_UsesClock(t *testing.T) {
+	start := time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	svc := NewCallService(repository.NewInMemoryCallRepository(), WithClock(fake))
+	userID := uuid.New()
+	req := &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001", Description: "Первая"}
+
+	first, err := svc.CreateCall(context.Background(), req, userID)
+	require.NoError(t, err)
+	assert.Equal(t, start, first.CreatedAt)
+
+	fake.Advance(24 * time.Hour)
+	second, err := svc.CreateCall(context.Background(), req, userID)
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(24*time.Hour), second.CreatedAt)
+
+	from, to := start, start.Add(24*time.Hour)
+	calls, total, err := svc.GetAllCalls(context.Background(), userID, model.CallFilter{CreatedFrom: &from, CreatedTo: &to})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, calls, 1)
+	assert.Equal(t, first.ID, calls[0].ID)
+}
+
+// Тест закрытия давно открытых заявок: репозиторий вызывается пакетами, пока пакет заполнен,
+// изменения выполняются от имени системного пользователя
+func TestCloseStaleCalls(t *testing.T) {
+	repo := mocks.NewMockCallRepository(gomock.NewController(t))
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc := NewCallService(repo, WithClock(clock.NewFake(now)))
+	before := now.Add(-72 * time.Hour)
+
+	gomock.InOrder(
+		repo.EXPECT().CloseStale(gomock.Any(), before, closeStaleBatchSize, model.SystemActorID).Return(make([]*model.Call, closeStaleBatchSize), nil),
+		repo.EXPECT().CloseStale(gomock.Any(), before, closeStaleBatchSize, model.SystemActorID).Return(make([]*model.Call, 3), nil),
+	)
+
+	closed, err := svc.CloseStaleCalls(context.Background(), 72*time.Hour)
+
+	require.NoError(t, err)
+	assert.Equal(t, closeStaleBatchSize+3, closed)
+
+	_, err = svc.CloseStaleCalls(context.Background(), 0)
+	assert.Error(t, err)
+}
+
+// Тест уведомлений о повторных звонках: отправленные уведомления отмечаются, неотправленные
+// остаются для следующего вызова, время звонка хранится в UTC
+func TestNotifyDueCallbacks(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC))
+	repo := repository.NewInMemoryCallRepository()
+	svc := NewCallService(repo, WithClock(fake))
+	ctx := context.Background()
+	userID := uuid.New()
+
+	callbackAt := time.Date(2025, 3, 1, 12, 30, 0, 0, time.FixedZone("MSK", 3*60*60))
+	first, err := svc.CreateCall(ctx, &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001", CallbackAt: &callbackAt}, userID)
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, first.CallbackAt.Location())
+	second, err := svc.CreateCall(ctx, &model.CreateCallRequest{ClientName: "Петр", PhoneNumber: "+79990000002", CallbackAt: &callbackAt}, userID)
+	require.NoError(t, err)
+
+	past := fake.Now().Add(-time.Minute)
+	_, err = svc.CreateCall(ctx, &model.CreateCallRequest{ClientName: "Вера", PhoneNumber: "+79990000003", CallbackAt: &past}, userID)
+	assert.ErrorIs(t, err, ErrCallbackInPast)
+
+	var notified []uuid.UUID
+	notify := func(_ context.Context, call *model.Call) error {
+		if call.ID == second.ID {
+			return errors.New("webhook unavailable")
+		}
+		notified = append(notified, call.ID)
+		return nil
+	}
+
+	n, err := svc.NotifyDueCallbacks(ctx, notify)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	fake.Advance(time.Hour)
+	n, err = svc.NotifyDueCallbacks(ctx, notify)
+	assert.ErrorContains(t, err, "webhook unavailable")
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []uuid.UUID{first.ID}, notified)
+
+	// Повторный вызов отправляет только неотправленное уведомление
+	n, err = svc.NotifyDueCallbacks(ctx, notify)
+	assert.Error(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, []uuid.UUID{first.ID}, notified)
+}
+
+// Тест исходящего звонка: проверка владельца и статуса заявки, запись попытки в историю
+// с ссылкой провайдера, вызов провайдера со своим сроком независимо от отмены запроса
+// и отказ провайдера без номера телефона в сообщении
+func TestDialCall(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dialer := mocks.NewMockDialer(ctrl)
+	repo := repository.NewInMemoryCallRepository()
+	svc := NewCallService(repo, WithDialer(dialer, time.Second))
+	ctx := context.Background()
+	userID := uuid.New()
+
+	call, err := svc.CreateCall(ctx, &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001"}, userID)
+	require.NoError(t, err)
+
+	_, err = NewCallService(repo).DialCall(ctx, call.ID, userID)
+	assert.ErrorIs(t, err, ErrDialingDisabled)
+	_, err = svc.DialCall(ctx, call.ID, uuid.New())
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = svc.DialCall(ctx, uuid.New(), userID)
+	assert.ErrorIs(t, err, ErrCallNotFound)
+
+	// Клиент закрывает соединение во время звонка, но звонок завершается и попадает в историю
+	reqCtx, cancel := context.WithCancel(ctx)
+	dialer.EXPECT().Dial(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, req telephony.DialRequest) (string, error) {
+		cancel()
+		assert.NoError(t, ctx.Err())
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, time.Second)
+		assert.Equal(t, call.ID, req.CallID)
+		assert.Equal(t, "+79990000001", req.PhoneNumber)
+		assert.Equal(t, userID, req.UserID)
+		return "sip-42", nil
+	})
+	attempt, err := svc.DialCall(reqCtx, call.ID, userID)
+	require.NoError(t, err)
+	require.NotNil(t, attempt.DialID)
+	assert.Equal(t, "sip-42", attempt.DialReference)
+
+	dialer.EXPECT().Dial(gomock.Any(), gomock.Any()).
+		Return("", &telephony.ProviderError{Provider: telephony.ProviderHTTP, Message: "number +79990000001 is blocked"})
+	_, err = svc.DialCall(ctx, call.ID, userID)
+	var dialErr *DialError
+	require.ErrorAs(t, err, &dialErr)
+	assert.Equal(t, "number *** is blocked", dialErr.Message)
+
+	changes, err := repo.ListStatusChanges(ctx, call.ID)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, *attempt.DialID, *changes[0].DialID)
+	assert.Equal(t, "sip-42", changes[0].DialReference)
+	assert.Equal(t, model.CallStatusOpen, changes[0].OldStatus)
+	assert.Equal(t, model.CallStatusOpen, changes[0].NewStatus)
+
+	require.NoError(t, svc.UpdateCallStatus(ctx, call.ID, model.CallStatusClosed, userID))
+	_, err = svc.DialCall(ctx, call.ID, userID)
+	assert.ErrorIs(t, err, ErrCallClosed)
+}
+
+// Тест доступа участников организации: заявку организации читают и изменяют все ее участники,
+// удаляют только ее владелец, создатель и владелец организации; заявки вне организации остаются
+// доступны только владельцу, как и запросы без членства в контексте
+func TestCallService_Organizations(t *testing.T) {
+	svc := NewCallService(repository.NewInMemoryCallRepository())
+	orgID := uuid.New()
+	authorID, memberID, ownerID := uuid.New(), uuid.New(), uuid.New()
+	author := tenant.WithMembership(context.Background(), tenant.Membership{OrgID: orgID, Role: tenant.RoleMember})
+	member := tenant.WithMembership(context.Background(), tenant.Membership{OrgID: orgID, Role: tenant.RoleMember})
+	owner := tenant.WithMembership(context.Background(), tenant.Membership{OrgID: orgID, Role: tenant.RoleOwner})
+	stranger := tenant.WithMembership(context.Background(), tenant.Membership{OrgID: uuid.New(), Role: tenant.RoleOwner})
+	req := &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001", Description: "Общая"}
+
+	call, err := svc.CreateCall(author, req, authorID)
+	require.NoError(t, err)
+	require.NotNil(t, call.OrgID)
+	assert.Equal(t, orgID, *call.OrgID)
+	private, err := svc.CreateCall(context.Background(), req, authorID)
+	require.NoError(t, err)
+	assert.Nil(t, private.OrgID)
+
+	// Чтение и изменение — любым участником организации
+	_, err = svc.GetCallByID(member, call.ID, memberID)
+	require.NoError(t, err)
+	require.NoError(t, svc.UpdateCallStatus(member, call.ID, model.CallStatusInProgress, memberID))
+	_, err = svc.GetCallByID(member, private.ID, memberID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = svc.GetCallByID(stranger, call.ID, uuid.New())
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = svc.GetCallByID(context.Background(), call.ID, memberID)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// Список участника содержит заявки организации, но не чужие заявки вне организации
+	calls, total, err := svc.GetAllCalls(member, memberID, model.CallFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, call.ID, calls[0].ID)
+	_, total, err = svc.GetAllCalls(context.Background(), memberID, model.CallFilter{})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	_, total, err = svc.GetAllCalls(author, authorID, model.CallFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	// Удаление — только владельцем заявки или владельцем организации
+	assert.ErrorIs(t, svc.DeleteCall(member, call.ID, memberID), ErrForbidden)
+	assert.ErrorIs(t, svc.DeleteCall(owner, private.ID, ownerID), ErrForbidden)
+	require.NoError(t, svc.DeleteCall(owner, call.ID, ownerID))
+	require.NoError(t, svc.DeleteCall(context.Background(), private.ID, authorID))
+}
+
+// Тест прав владельца организации на заявки участников: заявку, созданную одним участником
+// и переданную другому, владелец организации читает, изменяет и удаляет, как и ее создатель,
+// а прочие участники удалить не могут
+func TestCallService_OrganizationOwnerAccess(t *testing.T) {
+	svc := NewCallService(repository.NewInMemoryCallRepository())
+	orgID := uuid.New()
+	creatorID, assigneeID, memberID, ownerID, adminID := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	inOrg := func(role string) context.Context {
+		return tenant.WithMembership(context.Background(), tenant.Membership{OrgID: orgID, Role: role})
+	}
+	req := &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001", Description: "Общая"}
+
+	newCall := func() *model.Call {
+		call, err := svc.CreateCall(inOrg(tenant.RoleMember), req, creatorID)
+		require.NoError(t, err)
+		require.NoError(t, svc.ReassignCall(context.Background(), call.ID, assigneeID, adminID))
+		return call
+	}
+
+	call := newCall()
+	_, err := svc.GetCallByID(inOrg(tenant.RoleOwner), call.ID, ownerID)
+	require.NoError(t, err)
+	require.NoError(t, svc.UpdateCallStatus(inOrg(tenant.RoleOwner), call.ID, model.CallStatusInProgress, ownerID))
+	assert.ErrorIs(t, svc.DeleteCall(inOrg(tenant.RoleMember), call.ID, memberID), ErrForbidden)
+	require.NoError(t, svc.DeleteCall(inOrg(tenant.RoleOwner), call.ID, ownerID))
+
+	// Создатель удаляет переданную заявку, пока состоит в организации заявки
+	call = newCall()
+	assert.ErrorIs(t, svc.DeleteCall(context.Background(), call.ID, creatorID), ErrForbidden)
+	require.NoError(t, svc.DeleteCall(inOrg(tenant.RoleMember), call.ID, creatorID))
+}
+
+// Тест ленты действий: записи разных видов читаются постранично по позиции следующей
+// страницы, имена авторов и участников передачи заполняются одним запросом к справочнику
+// на страницу, а доступ к ленте проверяется так же, как к заявке
+func TestGetCallActivity(t *testing.T) {
+	owner, admin, newOwner := uuid.New(), uuid.New(), uuid.New()
+	lookup := &fakeUserLookup{users: map[uuid.UUID]string{owner: "owner", admin: "admin", newOwner: "new"}}
+	svc := NewCallService(repository.NewInMemoryCallRepository(),
+		WithUserDirectory(NewUserDirectory(lookup, time.Minute, nil)))
+	ctx := context.Background()
+
+	call, err := svc.CreateCall(ctx, &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001"}, owner)
+	require.NoError(t, err)
+	require.NoError(t, svc.UpdateCallStatus(ctx, call.ID, model.CallStatusInProgress, owner))
+	require.NoError(t, svc.ReassignCall(ctx, call.ID, newOwner, admin))
+
+	page, next, err := svc.GetCallActivity(ctx, call.ID, newOwner, nil, 1)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	require.NotNil(t, next)
+	assert.Equal(t, model.ActivityStatusChange, page[0].Type)
+	assert.Equal(t, "owner", page[0].ActorName)
+
+	page, next, err = svc.GetCallActivity(ctx, call.ID, newOwner, next, 1)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Nil(t, next)
+	assert.Equal(t, model.ActivityAssignment, page[0].Type)
+	assert.Equal(t, "admin", page[0].ActorName)
+	assert.Equal(t, "owner", page[0].OldUserName)
+	assert.Equal(t, "new", page[0].NewUserName)
+	// Имя прежнего владельца уже в кэше справочника
+	assert.Equal(t, []int{1, 2}, lookup.batches)
+
+	// После передачи прежний владелец не видит ленту заявки
+	_, _, err = svc.GetCallActivity(ctx, call.ID, owner, nil, 10)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, _, err = svc.GetCallActivity(ctx, uuid.New(), owner, nil, 10)
+	assert.ErrorIs(t, err, ErrCallNotFound)
+}
+
+// eventRecorder запоминает опубликованные события
+type eventRecorder struct {
+	events []events.Event
+}
+
+func (r *eventRecorder) Publish(e events.Event) {
+	r.events = append(r.events, e)
+}
+
+// Тест публикации событий: создание, изменение и удаление заявки публикуют события с состоянием
+// заявки после изменения, передача заявки адресуется прежнему и новому владельцам, а отклоненное
+// изменение событий не публикует
+func TestCallService_PublishesEvents(t *testing.T) {
+	recorder := &eventRecorder{}
+	svc := NewCallService(repository.NewInMemoryCallRepository(), WithEvents(recorder))
+	ctx := context.Background()
+	ownerID, newOwnerID, adminID := uuid.New(), uuid.New(), uuid.New()
+
+	call, err := svc.CreateCall(ctx, &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001", Description: "Звонок"}, ownerID)
+	require.NoError(t, err)
+	require.NoError(t, svc.UpdateCallStatus(ctx, call.ID, model.CallStatusInProgress, ownerID))
+	assert.ErrorIs(t, svc.UpdateCallStatus(ctx, call.ID, model.CallStatusClosed, uuid.New()), ErrForbidden)
+	require.NoError(t, svc.ReassignCall(ctx, call.ID, newOwnerID, adminID))
+	require.NoError(t, svc.DeleteCall(ctx, call.ID, newOwnerID))
+
+	require.Len(t, recorder.events, 4)
+	assert.Equal(t, events.CallCreated, recorder.events[0].Type)
+	assert.Equal(t, []uuid.UUID{ownerID}, recorder.events[0].UserIDs)
+
+	assert.Equal(t, events.CallUpdated, recorder.events[1].Type)
+	require.NotNil(t, recorder.events[1].Call)
+	assert.Equal(t, model.CallStatusInProgress, recorder.events[1].Call.Status)
+
+	assert.Equal(t, events.CallUpdated, recorder.events[2].Type)
+	assert.Equal(t, []uuid.UUID{newOwnerID, ownerID}, recorder.events[2].UserIDs)
+
+	assert.Equal(t, events.CallDeleted, recorder.events[3].Type)
+	assert.Equal(t, call.ID, recorder.events[3].CallID)
+	assert.Nil(t, recorder.events[3].Call)
+}
+
+// invalidationRecorder запоминает сброшенные пространства кэша ответов
+type invalidationRecorder struct {
+	userIDs [][]uuid.UUID
+	orgIDs  []*uuid.UUID
+}
+
+func (r *invalidationRecorder) InvalidateCalls(ctx context.Context, userIDs []uuid.UUID, orgID *uuid.UUID) {
+	r.userIDs = append(r.userIDs, userIDs)
+	r.orgIDs = append(r.orgIDs, orgID)
+}
+
+// Тест сброса кэша ответов без публикации событий: сбрасываются ответы тех же пользователей
+// и организации, которым адресуется событие, а отклоненное изменение кэш не сбрасывает
+func TestCallService_InvalidatesCache(t *testing.T) {
+	recorder := &invalidationRecorder{}
+	svc := NewCallService(repository.NewInMemoryCallRepository(), WithCacheInvalidator(recorder))
+	orgID := uuid.New()
+	ctx := tenant.WithMembership(context.Background(), tenant.Membership{OrgID: orgID, Role: tenant.RoleMember})
+	ownerID, newOwnerID, adminID := uuid.New(), uuid.New(), uuid.New()
+
+	call, err := svc.CreateCall(ctx, &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001", Description: "Звонок"}, ownerID)
+	require.NoError(t, err)
+	// Пользователь вне организации не может изменить заявку
+	assert.ErrorIs(t, svc.UpdateCallStatus(context.Background(), call.ID, model.CallStatusClosed, uuid.New()), ErrForbidden)
+	require.NoError(t, svc.ReassignCall(ctx, call.ID, newOwnerID, adminID))
+	require.NoError(t, svc.DeleteCall(ctx, call.ID, newOwnerID))
+
+	assert.Equal(t, [][]uuid.UUID{{ownerID}, {newOwnerID, ownerID}, {newOwnerID}}, recorder.userIDs)
+	for _, id := range recorder.orgIDs {
+		require.NotNil(t, id)
+		assert.Equal(t, orgID, *id)
+	}
+}
+
+// Тест сброса кэша ответов после закрытия давно открытых заявок: сбрасываются ответы
+// владельцев закрытых заявок и их организаций, по одному разу на организацию
+func TestCloseStaleCalls_InvalidatesCache(t *testing.T) {
+	recorder := &invalidationRecorder{}
+	fake := clock.NewFake(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	svc := NewCallService(repository.NewInMemoryCallRepository(), WithClock(fake), WithCacheInvalidator(recorder))
+	orgID := uuid.New()
+	orgCtx := tenant.WithMembership(context.Background(), tenant.Membership{OrgID: orgID, Role: tenant.RoleMember})
+	firstID, secondID := uuid.New(), uuid.New()
+	for _, userID := range []uuid.UUID{firstID, secondID} {
+		_, err := svc.CreateCall(orgCtx, &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001", Description: "Звонок"}, userID)
+		require.NoError(t, err)
+	}
+	recorder.userIDs, recorder.orgIDs = nil, nil
+
+	fake.Advance(73 * time.Hour)
+	closed, err := svc.CloseStaleCalls(context.Background(), 72*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 2, closed)
+
+	require.Len(t, recorder.orgIDs, 2)
+	require.NotNil(t, recorder.orgIDs[0])
+	assert.Equal(t, orgID, *recorder.orgIDs[0])
+	assert.Nil(t, recorder.userIDs[0])
+	assert.ElementsMatch(t, []uuid.UUID{firstID, secondID}, recorder.userIDs[1])
+	assert.Nil(t, recorder.orgIDs[1])
+
+	// Повторный запуск ничего не закрывает и кэш не сбрасывает
+	_, err = svc.CloseStaleCalls(context.Background(), 72*time.Hour)
+	require.NoError(t, err)
+	assert.Len(t, recorder.orgIDs, 2)
+}
+
+// Тест уведомления о передаче заявки: новый владелец получает уведомление, а передача
+// заявки самому себе и ошибка очереди уведомлений не мешают передаче
+func TestCallService_ReassignNotifiesNewOwner(t *testing.T) {
+	notifier := mocks.NewMockNotifier(gomock.NewController(t))
+	svc := NewCallService(repository.NewInMemoryCallRepository(), WithNotifier(notifier))
+	ctx := context.Background()
+	ownerID, newOwnerID, adminID := uuid.New(), uuid.New(), uuid.New()
+
+	call, err := svc.CreateCall(ctx, &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001", Description: "Звонок"}, ownerID)
+	require.NoError(t, err)
+
+	notifier.EXPECT().Send(gomock.Any(), newOwnerID, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, userID uuid.UUID, n notify.Notification) error {
+			assert.Equal(t, model.NotificationCallAssigned, n.Event)
+			assert.Contains(t, n.Text, call.ID.String())
+			return errors.New("queue is full")
+		})
+	require.NoError(t, svc.ReassignCall(ctx, call.ID, newOwnerID, adminID))
+	// Администратор, забравший заявку себе, уведомления не получает
+	require.NoError(t, svc.ReassignCall(ctx, call.ID, adminID, adminID))
+}
+
+// queryCountingCallRepository считает запросы ForEachByUserID к хранилищу заявок.
+type queryCountingCallRepository struct {
+	repository.CallRepository
+	queries int
+}
+
+func (r *queryCountingCallRepository) ForEachByUserID(ctx context.Context, userID uuid.UUID, filter model.CallFilter, fn func(*model.Call) error) error {
+	r.queries++
+	return r.CallRepository.ForEachByUserID(ctx, userID, filter, fn)
+}
+
+// Тест потоковой выгрузки заявок на границах пакетов: каждая заявка передается ровно один раз,
+// пустых пакетов нет, после полного последнего пакета выполняется один пустой запрос, а ошибка
+// fn прекращает чтение
+func TestStreamCalls_BatchBoundaries(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	for _, tc := range []struct {
+		calls   int
+		batches []int
+		queries int
+	}{
+		{calls: 0, batches: nil, queries: 1},
+		{calls: 2, batches: []int{2}, queries: 1},
+		{calls: 3, batches: []int{3}, queries: 2},
+		{calls: 4, batches: []int{3, 1}, queries: 2},
+		{calls: 6, batches: []int{3, 3}, queries: 3},
+	} {
+		repo := &queryCountingCallRepository{CallRepository: repository.NewInMemoryCallRepository()}
+		svc := NewCallService(repo)
+		var want []uuid.UUID
+		for i := 0; i < tc.calls; i++ {
+			call, err := svc.CreateCall(ctx, &model.CreateCallRequest{
+				ClientName: fmt.Sprintf("client%d", i), PhoneNumber: "+79990000000", Description: "test",
+			}, userID)
+			require.NoError(t, err)
+			want = append(want, call.ID)
+		}
+
+		var batches []int
+		var got []uuid.UUID
+		err := svc.StreamCalls(ctx, userID, model.CallFilter{SortBy: model.SortByClientName, Limit: 1, Offset: 5}, 3, func(calls []*model.Call) error {
+			batches = append(batches, len(calls))
+			for _, call := range calls {
+				got = append(got, call.ID)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, tc.batches, batches, "calls: %d", tc.calls)
+		assert.Equal(t, want, got, "calls: %d", tc.calls)
+		assert.Equal(t, tc.queries, repo.queries, "calls: %d", tc.calls)
+
+		repo.queries = 0
+		err = svc.StreamCalls(ctx, userID, model.CallFilter{}, 1, func([]*model.Call) error { return assert.AnError })
+		if tc.calls > 0 {
+			assert.ErrorIs(t, err, assert.AnError)
+		}
+		assert.Equal(t, 1, repo.queries)
+	}
+
+	err := NewCallService(repository.NewInMemoryCallRepository()).StreamCalls(ctx, userID, model.CallFilter{Status: "unknown"}, 0, func([]*model.Call) error { return nil })
+	assert.ErrorIs(t, err, ErrInvalidStatus)
+}
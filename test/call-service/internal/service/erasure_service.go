@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"common/clock"
+)
+
+// Параметры удаления учетных записей
+const (
+	// DefaultErasureRetryDelay — время без изменений, после которого незавершенное удаление
+	// считается прерванным и повторяется в Reconcile. Должно превышать время обработки
+	// запроса на удаление, чтобы Reconcile не выполнял удаление одновременно с ним.
+	DefaultErasureRetryDelay = 5 * time.Minute
+	// erasureReconcileBatch — наибольшее число удалений, повторяемых за один вызов Reconcile.
+	erasureReconcileBatch = 100
+)
+
+// AccountEraser удаляет пользователя в сервисе аутентификации; реализуется authclient.AuthClient.
+// Удаление должно быть идемпотентным: для уже удаленного пользователя EraseUser не возвращает ошибку.
+
+type AccountEraser interface {
+	EraseUser(ctx context.Context, userID string) error
+}
+
+// ErasureService определяет интерфейс удаления учетных записей по запросу пользователя.
+//
+// Данные пользователя хранятся в двух сервисах, поэтому удаление выполняется по шагам (сага):
+// сначала в базе данных сервиса заявок сохраняется запись model.AccountErasure, затем
+// пользователь удаляется в сервисе аутентификации, после чего обрабатываются его заявки,
+// их вложения и API-ключи, а запись удаляется. Каждый шаг можно безопасно повторить, поэтому удаление,
+// прерванное сбоем, завершает Reconcile.
+
+type ErasureService interface {
+	// Erase удаляет учетную запись пользователя, пароль которого уже проверен. Возвращает true,
+	// если удаление завершено, и false, если оно прервано и будет завершено в Reconcile.
+	// Ошибка возвращается, только если удаление не удалось начать.
+	Erase(ctx context.Context, userID uuid.UUID) (bool, error)
+	// Reconcile завершает прерванные удаления и возвращает число завершенных.
+	Reconcile(ctx context.Context) (int, error)
+}
+
+// ErasureConfig задает параметры ErasureService. Нулевые значения заменяются значениями по умолчанию.
+
+type ErasureConfig struct {
+	// Policy — обработка заявок пользователя; пустое значение — model.ErasurePolicyAnonymize.
+	Policy model.ErasurePolicy
+	// RetryDelay — см. DefaultErasureRetryDelay.
+	RetryDelay time.Duration
+	// Clock — источник времени; nil — системное время.
+	Clock clock.Clock
+	// SavedViews — сохраненные представления пользователей; nil — представления не удаляются.
+	SavedViews SavedViewService
+	// Notifications — настройки уведомлений пользователей; nil — настройки не удаляются.
+	Notifications NotificationService
+	// Webhooks — подписки пользователей на события заявок; nil — подписки не удаляются.
+	Webhooks WebhookService
+	// Invalidator — кэш ответов со списками заявок; после обработки заявок сбрасываются
+	// ответы их владельца и организаций. nil — кэш не сбрасывается.
+	Invalidator CallCacheInvalidator
+}
+
+// erasureService реализует интерфейс ErasureService
+
+type erasureService struct {
+	erasures    repository.ErasureRepository
+	calls       repository.CallRepository
+	apiKeys     APIKeyService
+	attachments AttachmentService
+	auth        AccountEraser
+	cfg         ErasureConfig
+}
+
+// NewErasureService создает новый экземпляр сервиса удаления учетных записей
+
+func NewErasureService(erasures repository.ErasureRepository, calls repository.CallRepository, apiKeys APIKeyService, attachments AttachmentService, auth AccountEraser, cfg ErasureConfig) ErasureService {
+	if cfg.Policy == "" {
+		cfg.Policy = model.ErasurePolicyAnonymize
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = DefaultErasureRetryDelay
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clock.Real
+	}
+	return &erasureService{erasures: erasures, calls: calls, apiKeys: apiKeys, attachments: attachments, auth: auth, cfg: cfg}
+}
+
+// Erase сохраняет запись об удалении и выполняет остальные шаги. Если удаление этого
+// пользователя уже начато, оно продолжается с сохраненного шага и с сохраненной политикой.
+
+func (s *erasureService) Erase(ctx context.Context, userID uuid.UUID) (bool, error) {
+	now := s.cfg.Clock.Now()
+	erasure := &model.AccountErasure{
+		UserID:    userID,
+		Policy:    s.cfg.Policy,
+		State:     model.ErasureStatePending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.erasures.Create(ctx, erasure); err != nil {
+		if !errors.Is(err, repository.ErrAlreadyExists) {
+			return false, err
+		}
+		if erasure, err = s.erasures.GetByUserID(ctx, userID); err != nil {
+			// Запись удаляется последним шагом: параллельный запрос уже завершил удаление
+			if errors.Is(err, repository.ErrNotFound) {
+				return true, nil
+			}
+			return false, err
+		}
+	}
+
+	if err := s.resume(ctx, erasure); err != nil {
+		log.Printf("account erasure of user %s interrupted, will be retried: %v", userID, err)
+		return false, nil
+	}
+	return true, nil
+}
+
+// Reconcile повторяет удаления, не изменявшиеся дольше RetryDelay, начиная с самых давних.
+// Неудачная попытка сохраняется в записи об удалении и не прерывает обработку остальных.
+
+func (s *erasureService) Reconcile(ctx context.Context) (int, error) {
+	before := s.cfg.Clock.Now().Add(-s.cfg.RetryDelay)
+	stale, err := s.erasures.ListStale(ctx, before, erasureReconcileBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	completed := 0
+	var errs []error
+	for _, erasure := range stale {
+		if err := ctx.Err(); err != nil {
+			return completed, err
+		}
+		if err := s.resume(ctx, erasure); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		completed++
+	}
+	return completed, errors.Join(errs...)
+}
+
+// resume выполняет шаги удаления, начиная с erasure.State. Ошибка шага сохраняется
+// в записи об удалении, чтобы повторная попытка была отложена на RetryDelay.
+
+func (s *erasureService) resume(ctx context.Context, erasure *model.AccountErasure) error {
+	userID := erasure.UserID
+	if erasure.State == model.ErasureStatePending {
+		if err := s.auth.EraseUser(ctx, userID.String()); err != nil {
+			return s.fail(ctx, userID, "erase user in auth service", err)
+		}
+		if err := s.erasures.SetState(ctx, userID, model.ErasureStateAuthErased, s.cfg.Clock.Now()); err != nil {
+			return s.fail(ctx, userID, "save erasure state", err)
+		}
+	}
+
+	if _, err := s.apiKeys.DeleteUserAPIKeys(ctx, userID); err != nil {
+		return s.fail(ctx, userID, "delete api keys", err)
+	}
+	// Фильтры представлений могут содержать номера телефонов клиентов
+	if s.cfg.SavedViews != nil {
+		if _, err := s.cfg.SavedViews.DeleteUserViews(ctx, userID); err != nil {
+			return s.fail(ctx, userID, "delete saved views", err)
+		}
+	}
+	if s.cfg.Notifications != nil {
+		if err := s.cfg.Notifications.DeleteUserNotifications(ctx, userID); err != nil {
+			return s.fail(ctx, userID, "delete notification settings", err)
+		}
+	}
+	// Очередь доставки подписок содержит заявки пользователя
+	if s.cfg.Webhooks != nil {
+		if _, err := s.cfg.Webhooks.DeleteUserWebhooks(ctx, userID); err != nil {
+			return s.fail(ctx, userID, "delete webhooks", err)
+		}
+	}
+	// Вложения удаляются и при обезличивании: фотографии и документы могут содержать
+	// персональные данные клиента
+	attachments, err := s.attachments.DeleteUserAttachments(ctx, userID)
+	if err != nil {
+		return s.fail(ctx, userID, "delete attachments", err)
+	}
+	calls, err := s.calls.EraseByUserID(ctx, userID, erasure.Policy)
+	if err != nil {
+		return s.fail(ctx, userID, "erase calls", err)
+	}
+	invalidateCalls(ctx, s.cfg.Invalidator, calls)
+	if err := s.erasures.Delete(ctx, userID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return s.fail(ctx, userID, "delete erasure record", err)
+	}
+	log.Printf("account of user %s erased, %d calls processed with policy %s, %d attachments deleted", userID, len(calls), erasure.Policy, attachments)
+	return nil
+}
+
+// fail сохраняет неудачную попытку шага step и возвращает ее ошибку.
+
+func (s *erasureService) fail(ctx context.Context, userID uuid.UUID, step string, err error) error {
+	err = fmt.Errorf("%s: %w", step, err)
+	// Попытка сохраняется, даже если ошибку вызвала отмена ctx (например, клиент закрыл соединение)
+	if recordErr := s.erasures.RecordFailure(context.WithoutCancel(ctx), userID, err.Error(), s.cfg.Clock.Now()); recordErr != nil {
+		log.Printf("failed to record account erasure failure of user %s: %v", userID, recordErr)
+	}
+	return err
+}
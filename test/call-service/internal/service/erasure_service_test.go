@@ -0,0 +1,265 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"common/clock"
+)
+
+// fakeAccountEraser запоминает удаленных пользователей и возвращает err, пока он задан.
+type fakeAccountEraser struct {
+	mu     sync.Mutex
+	err    error
+	erased []string
+}
+
+func (e *fakeAccountEraser) EraseUser(ctx context.Context, userID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err != nil {
+		return e.err
+	}
+	e.erased = append(e.erased, userID)
+	return nil
+}
+
+func (e *fakeAccountEraser) setErr(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.err = err
+}
+
+func (e *fakeAccountEraser) calls() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.erased)
+}
+
+// erasureFixture — сервис удаления учетных записей с хранилищами в памяти.
+type erasureFixture struct {
+	erasures    repository.ErasureRepository
+	calls       repository.CallRepository
+	apiKeys     APIKeyService
+	views       SavedViewService
+	notices     NotificationService
+	webhooks    WebhookService
+	attachments AttachmentService
+	store       *memStore
+	auth        *fakeAccountEraser
+	clock       *clock.Fake
+	invalidated *invalidationRecorder
+	svc         ErasureService
+}
+
+func newErasureFixture(policy model.ErasurePolicy) *erasureFixture {
+	f := &erasureFixture{
+		erasures: repository.NewInMemoryErasureRepository(),
+		calls:    repository.NewInMemoryCallRepository(),
+		apiKeys:  NewAPIKeyService(repository.NewInMemoryAPIKeyRepository()),
+		views:    NewSavedViewService(repository.NewInMemorySavedViewRepository()),
+		notices:  NewNotificationService(repository.NewInMemoryNotificationRepository(), model.NotificationChannels),
+		webhooks: NewWebhookService(repository.NewInMemoryWebhookRepository(), WebhookConfig{}),
+		store:    newMemStore(),
+		auth:     &fakeAccountEraser{},
+		clock:    clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
+	}
+	f.invalidated = &invalidationRecorder{}
+	f.attachments = NewAttachmentService(repository.NewInMemoryAttachmentRepository(), f.calls, f.store, AttachmentConfig{})
+	f.svc = NewErasureService(f.erasures, f.calls, f.apiKeys, f.attachments, f.auth, ErasureConfig{
+		Policy:        policy,
+		Clock:         f.clock,
+		SavedViews:    f.views,
+		Notifications: f.notices,
+		Webhooks:      f.webhooks,
+		Invalidator:   f.invalidated,
+	})
+	return f
+}
+
+// seed создает заявку с вложением, API-ключ, сохраненное представление, подписку на события
+// и привязку чата Telegram пользователя.
+func (f *erasureFixture) seed(t *testing.T, userID uuid.UUID) *model.Call {
+	ctx := context.Background()
+	callback := f.clock.Now().Add(time.Hour)
+	call := &model.Call{ClientName: "Иван", PhoneNumber: "+79990000001", Description: "Перезвонить", UserID: userID, CallbackAt: &callback}
+	require.NoError(t, f.calls.Create(ctx, call))
+	_, _, err := f.apiKeys.CreateAPIKey(ctx, userID, "ci", time.Time{})
+	require.NoError(t, err)
+	_, err = f.views.CreateView(ctx, userID, "client", map[string]string{"phone_number": call.PhoneNumber})
+	require.NoError(t, err)
+	_, err = f.webhooks.CreateWebhook(ctx, userID, "https://203.0.113.10/hooks")
+	require.NoError(t, err)
+	code, _, err := f.notices.CreateTelegramLinkCode(ctx, userID)
+	require.NoError(t, err)
+	_, err = f.notices.LinkTelegram(ctx, code, 42)
+	require.NoError(t, err)
+	_, err = f.attachments.UploadAttachment(ctx, call.ID, userID, &AttachmentUpload{
+		Size:    int64(len(pngContent)),
+		Content: bytes.NewReader(pngContent),
+	})
+	require.NoError(t, err)
+	return call
+}
+
+// Тест удаления с политикой anonymize: заявки пользователя обезличиваются, чужие не меняются,
+// API-ключи, представления, подписки, привязка Telegram и вложения удаляются, запись об удалении не остается,
+// кэшированные списки заявок пользователя сбрасываются
+func TestErasure_Anonymize(t *testing.T) {
+	f := newErasureFixture(model.ErasurePolicyAnonymize)
+	ctx := context.Background()
+	userID, otherID := uuid.New(), uuid.New()
+	call := f.seed(t, userID)
+	other := f.seed(t, otherID)
+
+	done, err := f.svc.Erase(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, []string{userID.String()}, f.auth.erased)
+
+	stored, err := f.calls.GetByID(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ErasedClientName, stored.ClientName)
+	assert.Empty(t, stored.PhoneNumber)
+	assert.Empty(t, stored.Description)
+	assert.Nil(t, stored.CallbackAt)
+	assert.Equal(t, userID, stored.UserID)
+
+	stored, err = f.calls.GetByID(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Иван", stored.ClientName)
+	// Осталось только вложение заявки другого пользователя
+	assert.Equal(t, 1, f.store.len())
+
+	keys, err := f.apiKeys.ListAPIKeys(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+	keys, err = f.apiKeys.ListAPIKeys(ctx, otherID)
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+	views, err := f.views.ListViews(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, views)
+	views, err = f.views.ListViews(ctx, otherID)
+	require.NoError(t, err)
+	assert.Len(t, views, 1)
+	webhooks, err := f.webhooks.ListWebhooks(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+	webhooks, err = f.webhooks.ListWebhooks(ctx, otherID)
+	require.NoError(t, err)
+	assert.Len(t, webhooks, 1)
+	_, err = f.notices.GetTelegramLink(ctx, userID)
+	assert.ErrorIs(t, err, ErrTelegramNotLinked)
+	_, err = f.notices.GetTelegramLink(ctx, otherID)
+	assert.NoError(t, err)
+
+	_, err = f.erasures.GetByUserID(ctx, userID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	assert.Equal(t, [][]uuid.UUID{{userID}}, f.invalidated.userIDs)
+
+	// Повторное удаление уже удаленного пользователя безопасно и кэш не сбрасывает
+	done, err = f.svc.Erase(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, done)
+	assert.Len(t, f.invalidated.userIDs, 1)
+}
+
+// Тест удаления с политикой delete: заявки пользователя удаляются
+func TestErasure_Delete(t *testing.T) {
+	f := newErasureFixture(model.ErasurePolicyDelete)
+	ctx := context.Background()
+	userID := uuid.New()
+	call := f.seed(t, userID)
+
+	done, err := f.svc.Erase(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, done)
+
+	_, err = f.calls.GetByID(ctx, call.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	assert.Equal(t, [][]uuid.UUID{{userID}}, f.invalidated.userIDs)
+}
+
+// Тест сбоя сервиса аутентификации: удаление откладывается, данные сервиса заявок не меняются,
+// а Reconcile завершает удаление только после RetryDelay
+func TestErasure_ReconcileAfterAuthFailure(t *testing.T) {
+	f := newErasureFixture(model.ErasurePolicyAnonymize)
+	ctx := context.Background()
+	userID := uuid.New()
+	call := f.seed(t, userID)
+
+	f.auth.setErr(errors.New("auth service unavailable"))
+	done, err := f.svc.Erase(ctx, userID)
+	require.NoError(t, err)
+	assert.False(t, done)
+
+	erasure, err := f.erasures.GetByUserID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ErasureStatePending, erasure.State)
+	assert.Equal(t, 1, erasure.Attempts)
+	assert.Contains(t, erasure.LastError, "auth service unavailable")
+	stored, err := f.calls.GetByID(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Иван", stored.ClientName)
+
+	// Запись изменялась недавно, поэтому Reconcile ее не трогает
+	f.auth.setErr(nil)
+	completed, err := f.svc.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, completed)
+	assert.Zero(t, f.auth.calls())
+
+	f.clock.Advance(DefaultErasureRetryDelay + time.Second)
+	completed, err = f.svc.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, completed)
+	assert.Equal(t, 1, f.auth.calls())
+
+	stored, err = f.calls.GetByID(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ErasedClientName, stored.ClientName)
+	_, err = f.erasures.GetByUserID(ctx, userID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+// Тест удаления, прерванного после удаления пользователя в сервисе аутентификации:
+// Reconcile завершает его, не обращаясь к сервису аутентификации повторно
+func TestErasure_ReconcileAfterAuthErased(t *testing.T) {
+	f := newErasureFixture(model.ErasurePolicyAnonymize)
+	ctx := context.Background()
+	userID := uuid.New()
+	call := f.seed(t, userID)
+
+	now := f.clock.Now()
+	require.NoError(t, f.erasures.Create(ctx, &model.AccountErasure{
+		UserID:    userID,
+		Policy:    model.ErasurePolicyDelete,
+		State:     model.ErasureStateAuthErased,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}))
+	f.clock.Advance(DefaultErasureRetryDelay + time.Second)
+
+	completed, err := f.svc.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, completed)
+	assert.Zero(t, f.auth.calls())
+
+	// Используется политика, сохраненная при начале удаления
+	_, err = f.calls.GetByID(ctx, call.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	keys, err := f.apiKeys.ListAPIKeys(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/model"
+	"call-service/internal/notify"
+	"call-service/internal/repository"
+	"common/clock"
+)
+
+// Ошибки операций с настройками уведомлений
+
+var (
+	ErrInvalidNotificationPreference = errors.New("unknown notification event or channel")
+	ErrTelegramDisabled              = errors.New("telegram notifications are disabled")
+	ErrTelegramNotLinked             = errors.New("telegram chat is not linked")
+	ErrInvalidTelegramLinkCode       = errors.New("telegram link code is invalid or expired")
+)
+
+// Параметры привязки чата Telegram
+const (
+	// TelegramLinkCodeTTL — срок действия кода привязки.
+	TelegramLinkCodeTTL = 15 * time.Minute
+	// telegramLinkCodeBytes — длина кода привязки в байтах; в шестнадцатеричном виде код
+	// укладывается в 64 символа, которые Telegram передает в команде /start.
+	telegramLinkCodeBytes = 16
+)
+
+// NotificationService определяет интерфейс сервиса настроек уведомлений пользователей:
+// о каких событиях и по каким каналам пользователь получает уведомления, и привязки чата
+// Telegram. Сервис также сообщает notify.Dispatcher каналы пользователя (notify.Preferences)
+// и чат Telegram (notify.TelegramChats)
+
+type NotificationService interface {
+	// EnabledChannels возвращает каналы, включенные в сервисе.
+	EnabledChannels() []string
+	// ListPreferences возвращает настройки пользователя для всех событий и включенных каналов;
+	// несохраненные настройки включены.
+	ListPreferences(ctx context.Context, userID uuid.UUID) ([]*model.NotificationPreference, error)
+	// UpdatePreferences сохраняет настройки prefs пользователя и возвращает все его настройки.
+	// Неизвестное событие или канал, не включенный в сервисе, — ErrInvalidNotificationPreference.
+	UpdatePreferences(ctx context.Context, userID uuid.UUID, prefs []model.NotificationPreference) ([]*model.NotificationPreference, error)
+	// GetTelegramLink возвращает привязанный чат или ErrTelegramNotLinked.
+	GetTelegramLink(ctx context.Context, userID uuid.UUID) (*model.TelegramLink, error)
+	// CreateTelegramLinkCode создает код привязки чата, действующий TelegramLinkCodeTTL;
+	// прежний код пользователя перестает действовать.
+	CreateTelegramLinkCode(ctx context.Context, userID uuid.UUID) (string, time.Time, error)
+	// LinkTelegram привязывает чат chatID к пользователю кода code или возвращает
+	// ErrInvalidTelegramLinkCode.
+	LinkTelegram(ctx context.Context, code string, chatID int64) (*model.TelegramLink, error)
+	UnlinkTelegram(ctx context.Context, userID uuid.UUID) error
+	// DeleteUserNotifications удаляет настройки и привязку чата при удалении учетной записи.
+	DeleteUserNotifications(ctx context.Context, userID uuid.UUID) error
+
+	notify.Preferences
+	notify.TelegramChats
+}
+
+// notificationService реализует интерфейс NotificationService
+
+type notificationService struct {
+	repo     repository.NotificationRepository
+	channels []string
+	clock    clock.Clock
+}
+
+// NewNotificationService создает новый экземпляр сервиса настроек уведомлений с каналами
+// channels (model.NotificationChannel*), включенными в сервисе
+
+func NewNotificationService(repo repository.NotificationRepository, channels []string) NotificationService {
+	return &notificationService{repo: repo, channels: channels, clock: clock.Real}
+}
+
+// EnabledChannels возвращает каналы, включенные в сервисе
+
+func (s *notificationService) EnabledChannels() []string {
+	return s.channels
+}
+
+// ListPreferences возвращает настройки пользователя в порядке model.NotificationEvents
+// и model.NotificationChannels
+
+func (s *notificationService) ListPreferences(ctx context.Context, userID uuid.UUID) ([]*model.NotificationPreference, error) {
+	stored, err := s.repo.ListPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var prefs []*model.NotificationPreference
+	for _, event := range model.NotificationEvents {
+		for _, channel := range model.NotificationChannels {
+			if !slices.Contains(s.channels, channel) {
+				continue
+			}
+			pref := &model.NotificationPreference{UserID: userID, Event: event, Channel: channel, Enabled: true}
+			for _, saved := range stored {
+				if saved.Event == event && saved.Channel == channel {
+					pref = saved
+				}
+			}
+			prefs = append(prefs, pref)
+		}
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences проверяет и сохраняет настройки пользователя
+
+func (s *notificationService) UpdatePreferences(ctx context.Context, userID uuid.UUID, prefs []model.NotificationPreference) ([]*model.NotificationPreference, error) {
+	now := s.clock.Now().UTC()
+	updated := make([]*model.NotificationPreference, 0, len(prefs))
+	for _, pref := range prefs {
+		if !slices.Contains(model.NotificationEvents, pref.Event) || !slices.Contains(s.channels, pref.Channel) {
+			return nil, fmt.Errorf("%w: %s via %s", ErrInvalidNotificationPreference, pref.Event, pref.Channel)
+		}
+		updated = append(updated, &model.NotificationPreference{
+			UserID:    userID,
+			Event:     pref.Event,
+			Channel:   pref.Channel,
+			Enabled:   pref.Enabled,
+			UpdatedAt: now,
+		})
+	}
+	if err := s.repo.SavePreferences(ctx, updated); err != nil {
+		return nil, err
+	}
+	return s.ListPreferences(ctx, userID)
+}
+
+// GetTelegramLink возвращает привязанный чат пользователя
+
+func (s *notificationService) GetTelegramLink(ctx context.Context, userID uuid.UUID) (*model.TelegramLink, error) {
+	link, err := s.repo.GetTelegramLink(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrTelegramNotLinked
+	}
+	return link, err
+}
+
+// CreateTelegramLinkCode создает случайный код привязки и сохраняет его хеш
+
+func (s *notificationService) CreateTelegramLinkCode(ctx context.Context, userID uuid.UUID) (string, time.Time, error) {
+	if !slices.Contains(s.channels, model.NotificationChannelTelegram) {
+		return "", time.Time{}, ErrTelegramDisabled
+	}
+	buf := make([]byte, telegramLinkCodeBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	code := hex.EncodeToString(buf)
+	expiresAt := s.clock.Now().Add(TelegramLinkCodeTTL).UTC()
+	if err := s.repo.CreateTelegramLinkCode(ctx, &model.TelegramLinkCode{
+		CodeHash:  hashTelegramLinkCode(code),
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return "", time.Time{}, err
+	}
+	return code, expiresAt, nil
+}
+
+// LinkTelegram привязывает чат по коду; код действует один раз
+
+func (s *notificationService) LinkTelegram(ctx context.Context, code string, chatID int64) (*model.TelegramLink, error) {
+	link, err := s.repo.ConsumeTelegramLinkCode(ctx, hashTelegramLinkCode(code), chatID, s.clock.Now().UTC())
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidTelegramLinkCode
+	}
+	return link, err
+}
+
+// UnlinkTelegram удаляет привязку чата пользователя
+
+func (s *notificationService) UnlinkTelegram(ctx context.Context, userID uuid.UUID) error {
+	err := s.repo.DeleteTelegramLink(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrTelegramNotLinked
+	}
+	return err
+}
+
+// DeleteUserNotifications удаляет настройки, привязку и код привязки пользователя
+
+func (s *notificationService) DeleteUserNotifications(ctx context.Context, userID uuid.UUID) error {
+	return s.repo.DeleteByUserID(ctx, userID)
+}
+
+// Channels возвращает каналы, по которым пользователь получает уведомления о событии event
+
+func (s *notificationService) Channels(ctx context.Context, userID uuid.UUID, event string) ([]string, error) {
+	prefs, err := s.ListPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var channels []string
+	for _, pref := range prefs {
+		if pref.Event == event && pref.Enabled {
+			channels = append(channels, pref.Channel)
+		}
+	}
+	return channels, nil
+}
+
+// TelegramChat возвращает чат Telegram пользователя или notify.ErrNoRecipient
+
+func (s *notificationService) TelegramChat(ctx context.Context, userID uuid.UUID) (int64, error) {
+	link, err := s.repo.GetTelegramLink(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, notify.ErrNoRecipient
+	}
+	if err != nil {
+		return 0, err
+	}
+	return link.ChatID, nil
+}
+
+// hashTelegramLinkCode возвращает SHA-256 хеш кода привязки в шестнадцатеричном виде.
+func hashTelegramLinkCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+	"call-service/internal/notify"
+	"call-service/internal/repository"
+	"common/clock"
+)
+
+// Тест выбора каналов по настройкам: без настроек уведомления включены во всех каналах
+// сервиса, отключенная настройка исключает канал только для своего события, а каналы,
+// не включенные в сервисе, не выбираются и не сохраняются
+func TestNotificationService_Channels(t *testing.T) {
+	svc := NewNotificationService(repository.NewInMemoryNotificationRepository(), []string{model.NotificationChannelTelegram})
+	ctx := context.Background()
+	userID := uuid.New()
+
+	channels, err := svc.Channels(ctx, userID, model.NotificationCallbackDue)
+	require.NoError(t, err)
+	assert.Equal(t, []string{model.NotificationChannelTelegram}, channels)
+
+	_, err = svc.UpdatePreferences(ctx, userID, []model.NotificationPreference{
+		{Event: model.NotificationCallbackDue, Channel: model.NotificationChannelEmail, Enabled: false},
+	})
+	assert.ErrorIs(t, err, ErrInvalidNotificationPreference)
+	_, err = svc.UpdatePreferences(ctx, userID, []model.NotificationPreference{
+		{Event: "unknown", Channel: model.NotificationChannelTelegram},
+	})
+	assert.ErrorIs(t, err, ErrInvalidNotificationPreference)
+
+	prefs, err := svc.UpdatePreferences(ctx, userID, []model.NotificationPreference{
+		{Event: model.NotificationCallbackDue, Channel: model.NotificationChannelTelegram, Enabled: false},
+	})
+	require.NoError(t, err)
+	require.Len(t, prefs, len(model.NotificationEvents))
+	assert.False(t, prefs[0].Enabled)
+	assert.True(t, prefs[1].Enabled)
+
+	channels, err = svc.Channels(ctx, userID, model.NotificationCallbackDue)
+	require.NoError(t, err)
+	assert.Empty(t, channels)
+	channels, err = svc.Channels(ctx, userID, model.NotificationCallAssigned)
+	require.NoError(t, err)
+	assert.Equal(t, []string{model.NotificationChannelTelegram}, channels)
+	channels, err = svc.Channels(ctx, uuid.New(), model.NotificationCallbackDue)
+	require.NoError(t, err)
+	assert.Equal(t, []string{model.NotificationChannelTelegram}, channels, "other users are not affected")
+}
+
+// Тест привязки чата Telegram: код действует один раз и до истечения срока, чат без привязки
+// не является получателем уведомлений
+func TestNotificationService_TelegramLink(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	svc := NewNotificationService(repository.NewInMemoryNotificationRepository(), model.NotificationChannels).(*notificationService)
+	svc.clock = fake
+	ctx := context.Background()
+	userID := uuid.New()
+
+	_, err := svc.TelegramChat(ctx, userID)
+	assert.ErrorIs(t, err, notify.ErrNoRecipient)
+
+	code, expiresAt, err := svc.CreateTelegramLinkCode(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(TelegramLinkCodeTTL), expiresAt)
+	_, err = svc.LinkTelegram(ctx, "wrong", 42)
+	assert.ErrorIs(t, err, ErrInvalidTelegramLinkCode)
+
+	link, err := svc.LinkTelegram(ctx, code, 42)
+	require.NoError(t, err)
+	assert.Equal(t, userID, link.UserID)
+	_, err = svc.LinkTelegram(ctx, code, 43)
+	assert.ErrorIs(t, err, ErrInvalidTelegramLinkCode)
+	chatID, err := svc.TelegramChat(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), chatID)
+
+	code, _, err = svc.CreateTelegramLinkCode(ctx, userID)
+	require.NoError(t, err)
+	fake.Advance(TelegramLinkCodeTTL)
+	_, err = svc.LinkTelegram(ctx, code, 43)
+	assert.ErrorIs(t, err, ErrInvalidTelegramLinkCode)
+
+	require.NoError(t, svc.UnlinkTelegram(ctx, userID))
+	assert.ErrorIs(t, svc.UnlinkTelegram(ctx, userID), ErrTelegramNotLinked)
+
+	disabled := NewNotificationService(repository.NewInMemoryNotificationRepository(), []string{model.NotificationChannelEmail})
+	_, _, err = disabled.CreateTelegramLinkCode(ctx, userID)
+	assert.ErrorIs(t, err, ErrTelegramDisabled)
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"common/clock"
+)
+
+// Ошибки операций с сохраненными представлениями
+
+var (
+	ErrSavedViewNotFound    = errors.New("saved view not found")
+	ErrInvalidSavedViewName = errors.New("saved view name must be 1 to 100 characters long")
+	ErrSavedViewNameTaken   = errors.New("saved view name is already taken")
+	ErrTooManySavedViews    = errors.New("too many saved views")
+	// ErrUnsupportedSavedView возвращается для представления, сохраненного более новой
+	// версией сервиса, схему фильтра которой эта версия не знает.
+	ErrUnsupportedSavedView = errors.New("saved view schema version is not supported")
+)
+
+// Параметры сохраненных представлений
+const (
+	// MaxSavedViews — максимальное число представлений одного пользователя.
+	MaxSavedViews = 20
+	// maxSavedViewNameLength — максимальная длина названия представления в символах.
+	maxSavedViewNameLength = 100
+)
+
+// savedViewUpgrades — преобразования фильтров между версиями схемы: savedViewUpgrades[i]
+// преобразует фильтр версии i+1 в фильтр версии i+2. При изменении параметров списка
+// заявок, после которого сохраненные фильтры нужно преобразовать, сюда добавляется
+// преобразование, а model.SavedViewSchemaVersion увеличивается на единицу.
+var savedViewUpgrades []func(filter map[string]string) map[string]string
+
+// SavedViewService определяет интерфейс сервиса сохраненных представлений — именованных
+// фильтров списка заявок. Фильтр проверяется обработчиком по параметрам списка заявок
+// до сохранения; сервис хранит его с текущей версией схемы и преобразует фильтры,
+// сохраненные с прежними версиями, при чтении
+
+type SavedViewService interface {
+	// CreateView сохраняет представление; не больше MaxSavedViews у пользователя,
+	// занятое название — ErrSavedViewNameTaken.
+	CreateView(ctx context.Context, userID uuid.UUID, name string, filter map[string]string) (*model.SavedView, error)
+	ListViews(ctx context.Context, userID uuid.UUID) ([]*model.SavedView, error)
+	GetView(ctx context.Context, userID, id uuid.UUID) (*model.SavedView, error)
+	// UpdateView заменяет название и фильтр представления.
+	UpdateView(ctx context.Context, userID, id uuid.UUID, name string, filter map[string]string) (*model.SavedView, error)
+	DeleteView(ctx context.Context, userID, id uuid.UUID) error
+	// DeleteUserViews удаляет все представления пользователя при удалении его учетной записи.
+	DeleteUserViews(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+// savedViewService реализует интерфейс SavedViewService
+
+type savedViewService struct {
+	repo  repository.SavedViewRepository
+	clock clock.Clock
+	// schemaVersion и upgrades — текущая версия схемы и преобразования к ней; в тестах
+	// подменяются, чтобы проверить преобразование фильтров.
+	schemaVersion int
+	upgrades      []func(map[string]string) map[string]string
+}
+
+// NewSavedViewService создает новый экземпляр сервиса сохраненных представлений
+
+func NewSavedViewService(repo repository.SavedViewRepository) SavedViewService {
+	return &savedViewService{
+		repo:          repo,
+		clock:         clock.Real,
+		schemaVersion: model.SavedViewSchemaVersion,
+		upgrades:      savedViewUpgrades,
+	}
+}
+
+// CreateView создает представление пользователя. Число представлений проверяется перед
+// сохранением, поэтому одновременные запросы одного пользователя могут превысить
+// MaxSavedViews на несколько представлений
+
+func (s *savedViewService) CreateView(ctx context.Context, userID uuid.UUID, name string, filter map[string]string) (*model.SavedView, error) {
+	name, err := savedViewName(name)
+	if err != nil {
+		return nil, err
+	}
+	n, err := s.repo.CountByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if n >= MaxSavedViews {
+		return nil, ErrTooManySavedViews
+	}
+
+	now := s.clock.Now().UTC()
+	view := &model.SavedView{
+		UserID:        userID,
+		Name:          name,
+		Filter:        nonNilFilter(filter),
+		SchemaVersion: s.schemaVersion,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.repo.Create(ctx, view); err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, ErrSavedViewNameTaken
+		}
+		return nil, err
+	}
+	return view, nil
+}
+
+// ListViews возвращает представления пользователя по названию; фильтры прежних версий
+// схемы преобразуются к текущей. Представления более новых версий возвращаются как есть
+
+func (s *savedViewService) ListViews(ctx context.Context, userID uuid.UUID) ([]*model.SavedView, error) {
+	views, err := s.repo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, view := range views {
+		if view.SchemaVersion < s.schemaVersion {
+			s.upgrade(ctx, view)
+		}
+	}
+	return views, nil
+}
+
+// GetView возвращает представление пользователя с фильтром текущей версии схемы
+
+func (s *savedViewService) GetView(ctx context.Context, userID, id uuid.UUID) (*model.SavedView, error) {
+	view, err := s.repo.Get(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrSavedViewNotFound
+		}
+		return nil, err
+	}
+	if view.SchemaVersion > s.schemaVersion {
+		return nil, fmt.Errorf("%w: version %d, supported %d", ErrUnsupportedSavedView, view.SchemaVersion, s.schemaVersion)
+	}
+	if view.SchemaVersion < s.schemaVersion {
+		s.upgrade(ctx, view)
+	}
+	return view, nil
+}
+
+// UpdateView заменяет название и фильтр представления пользователя; фильтр сохраняется
+// с текущей версией схемы
+
+func (s *savedViewService) UpdateView(ctx context.Context, userID, id uuid.UUID, name string, filter map[string]string) (*model.SavedView, error) {
+	name, err := savedViewName(name)
+	if err != nil {
+		return nil, err
+	}
+	view, err := s.repo.Get(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrSavedViewNotFound
+		}
+		return nil, err
+	}
+
+	view.Name = name
+	view.Filter = nonNilFilter(filter)
+	view.SchemaVersion = s.schemaVersion
+	view.UpdatedAt = s.clock.Now().UTC()
+	if err := s.repo.Update(ctx, view); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrAlreadyExists):
+			return nil, ErrSavedViewNameTaken
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrSavedViewNotFound
+		}
+		return nil, err
+	}
+	return view, nil
+}
+
+// DeleteView удаляет представление пользователя
+
+func (s *savedViewService) DeleteView(ctx context.Context, userID, id uuid.UUID) error {
+	if err := s.repo.Delete(ctx, userID, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrSavedViewNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteUserViews удаляет все представления пользователя
+
+func (s *savedViewService) DeleteUserViews(ctx context.Context, userID uuid.UUID) (int, error) {
+	return s.repo.DeleteByUserID(ctx, userID)
+}
+
+// upgrade преобразует фильтр представления прежней версии схемы к текущей и сохраняет его,
+// чтобы преобразование выполнялось один раз. Ошибка сохранения записывается в журнал:
+// представление возвращается преобразованным, а сохранение повторится при следующем чтении.
+func (s *savedViewService) upgrade(ctx context.Context, view *model.SavedView) {
+	for version := max(view.SchemaVersion, 1); version < s.schemaVersion; version++ {
+		view.Filter = nonNilFilter(s.upgrades[version-1](view.Filter))
+	}
+	view.SchemaVersion = s.schemaVersion
+	if err := s.repo.Update(ctx, view); err != nil {
+		log.Printf("failed to save upgraded saved view %s: %v", view.ID, err)
+	}
+}
+
+// savedViewName возвращает название представления без пробелов по краям или
+// ErrInvalidSavedViewName.
+func savedViewName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxSavedViewNameLength {
+		return "", ErrInvalidSavedViewName
+	}
+	return name, nil
+}
+
+// nonNilFilter заменяет отсутствующий фильтр пустым, чтобы в хранилище не попадал null.
+func nonNilFilter(filter map[string]string) map[string]string {
+	if filter == nil {
+		return map[string]string{}
+	}
+	return filter
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+	"call-service/internal/repository"
+)
+
+// Тест ограничений представлений: название обязательно и уникально у пользователя,
+// у пользователя не больше MaxSavedViews представлений, чужое представление не найдено
+func TestSavedView_Limits(t *testing.T) {
+	svc := NewSavedViewService(repository.NewInMemorySavedViewRepository())
+	ctx := context.Background()
+	userID := uuid.New()
+
+	view, err := svc.CreateView(ctx, userID, "  open  ", map[string]string{"status": "open"})
+	require.NoError(t, err)
+	assert.Equal(t, "open", view.Name)
+	assert.Equal(t, model.SavedViewSchemaVersion, view.SchemaVersion)
+
+	_, err = svc.CreateView(ctx, userID, "open", nil)
+	assert.ErrorIs(t, err, ErrSavedViewNameTaken)
+	_, err = svc.CreateView(ctx, userID, " ", nil)
+	assert.ErrorIs(t, err, ErrInvalidSavedViewName)
+	_, err = svc.GetView(ctx, uuid.New(), view.ID)
+	assert.ErrorIs(t, err, ErrSavedViewNotFound)
+
+	for i := 1; i < MaxSavedViews; i++ {
+		_, err := svc.CreateView(ctx, userID, fmt.Sprintf("view %d", i), nil)
+		require.NoError(t, err)
+	}
+	_, err = svc.CreateView(ctx, userID, "one more", nil)
+	assert.ErrorIs(t, err, ErrTooManySavedViews)
+
+	// Удаление освобождает место, переименование в занятое название отклоняется
+	require.NoError(t, svc.DeleteView(ctx, userID, view.ID))
+	assert.ErrorIs(t, svc.DeleteView(ctx, userID, view.ID), ErrSavedViewNotFound)
+	other, err := svc.CreateView(ctx, userID, "one more", map[string]string{})
+	require.NoError(t, err)
+	_, err = svc.UpdateView(ctx, userID, other.ID, "view 1", nil)
+	assert.ErrorIs(t, err, ErrSavedViewNameTaken)
+	updated, err := svc.UpdateView(ctx, userID, other.ID, "closed", map[string]string{"status": "closed"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"status": "closed"}, updated.Filter)
+}
+
+// Тест преобразования схемы: фильтр прежней версии преобразуется при чтении и сохраняется
+// преобразованным, представление более новой версии не применяется
+func TestSavedView_SchemaUpgrade(t *testing.T) {
+	repo := repository.NewInMemorySavedViewRepository()
+	svc := NewSavedViewService(repo).(*savedViewService)
+	svc.schemaVersion = 3
+	svc.upgrades = []func(map[string]string) map[string]string{
+		// Версия 2: параметр state переименован в status
+		func(filter map[string]string) map[string]string {
+			filter = maps.Clone(filter)
+			if state, ok := filter["state"]; ok {
+				delete(filter, "state")
+				filter["status"] = state
+			}
+			return filter
+		},
+		// Версия 3: сортировка по умолчанию задается явно
+		func(filter map[string]string) map[string]string {
+			filter = maps.Clone(filter)
+			if _, ok := filter["sort"]; !ok {
+				filter["sort"] = "created_at"
+			}
+			return filter
+		},
+	}
+	ctx := context.Background()
+	userID := uuid.New()
+
+	old := &model.SavedView{UserID: userID, Name: "old", Filter: map[string]string{"state": "open"}, SchemaVersion: 1}
+	require.NoError(t, repo.Create(ctx, old))
+	newer := &model.SavedView{UserID: userID, Name: "newer", Filter: map[string]string{}, SchemaVersion: 4}
+	require.NoError(t, repo.Create(ctx, newer))
+
+	view, err := svc.GetView(ctx, userID, old.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, view.SchemaVersion)
+	assert.Equal(t, map[string]string{"status": "open", "sort": "created_at"}, view.Filter)
+	stored, err := repo.Get(ctx, userID, old.ID)
+	require.NoError(t, err)
+	assert.Equal(t, view.Filter, stored.Filter)
+	assert.Equal(t, 3, stored.SchemaVersion)
+
+	_, err = svc.GetView(ctx, userID, newer.ID)
+	assert.ErrorIs(t, err, ErrUnsupportedSavedView)
+	views, err := svc.ListViews(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, views, 2)
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/model"
+	"call-service/pkg/authclient"
+	"common/clock"
+)
+
+// Параметры справочника имен пользователей
+const (
+	// DefaultUsernameCacheTTL — время, в течение которого имя пользователя не перезапрашивается
+	// у сервиса аутентификации.
+	DefaultUsernameCacheTTL = 5 * time.Minute
+	// usernameCacheSize — максимальное число имен в кэше.
+	usernameCacheSize = 10000
+)
+
+// UserLookup получает данные пользователей пакетом; реализуется authclient.AuthClient.
+
+type UserLookup interface {
+	GetUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]authclient.UserInfo, error)
+}
+
+// usernameEntry — имя пользователя в кэше UserDirectory. Пустое имя означает,
+// что сервис аутентификации не знает такого пользователя.
+
+type usernameEntry struct {
+	username    string
+	cachedUntil time.Time
+}
+
+// UserDirectory сопоставляет ID пользователей с их именами для показа в ответах API.
+// Имена, которых нет в кэше, запрашиваются у сервиса аутентификации одним вызовом GetUsers
+// и кэшируются на время ttl.
+
+type UserDirectory struct {
+	lookup UserLookup
+	clock  clock.Clock
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]usernameEntry
+}
+
+// NewUserDirectory создает справочник имен пользователей. Значение ttl <= 0 означает
+// DefaultUsernameCacheTTL; nil вместо часов — системное время.
+
+func NewUserDirectory(lookup UserLookup, ttl time.Duration, c clock.Clock) *UserDirectory {
+	if ttl <= 0 {
+		ttl = DefaultUsernameCacheTTL
+	}
+	if c == nil {
+		c = clock.Real
+	}
+	return &UserDirectory{lookup: lookup, clock: c, ttl: ttl, cache: make(map[uuid.UUID]usernameEntry)}
+}
+
+// SetTTL заменяет время кэширования имен; значение <= 0 означает DefaultUsernameCacheTTL.
+// Новое время действует для имен, запрошенных после замены.
+
+func (d *UserDirectory) SetTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultUsernameCacheTTL
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.ttl = ttl
+}
+
+// Usernames возвращает имена указанных пользователей; неизвестные пользователи в результат не попадают.
+// Для model.SystemActorID возвращается model.SystemActorName без обращения к сервису аутентификации.
+// Если сервис аутентификации недоступен, ошибка записывается в журнал, а для пользователей
+// без действующей записи в кэше используются устаревшие записи или имена не возвращаются:
+// ответ API в этом случае содержит только ID.
+
+func (d *UserDirectory) Usernames(ctx context.Context, ids []uuid.UUID) map[uuid.UUID]string {
+	names := make(map[uuid.UUID]string, len(ids))
+	now := d.clock.Now()
+
+	var missing []uuid.UUID
+	seen := make(map[uuid.UUID]bool, len(ids))
+	d.mu.Lock()
+	for _, id := range ids {
+		if id == uuid.Nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if id == model.SystemActorID {
+			names[id] = model.SystemActorName
+			continue
+		}
+		entry, ok := d.cache[id]
+		if ok && entry.username != "" {
+			names[id] = entry.username
+		}
+		if !ok || !now.Before(entry.cachedUntil) {
+			missing = append(missing, id)
+		}
+	}
+	d.mu.Unlock()
+
+	if len(missing) == 0 {
+		return names
+	}
+	users, err := d.lookup.GetUsers(ctx, missing)
+	if err != nil {
+		log.Printf("failed to resolve usernames: %v", err)
+		return names
+	}
+	d.store(missing, users, now, names)
+	return names
+}
+
+// store сохраняет в кэше результат запроса ids и добавляет найденные имена в names.
+// ID, которых нет в ответе, кэшируются как неизвестные.
+
+func (d *UserDirectory) store(ids []uuid.UUID, users map[uuid.UUID]authclient.UserInfo, now time.Time, names map[uuid.UUID]string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.cache)+len(ids) > usernameCacheSize {
+		for id, entry := range d.cache {
+			if !now.Before(entry.cachedUntil) {
+				delete(d.cache, id)
+			}
+		}
+		if len(d.cache)+len(ids) > usernameCacheSize {
+			clear(d.cache)
+		}
+	}
+	for _, id := range ids {
+		username := users[id].Username
+		d.cache[id] = usernameEntry{username: username, cachedUntil: now.Add(d.ttl)}
+		if username != "" {
+			names[id] = username
+		} else {
+			delete(names, id)
+		}
+	}
+}
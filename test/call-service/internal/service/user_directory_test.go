@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/featureflags"
+	"call-service/internal/model"
+	"call-service/internal/repository"
+	"call-service/pkg/authclient"
+	"common/clock"
+)
+
+// fakeUserLookup отвечает именами из users и запоминает число ID в каждом вызове.
+type fakeUserLookup struct {
+	users   map[uuid.UUID]string
+	err     error
+	batches []int
+}
+
+func (l *fakeUserLookup) GetUsers(_ context.Context, userIDs []uuid.UUID) (map[uuid.UUID]authclient.UserInfo, error) {
+	l.batches = append(l.batches, len(userIDs))
+	if l.err != nil {
+		return nil, l.err
+	}
+	users := make(map[uuid.UUID]authclient.UserInfo)
+	for _, id := range userIDs {
+		if name, ok := l.users[id]; ok {
+			users[id] = authclient.UserInfo{UserID: id.String(), Username: name}
+		}
+	}
+	return users, nil
+}
+
+// Тест кэша имен: повторный запрос в пределах TTL не обращается к сервису аутентификации,
+// неизвестные пользователи тоже кэшируются
+func TestUserDirectory_Cache(t *testing.T) {
+	alice, unknown := uuid.New(), uuid.New()
+	lookup := &fakeUserLookup{users: map[uuid.UUID]string{alice: "alice"}}
+	fake := clock.NewFake(time.Now())
+	dir := NewUserDirectory(lookup, time.Minute, fake)
+
+	names := dir.Usernames(context.Background(), []uuid.UUID{alice, unknown, alice, uuid.Nil})
+	assert.Equal(t, map[uuid.UUID]string{alice: "alice"}, names)
+	assert.Equal(t, []int{2}, lookup.batches)
+
+	names = dir.Usernames(context.Background(), []uuid.UUID{alice, unknown})
+	assert.Equal(t, map[uuid.UUID]string{alice: "alice"}, names)
+	assert.Len(t, lookup.batches, 1)
+
+	fake.Advance(time.Minute)
+	lookup.users[alice] = "alice2"
+	names = dir.Usernames(context.Background(), []uuid.UUID{alice})
+	assert.Equal(t, map[uuid.UUID]string{alice: "alice2"}, names)
+	assert.Len(t, lookup.batches, 2)
+}
+
+// Тест недоступности сервиса аутентификации: возвращаются устаревшие имена из кэша,
+// для остальных пользователей имена не возвращаются
+func TestUserDirectory_Unavailable(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	lookup := &fakeUserLookup{users: map[uuid.UUID]string{alice: "alice", bob: "bob"}}
+	fake := clock.NewFake(time.Now())
+	dir := NewUserDirectory(lookup, time.Minute, fake)
+	dir.Usernames(context.Background(), []uuid.UUID{alice})
+
+	fake.Advance(time.Hour)
+	lookup.err = errors.New("connection refused")
+	names := dir.Usernames(context.Background(), []uuid.UUID{alice, bob})
+
+	assert.Equal(t, map[uuid.UUID]string{alice: "alice"}, names)
+}
+
+// Тест авторов заявки: создатель и автор изменения сохраняются и показываются по именам
+func TestCallService_AuthorNames(t *testing.T) {
+	owner, admin := uuid.New(), uuid.New()
+	lookup := &fakeUserLookup{users: map[uuid.UUID]string{owner: "owner", admin: "admin"}}
+	svc := NewCallService(repository.NewInMemoryCallRepository(),
+		WithUserDirectory(NewUserDirectory(lookup, time.Minute, nil)))
+	ctx := context.Background()
+
+	call, err := svc.CreateCall(ctx, &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001"}, owner)
+	require.NoError(t, err)
+	assert.Equal(t, owner, call.CreatedBy)
+	require.NoError(t, svc.UpdateCallStatusAdmin(ctx, call.ID, "closed", admin))
+
+	calls, _, err := svc.GetAllCalls(ctx, owner, model.CallFilter{})
+	require.NoError(t, err)
+	require.Len(t, calls, 1)
+	assert.Equal(t, "owner", calls[0].CreatedByName)
+	require.NotNil(t, calls[0].UpdatedBy)
+	assert.Equal(t, admin, *calls[0].UpdatedBy)
+	assert.Equal(t, "admin", calls[0].UpdatedByName)
+	assert.Equal(t, []int{2}, lookup.batches)
+}
+
+// Тест флага featureflags.ResolveUsernames: выключенный флаг отключает заполнение имен
+// без обращений к справочнику
+func TestCallService_AuthorNamesDisabledByFlag(t *testing.T) {
+	owner := uuid.New()
+	lookup := &fakeUserLookup{users: map[uuid.UUID]string{owner: "owner"}}
+	svc := NewCallService(repository.NewInMemoryCallRepository(),
+		WithUserDirectory(NewUserDirectory(lookup, time.Minute, nil)),
+		WithFeatureFlags(featureflags.Static{featureflags.ResolveUsernames: false}))
+	ctx := context.Background()
+
+	_, err := svc.CreateCall(ctx, &model.CreateCallRequest{ClientName: "Иван", PhoneNumber: "+79990000001"}, owner)
+	require.NoError(t, err)
+	calls, _, err := svc.GetAllCalls(ctx, owner, model.CallFilter{})
+	require.NoError(t, err)
+	require.Len(t, calls, 1)
+	assert.Empty(t, calls[0].CreatedByName)
+	assert.Empty(t, lookup.batches)
+}
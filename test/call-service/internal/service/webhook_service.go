@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"call-service/internal/model"
+	"call-service/internal/notify"
+	"call-service/internal/repository"
+	"call-service/internal/safehttp"
+	"common/clock"
+)
+
+// Ошибки операций с подписками на события заявок
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrInvalidWebhookURL       = errors.New("webhook url is not allowed")
+	ErrTooManyWebhooks         = errors.New("too many webhooks")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrWebhookDeliveryNotFailed возвращается при ручном повторе доставки, которая еще
+	// ожидает попытки или уже доставлена.
+	ErrWebhookDeliveryNotFailed = errors.New("webhook delivery has not failed")
+)
+
+// Параметры подписок на события заявок
+const (
+	// MaxWebhooks — максимальное число подписок одного пользователя.
+	MaxWebhooks = 10
+	// MaxWebhookDeliveries — наибольшее число доставок в списке доставок подписки.
+	MaxWebhookDeliveries = 100
+	// MaxWebhookBulkRetry — наибольшее число доставок, повторяемых одним вызовом RetryFailed.
+	MaxWebhookBulkRetry = 100
+	// DefaultWebhookMaxAttempts — число попыток доставки по умолчанию, после которого
+	// доставка считается неудавшейся.
+	DefaultWebhookMaxAttempts = 5
+	// DefaultWebhookRetryDelay — задержка перед второй попыткой по умолчанию; каждая
+	// следующая задержка вдвое больше предыдущей.
+	DefaultWebhookRetryDelay = time.Minute
+	// DefaultWebhookRetention — срок хранения завершенных доставок по умолчанию.
+	DefaultWebhookRetention = 30 * 24 * time.Hour
+	// webhookSecretBytes — длина секрета подписи в байтах.
+	webhookSecretBytes = 32
+	// webhookDispatchBatch — наибольшее число доставок, отправляемых за один вызов DispatchDue.
+	webhookDispatchBatch = 100
+	// webhookPurgeBatch — число доставок, удаляемых одним запросом в PurgeDeliveries.
+	webhookPurgeBatch = 1000
+)
+
+// WebhookService определяет интерфейс сервиса подписок пользователей на события их заявок.
+//
+// События не отправляются сразу, а ставятся в очередь доставки: DispatchDue отправляет
+// доставки, время попытки которых наступило, и откладывает неудачные с растущей задержкой.
+// После MaxAttempts неудачных попыток доставка получает состояние failed; ее можно повторить
+// вручную, и повтор считается новой доставкой с полным числом попыток и задержками с начала.
+// Все операции с подпиской и ее доставками доступны только владельцу подписки: чужая
+// подписка не найдена
+
+type WebhookService interface {
+	// CreateWebhook создает подписку с новым секретом подписи; не больше MaxWebhooks
+	// у пользователя, адрес проверяется ограничениями WebhookConfig.Policy.
+	CreateWebhook(ctx context.Context, userID uuid.UUID, url string) (*model.Webhook, error)
+	ListWebhooks(ctx context.Context, userID uuid.UUID) ([]*model.Webhook, error)
+	// DeleteWebhook удаляет подписку вместе с ее доставками.
+	DeleteWebhook(ctx context.Context, userID, id uuid.UUID) error
+	// DeleteUserWebhooks удаляет все подписки пользователя при удалении его учетной записи.
+	DeleteUserWebhooks(ctx context.Context, userID uuid.UUID) (int, error)
+	// ListDeliveries возвращает до MaxWebhookDeliveries доставок подписки от новых к давним;
+	// пустой status — доставки в любом состоянии.
+	ListDeliveries(ctx context.Context, userID, webhookID uuid.UUID, status model.WebhookDeliveryStatus) ([]*model.WebhookDelivery, error)
+	// RetryDelivery снова ставит в очередь неудавшуюся доставку.
+	RetryDelivery(ctx context.Context, userID, webhookID, id uuid.UUID) error
+	// RetryFailed снова ставит в очередь до MaxWebhookBulkRetry давних неудавшихся доставок
+	// подписки и возвращает их количество.
+	RetryFailed(ctx context.Context, userID, webhookID uuid.UUID) (int, error)
+	// CallbackDue ставит событие notify.EventCallbackDue в очередь доставки подпискам
+	// владельца заявки; реализует notify.CallbackNotifier.
+	CallbackDue(ctx context.Context, call *model.Call) error
+	// DispatchDue отправляет доставки, время попытки которых наступило, и возвращает число
+	// доставленных. Ошибки отправки сохраняются в доставках; ошибка возвращается, только если
+	// не удалось прочитать или сохранить очередь.
+	DispatchDue(ctx context.Context) (int, error)
+	// PurgeDeliveries удаляет завершенные доставки старше WebhookConfig.Retention
+	// и возвращает их количество.
+	PurgeDeliveries(ctx context.Context) (int, error)
+}
+
+// WebhookConfig задает параметры WebhookService. Нулевые значения заменяются значениями по умолчанию.
+
+type WebhookConfig struct {
+	// Policy — ограничения адресов подписок: адрес проверяется при создании подписки
+	// и при каждом соединении.
+	Policy safehttp.Policy
+	// MaxAttempts — см. DefaultWebhookMaxAttempts.
+	MaxAttempts int
+	// RetryDelay — см. DefaultWebhookRetryDelay.
+	RetryDelay time.Duration
+	// Retention — см. DefaultWebhookRetention.
+	Retention time.Duration
+	// Clock — источник времени; nil — системное время.
+	Clock clock.Clock
+}
+
+// webhookService реализует интерфейс WebhookService
+
+type webhookService struct {
+	repo   repository.WebhookRepository
+	client *http.Client
+	cfg    WebhookConfig
+}
+
+// NewWebhookService создает новый экземпляр сервиса подписок на события заявок
+
+func NewWebhookService(repo repository.WebhookRepository, cfg WebhookConfig) WebhookService {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultWebhookMaxAttempts
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = DefaultWebhookRetryDelay
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = DefaultWebhookRetention
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clock.Real
+	}
+	return &webhookService{repo: repo, client: cfg.Policy.Client(), cfg: cfg}
+}
+
+// CreateWebhook создает подписку пользователя. Адрес, нарушающий ограничения, отклоняется;
+// ошибка разрешения имени не мешает созданию, так как адрес проверяется и при каждом соединении.
+// Число подписок проверяется перед сохранением, поэтому одновременные запросы одного
+// пользователя могут превысить MaxWebhooks на несколько подписок
+
+func (s *webhookService) CreateWebhook(ctx context.Context, userID uuid.UUID, url string) (*model.Webhook, error) {
+	if err := s.cfg.Policy.ValidateURL(ctx, url); err != nil {
+		if reason := safehttp.ReasonOf(err); reason != "" && reason != safehttp.ReasonTimeout {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidWebhookURL, err)
+		}
+		log.Printf("webhook url of user %s is not checked: %v", userID, err)
+	}
+	n, err := s.repo.CountByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if n >= MaxWebhooks {
+		return nil, ErrTooManyWebhooks
+	}
+
+	buf := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	webhook := &model.Webhook{
+		UserID:    userID,
+		URL:       url,
+		Secret:    hex.EncodeToString(buf),
+		CreatedAt: s.cfg.Clock.Now().UTC(),
+	}
+	if err := s.repo.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// ListWebhooks возвращает подписки пользователя по времени создания
+
+func (s *webhookService) ListWebhooks(ctx context.Context, userID uuid.UUID) ([]*model.Webhook, error) {
+	return s.repo.ListByUserID(ctx, userID)
+}
+
+// DeleteWebhook удаляет подписку пользователя
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, userID, id uuid.UUID) error {
+	if err := s.repo.Delete(ctx, userID, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrWebhookNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteUserWebhooks удаляет все подписки пользователя
+
+func (s *webhookService) DeleteUserWebhooks(ctx context.Context, userID uuid.UUID) (int, error) {
+	return s.repo.DeleteByUserID(ctx, userID)
+}
+
+// ListDeliveries возвращает доставки подписки пользователя
+
+func (s *webhookService) ListDeliveries(ctx context.Context, userID, webhookID uuid.UUID, status model.WebhookDeliveryStatus) ([]*model.WebhookDelivery, error) {
+	if err := s.checkOwner(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(ctx, webhookID, status, MaxWebhookDeliveries)
+}
+
+// RetryDelivery снова ставит в очередь неудавшуюся доставку подписки пользователя
+
+func (s *webhookService) RetryDelivery(ctx context.Context, userID, webhookID, id uuid.UUID) error {
+	if err := s.checkOwner(ctx, userID, webhookID); err != nil {
+		return err
+	}
+	delivery, err := s.repo.GetDelivery(ctx, webhookID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrWebhookDeliveryNotFound
+		}
+		return err
+	}
+	if delivery.Status != model.WebhookDeliveryFailed {
+		return ErrWebhookDeliveryNotFailed
+	}
+	// Доставка могла быть повторена одновременным запросом после чтения
+	if err := s.repo.RetryDelivery(ctx, webhookID, id, s.cfg.Clock.Now().UTC()); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrWebhookDeliveryNotFailed
+		}
+		return err
+	}
+	return nil
+}
+
+// RetryFailed снова ставит в очередь неудавшиеся доставки подписки пользователя
+
+func (s *webhookService) RetryFailed(ctx context.Context, userID, webhookID uuid.UUID) (int, error) {
+	if err := s.checkOwner(ctx, userID, webhookID); err != nil {
+		return 0, err
+	}
+	return s.repo.RetryFailed(ctx, webhookID, s.cfg.Clock.Now().UTC(), MaxWebhookBulkRetry)
+}
+
+// CallbackDue ставит событие о повторном звонке в очередь доставки подпискам владельца заявки.
+// Тело события кодируется один раз, поэтому все попытки отправляют одно и то же событие
+
+func (s *webhookService) CallbackDue(ctx context.Context, call *model.Call) error {
+	webhooks, err := s.repo.ListByUserID(ctx, call.UserID)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+	now := s.cfg.Clock.Now().UTC()
+	payload, err := json.Marshal(notify.Event{Type: notify.EventCallbackDue, OccurredAt: now, Call: call})
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", notify.EventCallbackDue, err)
+	}
+
+	deliveries := make([]*model.WebhookDelivery, len(webhooks))
+	for i, webhook := range webhooks {
+		deliveries[i] = &model.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventType:     notify.EventCallbackDue,
+			Payload:       payload,
+			Status:        model.WebhookDeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+	}
+	return s.repo.CreateDeliveries(ctx, deliveries)
+}
+
+// DispatchDue отправляет до webhookDispatchBatch доставок по очереди. Неудачная попытка
+// откладывает доставку на RetryDelay, удваивая задержку с каждой следующей попыткой;
+// после MaxAttempts попыток доставка получает состояние failed
+
+func (s *webhookService) DispatchDue(ctx context.Context) (int, error) {
+	deliveries, err := s.repo.ListDue(ctx, s.cfg.Clock.Now(), webhookDispatchBatch)
+	if err != nil {
+		return 0, err
+	}
+	delivered := 0
+	for _, delivery := range deliveries {
+		webhook := notify.NewWebhookWithClient(delivery.Webhook.URL, delivery.Webhook.Secret, s.client)
+		sendErr := webhook.Deliver(ctx, delivery.ID.String(), delivery.EventType, delivery.Payload)
+		// Попытка, прерванная остановкой задачи, не учитывается
+		if sendErr != nil && ctx.Err() != nil {
+			return delivered, ctx.Err()
+		}
+
+		now := s.cfg.Clock.Now().UTC()
+		delivery.UpdatedAt = now
+		if sendErr == nil {
+			delivery.Status = model.WebhookDeliveryDelivered
+			delivery.FailureReason, delivery.LastError = "", ""
+			delivered++
+		} else {
+			delivery.Attempts++
+			delivery.FailureReason, delivery.LastError = deliveryFailure(sendErr)
+			if delivery.Attempts >= s.cfg.MaxAttempts {
+				delivery.Status = model.WebhookDeliveryFailed
+			} else {
+				delivery.NextAttemptAt = now.Add(s.cfg.RetryDelay << (delivery.Attempts - 1))
+			}
+		}
+		if err := s.repo.UpdateDelivery(ctx, delivery); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			// Доставка будет отправлена повторно, так как ее состояние не сохранено
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+// PurgeDeliveries удаляет завершенные доставки по сроку хранения частями по webhookPurgeBatch,
+// чтобы не блокировать таблицу одним большим запросом
+
+func (s *webhookService) PurgeDeliveries(ctx context.Context) (int, error) {
+	before := s.cfg.Clock.Now().Add(-s.cfg.Retention)
+	purged := 0
+	for {
+		n, err := s.repo.DeleteFinishedBefore(ctx, before, webhookPurgeBatch)
+		purged += n
+		if err != nil || n < webhookPurgeBatch {
+			return purged, err
+		}
+	}
+}
+
+// checkOwner возвращает ErrWebhookNotFound, если подписка отсутствует или принадлежит
+// другому пользователю.
+func (s *webhookService) checkOwner(ctx context.Context, userID, webhookID uuid.UUID) error {
+	if _, err := s.repo.Get(ctx, userID, webhookID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrWebhookNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// deliveryFailure возвращает код причины неудачной отправки (см. notify.DeliveryError)
+// и ее описание.
+func deliveryFailure(err error) (string, string) {
+	var deliveryErr *notify.DeliveryError
+	if errors.As(err, &deliveryErr) {
+		return deliveryErr.Reason, deliveryErr.Err.Error()
+	}
+	return notify.ReasonRequest, err.Error()
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"call-service/internal/model"
+	"call-service/internal/notify"
+	"call-service/internal/repository"
+	"call-service/internal/safehttp"
+	"call-service/pkg/webhook/signing"
+	"common/clock"
+)
+
+// flakyReceiver — получатель событий, который отвечает 500 на первые failures запросов
+// и 200 на остальные, запоминая ID доставки каждого запроса с верной подписью.
+type flakyReceiver struct {
+	mu         sync.Mutex
+	secret     string
+	failures   int
+	requests   int
+	deliveries []string
+}
+
+func (r *flakyReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := signing.Verify(req.Header.Get(signing.SignatureHeader), r.secret, body, time.Hour); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	r.requests++
+	r.deliveries = append(r.deliveries, req.Header.Get(signing.DeliveryHeader))
+	if r.requests <= r.failures {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (r *flakyReceiver) deliveryIDs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.deliveries...)
+}
+
+// loopbackPolicy разрешает отправку на httptest-сервер по http.
+var loopbackPolicy = safehttp.Policy{AllowHTTP: true, AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}}
+
+// newWebhookFixture создает сервис подписок с хранилищем в памяти, тремя попытками доставки
+// и подписку пользователя на адрес receiverURL.
+func newWebhookFixture(t *testing.T, receiverURL string) (WebhookService, repository.WebhookRepository, *clock.Fake, *model.Webhook) {
+	t.Helper()
+	repo := repository.NewInMemoryWebhookRepository()
+	clk := clock.NewFake(time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC))
+	webhooks := NewWebhookService(repo, WebhookConfig{Policy: loopbackPolicy, MaxAttempts: 3, RetryDelay: time.Minute, Clock: clk})
+	webhook, err := webhooks.CreateWebhook(context.Background(), uuid.New(), receiverURL)
+	require.NoError(t, err)
+	return webhooks, repo, clk, webhook
+}
+
+// Тест неудавшейся доставки: после MaxAttempts отказов получателя доставка получает состояние
+// failed, а ручной повтор отправляет ее заново с тем же ID и полным числом попыток
+func TestWebhookService_RetryAfterFailures(t *testing.T) {
+	ctx := context.Background()
+	receiver := &flakyReceiver{failures: 4}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+	webhooks, _, clk, webhook := newWebhookFixture(t, srv.URL)
+	receiver.secret = webhook.Secret
+
+	call := &model.Call{ID: uuid.New(), UserID: webhook.UserID, ClientName: "Client"}
+	require.NoError(t, webhooks.CallbackDue(ctx, call))
+
+	// Отказы откладывают доставку на 1 и 2 минуты, третий отказ завершает попытки
+	for _, delay := range []time.Duration{0, time.Minute, 2 * time.Minute} {
+		clk.Advance(delay)
+		delivered, err := webhooks.DispatchDue(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, delivered)
+	}
+	clk.Advance(time.Hour)
+	delivered, err := webhooks.DispatchDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+	assert.Len(t, receiver.deliveryIDs(), 3)
+
+	failed, err := webhooks.ListDeliveries(ctx, webhook.UserID, webhook.ID, model.WebhookDeliveryFailed)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	delivery := failed[0]
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, notify.ReasonStatus, delivery.FailureReason)
+	assert.Contains(t, delivery.LastError, "500")
+	assert.Equal(t, notify.EventCallbackDue, delivery.EventType)
+
+	// Повтор доступен только владельцу подписки
+	err = webhooks.RetryDelivery(ctx, uuid.New(), webhook.ID, delivery.ID)
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+	err = webhooks.RetryDelivery(ctx, webhook.UserID, webhook.ID, uuid.New())
+	assert.ErrorIs(t, err, ErrWebhookDeliveryNotFound)
+
+	// Повтор — новая доставка: первая попытка отклонена, следующая через RetryDelay проходит
+	require.NoError(t, webhooks.RetryDelivery(ctx, webhook.UserID, webhook.ID, delivery.ID))
+	err = webhooks.RetryDelivery(ctx, webhook.UserID, webhook.ID, delivery.ID)
+	assert.ErrorIs(t, err, ErrWebhookDeliveryNotFailed)
+	delivered, err = webhooks.DispatchDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+
+	pending, err := webhooks.ListDeliveries(ctx, webhook.UserID, webhook.ID, model.WebhookDeliveryPending)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.Equal(t, clk.Now().Add(time.Minute), pending[0].NextAttemptAt)
+
+	clk.Advance(time.Minute)
+	delivered, err = webhooks.DispatchDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+
+	all, err := webhooks.ListDeliveries(ctx, webhook.UserID, webhook.ID, "")
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, model.WebhookDeliveryDelivered, all[0].Status)
+	assert.Empty(t, all[0].FailureReason)
+	for _, id := range receiver.deliveryIDs() {
+		assert.Equal(t, delivery.ID.String(), id)
+	}
+	assert.Len(t, receiver.deliveryIDs(), 5)
+}
+
+// Тест массового повтора: за один вызов повторяется не больше MaxWebhookBulkRetry доставок
+func TestWebhookService_RetryFailedIsBounded(t *testing.T) {
+	ctx := context.Background()
+	webhooks, repo, clk, webhook := newWebhookFixture(t, "http://127.0.0.1:1/hooks")
+
+	deliveries := make([]*model.WebhookDelivery, MaxWebhookBulkRetry+5)
+	for i := range deliveries {
+		deliveries[i] = &model.WebhookDelivery{
+			WebhookID: webhook.ID,
+			EventType: notify.EventCallbackDue,
+			Payload:   []byte(`{}`),
+			Status:    model.WebhookDeliveryFailed,
+			Attempts:  3,
+			CreatedAt: clk.Now().Add(time.Duration(i) * time.Second),
+			UpdatedAt: clk.Now(),
+		}
+	}
+	require.NoError(t, repo.CreateDeliveries(ctx, deliveries))
+
+	_, err := webhooks.RetryFailed(ctx, uuid.New(), webhook.ID)
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+
+	retried, err := webhooks.RetryFailed(ctx, webhook.UserID, webhook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, MaxWebhookBulkRetry, retried)
+	retried, err = webhooks.RetryFailed(ctx, webhook.UserID, webhook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, retried)
+	retried, err = webhooks.RetryFailed(ctx, webhook.UserID, webhook.ID)
+	require.NoError(t, err)
+	assert.Zero(t, retried)
+
+	pending, err := repo.ListDeliveries(ctx, webhook.ID, model.WebhookDeliveryPending, len(deliveries))
+	require.NoError(t, err)
+	require.Len(t, pending, len(deliveries))
+	for _, delivery := range pending {
+		assert.Zero(t, delivery.Attempts)
+		assert.Empty(t, delivery.FailureReason)
+	}
+}
+
+// Тест срока хранения: удаляются только завершенные доставки старше Retention
+func TestWebhookService_PurgeDeliveries(t *testing.T) {
+	ctx := context.Background()
+	webhooks, repo, clk, webhook := newWebhookFixture(t, "http://127.0.0.1:1/hooks")
+
+	old := clk.Now().Add(-DefaultWebhookRetention - time.Hour)
+	statuses := []model.WebhookDeliveryStatus{model.WebhookDeliveryDelivered, model.WebhookDeliveryFailed, model.WebhookDeliveryPending}
+	var deliveries []*model.WebhookDelivery
+	for _, status := range statuses {
+		for _, updatedAt := range []time.Time{old, clk.Now()} {
+			deliveries = append(deliveries, &model.WebhookDelivery{
+				WebhookID:     webhook.ID,
+				EventType:     notify.EventCallbackDue,
+				Payload:       []byte(`{}`),
+				Status:        status,
+				NextAttemptAt: updatedAt,
+				CreatedAt:     updatedAt,
+				UpdatedAt:     updatedAt,
+			})
+		}
+	}
+	require.NoError(t, repo.CreateDeliveries(ctx, deliveries))
+
+	purged, err := webhooks.PurgeDeliveries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+
+	left, err := repo.ListDeliveries(ctx, webhook.ID, "", len(deliveries))
+	require.NoError(t, err)
+	assert.Len(t, left, 4)
+	for _, delivery := range left {
+		assert.True(t, delivery.Status == model.WebhookDeliveryPending || delivery.UpdatedAt.Equal(clk.Now()))
+	}
+}
@@ -0,0 +1,87 @@
+package telephony
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// maxResponseSize ограничивает размер ответа провайдера, который читает HTTPDialer.
+const maxResponseSize = 64 << 10
+
+// httpDialRequest — тело запроса к HTTP API провайдера.
+type httpDialRequest struct {
+	TrackingID  uuid.UUID `json:"tracking_id"`
+	CallID      uuid.UUID `json:"call_id"`
+	PhoneNumber string    `json:"phone_number"`
+}
+
+// httpDialResponse — тело ответа HTTP API провайдера. При успехе заполнено Reference,
+// при отказе — Error или Message.
+type httpDialResponse struct {
+	Reference string `json:"reference"`
+	Error     string `json:"error"`
+	Message   string `json:"message"`
+}
+
+// HTTPDialer начинает звонки через HTTP API провайдера: POST-запрос с JSON-телом
+// {"tracking_id", "call_id", "phone_number"} и заголовком Authorization: Bearer <token>.
+// Ответ 2xx должен содержать {"reference": "..."}; ответ с другим статусом — отказ провайдера
+// с сообщением из поля error или message. Время вызова ограничивает ctx.
+type HTTPDialer struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewHTTPDialer создает HTTPDialer с адресом API url и токеном доступа token.
+func NewHTTPDialer(url, token string) *HTTPDialer {
+	return &HTTPDialer{url: url, token: token, client: &http.Client{}}
+}
+
+// Dial отправляет запрос на звонок провайдеру.
+func (d *HTTPDialer) Dial(ctx context.Context, req DialRequest) (string, error) {
+	body, err := json.Marshal(httpDialRequest{TrackingID: req.TrackingID, CallID: req.CallID, PhoneNumber: req.PhoneNumber})
+	if err != nil {
+		return "", fmt.Errorf("encode dial request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("build dial request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if d.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+d.token)
+	}
+
+	resp, err := d.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("send dial request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result httpDialResponse
+	decodeErr := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&result)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message := result.Error
+		if message == "" {
+			message = result.Message
+		}
+		if message == "" {
+			message = fmt.Sprintf("status %d", resp.StatusCode)
+		}
+		return "", &ProviderError{Provider: ProviderHTTP, Message: message}
+	}
+	if decodeErr != nil {
+		return "", fmt.Errorf("decode dial response: %w", decodeErr)
+	}
+	if result.Reference == "" {
+		return "", &ProviderError{Provider: ProviderHTTP, Message: "response has no call reference"}
+	}
+	return result.Reference, nil
+}
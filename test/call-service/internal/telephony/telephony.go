@@ -0,0 +1,122 @@
+// Package telephony начинает исходящие звонки клиентам заявок через провайдера телефонии
+// (click-to-call). Провайдер выбирается конфигурацией; без провайдера используется заглушка,
+// которая только записывает звонок в журнал.
+package telephony
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Провайдеры телефонии, которые можно выбрать в Config.Provider
+const (
+	// ProviderLog — заглушка LogDialer.
+	ProviderLog = "log"
+	// ProviderHTTP — провайдер с HTTP API (HTTPDialer).
+	ProviderHTTP = "http"
+)
+
+// maxMessageLength — наибольшая длина сообщения провайдера, которое возвращает SafeMessage.
+const maxMessageLength = 200
+
+// DialRequest описывает исходящий звонок по заявке.
+type DialRequest struct {
+	// TrackingID — ID попытки звонка в сервисе заявок; провайдер может использовать его
+	// как ключ идемпотентности и для сопоставления своих событий с попыткой.
+	TrackingID  uuid.UUID
+	CallID      uuid.UUID
+	PhoneNumber string
+	// UserID — пользователь, начавший звонок.
+	UserID uuid.UUID
+}
+
+// Dialer начинает исходящий звонок через провайдера телефонии.
+type Dialer interface {
+	// Dial просит провайдера начать звонок и возвращает ссылку провайдера на звонок.
+	// Отказ провайдера возвращается как *ProviderError.
+	Dial(ctx context.Context, req DialRequest) (string, error)
+}
+
+// ProviderError — отказ провайдера телефонии начать звонок с сообщением провайдера.
+type ProviderError struct {
+	Provider string
+	Message  string
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s provider: %s", e.Provider, e.Message)
+}
+
+// Config задает провайдера телефонии.
+type Config struct {
+	// Provider — ProviderLog (по умолчанию) или ProviderHTTP.
+	Provider string
+	// URL и Token — адрес API и токен доступа провайдера ProviderHTTP.
+	URL   string
+	Token string
+}
+
+// New создает Dialer провайдера cfg.Provider.
+func New(cfg Config) (Dialer, error) {
+	switch cfg.Provider {
+	case "", ProviderLog:
+		return LogDialer{}, nil
+	case ProviderHTTP:
+		if cfg.URL == "" {
+			return nil, errors.New("telephony: URL is required for the http provider")
+		}
+		return NewHTTPDialer(cfg.URL, cfg.Token), nil
+	}
+	return nil, fmt.Errorf("telephony: unknown provider %q", cfg.Provider)
+}
+
+// LogDialer — заглушка провайдера для разработки: записывает звонок в журнал без номера
+// телефона и возвращает ссылку вида "log-<TrackingID>".
+type LogDialer struct{}
+
+// Dial записывает звонок в журнал.
+func (LogDialer) Dial(ctx context.Context, req DialRequest) (string, error) {
+	log.Printf("telephony stub: dial for call %s requested by %s (tracking ID %s)", req.CallID, req.UserID, req.TrackingID)
+	return "log-" + req.TrackingID.String(), nil
+}
+
+// SafeMessage возвращает описание ошибки Dial, которое можно передать клиенту API. Для отказа
+// провайдера это его сообщение в одну строку без управляющих символов, без номера phone и не длиннее
+// maxMessageLength символов. Текст остальных ошибок может содержать внутренние адреса,
+// поэтому вместо него возвращается общее описание.
+func SafeMessage(err error, phone string) string {
+	var providerErr *ProviderError
+	switch {
+	case errors.As(err, &providerErr):
+		return sanitize(providerErr.Message, phone)
+	case errors.Is(err, context.DeadlineExceeded):
+		return "provider did not respond in time"
+	}
+	return "provider is unavailable"
+}
+
+func sanitize(message, phone string) string {
+	if phone != "" {
+		message = strings.ReplaceAll(message, phone, "***")
+	}
+	message = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsPrint(r) {
+			return r
+		}
+		return -1
+	}, message)
+	message = strings.Join(strings.Fields(message), " ")
+	if message == "" {
+		return "call rejected"
+	}
+	if runes := []rune(message); len(runes) > maxMessageLength {
+		message = string(runes[:maxMessageLength-1]) + "…"
+	}
+	return message
+}
@@ -0,0 +1,78 @@
+package telephony
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест HTTP-провайдера: запрос с токеном и JSON-телом, ссылка на звонок из ответа,
+// отказ провайдера как *ProviderError и ограничение времени вызова через ctx
+func TestHTTPDialer(t *testing.T) {
+	var received httpDialRequest
+	var status int
+	var response string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		if status == 0 {
+			time.Sleep(100 * time.Millisecond)
+			return
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, response)
+	}))
+	defer srv.Close()
+	dialer, err := New(Config{Provider: ProviderHTTP, URL: srv.URL, Token: "secret"})
+	require.NoError(t, err)
+	req := DialRequest{TrackingID: uuid.New(), CallID: uuid.New(), PhoneNumber: "+79990000001", UserID: uuid.New()}
+
+	status, response = http.StatusOK, `{"reference":"sip-42"}`
+	reference, err := dialer.Dial(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "sip-42", reference)
+	assert.Equal(t, req.TrackingID, received.TrackingID)
+	assert.Equal(t, req.PhoneNumber, received.PhoneNumber)
+
+	status, response = http.StatusUnprocessableEntity, `{"error":"number +79990000001 is blocked"}`
+	_, err = dialer.Dial(context.Background(), req)
+	var providerErr *ProviderError
+	require.ErrorAs(t, err, &providerErr)
+	assert.Equal(t, "number +79990000001 is blocked", providerErr.Message)
+
+	status = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = dialer.Dial(ctx, req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = New(Config{Provider: ProviderHTTP})
+	assert.Error(t, err)
+	_, err = New(Config{Provider: "sip"})
+	assert.Error(t, err)
+}
+
+// Тест сообщений для клиента: номер телефона и управляющие символы удаляются, длина
+// ограничивается, а текст ошибок, не являющихся отказом провайдера, не раскрывается
+func TestSafeMessage(t *testing.T) {
+	phone := "+79990000001"
+	err := fmt.Errorf("dial: %w", &ProviderError{Provider: ProviderHTTP, Message: "number +79990000001\r\nis\tblocked\x1b[0m"})
+	assert.Equal(t, "number *** is blocked[0m", SafeMessage(err, phone))
+
+	long := &ProviderError{Message: strings.Repeat("я", 2*maxMessageLength)}
+	assert.Len(t, []rune(SafeMessage(long, phone)), maxMessageLength)
+	assert.Equal(t, "call rejected", SafeMessage(&ProviderError{Message: " \n "}, phone))
+
+	assert.Equal(t, "provider did not respond in time", SafeMessage(fmt.Errorf("send: %w", context.DeadlineExceeded), phone))
+	assert.Equal(t, "provider is unavailable", SafeMessage(errors.New("dial tcp 10.0.0.5:443: connection refused"), phone))
+}
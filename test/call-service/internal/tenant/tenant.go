@@ -0,0 +1,44 @@
+// Package tenant передает членство пользователя в организации от middleware аутентификации
+// к сервисному слою через контекст запроса.
+//
+// Членство попадает в контекст, только если организации включены и токен пользователя
+// содержит организацию. Без членства в контексте доступ к заявкам проверяется только по
+// владельцу, как до появления организаций.
+package tenant
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Роли участника организации, возвращаемые сервисом аутентификации
+const (
+	RoleOwner  = "owner"
+	RoleMember = "member"
+)
+
+// Membership описывает членство пользователя запроса в организации.
+type Membership struct {
+	OrgID uuid.UUID
+	Role  string
+}
+
+// IsOwner сообщает, является ли пользователь владельцем организации.
+func (m Membership) IsOwner() bool {
+	return m.Role == RoleOwner
+}
+
+type membershipKey struct{}
+
+// WithMembership возвращает контекст с членством пользователя в организации.
+func WithMembership(ctx context.Context, m Membership) context.Context {
+	return context.WithValue(ctx, membershipKey{}, m)
+}
+
+// FromContext возвращает членство пользователя, сохраненное WithMembership. Второй результат
+// false, если пользователь не состоит в организации или организации отключены.
+func FromContext(ctx context.Context) (Membership, bool) {
+	m, ok := ctx.Value(membershipKey{}).(Membership)
+	return m, ok
+}
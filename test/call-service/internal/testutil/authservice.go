@@ -0,0 +1,89 @@
+// Package testutil содержит вспомогательные функции для тестов, которым нужен
+// настоящий сервис аутентификации.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// AuthServiceDir возвращает путь к исходникам сервиса аутентификации в репозитории.
+func AuthServiceDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "..", "auth-service")
+}
+
+// StartAuthService собирает сервис аутентификации из AuthServiceDir, запускает его
+// на свободных портах с дополнительными переменными окружения env и ждет, пока
+// gRPC-порт начнет принимать соединения. Возвращает адрес gRPC API и функцию остановки.
+func StartAuthService(ctx context.Context, env ...string) (string, func(), error) {
+	binDir, err := os.MkdirTemp("", "auth-service")
+	if err != nil {
+		return "", nil, err
+	}
+	binary := filepath.Join(binDir, "auth-service")
+	build := exec.CommandContext(ctx, "go", "build", "-o", binary, ".")
+	build.Dir = AuthServiceDir()
+	build.Stdout, build.Stderr = os.Stdout, os.Stderr
+	if err := build.Run(); err != nil {
+		_ = os.RemoveAll(binDir)
+		return "", nil, fmt.Errorf("build auth-service: %w", err)
+	}
+
+	grpcPort, err := freePort()
+	if err != nil {
+		_ = os.RemoveAll(binDir)
+		return "", nil, err
+	}
+	httpPort, err := freePort()
+	if err != nil {
+		_ = os.RemoveAll(binDir)
+		return "", nil, err
+	}
+
+	cmd := exec.Command(binary)
+	cmd.Env = append(os.Environ(), "GRPC_PORT="+grpcPort, "HTTP_PORT="+httpPort)
+	cmd.Env = append(cmd.Env, env...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Start(); err != nil {
+		_ = os.RemoveAll(binDir)
+		return "", nil, fmt.Errorf("start auth-service: %w", err)
+	}
+	stop := func() {
+		_ = cmd.Process.Signal(os.Interrupt)
+		_ = cmd.Wait()
+		_ = os.RemoveAll(binDir)
+	}
+
+	addr := net.JoinHostPort("localhost", grpcPort)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			_ = conn.Close()
+			return addr, stop, nil
+		}
+		select {
+		case <-ctx.Done():
+			stop()
+			return "", nil, fmt.Errorf("auth-service did not start: %w", ctx.Err())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// freePort возвращает номер свободного TCP-порта на localhost.
+func freePort() (string, error) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return "", err
+	}
+	defer lis.Close()
+	_, port, err := net.SplitHostPort(lis.Addr().String())
+	return port, err
+}
@@ -0,0 +1,224 @@
+// Package worker выполняет фоновую обработку элементов ограниченным числом горутин.
+//
+// Pool принимает элементы в очередь ограниченного размера и передает их обработчику
+// пачками до Config.BatchSize элементов. Когда очередь заполнена, Submit в зависимости
+// от Config.Policy ждет места в очереди или отбрасывает элемент. Паника обработчика
+// перехватывается и не останавливает пул, время обработки пачки ограничено
+// Config.TaskTimeout, а Close дожидается обработки элементов, оставшихся в очереди.
+//
+// Показатели каждого пула публикуются в /debug/vars в worker_pools под его именем:
+// queue_depth и in_flight — текущие число элементов в очереди и в обработке, submitted,
+// processed, failed, dropped и panics — счетчики элементов.
+package worker
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultQueueSize — размер очереди по умолчанию.
+const DefaultQueueSize = 1024
+
+// Policy определяет поведение Submit при заполненной очереди.
+type Policy int
+
+const (
+	// Block — Submit ждет места в очереди или отмены контекста.
+	Block Policy = iota
+	// Drop — Submit сразу отбрасывает элемент, возвращая ErrQueueFull; отброшенные элементы
+	// учитываются в показателе dropped.
+	Drop
+)
+
+var (
+	// ErrQueueFull возвращается Submit пула с политикой Drop, если очередь заполнена.
+	ErrQueueFull = errors.New("worker: queue is full")
+	// ErrClosed возвращается Submit после Close.
+	ErrClosed = errors.New("worker: pool is closed")
+)
+
+// poolStats — показатели пулов по именам.
+var poolStats = expvar.NewMap("worker_pools")
+
+// Config задает параметры пула.
+type Config struct {
+	// Name — имя пула в /debug/vars и в журнале.
+	Name string
+	// Workers — число горутин обработки; 0 — одна.
+	Workers int
+	// QueueSize — число элементов, ожидающих обработки; 0 — DefaultQueueSize.
+	QueueSize int
+	// BatchSize — наибольшее число элементов, передаваемых обработчику за один вызов;
+	// обработчик получает столько элементов, сколько уже ждет в очереди. 0 — по одному.
+	BatchSize int
+	// TaskTimeout ограничивает один вызов обработчика; 0 — без ограничения.
+	TaskTimeout time.Duration
+	// Policy — поведение при заполненной очереди; по умолчанию Block.
+	Policy Policy
+}
+
+// Handler обрабатывает пачку элементов. Ошибка записывается в журнал, а элементы пачки
+// учитываются в показателе failed и повторно не обрабатываются.
+type Handler[T any] func(ctx context.Context, batch []T) error
+
+// Pool обрабатывает элементы типа T в фоновых горутинах.
+type Pool[T any] struct {
+	cfg    Config
+	handle Handler[T]
+	queue  chan T
+	stats  *expvar.Map
+	// inFlight — число элементов, переданных обработчику и еще не обработанных.
+	inFlight atomic.Int64
+	// ctx — контекст обработки; отменяется, если Close не дождался опустошения очереди.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// mu защищает закрытие очереди от одновременной отправки в Submit.
+	mu     sync.RWMutex
+	closed bool
+}
+
+// New создает пул с параметрами cfg и обработчиком handle и запускает его горутины.
+// Горутины останавливаются в Close.
+func New[T any](cfg Config, handle Handler[T]) *Pool[T] {
+	cfg.Workers = max(cfg.Workers, 1)
+	cfg.BatchSize = max(cfg.BatchSize, 1)
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	p := &Pool[T]{cfg: cfg, handle: handle, queue: make(chan T, cfg.QueueSize), stats: new(expvar.Map).Init()}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	p.stats.Set("queue_depth", expvar.Func(func() any { return len(p.queue) }))
+	p.stats.Set("in_flight", expvar.Func(func() any { return p.inFlight.Load() }))
+	poolStats.Set(cfg.Name, p.stats)
+
+	p.wg.Add(cfg.Workers)
+	for range cfg.Workers {
+		go p.run()
+	}
+	return p
+}
+
+// Submit ставит элемент в очередь. Если очередь заполнена, пул с политикой Block ждет места
+// или отмены ctx (и возвращает ctx.Err()), а пул с политикой Drop возвращает ErrQueueFull.
+// После Close возвращает ErrClosed.
+func (p *Pool[T]) Submit(ctx context.Context, item T) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		p.stats.Add("dropped", 1)
+		return ErrClosed
+	}
+	select {
+	case p.queue <- item:
+		p.stats.Add("submitted", 1)
+		return nil
+	default:
+	}
+	if p.cfg.Policy == Drop {
+		p.stats.Add("dropped", 1)
+		return ErrQueueFull
+	}
+	select {
+	case p.queue <- item:
+		p.stats.Add("submitted", 1)
+		return nil
+	case <-ctx.Done():
+		p.stats.Add("dropped", 1)
+		return ctx.Err()
+	}
+}
+
+// Close перестает принимать элементы и ждет, пока элементы, оставшиеся в очереди, будут
+// обработаны. Если ctx отменяется раньше, обработка прерывается: контекст обработчика
+// отменяется, необработанные элементы отбрасываются, и Close возвращает ctx.Err().
+// Повторные вызовы только дожидаются остановки.
+func (p *Pool[T]) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// run обрабатывает пачки элементов из очереди, пока очередь не будет закрыта и опустошена.
+// После отмены контекста обработки оставшиеся элементы отбрасываются.
+func (p *Pool[T]) run() {
+	defer p.wg.Done()
+	batch := make([]T, 0, p.cfg.BatchSize)
+	for item := range p.queue {
+		batch = append(batch[:0], item)
+	fill:
+		for len(batch) < p.cfg.BatchSize {
+			select {
+			case next, ok := <-p.queue:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, next)
+			default:
+				break fill
+			}
+		}
+		if p.ctx.Err() != nil {
+			p.stats.Add("dropped", int64(len(batch)))
+			continue
+		}
+		p.process(batch)
+	}
+}
+
+// process передает пачку обработчику, ограничивая время TaskTimeout и перехватывая панику.
+func (p *Pool[T]) process(batch []T) {
+	n := int64(len(batch))
+	p.inFlight.Add(n)
+	defer p.inFlight.Add(-n)
+
+	ctx, cancel := p.ctx, context.CancelFunc(func() {})
+	if p.cfg.TaskTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, p.cfg.TaskTimeout)
+	}
+	defer cancel()
+
+	if err := p.call(ctx, batch); err != nil {
+		p.stats.Add("failed", n)
+		log.Printf("worker %s: failed to process %d items: %v", p.cfg.Name, n, err)
+		return
+	}
+	p.stats.Add("processed", n)
+}
+
+// call вызывает обработчик и возвращает панику как ошибку.
+func (p *Pool[T]) call(ctx context.Context, batch []T) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.stats.Add("panics", 1)
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return p.handle(ctx, batch)
+}
@@ -0,0 +1,155 @@
+package worker
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stat возвращает значение показателя пула.
+func stat(p *Pool[int], name string) int64 {
+	switch v := p.stats.Get(name).(type) {
+	case *expvar.Int:
+		return v.Value()
+	case expvar.Func:
+		switch n := v.Value().(type) {
+		case int:
+			return int64(n)
+		case int64:
+			return n
+		}
+	}
+	return 0
+}
+
+// Тест остановки: Close дожидается обработки всех элементов, оставшихся в очереди,
+// а после Close элементы не принимаются
+func TestPool_DrainOnClose(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var handled []int
+	p := New(Config{Name: "test_drain", QueueSize: 10, BatchSize: 3}, func(ctx context.Context, batch []int) error {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, batch...)
+		return nil
+	})
+
+	for i := range 7 {
+		require.NoError(t, p.Submit(context.Background(), i))
+	}
+	closed := make(chan error, 1)
+	go func() { closed <- p.Close(context.Background()) }()
+	select {
+	case <-closed:
+		t.Fatal("Close returned before the queue was drained")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+
+	require.NoError(t, <-closed)
+	assert.ErrorIs(t, p.Submit(context.Background(), 7), ErrClosed)
+	assert.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5, 6}, handled)
+	assert.EqualValues(t, 7, stat(p, "processed"))
+	assert.Zero(t, stat(p, "queue_depth"))
+	assert.Zero(t, stat(p, "in_flight"))
+	require.NoError(t, p.Close(context.Background()))
+}
+
+// Тест политики Drop: при занятом обработчике и заполненной очереди Submit не блокируется,
+// а элемент отбрасывается и учитывается в показателе dropped
+func TestPool_DropWhenSaturated(t *testing.T) {
+	started := make(chan struct{}, 3)
+	release := make(chan struct{})
+	p := New(Config{Name: "test_drop", QueueSize: 2, Policy: Drop}, func(ctx context.Context, batch []int) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	})
+
+	// Первый элемент в обработке, два ждут в очереди, остальные отбрасываются
+	require.NoError(t, p.Submit(context.Background(), 1))
+	<-started
+	require.NoError(t, p.Submit(context.Background(), 2))
+	require.NoError(t, p.Submit(context.Background(), 3))
+	assert.EqualValues(t, 1, stat(p, "in_flight"))
+	assert.EqualValues(t, 2, stat(p, "queue_depth"))
+	for range 3 {
+		assert.ErrorIs(t, p.Submit(context.Background(), 4), ErrQueueFull)
+	}
+	assert.EqualValues(t, 3, stat(p, "dropped"))
+
+	close(release)
+	require.NoError(t, p.Close(context.Background()))
+	assert.EqualValues(t, 3, stat(p, "processed"))
+}
+
+// Тест политики Block: при заполненной очереди Submit ждет места, пока не отменен контекст
+func TestPool_BlockWhenSaturated(t *testing.T) {
+	release := make(chan struct{})
+	p := New(Config{Name: "test_block", QueueSize: 1}, func(ctx context.Context, batch []int) error {
+		<-release
+		return nil
+	})
+	defer p.Close(context.Background())
+
+	require.NoError(t, p.Submit(context.Background(), 1))
+	require.Eventually(t, func() bool { return stat(p, "in_flight") == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, p.Submit(context.Background(), 2))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, p.Submit(ctx, 3), context.DeadlineExceeded)
+
+	submitted := make(chan error, 1)
+	go func() { submitted <- p.Submit(context.Background(), 3) }()
+	close(release)
+	assert.NoError(t, <-submitted)
+}
+
+// Тест изоляции обработчика: паника и превышение TaskTimeout учитываются как ошибки
+// и не останавливают пул
+func TestPool_PanicAndTimeout(t *testing.T) {
+	p := New(Config{Name: "test_panic", TaskTimeout: 10 * time.Millisecond}, func(ctx context.Context, batch []int) error {
+		switch batch[0] {
+		case 1:
+			panic("boom")
+		case 2:
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, p.Submit(context.Background(), i))
+	}
+	require.NoError(t, p.Close(context.Background()))
+	assert.EqualValues(t, 1, stat(p, "panics"))
+	assert.EqualValues(t, 2, stat(p, "failed"))
+	assert.EqualValues(t, 1, stat(p, "processed"))
+}
+
+// Тест прерванной остановки: если контекст Close истекает раньше, контекст обработчика
+// отменяется, а оставшиеся элементы отбрасываются
+func TestPool_CloseTimeout(t *testing.T) {
+	p := New(Config{Name: "test_close_timeout", QueueSize: 10}, func(ctx context.Context, batch []int) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	for i := range 5 {
+		require.NoError(t, p.Submit(context.Background(), i))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, p.Close(ctx), context.DeadlineExceeded)
+	assert.EqualValues(t, 1, stat(p, "failed"))
+	assert.EqualValues(t, 4, stat(p, "dropped"))
+}
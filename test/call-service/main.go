@@ -1,91 +1,72 @@
-package main
-
-import (
-	"database/sql"
-	"fmt"
-	"log"
-	"os"
-
-	"github.com/gin-gonic/gin"
-	"github.com/uptrace/bun"
-	"github.com/uptrace/bun/dialect/pgdialect"
-	"github.com/uptrace/bun/driver/pgdriver"
-
-	"call-service/internal/handler"
-	"call-service/internal/middleware"
-	"call-service/internal/repository"
-	"call-service/internal/service"
-	"call-service/pkg/authclient"
-)
-
-// Выполняет инициализацию всех компонентов и запускает HTTP-сервер.
-func main() {
-	// Получение переменных окружения для конфигурации
-	dbHost := getEnv("DB_HOST", "postgres")
-	dbPort := getEnv("DB_PORT", "5432")
-	dbUser := getEnv("DB_USER", "postgres")
-	dbPassword := getEnv("DB_PASSWORD", "postgres")
-	dbName := getEnv("DB_NAME", "call_service")
-	authServiceAddr := getEnv("AUTH_SERVICE_ADDR", "localhost:50051")
-	httpPort := getEnv("HTTP_PORT", "8080")
-
-	// Установка подключения к PostgreSQL базе данных
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		dbUser, dbPassword, dbHost, dbPort, dbName)
-	sqldb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn)))
-	db := bun.NewDB(sqldb, pgdialect.New())
-
-	// Создание клиента для аутентификации
-	authClient, err := authclient.NewAuthClient(authServiceAddr)
-	if err != nil {
-		log.Fatalf("failed to create auth client: %v", err)
-	}
-	defer authClient.Close()
-
-	// Инициализация репозиториев
-	callRepo := repository.NewCallRepository(db)
-
-	// Создание сервисов
-	callService := service.NewCallService(callRepo)
-
-	// Создание обработчиков
-	authHandler := handler.NewAuthHandler(authClient)
-	callHandler := handler.NewCallHandler(callService, authClient)
-
-	// Создание middleware для аутентификации
-	authMiddleware := middleware.NewAuthMiddleware(authClient)
-
-	// Создание маршрутизатора
-	router := gin.Default()
-
-	// Регистрация маршрутов аутентификации
-	router.POST("/register", authHandler.Register)
-	router.POST("/login", authHandler.Login)
-
-	// Группа маршрутов для работы с вызовами
-	calls := router.Group("/calls")
-	calls.Use(authMiddleware.AuthRequired())
-	{
-		calls.POST("", callHandler.CreateCall)
-		calls.GET("", callHandler.GetAllCalls)
-		calls.GET("/:id", callHandler.GetCall)
-		calls.PATCH("/:id/status", callHandler.UpdateCallStatus)
-		calls.DELETE("/:id", callHandler.DeleteCall)
-	}
-
-	// Запуск HTTP-сервера
-	log.Printf("Starting HTTP server on port %s", httpPort)
-	if err := router.Run(":" + httpPort); err != nil {
-		log.Fatalf("failed to start HTTP server: %v", err)
-	}
-}
-
-// getEnv получает значение переменной окружения с дефолтным значением.
-// Если переменная окружения не установлена, возвращается defaultValue.
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
-	}
-	return value
-}
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"call-service/internal/app"
+	"common/buildinfo"
+	"common/diagnostics"
+	"common/selfcheck"
+)
+
+// Загружает конфигурацию из переменных окружения и запускает приложение до получения
+// сигнала завершения. С флагом --check только проверяет готовность к запуску
+// и завершается с кодом 0 или 1.
+func main() {
+	check := flag.Bool("check", false, "check configuration and dependencies, then exit")
+	jsonOutput := flag.Bool("json", false, "print the --check report as JSON")
+	flag.Parse()
+
+	cfg, err := app.LoadConfig()
+	if *check {
+		probes := []selfcheck.Probe{{Name: "config", Run: func(context.Context) error { return err }}}
+		if err == nil {
+			probes = append(probes, app.CheckProbes(cfg)...)
+		}
+		selfcheck.Exit(selfcheck.Run(context.Background(), probes), *jsonOutput)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Журнал пишется в формате JSON; стандартный log также перенаправляется в него
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(cfg.LogLevel)
+	logger := app.NewLogger(logLevel)
+	slog.SetDefault(logger)
+	logger.Info("starting call-service", "build", buildinfo.Get())
+
+	a, err := app.New(cfg, app.Deps{Logger: logger, LogLevel: logLevel})
+	if err != nil {
+		log.Fatalf("failed to create application: %v", err)
+	}
+
+	// Показатели среды выполнения: сведения о сборке, число горутин и состояние пула соединений
+	// с базой данных
+	buildinfo.Publish()
+	diagnostics.PublishRuntimeStats(a.DB())
+
+	// SIGHUP перечитывает конфигурацию и файл флагов без перезапуска
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if _, _, err := a.ReloadConfig(); err != nil {
+				log.Printf("failed to reload config: %v", err)
+			}
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := a.Run(ctx); err != nil {
+		log.Fatalf("application stopped with error: %v", err)
+	}
+	log.Println("Servers stopped")
+}
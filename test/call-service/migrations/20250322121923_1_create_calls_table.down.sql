@@ -1,2 +1,2 @@
--- call-service/migrations/000001_create_calls_table.down.sql
+-- call-service/migrations/20250322121923_1_create_calls_table.down.sql
 DROP TABLE calls;
\ No newline at end of file
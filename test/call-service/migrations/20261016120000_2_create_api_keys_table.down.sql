@@ -0,0 +1,2 @@
+-- call-service/migrations/20261016120000_2_create_api_keys_table.down.sql
+DROP TABLE api_keys;
@@ -0,0 +1,3 @@
+-- call-service/migrations/20261016130000_3_add_calls_audit_columns.down.sql
+ALTER TABLE calls DROP COLUMN updated_by;
+ALTER TABLE calls DROP COLUMN created_by;
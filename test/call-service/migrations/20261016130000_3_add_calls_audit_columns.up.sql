@@ -0,0 +1,7 @@
+-- call-service/migrations/20261016130000_3_add_calls_audit_columns.up.sql
+ALTER TABLE calls ADD COLUMN created_by UUID;
+ALTER TABLE calls ADD COLUMN updated_by UUID;
+
+-- До появления колонок заявки создавались только их владельцами
+UPDATE calls SET created_by = user_id;
+ALTER TABLE calls ALTER COLUMN created_by SET NOT NULL;
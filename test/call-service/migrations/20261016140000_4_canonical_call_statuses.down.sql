@@ -0,0 +1,6 @@
+-- call-service/migrations/20261016140000_4_canonical_call_statuses.down.sql
+ALTER TABLE calls DROP CONSTRAINT calls_status_check;
+-- Прежде существовали только два статуса: заявки в работе считаются открытыми, отмененные — закрытыми
+UPDATE calls SET status = 'открыта' WHERE status IN ('open', 'in_progress');
+UPDATE calls SET status = 'закрыта' WHERE status IN ('closed', 'cancelled');
+ALTER TABLE calls ALTER COLUMN status SET DEFAULT 'открыта';
@@ -0,0 +1,6 @@
+-- call-service/migrations/20261016140000_4_canonical_call_statuses.up.sql
+UPDATE calls SET status = 'open' WHERE status = 'открыта';
+UPDATE calls SET status = 'closed' WHERE status = 'закрыта';
+ALTER TABLE calls ALTER COLUMN status SET DEFAULT 'open';
+ALTER TABLE calls ADD CONSTRAINT calls_status_check
+    CHECK (status IN ('open', 'in_progress', 'closed', 'cancelled'));
@@ -0,0 +1,3 @@
+-- call-service/migrations/20261016150000_5_create_call_status_changes_table.down.sql
+DROP INDEX calls_open_created_at_idx;
+DROP TABLE call_status_changes;
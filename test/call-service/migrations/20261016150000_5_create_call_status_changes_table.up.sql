@@ -0,0 +1,13 @@
+-- call-service/migrations/20261016150000_5_create_call_status_changes_table.up.sql
+CREATE TABLE call_status_changes (
+    id BIGSERIAL PRIMARY KEY,
+    call_id UUID NOT NULL REFERENCES calls (id) ON DELETE CASCADE,
+    old_status VARCHAR(20) NOT NULL,
+    new_status VARCHAR(20) NOT NULL,
+    changed_by UUID NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX call_status_changes_call_id_idx ON call_status_changes (call_id, changed_at);
+
+-- Поиск давно открытых заявок для автоматического закрытия
+CREATE INDEX calls_open_created_at_idx ON calls (created_at) WHERE status = 'open';
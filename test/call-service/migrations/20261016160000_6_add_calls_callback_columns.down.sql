@@ -0,0 +1,4 @@
+-- call-service/migrations/20261016160000_6_add_calls_callback_columns.down.sql
+DROP INDEX calls_callback_at_idx;
+ALTER TABLE calls DROP COLUMN callback_notified_at;
+ALTER TABLE calls DROP COLUMN callback_at;
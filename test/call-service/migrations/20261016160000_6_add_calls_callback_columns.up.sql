@@ -0,0 +1,6 @@
+-- call-service/migrations/20261016160000_6_add_calls_callback_columns.up.sql
+ALTER TABLE calls ADD COLUMN callback_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE calls ADD COLUMN callback_notified_at TIMESTAMP WITH TIME ZONE;
+
+-- Поиск заявок с наступившим временем повторного звонка
+CREATE INDEX calls_callback_at_idx ON calls (callback_at) WHERE callback_at IS NOT NULL;
@@ -0,0 +1,2 @@
+-- call-service/migrations/20261016170000_7_create_account_erasures_table.down.sql
+DROP TABLE account_erasures;
@@ -0,0 +1,11 @@
+-- call-service/migrations/20261016170000_7_create_account_erasures_table.up.sql
+-- Незавершенные удаления учетных записей; строка удаляется после обработки заявок пользователя
+CREATE TABLE account_erasures (
+    user_id UUID PRIMARY KEY,
+    policy VARCHAR(20) NOT NULL,
+    state VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
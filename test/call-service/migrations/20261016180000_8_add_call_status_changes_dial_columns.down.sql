@@ -0,0 +1,4 @@
+-- call-service/migrations/20261016180000_8_add_call_status_changes_dial_columns.down.sql
+DROP INDEX call_status_changes_dial_id_idx;
+ALTER TABLE call_status_changes DROP COLUMN dial_reference;
+ALTER TABLE call_status_changes DROP COLUMN dial_id;
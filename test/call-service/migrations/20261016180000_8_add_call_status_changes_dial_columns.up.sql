@@ -0,0 +1,6 @@
+-- call-service/migrations/20261016180000_8_add_call_status_changes_dial_columns.up.sql
+ALTER TABLE call_status_changes ADD COLUMN dial_id UUID;
+ALTER TABLE call_status_changes ADD COLUMN dial_reference VARCHAR(255);
+
+-- Поиск попытки звонка по ID, выданному клиенту
+CREATE UNIQUE INDEX call_status_changes_dial_id_idx ON call_status_changes (dial_id) WHERE dial_id IS NOT NULL;
@@ -0,0 +1,2 @@
+-- call-service/migrations/20261016190000_9_create_call_attachments_table.down.sql
+DROP TABLE call_attachments;
@@ -0,0 +1,14 @@
+-- call-service/migrations/20261016190000_9_create_call_attachments_table.up.sql
+-- Содержимое вложений хранится в хранилище объектов по storage_key; удаление заявки
+-- каскадом удаляет описания, но файлы удаляет сервис до удаления заявки
+CREATE TABLE call_attachments (
+    id UUID PRIMARY KEY,
+    call_id UUID NOT NULL REFERENCES calls (id) ON DELETE CASCADE,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key VARCHAR(255) NOT NULL,
+    uploaded_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX call_attachments_call_id_idx ON call_attachments (call_id, created_at);
@@ -0,0 +1,3 @@
+-- call-service/migrations/20261016200000_10_add_calls_org_id.down.sql
+DROP INDEX calls_org_id_idx;
+ALTER TABLE calls DROP COLUMN org_id;
@@ -0,0 +1,7 @@
+-- call-service/migrations/20261016200000_10_add_calls_org_id.up.sql
+-- Организация, участникам которой доступна заявка; NULL — заявка доступна только владельцу.
+-- Организации хранятся в сервисе аутентификации, поэтому внешнего ключа нет
+ALTER TABLE calls ADD COLUMN org_id UUID;
+
+-- Список заявок организации
+CREATE INDEX calls_org_id_idx ON calls (org_id, created_at) WHERE org_id IS NOT NULL;
@@ -0,0 +1,2 @@
+-- call-service/migrations/20261016210000_11_add_call_status_changes_impersonated_by.down.sql
+ALTER TABLE call_status_changes DROP COLUMN impersonated_by;
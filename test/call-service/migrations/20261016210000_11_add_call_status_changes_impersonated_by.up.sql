@@ -0,0 +1,4 @@
+-- call-service/migrations/20261016210000_11_add_call_status_changes_impersonated_by.up.sql
+-- Администратор, выполнивший изменение от имени пользователя; NULL — изменение сделал сам пользователь.
+-- Пользователи хранятся в сервисе аутентификации, поэтому внешнего ключа нет
+ALTER TABLE call_status_changes ADD COLUMN impersonated_by UUID;
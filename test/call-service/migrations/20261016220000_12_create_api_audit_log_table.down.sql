@@ -0,0 +1,2 @@
+-- call-service/migrations/20261016220000_12_create_api_audit_log_table.down.sql
+DROP TABLE api_audit_log;
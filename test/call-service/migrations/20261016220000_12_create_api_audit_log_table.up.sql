@@ -0,0 +1,20 @@
+-- call-service/migrations/20261016220000_12_create_api_audit_log_table.up.sql
+-- Журнал изменяющих запросов HTTP API. Сервис только добавляет строки; чтобы журнал нельзя
+-- было незаметно исправить, роли сервиса достаточно прав INSERT и SELECT на эту таблицу
+CREATE TABLE api_audit_log (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    route VARCHAR(255) NOT NULL,
+    resource_id VARCHAR(255),
+    user_id UUID,
+    auth_method VARCHAR(20),
+    impersonated_by UUID,
+    status INTEGER NOT NULL,
+    body_hash VARCHAR(64),
+    body_redacted BOOLEAN NOT NULL DEFAULT FALSE,
+    client_ip VARCHAR(45) NOT NULL,
+    request_id VARCHAR(128)
+);
+CREATE INDEX api_audit_log_user_id_idx ON api_audit_log (user_id, created_at);
+CREATE INDEX api_audit_log_created_at_idx ON api_audit_log (created_at);
@@ -0,0 +1,2 @@
+-- call-service/migrations/20261016230000_13_create_rate_limit_counters_table.down.sql
+DROP TABLE rate_limit_counters;
@@ -0,0 +1,11 @@
+-- call-service/migrations/20261016230000_13_create_rate_limit_counters_table.up.sql
+-- Счетчики запросов по фиксированным окнам времени, общие для всех экземпляров сервиса.
+-- Строки истекших окон удаляет сам ограничитель при открытии нового окна
+CREATE TABLE rate_limit_counters (
+    scope VARCHAR(64) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    window_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (scope, key, window_start)
+);
+CREATE INDEX rate_limit_counters_window_start_idx ON rate_limit_counters (scope, window_start);
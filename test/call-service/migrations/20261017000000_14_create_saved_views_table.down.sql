@@ -0,0 +1,2 @@
+-- call-service/migrations/20261017000000_14_create_saved_views_table.down.sql
+DROP TABLE saved_views;
@@ -0,0 +1,14 @@
+-- call-service/migrations/20261017000000_14_create_saved_views_table.up.sql
+-- Сохраненные фильтры списка заявок. filter — параметры строки запроса GET /calls,
+-- schema_version — версия схемы этих параметров, по которой сервис преобразует фильтры,
+-- сохраненные прежними версиями
+CREATE TABLE saved_views (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    filter JSONB NOT NULL DEFAULT '{}',
+    schema_version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
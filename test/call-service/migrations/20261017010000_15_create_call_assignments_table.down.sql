@@ -0,0 +1,2 @@
+-- call-service/migrations/20261017010000_15_create_call_assignments_table.down.sql
+DROP TABLE call_assignments;
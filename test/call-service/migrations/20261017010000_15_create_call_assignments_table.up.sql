@@ -0,0 +1,11 @@
+-- call-service/migrations/20261017010000_15_create_call_assignments_table.up.sql
+-- История передачи заявок другим пользователям для ленты действий с заявкой
+CREATE TABLE call_assignments (
+    id BIGSERIAL PRIMARY KEY,
+    call_id UUID NOT NULL REFERENCES calls (id) ON DELETE CASCADE,
+    old_user_id UUID NOT NULL,
+    new_user_id UUID NOT NULL,
+    changed_by UUID NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX call_assignments_call_id_idx ON call_assignments (call_id, changed_at);
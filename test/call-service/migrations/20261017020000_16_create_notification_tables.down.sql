@@ -0,0 +1,4 @@
+-- call-service/migrations/20261017020000_16_create_notification_tables.down.sql
+DROP TABLE telegram_link_codes;
+DROP TABLE telegram_links;
+DROP TABLE notification_preferences;
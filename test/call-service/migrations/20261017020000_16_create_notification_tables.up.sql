@@ -0,0 +1,23 @@
+-- call-service/migrations/20261017020000_16_create_notification_tables.up.sql
+-- Настройки уведомлений: строка отключает или снова включает уведомления о событии
+-- по каналу; без строки уведомления включены
+CREATE TABLE notification_preferences (
+    user_id UUID NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, event, channel)
+);
+
+-- Чаты Telegram, привязанные пользователями, и одноразовые коды привязки (хранится хеш кода)
+CREATE TABLE telegram_links (
+    user_id UUID PRIMARY KEY,
+    chat_id BIGINT NOT NULL,
+    linked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE TABLE telegram_link_codes (
+    code_hash VARCHAR(64) PRIMARY KEY,
+    user_id UUID NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
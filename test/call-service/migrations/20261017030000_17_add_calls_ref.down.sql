@@ -0,0 +1,3 @@
+-- call-service/migrations/20261017030000_17_add_calls_ref.down.sql
+DROP INDEX calls_ref_idx;
+ALTER TABLE calls DROP COLUMN ref;
@@ -0,0 +1,5 @@
+-- call-service/migrations/20261017030000_17_add_calls_ref.up.sql
+-- Код заявки для проверки статуса клиентом без учетной записи. У заявок, созданных
+-- раньше, кода нет; уникальный индекс не ограничивает NULL и используется для поиска по коду
+ALTER TABLE calls ADD COLUMN ref TEXT;
+CREATE UNIQUE INDEX calls_ref_idx ON calls (ref);
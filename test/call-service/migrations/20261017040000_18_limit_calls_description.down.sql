@@ -0,0 +1,2 @@
+-- call-service/migrations/20261017040000_18_limit_calls_description.down.sql
+ALTER TABLE calls ALTER COLUMN description TYPE TEXT;
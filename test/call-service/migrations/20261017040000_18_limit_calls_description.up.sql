@@ -0,0 +1,6 @@
+-- call-service/migrations/20261017040000_18_limit_calls_description.up.sql
+-- Размер описания заявки ограничивается model.MaxDescriptionLength символами. Описания,
+-- сохраненные до ограничения, обрезаются с тем же знаком многоточия, что и при создании
+-- заявки с truncate=true
+UPDATE calls SET description = left(description, 7999) || '…' WHERE char_length(description) > 8000;
+ALTER TABLE calls ALTER COLUMN description TYPE VARCHAR(8000);
@@ -0,0 +1,3 @@
+-- call-service/migrations/20261017050000_19_create_webhooks_tables.down.sql
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
@@ -0,0 +1,30 @@
+-- call-service/migrations/20261017050000_19_create_webhooks_tables.up.sql
+-- Подписки пользователей на события заявок и очередь доставки событий. Доставки удаляются
+-- вместе с подпиской; завершенные доставки удаляет задача очистки по истечении срока хранения
+CREATE TABLE webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    url TEXT NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_webhooks_user_id ON webhooks (user_id);
+
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id UUID NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    failure_reason VARCHAR(50) NOT NULL DEFAULT '',
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+-- Выборка доставок, ожидающих попытки, и списки доставок подписки по состоянию
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, status, created_at);
+-- Очистка завершенных доставок по сроку хранения
+CREATE INDEX idx_webhook_deliveries_finished ON webhook_deliveries (updated_at) WHERE status <> 'pending';
@@ -1,128 +1,1316 @@
-package authclient
-
-import (
-	"context"
-	"time"
-
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-
-	pb "call-service/proto"
-)
-
-// AuthClient представляет интерфейс клиента аутентификации.
-// Предоставляет методы для регистрации пользователя, входа в систему и проверки токенов.
-
-type AuthClient interface {
-	Register(ctx context.Context, username, password string) (string, string, error)
-	Login(ctx context.Context, username, password string) (string, string, error)
-	ValidateToken(ctx context.Context, token string) (bool, string, error)
-	Close() error
-}
-
-// authClient реализует интерфейс AuthClient для взаимодействия с gRPC-сервисом аутентификации.
-
-type authClient struct {
-	client pb.AuthServiceClient
-	conn   *grpc.ClientConn
-}
-
-// NewAuthClient создает новый экземпляр клиента аутентификации.
-
-func NewAuthClient(addr string) (AuthClient, error) {
-	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		return nil, err
-	}
-
-	client := pb.NewAuthServiceClient(conn)
-	return &authClient{client: client, conn: conn}, nil
-}
-
-// Register регистрирует нового пользователя в системе.
-//
-// Параметры:
-// ctx - контекст выполнения запроса
-// username - имя пользователя для регистрации
-// password - пароль пользователя
-//
-// Возвращает:
-// token - токен аутентификации нового пользователя
-// userId - ID зарегистрированного пользователя
-// error - ошибка регистрации, если произошла
-
-func (c *authClient) Register(ctx context.Context, username, password string) (string, string, error) {
-	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
-	defer cancel()
-
-	resp, err := c.client.Register(ctx, &pb.RegisterRequest{
-		Username: username,
-		Password: password,
-	})
-
-	if err != nil {
-		return "", "", err
-	}
-
-	return resp.Token, resp.UserId, nil
-}
-
-// Login выполняет вход пользователя в систему.
-//
-// Параметры:
-// ctx - контекст выполнения запроса
-// username - имя пользователя
-// password - пароль пользователя
-//
-// Возвращает:
-// token - токен аутентификации
-// userId - ID пользователя
-// error - ошибка входа, если произошла
-
-func (c *authClient) Login(ctx context.Context, username, password string) (string, string, error) {
-	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
-	defer cancel()
-
-	resp, err := c.client.Login(ctx, &pb.LoginRequest{
-		Username: username,
-		Password: password,
-	})
-
-	if err != nil {
-		return "", "", err
-	}
-
-	return resp.Token, resp.UserId, nil
-}
-
-// ValidateToken проверяет валидность токена аутентификации.
-//
-// Параметры:
-// ctx - контекст выполнения запроса
-// token - токен для проверки
-//
-// Возвращает:
-// valid - true если токен валиден, false если нет
-// userId - ID пользователя, если токен валиден
-// error - ошибка проверки токена, если произошла
-
-func (c *authClient) ValidateToken(ctx context.Context, token string) (bool, string, error) {
-	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
-	defer cancel()
-
-	resp, err := c.client.ValidateToken(ctx, &pb.ValidateTokenRequest{
-		Token: token,
-	})
-
-	if err != nil {
-		return false, "", err
-	}
-
-	return resp.Valid, resp.UserId, nil
-}
-
-// Close закрывает gRPC подключение к сервису аутентификации.
-
-func (c *authClient) Close() error {
-	return c.conn.Close()
-}
+package authclient
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "api/auth"
+)
+
+// InternalTokenMetadataKey — ключ метаданных gRPC, в котором сервису аутентификации
+// передается секрет внутренних сервисов.
+const InternalTokenMetadataKey = "x-internal-token"
+
+// Ключи метаданных gRPC, в которых сервису аутентификации передаются данные клиента
+// (см. WithClientInfo).
+const (
+	ClientIPMetadataKey    = "x-client-ip"
+	DeviceLabelMetadataKey = "x-device-label"
+	UserAgentMetadataKey   = "x-user-agent"
+)
+
+// Ограничения времени вызовов сервиса аутентификации по умолчанию. Применяются,
+// только если у контекста вызывающего нет собственного срока.
+const (
+	// DefaultMutationTimeout — для регистрации и входа (хеширование пароля занимает заметное время).
+	DefaultMutationTimeout = 5 * time.Second
+	// DefaultValidationTimeout — для проверки токенов, выполняемой на каждый запрос к API.
+	DefaultValidationTimeout = 5 * time.Second
+)
+
+// DefaultMaxMsgSize — предел размера сообщения сервера аутентификации по умолчанию
+// (GRPC_MAX_RECV_MSG_SIZE и GRPC_MAX_SEND_MSG_SIZE); подходит для Options.MaxRecvMsgSize
+// и Options.MaxSendMsgSize.
+const DefaultMaxMsgSize = 4 << 20
+
+// MaxGetUsers — наибольшее число ID, которое сервис аутентификации принимает в одном вызове GetUsers;
+// более длинные списки клиент разбивает на несколько вызовов.
+const MaxGetUsers = 100
+
+// Options содержит параметры клиента аутентификации. Нулевые значения заменяются значениями по умолчанию.
+
+type Options struct {
+	// MutationTimeout ограничивает Register, Login, ExportUserData, VerifyPassword и изменяющие
+	// вызовы (email, отзыв сеансов, удаление пользователя, организации, приглашения и работа
+	// от имени пользователя).
+	MutationTimeout time.Duration
+	// ValidationTimeout ограничивает ValidateToken, ValidateTokenFull, GetUser, GetUsers, ListUsers,
+	// ListSessions и ListInvites. ExportUsers и StreamUsers не ограничиваются.
+	ValidationTimeout time.Duration
+	// InternalToken — секрет внутренних сервисов, передаваемый с каждым вызовом.
+	// Пустое значение означает, что секрет не передается.
+	InternalToken string
+	// MaxRecvMsgSize и MaxSendMsgSize ограничивают размер ответа и запроса в байтах, как
+	// одноименные параметры сервера аутентификации; 0 — значения gRPC по умолчанию
+	// (4 МиБ на прием, без ограничения на отправку). Запрос больше предела не отправляется
+	// и завершается ошибкой codes.ResourceExhausted.
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+}
+
+// AuthClient представляет интерфейс клиента аутентификации.
+// Предоставляет методы для регистрации пользователя, входа в систему и проверки токенов.
+
+type AuthClient interface {
+	Register(ctx context.Context, username, password string) (string, string, error)
+	Login(ctx context.Context, username, password string) (string, string, error)
+	RegisterFull(ctx context.Context, username, password string) (*AuthResult, error)
+	LoginFull(ctx context.Context, username, password string) (*AuthResult, error)
+	ImpersonateUser(ctx context.Context, adminToken, userID string) (*AuthResult, error)
+	ExchangeOIDCToken(ctx context.Context, provider, idToken string) (*AuthResult, error)
+	ValidateToken(ctx context.Context, token string) (bool, string, error)
+	ValidateTokenFull(ctx context.Context, token string) (*TokenInfo, error)
+	ListSessions(ctx context.Context, userID string) ([]SessionInfo, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID, exceptSessionID string) (int, error)
+	ListDevices(ctx context.Context, userID string) ([]DeviceInfo, error)
+	GetUser(ctx context.Context, userID string) (*UserInfo, error)
+	GetUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]UserInfo, error)
+	ListUsers(ctx context.Context, filter UserFilter, limit, offset int) ([]UserInfo, int, error)
+	ExportUsers(ctx context.Context, filter UserFilter, fn func(UserInfo) error) error
+	StreamUsers(ctx context.Context, filter UserFilter, batchSize int) *UserIterator
+	UpdateEmail(ctx context.Context, userID, email string) error
+	VerifyEmail(ctx context.Context, userID, token string) error
+	ExportUserData(ctx context.Context, userID string) (*UserExport, error)
+	VerifyPassword(ctx context.Context, userID, password string) error
+	EraseUser(ctx context.Context, userID string) error
+	CreateOrganization(ctx context.Context, userID, name string) (*OrganizationInfo, error)
+	InviteToOrganization(ctx context.Context, orgID, inviterID, username string) (*MemberInfo, error)
+	CreateInvite(ctx context.Context, orgID, ownerID string, ttl time.Duration) (*InviteInfo, string, error)
+	ListInvites(ctx context.Context, orgID, ownerID string) ([]InviteInfo, error)
+	RevokeInvite(ctx context.Context, orgID, ownerID, inviteID string) error
+	AcceptInvite(ctx context.Context, userID, code string) (*MemberInfo, error)
+	Close() error
+}
+
+// AuthResult содержит результат регистрации или входа. RefreshToken пуст, а ExpiresAt — нулевое
+// время, если сервис аутентификации их не вернул (версии до появления сеансов и срока в ответе).
+
+type AuthResult struct {
+	Token        string
+	UserID       string
+	RefreshToken string
+	ExpiresAt    time.Time
+}
+
+// TokenInfo содержит результат проверки токена вместе с данными пользователя.
+// SessionID пуст для токенов, выпущенных до появления сеансов, ExpiresAt — нулевое время
+// для токенов без срока действия. ExpiresIn — оставшееся время действия токена по часам сервиса
+// аутентификации; оно не зависит от расхождения часов и равно 0, если срок истек, не указан
+// в токене или прежняя версия сервиса его не сообщила. OrgID и OrgRole — текущее участие пользователя в организации;
+// они пусты, если пользователь не состоит в организации или организации отключены в сервисе
+// аутентификации.
+
+type TokenInfo struct {
+	Valid     bool
+	UserID    string
+	Role      string
+	SessionID string
+	ExpiresAt time.Time
+	ExpiresIn time.Duration
+	OrgID     string
+	OrgRole   string
+	// ImpersonatedBy — ID администратора, выпустившего токен для работы от имени пользователя
+	// (см. ImpersonateUser); пуст для обычного токена.
+	ImpersonatedBy string
+}
+
+// SessionInfo описывает сеанс пользователя. RevokedAt — нулевое время для неотозванного сеанса.
+
+type SessionInfo struct {
+	ID          string
+	DeviceLabel string
+	IP          string
+	CreatedAt   time.Time
+	LastUsedAt  time.Time
+	ExpiresAt   time.Time
+	RevokedAt   time.Time
+}
+
+// DeviceInfo описывает устройство, с которого пользователь уже входил.
+
+type DeviceInfo struct {
+	Fingerprint string
+	UserAgent   string
+	IP          string
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+}
+
+// ClientInfo описывает клиента, от имени которого выполняется вход или регистрация.
+// Сервис аутентификации сохраняет эти данные в созданном сеансе, а по IP и UserAgent
+// определяет вход с нового устройства.
+
+type ClientInfo struct {
+	IP          string
+	DeviceLabel string
+	UserAgent   string
+}
+
+type clientInfoKey struct{}
+
+// WithClientInfo возвращает контекст, вызовы сервиса аутентификации с которым
+// передают данные клиента в метаданных.
+
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// ClientInfoFromContext возвращает данные клиента, сохраненные WithClientInfo. Нужна
+// реализациям AuthClient, которые передают их сервису аутентификации не через метаданные gRPC.
+
+func ClientInfoFromContext(ctx context.Context) (ClientInfo, bool) {
+	info, ok := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info, ok
+}
+
+// UserInfo содержит данные пользователя, возвращаемые сервисом аутентификации.
+
+type UserInfo struct {
+	UserID        string
+	Username      string
+	Role          string
+	Email         string
+	EmailVerified bool
+	CreatedAt     time.Time
+}
+
+// UserFilter задает условия отбора и порядок списка пользователей в ListUsers, ExportUsers
+// и StreamUsers.
+// Нулевые значения полей не ограничивают отбор. Сервис аутентификации не хранит признака
+// блокировки учетной записи, поэтому отбора по нему нет.
+
+type UserFilter struct {
+	// CreatedFrom и CreatedTo ограничивают время регистрации: не раньше CreatedFrom и раньше CreatedTo.
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// UsernamePrefix — начало имени пользователя, с учетом регистра.
+	UsernamePrefix string
+	// SortBy — UserSortByCreatedAt (по умолчанию) или UserSortByUsername.
+	SortBy string
+	// Ascending упорядочивает пользователей по возрастанию; по умолчанию сначала новые.
+	Ascending bool
+}
+
+// Поля сортировки UserFilter.SortBy.
+const (
+	UserSortByCreatedAt = "created_at"
+	UserSortByUsername  = "username"
+)
+
+// MaxListUsersLimit — наибольший размер страницы ListUsers и пакета StreamUsers.
+const MaxListUsersLimit = 500
+
+// UserExport содержит все данные пользователя, хранящиеся в сервисе аутентификации:
+// учетную запись, все сеансы (в том числе отозванные и истекшие) и известные устройства.
+// Хеши пароля и токенов сервис аутентификации не выгружает.
+// EmailVerificationExpiresAt — нулевое время, если токена подтверждения email нет.
+
+type UserExport struct {
+	User                       UserInfo
+	EmailVerificationExpiresAt time.Time
+	Sessions                   []SessionInfo
+	Devices                    []DeviceInfo
+}
+
+// OrganizationInfo описывает организацию пользователей.
+
+type OrganizationInfo struct {
+	ID        string
+	Name      string
+	CreatedAt time.Time
+}
+
+// MemberInfo описывает участника организации. Role — "owner" или "member".
+
+type MemberInfo struct {
+	OrgID    string
+	UserID   string
+	Role     string
+	JoinedAt time.Time
+}
+
+// InviteInfo описывает приглашение в организацию. Status — "pending", "accepted", "revoked"
+// или "expired"; AcceptedBy пуст, а AcceptedAt и RevokedAt — нулевое время, пока приглашение
+// не принято или не отозвано.
+
+type InviteInfo struct {
+	ID         string
+	OrgID      string
+	CreatedBy  string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	Status     string
+	AcceptedBy string
+	AcceptedAt time.Time
+	RevokedAt  time.Time
+}
+
+// authClient реализует интерфейс AuthClient для взаимодействия с gRPC-сервисом аутентификации.
+
+type authClient struct {
+	client pb.AuthServiceClient
+	opts   Options
+	// conn закрывается методом Close, только если соединение принадлежит клиенту.
+	conn *grpc.ClientConn
+}
+
+// NewAuthClient создает новый экземпляр клиента аутентификации с собственным соединением
+// и параметрами по умолчанию. Close закрывает это соединение.
+
+func NewAuthClient(addr string) (AuthClient, error) {
+	conn, err := Dial(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	client := newAuthClient(conn, Options{})
+	client.conn = conn
+	return client, nil
+}
+
+// NewAuthClientWithConn создает клиент аутентификации поверх существующего соединения.
+// Соединение остается во владении вызывающего: Close клиента его не закрывает.
+
+func NewAuthClientWithConn(conn *grpc.ClientConn, opts Options) AuthClient {
+	return newAuthClient(conn, opts)
+}
+
+func newAuthClient(conn *grpc.ClientConn, opts Options) *authClient {
+	if opts.MutationTimeout <= 0 {
+		opts.MutationTimeout = DefaultMutationTimeout
+	}
+	if opts.ValidationTimeout <= 0 {
+		opts.ValidationTimeout = DefaultValidationTimeout
+	}
+	var callOpts []grpc.CallOption
+	if opts.MaxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(opts.MaxRecvMsgSize))
+	}
+	if opts.MaxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(opts.MaxSendMsgSize))
+	}
+	var cc grpc.ClientConnInterface = conn
+	if len(callOpts) > 0 {
+		cc = callOptionsConn{ClientConnInterface: conn, opts: callOpts}
+	}
+	return &authClient{client: pb.NewAuthServiceClient(cc), opts: opts}
+}
+
+// callOptionsConn добавляет параметры вызова к каждому вызову через соединение: соединение
+// может принадлежать вызывающему и использоваться другими клиентами с другими параметрами.
+
+type callOptionsConn struct {
+	grpc.ClientConnInterface
+	opts []grpc.CallOption
+}
+
+func (c callOptionsConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return c.ClientConnInterface.Invoke(ctx, method, args, reply, append(c.opts, opts...)...)
+}
+
+func (c callOptionsConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return c.ClientConnInterface.NewStream(ctx, desc, method, append(c.opts, opts...)...)
+}
+
+// Register регистрирует нового пользователя в системе.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// username - имя пользователя для регистрации
+// password - пароль пользователя
+//
+// Возвращает:
+// token - токен аутентификации нового пользователя
+// userId - ID зарегистрированного пользователя
+// error - ошибка регистрации, если произошла; занятое имя - ErrUserExists,
+// отклоненные данные - ErrInvalidArgument, недоступность сервиса - ErrUnavailable
+
+func (c *authClient) Register(ctx context.Context, username, password string) (string, string, error) {
+	result, err := c.RegisterFull(ctx, username, password)
+	if err != nil {
+		return "", "", err
+	}
+	return result.Token, result.UserID, nil
+}
+
+// RegisterFull регистрирует нового пользователя и возвращает полный ответ сервиса
+// аутентификации, включая refresh-токен и срок действия токена доступа.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// username - имя пользователя для регистрации
+// password - пароль пользователя
+//
+// Возвращает:
+// result - токены и ID зарегистрированного пользователя
+// error - ошибка регистрации, если произошла; те же ошибки, что у Register
+
+func (c *authClient) RegisterFull(ctx context.Context, username, password string) (*AuthResult, error) {
+	ctx, cancel := c.callContext(ctx, c.opts.MutationTimeout)
+	defer cancel()
+
+	resp, err := c.client.Register(ctx, &pb.RegisterRequest{
+		Username: username,
+		Password: password,
+	})
+
+	if err != nil {
+		return nil, FromStatus(err)
+	}
+
+	return authResult(resp.Token, resp.UserId, resp.RefreshToken, resp.ExpiresAt), nil
+}
+
+// Login выполняет вход пользователя в систему.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// username - имя пользователя
+// password - пароль пользователя
+//
+// Возвращает:
+// token - токен аутентификации
+// userId - ID пользователя
+// error - ошибка входа, если произошла; неверные учетные данные - ErrInvalidCredentials,
+// отклоненные данные - ErrInvalidArgument, недоступность сервиса - ErrUnavailable
+
+func (c *authClient) Login(ctx context.Context, username, password string) (string, string, error) {
+	result, err := c.LoginFull(ctx, username, password)
+	if err != nil {
+		return "", "", err
+	}
+	return result.Token, result.UserID, nil
+}
+
+// LoginFull выполняет вход пользователя и возвращает полный ответ сервиса аутентификации,
+// включая refresh-токен и срок действия токена доступа.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// username - имя пользователя
+// password - пароль пользователя
+//
+// Возвращает:
+// result - токены и ID пользователя
+// error - ошибка входа, если произошла; те же ошибки, что у Login
+
+func (c *authClient) LoginFull(ctx context.Context, username, password string) (*AuthResult, error) {
+	ctx, cancel := c.callContext(ctx, c.opts.MutationTimeout)
+	defer cancel()
+
+	resp, err := c.client.Login(ctx, &pb.LoginRequest{
+		Username: username,
+		Password: password,
+	})
+
+	if err != nil {
+		return nil, FromStatus(err)
+	}
+
+	return authResult(resp.Token, resp.UserId, resp.RefreshToken, resp.ExpiresAt), nil
+}
+
+// ImpersonateUser выпускает администратору токен для работы от имени пользователя.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// adminToken - токен доступа администратора
+// userID - ID пользователя
+//
+// Возвращает:
+// result - токен пользователя со сроком действия; refresh-токен не выпускается
+// error - ошибка выпуска, если произошла; недействительный токен администратора - ErrInvalidCredentials,
+// владелец токена не администратор или пользователь - администратор - codes.PermissionDenied,
+// пользователь не найден - codes.NotFound, недоступность сервиса - ErrUnavailable
+
+func (c *authClient) ImpersonateUser(ctx context.Context, adminToken, userID string) (*AuthResult, error) {
+	ctx, cancel := c.callContext(ctx, c.opts.MutationTimeout)
+	defer cancel()
+
+	resp, err := c.client.ImpersonateUser(ctx, &pb.ImpersonateUserRequest{
+		AdminToken: adminToken,
+		UserId:     userID,
+	})
+
+	if err != nil {
+		return nil, FromStatus(err)
+	}
+
+	return authResult(resp.Token, resp.UserId, "", resp.ExpiresAt), nil
+}
+
+// ExchangeOIDCToken выполняет вход по ID-токену внешнего провайдера OIDC. При первом входе
+// сервис аутентификации связывает учетную запись провайдера с пользователем или создает его.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// provider - имя провайдера из конфигурации сервиса аутентификации
+// idToken - ID-токен, выданный провайдером
+//
+// Возвращает:
+// result - токены и ID пользователя
+// error - ошибка входа, если произошла; недействительный ID-токен - ErrInvalidCredentials,
+// неизвестный провайдер - ErrInvalidArgument, вход через OIDC отключен - codes.Unimplemented,
+// недоступность сервиса или провайдера - ErrUnavailable
+
+func (c *authClient) ExchangeOIDCToken(ctx context.Context, provider, idToken string) (*AuthResult, error) {
+	ctx, cancel := c.callContext(ctx, c.opts.MutationTimeout)
+	defer cancel()
+
+	resp, err := c.client.ExchangeOIDCToken(ctx, &pb.ExchangeOIDCTokenRequest{
+		Provider: provider,
+		IdToken:  idToken,
+	})
+
+	if err != nil {
+		return nil, FromStatus(err)
+	}
+
+	return authResult(resp.Token, resp.UserId, resp.RefreshToken, resp.ExpiresAt), nil
+}
+
+// authResult собирает AuthResult из полей ответа Register или Login; expiresAt — в секундах Unix,
+// 0 — срок не указан.
+func authResult(token, userID, refreshToken string, expiresAt int64) *AuthResult {
+	result := &AuthResult{Token: token, UserID: userID, RefreshToken: refreshToken}
+	if expiresAt != 0 {
+		result.ExpiresAt = time.Unix(expiresAt, 0)
+	}
+	return result
+}
+
+// ValidateToken проверяет валидность токена аутентификации.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// token - токен для проверки
+//
+// Возвращает:
+// valid - true если токен валиден, false если нет
+// userId - ID пользователя, если токен валиден
+// error - ошибка проверки токена, если произошла
+
+func (c *authClient) ValidateToken(ctx context.Context, token string) (bool, string, error) {
+	ctx, cancel := c.callContext(ctx, c.opts.ValidationTimeout)
+	defer cancel()
+
+	resp, err := c.client.ValidateToken(ctx, &pb.ValidateTokenRequest{
+		Token: token,
+	})
+
+	if err != nil {
+		return false, "", err
+	}
+
+	return resp.Valid, resp.UserId, nil
+}
+
+// ValidateTokenFull проверяет валидность токена аутентификации и возвращает
+// полный ответ сервиса аутентификации, включая роль пользователя.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// token - токен для проверки
+//
+// Возвращает:
+// info - результат проверки; поля UserID и Role заполнены только для валидного токена
+// error - ошибка проверки токена, если произошла
+
+func (c *authClient) ValidateTokenFull(ctx context.Context, token string) (*TokenInfo, error) {
+	ctx, cancel := c.callContext(ctx, c.opts.ValidationTimeout)
+	defer cancel()
+
+	resp, err := c.client.ValidateToken(ctx, &pb.ValidateTokenRequest{
+		Token: token,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	info := &TokenInfo{
+		Valid:          resp.Valid,
+		UserID:         resp.UserId,
+		Role:           resp.Role,
+		SessionID:      resp.SessionId,
+		OrgID:          resp.OrgId,
+		OrgRole:        resp.OrgRole,
+		ImpersonatedBy: resp.ImpersonatedBy,
+	}
+	if resp.ExpiresAt != 0 {
+		info.ExpiresAt = time.Unix(resp.ExpiresAt, 0)
+		info.ExpiresIn = time.Duration(resp.ExpiresIn) * time.Second
+	}
+	return info, nil
+}
+
+// ListSessions возвращает действующие сеансы пользователя, от последних использованных к давним.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// userID - ID пользователя
+//
+// Возвращает:
+// sessions - действующие сеансы пользователя
+// error - ошибка запроса, если произошла
+
+func (c *authClient) ListSessions(ctx context.Context, userID string) ([]SessionInfo, error) {
+	ctx, cancel := c.callContext(ctx, c.opts.ValidationTimeout)
+	defer cancel()
+
+	resp, err := c.client.ListSessions(ctx, &pb.ListSessionsRequest{
+		UserId: userID,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]SessionInfo, 0, len(resp.Sessions))
+	for _, session := range resp.Sessions {
+		sessions = append(sessions, sessionInfo(session))
+	}
+	return sessions, nil
+}
+
+// ListDevices возвращает устройства, с которых пользователь уже входил, от последних использованных к давним.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// userID - ID пользователя
+//
+// Возвращает:
+// devices - известные устройства пользователя
+// error - ошибка запроса, если произошла
+
+func (c *authClient) ListDevices(ctx context.Context, userID string) ([]DeviceInfo, error) {
+	ctx, cancel := c.callContext(ctx, c.opts.ValidationTimeout)
+	defer cancel()
+
+	resp, err := c.client.ListDevices(ctx, &pb.ListDevicesRequest{
+		UserId: userID,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]DeviceInfo, 0, len(resp.Devices))
+	for _, device := range resp.Devices {
+		devices = append(devices, deviceInfo(device))
+	}
+	return devices, nil
+}
+
+// RevokeSession отзывает сеанс пользователя; выпущенные для него токены перестают действовать сразу.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// userID - ID пользователя
+// sessionID - ID сеанса
+//
+// Возвращает:
+// error - ошибка запроса; чужой или уже отозванный сеанс - codes.NotFound
+
+func (c *authClient) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	ctx, cancel := c.callContext(ctx, c.opts.MutationTimeout)
+	defer cancel()
+
+	_, err := c.client.RevokeSession(ctx, &pb.RevokeSessionRequest{
+		UserId:    userID,
+		SessionId: sessionID,
+	})
+	return err
+}
+
+// RevokeAllSessions отзывает все сеансы пользователя, кроме exceptSessionID
+// (пустая строка - отозвать все).
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// userID - ID пользователя
+// exceptSessionID - ID сеанса, который нужно сохранить
+//
+// Возвращает:
+// revoked - количество отозванных сеансов
+// error - ошибка запроса, если произошла
+
+func (c *authClient) RevokeAllSessions(ctx context.Context, userID, exceptSessionID string) (int, error) {
+	ctx, cancel := c.callContext(ctx, c.opts.MutationTimeout)
+	defer cancel()
+
+	resp, err := c.client.RevokeAllSessions(ctx, &pb.RevokeAllSessionsRequest{
+		UserId:          userID,
+		ExceptSessionId: exceptSessionID,
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(resp.Revoked), nil
+}
+
+// GetUser возвращает данные пользователя, включая email и признак его подтверждения.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// userID - ID пользователя
+//
+// Возвращает:
+// info - данные пользователя
+// error - ошибка запроса; для несуществующего пользователя - gRPC-статус codes.NotFound
+
+func (c *authClient) GetUser(ctx context.Context, userID string) (*UserInfo, error) {
+	ctx, cancel := c.callContext(ctx, c.opts.ValidationTimeout)
+	defer cancel()
+
+	resp, err := c.client.GetUser(ctx, &pb.GetUserRequest{
+		UserId: userID,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserInfo{
+		UserID:        resp.UserId,
+		Username:      resp.Username,
+		Role:          resp.Role,
+		Email:         resp.Email,
+		EmailVerified: resp.EmailVerified,
+	}, nil
+}
+
+// GetUsers возвращает ID, имена и время регистрации пользователей; остальные поля UserInfo
+// не заполняются. Неизвестные ID пропускаются, повторяющиеся запрашиваются один раз.
+// Списки длиннее MaxGetUsers запрашиваются несколькими вызовами; пустой список не требует вызова.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// userIDs - ID пользователей
+//
+// Возвращает:
+// users - найденные пользователи по ID
+// error - ошибка первого неудачного вызова
+
+func (c *authClient) GetUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]UserInfo, error) {
+	ids := make([]string, 0, len(userIDs))
+	seen := make(map[uuid.UUID]bool, len(userIDs))
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id.String())
+		}
+	}
+
+	users := make(map[uuid.UUID]UserInfo, len(ids))
+	for start := 0; start < len(ids); start += MaxGetUsers {
+		if err := c.getUsers(ctx, ids[start:min(start+MaxGetUsers, len(ids))], users); err != nil {
+			return nil, err
+		}
+	}
+	return users, nil
+}
+
+// getUsers запрашивает один пакет пользователей и добавляет их в users.
+
+func (c *authClient) getUsers(ctx context.Context, ids []string, users map[uuid.UUID]UserInfo) error {
+	ctx, cancel := c.callContext(ctx, c.opts.ValidationTimeout)
+	defer cancel()
+
+	resp, err := c.client.GetUsers(ctx, &pb.GetUsersRequest{
+		UserIds: ids,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, user := range resp.Users {
+		id, err := uuid.Parse(user.UserId)
+		if err != nil {
+			continue
+		}
+		users[id] = UserInfo{UserID: user.UserId, Username: user.Username, CreatedAt: user.CreatedAt.AsTime()}
+	}
+	return nil
+}
+
+// ListUsers возвращает страницу пользователей, удовлетворяющих условиям отбора, и их общее число.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// filter - условия отбора и порядок пользователей
+// limit - размер страницы, не больше MaxListUsersLimit; 0 - размер по умолчанию сервиса аутентификации
+// offset - число пропускаемых пользователей
+//
+// Возвращает:
+// users - пользователи страницы
+// total - число пользователей, удовлетворяющих условиям отбора
+// error - ошибка запроса; неверные условия отбора или размер страницы - ErrInvalidArgument
+
+func (c *authClient) ListUsers(ctx context.Context, filter UserFilter, limit, offset int) ([]UserInfo, int, error) {
+	ctx, cancel := c.callContext(ctx, c.opts.ValidationTimeout)
+	defer cancel()
+
+	resp, err := c.client.ListUsers(ctx, &pb.ListUsersRequest{
+		Filter: userFilter(filter),
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	})
+
+	if err != nil {
+		return nil, 0, FromStatus(err)
+	}
+
+	users := make([]UserInfo, 0, len(resp.Users))
+	for _, user := range resp.Users {
+		users = append(users, userInfo(user))
+	}
+	return users, int(resp.Total), nil
+}
+
+// ExportUsers вызывает fn для каждого пользователя, удовлетворяющего условиям отбора,
+// в порядке сортировки. Сервис аутентификации отправляет пользователей потоком, поэтому
+// выгрузка не ограничивается ни размером страницы, ни ValidationTimeout: вызов длится,
+// пока не закончится поток или не отменится ctx.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// filter - условия отбора и порядок пользователей
+// fn - функция, вызываемая для каждого пользователя; ее ошибка прекращает выгрузку
+//
+// Возвращает:
+// error - ошибка fn или запроса; неверные условия отбора - ErrInvalidArgument
+
+func (c *authClient) ExportUsers(ctx context.Context, filter UserFilter, fn func(UserInfo) error) error {
+	ctx, cancel := context.WithCancel(c.outgoingContext(ctx))
+	defer cancel()
+
+	stream, err := c.client.ExportUsers(ctx, &pb.ExportUsersRequest{
+		Filter: userFilter(filter),
+	})
+
+	if err != nil {
+		return FromStatus(err)
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return FromStatus(err)
+		}
+		for _, user := range resp.Users {
+			if err := fn(userInfo(user)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// StreamUsers возвращает итератор по пользователям, удовлетворяющим условиям отбора,
+// в порядке сортировки. Сервис аутентификации читает пользователей пакетами по batchSize
+// (0 — размер по умолчанию, не больше MaxListUsersLimit) и читает следующий пакет только
+// после того, как предыдущий принят клиентом, поэтому, в отличие от ExportUsers, медленный
+// читатель не удерживает курсор базы данных сервиса. Вызов выполняется при первом Next
+// и не ограничивается ValidationTimeout; Close итератора или отмена ctx прекращают его.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// filter - условия отбора и порядок пользователей
+// batchSize - число пользователей в одном сообщении потока
+//
+// Возвращает:
+// *UserIterator - итератор; неверные условия отбора или размер пакета - ErrInvalidArgument из Next
+
+func (c *authClient) StreamUsers(ctx context.Context, filter UserFilter, batchSize int) *UserIterator {
+	ctx, cancel := context.WithCancel(c.outgoingContext(ctx))
+	var stream pb.AuthService_StreamUsersClient
+	next := func() ([]UserInfo, error) {
+		if stream == nil {
+			var err error
+			stream, err = c.client.StreamUsers(ctx, &pb.StreamUsersRequest{
+				Filter:    userFilter(filter),
+				BatchSize: int32(batchSize),
+			})
+			if err != nil {
+				return nil, FromStatus(err)
+			}
+		}
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, FromStatus(err)
+		}
+		users := make([]UserInfo, 0, len(resp.Users))
+		for _, user := range resp.Users {
+			users = append(users, userInfo(user))
+		}
+		return users, nil
+	}
+	return NewUserIterator(next, cancel)
+}
+
+// userFilter переводит условия отбора в сообщение запроса.
+
+func userFilter(filter UserFilter) *pb.UserFilter {
+	result := &pb.UserFilter{
+		UsernamePrefix: filter.UsernamePrefix,
+		Sort:           filter.SortBy,
+		Ascending:      filter.Ascending,
+	}
+	if !filter.CreatedFrom.IsZero() {
+		result.CreatedFrom = timestamppb.New(filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		result.CreatedTo = timestamppb.New(filter.CreatedTo)
+	}
+	return result
+}
+
+// userInfo переводит данные пользователя из ответа в UserInfo.
+
+func userInfo(user *pb.UserData) UserInfo {
+	return UserInfo{
+		UserID:        user.GetUserId(),
+		Username:      user.GetUsername(),
+		Role:          user.GetRole(),
+		Email:         user.GetEmail(),
+		EmailVerified: user.GetEmailVerified(),
+		CreatedAt:     optionalTime(user.GetCreatedAt()),
+	}
+}
+
+// UpdateEmail устанавливает пользователю новый email; сервис аутентификации отправляет
+// на него токен подтверждения.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// userID - ID пользователя
+// email - новый email
+//
+// Возвращает:
+// error - ошибка запроса; неверный формат email - codes.InvalidArgument,
+// email другого пользователя - codes.AlreadyExists
+
+func (c *authClient) UpdateEmail(ctx context.Context, userID, email string) error {
+	ctx, cancel := c.callContext(ctx, c.opts.MutationTimeout)
+	defer cancel()
+
+	_, err := c.client.UpdateEmail(ctx, &pb.UpdateEmailRequest{
+		UserId: userID,
+		Email:  email,
+	})
+	return err
+}
+
+// VerifyEmail подтверждает email пользователя токеном из письма.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// userID - ID пользователя
+// token - токен подтверждения
+//
+// Возвращает:
+// error - ошибка запроса; неверный, использованный или истекший токен - codes.InvalidArgument
+
+func (c *authClient) VerifyEmail(ctx context.Context, userID, token string) error {
+	ctx, cancel := c.callContext(ctx, c.opts.MutationTimeout)
+	defer cancel()
+
+	_, err := c.client.VerifyEmail(ctx, &pb.VerifyEmailRequest{
+		UserId: userID,
+		Token:  token,
+	})
+	return err
+}
+
+// ExportUserData выгружает данные пользователя по запросу субъекта данных. Сервис аутентификации
+// разрешает не более одной выгрузки данных пользователя в час.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// userID - ID пользователя
+//
+// Возвращает:
+// export - данные пользователя без хешей пароля и токенов
+// error - ошибка запроса; несуществующий пользователь - codes.NotFound,
+// повторная выгрузка раньше чем через час - codes.ResourceExhausted
+
+func (c *authClient) ExportUserData(ctx context.Context, userID string) (*UserExport, error) {
+	ctx, cancel := c.callContext(ctx, c.opts.MutationTimeout)
+	defer cancel()
+
+	resp, err := c.client.ExportUserData(ctx, &pb.ExportUserDataRequest{
+		UserId: userID,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	user := resp.GetUser()
+	export := &UserExport{
+		User:                       userInfo(user),
+		EmailVerificationExpiresAt: optionalTime(user.GetEmailVerificationExpiresAt()),
+		Sessions:                   make([]SessionInfo, 0, len(resp.Sessions)),
+		Devices:                    make([]DeviceInfo, 0, len(resp.Devices)),
+	}
+	for _, session := range resp.Sessions {
+		export.Sessions = append(export.Sessions, sessionInfo(session))
+	}
+	for _, device := range resp.Devices {
+		export.Devices = append(export.Devices, deviceInfo(device))
+	}
+	return export, nil
+}
+
+// VerifyPassword проверяет пароль пользователя перед удалением учетной записи.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// userID - ID пользователя
+// password - пароль пользователя
+//
+// Возвращает:
+// error - ошибка проверки; неверный пароль - ErrInvalidCredentials,
+// несуществующий пользователь - codes.NotFound, недоступность сервиса - ErrUnavailable
+
+func (c *authClient) VerifyPassword(ctx context.Context, userID, password string) error {
+	ctx, cancel := c.callContext(ctx, c.opts.MutationTimeout)
+	defer cancel()
+
+	_, err := c.client.VerifyPassword(ctx, &pb.VerifyPasswordRequest{
+		UserId:   userID,
+		Password: password,
+	})
+	if err != nil {
+		return FromStatus(err)
+	}
+	return nil
+}
+
+// EraseUser безвозвратно удаляет пользователя вместе с его сеансами и устройствами.
+// Вызов идемпотентен: для уже удаленного пользователя он завершается без ошибки.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// userID - ID пользователя
+//
+// Возвращает:
+// error - ошибка запроса; недоступность сервиса - ErrUnavailable
+
+func (c *authClient) EraseUser(ctx context.Context, userID string) error {
+	ctx, cancel := c.callContext(ctx, c.opts.MutationTimeout)
+	defer cancel()
+
+	_, err := c.client.EraseUser(ctx, &pb.EraseUserRequest{
+		UserId: userID,
+	})
+	if err != nil {
+		return FromStatus(err)
+	}
+	return nil
+}
+
+// CreateOrganization создает организацию, владельцем которой становится пользователь.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// userID - ID будущего владельца
+// name - название организации
+//
+// Возвращает:
+// info - созданная организация
+// error - ошибка запроса; пустое или слишком длинное название - codes.InvalidArgument,
+// пользователь уже состоит в организации - codes.AlreadyExists, организации отключены -
+// codes.Unimplemented
+
+func (c *authClient) CreateOrganization(ctx context.Context, userID, name string) (*OrganizationInfo, error) {
+	ctx, cancel := c.callContext(ctx, c.opts.MutationTimeout)
+	defer cancel()
+
+	resp, err := c.client.CreateOrganization(ctx, &pb.CreateOrganizationRequest{
+		UserId: userID,
+		Name:   name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	org := resp.Organization
+	return &OrganizationInfo{
+		ID:        org.GetId(),
+		Name:      org.GetName(),
+		CreatedAt: optionalTime(org.GetCreatedAt()),
+	}, nil
+}
+
+// InviteToOrganization добавляет пользователя в организацию с ролью участника.
+// Приглашать может только владелец организации.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// orgID - ID организации
+// inviterID - ID приглашающего пользователя
+// username - имя приглашаемого пользователя
+//
+// Возвращает:
+// info - новый участник организации
+// error - ошибка запроса; приглашающий не владелец организации - codes.PermissionDenied,
+// пользователь не найден - codes.NotFound, пользователь уже состоит в организации -
+// codes.AlreadyExists, организации отключены - codes.Unimplemented
+
+func (c *authClient) InviteToOrganization(ctx context.Context, orgID, inviterID, username string) (*MemberInfo, error) {
+	ctx, cancel := c.callContext(ctx, c.opts.MutationTimeout)
+	defer cancel()
+
+	resp, err := c.client.InviteToOrganization(ctx, &pb.InviteToOrganizationRequest{
+		OrgId:     orgID,
+		InviterId: inviterID,
+		Username:  username,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return memberInfo(resp.Member), nil
+}
+
+// CreateInvite создает одноразовое приглашение в организацию. Код приглашения возвращается
+// только здесь: сервис аутентификации хранит его хеш.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// orgID - ID организации
+// ownerID - ID владельца организации
+// ttl - срок действия приглашения; 0 - срок по умолчанию (7 дней)
+//
+// Возвращает:
+// info - созданное приглашение
+// code - код приглашения
+// error - ошибка запроса; пользователь не владелец организации - codes.PermissionDenied,
+// срок действия больше 30 дней - codes.InvalidArgument, организации отключены - codes.Unimplemented
+
+func (c *authClient) CreateInvite(ctx context.Context, orgID, ownerID string, ttl time.Duration) (*InviteInfo, string, error) {
+	ctx, cancel := c.callContext(ctx, c.opts.MutationTimeout)
+	defer cancel()
+
+	resp, err := c.client.CreateInvite(ctx, &pb.CreateInviteRequest{
+		OrgId:      orgID,
+		OwnerId:    ownerID,
+		TtlSeconds: int64(ttl / time.Second),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	info := inviteInfo(resp.Invite)
+	return &info, resp.Code, nil
+}
+
+// ListInvites возвращает приглашения организации, от новых к старым.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// orgID - ID организации
+// ownerID - ID владельца организации
+//
+// Возвращает:
+// invites - приглашения организации
+// error - ошибка запроса; пользователь не владелец организации - codes.PermissionDenied,
+// организации отключены - codes.Unimplemented
+
+func (c *authClient) ListInvites(ctx context.Context, orgID, ownerID string) ([]InviteInfo, error) {
+	ctx, cancel := c.callContext(ctx, c.opts.ValidationTimeout)
+	defer cancel()
+
+	resp, err := c.client.ListInvites(ctx, &pb.ListInvitesRequest{
+		OrgId:   orgID,
+		OwnerId: ownerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invites := make([]InviteInfo, 0, len(resp.Invites))
+	for _, invite := range resp.Invites {
+		invites = append(invites, inviteInfo(invite))
+	}
+	return invites, nil
+}
+
+// RevokeInvite отзывает непринятое приглашение; повторный отзыв не является ошибкой.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// orgID - ID организации
+// ownerID - ID владельца организации
+// inviteID - ID приглашения
+//
+// Возвращает:
+// error - ошибка запроса; пользователь не владелец организации - codes.PermissionDenied,
+// приглашение не найдено или уже принято - codes.NotFound, организации отключены - codes.Unimplemented
+
+func (c *authClient) RevokeInvite(ctx context.Context, orgID, ownerID, inviteID string) error {
+	ctx, cancel := c.callContext(ctx, c.opts.MutationTimeout)
+	defer cancel()
+
+	_, err := c.client.RevokeInvite(ctx, &pb.RevokeInviteRequest{
+		OrgId:    orgID,
+		OwnerId:  ownerID,
+		InviteId: inviteID,
+	})
+	return err
+}
+
+// AcceptInvite принимает приглашение: пользователь становится участником организации.
+// Повторное принятие тем же пользователем возвращает его участие.
+//
+// Параметры:
+// ctx - контекст выполнения запроса
+// userID - ID принимающего пользователя
+// code - код приглашения
+//
+// Возвращает:
+// info - участие пользователя в организации
+// error - ошибка запроса; код неизвестен, истек, отозван или использован другим пользователем -
+// codes.InvalidArgument, пользователь уже состоит в организации - codes.AlreadyExists,
+// организации отключены - codes.Unimplemented
+
+func (c *authClient) AcceptInvite(ctx context.Context, userID, code string) (*MemberInfo, error) {
+	ctx, cancel := c.callContext(ctx, c.opts.MutationTimeout)
+	defer cancel()
+
+	resp, err := c.client.AcceptInvite(ctx, &pb.AcceptInviteRequest{
+		UserId: userID,
+		Code:   code,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return memberInfo(resp.Member), nil
+}
+
+// memberInfo преобразует участника организации из ответа сервиса аутентификации в MemberInfo.
+
+func memberInfo(member *pb.OrganizationMember) *MemberInfo {
+	return &MemberInfo{
+		OrgID:    member.GetOrgId(),
+		UserID:   member.GetUserId(),
+		Role:     member.GetRole(),
+		JoinedAt: optionalTime(member.GetJoinedAt()),
+	}
+}
+
+// inviteInfo преобразует приглашение из ответа сервиса аутентификации в InviteInfo.
+
+func inviteInfo(invite *pb.OrganizationInvite) InviteInfo {
+	return InviteInfo{
+		ID:         invite.GetId(),
+		OrgID:      invite.GetOrgId(),
+		CreatedBy:  invite.GetCreatedBy(),
+		CreatedAt:  optionalTime(invite.GetCreatedAt()),
+		ExpiresAt:  optionalTime(invite.GetExpiresAt()),
+		Status:     invite.GetStatus(),
+		AcceptedBy: invite.GetAcceptedBy(),
+		AcceptedAt: optionalTime(invite.GetAcceptedAt()),
+		RevokedAt:  optionalTime(invite.GetRevokedAt()),
+	}
+}
+
+// sessionInfo преобразует сеанс из ответа сервиса аутентификации в SessionInfo.
+
+func sessionInfo(session *pb.Session) SessionInfo {
+	return SessionInfo{
+		ID:          session.Id,
+		DeviceLabel: session.DeviceLabel,
+		IP:          session.Ip,
+		CreatedAt:   session.CreatedAt.AsTime(),
+		LastUsedAt:  session.LastUsedAt.AsTime(),
+		ExpiresAt:   optionalTime(session.ExpiresAt),
+		RevokedAt:   optionalTime(session.RevokedAt),
+	}
+}
+
+// deviceInfo преобразует устройство из ответа сервиса аутентификации в DeviceInfo.
+
+func deviceInfo(device *pb.KnownDevice) DeviceInfo {
+	return DeviceInfo{
+		Fingerprint: device.Fingerprint,
+		UserAgent:   device.UserAgent,
+		IP:          device.Ip,
+		FirstSeenAt: device.FirstSeenAt.AsTime(),
+		LastSeenAt:  device.LastSeenAt.AsTime(),
+	}
+}
+
+// optionalTime возвращает нулевое время для незаданной отметки времени.
+
+func optionalTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// callContext возвращает контекст вызова сервиса аутентификации с секретом внутренних сервисов
+// и данными клиента (WithClientInfo) в метаданных. Если у ctx уже есть срок (например, срок HTTP-запроса, установленный
+// middleware.Timeout), вызов ограничивается только им и не продлевается; иначе применяется timeout.
+
+func (c *authClient) callContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx = c.outgoingContext(ctx)
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// outgoingContext добавляет в метаданные вызова секрет внутренних сервисов и данные клиента.
+
+func (c *authClient) outgoingContext(ctx context.Context) context.Context {
+	if c.opts.InternalToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, InternalTokenMetadataKey, c.opts.InternalToken)
+	}
+	if info, ok := ClientInfoFromContext(ctx); ok {
+		ctx = metadata.AppendToOutgoingContext(ctx,
+			ClientIPMetadataKey, info.IP,
+			DeviceLabelMetadataKey, info.DeviceLabel,
+			UserAgentMetadataKey, info.UserAgent,
+		)
+	}
+	return ctx
+}
+
+// Close закрывает gRPC подключение к сервису аутентификации, если оно было создано
+// в NewAuthClient. Соединение, переданное в NewAuthClientWithConn, не закрывается.
+
+func (c *authClient) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
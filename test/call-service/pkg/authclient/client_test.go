@@ -0,0 +1,398 @@
+package authclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "api/auth"
+)
+
+// deadlineServer запоминает оставшееся время вызова, с которым пришел запрос ValidateToken,
+// и признает токен действительным еще 90 секунд.
+
+type deadlineServer struct {
+	pb.UnimplementedAuthServiceServer
+	remaining      chan time.Duration
+	internalTokens chan []string
+	loginMetadata  chan metadata.MD
+	// streamDone получает результат обработчика StreamUsers
+	streamDone chan error
+
+	mu           sync.Mutex
+	getUsersSize []int
+}
+
+func (s *deadlineServer) ValidateToken(ctx context.Context, _ *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		s.remaining <- 0
+	} else {
+		s.remaining <- time.Until(deadline)
+	}
+	return &pb.ValidateTokenResponse{Valid: true, ExpiresAt: 1767225600, ExpiresIn: 90}, nil
+}
+
+// Register запоминает секрет внутренних сервисов из метаданных вызова.
+
+func (s *deadlineServer) Register(ctx context.Context, _ *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	s.internalTokens <- metadata.ValueFromIncomingContext(ctx, InternalTokenMetadataKey)
+	return &pb.RegisterResponse{Token: "jwt", UserId: "id"}, nil
+}
+
+// Login запоминает метаданные вызова.
+
+func (s *deadlineServer) Login(ctx context.Context, _ *pb.LoginRequest) (*pb.LoginResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.loginMetadata <- md
+	return &pb.LoginResponse{Token: "jwt", UserId: "id"}, nil
+}
+
+// GetUsers запоминает число ID в запросе и возвращает пользователя на каждый ID,
+// кроме нулевого.
+
+func (s *deadlineServer) GetUsers(_ context.Context, req *pb.GetUsersRequest) (*pb.GetUsersResponse, error) {
+	s.mu.Lock()
+	s.getUsersSize = append(s.getUsersSize, len(req.UserIds))
+	s.mu.Unlock()
+	resp := &pb.GetUsersResponse{}
+	for _, id := range req.UserIds {
+		if id != uuid.Nil.String() {
+			resp.Users = append(resp.Users, &pb.UserSummary{UserId: id, Username: "user-" + id[:8], CreatedAt: timestamppb.Now()})
+		}
+	}
+	return resp, nil
+}
+
+// ExportUsers отправляет двумя сообщениями трех пользователей, имя которых начинается
+// с префикса из запроса, и запоминает срок вызова.
+
+func (s *deadlineServer) ExportUsers(req *pb.ExportUsersRequest, stream pb.AuthService_ExportUsersServer) error {
+	if _, ok := stream.Context().Deadline(); ok {
+		return status.Error(codes.FailedPrecondition, "export must not have a deadline")
+	}
+	prefix := req.GetFilter().GetUsernamePrefix()
+	for _, batch := range [][]string{{"1", "2"}, {"3"}} {
+		resp := &pb.ExportUsersResponse{}
+		for _, n := range batch {
+			resp.Users = append(resp.Users, &pb.UserData{UserId: uuid.NewString(), Username: prefix + n, CreatedAt: timestamppb.Now()})
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamUsers отправляет пятерых пользователей user1..user5 пакетами по batch_size. Для префикса
+// "endless" отправляет пакеты, пока клиент не отменит вызов. Результат обработчика передается
+// в streamDone.
+
+func (s *deadlineServer) StreamUsers(req *pb.StreamUsersRequest, stream pb.AuthService_StreamUsersServer) (err error) {
+	defer func() { s.streamDone <- err }()
+	if _, ok := stream.Context().Deadline(); ok {
+		return status.Error(codes.FailedPrecondition, "stream must not have a deadline")
+	}
+	batchSize := int(req.BatchSize)
+	if batchSize <= 0 {
+		return status.Error(codes.InvalidArgument, "batch size is required")
+	}
+	endless := req.GetFilter().GetUsernamePrefix() == "endless"
+	for n := 1; endless || n <= 5; {
+		resp := &pb.StreamUsersResponse{}
+		for ; len(resp.Users) < batchSize && (endless || n <= 5); n++ {
+			resp.Users = append(resp.Users, &pb.UserData{UserId: uuid.NewString(), Username: fmt.Sprintf("user%d", n), CreatedAt: timestamppb.Now()})
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startDeadlineServer запускает тестовый сервис аутентификации и возвращает его адрес.
+
+func startDeadlineServer(t *testing.T) (string, *deadlineServer) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &deadlineServer{
+		remaining:      make(chan time.Duration, 1),
+		internalTokens: make(chan []string, 1),
+		loginMetadata:  make(chan metadata.MD, 1),
+		streamDone:     make(chan error, 1),
+	}
+	server := grpc.NewServer()
+	pb.RegisterAuthServiceServer(server, srv)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+	return lis.Addr().String(), srv
+}
+
+// TestDeadline_DerivedFromCaller проверяет, что срок вызова берется из контекста вызывающего
+// и не продлевается, а без срока ограничивается DefaultValidationTimeout.
+
+func TestDeadline_DerivedFromCaller(t *testing.T) {
+	addr, srv := startDeadlineServer(t)
+	client, err := NewAuthClient(addr)
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err = client.ValidateTokenFull(ctx, "token")
+	require.NoError(t, err)
+	remaining := <-srv.remaining
+	assert.Positive(t, remaining)
+	assert.LessOrEqual(t, remaining, 200*time.Millisecond)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, err = client.ValidateTokenFull(ctx, "token")
+	require.NoError(t, err)
+	assert.Greater(t, <-srv.remaining, DefaultValidationTimeout)
+
+	_, err = client.ValidateTokenFull(context.Background(), "token")
+	require.NoError(t, err)
+	remaining = <-srv.remaining
+	assert.Positive(t, remaining)
+	assert.LessOrEqual(t, remaining, DefaultValidationTimeout)
+}
+
+// TestNewAuthClientWithConn проверяет настраиваемое ограничение времени проверки токена
+// и то, что Close клиента не закрывает общее соединение.
+
+func TestNewAuthClientWithConn(t *testing.T) {
+	addr, srv := startDeadlineServer(t)
+	conn, err := Dial(addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := NewAuthClientWithConn(conn, Options{ValidationTimeout: 100 * time.Millisecond})
+	info, err := client.ValidateTokenFull(context.Background(), "token")
+	require.NoError(t, err)
+	assert.LessOrEqual(t, <-srv.remaining, 100*time.Millisecond)
+	assert.Equal(t, int64(1767225600), info.ExpiresAt.Unix())
+	assert.Equal(t, 90*time.Second, info.ExpiresIn)
+
+	// После закрытия клиента соединение продолжает обслуживать другие клиенты
+	require.NoError(t, client.Close())
+	other := NewAuthClientWithConn(conn, Options{})
+	valid, _, err := other.ValidateToken(context.Background(), "token")
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.LessOrEqual(t, <-srv.remaining, DefaultValidationTimeout)
+}
+
+// TestInternalToken проверяет, что секрет внутренних сервисов передается в метаданных
+// только если он задан.
+
+func TestInternalToken(t *testing.T) {
+	addr, srv := startDeadlineServer(t)
+	conn, err := Dial(addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, _, err = NewAuthClientWithConn(conn, Options{InternalToken: "secret"}).Register(context.Background(), "user", "password")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"secret"}, <-srv.internalTokens)
+
+	_, _, err = NewAuthClientWithConn(conn, Options{}).Register(context.Background(), "user", "password")
+	require.NoError(t, err)
+	assert.Empty(t, <-srv.internalTokens)
+}
+
+// TestClientInfo проверяет передачу данных клиента из контекста в метаданных вызова.
+
+func TestClientInfo(t *testing.T) {
+	addr, srv := startDeadlineServer(t)
+	client, err := NewAuthClient(addr)
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx := WithClientInfo(context.Background(), ClientInfo{IP: "203.0.113.5", DeviceLabel: "Firefox", UserAgent: "Mozilla/5.0 Firefox/128.0"})
+	_, _, err = client.Login(ctx, "user", "password")
+	require.NoError(t, err)
+	md := <-srv.loginMetadata
+	assert.Equal(t, []string{"203.0.113.5"}, md.Get(ClientIPMetadataKey))
+	assert.Equal(t, []string{"Firefox"}, md.Get(DeviceLabelMetadataKey))
+	assert.Equal(t, []string{"Mozilla/5.0 Firefox/128.0"}, md.Get(UserAgentMetadataKey))
+
+	_, _, err = client.Login(context.Background(), "user", "password")
+	require.NoError(t, err)
+	assert.Empty(t, (<-srv.loginMetadata).Get(ClientIPMetadataKey))
+}
+
+// TestGetUsers проверяет, что повторяющиеся ID запрашиваются один раз, длинный список
+// разбивается на вызовы не больше MaxGetUsers, а пустой список не требует вызова.
+
+func TestGetUsers(t *testing.T) {
+	addr, srv := startDeadlineServer(t)
+	client, err := NewAuthClient(addr)
+	require.NoError(t, err)
+	defer client.Close()
+
+	users, err := client.GetUsers(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+	assert.Empty(t, srv.getUsersSize)
+
+	ids := make([]uuid.UUID, MaxGetUsers+1)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+	ids = append(ids, ids[0], uuid.Nil)
+	users, err = client.GetUsers(context.Background(), ids)
+	require.NoError(t, err)
+	assert.Len(t, users, MaxGetUsers+1)
+	assert.Equal(t, "user-"+ids[0].String()[:8], users[ids[0]].Username)
+	assert.False(t, users[ids[0]].CreatedAt.IsZero())
+	assert.Equal(t, []int{MaxGetUsers, 2}, srv.getUsersSize)
+}
+
+// TestExportUsers проверяет, что выгрузка читает все сообщения потока без ограничения времени
+// и с параметрами вызова Options, а ошибка fn прекращает ее.
+
+func TestExportUsers(t *testing.T) {
+	addr, _ := startDeadlineServer(t)
+	conn, err := Dial(addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := NewAuthClientWithConn(conn, Options{MaxRecvMsgSize: DefaultMaxMsgSize})
+
+	var usernames []string
+	err = client.ExportUsers(context.Background(), UserFilter{UsernamePrefix: "user"}, func(user UserInfo) error {
+		usernames = append(usernames, user.Username)
+		assert.False(t, user.CreatedAt.IsZero())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user1", "user2", "user3"}, usernames)
+
+	err = client.ExportUsers(context.Background(), UserFilter{}, func(UserInfo) error { return assert.AnError })
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+// TestStreamUsers проверяет, что итератор возвращает всех пользователей по порядку при неполном
+// и при полном последнем пакете, после последнего — io.EOF, а ошибку вызова — из Next.
+
+func TestStreamUsers(t *testing.T) {
+	addr, srv := startDeadlineServer(t)
+	client, err := NewAuthClient(addr)
+	require.NoError(t, err)
+	defer client.Close()
+
+	for _, batchSize := range []int{1, 2, 4, 5, 6} {
+		it := client.StreamUsers(context.Background(), UserFilter{}, batchSize)
+		var usernames []string
+		for {
+			user, err := it.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err, "batch size %d", batchSize)
+			usernames = append(usernames, user.Username)
+		}
+		assert.Equal(t, []string{"user1", "user2", "user3", "user4", "user5"}, usernames, "batch size %d", batchSize)
+		require.NoError(t, <-srv.streamDone)
+		_, err = it.Next()
+		assert.Equal(t, io.EOF, err)
+		require.NoError(t, it.Close())
+	}
+
+	it := client.StreamUsers(context.Background(), UserFilter{}, 0)
+	_, err = it.Next()
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+	_, err = it.Next()
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+	<-srv.streamDone
+}
+
+// TestStreamUsers_Close проверяет, что закрытие итератора до конца потока отменяет вызов:
+// обработчик сервера, отправляющий пакеты без конца, завершается.
+
+func TestStreamUsers_Close(t *testing.T) {
+	addr, srv := startDeadlineServer(t)
+	client, err := NewAuthClient(addr)
+	require.NoError(t, err)
+	defer client.Close()
+
+	it := client.StreamUsers(context.Background(), UserFilter{UsernamePrefix: "endless"}, 1)
+	for i := 1; i <= 3; i++ {
+		user, err := it.Next()
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("user%d", i), user.Username)
+	}
+	require.NoError(t, it.Close())
+
+	select {
+	case err := <-srv.streamDone:
+		assert.Equal(t, codes.Canceled, status.Code(err))
+	case <-time.After(5 * time.Second):
+		t.Fatal("server handler did not stop after Close")
+	}
+	_, err = it.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+// TestUserIterator проверяет, что итератор пропускает пустые пакеты, повторяет ошибку
+// источника и освобождает его один раз.
+
+func TestUserIterator(t *testing.T) {
+	batches := [][]UserInfo{{{Username: "a"}, {Username: "b"}}, {}, {{Username: "c"}}}
+	stops := 0
+	it := NewUserIterator(func() ([]UserInfo, error) {
+		if len(batches) == 0 {
+			return nil, assert.AnError
+		}
+		batch := batches[0]
+		batches = batches[1:]
+		return batch, nil
+	}, func() { stops++ })
+
+	var usernames []string
+	for i := 0; i < 3; i++ {
+		user, err := it.Next()
+		require.NoError(t, err)
+		usernames = append(usernames, user.Username)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, usernames)
+	_, err := it.Next()
+	assert.ErrorIs(t, err, assert.AnError)
+	_, err = it.Next()
+	assert.ErrorIs(t, err, assert.AnError)
+	require.NoError(t, it.Close())
+	assert.Equal(t, 1, stops)
+}
+
+// TestMaxSendMsgSize проверяет, что запрос больше Options.MaxSendMsgSize не отправляется
+// и завершается ошибкой codes.ResourceExhausted, а остальные вызовы выполняются.
+
+func TestMaxSendMsgSize(t *testing.T) {
+	addr, srv := startDeadlineServer(t)
+	conn, err := Dial(addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := NewAuthClientWithConn(conn, Options{MaxSendMsgSize: 1024, MaxRecvMsgSize: 1024})
+
+	_, _, err = client.Login(context.Background(), "user", strings.Repeat("x", 2048))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Empty(t, srv.loginMetadata)
+
+	_, _, err = client.Login(context.Background(), "user", "password")
+	require.NoError(t, err)
+	<-srv.loginMetadata
+}
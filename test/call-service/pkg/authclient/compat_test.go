@@ -0,0 +1,67 @@
+package authclient
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	pb "api/auth"
+)
+
+// legacyServer отвечает на ValidateToken сообщением, разобранным из байтов, которые
+// отправляла версия сервиса аутентификации без полей role, session_id и expires_at.
+
+type legacyServer struct {
+	pb.UnimplementedAuthServiceServer
+	response []byte
+}
+
+func (s *legacyServer) ValidateToken(context.Context, *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
+	resp := &pb.ValidateTokenResponse{}
+	if err := proto.Unmarshal(s.response, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// TestValidateTokenFull_LegacyResponse проверяет, что ответ прежней версии сервиса
+// аутентификации, содержащий только valid (1) и user_id (2), разбирается в TokenInfo
+// с нулевыми значениями новых полей.
+
+func TestValidateTokenFull_LegacyResponse(t *testing.T) {
+	var legacy []byte
+	legacy = protowire.AppendTag(legacy, 1, protowire.VarintType)
+	legacy = protowire.AppendVarint(legacy, 1)
+	legacy = protowire.AppendTag(legacy, 2, protowire.BytesType)
+	legacy = protowire.AppendString(legacy, "user-1")
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	pb.RegisterAuthServiceServer(server, &legacyServer{response: legacy})
+	go func() { _ = server.Serve(lis) }()
+	defer server.Stop()
+
+	client, err := NewAuthClient(lis.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	info, err := client.ValidateTokenFull(context.Background(), "token")
+	require.NoError(t, err)
+	assert.Equal(t, &TokenInfo{Valid: true, UserID: "user-1"}, info)
+	assert.Empty(t, info.Role)
+	assert.True(t, info.ExpiresAt.IsZero())
+	assert.Equal(t, time.Duration(0), info.ExpiresIn)
+
+	valid, userID, err := client.ValidateToken(context.Background(), "token")
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, "user-1", userID)
+}
@@ -0,0 +1,63 @@
+package authclient
+
+import (
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Ошибки, которые Register и Login возвращают вместо статусов gRPC. Проверяются через errors.Is;
+// статус gRPC по-прежнему доступен через status.Code и status.Convert.
+var (
+	// ErrUserExists — пользователь с таким именем уже зарегистрирован (codes.AlreadyExists).
+	ErrUserExists = errors.New("user already exists")
+	// ErrInvalidCredentials — неверное имя пользователя или пароль (codes.Unauthenticated).
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrInvalidArgument — сервис аутентификации отклонил данные запроса (codes.InvalidArgument).
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrUnavailable — сервис аутентификации недоступен (codes.Unavailable).
+	ErrUnavailable = errors.New("auth service unavailable")
+)
+
+// Error — ошибка вызова сервиса аутентификации с известной причиной. Текст ошибки —
+// сообщение сервиса аутентификации без транспортных подробностей.
+
+type Error struct {
+	kind   error
+	status *status.Status
+}
+
+func (e *Error) Error() string { return e.status.Message() }
+
+// Is сообщает, соответствует ли ошибка одной из ошибок ErrUserExists, ErrInvalidCredentials,
+// ErrInvalidArgument или ErrUnavailable.
+func (e *Error) Is(target error) bool { return target == e.kind }
+
+// GRPCStatus возвращает исходный статус gRPC.
+func (e *Error) GRPCStatus() *status.Status { return e.status }
+
+// FromStatus преобразует ошибку gRPC в *Error, если ее код соответствует одной из известных
+// причин; остальные ошибки возвращаются без изменений. Позволяет настраивать mock-клиенты
+// так же, как отвечает настоящий клиент.
+
+func FromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	var kind error
+	switch st.Code() {
+	case codes.AlreadyExists:
+		kind = ErrUserExists
+	case codes.Unauthenticated:
+		kind = ErrInvalidCredentials
+	case codes.InvalidArgument:
+		kind = ErrInvalidArgument
+	case codes.Unavailable:
+		kind = ErrUnavailable
+	default:
+		return err
+	}
+	return &Error{kind: kind, status: st}
+}
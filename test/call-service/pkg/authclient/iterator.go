@@ -0,0 +1,68 @@
+package authclient
+
+import "io"
+
+// UserIterator последовательно возвращает пользователей потоковой выгрузки (AuthClient.StreamUsers),
+// скрывая получение сообщений потока. Очередной пакет запрашивается у источника, только когда
+// прочитан предыдущий, поэтому медленный читатель задерживает сервис аутентификации, а не
+// накапливает пакеты в памяти. Итератор нужно закрыть вызовом Close, если он не прочитан до конца:
+// закрытие отменяет вызов и освобождает ресурсы сервиса. Итератор не рассчитан на одновременное
+// использование из нескольких горутин.
+
+type UserIterator struct {
+	next  func() ([]UserInfo, error)
+	stop  func()
+	batch []UserInfo
+	err   error
+}
+
+// NewUserIterator создает итератор поверх источника пакетов next, который возвращает
+// очередной пакет пользователей или io.EOF после последнего. stop освобождает ресурсы
+// источника; он вызывается один раз — из Close или после первой ошибки next, включая io.EOF.
+// Используется реализациями AuthClient и в тестах.
+
+func NewUserIterator(next func() ([]UserInfo, error), stop func()) *UserIterator {
+	return &UserIterator{next: next, stop: stop}
+}
+
+// Next возвращает очередного пользователя. После последнего пользователя и после Close
+// возвращается io.EOF, после ошибки выгрузки — та же ошибка при каждом вызове.
+
+func (it *UserIterator) Next() (*UserInfo, error) {
+	for len(it.batch) == 0 {
+		if it.err != nil {
+			return nil, it.err
+		}
+		batch, err := it.next()
+		if err != nil {
+			it.err = err
+			it.release()
+			return nil, err
+		}
+		it.batch = batch
+	}
+	user := it.batch[0]
+	it.batch = it.batch[1:]
+	return &user, nil
+}
+
+// Close прекращает выгрузку, не дочитывая поток. Повторный вызов и вызов после окончания
+// выгрузки ничего не делают.
+
+func (it *UserIterator) Close() error {
+	it.batch = nil
+	if it.err == nil {
+		it.err = io.EOF
+	}
+	it.release()
+	return nil
+}
+
+// release вызывает stop не больше одного раза.
+
+func (it *UserIterator) release() {
+	if it.stop != nil {
+		it.stop()
+		it.stop = nil
+	}
+}
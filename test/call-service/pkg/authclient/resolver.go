@@ -0,0 +1,212 @@
+package authclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	_ "google.golang.org/grpc/health" // проверка состояния серверов на стороне клиента
+	"google.golang.org/grpc/resolver"
+)
+
+// DefaultResolveInterval — период повторного разрешения адресов сервиса аутентификации
+// по умолчанию.
+const DefaultResolveInterval = 30 * time.Second
+
+// resolverScheme — схема адреса, по которой Dial выбирает resolver этого пакета.
+const resolverScheme = "authclient"
+
+// serviceConfig распределяет вызовы по всем адресам сервиса по очереди (round_robin)
+// и пропускает серверы, которые по протоколу grpc.health.v1 сообщают не SERVING
+// (например, сервис аутентификации при остановке).
+const serviceConfig = `{
+	"loadBalancingConfig": [{"round_robin": {}}],
+	"healthCheckConfig": {"serviceName": ""}
+}`
+
+// DialOption задает необязательный параметр Dial.
+
+type DialOption func(*dialOptions)
+
+type dialOptions struct {
+	resolveInterval time.Duration
+	lookupHost      func(ctx context.Context, host string) ([]string, error)
+}
+
+// WithResolveInterval задает период, с которым Dial повторно разрешает имена в адресе,
+// чтобы вызовы начали поступать на новые экземпляры сервиса без перезапуска клиента.
+// 0 — DefaultResolveInterval.
+
+func WithResolveInterval(d time.Duration) DialOption {
+	return func(o *dialOptions) {
+		o.resolveInterval = d
+	}
+}
+
+// withLookupHost заменяет разрешение имен в DNS (для тестов).
+
+func withLookupHost(lookup func(ctx context.Context, host string) ([]string, error)) DialOption {
+	return func(o *dialOptions) {
+		o.lookupHost = lookup
+	}
+}
+
+// Dial создает gRPC-соединение с внутренним сервисом. Одно соединение можно передать
+// в NewAuthClientWithConn и в клиенты других сервисов по тому же адресу.
+//
+// addr — адрес host:port или несколько адресов через запятую. Имена разрешаются в DNS
+// при создании соединения, раз в WithResolveInterval и после обрыва соединения с одним
+// из серверов; вызовы распределяются по всем полученным адресам по очереди, а серверы,
+// которые не отвечают или сообщают о состоянии не SERVING, пропускаются. Адрес со схемой
+// gRPC (например, dns:///auth:50051 или unix:///run/auth.sock) передается gRPC без изменений.
+
+func Dial(addr string, opts ...DialOption) (*grpc.ClientConn, error) {
+	o := dialOptions{resolveInterval: DefaultResolveInterval, lookupHost: net.DefaultResolver.LookupHost}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.resolveInterval <= 0 {
+		o.resolveInterval = DefaultResolveInterval
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+	}
+	target := addr
+	if !strings.Contains(addr, "://") {
+		endpoints, err := parseEndpoints(addr)
+		if err != nil {
+			return nil, err
+		}
+		target = resolverScheme + ":///" + addr
+		dialOpts = append(dialOpts, grpc.WithResolvers(&resolverBuilder{endpoints: endpoints, opts: o}))
+	}
+	return grpc.NewClient(target, dialOpts...)
+}
+
+// endpoint — имя или IP-адрес сервера и его порт.
+
+type endpoint struct {
+	host, port string
+}
+
+// parseEndpoints разбирает список адресов host:port через запятую.
+
+func parseEndpoints(addr string) ([]endpoint, error) {
+	var endpoints []endpoint
+	for _, part := range strings.Split(addr, ",") {
+		host, port, err := net.SplitHostPort(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid auth service address %q: %w", part, err)
+		}
+		endpoints = append(endpoints, endpoint{host: host, port: port})
+	}
+	return endpoints, nil
+}
+
+// resolverBuilder создает resolver для адресов, переданных в Dial.
+
+type resolverBuilder struct {
+	endpoints []endpoint
+	opts      dialOptions
+}
+
+func (b *resolverBuilder) Scheme() string { return resolverScheme }
+
+func (b *resolverBuilder) Build(_ resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &pollingResolver{
+		endpoints: b.endpoints,
+		opts:      b.opts,
+		cc:        cc,
+		ctx:       ctx,
+		cancel:    cancel,
+		now:       make(chan struct{}, 1),
+	}
+	r.wg.Add(1)
+	go r.run()
+	return r, nil
+}
+
+// pollingResolver разрешает адреса сразу, раз в resolveInterval и по запросу gRPC
+// (ResolveNow после обрыва соединения) и передает gRPC все полученные адреса.
+
+type pollingResolver struct {
+	endpoints []endpoint
+	opts      dialOptions
+	cc        resolver.ClientConn
+	ctx       context.Context
+	cancel    context.CancelFunc
+	// now сигнализирует о запросе немедленного разрешения; запросы, пришедшие во время
+	// разрешения, объединяются в один.
+	now chan struct{}
+	wg  sync.WaitGroup
+}
+
+func (r *pollingResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.now <- struct{}{}:
+	default:
+	}
+}
+
+func (r *pollingResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *pollingResolver) run() {
+	defer r.wg.Done()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-timer.C:
+		case <-r.now:
+			timer.Stop()
+		}
+		r.resolve()
+		timer.Reset(r.opts.resolveInterval)
+	}
+}
+
+// resolve разрешает все адреса и передает gRPC полученные. Адреса, имена которых
+// не разрешились, пропускаются; если не разрешилось ни одно, gRPC получает ошибку
+// и продолжает использовать прежние адреса.
+
+func (r *pollingResolver) resolve() {
+	ctx, cancel := context.WithTimeout(r.ctx, r.opts.resolveInterval)
+	defer cancel()
+	var addrs []resolver.Address
+	var errs []error
+	for _, ep := range r.endpoints {
+		hosts, err := r.opts.lookupHost(ctx, ep.host)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("resolve %s: %w", ep.host, err))
+			continue
+		}
+		for _, host := range hosts {
+			addrs = append(addrs, resolver.Address{Addr: net.JoinHostPort(host, ep.port), ServerName: ep.host})
+		}
+	}
+	if len(addrs) == 0 {
+		if r.ctx.Err() == nil {
+			r.cc.ReportError(errors.Join(errs...))
+		}
+		return
+	}
+	if len(errs) > 0 {
+		log.Printf("auth service addresses partially resolved: %v", errors.Join(errs...))
+	}
+	_ = r.cc.UpdateState(resolver.State{Addresses: addrs})
+}
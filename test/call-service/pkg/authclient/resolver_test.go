@@ -0,0 +1,136 @@
+package authclient
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	pb "api/auth"
+)
+
+// countingServer считает вызовы ValidateToken.
+
+type countingServer struct {
+	pb.UnimplementedAuthServiceServer
+	calls atomic.Int64
+}
+
+func (s *countingServer) ValidateToken(context.Context, *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
+	s.calls.Add(1)
+	return &pb.ValidateTokenResponse{Valid: true}, nil
+}
+
+// replica — экземпляр тестового сервиса аутентификации с сервером проверки состояния.
+
+type replica struct {
+	addr   string
+	server *grpc.Server
+	auth   *countingServer
+	health *health.Server
+}
+
+// startReplica запускает экземпляр сервиса аутентификации на адресе addr.
+
+func startReplica(t *testing.T, addr string) *replica {
+	lis, err := net.Listen("tcp", addr)
+	require.NoError(t, err)
+	r := &replica{addr: lis.Addr().String(), server: grpc.NewServer(), auth: &countingServer{}, health: health.NewServer()}
+	pb.RegisterAuthServiceServer(r.server, r.auth)
+	healthpb.RegisterHealthServer(r.server, r.health)
+	go r.server.Serve(lis)
+	t.Cleanup(r.server.Stop)
+	return r
+}
+
+// validate выполняет n вызовов ValidateToken и возвращает число успешных.
+
+func validate(client AuthClient, n int) int {
+	ok := 0
+	for range n {
+		if _, _, err := client.ValidateToken(context.Background(), "token"); err == nil {
+			ok++
+		}
+	}
+	return ok
+}
+
+// Тест балансировки: вызовы распределяются по обоим экземплярам, экземпляр в состоянии
+// NOT_SERVING пропускается, а после остановки одного экземпляра вызовы продолжаются на другом
+
+func TestDial_RoundRobinAndFailover(t *testing.T) {
+	first := startReplica(t, "127.0.0.1:0")
+	second := startReplica(t, "127.0.0.1:0")
+	conn, err := Dial(first.addr + "," + second.addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := NewAuthClientWithConn(conn, Options{})
+
+	require.Eventually(t, func() bool {
+		validate(client, 10)
+		return first.auth.calls.Load() > 0 && second.auth.calls.Load() > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Экземпляр сообщает NOT_SERVING, например при остановке, и перестает получать вызовы
+	first.health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	require.Eventually(t, func() bool {
+		before := first.auth.calls.Load()
+		return validate(client, 10) == 10 && first.auth.calls.Load() == before
+	}, 5*time.Second, 10*time.Millisecond)
+
+	first.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	second.server.Stop()
+	require.Eventually(t, func() bool {
+		before := first.auth.calls.Load()
+		return validate(client, 10) == 10 && first.auth.calls.Load() == before+10
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// Тест повторного разрешения имен: экземпляр, появившийся в DNS, начинает получать вызовы
+// без пересоздания соединения
+
+func TestDial_ResolvesNewReplicas(t *testing.T) {
+	first := startReplica(t, "127.0.0.1:0")
+	_, port, err := net.SplitHostPort(first.addr)
+	require.NoError(t, err)
+	second := startReplica(t, net.JoinHostPort("127.0.0.2", port))
+
+	var mu sync.Mutex
+	hosts := []string{"127.0.0.1"}
+	lookup := func(_ context.Context, host string) ([]string, error) {
+		assert.Equal(t, "auth.test", host)
+		mu.Lock()
+		defer mu.Unlock()
+		return hosts, nil
+	}
+	conn, err := Dial(net.JoinHostPort("auth.test", port), WithResolveInterval(20*time.Millisecond), withLookupHost(lookup))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := NewAuthClientWithConn(conn, Options{})
+
+	require.Equal(t, 10, validate(client, 10))
+	assert.Zero(t, second.auth.calls.Load())
+
+	mu.Lock()
+	hosts = []string{"127.0.0.1", "127.0.0.2"}
+	mu.Unlock()
+	require.Eventually(t, func() bool {
+		validate(client, 10)
+		return second.auth.calls.Load() > 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// Тест разбора адреса: адрес без порта отклоняется до создания соединения
+
+func TestDial_InvalidAddress(t *testing.T) {
+	_, err := Dial("auth-a:50051,auth-b")
+	assert.ErrorContains(t, err, `invalid auth service address "auth-b"`)
+}
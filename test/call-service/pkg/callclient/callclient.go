@@ -0,0 +1,87 @@
+// Package callclient содержит клиентские обертки gRPC API сервиса заявок (CallService) для
+// внутренних сервисов. Обычные вызовы выполняются сгенерированным клиентом callpb напрямую;
+// пакет скрывает механику потоковых вызовов за итераторами.
+package callclient
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc"
+
+	pb "api/call"
+)
+
+// CallIterator последовательно возвращает заявки потоковой выгрузки StreamCalls. Очередное
+// сообщение потока читается, только когда прочитаны заявки предыдущего, поэтому медленный
+// читатель задерживает сервис заявок через управление потоком gRPC, а не накапливает пакеты
+// в памяти. Итератор нужно закрыть вызовом Close, если он не прочитан до конца: закрытие
+// отменяет вызов, и сервис прекращает чтение заявок. Итератор не рассчитан на одновременное
+// использование из нескольких горутин.
+type CallIterator struct {
+	open   func() (pb.CallService_StreamCallsClient, error)
+	stream pb.CallService_StreamCallsClient
+	cancel context.CancelFunc
+	batch  []*pb.Call
+	err    error
+}
+
+// StreamCalls возвращает итератор по заявкам, удовлетворяющим условиям отбора req, в порядке
+// сортировки. Токен пользователя передается в метаданных ctx (authorization: Bearer <token>).
+// Вызов выполняется при первом Next; ошибки вызова, в том числе отказ в доступе и неверные
+// условия отбора, возвращает Next в виде статуса gRPC.
+func StreamCalls(ctx context.Context, client pb.CallServiceClient, req *pb.StreamCallsRequest, opts ...grpc.CallOption) *CallIterator {
+	ctx, cancel := context.WithCancel(ctx)
+	return &CallIterator{
+		open: func() (pb.CallService_StreamCallsClient, error) {
+			return client.StreamCalls(ctx, req, opts...)
+		},
+		cancel: cancel,
+	}
+}
+
+// Next возвращает очередную заявку. После последней заявки и после Close возвращается io.EOF,
+// после ошибки вызова — та же ошибка при каждом вызове.
+func (it *CallIterator) Next() (*pb.Call, error) {
+	for len(it.batch) == 0 {
+		if it.err != nil {
+			return nil, it.err
+		}
+		if err := it.recv(); err != nil {
+			it.err = err
+			it.cancel()
+			return nil, err
+		}
+	}
+	call := it.batch[0]
+	it.batch = it.batch[1:]
+	return call, nil
+}
+
+// recv открывает поток при первом вызове и читает из него очередное сообщение.
+func (it *CallIterator) recv() error {
+	if it.stream == nil {
+		stream, err := it.open()
+		if err != nil {
+			return err
+		}
+		it.stream = stream
+	}
+	resp, err := it.stream.Recv()
+	if err != nil {
+		return err
+	}
+	it.batch = resp.Calls
+	return nil
+}
+
+// Close прекращает выгрузку, не дочитывая поток. Повторный вызов и вызов после окончания
+// выгрузки ничего не делают.
+func (it *CallIterator) Close() error {
+	it.batch = nil
+	if it.err == nil {
+		it.err = io.EOF
+	}
+	it.cancel()
+	return nil
+}
@@ -0,0 +1,83 @@
+package callclient
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	pb "api/call"
+)
+
+// fakeClient открывает поток, отдающий batches, а затем ошибку err.
+type fakeClient struct {
+	pb.CallServiceClient
+	batches [][]*pb.Call
+	err     error
+	opened  int
+	ctx     context.Context
+}
+
+func (c *fakeClient) StreamCalls(ctx context.Context, _ *pb.StreamCallsRequest, _ ...grpc.CallOption) (pb.CallService_StreamCallsClient, error) {
+	c.opened++
+	c.ctx = ctx
+	return &fakeStream{client: c}, nil
+}
+
+type fakeStream struct {
+	grpc.ClientStream
+	client *fakeClient
+}
+
+func (s *fakeStream) Recv() (*pb.StreamCallsResponse, error) {
+	if len(s.client.batches) == 0 {
+		return nil, s.client.err
+	}
+	batch := s.client.batches[0]
+	s.client.batches = s.client.batches[1:]
+	return &pb.StreamCallsResponse{Calls: batch}, nil
+}
+
+// TestCallIterator проверяет, что вызов открывается при первом Next, пустые пакеты
+// пропускаются, а ошибка потока повторяется и отменяет контекст вызова.
+
+func TestCallIterator(t *testing.T) {
+	client := &fakeClient{
+		batches: [][]*pb.Call{{{Id: "a"}, {Id: "b"}}, {}, {{Id: "c"}}},
+		err:     assert.AnError,
+	}
+	it := StreamCalls(context.Background(), client, &pb.StreamCallsRequest{})
+	assert.Zero(t, client.opened)
+
+	var ids []string
+	for range 3 {
+		call, err := it.Next()
+		require.NoError(t, err)
+		ids = append(ids, call.Id)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+	for range 2 {
+		_, err := it.Next()
+		assert.ErrorIs(t, err, assert.AnError)
+	}
+	assert.Equal(t, 1, client.opened)
+	assert.ErrorIs(t, client.ctx.Err(), context.Canceled)
+	require.NoError(t, it.Close())
+	_, err := it.Next()
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+// TestCallIterator_Close проверяет, что закрытый до первого Next итератор не открывает вызов.
+
+func TestCallIterator_Close(t *testing.T) {
+	client := &fakeClient{err: io.EOF}
+	it := StreamCalls(context.Background(), client, &pb.StreamCallsRequest{})
+	require.NoError(t, it.Close())
+	require.NoError(t, it.Close())
+	_, err := it.Next()
+	assert.Equal(t, io.EOF, err)
+	assert.Zero(t, client.opened)
+}
@@ -0,0 +1,118 @@
+// Package signing подписывает тела запросов webhook сервиса заявок и проверяет их подпись
+// на стороне получателя.
+//
+// Подпись передается в заголовке SignatureHeader в виде "t=<timestamp>,v1=<signature>",
+// где timestamp — время отправки в секундах Unix, а signature — HMAC-SHA256 строки
+// "<timestamp>.<body>" на общем секрете в шестнадцатеричной записи. Время входит в подпись,
+// поэтому перехваченный запрос нельзя повторить позже допустимого расхождения часов;
+// повторы внутри этого окна получатель отсекает по заголовку DeliveryHeader.
+//
+// Получатель проверяет запрос так:
+//
+//	body, _ := io.ReadAll(r.Body)
+//	if err := signing.Verify(r.Header.Get(signing.SignatureHeader), secret, body, signing.DefaultTolerance); err != nil {
+//		http.Error(w, "invalid signature", http.StatusUnauthorized)
+//		return
+//	}
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Заголовки запроса webhook
+const (
+	// SignatureHeader содержит время отправки и подпись тела.
+	SignatureHeader = "X-Webhook-Signature"
+	// TimestampHeader содержит время отправки в секундах Unix, то же, что в подписи.
+	TimestampHeader = "X-Webhook-Timestamp"
+	// DeliveryHeader содержит уникальный идентификатор доставки; повторные отправки одной
+	// доставки передают тот же идентификатор.
+	DeliveryHeader = "X-Webhook-Delivery"
+)
+
+// DefaultTolerance — допустимое расхождение времени отправки и времени проверки по умолчанию.
+const DefaultTolerance = 5 * time.Minute
+
+// signatureVersion — схема подписи в заголовке SignatureHeader.
+const signatureVersion = "v1"
+
+// Ошибки проверки подписи
+var (
+	// ErrInvalidHeader возвращается, если заголовок подписи отсутствует или не разбирается.
+	ErrInvalidHeader = errors.New("signing: invalid signature header")
+	// ErrTimestampOutOfRange возвращается, если время отправки отличается от текущего
+	// больше допустимого расхождения в любую сторону.
+	ErrTimestampOutOfRange = errors.New("signing: timestamp outside the tolerance window")
+	// ErrSignatureMismatch возвращается, если ни одна подпись из заголовка не совпадает с телом.
+	ErrSignatureMismatch = errors.New("signing: signature mismatch")
+)
+
+// Sign возвращает значение заголовка SignatureHeader для тела body, отправленного в момент timestamp.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + ts + "," + signatureVersion + "=" + hex.EncodeToString(compute(secret, ts, body))
+}
+
+// Verify проверяет заголовок SignatureHeader тела body: время отправки должно отличаться
+// от текущего не больше чем на tolerance (значение <= 0 — DefaultTolerance), а одна из
+// подписей v1 — совпадать с подписью на секрете secret. Несколько подписей в заголовке
+// допускаются на время замены секрета.
+func Verify(header, secret string, body []byte, tolerance time.Duration) error {
+	return verify(header, secret, body, tolerance, time.Now())
+}
+
+func verify(header, secret string, body []byte, tolerance time.Duration, now time.Time) error {
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	var ts string
+	var signatures [][]byte
+	for _, item := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return ErrInvalidHeader
+		}
+		switch name {
+		case "t":
+			ts = value
+		case signatureVersion:
+			signature, err := hex.DecodeString(value)
+			if err != nil {
+				return ErrInvalidHeader
+			}
+			signatures = append(signatures, signature)
+		}
+	}
+	seconds, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidHeader
+	}
+
+	skew := now.Sub(time.Unix(seconds, 0))
+	if skew > tolerance || skew < -tolerance {
+		return ErrTimestampOutOfRange
+	}
+	expected := compute(secret, ts, body)
+	for _, signature := range signatures {
+		if hmac.Equal(signature, expected) {
+			return nil
+		}
+	}
+	return ErrSignatureMismatch
+}
+
+// compute возвращает HMAC-SHA256 строки "<ts>.<body>".
+func compute(secret, ts string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
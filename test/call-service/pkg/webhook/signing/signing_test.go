@@ -0,0 +1,73 @@
+package signing
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест формата подписи: заголовок содержит время отправки и HMAC-SHA256 строки "<t>.<body>"
+func TestSign(t *testing.T) {
+	sentAt := time.Unix(1700000000, 0)
+
+	header := Sign("secret", sentAt, []byte(`{"type":"call.callback_due"}`))
+	assert.Equal(t, "t=1700000000,v1=d9098120def99d87f1c2943c8a07ed2f3e1707246d387da8435fe9edacfd5455", header)
+	assert.NotEqual(t, header, Sign("other", sentAt, []byte(`{"type":"call.callback_due"}`)))
+	assert.NotEqual(t, header, Sign("secret", sentAt.Add(time.Second), []byte(`{"type":"call.callback_due"}`)))
+}
+
+// Тест проверки подписи: допускается расхождение часов не больше tolerance в обе стороны,
+// подпись должна совпадать с телом и секретом
+func TestVerify(t *testing.T) {
+	const secret = "secret"
+	body := []byte(`{"type":"call.callback_due"}`)
+	sentAt := time.Unix(1700000000, 0)
+	header := Sign(secret, sentAt, body)
+	tolerance := 5 * time.Minute
+
+	tests := []struct {
+		name      string
+		header    string
+		secret    string
+		body      []byte
+		tolerance time.Duration
+		now       time.Time
+		want      error
+	}{
+		{"valid", header, secret, body, tolerance, sentAt, nil},
+		{"delivered late within tolerance", header, secret, body, tolerance, sentAt.Add(tolerance), nil},
+		{"delivered late beyond tolerance", header, secret, body, tolerance, sentAt.Add(tolerance + time.Second), ErrTimestampOutOfRange},
+		{"receiver clock behind within tolerance", header, secret, body, tolerance, sentAt.Add(-tolerance), nil},
+		{"receiver clock behind beyond tolerance", header, secret, body, tolerance, sentAt.Add(-tolerance - time.Second), ErrTimestampOutOfRange},
+		{"default tolerance", header, secret, body, 0, sentAt.Add(DefaultTolerance), nil},
+		{"beyond default tolerance", header, secret, body, 0, sentAt.Add(DefaultTolerance + time.Second), ErrTimestampOutOfRange},
+		{"replay of old delivery", header, secret, body, tolerance, sentAt.Add(24 * time.Hour), ErrTimestampOutOfRange},
+		{"wrong secret", header, "other", body, tolerance, sentAt, ErrSignatureMismatch},
+		{"modified body", header, secret, []byte(`{"type":"call.deleted"}`), tolerance, sentAt, ErrSignatureMismatch},
+		{"timestamp replaced", strings.Replace(header, "t=1700000000", "t=1700000100", 1), secret, body, tolerance, sentAt, ErrSignatureMismatch},
+		{"rotated secret", header + "," + strings.TrimPrefix(Sign("old", sentAt, body), "t=1700000000,"), secret, body, tolerance, sentAt, nil},
+		{"unknown scheme ignored", "t=1700000000,v0=abc," + strings.TrimPrefix(header, "t=1700000000,"), secret, body, tolerance, sentAt, nil},
+		{"empty header", "", secret, body, tolerance, sentAt, ErrInvalidHeader},
+		{"no timestamp", strings.TrimPrefix(header, "t=1700000000,"), secret, body, tolerance, sentAt, ErrInvalidHeader},
+		{"no signature", "t=1700000000", secret, body, tolerance, sentAt, ErrInvalidHeader},
+		{"only unknown scheme", "t=1700000000,v0=abc", secret, body, tolerance, sentAt, ErrInvalidHeader},
+		{"malformed timestamp", "t=yesterday,v1=00", secret, body, tolerance, sentAt, ErrInvalidHeader},
+		{"malformed signature", "t=1700000000,v1=zz", secret, body, tolerance, sentAt, ErrInvalidHeader},
+		{"malformed item", "t=1700000000;v1=00", secret, body, tolerance, sentAt, ErrInvalidHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, verify(tt.header, tt.secret, tt.body, tt.tolerance, tt.now))
+		})
+	}
+}
+
+// Тест Verify по текущему времени: только что подписанное тело проходит проверку
+func TestVerify_Now(t *testing.T) {
+	body := []byte(`{}`)
+	require.NoError(t, Verify(Sign("secret", time.Now(), body), "secret", body, time.Minute))
+	assert.ErrorIs(t, Verify(Sign("secret", time.Now().Add(-time.Hour), body), "secret", body, time.Minute), ErrTimestampOutOfRange)
+}
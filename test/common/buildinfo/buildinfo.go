@@ -0,0 +1,56 @@
+// Package buildinfo содержит сведения о сборке сервиса: версию, коммит и дату сборки.
+// Пакет общий для обоих сервисов, поэтому значения задаются при сборке любого из них
+// одними и теми же флагами компоновщика, например:
+//
+//	go build -ldflags "-X common/buildinfo.Version=1.4.0 \
+//		-X common/buildinfo.Commit=$(git rev-parse HEAD) \
+//		-X common/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Без флагов все значения равны Dev.
+package buildinfo
+
+import (
+	"expvar"
+	"log/slog"
+	"runtime"
+)
+
+// Dev — значение сведений, не заданных при сборке.
+const Dev = "dev"
+
+// Сведения о сборке, задаваемые флагом -ldflags "-X". Переменные, а не константы, чтобы
+// компоновщик мог их заменить; изменять их во время работы нельзя.
+var (
+	Version = Dev
+	Commit  = Dev
+	Date    = Dev
+)
+
+// Info — сведения о сборке.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get возвращает сведения о текущей сборке.
+func Get() Info {
+	return Info{Version: Version, Commit: Commit, BuildDate: Date, GoVersion: runtime.Version()}
+}
+
+// Publish публикует сведения о сборке в /debug/vars под именем build_info. Функция
+// вызывается один раз при запуске.
+func Publish() {
+	expvar.Publish("build_info", expvar.Func(func() any { return Get() }))
+}
+
+// LogValue реализует slog.LogValuer: сведения записываются в журнал группой полей.
+func (i Info) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("version", i.Version),
+		slog.String("commit", i.Commit),
+		slog.String("build_date", i.BuildDate),
+		slog.String("go_version", i.GoVersion),
+	)
+}
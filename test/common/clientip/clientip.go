@@ -0,0 +1,89 @@
+// Package clientip определяет IP клиента HTTP-запроса, прошедшего через доверенные прокси.
+//
+// Адреса проверяются справа налево, начиная с адреса соединения и продолжая по X-Forwarded-For:
+// IP клиента — первый адрес, не принадлежащий доверенным прокси (правило крайнего правого
+// недоверенного адреса). Поэтому X-Forwarded-For от недоверенного источника не учитывается,
+// а адреса, которые клиент дописал в начало заголовка сам, не подменяют его адрес.
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParseTrustedProxies разбирает адреса и подсети доверенных прокси (например, "10.0.0.0/8"
+// или "192.0.2.1"). Пустой список означает, что прокси не доверяют.
+func ParseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	proxies := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if strings.Contains(value, "/") {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+			}
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+		}
+		addr = addr.Unmap()
+		proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return proxies, nil
+}
+
+// FromRequest возвращает IP клиента запроса r по адресу соединения и X-Forwarded-For.
+// Если все адреса принадлежат доверенным прокси proxies, IP клиента — крайний левый адрес;
+// если очередной адрес в заголовке не разбирается, IP клиента — последний проверенный адрес.
+func FromRequest(r *http.Request, proxies []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	client, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+	client = client.Unmap()
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0 && isTrustedProxy(client, proxies); i-- {
+		hop, ok := parseHop(hops[i])
+		if !ok {
+			break
+		}
+		client = hop
+	}
+	return client.String()
+}
+
+// parseHop разбирает адрес из X-Forwarded-For; адрес может быть указан с портом.
+func parseHop(value string) (netip.Addr, bool) {
+	value = strings.TrimSpace(value)
+	if addr, err := netip.ParseAddr(value); err == nil {
+		return addr.Unmap(), true
+	}
+	if addrPort, err := netip.ParseAddrPort(value); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+// isTrustedProxy сообщает, принадлежит ли адрес одной из подсетей доверенных прокси.
+func isTrustedProxy(addr netip.Addr, proxies []netip.Prefix) bool {
+	for _, prefix := range proxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест определения IP клиента по правилу крайнего правого недоверенного адреса
+func TestFromRequest(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		want       string
+	}{
+		{name: "прямое соединение", remoteAddr: "203.0.113.7:5000", want: "203.0.113.7"},
+		{name: "через доверенный прокси", remoteAddr: "10.0.0.1:5000", xff: []string{"203.0.113.7"}, want: "203.0.113.7"},
+		{name: "доверенный прокси без заголовка", remoteAddr: "10.0.0.1:5000", want: "10.0.0.1"},
+		{name: "подмена от недоверенного источника", remoteAddr: "198.51.100.2:5000", xff: []string{"203.0.113.7"}, want: "198.51.100.2"},
+		{name: "подмена в начале заголовка", remoteAddr: "10.0.0.1:5000", xff: []string{"1.2.3.4, 203.0.113.7"}, want: "203.0.113.7"},
+		{name: "цепочка доверенных прокси", remoteAddr: "192.0.2.1:5000", xff: []string{"203.0.113.7, 10.0.0.2"}, want: "203.0.113.7"},
+		{name: "несколько заголовков", remoteAddr: "10.0.0.1:5000", xff: []string{"203.0.113.7", "10.0.0.2"}, want: "203.0.113.7"},
+		{name: "все адреса доверенные", remoteAddr: "10.0.0.1:5000", xff: []string{"10.0.0.3, 10.0.0.2"}, want: "10.0.0.3"},
+		{name: "неверный адрес в заголовке", remoteAddr: "10.0.0.1:5000", xff: []string{"203.0.113.7, garbage, 10.0.0.2"}, want: "10.0.0.2"},
+		{name: "адрес с портом", remoteAddr: "10.0.0.1:5000", xff: []string{"203.0.113.7:4321"}, want: "203.0.113.7"},
+		{name: "IPv6", remoteAddr: "[2001:db8::1]:5000", want: "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.xff {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+
+			assert.Equal(t, tt.want, FromRequest(req, proxies))
+		})
+	}
+}
+
+// Тест разбора адресов и подсетей доверенных прокси
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", " 192.0.2.1 ", "2001:db8::/32"})
+	require.NoError(t, err)
+	require.Len(t, proxies, 3)
+	assert.Equal(t, "192.0.2.1/32", proxies[1].String())
+
+	for _, value := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0"} {
+		_, err := ParseTrustedProxies([]string{value})
+		assert.Error(t, err, value)
+	}
+}
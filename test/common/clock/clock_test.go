@@ -0,0 +1,23 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFake проверяет, что время управляемых часов меняется только через Advance и Set.
+
+func TestFake(t *testing.T) {
+	start := time.Date(2025, 3, 22, 12, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+
+	assert.Equal(t, start, c.Now())
+	c.Advance(24 * time.Hour)
+	assert.Equal(t, start.Add(24*time.Hour), c.Now())
+	assert.Equal(t, 24*time.Hour, c.Since(start))
+
+	c.Set(start)
+	assert.Equal(t, time.Duration(0), c.Since(start))
+}
@@ -0,0 +1,50 @@
+// Package diagnostics предоставляет отладочные HTTP-эндпоинты: профили net/http/pprof
+// и переменные expvar с показателями среды выполнения.
+//
+// Эндпоинты раскрывают внутреннее состояние процесса, поэтому сервер диагностики
+// запускается на отдельном порту и только при ENABLE_PPROF=true; публиковать этот порт
+// наружу не следует.
+package diagnostics
+
+import (
+	"database/sql"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+)
+
+// NewHandler возвращает обработчик эндпоинтов /debug/pprof/ и /debug/vars.
+func NewHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	return mux
+}
+
+// PublishRuntimeStats публикует в expvar число горутин и, если db не nil, состояние пула
+// соединений с базой данных. Рост этих показателей позволяет заметить утечку горутин или
+// соединений до того, как понадобится профилирование. Функция вызывается один раз при запуске.
+func PublishRuntimeStats(db *sql.DB) {
+	expvar.Publish("goroutines", expvar.Func(func() any {
+		return runtime.NumGoroutine()
+	}))
+	if db == nil {
+		return
+	}
+	expvar.Publish("db_pool", expvar.Func(func() any {
+		stats := db.Stats()
+		return map[string]any{
+			"max_open":      stats.MaxOpenConnections,
+			"open":          stats.OpenConnections,
+			"in_use":        stats.InUse,
+			"idle":          stats.Idle,
+			"wait_count":    stats.WaitCount,
+			"wait_duration": stats.WaitDuration.String(),
+		}
+	}))
+}
@@ -0,0 +1,44 @@
+package diagnostics
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+// Тест эндпоинтов диагностики: индекс pprof и переменные expvar с показателями среды выполнения
+func TestHandler(t *testing.T) {
+	// Пул соединений создается без подключения к базе данных
+	db := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN("postgres://postgres@localhost:1/test?sslmode=disable")))
+	defer db.Close()
+	PublishRuntimeStats(db)
+	handler := NewHandler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "goroutine")
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/pprof/goroutine?debug=1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var vars struct {
+		Goroutines int            `json:"goroutines"`
+		DBPool     map[string]any `json:"db_pool"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &vars))
+	assert.Positive(t, vars.Goroutines)
+	assert.EqualValues(t, 0, vars.DBPool["open"])
+	assert.Contains(t, vars.DBPool, "in_use")
+}
@@ -0,0 +1,106 @@
+// Package env читает параметры сервисов из переменных окружения: строки, числа,
+// длительности, списки через запятую и секреты, которые можно передать файлом.
+//
+// Функции пакета читают окружение процесса. Сервис, у которого часть параметров
+// задается иначе (например, файлом конфигурации), создает Source со своей функцией поиска.
+package env
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Source возвращает значение параметра key или пустую строку, если параметр не задан.
+type Source func(key string) string
+
+// OS — переменные окружения процесса.
+var OS Source = os.Getenv
+
+// Get возвращает значение параметра key или defaultValue, если параметр не задан.
+func (s Source) Get(key, defaultValue string) string {
+	if value := s(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// Int возвращает целое число из параметра key. Если параметр не задан или не является
+// числом, возвращается defaultValue; некорректное значение записывается в журнал.
+func (s Source) Int(key string, defaultValue int) int {
+	value := s(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid integer in %s, using default %d: %v", key, defaultValue, err)
+		return defaultValue
+	}
+	return parsed
+}
+
+// Duration возвращает длительность из параметра key в формате time.ParseDuration (например, "30s").
+// Если параметр не задан или некорректен, возвращается defaultValue; некорректное значение
+// записывается в журнал.
+func (s Source) Duration(key string, defaultValue time.Duration) time.Duration {
+	value := s(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration in %s, using default %s: %v", key, defaultValue, err)
+		return defaultValue
+	}
+	return parsed
+}
+
+// List разбивает значение параметра key (или defaultValue, если параметр не задан)
+// по запятым, пропуская пустые элементы.
+func (s Source) List(key, defaultValue string) []string {
+	var items []string
+	for _, item := range strings.Split(s.Get(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Secret возвращает секрет из параметра key или, если он не задан, из файла, путь к которому
+// указан в параметре key_FILE (например, Docker secret). Если секрет не задан, возвращается
+// пустая строка; ошибка чтения файла завершает работу.
+func (s Source) Secret(key string) string {
+	if value := s(key); value != "" {
+		return value
+	}
+	path := s(key + "_FILE")
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("failed to read %s_FILE: %v", key, err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Get возвращает переменную окружения key или defaultValue, если она не задана.
+func Get(key, defaultValue string) string { return OS.Get(key, defaultValue) }
+
+// Int возвращает целое число из переменной окружения key (см. Source.Int).
+func Int(key string, defaultValue int) int { return OS.Int(key, defaultValue) }
+
+// Duration возвращает длительность из переменной окружения key (см. Source.Duration).
+func Duration(key string, defaultValue time.Duration) time.Duration {
+	return OS.Duration(key, defaultValue)
+}
+
+// List возвращает список из переменной окружения key (см. Source.List).
+func List(key, defaultValue string) []string { return OS.List(key, defaultValue) }
+
+// Secret возвращает секрет из переменной окружения key или файла key_FILE (см. Source.Secret).
+func Secret(key string) string { return OS.Secret(key) }
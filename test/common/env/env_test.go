@@ -0,0 +1,57 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест чтения параметров: незаданные и некорректные значения заменяются значениями по умолчанию
+func TestSource(t *testing.T) {
+	values := map[string]string{
+		"NAME":     "call-service",
+		"WORKERS":  "8",
+		"BAD_INT":  "eight",
+		"TIMEOUT":  "1m30s",
+		"BAD_TIME": "90",
+		"PATHS":    " /healthz, ,/metrics,",
+	}
+	src := Source(func(key string) string { return values[key] })
+
+	assert.Equal(t, "call-service", src.Get("NAME", "default"))
+	assert.Equal(t, "default", src.Get("MISSING", "default"))
+	assert.Equal(t, 8, src.Int("WORKERS", 1))
+	assert.Equal(t, 1, src.Int("BAD_INT", 1))
+	assert.Equal(t, 1, src.Int("MISSING", 1))
+	assert.Equal(t, 90*time.Second, src.Duration("TIMEOUT", time.Second))
+	assert.Equal(t, time.Second, src.Duration("BAD_TIME", time.Second))
+	assert.Equal(t, []string{"/healthz", "/metrics"}, src.List("PATHS", ""))
+	assert.Equal(t, []string{"a", "b"}, src.List("MISSING", "a,b"))
+	assert.Nil(t, src.List("MISSING", ""))
+}
+
+// Тест секрета: значение параметра важнее файла key_FILE, пробелы вокруг содержимого файла удаляются
+func TestSource_Secret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("from-file\n"), 0o600))
+	values := map[string]string{"TOKEN_FILE": path}
+	src := Source(func(key string) string { return values[key] })
+
+	assert.Equal(t, "from-file", src.Secret("TOKEN"))
+	values["TOKEN"] = "from-env"
+	assert.Equal(t, "from-env", src.Secret("TOKEN"))
+	assert.Empty(t, src.Secret("MISSING"))
+}
+
+// Тест функций пакета: они читают окружение процесса
+func TestOS(t *testing.T) {
+	t.Setenv("ENV_TEST_PORT", "8081")
+
+	assert.Equal(t, "8081", Get("ENV_TEST_PORT", "8080"))
+	assert.Equal(t, 8081, Int("ENV_TEST_PORT", 8080))
+	assert.Equal(t, []string{"8081"}, List("ENV_TEST_PORT", ""))
+}
@@ -0,0 +1,330 @@
+// Package querybuilder переводит описание отбора списка (FilterSpec) в условия, сортировку
+// и пагинацию запроса bun. Поля фильтра сопоставляются с колонками таблицы по реестру полей
+// модели (Fields): в запрос подставляются только колонки из реестра и только как
+// идентификаторы (bun.Ident), а значения — как параметры запроса. Имя поля из фильтра
+// в текст запроса не попадает никогда, поэтому через поле сортировки или условия нельзя
+// изменить запрос.
+package querybuilder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+// Op — оператор условия.
+type Op string
+
+// Операторы условий
+const (
+	Eq  Op = "eq"
+	Ne  Op = "ne"
+	Lt  Op = "lt"
+	Lte Op = "lte"
+	Gt  Op = "gt"
+	Gte Op = "gte"
+	// In и NotIn сравнивают поле со значениями непустого среза; nil и коллекции среди
+	// значений не допускаются.
+	In    Op = "in"
+	NotIn Op = "not_in"
+	// Prefix отбирает строки, начинающиеся со значения-строки (с учетом регистра); символы
+	// шаблона LIKE в значении сравниваются буквально.
+	Prefix Op = "prefix"
+	// IsNull со значением true отбирает строки без значения поля, с false — со значением.
+	IsNull Op = "is_null"
+)
+
+// comparisons — операторы сравнения с одним значением и их запись в SQL.
+var comparisons = map[Op]string{Eq: "=", Ne: "<>", Lt: "<", Lte: "<=", Gt: ">", Gte: ">="}
+
+// Наборы операторов для полей реестра
+var (
+	// Equality — сравнение на равенство и вхождение в список.
+	Equality = []Op{Eq, Ne, In, NotIn}
+	// Ordered — Equality и сравнение на больше и меньше.
+	Ordered = []Op{Eq, Ne, Lt, Lte, Gt, Gte, In, NotIn}
+)
+
+var (
+	// ErrInvalidFilter — общая ошибка некорректного фильтра; ее оборачивает каждая *FilterError.
+	ErrInvalidFilter = errors.New("invalid filter")
+	// ErrUnknownField возвращается для поля, которого нет в реестре модели.
+	ErrUnknownField = errors.New("unknown field")
+	// ErrUnsupportedOp возвращается для оператора, недопустимого для поля.
+	ErrUnsupportedOp = errors.New("unsupported operator")
+	// ErrNotSortable возвращается для сортировки по полю, по которому она не разрешена.
+	ErrNotSortable = errors.New("field is not sortable")
+	// ErrInvalidValue возвращается для значения, не подходящего оператору, и для
+	// отрицательных Limit и Offset.
+	ErrInvalidValue = errors.New("invalid value")
+)
+
+// FilterError — ошибка фильтра в поле Field. Err — ErrUnknownField, ErrUnsupportedOp,
+// ErrNotSortable или ErrInvalidValue; errors.Is также сопоставляет ее с ErrInvalidFilter.
+type FilterError struct {
+	Field string
+	Op    Op
+	Err   error
+}
+
+func (e *FilterError) Error() string {
+	if e.Op != "" {
+		return fmt.Sprintf("filter field %q, operator %q: %v", e.Field, e.Op, e.Err)
+	}
+	return fmt.Sprintf("filter field %q: %v", e.Field, e.Err)
+}
+
+func (e *FilterError) Unwrap() error {
+	return e.Err
+}
+
+func (e *FilterError) Is(target error) bool {
+	return target == ErrInvalidFilter
+}
+
+// Condition — условие на поле Field с оператором Op и значением Value. Условие, созданное
+// AnyOf, объединяет вложенные условия Any через OR; Field, Op и Value у него не заданы.
+type Condition struct {
+	Field string
+	Op    Op
+	Value any
+	Any   []Condition
+}
+
+// Where возвращает условие field op value.
+func Where(field string, op Op, value any) Condition {
+	return Condition{Field: field, Op: op, Value: value}
+}
+
+// AnyOf возвращает условие, которое выполняется, если выполняется хотя бы одно из conditions.
+func AnyOf(conditions ...Condition) Condition {
+	return Condition{Any: conditions}
+}
+
+// Sort — сортировка по полю Field.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// FilterSpec — отбор, сортировка и пагинация списка. Условия объединяются через AND,
+// сортировки применяются по порядку; нулевые Limit и Offset не ограничивают список.
+type FilterSpec struct {
+	Conditions []Condition
+	Sort       []Sort
+	Limit      int
+	Offset     int
+}
+
+// Field — поле реестра: колонка таблицы, допустимые операторы и возможность сортировки.
+type Field struct {
+	Column   string
+	Ops      []Op
+	Sortable bool
+}
+
+// identifier — допустимое имя колонки реестра.
+var identifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// Fields — реестр полей модели, по которым разрешены отбор и сортировка.
+type Fields struct {
+	fields map[string]Field
+}
+
+// NewFields создает реестр полей. Реестр объявляется в коде, поэтому неверное имя колонки
+// или неизвестный оператор — ошибка программы и приводят к панике.
+func NewFields(fields map[string]Field) *Fields {
+	for name, field := range fields {
+		if !identifier.MatchString(field.Column) {
+			panic(fmt.Sprintf("querybuilder: invalid column %q for field %q", field.Column, name))
+		}
+		for _, op := range field.Ops {
+			if !knownOp(op) {
+				panic(fmt.Sprintf("querybuilder: unknown operator %q for field %q", op, name))
+			}
+		}
+	}
+	return &Fields{fields: fields}
+}
+
+func knownOp(op Op) bool {
+	_, ok := comparisons[op]
+	return ok || op == In || op == NotIn || op == Prefix || op == IsNull
+}
+
+// Validate проверяет фильтр, не строя запрос. Возвращает первую найденную *FilterError.
+func (f *Fields) Validate(spec FilterSpec) error {
+	_, err := f.compile(spec)
+	return err
+}
+
+// Apply добавляет к запросу q условия, сортировку и пагинацию spec. Фильтр проверяется
+// целиком до изменения запроса: при ошибке запрос не изменяется и возвращается *FilterError.
+func (f *Fields) Apply(q *bun.SelectQuery, spec FilterSpec) (*bun.SelectQuery, error) {
+	clauses, err := f.compile(spec)
+	if err != nil {
+		return q, err
+	}
+	for _, where := range clauses.where {
+		q = q.Where(where.query, where.args...)
+	}
+	for _, order := range clauses.order {
+		q = q.OrderExpr(order.query, order.args...)
+	}
+	if spec.Limit > 0 {
+		q = q.Limit(spec.Limit)
+	}
+	if spec.Offset > 0 {
+		q = q.Offset(spec.Offset)
+	}
+	return q, nil
+}
+
+// clause — фрагмент запроса с параметрами. Идентификаторы передаются параметрами bun.Ident.
+type clause struct {
+	query string
+	args  []any
+}
+
+type clauses struct {
+	where, order []clause
+}
+
+func (f *Fields) compile(spec FilterSpec) (clauses, error) {
+	var result clauses
+	for _, condition := range spec.Conditions {
+		where, err := f.condition(condition)
+		if err != nil {
+			return clauses{}, err
+		}
+		result.where = append(result.where, where)
+	}
+	for _, sort := range spec.Sort {
+		field, ok := f.fields[sort.Field]
+		if !ok {
+			return clauses{}, &FilterError{Field: sort.Field, Err: ErrUnknownField}
+		}
+		if !field.Sortable {
+			return clauses{}, &FilterError{Field: sort.Field, Err: ErrNotSortable}
+		}
+		direction := "? ASC"
+		if sort.Desc {
+			direction = "? DESC"
+		}
+		result.order = append(result.order, clause{query: direction, args: []any{bun.Ident(field.Column)}})
+	}
+	if spec.Limit < 0 {
+		return clauses{}, &FilterError{Field: "limit", Err: ErrInvalidValue}
+	}
+	if spec.Offset < 0 {
+		return clauses{}, &FilterError{Field: "offset", Err: ErrInvalidValue}
+	}
+	return result, nil
+}
+
+// condition переводит условие в фрагмент WHERE; условия AnyOf объединяются в скобках через OR.
+func (f *Fields) condition(c Condition) (clause, error) {
+	if c.Any != nil || (c.Field == "" && c.Op == "") {
+		if len(c.Any) == 0 {
+			return clause{}, &FilterError{Err: ErrInvalidValue}
+		}
+		parts := make([]string, len(c.Any))
+		var args []any
+		for i, nested := range c.Any {
+			where, err := f.condition(nested)
+			if err != nil {
+				return clause{}, err
+			}
+			parts[i] = where.query
+			args = append(args, where.args...)
+		}
+		return clause{query: "(" + strings.Join(parts, " OR ") + ")", args: args}, nil
+	}
+
+	field, ok := f.fields[c.Field]
+	if !ok {
+		return clause{}, &FilterError{Field: c.Field, Err: ErrUnknownField}
+	}
+	if !allowed(field.Ops, c.Op) {
+		return clause{}, &FilterError{Field: c.Field, Op: c.Op, Err: ErrUnsupportedOp}
+	}
+	invalid := &FilterError{Field: c.Field, Op: c.Op, Err: ErrInvalidValue}
+	column := bun.Ident(field.Column)
+
+	switch c.Op {
+	case In, NotIn:
+		v := reflect.ValueOf(c.Value)
+		if !v.IsValid() || v.Kind() != reflect.Slice || v.Len() == 0 {
+			return clause{}, invalid
+		}
+		for i := 0; i < v.Len(); i++ {
+			if !scalar(v.Index(i).Interface()) {
+				return clause{}, invalid
+			}
+		}
+		if c.Op == In {
+			return clause{query: "? IN (?)", args: []any{column, bun.In(c.Value)}}, nil
+		}
+		return clause{query: "? NOT IN (?)", args: []any{column, bun.In(c.Value)}}, nil
+	case Prefix:
+		prefix, ok := c.Value.(string)
+		if !ok {
+			return clause{}, invalid
+		}
+		return clause{query: `? LIKE ? ESCAPE '\'`, args: []any{column, likePrefix(prefix)}}, nil
+	case IsNull:
+		isNull, ok := c.Value.(bool)
+		if !ok {
+			return clause{}, invalid
+		}
+		if isNull {
+			return clause{query: "? IS NULL", args: []any{column}}, nil
+		}
+		return clause{query: "? IS NOT NULL", args: []any{column}}, nil
+	}
+	if !scalar(c.Value) {
+		return clause{}, invalid
+	}
+	return clause{query: "? " + comparisons[c.Op] + " ?", args: []any{column, c.Value}}, nil
+}
+
+func allowed(ops []Op, op Op) bool {
+	for _, allowed := range ops {
+		if allowed == op {
+			return true
+		}
+	}
+	return false
+}
+
+// scalar сообщает, подходит ли значение для сравнения: не nil и не коллекция. Срезы байтов
+// допускаются как одно значение.
+func scalar(value any) bool {
+	v := reflect.ValueOf(value)
+	switch {
+	case !v.IsValid():
+		return false
+	case v.Kind() == reflect.Pointer:
+		return !v.IsNil() && scalar(v.Elem().Interface())
+	case v.Kind() == reflect.Slice:
+		return v.Type().Elem().Kind() == reflect.Uint8
+	}
+	switch v.Kind() {
+	case reflect.Array:
+		// Массивы фиксированной длины — например, uuid.UUID — сравниваются как одно значение
+		return v.Type().Elem().Kind() == reflect.Uint8
+	case reflect.Map, reflect.Chan, reflect.Func, reflect.Interface, reflect.UnsafePointer:
+		return false
+	}
+	return true
+}
+
+// likePrefix возвращает шаблон LIKE для строк, начинающихся с prefix: символы %, _ и \
+// экранируются и сравниваются буквально.
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
@@ -0,0 +1,200 @@
+package querybuilder
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+type item struct {
+	bun.BaseModel `bun:"table:items,alias:item"`
+
+	ID        int64     `bun:"id,pk"`
+	Name      string    `bun:"name"`
+	Status    string    `bun:"status"`
+	CreatedAt time.Time `bun:"created_at"`
+	DeletedAt time.Time `bun:"deleted_at"`
+}
+
+var itemFields = NewFields(map[string]Field{
+	"name":       {Column: "name", Ops: []Op{Eq, Prefix}, Sortable: true},
+	"status":     {Column: "status", Ops: Equality},
+	"created_at": {Column: "created_at", Ops: Ordered, Sortable: true},
+	"deleted":    {Column: "deleted_at", Ops: []Op{IsNull}},
+})
+
+// newDB возвращает bun.DB для построения текста запросов; соединение с базой не открывается.
+func newDB(t testing.TB) *bun.DB {
+	sqldb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN("postgres://user@localhost:1/test?sslmode=disable")))
+	db := bun.NewDB(sqldb, pgdialect.New())
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// query возвращает текст запроса списка item с фильтром spec.
+func query(t testing.TB, db *bun.DB, spec FilterSpec) (string, error) {
+	q, err := itemFields.Apply(db.NewSelect().Model((*item)(nil)), spec)
+	return q.String(), err
+}
+
+// Тест перевода условий, сортировки и пагинации в запрос: колонки подставляются
+// идентификаторами, значения — литералами
+func TestFields_Apply(t *testing.T) {
+	db := newDB(t)
+	created := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	got, err := query(t, db, FilterSpec{
+		Conditions: []Condition{
+			AnyOf(Where("name", Eq, "Ann"), Where("name", Prefix, `50%_off\`)),
+			Where("status", NotIn, []string{"closed", "cancelled"}),
+			Where("created_at", Gte, created),
+			Where("deleted", IsNull, true),
+		},
+		Sort:   []Sort{{Field: "name", Desc: true}, {Field: "created_at"}},
+		Limit:  10,
+		Offset: 20,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, got, `WHERE (("name" = 'Ann' OR "name" LIKE '50\%\_off\\%' ESCAPE '\')) `+
+		`AND ("status" NOT IN ('closed', 'cancelled')) `+
+		`AND ("created_at" >= '2026-10-16 12:00:00+00:00') `+
+		`AND ("deleted_at" IS NULL) `+
+		`ORDER BY "name" DESC, "created_at" ASC LIMIT 10 OFFSET 20`)
+
+	got, err = query(t, db, FilterSpec{Conditions: []Condition{Where("deleted", IsNull, false)}})
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(got, `WHERE ("deleted_at" IS NOT NULL)`), got)
+}
+
+// Тест ошибок фильтра: каждая ошибка — *FilterError с полем и причиной, сопоставляемая
+// с ErrInvalidFilter, а запрос при ошибке не изменяется
+func TestFields_Apply_Errors(t *testing.T) {
+	db := newDB(t)
+	tests := []struct {
+		name  string
+		spec  FilterSpec
+		field string
+		want  error
+	}{
+		{"неизвестное поле", FilterSpec{Conditions: []Condition{Where("password", Eq, "x")}}, "password", ErrUnknownField},
+		{"колонка вместо поля", FilterSpec{Conditions: []Condition{Where("deleted_at", IsNull, true)}}, "deleted_at", ErrUnknownField},
+		{"недопустимый оператор", FilterSpec{Conditions: []Condition{Where("status", Prefix, "op")}}, "status", ErrUnsupportedOp},
+		{"неизвестный оператор", FilterSpec{Conditions: []Condition{Where("name", "; DROP", "x")}}, "name", ErrUnsupportedOp},
+		{"пустой список", FilterSpec{Conditions: []Condition{Where("status", In, []string{})}}, "status", ErrInvalidValue},
+		{"список вместо значения", FilterSpec{Conditions: []Condition{Where("status", Eq, []string{"open"})}}, "status", ErrInvalidValue},
+		{"nil вместо значения", FilterSpec{Conditions: []Condition{Where("name", Eq, nil)}}, "name", ErrInvalidValue},
+		{"префикс не строка", FilterSpec{Conditions: []Condition{Where("name", Prefix, 1)}}, "name", ErrInvalidValue},
+		{"пустая группа", FilterSpec{Conditions: []Condition{AnyOf()}}, "", ErrInvalidValue},
+		{"ошибка в группе", FilterSpec{Conditions: []Condition{AnyOf(Where("name", Eq, "a"), Where("id", Eq, 1))}}, "id", ErrUnknownField},
+		{"сортировка по неизвестному полю", FilterSpec{Sort: []Sort{{Field: `name" DESC; --`}}}, `name" DESC; --`, ErrUnknownField},
+		{"сортировка не разрешена", FilterSpec{Sort: []Sort{{Field: "status"}}}, "status", ErrNotSortable},
+		{"отрицательный limit", FilterSpec{Limit: -1}, "limit", ErrInvalidValue},
+		{"отрицательный offset", FilterSpec{Offset: -1}, "offset", ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := query(t, db, tt.spec)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, ErrInvalidFilter)
+			assert.ErrorIs(t, err, tt.want)
+			var filterErr *FilterError
+			require.True(t, errors.As(err, &filterErr))
+			assert.Equal(t, tt.field, filterErr.Field)
+			assert.Equal(t, `SELECT "item"."id", "item"."name", "item"."status", "item"."created_at", "item"."deleted_at" FROM "items" AS "item"`, got)
+			assert.Equal(t, err, itemFields.Validate(tt.spec))
+		})
+	}
+}
+
+// Тест проверки реестра: неверное имя колонки или неизвестный оператор — ошибка программы
+func TestNewFields_Panics(t *testing.T) {
+	assert.Panics(t, func() { NewFields(map[string]Field{"name": {Column: `name"; --`}}) })
+	assert.Panics(t, func() { NewFields(map[string]Field{"name": {Column: "Name"}}) })
+	assert.Panics(t, func() { NewFields(map[string]Field{"name": {Column: "name", Ops: []Op{"like"}}}) })
+}
+
+var (
+	// literal — строковый литерал PostgreSQL; кавычка внутри литерала удваивается.
+	literal = regexp.MustCompile(`'(?:[^']|'')*'`)
+	// quoted — идентификатор в двойных кавычках.
+	quoted = regexp.MustCompile(`"([^"]*)"`)
+	// word — слово вне литералов и идентификаторов в кавычках.
+	word = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+)
+
+// keywords — слова SQL, из которых состоит запрос с фильтром.
+var keywords = map[string]bool{
+	"SELECT": true, "FROM": true, "AS": true, "WHERE": true, "AND": true, "OR": true,
+	"IN": true, "NOT": true, "LIKE": true, "ESCAPE": true, "IS": true, "NULL": true,
+	"ORDER": true, "BY": true, "ASC": true, "DESC": true, "LIMIT": true, "OFFSET": true,
+	"TRUE": true, "FALSE": true,
+}
+
+// identifiers — идентификаторы, которые могут встретиться в запросе: таблица, ее псевдоним и колонки.
+var identifiers = map[string]bool{
+	"items": true, "item": true, "id": true, "name": true, "status": true, "created_at": true, "deleted_at": true,
+}
+
+// Fuzz-тест: произвольный фильтр не приводит к панике, ошибка всегда — *FilterError,
+// а в тексте запроса вне строковых литералов встречаются только слова SQL
+// и идентификаторы таблицы — поле, оператор и значение из фильтра в запрос не подставляются
+func FuzzFields_Apply(f *testing.F) {
+	f.Add("name", "prefix", "Ann", uint8(0), "name", true, 10, 0)
+	f.Add("status", "not_in", "closed", uint8(3), "created_at", false, 0, 5)
+	f.Add("deleted", "is_null", "", uint8(2), "", false, 0, 0)
+	f.Add(`name"; DROP TABLE items; --`, "eq", "x", uint8(0), `name" DESC, (SELECT 1) --`, true, 1, 1)
+	f.Add("created_at", ">=", "2026-10-16", uint8(1), "status", false, -1, 0)
+	f.Add("name", "eq", `' OR 1=1 --`, uint8(0), "name", false, 0, 0)
+
+	db := newDB(f)
+	f.Fuzz(func(t *testing.T, field, op, value string, kind uint8, sortField string, desc bool, limit, offset int) {
+		var v any
+		switch kind % 6 {
+		case 0:
+			v = value
+		case 1:
+			v = len(value)
+		case 2:
+			v = len(value)%2 == 0
+		case 3:
+			v = strings.Split(value, ",")
+		case 4:
+			v = nil
+		case 5:
+			v = []any{value, nil}
+		}
+		conditions := []Condition{Where(field, Op(op), v)}
+		if desc {
+			conditions = []Condition{AnyOf(Where("name", Eq, value), conditions[0])}
+		}
+		spec := FilterSpec{Conditions: conditions, Limit: limit, Offset: offset}
+		if sortField != "" {
+			spec.Sort = []Sort{{Field: sortField, Desc: desc}}
+		}
+
+		got, err := query(t, db, spec)
+		if err != nil {
+			var filterErr *FilterError
+			require.True(t, errors.As(err, &filterErr), err)
+			require.ErrorIs(t, err, ErrInvalidFilter)
+			return
+		}
+		rest := literal.ReplaceAllString(got, "''")
+		for _, m := range quoted.FindAllStringSubmatch(rest, -1) {
+			require.True(t, identifiers[m[1]], "identifier %q in %s", m[1], got)
+		}
+		rest = quoted.ReplaceAllString(rest, "")
+		for _, w := range word.FindAllString(rest, -1) {
+			require.True(t, keywords[w], "word %q in %s", w, got)
+		}
+	})
+}
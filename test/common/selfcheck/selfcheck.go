@@ -0,0 +1,182 @@
+// Package selfcheck выполняет проверки готовности сервиса к запуску (флаг --check):
+// конфигурацию, соединения с зависимостями и актуальность миграций базы данных.
+//
+// Каждая проверка ограничена собственным временем, поэтому зависшая зависимость
+// не задерживает остальные проверки. Отчет выводится в читаемом виде или в JSON
+// для конвейеров развертывания.
+package selfcheck
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout — время, отведенное одной проверке, если у нее не задано собственное.
+const DefaultTimeout = 5 * time.Second
+
+// Probe — одна проверка. Run должна завершаться при отмене ctx; если она этого не делает,
+// проверка все равно считается неудачной по истечении Timeout.
+type Probe struct {
+	Name string
+	// Timeout ограничивает проверку; 0 — DefaultTimeout.
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// Result — результат одной проверки.
+type Result struct {
+	Name     string        `json:"name"`
+	OK       bool          `json:"ok"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"-"`
+}
+
+// MarshalJSON записывает длительность проверки в миллисекундах.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+	return json.Marshal(struct {
+		result
+		Duration int64 `json:"duration_ms"`
+	}{result(r), r.Duration.Milliseconds()})
+}
+
+// Report — результаты всех проверок; OK означает, что все проверки пройдены.
+type Report struct {
+	OK      bool     `json:"ok"`
+	Results []Result `json:"checks"`
+}
+
+// Run выполняет проверки по очереди и возвращает отчет. Неудача одной проверки
+// не останавливает остальные.
+func Run(ctx context.Context, probes []Probe) Report {
+	report := Report{OK: true, Results: make([]Result, 0, len(probes))}
+	for _, probe := range probes {
+		result := run(ctx, probe)
+		report.OK = report.OK && result.OK
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+// run выполняет проверку в отдельной горутине, чтобы вернуть результат по истечении
+// времени, даже если проверка не реагирует на отмену контекста.
+func run(ctx context.Context, probe Probe) Result {
+	timeout := probe.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- probe.Run(ctx) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+
+	result := Result{Name: probe.Name, OK: err == nil, Duration: time.Since(start)}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// WriteText выводит отчет в читаемом виде: строку на каждую проверку и итог.
+func (r Report) WriteText(w io.Writer) error {
+	for _, result := range r.Results {
+		status := "OK"
+		if !result.OK {
+			status = "FAIL"
+		}
+		line := fmt.Sprintf("%-4s  %-16s  %s", status, result.Name, result.Duration.Round(time.Millisecond))
+		if result.Error != "" {
+			line += "  " + result.Error
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	summary := "all checks passed"
+	if !r.OK {
+		summary = "check failed"
+	}
+	_, err := fmt.Fprintln(w, summary)
+	return err
+}
+
+// WriteJSON выводит отчет в JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// LatestMigration возвращает наибольшую версию миграции в каталоге dir — число в начале
+// имени файла *.up.sql, как у golang-migrate.
+func LatestMigration(dir string) (uint64, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return 0, err
+	}
+	if len(files) == 0 {
+		return 0, fmt.Errorf("no migrations found in %s", dir)
+	}
+	var latest uint64
+	for _, file := range files {
+		prefix, _, _ := strings.Cut(filepath.Base(file), "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid migration file name %s", filepath.Base(file))
+		}
+		latest = max(latest, version)
+	}
+	return latest, nil
+}
+
+// MigrationsUpToDate возвращает ошибку, если версия схемы в таблице schema_migrations,
+// которую ведет golang-migrate, отличается от последней миграции в каталоге dir
+// или последняя миграция применена не полностью.
+func MigrationsUpToDate(ctx context.Context, db *sql.DB, dir string) error {
+	latest, err := LatestMigration(dir)
+	if err != nil {
+		return err
+	}
+	var version uint64
+	var dirty bool
+	err = db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("migration %d is dirty", version)
+	}
+	if version != latest {
+		return fmt.Errorf("schema version %d, latest migration %d", version, latest)
+	}
+	return nil
+}
+
+// Exit выводит отчет в stdout в JSON, если jsonOutput, иначе в читаемом виде,
+// и завершает процесс с кодом 0, если все проверки пройдены, и 1 в противном случае.
+func Exit(report Report, jsonOutput bool) {
+	write := report.WriteText
+	if jsonOutput {
+		write = report.WriteJSON
+	}
+	if err := write(os.Stdout); err != nil || !report.OK {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,85 @@
+package selfcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест выполнения проверок: неудачная проверка не останавливает следующие, а проверка,
+// не реагирующая на отмену контекста, завершается неудачей по истечении своего времени
+func TestRun(t *testing.T) {
+	hang := make(chan struct{})
+	defer close(hang)
+	report := Run(context.Background(), []Probe{
+		{Name: "ok", Run: func(context.Context) error { return nil }},
+		{Name: "failing", Run: func(context.Context) error { return errors.New("connection refused") }},
+		{Name: "hanging", Timeout: 20 * time.Millisecond, Run: func(context.Context) error {
+			<-hang
+			return nil
+		}},
+		{Name: "after", Run: func(context.Context) error { return nil }},
+	})
+
+	assert.False(t, report.OK)
+	require.Len(t, report.Results, 4)
+	assert.True(t, report.Results[0].OK)
+	assert.Equal(t, Result{Name: "failing", Error: "connection refused", Duration: report.Results[1].Duration}, report.Results[1])
+	assert.False(t, report.Results[2].OK)
+	assert.Equal(t, "timed out after 20ms", report.Results[2].Error)
+	assert.True(t, report.Results[3].OK)
+
+	assert.True(t, Run(context.Background(), []Probe{{Name: "ok", Run: func(context.Context) error { return nil }}}).OK)
+}
+
+// Тест вывода отчета в читаемом виде и в JSON
+func TestReport_Write(t *testing.T) {
+	report := Report{Results: []Result{
+		{Name: "config", OK: true, Duration: 2 * time.Millisecond},
+		{Name: "database", Error: "connection refused", Duration: 1500 * time.Millisecond},
+	}}
+
+	var text bytes.Buffer
+	require.NoError(t, report.WriteText(&text))
+	assert.Equal(t, "OK    config            2ms\n"+
+		"FAIL  database          1.5s  connection refused\n"+
+		"check failed\n", text.String())
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteJSON(&out))
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, map[string]any{
+		"ok": false,
+		"checks": []any{
+			map[string]any{"name": "config", "ok": true, "duration_ms": float64(2)},
+			map[string]any{"name": "database", "ok": false, "error": "connection refused", "duration_ms": float64(1500)},
+		},
+	}, decoded)
+}
+
+// Тест определения последней миграции по именам файлов
+func TestLatestMigration(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"000002_add_users.up.sql", "000002_add_users.down.sql", "000010_add_sessions.up.sql", "000010_add_sessions.down.sql"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+	latest, err := LatestMigration(dir)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), latest)
+
+	_, err = LatestMigration(t.TempDir())
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "init.up.sql"), nil, 0o644))
+	_, err = LatestMigration(dir)
+	assert.Error(t, err)
+}
@@ -0,0 +1,30 @@
+package slo
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// serverErrors — коды gRPC, означающие ошибку сервера; остальные коды, в том числе
+// отказы из-за неверного запроса или отсутствия прав, не расходуют бюджет ошибок.
+var serverErrors = map[codes.Code]bool{
+	codes.Unknown:          true,
+	codes.DeadlineExceeded: true,
+	codes.Internal:         true,
+	codes.Unavailable:      true,
+	codes.DataLoss:         true,
+}
+
+// UnaryServerInterceptor возвращает перехватчик, учитывающий унарные вызовы методов gRPC.
+func (r *Registry) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		r.ObserveMethod(info.FullMethod, time.Since(start), err != nil && serverErrors[status.Code(err)])
+		return resp, err
+	}
+}
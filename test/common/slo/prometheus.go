@@ -0,0 +1,67 @@
+package slo
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"common/buildinfo"
+)
+
+// ContentType — тип содержимого текстового формата показателей Prometheus.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// labelEscaper экранирует значение метки в текстовом формате Prometheus.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WriteMetrics записывает состояние целей в текстовом формате Prometheus: цель
+// (slo_objective_target), счетчики хороших и плохих запросов с момента запуска
+// (slo_requests_total), скорость расходования бюджета в окнах (slo_burn_rate) и остаток
+// бюджета за период (slo_error_budget_remaining). Каждый показатель содержит постоянные метки
+// сборки version и commit (см. пакет buildinfo), чтобы изменение показателей можно было
+// сопоставить с развертыванием.
+func (r *Registry) WriteMetrics(w io.Writer) error {
+	summary := r.Summary()
+	bw := bufio.NewWriter(w)
+	labels := func(o ObjectiveSummary) string {
+		return `objective="` + labelEscaper.Replace(o.Name) + `",endpoint="` + labelEscaper.Replace(o.Endpoint) + `"`
+	}
+	header := func(name, kind, help string) {
+		bw.WriteString("# HELP " + name + " " + help + "\n# TYPE " + name + " " + kind + "\n")
+	}
+	build := `version="` + labelEscaper.Replace(buildinfo.Version) + `",commit="` + labelEscaper.Replace(buildinfo.Commit) + `"`
+	sample := func(name, labels string, value float64) {
+		bw.WriteString(name + "{" + labels + "," + build + "} " + strconv.FormatFloat(value, 'g', -1, 64) + "\n")
+	}
+
+	header("slo_objective_target", "gauge", "Target share of good requests.")
+	for _, o := range summary.Objectives {
+		sample("slo_objective_target", labels(o), o.Target)
+	}
+	header("slo_requests_total", "counter", "Requests counted by the objective since start.")
+	for _, o := range summary.Objectives {
+		sample("slo_requests_total", labels(o)+`,result="good"`, float64(o.Good))
+		sample("slo_requests_total", labels(o)+`,result="bad"`, float64(o.Bad))
+	}
+	header("slo_burn_rate", "gauge", "Error budget burn rate over the window.")
+	for _, o := range summary.Objectives {
+		for _, window := range o.Windows {
+			sample("slo_burn_rate", labels(o)+`,window="`+window.Window+`"`, window.BurnRate)
+		}
+	}
+	header("slo_error_budget_remaining", "gauge", "Remaining share of the error budget over the period.")
+	for _, o := range summary.Objectives {
+		sample("slo_error_budget_remaining", labels(o)+`,period="`+o.Budget.Period+`"`, o.Budget.Remaining)
+	}
+	return bw.Flush()
+}
+
+// MetricsHandler возвращает обработчик, отдающий показатели целей в формате Prometheus.
+func (r *Registry) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = r.WriteMetrics(w)
+	})
+}
@@ -0,0 +1,59 @@
+package slo
+
+import "time"
+
+// bucket — счетчики запросов за интервал времени epoch (номер интервала от начала эпохи Unix).
+type bucket struct {
+	epoch     int64
+	good, bad int64
+}
+
+// ring — кольцевой буфер корзин ширины width. Корзина переиспользуется, когда наступает
+// ее следующий интервал, поэтому буфер хранит запросы только за последние len(buckets)
+// интервалов. Синхронизацию обеспечивает вызывающий.
+type ring struct {
+	width   int64
+	buckets []bucket
+}
+
+func newRing(width time.Duration, size int) ring {
+	if width <= 0 {
+		width = time.Second
+	}
+	if size < 1 {
+		size = 1
+	}
+	return ring{width: int64(width), buckets: make([]bucket, size)}
+}
+
+// add учитывает запрос в момент now (в наносекундах Unix).
+func (r *ring) add(now int64, bad bool) {
+	epoch := now / r.width
+	b := &r.buckets[epoch%int64(len(r.buckets))]
+	if b.epoch != epoch {
+		*b = bucket{epoch: epoch}
+	}
+	if bad {
+		b.bad++
+	} else {
+		b.good++
+	}
+}
+
+// sum возвращает число запросов и плохих запросов за span до момента now. Окно округляется
+// до целого числа корзин, включая текущую, и не может быть больше буфера.
+func (r *ring) sum(now int64, span time.Duration) (total, bad int64) {
+	epoch := now / r.width
+	n := (int64(span) + r.width - 1) / r.width
+	if n > int64(len(r.buckets)) {
+		n = int64(len(r.buckets))
+	}
+	for i := int64(0); i < n; i++ {
+		b := r.buckets[(epoch-i)%int64(len(r.buckets))]
+		if b.epoch == epoch-i {
+			total += b.good + b.bad
+			bad += b.bad
+		}
+	}
+	return total, bad
+}
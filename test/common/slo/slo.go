@@ -0,0 +1,296 @@
+// Package slo считает выполнение целевых показателей уровня обслуживания (SLO): для каждой
+// цели — доли «хороших» запросов к одному маршруту или методу gRPC — ведутся счетчики хороших
+// и плохих запросов в скользящих окнах и по ним вычисляются скорость расходования бюджета
+// ошибок (burn rate) и остаток бюджета за период.
+//
+// Запросы учитываются кольцевыми буферами корзин фиксированной ширины, поэтому учет запроса
+// не выделяет память, а размер счетчиков не зависит от нагрузки. Метки показателей — только
+// имя цели и шаблон маршрута или имя метода, без параметров запроса.
+package slo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"common/clock"
+)
+
+// DefaultPeriod — период бюджета ошибок, если он не задан.
+const DefaultPeriod = 30 * 24 * time.Hour
+
+// DefaultWindows — окна, за которые вычисляется скорость расходования бюджета: короткое окно
+// каждой пары подтверждает, что расходование, замеченное в длинном окне, еще продолжается.
+var DefaultWindows = []time.Duration{5 * time.Minute, 30 * time.Minute, time.Hour, 6 * time.Hour}
+
+// Ширина корзин: окон скорости расходования и периода бюджета, который делится
+// на periodBuckets корзин.
+const (
+	windowBucket  = 10 * time.Second
+	periodBuckets = 720
+)
+
+// Objective — цель уровня обслуживания.
+type Objective struct {
+	// Name — имя цели в показателях и сводке.
+	Name string
+	// Endpoint — маршрут HTTP API в виде "МЕТОД /шаблон" (например, "GET /calls/:id")
+	// или короткое имя метода gRPC (например, "ValidateToken").
+	Endpoint string
+	// Target — доля хороших запросов от 0 до 1 (например, 0.995).
+	Target float64
+	// Latency — наибольшее время хорошего запроса; 0 — время не учитывается, и хороший
+	// запрос — любой запрос без ошибки сервера.
+	Latency time.Duration
+}
+
+// ParseObjectives разбирает цели из строки вида
+// "name=validate_token,endpoint=ValidateToken,target=99.5,latency=50ms;name=list_calls,endpoint=GET /calls,target=99.9".
+// Цели разделяются точкой с запятой, target задается в процентах.
+func ParseObjectives(spec string) ([]Objective, error) {
+	var objectives []Objective
+	names := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		var o Objective
+		for _, field := range strings.Split(entry, ",") {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid objective field %q: expected key=value", strings.TrimSpace(field))
+			}
+			value = strings.TrimSpace(value)
+			switch strings.TrimSpace(key) {
+			case "name":
+				o.Name = value
+			case "endpoint":
+				o.Endpoint = strings.Join(strings.Fields(value), " ")
+			case "target":
+				percent, err := strconv.ParseFloat(value, 64)
+				if err != nil || percent <= 0 || percent >= 100 {
+					return nil, fmt.Errorf("invalid objective target %q: expected percent between 0 and 100", value)
+				}
+				// Округление убирает погрешность деления: 99.9% — ровно 0.999
+				o.Target = math.Round(percent*1e7) / 1e9
+			case "latency":
+				latency, err := time.ParseDuration(value)
+				if err != nil || latency <= 0 {
+					return nil, fmt.Errorf("invalid objective latency %q", value)
+				}
+				o.Latency = latency
+			default:
+				return nil, fmt.Errorf("unknown objective field %q", strings.TrimSpace(key))
+			}
+		}
+		if o.Name == "" || o.Endpoint == "" || o.Target == 0 {
+			return nil, fmt.Errorf("objective %q: name, endpoint and target are required", strings.TrimSpace(entry))
+		}
+		if names[o.Name] {
+			return nil, fmt.Errorf("duplicate objective %q", o.Name)
+		}
+		names[o.Name] = true
+		objectives = append(objectives, o)
+	}
+	return objectives, nil
+}
+
+// Registry учитывает запросы по целям. Методы безопасны для одновременного вызова.
+type Registry struct {
+	clock   clock.Clock
+	period  time.Duration
+	windows []time.Duration
+
+	trackers []*tracker
+	// byRoute — цели маршрутов HTTP по методу и шаблону, byMethod — цели методов gRPC.
+	// Поиск по двум ключам не требует склеивания строк на каждый запрос.
+	byRoute  map[string]map[string][]*tracker
+	byMethod map[string][]*tracker
+}
+
+// New создает Registry для целей objectives с бюджетом ошибок за period (0 — DefaultPeriod);
+// nil clk означает системное время.
+func New(objectives []Objective, period time.Duration, clk clock.Clock) (*Registry, error) {
+	if len(objectives) == 0 {
+		return nil, errors.New("slo: no objectives")
+	}
+	if period <= 0 {
+		period = DefaultPeriod
+	}
+	if clk == nil {
+		clk = clock.Real
+	}
+	r := &Registry{
+		clock:    clk,
+		period:   period,
+		windows:  DefaultWindows,
+		byRoute:  make(map[string]map[string][]*tracker),
+		byMethod: make(map[string][]*tracker),
+	}
+	longest := r.windows[len(r.windows)-1]
+	for _, o := range objectives {
+		t := &tracker{
+			objective: o,
+			windows:   newRing(windowBucket, int(longest/windowBucket)),
+			budget:    newRing(period/periodBuckets, periodBuckets),
+		}
+		r.trackers = append(r.trackers, t)
+		if method, route, ok := strings.Cut(o.Endpoint, " "); ok {
+			if r.byRoute[method] == nil {
+				r.byRoute[method] = make(map[string][]*tracker)
+			}
+			r.byRoute[method][route] = append(r.byRoute[method][route], t)
+		} else {
+			r.byMethod[o.Endpoint] = append(r.byMethod[o.Endpoint], t)
+		}
+	}
+	return r, nil
+}
+
+// ObserveRoute учитывает запрос HTTP method к маршруту с шаблоном route, выполненный
+// за duration; failed — запрос завершился ошибкой сервера.
+func (r *Registry) ObserveRoute(method, route string, duration time.Duration, failed bool) {
+	r.observe(r.byRoute[method][route], duration, failed)
+}
+
+// ObserveMethod учитывает вызов метода gRPC fullMethod (например,
+// "/auth.AuthService/ValidateToken"), выполненный за duration.
+func (r *Registry) ObserveMethod(fullMethod string, duration time.Duration, failed bool) {
+	r.observe(r.byMethod[path.Base(fullMethod)], duration, failed)
+}
+
+func (r *Registry) observe(trackers []*tracker, duration time.Duration, failed bool) {
+	if len(trackers) == 0 {
+		return
+	}
+	now := r.clock.Now().UnixNano()
+	for _, t := range trackers {
+		bad := failed || (t.objective.Latency > 0 && duration > t.objective.Latency)
+		t.add(now, bad)
+	}
+}
+
+// tracker — счетчики одной цели: корзины окон скорости расходования, корзины периода
+// бюджета и счетчики с момента запуска.
+type tracker struct {
+	objective Objective
+
+	mu        sync.Mutex
+	windows   ring
+	budget    ring
+	good, bad int64
+}
+
+func (t *tracker) add(now int64, bad bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.windows.add(now, bad)
+	t.budget.add(now, bad)
+	if bad {
+		t.bad++
+	} else {
+		t.good++
+	}
+}
+
+// Summary — состояние целей.
+type Summary struct {
+	Objectives []ObjectiveSummary `json:"objectives"`
+}
+
+// ObjectiveSummary — состояние цели: доля ошибок и скорость расходования бюджета в каждом
+// окне и остаток бюджета за период.
+type ObjectiveSummary struct {
+	Name     string          `json:"name"`
+	Endpoint string          `json:"endpoint"`
+	Target   float64         `json:"target"`
+	Latency  string          `json:"latency,omitempty"`
+	Windows  []WindowSummary `json:"windows"`
+	Budget   BudgetSummary   `json:"budget"`
+	// Good и Bad — число хороших и плохих запросов с момента запуска.
+	Good int64 `json:"good"`
+	Bad  int64 `json:"bad"`
+}
+
+// WindowSummary — запросы цели за окно. BurnRate — во сколько раз доля ошибок превышает
+// допустимую (1 - Target): при 1 бюджет расходуется ровно за период.
+type WindowSummary struct {
+	Window     string  `json:"window"`
+	Total      int64   `json:"total"`
+	Bad        int64   `json:"bad"`
+	ErrorRatio float64 `json:"error_ratio"`
+	BurnRate   float64 `json:"burn_rate"`
+}
+
+// BudgetSummary — бюджет ошибок за период. Remaining — доля неизрасходованного бюджета:
+// 1 — ошибок не было, 0 и меньше — бюджет исчерпан. Учитываются запросы с момента запуска,
+// не раньше начала периода.
+type BudgetSummary struct {
+	Period    string  `json:"period"`
+	Total     int64   `json:"total"`
+	Bad       int64   `json:"bad"`
+	Remaining float64 `json:"remaining"`
+}
+
+// Summary возвращает состояние всех целей в порядке их объявления.
+func (r *Registry) Summary() Summary {
+	now := r.clock.Now().UnixNano()
+	summary := Summary{Objectives: make([]ObjectiveSummary, 0, len(r.trackers))}
+	for _, t := range r.trackers {
+		summary.Objectives = append(summary.Objectives, r.summarize(t, now))
+	}
+	return summary
+}
+
+func (r *Registry) summarize(t *tracker, now int64) ObjectiveSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	o := t.objective
+	s := ObjectiveSummary{Name: o.Name, Endpoint: o.Endpoint, Target: o.Target, Good: t.good, Bad: t.bad}
+	if o.Latency > 0 {
+		s.Latency = o.Latency.String()
+	}
+	allowed := 1 - o.Target
+	for _, window := range r.windows {
+		total, bad := t.windows.sum(now, window)
+		w := WindowSummary{Window: formatWindow(window), Total: total, Bad: bad}
+		if total > 0 {
+			w.ErrorRatio = float64(bad) / float64(total)
+			w.BurnRate = round(w.ErrorRatio / allowed)
+			w.ErrorRatio = round(w.ErrorRatio)
+		}
+		s.Windows = append(s.Windows, w)
+	}
+	total, bad := t.budget.sum(now, r.period)
+	s.Budget = BudgetSummary{Period: formatWindow(r.period), Total: total, Bad: bad, Remaining: 1}
+	if total > 0 {
+		s.Budget.Remaining = round(1 - float64(bad)/(allowed*float64(total)))
+	}
+	return s
+}
+
+// formatWindow возвращает длительность в виде "5m", "6h" или "720h".
+func formatWindow(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return strconv.FormatInt(int64(d/time.Hour), 10) + "h"
+	case d%time.Minute == 0:
+		return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+	}
+	return d.String()
+}
+
+// round округляет значение показателя до 6 знаков после запятой. Отрицательный ноль
+// заменяется нулем, чтобы исчерпанный бюджет не выводился как "-0".
+func round(v float64) float64 {
+	v = math.Round(v*1e6) / 1e6
+	if v == 0 {
+		return 0
+	}
+	return v
+}
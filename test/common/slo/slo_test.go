@@ -0,0 +1,186 @@
+package slo
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"common/buildinfo"
+	"common/clock"
+)
+
+// windowSummary возвращает состояние окна window цели name.
+func windowSummary(t *testing.T, s Summary, name, window string) WindowSummary {
+	t.Helper()
+	for _, o := range s.Objectives {
+		if o.Name != name {
+			continue
+		}
+		for _, w := range o.Windows {
+			if w.Window == window {
+				return w
+			}
+		}
+	}
+	t.Fatalf("window %s of objective %s not found", window, name)
+	return WindowSummary{}
+}
+
+// Тест скорости расходования бюджета на синтетической нагрузке: при доле ошибок 2% и цели
+// 99% бюджет расходуется вдвое быстрее допустимого, а остаток бюджета за период — -1
+func TestRegistry_BurnRate(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	r, err := New([]Objective{{Name: "list_calls", Endpoint: "GET /calls", Target: 0.99}}, 0, fake)
+	require.NoError(t, err)
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	// 20 запросов в секунду в течение часа, каждый 50-й — ошибка сервера
+	for second := 0; second < 3600; second++ {
+		for i := 0; i < 20; i++ {
+			r.ObserveRoute("GET", "/calls", time.Millisecond, rng.IntN(50) == 0)
+		}
+		fake.Advance(time.Second)
+	}
+	summary := r.Summary()
+	for _, window := range []string{"5m", "30m", "1h"} {
+		w := windowSummary(t, summary, "list_calls", window)
+		assert.InDelta(t, 0.02, w.ErrorRatio, 0.004, window)
+		assert.InDelta(t, 2.0, w.BurnRate, 0.4, window)
+	}
+	// В шестичасовом окне только час нагрузки, и доля ошибок та же
+	assert.InDelta(t, 2.0, windowSummary(t, summary, "list_calls", "6h").BurnRate, 0.2)
+	assert.InDelta(t, -1.0, summary.Objectives[0].Budget.Remaining, 0.2)
+
+	// Без нагрузки запросы выходят из коротких окон, а бюджет за период не восстанавливается
+	fake.Advance(10 * time.Minute)
+	summary = r.Summary()
+	assert.Zero(t, windowSummary(t, summary, "list_calls", "5m").Total)
+	assert.Zero(t, windowSummary(t, summary, "list_calls", "5m").BurnRate)
+	assert.NotZero(t, windowSummary(t, summary, "list_calls", "1h").Total)
+	assert.InDelta(t, -1.0, summary.Objectives[0].Budget.Remaining, 0.2)
+}
+
+// Тест цели по времени ответа: медленный запрос без ошибки расходует бюджет только цели
+// с ограничением времени, а запросы к другим маршрутам и методам не учитываются
+func TestRegistry_Latency(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	r, err := New([]Objective{
+		{Name: "validate_fast", Endpoint: "ValidateToken", Target: 0.995, Latency: 50 * time.Millisecond},
+		{Name: "validate_ok", Endpoint: "ValidateToken", Target: 0.999},
+	}, time.Hour, fake)
+	require.NoError(t, err)
+
+	for i := 0; i < 90; i++ {
+		r.ObserveMethod("/auth.AuthService/ValidateToken", 10*time.Millisecond, false)
+	}
+	for i := 0; i < 10; i++ {
+		r.ObserveMethod("/auth.AuthService/ValidateToken", 80*time.Millisecond, false)
+	}
+	r.ObserveMethod("/auth.AuthService/Login", time.Second, true)
+	r.ObserveRoute("GET", "/calls", time.Second, true)
+
+	summary := r.Summary()
+	fast := windowSummary(t, summary, "validate_fast", "5m")
+	assert.Equal(t, WindowSummary{Window: "5m", Total: 100, Bad: 10, ErrorRatio: 0.1, BurnRate: 20}, fast)
+	assert.Zero(t, windowSummary(t, summary, "validate_ok", "5m").Bad)
+	assert.Equal(t, BudgetSummary{Period: "1h", Total: 100, Bad: 0, Remaining: 1}, summary.Objectives[1].Budget)
+}
+
+// Тест перехватчика gRPC: бюджет расходуют только ошибки сервера
+func TestRegistry_UnaryServerInterceptor(t *testing.T) {
+	r, err := New([]Objective{{Name: "get_call", Endpoint: "GetCall", Target: 0.9}}, 0, nil)
+	require.NoError(t, err)
+	interceptor := r.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/call.CallService/GetCall"}
+
+	for _, code := range []codes.Code{codes.OK, codes.NotFound, codes.PermissionDenied, codes.Unavailable, codes.Internal} {
+		_, _ = interceptor(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+			return nil, status.Error(code, "")
+		})
+	}
+	w := windowSummary(t, r.Summary(), "get_call", "5m")
+	assert.Equal(t, int64(5), w.Total)
+	assert.Equal(t, int64(2), w.Bad)
+}
+
+// Тест учета запроса без выделения памяти
+func TestRegistry_ObserveAllocs(t *testing.T) {
+	r, err := New([]Objective{
+		{Name: "get_call", Endpoint: "GET /calls/:id", Target: 0.999},
+		{Name: "validate", Endpoint: "ValidateToken", Target: 0.999},
+	}, 0, nil)
+	require.NoError(t, err)
+	allocs := testing.AllocsPerRun(1000, func() {
+		r.ObserveRoute("GET", "/calls/:id", time.Millisecond, false)
+		r.ObserveRoute("POST", "/calls", time.Millisecond, false)
+		r.ObserveMethod("/auth.AuthService/ValidateToken", time.Millisecond, true)
+	})
+	assert.Zero(t, allocs)
+}
+
+// Тест показателей в формате Prometheus
+func TestRegistry_WriteMetrics(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	r, err := New([]Objective{{Name: "list_calls", Endpoint: "GET /calls", Target: 0.99}}, 0, fake)
+	require.NoError(t, err)
+	for i := 0; i < 99; i++ {
+		r.ObserveRoute("GET", "/calls", time.Millisecond, false)
+	}
+	r.ObserveRoute("GET", "/calls", time.Millisecond, true)
+
+	var out strings.Builder
+	require.NoError(t, r.WriteMetrics(&out))
+	metrics := out.String()
+	for _, line := range []string{
+		"# TYPE slo_burn_rate gauge",
+		`slo_objective_target{objective="list_calls",endpoint="GET /calls",version="dev",commit="dev"} 0.99`,
+		`slo_requests_total{objective="list_calls",endpoint="GET /calls",result="good",version="dev",commit="dev"} 99`,
+		`slo_requests_total{objective="list_calls",endpoint="GET /calls",result="bad",version="dev",commit="dev"} 1`,
+		`slo_burn_rate{objective="list_calls",endpoint="GET /calls",window="5m",version="dev",commit="dev"} 1`,
+		`slo_burn_rate{objective="list_calls",endpoint="GET /calls",window="6h",version="dev",commit="dev"} 1`,
+		`slo_error_budget_remaining{objective="list_calls",endpoint="GET /calls",period="720h",version="dev",commit="dev"} 0`,
+	} {
+		assert.Contains(t, metrics, line+"\n")
+	}
+
+	// Метки сборки берутся из сведений о сборке
+	version, commit := buildinfo.Version, buildinfo.Commit
+	t.Cleanup(func() { buildinfo.Version, buildinfo.Commit = version, commit })
+	buildinfo.Version, buildinfo.Commit = "1.4.0", "0a1b2c3"
+	out.Reset()
+	require.NoError(t, r.WriteMetrics(&out))
+	assert.Contains(t, out.String(), `slo_objective_target{objective="list_calls",endpoint="GET /calls",version="1.4.0",commit="0a1b2c3"} 0.99`+"\n")
+}
+
+// Тест разбора целей из конфигурации
+func TestParseObjectives(t *testing.T) {
+	objectives, err := ParseObjectives("name=validate_token,endpoint=ValidateToken,target=99.5,latency=50ms; name=list_calls, endpoint=GET  /calls, target=99.9;")
+	require.NoError(t, err)
+	assert.Equal(t, []Objective{
+		{Name: "validate_token", Endpoint: "ValidateToken", Target: 0.995, Latency: 50 * time.Millisecond},
+		{Name: "list_calls", Endpoint: "GET /calls", Target: 0.999},
+	}, objectives)
+
+	objectives, err = ParseObjectives("")
+	require.NoError(t, err)
+	assert.Empty(t, objectives)
+
+	for _, spec := range []string{
+		"name=a,endpoint=GET /calls",
+		"name=a,endpoint=GET /calls,target=100",
+		"name=a,endpoint=GET /calls,target=99,latency=fast",
+		"name=a,endpoint=GET /calls,target=99,owner=sre",
+		"name=a,endpoint=GET /calls,target=99;name=a,endpoint=GET /calls/:id,target=99",
+	} {
+		_, err := ParseObjectives(spec)
+		assert.Error(t, err, spec)
+	}
+}